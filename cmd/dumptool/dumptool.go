@@ -0,0 +1,127 @@
+// Copyright 2021 FerretDB Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package main contains a tool for exporting FerretDB databases to mongodump archives.
+//
+// Documents are read directly from the backend, so neither FerretDB nor MongoDB should be running.
+// Archives could be restored by mongorestore --archive to FerretDB or MongoDB.
+// The same could be done with the dumpArchive command.
+package main
+
+import (
+	"bufio"
+	"context"
+	"flag"
+	"fmt"
+	"os"
+	"os/signal"
+	"strings"
+
+	"go.uber.org/zap"
+
+	"github.com/FerretDB/FerretDB/internal/handlers/backend"
+	"github.com/FerretDB/FerretDB/internal/handlers/common"
+	"github.com/FerretDB/FerretDB/internal/handlers/pg/pgdb"
+	"github.com/FerretDB/FerretDB/internal/handlers/sqlite/sqlitedb"
+	"github.com/FerretDB/FerretDB/internal/util/logging"
+)
+
+func main() {
+	debugF := flag.Bool("debug", false, "enable debug mode")
+	handlerF := flag.String("handler", "pg", "backend handler: pg, sqlite")
+	postgreSQLURLF := flag.String("postgresql-url", "postgres://postgres@127.0.0.1:5432/ferretdb", "PostgreSQL URL")
+	sqliteURLF := flag.String("sqlite-url", "", "SQLite database file path or `file:` URI")
+	dbF := flag.String("db", "", "database to export")
+	collectionsF := flag.String("collections", "", "comma-separated collections to export; all database collections if empty")
+	archiveF := flag.String("archive", "-", "archive file path; standard output if -")
+	flag.Parse()
+
+	if flag.NArg() != 0 {
+		flag.Usage()
+		fmt.Fprintln(flag.CommandLine.Output(), "zero arguments expected")
+		os.Exit(2)
+	}
+
+	logging.Setup(zap.InfoLevel, logging.FormatText)
+	if *debugF {
+		logging.Setup(zap.DebugLevel, logging.FormatText)
+	}
+	logger := zap.S()
+
+	if *dbF == "" {
+		logger.Fatal("-db flag must be specified.")
+	}
+
+	var collections []string
+	if *collectionsF != "" {
+		collections = strings.Split(*collectionsF, ",")
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+
+	var b backend.Backend
+
+	switch *handlerF {
+	case "pg":
+		pgPool, err := pgdb.NewPool(ctx, *postgreSQLURLF, logger.Desugar(), nil)
+		if err != nil {
+			logger.Fatal(err)
+		}
+		defer pgPool.Close()
+
+		b = pgPool
+
+	case "sqlite":
+		if *sqliteURLF == "" {
+			logger.Fatal("-sqlite-url flag must be specified.")
+		}
+
+		db, err := sqlitedb.Open(ctx, *sqliteURLF, logger.Desugar())
+		if err != nil {
+			logger.Fatal(err)
+		}
+		defer db.Close()
+
+		b = db
+
+	default:
+		logger.Fatalf("Unknown handler %q.", *handlerF)
+	}
+
+	out := os.Stdout
+	if *archiveF != "-" {
+		var err error
+		if out, err = os.OpenFile(*archiveF, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0o666); err != nil {
+			logger.Fatal(err)
+		}
+	}
+
+	w := bufio.NewWriter(out)
+
+	stats, err := common.DumpArchive(ctx, b, w, *dbF, collections)
+	if err == nil {
+		err = w.Flush()
+	}
+
+	if err == nil && out != os.Stdout {
+		err = out.Close()
+	}
+
+	if err != nil {
+		logger.Fatal(err)
+	}
+
+	logger.Infof("%s: %d collections, %d documents exported.", *dbF, stats.Collections, stats.Documents)
+}
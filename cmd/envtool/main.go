@@ -82,7 +82,7 @@ func waitForPostgresPort(ctx context.Context, logger *zap.SugaredLogger, port ui
 
 	for ctx.Err() == nil {
 		var pgPool *pgdb.Pool
-		pgPool, err := pgdb.NewPool(ctx, fmt.Sprintf("postgres://postgres@127.0.0.1:%d/ferretdb", port), logger.Desugar(), false)
+		pgPool, err := pgdb.NewPool(ctx, fmt.Sprintf("postgres://postgres@127.0.0.1:%d/ferretdb", port), logger.Desugar(), nil)
 		if err == nil {
 			pgPool.Close()
 			return nil
@@ -165,7 +165,7 @@ func setupPostgres(ctx context.Context, logger *zap.SugaredLogger) error {
 		return err
 	}
 
-	pgPool, err := pgdb.NewPool(ctx, "postgres://postgres@127.0.0.1:5432/ferretdb", logger.Desugar(), false)
+	pgPool, err := pgdb.NewPool(ctx, "postgres://postgres@127.0.0.1:5432/ferretdb", logger.Desugar(), nil)
 	if err != nil {
 		return err
 	}
@@ -254,7 +254,7 @@ func main() {
 	if *debugF {
 		level = zap.DebugLevel
 	}
-	logging.Setup(level)
+	logging.Setup(level, logging.FormatText)
 	logger := zap.S()
 
 	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
@@ -0,0 +1,314 @@
+// Copyright 2021 FerretDB Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+
+	"github.com/FerretDB/FerretDB/internal/util/must"
+)
+
+// envPrefix is the prefix of environment variables that set flags, like FERRETDB_LISTEN_ADDR for -listen-addr.
+const envPrefix = "FERRETDB_"
+
+// envExcludedFlags are flags that can't be set by environment variables.
+var envExcludedFlags = map[string]struct{}{
+	"version": {},
+}
+
+// fileExcludedFlags are flags that can't be set in the configuration file.
+var fileExcludedFlags = map[string]struct{}{
+	"config":       {},
+	"config-check": {},
+	"version":      {},
+}
+
+// reloadableFlags are flags that are re-read from the configuration file by reloadConfig.
+var reloadableFlags = []string{
+	"limit-conn-ops",
+	"limit-ip-ops",
+	"limit-ip-max-in-flight",
+	"limit-max-conns",
+	"limit-ip-max-conns",
+	"log-level",
+	"log-slow-ms",
+	"log-slow-sample-rate",
+}
+
+// listSeparators contains separators of list values in the configuration file for flags
+// that use something other than a comma.
+var listSeparators = map[string]string{
+	"ldap-bind-dn-template":   ";",
+	"postgresql-replica-urls": ";",
+}
+
+// envName returns the name of the environment variable for the given flag.
+func envName(flagName string) string {
+	return envPrefix + strings.ToUpper(strings.ReplaceAll(flagName, "-", "_"))
+}
+
+// addEnvUsage adds environment variable names to flags' usage.
+func addEnvUsage(fs *flag.FlagSet) {
+	fs.VisitAll(func(f *flag.Flag) {
+		if _, ok := envExcludedFlags[f.Name]; ok {
+			return
+		}
+
+		f.Usage += " [$" + envName(f.Name) + "]"
+	})
+}
+
+// setFlags returns names of flags that were set.
+func setFlags(fs *flag.FlagSet) map[string]struct{} {
+	res := map[string]struct{}{}
+	fs.Visit(func(f *flag.Flag) {
+		res[f.Name] = struct{}{}
+	})
+
+	return res
+}
+
+// applyConfig sets flags that were not set on the command line
+// from environment variables (in os.Environ format) and then from the YAML configuration file set by -config.
+//
+// Precedence is: command-line flags, environment variables, configuration file, default values.
+//
+// Configuration file keys are flag names; nested keys are joined with "-",
+// so `postgresql: {pool: {max-conns: 10}}` sets -postgresql-pool-max-conns; "_" may be used instead of "-".
+// Lists are joined with commas (or semicolons for flags that use them), other values are converted to strings.
+//
+// It returns names of flags set on the command line or by environment variables; see reloadConfig.
+func applyConfig(fs *flag.FlagSet, environ []string) (map[string]struct{}, error) {
+	if err := applyEnv(fs, environ); err != nil {
+		return nil, err
+	}
+
+	set := setFlags(fs)
+
+	path := fs.Lookup("config").Value.String()
+	if path == "" {
+		return set, nil
+	}
+
+	file, err := loadConfigFile(fs, path)
+	if err != nil {
+		return nil, err
+	}
+
+	names := make([]string, 0, len(file))
+	for name := range file {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		if _, ok := set[name]; ok {
+			continue
+		}
+
+		v := file[name]
+		if err = fs.Set(name, v); err != nil {
+			return nil, fmt.Errorf("invalid value %q for %q in configuration file %s: %w", v, name, path, err)
+		}
+	}
+
+	return set, nil
+}
+
+// reloadConfig re-reads the configuration file set by -config and updates reloadable flags,
+// except fixed flags set on the command line or by environment variables.
+// Flags removed from the file are reset to default values.
+//
+// If the file or some value is invalid, flags are not changed.
+func reloadConfig(fs *flag.FlagSet, fixed map[string]struct{}) error {
+	path := fs.Lookup("config").Value.String()
+	if path == "" {
+		return nil
+	}
+
+	file, err := loadConfigFile(fs, path)
+	if err != nil {
+		return err
+	}
+
+	prev := map[string]string{}
+
+	for _, name := range reloadableFlags {
+		f := fs.Lookup(name)
+		if f == nil {
+			continue
+		}
+
+		if _, ok := fixed[name]; ok {
+			continue
+		}
+
+		v, ok := file[name]
+		if !ok {
+			v = f.DefValue
+		}
+
+		prev[name] = f.Value.String()
+
+		if err = f.Value.Set(v); err != nil {
+			// previous values were valid
+			for n, pv := range prev {
+				must.NoError(fs.Lookup(n).Value.Set(pv))
+			}
+
+			return fmt.Errorf("invalid value %q for %q in configuration file %s: %w", v, name, path, err)
+		}
+	}
+
+	return nil
+}
+
+// applyEnv sets flags that were not set on the command line from FERRETDB_* environment variables.
+//
+// Unknown FERRETDB_* variables are errors, so typos are not silently ignored.
+func applyEnv(fs *flag.FlagSet, environ []string) error {
+	names := map[string]string{}
+	fs.VisitAll(func(f *flag.Flag) {
+		if _, ok := envExcludedFlags[f.Name]; ok {
+			return
+		}
+
+		names[envName(f.Name)] = f.Name
+	})
+
+	set := setFlags(fs)
+
+	for _, kv := range environ {
+		k, v, _ := strings.Cut(kv, "=")
+		if !strings.HasPrefix(k, envPrefix) {
+			continue
+		}
+
+		name, ok := names[k]
+		if !ok {
+			return fmt.Errorf("unknown environment variable %s", k)
+		}
+
+		if _, ok = set[name]; ok {
+			continue
+		}
+
+		if err := fs.Set(name, v); err != nil {
+			return fmt.Errorf("invalid value %q for environment variable %s: %w", v, k, err)
+		}
+	}
+
+	return nil
+}
+
+// loadConfigFile reads the YAML configuration file and returns flag values from it.
+func loadConfigFile(fs *flag.FlagSet, path string) (map[string]string, error) {
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var doc map[string]any
+	if err = yaml.Unmarshal(b, &doc); err != nil {
+		return nil, fmt.Errorf("failed to parse configuration file %s: %w", path, err)
+	}
+
+	res := map[string]string{}
+	if err = flattenConfig(fs, "", doc, res); err != nil {
+		return nil, fmt.Errorf("invalid configuration file %s: %w", path, err)
+	}
+
+	return res, nil
+}
+
+// flattenConfig converts nested configuration values to flag values.
+func flattenConfig(fs *flag.FlagSet, prefix string, m map[string]any, res map[string]string) error {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	for _, k := range keys {
+		name := prefix + strings.ReplaceAll(k, "_", "-")
+		v := m[k]
+
+		_, excluded := fileExcludedFlags[name]
+		if f := fs.Lookup(name); f != nil && !excluded {
+			s, err := configValue(name, v)
+			if err != nil {
+				return err
+			}
+
+			res[name] = s
+			continue
+		}
+
+		nested, ok := v.(map[string]any)
+		if !ok {
+			return fmt.Errorf("unknown option %q", name)
+		}
+
+		if err := flattenConfig(fs, name+"-", nested, res); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// configValue converts a configuration file value to the flag value.
+func configValue(name string, v any) (string, error) {
+	switch v := v.(type) {
+	case nil:
+		return "", nil
+
+	case []any:
+		sep := listSeparators[name]
+		if sep == "" {
+			sep = ","
+		}
+
+		parts := make([]string, len(v))
+		for i, e := range v {
+			s, err := configValue(name, e)
+			if err != nil {
+				return "", err
+			}
+			parts[i] = s
+		}
+
+		return strings.Join(parts, sep), nil
+
+	case map[string]any:
+		// for options with JSON values, like -ldap-group-roles
+		b, err := json.Marshal(v)
+		if err != nil {
+			return "", fmt.Errorf("invalid value for %q: %w", name, err)
+		}
+
+		return string(b), nil
+
+	default:
+		return fmt.Sprint(v), nil
+	}
+}
@@ -0,0 +1,228 @@
+// Copyright 2021 FerretDB Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"flag"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// testFlagSet returns a new flag set with a few flags similar to real ones.
+func testFlagSet() *flag.FlagSet {
+	fs := flag.NewFlagSet("test", flag.ContinueOnError)
+	fs.String("config", "", "")
+	fs.Bool("version", false, "")
+	fs.String("listen-addr", "127.0.0.1:27017", "")
+	fs.String("listen-allow", "", "")
+	fs.Bool("tls-require-client-cert", false, "")
+	fs.Int("postgresql-pool-max-conns", 0, "")
+	fs.Duration("postgresql-pool-max-conn-lifetime", 0, "")
+	fs.String("postgresql-replica-urls", "", "")
+	fs.String("ldap-group-roles", "", "")
+	fs.String("log-level", "debug", "")
+
+	return fs
+}
+
+// writeConfig writes the configuration file and returns its path.
+func writeConfig(t *testing.T, content string) string {
+	t.Helper()
+
+	path := filepath.Join(t.TempDir(), "ferretdb.yml")
+	require.NoError(t, os.WriteFile(path, []byte(content), 0o600))
+
+	return path
+}
+
+func TestAddEnvUsage(t *testing.T) {
+	t.Parallel()
+
+	fs := testFlagSet()
+	addEnvUsage(fs)
+
+	assert.Equal(t, " [$FERRETDB_POSTGRESQL_POOL_MAX_CONNS]", fs.Lookup("postgresql-pool-max-conns").Usage)
+	assert.Equal(t, "", fs.Lookup("version").Usage)
+}
+
+func TestApplyConfig(t *testing.T) {
+	t.Parallel()
+
+	path := writeConfig(t, `
+listen:
+  addr: 0.0.0.0:27017
+  allow: [10.0.0.0/8, 127.0.0.1]
+tls:
+  require-client-cert: true
+postgresql:
+  pool:
+    max_conns: 10
+    max-conn-lifetime: 1h
+  replica-urls:
+    - postgres://replica1/ferretdb
+    - postgres://replica2/ferretdb
+ldap-group-roles:
+  cn=admins: [root]
+log-level: warn
+`)
+
+	t.Run("File", func(t *testing.T) {
+		t.Parallel()
+
+		fs := testFlagSet()
+		require.NoError(t, fs.Parse([]string{"-config", path}))
+		_, err := applyConfig(fs, []string{"HOME=/root"})
+		require.NoError(t, err)
+
+		expected := map[string]string{
+			"listen-addr":                       "0.0.0.0:27017",
+			"listen-allow":                      "10.0.0.0/8,127.0.0.1",
+			"tls-require-client-cert":           "true",
+			"postgresql-pool-max-conns":         "10",
+			"postgresql-pool-max-conn-lifetime": time.Hour.String(),
+			"postgresql-replica-urls":           "postgres://replica1/ferretdb;postgres://replica2/ferretdb",
+			"ldap-group-roles":                  `{"cn=admins":["root"]}`,
+			"log-level":                         "warn",
+		}
+		for name, v := range expected {
+			assert.Equal(t, v, fs.Lookup(name).Value.String(), name)
+		}
+	})
+
+	t.Run("Precedence", func(t *testing.T) {
+		t.Parallel()
+
+		environ := []string{
+			"FERRETDB_CONFIG=" + path,
+			"FERRETDB_LISTEN_ADDR=127.0.0.1:27018",
+			"FERRETDB_LOG_LEVEL=error",
+		}
+
+		fs := testFlagSet()
+		require.NoError(t, fs.Parse([]string{"-log-level=info"}))
+		fixed, err := applyConfig(fs, environ)
+		require.NoError(t, err)
+
+		expectedFixed := map[string]struct{}{
+			"config":      {},
+			"listen-addr": {},
+			"log-level":   {},
+		}
+		assert.Equal(t, expectedFixed, fixed)
+
+		assert.Equal(t, "info", fs.Lookup("log-level").Value.String())
+		assert.Equal(t, "127.0.0.1:27018", fs.Lookup("listen-addr").Value.String())
+		assert.Equal(t, "10", fs.Lookup("postgresql-pool-max-conns").Value.String())
+	})
+
+	t.Run("Errors", func(t *testing.T) {
+		t.Parallel()
+
+		for name, tc := range map[string]struct {
+			config  string
+			environ []string
+			err     string
+		}{
+			"UnknownOption": {
+				config: "listen:\n  address: 127.0.0.1:27017\n",
+				err:    `unknown option "listen-address"`,
+			},
+			"InvalidValue": {
+				config: "postgresql-pool-max-conns: many\n",
+				err:    `invalid value "many" for "postgresql-pool-max-conns"`,
+			},
+			"InvalidEnv": {
+				environ: []string{"FERRETDB_TLS_REQUIRE_CLIENT_CERT=maybe"},
+				err:     "environment variable FERRETDB_TLS_REQUIRE_CLIENT_CERT",
+			},
+			"UnknownEnv": {
+				environ: []string{"FERRETDB_LISTEN_ADR=127.0.0.1:27017"},
+				err:     "unknown environment variable FERRETDB_LISTEN_ADR",
+			},
+			"VersionEnv": {
+				environ: []string{"FERRETDB_VERSION=true"},
+				err:     "unknown environment variable FERRETDB_VERSION",
+			},
+			"Config": {
+				config: "config: other.yml\n",
+				err:    `unknown option "config"`,
+			},
+		} {
+			name, tc := name, tc
+			t.Run(name, func(t *testing.T) {
+				t.Parallel()
+
+				var args []string
+				if tc.config != "" {
+					args = []string{"-config", writeConfig(t, tc.config)}
+				}
+
+				fs := testFlagSet()
+				require.NoError(t, fs.Parse(args))
+				_, err := applyConfig(fs, tc.environ)
+				require.Error(t, err)
+				assert.Contains(t, err.Error(), tc.err)
+			})
+		}
+	})
+}
+
+func TestReloadConfig(t *testing.T) {
+	t.Parallel()
+
+	path := writeConfig(t, "log-level: warn\nlisten-addr: 0.0.0.0:27017\npostgresql-pool-max-conns: 10\n")
+
+	fs := testFlagSet()
+	fs.Int("limit-max-conns", 0, "")
+	fs.Int("log-slow-ms", 100, "")
+	fs.Float64("log-slow-sample-rate", 1, "")
+	require.NoError(t, fs.Parse([]string{"-config", path, "-log-slow-sample-rate=0.5"}))
+
+	fixed, err := applyConfig(fs, nil)
+	require.NoError(t, err)
+
+	require.NoError(t, os.WriteFile(path, []byte(
+		"log-level: error\nlisten-addr: 0.0.0.0:27018\nlimit-max-conns: 100\nlog-slow-sample-rate: 0.1\n",
+	), 0o600))
+	require.NoError(t, reloadConfig(fs, fixed))
+
+	assert.Equal(t, "error", fs.Lookup("log-level").Value.String())
+	assert.Equal(t, "100", fs.Lookup("limit-max-conns").Value.String())
+
+	// fixed flag
+	assert.Equal(t, "0.5", fs.Lookup("log-slow-sample-rate").Value.String())
+
+	// not reloadable flags
+	assert.Equal(t, "0.0.0.0:27017", fs.Lookup("listen-addr").Value.String())
+	assert.Equal(t, "10", fs.Lookup("postgresql-pool-max-conns").Value.String())
+
+	// invalid value does not change anything
+	require.NoError(t, os.WriteFile(path, []byte("limit-max-conns: 5\nlog-level: info\nlog-slow-ms: slow\n"), 0o600))
+	require.Error(t, reloadConfig(fs, fixed))
+	assert.Equal(t, "error", fs.Lookup("log-level").Value.String())
+	assert.Equal(t, "100", fs.Lookup("limit-max-conns").Value.String())
+	assert.Equal(t, "100", fs.Lookup("log-slow-ms").Value.String())
+
+	// removed option is reset to default
+	require.NoError(t, os.WriteFile(path, []byte("log-level: info\n"), 0o600))
+	require.NoError(t, reloadConfig(fs, fixed))
+	assert.Equal(t, "info", fs.Lookup("log-level").Value.String())
+	assert.Equal(t, "0", fs.Lookup("limit-max-conns").Value.String())
+}
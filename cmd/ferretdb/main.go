@@ -16,40 +16,283 @@ package main
 
 import (
 	"context"
+	"crypto/tls"
+	"encoding/json"
 	"flag"
 	"fmt"
 	"log"
 	"os"
 	"strings"
+	"time"
 
 	"github.com/prometheus/client_golang/prometheus"
 	"github.com/prometheus/common/expfmt"
 	"go.uber.org/zap"
 	"go.uber.org/zap/zapcore"
 
+	"github.com/FerretDB/FerretDB/ferretdb/middleware"
 	"github.com/FerretDB/FerretDB/internal/clientconn"
+	"github.com/FerretDB/FerretDB/internal/handlers/common"
+	"github.com/FerretDB/FerretDB/internal/handlers/pg"
+	"github.com/FerretDB/FerretDB/internal/handlers/pg/pgdb"
 	"github.com/FerretDB/FerretDB/internal/handlers/registry"
+	"github.com/FerretDB/FerretDB/internal/types"
+	"github.com/FerretDB/FerretDB/internal/util/audit"
 	"github.com/FerretDB/FerretDB/internal/util/debug"
+	"github.com/FerretDB/FerretDB/internal/util/fault"
+	"github.com/FerretDB/FerretDB/internal/util/ftdc"
+	"github.com/FerretDB/FerretDB/internal/util/ldapauth"
 	"github.com/FerretDB/FerretDB/internal/util/logging"
 	"github.com/FerretDB/FerretDB/internal/util/must"
+	"github.com/FerretDB/FerretDB/internal/util/sdnotify"
+	"github.com/FerretDB/FerretDB/internal/util/state"
+	"github.com/FerretDB/FerretDB/internal/util/tracing"
 	"github.com/FerretDB/FerretDB/internal/util/version"
+	"github.com/FerretDB/FerretDB/internal/wire"
 )
 
 var (
 	versionF = flag.Bool("version", false, "print version to stdout (full version, commit, branch, dirty flag) and exit")
 
-	listenAddrF = flag.String("listen-addr", "127.0.0.1:27017", "listen address")
+	configF = flag.String(
+		"config", "",
+		"YAML configuration file; flags take precedence over "+envPrefix+"* environment variables, which take precedence over it",
+	)
+	configCheckF = flag.Bool("config-check", false, "validate configuration and exit")
+
+	listenAddrF = flag.String("listen-addr", "127.0.0.1:27017", "listen address; disabled if empty")
+	listenTLSF  = flag.String("listen-tls", "", "TLS listen address; if set, TLS flags apply only to it and not to listen-addr")
+	listenUnixF = flag.String("listen-unix", "", "listen Unix domain socket path; disabled if empty")
 	proxyAddrF  = flag.String("proxy-addr", "127.0.0.1:37017", "proxy address")
 	debugAddrF  = flag.String("debug-addr", "127.0.0.1:8088", "debug address")
 	modeF       = flag.String("mode", string(clientconn.AllModes[0]), fmt.Sprintf("operation mode: %v", clientconn.AllModes))
 
+	tlsCertFileF          = flag.String("tls-cert-file", "", "TLS certificate file(s), comma-separated for SNI-based selection")
+	tlsKeyFileF           = flag.String("tls-key-file", "", "TLS key file(s), in the same order as certificate files")
+	tlsCAFileF            = flag.String("tls-ca-file", "", "TLS CA file for client certificates verification")
+	tlsRequireClientCertF = flag.Bool("tls-require-client-cert", false, "require valid TLS client certificates")
+
+	listenAllowF = flag.String("listen-allow", "", "allowed client IP addresses and CIDRs, comma-separated; all if empty")
+	listenDenyF  = flag.String("listen-deny", "", "denied client IP addresses and CIDRs, comma-separated; take precedence")
+
+	listenProxyProtocolF = flag.Bool(
+		"listen-proxy-protocol", false,
+		"require PROXY protocol v1/v2 header on all client connections; enable only behind trusted proxies",
+	)
+
+	limitConnOpsF       = flag.Float64("limit-conn-ops", 0, "maximum operations per second per connection; 0 - no limit")
+	limitIPOpsF         = flag.Float64("limit-ip-ops", 0, "maximum operations per second per client IP; 0 - no limit")
+	limitIPMaxInFlightF = flag.Int("limit-ip-max-in-flight", 0, "maximum concurrent operations per client IP; 0 - no limit")
+	limitMaxConnsF      = flag.Int("limit-max-conns", 0, "maximum open client connections; 0 - no limit")
+	limitIPMaxConnsF    = flag.Int("limit-ip-max-conns", 0, "maximum open connections per client IP; 0 - no limit")
+
+	connIdleTimeoutF = flag.Duration(
+		"conn-idle-timeout", 0,
+		"close client connections without requests for that duration; 0 - never",
+	)
+	connKeepAliveF = flag.Duration(
+		"conn-keepalive", 0,
+		"TCP keep-alive period of client connections; 0 - Go's default (15s), negative - disabled",
+	)
+	shutdownDrainTimeoutF = flag.Duration(
+		"shutdown-drain-timeout", clientconn.DefaultDrainTimeout,
+		"maximal duration for in-flight operations to finish on shutdown before connections are closed",
+	)
+
+	auditDestinationF = flag.String(
+		"audit-destination", "",
+		fmt.Sprintf("audit log destination: %v; disabled if empty", audit.AllDestinations),
+	)
+	auditPathF       = flag.String("audit-path", "", "audit log file path for file destination")
+	auditATypesF     = flag.String("audit-filter-atypes", "", "audited event types, comma-separated; all if empty")
+	auditFailedOnlyF = flag.Bool("audit-filter-failed-only", false, "audit only failed operations")
+
+	compressorsF = flag.String(
+		"compressors", strings.Join(wire.AllCompressors, ","),
+		fmt.Sprintf("wire protocol compressors, comma-separated: %v; disabled if empty", wire.AllCompressors),
+	)
+	compressionThresholdF = flag.Int(
+		"compression-threshold", clientconn.DefaultCompressionThreshold,
+		"minimal response size in bytes to be compressed",
+	)
+
+	otelTracesURLF = flag.String(
+		"otel-traces-url", "",
+		"OpenTelemetry OTLP/HTTP traces endpoint like http://127.0.0.1:4318/v1/traces; tracing is disabled if empty",
+	)
+
+	stateDirF = flag.String(
+		"state-dir", "",
+		"directory for instance state persisted across restarts, like instance UUID and cluster time key; "+
+			"not persisted if empty",
+	)
+
+	diagnosticDataDirF = flag.String(
+		"diagnostic-data-dir", "",
+		"directory for FTDC diagnostic data files compatible with MongoDB's diagnostic.data; disabled if empty",
+	)
+	diagnosticDataPeriodF = flag.Duration("diagnostic-data-period", ftdc.DefaultPeriod, "diagnostic data collection period")
+
+	archiveDirF = flag.String(
+		"archive-dir", "",
+		"directory for mongodump-compatible archives written by dumpArchive command; disabled if empty",
+	)
+
+	middlewaresF = flag.String("middleware", "", "<set in initFlags()>")
+
+	captureFileF = flag.String("capture-file", "", "record all client requests to that file for replaying with replaytool")
+
+	maxBSONObjectSizeF = flag.Int(
+		"max-bson-object-size", int(wire.DefaultLimits.MaxBSONObjectSize),
+		"maximum BSON document size in bytes",
+	)
+	maxMessageSizeF = flag.Int(
+		"max-message-size", int(wire.DefaultLimits.MaxMessageSizeBytes),
+		"maximum wire protocol message size in bytes",
+	)
+	maxWriteBatchSizeF = flag.Int(
+		"max-write-batch-size", int(wire.DefaultLimits.MaxWriteBatchSize),
+		"maximum number of write operations in a single command",
+	)
+
 	handlerF = flag.String("handler", "<set in initFlags()>", "<set in initFlags()>")
 
-	postgreSQLURLF = flag.String("postgresql-url", "postgres://postgres@127.0.0.1:5432/ferretdb", "PostgreSQL URL")
+	postgreSQLURLF       = flag.String("postgresql-url", "postgres://postgres@127.0.0.1:5432/ferretdb", "PostgreSQL URL")
+	postgreSQLWatchModeF = flag.String(
+		"postgresql-watch-mode", string(pgdb.AllWatchModes[0]),
+		fmt.Sprintf(
+			"PostgreSQL change notification transport: %v; "+
+				"notify uses triggers and LISTEN/NOTIFY: events are delivered at most once, only to connected listeners, "+
+				"in commit order, with _id only; triggers for existing collections are created on start",
+			pgdb.AllWatchModes,
+		),
+	)
+	postgreSQLAuthModeF = flag.String(
+		"postgresql-auth-mode", string(pg.AllAuthModes[0]),
+		fmt.Sprintf("PostgreSQL handler authentication mode: %v", pg.AllAuthModes),
+	)
+	postgreSQLUUIDColumnF = flag.Bool(
+		"postgresql-uuid-column", false,
+		"PostgreSQL: store UUID _id values of new collections in an indexed native uuid column",
+	)
+	postgreSQLGINIndexF = flag.Bool(
+		"postgresql-gin-index", true,
+		"PostgreSQL: create GIN index on documents of new collections to speed up equality filters",
+	)
+	postgreSQLPgBouncerModeF = flag.Bool(
+		"postgresql-pgbouncer-mode", false,
+		"PostgreSQL: do not use session-level state to support PgBouncer in transaction pooling mode",
+	)
+	postgreSQLPoolMaxConnsF = flag.Int(
+		"postgresql-pool-max-conns", 0,
+		"PostgreSQL: maximum number of connections in a pool; overrides pool_max_conns from the URL if positive",
+	)
+	postgreSQLPoolMaxConnLifetimeF = flag.Duration(
+		"postgresql-pool-max-conn-lifetime", 0,
+		"PostgreSQL: maximum connection lifetime; overrides pool_max_conn_lifetime from the URL if positive",
+	)
+	postgreSQLPoolMaxConnIdleTimeF = flag.Duration(
+		"postgresql-pool-max-conn-idle-time", 0,
+		"PostgreSQL: maximum connection idle time; overrides pool_max_conn_idle_time from the URL if positive",
+	)
+	postgreSQLPoolHealthCheckPeriodF = flag.Duration(
+		"postgresql-pool-health-check-period", 0,
+		"PostgreSQL: pool health check period; overrides pool_health_check_period from the URL if positive",
+	)
+	postgreSQLPoolPerDatabaseF = flag.Bool(
+		"postgresql-pool-per-database", false,
+		"PostgreSQL: use a separate connection pool for queries to each database; other pool settings apply to each pool",
+	)
+	postgreSQLPoolPerDatabaseMaxF = flag.Int(
+		"postgresql-pool-per-database-max", 100,
+		"PostgreSQL: maximum number of open per-database pools; least recently used idle pools are closed; 0 disables limit",
+	)
+	postgreSQLTenantRolesF = flag.Bool(
+		"postgresql-tenant-roles", false,
+		"PostgreSQL: own each database by a dedicated role, and run queries of its users as that role; "+
+			"requires ferretdb authentication mode and CREATEROLE privilege",
+	)
+	postgreSQLMetadataCacheTTLF = flag.Duration(
+		"postgresql-metadata-cache-ttl", 30*time.Second,
+		"PostgreSQL: collections metadata cache TTL; 0 disables cache",
+	)
+	postgreSQLAnalyzeThresholdF = flag.Int64(
+		"postgresql-analyze-threshold", 10000,
+		"PostgreSQL: number of inserted or deleted rows after which a table is analyzed; 0 disables that",
+	)
+	postgreSQLAnalyzeIntervalF = flag.Duration(
+		"postgresql-analyze-interval", time.Minute,
+		"PostgreSQL: minimal interval between analyzes of the same table after bulk writes",
+	)
+	postgreSQLBulkImportIndexDelayF = flag.Duration(
+		"postgresql-bulk-import-index-delay", 5*time.Second,
+		"PostgreSQL: idle time after bulk import batches before deferred indexes are built; 0 disables deferring",
+	)
+	postgreSQLLargeDocumentThresholdF = flag.Int(
+		"postgresql-large-document-threshold", pgdb.DefaultLargeDocumentThreshold,
+		"PostgreSQL: size of documents in bytes above which they are stored in a separate column; 0 disables that",
+	)
+	postgreSQLHealthCheckIntervalF = flag.Duration(
+		"postgresql-health-check-interval", 5*time.Second,
+		"PostgreSQL: interval between health checks; 0 disables them and fast failing of requests",
+	)
+	postgreSQLHealthCheckFailuresF = flag.Int(
+		"postgresql-health-check-failures", 3,
+		"PostgreSQL: number of consecutive failed health checks after which requests fail fast until the next successful one",
+	)
+	postgreSQLReplicaURLsF = flag.String(
+		"postgresql-replica-urls", "",
+		"PostgreSQL read replica URLs, ';'-separated; reads with secondary read preference are routed to them",
+	)
+	postgreSQLSSLModeF     = flag.String("postgresql-sslmode", "", "PostgreSQL sslmode; overrides one from the URL")
+	postgreSQLSSLRootCertF = flag.String("postgresql-sslrootcert", "", "PostgreSQL server CA file; overrides one from the URL")
+	postgreSQLSSLCertF     = flag.String("postgresql-sslcert", "", "PostgreSQL client certificate; overrides one from the URL")
+	postgreSQLSSLKeyF      = flag.String("postgresql-sslkey", "", "PostgreSQL client key file; overrides one from the URL")
+
+	sqliteURLF = flag.String("sqlite-url", "file:ferretdb.sqlite", "SQLite database file path or URI")
+
+	mysqlURLF = flag.String("mysql-url", "root@tcp(127.0.0.1:3306)/ferretdb", "MySQL data source name")
+
+	ldapURLF               = flag.String("ldap-url", "", "LDAP server URL for ldap authentication mode")
+	ldapBindDNTemplateF    = flag.String("ldap-bind-dn-template", "", "LDAP bind DN templates with {username}, ';'-separated")
+	ldapGroupSearchBaseF   = flag.String("ldap-group-search-base", "", "LDAP base DN for user's groups search")
+	ldapGroupSearchFilterF = flag.String(
+		"ldap-group-search-filter", ldapauth.DefaultGroupSearchFilter,
+		"LDAP group search filter with {dn} and/or {username}",
+	)
+	ldapGroupRolesF = flag.String(
+		"ldap-group-roles", "",
+		fmt.Sprintf(`LDAP group DN to roles %v mapping as JSON: {"<group DN>": ["<role>"]}`, common.AllRoles),
+	)
+	ldapStartTLSF = flag.Bool("ldap-start-tls", false, "use StartTLS for ldap:// URL")
+
+	logLevelF  = flag.String("log-level", "<set in initFlags()>", "<set in initFlags()>")
+	logFormatF = flag.String("log-format", string(logging.AllFormats[0]), fmt.Sprintf("log format: %v", logging.AllFormats))
+	logRedactF = flag.String(
+		"log-redact", wire.AllRedactModes[0].String(),
+		fmt.Sprintf("redaction of document values in logged messages: %v", wire.AllRedactModes),
+	)
+	logSlowMSF = flag.Int(
+		"log-slow-ms", int(clientconn.DefaultSlowOpThreshold/time.Millisecond),
+		"log operations running at least that many milliseconds; 0 - all operations, negative - disabled",
+	)
+	logSlowSampleRateF = flag.Float64("log-slow-sample-rate", 1, "fraction of slow operations to log, from 0 to 1")
 
-	logLevelF = flag.String("log-level", "<set in initFlags()>", "<set in initFlags()>")
+	fieldNamesF = flag.String(
+		"field-names", common.AllFieldNamesModes[0].String(),
+		fmt.Sprintf("validation of dots and dollars in stored field names: %v", common.AllFieldNamesModes),
+	)
+	fcvF = flag.String(
+		"feature-compatibility-version", common.AllFCVs[0].String(),
+		fmt.Sprintf("feature compatibility version if it is not persisted by setFeatureCompatibilityVersion: %v", common.AllFCVs),
+	)
 
 	testConnTimeoutF = flag.Duration("test-conn-timeout", 0, "test: set connection timeout")
+	testFaultsF      = flag.String(
+		"test-faults", "",
+		"test: inject faults into client and PostgreSQL connections, "+
+			"like \"latency=10ms,latency-rate=0.1,drop-rate=0.01,error-rate=0.01,commands=insert;find,seed=42\"",
+	)
 )
 
 // tigrisURL is a Tigris URL. It is set in the main_tigris.go.
@@ -70,23 +313,82 @@ func initFlags() {
 		zapcore.ErrorLevel.String(),
 	}
 
+	f = flag.Lookup("middleware")
+	f.Usage = "enabled middlewares in order, comma-separated; only custom builds register them"
+	if names := middleware.Registered(); len(names) > 0 {
+		f.Usage = "enabled middlewares in order, comma-separated: " + strings.Join(names, ", ")
+	}
+
 	f = flag.Lookup("log-level")
 	f.Usage = "log level: " + strings.Join(levels, ", ")
 	f.DefValue = zapcore.DebugLevel.String()
 	must.NoError(f.Value.Set(f.DefValue))
+
+	addEnvUsage(flag.CommandLine)
 }
 
 func main() {
 	initFlags()
 	flag.Parse()
 
+	fixedFlags, err := applyConfig(flag.CommandLine, os.Environ())
+	if err != nil {
+		log.Fatal(err)
+	}
+
 	level, err := zapcore.ParseLevel(*logLevelF)
 	if err != nil {
 		log.Fatal(err)
 	}
-	logging.Setup(level)
+
+	var logFormat logging.Format
+	for _, f := range logging.AllFormats {
+		if *logFormatF == string(f) {
+			logFormat = f
+			break
+		}
+	}
+	if logFormat == "" {
+		log.Fatalf("Unknown log format %q.", *logFormatF)
+	}
+
+	logging.Setup(level, logFormat)
 	logger := zap.L()
 
+	redactMode, err := wire.ParseRedactMode(*logRedactF)
+	if err != nil {
+		logger.Fatal(err.Error())
+	}
+	wire.SetRedactMode(redactMode)
+
+	slowOps, err := slowOpsOpts()
+	if err != nil {
+		logger.Fatal(err.Error())
+	}
+
+	fieldNamesMode, err := common.ParseFieldNamesMode(*fieldNamesF)
+	if err != nil {
+		logger.Fatal(err.Error())
+	}
+	common.SetFieldNamesMode(fieldNamesMode)
+
+	fcv, err := common.ParseFCV(*fcvF)
+	if err != nil {
+		logger.Fatal(err.Error())
+	}
+	common.SetFCV(fcv)
+
+	common.SetArchiveDir(*archiveDirF)
+
+	sizeLimits := wire.Limits{
+		MaxBSONObjectSize:   int32(*maxBSONObjectSizeF),
+		MaxMessageSizeBytes: int32(*maxMessageSizeF),
+		MaxWriteBatchSize:   int32(*maxWriteBatchSizeF),
+	}
+	if err = wire.SetLimits(sizeLimits); err != nil {
+		logger.Fatal(err.Error())
+	}
+
 	info := version.Get()
 
 	if *versionF {
@@ -103,6 +405,9 @@ func main() {
 		zap.String("branch", info.Branch),
 		zap.Bool("dirty", info.Dirty),
 	}
+	if *configF != "" {
+		startFields = append(startFields, zap.String("config", *configF))
+	}
 	for _, k := range info.BuildEnvironment.Keys() {
 		v := must.NotFail(info.BuildEnvironment.Get(k))
 		startFields = append(startFields, zap.Any(k, v))
@@ -120,37 +425,347 @@ func main() {
 		logger.Sugar().Fatalf("Unknown mode %q.", *modeF)
 	}
 
+	var tlsReloadable *clientconn.ReloadableTLSConfig
+	var tlsConfig *tls.Config
+	if *tlsCertFileF != "" {
+		tlsReloadable, err = clientconn.NewReloadableTLSConfig(&clientconn.TLSOpts{
+			CertFiles:         strings.Split(*tlsCertFileF, ","),
+			KeyFiles:          strings.Split(*tlsKeyFileF, ","),
+			CAFile:            *tlsCAFileF,
+			RequireClientCert: *tlsRequireClientCertF,
+		})
+		if err != nil {
+			logger.Fatal(err.Error())
+		}
+
+		tlsConfig = tlsReloadable.Config()
+	}
+
+	var listeners []clientconn.ListenerConfig
+
+	if *listenAddrF != "" {
+		// TLS flags apply to listen-addr unless a separate TLS listen address is set
+		listener := clientconn.ListenerConfig{Addr: *listenAddrF}
+		if *listenTLSF == "" {
+			listener.TLS = tlsConfig
+		}
+
+		listeners = append(listeners, listener)
+	}
+
+	if *listenTLSF != "" {
+		if tlsConfig == nil {
+			logger.Fatal("TLS certificate file is required for TLS listen address")
+		}
+
+		listeners = append(listeners, clientconn.ListenerConfig{Addr: *listenTLSF, TLS: tlsConfig})
+	}
+
+	if *listenUnixF != "" {
+		listeners = append(listeners, clientconn.ListenerConfig{Network: "unix", Addr: *listenUnixF})
+	}
+
+	for i := range listeners {
+		listeners[i].ProxyProtocol = *listenProxyProtocolF
+	}
+
+	var ipFilter *clientconn.IPFilter
+	if *listenAllowF != "" || *listenDenyF != "" {
+		ipFilter, err = clientconn.NewIPFilter(strings.Split(*listenAllowF, ","), strings.Split(*listenDenyF, ","))
+		if err != nil {
+			logger.Fatal(err.Error())
+		}
+	}
+
+	var compressors []wire.CompressorID
+	if *compressorsF != "" {
+		for _, name := range strings.Split(*compressorsF, ",") {
+			var compressor wire.CompressorID
+			if compressor, err = wire.ParseCompressor(name); err != nil {
+				logger.Fatal(err.Error())
+			}
+
+			compressors = append(compressors, compressor)
+		}
+	}
+
+	var middlewares []middleware.Middleware
+	if *middlewaresF != "" {
+		if middlewares, err = middleware.Get(strings.Split(*middlewaresF, ",")...); err != nil {
+			logger.Fatal(err.Error())
+		}
+	}
+
+	if *configCheckF {
+		logger.Info("Configuration is valid.")
+		return
+	}
+
+	instance, err := state.Load(*stateDirF)
+	if err != nil {
+		logger.Fatal(err.Error())
+	}
+	logger.Info("Instance state loaded.", zap.String("uuid", instance.UUID), zap.String("dir", *stateDirF))
+
+	if prev := instance.Metadata["version"]; prev != "" && prev != info.Version {
+		logger.Info("FerretDB version changed since the last start.", zap.String("previous", prev))
+	}
+
+	err = instance.Update(func(s *state.State) {
+		s.Metadata = map[string]string{
+			"version": info.Version,
+			"handler": *handlerF,
+		}
+	})
+	if err != nil {
+		logger.Fatal(err.Error())
+	}
+
 	ctx, stop := notifyAppTermination(context.Background())
 	go func() {
 		<-ctx.Done()
 		logger.Info("Stopping...")
+		if err := sdnotify.Notify(sdnotify.Stopping); err != nil {
+			logger.Warn("Failed to notify systemd", zap.Error(err))
+		}
 		stop()
 	}()
 
 	go debug.RunHandler(ctx, *debugAddrF, logger.Named("debug"))
 
+	var tracer *tracing.Tracer
+	if *otelTracesURLF != "" {
+		tracer, err = tracing.NewTracer(&tracing.NewTracerOpts{
+			URL:               *otelTracesURLF,
+			ServiceName:       "ferretdb",
+			ServiceVersion:    info.Version,
+			ServiceInstanceID: instance.UUID,
+			Logger:            logger.Named("tracing"),
+		})
+		if err != nil {
+			logger.Fatal(err.Error())
+		}
+
+		// closed after the handler, so spans of the last requests are exported
+		defer tracer.Close()
+	}
+
+	var ldapConfig *ldapauth.Config
+	if pg.AuthMode(*postgreSQLAuthModeF) == pg.AuthModeLDAP {
+		ldapConfig = &ldapauth.Config{
+			URL:               *ldapURLF,
+			BindDNTemplates:   strings.Split(*ldapBindDNTemplateF, ";"),
+			GroupSearchBase:   *ldapGroupSearchBaseF,
+			GroupSearchFilter: *ldapGroupSearchFilterF,
+			StartTLS:          *ldapStartTLSF,
+		}
+
+		if *ldapGroupRolesF != "" {
+			if err := json.Unmarshal([]byte(*ldapGroupRolesF), &ldapConfig.GroupRoles); err != nil {
+				logger.Sugar().Fatalf("Invalid LDAP group roles mapping: %s.", err)
+			}
+		}
+	}
+
+	var faults *fault.Injector
+	if *testFaultsF != "" {
+		faultsConfig, err := fault.ParseConfig(*testFaultsF)
+		if err != nil {
+			logger.Fatal(err.Error())
+		}
+
+		faults = fault.New(faultsConfig)
+		logger.Sugar().Warnf("Injecting faults: %s.", *testFaultsF)
+	}
+
+	var replicaURLs []string
+	if *postgreSQLReplicaURLsF != "" {
+		replicaURLs = strings.Split(*postgreSQLReplicaURLsF, ";")
+	}
+
 	h, err := registry.NewHandler(*handlerF, &registry.NewHandlerOpts{
-		Ctx:           ctx,
-		Logger:        logger,
-		PostgreSQLURL: *postgreSQLURLF,
-		TigrisURL:     tigrisURL,
+		Ctx:                  ctx,
+		Logger:               logger,
+		Tracing:              tracer != nil,
+		PostgreSQLURL:        *postgreSQLURLF,
+		PostgreSQLWatchMode:  pgdb.WatchMode(*postgreSQLWatchModeF),
+		PostgreSQLAuthMode:   pg.AuthMode(*postgreSQLAuthModeF),
+		PostgreSQLUUIDColumn: *postgreSQLUUIDColumnF,
+		PostgreSQLGINIndex:   *postgreSQLGINIndexF,
+
+		PostgreSQLPgBouncerMode: *postgreSQLPgBouncerModeF,
+
+		PostgreSQLPoolMaxConns:          int32(*postgreSQLPoolMaxConnsF),
+		PostgreSQLPoolMaxConnLifetime:   *postgreSQLPoolMaxConnLifetimeF,
+		PostgreSQLPoolMaxConnIdleTime:   *postgreSQLPoolMaxConnIdleTimeF,
+		PostgreSQLPoolHealthCheckPeriod: *postgreSQLPoolHealthCheckPeriodF,
+		PostgreSQLPoolPerDatabase:       *postgreSQLPoolPerDatabaseF,
+		PostgreSQLPoolPerDatabaseMax:    *postgreSQLPoolPerDatabaseMaxF,
+		PostgreSQLTenantRoles:           *postgreSQLTenantRolesF,
+
+		PostgreSQLMetadataCacheTTL: *postgreSQLMetadataCacheTTLF,
+		PostgreSQLAnalyzeThreshold: *postgreSQLAnalyzeThresholdF,
+		PostgreSQLAnalyzeInterval:  *postgreSQLAnalyzeIntervalF,
+		PostgreSQLReplicaURLs:      replicaURLs,
+
+		PostgreSQLBulkImportIndexDelay: *postgreSQLBulkImportIndexDelayF,
+
+		PostgreSQLLargeDocumentThreshold: *postgreSQLLargeDocumentThresholdF,
+
+		PostgreSQLHealthCheckInterval: *postgreSQLHealthCheckIntervalF,
+		PostgreSQLHealthCheckFailures: *postgreSQLHealthCheckFailuresF,
+
+		PostgreSQLSSLMode:     *postgreSQLSSLModeF,
+		PostgreSQLSSLRootCert: *postgreSQLSSLRootCertF,
+		PostgreSQLSSLCert:     *postgreSQLSSLCertF,
+		PostgreSQLSSLKey:      *postgreSQLSSLKeyF,
+
+		LDAP: ldapConfig,
+
+		TigrisURL: tigrisURL,
+		SQLiteURL: *sqliteURLF,
+		MySQLURL:  *mysqlURLF,
+
+		Faults: faults,
 	})
 	if err != nil {
 		logger.Fatal(err.Error())
 	}
 	defer h.Close()
 
+	var auditLogger *audit.Logger
+	if *auditDestinationF != "" {
+		var atypes []string
+		if *auditATypesF != "" {
+			atypes = strings.Split(*auditATypesF, ",")
+		}
+
+		auditLogger, err = audit.NewLogger(&audit.NewLoggerOpts{
+			Destination: audit.Destination(*auditDestinationF),
+			Path:        *auditPathF,
+			Filter: audit.Filter{
+				ATypes:     atypes,
+				FailedOnly: *auditFailedOnlyF,
+			},
+		})
+		if err != nil {
+			logger.Fatal(err.Error())
+		}
+
+		defer auditLogger.Close()
+	}
+
+	var capture *wire.CaptureWriter
+	if *captureFileF != "" {
+		f, err := os.OpenFile(*captureFileF, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0o600)
+		if err != nil {
+			logger.Fatal(err.Error())
+		}
+
+		defer f.Close()
+
+		capture = wire.NewCaptureWriter(f)
+		logger.Sugar().Warnf("Capturing all client requests to %s; the file contains unredacted data.", *captureFileF)
+	}
+
 	l := clientconn.NewListener(&clientconn.NewListenerOpts{
-		ListenAddr:      *listenAddrF,
 		ProxyAddr:       *proxyAddrF,
 		Mode:            clientconn.Mode(*modeF),
 		Handler:         h,
 		Logger:          logger,
 		TestConnTimeout: *testConnTimeoutF,
+		Listeners:       listeners,
+		IPFilter:        ipFilter,
+		Limits:          limitsOpts(),
+		IdleTimeout:     *connIdleTimeoutF,
+		KeepAlive:       *connKeepAliveF,
+		DrainTimeout:    *shutdownDrainTimeoutF,
+		AuditLogger:     auditLogger,
+		SlowOps:         slowOps,
+		Tracer:          tracer,
+
+		Compressors:          compressors,
+		CompressionThreshold: *compressionThresholdF,
+
+		Capture: capture,
+
+		Middlewares: middlewares,
+
+		Faults: faults,
 	})
 
 	prometheus.DefaultRegisterer.MustRegister(l)
 
+	// some handlers provide their own metrics
+	if c, ok := h.(prometheus.Collector); ok {
+		prometheus.DefaultRegisterer.MustRegister(c)
+	}
+
+	// liveness does not depend on the backend, so the process is not restarted while it is unavailable
+	debug.SetProbes(
+		map[string]debug.Probe{"listener": l.Check},
+		map[string]debug.Probe{"backend": h.Check},
+	)
+
+	// certificates and some settings are reloaded on SIGHUP without dropping client connections
+	reload := make(chan os.Signal, 1)
+	notifyReload(reload)
+	go func() {
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-reload:
+				reloadSettings(logger, l, tlsReloadable, fixedFlags)
+			}
+		}
+	}()
+
+	// for systemd units with Type=notify, service is ready when all sockets are opened
+	go func() {
+		if l.Addrs() == nil {
+			return
+		}
+
+		if err := sdnotify.Notify(sdnotify.Ready); err != nil {
+			logger.Warn("Failed to notify systemd", zap.Error(err))
+		}
+	}()
+
+	// the watchdog restarts FerretDB if the listener stops accepting connections
+	if interval := sdnotify.WatchdogInterval(); interval > 0 {
+		go sdnotify.RunWatchdog(ctx, interval, l.Check, logger.Named("sdnotify"))
+	}
+
+	var ftdcDone chan struct{}
+	if *diagnosticDataDirF != "" {
+		start := time.Now()
+
+		c, err := ftdc.NewCollector(&ftdc.NewCollectorOpts{
+			Dir: *diagnosticDataDirF,
+			Sample: func(context.Context) (*types.Document, error) {
+				serverStatus, err := ftdc.ServerStatus(prometheus.DefaultGatherer, start)
+				if err != nil {
+					return nil, err
+				}
+
+				return types.NewDocument("serverStatus", serverStatus)
+			},
+			Metadata: diagnosticMetadata(ctx, instance),
+			Logger:   logger.Named("ftdc"),
+			Period:   *diagnosticDataPeriodF,
+		})
+		if err != nil {
+			logger.Fatal(err.Error())
+		}
+
+		ftdcDone = make(chan struct{})
+		go func() {
+			defer close(ftdcDone)
+			c.Run(ctx)
+		}()
+	}
+
 	err = l.Run(ctx)
 	if err == nil || err == context.Canceled {
 		logger.Info("Listener stopped")
@@ -158,6 +773,11 @@ func main() {
 		logger.Error("Listener stopped", zap.Error(err))
 	}
 
+	// wait for the last chunk to be written
+	if ftdcDone != nil {
+		<-ftdcDone
+	}
+
 	mfs, err := prometheus.DefaultGatherer.Gather()
 	if err != nil {
 		panic(err)
@@ -168,3 +788,106 @@ func main() {
 		}
 	}
 }
+
+// slowOpsOpts returns slow operations logging configuration from flags; nil if it is disabled.
+func slowOpsOpts() (*clientconn.SlowOpsOpts, error) {
+	if *logSlowMSF < 0 {
+		return nil, nil
+	}
+
+	if *logSlowSampleRateF <= 0 || *logSlowSampleRateF > 1 {
+		return nil, fmt.Errorf("invalid -log-slow-sample-rate %v: must be greater than 0 and at most 1", *logSlowSampleRateF)
+	}
+
+	return &clientconn.SlowOpsOpts{
+		Threshold:  time.Duration(*logSlowMSF) * time.Millisecond,
+		SampleRate: *logSlowSampleRateF,
+	}, nil
+}
+
+// limitsOpts returns operation and connection limits from flags; nil if there are no limits.
+func limitsOpts() *clientconn.LimitsOpts {
+	if *limitConnOpsF == 0 && *limitIPOpsF == 0 && *limitIPMaxInFlightF == 0 && *limitMaxConnsF == 0 && *limitIPMaxConnsF == 0 {
+		return nil
+	}
+
+	return &clientconn.LimitsOpts{
+		ConnOpsPerSecond: *limitConnOpsF,
+		IPOpsPerSecond:   *limitIPOpsF,
+		IPMaxInFlight:    *limitIPMaxInFlightF,
+		MaxConns:         *limitMaxConnsF,
+		IPMaxConns:       *limitIPMaxConnsF,
+	}
+}
+
+// reloadSettings reloads TLS certificates from files,
+// and log level, slow operations logging and limits from the configuration file.
+//
+// Settings that fail to reload keep their previous values.
+func reloadSettings(
+	logger *zap.Logger, l *clientconn.Listener, tlsConfig *clientconn.ReloadableTLSConfig, fixed map[string]struct{},
+) {
+	logger.Info("Reloading configuration...")
+
+	if tlsConfig != nil {
+		if err := tlsConfig.Reload(); err != nil {
+			logger.Error("Failed to reload TLS certificates", zap.Error(err))
+		}
+	}
+
+	if err := reloadConfig(flag.CommandLine, fixed); err != nil {
+		logger.Error("Failed to reload configuration file", zap.Error(err))
+		return
+	}
+
+	if level, err := zapcore.ParseLevel(*logLevelF); err != nil {
+		logger.Error("Failed to reload log level", zap.Error(err))
+	} else {
+		logging.SetLevel(level)
+	}
+
+	if slowOps, err := slowOpsOpts(); err != nil {
+		logger.Error("Failed to reload slow operations logging", zap.Error(err))
+	} else {
+		l.SetSlowOps(slowOps)
+	}
+
+	if err := l.SetLimits(limitsOpts()); err != nil {
+		logger.Error("Failed to reload limits", zap.Error(err))
+	}
+
+	logger.Info("Configuration reloaded.")
+}
+
+// diagnosticMetadata returns the document written at the start of each diagnostic data file.
+func diagnosticMetadata(ctx context.Context, instance *state.State) *types.Document {
+	argv := types.MakeArray(len(os.Args))
+	for _, arg := range os.Args {
+		must.NoError(argv.Append(arg))
+	}
+
+	metadata := must.NotFail(types.NewDocument(
+		"getCmdLineOpts", must.NotFail(types.NewDocument("argv", argv)),
+		"instance", must.NotFail(types.NewDocument("uuid", instance.UUID)),
+	))
+
+	for _, c := range []struct {
+		name string
+		f    func(context.Context, *wire.OpMsg) (*wire.OpMsg, error)
+	}{
+		{"buildInfo", common.MsgBuildInfo},
+		{"hostInfo", common.MsgHostInfo},
+	} {
+		// hostInfo may fail on some systems; analysis tools work without it
+		reply, err := c.f(ctx, new(wire.OpMsg))
+		if err != nil {
+			continue
+		}
+
+		doc := must.NotFail(reply.Document())
+		doc.Remove("ok")
+		must.NoError(metadata.Set(c.name, doc))
+	}
+
+	return metadata
+}
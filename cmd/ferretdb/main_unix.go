@@ -18,6 +18,7 @@ package main
 
 import (
 	"context"
+	"os"
 	"os/signal"
 
 	"golang.org/x/sys/unix"
@@ -26,3 +27,7 @@ import (
 func notifyAppTermination(parent context.Context) (context.Context, context.CancelFunc) {
 	return signal.NotifyContext(parent, unix.SIGTERM, unix.SIGINT)
 }
+
+func notifyReload(c chan<- os.Signal) {
+	signal.Notify(c, unix.SIGHUP)
+}
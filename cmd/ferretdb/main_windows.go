@@ -25,3 +25,6 @@ import (
 func notifyAppTermination(parent context.Context) (context.Context, context.CancelFunc) {
 	return signal.NotifyContext(parent, windows.SIGTERM, windows.SIGINT, os.Interrupt)
 }
+
+// notifyReload does nothing as there is no SIGHUP on Windows.
+func notifyReload(c chan<- os.Signal) {}
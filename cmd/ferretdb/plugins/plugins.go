@@ -0,0 +1,32 @@
+// Copyright 2021 FerretDB Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package plugins links custom commands and middlewares into FerretDB binary
+// built with `plugins` build tag (for example, `go build -tags=plugins ./cmd/ferretdb`).
+//
+// Put plugin packages into subdirectories and add blank imports for them to this file.
+// Plugins register themselves from init functions:
+//
+//	func init() {
+//		opts := &ferretdb.CommandOpts{ReadOnly: true}
+//		if err := ferretdb.RegisterCommand("tenantStats", "Returns tenant statistics.", opts, tenantStats); err != nil {
+//			panic(err)
+//		}
+//
+//		middleware.Register("audit", audit)
+//	}
+//
+// Registering a command that conflicts with a built-in or another plugin's command fails.
+// Registered middlewares should be enabled with -middleware flag.
+package plugins
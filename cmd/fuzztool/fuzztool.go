@@ -188,9 +188,9 @@ func main() {
 		os.Exit(2)
 	}
 
-	logging.Setup(zap.InfoLevel)
+	logging.Setup(zap.InfoLevel, logging.FormatText)
 	if *debugF {
-		logging.Setup(zap.DebugLevel)
+		logging.Setup(zap.DebugLevel, logging.FormatText)
 	}
 	logger := zap.S()
 
@@ -0,0 +1,106 @@
+// Copyright 2021 FerretDB Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package main contains a tool for converting collections stored by the pg handler
+// to the latest storage format.
+//
+// Collections are converted online, so FerretDB could keep serving clients during migration.
+// The same could be done with the migrateCollection command.
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+	"os/signal"
+	"time"
+
+	"go.uber.org/zap"
+
+	"github.com/FerretDB/FerretDB/internal/handlers/pg/pgdb"
+	"github.com/FerretDB/FerretDB/internal/util/logging"
+)
+
+// progressInterval is the minimal interval between progress messages.
+const progressInterval = time.Second
+
+func main() {
+	debugF := flag.Bool("debug", false, "enable debug mode")
+	postgreSQLURLF := flag.String("postgresql-url", "postgres://postgres@127.0.0.1:5432/ferretdb", "PostgreSQL URL")
+	dbF := flag.String("db", "", "database to migrate")
+	collectionF := flag.String("collection", "", "collection to migrate; all database collections if empty")
+	batchSizeF := flag.Int("batch-size", pgdb.DefaultMigrateBatchSize, "number of documents converted in a single transaction")
+	flag.Parse()
+
+	if flag.NArg() != 0 {
+		flag.Usage()
+		fmt.Fprintln(flag.CommandLine.Output(), "zero arguments expected")
+		os.Exit(2)
+	}
+
+	logging.Setup(zap.InfoLevel, logging.FormatText)
+	if *debugF {
+		logging.Setup(zap.DebugLevel, logging.FormatText)
+	}
+	logger := zap.S()
+
+	if *dbF == "" {
+		logger.Fatal("-db flag must be specified.")
+	}
+
+	if *batchSizeF <= 0 {
+		logger.Fatal("-batch-size flag must be positive.")
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+
+	pgPool, err := pgdb.NewPool(ctx, *postgreSQLURLF, logger.Desugar(), nil)
+	if err != nil {
+		logger.Fatal(err)
+	}
+	defer pgPool.Close()
+
+	collections := []string{*collectionF}
+	if *collectionF == "" {
+		if collections, err = pgPool.Collections(ctx, *dbF); err != nil {
+			logger.Fatal(err)
+		}
+	}
+
+	for _, collection := range collections {
+		var last time.Time
+		progress := func(done, total int64) {
+			if time.Since(last) < progressInterval {
+				return
+			}
+			last = time.Now()
+
+			logger.Infof("%s.%s: %d of ~%d documents processed.", *dbF, collection, done, total)
+		}
+
+		n, err := pgPool.MigrateCollection(ctx, *dbF, collection, *batchSizeF, progress)
+		if err != nil {
+			logger.Fatalf("%s.%s: %s", *dbF, collection, err)
+		}
+
+		if n == 0 {
+			logger.Infof("%s.%s: already uses the latest storage format.", *dbF, collection)
+			continue
+		}
+
+		logger.Infof("%s.%s: migrated, %d documents processed.", *dbF, collection, n)
+	}
+}
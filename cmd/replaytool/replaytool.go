@@ -0,0 +1,285 @@
+// Copyright 2021 FerretDB Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package main contains a tool for replaying client requests recorded by FerretDB's -capture-file flag.
+//
+// Requests of each recorded connection are sent over a separate connection to the given address,
+// and responses are read and discarded. Only ok fields of OP_MSG responses are decoded to count failed commands;
+// other responses (like compressed ones) are not checked.
+// Authentication conversations can't be replayed, so requests should be captured without authentication.
+package main
+
+import (
+	"bufio"
+	"flag"
+	"fmt"
+	"io"
+	"net"
+	"os"
+	"sort"
+	"sync"
+	"time"
+
+	"go.uber.org/zap"
+
+	"github.com/FerretDB/FerretDB/internal/bson"
+	"github.com/FerretDB/FerretDB/internal/util/lazyerrors"
+	"github.com/FerretDB/FerretDB/internal/util/logging"
+	"github.com/FerretDB/FerretDB/internal/wire"
+)
+
+// stats contains replay statistics for all connections.
+type stats struct {
+	m         sync.Mutex
+	requests  int
+	errors    int
+	failed    int
+	latencies []time.Duration
+}
+
+// add adds the result of a single request.
+//
+// Failed commands are counted separately from errors; their latencies are included.
+func (s *stats) add(latency time.Duration, failed bool, err error) {
+	s.m.Lock()
+	defer s.m.Unlock()
+
+	s.requests++
+
+	if err != nil {
+		s.errors++
+		return
+	}
+
+	if failed {
+		s.failed++
+	}
+
+	s.latencies = append(s.latencies, latency)
+}
+
+// readCapture reads all records from the capture file, grouped by connection ID.
+func readCapture(path string) (map[uint64][]*wire.CaptureRecord, time.Time, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, time.Time{}, lazyerrors.Error(err)
+	}
+	defer f.Close()
+
+	r := wire.NewCaptureReader(f)
+	res := map[uint64][]*wire.CaptureRecord{}
+
+	var first time.Time
+	for {
+		record, err := r.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, time.Time{}, lazyerrors.Error(err)
+		}
+
+		if first.IsZero() || record.Time.Before(first) {
+			first = record.Time
+		}
+
+		res[record.ConnID] = append(res[record.ConnID], record)
+	}
+
+	return res, first, nil
+}
+
+// prepareRequest returns the request to send and true if the response is expected.
+//
+// Compressed requests are sent uncompressed.
+// Exhaust flag is removed so the server sends a single response.
+func prepareRequest(record *wire.CaptureRecord) (*wire.MsgHeader, wire.MsgBody, bool, error) {
+	header, body := record.Header, record.Body
+	if compressed, ok := body.(*wire.OpCompressed); ok {
+		var err error
+		if header, body, err = compressed.Message(header); err != nil {
+			return nil, nil, false, lazyerrors.Error(err)
+		}
+	}
+
+	switch body := body.(type) {
+	case *wire.OpMsg:
+		body.FlagBits &^= wire.OpMsgFlags(wire.OpMsgExhaustAllowed)
+		return header, body, !body.FlagBits.FlagSet(wire.OpMsgMoreToCome), nil
+
+	case *wire.OpKillCursors:
+		return header, body, false, nil
+
+	default:
+		return header, body, true, nil
+	}
+}
+
+// replayConn replays requests of a single recorded connection.
+//
+// If speed is positive, requests are sent at the recorded times (relative to first) multiplied by speed.
+func replayConn(addr string, records []*wire.CaptureRecord, first, start time.Time, speed float64, s *stats) error {
+	netConn, err := net.Dial("tcp", addr)
+	if err != nil {
+		return lazyerrors.Error(err)
+	}
+	defer netConn.Close()
+
+	bufr := bufio.NewReader(netConn)
+	bufw := bufio.NewWriter(netConn)
+
+	for _, record := range records {
+		if speed > 0 {
+			at := start.Add(time.Duration(float64(record.Time.Sub(first)) / speed))
+			time.Sleep(time.Until(at))
+		}
+
+		header, body, response, err := prepareRequest(record)
+		if err != nil {
+			return lazyerrors.Error(err)
+		}
+
+		reqStart := time.Now()
+
+		if err = wire.WriteMessage(bufw, header, body); err == nil {
+			err = bufw.Flush()
+		}
+
+		var failed bool
+		if err == nil && response {
+			failed, err = readResponse(bufr)
+		}
+
+		s.add(time.Since(reqStart), failed, err)
+
+		if err != nil {
+			return lazyerrors.Error(err)
+		}
+	}
+
+	return nil
+}
+
+// readResponse reads the response and returns true if it is OP_MSG with a failed command.
+//
+// Responses could be large (for example, with find's first batch),
+// so only ok field is decoded; see bson.RawDocument.
+func readResponse(bufr *bufio.Reader) (bool, error) {
+	header, b, err := wire.ReadRawMessage(bufr)
+	if err != nil {
+		return false, lazyerrors.Error(err)
+	}
+
+	if header.OpCode != wire.OpCodeMsg {
+		return false, nil
+	}
+
+	var doc bson.RawDocument
+	if doc, err = wire.RawOpMsgDocument(b); err != nil {
+		return false, lazyerrors.Error(err)
+	}
+
+	ok, err := doc.Get("ok")
+	if err != nil {
+		return false, lazyerrors.Error(err)
+	}
+
+	switch ok := ok.(type) {
+	case float64:
+		return ok != 1, nil
+	case int32:
+		return ok != 1, nil
+	case int64:
+		return ok != 1, nil
+	case bool:
+		return !ok, nil
+	default:
+		return false, lazyerrors.Errorf("unexpected ok value type %T", ok)
+	}
+}
+
+// percentile returns p-th percentile of sorted durations.
+func percentile(sorted []time.Duration, p int) time.Duration {
+	if len(sorted) == 0 {
+		return 0
+	}
+
+	return sorted[(len(sorted)-1)*p/100]
+}
+
+func main() {
+	debugF := flag.Bool("debug", false, "enable debug mode")
+	fileF := flag.String("file", "", "capture file recorded by FerretDB's -capture-file flag")
+	addrF := flag.String("addr", "127.0.0.1:27017", "address to send requests to")
+	speedF := flag.Float64("speed", 0, "replay speed relative to the recorded one; 0 - as fast as possible")
+	flag.Parse()
+
+	if flag.NArg() != 0 {
+		flag.Usage()
+		fmt.Fprintln(flag.CommandLine.Output(), "zero arguments expected")
+		os.Exit(2)
+	}
+
+	logging.Setup(zap.InfoLevel, logging.FormatText)
+	if *debugF {
+		logging.Setup(zap.DebugLevel, logging.FormatText)
+	}
+	logger := zap.S()
+
+	if *fileF == "" {
+		logger.Fatal("-file flag must be specified.")
+	}
+
+	if *speedF < 0 {
+		logger.Fatal("-speed flag can't be negative.")
+	}
+
+	conns, first, err := readCapture(*fileF)
+	if err != nil {
+		logger.Fatal(err)
+	}
+	logger.Infof("Replaying %d connections from %s to %s.", len(conns), *fileF, *addrF)
+
+	var s stats
+	var wg sync.WaitGroup
+	start := time.Now()
+
+	for id, records := range conns {
+		id, records := id, records
+
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+
+			if err := replayConn(*addrF, records, first, start, *speedF, &s); err != nil {
+				logger.Warnf("Connection %d: %s", id, err)
+			}
+		}()
+	}
+
+	wg.Wait()
+
+	elapsed := time.Since(start)
+
+	sort.Slice(s.latencies, func(i, j int) bool { return s.latencies[i] < s.latencies[j] })
+
+	logger.Infof(
+		"Sent %d requests (%d failed, %d commands returned errors) in %s, %.1f requests/s.",
+		s.requests, s.errors, s.failed, elapsed, float64(s.requests)/elapsed.Seconds(),
+	)
+	logger.Infof(
+		"Latency: p50 %s, p90 %s, p99 %s, max %s.",
+		percentile(s.latencies, 50), percentile(s.latencies, 90), percentile(s.latencies, 99), percentile(s.latencies, 100),
+	)
+}
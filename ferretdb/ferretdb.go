@@ -19,11 +19,14 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"net"
 	"net/url"
+	"sync/atomic"
 
 	"go.uber.org/zap"
 	"go.uber.org/zap/zapcore"
 
+	"github.com/FerretDB/FerretDB/ferretdb/middleware"
 	"github.com/FerretDB/FerretDB/internal/clientconn"
 	"github.com/FerretDB/FerretDB/internal/handlers/registry"
 	"github.com/FerretDB/FerretDB/internal/util/logging"
@@ -31,7 +34,7 @@ import (
 
 // Config represents FerretDB configuration.
 type Config struct {
-	// Handler to use; one of `pg` or `tigris` (if enabled at compile-time).
+	// Handler to use; one of `pg`, `sqlite`, `mysql`, or `tigris` (if enabled at compile-time).
 	Handler string
 
 	// PostgreSQL connection string for `pg` handler.
@@ -39,19 +42,58 @@ type Config struct {
 
 	// Tigris connection string for `tigris` handler.
 	TigrisURL string
+
+	// SQLite database file path or URI for `sqlite` handler.
+	SQLiteURL string
+
+	// MySQL data source name for `mysql` handler.
+	MySQLURL string
+
+	// TCP address to listen on; 127.0.0.1:27017 if empty.
+	// Zero port means any free port; see MongoDBURI.
+	ListenAddr string
+
+	// If set, Unix domain socket path to listen on in addition to ListenAddr.
+	ListenUnix string
+
+	// If set, no sockets are opened, and clients can connect only in-process with DialContext;
+	// ListenAddr and ListenUnix are ignored.
+	InProcessOnly bool
+
+	// Logger to use; if nil, the package's logger is used, which logs only fatal errors.
+	Logger *zap.Logger
+
+	// Middlewares that observe or modify commands and responses; the first one is the outermost.
+	Middlewares []middleware.Middleware
 }
 
 // FerretDB represents an instance of embeddable FerretDB implementation.
 type FerretDB struct {
 	config     *Config
 	listenAddr string
+
+	pipe      *clientconn.PipeListener
+	listening chan struct{} // closed when Run starts listening or fails to do that
+	addr      net.Addr      // actual TCP address; nil if Run failed to listen
+	running   int32         // set when Run is called; accessed atomically
 }
 
 // New creates a new instance of embeddable FerretDB implementation.
 func New(config *Config) (*FerretDB, error) {
+	listenAddr := config.ListenAddr
+	if listenAddr == "" {
+		listenAddr = "127.0.0.1:27017"
+	}
+
+	if _, _, err := net.SplitHostPort(listenAddr); err != nil {
+		return nil, fmt.Errorf("invalid listen address: %s", err)
+	}
+
 	f := &FerretDB{
 		config:     config,
-		listenAddr: "127.0.0.1:27017",
+		listenAddr: listenAddr,
+		pipe:       clientconn.NewPipeListener(),
+		listening:  make(chan struct{}),
 	}
 
 	return f, nil
@@ -59,28 +101,63 @@ func New(config *Config) (*FerretDB, error) {
 
 // Run runs FerretDB until ctx is done.
 //
-// When this method returns, listener and all connections are closed.
+// It could be called only once for each FerretDB instance.
+// When this method returns, listener, all connections, and the handler are closed.
 func (f *FerretDB) Run(ctx context.Context) error {
+	if !atomic.CompareAndSwapInt32(&f.running, 0, 1) {
+		return errors.New("FerretDB is already running")
+	}
+
+	// stop in-process connections attempts even if the listener fails to start
+	defer f.pipe.Close()
+
+	l := f.config.Logger
+	if l == nil {
+		l = logger
+	}
+
 	newOpts := registry.NewHandlerOpts{
-		Ctx:           context.Background(),
-		Logger:        logger,
+		Ctx:           ctx,
+		Logger:        l,
 		PostgreSQLURL: f.config.PostgreSQLURL,
 		TigrisURL:     f.config.TigrisURL,
+		SQLiteURL:     f.config.SQLiteURL,
+		MySQLURL:      f.config.MySQLURL,
 	}
 	h, err := registry.NewHandler(f.config.Handler, &newOpts)
 	if err != nil {
+		close(f.listening)
 		return fmt.Errorf("failed to construct handler: %s", err)
 	}
 	defer h.Close()
 
-	l := clientconn.NewListener(&clientconn.NewListenerOpts{
-		ListenAddr: f.listenAddr,
+	listenAddr := f.listenAddr
+	var listeners []clientconn.ListenerConfig
+
+	if f.config.InProcessOnly {
+		listenAddr = ""
+	} else if f.config.ListenUnix != "" {
+		listeners = append(listeners, clientconn.ListenerConfig{Network: "unix", Addr: f.config.ListenUnix})
+	}
+
+	listeners = append(listeners, clientconn.ListenerConfig{Listener: f.pipe})
+
+	lis := clientconn.NewListener(&clientconn.NewListenerOpts{
+		ListenAddr: listenAddr,
+		Listeners:  listeners,
 		Mode:       clientconn.NormalMode,
 		Handler:    h,
-		Logger:     logger,
+		Logger:     l,
+
+		Middlewares: f.config.Middlewares,
 	})
 
-	if err = l.Run(ctx); err != nil {
+	go func() {
+		f.addr = lis.Addr()
+		close(f.listening)
+	}()
+
+	if err = lis.Run(ctx); err != nil {
 		// Do not expose internal error details.
 		// If you need stable error values and/or types for some cases, please create an issue.
 		err = errors.New(err.Error())
@@ -88,16 +165,69 @@ func (f *FerretDB) Run(ctx context.Context) error {
 	return err
 }
 
+// DialContext connects to this FerretDB instance in-process, without network.
+// It blocks until Run accepts the connection.
+//
+// It implements MongoDB Go driver's options.ContextDialer interface,
+// so FerretDB could be passed to options.ClientOptions.SetDialer; network and address are ignored.
+func (f *FerretDB) DialContext(ctx context.Context, network, address string) (net.Conn, error) {
+	return f.pipe.Dial(ctx)
+}
+
 // MongoDBURI returns MongoDB URI for this FerretDB instance.
+//
+// If the listen address has zero port, it blocks until Run starts listening
+// to return the actually used port.
+//
+// With InProcessOnly, the URI's host is a placeholder, and clients should connect with DialContext.
 func (f *FerretDB) MongoDBURI() string {
+	host := f.listenAddr
+	if f.config.InProcessOnly {
+		host = "in-process"
+	} else if _, port, _ := net.SplitHostPort(host); port == "0" {
+		<-f.listening
+
+		if f.addr != nil {
+			host = f.addr.String()
+		}
+	}
+
 	u := url.URL{
 		Scheme: "mongodb",
-		Host:   f.listenAddr,
+		Host:   host,
 		Path:   "/",
 	}
 	return u.String()
 }
 
+// CommandOpts represents custom command options.
+type CommandOpts struct {
+	// ReadOnly should be set for commands that do not modify data.
+	//
+	// By default, commands are assumed to modify data: they require readWrite role
+	// and wait while writes are locked by fsync command.
+	// Read-only commands require only read role and are not blocked by fsync.
+	ReadOnly bool
+}
+
+// RegisterCommand adds a custom command, like a proprietary "tenantStats", to all FerretDB instances.
+//
+// The handler receives the command document and returns the response document, both in BSON encoding,
+// like middleware.Handler; it could return *middleware.Error to send the error code to the client.
+// Custom commands are listed by listCommands and go through middlewares.
+// If opts is nil, default options are used.
+//
+// It returns an error if the command with the same name already exists, including built-in commands.
+// It is not safe for concurrent use and should be called before any FerretDB instance is run,
+// for example, from init functions.
+func RegisterCommand(name, help string, opts *CommandOpts, h middleware.Handler) error {
+	if opts == nil {
+		opts = new(CommandOpts)
+	}
+
+	return clientconn.RegisterCommand(name, help, !opts.ReadOnly, h)
+}
+
 // logger is a global logger used by FerretDB.
 //
 // If it is a problem for you, please create an issue.
@@ -106,6 +236,6 @@ var logger *zap.Logger
 // Initialize the global logger there to avoid creating too many issues for zap users that initialize it in their
 // `main()` functions. It is still not a full solution; eventually, we should remove the usage of the global logger.
 func init() {
-	logging.Setup(zapcore.FatalLevel)
+	logging.Setup(zapcore.FatalLevel, logging.FormatText)
 	logger = zap.L()
 }
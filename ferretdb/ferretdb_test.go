@@ -18,11 +18,22 @@ import (
 	"context"
 	"fmt"
 	"log"
+	"net/url"
+	"os"
+	"path/filepath"
+	"testing"
 
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 	"go.mongodb.org/mongo-driver/bson"
 	"go.mongodb.org/mongo-driver/bson/primitive"
 	"go.mongodb.org/mongo-driver/mongo"
 	"go.mongodb.org/mongo-driver/mongo/options"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zaptest/observer"
+
+	"github.com/FerretDB/FerretDB/ferretdb/middleware"
+	"github.com/FerretDB/FerretDB/internal/handlers/common"
 )
 
 func Example() {
@@ -44,12 +55,12 @@ func Example() {
 	}
 
 	filter := bson.D{{
-		"name",
-		bson.D{{
-			"$not",
-			bson.D{{
-				"$regex",
-				primitive.Regex{Pattern: "test.*"},
+		Key: "name",
+		Value: bson.D{{
+			Key: "$not",
+			Value: bson.D{{
+				Key:   "$regex",
+				Value: primitive.Regex{Pattern: "test.*"},
 			}},
 		}},
 	}}
@@ -61,3 +72,277 @@ func Example() {
 	fmt.Println(collections)
 	// Output: [admin public]
 }
+
+func TestZeroPort(t *testing.T) {
+	t.Parallel()
+
+	_, err := New(&Config{ListenAddr: "127.0.0.1"})
+	require.Error(t, err)
+
+	// Go driver lowercases hosts, including Unix domain socket paths, so t.TempDir() can't be used
+	dir, err := os.MkdirTemp("", "ferretdb")
+	require.NoError(t, err)
+	t.Cleanup(func() {
+		require.NoError(t, os.RemoveAll(dir))
+	})
+
+	socket := filepath.Join(dir, "ferretdb.sock")
+	f, err := New(&Config{
+		Handler:    "sqlite",
+		SQLiteURL:  filepath.Join(dir, "ferretdb.sqlite"),
+		ListenAddr: "127.0.0.1:0",
+		ListenUnix: socket,
+	})
+	require.NoError(t, err)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		_ = f.Run(ctx)
+	}()
+	t.Cleanup(func() {
+		cancel()
+		<-done
+	})
+
+	uri := f.MongoDBURI()
+	u, err := url.Parse(uri)
+	require.NoError(t, err)
+	assert.NotEqual(t, "0", u.Port())
+
+	client, err := mongo.Connect(ctx, options.Client().ApplyURI(uri))
+	require.NoError(t, err)
+	t.Cleanup(func() {
+		require.NoError(t, client.Disconnect(context.Background()))
+	})
+
+	require.NoError(t, client.Ping(ctx, nil))
+
+	// Unix domain socket path is percent-encoded as a host
+	socketURI := "mongodb://" + url.PathEscape(socket)
+	socketClient, err := mongo.Connect(ctx, options.Client().ApplyURI(socketURI))
+	require.NoError(t, err)
+	t.Cleanup(func() {
+		require.NoError(t, socketClient.Disconnect(context.Background()))
+	})
+
+	require.NoError(t, socketClient.Ping(ctx, nil))
+}
+
+func TestRun(t *testing.T) {
+	t.Parallel()
+
+	t.Run("Logger", func(t *testing.T) {
+		t.Parallel()
+
+		core, logs := observer.New(zap.InfoLevel)
+
+		f, err := New(&Config{
+			Handler:    "sqlite",
+			SQLiteURL:  filepath.Join(t.TempDir(), "ferretdb.sqlite"),
+			ListenAddr: "127.0.0.1:0",
+			Logger:     zap.New(core),
+		})
+		require.NoError(t, err)
+
+		ctx, cancel := context.WithCancel(context.Background())
+		done := make(chan struct{})
+		go func() {
+			defer close(done)
+			_ = f.Run(ctx)
+		}()
+
+		uri := f.MongoDBURI()
+		assert.NotEmpty(t, logs.FilterMessageSnippet("Listening on").All())
+
+		assert.EqualError(t, f.Run(ctx), "FerretDB is already running")
+		assert.Equal(t, uri, f.MongoDBURI())
+
+		cancel()
+		<-done
+	})
+
+	t.Run("InvalidHandler", func(t *testing.T) {
+		t.Parallel()
+
+		f, err := New(&Config{
+			Handler:    "invalid",
+			ListenAddr: "127.0.0.1:0",
+		})
+		require.NoError(t, err)
+
+		assert.Error(t, f.Run(context.Background()))
+
+		// does not block
+		assert.Equal(t, "mongodb://127.0.0.1:0/", f.MongoDBURI())
+	})
+}
+
+func TestInProcess(t *testing.T) {
+	t.Parallel()
+
+	f, err := New(&Config{
+		Handler:       "sqlite",
+		SQLiteURL:     filepath.Join(t.TempDir(), "ferretdb.sqlite"),
+		InProcessOnly: true,
+	})
+	require.NoError(t, err)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		_ = f.Run(ctx)
+	}()
+
+	client, err := mongo.Connect(ctx, options.Client().ApplyURI(f.MongoDBURI()).SetDialer(f))
+	require.NoError(t, err)
+	t.Cleanup(func() {
+		require.NoError(t, client.Disconnect(context.Background()))
+	})
+
+	require.NoError(t, client.Ping(ctx, nil))
+
+	collection := client.Database("test").Collection(t.Name())
+	_, err = collection.InsertOne(ctx, bson.D{{Key: "_id", Value: int32(1)}, {Key: "v", Value: "foo"}})
+	require.NoError(t, err)
+
+	var doc bson.D
+	require.NoError(t, collection.FindOne(ctx, bson.D{{Key: "_id", Value: int32(1)}}).Decode(&doc))
+	assert.Equal(t, bson.D{{Key: "_id", Value: int32(1)}, {Key: "v", Value: "foo"}}, doc)
+
+	cancel()
+	<-done
+
+	_, err = f.DialContext(context.Background(), "tcp", "in-process:27017")
+	assert.Error(t, err)
+}
+
+func TestMiddlewares(t *testing.T) {
+	t.Parallel()
+
+	// forbids dropping databases
+	noDrop := func(next middleware.Handler) middleware.Handler {
+		return func(ctx context.Context, cmd *middleware.Command) ([]byte, error) {
+			if cmd.Name == "dropDatabase" {
+				db, _ := bson.Raw(cmd.Document).Lookup("$db").StringValueOK()
+				return nil, &middleware.Error{Code: 13, Message: "dropping " + db + " is not allowed"}
+			}
+
+			return next(ctx, cmd)
+		}
+	}
+
+	f, err := New(&Config{
+		Handler:       "sqlite",
+		SQLiteURL:     filepath.Join(t.TempDir(), "ferretdb.sqlite"),
+		InProcessOnly: true,
+		Middlewares:   []middleware.Middleware{noDrop},
+	})
+	require.NoError(t, err)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		_ = f.Run(ctx)
+	}()
+	t.Cleanup(func() {
+		cancel()
+		<-done
+	})
+
+	client, err := mongo.Connect(ctx, options.Client().ApplyURI(f.MongoDBURI()).SetDialer(f))
+	require.NoError(t, err)
+	t.Cleanup(func() {
+		require.NoError(t, client.Disconnect(context.Background()))
+	})
+
+	db := client.Database("test")
+	_, err = db.Collection(t.Name()).InsertOne(ctx, bson.D{{Key: "v", Value: "foo"}})
+	require.NoError(t, err)
+
+	err = db.Drop(ctx)
+	var cmdErr mongo.CommandError
+	require.ErrorAs(t, err, &cmdErr)
+	assert.Equal(t, int32(13), cmdErr.Code)
+	assert.Equal(t, "dropping test is not allowed", cmdErr.Message)
+}
+
+func TestRegisterCommand(t *testing.T) {
+	// register before t.Parallel() so no other tests handle commands at the same time
+	tenantStats := func(ctx context.Context, cmd *middleware.Command) ([]byte, error) {
+		tenant, _ := bson.Raw(cmd.Document).Lookup("tenantStats").StringValueOK()
+		if tenant == "" {
+			return nil, &middleware.Error{Code: 2, Message: "tenant is required"}
+		}
+
+		return bson.Marshal(bson.D{{Key: "tenant", Value: tenant}, {Key: "ok", Value: float64(1)}})
+	}
+
+	noop := func(context.Context, *middleware.Command) ([]byte, error) { return nil, nil }
+
+	err := RegisterCommand("tenantStats", "Returns tenant statistics.", &CommandOpts{ReadOnly: true}, tenantStats)
+	require.NoError(t, err)
+	assert.False(t, common.Commands["tenantStats"].Write)
+
+	// commands modify data by default
+	err = RegisterCommand("tenantReset", "Resets tenant statistics.", nil, noop)
+	require.NoError(t, err)
+	assert.True(t, common.Commands["tenantReset"].Write)
+
+	err = RegisterCommand("tenantStats", "", nil, noop)
+	assert.EqualError(t, err, `command "tenantStats" already exists`)
+
+	err = RegisterCommand("find", "", nil, noop)
+	assert.EqualError(t, err, `command "find" already exists`)
+
+	err = RegisterCommand("noHandler", "", nil, nil)
+	assert.EqualError(t, err, `command "noHandler" has no handler`)
+
+	t.Parallel()
+
+	f, err := New(&Config{
+		Handler:       "sqlite",
+		SQLiteURL:     filepath.Join(t.TempDir(), "ferretdb.sqlite"),
+		InProcessOnly: true,
+	})
+	require.NoError(t, err)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		_ = f.Run(ctx)
+	}()
+	t.Cleanup(func() {
+		cancel()
+		<-done
+	})
+
+	client, err := mongo.Connect(ctx, options.Client().ApplyURI(f.MongoDBURI()).SetDialer(f))
+	require.NoError(t, err)
+	t.Cleanup(func() {
+		require.NoError(t, client.Disconnect(context.Background()))
+	})
+
+	db := client.Database("test")
+
+	var res bson.D
+	err = db.RunCommand(ctx, bson.D{{Key: "tenantStats", Value: "acme"}}).Decode(&res)
+	require.NoError(t, err)
+	assert.Equal(t, bson.D{{Key: "tenant", Value: "acme"}, {Key: "ok", Value: float64(1)}}, res)
+
+	err = db.RunCommand(ctx, bson.D{{Key: "tenantStats", Value: ""}}).Err()
+	var cmdErr mongo.CommandError
+	require.ErrorAs(t, err, &cmdErr)
+	assert.Equal(t, int32(2), cmdErr.Code)
+	assert.Equal(t, "tenant is required", cmdErr.Message)
+
+	raw, err := db.RunCommand(ctx, bson.D{{Key: "listCommands", Value: 1}}).DecodeBytes()
+	require.NoError(t, err)
+	help, err := raw.LookupErr("commands", "tenantStats", "help")
+	require.NoError(t, err)
+	assert.Equal(t, "Returns tenant statistics.", help.StringValue())
+}
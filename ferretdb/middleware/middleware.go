@@ -0,0 +1,125 @@
+// Copyright 2021 FerretDB Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package middleware provides an API for observing and modifying client commands and responses,
+// for example, for custom authorization, multi-tenancy, or request logging.
+//
+// Middlewares could be passed to the embeddable FerretDB (see ferretdb.Config),
+// or registered with Register by packages linked into a custom FerretDB binary
+// and then enabled with -middleware flag.
+package middleware
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"sort"
+	"sync"
+)
+
+// Command represents a client command.
+type Command struct {
+	// Command name, like "find".
+	Name string
+
+	// Command document in BSON encoding, including $db and other generic fields.
+	// Document sequences like insert's documents are included as arrays.
+	// Middleware could replace it before calling the next handler; the name should stay the same.
+	Document []byte
+
+	// Client's address.
+	PeerAddr net.Addr
+
+	// Authenticated username and authentication database; empty if the client is not authenticated.
+	Username string
+	AuthDB   string
+}
+
+// Handler runs the command and returns the response document in BSON encoding.
+type Handler func(ctx context.Context, cmd *Command) ([]byte, error)
+
+// Middleware returns a handler that wraps the next one.
+//
+// It could observe or modify the command and the response,
+// or return an error without calling the next handler.
+// Middlewares are called once per listener; returned handlers are called concurrently.
+type Middleware func(next Handler) Handler
+
+// Error represents an error with MongoDB error code returned to the client.
+//
+// Other errors returned by middlewares are returned to the client as InternalError.
+type Error struct {
+	Code    int32
+	Message string
+}
+
+// Error implements error interface.
+func (e *Error) Error() string {
+	return fmt.Sprintf("%s (%d)", e.Message, e.Code)
+}
+
+// registry contains registered middlewares by name.
+var registry = struct {
+	rw sync.RWMutex
+	m  map[string]Middleware
+}{
+	m: map[string]Middleware{},
+}
+
+// Register registers middleware with the given name, so it could be enabled by name.
+//
+// It should be called from init functions. It panics if the name is already registered.
+func Register(name string, m Middleware) {
+	registry.rw.Lock()
+	defer registry.rw.Unlock()
+
+	if _, ok := registry.m[name]; ok {
+		panic(fmt.Sprintf("middleware %q is already registered", name))
+	}
+
+	registry.m[name] = m
+}
+
+// Get returns registered middlewares with the given names in the same order.
+func Get(names ...string) ([]Middleware, error) {
+	registry.rw.RLock()
+	defer registry.rw.RUnlock()
+
+	res := make([]Middleware, len(names))
+	for i, name := range names {
+		m, ok := registry.m[name]
+		if !ok {
+			return nil, fmt.Errorf("middleware %q is not registered", name)
+		}
+
+		res[i] = m
+	}
+
+	return res, nil
+}
+
+// Registered returns sorted names of registered middlewares.
+func Registered() []string {
+	registry.rw.RLock()
+	defer registry.rw.RUnlock()
+
+	res := make([]string, 0, len(registry.m))
+	for name := range registry.m {
+		res = append(res, name)
+	}
+
+	sort.Strings(res)
+
+	return res
+}
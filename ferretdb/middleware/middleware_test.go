@@ -0,0 +1,48 @@
+// Copyright 2021 FerretDB Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package middleware
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRegistry(t *testing.T) {
+	t.Parallel()
+
+	noop := func(next Handler) Handler { return next }
+
+	Register("test-registry-b", noop)
+	Register("test-registry-a", noop)
+
+	assert.Panics(t, func() { Register("test-registry-a", noop) })
+
+	assert.Subset(t, Registered(), []string{"test-registry-a", "test-registry-b"})
+
+	m, err := Get("test-registry-b", "test-registry-a")
+	require.NoError(t, err)
+	assert.Len(t, m, 2)
+
+	_, err = Get("test-registry-a", "test-registry-missing")
+	assert.EqualError(t, err, `middleware "test-registry-missing" is not registered`)
+}
+
+func TestError(t *testing.T) {
+	t.Parallel()
+
+	assert.EqualError(t, &Error{Code: 13, Message: "Access denied"}, "Access denied (13)")
+}
@@ -0,0 +1,94 @@
+// Copyright 2021 FerretDB Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package integration
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+func TestApplyOps(t *testing.T) {
+	t.Parallel()
+	ctx, collection := Setup(t)
+
+	_, err := collection.InsertMany(ctx, []any{
+		bson.D{{"_id", 1}, {"v", "foo"}},
+		bson.D{{"_id", 2}, {"v", "bar"}},
+	})
+	require.NoError(t, err)
+
+	ns := collection.Database().Name() + "." + collection.Name()
+	ui := primitive.Binary{Subtype: 0x04, Data: make([]byte, 16)}
+
+	var res bson.D
+	err = collection.Database().RunCommand(ctx, bson.D{{"applyOps", bson.A{
+		bson.D{{"op", "i"}, {"ns", ns}, {"ui", ui}, {"o", bson.D{{"_id", 3}, {"v", "baz"}}}},
+		bson.D{{"op", "i"}, {"ns", ns}, {"o", bson.D{{"_id", 1}, {"v", "replaced"}}}},
+		bson.D{{"op", "u"}, {"ns", ns}, {"o2", bson.D{{"_id", 2}}}, {"o", bson.D{
+			{"$v", 2},
+			{"diff", bson.D{{"u", bson.D{{"v", "updated"}}}, {"i", bson.D{{"w", int32(42)}}}}},
+		}}},
+		bson.D{{"op", "u"}, {"ns", ns}, {"o2", bson.D{{"_id", 3}}}, {"o", bson.D{{"$set", bson.D{{"w", int32(13)}}}}}},
+		bson.D{{"op", "d"}, {"ns", ns}, {"o", bson.D{{"_id", 3}}}},
+		bson.D{{"op", "n"}, {"ns", ""}, {"o", bson.D{{"msg", "noop"}}}},
+	}}}).Decode(&res)
+	require.NoError(t, err)
+
+	expected := bson.D{{"applied", int32(6)}, {"results", bson.A{true, true, true, true, true, true}}, {"ok", 1.0}}
+	assert.Equal(t, expected, res)
+
+	cursor, err := collection.Find(ctx, bson.D{}, options.Find().SetSort(bson.D{{"_id", 1}}))
+	require.NoError(t, err)
+
+	var docs []bson.D
+	require.NoError(t, cursor.All(ctx, &docs))
+
+	assert.Equal(t, []bson.D{
+		{{"_id", int32(1)}, {"v", "replaced"}},
+		{{"_id", int32(2)}, {"v", "updated"}, {"w", int32(42)}},
+	}, docs)
+
+	t.Run("UnsupportedOp", func(t *testing.T) {
+		t.Parallel()
+
+		err := collection.Database().RunCommand(ctx, bson.D{{"applyOps", bson.A{
+			bson.D{{"op", "c"}, {"ns", collection.Database().Name() + ".$cmd"}, {"o", bson.D{{"create", "foo"}}}},
+		}}}).Err()
+		AssertEqualError(t, mongo.CommandError{
+			Code:    238,
+			Name:    "NotImplemented",
+			Message: "applyOps: operation type 'c' is not supported; only 'i', 'u', 'd', and 'n' are",
+		}, err)
+	})
+
+	t.Run("InvalidNamespace", func(t *testing.T) {
+		t.Parallel()
+
+		err := collection.Database().RunCommand(ctx, bson.D{{"applyOps", bson.A{
+			bson.D{{"op", "d"}, {"ns", "nodot"}, {"o", bson.D{{"_id", 1}}}},
+		}}}).Err()
+		AssertEqualError(t, mongo.CommandError{
+			Code:    73,
+			Name:    "InvalidNamespace",
+			Message: "applyOps: invalid namespace 'nodot'",
+		}, err)
+	})
+}
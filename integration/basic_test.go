@@ -21,10 +21,12 @@ import (
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
 	"go.mongodb.org/mongo-driver/mongo"
 	"go.mongodb.org/mongo-driver/mongo/options"
 
 	"github.com/FerretDB/FerretDB/integration/shareddata"
+	"github.com/FerretDB/FerretDB/internal/util/testutil"
 )
 
 func TestMostCommandsAreCaseSensitive(t *testing.T) {
@@ -99,10 +101,130 @@ func TestInsertFind(t *testing.T) {
 	}
 }
 
+func TestInsertFindJavaScript(t *testing.T) {
+	t.Parallel()
+	ctx, collection := Setup(t)
+
+	docs := []any{
+		bson.D{{"_id", "js"}, {"value", primitive.JavaScript("function() { return 42; }")}},
+		bson.D{{"_id", "js-scope"}, {"value", primitive.CodeWithScope{
+			Code:  "function() { return x; }",
+			Scope: bson.D{{"x", int32(42)}},
+		}}},
+	}
+	_, err := collection.InsertMany(ctx, docs)
+	require.NoError(t, err)
+
+	for alias, expected := range map[string]bson.D{
+		"javascript":          docs[0].(bson.D),
+		"javascriptWithScope": docs[1].(bson.D),
+	} {
+		alias, expected := alias, expected
+		t.Run(alias, func(t *testing.T) {
+			t.Parallel()
+
+			cursor, err := collection.Find(ctx, bson.D{{"value", bson.D{{"$type", alias}}}})
+			require.NoError(t, err)
+
+			var actual []bson.D
+			err = cursor.All(ctx, &actual)
+			require.NoError(t, err)
+			require.Len(t, actual, 1)
+			AssertEqualDocuments(t, expected, actual[0])
+		})
+	}
+}
+
+func TestInsertFindDeprecated(t *testing.T) {
+	t.Parallel()
+	ctx, collection := Setup(t)
+
+	docs := []any{
+		bson.D{{"_id", "undefined"}, {"value", primitive.Undefined{}}},
+		bson.D{{"_id", "symbol"}, {"value", primitive.Symbol("foo")}},
+		bson.D{{"_id", "dbPointer"}, {"value", primitive.DBPointer{
+			DB:      "db.collection",
+			Pointer: primitive.ObjectID{0x62, 0x56, 0xc5, 0xba, 0x18, 0x2d, 0x4f, 0x28, 0x31, 0x7d, 0x71, 0x0b},
+		}}},
+	}
+	_, err := collection.InsertMany(ctx, docs)
+	require.NoError(t, err)
+
+	for alias, expected := range map[string]bson.D{
+		"undefined": docs[0].(bson.D),
+		"symbol":    docs[1].(bson.D),
+		"dbPointer": docs[2].(bson.D),
+	} {
+		alias, expected := alias, expected
+		t.Run(alias, func(t *testing.T) {
+			t.Parallel()
+
+			cursor, err := collection.Find(ctx, bson.D{{"value", bson.D{{"$type", alias}}}})
+			require.NoError(t, err)
+
+			var actual []bson.D
+			err = cursor.All(ctx, &actual)
+			require.NoError(t, err)
+			require.Len(t, actual, 1)
+			AssertEqualDocuments(t, expected, actual[0])
+		})
+	}
+
+	t.Run("SymbolAsString", func(t *testing.T) {
+		t.Parallel()
+
+		cursor, err := collection.Find(ctx, bson.D{{"value", "foo"}})
+		require.NoError(t, err)
+
+		var actual []bson.D
+		err = cursor.All(ctx, &actual)
+		require.NoError(t, err)
+		require.Len(t, actual, 1)
+		AssertEqualDocuments(t, docs[1].(bson.D), actual[0])
+	})
+}
+
 //nolint:paralleltest // we test a global list of databases
+func TestInsertManyBatch(t *testing.T) {
+	t.Parallel()
+
+	for name, tc := range map[string]struct {
+		ordered bool
+		n       int64
+	}{
+		"Ordered":   {ordered: true, n: 12},
+		"Unordered": {ordered: false, n: 19},
+	} {
+		name, tc := name, tc
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+			ctx, collection := Setup(t)
+
+			// large enough to be inserted with a single COPY
+			docs := make([]any, 20)
+			for i := range docs {
+				docs[i] = bson.D{{"_id", int32(i)}, {"v", fmt.Sprintf("foo%d", i)}}
+			}
+			docs[12] = bson.D{{"_id", int32(12)}, {"$v", "invalid"}}
+
+			_, err := collection.InsertMany(ctx, docs, options.InsertMany().SetOrdered(tc.ordered))
+
+			var we mongo.BulkWriteException
+			require.ErrorAs(t, err, &we)
+			require.Len(t, we.WriteErrors, 1)
+			assert.Equal(t, 12, we.WriteErrors[0].Index)
+			assert.Equal(t, 2, we.WriteErrors[0].Code)
+
+			n, err := collection.CountDocuments(ctx, bson.D{})
+			require.NoError(t, err)
+			assert.Equal(t, tc.n, n)
+		})
+	}
+}
+
 func TestFindCommentMethod(t *testing.T) {
 	ctx, collection := Setup(t, shareddata.Scalars)
-	name := collection.Name()
+	name := collection.Database().Name()
 	databaseNames, err := collection.Database().Client().ListDatabaseNames(ctx, bson.D{})
 	require.NoError(t, err)
 	comment := "*/ 1; DROP SCHEMA " + name + " CASCADE -- "
@@ -117,7 +239,7 @@ func TestFindCommentMethod(t *testing.T) {
 //nolint:paralleltest // we test a global list of databases
 func TestFindCommentQuery(t *testing.T) {
 	ctx, collection := Setup(t, shareddata.Scalars)
-	name := collection.Name()
+	name := collection.Database().Name()
 	databaseNames, err := collection.Database().Client().ListDatabaseNames(ctx, bson.D{})
 	require.NoError(t, err)
 	comment := "*/ 1; DROP SCHEMA " + name + " CASCADE -- "
@@ -147,3 +269,29 @@ func TestLongCollectionName(t *testing.T) {
 	assert.Contains(t, names, longCollectionName)
 	assert.Contains(t, names, sixtyThreeCharsCollectionName)
 }
+
+func TestInsertGenerated(t *testing.T) {
+	t.Parallel()
+	ctx, collection := Setup(t)
+
+	g := testutil.NewGenerator(t, 0, &testutil.GeneratorOpts{MaxDepth: 3})
+
+	expected := make([]bson.D, 100)
+	for i, doc := range g.Documents(len(expected)) {
+		expected[i] = Unconvert(t, doc).(bson.D)
+
+		_, err := collection.InsertOne(ctx, expected[i])
+		require.NoError(t, err)
+	}
+
+	cursor, err := collection.Find(ctx, bson.D{}, options.Find().SetSort(bson.D{{"_id", 1}}))
+	require.NoError(t, err)
+
+	var actual []bson.D
+	require.NoError(t, cursor.All(ctx, &actual))
+	require.Len(t, actual, len(expected))
+
+	for i, doc := range expected {
+		AssertEqualDocuments(t, doc, actual[i])
+	}
+}
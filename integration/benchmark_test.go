@@ -0,0 +1,176 @@
+// Copyright 2021 FerretDB Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package integration
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+
+	"github.com/FerretDB/FerretDB/internal/util/testutil"
+)
+
+// benchmarkDocuments is the number of distinct generated documents used by benchmarks.
+const benchmarkDocuments = 100
+
+// benchmarkSetup setups the benchmark and returns generated documents of the given size
+// without inserting them.
+func benchmarkSetup(b *testing.B, opts *testutil.GeneratorOpts) (*mongo.Collection, []bson.D) {
+	b.Helper()
+
+	_, collection := Setup(b)
+
+	g := testutil.NewGenerator(b, 1, opts)
+
+	docs := make([]bson.D, benchmarkDocuments)
+	for i, doc := range g.Documents(len(docs)) {
+		docs[i] = Unconvert(b, doc).(bson.D)
+	}
+
+	return collection, docs
+}
+
+// reportThroughput reports the number of processed documents per second since start.
+func reportThroughput(b *testing.B, start time.Time, docs int) {
+	b.Helper()
+
+	b.ReportMetric(float64(docs)/time.Since(start).Seconds(), "docs/s")
+}
+
+func BenchmarkInsert(b *testing.B) {
+	for _, size := range testutil.GeneratorSizes {
+		size := size
+
+		b.Run(size.Name, func(b *testing.B) {
+			collection, docs := benchmarkSetup(b, size.Opts)
+			ctx := testutil.Ctx(b)
+
+			var err error
+
+			b.ReportAllocs()
+			b.ResetTimer()
+			start := time.Now()
+
+			for i := 0; i < b.N && err == nil; i++ {
+				// generated documents are reused with unique _id
+				doc := append(bson.D{{"_id", int32(i)}}, docs[i%len(docs)][1:]...)
+				_, err = collection.InsertOne(ctx, doc)
+			}
+
+			b.StopTimer()
+			require.NoError(b, err)
+			reportThroughput(b, start, b.N)
+		})
+	}
+}
+
+func BenchmarkFind(b *testing.B) {
+	for _, size := range testutil.GeneratorSizes {
+		size := size
+
+		b.Run(size.Name, func(b *testing.B) {
+			b.Run("ByID", func(b *testing.B) {
+				collection, docs := benchmarkSetup(b, size.Opts)
+				ctx := testutil.Ctx(b)
+				insertBenchmarkDocuments(b, collection, docs)
+
+				var res bson.D
+				var err error
+
+				b.ReportAllocs()
+				b.ResetTimer()
+				start := time.Now()
+
+				for i := 0; i < b.N && err == nil; i++ {
+					err = collection.FindOne(ctx, bson.D{{"_id", docs[i%len(docs)][0].Value}}).Decode(&res)
+				}
+
+				b.StopTimer()
+				require.NoError(b, err)
+				reportThroughput(b, start, b.N)
+			})
+
+			b.Run("All", func(b *testing.B) {
+				collection, docs := benchmarkSetup(b, size.Opts)
+				ctx := testutil.Ctx(b)
+				insertBenchmarkDocuments(b, collection, docs)
+
+				var res []bson.D
+				var cursor *mongo.Cursor
+				var err error
+
+				b.ReportAllocs()
+				b.ResetTimer()
+				start := time.Now()
+
+				for i := 0; i < b.N && err == nil; i++ {
+					if cursor, err = collection.Find(ctx, bson.D{}); err == nil {
+						err = cursor.All(ctx, &res)
+					}
+				}
+
+				b.StopTimer()
+				require.NoError(b, err)
+				require.Len(b, res, len(docs))
+				reportThroughput(b, start, b.N*len(docs))
+			})
+		})
+	}
+}
+
+func BenchmarkUpdate(b *testing.B) {
+	for _, size := range testutil.GeneratorSizes {
+		size := size
+
+		b.Run(size.Name, func(b *testing.B) {
+			collection, docs := benchmarkSetup(b, size.Opts)
+			ctx := testutil.Ctx(b)
+			insertBenchmarkDocuments(b, collection, docs)
+
+			var res *mongo.UpdateResult
+			var err error
+
+			b.ReportAllocs()
+			b.ResetTimer()
+			start := time.Now()
+
+			for i := 0; i < b.N && err == nil; i++ {
+				filter := bson.D{{"_id", docs[i%len(docs)][0].Value}}
+				res, err = collection.UpdateOne(ctx, filter, bson.D{{"$set", bson.D{{"v", int32(i)}}}})
+			}
+
+			b.StopTimer()
+			require.NoError(b, err)
+			require.Equal(b, int64(1), res.MatchedCount)
+			reportThroughput(b, start, b.N)
+		})
+	}
+}
+
+// insertBenchmarkDocuments inserts given documents without affecting benchmark results.
+func insertBenchmarkDocuments(b *testing.B, collection *mongo.Collection, docs []bson.D) {
+	b.Helper()
+
+	b.StopTimer()
+	defer b.StartTimer()
+
+	for _, doc := range docs {
+		_, err := collection.InsertOne(testutil.Ctx(b), doc)
+		require.NoError(b, err)
+	}
+}
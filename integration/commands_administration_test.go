@@ -26,8 +26,10 @@ import (
 	"go.mongodb.org/mongo-driver/bson"
 	"go.mongodb.org/mongo-driver/bson/primitive"
 	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
 
 	"github.com/FerretDB/FerretDB/integration/shareddata"
+	"github.com/FerretDB/FerretDB/internal/handlers"
 	"github.com/FerretDB/FerretDB/internal/types"
 	"github.com/FerretDB/FerretDB/internal/util/must"
 	"github.com/FerretDB/FerretDB/internal/util/testutil"
@@ -71,10 +73,9 @@ func TestCommandsAdministrationCreateDropList(t *testing.T) {
 
 	err = db.CreateCollection(ctx, name)
 	expectedErr = mongo.CommandError{
-		Code: 48,
-		Name: "NamespaceExists",
-		Message: `Collection already exists. ` +
-			`NS: testcommandsadministrationcreatedroplist.testcommandsadministrationcreatedroplist`,
+		Code:    48,
+		Name:    "NamespaceExists",
+		Message: `Collection already exists. NS: ` + db.Name() + `.` + name,
 	}
 	AssertEqualError(t, expectedErr, err)
 
@@ -598,6 +599,372 @@ func TestCommandsAdministrationGetParameter(t *testing.T) {
 	}
 }
 
+func TestCommandsAdministrationSetParameter(t *testing.T) {
+	t.Parallel()
+	ctx, collection := Setup(t)
+
+	admin := collection.Database().Client().Database("admin")
+
+	var actual bson.D
+	err := admin.RunCommand(ctx, bson.D{{"setParameter", 1}, {"redactClientLogData", true}}).Decode(&actual)
+	require.NoError(t, err)
+
+	t.Cleanup(func() {
+		err = admin.RunCommand(ctx, bson.D{{"setParameter", 1}, {"redactClientLogData", false}}).Err()
+		require.NoError(t, err)
+	})
+
+	doc := ConvertDocument(t, actual)
+	assert.Equal(t, float64(1), must.NotFail(doc.Get("ok")))
+	assert.Contains(t, doc.Keys(), "was")
+
+	err = admin.RunCommand(ctx, bson.D{{"getParameter", 1}, {"redactClientLogData", 1}}).Decode(&actual)
+	require.NoError(t, err)
+	assert.Equal(t, true, must.NotFail(ConvertDocument(t, actual).Get("redactClientLogData")))
+
+	err = admin.RunCommand(ctx, bson.D{{"setParameter", 1}, {"noSuchParameter", true}}).Err()
+	AssertEqualError(t, mongo.CommandError{
+		Code:    72,
+		Name:    "InvalidOptions",
+		Message: `Attempted to set unknown parameter 'noSuchParameter' via setParameter command`,
+	}, err)
+
+	err = collection.Database().RunCommand(ctx, bson.D{{"setParameter", 1}, {"redactClientLogData", true}}).Err()
+	AssertEqualError(t, mongo.CommandError{
+		Code:    13,
+		Name:    "Unauthorized",
+		Message: `setParameter may only be run against the admin database.`,
+	}, err)
+}
+
+func TestCommandsAdministrationSetParameterLogComponentVerbosity(t *testing.T) {
+	t.Parallel()
+	ctx, collection := Setup(t)
+
+	admin := collection.Database().Client().Database("admin")
+
+	var actual bson.D
+	err := admin.RunCommand(ctx, bson.D{{"setParameter", 1}, {"logComponentVerbosity", bson.D{{"verbosity", 1}}}}).Decode(&actual)
+	require.NoError(t, err)
+
+	doc := ConvertDocument(t, actual)
+	assert.Equal(t, float64(1), must.NotFail(doc.Get("ok")))
+
+	was := must.NotFail(must.NotFail(doc.Get("was")).(*types.Document).Get("verbosity"))
+
+	t.Cleanup(func() {
+		err = admin.RunCommand(ctx, bson.D{{"setParameter", 1}, {"logComponentVerbosity", bson.D{{"verbosity", was}}}}).Err()
+		require.NoError(t, err)
+	})
+
+	err = admin.RunCommand(ctx, bson.D{{"getParameter", 1}, {"logComponentVerbosity", 1}}).Decode(&actual)
+	require.NoError(t, err)
+
+	verbosity := must.NotFail(ConvertDocument(t, actual).Get("logComponentVerbosity")).(*types.Document)
+	assert.Equal(t, int32(1), must.NotFail(verbosity.Get("verbosity")))
+}
+
+// TestCommandsAdministrationFsync is not parallel, as the lock blocks writes of all tests.
+func TestCommandsAdministrationFsync(t *testing.T) {
+	ctx, collection := Setup(t)
+
+	admin := collection.Database().Client().Database("admin")
+
+	var actual bson.D
+	err := admin.RunCommand(ctx, bson.D{{"fsync", 1}}).Decode(&actual)
+	require.NoError(t, err)
+	assert.Equal(t, float64(1), must.NotFail(ConvertDocument(t, actual).Get("ok")))
+
+	err = admin.RunCommand(ctx, bson.D{{"fsync", 1}, {"lock", true}}).Decode(&actual)
+	require.NoError(t, err)
+
+	var unlocked bool
+	t.Cleanup(func() {
+		if !unlocked {
+			require.NoError(t, admin.RunCommand(ctx, bson.D{{"fsyncUnlock", 1}}).Err())
+		}
+	})
+
+	doc := ConvertDocument(t, actual)
+	assert.Equal(t, int64(1), must.NotFail(doc.Get("lockCount")))
+
+	err = admin.RunCommand(ctx, bson.D{{"currentOp", 1}}).Decode(&actual)
+	require.NoError(t, err)
+	assert.Equal(t, true, must.NotFail(ConvertDocument(t, actual).Get("fsyncLock")))
+
+	inserted := make(chan error, 1)
+	go func() {
+		_, err := collection.InsertOne(ctx, bson.D{{"_id", "fsync"}})
+		inserted <- err
+	}()
+
+	select {
+	case err = <-inserted:
+		t.Fatalf("insert was not blocked: %v", err)
+	case <-time.After(200 * time.Millisecond):
+	}
+
+	err = admin.RunCommand(ctx, bson.D{{"fsyncUnlock", 1}}).Decode(&actual)
+	require.NoError(t, err)
+	unlocked = true
+
+	doc = ConvertDocument(t, actual)
+	assert.Equal(t, int64(0), must.NotFail(doc.Get("lockCount")))
+
+	require.NoError(t, <-inserted)
+
+	err = admin.RunCommand(ctx, bson.D{{"fsyncUnlock", 1}}).Err()
+	AssertEqualError(t, mongo.CommandError{
+		Code:    20,
+		Name:    "IllegalOperation",
+		Message: `fsyncUnlock called when not locked`,
+	}, err)
+
+	err = collection.Database().RunCommand(ctx, bson.D{{"fsync", 1}}).Err()
+	AssertEqualError(t, mongo.CommandError{
+		Code:    13,
+		Name:    "Unauthorized",
+		Message: `fsync may only be run against the admin database.`,
+	}, err)
+}
+
+func TestCommandsAdministrationSetFeatureCompatibilityVersion(t *testing.T) {
+	t.Parallel()
+	ctx, collection, _ := SetupWithOpts(t, &SetupOpts{
+		Requires: []handlers.Feature{handlers.FeatureFCV},
+	})
+
+	admin := collection.Database().Client().Database("admin")
+
+	// only the latest version is set, as lower versions change behavior for concurrent tests
+	var actual bson.D
+	err := admin.RunCommand(ctx, bson.D{{"setFeatureCompatibilityVersion", "5.0"}}).Decode(&actual)
+	require.NoError(t, err)
+	assert.Equal(t, float64(1), must.NotFail(ConvertDocument(t, actual).Get("ok")))
+
+	err = admin.RunCommand(ctx, bson.D{{"getParameter", 1}, {"featureCompatibilityVersion", 1}}).Decode(&actual)
+	require.NoError(t, err)
+
+	fcv := must.NotFail(ConvertDocument(t, actual).Get("featureCompatibilityVersion")).(*types.Document)
+	assert.Equal(t, "5.0", must.NotFail(fcv.Get("version")))
+
+	err = admin.RunCommand(ctx, bson.D{{"setFeatureCompatibilityVersion", "4.2"}}).Err()
+	AssertEqualError(t, mongo.CommandError{
+		Code:    2,
+		Name:    "BadValue",
+		Message: `Invalid feature compatibility version value '4.2'. Expected one of the following versions: '5.0', '4.4'`,
+	}, err)
+
+	err = collection.Database().RunCommand(ctx, bson.D{{"setFeatureCompatibilityVersion", "5.0"}}).Err()
+	AssertEqualError(t, mongo.CommandError{
+		Code:    13,
+		Name:    "Unauthorized",
+		Message: `setFeatureCompatibilityVersion may only be run against the admin database.`,
+	}, err)
+}
+
+func TestCommandsAdministrationCurrentOp(t *testing.T) {
+	t.Parallel()
+	ctx, collection := Setup(t)
+
+	admin := collection.Database().Client().Database("admin")
+
+	var actual bson.D
+	err := admin.RunCommand(ctx, bson.D{{"currentOp", 1}}).Decode(&actual)
+	require.NoError(t, err)
+
+	doc := ConvertDocument(t, actual)
+	assert.Equal(t, float64(1), must.NotFail(doc.Get("ok")))
+
+	_, ok := must.NotFail(doc.Get("inprog")).(*types.Array)
+	assert.True(t, ok)
+
+	err = collection.Database().RunCommand(ctx, bson.D{{"currentOp", 1}}).Err()
+	AssertEqualError(t, mongo.CommandError{
+		Code:    13,
+		Name:    "Unauthorized",
+		Message: `currentOp may only be run against the admin database.`,
+	}, err)
+}
+
+func TestCommandsAdministrationListIndexes(t *testing.T) {
+	t.Parallel()
+	ctx, collection, _ := SetupWithOpts(t, &SetupOpts{
+		Providers: []shareddata.Provider{shareddata.Scalars},
+		Requires:  []handlers.Feature{handlers.FeatureIndexes},
+	})
+
+	var actual bson.D
+	err := collection.Database().RunCommand(ctx, bson.D{{"listIndexes", collection.Name()}}).Decode(&actual)
+	require.NoError(t, err)
+
+	doc := ConvertDocument(t, actual)
+	assert.Equal(t, float64(1), must.NotFail(doc.Get("ok")))
+
+	cursor := must.NotFail(doc.Get("cursor")).(*types.Document)
+	assert.Equal(t, int64(0), must.NotFail(cursor.Get("id")))
+	assert.Equal(t, collection.Database().Name()+"."+collection.Name(), must.NotFail(cursor.Get("ns")))
+
+	firstBatch := must.NotFail(cursor.Get("firstBatch")).(*types.Array)
+	require.GreaterOrEqual(t, firstBatch.Len(), 1)
+
+	expected := must.NotFail(types.NewDocument(
+		"v", int32(2),
+		"key", must.NotFail(types.NewDocument("_id", int32(1))),
+		"name", "_id_",
+	))
+	assert.Equal(t, expected, must.NotFail(firstBatch.Get(0)))
+
+	// other indexes could be only internal indexes created by FerretDB
+	for i := 1; i < firstBatch.Len(); i++ {
+		index := must.NotFail(firstBatch.Get(i)).(*types.Document)
+		assert.Equal(t, true, must.NotFail(index.Get("ferretdbInternal")), "%v", index)
+	}
+
+	err = collection.Database().RunCommand(ctx, bson.D{{"listIndexes", "no-such-collection"}}).Err()
+	AssertEqualError(t, mongo.CommandError{
+		Code:    26,
+		Name:    "NamespaceNotFound",
+		Message: `ns does not exist: ` + collection.Database().Name() + `.no-such-collection`,
+	}, err)
+}
+
+func TestCommandsAdministrationCreateIndexes(t *testing.T) {
+	t.Parallel()
+	ctx, collection, _ := SetupWithOpts(t, &SetupOpts{
+		Providers: []shareddata.Provider{shareddata.Scalars},
+		Requires:  []handlers.Feature{handlers.FeatureIndexes},
+	})
+
+	command := bson.D{
+		{"createIndexes", collection.Name()},
+		{"indexes", bson.A{bson.D{{"key", bson.D{{"v", int32(1)}}}, {"name", "v_1"}}}},
+	}
+
+	var actual bson.D
+	err := collection.Database().RunCommand(ctx, command).Decode(&actual)
+	require.NoError(t, err)
+
+	expected := must.NotFail(types.NewDocument(
+		"numIndexesBefore", int32(1),
+		"numIndexesAfter", int32(2),
+		"createdCollectionAutomatically", false,
+		"ok", float64(1),
+	))
+	assert.Equal(t, expected, ConvertDocument(t, actual))
+
+	err = collection.Database().RunCommand(ctx, command).Decode(&actual)
+	require.NoError(t, err)
+
+	expected = must.NotFail(types.NewDocument(
+		"numIndexesBefore", int32(2),
+		"numIndexesAfter", int32(2),
+		"createdCollectionAutomatically", false,
+		"note", "all indexes already exist",
+		"ok", float64(1),
+	))
+	assert.Equal(t, expected, ConvertDocument(t, actual))
+
+	cursor, err := collection.Indexes().List(ctx)
+	require.NoError(t, err)
+
+	var indexes []bson.D
+	require.NoError(t, cursor.All(ctx, &indexes))
+
+	var names []string
+	for _, index := range indexes {
+		doc := ConvertDocument(t, index)
+		if doc.Has("ferretdbInternal") {
+			continue
+		}
+		names = append(names, must.NotFail(doc.Get("name")).(string))
+	}
+	assert.Equal(t, []string{"_id_", "v_1"}, names)
+
+	command = bson.D{
+		{"createIndexes", collection.Name()},
+		{"indexes", bson.A{bson.D{{"key", bson.D{{"v", int32(-1)}}}, {"name", "v_1"}}}},
+	}
+	err = collection.Database().RunCommand(ctx, command).Err()
+	AssertEqualError(t, mongo.CommandError{
+		Code:    86,
+		Name:    "IndexKeySpecsConflict",
+		Message: `An existing index has the same name as the requested index. Requested index name: v_1`,
+	}, err)
+}
+
+func TestCommandsAdministrationCreateOptions(t *testing.T) {
+	t.Parallel()
+
+	clusteredIndex := bson.D{{"key", bson.D{{"_id", int32(1)}}}, {"unique", true}}
+
+	for name, tc := range map[string]struct {
+		options bson.D
+		err     mongo.CommandError
+	}{
+		"ClusteredIndexKey": {
+			options: bson.D{{"clusteredIndex", bson.D{{"key", bson.D{{"v", int32(1)}}}, {"unique", true}}}},
+			err: mongo.CommandError{
+				Code:    197,
+				Name:    "InvalidIndexSpecificationOption",
+				Message: "The clusteredIndex option is only supported for key: {_id: 1}",
+			},
+		},
+		"ClusteredIndexNotUnique": {
+			options: bson.D{{"clusteredIndex", bson.D{{"key", bson.D{{"_id", int32(1)}}}, {"unique", false}}}},
+			err: mongo.CommandError{
+				Code:    197,
+				Name:    "InvalidIndexSpecificationOption",
+				Message: "The clusteredIndex option requires unique: true",
+			},
+		},
+		"ExpireNotClustered": {
+			options: bson.D{{"expireAfterSeconds", int32(10)}},
+			err: mongo.CommandError{
+				Code: 72,
+				Name: "InvalidOptions",
+				Message: "'expireAfterSeconds' option is only supported on clustered collections " +
+					"or timeseries collections",
+			},
+		},
+		"ExpireNegative": {
+			options: bson.D{{"clusteredIndex", clusteredIndex}, {"expireAfterSeconds", int32(-1)}},
+			err: mongo.CommandError{
+				Code:    72,
+				Name:    "InvalidOptions",
+				Message: "Invalid value for 'expireAfterSeconds': -1",
+			},
+		},
+		"TimeseriesSameFields": {
+			options: bson.D{{"timeseries", bson.D{{"timeField", "t"}, {"metaField", "t"}}}},
+			err: mongo.CommandError{
+				Code:    72,
+				Name:    "InvalidOptions",
+				Message: "The 'metaField' option cannot be the same as 'timeField'",
+			},
+		},
+		"TimeseriesClustered": {
+			options: bson.D{{"clusteredIndex", clusteredIndex}, {"timeseries", bson.D{{"timeField", "t"}}}},
+			err: mongo.CommandError{
+				Code:    72,
+				Name:    "InvalidOptions",
+				Message: "Time-series collections cannot be created with a clustered index",
+			},
+		},
+	} {
+		name, tc := name, tc
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			ctx, collection := Setup(t)
+
+			command := append(bson.D{{"create", collection.Name() + "_new"}}, tc.options...)
+			err := collection.Database().RunCommand(ctx, command).Err()
+			AssertEqualError(t, tc.err, err)
+		})
+	}
+}
+
 func TestCommandsAdministrationBuildInfo(t *testing.T) {
 	t.Parallel()
 	ctx, collection := Setup(t)
@@ -633,7 +1000,9 @@ func TestCommandsAdministrationBuildInfo(t *testing.T) {
 
 func TestCommandsAdministrationCollStatsEmpty(t *testing.T) {
 	t.Parallel()
-	ctx, collection := Setup(t)
+	ctx, collection, _ := SetupWithOpts(t, &SetupOpts{
+		Requires: []handlers.Feature{handlers.FeatureCollStats},
+	})
 
 	var actual bson.D
 	command := bson.D{{"collStats", collection.Name()}}
@@ -654,7 +1023,10 @@ func TestCommandsAdministrationCollStatsEmpty(t *testing.T) {
 
 func TestCommandsAdministrationCollStats(t *testing.T) {
 	t.Parallel()
-	ctx, collection := Setup(t, shareddata.Scalars, shareddata.Composites)
+	ctx, collection, _ := SetupWithOpts(t, &SetupOpts{
+		Providers: []shareddata.Provider{shareddata.Scalars, shareddata.Composites},
+		Requires:  []handlers.Feature{handlers.FeatureCollStats},
+	})
 
 	var actual bson.D
 	command := bson.D{{"collStats", collection.Name()}}
@@ -674,7 +1046,10 @@ func TestCommandsAdministrationCollStats(t *testing.T) {
 
 func TestCommandsAdministrationDataSize(t *testing.T) {
 	t.Parallel()
-	ctx, collection := Setup(t, shareddata.Scalars, shareddata.Composites)
+	ctx, collection, _ := SetupWithOpts(t, &SetupOpts{
+		Providers: []shareddata.Provider{shareddata.Scalars, shareddata.Composites},
+		Requires:  []handlers.Feature{handlers.FeatureDataSize},
+	})
 
 	var actual bson.D
 	command := bson.D{{"dataSize", collection.Database().Name() + "." + collection.Name()}}
@@ -690,7 +1065,9 @@ func TestCommandsAdministrationDataSize(t *testing.T) {
 
 func TestCommandsAdministrationDataSizeCollectionNotExist(t *testing.T) {
 	t.Parallel()
-	ctx, collection := Setup(t)
+	ctx, collection, _ := SetupWithOpts(t, &SetupOpts{
+		Requires: []handlers.Feature{handlers.FeatureDataSize},
+	})
 
 	var actual bson.D
 	command := bson.D{{"dataSize", "some-database.some-collection"}}
@@ -707,7 +1084,9 @@ func TestCommandsAdministrationDataSizeCollectionNotExist(t *testing.T) {
 
 func TestCommandsAdministrationDBStatsEmpty(t *testing.T) {
 	t.Parallel()
-	ctx, collection := Setup(t)
+	ctx, collection, _ := SetupWithOpts(t, &SetupOpts{
+		Requires: []handlers.Feature{handlers.FeatureDBStats},
+	})
 
 	var actual bson.D
 	command := bson.D{{"dbStats", int32(1)}}
@@ -733,7 +1112,10 @@ func TestCommandsAdministrationDBStatsEmpty(t *testing.T) {
 
 func TestCommandsAdministrationDBStatsWithScale(t *testing.T) {
 	t.Parallel()
-	ctx, collection := Setup(t, shareddata.Scalars, shareddata.Composites)
+	ctx, collection, _ := SetupWithOpts(t, &SetupOpts{
+		Providers: []shareddata.Provider{shareddata.Scalars, shareddata.Composites},
+		Requires:  []handlers.Feature{handlers.FeatureDBStats},
+	})
 
 	var actual bson.D
 	command := bson.D{{"dbStats", int32(1)}, {"scale", float64(1_000)}}
@@ -794,6 +1176,58 @@ func TestCommandsAdministrationServerStatus(t *testing.T) {
 	assert.Equal(t, int32(0), must.NotFail(catalogStats.Get("internalViews")))
 }
 
+func TestCommandsAdministrationServerStatusCursors(t *testing.T) {
+	t.Parallel()
+	ctx, collection := Setup(t)
+
+	_, err := collection.InsertMany(ctx, []any{bson.D{{"_id", 1}}, bson.D{{"_id", 2}}, bson.D{{"_id", 3}}})
+	require.NoError(t, err)
+
+	// cursors of parallel tests are counted too, so only lower bounds are checked
+	metrics := func() *types.Document {
+		t.Helper()
+
+		var res bson.D
+		err := collection.Database().RunCommand(ctx, bson.D{{"serverStatus", int32(1)}}).Decode(&res)
+		require.NoError(t, err)
+
+		doc := ConvertDocument(t, res)
+		cursor, err := doc.GetByPath(types.NewPathFromString("metrics.cursor"))
+		require.NoError(t, err)
+
+		return cursor.(*types.Document)
+	}
+
+	get := func(doc *types.Document, path string) int64 {
+		t.Helper()
+
+		v, err := doc.GetByPath(types.NewPathFromString(path))
+		require.NoError(t, err)
+
+		return v.(int64)
+	}
+
+	before := metrics()
+
+	opts := options.Find().SetBatchSize(1).SetNoCursorTimeout(true)
+	cursor, err := collection.Find(ctx, bson.D{}, opts)
+	require.NoError(t, err)
+	require.True(t, cursor.Next(ctx))
+
+	open := metrics()
+	assert.GreaterOrEqual(t, get(open, "totalOpened"), get(before, "totalOpened")+1)
+	assert.GreaterOrEqual(t, get(open, "open.noTimeout"), int64(1))
+	assert.GreaterOrEqual(t, get(open, "open.total"), int64(1))
+
+	// killCursors is sent
+	require.NoError(t, cursor.Close(ctx))
+
+	// that field is not present in MongoDB
+	if *portF == 0 {
+		assert.GreaterOrEqual(t, get(metrics(), "killed"), get(before, "killed")+1)
+	}
+}
+
 // TestCommandsAdministrationWhatsMyURI tests the `whatsmyuri` command.
 // It connects two clients to the same server and checks that `whatsmyuri` returns different ports for these clients.
 func TestCommandsAdministrationWhatsMyURI(t *testing.T) {
@@ -0,0 +1,146 @@
+// Copyright 2021 FerretDB Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package integration
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+
+	"github.com/FerretDB/FerretDB/integration/shareddata"
+	"github.com/FerretDB/FerretDB/internal/handlers"
+	"github.com/FerretDB/FerretDB/internal/util/testutil"
+)
+
+func TestCommandsAuthenticationSCRAM(t *testing.T) {
+	t.Parallel()
+	ctx, collection, port := SetupWithOpts(t, &SetupOpts{
+		Requires: []handlers.Feature{handlers.FeatureAuthentication, handlers.FeatureUserManagement},
+	})
+	db := collection.Database()
+	username := testutil.TableName(t)
+
+	err := db.RunCommand(ctx, bson.D{
+		{"createUser", username},
+		{"pwd", "password"},
+		{"roles", bson.A{}},
+	}).Err()
+	require.NoError(t, err)
+	t.Cleanup(func() {
+		require.NoError(t, db.RunCommand(ctx, bson.D{{"dropUser", username}}).Err())
+	})
+
+	err = db.RunCommand(ctx, bson.D{
+		{"createUser", username},
+		{"pwd", "password"},
+		{"roles", bson.A{}},
+	}).Err()
+	expectedErr := mongo.CommandError{
+		Code:    51003,
+		Name:    "Location51003",
+		Message: fmt.Sprintf(`User "%s@%s" already exists`, username, db.Name()),
+	}
+	AssertEqualError(t, expectedErr, err)
+
+	var res bson.D
+	err = db.RunCommand(ctx, bson.D{{"usersInfo", username}}).Decode(&res)
+	require.NoError(t, err)
+	users := res.Map()["users"].(bson.A)
+	require.Len(t, users, 1)
+	user := users[0].(bson.D).Map()
+	assert.Equal(t, bson.A{"SCRAM-SHA-1", "SCRAM-SHA-256"}, user["mechanisms"])
+	assert.NotContains(t, user, "credentials")
+
+	for _, mechanism := range []string{"SCRAM-SHA-1", "SCRAM-SHA-256"} {
+		mechanism := mechanism
+
+		t.Run(mechanism, func(t *testing.T) {
+			t.Parallel()
+
+			uri := fmt.Sprintf("mongodb://127.0.0.1:%d", port)
+			credential := options.Credential{
+				AuthMechanism: mechanism,
+				AuthSource:    db.Name(),
+				Username:      username,
+				Password:      "password",
+			}
+
+			client, err := mongo.Connect(ctx, options.Client().ApplyURI(uri).SetAuth(credential))
+			require.NoError(t, err)
+			t.Cleanup(func() {
+				require.NoError(t, client.Disconnect(ctx))
+			})
+
+			var res bson.D
+			err = client.Database(db.Name()).RunCommand(ctx, bson.D{{"connectionStatus", 1}}).Decode(&res)
+			require.NoError(t, err)
+			authInfo := res.Map()["authInfo"].(bson.D).Map()
+			expected := bson.A{bson.D{{"user", username}, {"db", db.Name()}}}
+			assert.Equal(t, expected, authInfo["authenticatedUsers"])
+
+			credential.Password = "wrong"
+			client, err = mongo.Connect(ctx, options.Client().ApplyURI(uri).SetAuth(credential))
+			require.NoError(t, err)
+			t.Cleanup(func() {
+				require.NoError(t, client.Disconnect(ctx))
+			})
+
+			err = client.Ping(ctx, nil)
+			require.Error(t, err)
+		})
+	}
+}
+
+func TestCommandsAuthenticationTLS(t *testing.T) {
+	t.Parallel()
+	ctx, collection, port := SetupWithOpts(t, &SetupOpts{TLS: true, Providers: []shareddata.Provider{shareddata.Scalars}})
+
+	cursor, err := collection.Find(ctx, bson.D{})
+	require.NoError(t, err)
+
+	var docs []bson.D
+	require.NoError(t, cursor.All(ctx, &docs))
+	assert.Len(t, docs, len(shareddata.Scalars.DocsFor(*handlerF)))
+
+	// connections without TLS are rejected
+	uri := fmt.Sprintf("mongodb://127.0.0.1:%d", port)
+	client, err := mongo.Connect(ctx, options.Client().ApplyURI(uri).SetServerSelectionTimeout(time.Second))
+	require.NoError(t, err)
+	t.Cleanup(func() {
+		require.NoError(t, client.Disconnect(ctx))
+	})
+
+	err = client.Ping(ctx, nil)
+	require.Error(t, err)
+}
+
+func TestCommandsAuthenticationSetup(t *testing.T) {
+	t.Parallel()
+	ctx, collection, _ := SetupWithOpts(t, &SetupOpts{TLS: true, Auth: true})
+	db := collection.Database()
+
+	var res bson.D
+	err := db.RunCommand(ctx, bson.D{{"connectionStatus", 1}}).Decode(&res)
+	require.NoError(t, err)
+	authInfo := res.Map()["authInfo"].(bson.D).Map()
+	expected := bson.A{bson.D{{"user", testutil.TableName(t)}, {"db", db.Name()}}}
+	assert.Equal(t, expected, authInfo["authenticatedUsers"])
+}
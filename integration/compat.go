@@ -0,0 +1,237 @@
+// Copyright 2021 FerretDB Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package integration
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"sync"
+	"testing"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zaptest"
+
+	"github.com/FerretDB/FerretDB/integration/shareddata"
+	"github.com/FerretDB/FerretDB/internal/handlers"
+	"github.com/FerretDB/FerretDB/internal/util/testutil"
+)
+
+var compatPortF = flag.Int("compat-port", 0, "MongoDB port to compare with; if 0, compatibility tests are skipped")
+
+// compatIgnoredFields contains fields of command responses that are specific to the server instance,
+// so they are removed before comparison.
+var compatIgnoredFields = []string{"$clusterTime", "operationTime", "electionId", "opTime"}
+
+// SetupCompatOpts represents compatibility test setup options.
+type SetupCompatOpts struct {
+	// Data providers.
+	Providers []shareddata.Provider
+
+	// Optional features required by the test; see SetupOpts.
+	Requires []handlers.Feature
+}
+
+// SetupCompatWithOpts setups the compatibility test according to given options:
+// the same test-specific database and collection with the same data are created
+// in FerretDB (target) and MongoDB (compat).
+// It returns test-specific context (that is cancelled when the test ends) and both collections.
+//
+// The test is skipped if MongoDB port is not set with -compat-port flag.
+func SetupCompatWithOpts(t *testing.T, opts *SetupCompatOpts) (context.Context, *mongo.Collection, *mongo.Collection) {
+	t.Helper()
+
+	if *compatPortF == 0 {
+		t.Skip("-compat-port is not set")
+	}
+
+	startupOnce.Do(func() { startup(t) })
+
+	if opts == nil {
+		opts = new(SetupCompatOpts)
+	}
+
+	logger := zaptest.NewLogger(t, zaptest.Level(zap.DebugLevel))
+
+	ctx, cancel := context.WithCancel(context.Background())
+
+	var caps *handlers.Capabilities
+	port := *portF
+	if port == 0 {
+		port, caps = setupListener(t, ctx, logger, nil, nil)
+	}
+
+	// register cleanup function after setupListener's internal registration
+	t.Cleanup(cancel)
+
+	if caps != nil {
+		skipUnsupported(t, caps, opts.Requires)
+	}
+
+	databaseName := testutil.SchemaName(t)
+
+	targetCollection := setupCollection(t, ctx, setupClient(t, ctx, port), databaseName, true, opts.Providers)
+	compatCollection := setupCollection(t, ctx, setupClient(t, ctx, *compatPortF), databaseName, true, opts.Providers)
+
+	return ctx, targetCollection, compatCollection
+}
+
+// SetupCompat calls SetupCompatWithOpts with specified data providers.
+func SetupCompat(t *testing.T, providers ...shareddata.Provider) (context.Context, *mongo.Collection, *mongo.Collection) {
+	t.Helper()
+
+	return SetupCompatWithOpts(t, &SetupCompatOpts{
+		Providers: providers,
+	})
+}
+
+// CompatOp is an operation of the compatibility test.
+//
+// It is run with both FerretDB and MongoDB collections returned by SetupCompat
+// and should return a driver value (bson.D, bson.A, []bson.D, or a scalar) or an error.
+type CompatOp func(ctx context.Context, collection *mongo.Collection) (any, error)
+
+// CompatFind returns CompatOp that finds all documents matching the filter.
+func CompatFind(filter bson.D, opts ...*options.FindOptions) CompatOp {
+	return func(ctx context.Context, collection *mongo.Collection) (any, error) {
+		cursor, err := collection.Find(ctx, filter, opts...)
+		if err != nil {
+			return nil, err
+		}
+
+		var res []bson.D
+		err = cursor.All(ctx, &res)
+
+		return res, err
+	}
+}
+
+// CompatCommand returns CompatOp that runs the command returned by the given function
+// for the collection name in the collection's database.
+func CompatCommand(command func(collection string) bson.D) CompatOp {
+	return func(ctx context.Context, collection *mongo.Collection) (any, error) {
+		var res bson.D
+		err := collection.Database().RunCommand(ctx, command(collection.Name())).Decode(&res)
+
+		return res, err
+	}
+}
+
+// AssertCompat runs the operation with target (FerretDB) and compat (MongoDB) collections concurrently,
+// canonicalizes their results and errors, and asserts that they are equal.
+//
+// Fields that are specific to the server instance (see compatIgnoredFields) are removed from responses.
+// Errors are compared by codes only, as messages often differ in details.
+func AssertCompat(t testing.TB, ctx context.Context, targetCollection, compatCollection *mongo.Collection, op CompatOp) bool {
+	t.Helper()
+
+	var targetRes, compatRes any
+	var targetErr, compatErr error
+
+	var wg sync.WaitGroup
+	wg.Add(2)
+
+	go func() {
+		defer wg.Done()
+		targetRes, targetErr = op(ctx, targetCollection)
+	}()
+
+	go func() {
+		defer wg.Done()
+		compatRes, compatErr = op(ctx, compatCollection)
+	}()
+
+	wg.Wait()
+
+	expected := ConvertDocument(t, canonicalCompatResult(compatRes, compatErr))
+	actual := ConvertDocument(t, canonicalCompatResult(targetRes, targetErr))
+
+	return testutil.AssertEqual(t, expected, actual)
+}
+
+// canonicalCompatResult returns the document with canonical representation of the operation's result or error.
+func canonicalCompatResult(res any, err error) bson.D {
+	if err != nil {
+		return bson.D{{"error", canonicalCompatError(err)}}
+	}
+
+	return bson.D{{"result", canonicalCompatValue(res)}}
+}
+
+// canonicalCompatValue converts slices of documents to arrays and removes ignored fields from documents.
+func canonicalCompatValue(v any) any {
+	switch v := v.(type) {
+	case bson.D:
+		res := make(bson.D, 0, len(v))
+
+	fields:
+		for _, e := range v {
+			for _, f := range compatIgnoredFields {
+				if e.Key == f {
+					continue fields
+				}
+			}
+
+			res = append(res, e)
+		}
+
+		return res
+
+	case []bson.D:
+		res := make(bson.A, len(v))
+		for i, doc := range v {
+			res[i] = canonicalCompatValue(doc)
+		}
+
+		return res
+
+	default:
+		return v
+	}
+}
+
+// canonicalCompatError returns the document with codes of the command or write errors,
+// or the error message for other errors.
+func canonicalCompatError(err error) bson.D {
+	var ce mongo.CommandError
+	if errors.As(err, &ce) {
+		return bson.D{{"code", ce.Code}, {"codeName", ce.Name}}
+	}
+
+	var we mongo.WriteException
+	if errors.As(err, &we) {
+		writeErrors := make(bson.A, len(we.WriteErrors))
+		for i, e := range we.WriteErrors {
+			writeErrors[i] = bson.D{{"index", int32(e.Index)}, {"code", int32(e.Code)}}
+		}
+
+		return bson.D{{"writeErrors", writeErrors}}
+	}
+
+	var bwe mongo.BulkWriteException
+	if errors.As(err, &bwe) {
+		writeErrors := make(bson.A, len(bwe.WriteErrors))
+		for i, e := range bwe.WriteErrors {
+			writeErrors[i] = bson.D{{"index", int32(e.Index)}, {"code", int32(e.Code)}}
+		}
+
+		return bson.D{{"writeErrors", writeErrors}}
+	}
+
+	return bson.D{{"message", err.Error()}}
+}
@@ -0,0 +1,151 @@
+// Copyright 2021 FerretDB Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package integration
+
+import (
+	"context"
+	"math"
+	"testing"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+
+	"github.com/FerretDB/FerretDB/integration/shareddata"
+	"github.com/FerretDB/FerretDB/internal/util/testutil"
+)
+
+func TestQueryCompat(t *testing.T) {
+	t.Parallel()
+	ctx, targetCollection, compatCollection := SetupCompat(t, shareddata.Scalars, shareddata.Composites)
+
+	sort := options.Find().SetSort(bson.D{{"_id", 1}})
+
+	for name, filter := range map[string]bson.D{
+		"All":           {},
+		"IDString":      {{"_id", "string"}},
+		"EqDouble":      {{"value", bson.D{{"$eq", 42.13}}}},
+		"EqNaN":         {{"value", bson.D{{"$eq", math.NaN()}}}},
+		"GtInt32":       {{"value", bson.D{{"$gt", int32(42)}}}},
+		"LteString":     {{"value", bson.D{{"$lte", "foo"}}}},
+		"InMixed":       {{"value", bson.D{{"$in", bson.A{int32(42), "foo", nil}}}}},
+		"NinMixed":      {{"value", bson.D{{"$nin", bson.A{int32(42), "foo", nil}}}}},
+		"ExistsFalse":   {{"value", bson.D{{"$exists", false}}}},
+		"TypeString":    {{"value", bson.D{{"$type", "string"}}}},
+		"SizeArray":     {{"value", bson.D{{"$size", 2}}}},
+		"Regex":         {{"value", bson.D{{"$regex", "^fo"}}}},
+		"AndOr":         {{"$or", bson.A{bson.D{{"value", int32(42)}}, bson.D{{"_id", "double"}}}}},
+		"InvalidOp":     {{"value", bson.D{{"$invalid", 1}}}},
+		"InvalidSize":   {{"value", bson.D{{"$size", -1}}}},
+		"InvalidRegex":  {{"value", bson.D{{"$regex", "("}}}},
+		"InvalidIn":     {{"value", bson.D{{"$in", "foo"}}}},
+		"InvalidExists": {{"$exists", true}},
+	} {
+		name, filter := name, filter
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			AssertCompat(t, ctx, targetCollection, compatCollection, CompatFind(filter, sort))
+		})
+	}
+}
+
+func TestCommandsCompat(t *testing.T) {
+	t.Parallel()
+	ctx, targetCollection, compatCollection := SetupCompat(t, shareddata.Scalars)
+
+	for name, command := range map[string]func(collection string) bson.D{
+		"Count": func(collection string) bson.D {
+			return bson.D{{"count", collection}}
+		},
+		"CountQuery": func(collection string) bson.D {
+			return bson.D{{"count", collection}, {"query", bson.D{{"value", bson.D{{"$type", "number"}}}}}}
+		},
+		"Distinct": func(collection string) bson.D {
+			return bson.D{{"distinct", collection}, {"key", "_id"}, {"query", bson.D{{"_id", bson.D{{"$lt", "d"}}}}}}
+		},
+		"DistinctInvalidKey": func(collection string) bson.D {
+			return bson.D{{"distinct", collection}, {"key", int32(1)}}
+		},
+		"UnknownCommand": func(collection string) bson.D {
+			return bson.D{{"noSuchCommand", collection}}
+		},
+	} {
+		name, command := name, command
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			AssertCompat(t, ctx, targetCollection, compatCollection, CompatCommand(command))
+		})
+	}
+}
+
+func TestUpdateCompat(t *testing.T) {
+	t.Parallel()
+
+	for name, update := range map[string]bson.D{
+		"Set":           {{"$set", bson.D{{"value", "new"}}}},
+		"Inc":           {{"$inc", bson.D{{"count", int32(1)}}}},
+		"Unset":         {{"$unset", bson.D{{"value", ""}}}},
+		"Empty":         {{"$set", bson.D{}}},
+		"UnknownOp":     {{"$foo", bson.D{{"value", 1}}}},
+		"ConflictPaths": {{"$set", bson.D{{"value", 1}}}, {"$inc", bson.D{{"value", 1}}}},
+	} {
+		name, update := name, update
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			// each case changes data, so it uses its own collections
+			ctx, targetCollection, compatCollection := SetupCompat(t, shareddata.Scalars)
+
+			updateOne := func(ctx context.Context, collection *mongo.Collection) (any, error) {
+				res, err := collection.UpdateOne(ctx, bson.D{{"_id", "string"}}, update)
+				if err != nil {
+					return nil, err
+				}
+
+				return bson.D{{"matched", res.MatchedCount}, {"modified", res.ModifiedCount}}, nil
+			}
+
+			AssertCompat(t, ctx, targetCollection, compatCollection, updateOne)
+
+			AssertCompat(t, ctx, targetCollection, compatCollection, CompatFind(bson.D{{"_id", "string"}}))
+		})
+	}
+}
+
+func TestInsertCompat(t *testing.T) {
+	t.Parallel()
+	ctx, targetCollection, compatCollection := SetupCompat(t)
+
+	g := testutil.NewGenerator(t, 0, &testutil.GeneratorOpts{MaxDepth: 2})
+
+	docs := make([]any, 0, 50)
+	for _, doc := range g.Documents(50) {
+		docs = append(docs, Unconvert(t, doc))
+	}
+
+	insertMany := func(ctx context.Context, collection *mongo.Collection) (any, error) {
+		res, err := collection.InsertMany(ctx, docs, options.InsertMany().SetOrdered(false))
+		if err != nil {
+			return nil, err
+		}
+
+		return bson.D{{"inserted", int32(len(res.InsertedIDs))}}, nil
+	}
+
+	AssertCompat(t, ctx, targetCollection, compatCollection, insertMany)
+	AssertCompat(t, ctx, targetCollection, compatCollection, CompatFind(bson.D{}, options.Find().SetSort(bson.D{{"_id", 1}})))
+}
@@ -15,11 +15,13 @@
 package integration
 
 import (
+	"errors"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
 
 	"github.com/FerretDB/FerretDB/integration/shareddata"
 )
@@ -52,3 +54,73 @@ func TestDeleteSimple(t *testing.T) {
 		})
 	}
 }
+
+func TestDeleteOrdered(t *testing.T) {
+	t.Parallel()
+
+	for name, tc := range map[string]struct {
+		ordered bool
+		n       int32
+		indexes []int32
+		left    []string
+	}{
+		"Ordered": {
+			ordered: true,
+			n:       1,
+			indexes: []int32{1},
+			left:    []string{"b", "c"},
+		},
+		"Unordered": {
+			ordered: false,
+			n:       2,
+			indexes: []int32{1, 3},
+			left:    []string{"c"},
+		},
+	} {
+		name, tc := name, tc
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			ctx, collection := Setup(t)
+
+			_, err := collection.InsertMany(ctx, []any{bson.D{{"_id", "a"}}, bson.D{{"_id", "b"}}, bson.D{{"_id", "c"}}})
+			require.NoError(t, err)
+
+			err = collection.Database().RunCommand(ctx, bson.D{
+				{"delete", collection.Name()},
+				{"deletes", bson.A{
+					bson.D{{"q", bson.D{{"_id", "a"}}}, {"limit", int32(0)}},
+					bson.D{{"q", bson.D{{"$foo", "a"}}}, {"limit", int32(0)}},
+					bson.D{{"q", bson.D{{"_id", "b"}}}, {"limit", int32(0)}},
+					bson.D{{"q", bson.D{{"$foo", "b"}}}, {"limit", int32(0)}},
+				}},
+				{"ordered", tc.ordered},
+			}).Err()
+
+			var we mongo.WriteException
+			require.True(t, errors.As(err, &we), "%v", err)
+
+			indexes := make([]int32, len(we.WriteErrors))
+			for i, e := range we.WriteErrors {
+				indexes[i] = int32(e.Index)
+			}
+			assert.Equal(t, tc.indexes, indexes)
+
+			var actual bson.D
+			require.NoError(t, bson.Unmarshal(we.Raw, &actual))
+			assert.Equal(t, tc.n, actual.Map()["n"])
+
+			cursor, err := collection.Find(ctx, bson.D{})
+			require.NoError(t, err)
+
+			var docs []bson.D
+			require.NoError(t, cursor.All(ctx, &docs))
+
+			var left []string
+			for _, doc := range docs {
+				left = append(left, doc.Map()["_id"].(string))
+			}
+			assert.Equal(t, tc.left, left)
+		})
+	}
+}
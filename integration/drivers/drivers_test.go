@@ -0,0 +1,154 @@
+// Copyright 2021 FerretDB Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package drivers contains smoke tests that run scripted sessions of mongosh and of minimal programs
+// for popular MongoDB drivers against FerretDB, and check their output.
+//
+// Programs are located in testdata directory; they all perform the same operations
+// and print the same output (see testdata/expected.txt).
+// Tests for drivers and tools that are not installed are skipped unless -drivers-required flag is set.
+package drivers
+
+import (
+	"bytes"
+	"context"
+	"flag"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/FerretDB/FerretDB/integration"
+)
+
+var (
+	driversRequiredF = flag.Bool("drivers-required", false, "fail instead of skipping if driver or tool is not installed")
+	javaClasspathF   = flag.String("java-classpath", "", "classpath with MongoDB Java driver jars; if empty, Java test is skipped")
+)
+
+// driverTestCase describes how to run a smoke program for a single driver or tool.
+type driverTestCase struct {
+	dir   string   // directory in testdata
+	check []string // command that fails if driver or tool is not installed
+	run   []string // command that runs the program
+	skip  string   // reason to skip the test, if any
+}
+
+func TestDrivers(t *testing.T) {
+	t.Parallel()
+
+	expected, err := os.ReadFile(filepath.Join("testdata", "expected.txt"))
+	require.NoError(t, err)
+
+	var javaSkip string
+	if *javaClasspathF == "" {
+		javaSkip = "-java-classpath flag is not set"
+	}
+
+	for name, tc := range map[string]driverTestCase{
+		"Mongosh": {
+			dir:   "mongosh",
+			check: []string{"mongosh", "--version"},
+			run:   []string{"mongosh", "--quiet", "--nodb", "--file", "smoke.js"},
+		},
+		"Node": {
+			dir:   "node",
+			check: []string{"node", "-e", "require('mongodb')"},
+			run:   []string{"node", "smoke.js"},
+		},
+		"Python": {
+			dir:   "python",
+			check: []string{"python3", "-c", "import pymongo"},
+			run:   []string{"python3", "smoke.py"},
+		},
+		"Java": {
+			dir:   "java",
+			check: []string{"java", "-version"},
+			run:   []string{"java", "-cp", *javaClasspathF, "Smoke.java"},
+			skip:  javaSkip,
+		},
+		"Go": {
+			dir:   "go",
+			check: []string{"go", "version"},
+			run:   []string{"go", "run", "."},
+		},
+	} {
+		name, tc := name, tc
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			ctx, collection, port := integration.SetupWithOpts(t, nil)
+
+			if tc.skip == "" {
+				tc.skip = checkInstalled(ctx, tc)
+			}
+
+			if tc.skip != "" {
+				if *driversRequiredF {
+					t.Fatal(tc.skip)
+				}
+
+				t.Skip(tc.skip)
+			}
+
+			ctx, cancel := context.WithTimeout(ctx, 2*time.Minute)
+			defer cancel()
+
+			cmd := exec.CommandContext(ctx, tc.run[0], tc.run[1:]...)
+			cmd.Dir = filepath.Join("testdata", tc.dir)
+			cmd.Env = append(
+				os.Environ(),
+				fmt.Sprintf("MONGODB_URI=mongodb://127.0.0.1:%d/", port),
+				"SMOKE_DATABASE="+collection.Database().Name(),
+				"SMOKE_COLLECTION="+collection.Name(),
+			)
+
+			var stderr bytes.Buffer
+			cmd.Stderr = &stderr
+
+			out, err := cmd.Output()
+			require.NoError(t, err, "stdout:\n%s\nstderr:\n%s", out, stderr.Bytes())
+
+			assert.Equal(t, normalizeOutput(expected), normalizeOutput(out), "stderr:\n%s", stderr.Bytes())
+		})
+	}
+}
+
+// checkInstalled runs the check command of the given test case,
+// and returns a non-empty reason to skip the test if it fails.
+func checkInstalled(ctx context.Context, tc driverTestCase) string {
+	if _, err := exec.LookPath(tc.check[0]); err != nil {
+		return fmt.Sprintf("%s is not installed", tc.check[0])
+	}
+
+	cmd := exec.CommandContext(ctx, tc.check[0], tc.check[1:]...)
+	cmd.Dir = filepath.Join("testdata", tc.dir)
+
+	if out, err := cmd.CombinedOutput(); err != nil {
+		return fmt.Sprintf("%q failed (%s):\n%s", strings.Join(tc.check, " "), err, out)
+	}
+
+	return ""
+}
+
+// normalizeOutput trims whitespace and unifies line endings for comparison.
+func normalizeOutput(b []byte) string {
+	return strings.TrimSpace(strings.ReplaceAll(string(b), "\r\n", "\n"))
+}
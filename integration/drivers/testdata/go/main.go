@@ -0,0 +1,100 @@
+// Copyright 2021 FerretDB Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package main is a smoke test program for MongoDB Go driver.
+package main
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"os"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+func main() {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+	defer cancel()
+
+	client, err := mongo.Connect(ctx, options.Client().ApplyURI(os.Getenv("MONGODB_URI")))
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	defer client.Disconnect(ctx) //nolint:errcheck // nothing to do on error
+
+	db := client.Database(os.Getenv("SMOKE_DATABASE"))
+	collection := db.Collection(os.Getenv("SMOKE_COLLECTION"))
+
+	var ping struct {
+		OK float64 `bson:"ok"`
+	}
+	if err = db.RunCommand(ctx, bson.D{{"ping", 1}}).Decode(&ping); err != nil {
+		log.Fatal(err)
+	}
+	fmt.Printf("ping: %v\n", ping.OK)
+
+	inserted, err := collection.InsertMany(ctx, []any{
+		bson.D{{"_id", int32(1)}, {"v", "foo"}},
+		bson.D{{"_id", int32(2)}, {"v", "bar"}},
+		bson.D{{"_id", int32(3)}, {"v", "baz"}},
+	})
+	if err != nil {
+		log.Fatal(err)
+	}
+	fmt.Printf("insertMany: %d\n", len(inserted.InsertedIDs))
+
+	var docs []bson.M
+	cursor, err := collection.Find(ctx, bson.D{})
+	if err != nil {
+		log.Fatal(err)
+	}
+	if err = cursor.All(ctx, &docs); err != nil {
+		log.Fatal(err)
+	}
+	fmt.Printf("find: %d\n", len(docs))
+
+	var doc bson.M
+	if err = collection.FindOne(ctx, bson.D{{"_id", int32(2)}}).Decode(&doc); err != nil {
+		log.Fatal(err)
+	}
+	fmt.Printf("findOne: %v\n", doc["v"])
+
+	updated, err := collection.UpdateOne(ctx, bson.D{{"_id", int32(1)}}, bson.D{{"$set", bson.D{{"v", "qux"}}}})
+	if err != nil {
+		log.Fatal(err)
+	}
+	fmt.Printf("updateOne: %d\n", updated.ModifiedCount)
+
+	deleted, err := collection.DeleteMany(ctx, bson.D{{"_id", bson.D{{"$gt", int32(1)}}}})
+	if err != nil {
+		log.Fatal(err)
+	}
+	fmt.Printf("deleteMany: %d\n", deleted.DeletedCount)
+
+	docs = nil
+	if cursor, err = collection.Find(ctx, bson.D{}); err != nil {
+		log.Fatal(err)
+	}
+	if err = cursor.All(ctx, &docs); err != nil {
+		log.Fatal(err)
+	}
+	for _, doc := range docs {
+		fmt.Printf("remaining: %v %v\n", doc["_id"], doc["v"])
+	}
+}
@@ -0,0 +1,45 @@
+// Smoke test program for MongoDB Java driver; see ../../drivers_test.go.
+//
+// It is run with source-file mode of java launcher (Java 11+),
+// with mongodb-driver-sync, mongodb-driver-core, and bson jars in the classpath.
+
+import com.mongodb.client.MongoClient;
+import com.mongodb.client.MongoClients;
+import com.mongodb.client.MongoCollection;
+import com.mongodb.client.MongoDatabase;
+import com.mongodb.client.model.Filters;
+import com.mongodb.client.model.Updates;
+import java.util.ArrayList;
+import java.util.List;
+import org.bson.Document;
+
+public class Smoke {
+    public static void main(String[] args) {
+        try (MongoClient client = MongoClients.create(System.getenv("MONGODB_URI"))) {
+            MongoDatabase db = client.getDatabase(System.getenv("SMOKE_DATABASE"));
+            MongoCollection<Document> collection = db.getCollection(System.getenv("SMOKE_COLLECTION"));
+
+            Number ok = (Number) db.runCommand(new Document("ping", 1)).get("ok");
+            System.out.println("ping: " + ok.intValue());
+
+            List<Document> docs = List.of(
+                    new Document("_id", 1).append("v", "foo"),
+                    new Document("_id", 2).append("v", "bar"),
+                    new Document("_id", 3).append("v", "baz"));
+            System.out.println("insertMany: " + collection.insertMany(docs).getInsertedIds().size());
+
+            System.out.println("find: " + collection.find().into(new ArrayList<>()).size());
+
+            System.out.println("findOne: " + collection.find(Filters.eq("_id", 2)).first().getString("v"));
+
+            long modified = collection.updateOne(Filters.eq("_id", 1), Updates.set("v", "qux")).getModifiedCount();
+            System.out.println("updateOne: " + modified);
+
+            System.out.println("deleteMany: " + collection.deleteMany(Filters.gt("_id", 1)).getDeletedCount());
+
+            for (Document doc : collection.find()) {
+                System.out.println("remaining: " + doc.get("_id") + " " + doc.getString("v"));
+            }
+        }
+    }
+}
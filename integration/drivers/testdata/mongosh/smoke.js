@@ -0,0 +1,23 @@
+// Smoke test script for MongoDB Shell (mongosh); see ../../drivers_test.go.
+
+const db = connect(process.env.MONGODB_URI).getSiblingDB(process.env.SMOKE_DATABASE);
+const collection = db.getCollection(process.env.SMOKE_COLLECTION);
+
+print(`ping: ${db.runCommand({ ping: 1 }).ok}`);
+
+const inserted = collection.insertMany([
+  { _id: 1, v: 'foo' },
+  { _id: 2, v: 'bar' },
+  { _id: 3, v: 'baz' },
+]);
+print(`insertMany: ${Object.keys(inserted.insertedIds).length}`);
+
+print(`find: ${collection.find().toArray().length}`);
+
+print(`findOne: ${collection.findOne({ _id: 2 }).v}`);
+
+print(`updateOne: ${collection.updateOne({ _id: 1 }, { $set: { v: 'qux' } }).modifiedCount}`);
+
+print(`deleteMany: ${collection.deleteMany({ _id: { $gt: 1 } }).deletedCount}`);
+
+collection.find().forEach((doc) => print(`remaining: ${doc._id} ${doc.v}`));
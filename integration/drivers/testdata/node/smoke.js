@@ -0,0 +1,43 @@
+// Smoke test program for MongoDB Node.js driver; see ../../drivers_test.go.
+
+const { MongoClient } = require('mongodb');
+
+async function main() {
+  const client = new MongoClient(process.env.MONGODB_URI);
+  await client.connect();
+
+  try {
+    const db = client.db(process.env.SMOKE_DATABASE);
+    const collection = db.collection(process.env.SMOKE_COLLECTION);
+
+    console.log(`ping: ${(await db.command({ ping: 1 })).ok}`);
+
+    const inserted = await collection.insertMany([
+      { _id: 1, v: 'foo' },
+      { _id: 2, v: 'bar' },
+      { _id: 3, v: 'baz' },
+    ]);
+    console.log(`insertMany: ${inserted.insertedCount}`);
+
+    console.log(`find: ${(await collection.find().toArray()).length}`);
+
+    console.log(`findOne: ${(await collection.findOne({ _id: 2 })).v}`);
+
+    const updated = await collection.updateOne({ _id: 1 }, { $set: { v: 'qux' } });
+    console.log(`updateOne: ${updated.modifiedCount}`);
+
+    const deleted = await collection.deleteMany({ _id: { $gt: 1 } });
+    console.log(`deleteMany: ${deleted.deletedCount}`);
+
+    for (const doc of await collection.find().toArray()) {
+      console.log(`remaining: ${doc._id} ${doc.v}`);
+    }
+  } finally {
+    await client.close();
+  }
+}
+
+main().catch((err) => {
+  console.error(err);
+  process.exit(1);
+});
@@ -0,0 +1,41 @@
+# Smoke test program for MongoDB Python driver (PyMongo); see ../../drivers_test.go.
+
+import os
+
+from pymongo import MongoClient
+
+
+def main():
+    client = MongoClient(os.environ["MONGODB_URI"])
+
+    try:
+        db = client[os.environ["SMOKE_DATABASE"]]
+        collection = db[os.environ["SMOKE_COLLECTION"]]
+
+        print(f"ping: {int(db.command('ping')['ok'])}")
+
+        inserted = collection.insert_many([
+            {"_id": 1, "v": "foo"},
+            {"_id": 2, "v": "bar"},
+            {"_id": 3, "v": "baz"},
+        ])
+        print(f"insertMany: {len(inserted.inserted_ids)}")
+
+        print(f"find: {len(list(collection.find()))}")
+
+        print(f"findOne: {collection.find_one({'_id': 2})['v']}")
+
+        updated = collection.update_one({"_id": 1}, {"$set": {"v": "qux"}})
+        print(f"updateOne: {updated.modified_count}")
+
+        deleted = collection.delete_many({"_id": {"$gt": 1}})
+        print(f"deleteMany: {deleted.deleted_count}")
+
+        for doc in collection.find():
+            print(f"remaining: {doc['_id']} {doc['v']}")
+    finally:
+        client.close()
+
+
+if __name__ == "__main__":
+    main()
@@ -0,0 +1,313 @@
+// Copyright 2021 FerretDB Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package integration
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+
+	"github.com/FerretDB/FerretDB/internal/util/fault"
+)
+
+// insertWithRetries inserts documents with _id values from 0 to n-1 one by one,
+// retrying each insert up to 10 times if it fails with an allowed error,
+// and returns the number of failed attempts.
+func insertWithRetries(t *testing.T, ctx context.Context, collection *mongo.Collection, n int, allowed func(error) bool) int {
+	t.Helper()
+
+	var failed int
+	for i := int32(0); i < int32(n); i++ {
+		var err error
+		for attempt := 0; attempt < 10; attempt++ {
+			if _, err = collection.InsertOne(ctx, bson.D{{"_id", i}, {"v", "foo"}}); err == nil {
+				break
+			}
+
+			// duplicate key errors would mean that the failed attempt was partially executed
+			require.True(t, allowed(err), "unexpected error: %v", err)
+			failed++
+		}
+
+		require.NoError(t, err)
+	}
+
+	return failed
+}
+
+// assertIDs checks that the collection contains documents with _id values from 0 to n-1 and nothing else.
+func assertIDs(t *testing.T, ctx context.Context, collection *mongo.Collection, n int) {
+	t.Helper()
+
+	cursor, err := collection.Find(ctx, bson.D{}, options.Find().SetSort(bson.D{{"_id", 1}}))
+	require.NoError(t, err)
+
+	var docs []struct {
+		ID int32 `bson:"_id"`
+	}
+	require.NoError(t, cursor.All(ctx, &docs))
+
+	expected := make([]int32, n)
+	actual := make([]int32, len(docs))
+	for i := range expected {
+		expected[i] = int32(i)
+	}
+	for i, doc := range docs {
+		actual[i] = doc.ID
+	}
+
+	assert.Equal(t, expected, actual)
+}
+
+// incrementWithRetries increments the counter field of the document with the given _id n times,
+// retrying each failed increment up to 10 times if it fails with an allowed error,
+// and returns the number of failed attempts.
+//
+// Increments are not idempotent, so the final counter value shows whether failed attempts were applied.
+func incrementWithRetries(
+	t *testing.T, ctx context.Context, collection *mongo.Collection, id any, n int, allowed func(error) bool,
+) int {
+	t.Helper()
+
+	var failed int
+	for i := 0; i < n; i++ {
+		var err error
+		for attempt := 0; attempt < 10; attempt++ {
+			var res *mongo.UpdateResult
+			res, err = collection.UpdateOne(ctx, bson.D{{"_id", id}}, bson.D{{"$inc", bson.D{{"v", int32(1)}}}})
+			if err == nil {
+				require.Equal(t, int64(1), res.ModifiedCount)
+				break
+			}
+
+			require.True(t, allowed(err), "unexpected error: %v", err)
+			failed++
+		}
+
+		require.NoError(t, err)
+	}
+
+	return failed
+}
+
+// assertCounter checks that the counter field of the document with the given _id is equal to expected.
+func assertCounter(t *testing.T, ctx context.Context, collection *mongo.Collection, id any, expected int32) {
+	t.Helper()
+
+	var doc struct {
+		V int32 `bson:"v"`
+	}
+	require.NoError(t, collection.FindOne(ctx, bson.D{{"_id", id}}).Decode(&doc))
+	assert.Equal(t, expected, doc.V, "failed writes were applied or successful writes were applied twice")
+}
+
+// isHostUnreachable returns true if err is HostUnreachable error without RetryableWriteError label.
+//
+// Backend errors do not say whether the statement reached the backend, so drivers should not retry them.
+func isHostUnreachable(t *testing.T, err error) bool {
+	t.Helper()
+
+	var se mongo.ServerError
+	if !errors.As(err, &se) || !se.HasErrorCode(6) { // HostUnreachable
+		return false
+	}
+
+	assert.False(t, se.HasErrorLabel("RetryableWriteError"), "unexpected label: %v", err)
+
+	return true
+}
+
+func TestFaultsDroppedConnections(t *testing.T) {
+	t.Parallel()
+
+	faults := fault.New(nil)
+	ctx, collection, _ := SetupWithOpts(t, &SetupOpts{Faults: faults})
+
+	// only inserts consume pseudo-random numbers, so faults are the same on each run
+	faults.SetConfig(&fault.Config{
+		DropRate: 0.3,
+		Commands: []string{"insert"},
+		Seed:     42,
+	})
+
+	failed := insertWithRetries(t, ctx, collection, 20, mongo.IsNetworkError)
+
+	faults.SetConfig(nil)
+
+	// Drivers retry writes only if the server supports sessions, and FerretDB does not advertise them yet,
+	// so every dropped insert is seen by the client.
+	// Dropped requests are not executed, so they are safe to retry by the application.
+	assert.Equal(t, int(faults.Stats().Drops), failed)
+	assert.NotZero(t, failed)
+
+	assertIDs(t, ctx, collection, 20)
+}
+
+func TestFaultsDroppedConnectionsIncrements(t *testing.T) {
+	t.Parallel()
+
+	faults := fault.New(nil)
+	ctx, collection, _ := SetupWithOpts(t, &SetupOpts{Faults: faults})
+
+	_, err := collection.InsertOne(ctx, bson.D{{"_id", "counter"}, {"v", int32(0)}})
+	require.NoError(t, err)
+
+	// only updates consume pseudo-random numbers, so faults are the same on each run
+	faults.SetConfig(&fault.Config{
+		DropRate: 0.3,
+		Commands: []string{"update"},
+		Seed:     42,
+	})
+
+	failed := incrementWithRetries(t, ctx, collection, "counter", 20, mongo.IsNetworkError)
+
+	faults.SetConfig(nil)
+
+	assert.Equal(t, int(faults.Stats().Drops), failed)
+	assert.NotZero(t, failed)
+
+	// neither the driver nor FerretDB applied dropped increments
+	assertCounter(t, ctx, collection, "counter", 20)
+}
+
+func TestFaultsRetryableReads(t *testing.T) {
+	t.Parallel()
+
+	faults := fault.New(nil)
+	ctx, collection, _ := SetupWithOpts(t, &SetupOpts{Faults: faults})
+
+	_, err := collection.InsertOne(ctx, bson.D{{"_id", "foo"}})
+	require.NoError(t, err)
+
+	// only finds consume pseudo-random numbers, so faults are the same on each run
+	faults.SetConfig(&fault.Config{
+		DropRate: 0.3,
+		Commands: []string{"find"},
+		Seed:     42,
+	})
+
+	var failed int
+	for i := 0; i < 20; i++ {
+		if err = collection.FindOne(ctx, bson.D{{"_id", "foo"}}).Err(); err != nil {
+			require.True(t, mongo.IsNetworkError(err), "unexpected error: %v", err)
+			failed++
+		}
+	}
+
+	faults.SetConfig(nil)
+
+	// reads are retried once, so only reads with both attempts dropped fail
+	drops := int(faults.Stats().Drops)
+	assert.NotZero(t, drops)
+	assert.Less(t, failed, drops)
+}
+
+func TestFaultsBackendErrors(t *testing.T) {
+	t.Parallel()
+
+	if *handlerF != "pg" {
+		t.Skip("Backend faults are injected only by pg handler")
+	}
+
+	faults := fault.New(nil)
+	ctx, collection, _ := SetupWithOpts(t, &SetupOpts{Faults: faults})
+
+	// background queries of the handler also consume pseudo-random numbers, so faults are different on each run
+	faults.SetConfig(&fault.Config{ErrorRate: 0.1})
+
+	allowed := func(err error) bool { return isHostUnreachable(t, err) }
+
+	// injected errors fail writes to PostgreSQL connections, so statements are not sent and are safe to retry
+	insertWithRetries(t, ctx, collection, 20, allowed)
+
+	// the counter is stored separately to keep _id values checked by assertIDs
+	counters := collection.Database().Collection(collection.Name() + "_counters")
+
+	_, err := counters.InsertOne(ctx, bson.D{{"_id", "counter"}, {"v", int32(0)}})
+	for attempt := 0; err != nil && attempt < 10; attempt++ {
+		require.True(t, allowed(err), "unexpected error: %v", err)
+		_, err = counters.InsertOne(ctx, bson.D{{"_id", "counter"}, {"v", int32(0)}})
+	}
+	require.NoError(t, err)
+
+	incrementWithRetries(t, ctx, counters, "counter", 20, allowed)
+
+	faults.SetConfig(nil)
+
+	require.NotZero(t, faults.Stats().Errors)
+
+	assertIDs(t, ctx, collection, 20)
+	assertCounter(t, ctx, counters, "counter", 20)
+}
+
+func TestFaultsLatency(t *testing.T) {
+	t.Parallel()
+
+	faults := fault.New(nil)
+	ctx, collection, _ := SetupWithOpts(t, &SetupOpts{Faults: faults})
+	db := collection.Database()
+
+	faults.SetConfig(&fault.Config{
+		Latency:     200 * time.Millisecond,
+		LatencyRate: 1,
+		Commands:    []string{"ping"},
+	})
+
+	start := time.Now()
+	require.NoError(t, db.RunCommand(ctx, bson.D{{"ping", 1}}).Err())
+	assert.GreaterOrEqual(t, time.Since(start), 200*time.Millisecond)
+
+	// the connection is closed by the driver on timeout
+	timeoutCtx, cancel := context.WithTimeout(ctx, 50*time.Millisecond)
+	defer cancel()
+
+	err := db.RunCommand(timeoutCtx, bson.D{{"ping", 1}}).Err()
+	require.True(t, mongo.IsTimeout(err), "unexpected error: %v", err)
+
+	faults.SetConfig(nil)
+
+	require.NoError(t, db.RunCommand(ctx, bson.D{{"ping", 1}}).Err())
+	assert.Equal(t, uint64(2), faults.Stats().Latencies)
+}
+
+func TestFaultsMonitoring(t *testing.T) {
+	t.Parallel()
+
+	faults := fault.New(nil)
+	ctx, collection, port := SetupWithOpts(t, &SetupOpts{Faults: faults})
+
+	clientOpts := options.Client().SetHeartbeatInterval(500 * time.Millisecond).SetServerSelectionTimeout(10 * time.Second)
+	db := setupClient(t, ctx, port, clientOpts).Database(collection.Database().Name())
+
+	// drop monitoring connections and handshakes of new connections
+	faults.SetConfig(&fault.Config{
+		DropRate: 1,
+		Commands: []string{"hello", "isMaster", "ismaster"},
+	})
+
+	require.Eventually(t, func() bool { return faults.Stats().Drops > 0 }, 10*time.Second, 100*time.Millisecond)
+
+	faults.SetConfig(nil)
+
+	// server is rediscovered
+	require.NoError(t, db.RunCommand(ctx, bson.D{{"ping", 1}}).Err())
+}
@@ -15,6 +15,7 @@
 package integration
 
 import (
+	"fmt"
 	"math"
 	"testing"
 
@@ -25,6 +26,7 @@ import (
 	"go.mongodb.org/mongo-driver/mongo"
 
 	"github.com/FerretDB/FerretDB/integration/shareddata"
+	"github.com/FerretDB/FerretDB/internal/handlers"
 )
 
 func TestFindAndModifySimple(t *testing.T) {
@@ -139,7 +141,10 @@ func TestFindAndModifySimple(t *testing.T) {
 		name, tc := name, tc
 		t.Run(name, func(t *testing.T) {
 			t.Parallel()
-			ctx, collection := Setup(t, shareddata.Scalars, shareddata.Composites)
+			ctx, collection, _ := SetupWithOpts(t, &SetupOpts{
+				Providers: []shareddata.Provider{shareddata.Scalars, shareddata.Composites},
+				Requires:  []handlers.Feature{handlers.FeatureFindAndModify},
+			})
 
 			command := append(bson.D{{"findAndModify", collection.Name()}}, tc.command...)
 
@@ -162,7 +167,7 @@ func TestFindAndModifyEmptyCollectionName(t *testing.T) {
 		"EmptyCollectionName": {
 			err: &mongo.CommandError{
 				Code:    73,
-				Message: "Invalid namespace specified 'testfindandmodifyemptycollectionname-emptycollectionname.'",
+				Message: "Invalid namespace specified '%s.'",
 				Name:    "InvalidNamespace",
 			},
 		},
@@ -170,12 +175,17 @@ func TestFindAndModifyEmptyCollectionName(t *testing.T) {
 		name, tc := name, tc
 		t.Run(name, func(t *testing.T) {
 			t.Parallel()
-			ctx, collection := Setup(t, shareddata.Scalars, shareddata.Composites)
+			ctx, collection, _ := SetupWithOpts(t, &SetupOpts{
+				Providers: []shareddata.Provider{shareddata.Scalars, shareddata.Composites},
+				Requires:  []handlers.Feature{handlers.FeatureFindAndModify},
+			})
 
 			var actual bson.D
 			err := collection.Database().RunCommand(ctx, bson.D{{"findAndModify", ""}}).Decode(&actual)
 
-			AssertEqualError(t, *tc.err, err)
+			expectedErr := *tc.err
+			expectedErr.Message = fmt.Sprintf(tc.err.Message, collection.Database().Name())
+			AssertEqualError(t, expectedErr, err)
 		})
 	}
 }
@@ -292,7 +302,10 @@ func TestFindAndModifyErrors(t *testing.T) {
 		name, tc := name, tc
 		t.Run(name, func(t *testing.T) {
 			t.Parallel()
-			ctx, collection := Setup(t, shareddata.Scalars, shareddata.Composites)
+			ctx, collection, _ := SetupWithOpts(t, &SetupOpts{
+				Providers: []shareddata.Provider{shareddata.Scalars, shareddata.Composites},
+				Requires:  []handlers.Feature{handlers.FeatureFindAndModify},
+			})
 
 			command := append(bson.D{{"findAndModify", collection.Name()}}, tc.command...)
 
@@ -405,7 +418,10 @@ func TestFindAndModifyUpdate(t *testing.T) {
 		name, tc := name, tc
 		t.Run(name, func(t *testing.T) {
 			t.Parallel()
-			ctx, collection := Setup(t, shareddata.Scalars, shareddata.Composites)
+			ctx, collection, _ := SetupWithOpts(t, &SetupOpts{
+				Providers: []shareddata.Provider{shareddata.Scalars, shareddata.Composites},
+				Requires:  []handlers.Feature{handlers.FeatureFindAndModify},
+			})
 
 			command := bson.D{{"findAndModify", collection.Name()}, {"query", tc.query}}
 			command = append(command, tc.command...)
@@ -544,7 +560,10 @@ func TestFindAndModifyUpsert(t *testing.T) {
 		name, tc := name, tc
 		t.Run(name, func(t *testing.T) {
 			t.Parallel()
-			ctx, collection := Setup(t, shareddata.Scalars, shareddata.Composites)
+			ctx, collection, _ := SetupWithOpts(t, &SetupOpts{
+				Providers: []shareddata.Provider{shareddata.Scalars, shareddata.Composites},
+				Requires:  []handlers.Feature{handlers.FeatureFindAndModify},
+			})
 
 			command := append(bson.D{{"findAndModify", collection.Name()}}, tc.command...)
 
@@ -589,7 +608,10 @@ func TestFindAndModifyUpsertComplex(t *testing.T) {
 		name, tc := name, tc
 		t.Run(name, func(t *testing.T) {
 			t.Parallel()
-			ctx, collection := Setup(t, shareddata.Scalars, shareddata.Composites)
+			ctx, collection, _ := SetupWithOpts(t, &SetupOpts{
+				Providers: []shareddata.Provider{shareddata.Scalars, shareddata.Composites},
+				Requires:  []handlers.Feature{handlers.FeatureFindAndModify},
+			})
 
 			command := append(bson.D{{"findAndModify", collection.Name()}}, tc.command...)
 
@@ -658,7 +680,10 @@ func TestFindAndModifyRemove(t *testing.T) {
 		name, tc := name, tc
 		t.Run(name, func(t *testing.T) {
 			t.Parallel()
-			ctx, collection := Setup(t, shareddata.Scalars)
+			ctx, collection, _ := SetupWithOpts(t, &SetupOpts{
+				Providers: []shareddata.Provider{shareddata.Scalars},
+				Requires:  []handlers.Feature{handlers.FeatureFindAndModify},
+			})
 
 			command := append(bson.D{{"findAndModify", collection.Name()}}, tc.command...)
 
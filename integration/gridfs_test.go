@@ -0,0 +1,109 @@
+// Copyright 2021 FerretDB Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package integration
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo/gridfs"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+func TestGridFS(t *testing.T) {
+	t.Parallel()
+	ctx, collection := Setup(t)
+	db := collection.Database()
+
+	bucket, err := gridfs.NewBucket(db, options.GridFSBucket().SetName(collection.Name()).SetChunkSizeBytes(1024))
+	require.NoError(t, err)
+
+	data := bytes.Repeat([]byte("FerretDB"), 1000) // several chunks
+	id, err := bucket.UploadFromStream("ferretdb.txt", bytes.NewReader(data))
+	require.NoError(t, err)
+
+	var buf bytes.Buffer
+	_, err = bucket.DownloadToStream(id, &buf)
+	require.NoError(t, err)
+	assert.Equal(t, data, buf.Bytes())
+
+	chunks := db.Collection(collection.Name() + ".chunks")
+
+	cursor, err := chunks.Find(ctx, bson.D{{"files_id", id}})
+	require.NoError(t, err)
+
+	var docs []bson.D
+	require.NoError(t, cursor.All(ctx, &docs))
+	assert.Len(t, docs, 8)
+
+	var chunk bson.D
+	opts := options.FindOne().SetProjection(bson.D{{"data", 1}})
+	err = chunks.FindOne(ctx, bson.D{{"files_id", id}, {"n", 7}}, opts).Decode(&chunk)
+	require.NoError(t, err)
+	require.Len(t, chunk, 2)
+	assert.Equal(t, primitive.Binary{Data: data[7*1024:]}, chunk[1].Value)
+
+	require.NoError(t, bucket.Delete(id))
+
+	cursor, err = chunks.Find(ctx, bson.D{})
+	require.NoError(t, err)
+
+	require.NoError(t, cursor.All(ctx, &docs))
+	assert.Empty(t, docs)
+}
+
+func TestGridFSChunksCollection(t *testing.T) {
+	t.Parallel()
+	ctx, collection := Setup(t)
+	chunks := collection.Database().Collection(collection.Name() + ".chunks")
+
+	// documents that are not stored as regular chunks should not change either
+	docs := []bson.D{
+		{{"_id", "chunk"}, {"n", int32(0)}, {"data", primitive.Binary{Data: []byte{1, 2, 3}}}},
+		{{"_id", "empty"}, {"n", int32(1)}, {"data", primitive.Binary{Data: []byte{}}}},
+		{{"_id", "not-last"}, {"data", primitive.Binary{Data: []byte{1}}}, {"n", int32(2)}},
+		{{"_id", "subtype"}, {"n", int32(3)}, {"data", primitive.Binary{Subtype: 0x80, Data: []byte{1}}}},
+		{{"_id", "string"}, {"n", int32(4)}, {"data", "foo"}},
+		{{"_id", "missing"}, {"n", int32(5)}},
+	}
+
+	for _, doc := range docs {
+		_, err := chunks.InsertOne(ctx, doc)
+		require.NoError(t, err)
+	}
+
+	cursor, err := chunks.Find(ctx, bson.D{}, options.Find().SetSort(bson.D{{"n", 1}}))
+	require.NoError(t, err)
+
+	var actual []bson.D
+	require.NoError(t, cursor.All(ctx, &actual))
+	require.Len(t, actual, len(docs))
+
+	for i, doc := range docs {
+		AssertEqualDocuments(t, doc, actual[i])
+	}
+
+	update := bson.D{{"$set", bson.D{{"data", primitive.Binary{Data: []byte{4}}}}}}
+	_, err = chunks.UpdateOne(ctx, bson.D{{"_id", "chunk"}}, update)
+	require.NoError(t, err)
+
+	var doc bson.D
+	require.NoError(t, chunks.FindOne(ctx, bson.D{{"_id", "chunk"}}).Decode(&doc))
+	AssertEqualDocuments(t, bson.D{{"_id", "chunk"}, {"n", int32(0)}, {"data", primitive.Binary{Data: []byte{4}}}}, doc)
+}
@@ -61,6 +61,8 @@ func Convert(t testing.TB, v any) any {
 			Subtype: types.BinarySubtype(v.Subtype),
 			B:       v.Data,
 		}
+	case primitive.Undefined:
+		return types.Undefined
 	case primitive.ObjectID:
 		return types.ObjectID(v)
 	case bool:
@@ -74,12 +76,89 @@ func Convert(t testing.TB, v any) any {
 			Pattern: v.Pattern,
 			Options: v.Options,
 		}
+	case primitive.DBPointer:
+		return types.DBPointer{Namespace: v.DB, ID: types.ObjectID(v.Pointer)}
+	case primitive.JavaScript:
+		return types.JavaScript{Code: string(v)}
+	case primitive.Symbol:
+		return types.Symbol(v)
+	case primitive.CodeWithScope:
+		scope, ok := v.Scope.(bson.D)
+		if !ok {
+			t.Fatalf("unexpected scope type %T", v.Scope)
+		}
+		return types.JavaScriptWithScope{Code: string(v.Code), Scope: ConvertDocument(t, scope)}
 	case int32:
 		return v
 	case primitive.Timestamp:
 		return types.NewTimestamp(time.Unix(int64(v.T), 0), uint32(v.I))
 	case int64:
 		return v
+	case primitive.Decimal128:
+		h, l := v.GetBytes()
+		return types.Decimal128{H: h, L: l}
+	default:
+		t.Fatalf("unexpected type %T", v)
+		panic("not reached")
+	}
+}
+
+// Unconvert converts given FerretDB types package value to driver value (bson.D, bson.A, etc).
+//
+// It is the reverse of Convert; for example, it could be used to insert documents generated by testutil.Generator.
+func Unconvert(t testing.TB, v any) any {
+	t.Helper()
+
+	switch v := v.(type) {
+	// composite types
+	case *types.Document:
+		doc := make(bson.D, 0, v.Len())
+		for _, k := range v.Keys() {
+			doc = append(doc, bson.E{Key: k, Value: Unconvert(t, must.NotFail(v.Get(k)))})
+		}
+		return doc
+	case *types.Array:
+		arr := make(bson.A, v.Len())
+		for i := range arr {
+			arr[i] = Unconvert(t, must.NotFail(v.Get(i)))
+		}
+		return arr
+
+	// scalar types (in the same order as in types package)
+	case float64:
+		return v
+	case string:
+		return v
+	case types.Binary:
+		return primitive.Binary{Subtype: byte(v.Subtype), Data: v.B}
+	case types.UndefinedType:
+		return primitive.Undefined{}
+	case types.ObjectID:
+		return primitive.ObjectID(v)
+	case bool:
+		return v
+	case time.Time:
+		return primitive.NewDateTimeFromTime(v)
+	case types.NullType:
+		return nil
+	case types.Regex:
+		return primitive.Regex{Pattern: v.Pattern, Options: v.Options}
+	case types.DBPointer:
+		return primitive.DBPointer{DB: v.Namespace, Pointer: primitive.ObjectID(v.ID)}
+	case types.JavaScript:
+		return primitive.JavaScript(v.Code)
+	case types.Symbol:
+		return primitive.Symbol(v)
+	case types.JavaScriptWithScope:
+		return primitive.CodeWithScope{Code: primitive.JavaScript(v.Code), Scope: Unconvert(t, v.Scope)}
+	case int32:
+		return v
+	case types.Timestamp:
+		return primitive.Timestamp{T: uint32(v >> 32), I: uint32(v)}
+	case int64:
+		return v
+	case types.Decimal128:
+		return primitive.NewDecimal128(v.H, v.L)
 	default:
 		t.Fatalf("unexpected type %T", v)
 		panic("not reached")
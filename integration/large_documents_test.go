@@ -0,0 +1,108 @@
+// Copyright 2021 FerretDB Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package integration
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+func TestLargeDocuments(t *testing.T) {
+	t.Parallel()
+	ctx, collection := Setup(t)
+
+	// large enough to be stored separately by the `pg` handler with default settings
+	large := strings.Repeat("x", 4*1024*1024)
+
+	docs := []bson.D{
+		{{"_id", int32(1)}, {"v", "foo"}, {"n", int32(3)}, {"data", large}},
+		{{"_id", int32(2)}, {"v", "bar"}, {"n", int32(1)}},
+		{{"_id", int32(3)}, {"v", "foo"}, {"n", int32(2)}, {"data", large + "y"}},
+	}
+
+	for _, doc := range docs {
+		_, err := collection.InsertOne(ctx, doc)
+		require.NoError(t, err)
+	}
+
+	t.Run("FindByID", func(t *testing.T) {
+		t.Parallel()
+
+		var doc bson.D
+		require.NoError(t, collection.FindOne(ctx, bson.D{{"_id", int32(3)}}).Decode(&doc))
+		AssertEqualDocuments(t, docs[2], doc)
+	})
+
+	t.Run("FilterSort", func(t *testing.T) {
+		t.Parallel()
+
+		opts := options.Find().SetSort(bson.D{{"n", 1}}).SetProjection(bson.D{{"data", 0}})
+		cursor, err := collection.Find(ctx, bson.D{{"v", "foo"}}, opts)
+		require.NoError(t, err)
+
+		var actual []bson.D
+		require.NoError(t, cursor.All(ctx, &actual))
+
+		expected := []bson.D{
+			{{"_id", int32(3)}, {"v", "foo"}, {"n", int32(2)}},
+			{{"_id", int32(1)}, {"v", "foo"}, {"n", int32(3)}},
+		}
+		require.Len(t, actual, len(expected))
+		for i, doc := range expected {
+			AssertEqualDocuments(t, doc, actual[i])
+		}
+	})
+
+	t.Run("FilterLargeField", func(t *testing.T) {
+		t.Parallel()
+
+		cursor, err := collection.Find(ctx, bson.D{{"data", large}})
+		require.NoError(t, err)
+
+		var actual []bson.D
+		require.NoError(t, cursor.All(ctx, &actual))
+		require.Len(t, actual, 1)
+		AssertEqualDocuments(t, docs[0], actual[0])
+	})
+
+	t.Run("Update", func(t *testing.T) {
+		t.Parallel()
+
+		// use a separate collection, so other subtests are not affected
+		c := collection.Database().Collection(collection.Name() + "_update")
+		t.Cleanup(func() { _ = c.Drop(ctx) })
+
+		_, err := c.InsertOne(ctx, docs[0])
+		require.NoError(t, err)
+
+		// documents that are no longer large are stored as usual
+		_, err = c.UpdateOne(ctx, bson.D{{"_id", int32(1)}}, bson.D{{"$set", bson.D{{"data", "small"}}}})
+		require.NoError(t, err)
+
+		var doc bson.D
+		require.NoError(t, c.FindOne(ctx, bson.D{{"_id", int32(1)}}).Decode(&doc))
+		AssertEqualDocuments(t, bson.D{{"_id", int32(1)}, {"v", "foo"}, {"n", int32(3)}, {"data", "small"}}, doc)
+
+		_, err = c.UpdateOne(ctx, bson.D{{"_id", int32(1)}}, bson.D{{"$set", bson.D{{"data", large}}}})
+		require.NoError(t, err)
+
+		require.NoError(t, c.FindOne(ctx, bson.D{{"data", large}}).Decode(&doc))
+		AssertEqualDocuments(t, docs[0], doc)
+	})
+}
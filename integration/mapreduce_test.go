@@ -0,0 +1,114 @@
+// Copyright 2021 FerretDB Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package integration
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+func TestMapReduce(t *testing.T) {
+	t.Parallel()
+	ctx, collection := Setup(t)
+
+	_, err := collection.InsertMany(ctx, []any{
+		bson.D{{"_id", 1}, {"status", "A"}, {"amount", 10}},
+		bson.D{{"_id", 2}, {"status", "B"}, {"amount", 20}},
+		bson.D{{"_id", 3}, {"status", "A"}, {"amount", 30}},
+		bson.D{{"_id", 4}, {"status", "C"}, {"amount", 40}},
+	})
+	require.NoError(t, err)
+
+	sum := primitive.JavaScript("function(key, values) { return Array.sum(values); }")
+	inline := bson.D{{"inline", 1}}
+
+	for name, tc := range map[string]struct {
+		command  bson.D
+		expected bson.A
+		err      *mongo.CommandError
+	}{
+		"Count": {
+			command: bson.D{
+				{"map", primitive.JavaScript("function() { emit(this.status, 1); }")},
+				{"reduce", sum},
+				{"out", inline},
+			},
+			expected: bson.A{
+				bson.D{{"_id", "A"}, {"value", 2.0}},
+				bson.D{{"_id", "B"}, {"value", 1.0}},
+				bson.D{{"_id", "C"}, {"value", 1.0}},
+			},
+		},
+		"SumQuery": {
+			command: bson.D{
+				{"map", "function() { emit(this.status, this.amount); }"},
+				{"reduce", "function(k, v) { var s = 0; for (var i = 0; i < v.length; i++) { s += v[i]; } return s; }"},
+				{"query", bson.D{{"_id", bson.D{{"$lt", 4}}}}},
+				{"out", inline},
+			},
+			expected: bson.A{
+				bson.D{{"_id", "A"}, {"value", 40.0}},
+				bson.D{{"_id", "B"}, {"value", int32(20)}},
+			},
+		},
+		"UnsupportedMap": {
+			command: bson.D{
+				{"map", primitive.JavaScript("function() { emit(this.status, {amount: this.amount}); }")},
+				{"reduce", sum},
+				{"out", inline},
+			},
+			err: &mongo.CommandError{
+				Code: 238,
+				Name: "NotImplemented",
+				Message: "mapReduce: map function is not supported; " +
+					"only `function() { emit(this.<field>, <number or this.<field>>); }` is",
+			},
+		},
+		"UnsupportedOut": {
+			command: bson.D{
+				{"map", primitive.JavaScript("function() { emit(this.status, 1); }")},
+				{"reduce", sum},
+				{"out", "results"},
+			},
+			err: &mongo.CommandError{
+				Code:    238,
+				Name:    "NotImplemented",
+				Message: "mapReduce: only inline output ({out: {inline: 1}}) is supported",
+			},
+		},
+	} {
+		name, tc := name, tc
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			command := append(bson.D{{"mapReduce", collection.Name()}}, tc.command...)
+
+			var res bson.D
+			err := collection.Database().RunCommand(ctx, command).Decode(&res)
+			if tc.err != nil {
+				AssertEqualError(t, *tc.err, err)
+				return
+			}
+
+			require.NoError(t, err)
+			assert.Equal(t, bson.D{{"results", tc.expected}, {"ok", 1.0}}, res)
+		})
+	}
+}
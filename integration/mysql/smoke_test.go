@@ -0,0 +1,51 @@
+// Copyright 2021 FerretDB Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package mysql
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.mongodb.org/mongo-driver/bson"
+
+	"github.com/FerretDB/FerretDB/integration"
+	"github.com/FerretDB/FerretDB/integration/shareddata"
+)
+
+func TestSmoke(t *testing.T) {
+	t.Parallel()
+	ctx, collection := integration.Setup(t, shareddata.FixedScalars)
+
+	var doc bson.D
+	err := collection.FindOne(ctx, bson.D{{"_id", "double"}}).Decode(&doc)
+	require.NoError(t, err)
+	integration.AssertEqualDocuments(t, bson.D{{"_id", "double"}, {"double_value", 42.13}}, doc)
+
+	res, err := collection.UpdateOne(ctx, bson.D{{"_id", "double"}}, bson.D{{"$set", bson.D{{"double_value", 42.0}}}})
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), res.ModifiedCount)
+
+	err = collection.FindOne(ctx, bson.D{{"_id", "double"}}).Decode(&doc)
+	require.NoError(t, err)
+	integration.AssertEqualDocuments(t, bson.D{{"_id", "double"}, {"double_value", 42.0}}, doc)
+
+	_, err = collection.InsertOne(ctx, bson.D{{"_id", "double"}})
+	require.Error(t, err)
+
+	deleted, err := collection.DeleteOne(ctx, bson.D{{"_id", "double"}})
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), deleted.DeletedCount)
+}
@@ -66,6 +66,21 @@ func TestQueryProjection(t *testing.T) {
 			projection: bson.D{{"_id", false}, {"array", int32(1)}},
 			expected:   bson.D{},
 		},
+		"DotNotationInclusion": {
+			filter:     bson.D{{"_id", "document-composite"}},
+			projection: bson.D{{"value.foo", int32(1)}},
+			expected:   bson.D{{"_id", "document-composite"}, {"value", bson.D{{"foo", int32(42)}}}},
+		},
+		"DotNotationExclusion": {
+			filter:     bson.D{{"_id", "document-composite"}},
+			projection: bson.D{{"value.array", false}},
+			expected:   bson.D{{"_id", "document-composite"}, {"value", bson.D{{"foo", int32(42)}, {"42", "foo"}}}},
+		},
+		"DotNotationArrayInclusion": {
+			filter:     bson.D{{"_id", "document-composite-2"}},
+			projection: bson.D{{"_id", false}, {"value.field", true}},
+			expected:   bson.D{{"value", bson.A{bson.D{{"field", int32(42)}}, bson.D{{"field", int32(44)}}}}},
+		},
 		"ProjectionSliceNonArrayField": {
 			filter:     bson.D{{"_id", "document"}},
 			projection: bson.D{{"_id", bson.D{{"$slice", 1}}}},
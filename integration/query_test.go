@@ -15,6 +15,7 @@
 package integration
 
 import (
+	"math"
 	"testing"
 	"time"
 
@@ -26,6 +27,7 @@ import (
 	"go.mongodb.org/mongo-driver/mongo/options"
 
 	"github.com/FerretDB/FerretDB/integration/shareddata"
+	"github.com/FerretDB/FerretDB/internal/handlers"
 )
 
 func TestQueryUnknownFilterOperator(t *testing.T) {
@@ -310,7 +312,10 @@ func TestQuerySortValue(t *testing.T) {
 
 func TestQueryCount(t *testing.T) {
 	t.Parallel()
-	ctx, collection := Setup(t, shareddata.Scalars, shareddata.Composites)
+	ctx, collection, _ := SetupWithOpts(t, &SetupOpts{
+		Providers: []shareddata.Provider{shareddata.Scalars, shareddata.Composites},
+		Requires:  []handlers.Feature{handlers.FeatureCount},
+	})
 
 	for name, tc := range map[string]struct {
 		command  any
@@ -645,6 +650,82 @@ func TestQueryExactMatches(t *testing.T) {
 	}
 }
 
+func TestQuerySkip(t *testing.T) {
+	t.Parallel()
+	ctx, collection := Setup(t)
+
+	_, err := collection.InsertMany(ctx, []any{
+		bson.D{{"_id", int32(1)}, {"v", "foo"}},
+		bson.D{{"_id", int32(2)}, {"v", "bar"}},
+		bson.D{{"_id", int32(3)}, {"v", "foo"}},
+		bson.D{{"_id", int32(4)}, {"v", "bar"}},
+		bson.D{{"_id", int32(5)}, {"v", "foo"}},
+	})
+	require.NoError(t, err)
+
+	for name, tc := range map[string]struct {
+		filter      bson.D
+		opts        *options.FindOptions
+		expectedIDs []any // nil if the order is not defined
+		expectedLen int
+	}{
+		"Sort": {
+			filter:      bson.D{},
+			opts:        options.Find().SetSort(bson.D{{"_id", 1}}).SetSkip(2),
+			expectedIDs: []any{int32(3), int32(4), int32(5)},
+		},
+		"SortLimit": {
+			filter:      bson.D{},
+			opts:        options.Find().SetSort(bson.D{{"_id", -1}}).SetSkip(1).SetLimit(2),
+			expectedIDs: []any{int32(4), int32(3)},
+		},
+		"SortFilter": {
+			filter:      bson.D{{"v", "foo"}},
+			opts:        options.Find().SetSort(bson.D{{"_id", 1}}).SetSkip(1),
+			expectedIDs: []any{int32(3), int32(5)},
+		},
+		"SortAll": {
+			filter:      bson.D{},
+			opts:        options.Find().SetSort(bson.D{{"_id", 1}}).SetSkip(10),
+			expectedIDs: []any{},
+		},
+		"NoSort": {
+			filter:      bson.D{},
+			opts:        options.Find().SetSkip(3),
+			expectedLen: 2,
+		},
+		"NoSortFilterBatches": {
+			filter:      bson.D{{"v", "foo"}},
+			opts:        options.Find().SetSkip(1).SetBatchSize(1),
+			expectedLen: 2,
+		},
+		"NoSortAll": {
+			filter:      bson.D{},
+			opts:        options.Find().SetSkip(5),
+			expectedLen: 0,
+		},
+	} {
+		name, tc := name, tc
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			cursor, err := collection.Find(ctx, tc.filter, tc.opts)
+			require.NoError(t, err)
+
+			var actual []bson.D
+			err = cursor.All(ctx, &actual)
+			require.NoError(t, err)
+
+			if tc.expectedIDs != nil {
+				assert.Equal(t, tc.expectedIDs, CollectIDs(t, actual))
+				return
+			}
+
+			assert.Len(t, actual, tc.expectedLen)
+		})
+	}
+}
+
 func TestDotNotation(t *testing.T) {
 	t.Parallel()
 	ctx, collection := Setup(t)
@@ -715,6 +796,70 @@ func TestDotNotation(t *testing.T) {
 	}
 }
 
+func TestQueryMaxTimeMS(t *testing.T) {
+	t.Parallel()
+	ctx, collection := Setup(t, shareddata.Scalars)
+
+	for name, tc := range map[string]struct {
+		maxTimeMS any
+		err       *mongo.CommandError
+	}{
+		"Int32": {
+			maxTimeMS: int32(60000),
+		},
+		"Int64": {
+			maxTimeMS: int64(60000),
+		},
+		"Zero": {
+			maxTimeMS: int32(0),
+		},
+		"Negative": {
+			maxTimeMS: int32(-1),
+			err: &mongo.CommandError{
+				Code:    2,
+				Name:    "BadValue",
+				Message: "-1 value for maxTimeMS is out of range",
+			},
+		},
+		"TooLarge": {
+			maxTimeMS: int64(math.MaxInt32) + 1,
+			err: &mongo.CommandError{
+				Code:    2,
+				Name:    "BadValue",
+				Message: "2147483648 value for maxTimeMS is out of range",
+			},
+		},
+		"String": {
+			maxTimeMS: "1",
+			err: &mongo.CommandError{
+				Code:    2,
+				Name:    "BadValue",
+				Message: "maxTimeMS must be a number",
+			},
+		},
+	} {
+		name, tc := name, tc
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			for _, command := range []bson.D{
+				{{"find", collection.Name()}, {"maxTimeMS", tc.maxTimeMS}},
+				{{"count", collection.Name()}, {"maxTimeMS", tc.maxTimeMS}},
+			} {
+				var actual bson.D
+				err := collection.Database().RunCommand(ctx, command).Decode(&actual)
+				if tc.err != nil {
+					AssertEqualError(t, *tc.err, err)
+					continue
+				}
+
+				require.NoError(t, err)
+				assert.Equal(t, float64(1), actual.Map()["ok"])
+			}
+		})
+	}
+}
+
 // TestQueryNonExistingCollection tests that a query to a non existing collection doesn't fail but returns an empty result.
 func TestQueryNonExistingCollection(t *testing.T) {
 	t.Parallel()
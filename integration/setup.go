@@ -16,13 +16,18 @@ package integration
 
 import (
 	"context"
+	"crypto/tls"
+	"crypto/x509"
 	"flag"
 	"fmt"
 	"net"
+	"path/filepath"
 	"sync"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/require"
+	"go.mongodb.org/mongo-driver/bson"
 	"go.mongodb.org/mongo-driver/mongo"
 	"go.mongodb.org/mongo-driver/mongo/options"
 	"go.uber.org/zap"
@@ -30,8 +35,11 @@ import (
 
 	"github.com/FerretDB/FerretDB/integration/shareddata"
 	"github.com/FerretDB/FerretDB/internal/clientconn"
+	"github.com/FerretDB/FerretDB/internal/handlers"
+	"github.com/FerretDB/FerretDB/internal/handlers/pg/pgdb"
 	"github.com/FerretDB/FerretDB/internal/handlers/registry"
 	"github.com/FerretDB/FerretDB/internal/util/debug"
+	"github.com/FerretDB/FerretDB/internal/util/fault"
 	"github.com/FerretDB/FerretDB/internal/util/logging"
 	"github.com/FerretDB/FerretDB/internal/util/testutil"
 )
@@ -51,12 +59,31 @@ type SetupOpts struct {
 
 	// Data providers.
 	Providers []shareddata.Provider
+
+	// If true, in-process FerretDB listens with TLS using generated throwaway certificates,
+	// and the client connects with TLS and a client certificate.
+	// The test is skipped if in-process FerretDB is not used.
+	TLS bool
+
+	// If true, a throwaway user is created in the test database,
+	// and the client authenticates as that user with SCRAM-SHA-256 mechanism.
+	// It implies FeatureAuthentication and FeatureUserManagement requirements.
+	Auth bool
+
+	// Optional features required by the test.
+	// The test is skipped if the in-process FerretDB handler does not support any of them.
+	// They are not checked if in-process FerretDB is not used.
+	Requires []handlers.Feature
+
+	// If set, in-process FerretDB injects faults into client and backend connections.
+	// The test is skipped if in-process FerretDB is not used.
+	Faults *fault.Injector
 }
 
 // SetupWithOpts setups the test according to given options,
 // and returns test-specific context (that is cancelled when the test ends), database collection
 // and the port of the running server.
-func SetupWithOpts(t *testing.T, opts *SetupOpts) (context.Context, *mongo.Collection, int) {
+func SetupWithOpts(t testing.TB, opts *SetupOpts) (context.Context, *mongo.Collection, int) {
 	t.Helper()
 
 	startupOnce.Do(func() { startup(t) })
@@ -65,26 +92,74 @@ func SetupWithOpts(t *testing.T, opts *SetupOpts) (context.Context, *mongo.Colle
 		opts = new(SetupOpts)
 	}
 
-	var ownDatabase bool
-	if opts.DatabaseName == "" {
-		opts.DatabaseName = testutil.SchemaName(t)
-		ownDatabase = true
+	// debug logging significantly affects benchmark results
+	level := zap.DebugLevel
+	if _, ok := t.(*testing.B); ok {
+		level = zap.WarnLevel
 	}
 
-	logger := zaptest.NewLogger(t, zaptest.Level(zap.DebugLevel))
+	logger := zaptest.NewLogger(t, zaptest.Level(level))
 
 	ctx, cancel := context.WithCancel(context.Background())
 
+	var serverTLS *tls.Config
+	clientOpts := options.Client()
+
+	if opts.TLS {
+		if *portF != 0 {
+			t.Skip("TLS requires in-process FerretDB")
+		}
+
+		serverTLS, clientOpts = setupTLS(t)
+	}
+
+	if opts.Faults != nil && *portF != 0 {
+		t.Skip("Fault injection requires in-process FerretDB")
+	}
+
+	requires := opts.Requires
+	if opts.Auth {
+		requires = append([]handlers.Feature{handlers.FeatureAuthentication, handlers.FeatureUserManagement}, requires...)
+	}
+
+	var caps *handlers.Capabilities
 	port := *portF
 	if port == 0 {
-		port = setupListener(t, ctx, logger)
+		port, caps = setupListener(t, ctx, logger, serverTLS, opts.Faults)
 	}
 
 	// register cleanup function after setupListener's internal registration
 	t.Cleanup(cancel)
 
-	client := setupClient(t, ctx, port)
-	db := client.Database(opts.DatabaseName)
+	if caps != nil {
+		skipUnsupported(t, caps, requires)
+	}
+
+	databaseName, ownDatabase := opts.DatabaseName, false
+	if databaseName == "" {
+		databaseName, ownDatabase = testutil.SchemaName(t), true
+	}
+
+	collection := setupCollection(t, ctx, setupClient(t, ctx, port, clientOpts), databaseName, ownDatabase, opts.Providers)
+
+	if opts.Auth {
+		clientOpts.SetAuth(setupUser(t, ctx, collection.Database()))
+		collection = setupClient(t, ctx, port, clientOpts).Database(databaseName).Collection(collection.Name())
+	}
+
+	return ctx, collection, port
+}
+
+// setupCollection creates test-specific collection in the given database with the given client,
+// inserts documents from providers, and registers cleanup that drops the collection
+// (and the database if ownDatabase is true) unless the test failed.
+func setupCollection(
+	t testing.TB, ctx context.Context, client *mongo.Client, databaseName string, ownDatabase bool,
+	providers []shareddata.Provider,
+) *mongo.Collection {
+	t.Helper()
+
+	db := client.Database(databaseName)
 	collectionName := testutil.TableName(t)
 	collection := db.Collection(collectionName)
 
@@ -101,7 +176,7 @@ func SetupWithOpts(t *testing.T, opts *SetupOpts) (context.Context, *mongo.Colle
 	// delete collection and (possibly) database unless test failed
 	t.Cleanup(func() {
 		if t.Failed() {
-			t.Logf("Keeping database %q and collection %q for debugging.", opts.DatabaseName, collectionName)
+			t.Logf("Keeping database %q and collection %q for debugging.", databaseName, collectionName)
 			return
 		}
 
@@ -114,19 +189,19 @@ func SetupWithOpts(t *testing.T, opts *SetupOpts) (context.Context, *mongo.Colle
 		}
 	})
 
-	// insert all provided data
-	for _, provider := range opts.Providers {
-		for _, doc := range provider.Docs() {
+	// insert all provided data that could be stored by the handler
+	for _, provider := range providers {
+		for _, doc := range provider.DocsFor(*handlerF) {
 			_, err = collection.InsertOne(ctx, doc)
 			require.NoError(t, err)
 		}
 	}
 
-	return ctx, collection, port
+	return collection
 }
 
 // Setup calls setupWithOpts with specified data providers.
-func Setup(t *testing.T, providers ...shareddata.Provider) (context.Context, *mongo.Collection) {
+func Setup(t testing.TB, providers ...shareddata.Provider) (context.Context, *mongo.Collection) {
 	t.Helper()
 
 	ctx, collection, _ := SetupWithOpts(t, &SetupOpts{
@@ -136,15 +211,31 @@ func Setup(t *testing.T, providers ...shareddata.Provider) (context.Context, *mo
 }
 
 // setupListener starts in-process FerretDB server that runs until ctx is done,
-// and returns listening port number.
-func setupListener(t *testing.T, ctx context.Context, logger *zap.Logger) int {
+// and returns listening port number and handler's capabilities.
+//
+// If tlsConfig is not nil, the listener uses TLS.
+// If faults is not nil, they are injected into client and backend connections.
+func setupListener(
+	t testing.TB, ctx context.Context, logger *zap.Logger, tlsConfig *tls.Config, faults *fault.Injector,
+) (int, *handlers.Capabilities) {
 	t.Helper()
 
 	h, err := registry.NewHandler(*handlerF, &registry.NewHandlerOpts{
-		Ctx:           ctx,
-		Logger:        logger,
-		PostgreSQLURL: testutil.PoolConnString(t, nil),
-		TigrisURL:     "127.0.0.1:8081",
+		Ctx:                ctx,
+		Logger:             logger,
+		PostgreSQLURL:      testutil.PoolConnString(t, nil),
+		PostgreSQLGINIndex: true,
+
+		PostgreSQLMetadataCacheTTL:       time.Minute,
+		PostgreSQLLargeDocumentThreshold: pgdb.DefaultLargeDocumentThreshold,
+
+		TigrisURL: "127.0.0.1:8081",
+
+		SQLiteURL: filepath.Join(t.TempDir(), "ferretdb.sqlite"),
+
+		MySQLURL: "root@tcp(127.0.0.1:3306)/ferretdb",
+
+		Faults: faults,
 	})
 	require.NoError(t, err)
 
@@ -160,6 +251,8 @@ func setupListener(t *testing.T, ctx context.Context, logger *zap.Logger) int {
 		Mode:       mode,
 		Handler:    h,
 		Logger:     logger,
+		TLS:        tlsConfig,
+		Faults:     faults,
 	})
 
 	done := make(chan struct{})
@@ -180,12 +273,38 @@ func setupListener(t *testing.T, ctx context.Context, logger *zap.Logger) int {
 		h.Close()
 	})
 
-	return l.Addr().(*net.TCPAddr).Port
+	// Addr returns nil if listener failed to start
+	addr, ok := l.Addr().(*net.TCPAddr)
+	require.True(t, ok, "listener failed to start")
+
+	return addr.Port, h.Capabilities()
+}
+
+// skipUnsupported skips the test if the handler with given capabilities
+// does not support any of the required features.
+func skipUnsupported(t testing.TB, caps *handlers.Capabilities, requires []handlers.Feature) {
+	t.Helper()
+
+	for _, f := range requires {
+		if caps.Supports(f) {
+			continue
+		}
+
+		msg := fmt.Sprintf("Feature %q is not supported by %q handler", f, caps.Handler)
+		if issue := caps.Unsupported[f]; issue != "" {
+			msg += "; see " + issue
+		}
+
+		t.Skip(msg)
+	}
 }
 
-func setupClient(t *testing.T, ctx context.Context, port int) *mongo.Client {
+// setupClient connects to the server on the given port with additional options (TLS, credentials, etc).
+func setupClient(t testing.TB, ctx context.Context, port int, opts ...*options.ClientOptions) *mongo.Client {
+	t.Helper()
+
 	uri := fmt.Sprintf("mongodb://127.0.0.1:%d", port)
-	client, err := mongo.Connect(ctx, options.Client().ApplyURI(uri))
+	client, err := mongo.Connect(ctx, append([]*options.ClientOptions{options.Client().ApplyURI(uri)}, opts...)...)
 	require.NoError(t, err)
 	err = client.Ping(ctx, nil)
 	require.NoError(t, err)
@@ -197,11 +316,71 @@ func setupClient(t *testing.T, ctx context.Context, port int) *mongo.Client {
 	return client
 }
 
+// setupTLS generates throwaway CA, server, and client certificates,
+// and returns listener's TLS configuration that verifies client certificates,
+// and client options that use them.
+func setupTLS(t testing.TB) (*tls.Config, *options.ClientOptions) {
+	t.Helper()
+
+	dir := t.TempDir()
+	ca := testutil.GenerateCert(t, dir, "ca", nil)
+	server := testutil.GenerateCert(t, dir, "server", ca, "127.0.0.1", "localhost")
+	client := testutil.GenerateCert(t, dir, "client", ca)
+
+	serverTLS, err := clientconn.NewTLSConfig(&clientconn.TLSOpts{
+		CertFiles:         []string{server.CertFile},
+		KeyFiles:          []string{server.KeyFile},
+		CAFile:            ca.CertFile,
+		RequireClientCert: true,
+	})
+	require.NoError(t, err)
+
+	roots := x509.NewCertPool()
+	roots.AddCert(ca.Cert)
+
+	clientCert, err := tls.LoadX509KeyPair(client.CertFile, client.KeyFile)
+	require.NoError(t, err)
+
+	clientTLS := &tls.Config{
+		RootCAs:      roots,
+		Certificates: []tls.Certificate{clientCert},
+		MinVersion:   tls.VersionTLS12,
+	}
+
+	return serverTLS, options.Client().SetTLSConfig(clientTLS)
+}
+
+// setupUser creates a throwaway user in the given database, registers cleanup that drops it,
+// and returns credential for that user.
+func setupUser(t testing.TB, ctx context.Context, db *mongo.Database) options.Credential {
+	t.Helper()
+
+	username, password := testutil.TableName(t), "password"
+
+	err := db.RunCommand(ctx, bson.D{
+		{"createUser", username},
+		{"pwd", password},
+		{"roles", bson.A{}},
+	}).Err()
+	require.NoError(t, err)
+
+	t.Cleanup(func() {
+		require.NoError(t, db.RunCommand(ctx, bson.D{{"dropUser", username}}).Err())
+	})
+
+	return options.Credential{
+		AuthMechanism: "SCRAM-SHA-256",
+		AuthSource:    db.Name(),
+		Username:      username,
+		Password:      password,
+	}
+}
+
 // startup initializes things that should be initialized only once.
-func startup(t *testing.T) {
+func startup(t testing.TB) {
 	t.Helper()
 
-	logging.Setup(zap.DebugLevel)
+	logging.Setup(zap.DebugLevel, logging.FormatText)
 
 	ctx := context.Background()
 
@@ -0,0 +1,202 @@
+// Copyright 2021 FerretDB Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package shareddata
+
+import (
+	"math"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+
+	"github.com/FerretDB/FerretDB/internal/util/must"
+)
+
+// Those shared data sets cover every BSON type and edge values of them.
+// Unlike Scalars and Composites, they are declared with handlers that can't store some values
+// (see Provider.DocsFor); use them in tests that should work with every type.
+
+// Doubles contain edge double values.
+var Doubles = &Values[string]{
+	data: map[string]any{
+		"double-zero":              0.0,
+		"double-negative-zero":     math.Copysign(0, -1),
+		"double-max":               math.MaxFloat64,
+		"double-min":               -math.MaxFloat64,
+		"double-smallest":          math.SmallestNonzeroFloat64,
+		"double-negative-smallest": -math.SmallestNonzeroFloat64,
+		"double-max-safe":          float64(1<<53 - 1),
+		"double-min-safe":          -float64(1<<53 - 1),
+		"double-positive-infinity": math.Inf(+1),
+		"double-negative-infinity": math.Inf(-1),
+		"double-nan":               math.NaN(),
+	},
+	incompatibility: incompatibility[string]{
+		ids: map[string][]string{
+			"double-positive-infinity": {tigris},
+			"double-negative-infinity": {tigris},
+			"double-nan":               {tigris},
+		},
+	},
+}
+
+// Integers contain edge int32 and int64 values.
+var Integers = &Values[string]{
+	data: map[string]any{
+		"int32-zero":          int32(0),
+		"int32-minus-one":     int32(-1),
+		"int32-max":           int32(math.MaxInt32),
+		"int32-min":           int32(math.MinInt32),
+		"int64-zero":          int64(0),
+		"int64-minus-one":     int64(-1),
+		"int64-int32-max":     int64(math.MaxInt32) + 1,
+		"int64-int32-min":     int64(math.MinInt32) - 1,
+		"int64-max-safe":      int64(1<<53 - 1),
+		"int64-above-safe":    int64(1<<53 + 1),
+		"int64-max":           int64(math.MaxInt64),
+		"int64-min":           int64(math.MinInt64),
+		"int64-max-minus-one": int64(math.MaxInt64 - 1),
+	},
+}
+
+// Decimal128s contain 128-bit decimal floating point values.
+var Decimal128s = &Values[string]{
+	data: map[string]any{
+		"decimal128":                   must.NotFail(primitive.ParseDecimal128("42.13")),
+		"decimal128-whole":             must.NotFail(primitive.ParseDecimal128("42")),
+		"decimal128-zero":              must.NotFail(primitive.ParseDecimal128("0")),
+		"decimal128-negative-zero":     must.NotFail(primitive.ParseDecimal128("-0")),
+		"decimal128-max":               must.NotFail(primitive.ParseDecimal128("9.999999999999999999999999999999999E+6144")),
+		"decimal128-smallest":          must.NotFail(primitive.ParseDecimal128("1E-6176")),
+		"decimal128-positive-infinity": must.NotFail(primitive.ParseDecimal128("Infinity")),
+		"decimal128-negative-infinity": must.NotFail(primitive.ParseDecimal128("-Infinity")),
+		"decimal128-nan":               must.NotFail(primitive.ParseDecimal128("NaN")),
+	},
+	incompatibility: incompatibility[string]{
+		handlers: []string{tigris},
+	},
+}
+
+// Binaries contain binary values of all subtypes.
+var Binaries = &Values[string]{
+	data: map[string]any{
+		"binary-generic":   primitive.Binary{Subtype: 0x00, Data: []byte{42, 0, 13}},
+		"binary-function":  primitive.Binary{Subtype: 0x01, Data: []byte{42, 0, 13}},
+		"binary-old":       primitive.Binary{Subtype: 0x02, Data: []byte{42, 0, 13}},
+		"binary-uuid-old":  primitive.Binary{Subtype: 0x03, Data: make([]byte, 16)},
+		"binary-uuid":      primitive.Binary{Subtype: 0x04, Data: make([]byte, 16)},
+		"binary-md5":       primitive.Binary{Subtype: 0x05, Data: make([]byte, 16)},
+		"binary-encrypted": primitive.Binary{Subtype: 0x06, Data: []byte{42, 0, 13}},
+		"binary-column":    primitive.Binary{Subtype: 0x07, Data: []byte{42, 0, 13}},
+		"binary-user":      primitive.Binary{Subtype: 0x80, Data: []byte{42, 0, 13}},
+		"binary-user-max":  primitive.Binary{Subtype: 0xff, Data: []byte{42, 0, 13}},
+		"binary-empty":     primitive.Binary{Subtype: 0x00, Data: []byte{}},
+	},
+}
+
+// Regexes contain regular expressions with different options and special characters.
+var Regexes = &Values[string]{
+	data: map[string]any{
+		"regex":               primitive.Regex{Pattern: "foo"},
+		"regex-options":       primitive.Regex{Pattern: "^foo$", Options: "imsx"},
+		"regex-special-chars": primitive.Regex{Pattern: `\d+\.\d*[/"'\\]`, Options: "i"},
+		"regex-unicode":       primitive.Regex{Pattern: "фу", Options: "u"},
+		"regex-empty":         primitive.Regex{},
+	},
+	incompatibility: incompatibility[string]{
+		handlers: []string{tigris},
+	},
+}
+
+// Timestamps contain timestamp values.
+var Timestamps = &Values[string]{
+	data: map[string]any{
+		"timestamp":      primitive.Timestamp{T: 42, I: 13},
+		"timestamp-zero": primitive.Timestamp{},
+		"timestamp-i":    primitive.Timestamp{I: 1},
+		"timestamp-max":  primitive.Timestamp{T: math.MaxUint32, I: math.MaxUint32},
+	},
+	incompatibility: incompatibility[string]{
+		handlers: []string{tigris},
+	},
+}
+
+// MinMaxKeys contain MinKey and MaxKey values.
+var MinMaxKeys = &Values[string]{
+	data: map[string]any{
+		"min-key": primitive.MinKey{},
+		"max-key": primitive.MaxKey{},
+	},
+	incompatibility: incompatibility[string]{
+		handlers: ferretDBHandlers,
+	},
+}
+
+// Nested contain deeply nested documents and arrays, and long arrays.
+var Nested = &Values[string]{
+	data: map[string]any{
+		"document-nested":   nestedDocument(50),
+		"array-nested":      nestedArray(50),
+		"array-long":        longArray(1000),
+		"array-long-mixed":  bson.A{int32(42), 42.13, "foo", nil, bson.D{{"foo", int32(42)}}, bson.A{}, true},
+		"document-long-key": bson.D{{longKey(1000), int32(42)}},
+	},
+	incompatibility: incompatibility[string]{
+		ids: map[string][]string{
+			"array-nested":     {tigris},
+			"array-long":       {tigris},
+			"array-long-mixed": {tigris},
+		},
+	},
+}
+
+// nestedDocument returns a document with the given nesting depth.
+func nestedDocument(depth int) bson.D {
+	res := bson.D{{"value", int32(42)}}
+	for i := 1; i < depth; i++ {
+		res = bson.D{{"nested", res}}
+	}
+
+	return res
+}
+
+// nestedArray returns an array with the given nesting depth.
+func nestedArray(depth int) bson.A {
+	res := bson.A{int32(42)}
+	for i := 1; i < depth; i++ {
+		res = bson.A{res}
+	}
+
+	return res
+}
+
+// longArray returns an array of int32 values from 0 to n-1.
+func longArray(n int) bson.A {
+	res := make(bson.A, n)
+	for i := range res {
+		res[i] = int32(i)
+	}
+
+	return res
+}
+
+// longKey returns a field name of the given length.
+func longKey(n int) string {
+	res := make([]byte, n)
+	for i := range res {
+		res[i] = 'a' + byte(i%26)
+	}
+
+	return string(res)
+}
@@ -23,22 +23,64 @@ import (
 
 // Provider is implemented by shared data sets that provide documents.
 type Provider interface {
-	// Docs returns shared data documents. All calls should return the same data.
+	// Docs returns all shared data documents. All calls should return the same data.
 	Docs() []bson.D
+
+	// DocsFor returns shared data documents that could be stored by the given handler.
+	DocsFor(handler string) []bson.D
+}
+
+// Handler names used to declare values that are not supported by some handlers.
+const (
+	pg     = "pg"
+	tigris = "tigris"
+	sqlite = "sqlite"
+	mysql  = "mysql"
+)
+
+// ferretDBHandlers contains all FerretDB handlers; it is used for values not supported by FerretDB at all.
+var ferretDBHandlers = []string{pg, tigris, sqlite, mysql}
+
+// incompatibility declares handlers that can't store some documents of the shared data set.
+type incompatibility[idType constraints.Ordered] struct {
+	// handlers that can't store any document
+	handlers []string
+
+	// handlers that can't store documents with given IDs
+	ids map[idType][]string
+}
+
+// skip returns true if the given handler can't store the document with the given ID.
+func (inc *incompatibility[idType]) skip(handler string, id idType) bool {
+	if handler == "" {
+		return false
+	}
+
+	return slices.Contains(inc.handlers, handler) || slices.Contains(inc.ids[id], handler)
 }
 
 // Docs stores shared data documents as maps.
 type Docs[idType constraints.Ordered] struct {
 	data map[idType]map[string]any
+	incompatibility[idType]
 }
 
 // Docs implement Provider interface.
 func (docs *Docs[idType]) Docs() []bson.D {
+	return docs.DocsFor("")
+}
+
+// DocsFor implement Provider interface.
+func (docs *Docs[idType]) DocsFor(handler string) []bson.D {
 	ids := maps.Keys(docs.data)
 	slices.Sort(ids)
 
 	res := make([]bson.D, 0, len(docs.data))
 	for _, id := range ids {
+		if docs.skip(handler, id) {
+			continue
+		}
+
 		doc := docs.data[id]
 
 		d := make(bson.D, 0, len(doc)+1)
@@ -56,15 +98,25 @@ func (docs *Docs[idType]) Docs() []bson.D {
 // Values stores shared data documents as {"_id": key, "value": value} documents.
 type Values[idType constraints.Ordered] struct {
 	data map[idType]any
+	incompatibility[idType]
 }
 
 // Docs implement Provider interface.
 func (values *Values[idType]) Docs() []bson.D {
+	return values.DocsFor("")
+}
+
+// DocsFor implement Provider interface.
+func (values *Values[idType]) DocsFor(handler string) []bson.D {
 	ids := maps.Keys(values.data)
 	slices.Sort(ids)
 
 	res := make([]bson.D, 0, len(values.data))
 	for _, id := range ids {
+		if values.skip(handler, id) {
+			continue
+		}
+
 		res = append(res, bson.D{{"_id", id}, {"value", values.data[id]}})
 	}
 
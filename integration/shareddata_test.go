@@ -18,6 +18,8 @@ import (
 	"testing"
 
 	"github.com/stretchr/testify/require"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo/options"
 
 	"github.com/FerretDB/FerretDB/integration/shareddata"
 )
@@ -42,3 +44,38 @@ func TestEnvData(t *testing.T) {
 		}
 	}
 }
+
+func TestSharedDataRoundTrip(t *testing.T) {
+	t.Parallel()
+
+	for name, provider := range map[string]shareddata.Provider{
+		"Doubles":     shareddata.Doubles,
+		"Integers":    shareddata.Integers,
+		"Decimal128s": shareddata.Decimal128s,
+		"Binaries":    shareddata.Binaries,
+		"Regexes":     shareddata.Regexes,
+		"Timestamps":  shareddata.Timestamps,
+		"MinMaxKeys":  shareddata.MinMaxKeys,
+		"Nested":      shareddata.Nested,
+	} {
+		name, provider := name, provider
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			ctx, collection := Setup(t, provider)
+
+			cursor, err := collection.Find(ctx, bson.D{}, options.Find().SetSort(bson.D{{"_id", 1}}))
+			require.NoError(t, err)
+
+			var actual []bson.D
+			require.NoError(t, cursor.All(ctx, &actual))
+
+			expected := provider.DocsFor(*handlerF)
+			require.Len(t, actual, len(expected))
+
+			for i, doc := range expected {
+				AssertEqualDocuments(t, doc, actual[i])
+			}
+		})
+	}
+}
@@ -28,6 +28,7 @@ import (
 
 	"github.com/FerretDB/FerretDB/integration/shareddata"
 	"github.com/FerretDB/FerretDB/internal/types"
+	"github.com/FerretDB/FerretDB/internal/util/must"
 	"github.com/FerretDB/FerretDB/internal/util/testutil"
 )
 
@@ -291,6 +292,19 @@ func TestUpdateFieldInc(t *testing.T) {
 				update:   bson.D{{"$inc", bson.D{{"value", float64(42.13)}}}},
 				expected: bson.D{{"_id", "double"}, {"value", float64(84.26)}},
 			},
+			"DotNotation": {
+				filter: bson.D{{"_id", "document-composite"}},
+				update: bson.D{{"$inc", bson.D{{"value.foo", int32(1)}, {"value.array.0", int32(1)}}}},
+				expected: bson.D{
+					{"_id", "document-composite"},
+					{"value", bson.D{{"foo", int32(43)}, {"42", "foo"}, {"array", bson.A{int32(43), "foo", nil}}}},
+				},
+			},
+			"DotNotationMissingField": {
+				filter:   bson.D{{"_id", "double"}},
+				update:   bson.D{{"$inc", bson.D{{"foo.bar", int32(1)}}}},
+				expected: bson.D{{"_id", "double"}, {"value", float64(42.13)}, {"foo", bson.D{{"bar", int32(1)}}}},
+			},
 			"DoubleIncrementNaN": {
 				filter:   bson.D{{"_id", "double"}},
 				update:   bson.D{{"$inc", bson.D{{"value", math.NaN()}}}},
@@ -366,6 +380,11 @@ func TestUpdateFieldInc(t *testing.T) {
 				update:   bson.D{{"$inc", bson.D{{"value", int64(1)}}}},
 				expected: bson.D{{"_id", "int32"}, {"value", int64(43)}},
 			},
+			"DecimalIncrementLongField": {
+				filter:   bson.D{{"_id", "int64"}},
+				update:   bson.D{{"$inc", bson.D{{"value", must.NotFail(primitive.ParseDecimal128("0.01"))}}}},
+				expected: bson.D{{"_id", "int64"}, {"value", must.NotFail(primitive.ParseDecimal128("42.01"))}},
+			},
 
 			"FieldNotExist": {
 				filter:   bson.D{{"_id", "int32"}},
@@ -482,15 +501,255 @@ func TestUpdateFieldInc(t *testing.T) {
 }
 
 func TestUpdateFieldMin(t *testing.T) {
-	// TODO https://github.com/FerretDB/FerretDB/issues/623
+	t.Parallel()
+
+	for name, tc := range map[string]struct {
+		id       string
+		update   bson.D
+		expected bson.D
+		stat     *mongo.UpdateResult
+	}{
+		"Less": {
+			id:       "int32",
+			update:   bson.D{{"$min", bson.D{{"value", float64(41.5)}}}},
+			expected: bson.D{{"_id", "int32"}, {"value", float64(41.5)}},
+			stat: &mongo.UpdateResult{
+				MatchedCount:  1,
+				ModifiedCount: 1,
+				UpsertedCount: 0,
+			},
+		},
+		"Greater": {
+			id:       "int32",
+			update:   bson.D{{"$min", bson.D{{"value", int64(43)}}}},
+			expected: bson.D{{"_id", "int32"}, {"value", int32(42)}},
+			stat: &mongo.UpdateResult{
+				MatchedCount:  1,
+				ModifiedCount: 0,
+				UpsertedCount: 0,
+			},
+		},
+		"EqualOtherType": {
+			id:       "int32",
+			update:   bson.D{{"$min", bson.D{{"value", float64(42)}}}},
+			expected: bson.D{{"_id", "int32"}, {"value", int32(42)}},
+			stat: &mongo.UpdateResult{
+				MatchedCount:  1,
+				ModifiedCount: 0,
+				UpsertedCount: 0,
+			},
+		},
+		"CrossTypeNull": {
+			id:       "string",
+			update:   bson.D{{"$min", bson.D{{"value", nil}}}},
+			expected: bson.D{{"_id", "string"}, {"value", nil}},
+			stat: &mongo.UpdateResult{
+				MatchedCount:  1,
+				ModifiedCount: 1,
+				UpsertedCount: 0,
+			},
+		},
+		"FieldNotExist": {
+			id:       "int32",
+			update:   bson.D{{"$min", bson.D{{"foo", int32(1)}}}},
+			expected: bson.D{{"_id", "int32"}, {"value", int32(42)}, {"foo", int32(1)}},
+			stat: &mongo.UpdateResult{
+				MatchedCount:  1,
+				ModifiedCount: 1,
+				UpsertedCount: 0,
+			},
+		},
+	} {
+		name, tc := name, tc
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+			ctx, collection := Setup(t, shareddata.Scalars)
+
+			res, err := collection.UpdateOne(ctx, bson.D{{"_id", tc.id}}, tc.update)
+			require.NoError(t, err)
+			require.Equal(t, tc.stat, res)
+
+			var actual bson.D
+			err = collection.FindOne(ctx, bson.D{{"_id", tc.id}}).Decode(&actual)
+			require.NoError(t, err)
+			AssertEqualDocuments(t, tc.expected, actual)
+		})
+	}
 }
 
 func TestUpdateFieldMax(t *testing.T) {
-	// TODO https://github.com/FerretDB/FerretDB/issues/624
+	t.Parallel()
+
+	for name, tc := range map[string]struct {
+		id       string
+		update   bson.D
+		expected bson.D
+		stat     *mongo.UpdateResult
+	}{
+		"Greater": {
+			id:       "int32",
+			update:   bson.D{{"$max", bson.D{{"value", int64(43)}}}},
+			expected: bson.D{{"_id", "int32"}, {"value", int64(43)}},
+			stat: &mongo.UpdateResult{
+				MatchedCount:  1,
+				ModifiedCount: 1,
+				UpsertedCount: 0,
+			},
+		},
+		"Less": {
+			id:       "int32",
+			update:   bson.D{{"$max", bson.D{{"value", float64(41.5)}}}},
+			expected: bson.D{{"_id", "int32"}, {"value", int32(42)}},
+			stat: &mongo.UpdateResult{
+				MatchedCount:  1,
+				ModifiedCount: 0,
+				UpsertedCount: 0,
+			},
+		},
+		"CrossTypeString": {
+			id:       "int32",
+			update:   bson.D{{"$max", bson.D{{"value", "foo"}}}},
+			expected: bson.D{{"_id", "int32"}, {"value", "foo"}},
+			stat: &mongo.UpdateResult{
+				MatchedCount:  1,
+				ModifiedCount: 1,
+				UpsertedCount: 0,
+			},
+		},
+		"Document": {
+			id:       "document",
+			update:   bson.D{{"$max", bson.D{{"value", bson.D{{"foo", int32(43)}}}}}},
+			expected: bson.D{{"_id", "document"}, {"value", bson.D{{"foo", int32(43)}}}},
+			stat: &mongo.UpdateResult{
+				MatchedCount:  1,
+				ModifiedCount: 1,
+				UpsertedCount: 0,
+			},
+		},
+		"DotNotation": {
+			id:       "document",
+			update:   bson.D{{"$max", bson.D{{"value.foo", int32(41)}}}},
+			expected: bson.D{{"_id", "document"}, {"value", bson.D{{"foo", int32(42)}}}},
+			stat: &mongo.UpdateResult{
+				MatchedCount:  1,
+				ModifiedCount: 0,
+				UpsertedCount: 0,
+			},
+		},
+	} {
+		name, tc := name, tc
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+			ctx, collection := Setup(t, shareddata.Scalars, shareddata.Composites)
+
+			res, err := collection.UpdateOne(ctx, bson.D{{"_id", tc.id}}, tc.update)
+			require.NoError(t, err)
+			require.Equal(t, tc.stat, res)
+
+			var actual bson.D
+			err = collection.FindOne(ctx, bson.D{{"_id", tc.id}}).Decode(&actual)
+			require.NoError(t, err)
+			AssertEqualDocuments(t, tc.expected, actual)
+		})
+	}
 }
 
 func TestUpdateFieldMul(t *testing.T) {
-	// TODO https://github.com/FerretDB/FerretDB/issues/625
+	t.Parallel()
+
+	t.Run("Ok", func(t *testing.T) {
+		t.Parallel()
+
+		for name, tc := range map[string]struct {
+			filter   bson.D
+			update   bson.D
+			expected bson.D
+		}{
+			"Int": {
+				filter:   bson.D{{"_id", "int32"}},
+				update:   bson.D{{"$mul", bson.D{{"value", int32(2)}}}},
+				expected: bson.D{{"_id", "int32"}, {"value", int32(84)}},
+			},
+			"IntOverflow": {
+				filter:   bson.D{{"_id", "int32-max"}},
+				update:   bson.D{{"$mul", bson.D{{"value", int32(2)}}}},
+				expected: bson.D{{"_id", "int32-max"}, {"value", int64(math.MaxInt32) * 2}},
+			},
+			"LongIntField": {
+				filter:   bson.D{{"_id", "int32"}},
+				update:   bson.D{{"$mul", bson.D{{"value", int64(2)}}}},
+				expected: bson.D{{"_id", "int32"}, {"value", int64(84)}},
+			},
+			"DoubleLongField": {
+				filter:   bson.D{{"_id", "int64"}},
+				update:   bson.D{{"$mul", bson.D{{"value", float64(0.5)}}}},
+				expected: bson.D{{"_id", "int64"}, {"value", float64(21)}},
+			},
+			"DecimalIntField": {
+				filter:   bson.D{{"_id", "int32"}},
+				update:   bson.D{{"$mul", bson.D{{"value", must.NotFail(primitive.ParseDecimal128("1.5"))}}}},
+				expected: bson.D{{"_id", "int32"}, {"value", must.NotFail(primitive.ParseDecimal128("63.0"))}},
+			},
+			"FieldNotExist": {
+				filter:   bson.D{{"_id", "int32"}},
+				update:   bson.D{{"$mul", bson.D{{"foo", int64(5)}}}},
+				expected: bson.D{{"_id", "int32"}, {"value", int32(42)}, {"foo", int64(0)}},
+			},
+		} {
+			name, tc := name, tc
+			t.Run(name, func(t *testing.T) {
+				t.Parallel()
+				ctx, collection := Setup(t, shareddata.Scalars)
+
+				_, err := collection.UpdateOne(ctx, tc.filter, tc.update)
+				require.NoError(t, err)
+
+				var actual bson.D
+				err = collection.FindOne(ctx, tc.filter).Decode(&actual)
+				require.NoError(t, err)
+
+				AssertEqualDocuments(t, tc.expected, actual)
+			})
+		}
+	})
+
+	t.Run("Err", func(t *testing.T) {
+		t.Parallel()
+
+		for name, tc := range map[string]struct {
+			filter bson.D
+			update bson.D
+			err    *mongo.WriteError
+		}{
+			"MulOnString": {
+				filter: bson.D{{"_id", "string"}},
+				update: bson.D{{"$mul", bson.D{{"value", int32(2)}}}},
+				err: &mongo.WriteError{
+					Code: 14,
+					Message: `Cannot apply $mul to a value of non-numeric type. ` +
+						`{_id: "string"} has the field 'value' of non-numeric type string`,
+				},
+			},
+			"MulWithStringValue": {
+				filter: bson.D{{"_id", "int32"}},
+				update: bson.D{{"$mul", bson.D{{"value", "bad value"}}}},
+				err: &mongo.WriteError{
+					Code:    14,
+					Message: `Cannot multiply with non-numeric argument: {value: "bad value"}`,
+				},
+			},
+		} {
+			name, tc := name, tc
+			t.Run(name, func(t *testing.T) {
+				t.Parallel()
+				ctx, collection := Setup(t, shareddata.Scalars)
+
+				_, err := collection.UpdateOne(ctx, tc.filter, tc.update)
+				require.NotNil(t, tc.err)
+				AssertEqualWriteError(t, *tc.err, err)
+			})
+		}
+	})
 }
 
 func TestUpdateFieldRename(t *testing.T) {
@@ -658,6 +917,59 @@ func TestUpdateFieldSet(t *testing.T) {
 				UpsertedCount: 0,
 			},
 		},
+		"DotNotationDocumentField": {
+			id:     "document-composite",
+			update: bson.D{{"$set", bson.D{{"value.foo", int32(43)}}}},
+			result: bson.D{
+				{"_id", "document-composite"},
+				{"value", bson.D{{"foo", int32(43)}, {"42", "foo"}, {"array", bson.A{int32(42), "foo", nil}}}},
+			},
+			stat: &mongo.UpdateResult{
+				MatchedCount:  1,
+				ModifiedCount: 1,
+				UpsertedCount: 0,
+			},
+		},
+		"DotNotationArrayElement": {
+			id:     "document-composite",
+			update: bson.D{{"$set", bson.D{{"value.array.1", "bar"}}}},
+			result: bson.D{
+				{"_id", "document-composite"},
+				{"value", bson.D{{"foo", int32(42)}, {"42", "foo"}, {"array", bson.A{int32(42), "bar", nil}}}},
+			},
+			stat: &mongo.UpdateResult{
+				MatchedCount:  1,
+				ModifiedCount: 1,
+				UpsertedCount: 0,
+			},
+		},
+		"DotNotationMissingField": {
+			id:     "double",
+			update: bson.D{{"$set", bson.D{{"foo.bar", int32(1)}}}},
+			result: bson.D{{"_id", "double"}, {"value", float64(42.13)}, {"foo", bson.D{{"bar", int32(1)}}}},
+			stat: &mongo.UpdateResult{
+				MatchedCount:  1,
+				ModifiedCount: 1,
+				UpsertedCount: 0,
+			},
+		},
+		"DotNotationPathNotViable": {
+			id:     "string",
+			update: bson.D{{"$set", bson.D{{"value.foo", int32(1)}}}},
+			err: &mongo.WriteError{
+				Code:    28,
+				Message: `Cannot create field 'foo' in element {value: "foo"}`,
+			},
+			alt: "Cannot create field in path 'value.foo'",
+		},
+		"DotNotationEmptyFieldName": {
+			id:     "string",
+			update: bson.D{{"$set", bson.D{{"value..foo", int32(1)}}}},
+			err: &mongo.WriteError{
+				Code:    56,
+				Message: "The update path 'value..foo' contains an empty field name, which is not allowed.",
+			},
+		},
 	} {
 		name, tc := name, tc
 		t.Run(name, func(t *testing.T) {
@@ -834,6 +1146,16 @@ func TestUpdateFieldUnset(t *testing.T) {
 				UpsertedCount: 1,
 			},
 		},
+		"DotNotation": {
+			filter:   bson.D{{"_id", "document-composite"}},
+			update:   bson.D{{"$unset", bson.D{{"value.array", int32(1)}}}},
+			expected: bson.D{{"_id", "document-composite"}, {"value", bson.D{{"foo", int32(42)}, {"42", "foo"}}}},
+			stat: &mongo.UpdateResult{
+				MatchedCount:  1,
+				ModifiedCount: 1,
+				UpsertedCount: 0,
+			},
+		},
 		"EmptyArray": {
 			filter: bson.D{{"_id", "document-composite"}},
 			update: bson.D{{"$unset", bson.A{}}},
@@ -901,6 +1223,17 @@ func TestUpdateFieldMixed(t *testing.T) {
 				Message: "Updating the path 'foo' would create a conflict at 'foo'",
 			},
 		},
+		"SetIncDotNotationConflict": {
+			filter: bson.D{{"_id", "test"}},
+			update: bson.D{
+				{"$set", bson.D{{"foo", int32(12)}}},
+				{"$inc", bson.D{{"foo.bar", int32(1)}}},
+			},
+			err: &mongo.WriteError{
+				Code:    40,
+				Message: "Updating the path 'foo.bar' would create a conflict at 'foo'",
+			},
+		},
 		"UnknownOperator": {
 			filter: bson.D{{"_id", "test"}},
 			update: bson.D{{"$foo", bson.D{{"foo", int32(1)}}}},
@@ -15,6 +15,7 @@
 package integration
 
 import (
+	"errors"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
@@ -74,3 +75,84 @@ func TestUpdateUpsert(t *testing.T) {
 	require.NoError(t, err)
 	AssertEqualDocuments(t, bson.D{{"_id", id}, {"foo", "qux"}}, doc)
 }
+
+func TestUpdateOrdered(t *testing.T) {
+	t.Parallel()
+
+	for name, tc := range map[string]struct {
+		ordered   bool
+		nModified int32
+		indexes   []int32
+	}{
+		"Ordered": {
+			ordered:   true,
+			nModified: 1,
+			indexes:   []int32{1},
+		},
+		"Unordered": {
+			ordered:   false,
+			nModified: 2,
+			indexes:   []int32{1, 3},
+		},
+	} {
+		name, tc := name, tc
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			ctx, collection := Setup(t)
+
+			_, err := collection.InsertMany(ctx, []any{
+				bson.D{{"_id", "a"}, {"v", int32(1)}},
+				bson.D{{"_id", "b"}, {"v", int32(1)}},
+			})
+			require.NoError(t, err)
+
+			err = collection.Database().RunCommand(ctx, bson.D{
+				{"update", collection.Name()},
+				{"updates", bson.A{
+					bson.D{{"q", bson.D{{"_id", "a"}}}, {"u", bson.D{{"$set", bson.D{{"v", int32(2)}}}}}},
+					bson.D{{"q", bson.D{{"_id", "a"}}}, {"u", bson.D{{"$foo", bson.D{{"v", int32(3)}}}}}},
+					bson.D{{"q", bson.D{{"_id", "b"}}}, {"u", bson.D{{"$set", bson.D{{"v", int32(2)}}}}}},
+					bson.D{{"q", bson.D{{"_id", "b"}}}, {"u", bson.D{{"$foo", bson.D{{"v", int32(3)}}}}}},
+				}},
+				{"ordered", tc.ordered},
+			}).Err()
+
+			var we mongo.WriteException
+			require.True(t, errors.As(err, &we), "%v", err)
+
+			indexes := make([]int32, len(we.WriteErrors))
+			for i, e := range we.WriteErrors {
+				indexes[i] = int32(e.Index)
+			}
+			assert.Equal(t, tc.indexes, indexes)
+
+			var actual bson.D
+			require.NoError(t, bson.Unmarshal(we.Raw, &actual))
+			assert.Equal(t, tc.nModified, actual.Map()["nModified"])
+		})
+	}
+}
+
+func TestUpdateUpsertedIndex(t *testing.T) {
+	t.Parallel()
+	ctx, collection := Setup(t)
+
+	var actual bson.D
+	err := collection.Database().RunCommand(ctx, bson.D{
+		{"update", collection.Name()},
+		{"updates", bson.A{
+			bson.D{{"q", bson.D{{"_id", "none"}}}, {"u", bson.D{{"$set", bson.D{{"v", int32(1)}}}}}},
+			bson.D{{"q", bson.D{{"_id", "new"}}}, {"u", bson.D{{"$set", bson.D{{"v", int32(1)}}}}}, {"upsert", true}},
+		}},
+	}).Decode(&actual)
+	require.NoError(t, err)
+
+	upserted, ok := actual.Map()["upserted"].(bson.A)
+	require.True(t, ok, "%+v", actual)
+	require.Len(t, upserted, 1)
+	upsertedDoc, ok := upserted[0].(bson.D)
+	require.True(t, ok)
+	assert.Equal(t, int32(1), upsertedDoc.Map()["index"])
+	assert.Equal(t, "new", upsertedDoc.Map()["_id"])
+}
@@ -54,6 +54,8 @@ func fromBSON(v bsontype) any {
 		return string(*v)
 	case *binaryType:
 		return types.Binary(*v)
+	case *undefinedType:
+		return types.Undefined
 	case *objectIDType:
 		return types.ObjectID(*v)
 	case *boolType:
@@ -64,12 +66,22 @@ func fromBSON(v bsontype) any {
 		return types.Null
 	case *regexType:
 		return types.Regex(*v)
+	case *dbPointerType:
+		return types.DBPointer(*v)
+	case *javaScriptType:
+		return types.JavaScript(*v)
+	case *symbolType:
+		return types.Symbol(*v)
+	case *javaScriptWithScopeType:
+		return types.JavaScriptWithScope(*v)
 	case *int32Type:
 		return int32(*v)
 	case *timestampType:
 		return types.Timestamp(*v)
 	case *int64Type:
 		return int64(*v)
+	case *decimal128Type:
+		return types.Decimal128(*v)
 	case *CString:
 		panic("not reached")
 	}
@@ -92,6 +104,8 @@ func toBSON(v any) bsontype {
 		return pointer.To(stringType(v))
 	case types.Binary:
 		return pointer.To(binaryType(v))
+	case types.UndefinedType:
+		return pointer.To(undefinedType(v))
 	case types.ObjectID:
 		return pointer.To(objectIDType(v))
 	case bool:
@@ -102,12 +116,22 @@ func toBSON(v any) bsontype {
 		return pointer.To(nullType(v))
 	case types.Regex:
 		return pointer.To(regexType(v))
+	case types.DBPointer:
+		return pointer.To(dbPointerType(v))
+	case types.JavaScript:
+		return pointer.To(javaScriptType(v))
+	case types.Symbol:
+		return pointer.To(symbolType(v))
+	case types.JavaScriptWithScope:
+		return pointer.To(javaScriptWithScopeType(v))
 	case int32:
 		return pointer.To(int32Type(v))
 	case types.Timestamp:
 		return pointer.To(timestampType(v))
 	case int64:
 		return pointer.To(int64Type(v))
+	case types.Decimal128:
+		return pointer.To(decimal128Type(v))
 	}
 
 	panic(fmt.Sprintf("not reached: %T", v)) // for go-sumtype to work
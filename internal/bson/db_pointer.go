@@ -0,0 +1,86 @@
+// Copyright 2021 FerretDB Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package bson
+
+import (
+	"bufio"
+	"bytes"
+
+	"github.com/FerretDB/FerretDB/internal/types"
+	"github.com/FerretDB/FerretDB/internal/util/lazyerrors"
+)
+
+// dbPointerType represents deprecated BSON DBPointer type.
+type dbPointerType types.DBPointer
+
+func (dbp *dbPointerType) bsontype() {}
+
+// ReadFrom implements bsontype interface.
+func (dbp *dbPointerType) ReadFrom(r *bufio.Reader) error {
+	var ns stringType
+	if err := ns.ReadFrom(r); err != nil {
+		return lazyerrors.Errorf("bson.DBPointer.ReadFrom (namespace): %w", err)
+	}
+
+	var id objectIDType
+	if err := id.ReadFrom(r); err != nil {
+		return lazyerrors.Errorf("bson.DBPointer.ReadFrom (ObjectID): %w", err)
+	}
+
+	*dbp = dbPointerType{
+		Namespace: string(ns),
+		ID:        types.ObjectID(id),
+	}
+	return nil
+}
+
+// WriteTo implements bsontype interface.
+func (dbp dbPointerType) WriteTo(w *bufio.Writer) error {
+	v, err := dbp.MarshalBinary()
+	if err != nil {
+		return lazyerrors.Errorf("bson.DBPointer.WriteTo: %w", err)
+	}
+
+	_, err = w.Write(v)
+	if err != nil {
+		return lazyerrors.Errorf("bson.DBPointer.WriteTo: %w", err)
+	}
+
+	return nil
+}
+
+// MarshalBinary implements bsontype interface.
+func (dbp dbPointerType) MarshalBinary() ([]byte, error) {
+	var buf bytes.Buffer
+
+	ns, err := stringType(dbp.Namespace).MarshalBinary()
+	if err != nil {
+		return nil, lazyerrors.Error(err)
+	}
+	buf.Write(ns)
+
+	id, err := objectIDType(dbp.ID).MarshalBinary()
+	if err != nil {
+		return nil, lazyerrors.Error(err)
+	}
+	buf.Write(id)
+
+	return buf.Bytes(), nil
+}
+
+// check interfaces
+var (
+	_ bsontype = (*dbPointerType)(nil)
+)
@@ -131,118 +131,164 @@ func (doc *Document) ReadFrom(r *bufio.Reader) error {
 			doc.m = map[string]any{}
 		}
 
-		switch tag(t) {
-		case tagDocument:
-			// TODO check maximum nesting
+		v, err := readValue(tag(t), bufr)
+		if err != nil {
+			return lazyerrors.Error(err)
+		}
+		doc.m[string(ename)] = v
+	}
 
-			var v Document
-			if err := v.ReadFrom(bufr); err != nil {
-				return lazyerrors.Errorf("bson.Document.ReadFrom (embedded document): %w", err)
-			}
-			doc.m[string(ename)], err = types.ConvertDocument(&v)
-			if err != nil {
-				return lazyerrors.Errorf("bson.Document.ReadFrom (embedded document): %w", err)
-			}
+	if _, err := types.ConvertDocument(doc); err != nil {
+		return lazyerrors.Errorf("bson.Document.ReadFrom: %w", err)
+	}
 
-		case tagArray:
-			// TODO check maximum nesting
+	return nil
+}
 
-			var v arrayType
-			if err := v.ReadFrom(bufr); err != nil {
-				return lazyerrors.Errorf("bson.Document.ReadFrom (Array): %w", err)
-			}
-			a := types.Array(v)
-			doc.m[string(ename)] = &a
+// readValue reads a single element value of the given type.
+func readValue(t tag, bufr *bufio.Reader) (any, error) {
+	switch t {
+	case tagDocument:
+		// TODO check maximum nesting
 
-		case tagDouble:
-			var v doubleType
-			if err := v.ReadFrom(bufr); err != nil {
-				return lazyerrors.Errorf("bson.Document.ReadFrom (Double): %w", err)
-			}
-			doc.m[string(ename)] = float64(v)
+		var v Document
+		if err := v.ReadFrom(bufr); err != nil {
+			return nil, lazyerrors.Errorf("bson.Document.ReadFrom (embedded document): %w", err)
+		}
+		res, err := types.ConvertDocument(&v)
+		if err != nil {
+			return nil, lazyerrors.Errorf("bson.Document.ReadFrom (embedded document): %w", err)
+		}
+		return res, nil
 
-		case tagString:
-			var v stringType
-			if err := v.ReadFrom(bufr); err != nil {
-				return lazyerrors.Errorf("bson.Document.ReadFrom (String): %w", err)
-			}
-			doc.m[string(ename)] = string(v)
+	case tagArray:
+		// TODO check maximum nesting
 
-		case tagBinary:
-			var v binaryType
-			if err := v.ReadFrom(bufr); err != nil {
-				return lazyerrors.Errorf("bson.Document.ReadFrom (Binary): %w", err)
-			}
-			doc.m[string(ename)] = types.Binary(v)
+		var v arrayType
+		if err := v.ReadFrom(bufr); err != nil {
+			return nil, lazyerrors.Errorf("bson.Document.ReadFrom (Array): %w", err)
+		}
+		a := types.Array(v)
+		return &a, nil
 
-		case tagUndefined:
-			return lazyerrors.Errorf("bson.Document.ReadFrom: unhandled element type `Undefined (value) — Deprecated`")
+	case tagDouble:
+		var v doubleType
+		if err := v.ReadFrom(bufr); err != nil {
+			return nil, lazyerrors.Errorf("bson.Document.ReadFrom (Double): %w", err)
+		}
+		return float64(v), nil
 
-		case tagObjectID:
-			var v objectIDType
-			if err := v.ReadFrom(bufr); err != nil {
-				return lazyerrors.Errorf("bson.Document.ReadFrom (ObjectID): %w", err)
-			}
-			doc.m[string(ename)] = types.ObjectID(v)
+	case tagString:
+		var v stringType
+		if err := v.ReadFrom(bufr); err != nil {
+			return nil, lazyerrors.Errorf("bson.Document.ReadFrom (String): %w", err)
+		}
+		return string(v), nil
 
-		case tagBool:
-			var v boolType
-			if err := v.ReadFrom(bufr); err != nil {
-				return lazyerrors.Errorf("bson.Document.ReadFrom (Bool): %w", err)
-			}
-			doc.m[string(ename)] = bool(v)
+	case tagBinary:
+		var v binaryType
+		if err := v.ReadFrom(bufr); err != nil {
+			return nil, lazyerrors.Errorf("bson.Document.ReadFrom (Binary): %w", err)
+		}
+		return types.Binary(v), nil
 
-		case tagDateTime:
-			var v dateTimeType
-			if err := v.ReadFrom(bufr); err != nil {
-				return lazyerrors.Errorf("bson.Document.ReadFrom (DateTime): %w", err)
-			}
-			doc.m[string(ename)] = time.Time(v)
+	case tagUndefined:
+		// skip calling ReadFrom that does nothing
+		return types.Undefined, nil
 
-		case tagNull:
-			// skip calling ReadFrom that does nothing
-			doc.m[string(ename)] = types.Null
+	case tagObjectID:
+		var v objectIDType
+		if err := v.ReadFrom(bufr); err != nil {
+			return nil, lazyerrors.Errorf("bson.Document.ReadFrom (ObjectID): %w", err)
+		}
+		return types.ObjectID(v), nil
 
-		case tagRegex:
-			var v regexType
-			if err := v.ReadFrom(bufr); err != nil {
-				return lazyerrors.Errorf("bson.Document.ReadFrom (Regex): %w", err)
-			}
-			doc.m[string(ename)] = types.Regex(v)
+	case tagBool:
+		var v boolType
+		if err := v.ReadFrom(bufr); err != nil {
+			return nil, lazyerrors.Errorf("bson.Document.ReadFrom (Bool): %w", err)
+		}
+		return bool(v), nil
 
-		case tagInt32:
-			var v int32Type
-			if err := v.ReadFrom(bufr); err != nil {
-				return lazyerrors.Errorf("bson.Document.ReadFrom (Int32): %w", err)
-			}
-			doc.m[string(ename)] = int32(v)
+	case tagDateTime:
+		var v dateTimeType
+		if err := v.ReadFrom(bufr); err != nil {
+			return nil, lazyerrors.Errorf("bson.Document.ReadFrom (DateTime): %w", err)
+		}
+		return time.Time(v), nil
 
-		case tagTimestamp:
-			var v timestampType
-			if err := v.ReadFrom(bufr); err != nil {
-				return lazyerrors.Errorf("bson.Document.ReadFrom (Timestamp): %w", err)
-			}
-			doc.m[string(ename)] = types.Timestamp(v)
+	case tagNull:
+		// skip calling ReadFrom that does nothing
+		return types.Null, nil
 
-		case tagInt64:
-			var v int64Type
-			if err := v.ReadFrom(bufr); err != nil {
-				return lazyerrors.Errorf("bson.Document.ReadFrom (Int64): %w", err)
-			}
-			doc.m[string(ename)] = int64(v)
+	case tagRegex:
+		var v regexType
+		if err := v.ReadFrom(bufr); err != nil {
+			return nil, lazyerrors.Errorf("bson.Document.ReadFrom (Regex): %w", err)
+		}
+		return types.Regex(v), nil
 
-		case tagDBPointer, tagDecimal, tagJavaScript, tagJavaScriptScope, tagMaxKey, tagMinKey, tagSymbol:
-			return lazyerrors.Errorf("bson.Document.ReadFrom: unhandled element type %#02x (%s)", t, tag(t))
-		default:
-			return lazyerrors.Errorf("bson.Document.ReadFrom: unhandled element type %#02x (%s)", t, tag(t))
+	case tagDBPointer:
+		var v dbPointerType
+		if err := v.ReadFrom(bufr); err != nil {
+			return nil, lazyerrors.Errorf("bson.Document.ReadFrom (DBPointer): %w", err)
 		}
-	}
+		return types.DBPointer(v), nil
 
-	if _, err := types.ConvertDocument(doc); err != nil {
-		return lazyerrors.Errorf("bson.Document.ReadFrom: %w", err)
-	}
+	case tagInt32:
+		var v int32Type
+		if err := v.ReadFrom(bufr); err != nil {
+			return nil, lazyerrors.Errorf("bson.Document.ReadFrom (Int32): %w", err)
+		}
+		return int32(v), nil
 
-	return nil
+	case tagTimestamp:
+		var v timestampType
+		if err := v.ReadFrom(bufr); err != nil {
+			return nil, lazyerrors.Errorf("bson.Document.ReadFrom (Timestamp): %w", err)
+		}
+		return types.Timestamp(v), nil
+
+	case tagInt64:
+		var v int64Type
+		if err := v.ReadFrom(bufr); err != nil {
+			return nil, lazyerrors.Errorf("bson.Document.ReadFrom (Int64): %w", err)
+		}
+		return int64(v), nil
+
+	case tagDecimal:
+		var v decimal128Type
+		if err := v.ReadFrom(bufr); err != nil {
+			return nil, lazyerrors.Errorf("bson.Document.ReadFrom (Decimal128): %w", err)
+		}
+		return types.Decimal128(v), nil
+
+	case tagJavaScript:
+		var v javaScriptType
+		if err := v.ReadFrom(bufr); err != nil {
+			return nil, lazyerrors.Errorf("bson.Document.ReadFrom (JavaScript): %w", err)
+		}
+		return types.JavaScript(v), nil
+
+	case tagSymbol:
+		var v symbolType
+		if err := v.ReadFrom(bufr); err != nil {
+			return nil, lazyerrors.Errorf("bson.Document.ReadFrom (Symbol): %w", err)
+		}
+		return types.Symbol(v), nil
+
+	case tagJavaScriptScope:
+		var v javaScriptWithScopeType
+		if err := v.ReadFrom(bufr); err != nil {
+			return nil, lazyerrors.Errorf("bson.Document.ReadFrom (JavaScriptWithScope): %w", err)
+		}
+		return types.JavaScriptWithScope(v), nil
+
+	case tagMaxKey, tagMinKey:
+		return nil, lazyerrors.Errorf("bson.Document.ReadFrom: unhandled element type %#02x (%s)", byte(t), t)
+	default:
+		return nil, lazyerrors.Errorf("bson.Document.ReadFrom: unhandled element type %#02x (%s)", byte(t), t)
+	}
 }
 
 // WriteTo implements bsontype interface.
@@ -322,6 +368,13 @@ func (doc Document) MarshalBinary() ([]byte, error) {
 				return nil, lazyerrors.Error(err)
 			}
 
+		case types.UndefinedType:
+			bufw.WriteByte(byte(tagUndefined))
+			if err := ename.WriteTo(bufw); err != nil {
+				return nil, lazyerrors.Error(err)
+			}
+			// skip calling WriteTo that does nothing
+
 		case types.ObjectID:
 			bufw.WriteByte(byte(tagObjectID))
 			if err := ename.WriteTo(bufw); err != nil {
@@ -365,6 +418,42 @@ func (doc Document) MarshalBinary() ([]byte, error) {
 				return nil, lazyerrors.Error(err)
 			}
 
+		case types.DBPointer:
+			bufw.WriteByte(byte(tagDBPointer))
+			if err := ename.WriteTo(bufw); err != nil {
+				return nil, lazyerrors.Error(err)
+			}
+			if err := dbPointerType(elV).WriteTo(bufw); err != nil {
+				return nil, lazyerrors.Error(err)
+			}
+
+		case types.JavaScript:
+			bufw.WriteByte(byte(tagJavaScript))
+			if err := ename.WriteTo(bufw); err != nil {
+				return nil, lazyerrors.Error(err)
+			}
+			if err := javaScriptType(elV).WriteTo(bufw); err != nil {
+				return nil, lazyerrors.Error(err)
+			}
+
+		case types.Symbol:
+			bufw.WriteByte(byte(tagSymbol))
+			if err := ename.WriteTo(bufw); err != nil {
+				return nil, lazyerrors.Error(err)
+			}
+			if err := symbolType(elV).WriteTo(bufw); err != nil {
+				return nil, lazyerrors.Error(err)
+			}
+
+		case types.JavaScriptWithScope:
+			bufw.WriteByte(byte(tagJavaScriptScope))
+			if err := ename.WriteTo(bufw); err != nil {
+				return nil, lazyerrors.Error(err)
+			}
+			if err := javaScriptWithScopeType(elV).WriteTo(bufw); err != nil {
+				return nil, lazyerrors.Error(err)
+			}
+
 		case int32:
 			bufw.WriteByte(byte(tagInt32))
 			if err := ename.WriteTo(bufw); err != nil {
@@ -392,6 +481,15 @@ func (doc Document) MarshalBinary() ([]byte, error) {
 				return nil, lazyerrors.Error(err)
 			}
 
+		case types.Decimal128:
+			bufw.WriteByte(byte(tagDecimal))
+			if err := ename.WriteTo(bufw); err != nil {
+				return nil, lazyerrors.Error(err)
+			}
+			if err := decimal128Type(elV).WriteTo(bufw); err != nil {
+				return nil, lazyerrors.Error(err)
+			}
+
 		default:
 			return nil, lazyerrors.Errorf("bson.Document.MarshalBinary: unhandled element type %T", elV)
 		}
@@ -162,13 +162,30 @@ var (
 		b: testutil.MustParseDumpFile("testdata", "all.hex"),
 	}
 
+	deprecated = testCase{
+		name: "deprecated",
+		v: MustConvertDocument(must.NotFail(types.NewDocument(
+			"u", types.Undefined,
+			"s", types.Symbol("a"),
+			"p", types.DBPointer{Namespace: "d.c", ID: types.ObjectID{0x42}},
+		))),
+		b: []byte{
+			0x28, 0x00, 0x00, 0x00,
+			0x06, 0x75, 0x00,
+			0x0e, 0x73, 0x00, 0x02, 0x00, 0x00, 0x00, 0x61, 0x00,
+			0x0c, 0x70, 0x00, 0x04, 0x00, 0x00, 0x00, 0x64, 0x2e, 0x63, 0x00,
+			0x42, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00,
+			0x00,
+		},
+	}
+
 	eof = testCase{
 		name: "EOF",
 		b:    []byte{0x00},
 		bErr: `unexpected EOF`,
 	}
 
-	documentTestCases = []testCase{handshake1, handshake2, handshake3, handshake4, all, eof}
+	documentTestCases = []testCase{handshake1, handshake2, handshake3, handshake4, all, deprecated, eof}
 )
 
 func TestDocument(t *testing.T) {
@@ -177,6 +194,16 @@ func TestDocument(t *testing.T) {
 }
 
 func FuzzDocument(f *testing.F) {
+	for _, b := range testutil.DocumentsSeedCorpus(f) {
+		f.Add(b)
+	}
+
+	// seed corpus with generated documents of all types; the seed is fixed to keep the corpus stable
+	g := testutil.NewGenerator(f, 1, &testutil.GeneratorOpts{MaxDepth: 3})
+	for _, doc := range g.Documents(20) {
+		f.Add(must.NotFail(MustConvertDocument(doc).MarshalBinary()))
+	}
+
 	fuzzBinary(f, documentTestCases, func() bsontype { return new(Document) })
 }
 
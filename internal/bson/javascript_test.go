@@ -0,0 +1,101 @@
+// Copyright 2021 FerretDB Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package bson
+
+import (
+	"testing"
+
+	"github.com/AlekSi/pointer"
+
+	"github.com/FerretDB/FerretDB/internal/types"
+	"github.com/FerretDB/FerretDB/internal/util/must"
+)
+
+var javaScriptTestCases = []testCase{{
+	name: "normal",
+	v:    pointer.To(javaScriptType{Code: "x"}),
+	b:    []byte{0x02, 0x00, 0x00, 0x00, 0x78, 0x00},
+}, {
+	name: "empty",
+	v:    pointer.To(javaScriptType{Code: ""}),
+	b:    []byte{0x01, 0x00, 0x00, 0x00, 0x00},
+}, {
+	name: "EOF",
+	b:    []byte{0x00},
+	bErr: `unexpected EOF`,
+}}
+
+func TestJavaScript(t *testing.T) {
+	t.Parallel()
+	testBinary(t, javaScriptTestCases, func() bsontype { return new(javaScriptType) })
+}
+
+func FuzzJavaScript(f *testing.F) {
+	fuzzBinary(f, javaScriptTestCases, func() bsontype { return new(javaScriptType) })
+}
+
+func BenchmarkJavaScript(b *testing.B) {
+	benchmark(b, javaScriptTestCases, func() bsontype { return new(javaScriptType) })
+}
+
+var javaScriptWithScopeTestCases = []testCase{{
+	name: "normal",
+	v: pointer.To(javaScriptWithScopeType{
+		Code:  "x",
+		Scope: must.NotFail(types.NewDocument("a", int32(1))),
+	}),
+	b: []byte{
+		0x16, 0x00, 0x00, 0x00, // total length
+		0x02, 0x00, 0x00, 0x00, 0x78, 0x00, // code
+		0x0c, 0x00, 0x00, 0x00, 0x10, 0x61, 0x00, 0x01, 0x00, 0x00, 0x00, 0x00, // scope
+	},
+}, {
+	name: "empty",
+	v: pointer.To(javaScriptWithScopeType{
+		Code:  "",
+		Scope: must.NotFail(types.NewDocument()),
+	}),
+	b: []byte{
+		0x0e, 0x00, 0x00, 0x00,
+		0x01, 0x00, 0x00, 0x00, 0x00,
+		0x05, 0x00, 0x00, 0x00, 0x00,
+	},
+}, {
+	name: "invalid length",
+	b: []byte{
+		0x0f, 0x00, 0x00, 0x00,
+		0x01, 0x00, 0x00, 0x00, 0x00,
+		0x05, 0x00, 0x00, 0x00, 0x00,
+		0x00,
+	},
+	bErr: `bson.JavaScriptWithScope.ReadFrom: 1 bytes remains`,
+}, {
+	name: "EOF",
+	b:    []byte{0x00},
+	bErr: `unexpected EOF`,
+}}
+
+func TestJavaScriptWithScope(t *testing.T) {
+	t.Parallel()
+	testBinary(t, javaScriptWithScopeTestCases, func() bsontype { return new(javaScriptWithScopeType) })
+}
+
+func FuzzJavaScriptWithScope(f *testing.F) {
+	fuzzBinary(f, javaScriptWithScopeTestCases, func() bsontype { return new(javaScriptWithScopeType) })
+}
+
+func BenchmarkJavaScriptWithScope(b *testing.B) {
+	benchmark(b, javaScriptWithScopeTestCases, func() bsontype { return new(javaScriptWithScopeType) })
+}
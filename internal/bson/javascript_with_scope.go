@@ -0,0 +1,124 @@
+// Copyright 2021 FerretDB Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package bson
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"io"
+
+	"github.com/FerretDB/FerretDB/internal/types"
+	"github.com/FerretDB/FerretDB/internal/util/lazyerrors"
+)
+
+// javaScriptWithScopeType represents deprecated BSON JavaScript code with scope type.
+type javaScriptWithScopeType types.JavaScriptWithScope
+
+func (js *javaScriptWithScopeType) bsontype() {}
+
+// ReadFrom implements bsontype interface.
+func (js *javaScriptWithScopeType) ReadFrom(r *bufio.Reader) error {
+	var l int32
+	if err := binary.Read(r, binary.LittleEndian, &l); err != nil {
+		return lazyerrors.Errorf("bson.JavaScriptWithScope.ReadFrom (binary.Read): %w", err)
+	}
+
+	// length, string length, terminating 0x00, and minimal document
+	if l < 14 || l > types.MaxDocumentLen {
+		return lazyerrors.Errorf("bson.JavaScriptWithScope.ReadFrom: invalid length %d", l)
+	}
+
+	b := make([]byte, l-4)
+	if n, err := io.ReadFull(r, b); err != nil {
+		return lazyerrors.Errorf("bson.JavaScriptWithScope.ReadFrom: expected %d, read %d: %w", len(b), n, err)
+	}
+
+	// check code length there to avoid huge allocations for invalid input
+	if cl := int32(binary.LittleEndian.Uint32(b)); cl <= 0 || cl > l-4-4-minDocumentLen {
+		return lazyerrors.Errorf("bson.JavaScriptWithScope.ReadFrom: invalid code length %d", cl)
+	}
+
+	br := bytes.NewReader(b)
+	bufr := bufio.NewReader(br)
+
+	var code stringType
+	if err := code.ReadFrom(bufr); err != nil {
+		return lazyerrors.Errorf("bson.JavaScriptWithScope.ReadFrom (code): %w", err)
+	}
+
+	var scope Document
+	if err := scope.ReadFrom(bufr); err != nil {
+		return lazyerrors.Errorf("bson.JavaScriptWithScope.ReadFrom (scope): %w", err)
+	}
+
+	if _, err := bufr.Peek(1); err != io.EOF {
+		return lazyerrors.Errorf("bson.JavaScriptWithScope.ReadFrom: %d bytes remains", bufr.Buffered()+br.Len())
+	}
+
+	doc, err := types.ConvertDocument(&scope)
+	if err != nil {
+		return lazyerrors.Errorf("bson.JavaScriptWithScope.ReadFrom (scope): %w", err)
+	}
+
+	res := javaScriptWithScopeType{
+		Code:  string(code),
+		Scope: doc,
+	}
+
+	*js = res
+	return nil
+}
+
+// WriteTo implements bsontype interface.
+func (js javaScriptWithScopeType) WriteTo(w *bufio.Writer) error {
+	v, err := js.MarshalBinary()
+	if err != nil {
+		return lazyerrors.Errorf("bson.JavaScriptWithScope.WriteTo: %w", err)
+	}
+
+	_, err = w.Write(v)
+	if err != nil {
+		return lazyerrors.Errorf("bson.JavaScriptWithScope.WriteTo: %w", err)
+	}
+
+	return nil
+}
+
+// MarshalBinary implements bsontype interface.
+func (js javaScriptWithScopeType) MarshalBinary() ([]byte, error) {
+	code, err := stringType(js.Code).MarshalBinary()
+	if err != nil {
+		return nil, lazyerrors.Errorf("bson.JavaScriptWithScope.MarshalBinary: %w", err)
+	}
+
+	scope, err := MustConvertDocument(js.Scope).MarshalBinary()
+	if err != nil {
+		return nil, lazyerrors.Errorf("bson.JavaScriptWithScope.MarshalBinary: %w", err)
+	}
+
+	var buf bytes.Buffer
+
+	binary.Write(&buf, binary.LittleEndian, int32(4+len(code)+len(scope)))
+	buf.Write(code)
+	buf.Write(scope)
+
+	return buf.Bytes(), nil
+}
+
+// check interfaces
+var (
+	_ bsontype = (*javaScriptWithScopeType)(nil)
+)
@@ -0,0 +1,361 @@
+// Copyright 2021 FerretDB Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package bson
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"io"
+
+	"github.com/FerretDB/FerretDB/internal/types"
+	"github.com/FerretDB/FerretDB/internal/util/lazyerrors"
+)
+
+// RawDocument represents a single BSON document in the binary encoded form.
+//
+// Unlike Document, it is not decoded upfront: fields are located and decoded on demand,
+// so the cost of accessing a single field does not depend on the sizes of other fields' values.
+// That makes it suitable for paths that only need a few fields of large documents, like filtering.
+//
+// It references the given bytes without copying them.
+type RawDocument []byte
+
+// ReadRawDocument reads a single document from the reader without decoding field values.
+// The document structure is validated; see Validate.
+func ReadRawDocument(r *bufio.Reader) (RawDocument, error) {
+	var l int32
+	if err := binary.Read(r, binary.LittleEndian, &l); err != nil {
+		return nil, lazyerrors.Errorf("bson.ReadRawDocument (binary.Read): %w", err)
+	}
+	if l < minDocumentLen || l > types.MaxDocumentLen {
+		return nil, lazyerrors.Errorf("bson.ReadRawDocument: invalid length %d", l)
+	}
+
+	raw := make(RawDocument, l)
+	binary.LittleEndian.PutUint32(raw, uint32(l))
+
+	if n, err := io.ReadFull(r, raw[4:]); err != nil {
+		return nil, lazyerrors.Errorf("bson.ReadRawDocument (io.ReadFull, expected %d, read %d): %w", len(raw), n, err)
+	}
+
+	if err := raw.Validate(); err != nil {
+		return nil, lazyerrors.Error(err)
+	}
+
+	return raw, nil
+}
+
+// Validate checks the document length, elements' types and lengths, and the terminating zero.
+// Field values are not decoded, so Validate may succeed for documents that Convert rejects.
+func (raw RawDocument) Validate() error {
+	iter := raw.Iterator()
+
+	for {
+		_, _, err := iter.Next()
+		if err == io.EOF {
+			return nil
+		}
+
+		if err != nil {
+			return lazyerrors.Error(err)
+		}
+	}
+}
+
+// Convert decodes the whole document.
+func (raw RawDocument) Convert() (*types.Document, error) {
+	var doc Document
+	if err := doc.ReadFrom(bufio.NewReader(bytes.NewReader(raw))); err != nil {
+		return nil, lazyerrors.Error(err)
+	}
+
+	res, err := types.ConvertDocument(&doc)
+	if err != nil {
+		return nil, lazyerrors.Error(err)
+	}
+
+	return res, nil
+}
+
+// Keys returns document's keys in order without decoding values.
+func (raw RawDocument) Keys() ([]string, error) {
+	var res []string
+
+	iter := raw.Iterator()
+
+	for {
+		key, _, err := iter.Next()
+		if err == io.EOF {
+			return res, nil
+		}
+
+		if err != nil {
+			return nil, lazyerrors.Error(err)
+		}
+
+		res = append(res, key)
+	}
+}
+
+// Lookup returns the raw value for the given key.
+// Only elements before the found one are visited; none of them are decoded.
+func (raw RawDocument) Lookup(key string) (RawValue, error) {
+	iter := raw.Iterator()
+
+	for {
+		k, v, err := iter.Next()
+		if err == io.EOF {
+			return RawValue{}, lazyerrors.Errorf("bson.RawDocument.Lookup: key not found: %q", key)
+		}
+
+		if err != nil {
+			return RawValue{}, lazyerrors.Error(err)
+		}
+
+		if k == key {
+			return v, nil
+		}
+	}
+}
+
+// Get returns a decoded value at the given key.
+func (raw RawDocument) Get(key string) (any, error) {
+	v, err := raw.Lookup(key)
+	if err != nil {
+		return nil, lazyerrors.Error(err)
+	}
+
+	return v.Decode()
+}
+
+// GetByPath returns a decoded value by path - a sequence of keys and array indexes.
+// Only the found value is decoded; embedded documents and arrays on the path are accessed lazily.
+func (raw RawDocument) GetByPath(path types.Path) (any, error) {
+	cur := raw
+
+	keys := path.Slice()
+	for i, key := range keys {
+		v, err := cur.Lookup(key)
+		if err != nil {
+			return nil, lazyerrors.Error(err)
+		}
+
+		if i == len(keys)-1 {
+			return v.Decode()
+		}
+
+		// BSON arrays are documents with "0", "1", etc. keys
+		var ok bool
+		if cur, ok = v.Document(); !ok {
+			return nil, lazyerrors.Errorf("bson.RawDocument.GetByPath: can't access %s by path %q", v.t, key)
+		}
+	}
+
+	return nil, lazyerrors.New("bson.RawDocument.GetByPath: empty path")
+}
+
+// Iterator returns a new iterator over document's elements.
+func (raw RawDocument) Iterator() *RawIterator {
+	return &RawIterator{raw: raw}
+}
+
+// RawIterator iterates over raw document's elements.
+type RawIterator struct {
+	raw RawDocument
+	off int // 0 before the first call to Next
+	err error
+}
+
+// Next returns the next element's key and raw value.
+//
+// It returns io.EOF after the last element; any other error means that the document is malformed.
+// Once an error is returned, all subsequent calls return the same error.
+func (iter *RawIterator) Next() (string, RawValue, error) {
+	if iter.err != nil {
+		return "", RawValue{}, iter.err
+	}
+
+	key, v, err := iter.next()
+	if err != nil {
+		iter.err = err
+	}
+
+	return key, v, err
+}
+
+// next implements Next.
+func (iter *RawIterator) next() (string, RawValue, error) {
+	raw := iter.raw
+
+	if iter.off == 0 {
+		if len(raw) < minDocumentLen {
+			return "", RawValue{}, lazyerrors.Errorf("bson.RawIterator.Next: invalid length %d", len(raw))
+		}
+
+		if l := binary.LittleEndian.Uint32(raw); int64(l) != int64(len(raw)) {
+			return "", RawValue{}, lazyerrors.Errorf("bson.RawIterator.Next: length %d, expected %d", l, len(raw))
+		}
+
+		if b := raw[len(raw)-1]; b != 0 {
+			return "", RawValue{}, lazyerrors.Errorf("bson.RawIterator.Next: unexpected terminating byte %#02x", b)
+		}
+
+		iter.off = 4
+	}
+
+	// the last byte is the terminating zero
+	if iter.off == len(raw)-1 {
+		return "", RawValue{}, io.EOF
+	}
+
+	t := tag(raw[iter.off])
+	if t == 0 {
+		return "", RawValue{}, lazyerrors.New("bson.RawIterator.Next: unexpected end of the document")
+	}
+
+	// the terminating zero guarantees that the key is terminated
+	rest := raw[iter.off+1 : len(raw)-1]
+	i := bytes.IndexByte(rest, 0)
+	if i < 0 {
+		return "", RawValue{}, lazyerrors.New("bson.RawIterator.Next: unterminated key")
+	}
+
+	key := string(rest[:i])
+	rest = rest[i+1:]
+
+	l, err := valueLen(t, rest)
+	if err != nil {
+		return "", RawValue{}, lazyerrors.Errorf("bson.RawIterator.Next: key %q: %w", key, err)
+	}
+
+	iter.off += 1 + i + 1 + l
+
+	return key, RawValue{t: t, b: rest[:l]}, nil
+}
+
+// valueLen returns the length of the encoded value of the given type at the start of b.
+func valueLen(t tag, b []byte) (int, error) {
+	var l int
+
+	switch t {
+	case tagUndefined, tagNull, tagMinKey, tagMaxKey:
+		l = 0
+
+	case tagBool:
+		l = 1
+
+	case tagInt32:
+		l = 4
+
+	case tagDouble, tagDateTime, tagTimestamp, tagInt64:
+		l = 8
+
+	case tagObjectID:
+		l = types.ObjectIDLen
+
+	case tagDecimal:
+		l = 16
+
+	case tagString, tagJavaScript, tagSymbol, tagDBPointer:
+		sl, err := int32Len(b, 1)
+		if err != nil {
+			return 0, err
+		}
+
+		l = 4 + sl
+		if t == tagDBPointer {
+			l += types.ObjectIDLen
+		}
+
+	case tagBinary:
+		bl, err := int32Len(b, 0)
+		if err != nil {
+			return 0, err
+		}
+
+		l = 4 + 1 + bl
+
+	case tagDocument, tagArray, tagJavaScriptScope:
+		dl, err := int32Len(b, minDocumentLen)
+		if err != nil {
+			return 0, err
+		}
+
+		l = dl
+
+	case tagRegex:
+		pattern := bytes.IndexByte(b, 0)
+		if pattern < 0 {
+			return 0, lazyerrors.New("unterminated regex pattern")
+		}
+
+		options := bytes.IndexByte(b[pattern+1:], 0)
+		if options < 0 {
+			return 0, lazyerrors.New("unterminated regex options")
+		}
+
+		l = pattern + 1 + options + 1
+
+	default:
+		return 0, lazyerrors.Errorf("unhandled element type %#02x (%s)", byte(t), t)
+	}
+
+	if l > len(b) {
+		return 0, lazyerrors.Errorf("%s value length %d exceeds remaining %d bytes", t, l, len(b))
+	}
+
+	return l, nil
+}
+
+// int32Len reads a little-endian int32 length at the start of b and checks that it is at least min.
+func int32Len(b []byte, min int32) (int, error) {
+	if len(b) < 4 {
+		return 0, lazyerrors.Errorf("expected 4 bytes of length, got %d", len(b))
+	}
+
+	l := int32(binary.LittleEndian.Uint32(b))
+	if l < min || l > types.MaxDocumentLen {
+		return 0, lazyerrors.Errorf("invalid length %d", l)
+	}
+
+	return int(l), nil
+}
+
+// RawValue represents a single BSON value in the binary encoded form, without its type and key.
+type RawValue struct {
+	t tag
+	b []byte
+}
+
+// Decode decodes the value.
+func (v RawValue) Decode() (any, error) {
+	// the smallest buffer is enough as large reads bypass it
+	res, err := readValue(v.t, bufio.NewReaderSize(bytes.NewReader(v.b), 0))
+	if err != nil {
+		return nil, lazyerrors.Error(err)
+	}
+
+	return res, nil
+}
+
+// Document returns the value as a raw document and true if the value is an embedded document or an array.
+func (v RawValue) Document() (RawDocument, bool) {
+	if v.t != tagDocument && v.t != tagArray {
+		return nil, false
+	}
+
+	return RawDocument(v.b), true
+}
@@ -0,0 +1,258 @@
+// Copyright 2021 FerretDB Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package bson
+
+import (
+	"bufio"
+	"bytes"
+	"io"
+	"strconv"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/FerretDB/FerretDB/internal/types"
+	"github.com/FerretDB/FerretDB/internal/util/must"
+	"github.com/FerretDB/FerretDB/internal/util/testutil"
+)
+
+func TestRawDocument(t *testing.T) {
+	t.Parallel()
+
+	for _, tc := range documentTestCases {
+		if tc.v == nil {
+			continue
+		}
+
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			expected := must.NotFail(types.ConvertDocument(tc.v.(*Document)))
+
+			raw, err := ReadRawDocument(bufio.NewReader(bytes.NewReader(tc.b)))
+			require.NoError(t, err)
+			assert.Equal(t, RawDocument(tc.b), raw)
+
+			actual, err := raw.Convert()
+			require.NoError(t, err)
+			assert.Equal(t, expected, actual)
+
+			keys, err := raw.Keys()
+			require.NoError(t, err)
+			assert.Equal(t, expected.Keys(), keys)
+
+			for _, k := range keys {
+				v, err := raw.Get(k)
+				require.NoError(t, err)
+				assert.Equal(t, must.NotFail(expected.Get(k)), v, k)
+			}
+
+			_, err = raw.Get("no such key")
+			assert.EqualError(t, lastErr(err), `bson.RawDocument.Lookup: key not found: "no such key"`)
+		})
+	}
+}
+
+func TestRawDocumentGetByPath(t *testing.T) {
+	t.Parallel()
+
+	doc := must.NotFail(types.NewDocument(
+		"a", must.NotFail(types.NewDocument(
+			"b", must.NotFail(types.NewArray(
+				int32(1),
+				must.NotFail(types.NewDocument("c", "foo")),
+			)),
+		)),
+		"d", int64(42),
+	))
+	raw := RawDocument(must.NotFail(MustConvertDocument(doc).MarshalBinary()))
+
+	v, err := raw.GetByPath(types.NewPathFromString("a.b.1.c"))
+	require.NoError(t, err)
+	assert.Equal(t, "foo", v)
+
+	v, err = raw.GetByPath(types.NewPathFromString("a.b.0"))
+	require.NoError(t, err)
+	assert.Equal(t, int32(1), v)
+
+	v, err = raw.GetByPath(types.NewPathFromString("a.b"))
+	require.NoError(t, err)
+	assert.Equal(t, must.NotFail(doc.GetByPath(types.NewPathFromString("a.b"))), v)
+
+	_, err = raw.GetByPath(types.NewPathFromString("d.e"))
+	assert.EqualError(t, lastErr(err), `bson.RawDocument.GetByPath: can't access Int64 by path "d"`)
+
+	_, err = raw.GetByPath(types.NewPathFromString("a.x"))
+	assert.EqualError(t, lastErr(err), `bson.RawDocument.Lookup: key not found: "x"`)
+}
+
+func TestRawDocumentErrors(t *testing.T) {
+	t.Parallel()
+
+	for name, tc := range map[string]struct {
+		b   []byte
+		err string
+	}{
+		"Short": {
+			b:   []byte{0x04, 0x00, 0x00, 0x00},
+			err: `bson.RawIterator.Next: invalid length 4`,
+		},
+		"Length": {
+			b:   []byte{0x06, 0x00, 0x00, 0x00, 0x00},
+			err: `bson.RawIterator.Next: length 6, expected 5`,
+		},
+		"Terminator": {
+			b:   []byte{0x05, 0x00, 0x00, 0x00, 0x01},
+			err: `bson.RawIterator.Next: unexpected terminating byte 0x01`,
+		},
+		"EarlyEnd": {
+			b:   []byte{0x06, 0x00, 0x00, 0x00, 0x00, 0x00},
+			err: `bson.RawIterator.Next: unexpected end of the document`,
+		},
+		"Key": {
+			b:   []byte{0x07, 0x00, 0x00, 0x00, 0x0a, 0x61, 0x00},
+			err: `bson.RawIterator.Next: unterminated key`,
+		},
+		"ValueLength": {
+			b:   []byte{0x0b, 0x00, 0x00, 0x00, 0x10, 0x61, 0x00, 0x01, 0x00, 0x00, 0x00},
+			err: `Int32 value length 4 exceeds remaining 3 bytes`,
+		},
+		"StringLength": {
+			b:   []byte{0x0c, 0x00, 0x00, 0x00, 0x02, 0x61, 0x00, 0xff, 0xff, 0xff, 0xff, 0x00},
+			err: `invalid length -1`,
+		},
+		"Type": {
+			b:   []byte{0x08, 0x00, 0x00, 0x00, 0x42, 0x61, 0x00, 0x00},
+			err: `unhandled element type 0x42 (tag(66))`,
+		},
+	} {
+		name, tc := name, tc
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			err := RawDocument(tc.b).Validate()
+			require.Error(t, err)
+			assert.EqualError(t, lastErr(err), tc.err)
+
+			// the same error is returned again
+			iter := RawDocument(tc.b).Iterator()
+			_, _, err1 := iter.Next()
+			_, _, err2 := iter.Next()
+			assert.Equal(t, err1, err2)
+		})
+	}
+}
+
+func FuzzRawDocument(f *testing.F) {
+	for _, tc := range documentTestCases {
+		f.Add(tc.b)
+	}
+
+	f.Fuzz(func(t *testing.T, b []byte) {
+		t.Parallel()
+
+		// Validate first: it bounds all lengths by the document size,
+		// so decoding below can't make huge allocations for malformed lengths.
+		raw := RawDocument(b)
+		if err := raw.Validate(); err != nil {
+			t.Skip()
+		}
+
+		var doc Document
+		if err := doc.ReadFrom(bufio.NewReader(bytes.NewReader(b))); err != nil {
+			t.Skip()
+		}
+
+		// a document that could be fully decoded should be decoded lazily to the same values
+		expected := must.NotFail(types.ConvertDocument(&doc))
+
+		actual := must.NotFail(types.NewDocument())
+
+		iter := raw.Iterator()
+		for {
+			key, v, err := iter.Next()
+			if err == io.EOF {
+				break
+			}
+			require.NoError(t, err)
+
+			value, err := v.Decode()
+			require.NoError(t, err)
+			require.NoError(t, actual.Set(key, value))
+		}
+
+		testutil.AssertEqual(t, expected, actual)
+	})
+}
+
+// largeDocument returns an encoded document with a few small fields around a large one.
+func largeDocument(b *testing.B) []byte {
+	b.Helper()
+
+	items := types.MakeArray(1000)
+	for i := 0; i < 1000; i++ {
+		must.NoError(items.Append(must.NotFail(types.NewDocument(
+			"name", "item "+strconv.Itoa(i),
+			"value", float64(i),
+		))))
+	}
+
+	doc := must.NotFail(types.NewDocument(
+		"_id", types.ObjectID{0x42},
+		"status", "active",
+		"items", items,
+		"last", int32(42),
+	))
+
+	return must.NotFail(MustConvertDocument(doc).MarshalBinary())
+}
+
+func BenchmarkDocumentLarge(b *testing.B) {
+	data := largeDocument(b)
+
+	for _, key := range []string{"status", "last"} {
+		key := key
+		b.Run(key, func(b *testing.B) {
+			b.ReportAllocs()
+
+			for i := 0; i < b.N; i++ {
+				var doc Document
+				must.NoError(doc.ReadFrom(bufio.NewReader(bytes.NewReader(data))))
+				if doc.m[key] == nil {
+					b.Fatal(key)
+				}
+			}
+		})
+	}
+}
+
+func BenchmarkRawDocumentLarge(b *testing.B) {
+	data := largeDocument(b)
+
+	for _, key := range []string{"status", "last"} {
+		key := key
+		b.Run(key, func(b *testing.B) {
+			b.ReportAllocs()
+
+			for i := 0; i < b.N; i++ {
+				if _, err := RawDocument(data).Get(key); err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+	}
+}
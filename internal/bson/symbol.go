@@ -0,0 +1,63 @@
+// Copyright 2021 FerretDB Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package bson
+
+import (
+	"bufio"
+
+	"github.com/FerretDB/FerretDB/internal/types"
+	"github.com/FerretDB/FerretDB/internal/util/lazyerrors"
+)
+
+// symbolType represents deprecated BSON Symbol type.
+type symbolType types.Symbol
+
+func (sym *symbolType) bsontype() {}
+
+// ReadFrom implements bsontype interface.
+func (sym *symbolType) ReadFrom(r *bufio.Reader) error {
+	var s stringType
+	if err := s.ReadFrom(r); err != nil {
+		return lazyerrors.Errorf("bson.Symbol.ReadFrom: %w", err)
+	}
+
+	*sym = symbolType(s)
+	return nil
+}
+
+// WriteTo implements bsontype interface.
+func (sym symbolType) WriteTo(w *bufio.Writer) error {
+	v, err := sym.MarshalBinary()
+	if err != nil {
+		return lazyerrors.Errorf("bson.Symbol.WriteTo: %w", err)
+	}
+
+	_, err = w.Write(v)
+	if err != nil {
+		return lazyerrors.Errorf("bson.Symbol.WriteTo: %w", err)
+	}
+
+	return nil
+}
+
+// MarshalBinary implements bsontype interface.
+func (sym symbolType) MarshalBinary() ([]byte, error) {
+	return stringType(sym).MarshalBinary()
+}
+
+// check interfaces
+var (
+	_ bsontype = (*symbolType)(nil)
+)
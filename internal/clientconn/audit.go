@@ -0,0 +1,138 @@
+// Copyright 2021 FerretDB Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package clientconn
+
+import (
+	"time"
+
+	"github.com/FerretDB/FerretDB/internal/handlers/common"
+	"github.com/FerretDB/FerretDB/internal/types"
+	"github.com/FerretDB/FerretDB/internal/util/audit"
+	"github.com/FerretDB/FerretDB/internal/wire"
+)
+
+// auditTypes maps audited commands to audit event types.
+//
+// Unauthorized errors of all commands are audited as audit.TypeAuthCheck events.
+var auditTypes = map[string]string{
+	"authenticate":  audit.TypeAuthenticate,
+	"saslStart":     audit.TypeAuthenticate,
+	"saslContinue":  audit.TypeAuthenticate,
+	"create":        audit.TypeCreateCollection,
+	"drop":          audit.TypeDropCollection,
+	"dropDatabase":  audit.TypeDropDatabase,
+	"createIndexes": audit.TypeCreateIndex,
+	"createUser":    audit.TypeCreateUser,
+	"dropUser":      audit.TypeDropUser,
+}
+
+// auditStart returns a new audit event for the given command before it is handled.
+//
+// It returns nil if audit logging is disabled.
+func (c *conn) auditStart(command string, document *types.Document) *audit.Event {
+	if c.audit == nil {
+		return nil
+	}
+
+	db, _ := common.GetOptionalParam(document, "$db", "")
+
+	e := &audit.Event{
+		AType:  auditTypes[command],
+		TS:     time.Now(),
+		Local:  audit.NewAddr(c.netConn.LocalAddr()),
+		Remote: audit.NewAddr(c.netConn.RemoteAddr()),
+		Param:  map[string]any{},
+	}
+
+	switch command {
+	case "authenticate":
+		mechanism, _ := common.GetOptionalParam(document, "mechanism", "")
+		user, _ := common.GetOptionalParam(document, "user", "")
+		e.Param = map[string]any{"user": user, "db": db, "mechanism": mechanism}
+
+	case "saslStart":
+		mechanism, _ := common.GetOptionalParam(document, "mechanism", "")
+		e.Param = map[string]any{"user": common.SASLStartUsername(document), "db": db, "mechanism": mechanism}
+
+	case "saslContinue":
+		// conversation is reset on failure, so get username before the command is handled
+		var user string
+		if conv := c.connInfo.SASLConversation(); conv != nil {
+			user = conv.Username()
+		}
+		e.Param = map[string]any{"user": user, "db": db}
+
+	case "create", "drop", "createIndexes":
+		collection, _ := common.GetOptionalParam(document, command, "")
+		e.Param = map[string]any{"ns": db + "." + collection}
+
+	case "dropDatabase":
+		e.Param = map[string]any{"ns": db}
+
+	case "createUser", "dropUser":
+		user, _ := common.GetOptionalParam(document, command, "")
+		e.Param = map[string]any{"user": user, "db": db}
+
+	default:
+		e.Param = map[string]any{"command": command, "ns": db}
+	}
+
+	return e
+}
+
+// auditFinish completes the audit event started by auditStart and logs it, if needed.
+func (c *conn) auditFinish(e *audit.Event, resBody wire.MsgBody, err error) {
+	if e == nil {
+		return
+	}
+
+	if err != nil {
+		protoErr, _ := common.ProtocolError(err)
+		e.Result = int32(protoErr.Code())
+
+		if protoErr.Code() == common.ErrUnauthorized {
+			e.AType = audit.TypeAuthCheck
+		}
+	}
+
+	if e.AType == "" {
+		return
+	}
+
+	// SASL conversation is audited once it is completed
+	if e.AType == audit.TypeAuthenticate && err == nil {
+		if msg, ok := resBody.(*wire.OpMsg); ok {
+			if res, _ := msg.Document(); res != nil {
+				if done, _ := common.GetOptionalParam(res, "done", true); !done {
+					return
+				}
+			}
+		}
+	}
+
+	e.Users = []audit.User{}
+	if username, db := c.connInfo.Auth(); username != "" {
+		e.Users = append(e.Users, audit.User{User: username, DB: db})
+	}
+
+	e.Roles = []audit.Role{}
+	for _, role := range c.connInfo.Roles() {
+		e.Roles = append(e.Roles, audit.Role{Role: role, DB: "admin"})
+	}
+
+	if err = c.audit.Log(e); err != nil {
+		c.l.Warnf("Failed to write audit event: %s", err)
+	}
+}
@@ -0,0 +1,125 @@
+// Copyright 2021 FerretDB Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package clientconn
+
+import (
+	"encoding/json"
+	"net"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+
+	"github.com/FerretDB/FerretDB/internal/clientconn/conninfo"
+	"github.com/FerretDB/FerretDB/internal/handlers/common"
+	"github.com/FerretDB/FerretDB/internal/types"
+	"github.com/FerretDB/FerretDB/internal/util/audit"
+	"github.com/FerretDB/FerretDB/internal/util/must"
+	"github.com/FerretDB/FerretDB/internal/wire"
+)
+
+func TestAudit(t *testing.T) {
+	t.Parallel()
+
+	path := filepath.Join(t.TempDir(), "audit.json")
+	logger, err := audit.NewLogger(&audit.NewLoggerOpts{Destination: audit.DestinationFile, Path: path})
+	require.NoError(t, err)
+
+	netConn, peer := net.Pipe()
+	t.Cleanup(func() {
+		netConn.Close()
+		peer.Close()
+	})
+
+	c := &conn{
+		netConn:  netConn,
+		l:        zap.NewNop().Sugar(),
+		connInfo: new(conninfo.ConnInfo),
+		audit:    logger,
+	}
+
+	reply := func(pairs ...any) wire.MsgBody {
+		var msg wire.OpMsg
+		must.NoError(msg.SetSections(wire.OpMsgSection{
+			Documents: []*types.Document{must.NotFail(types.NewDocument(pairs...))},
+		}))
+		return &msg
+	}
+
+	// not completed SASL conversation is not logged
+	e := c.auditStart("saslStart", must.NotFail(types.NewDocument(
+		"saslStart", int32(1), "mechanism", common.MechanismSCRAMSHA256, "payload", "n,,n=user,r=nonce", "$db", "admin",
+	)))
+	c.auditFinish(e, reply("done", false, "ok", float64(1)), nil)
+
+	e = c.auditStart("saslContinue", must.NotFail(types.NewDocument("saslContinue", int32(1), "$db", "admin")))
+	c.auditFinish(e, nil, common.NewErrorMsg(common.ErrAuthenticationFailed, "Authentication failed."))
+
+	c.connInfo.SetAuth("user", "admin")
+	c.connInfo.SetRoles([]string{"readWriteAnyDatabase"})
+
+	e = c.auditStart("create", must.NotFail(types.NewDocument("create", "values", "$db", "test")))
+	c.auditFinish(e, reply("ok", float64(1)), nil)
+
+	// not audited command is logged only for Unauthorized errors
+	e = c.auditStart("find", must.NotFail(types.NewDocument("find", "values", "$db", "test")))
+	c.auditFinish(e, reply("ok", float64(1)), nil)
+
+	e = c.auditStart("find", must.NotFail(types.NewDocument("find", "values", "$db", "test")))
+	c.auditFinish(e, nil, common.NewErrorMsg(common.ErrUnauthorized, "Command requires authentication"))
+
+	require.NoError(t, logger.Close())
+
+	b, err := os.ReadFile(path)
+	require.NoError(t, err)
+
+	lines := strings.Split(strings.TrimSpace(string(b)), "\n")
+	require.Len(t, lines, 3)
+
+	var events []map[string]any
+	for _, line := range lines {
+		var event map[string]any
+		require.NoError(t, json.Unmarshal([]byte(line), &event))
+		delete(event, "ts")
+		delete(event, "local")
+		delete(event, "remote")
+		events = append(events, event)
+	}
+
+	expected := []map[string]any{{
+		"atype":  "authenticate",
+		"users":  []any{},
+		"roles":  []any{},
+		"param":  map[string]any{"user": "", "db": "admin"},
+		"result": float64(18),
+	}, {
+		"atype":  "createCollection",
+		"users":  []any{map[string]any{"user": "user", "db": "admin"}},
+		"roles":  []any{map[string]any{"role": "readWriteAnyDatabase", "db": "admin"}},
+		"param":  map[string]any{"ns": "test.values"},
+		"result": float64(0),
+	}, {
+		"atype":  "authCheck",
+		"users":  []any{map[string]any{"user": "user", "db": "admin"}},
+		"roles":  []any{map[string]any{"role": "readWriteAnyDatabase", "db": "admin"}},
+		"param":  map[string]any{"command": "find", "ns": "test"},
+		"result": float64(13),
+	}}
+	assert.Equal(t, expected, events)
+}
@@ -0,0 +1,202 @@
+// Copyright 2021 FerretDB Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package clientconn
+
+import (
+	"github.com/FerretDB/FerretDB/internal/types"
+	"github.com/FerretDB/FerretDB/internal/util/must"
+	"github.com/FerretDB/FerretDB/internal/wire"
+)
+
+// DefaultCompressionThreshold is the default minimal size of the response body to be compressed.
+const DefaultCompressionThreshold = 1024
+
+// compressionExempt contains commands which responses are never compressed, see
+// https://github.com/mongodb/specifications/blob/master/source/compression/OP_COMPRESSED.rst.
+var compressionExempt = map[string]struct{}{
+	"hello":           {},
+	"isMaster":        {},
+	"ismaster":        {},
+	"saslStart":       {},
+	"saslContinue":    {},
+	"getnonce":        {},
+	"authenticate":    {},
+	"createUser":      {},
+	"updateUser":      {},
+	"copydbSaslStart": {},
+	"copydbgetnonce":  {},
+	"copydb":          {},
+}
+
+// requestCommand returns the command name of OP_MSG or OP_QUERY request, or empty string.
+func requestCommand(body wire.MsgBody) string {
+	switch body := body.(type) {
+	case *wire.OpMsg:
+		document, err := body.Document()
+		if err != nil {
+			return ""
+		}
+		return document.Command()
+
+	case *wire.OpQuery:
+		if body.Query == nil {
+			return ""
+		}
+		return body.Query.Command()
+
+	default:
+		return ""
+	}
+}
+
+// negotiateCompression adds enabled compressors requested by the client
+// in the hello or isMaster request document to the response body
+// and stores them for the connection.
+// Compressors are listed in the client's order of preference.
+func (c *conn) negotiateCompression(req *types.Document, resBody wire.MsgBody) wire.MsgBody {
+	if _, ok := helloCommands[req.Command()]; !ok {
+		return resBody
+	}
+
+	c.negotiated = nil
+
+	v, _ := req.Get("compression")
+	requested, ok := v.(*types.Array)
+	if !ok {
+		return resBody
+	}
+
+	compression := must.NotFail(types.NewArray())
+	for i := 0; i < requested.Len(); i++ {
+		name, ok := must.NotFail(requested.Get(i)).(string)
+		if !ok {
+			continue
+		}
+
+		for _, id := range c.compressors {
+			if id.String() == name {
+				c.negotiated = append(c.negotiated, id)
+				must.NoError(compression.Append(name))
+			}
+		}
+	}
+
+	if compression.Len() == 0 {
+		return resBody
+	}
+
+	switch resBody := resBody.(type) {
+	case *wire.OpMsg:
+		document, err := resBody.Document()
+		if err != nil {
+			return resBody
+		}
+
+		must.NoError(document.Set("compression", compression))
+
+		var res wire.OpMsg
+		must.NoError(res.SetSections(wire.OpMsgSection{
+			Documents: []*types.Document{document},
+		}))
+
+		return &res
+
+	case *wire.OpReply:
+		if len(resBody.Documents) != 1 {
+			return resBody
+		}
+
+		res := *resBody
+		res.Documents = []*types.Document{resBody.Documents[0].DeepCopy()}
+		must.NoError(res.Documents[0].Set("compression", compression))
+
+		return &res
+
+	default:
+		return resBody
+	}
+}
+
+// compressorEnabled returns true if the given compressor is enabled for the connection.
+//
+// Requests compressed with other compressors are rejected,
+// even if they are supported by the wire package.
+func (c *conn) compressorEnabled(compressor wire.CompressorID) bool {
+	for _, id := range c.compressors {
+		if id == compressor {
+			return true
+		}
+	}
+
+	return false
+}
+
+// responseCompressor returns the compressor for the response to the request compressed with the given compressor.
+//
+// The request's compressor is used if it is enabled, the first negotiated compressor otherwise.
+// False is returned if there is no suitable compressor.
+func (c *conn) responseCompressor(reqCompressor wire.CompressorID) (wire.CompressorID, bool) {
+	if c.compressorEnabled(reqCompressor) {
+		return reqCompressor, true
+	}
+
+	if len(c.negotiated) > 0 {
+		return c.negotiated[0], true
+	}
+
+	return 0, false
+}
+
+// compressResponse compresses the response to the request compressed with the given compressor,
+// unless the command is exempt from compression or the response is small.
+func (c *conn) compressResponse(
+	command string, reqCompressor wire.CompressorID, resHeader *wire.MsgHeader, resBody wire.MsgBody,
+) (*wire.MsgHeader, wire.MsgBody) {
+	if _, ok := compressionExempt[command]; ok {
+		return resHeader, resBody
+	}
+
+	compressor, ok := c.responseCompressor(reqCompressor)
+	if !ok {
+		return resHeader, resBody
+	}
+
+	if int(resHeader.MessageLength)-wire.MsgHeaderLen < c.compressionThreshold {
+		return resHeader, resBody
+	}
+
+	header, body, err := wire.NewOpCompressed(resHeader, resBody, compressor)
+	if err != nil {
+		c.l.Warnf("Failed to compress response: %s", err)
+		return resHeader, resBody
+	}
+
+	c.compressionSaved(compressor, "response", resHeader, header)
+
+	return header, body
+}
+
+// compressionSaved updates metrics with the number of bytes saved by compression
+// of the message with the given uncompressed and compressed headers.
+func (c *conn) compressionSaved(compressor wire.CompressorID, direction string, uncompressed, compressed *wire.MsgHeader) {
+	if c.m == nil {
+		return
+	}
+
+	// incompressible messages are slightly larger after compression; that is not counted
+	if saved := uncompressed.MessageLength - compressed.MessageLength; saved > 0 {
+		c.m.compressionSaved.WithLabelValues(compressor.String(), direction).Add(float64(saved))
+	}
+}
@@ -0,0 +1,217 @@
+// Copyright 2021 FerretDB Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package clientconn
+
+import (
+	"bufio"
+	"context"
+	"io"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zaptest"
+
+	"github.com/FerretDB/FerretDB/internal/handlers/dummy"
+	"github.com/FerretDB/FerretDB/internal/types"
+	"github.com/FerretDB/FerretDB/internal/util/must"
+	"github.com/FerretDB/FerretDB/internal/wire"
+)
+
+func TestNegotiateCompression(t *testing.T) {
+	t.Parallel()
+
+	c := &conn{
+		l:           zap.NewNop().Sugar(),
+		compressors: []wire.CompressorID{wire.CompressorZlib, wire.CompressorSnappy, wire.CompressorZstd},
+	}
+
+	var res wire.OpMsg
+	must.NoError(res.SetSections(wire.OpMsgSection{
+		Documents: []*types.Document{must.NotFail(types.NewDocument("ok", float64(1)))},
+	}))
+
+	req := must.NotFail(types.NewDocument(
+		"hello", int32(1),
+		"compression", must.NotFail(types.NewArray("zstd", "snappy", int32(42), "lz4")),
+	))
+	actual, err := c.negotiateCompression(req, &res).(*wire.OpMsg).Document()
+	require.NoError(t, err)
+	expected := must.NotFail(types.NewDocument(
+		"ok", float64(1),
+		"compression", must.NotFail(types.NewArray("zstd", "snappy")),
+	))
+	assert.Equal(t, expected, actual)
+	assert.Equal(t, []wire.CompressorID{wire.CompressorZstd, wire.CompressorSnappy}, c.negotiated)
+
+	// not a hello command
+	req = must.NotFail(types.NewDocument("find", "values", "compression", must.NotFail(types.NewArray("zlib"))))
+	assert.Same(t, &res, c.negotiateCompression(req, &res))
+	assert.Equal(t, []wire.CompressorID{wire.CompressorZstd, wire.CompressorSnappy}, c.negotiated)
+
+	// no common compressors
+	req = must.NotFail(types.NewDocument("isMaster", int32(1), "compression", must.NotFail(types.NewArray("lz4"))))
+	assert.Same(t, &res, c.negotiateCompression(req, &res))
+	assert.Empty(t, c.negotiated)
+
+	expected = must.NotFail(types.NewDocument(
+		"ok", float64(1),
+		"compression", must.NotFail(types.NewArray("snappy")),
+	))
+
+	reply := &wire.OpReply{
+		NumberReturned: 1,
+		Documents:      []*types.Document{must.NotFail(types.NewDocument("ok", float64(1)))},
+	}
+	req = must.NotFail(types.NewDocument("ismaster", int32(1), "compression", must.NotFail(types.NewArray("snappy"))))
+	actualReply := c.negotiateCompression(req, reply).(*wire.OpReply)
+	assert.Equal(t, []*types.Document{expected}, actualReply.Documents)
+	assert.False(t, reply.Documents[0].Has("compression"), "original reply should not be modified")
+}
+
+func TestCompressResponse(t *testing.T) {
+	t.Parallel()
+
+	c := &conn{
+		l:                    zap.NewNop().Sugar(),
+		m:                    newConnMetrics(),
+		compressors:          []wire.CompressorID{wire.CompressorSnappy, wire.CompressorZstd},
+		negotiated:           []wire.CompressorID{wire.CompressorZstd},
+		compressionThreshold: 100,
+	}
+
+	reply := func(s string) (*wire.MsgHeader, wire.MsgBody) {
+		var msg wire.OpMsg
+		must.NoError(msg.SetSections(wire.OpMsgSection{
+			Documents: []*types.Document{must.NotFail(types.NewDocument("v", s, "ok", float64(1)))},
+		}))
+
+		header := &wire.MsgHeader{
+			MessageLength: int32(wire.MsgHeaderLen + len(must.NotFail(msg.MarshalBinary()))),
+			RequestID:     2,
+			ResponseTo:    1,
+			OpCode:        wire.OpCodeMsg,
+		}
+
+		return header, &msg
+	}
+
+	// small response
+	header, body := reply("small")
+	actualHeader, actualBody := c.compressResponse("find", wire.CompressorSnappy, header, body)
+	assert.Same(t, header, actualHeader)
+	assert.Same(t, body, actualBody)
+
+	// large response
+	header, body = reply(string(make([]byte, 1000)))
+	actualHeader, actualBody = c.compressResponse("find", wire.CompressorSnappy, header, body)
+	assert.Equal(t, wire.OpCodeCompressed, actualHeader.OpCode)
+	assert.Less(t, actualHeader.MessageLength, header.MessageLength)
+
+	compressed := actualBody.(*wire.OpCompressed)
+	assert.Equal(t, wire.CompressorSnappy, compressed.Compressor)
+
+	originalHeader, originalBody, err := compressed.Message(actualHeader)
+	require.NoError(t, err)
+	assert.Equal(t, header, originalHeader)
+	assert.Equal(t, body, originalBody)
+
+	saved := testutil.ToFloat64(c.m.compressionSaved.WithLabelValues("snappy", "response"))
+	assert.Equal(t, float64(header.MessageLength-actualHeader.MessageLength), saved)
+
+	// not enabled request compressor, the first negotiated is used
+	actualHeader, actualBody = c.compressResponse("find", wire.CompressorZlib, header, body)
+	assert.Equal(t, wire.OpCodeCompressed, actualHeader.OpCode)
+	assert.Equal(t, wire.CompressorZstd, actualBody.(*wire.OpCompressed).Compressor)
+
+	// nothing negotiated
+	c.negotiated = nil
+	actualHeader, actualBody = c.compressResponse("find", wire.CompressorZlib, header, body)
+	assert.Same(t, header, actualHeader)
+	assert.Same(t, body, actualBody)
+
+	// exempt command
+	actualHeader, actualBody = c.compressResponse("saslStart", wire.CompressorSnappy, header, body)
+	assert.Same(t, header, actualHeader)
+	assert.Same(t, body, actualBody)
+}
+
+func TestDisabledCompressor(t *testing.T) {
+	t.Parallel()
+
+	h, err := dummy.New()
+	require.NoError(t, err)
+
+	pl := NewPipeListener()
+
+	l := NewListener(&NewListenerOpts{
+		Listeners:   []ListenerConfig{{Listener: pl}},
+		Mode:        NormalMode,
+		Handler:     h,
+		Logger:      zaptest.NewLogger(t),
+		Compressors: []wire.CompressorID{wire.CompressorZstd},
+	})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		_ = l.Run(ctx)
+	}()
+
+	var msg wire.OpMsg
+	must.NoError(msg.SetSections(wire.OpMsgSection{
+		Documents: []*types.Document{must.NotFail(types.NewDocument("ping", int32(1), "$db", "admin"))},
+	}))
+
+	header := &wire.MsgHeader{
+		MessageLength: int32(wire.MsgHeaderLen + len(must.NotFail(msg.MarshalBinary()))),
+		RequestID:     1,
+		OpCode:        wire.OpCodeMsg,
+	}
+
+	for compressor, enabled := range map[wire.CompressorID]bool{
+		wire.CompressorZstd:   true,
+		wire.CompressorSnappy: false,
+		wire.CompressorNoop:   false,
+	} {
+		conn, err := pl.Dial(ctx)
+		require.NoError(t, err)
+		require.NoError(t, conn.SetDeadline(time.Now().Add(5*time.Second)))
+
+		reqHeader, reqBody, err := wire.NewOpCompressed(header, &msg, compressor)
+		require.NoError(t, err)
+
+		bufw := bufio.NewWriter(conn)
+		require.NoError(t, wire.WriteMessage(bufw, reqHeader, reqBody))
+		require.NoError(t, bufw.Flush())
+
+		resHeader, _, err := wire.ReadMessage(bufio.NewReader(conn))
+		if enabled {
+			require.NoError(t, err, "%s", compressor)
+			assert.Equal(t, int32(1), resHeader.ResponseTo)
+		} else {
+			assert.ErrorIs(t, err, io.EOF, "%s: connection should be closed", compressor)
+		}
+
+		require.NoError(t, conn.Close())
+	}
+
+	cancel()
+	<-done
+}
@@ -18,14 +18,17 @@ package clientconn
 import (
 	"bufio"
 	"context"
+	"crypto/tls"
 	"errors"
 	"fmt"
 	"net"
+	"os"
+	"runtime/pprof"
+	"strconv"
 	"sync/atomic"
 	"time"
 
 	"github.com/AlekSi/pointer"
-	"github.com/pmezard/go-difflib/difflib"
 	"github.com/prometheus/client_golang/prometheus"
 	"go.uber.org/zap"
 	"go.uber.org/zap/zapcore"
@@ -35,8 +38,11 @@ import (
 	"github.com/FerretDB/FerretDB/internal/handlers/common"
 	"github.com/FerretDB/FerretDB/internal/handlers/proxy"
 	"github.com/FerretDB/FerretDB/internal/types"
+	"github.com/FerretDB/FerretDB/internal/util/audit"
+	"github.com/FerretDB/FerretDB/internal/util/fault"
 	"github.com/FerretDB/FerretDB/internal/util/lazyerrors"
 	"github.com/FerretDB/FerretDB/internal/util/must"
+	"github.com/FerretDB/FerretDB/internal/util/tracing"
 	"github.com/FerretDB/FerretDB/internal/wire"
 )
 
@@ -67,7 +73,24 @@ type conn struct {
 	h             handlers.Interface
 	m             *ConnMetrics
 	proxy         *proxy.Router
+	connInfo      *conninfo.ConnInfo
+	limiter       *connLimiter
+	audit         *audit.Logger
+	idleTimeout   time.Duration
+	drain         <-chan struct{}
+	slowOps       *slowOpsConfig
+	middlewares   commandFunc
+	tracer        *tracing.Tracer
 	lastRequestID int32
+
+	compressors          []wire.CompressorID
+	negotiated           []wire.CompressorID
+	compressionThreshold int
+
+	capture   *wire.CaptureWriter
+	captureID uint64
+
+	faults *fault.Injector
 }
 
 // newConnOpts represents newConn options.
@@ -78,6 +101,21 @@ type newConnOpts struct {
 	handler     handlers.Interface
 	connMetrics *ConnMetrics
 	proxyAddr   string
+	limiter     *connLimiter    // may be nil
+	auditLogger *audit.Logger   // may be nil
+	idleTimeout time.Duration   // 0 means no timeout
+	drain       <-chan struct{} // closed when the listener starts draining connections; may be nil
+	slowOps     *slowOpsConfig  // may be nil
+	middlewares commandFunc     // runs middlewares and the handler; may be nil
+	tracer      *tracing.Tracer // may be nil
+
+	compressors          []wire.CompressorID // enabled compressors
+	compressionThreshold int                 // 0 means DefaultCompressionThreshold
+
+	capture   *wire.CaptureWriter // may be nil
+	captureID uint64              // connection ID for logs and capture records
+
+	faults *fault.Injector // may be nil
 }
 
 // newConn creates a new client connection for given net.Conn.
@@ -87,7 +125,7 @@ func newConn(opts *newConnOpts) (*conn, error) {
 	}
 
 	prefix := fmt.Sprintf("// %s -> %s ", opts.netConn.RemoteAddr(), opts.netConn.LocalAddr())
-	l := opts.l.Named(prefix)
+	l := opts.l.Named(prefix).With(zap.Uint64("connectionId", opts.captureID))
 
 	var p *proxy.Router
 	if opts.mode != NormalMode {
@@ -97,6 +135,11 @@ func newConn(opts *newConnOpts) (*conn, error) {
 		}
 	}
 
+	threshold := opts.compressionThreshold
+	if threshold == 0 {
+		threshold = DefaultCompressionThreshold
+	}
+
 	return &conn{
 		netConn: opts.netConn,
 		mode:    opts.mode,
@@ -104,6 +147,24 @@ func newConn(opts *newConnOpts) (*conn, error) {
 		h:       opts.handler,
 		m:       opts.connMetrics,
 		proxy:   p,
+		connInfo: &conninfo.ConnInfo{
+			PeerAddr: opts.netConn.RemoteAddr(),
+		},
+		limiter:     opts.limiter,
+		audit:       opts.auditLogger,
+		idleTimeout: opts.idleTimeout,
+		drain:       opts.drain,
+		slowOps:     opts.slowOps,
+		middlewares: opts.middlewares,
+		tracer:      opts.tracer,
+
+		compressors:          opts.compressors,
+		compressionThreshold: threshold,
+
+		capture:   opts.capture,
+		captureID: opts.captureID,
+
+		faults: opts.faults,
 	}, nil
 }
 
@@ -114,15 +175,27 @@ func newConn(opts *newConnOpts) (*conn, error) {
 func (c *conn) run(ctx context.Context) (err error) {
 	done := make(chan struct{})
 
-	// handle ctx cancelation
+	// handle ctx cancelation and draining
 	go func() {
-		select {
-		case <-done:
-			// nothing, let goroutine exit
-		case <-ctx.Done():
-			// unblocks ReadMessage below; any non-zero past value will do
-			if e := c.netConn.SetDeadline(time.Unix(0, 0)); e != nil {
-				c.l.Warnf("Failed to set deadline: %s", e)
+		drain := c.drain
+
+		for {
+			select {
+			case <-done:
+				// nothing, let goroutine exit
+				return
+			case <-drain:
+				// unblocks ReadMessage below, but lets the current operation finish and send the response
+				if e := c.netConn.SetReadDeadline(time.Unix(0, 0)); e != nil {
+					c.l.Warnf("Failed to set read deadline: %s", e)
+				}
+				drain = nil
+			case <-ctx.Done():
+				// unblocks ReadMessage below; any non-zero past value will do
+				if e := c.netConn.SetDeadline(time.Unix(0, 0)); e != nil {
+					c.l.Warnf("Failed to set deadline: %s", e)
+				}
+				return
 			}
 		}
 	}()
@@ -142,6 +215,24 @@ func (c *conn) run(ctx context.Context) (err error) {
 		close(done)
 	}()
 
+	// the client of rejected connection has limited time to send the first request
+	if c.limiter.rejected() != nil {
+		if err = c.setDeadline(ctx, c.netConn.SetDeadline, rejectedConnTimeout); err != nil {
+			return
+		}
+	}
+
+	// complete TLS handshake before reading the first message to get client certificate
+	if tlsConn, ok := c.netConn.(*tls.Conn); ok {
+		if err = tlsConn.HandshakeContext(ctx); err != nil {
+			return
+		}
+
+		if state := tlsConn.ConnectionState(); len(state.VerifiedChains) > 0 {
+			c.connInfo.PeerCertificate = state.PeerCertificates[0]
+		}
+	}
+
 	bufr := bufio.NewReader(c.netConn)
 	bufw := bufio.NewWriter(c.netConn)
 	defer func() {
@@ -153,21 +244,124 @@ func (c *conn) run(ctx context.Context) (err error) {
 			c.proxy.Close()
 		}
 
+		// cursors without timeout are not closed when idle, so they are closed with the connection
+		if c.mode != ProxyMode {
+			common.KillConnCursors(conninfo.WithConnInfo(ctx, c.connInfo))
+		}
+
 		// c.netConn is closed by the caller
 	}()
 
+	// the last request with the response sent with moreToCome flag, see exhaustHello
+	var exhaustHeader *wire.MsgHeader
+	var exhaustBody wire.MsgBody
+
 	for {
 		var reqHeader *wire.MsgHeader
 		var reqBody wire.MsgBody
-		reqHeader, reqBody, err = wire.ReadMessage(bufr)
-		if err != nil {
-			return
+		var reqCompressor *wire.CompressorID
+
+		if exhaustHeader != nil {
+			// handle the same request again without reading
+			reqHeader, reqBody = exhaustHeader, exhaustBody
+			exhaustHeader, exhaustBody = nil, nil
+		} else {
+			// do not start new operations during shutdown
+			if c.draining() {
+				err = errShutdown
+				return
+			}
+
+			if c.idleTimeout > 0 && c.limiter.rejected() == nil {
+				if err = c.setDeadline(ctx, c.netConn.SetReadDeadline, c.idleTimeout); err != nil {
+					return
+				}
+			}
+
+			reqHeader, reqBody, err = wire.ReadMessage(bufr)
+			if err != nil {
+				if c.draining() {
+					err = errShutdown
+					return
+				}
+
+				if c.idleTimeout > 0 && errors.Is(err, os.ErrDeadlineExceeded) && ctx.Err() == nil {
+					err = fmt.Errorf("no requests for %s, closing idle connection", c.idleTimeout)
+				}
+
+				return
+			}
+
+			c.m.bytes.WithLabelValues("request").Add(float64(reqHeader.MessageLength))
+
+			if c.capture != nil {
+				record := &wire.CaptureRecord{
+					Time:   time.Now(),
+					ConnID: c.captureID,
+					Header: reqHeader,
+					Body:   reqBody,
+				}
+				if e := c.capture.Write(record); e != nil {
+					c.l.Warnf("Failed to capture request: %s", e)
+				}
+			}
+
+			// handle the original message and compress the response with the same compressor
+			if compressed, ok := reqBody.(*wire.OpCompressed); ok {
+				if !c.compressorEnabled(compressed.Compressor) {
+					err = lazyerrors.Errorf("request is compressed with %s compressor that is not enabled", compressed.Compressor)
+					return
+				}
+
+				compressedHeader := reqHeader
+				reqCompressor = &compressed.Compressor
+				if reqHeader, reqBody, err = compressed.Message(reqHeader); err != nil {
+					return
+				}
+
+				c.compressionSaved(compressed.Compressor, "request", reqHeader, compressedHeader)
+			}
+
+			// like MongoDB, close the connection on too large messages
+			if max := wire.GetLimits().MaxMessageSizeBytes; reqHeader.MessageLength > max {
+				err = lazyerrors.Errorf("message length %d exceeds maxMessageSizeBytes %d", reqHeader.MessageLength, max)
+				return
+			}
 		}
 
 		// do not spend time dumping if we are not going to log it
 		if c.l.Desugar().Core().Enabled(zap.DebugLevel) {
 			c.l.Debugf("Request header: %s", reqHeader)
-			c.l.Debugf("Request message:\n%s\n\n\n", reqBody)
+			c.l.Debugf("Request message:\n%s\n\n\n", wire.Redact(reqBody))
+		}
+
+		// there is no response
+		if reqHeader.OpCode == wire.OpCodeKillCursors {
+			c.m.requests.WithLabelValues(reqHeader.OpCode.String(), "killCursors").Inc()
+
+			if c.mode != ProxyMode {
+				killCtx := conninfo.WithConnInfo(ctx, c.connInfo)
+				common.KillCursors(killCtx, reqBody.(*wire.OpKillCursors).CursorIDs)
+			}
+
+			if c.mode != NormalMode {
+				c.proxy.Send(ctx, reqHeader, reqBody)
+			}
+
+			continue
+		}
+
+		// injected faults are checked before the request is handled, so dropped requests are safe to retry
+		if c.faults != nil {
+			var drop bool
+			if drop, err = c.faults.Request(ctx, requestCommand(reqBody)); err != nil {
+				return
+			}
+
+			if drop {
+				err = lazyerrors.Errorf("dropping connection: %w", fault.ErrInjected)
+				return
+			}
 		}
 
 		// diffLogLevel provides the level of logging for the diff between the "normal" and "proxy" responses.
@@ -202,31 +396,13 @@ func (c *conn) run(ctx context.Context) (err error) {
 
 		// diff in diff mode
 		if c.mode == DiffNormalMode || c.mode == DiffProxyMode {
-			var diffHeader string
-			diffHeader, err = difflib.GetUnifiedDiffString(difflib.UnifiedDiff{
-				A:        difflib.SplitLines(resHeader.String()),
-				FromFile: "res header",
-				B:        difflib.SplitLines(proxyHeader.String()),
-				ToFile:   "proxy header",
-				Context:  1,
-			})
-			if err != nil {
-				return
-			}
-
-			var diffBody string
-			diffBody, err = difflib.GetUnifiedDiffString(difflib.UnifiedDiff{
-				A:        difflib.SplitLines(resBody.String()),
-				FromFile: "res body",
-				B:        difflib.SplitLines(proxyBody.String()),
-				ToFile:   "proxy body",
-				Context:  1,
-			})
+			err = c.diffResponses(
+				reqHeader.OpCode, requestCommand(reqBody), diffLogLevel,
+				resHeader, resBody, proxyHeader, proxyBody,
+			)
 			if err != nil {
 				return
 			}
-
-			c.l.Desugar().Check(diffLogLevel, fmt.Sprintf("Header diff:\n%s\nBody diff:\n%s\n\n", diffHeader, diffBody)).Write()
 		}
 
 		// replace response with one from proxy in proxy and diff-proxy modes
@@ -239,6 +415,10 @@ func (c *conn) run(ctx context.Context) (err error) {
 			panic("no response to send to client")
 		}
 
+		if reqCompressor != nil {
+			resHeader, resBody = c.compressResponse(requestCommand(reqBody), *reqCompressor, resHeader, resBody)
+		}
+
 		if err = wire.WriteMessage(bufw, resHeader, resBody); err != nil {
 			return
 		}
@@ -247,10 +427,49 @@ func (c *conn) run(ctx context.Context) (err error) {
 			return
 		}
 
+		c.m.bytes.WithLabelValues("response").Add(float64(resHeader.MessageLength))
+
 		if resCloseConn {
 			err = errors.New("fatal error")
 			return
 		}
+
+		// connections over the limits are closed after the response to the first request
+		if err = c.limiter.rejected(); err != nil {
+			return
+		}
+
+		// the client expects the next response to the same request
+		if resMsg, ok := resBody.(*wire.OpMsg); ok && resMsg.FlagBits.FlagSet(wire.OpMsgMoreToCome) {
+			exhaustHeader, exhaustBody = nextExhaustHello(reqBody, resHeader, resBody)
+		}
+	}
+}
+
+// setDeadline sets the given deadline of the connection to the given duration from now,
+// unless ctx is done or connection is draining.
+//
+// Deadlines set by ctx cancelation and draining in run could be overridden by that call,
+// so they are checked after it.
+func (c *conn) setDeadline(ctx context.Context, set func(time.Time) error, d time.Duration) error {
+	if err := set(time.Now().Add(d)); err != nil {
+		return err
+	}
+
+	if c.draining() {
+		return errShutdown
+	}
+
+	return ctx.Err()
+}
+
+// draining returns true if the listener started draining connections.
+func (c *conn) draining() bool {
+	select {
+	case <-c.drain:
+		return true
+	default:
+		return false
 	}
 }
 
@@ -265,6 +484,17 @@ func (c *conn) run(ctx context.Context) (err error) {
 // They also should not use recover(). That allows us to use fuzzing.
 func (c *conn) route(ctx context.Context, reqHeader *wire.MsgHeader, reqBody wire.MsgBody) (resHeader *wire.MsgHeader, resBody wire.MsgBody, closeConn bool) { //nolint:lll // argument list is too long
 	requests := c.m.requests.MustCurryWith(prometheus.Labels{"opcode": reqHeader.OpCode.String()})
+	start := time.Now()
+
+	var span *tracing.Span
+	if c.tracer != nil {
+		ctx, span = c.tracer.Start(
+			ctx, reqHeader.OpCode.String(),
+			tracing.String("net.sock.peer.addr", c.netConn.RemoteAddr().String()),
+			tracing.Int("ferretdb.request_id", int64(reqHeader.RequestID)),
+		)
+	}
+
 	var command string
 	var result *string
 	defer func() {
@@ -272,12 +502,17 @@ func (c *conn) route(ctx context.Context, reqHeader *wire.MsgHeader, reqBody wir
 			result = pointer.ToString("panic")
 		}
 		c.m.responses.WithLabelValues(resHeader.OpCode.String(), command, *result).Inc()
+		c.m.durations.WithLabelValues(resHeader.OpCode.String(), command, *result).Observe(time.Since(start).Seconds())
+
+		var spanErr error
+		if *result != "ok" {
+			spanErr = errors.New(*result)
+		}
+		span.SetAttrs(tracing.String("ferretdb.result", *result))
+		span.End(spanErr)
 	}()
 
-	connInfo := &conninfo.ConnInfo{
-		PeerAddr: c.netConn.RemoteAddr(),
-	}
-	ctx = conninfo.WithConnInfo(ctx, connInfo)
+	ctx = conninfo.WithConnInfo(ctx, c.connInfo)
 
 	resHeader = new(wire.MsgHeader)
 	var err error
@@ -290,13 +525,62 @@ func (c *conn) route(ctx context.Context, reqHeader *wire.MsgHeader, reqBody wir
 		command = document.Command()
 		if err == nil {
 			resHeader.OpCode = wire.OpCodeMsg
+			e := c.auditStart(command, document)
 			resBody, err = c.handleOpMsg(ctx, msg, command)
+			c.auditFinish(e, resBody, err)
+
+			if err == nil {
+				resBody = c.negotiateCompression(document, resBody)
+			}
 		}
 
 	case wire.OpCodeQuery:
 		query := reqBody.(*wire.OpQuery)
 		resHeader.OpCode = wire.OpCodeReply
-		resBody, err = c.h.CmdQuery(ctx, query)
+
+		var document *types.Document
+		var resMsg *wire.OpMsg
+		var msg *wire.OpMsg
+		if msg, err = legacyQueryMsg(query); err == nil {
+			document = must.NotFail(msg.Document())
+			command = document.Command()
+			e := c.auditStart(command, document)
+			resMsg, err = c.handleOpMsg(ctx, msg, command)
+			c.auditFinish(e, resMsg, err)
+		}
+
+		if err != nil {
+			if protoErr, ok := common.ProtocolError(err); ok {
+				result = pointer.ToString(protoErr.Code().String())
+			}
+		}
+
+		resBody, err = legacyReply(query, resMsg, err)
+
+		if err == nil && document != nil {
+			resBody = c.negotiateCompression(document, resBody)
+		}
+
+	case wire.OpCodeGetMore:
+		resHeader.OpCode = wire.OpCodeReply
+		command = "getMore"
+
+		var resMsg *wire.OpMsg
+		var msg *wire.OpMsg
+		if msg, err = legacyGetMoreMsg(reqBody.(*wire.OpGetMore)); err == nil {
+			document := must.NotFail(msg.Document())
+			e := c.auditStart(command, document)
+			resMsg, err = c.handleOpMsg(ctx, msg, command)
+			c.auditFinish(e, resMsg, err)
+		}
+
+		if err != nil {
+			if protoErr, ok := common.ProtocolError(err); ok {
+				result = pointer.ToString(protoErr.Code().String())
+			}
+		}
+
+		resBody, err = legacyGetMoreReply(resMsg, err)
 
 	case wire.OpCodeReply:
 		fallthrough
@@ -306,11 +590,9 @@ func (c *conn) route(ctx context.Context, reqHeader *wire.MsgHeader, reqBody wir
 		fallthrough
 	case wire.OpCodeGetByOID:
 		fallthrough
-	case wire.OpCodeGetMore:
-		fallthrough
 	case wire.OpCodeDelete:
 		fallthrough
-	case wire.OpCodeKillCursors:
+	case wire.OpCodeKillCursors: // handled by the caller as there is no response
 		fallthrough
 	case wire.OpCodeCompressed:
 		err = lazyerrors.Errorf("unhandled OpCode %s", reqHeader.OpCode)
@@ -372,6 +654,18 @@ func (c *conn) route(ctx context.Context, reqHeader *wire.MsgHeader, reqBody wir
 		}
 	}
 
+	// stream responses to the monitoring connection
+	if err == nil && c.mode == NormalMode && exhaustHello(reqBody, resBody) {
+		resBody.(*wire.OpMsg).FlagBits |= wire.OpMsgFlags(wire.OpMsgMoreToCome)
+	}
+
+	// respond with checksum if the client sent it; it is set by wire.WriteMessage
+	if reqMsg, ok := reqBody.(*wire.OpMsg); ok && reqMsg.FlagBits.FlagSet(wire.OpMsgChecksumPresent) {
+		if resMsg, ok := resBody.(*wire.OpMsg); ok {
+			resMsg.FlagBits |= wire.OpMsgFlags(wire.OpMsgChecksumPresent)
+		}
+	}
+
 	// TODO Don't call MarshalBinary there. Fix header in the caller?
 	// https://github.com/FerretDB/FerretDB/issues/273
 	b, err := resBody.MarshalBinary()
@@ -390,10 +684,98 @@ func (c *conn) route(ctx context.Context, reqHeader *wire.MsgHeader, reqBody wir
 	return
 }
 
-func (c *conn) handleOpMsg(ctx context.Context, msg *wire.OpMsg, cmd string) (*wire.OpMsg, error) {
+func (c *conn) handleOpMsg(ctx context.Context, msg *wire.OpMsg, cmd string) (res *wire.OpMsg, err error) {
+	defer func() {
+		if err != nil {
+			c.countError(cmd, err)
+		}
+	}()
+
+	if err = c.limiter.acquire(cmd); err != nil {
+		return nil, err
+	}
+	defer c.limiter.release(cmd)
+
+	// during shutdown, hello requests get an error, so drivers stop using this server;
+	// awaitable ones waiting for topology changes are interrupted
+	_, hello := helloCommands[cmd]
+	if hello {
+		if c.draining() {
+			return nil, errShutdownInProgress()
+		}
+
+		var cancel context.CancelFunc
+		ctx, cancel = c.withDrain(ctx)
+		defer cancel()
+	}
+
+	// awaitable hello requests are slow by design
+	if slowOps := c.slowOps.get(); slowOps != nil && !hello {
+		var stats *common.OpStats
+		ctx, stats = common.WithOpStats(ctx)
+
+		start := time.Now()
+		defer func() {
+			c.logSlowOp(slowOps, msg, stats, time.Since(start), err)
+		}()
+	}
+
+	ctx, span := tracing.Start(ctx, cmd, tracing.String("db.system", "mongodb"), tracing.String("db.operation", cmd))
+	defer func() {
+		span.End(err)
+	}()
+
 	if cmd, ok := common.Commands[cmd]; ok {
 		if cmd.Handler != nil {
-			return cmd.Handler(c.h, ctx, msg)
+			var document *types.Document
+			if document, err = msg.Document(); err != nil {
+				return nil, lazyerrors.Error(err)
+			}
+
+			db, _ := common.GetOptionalParam(document, "$db", "")
+			if db != "" {
+				span.SetAttrs(tracing.String("db.name", db))
+			}
+
+			caps := c.h.Capabilities()
+
+			if caps.Authorization {
+				if err = common.CheckRoles(ctx, document); err != nil {
+					return nil, err
+				}
+			}
+
+			if err = common.CheckCapabilities(caps, document); err != nil {
+				return nil, err
+			}
+
+			if err = common.CheckSystemCollections(document); err != nil {
+				return nil, err
+			}
+
+			// writes wait while they are locked by fsync command
+			if cmd.Write {
+				var done func()
+				if done, err = common.StartWrite(ctx); err != nil {
+					return nil, lazyerrors.Error(err)
+				}
+				defer done()
+			}
+
+			// labels are inherited by goroutines started by the handler
+			pprof.Do(ctx, profileLabels(document, db), func(ctx context.Context) {
+				if c.middlewares != nil {
+					res, err = c.middlewares(ctx, msg)
+					return
+				}
+
+				res, err = cmd.Handler(c.h, ctx, msg)
+			})
+			if hello && c.draining() {
+				return nil, errShutdownInProgress()
+			}
+
+			return res, err
 		}
 	}
 
@@ -401,6 +783,49 @@ func (c *conn) handleOpMsg(ctx context.Context, msg *wire.OpMsg, cmd string) (*w
 	return nil, common.NewErrorMsg(common.ErrCommandNotFound, errMsg)
 }
 
+// countError updates errors metrics for the error returned by the command.
+//
+// Errors caused by the backend are also logged, because clients get only sanitized messages.
+func (c *conn) countError(cmd string, err error) {
+	protoErr, _ := common.ProtocolError(err)
+	code := protoErr.Code()
+	source := common.ErrorSource(err)
+
+	c.m.errors.WithLabelValues(strconv.Itoa(int(code)), code.String(), source).Inc()
+
+	if source == common.ErrorSourceBackend {
+		c.l.Desugar().Warn(
+			"Command failed", zap.String("command", cmd), zap.String("source", source),
+			zap.Stringer("code", code), zap.Error(err),
+		)
+	}
+}
+
+// profileLabels returns runtime/pprof labels for the command document,
+// so CPU and other profiles could be broken down by command, database, and collection.
+//
+// Collection name is taken from the command value (like `find: "coll"`) or, for getMore, from the collection field.
+// Empty database and collection labels are omitted.
+func profileLabels(document *types.Document, db string) pprof.LabelSet {
+	cmd := document.Command()
+	labels := []string{"command", cmd}
+
+	if db != "" {
+		labels = append(labels, "db", db)
+	}
+
+	collection, _ := must.NotFail(document.Get(cmd)).(string)
+	if cmd == "getMore" {
+		collection, _ = common.GetOptionalParam(document, "collection", "")
+	}
+
+	if collection != "" {
+		labels = append(labels, "collection", collection)
+	}
+
+	return pprof.Labels(labels...)
+}
+
 // Describe implements prometheus.Collector.
 func (c *conn) Describe(ch chan<- *prometheus.Desc) {
 	c.m.Describe(ch)
@@ -439,7 +864,7 @@ func (c *conn) logResponse(who string, resHeader *wire.MsgHeader, resBody wire.M
 	}
 
 	c.l.Desugar().Check(level, fmt.Sprintf("%s header: %s", who, resHeader)).Write()
-	c.l.Desugar().Check(level, fmt.Sprintf("%s message:\n%s\n\n\n", who, resBody)).Write()
+	c.l.Desugar().Check(level, fmt.Sprintf("%s message:\n%s\n\n\n", who, wire.Redact(resBody))).Write()
 
 	return level
 }
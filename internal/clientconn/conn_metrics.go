@@ -20,6 +20,12 @@ import "github.com/prometheus/client_golang/prometheus"
 type ConnMetrics struct {
 	requests  *prometheus.CounterVec
 	responses *prometheus.CounterVec
+	durations *prometheus.HistogramVec
+	bytes     *prometheus.CounterVec
+	errors    *prometheus.CounterVec
+
+	compressionSaved *prometheus.CounterVec
+	diffs            *prometheus.CounterVec
 }
 
 // newConnMetrics creates new conn metrics.
@@ -43,6 +49,52 @@ func newConnMetrics() *ConnMetrics {
 			},
 			[]string{"opcode", "command", "result"},
 		),
+		durations: prometheus.NewHistogramVec(
+			prometheus.HistogramOpts{
+				Namespace: namespace,
+				Subsystem: subsystem,
+				Name:      "request_duration_seconds",
+				Help:      "Request handling durations.",
+				Buckets:   prometheus.ExponentialBuckets(0.001, 2, 15), // 1ms - 16s
+			},
+			[]string{"opcode", "command", "result"},
+		),
+		bytes: prometheus.NewCounterVec(
+			prometheus.CounterOpts{
+				Namespace: namespace,
+				Subsystem: subsystem,
+				Name:      "message_bytes_total",
+				Help:      "Total size of wire protocol messages, after compression.",
+			},
+			[]string{"direction"},
+		),
+		compressionSaved: prometheus.NewCounterVec(
+			prometheus.CounterOpts{
+				Namespace: namespace,
+				Subsystem: subsystem,
+				Name:      "compression_saved_bytes_total",
+				Help:      "Total number of bytes saved by wire protocol compression.",
+			},
+			[]string{"compressor", "direction"},
+		),
+		errors: prometheus.NewCounterVec(
+			prometheus.CounterOpts{
+				Namespace: namespace,
+				Subsystem: subsystem,
+				Name:      "errors_total",
+				Help:      "Total number of errors returned by commands, by MongoDB error code and source.",
+			},
+			[]string{"code", "code_name", "source"},
+		),
+		diffs: prometheus.NewCounterVec(
+			prometheus.CounterOpts{
+				Namespace: namespace,
+				Subsystem: subsystem,
+				Name:      "diff_responses_total",
+				Help:      "Total number of compared responses in diff modes.",
+			},
+			[]string{"opcode", "command", "result"},
+		),
 	}
 }
 
@@ -50,12 +102,22 @@ func newConnMetrics() *ConnMetrics {
 func (cm *ConnMetrics) Describe(ch chan<- *prometheus.Desc) {
 	cm.requests.Describe(ch)
 	cm.responses.Describe(ch)
+	cm.durations.Describe(ch)
+	cm.bytes.Describe(ch)
+	cm.errors.Describe(ch)
+	cm.compressionSaved.Describe(ch)
+	cm.diffs.Describe(ch)
 }
 
 // Collect implements prometheus.Collector.
 func (cm *ConnMetrics) Collect(ch chan<- prometheus.Metric) {
 	cm.requests.Collect(ch)
 	cm.responses.Collect(ch)
+	cm.durations.Collect(ch)
+	cm.bytes.Collect(ch)
+	cm.errors.Collect(ch)
+	cm.compressionSaved.Collect(ch)
+	cm.diffs.Collect(ch)
 }
 
 // check interfaces
@@ -0,0 +1,71 @@
+// Copyright 2021 FerretDB Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package clientconn
+
+import (
+	"context"
+	"runtime/pprof"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/FerretDB/FerretDB/internal/types"
+	"github.com/FerretDB/FerretDB/internal/util/must"
+)
+
+func TestProfileLabels(t *testing.T) {
+	t.Parallel()
+
+	for name, tc := range map[string]struct {
+		document *types.Document
+		db       string
+		expected map[string]string
+	}{
+		"Find": {
+			document: must.NotFail(types.NewDocument("find", "values", "filter", must.NotFail(types.NewDocument()))),
+			db:       "test",
+			expected: map[string]string{"command": "find", "db": "test", "collection": "values"},
+		},
+		"GetMore": {
+			document: must.NotFail(types.NewDocument("getMore", int64(42), "collection", "values")),
+			db:       "test",
+			expected: map[string]string{"command": "getMore", "db": "test", "collection": "values"},
+		},
+		"NoCollection": {
+			document: must.NotFail(types.NewDocument("listCollections", int32(1))),
+			db:       "test",
+			expected: map[string]string{"command": "listCollections", "db": "test"},
+		},
+		"NoDB": {
+			document: must.NotFail(types.NewDocument("ping", int32(1))),
+			expected: map[string]string{"command": "ping"},
+		},
+	} {
+		name, tc := name, tc
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			ctx := pprof.WithLabels(context.Background(), profileLabels(tc.document, tc.db))
+
+			actual := map[string]string{}
+			pprof.ForLabels(ctx, func(key, value string) bool {
+				actual[key] = value
+				return true
+			})
+
+			assert.Equal(t, tc.expected, actual)
+		})
+	}
+}
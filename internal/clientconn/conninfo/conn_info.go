@@ -18,7 +18,9 @@ package conninfo
 
 import (
 	"context"
+	"crypto/x509"
 	"net"
+	"sync"
 )
 
 // contextKey is a special type to represent context.WithValue keys a bit more safely.
@@ -27,9 +29,88 @@ type contextKey struct{}
 // connInfoKey stores the key for withConnInfo context value.
 var connInfoKey = contextKey{}
 
+// SASLConversation represents a state of the SASL authentication conversation.
+type SASLConversation interface {
+	// Step takes a client message and returns a message to be sent to the client.
+	Step(challenge string) (string, error)
+
+	// Done returns true if the conversation is completed or has errored.
+	Done() bool
+
+	// Valid returns true if the conversation successfully authenticated the client.
+	Valid() bool
+
+	// Username returns the client-provided username.
+	Username() string
+}
+
 // ConnInfo represents connection info.
+//
+// It is created once per client connection and shared by all requests of that connection.
 type ConnInfo struct {
 	PeerAddr net.Addr
+
+	// Verified client certificate for TLS connections, nil otherwise.
+	// It is set before the first request is handled and never changed after that.
+	PeerCertificate *x509.Certificate
+
+	rw       sync.RWMutex
+	username string
+	db       string
+	roles    []string
+	conv     SASLConversation
+}
+
+// Auth returns authenticated username and authentication database.
+// Empty strings are returned if the connection is not authenticated.
+func (connInfo *ConnInfo) Auth() (username, db string) {
+	connInfo.rw.RLock()
+	defer connInfo.rw.RUnlock()
+
+	return connInfo.username, connInfo.db
+}
+
+// SetAuth stores authenticated username and authentication database.
+// It also resets roles.
+func (connInfo *ConnInfo) SetAuth(username, db string) {
+	connInfo.rw.Lock()
+	defer connInfo.rw.Unlock()
+
+	connInfo.username = username
+	connInfo.db = db
+	connInfo.roles = nil
+}
+
+// Roles returns roles of the authenticated user granted by the external authentication source, if any.
+func (connInfo *ConnInfo) Roles() []string {
+	connInfo.rw.RLock()
+	defer connInfo.rw.RUnlock()
+
+	return connInfo.roles
+}
+
+// SetRoles stores roles of the authenticated user.
+func (connInfo *ConnInfo) SetRoles(roles []string) {
+	connInfo.rw.Lock()
+	defer connInfo.rw.Unlock()
+
+	connInfo.roles = roles
+}
+
+// SASLConversation returns the current SASL conversation, if any.
+func (connInfo *ConnInfo) SASLConversation() SASLConversation {
+	connInfo.rw.RLock()
+	defer connInfo.rw.RUnlock()
+
+	return connInfo.conv
+}
+
+// SetSASLConversation stores the current SASL conversation; nil resets it.
+func (connInfo *ConnInfo) SetSASLConversation(conv SASLConversation) {
+	connInfo.rw.Lock()
+	defer connInfo.rw.Unlock()
+
+	connInfo.conv = conv
 }
 
 // WithConnInfo returns a new context with the given ConnInfo.
@@ -47,7 +47,23 @@ func TestConnInfo(t *testing.T) {
 			}
 			ctx = WithConnInfo(ctx, connInfo)
 			actual := GetConnInfo(ctx)
-			assert.Equal(t, *connInfo, *actual)
+			assert.Equal(t, connInfo, actual)
+
+			username, db := actual.Auth()
+			assert.Empty(t, username)
+			assert.Empty(t, db)
+
+			connInfo.SetAuth("user", "admin")
+			username, db = actual.Auth()
+			assert.Equal(t, "user", username)
+			assert.Equal(t, "admin", db)
+			assert.Empty(t, actual.Roles())
+
+			connInfo.SetRoles([]string{"read"})
+			assert.Equal(t, []string{"read"}, actual.Roles())
+
+			connInfo.SetAuth("other", "admin")
+			assert.Empty(t, actual.Roles())
 		})
 	}
 
@@ -0,0 +1,166 @@
+// Copyright 2021 FerretDB Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package clientconn
+
+import (
+	"bytes"
+	"fmt"
+
+	"github.com/pmezard/go-difflib/difflib"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+
+	"github.com/FerretDB/FerretDB/internal/types"
+	"github.com/FerretDB/FerretDB/internal/util/lazyerrors"
+	"github.com/FerretDB/FerretDB/internal/util/must"
+	"github.com/FerretDB/FerretDB/internal/wire"
+)
+
+// diffIgnoredFields contains top-level response fields that are expected to be different
+// between FerretDB and the proxy (like timestamps, cluster state, and configuration),
+// so they are not compared in diff modes.
+var diffIgnoredFields = []string{
+	"$clusterTime",
+	"$configServerState",
+	"$gleStats",
+	"compression",
+	"connectionId",
+	"electionId",
+	"lastCommittedOpTime",
+	"lastWrite",
+	"localTime",
+	"operationTime",
+	"topologyVersion",
+}
+
+// diffCursorID replaces non-zero cursor IDs in canonical responses,
+// as they are generated independently by FerretDB and the proxy.
+const diffCursorID = "<non-zero>"
+
+// canonicalDocument returns a copy of the response document without fields that are expected to be different.
+func canonicalDocument(doc *types.Document) *types.Document {
+	res := doc.DeepCopy()
+
+	for _, k := range diffIgnoredFields {
+		res.Remove(k)
+	}
+
+	if cursor, _ := res.Get("cursor"); cursor != nil {
+		if cursor, ok := cursor.(*types.Document); ok {
+			if id, _ := cursor.Get("id"); id != nil && id != int64(0) {
+				must.NoError(cursor.Set("id", diffCursorID))
+			}
+		}
+	}
+
+	return res
+}
+
+// canonicalResponse returns a copy of the response body that could be compared with another one.
+//
+// Only the body's documents and significant fields are kept; see canonicalDocument.
+func canonicalResponse(body wire.MsgBody) (wire.MsgBody, error) {
+	switch body := body.(type) {
+	case *wire.OpMsg:
+		doc, err := body.Document()
+		if err != nil {
+			return nil, lazyerrors.Error(err)
+		}
+
+		var res wire.OpMsg
+		if err = res.SetSections(wire.OpMsgSection{Documents: []*types.Document{canonicalDocument(doc)}}); err != nil {
+			return nil, lazyerrors.Error(err)
+		}
+
+		return &res, nil
+
+	case *wire.OpReply:
+		res := *body
+		res.Documents = make([]*types.Document, len(body.Documents))
+		for i, doc := range body.Documents {
+			res.Documents[i] = canonicalDocument(doc)
+		}
+
+		if res.CursorID != 0 {
+			res.CursorID = 1
+		}
+
+		return &res, nil
+
+	default:
+		return body, nil
+	}
+}
+
+// diffResponses compares canonical FerretDB and proxy responses, logs the diff, and updates metrics.
+// Mismatches are logged with at least warning level.
+func (c *conn) diffResponses(
+	opCode wire.OpCode, command string, level zapcore.Level,
+	resHeader *wire.MsgHeader, resBody wire.MsgBody, proxyHeader *wire.MsgHeader, proxyBody wire.MsgBody,
+) error {
+	res, err := canonicalResponse(resBody)
+	if err != nil {
+		return lazyerrors.Error(err)
+	}
+
+	proxy, err := canonicalResponse(proxyBody)
+	if err != nil {
+		return lazyerrors.Error(err)
+	}
+
+	resB, err := res.MarshalBinary()
+	if err != nil {
+		return lazyerrors.Error(err)
+	}
+
+	proxyB, err := proxy.MarshalBinary()
+	if err != nil {
+		return lazyerrors.Error(err)
+	}
+
+	// compare encoded bodies, not their string representations that may lose type information
+	result := "match"
+	if resHeader.OpCode != proxyHeader.OpCode || !bytes.Equal(resB, proxyB) {
+		result = "mismatch"
+	}
+
+	if c.m != nil {
+		c.m.diffs.WithLabelValues(opCode.String(), command, result).Inc()
+	}
+
+	if result == "match" {
+		c.l.Debugf("Responses match.")
+		return nil
+	}
+
+	diffBody, err := difflib.GetUnifiedDiffString(difflib.UnifiedDiff{
+		A:        difflib.SplitLines(fmt.Sprintf("%s\n%s", resHeader.OpCode, wire.Redact(res))),
+		FromFile: "res",
+		B:        difflib.SplitLines(fmt.Sprintf("%s\n%s", proxyHeader.OpCode, wire.Redact(proxy))),
+		ToFile:   "proxy",
+		Context:  1,
+	})
+	if err != nil {
+		return lazyerrors.Error(err)
+	}
+
+	if level < zap.WarnLevel {
+		level = zap.WarnLevel
+	}
+
+	c.l.Desugar().Check(level, fmt.Sprintf("Responses mismatch for %q:\n%s\n\n", command, diffBody)).Write()
+
+	return nil
+}
@@ -0,0 +1,102 @@
+// Copyright 2021 FerretDB Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package clientconn
+
+import (
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+
+	"github.com/FerretDB/FerretDB/internal/types"
+	"github.com/FerretDB/FerretDB/internal/util/must"
+	"github.com/FerretDB/FerretDB/internal/wire"
+)
+
+func TestDiffResponses(t *testing.T) {
+	t.Parallel()
+
+	c := &conn{
+		l: zap.NewNop().Sugar(),
+		m: newConnMetrics(),
+	}
+
+	msg := func(pairs ...any) (*wire.MsgHeader, wire.MsgBody) {
+		var res wire.OpMsg
+		must.NoError(res.SetSections(wire.OpMsgSection{
+			Documents: []*types.Document{must.NotFail(types.NewDocument(pairs...))},
+		}))
+		return &wire.MsgHeader{OpCode: wire.OpCodeMsg}, &res
+	}
+
+	cursor := func(id int64) *types.Document {
+		return must.NotFail(types.NewDocument(
+			"firstBatch", must.NotFail(types.NewArray()),
+			"id", id,
+			"ns", "test.values",
+		))
+	}
+
+	for name, tc := range map[string]struct {
+		res   []any
+		proxy []any
+		match bool
+	}{
+		"IgnoredFields": {
+			res:   []any{"ismaster", true, "localTime", time.Unix(1, 0), "ok", float64(1)},
+			proxy: []any{"ismaster", true, "localTime", time.Unix(2, 0), "connectionId", int32(3), "ok", float64(1)},
+			match: true,
+		},
+		"CursorID": {
+			res:   []any{"cursor", cursor(42), "ok", float64(1)},
+			proxy: []any{"cursor", cursor(43), "ok", float64(1)},
+			match: true,
+		},
+		"ClosedCursor": {
+			res:   []any{"cursor", cursor(42), "ok", float64(1)},
+			proxy: []any{"cursor", cursor(0), "ok", float64(1)},
+		},
+		"Type": {
+			res:   []any{"n", int64(1), "ok", float64(1)},
+			proxy: []any{"n", int32(1), "ok", float64(1)},
+		},
+	} {
+		name, tc := name, tc
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			resHeader, resBody := msg(tc.res...)
+			proxyHeader, proxyBody := msg(tc.proxy...)
+
+			before := resBody.String()
+
+			command := "test" + name
+			err := c.diffResponses(wire.OpCodeMsg, command, zap.DebugLevel, resHeader, resBody, proxyHeader, proxyBody)
+			require.NoError(t, err)
+
+			// the response sent to the client is not modified
+			assert.Equal(t, before, resBody.String())
+
+			result := "mismatch"
+			if tc.match {
+				result = "match"
+			}
+			assert.Equal(t, 1.0, testutil.ToFloat64(c.m.diffs.WithLabelValues("OP_MSG", command, result)))
+		})
+	}
+}
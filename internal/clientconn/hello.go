@@ -0,0 +1,120 @@
+// Copyright 2021 FerretDB Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package clientconn
+
+import (
+	"context"
+
+	"github.com/FerretDB/FerretDB/internal/handlers/common"
+	"github.com/FerretDB/FerretDB/internal/types"
+	"github.com/FerretDB/FerretDB/internal/util/must"
+	"github.com/FerretDB/FerretDB/internal/wire"
+)
+
+// helloCommands contains commands that negotiate compression and could be streamed.
+var helloCommands = map[string]struct{}{
+	"hello":    {},
+	"isMaster": {},
+	"ismaster": {},
+}
+
+// exhaustHello returns true if responses to the given request should be streamed.
+//
+// That's the case for hello and isMaster requests with exhaustAllowed flag sent by drivers' monitoring connections
+// that are actually awaited (see common.AwaitHello): they contain topologyVersion of this process
+// and positive maxAwaitTimeMS.
+// Responses are sent with moreToCome flag, and the request is handled again
+// after each response (see nextExhaustHello) until the connection is closed.
+//
+// Other requests get a single response; otherwise, they would be answered immediately in a tight loop.
+func exhaustHello(reqBody, resBody wire.MsgBody) bool {
+	msg, ok := reqBody.(*wire.OpMsg)
+	if !ok || !msg.FlagBits.FlagSet(wire.OpMsgExhaustAllowed) {
+		return false
+	}
+
+	document, err := msg.Document()
+	if err != nil {
+		return false
+	}
+
+	if _, ok = helloCommands[document.Command()]; !ok {
+		return false
+	}
+
+	if !common.HelloAwaits(document) {
+		return false
+	}
+
+	res, ok := resBody.(*wire.OpMsg)
+	if !ok {
+		return false
+	}
+
+	resDocument, err := res.Document()
+	if err != nil {
+		return false
+	}
+
+	return resDocument.Has("topologyVersion")
+}
+
+// nextExhaustHello returns the request to handle for the next response to the streamed request
+// (see exhaustHello).
+//
+// Like drivers do, it uses topologyVersion of the previous response.
+// The next response is sent in reply to the previous one.
+func nextExhaustHello(reqBody wire.MsgBody, resHeader *wire.MsgHeader, resBody wire.MsgBody) (*wire.MsgHeader, wire.MsgBody) {
+	reqMsg := reqBody.(*wire.OpMsg)
+	document := must.NotFail(reqMsg.Document())
+	resDocument := must.NotFail(resBody.(*wire.OpMsg).Document())
+
+	must.NoError(document.Set("topologyVersion", must.NotFail(resDocument.Get("topologyVersion"))))
+
+	next := &wire.OpMsg{FlagBits: reqMsg.FlagBits}
+	must.NoError(next.SetSections(wire.OpMsgSection{
+		Documents: []*types.Document{document},
+	}))
+
+	header := &wire.MsgHeader{
+		MessageLength: int32(wire.MsgHeaderLen + len(must.NotFail(next.MarshalBinary()))),
+		RequestID:     resHeader.RequestID,
+		OpCode:        wire.OpCodeMsg,
+	}
+
+	return header, next
+}
+
+// errShutdownInProgress returns an error for hello and isMaster requests during shutdown.
+//
+// Like MongoDB in quiesce mode, it makes drivers mark the server as unknown and stop using it.
+func errShutdownInProgress() error {
+	return common.NewErrorMsg(common.ErrShutdownInProgress, "The server is in quiesce mode and will shut down")
+}
+
+// withDrain returns a context that is also canceled when the listener starts draining connections.
+func (c *conn) withDrain(ctx context.Context) (context.Context, context.CancelFunc) {
+	ctx, cancel := context.WithCancel(ctx)
+
+	go func() {
+		select {
+		case <-c.drain:
+			cancel()
+		case <-ctx.Done():
+		}
+	}()
+
+	return ctx, cancel
+}
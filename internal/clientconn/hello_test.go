@@ -0,0 +1,242 @@
+// Copyright 2021 FerretDB Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package clientconn
+
+import (
+	"bufio"
+	"context"
+	"net"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap/zaptest"
+
+	"github.com/FerretDB/FerretDB/internal/handlers"
+	"github.com/FerretDB/FerretDB/internal/handlers/common"
+	"github.com/FerretDB/FerretDB/internal/handlers/dummy"
+	"github.com/FerretDB/FerretDB/internal/types"
+	"github.com/FerretDB/FerretDB/internal/util/must"
+	"github.com/FerretDB/FerretDB/internal/wire"
+)
+
+func TestExhaustHello(t *testing.T) {
+	t.Parallel()
+
+	msg := func(flags wire.OpMsgFlagBit, pairs ...any) wire.MsgBody {
+		res := wire.OpMsg{FlagBits: wire.OpMsgFlags(flags)}
+		must.NoError(res.SetSections(wire.OpMsgSection{
+			Documents: []*types.Document{must.NotFail(types.NewDocument(pairs...))},
+		}))
+		return &res
+	}
+
+	tv := common.TopologyVersion()
+	res := msg(0, "isWritablePrimary", true, "topologyVersion", tv, "ok", float64(1))
+
+	assert.True(t, exhaustHello(msg(
+		wire.OpMsgExhaustAllowed, "hello", int32(1), "topologyVersion", tv, "maxAwaitTimeMS", int64(10000), "$db", "admin",
+	), res))
+	assert.True(t, exhaustHello(msg(
+		wire.OpMsgExhaustAllowed, "isMaster", int32(1), "topologyVersion", tv, "maxAwaitTimeMS", int64(10000), "$db", "admin",
+	), res))
+
+	assert.False(t, exhaustHello(msg(
+		0, "hello", int32(1), "topologyVersion", tv, "maxAwaitTimeMS", int64(10000), "$db", "admin",
+	), res), "no exhaustAllowed flag")
+	assert.False(t, exhaustHello(msg(
+		wire.OpMsgExhaustAllowed, "hello", int32(1), "$db", "admin",
+	), res), "not awaitable")
+	assert.False(t, exhaustHello(msg(
+		wire.OpMsgExhaustAllowed, "hello", int32(1), "topologyVersion", tv, "maxAwaitTimeMS", int64(0), "$db", "admin",
+	), res), "zero maxAwaitTimeMS")
+	assert.False(t, exhaustHello(msg(
+		wire.OpMsgExhaustAllowed, "hello", int32(1),
+		"topologyVersion", must.NotFail(types.NewDocument("processId", types.NewObjectID(), "counter", int64(0))),
+		"maxAwaitTimeMS", int64(10000), "$db", "admin",
+	), res), "other process")
+	assert.False(t, exhaustHello(msg(
+		wire.OpMsgExhaustAllowed, "ping", int32(1), "topologyVersion", tv, "maxAwaitTimeMS", int64(10000), "$db", "admin",
+	), res), "not hello")
+	assert.False(t, exhaustHello(msg(
+		wire.OpMsgExhaustAllowed, "hello", int32(1), "topologyVersion", tv, "maxAwaitTimeMS", int64(10000), "$db", "admin",
+	), msg(0, "ok", float64(1))), "no topologyVersion in response")
+	assert.False(t, exhaustHello(&wire.OpQuery{
+		Query: must.NotFail(types.NewDocument("isMaster", int32(1), "topologyVersion", tv, "maxAwaitTimeMS", int64(10000))),
+	}, res), "OP_QUERY")
+}
+
+func TestNextExhaustHello(t *testing.T) {
+	t.Parallel()
+
+	req := &wire.OpMsg{FlagBits: wire.OpMsgFlags(wire.OpMsgExhaustAllowed)}
+	must.NoError(req.SetSections(wire.OpMsgSection{
+		Documents: []*types.Document{must.NotFail(types.NewDocument(
+			"hello", int32(1),
+			"topologyVersion", must.NotFail(types.NewDocument("processId", types.NewObjectID(), "counter", int64(0))),
+			"maxAwaitTimeMS", int64(10000),
+			"$db", "admin",
+		))},
+	}))
+
+	tv := common.TopologyVersion()
+
+	var res wire.OpMsg
+	must.NoError(res.SetSections(wire.OpMsgSection{
+		Documents: []*types.Document{must.NotFail(types.NewDocument("topologyVersion", tv, "ok", float64(1)))},
+	}))
+
+	header, body := nextExhaustHello(req, &wire.MsgHeader{RequestID: 42}, &res)
+	assert.Equal(t, int32(42), header.RequestID)
+	assert.Equal(t, wire.OpCodeMsg, header.OpCode)
+	assert.Equal(t, int32(wire.MsgHeaderLen+len(must.NotFail(body.MarshalBinary()))), header.MessageLength)
+
+	next := body.(*wire.OpMsg)
+	assert.True(t, next.FlagBits.FlagSet(wire.OpMsgExhaustAllowed))
+
+	document := must.NotFail(next.Document())
+	assert.Equal(t, tv, must.NotFail(document.Get("topologyVersion")))
+	assert.True(t, exhaustHello(next, &res))
+}
+
+// topologyHelloHandler is a handler with awaitable hello command (see common.AwaitHello).
+type topologyHelloHandler struct {
+	handlers.Interface
+}
+
+// MsgHello implements HandlerInterface.
+func (h *topologyHelloHandler) MsgHello(ctx context.Context, msg *wire.OpMsg) (*wire.OpMsg, error) {
+	document, err := msg.Document()
+	if err != nil {
+		return nil, err
+	}
+
+	if err = common.AwaitHello(ctx, document); err != nil {
+		return nil, err
+	}
+
+	var reply wire.OpMsg
+	must.NoError(reply.SetSections(wire.OpMsgSection{
+		Documents: []*types.Document{must.NotFail(types.NewDocument(
+			"isWritablePrimary", true,
+			"topologyVersion", common.TopologyVersion(),
+			"ok", float64(1),
+		))},
+	}))
+
+	return &reply, nil
+}
+
+func TestExhaustHelloStream(t *testing.T) {
+	t.Parallel()
+
+	h, err := dummy.New()
+	require.NoError(t, err)
+
+	pl := NewPipeListener()
+
+	l := NewListener(&NewListenerOpts{
+		Listeners: []ListenerConfig{{Listener: pl}},
+		Mode:      NormalMode,
+		Handler:   &topologyHelloHandler{Interface: h},
+		Logger:    zaptest.NewLogger(t),
+	})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		_ = l.Run(ctx)
+	}()
+
+	// sends hello request with exhaustAllowed flag and returns a reader for responses
+	hello := func(t *testing.T, topologyVersion *types.Document, maxAwaitTimeMS int64) (net.Conn, *bufio.Reader) {
+		t.Helper()
+
+		conn, err := pl.Dial(ctx)
+		require.NoError(t, err)
+		t.Cleanup(func() { conn.Close() })
+
+		msg := wire.OpMsg{FlagBits: wire.OpMsgFlags(wire.OpMsgExhaustAllowed)}
+		require.NoError(t, msg.SetSections(wire.OpMsgSection{
+			Documents: []*types.Document{must.NotFail(types.NewDocument(
+				"hello", int32(1),
+				"topologyVersion", topologyVersion,
+				"maxAwaitTimeMS", maxAwaitTimeMS,
+				"$db", "admin",
+			))},
+		}))
+
+		bufw := bufio.NewWriter(conn)
+		require.NoError(t, wire.WriteMessage(bufw, &wire.MsgHeader{
+			MessageLength: int32(wire.MsgHeaderLen + len(must.NotFail(msg.MarshalBinary()))),
+			RequestID:     1,
+			OpCode:        wire.OpCodeMsg,
+		}, &msg))
+		require.NoError(t, bufw.Flush())
+
+		return conn, bufio.NewReader(conn)
+	}
+
+	t.Run("Stream", func(t *testing.T) {
+		conn, bufr := hello(t, common.TopologyVersion(), 10)
+		require.NoError(t, conn.SetDeadline(time.Now().Add(5*time.Second)))
+
+		responseTo := int32(1)
+		for i := 0; i < 3; i++ {
+			header, body, err := wire.ReadMessage(bufr)
+			require.NoError(t, err)
+			assert.Equal(t, responseTo, header.ResponseTo)
+			assert.True(t, body.(*wire.OpMsg).FlagBits.FlagSet(wire.OpMsgMoreToCome))
+
+			responseTo = header.RequestID
+		}
+	})
+
+	for name, tc := range map[string]struct {
+		topologyVersion *types.Document
+		maxAwaitTimeMS  int64
+	}{
+		"OtherProcess": {
+			topologyVersion: must.NotFail(types.NewDocument("processId", types.NewObjectID(), "counter", int64(0))),
+			maxAwaitTimeMS:  10000,
+		},
+		"ZeroMaxAwaitTime": {
+			topologyVersion: common.TopologyVersion(),
+			maxAwaitTimeMS:  0,
+		},
+	} {
+		tc := tc
+		t.Run(name, func(t *testing.T) {
+			conn, bufr := hello(t, tc.topologyVersion, tc.maxAwaitTimeMS)
+			require.NoError(t, conn.SetDeadline(time.Now().Add(5*time.Second)))
+
+			header, body, err := wire.ReadMessage(bufr)
+			require.NoError(t, err)
+			assert.Equal(t, int32(1), header.ResponseTo)
+			assert.False(t, body.(*wire.OpMsg).FlagBits.FlagSet(wire.OpMsgMoreToCome))
+
+			// there is no second response
+			require.NoError(t, conn.SetReadDeadline(time.Now().Add(100*time.Millisecond)))
+			_, _, err = wire.ReadMessage(bufr)
+			assert.ErrorIs(t, err, os.ErrDeadlineExceeded)
+		})
+	}
+
+	cancel()
+	<-done
+}
@@ -0,0 +1,117 @@
+// Copyright 2021 FerretDB Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package clientconn
+
+import (
+	"fmt"
+	"net"
+	"net/netip"
+	"strings"
+)
+
+// IPFilter decides whether client connections are accepted based on their source IP addresses.
+//
+// Deny rules take precedence over allow rules.
+// If there are no allow rules, all addresses that are not denied are allowed.
+type IPFilter struct {
+	allow []netip.Prefix
+	deny  []netip.Prefix
+}
+
+// NewIPFilter returns a new filter for given allow and deny rules.
+// Each rule is either CIDR ("10.0.0.0/8", "fd00::/8") or a single IP address.
+func NewIPFilter(allow, deny []string) (*IPFilter, error) {
+	var f IPFilter
+	var err error
+
+	if f.allow, err = parsePrefixes(allow); err != nil {
+		return nil, fmt.Errorf("clientconn.NewIPFilter: %w", err)
+	}
+
+	if f.deny, err = parsePrefixes(deny); err != nil {
+		return nil, fmt.Errorf("clientconn.NewIPFilter: %w", err)
+	}
+
+	return &f, nil
+}
+
+// Allowed returns true if connections from the given address are allowed.
+//
+// Only TCP addresses are checked; other addresses (like Unix sockets) are always allowed.
+func (f *IPFilter) Allowed(addr net.Addr) bool {
+	tcpAddr, ok := addr.(*net.TCPAddr)
+	if !ok {
+		return true
+	}
+
+	ip, ok := netip.AddrFromSlice(tcpAddr.IP)
+	if !ok {
+		return false
+	}
+
+	// IPv4-mapped IPv6 addresses should match IPv4 rules
+	ip = ip.Unmap()
+
+	for _, p := range f.deny {
+		if p.Contains(ip) {
+			return false
+		}
+	}
+
+	if len(f.allow) == 0 {
+		return true
+	}
+
+	for _, p := range f.allow {
+		if p.Contains(ip) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// parsePrefixes parses CIDRs and single IP addresses; empty strings are skipped.
+func parsePrefixes(rules []string) ([]netip.Prefix, error) {
+	res := make([]netip.Prefix, 0, len(rules))
+
+	for _, rule := range rules {
+		rule = strings.TrimSpace(rule)
+		if rule == "" {
+			continue
+		}
+
+		if !strings.Contains(rule, "/") {
+			ip, err := netip.ParseAddr(rule)
+			if err != nil {
+				return nil, err
+			}
+
+			ip = ip.Unmap()
+			res = append(res, netip.PrefixFrom(ip, ip.BitLen()))
+
+			continue
+		}
+
+		p, err := netip.ParsePrefix(rule)
+		if err != nil {
+			return nil, err
+		}
+
+		res = append(res, p.Masked())
+	}
+
+	return res, nil
+}
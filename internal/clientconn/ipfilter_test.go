@@ -0,0 +1,85 @@
+// Copyright 2021 FerretDB Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package clientconn
+
+import (
+	"net"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestIPFilter(t *testing.T) {
+	t.Parallel()
+
+	_, err := NewIPFilter([]string{"10.0.0.0/33"}, nil)
+	assert.Error(t, err)
+
+	_, err = NewIPFilter(nil, []string{"localhost"})
+	assert.Error(t, err)
+
+	for name, tc := range map[string]struct {
+		allow    []string
+		deny     []string
+		allowed  []string
+		rejected []string
+	}{
+		"Empty": {
+			allowed: []string{"127.0.0.1", "::1", "192.0.2.1"},
+		},
+		"Allow": {
+			allow:    []string{"10.0.0.0/8", "192.0.2.1", " fd00::/8", ""},
+			allowed:  []string{"10.1.2.3", "::ffff:10.1.2.3", "192.0.2.1", "fd12::1"},
+			rejected: []string{"11.0.0.1", "192.0.2.2", "::1"},
+		},
+		"Deny": {
+			deny:     []string{"10.1.0.0/16", "::1"},
+			allowed:  []string{"10.2.0.1", "127.0.0.1"},
+			rejected: []string{"10.1.2.3", "::ffff:10.1.2.3", "::1"},
+		},
+		"AllowDeny": {
+			allow:    []string{"10.0.0.0/8"},
+			deny:     []string{"10.1.2.3"},
+			allowed:  []string{"10.1.2.4"},
+			rejected: []string{"10.1.2.3", "127.0.0.1"},
+		},
+		"NotMasked": {
+			allow:    []string{"10.1.2.3/16"},
+			allowed:  []string{"10.1.200.1"},
+			rejected: []string{"10.2.0.1"},
+		},
+	} {
+		name, tc := name, tc
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			f, err := NewIPFilter(tc.allow, tc.deny)
+			require.NoError(t, err)
+
+			for _, ip := range tc.allowed {
+				assert.True(t, f.Allowed(&net.TCPAddr{IP: net.ParseIP(ip), Port: 12345}), ip)
+			}
+
+			for _, ip := range tc.rejected {
+				assert.False(t, f.Allowed(&net.TCPAddr{IP: net.ParseIP(ip), Port: 12345}), ip)
+			}
+		})
+	}
+
+	f, err := NewIPFilter([]string{"10.0.0.0/8"}, nil)
+	require.NoError(t, err)
+	assert.True(t, f.Allowed(&net.UnixAddr{Name: "/tmp/ferretdb.sock", Net: "unix"}))
+}
@@ -0,0 +1,305 @@
+// Copyright 2021 FerretDB Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package clientconn
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/FerretDB/FerretDB/internal/handlers/common"
+	"github.com/FerretDB/FerretDB/internal/types"
+	"github.com/FerretDB/FerretDB/internal/util/lazyerrors"
+	"github.com/FerretDB/FerretDB/internal/util/must"
+	"github.com/FerretDB/FerretDB/internal/wire"
+)
+
+// Legacy opcodes (OP_QUERY, OP_GET_MORE, OP_KILL_CURSORS) are handled by translating them to OP_MSG commands.
+//
+// Cursors are shared with getMore and killCursors commands.
+
+// legacyModifiers maps OP_QUERY query modifiers to find command fields.
+//
+// Other modifiers (like $explain, $snapshot, $min, and $max) are rejected.
+// $min and $max are not mapped because find ignores min and max bounds.
+var legacyModifiers = map[string]string{
+	"$orderby":        "sort",
+	"$comment":        "comment",
+	"$hint":           "hint",
+	"$maxTimeMS":      "maxTimeMS",
+	"$returnKey":      "returnKey",
+	"$showDiskLoc":    "showRecordId",
+	"$readPreference": "$readPreference",
+}
+
+// legacyCommandModifiers maps OP_QUERY query modifiers to fields of other commands.
+var legacyCommandModifiers = map[string]string{
+	"$readPreference": "$readPreference",
+}
+
+// legacyQueryMsg translates OP_QUERY request to OP_MSG command.
+//
+// Queries of `<db>.$cmd` collections are translated to commands,
+// queries of other collections are translated to find commands.
+func legacyQueryMsg(query *wire.OpQuery) (*wire.OpMsg, error) {
+	db, collection, err := legacyNamespace(query.FullCollectionName)
+	if err != nil {
+		return nil, err
+	}
+
+	// query could be wrapped with modifiers: {$query: {...}, $orderby: {...}, $readPreference: {...}}
+	q := query.Query
+	var modifiers *types.Document
+	if v, _ := q.Get("$query"); v != nil {
+		filter, ok := v.(*types.Document)
+		if !ok {
+			return nil, common.NewErrorMsg(common.ErrBadValue, "$query must be an object")
+		}
+
+		modifiers, q = q, filter
+	}
+
+	var document *types.Document
+
+	if collection == "$cmd" {
+		if q.Command() == "" {
+			return nil, common.NewErrorMsg(common.ErrFailedToParse, "Empty command object")
+		}
+
+		document = must.NotFail(types.NewDocument())
+		m := q.Map()
+		for _, k := range q.Keys() {
+			must.NoError(document.Set(k, m[k]))
+		}
+
+		if err = legacySetModifiers(document, modifiers, legacyCommandModifiers); err != nil {
+			return nil, err
+		}
+	} else {
+		document = must.NotFail(types.NewDocument(
+			"find", collection,
+			"filter", q,
+		))
+
+		if err = legacySetModifiers(document, modifiers, legacyModifiers); err != nil {
+			return nil, err
+		}
+
+		if query.ReturnFieldsSelector != nil {
+			must.NoError(document.Set("projection", query.ReturnFieldsSelector))
+		}
+
+		if query.NumberToSkip > 0 {
+			must.NoError(document.Set("skip", int64(query.NumberToSkip)))
+		}
+
+		// negative value or 1 means a single batch of that size; other positive values are batch sizes
+		switch n := query.NumberToReturn; {
+		case n < 0:
+			must.NoError(document.Set("limit", -int64(n)))
+			must.NoError(document.Set("singleBatch", true))
+		case n == 1:
+			must.NoError(document.Set("limit", int64(1)))
+			must.NoError(document.Set("singleBatch", true))
+		case n > 1:
+			must.NoError(document.Set("batchSize", int64(n)))
+		}
+	}
+
+	must.NoError(document.Set("$db", db))
+
+	return legacyMsg(document)
+}
+
+// legacySetModifiers sets command fields for the given OP_QUERY query modifiers (if any)
+// using the given mapping.
+//
+// It returns an error for modifiers that are not in the mapping,
+// so they are not silently ignored.
+func legacySetModifiers(document, modifiers *types.Document, mapping map[string]string) error {
+	if modifiers == nil {
+		return nil
+	}
+
+	m := modifiers.Map()
+	for _, k := range modifiers.Keys() {
+		if k == "$query" {
+			continue
+		}
+
+		field, ok := mapping[k]
+		if !ok {
+			return common.NewErrorMsg(common.ErrNotImplemented, fmt.Sprintf("Query modifier %s is not supported", k))
+		}
+
+		must.NoError(document.Set(field, m[k]))
+	}
+
+	return nil
+}
+
+// legacyGetMoreMsg translates OP_GET_MORE request to OP_MSG getMore command.
+func legacyGetMoreMsg(getMore *wire.OpGetMore) (*wire.OpMsg, error) {
+	db, collection, err := legacyNamespace(getMore.FullCollectionName)
+	if err != nil {
+		return nil, err
+	}
+
+	document := must.NotFail(types.NewDocument(
+		"getMore", getMore.CursorID,
+		"collection", collection,
+	))
+
+	if getMore.NumberToReturn > 0 {
+		must.NoError(document.Set("batchSize", int64(getMore.NumberToReturn)))
+	}
+
+	must.NoError(document.Set("$db", db))
+
+	return legacyMsg(document)
+}
+
+// legacyNamespace splits full collection name into database and collection names.
+func legacyNamespace(ns string) (db, collection string, err error) {
+	var ok bool
+	if db, collection, ok = strings.Cut(ns, "."); !ok || db == "" || collection == "" {
+		msg := fmt.Sprintf("Invalid namespace specified '%s'", ns)
+		return "", "", common.NewErrorMsg(common.ErrInvalidNamespace, msg)
+	}
+
+	return db, collection, nil
+}
+
+// legacyMsg returns OP_MSG request with the given command document.
+func legacyMsg(document *types.Document) (*wire.OpMsg, error) {
+	var msg wire.OpMsg
+	if err := msg.SetSections(wire.OpMsgSection{Documents: []*types.Document{document}}); err != nil {
+		return nil, lazyerrors.Error(err)
+	}
+
+	return &msg, nil
+}
+
+// legacyReply translates OP_MSG command response or error to OP_REPLY response to the given OP_QUERY request.
+//
+// Non-recoverable errors are returned as is.
+func legacyReply(query *wire.OpQuery, resMsg *wire.OpMsg, err error) (*wire.OpReply, error) {
+	cmd := strings.HasSuffix(query.FullCollectionName, ".$cmd")
+
+	if err != nil {
+		protoErr, recoverable := common.ProtocolError(err)
+		if !recoverable {
+			return nil, err
+		}
+
+		if cmd {
+			return &wire.OpReply{
+				NumberReturned: 1,
+				Documents:      []*types.Document{protoErr.Document()},
+			}, nil
+		}
+
+		return legacyErrorReply(protoErr), nil
+	}
+
+	document, err := resMsg.Document()
+	if err != nil {
+		return nil, lazyerrors.Error(err)
+	}
+
+	if cmd {
+		return &wire.OpReply{
+			NumberReturned: 1,
+			Documents:      []*types.Document{document},
+		}, nil
+	}
+
+	return legacyCursorReply(document, "firstBatch")
+}
+
+// legacyGetMoreReply translates OP_MSG getMore command response or error to OP_REPLY response.
+//
+// Non-recoverable errors are returned as is.
+func legacyGetMoreReply(resMsg *wire.OpMsg, err error) (*wire.OpReply, error) {
+	if err != nil {
+		protoErr, recoverable := common.ProtocolError(err)
+		if !recoverable {
+			return nil, err
+		}
+
+		if protoErr.Code() == common.ErrCursorNotFound {
+			return &wire.OpReply{
+				ResponseFlags: wire.OpReplyFlags(wire.OpReplyCursorNotFound),
+			}, nil
+		}
+
+		return legacyErrorReply(protoErr), nil
+	}
+
+	document, err := resMsg.Document()
+	if err != nil {
+		return nil, lazyerrors.Error(err)
+	}
+
+	return legacyCursorReply(document, "nextBatch")
+}
+
+// legacyErrorReply returns OP_REPLY query failure response for the given error.
+func legacyErrorReply(protoErr common.ProtoErr) *wire.OpReply {
+	errmsg, _ := protoErr.Document().Get("errmsg")
+	if errmsg == nil {
+		errmsg = protoErr.Error()
+	}
+
+	return &wire.OpReply{
+		ResponseFlags:  wire.OpReplyFlags(wire.OpReplyQueryFailure),
+		NumberReturned: 1,
+		Documents: []*types.Document{must.NotFail(types.NewDocument(
+			"$err", errmsg,
+			"code", int32(protoErr.Code()),
+		))},
+	}
+}
+
+// legacyCursorReply returns OP_REPLY response with documents from the given batch field of cursor command response.
+func legacyCursorReply(document *types.Document, batchKey string) (*wire.OpReply, error) {
+	cursor, err := common.GetRequiredParam[*types.Document](document, "cursor")
+	if err != nil {
+		return nil, lazyerrors.Error(err)
+	}
+
+	batch, err := common.GetRequiredParam[*types.Array](cursor, batchKey)
+	if err != nil {
+		return nil, lazyerrors.Error(err)
+	}
+
+	id, err := common.GetRequiredParam[int64](cursor, "id")
+	if err != nil {
+		return nil, lazyerrors.Error(err)
+	}
+
+	reply := &wire.OpReply{
+		CursorID:       id,
+		NumberReturned: int32(batch.Len()),
+		Documents:      make([]*types.Document, batch.Len()),
+	}
+
+	for i := 0; i < batch.Len(); i++ {
+		if reply.Documents[i], err = common.AssertType[*types.Document](must.NotFail(batch.Get(i))); err != nil {
+			return nil, lazyerrors.Error(err)
+		}
+	}
+
+	return reply, nil
+}
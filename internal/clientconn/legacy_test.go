@@ -0,0 +1,271 @@
+// Copyright 2021 FerretDB Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package clientconn
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/FerretDB/FerretDB/internal/handlers/common"
+	"github.com/FerretDB/FerretDB/internal/types"
+	"github.com/FerretDB/FerretDB/internal/util/must"
+	"github.com/FerretDB/FerretDB/internal/wire"
+)
+
+func TestLegacyQueryMsg(t *testing.T) {
+	t.Parallel()
+
+	for name, tc := range map[string]struct {
+		query    *wire.OpQuery
+		expected *types.Document
+		err      common.ErrorCode
+	}{
+		"Command": {
+			query: &wire.OpQuery{
+				FullCollectionName: "admin.$cmd",
+				NumberToReturn:     -1,
+				Query:              must.NotFail(types.NewDocument("isMaster", int32(1))),
+			},
+			expected: must.NotFail(types.NewDocument("isMaster", int32(1), "$db", "admin")),
+		},
+		"CommandWrapped": {
+			query: &wire.OpQuery{
+				FullCollectionName: "test.$cmd",
+				NumberToReturn:     -1,
+				Query: must.NotFail(types.NewDocument(
+					"$query", must.NotFail(types.NewDocument("count", "values")),
+					"$readPreference", must.NotFail(types.NewDocument("mode", "secondaryPreferred")),
+				)),
+			},
+			expected: must.NotFail(types.NewDocument(
+				"count", "values",
+				"$readPreference", must.NotFail(types.NewDocument("mode", "secondaryPreferred")),
+				"$db", "test",
+			)),
+		},
+		"CommandUnsupportedModifier": {
+			query: &wire.OpQuery{
+				FullCollectionName: "test.$cmd",
+				NumberToReturn:     -1,
+				Query: must.NotFail(types.NewDocument(
+					"$query", must.NotFail(types.NewDocument("count", "values")),
+					"$orderby", must.NotFail(types.NewDocument("v", int32(1))),
+				)),
+			},
+			err: common.ErrNotImplemented,
+		},
+		"Find": {
+			query: &wire.OpQuery{
+				FullCollectionName:   "test.values",
+				NumberToSkip:         2,
+				NumberToReturn:       -5,
+				Query:                must.NotFail(types.NewDocument("v", int32(42))),
+				ReturnFieldsSelector: must.NotFail(types.NewDocument("v", true)),
+			},
+			expected: must.NotFail(types.NewDocument(
+				"find", "values",
+				"filter", must.NotFail(types.NewDocument("v", int32(42))),
+				"projection", must.NotFail(types.NewDocument("v", true)),
+				"skip", int64(2),
+				"limit", int64(5),
+				"singleBatch", true,
+				"$db", "test",
+			)),
+		},
+		"FindModifiers": {
+			query: &wire.OpQuery{
+				FullCollectionName: "test.values",
+				NumberToReturn:     100,
+				Query: must.NotFail(types.NewDocument(
+					"$query", must.NotFail(types.NewDocument()),
+					"$orderby", must.NotFail(types.NewDocument("v", int32(-1))),
+					"$comment", "test",
+					"$readPreference", must.NotFail(types.NewDocument("mode", "secondaryPreferred")),
+				)),
+			},
+			expected: must.NotFail(types.NewDocument(
+				"find", "values",
+				"filter", must.NotFail(types.NewDocument()),
+				"sort", must.NotFail(types.NewDocument("v", int32(-1))),
+				"comment", "test",
+				"$readPreference", must.NotFail(types.NewDocument("mode", "secondaryPreferred")),
+				"batchSize", int64(100),
+				"$db", "test",
+			)),
+		},
+		"FindExplain": {
+			query: &wire.OpQuery{
+				FullCollectionName: "test.values",
+				Query: must.NotFail(types.NewDocument(
+					"$query", must.NotFail(types.NewDocument()),
+					"$explain", true,
+				)),
+			},
+			err: common.ErrNotImplemented,
+		},
+		"FindSnapshot": {
+			query: &wire.OpQuery{
+				FullCollectionName: "test.values",
+				Query: must.NotFail(types.NewDocument(
+					"$query", must.NotFail(types.NewDocument()),
+					"$snapshot", true,
+				)),
+			},
+			err: common.ErrNotImplemented,
+		},
+		"FindMin": {
+			query: &wire.OpQuery{
+				FullCollectionName: "test.values",
+				Query: must.NotFail(types.NewDocument(
+					"$query", must.NotFail(types.NewDocument()),
+					"$min", must.NotFail(types.NewDocument("v", int32(1))),
+				)),
+			},
+			err: common.ErrNotImplemented,
+		},
+		"InvalidNamespace": {
+			query: &wire.OpQuery{
+				FullCollectionName: "test",
+				Query:              must.NotFail(types.NewDocument()),
+			},
+			err: common.ErrInvalidNamespace,
+		},
+		"EmptyCommand": {
+			query: &wire.OpQuery{
+				FullCollectionName: "test.$cmd",
+				Query:              must.NotFail(types.NewDocument()),
+			},
+			err: common.ErrFailedToParse,
+		},
+	} {
+		name, tc := name, tc
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			msg, err := legacyQueryMsg(tc.query)
+			if tc.err != 0 {
+				var e *common.Error
+				require.ErrorAs(t, err, &e)
+				assert.Equal(t, tc.err, e.Code())
+				return
+			}
+
+			require.NoError(t, err)
+			assert.Equal(t, tc.expected, must.NotFail(msg.Document()))
+		})
+	}
+}
+
+func TestLegacyReply(t *testing.T) {
+	t.Parallel()
+
+	msg := func(doc *types.Document) *wire.OpMsg {
+		var res wire.OpMsg
+		must.NoError(res.SetSections(wire.OpMsgSection{Documents: []*types.Document{doc}}))
+		return &res
+	}
+
+	cmd := &wire.OpQuery{FullCollectionName: "admin.$cmd"}
+	find := &wire.OpQuery{FullCollectionName: "test.values"}
+
+	reply, err := legacyReply(cmd, msg(must.NotFail(types.NewDocument("ok", float64(1)))), nil)
+	require.NoError(t, err)
+	assert.Equal(t, &wire.OpReply{
+		NumberReturned: 1,
+		Documents:      []*types.Document{must.NotFail(types.NewDocument("ok", float64(1)))},
+	}, reply)
+
+	doc := must.NotFail(types.NewDocument("v", int32(42)))
+	reply, err = legacyReply(find, msg(must.NotFail(types.NewDocument(
+		"cursor", must.NotFail(types.NewDocument(
+			"firstBatch", must.NotFail(types.NewArray(doc)),
+			"id", int64(0),
+			"ns", "test.values",
+		)),
+		"ok", float64(1),
+	))), nil)
+	require.NoError(t, err)
+	assert.Equal(t, &wire.OpReply{
+		NumberReturned: 1,
+		Documents:      []*types.Document{doc},
+	}, reply)
+
+	protoErr := common.NewErrorMsg(common.ErrBadValue, "bad")
+
+	reply, err = legacyReply(cmd, nil, protoErr)
+	require.NoError(t, err)
+	assert.Equal(t, &wire.OpReply{
+		NumberReturned: 1,
+		Documents: []*types.Document{must.NotFail(types.NewDocument(
+			"ok", float64(0), "errmsg", "bad", "code", int32(2), "codeName", "BadValue",
+		))},
+	}, reply)
+
+	reply, err = legacyReply(find, nil, protoErr)
+	require.NoError(t, err)
+	assert.Equal(t, &wire.OpReply{
+		ResponseFlags:  wire.OpReplyFlags(wire.OpReplyQueryFailure),
+		NumberReturned: 1,
+		Documents:      []*types.Document{must.NotFail(types.NewDocument("$err", "bad", "code", int32(2)))},
+	}, reply)
+
+	_, err = legacyReply(find, nil, assert.AnError)
+	assert.Equal(t, assert.AnError, err)
+}
+
+func TestLegacyGetMore(t *testing.T) {
+	t.Parallel()
+
+	msg, err := legacyGetMoreMsg(&wire.OpGetMore{
+		FullCollectionName: "test.values",
+		NumberToReturn:     10,
+		CursorID:           42,
+	})
+	require.NoError(t, err)
+	expected := must.NotFail(types.NewDocument(
+		"getMore", int64(42),
+		"collection", "values",
+		"batchSize", int64(10),
+		"$db", "test",
+	))
+	assert.Equal(t, expected, must.NotFail(msg.Document()))
+
+	doc := must.NotFail(types.NewDocument("v", int32(42)))
+	var res wire.OpMsg
+	must.NoError(res.SetSections(wire.OpMsgSection{Documents: []*types.Document{must.NotFail(types.NewDocument(
+		"cursor", must.NotFail(types.NewDocument(
+			"nextBatch", must.NotFail(types.NewArray(doc)),
+			"id", int64(42),
+			"ns", "test.values",
+		)),
+		"ok", float64(1),
+	))}}))
+
+	reply, err := legacyGetMoreReply(&res, nil)
+	require.NoError(t, err)
+	assert.Equal(t, &wire.OpReply{
+		CursorID:       42,
+		NumberReturned: 1,
+		Documents:      []*types.Document{doc},
+	}, reply)
+
+	reply, err = legacyGetMoreReply(nil, common.NewErrorMsg(common.ErrCursorNotFound, "cursor id 42 not found"))
+	require.NoError(t, err)
+	assert.Equal(t, &wire.OpReply{
+		ResponseFlags: wire.OpReplyFlags(wire.OpReplyCursorNotFound),
+	}, reply)
+}
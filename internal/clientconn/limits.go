@@ -0,0 +1,324 @@
+// Copyright 2021 FerretDB Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package clientconn
+
+import (
+	"fmt"
+	"math"
+	"net"
+	"sync"
+	"time"
+
+	"github.com/FerretDB/FerretDB/internal/handlers/common"
+)
+
+// LimitsOpts represents per-client operation limits; zero values mean no limit.
+//
+// Each connection handles one operation at a time,
+// so concurrent operations are limited only across all connections from the same IP address.
+type LimitsOpts struct {
+	// Operations per second for a single connection.
+	ConnOpsPerSecond float64
+
+	// Operations per second for all connections from the same IP address.
+	IPOpsPerSecond float64
+
+	// Concurrent operations for all connections from the same IP address.
+	IPMaxInFlight int
+
+	// Open connections in total and from the same IP address.
+	// Connections over those limits get an error in response to the first request and are closed.
+	MaxConns   int
+	IPMaxConns int
+}
+
+// limitsExempt contains commands that are never limited,
+// so drivers' server monitoring keeps working for throttled clients.
+var limitsExempt = map[string]struct{}{
+	"hello":    {},
+	"isMaster": {},
+	"ismaster": {},
+}
+
+// rejectedConnTimeout is the time given to clients of connections over the limits
+// to send the first request and to read the error response.
+const rejectedConnTimeout = 10 * time.Second
+
+// tokenBucket implements token bucket rate limiting algorithm.
+//
+// It is not safe for concurrent use.
+type tokenBucket struct {
+	rate   float64 // tokens per second
+	burst  float64
+	tokens float64
+	last   time.Time
+}
+
+// newTokenBucket returns a new full bucket for the given rate.
+// Burst is equal to one second of operations, but at least one.
+func newTokenBucket(rate float64, now time.Time) *tokenBucket {
+	burst := math.Max(1, math.Ceil(rate))
+
+	return &tokenBucket{
+		rate:   rate,
+		burst:  burst,
+		tokens: burst,
+		last:   now,
+	}
+}
+
+// allow takes a single token from the bucket, if possible.
+func (tb *tokenBucket) allow(now time.Time) bool {
+	if elapsed := now.Sub(tb.last); elapsed > 0 {
+		tb.tokens = math.Min(tb.burst, tb.tokens+elapsed.Seconds()*tb.rate)
+		tb.last = now
+	}
+
+	if tb.tokens < 1 {
+		return false
+	}
+
+	tb.tokens--
+
+	return true
+}
+
+// setRate changes the rate of the bucket, keeping available tokens within the new burst.
+func (tb *tokenBucket) setRate(rate float64) {
+	tb.rate = rate
+	tb.burst = math.Max(1, math.Ceil(rate))
+	tb.tokens = math.Min(tb.burst, tb.tokens)
+}
+
+// full returns true if the bucket would be full at the given time.
+func (tb *tokenBucket) full(now time.Time) bool {
+	return tb.tokens+now.Sub(tb.last).Seconds()*tb.rate >= tb.burst
+}
+
+// ipLimits represents limits state shared by all connections from the same IP address.
+type ipLimits struct {
+	bucket   *tokenBucket
+	inFlight int
+	conns    int
+}
+
+// limits tracks operation limits for all client connections.
+type limits struct {
+	opts *LimitsOpts
+	now  func() time.Time
+
+	m     sync.Mutex
+	ips   map[string]*ipLimits
+	conns int
+}
+
+// newLimits returns a new limits tracker.
+func newLimits(opts *LimitsOpts) (*limits, error) {
+	l := &limits{
+		now: time.Now,
+		ips: map[string]*ipLimits{},
+	}
+
+	if err := l.setOpts(opts); err != nil {
+		return nil, err
+	}
+
+	return l, nil
+}
+
+// setOpts changes limits at runtime.
+//
+// Connection and concurrent operations limits apply immediately to new connections and operations.
+// Per-IP rate limit applies immediately to all addresses,
+// per-connection rate limit applies only to new connections.
+func (l *limits) setOpts(opts *LimitsOpts) error {
+	if opts.ConnOpsPerSecond < 0 || opts.IPOpsPerSecond < 0 || opts.IPMaxInFlight < 0 ||
+		opts.MaxConns < 0 || opts.IPMaxConns < 0 {
+		return fmt.Errorf("clientconn.limits.setOpts: limits can't be negative")
+	}
+
+	l.m.Lock()
+	defer l.m.Unlock()
+
+	l.opts = opts
+
+	for _, ipl := range l.ips {
+		switch {
+		case opts.IPOpsPerSecond <= 0:
+			ipl.bucket = nil
+		case ipl.bucket == nil:
+			ipl.bucket = newTokenBucket(opts.IPOpsPerSecond, l.now())
+		default:
+			ipl.bucket.setRate(opts.IPOpsPerSecond)
+		}
+	}
+
+	return nil
+}
+
+// conn returns a limiter for a new connection from the given address.
+// Limiter's close method should be called when the connection is closed.
+//
+// If connection limits are exceeded, the returned limiter rejects all operations; see connLimiter.rejected.
+//
+// Nil limits tracker returns nil limiter.
+func (l *limits) conn(addr net.Addr) *connLimiter {
+	if l == nil {
+		return nil
+	}
+
+	ip := addr.String()
+	if tcpAddr, ok := addr.(*net.TCPAddr); ok {
+		ip = tcpAddr.IP.String()
+	}
+
+	l.m.Lock()
+	defer l.m.Unlock()
+
+	now := l.now()
+
+	// Forget addresses without connections once their state is the same as for a new address.
+	// Entries are not removed on close so clients can't bypass rate limit by reconnecting.
+	for k, v := range l.ips {
+		if v.conns == 0 && (v.bucket == nil || v.bucket.full(now)) {
+			delete(l.ips, k)
+		}
+	}
+
+	ipl := l.ips[ip]
+	if ipl == nil {
+		ipl = new(ipLimits)
+		if l.opts.IPOpsPerSecond > 0 {
+			ipl.bucket = newTokenBucket(l.opts.IPOpsPerSecond, now)
+		}
+		l.ips[ip] = ipl
+	}
+
+	if limit := l.opts.MaxConns; limit > 0 && l.conns >= limit {
+		return &connLimiter{
+			rejectErr: common.NewErrorMsg(common.ErrRateLimitExceeded, "Too many open connections"),
+		}
+	}
+
+	if limit := l.opts.IPMaxConns; limit > 0 && ipl.conns >= limit {
+		return &connLimiter{
+			rejectErr: common.NewErrorMsg(common.ErrRateLimitExceeded, "Too many open connections from client address"),
+		}
+	}
+
+	ipl.conns++
+	l.conns++
+
+	cl := &connLimiter{
+		l:   l,
+		ipl: ipl,
+	}
+
+	if l.opts.ConnOpsPerSecond > 0 {
+		cl.bucket = newTokenBucket(l.opts.ConnOpsPerSecond, now)
+	}
+
+	return cl
+}
+
+// connLimiter limits operations of a single connection.
+//
+// Nil limiter does not limit anything.
+type connLimiter struct {
+	l         *limits
+	ipl       *ipLimits
+	bucket    *tokenBucket // accessed only by the connection's goroutine
+	rejectErr error        // set if the connection exceeded connection limits
+}
+
+// rejected returns an error if the connection exceeded connection limits, nil otherwise.
+//
+// Such connection should be closed after sending that error in response to the first request.
+func (cl *connLimiter) rejected() error {
+	if cl == nil {
+		return nil
+	}
+
+	return cl.rejectErr
+}
+
+// acquire checks limits before running the given command.
+//
+// If limits are exceeded, it returns an error that should be sent to the client.
+// Otherwise, release must be called after the command is completed.
+func (cl *connLimiter) acquire(command string) error {
+	if cl == nil {
+		return nil
+	}
+
+	// even hello, so the client gets the error during the handshake
+	if cl.rejectErr != nil {
+		return cl.rejectErr
+	}
+
+	if _, ok := limitsExempt[command]; ok {
+		return nil
+	}
+
+	now := cl.l.now()
+
+	if cl.bucket != nil && !cl.bucket.allow(now) {
+		return common.NewErrorMsg(common.ErrRateLimitExceeded, "Connection operations rate limit exceeded")
+	}
+
+	cl.l.m.Lock()
+	defer cl.l.m.Unlock()
+
+	if limit := cl.l.opts.IPMaxInFlight; limit > 0 && cl.ipl.inFlight >= limit {
+		return common.NewErrorMsg(common.ErrRateLimitExceeded, "Too many concurrent operations from client address")
+	}
+
+	if cl.ipl.bucket != nil && !cl.ipl.bucket.allow(now) {
+		return common.NewErrorMsg(common.ErrRateLimitExceeded, "Client address operations rate limit exceeded")
+	}
+
+	cl.ipl.inFlight++
+
+	return nil
+}
+
+// release marks the command started by a successful acquire call as completed.
+func (cl *connLimiter) release(command string) {
+	if cl == nil {
+		return
+	}
+
+	if _, ok := limitsExempt[command]; ok {
+		return
+	}
+
+	cl.l.m.Lock()
+	defer cl.l.m.Unlock()
+
+	cl.ipl.inFlight--
+}
+
+// close marks the connection as closed.
+func (cl *connLimiter) close() {
+	if cl == nil || cl.rejectErr != nil {
+		return
+	}
+
+	cl.l.m.Lock()
+	defer cl.l.m.Unlock()
+
+	cl.ipl.conns--
+	cl.l.conns--
+}
@@ -0,0 +1,201 @@
+// Copyright 2021 FerretDB Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package clientconn
+
+import (
+	"errors"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/FerretDB/FerretDB/internal/handlers/common"
+)
+
+// assertRateLimited checks that err is a rate limit error.
+func assertRateLimited(t *testing.T, err error) {
+	t.Helper()
+
+	var protoErr *common.Error
+	require.True(t, errors.As(err, &protoErr), "%v", err)
+	assert.Equal(t, common.ErrRateLimitExceeded, protoErr.Code())
+}
+
+func TestLimits(t *testing.T) {
+	t.Parallel()
+
+	_, err := newLimits(&LimitsOpts{IPMaxInFlight: -1})
+	assert.Error(t, err)
+
+	var nilLimits *limits
+	cl := nilLimits.conn(&net.TCPAddr{IP: net.IPv4(127, 0, 0, 1)})
+	assert.NoError(t, cl.acquire("find"))
+	cl.release("find")
+	cl.close()
+
+	t.Run("ConnOpsPerSecond", func(t *testing.T) {
+		t.Parallel()
+
+		now := time.Unix(1000, 0)
+		l, err := newLimits(&LimitsOpts{ConnOpsPerSecond: 2})
+		require.NoError(t, err)
+		l.now = func() time.Time { return now }
+
+		addr := &net.TCPAddr{IP: net.IPv4(127, 0, 0, 1), Port: 1}
+		cl1 := l.conn(addr)
+		cl2 := l.conn(addr)
+
+		for i := 0; i < 2; i++ {
+			require.NoError(t, cl1.acquire("find"))
+			cl1.release("find")
+		}
+		assertRateLimited(t, cl1.acquire("find"))
+
+		// exempt commands and other connections are not affected
+		assert.NoError(t, cl1.acquire("hello"))
+		cl1.release("hello")
+		assert.NoError(t, cl2.acquire("find"))
+		cl2.release("find")
+
+		now = now.Add(500 * time.Millisecond)
+		assert.NoError(t, cl1.acquire("find"))
+		cl1.release("find")
+		assertRateLimited(t, cl1.acquire("find"))
+	})
+
+	t.Run("IPOpsPerSecond", func(t *testing.T) {
+		t.Parallel()
+
+		now := time.Unix(1000, 0)
+		l, err := newLimits(&LimitsOpts{IPOpsPerSecond: 1})
+		require.NoError(t, err)
+		l.now = func() time.Time { return now }
+
+		cl1 := l.conn(&net.TCPAddr{IP: net.IPv4(127, 0, 0, 1), Port: 1})
+		cl2 := l.conn(&net.TCPAddr{IP: net.IPv4(127, 0, 0, 1), Port: 2})
+		cl3 := l.conn(&net.TCPAddr{IP: net.IPv4(127, 0, 0, 2), Port: 1})
+
+		require.NoError(t, cl1.acquire("find"))
+		cl1.release("find")
+		assertRateLimited(t, cl2.acquire("find"))
+		assert.NoError(t, cl3.acquire("find"))
+		cl3.release("find")
+
+		// reconnecting does not reset the limit
+		cl1.close()
+		cl2.close()
+		cl1 = l.conn(&net.TCPAddr{IP: net.IPv4(127, 0, 0, 1), Port: 3})
+		assertRateLimited(t, cl1.acquire("find"))
+		cl1.close()
+
+		// but state is forgotten after the bucket is refilled
+		now = now.Add(time.Second)
+		cl3.close()
+		l.conn(&net.TCPAddr{IP: net.IPv4(127, 0, 0, 3), Port: 1}).close()
+		assert.Len(t, l.ips, 1)
+	})
+
+	t.Run("IPMaxInFlight", func(t *testing.T) {
+		t.Parallel()
+
+		l, err := newLimits(&LimitsOpts{IPMaxInFlight: 1})
+		require.NoError(t, err)
+
+		cl1 := l.conn(&net.TCPAddr{IP: net.IPv4(127, 0, 0, 1), Port: 1})
+		cl2 := l.conn(&net.TCPAddr{IP: net.IPv4(127, 0, 0, 1), Port: 2})
+
+		require.NoError(t, cl1.acquire("find"))
+		assertRateLimited(t, cl2.acquire("insert"))
+
+		cl1.release("find")
+		require.NoError(t, cl2.acquire("insert"))
+		cl2.release("insert")
+	})
+
+	t.Run("MaxConns", func(t *testing.T) {
+		t.Parallel()
+
+		l, err := newLimits(&LimitsOpts{MaxConns: 2, IPMaxConns: 1})
+		require.NoError(t, err)
+
+		cl1 := l.conn(&net.TCPAddr{IP: net.IPv4(127, 0, 0, 1), Port: 1})
+		require.NoError(t, cl1.rejected())
+
+		// over the limit for the same address
+		cl2 := l.conn(&net.TCPAddr{IP: net.IPv4(127, 0, 0, 1), Port: 2})
+		assertRateLimited(t, cl2.rejected())
+		assertRateLimited(t, cl2.acquire("hello"))
+		cl2.close()
+
+		cl3 := l.conn(&net.TCPAddr{IP: net.IPv4(127, 0, 0, 2), Port: 1})
+		require.NoError(t, cl3.rejected())
+
+		// over the total limit
+		cl4 := l.conn(&net.TCPAddr{IP: net.IPv4(127, 0, 0, 3), Port: 1})
+		assertRateLimited(t, cl4.rejected())
+		cl4.close()
+
+		// rejected connections are not counted
+		cl1.close()
+		cl5 := l.conn(&net.TCPAddr{IP: net.IPv4(127, 0, 0, 1), Port: 3})
+		require.NoError(t, cl5.rejected())
+		require.NoError(t, cl5.acquire("find"))
+		cl5.release("find")
+
+		cl3.close()
+		cl5.close()
+		assert.Zero(t, l.conns)
+	})
+
+	t.Run("SetOpts", func(t *testing.T) {
+		t.Parallel()
+
+		now := time.Unix(1000, 0)
+		l, err := newLimits(new(LimitsOpts))
+		require.NoError(t, err)
+		l.now = func() time.Time { return now }
+
+		assert.Error(t, l.setOpts(&LimitsOpts{MaxConns: -1}))
+
+		addr := &net.TCPAddr{IP: net.IPv4(127, 0, 0, 1), Port: 1}
+		cl1 := l.conn(addr)
+		require.NoError(t, cl1.acquire("find"))
+		cl1.release("find")
+
+		// existing addresses and connections get the new per-IP rate limit
+		require.NoError(t, l.setOpts(&LimitsOpts{IPOpsPerSecond: 1, MaxConns: 1}))
+		require.NoError(t, cl1.acquire("find"))
+		cl1.release("find")
+		assertRateLimited(t, cl1.acquire("find"))
+
+		cl2 := l.conn(&net.TCPAddr{IP: net.IPv4(127, 0, 0, 2), Port: 1})
+		assertRateLimited(t, cl2.rejected())
+		cl2.close()
+
+		// limits are removed
+		require.NoError(t, l.setOpts(new(LimitsOpts)))
+		require.NoError(t, cl1.acquire("find"))
+		cl1.release("find")
+
+		cl2 = l.conn(&net.TCPAddr{IP: net.IPv4(127, 0, 0, 2), Port: 1})
+		require.NoError(t, cl2.rejected())
+
+		cl1.close()
+		cl2.close()
+		assert.Zero(t, l.conns)
+	})
+}
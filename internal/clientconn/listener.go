@@ -16,28 +16,70 @@ package clientconn
 
 import (
 	"context"
+	"crypto/tls"
 	"errors"
 	"fmt"
 	"io"
 	"net"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/prometheus/client_golang/prometheus"
 	"go.uber.org/zap"
 
+	"github.com/FerretDB/FerretDB/ferretdb/middleware"
 	"github.com/FerretDB/FerretDB/internal/handlers"
+	"github.com/FerretDB/FerretDB/internal/util/audit"
 	"github.com/FerretDB/FerretDB/internal/util/ctxutil"
+	"github.com/FerretDB/FerretDB/internal/util/fault"
 	"github.com/FerretDB/FerretDB/internal/util/lazyerrors"
+	"github.com/FerretDB/FerretDB/internal/util/must"
+	"github.com/FerretDB/FerretDB/internal/util/tracing"
+	"github.com/FerretDB/FerretDB/internal/wire"
 )
 
 // Listener accepts incoming client connections.
 type Listener struct {
 	opts      *NewListenerOpts
 	metrics   *ListenerMetrics
+	limits    *limits
+	slowOps   *slowOpsConfig
+	mws       commandFunc // runs middlewares and the handler; nil if there are no middlewares
 	handler   handlers.Interface
-	listener  net.Listener
+	sockets   []socket
 	listening chan struct{}
+	drain     chan struct{}
+	connID    uint64 // accessed atomically
+	failing   int32  // number of sockets failing to accept connections; accessed atomically
+}
+
+// ListenerConfig represents configuration of a single listening socket.
+type ListenerConfig struct {
+	// Network is "tcp" (the default) or "unix".
+	Network string
+
+	// Address to listen on: host:port for TCP, where zero port means any free port,
+	// or a socket file path for Unix.
+	Addr string
+
+	// If set, all client connections to that socket use TLS; see NewTLSConfig.
+	TLS *tls.Config
+
+	// If set, all client connections to that socket should start with PROXY protocol v1 or v2 header
+	// (before TLS handshake); client addresses are taken from it. Connections without valid header are rejected.
+	// It should be set only for sockets that are reachable only via trusted proxies like HAProxy or AWS NLB.
+	ProxyProtocol bool
+
+	// If set, connections are accepted from that listener instead of a new socket; Network and Addr are ignored.
+	// It allows serving in-process connections, like net.Pipe pairs. It is closed on shutdown like sockets.
+	Listener net.Listener
+}
+
+// socket represents an opened listening socket with its configuration.
+type socket struct {
+	net.Listener
+	config ListenerConfig
 }
 
 // NewListenerOpts represents listener configuration.
@@ -48,48 +90,206 @@ type NewListenerOpts struct {
 	Handler         handlers.Interface
 	Logger          *zap.Logger
 	TestConnTimeout time.Duration
+
+	// If set, all client connections to ListenAddr use TLS; see NewTLSConfig.
+	TLS *tls.Config
+
+	// Additional sockets to listen on, each with its own settings.
+	// ListenAddr and TLS are a shorthand for the first TCP socket; it is not opened if ListenAddr is empty.
+	Listeners []ListenerConfig
+
+	// If set, connections from not allowed addresses are closed before TLS and wire protocol handshakes.
+	// With PROXY protocol, client addresses from its header are checked.
+	IPFilter *IPFilter
+
+	// If set, operations and connections exceeding limits are rejected with a retryable error.
+	// Limits could be changed at runtime with SetLimits.
+	Limits *LimitsOpts
+
+	// If positive, connections without requests for that duration are closed.
+	IdleTimeout time.Duration
+
+	// Maximal duration for in-flight operations to finish after ctx passed to Run is done;
+	// zero means DefaultDrainTimeout.
+	DrainTimeout time.Duration
+
+	// TCP keep-alive period of client connections; zero means Go's default (15 seconds), negative disables keep-alives.
+	KeepAlive time.Duration
+
+	// If set, security-relevant events are written to the audit log.
+	AuditLogger *audit.Logger
+
+	// If set, operations running longer than the threshold are logged.
+	// It could be changed at runtime with SetSlowOps.
+	SlowOps *SlowOpsOpts
+
+	// If set, client requests are traced.
+	Tracer *tracing.Tracer
+
+	// Compressors that could be negotiated by clients.
+	Compressors []wire.CompressorID
+
+	// Minimal size of the response body to be compressed; 0 means DefaultCompressionThreshold.
+	CompressionThreshold int
+
+	// If set, all client requests are recorded for replaying; see wire.CaptureWriter.
+	Capture *wire.CaptureWriter
+
+	// Middlewares that observe or modify commands and responses; the first one is the outermost.
+	// Commands unknown to FerretDB are rejected before middlewares are called.
+	Middlewares []middleware.Middleware
+
+	// If set, latency and dropped connections are injected into client connections; only for tests.
+	Faults *fault.Injector
 }
 
+// DefaultDrainTimeout is the default value of NewListenerOpts.DrainTimeout.
+const DefaultDrainTimeout = 3 * time.Second
+
+// errShutdown is returned by conn.run when the connection is closed due to the listener shutdown.
+var errShutdown = errors.New("server is shutting down")
+
 // NewListener returns a new listener, configured by the NewListenerOpts argument.
 func NewListener(opts *NewListenerOpts) *Listener {
 	return &Listener{
 		opts:      opts,
 		metrics:   newListenerMetrics(),
+		limits:    must.NotFail(newLimits(new(LimitsOpts))),
+		slowOps:   newSlowOpsConfig(opts.SlowOps),
+		mws:       chainMiddlewares(opts.Handler, opts.Middlewares),
 		handler:   opts.Handler,
 		listening: make(chan struct{}),
+		drain:     make(chan struct{}),
 	}
 }
 
 // Run runs the listener until ctx is done or some unrecoverable error occurs.
 //
+// When ctx is done, listener stops accepting new connections and starts draining existing ones:
+// idle connections are closed, hello and isMaster requests get ShutdownInProgress errors,
+// and in-flight operations have up to DrainTimeout to finish before they are canceled.
 // When this method returns, listener and all connections are closed.
 func (l *Listener) Run(ctx context.Context) error {
 	logger := l.opts.Logger.Named("listener")
 
-	var err error
-	if l.listener, err = net.Listen("tcp", l.opts.ListenAddr); err != nil {
-		return lazyerrors.Error(err)
+	err := l.listen()
+	close(l.listening)
+
+	if err != nil {
+		return err
 	}
 
-	close(l.listening)
-	logger.Sugar().Infof("Listening on %s ...", l.Addr())
+	for _, s := range l.sockets {
+		logger.Sugar().Infof("Listening on %s ...", s.Addr())
+	}
 
 	// handle ctx cancelation
 	go func() {
 		<-ctx.Done()
-		l.listener.Close()
+
+		for _, s := range l.sockets {
+			s.Close()
+		}
+
+		close(l.drain)
 	}()
 
-	const delay = 3 * time.Second
+	var connWG, acceptWG sync.WaitGroup
+	for _, s := range l.sockets {
+		s := s
+
+		acceptWG.Add(1)
+		go func() {
+			defer acceptWG.Done()
+			l.accept(ctx, s, &connWG)
+		}()
+	}
+
+	acceptWG.Wait()
+
+	logger.Info("Waiting for all connections to stop...")
+	connWG.Wait()
+
+	return ctx.Err()
+}
+
+// listen opens all configured sockets.
+// If some of them could not be opened, already opened sockets are closed.
+func (l *Listener) listen() error {
+	var err error
+	if l.opts.Limits != nil {
+		if err = l.limits.setOpts(l.opts.Limits); err != nil {
+			return lazyerrors.Error(err)
+		}
+	}
+
+	configs := l.opts.Listeners
+	if l.opts.ListenAddr != "" {
+		configs = append([]ListenerConfig{{Addr: l.opts.ListenAddr, TLS: l.opts.TLS}}, configs...)
+	}
+
+	if len(configs) == 0 {
+		return lazyerrors.New("no listen addresses")
+	}
+
+	sockets := make([]socket, 0, len(configs))
+	for _, c := range configs {
+		network := c.Network
+		if network == "" {
+			network = "tcp"
+		}
+
+		var lis net.Listener
+		switch {
+		case c.Listener != nil:
+			lis = c.Listener
+		case network != "tcp" && network != "unix":
+			err = fmt.Errorf("unsupported network %q", network)
+		default:
+			lc := net.ListenConfig{KeepAlive: l.opts.KeepAlive}
+			lis, err = lc.Listen(context.Background(), network, c.Addr)
+		}
+
+		if err != nil {
+			for _, s := range sockets {
+				s.Close()
+			}
+
+			return lazyerrors.Error(err)
+		}
+
+		sockets = append(sockets, socket{Listener: lis, config: c})
+	}
+
+	l.sockets = sockets
+
+	return nil
+}
+
+// accept accepts client connections on the given socket and runs them until ctx is done.
+// Running connections are tracked by connWG.
+func (l *Listener) accept(ctx context.Context, s socket, connWG *sync.WaitGroup) {
+	logger := l.opts.Logger.Named("listener")
+
+	var failing bool
+	defer func() {
+		if failing {
+			atomic.AddInt32(&l.failing, -1)
+		}
+	}()
 
-	var wg sync.WaitGroup
 	for {
-		netConn, err := l.listener.Accept()
+		netConn, err := s.Accept()
 		if err != nil {
 			l.metrics.accepts.WithLabelValues("1").Inc()
 
 			if ctx.Err() != nil {
-				break
+				return
+			}
+
+			if !failing {
+				failing = true
+				atomic.AddInt32(&l.failing, 1)
 			}
 
 			logger.Warn("Failed to accept connection", zap.Error(err))
@@ -99,61 +299,194 @@ func (l *Listener) Run(ctx context.Context) error {
 			continue
 		}
 
-		wg.Add(1)
-		l.metrics.accepts.WithLabelValues("0").Inc()
-		l.metrics.connectedClients.Inc()
+		if failing {
+			failing = false
+			atomic.AddInt32(&l.failing, -1)
+		}
+
+		connWG.Add(1)
+
+		captureID := atomic.AddUint64(&l.connID, 1)
 
-		// run connection
+		// run connection;
+		// PROXY protocol header is read there, so slow clients don't block accepting other connections
 		go func() {
-			defer func() {
-				netConn.Close()
-				l.metrics.connectedClients.Dec()
-				wg.Done()
-			}()
-
-			prefix := fmt.Sprintf("// %s -> %s ", netConn.RemoteAddr(), netConn.LocalAddr())
-			opts := &newConnOpts{
-				netConn:     netConn,
-				mode:        l.opts.Mode,
-				l:           l.opts.Logger.Named(prefix), // original unnamed logger
-				proxyAddr:   l.opts.ProxyAddr,
-				handler:     l.opts.Handler,
-				connMetrics: l.metrics.connMetrics,
-			}
-			conn, e := newConn(opts)
-			if e != nil {
-				logger.Warn("Failed to create connection", zap.Error(e))
-				return
-			}
+			defer connWG.Done()
+			l.runConn(ctx, s.config, netConn, captureID)
+		}()
+	}
+}
 
-			runCtx, runCancel := ctxutil.WithDelay(ctx.Done(), delay)
-			defer runCancel()
+// runConn runs a single accepted client connection until ctx is done or the client disconnects.
+//
+// When this method returns, the connection is closed.
+func (l *Listener) runConn(ctx context.Context, config ListenerConfig, netConn net.Conn, captureID uint64) {
+	logger := l.opts.Logger.Named("listener")
 
-			if l.opts.TestConnTimeout != 0 {
-				runCtx, runCancel = context.WithTimeout(runCtx, l.opts.TestConnTimeout)
-				defer runCancel()
-			}
+	defer netConn.Close()
 
-			e = conn.run(runCtx) //nolint:contextcheck // false positive
-			if e == io.EOF {
-				logger.Info("Connection stopped")
-			} else {
-				logger.Warn("Connection stopped", zap.Error(e))
-			}
-		}()
+	if config.ProxyProtocol {
+		conn, err := readProxyHeader(netConn)
+		if err != nil {
+			l.metrics.rejects.WithLabelValues("proxy_header").Inc()
+			logger.Warn("Connection rejected", zap.Stringer("addr", netConn.RemoteAddr()), zap.Error(err))
+			return
+		}
+
+		netConn = conn
 	}
 
-	logger.Info("Waiting for all connections to stop...")
-	wg.Wait()
+	if l.opts.IPFilter != nil && !l.opts.IPFilter.Allowed(netConn.RemoteAddr()) {
+		l.metrics.rejects.WithLabelValues("ip_filter").Inc()
+		logger.Info("Connection rejected by IP filter", zap.Stringer("addr", netConn.RemoteAddr()))
+		return
+	}
 
-	return ctx.Err()
+	if config.TLS != nil {
+		netConn = tls.Server(netConn, config.TLS)
+	}
+
+	l.metrics.accepts.WithLabelValues("0").Inc()
+	l.metrics.connectedClients.Inc()
+
+	limiter := l.limits.conn(netConn.RemoteAddr())
+	if err := limiter.rejected(); err != nil {
+		l.metrics.rejects.WithLabelValues("conn_limit").Inc()
+		logger.Info("Connection rejected by connection limits", zap.Stringer("addr", netConn.RemoteAddr()))
+	}
+
+	defer func() {
+		limiter.close()
+		netConn.Close()
+		l.metrics.connectedClients.Dec()
+	}()
+
+	prefix := fmt.Sprintf("// %s -> %s ", netConn.RemoteAddr(), netConn.LocalAddr())
+	opts := &newConnOpts{
+		netConn:     netConn,
+		mode:        l.opts.Mode,
+		l:           l.opts.Logger.Named(prefix), // original unnamed logger
+		proxyAddr:   l.opts.ProxyAddr,
+		handler:     l.opts.Handler,
+		connMetrics: l.metrics.connMetrics,
+		limiter:     limiter,
+		auditLogger: l.opts.AuditLogger,
+		idleTimeout: l.opts.IdleTimeout,
+		drain:       l.drain,
+		slowOps:     l.slowOps,
+		middlewares: l.mws,
+		tracer:      l.opts.Tracer,
+
+		compressors:          l.opts.Compressors,
+		compressionThreshold: l.opts.CompressionThreshold,
+
+		capture:   l.opts.Capture,
+		captureID: captureID,
+
+		faults: l.opts.Faults,
+	}
+	conn, err := newConn(opts)
+	if err != nil {
+		logger.Warn("Failed to create connection", zap.Error(err))
+		return
+	}
+
+	drainTimeout := l.opts.DrainTimeout
+	if drainTimeout == 0 {
+		drainTimeout = DefaultDrainTimeout
+	}
+
+	runCtx, runCancel := ctxutil.WithDelay(ctx.Done(), drainTimeout)
+	defer runCancel()
+
+	if l.opts.TestConnTimeout != 0 {
+		runCtx, runCancel = context.WithTimeout(runCtx, l.opts.TestConnTimeout)
+		defer runCancel()
+	}
+
+	err = conn.run(runCtx) //nolint:contextcheck // false positive
+	if err == io.EOF || err == errShutdown {
+		logger.Info("Connection stopped")
+	} else {
+		logger.Warn("Connection stopped", zap.Error(err))
+	}
+}
+
+// SetLimits changes operation and connection limits; nil opts remove all limits.
+//
+// Existing connections are not closed if they exceed new connection limits.
+// Per-connection operations rate limit applies only to new connections.
+func (l *Listener) SetLimits(opts *LimitsOpts) error {
+	if opts == nil {
+		opts = new(LimitsOpts)
+	}
+
+	if err := l.limits.setOpts(opts); err != nil {
+		return lazyerrors.Error(err)
+	}
+
+	return nil
 }
 
-// Addr returns listener's address.
+// SetSlowOps changes slow operations logging configuration for all connections; nil opts disable it.
+func (l *Listener) SetSlowOps(opts *SlowOpsOpts) {
+	l.slowOps.set(opts)
+}
+
+// Addr returns the address of the first socket.
 // It can be used to determine an actually used port, if it was zero.
+//
+// It blocks until Run opens sockets, and returns nil if that failed.
 func (l *Listener) Addr() net.Addr {
+	addrs := l.Addrs()
+	if len(addrs) == 0 {
+		return nil
+	}
+
+	return addrs[0]
+}
+
+// Addrs returns addresses of all sockets in the configuration order,
+// with ListenAddr first (if set).
+//
+// It blocks until Run opens sockets, and returns nil if that failed.
+func (l *Listener) Addrs() []net.Addr {
 	<-l.listening
-	return l.listener.Addr()
+
+	var res []net.Addr
+	for _, s := range l.sockets {
+		res = append(res, s.Addr())
+	}
+
+	return res
+}
+
+// Check returns an error if the listener is not accepting client connections:
+// sockets are not opened yet or failed to open, the last accept on some socket failed, or listener is shutting down.
+//
+// It does not block and is used by liveness and readiness probes.
+func (l *Listener) Check(ctx context.Context) error {
+	select {
+	case <-l.listening:
+	default:
+		return errors.New("listener is not started yet")
+	}
+
+	if len(l.sockets) == 0 {
+		return errors.New("listener failed to open sockets")
+	}
+
+	select {
+	case <-l.drain:
+		return errShutdown
+	default:
+	}
+
+	if n := atomic.LoadInt32(&l.failing); n > 0 {
+		return fmt.Errorf("%d socket(s) failing to accept connections", n)
+	}
+
+	return nil
 }
 
 // Describe implements prometheus.Collector.
@@ -25,6 +25,7 @@ const (
 type ListenerMetrics struct {
 	connectedClients prometheus.Gauge
 	accepts          *prometheus.CounterVec
+	rejects          *prometheus.CounterVec
 	connMetrics      *ConnMetrics
 }
 
@@ -48,6 +49,15 @@ func newListenerMetrics() *ListenerMetrics {
 			},
 			[]string{"error"},
 		),
+		rejects: prometheus.NewCounterVec(
+			prometheus.CounterOpts{
+				Namespace: namespace,
+				Subsystem: subsystem,
+				Name:      "rejects_total",
+				Help:      "Total number of rejected client connections.",
+			},
+			[]string{"reason"},
+		),
 		connMetrics: newConnMetrics(),
 	}
 }
@@ -56,6 +66,7 @@ func newListenerMetrics() *ListenerMetrics {
 func (lm *ListenerMetrics) Describe(ch chan<- *prometheus.Desc) {
 	lm.connectedClients.Describe(ch)
 	lm.accepts.Describe(ch)
+	lm.rejects.Describe(ch)
 	lm.connMetrics.Describe(ch)
 }
 
@@ -63,6 +74,7 @@ func (lm *ListenerMetrics) Describe(ch chan<- *prometheus.Desc) {
 func (lm *ListenerMetrics) Collect(ch chan<- prometheus.Metric) {
 	lm.connectedClients.Collect(ch)
 	lm.accepts.Collect(ch)
+	lm.rejects.Collect(ch)
 	lm.connMetrics.Collect(ch)
 }
 
@@ -0,0 +1,340 @@
+// Copyright 2021 FerretDB Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package clientconn
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"io"
+	"net"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap/zaptest"
+
+	"github.com/FerretDB/FerretDB/internal/handlers"
+	"github.com/FerretDB/FerretDB/internal/handlers/common"
+	"github.com/FerretDB/FerretDB/internal/handlers/dummy"
+	"github.com/FerretDB/FerretDB/internal/types"
+	"github.com/FerretDB/FerretDB/internal/util/must"
+	"github.com/FerretDB/FerretDB/internal/wire"
+)
+
+// awaitHelloHandler is a handler with hello command waiting until the context is canceled.
+type awaitHelloHandler struct {
+	handlers.Interface
+	started chan struct{}
+}
+
+// MsgHello implements HandlerInterface.
+func (h *awaitHelloHandler) MsgHello(ctx context.Context, msg *wire.OpMsg) (*wire.OpMsg, error) {
+	close(h.started)
+	<-ctx.Done()
+	return nil, ctx.Err()
+}
+
+// writeHello writes hello request to the given connection.
+func writeHello(t *testing.T, conn net.Conn) {
+	t.Helper()
+
+	var msg wire.OpMsg
+	require.NoError(t, msg.SetSections(wire.OpMsgSection{
+		Documents: []*types.Document{must.NotFail(types.NewDocument("hello", int32(1), "$db", "admin"))},
+	}))
+	b, err := msg.MarshalBinary()
+	require.NoError(t, err)
+
+	w := bufio.NewWriter(conn)
+	require.NoError(t, wire.WriteMessage(w, &wire.MsgHeader{
+		MessageLength: int32(wire.MsgHeaderLen + len(b)),
+		RequestID:     1,
+		OpCode:        wire.OpCodeMsg,
+	}, &msg))
+	require.NoError(t, w.Flush())
+}
+
+func TestListener(t *testing.T) {
+	t.Parallel()
+
+	h, err := dummy.New()
+	require.NoError(t, err)
+
+	t.Run("Multiple", func(t *testing.T) {
+		t.Parallel()
+
+		socket := filepath.Join(t.TempDir(), "ferretdb.sock")
+
+		l := NewListener(&NewListenerOpts{
+			ListenAddr: "127.0.0.1:0",
+			Listeners: []ListenerConfig{
+				{Network: "unix", Addr: socket},
+				{Addr: "127.0.0.1:0"},
+			},
+			Mode:    NormalMode,
+			Handler: h,
+			Logger:  zaptest.NewLogger(t),
+		})
+
+		assert.EqualError(t, l.Check(context.Background()), "listener is not started yet")
+
+		ctx, cancel := context.WithCancel(context.Background())
+		done := make(chan struct{})
+		go func() {
+			defer close(done)
+			_ = l.Run(ctx)
+		}()
+		t.Cleanup(func() {
+			cancel()
+			<-done
+		})
+
+		addrs := l.Addrs()
+		require.Len(t, addrs, 3)
+		assert.NoError(t, l.Check(ctx))
+		assert.Equal(t, l.Addr(), addrs[0])
+
+		// zero ports are replaced by actual ones
+		assert.NotZero(t, addrs[0].(*net.TCPAddr).Port)
+		assert.NotZero(t, addrs[2].(*net.TCPAddr).Port)
+		assert.NotEqual(t, addrs[0].String(), addrs[2].String())
+		assert.Equal(t, socket, addrs[1].String())
+
+		for _, addr := range addrs {
+			conn, err := net.Dial(addr.Network(), addr.String())
+			require.NoError(t, err)
+			require.NoError(t, conn.Close())
+		}
+
+		assert.Eventually(t, func() bool {
+			return testutil.ToFloat64(l.metrics.accepts.WithLabelValues("0")) == 3
+		}, 5*time.Second, 10*time.Millisecond)
+	})
+
+	t.Run("ProxyProtocol", func(t *testing.T) {
+		t.Parallel()
+
+		ipFilter, err := NewIPFilter(nil, []string{"192.0.2.0/24"})
+		require.NoError(t, err)
+
+		l := NewListener(&NewListenerOpts{
+			Listeners: []ListenerConfig{
+				{Addr: "127.0.0.1:0", ProxyProtocol: true},
+			},
+			Mode:     NormalMode,
+			Handler:  h,
+			Logger:   zaptest.NewLogger(t),
+			IPFilter: ipFilter,
+		})
+
+		ctx, cancel := context.WithCancel(context.Background())
+		done := make(chan struct{})
+		go func() {
+			defer close(done)
+			_ = l.Run(ctx)
+		}()
+		t.Cleanup(func() {
+			cancel()
+			<-done
+		})
+
+		for _, header := range []string{
+			"PROXY TCP4 198.51.100.1 127.0.0.1 12345 27017\r\n", // allowed
+			"PROXY TCP4 192.0.2.1 127.0.0.1 12345 27017\r\n",    // denied by IP filter
+			"GET / HTTP/1.1\r\n\r\n",                            // invalid
+		} {
+			conn, err := net.Dial("tcp", l.Addr().String())
+			require.NoError(t, err)
+
+			_, err = conn.Write([]byte(header))
+			require.NoError(t, err)
+			require.NoError(t, conn.Close())
+		}
+
+		assert.Eventually(t, func() bool {
+			return testutil.ToFloat64(l.metrics.accepts.WithLabelValues("0")) == 1 &&
+				testutil.ToFloat64(l.metrics.rejects.WithLabelValues("ip_filter")) == 1 &&
+				testutil.ToFloat64(l.metrics.rejects.WithLabelValues("proxy_header")) == 1
+		}, 5*time.Second, 10*time.Millisecond)
+	})
+
+	t.Run("ConnLimits", func(t *testing.T) {
+		t.Parallel()
+
+		l := NewListener(&NewListenerOpts{
+			ListenAddr:  "127.0.0.1:0",
+			Mode:        NormalMode,
+			Handler:     h,
+			Logger:      zaptest.NewLogger(t),
+			Limits:      &LimitsOpts{MaxConns: 1},
+			IdleTimeout: 500 * time.Millisecond,
+		})
+
+		ctx, cancel := context.WithCancel(context.Background())
+		done := make(chan struct{})
+		go func() {
+			defer close(done)
+			_ = l.Run(ctx)
+		}()
+		t.Cleanup(func() {
+			cancel()
+			<-done
+		})
+
+		conn1, err := net.Dial("tcp", l.Addr().String())
+		require.NoError(t, err)
+		defer conn1.Close()
+
+		// wait for the first connection to be counted
+		assert.Eventually(t, func() bool {
+			return testutil.ToFloat64(l.metrics.connectedClients) == 1
+		}, 5*time.Second, 10*time.Millisecond)
+
+		conn2, err := net.Dial("tcp", l.Addr().String())
+		require.NoError(t, err)
+		defer conn2.Close()
+
+		writeHello(t, conn2)
+
+		// the error is sent before closing the connection
+		r := bufio.NewReader(conn2)
+		_, resBody, err := wire.ReadMessage(r)
+		require.NoError(t, err)
+
+		res := must.NotFail(resBody.(*wire.OpMsg).Document())
+		assert.Equal(t, int32(common.ErrRateLimitExceeded), must.NotFail(res.Get("code")))
+		assert.Equal(t, "Too many open connections", must.NotFail(res.Get("errmsg")))
+
+		_, err = r.ReadByte()
+		assert.Equal(t, io.EOF, err)
+
+		// idle connection is closed
+		_, err = conn1.Read(make([]byte, 1))
+		assert.Equal(t, io.EOF, err)
+
+		assert.Equal(t, float64(1), testutil.ToFloat64(l.metrics.rejects.WithLabelValues("conn_limit")))
+
+		assert.Equal(t, 1, testutil.CollectAndCount(l.metrics.connMetrics.durations))
+		assert.Positive(t, testutil.ToFloat64(l.metrics.connMetrics.bytes.WithLabelValues("request")))
+		assert.Positive(t, testutil.ToFloat64(l.metrics.connMetrics.bytes.WithLabelValues("response")))
+	})
+
+	t.Run("Drain", func(t *testing.T) {
+		t.Parallel()
+
+		ah := &awaitHelloHandler{Interface: h, started: make(chan struct{})}
+		l := NewListener(&NewListenerOpts{
+			ListenAddr:   "127.0.0.1:0",
+			Mode:         NormalMode,
+			Handler:      ah,
+			Logger:       zaptest.NewLogger(t),
+			DrainTimeout: time.Minute,
+		})
+
+		ctx, cancel := context.WithCancel(context.Background())
+		done := make(chan struct{})
+		go func() {
+			defer close(done)
+			_ = l.Run(ctx)
+		}()
+		t.Cleanup(cancel)
+
+		conn1, err := net.Dial("tcp", l.Addr().String())
+		require.NoError(t, err)
+		defer conn1.Close()
+
+		conn2, err := net.Dial("tcp", l.Addr().String())
+		require.NoError(t, err)
+		defer conn2.Close()
+
+		writeHello(t, conn2)
+		<-ah.started
+
+		cancel()
+
+		assert.Eventually(t, func() bool {
+			return errors.Is(l.Check(context.Background()), errShutdown)
+		}, 5*time.Second, 10*time.Millisecond)
+
+		// new connections are not accepted
+		assert.Eventually(t, func() bool {
+			c, e := net.Dial("tcp", l.Addr().String())
+			if e == nil {
+				c.Close()
+			}
+			return e != nil
+		}, 5*time.Second, 10*time.Millisecond)
+
+		// in-flight hello gets an error
+		r := bufio.NewReader(conn2)
+		_, resBody, err := wire.ReadMessage(r)
+		require.NoError(t, err)
+
+		res := must.NotFail(resBody.(*wire.OpMsg).Document())
+		assert.Equal(t, int32(common.ErrShutdownInProgress), must.NotFail(res.Get("code")))
+
+		_, err = r.ReadByte()
+		assert.Equal(t, io.EOF, err)
+
+		// idle connection is closed
+		_, err = conn1.Read(make([]byte, 1))
+		assert.Equal(t, io.EOF, err)
+
+		// listener does not wait for the drain timeout
+		select {
+		case <-done:
+		case <-time.After(5 * time.Second):
+			t.Fatal("listener did not stop")
+		}
+	})
+
+	t.Run("Error", func(t *testing.T) {
+		t.Parallel()
+
+		l := NewListener(&NewListenerOpts{
+			ListenAddr: "127.0.0.1:0",
+			Listeners: []ListenerConfig{
+				{Network: "udp", Addr: "127.0.0.1:0"},
+			},
+			Mode:    NormalMode,
+			Handler: h,
+			Logger:  zaptest.NewLogger(t),
+		})
+
+		err := l.Run(context.Background())
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), `unsupported network "udp"`)
+		assert.Nil(t, l.Addr())
+		assert.Nil(t, l.Addrs())
+		assert.EqualError(t, l.Check(context.Background()), "listener failed to open sockets")
+	})
+
+	t.Run("NoAddresses", func(t *testing.T) {
+		t.Parallel()
+
+		l := NewListener(&NewListenerOpts{
+			Mode:    NormalMode,
+			Handler: h,
+			Logger:  zaptest.NewLogger(t),
+		})
+
+		require.Error(t, l.Run(context.Background()))
+		assert.Nil(t, l.Addr())
+	})
+}
@@ -0,0 +1,171 @@
+// Copyright 2021 FerretDB Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package clientconn
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/FerretDB/FerretDB/ferretdb/middleware"
+	"github.com/FerretDB/FerretDB/internal/bson"
+	"github.com/FerretDB/FerretDB/internal/clientconn/conninfo"
+	"github.com/FerretDB/FerretDB/internal/handlers"
+	"github.com/FerretDB/FerretDB/internal/handlers/common"
+	"github.com/FerretDB/FerretDB/internal/types"
+	"github.com/FerretDB/FerretDB/internal/util/lazyerrors"
+	"github.com/FerretDB/FerretDB/internal/wire"
+)
+
+// commandFunc runs the command and returns the response.
+type commandFunc func(ctx context.Context, msg *wire.OpMsg) (*wire.OpMsg, error)
+
+// chainMiddlewares returns a command function that runs the given middlewares before the handler,
+// or nil if there are no middlewares. The first middleware is the outermost one.
+//
+// Commands and responses are converted to and from BSON for each middleware,
+// so middlewares are not free.
+func chainMiddlewares(h handlers.Interface, middlewares []middleware.Middleware) commandFunc {
+	if len(middlewares) == 0 {
+		return nil
+	}
+
+	next := middlewareHandler(handlerCommandFunc(h))
+	for i := len(middlewares) - 1; i >= 0; i-- {
+		next = middlewares[i](next)
+	}
+
+	return middlewareCommandFunc(next)
+}
+
+// handlerCommandFunc returns the command function that runs the handler's implementation of the command.
+func handlerCommandFunc(h handlers.Interface) commandFunc {
+	return func(ctx context.Context, msg *wire.OpMsg) (*wire.OpMsg, error) {
+		document, err := msg.Document()
+		if err != nil {
+			return nil, lazyerrors.Error(err)
+		}
+
+		// middlewares should not change the command name
+		cmd, ok := common.Commands[document.Command()]
+		if !ok || cmd.Handler == nil {
+			errMsg := fmt.Sprintf("no such command: '%s'", document.Command())
+			return nil, common.NewErrorMsg(common.ErrCommandNotFound, errMsg)
+		}
+
+		return cmd.Handler(h, ctx, msg)
+	}
+}
+
+// RegisterCommand adds a custom command implemented by the given middleware handler;
+// see common.RegisterCommand.
+//
+// Commands go through middlewares like built-in commands.
+func RegisterCommand(name, help string, write bool, h middleware.Handler) error {
+	if h == nil {
+		return fmt.Errorf("command %q has no handler", name)
+	}
+
+	return common.RegisterCommand(name, help, write, middlewareCommandFunc(h))
+}
+
+// middlewareHandler returns the middleware handler that calls the given command function.
+func middlewareHandler(run commandFunc) middleware.Handler {
+	return func(ctx context.Context, cmd *middleware.Command) ([]byte, error) {
+		doc, err := unmarshalDocument(cmd.Document)
+		if err != nil {
+			return nil, err
+		}
+
+		var msg wire.OpMsg
+		if err = msg.SetSections(wire.OpMsgSection{Documents: []*types.Document{doc}}); err != nil {
+			return nil, lazyerrors.Error(err)
+		}
+
+		res, err := run(ctx, &msg)
+		if err != nil {
+			return nil, err
+		}
+
+		resDoc, err := res.Document()
+		if err != nil {
+			return nil, lazyerrors.Error(err)
+		}
+
+		return bson.MustConvertDocument(resDoc).MarshalBinary()
+	}
+}
+
+// middlewareCommandFunc returns the command function that calls the given middleware handler.
+func middlewareCommandFunc(h middleware.Handler) commandFunc {
+	return func(ctx context.Context, msg *wire.OpMsg) (*wire.OpMsg, error) {
+		doc, err := msg.Document()
+		if err != nil {
+			return nil, lazyerrors.Error(err)
+		}
+
+		b, err := bson.MustConvertDocument(doc).MarshalBinary()
+		if err != nil {
+			return nil, lazyerrors.Error(err)
+		}
+
+		connInfo := conninfo.GetConnInfo(ctx)
+		cmd := &middleware.Command{
+			Name:     doc.Command(),
+			Document: b,
+			PeerAddr: connInfo.PeerAddr,
+		}
+		cmd.Username, cmd.AuthDB = connInfo.Auth()
+
+		resB, err := h(ctx, cmd)
+		if err != nil {
+			var e *middleware.Error
+			if errors.As(err, &e) {
+				return nil, common.NewErrorMsg(common.ErrorCode(e.Code), e.Message)
+			}
+
+			return nil, err
+		}
+
+		resDoc, err := unmarshalDocument(resB)
+		if err != nil {
+			return nil, err
+		}
+
+		var res wire.OpMsg
+		if err = res.SetSections(wire.OpMsgSection{Documents: []*types.Document{resDoc}}); err != nil {
+			return nil, lazyerrors.Error(err)
+		}
+
+		return &res, nil
+	}
+}
+
+// unmarshalDocument decodes BSON document returned or modified by middleware.
+func unmarshalDocument(b []byte) (*types.Document, error) {
+	var doc bson.Document
+	if err := doc.ReadFrom(bufio.NewReader(bytes.NewReader(b))); err != nil {
+		return nil, lazyerrors.Errorf("invalid middleware document: %w", err)
+	}
+
+	res, err := types.ConvertDocument(&doc)
+	if err != nil {
+		return nil, lazyerrors.Errorf("invalid middleware document: %w", err)
+	}
+
+	return res, nil
+}
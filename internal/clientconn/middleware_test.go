@@ -0,0 +1,118 @@
+// Copyright 2021 FerretDB Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package clientconn
+
+import (
+	"context"
+	"net"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/FerretDB/FerretDB/ferretdb/middleware"
+	"github.com/FerretDB/FerretDB/internal/bson"
+	"github.com/FerretDB/FerretDB/internal/clientconn/conninfo"
+	"github.com/FerretDB/FerretDB/internal/handlers/common"
+	"github.com/FerretDB/FerretDB/internal/handlers/dummy"
+	"github.com/FerretDB/FerretDB/internal/types"
+	"github.com/FerretDB/FerretDB/internal/util/must"
+	"github.com/FerretDB/FerretDB/internal/wire"
+)
+
+func TestMiddlewares(t *testing.T) {
+	t.Parallel()
+
+	h, err := dummy.New()
+	require.NoError(t, err)
+
+	assert.Nil(t, chainMiddlewares(h, nil))
+
+	var calls []string
+
+	// rejects commands for the "forbidden" database
+	auth := func(next middleware.Handler) middleware.Handler {
+		return func(ctx context.Context, cmd *middleware.Command) ([]byte, error) {
+			calls = append(calls, "auth")
+
+			assert.Equal(t, "127.0.0.1:1234", cmd.PeerAddr.String())
+			assert.Equal(t, "user", cmd.Username)
+			assert.Equal(t, "admin", cmd.AuthDB)
+
+			doc := must.NotFail(unmarshalDocument(cmd.Document))
+			if must.NotFail(doc.Get("$db")) == "forbidden" {
+				return nil, &middleware.Error{Code: 13, Message: "Access denied"}
+			}
+
+			return next(ctx, cmd)
+		}
+	}
+
+	// adds a field to responses, and renames commands
+	modify := func(next middleware.Handler) middleware.Handler {
+		return func(ctx context.Context, cmd *middleware.Command) ([]byte, error) {
+			calls = append(calls, "modify")
+
+			if cmd.Name == "ping" {
+				doc := must.NotFail(unmarshalDocument(cmd.Document))
+				doc.Remove("ping")
+				cmd.Document = must.NotFail(bson.MustConvertDocument(
+					must.NotFail(types.NewDocument("unknownCommand", int32(1), "$db", must.NotFail(doc.Get("$db")))),
+				).MarshalBinary())
+			}
+
+			b, err := next(ctx, cmd)
+			if err != nil {
+				return nil, err
+			}
+
+			res := must.NotFail(unmarshalDocument(b))
+			res.Set("middleware", true)
+
+			return bson.MustConvertDocument(res).MarshalBinary()
+		}
+	}
+
+	run := chainMiddlewares(h, []middleware.Middleware{auth, modify})
+
+	connInfo := &conninfo.ConnInfo{PeerAddr: &net.TCPAddr{IP: net.IPv4(127, 0, 0, 1), Port: 1234}}
+	connInfo.SetAuth("user", "admin")
+	ctx := conninfo.WithConnInfo(context.Background(), connInfo)
+
+	request := func(command, db string) *wire.OpMsg {
+		var msg wire.OpMsg
+		require.NoError(t, msg.SetSections(wire.OpMsgSection{
+			Documents: []*types.Document{must.NotFail(types.NewDocument(command, int32(1), "$db", db))},
+		}))
+
+		return &msg
+	}
+
+	res, err := run(ctx, request("buildInfo", "admin"))
+	require.NoError(t, err)
+	assert.Equal(t, []string{"auth", "modify"}, calls)
+
+	doc := must.NotFail(res.Document())
+	assert.Equal(t, true, must.NotFail(doc.Get("middleware")))
+	assert.Equal(t, float64(1), must.NotFail(doc.Get("ok")))
+
+	calls = nil
+	_, err = run(ctx, request("buildInfo", "forbidden"))
+	assert.Equal(t, []string{"auth"}, calls)
+	assert.Equal(t, common.NewErrorMsg(common.ErrUnauthorized, "Access denied"), err)
+
+	_, err = run(ctx, request("ping", "admin"))
+	assert.Equal(t, common.NewErrorMsg(common.ErrCommandNotFound, "no such command: 'unknownCommand'"), err)
+}
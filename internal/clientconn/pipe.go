@@ -0,0 +1,97 @@
+// Copyright 2021 FerretDB Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package clientconn
+
+import (
+	"context"
+	"net"
+	"sync"
+)
+
+// PipeListener is a net.Listener for in-process client connections created by Dial.
+//
+// It could be used as ListenerConfig.Listener.
+type PipeListener struct {
+	conns     chan net.Conn
+	closed    chan struct{}
+	closeOnce sync.Once
+}
+
+// pipeAddr is the address of PipeListener.
+type pipeAddr struct{}
+
+// Network implements net.Addr.
+func (pipeAddr) Network() string { return "pipe" }
+
+// String implements net.Addr.
+func (pipeAddr) String() string { return "pipe" }
+
+// NewPipeListener returns a new PipeListener.
+func NewPipeListener() *PipeListener {
+	return &PipeListener{
+		conns:  make(chan net.Conn),
+		closed: make(chan struct{}),
+	}
+}
+
+// Dial returns the client side of a new in-process connection.
+//
+// It blocks until the server side is accepted, ctx is done, or the listener is closed.
+func (pl *PipeListener) Dial(ctx context.Context) (net.Conn, error) {
+	server, client := net.Pipe()
+
+	select {
+	case pl.conns <- server:
+		return client, nil
+	case <-pl.closed:
+		err := &net.OpError{Op: "dial", Net: "pipe", Addr: pipeAddr{}, Err: net.ErrClosed}
+		server.Close()
+		client.Close()
+		return nil, err
+	case <-ctx.Done():
+		server.Close()
+		client.Close()
+		return nil, ctx.Err()
+	}
+}
+
+// Accept implements net.Listener.
+func (pl *PipeListener) Accept() (net.Conn, error) {
+	select {
+	case conn := <-pl.conns:
+		return conn, nil
+	case <-pl.closed:
+		return nil, &net.OpError{Op: "accept", Net: "pipe", Addr: pipeAddr{}, Err: net.ErrClosed}
+	}
+}
+
+// Close implements net.Listener.
+//
+// Already accepted connections are not closed.
+func (pl *PipeListener) Close() error {
+	pl.closeOnce.Do(func() { close(pl.closed) })
+	return nil
+}
+
+// Addr implements net.Listener.
+func (pl *PipeListener) Addr() net.Addr {
+	return pipeAddr{}
+}
+
+// check interfaces
+var (
+	_ net.Listener = (*PipeListener)(nil)
+	_ net.Addr     = pipeAddr{}
+)
@@ -0,0 +1,77 @@
+// Copyright 2021 FerretDB Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package clientconn
+
+import (
+	"bufio"
+	"context"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap/zaptest"
+
+	"github.com/FerretDB/FerretDB/internal/handlers/dummy"
+	"github.com/FerretDB/FerretDB/internal/wire"
+)
+
+func TestPipeListener(t *testing.T) {
+	t.Parallel()
+
+	h, err := dummy.New()
+	require.NoError(t, err)
+
+	pl := NewPipeListener()
+
+	l := NewListener(&NewListenerOpts{
+		Listeners: []ListenerConfig{{Listener: pl}},
+		Mode:      NormalMode,
+		Handler:   h,
+		Logger:    zaptest.NewLogger(t),
+	})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		_ = l.Run(ctx)
+	}()
+
+	assert.Equal(t, "pipe", l.Addr().String())
+
+	conn, err := pl.Dial(ctx)
+	require.NoError(t, err)
+	require.NoError(t, conn.SetDeadline(time.Now().Add(5*time.Second)))
+
+	writeHello(t, conn)
+
+	header, _, err := wire.ReadMessage(bufio.NewReader(conn))
+	require.NoError(t, err)
+	assert.Equal(t, int32(1), header.ResponseTo)
+	require.NoError(t, conn.Close())
+
+	cancel()
+	<-done
+
+	_, err = pl.Dial(context.Background())
+	assert.ErrorIs(t, err, net.ErrClosed)
+
+	dialCtx, dialCancel := context.WithCancel(context.Background())
+	dialCancel()
+	_, err = NewPipeListener().Dial(dialCtx)
+	assert.ErrorIs(t, err, context.Canceled)
+}
@@ -0,0 +1,250 @@
+// Copyright 2021 FerretDB Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package clientconn
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// proxyHeaderTimeout is the maximum duration of reading PROXY protocol header.
+const proxyHeaderTimeout = 5 * time.Second
+
+// PROXY protocol v1 and v2 constants.
+// See https://www.haproxy.org/download/2.6/doc/proxy-protocol.txt.
+const (
+	proxyV1Prefix    = "PROXY "
+	proxyV1MaxLength = 107
+
+	proxyV2HeaderLength = 16
+	proxyV2CmdLocal     = 0x20
+	proxyV2CmdProxy     = 0x21
+	proxyV2FamTCP4      = 0x11
+	proxyV2FamTCP6      = 0x21
+)
+
+// proxyV2Signature is the first 12 bytes of PROXY protocol v2 header.
+var proxyV2Signature = []byte("\r\n\r\n\x00\r\nQUIT\n")
+
+// errProxyHeader indicates invalid or missing PROXY protocol header.
+var errProxyHeader = errors.New("invalid PROXY protocol header")
+
+// proxyConn is a net.Conn with client and proxy addresses taken from PROXY protocol header.
+type proxyConn struct {
+	net.Conn
+	r      *bufio.Reader
+	remote net.Addr
+	local  net.Addr
+}
+
+// Read implements net.Conn.
+//
+// It reads data buffered after the header first.
+func (c *proxyConn) Read(b []byte) (int, error) {
+	return c.r.Read(b)
+}
+
+// RemoteAddr implements net.Conn.
+//
+// It returns the original client address.
+func (c *proxyConn) RemoteAddr() net.Addr {
+	return c.remote
+}
+
+// LocalAddr implements net.Conn.
+//
+// It returns the original destination address.
+func (c *proxyConn) LocalAddr() net.Addr {
+	return c.local
+}
+
+// readProxyHeader reads PROXY protocol v1 or v2 header from the given connection
+// and returns connection with addresses from it.
+//
+// Connections without a valid header are rejected.
+// Headers of LOCAL (v2) and UNKNOWN (v1) connections, such as proxy's health checks,
+// and headers with unsupported address families keep connection's own addresses.
+func readProxyHeader(conn net.Conn) (net.Conn, error) {
+	if err := conn.SetReadDeadline(time.Now().Add(proxyHeaderTimeout)); err != nil {
+		return nil, err
+	}
+
+	r := bufio.NewReader(conn)
+
+	// both headers are longer than v2 signature
+	prefix, err := r.Peek(len(proxyV2Signature))
+	if err != nil {
+		return nil, fmt.Errorf("%w: %s", errProxyHeader, err)
+	}
+
+	res := &proxyConn{
+		Conn:   conn,
+		r:      r,
+		remote: conn.RemoteAddr(),
+		local:  conn.LocalAddr(),
+	}
+
+	switch {
+	case bytes.Equal(prefix, proxyV2Signature):
+		err = res.readV2()
+	case bytes.HasPrefix(prefix, []byte(proxyV1Prefix)):
+		err = res.readV1()
+	default:
+		err = errProxyHeader
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	if err = conn.SetReadDeadline(time.Time{}); err != nil {
+		return nil, err
+	}
+
+	return res, nil
+}
+
+// readV1 reads PROXY protocol v1 (text) header.
+func (c *proxyConn) readV1() error {
+	var line []byte
+
+	for !bytes.HasSuffix(line, []byte("\r\n")) {
+		if len(line) >= proxyV1MaxLength {
+			return fmt.Errorf("%w: v1 header is too long", errProxyHeader)
+		}
+
+		b, err := c.r.ReadByte()
+		if err != nil {
+			return fmt.Errorf("%w: %s", errProxyHeader, err)
+		}
+
+		line = append(line, b)
+	}
+
+	// PROXY TCP4 <src> <dst> <src port> <dst port>
+	fields := strings.Split(strings.TrimSuffix(string(line), "\r\n"), " ")
+	if len(fields) < 2 {
+		return fmt.Errorf("%w: %q", errProxyHeader, line)
+	}
+
+	switch fields[1] {
+	case "UNKNOWN":
+		return nil
+	case "TCP4", "TCP6":
+		// expected
+	default:
+		return fmt.Errorf("%w: %q", errProxyHeader, line)
+	}
+
+	if len(fields) != 6 {
+		return fmt.Errorf("%w: %q", errProxyHeader, line)
+	}
+
+	remote, err := parseProxyV1Addr(fields[2], fields[4])
+	if err != nil {
+		return fmt.Errorf("%w: %q", errProxyHeader, line)
+	}
+
+	local, err := parseProxyV1Addr(fields[3], fields[5])
+	if err != nil {
+		return fmt.Errorf("%w: %q", errProxyHeader, line)
+	}
+
+	c.remote, c.local = remote, local
+
+	return nil
+}
+
+// parseProxyV1Addr parses IP address and port of PROXY protocol v1 header.
+func parseProxyV1Addr(ip, port string) (*net.TCPAddr, error) {
+	res := &net.TCPAddr{
+		IP: net.ParseIP(ip),
+	}
+	if res.IP == nil {
+		return nil, fmt.Errorf("invalid IP address %q", ip)
+	}
+
+	p, err := strconv.ParseUint(port, 10, 16)
+	if err != nil {
+		return nil, err
+	}
+
+	res.Port = int(p)
+
+	return res, nil
+}
+
+// readV2 reads PROXY protocol v2 (binary) header.
+func (c *proxyConn) readV2() error {
+	header := make([]byte, proxyV2HeaderLength)
+	if _, err := io.ReadFull(c.r, header); err != nil {
+		return fmt.Errorf("%w: %s", errProxyHeader, err)
+	}
+
+	cmd, fam := header[12], header[13]
+	body := make([]byte, binary.BigEndian.Uint16(header[14:]))
+
+	if _, err := io.ReadFull(c.r, body); err != nil {
+		return fmt.Errorf("%w: %s", errProxyHeader, err)
+	}
+
+	switch cmd {
+	case proxyV2CmdLocal:
+		return nil
+	case proxyV2CmdProxy:
+		// expected
+	default:
+		return fmt.Errorf("%w: v2 command %#x", errProxyHeader, cmd)
+	}
+
+	var ipLen int
+	switch fam {
+	case proxyV2FamTCP4:
+		ipLen = net.IPv4len
+	case proxyV2FamTCP6:
+		ipLen = net.IPv6len
+	default:
+		// UDP, Unix sockets and unspecified families; TLVs are ignored
+		return nil
+	}
+
+	if len(body) < 2*ipLen+4 {
+		return fmt.Errorf("%w: v2 addresses are too short", errProxyHeader)
+	}
+
+	c.remote = &net.TCPAddr{
+		IP:   net.IP(body[:ipLen]),
+		Port: int(binary.BigEndian.Uint16(body[2*ipLen:])),
+	}
+	c.local = &net.TCPAddr{
+		IP:   net.IP(body[ipLen : 2*ipLen]),
+		Port: int(binary.BigEndian.Uint16(body[2*ipLen+2:])),
+	}
+
+	return nil
+}
+
+// check interfaces
+var (
+	_ net.Conn = (*proxyConn)(nil)
+)
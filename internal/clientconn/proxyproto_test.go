@@ -0,0 +1,145 @@
+// Copyright 2021 FerretDB Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package clientconn
+
+import (
+	"encoding/binary"
+	"io"
+	"net"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// proxyV2Header returns PROXY protocol v2 header with the given command, address family and addresses.
+func proxyV2Header(cmd, fam byte, addrs []byte) []byte {
+	res := append([]byte{}, proxyV2Signature...)
+	res = append(res, cmd, fam, 0, 0)
+	binary.BigEndian.PutUint16(res[14:], uint16(len(addrs)))
+	return append(res, addrs...)
+}
+
+func TestReadProxyHeader(t *testing.T) {
+	t.Parallel()
+
+	v2TCP4 := []byte{
+		192, 0, 2, 1, // source
+		198, 51, 100, 1, // destination
+		0x30, 0x39, // source port 12345
+		0x69, 0x89, // destination port 27017
+	}
+
+	for name, tc := range map[string]struct {
+		header []byte
+		remote string // empty if the connection's own address is kept
+		local  string
+		err    bool
+	}{
+		"V1TCP4": {
+			header: []byte("PROXY TCP4 192.0.2.1 198.51.100.1 12345 27017\r\n"),
+			remote: "192.0.2.1:12345",
+			local:  "198.51.100.1:27017",
+		},
+		"V1TCP6": {
+			header: []byte("PROXY TCP6 2001:db8::1 2001:db8::2 12345 27017\r\n"),
+			remote: "[2001:db8::1]:12345",
+			local:  "[2001:db8::2]:27017",
+		},
+		"V1Unknown": {
+			header: []byte("PROXY UNKNOWN ffff::1 ffff::2 1 2\r\n"),
+		},
+		"V1InvalidIP": {
+			header: []byte("PROXY TCP4 192.0.2 198.51.100.1 12345 27017\r\n"),
+			err:    true,
+		},
+		"V1InvalidPort": {
+			header: []byte("PROXY TCP4 192.0.2.1 198.51.100.1 123456 27017\r\n"),
+			err:    true,
+		},
+		"V1MissingFields": {
+			header: []byte("PROXY TCP4 192.0.2.1 198.51.100.1\r\n"),
+			err:    true,
+		},
+		"V1TooLong": {
+			header: []byte("PROXY TCP4 " + string(make([]byte, 200))),
+			err:    true,
+		},
+		"V2TCP4": {
+			header: proxyV2Header(proxyV2CmdProxy, proxyV2FamTCP4, v2TCP4),
+			remote: "192.0.2.1:12345",
+			local:  "198.51.100.1:27017",
+		},
+		"V2TCP4WithTLV": {
+			header: proxyV2Header(proxyV2CmdProxy, proxyV2FamTCP4, append(v2TCP4, 0x04, 0, 1, 42)),
+			remote: "192.0.2.1:12345",
+			local:  "198.51.100.1:27017",
+		},
+		"V2Local": {
+			header: proxyV2Header(proxyV2CmdLocal, 0, nil),
+		},
+		"V2Unix": {
+			header: proxyV2Header(proxyV2CmdProxy, 0x31, make([]byte, 216)),
+		},
+		"V2ShortAddresses": {
+			header: proxyV2Header(proxyV2CmdProxy, proxyV2FamTCP4, v2TCP4[:8]),
+			err:    true,
+		},
+		"V2InvalidCommand": {
+			header: proxyV2Header(0x22, proxyV2FamTCP4, v2TCP4),
+			err:    true,
+		},
+		"NoHeader": {
+			header: make([]byte, 16),
+			err:    true,
+		},
+	} {
+		name, tc := name, tc
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			server, client := net.Pipe()
+			t.Cleanup(func() {
+				server.Close()
+				client.Close()
+			})
+
+			// data after the header should be readable
+			go func() {
+				_, _ = client.Write(append(tc.header, "data"...))
+				client.Close()
+			}()
+
+			conn, err := readProxyHeader(server)
+			if tc.err {
+				require.ErrorIs(t, err, errProxyHeader)
+				return
+			}
+			require.NoError(t, err)
+
+			remote, local := server.RemoteAddr().String(), server.LocalAddr().String()
+			if tc.remote != "" {
+				remote, local = tc.remote, tc.local
+			}
+
+			assert.Equal(t, remote, conn.RemoteAddr().String())
+			assert.Equal(t, local, conn.LocalAddr().String())
+
+			data, err := io.ReadAll(conn)
+			require.NoError(t, err)
+			assert.Equal(t, "data", string(data))
+		})
+	}
+}
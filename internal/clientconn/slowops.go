@@ -0,0 +1,116 @@
+// Copyright 2021 FerretDB Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package clientconn
+
+import (
+	"encoding/json"
+	"math/rand"
+	"sync/atomic"
+	"time"
+
+	"go.uber.org/zap"
+
+	"github.com/FerretDB/FerretDB/internal/extjson"
+	"github.com/FerretDB/FerretDB/internal/handlers/common"
+	"github.com/FerretDB/FerretDB/internal/util/must"
+	"github.com/FerretDB/FerretDB/internal/wire"
+)
+
+// DefaultSlowOpThreshold is the default value of SlowOpsOpts.Threshold; it matches MongoDB's slowms.
+const DefaultSlowOpThreshold = 100 * time.Millisecond
+
+// SlowOpsOpts represents slow operations logging configuration.
+type SlowOpsOpts struct {
+	// Operations running at least that long are logged; zero means all operations.
+	Threshold time.Duration
+
+	// Fraction of slow operations to log, from 0 to 1; zero means all of them.
+	SampleRate float64
+}
+
+// slowOpsConfig holds slow operations logging configuration that could be changed at runtime.
+type slowOpsConfig struct {
+	v atomic.Value // *SlowOpsOpts
+}
+
+// newSlowOpsConfig returns a new configuration; nil opts disable logging.
+func newSlowOpsConfig(opts *SlowOpsOpts) *slowOpsConfig {
+	var c slowOpsConfig
+	c.set(opts)
+
+	return &c
+}
+
+// get returns the current configuration or nil if logging is disabled.
+//
+// Nil configuration always disables logging.
+func (c *slowOpsConfig) get() *SlowOpsOpts {
+	if c == nil {
+		return nil
+	}
+
+	return c.v.Load().(*SlowOpsOpts)
+}
+
+// set replaces the current configuration; nil opts disable logging.
+func (c *slowOpsConfig) set(opts *SlowOpsOpts) {
+	c.v.Store(opts)
+}
+
+// logSlowOp logs the operation if it took at least the threshold duration,
+// like MongoDB's "Slow query" log message.
+//
+// Document values of the command are redacted according to the current redact mode (see wire.Redact).
+func (c *conn) logSlowOp(opts *SlowOpsOpts, msg *wire.OpMsg, stats *common.OpStats, d time.Duration, err error) {
+	if d < opts.Threshold {
+		return
+	}
+
+	if rate := opts.SampleRate; rate > 0 && rate < 1 && rand.Float64() >= rate {
+		return
+	}
+
+	document, e := wire.Redact(msg).(*wire.OpMsg).Document()
+	if e != nil {
+		return
+	}
+
+	db, _ := common.GetOptionalParam(document, "$db", "")
+	ns := db + ".$cmd"
+	if collection, ok := document.Map()[document.Command()].(string); ok && collection != "" {
+		ns = db + "." + collection
+	}
+
+	fields := []zap.Field{
+		zap.String("type", "command"),
+		zap.String("ns", ns),
+		zap.Reflect("command", json.RawMessage(must.NotFail(extjson.MarshalLog(document)))),
+	}
+
+	if planSummary := stats.PlanSummary(); planSummary != "" {
+		fields = append(fields, zap.String("planSummary", planSummary))
+	}
+
+	fields = append(fields, zap.Int64("docsExamined", stats.DocsExamined()))
+
+	if err != nil {
+		protoErr, _ := common.ProtocolError(err)
+		fields = append(fields, zap.Int32("errCode", int32(protoErr.Code())), zap.Stringer("errName", protoErr.Code()))
+	}
+
+	fields = append(fields, zap.Int64("durationMillis", d.Milliseconds()))
+
+	c.l.Desugar().Warn("Slow query", fields...)
+}
@@ -0,0 +1,91 @@
+// Copyright 2021 FerretDB Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package clientconn
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zaptest/observer"
+
+	"github.com/FerretDB/FerretDB/internal/handlers/common"
+	"github.com/FerretDB/FerretDB/internal/types"
+	"github.com/FerretDB/FerretDB/internal/util/must"
+	"github.com/FerretDB/FerretDB/internal/wire"
+)
+
+func TestLogSlowOp(t *testing.T) {
+	t.Parallel()
+
+	var msg wire.OpMsg
+	require.NoError(t, msg.SetSections(wire.OpMsgSection{
+		Documents: []*types.Document{must.NotFail(types.NewDocument(
+			"find", "values",
+			"filter", must.NotFail(types.NewDocument("v", "foo")),
+			"$db", "test",
+		))},
+	}))
+
+	filter := must.NotFail(types.NewDocument("v", "foo"))
+
+	_, stats := common.WithOpStats(context.Background())
+	stats.AddQuery(filter, must.NotFail(types.NewDocument()))
+	stats.AddDocsExamined(2)
+
+	core, logs := observer.New(zap.DebugLevel)
+	c := &conn{
+		l: zap.New(core).Sugar(),
+	}
+	opts := &SlowOpsOpts{Threshold: 100 * time.Millisecond}
+
+	c.logSlowOp(opts, &msg, stats, 99*time.Millisecond, nil)
+	assert.Equal(t, 0, logs.Len())
+
+	c.logSlowOp(opts, &msg, stats, 150*time.Millisecond, common.NewErrorMsg(common.ErrBadValue, "error"))
+	require.Equal(t, 1, logs.Len())
+
+	entry := logs.All()[0]
+	assert.Equal(t, zap.WarnLevel, entry.Level)
+	assert.Equal(t, "Slow query", entry.Message)
+
+	fields := entry.ContextMap()
+	assert.Equal(t, "test.values", fields["ns"])
+	assert.Equal(t, "PUSHDOWN", fields["planSummary"])
+	assert.Equal(t, int64(2), fields["docsExamined"])
+	assert.Equal(t, int32(common.ErrBadValue), fields["errCode"])
+	assert.Equal(t, int64(150), fields["durationMillis"])
+	assert.Contains(t, fields, "command")
+}
+
+func TestSlowOpsConfig(t *testing.T) {
+	t.Parallel()
+
+	var nilConfig *slowOpsConfig
+	assert.Nil(t, nilConfig.get())
+
+	c := newSlowOpsConfig(nil)
+	assert.Nil(t, c.get())
+
+	opts := &SlowOpsOpts{Threshold: time.Second}
+	c.set(opts)
+	assert.Same(t, opts, c.get())
+
+	c.set(nil)
+	assert.Nil(t, c.get())
+}
@@ -0,0 +1,141 @@
+// Copyright 2021 FerretDB Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package clientconn
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"os"
+	"sync/atomic"
+)
+
+// TLSOpts represents TLS configuration of the listener.
+type TLSOpts struct {
+	// Server certificate and key files in PEM format.
+	// Several certificates can be provided; the one matching the client's SNI is used,
+	// the first one is used if there is no match.
+	CertFiles []string
+	KeyFiles  []string
+
+	// If set, client certificates are verified against CAs from that file.
+	CAFile string
+
+	// If set, clients have to present a valid certificate; requires CAFile.
+	RequireClientCert bool
+}
+
+// NewTLSConfig returns TLS configuration for the listener.
+func NewTLSConfig(opts *TLSOpts) (*tls.Config, error) {
+	if len(opts.CertFiles) == 0 {
+		return nil, fmt.Errorf("clientconn.NewTLSConfig: certificate file is required")
+	}
+
+	if len(opts.CertFiles) != len(opts.KeyFiles) {
+		return nil, fmt.Errorf(
+			"clientconn.NewTLSConfig: got %d certificate files and %d key files",
+			len(opts.CertFiles), len(opts.KeyFiles),
+		)
+	}
+
+	config := &tls.Config{
+		MinVersion: tls.VersionTLS12,
+	}
+
+	for i, certFile := range opts.CertFiles {
+		cert, err := tls.LoadX509KeyPair(certFile, opts.KeyFiles[i])
+		if err != nil {
+			return nil, fmt.Errorf("clientconn.NewTLSConfig: %w", err)
+		}
+
+		config.Certificates = append(config.Certificates, cert)
+	}
+
+	if opts.CAFile == "" {
+		if opts.RequireClientCert {
+			return nil, fmt.Errorf("clientconn.NewTLSConfig: CA file is required to verify client certificates")
+		}
+
+		return config, nil
+	}
+
+	b, err := os.ReadFile(opts.CAFile)
+	if err != nil {
+		return nil, fmt.Errorf("clientconn.NewTLSConfig: %w", err)
+	}
+
+	config.ClientCAs = x509.NewCertPool()
+	if !config.ClientCAs.AppendCertsFromPEM(b) {
+		return nil, fmt.Errorf("clientconn.NewTLSConfig: no certificates found in %s", opts.CAFile)
+	}
+
+	config.ClientAuth = tls.VerifyClientCertIfGiven
+	if opts.RequireClientCert {
+		config.ClientAuth = tls.RequireAndVerifyClientCert
+	}
+
+	return config, nil
+}
+
+// ReloadableTLSConfig represents TLS configuration of the listener that could be reloaded from files at runtime,
+// for example, after certificates rotation.
+//
+// Established connections are not affected by reloading.
+type ReloadableTLSConfig struct {
+	opts    *TLSOpts
+	current atomic.Value // *tls.Config
+	config  *tls.Config
+}
+
+// NewReloadableTLSConfig loads TLS configuration and returns a new ReloadableTLSConfig.
+func NewReloadableTLSConfig(opts *TLSOpts) (*ReloadableTLSConfig, error) {
+	r := &ReloadableTLSConfig{
+		opts: opts,
+	}
+
+	if err := r.Reload(); err != nil {
+		return nil, err
+	}
+
+	r.config = &tls.Config{
+		MinVersion: tls.VersionTLS12,
+		GetConfigForClient: func(*tls.ClientHelloInfo) (*tls.Config, error) {
+			return r.current.Load().(*tls.Config), nil
+		},
+	}
+
+	return r, nil
+}
+
+// Config returns TLS configuration for ListenerConfig.TLS.
+//
+// New connections use the last successfully loaded configuration.
+func (r *ReloadableTLSConfig) Config() *tls.Config {
+	return r.config
+}
+
+// Reload loads TLS configuration from files again.
+//
+// If that fails, the previous configuration is kept.
+func (r *ReloadableTLSConfig) Reload() error {
+	config, err := NewTLSConfig(r.opts)
+	if err != nil {
+		return err
+	}
+
+	r.current.Store(config)
+
+	return nil
+}
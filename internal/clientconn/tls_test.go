@@ -0,0 +1,197 @@
+// Copyright 2021 FerretDB Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package clientconn
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap/zaptest"
+
+	"github.com/FerretDB/FerretDB/internal/handlers/dummy"
+	"github.com/FerretDB/FerretDB/internal/util/testutil"
+)
+
+func TestTLS(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	ca := testutil.GenerateCert(t, dir, "ca", nil)
+	server1 := testutil.GenerateCert(t, dir, "server1", ca, "one.example.com")
+	server2 := testutil.GenerateCert(t, dir, "server2", ca, "two.example.com")
+	client := testutil.GenerateCert(t, dir, "client", ca)
+
+	t.Run("Errors", func(t *testing.T) {
+		t.Parallel()
+
+		_, err := NewTLSConfig(&TLSOpts{})
+		assert.Error(t, err)
+
+		_, err = NewTLSConfig(&TLSOpts{
+			CertFiles: []string{server1.CertFile, server2.CertFile},
+			KeyFiles:  []string{server1.KeyFile},
+		})
+		assert.Error(t, err)
+
+		_, err = NewTLSConfig(&TLSOpts{
+			CertFiles:         []string{server1.CertFile},
+			KeyFiles:          []string{server1.KeyFile},
+			RequireClientCert: true,
+		})
+		assert.Error(t, err)
+	})
+
+	t.Run("Listener", func(t *testing.T) {
+		t.Parallel()
+
+		config, err := NewTLSConfig(&TLSOpts{
+			CertFiles:         []string{server1.CertFile, server2.CertFile},
+			KeyFiles:          []string{server1.KeyFile, server2.KeyFile},
+			CAFile:            ca.CertFile,
+			RequireClientCert: true,
+		})
+		require.NoError(t, err)
+
+		h, err := dummy.New()
+		require.NoError(t, err)
+
+		l := NewListener(&NewListenerOpts{
+			ListenAddr: "127.0.0.1:0",
+			Mode:       NormalMode,
+			Handler:    h,
+			Logger:     zaptest.NewLogger(t),
+			TLS:        config,
+		})
+
+		ctx, cancel := context.WithCancel(context.Background())
+		done := make(chan struct{})
+		go func() {
+			defer close(done)
+			_ = l.Run(ctx)
+		}()
+		t.Cleanup(func() {
+			cancel()
+			<-done
+		})
+
+		roots := x509.NewCertPool()
+		roots.AddCert(ca.Cert)
+
+		clientCert, err := tls.LoadX509KeyPair(client.CertFile, client.KeyFile)
+		require.NoError(t, err)
+
+		for serverName, expected := range map[string]*testutil.Cert{
+			"one.example.com": server1,
+			"two.example.com": server2,
+		} {
+			conn, err := tls.Dial("tcp", l.Addr().String(), &tls.Config{
+				ServerName:   serverName,
+				RootCAs:      roots,
+				Certificates: []tls.Certificate{clientCert},
+				MinVersion:   tls.VersionTLS12,
+			})
+			require.NoError(t, err)
+			require.NoError(t, conn.Handshake())
+
+			assert.Equal(t, expected.Cert.Raw, conn.ConnectionState().PeerCertificates[0].Raw)
+			require.NoError(t, conn.Close())
+		}
+
+		// no client certificate
+		conn, err := tls.Dial("tcp", l.Addr().String(), &tls.Config{
+			ServerName: "one.example.com",
+			RootCAs:    roots,
+			MinVersion: tls.VersionTLS12,
+		})
+		if err == nil {
+			// with TLS 1.3, client certificate is verified after the client's handshake is complete
+			_, err = conn.Read(make([]byte, 1))
+			conn.Close()
+		}
+		assert.Error(t, err)
+	})
+}
+
+func TestReloadableTLSConfig(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	ca := testutil.GenerateCert(t, dir, "ca", nil)
+	server := testutil.GenerateCert(t, dir, "server", ca, "one.example.com")
+
+	opts := &TLSOpts{
+		CertFiles: []string{server.CertFile},
+		KeyFiles:  []string{server.KeyFile},
+	}
+	r, err := NewReloadableTLSConfig(opts)
+	require.NoError(t, err)
+
+	h, err := dummy.New()
+	require.NoError(t, err)
+
+	l := NewListener(&NewListenerOpts{
+		ListenAddr: "127.0.0.1:0",
+		Mode:       NormalMode,
+		Handler:    h,
+		Logger:     zaptest.NewLogger(t),
+		TLS:        r.Config(),
+	})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		_ = l.Run(ctx)
+	}()
+	t.Cleanup(func() {
+		cancel()
+		<-done
+	})
+
+	roots := x509.NewCertPool()
+	roots.AddCert(ca.Cert)
+
+	peerCert := func() []byte {
+		conn, err := tls.Dial("tcp", l.Addr().String(), &tls.Config{
+			ServerName: "one.example.com",
+			RootCAs:    roots,
+			MinVersion: tls.VersionTLS12,
+		})
+		require.NoError(t, err)
+
+		defer conn.Close()
+
+		require.NoError(t, conn.Handshake())
+
+		return conn.ConnectionState().PeerCertificates[0].Raw
+	}
+
+	assert.Equal(t, server.Cert.Raw, peerCert())
+
+	// rotate certificate
+	rotated := testutil.GenerateCert(t, dir, "server", ca, "one.example.com")
+	require.NoError(t, r.Reload())
+	assert.Equal(t, rotated.Cert.Raw, peerCert())
+
+	// broken files keep the previous configuration
+	require.NoError(t, os.WriteFile(server.KeyFile, []byte("invalid"), 0o600))
+	assert.Error(t, r.Reload())
+	assert.Equal(t, rotated.Cert.Raw, peerCert())
+}
@@ -0,0 +1,540 @@
+// Copyright 2021 FerretDB Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package extjson
+
+import (
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"io"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/FerretDB/FerretDB/internal/types"
+	"github.com/FerretDB/FerretDB/internal/util/lazyerrors"
+)
+
+// object is a JSON object with preserved keys order.
+type object struct {
+	keys   []string
+	values map[string]any
+}
+
+// has returns true if object has exactly the given keys.
+func (o *object) has(keys ...string) bool {
+	if len(o.keys) != len(keys) {
+		return false
+	}
+
+	for _, k := range keys {
+		if _, ok := o.values[k]; !ok {
+			return false
+		}
+	}
+
+	return true
+}
+
+// decode reads a single JSON value from r and converts it.
+func decode(r io.Reader) (any, error) {
+	dec := json.NewDecoder(r)
+	dec.UseNumber()
+
+	v, err := readValue(dec)
+	if err != nil {
+		return nil, lazyerrors.Error(err)
+	}
+
+	if _, err = dec.Token(); err != io.EOF {
+		return nil, lazyerrors.New("extjson.decode: unexpected data after the value")
+	}
+
+	return convert(v)
+}
+
+// readValue reads JSON value from the decoder,
+// returning *object, []any, string, json.Number, bool, or nil.
+func readValue(dec *json.Decoder) (any, error) {
+	t, err := dec.Token()
+	if err != nil {
+		return nil, lazyerrors.Error(err)
+	}
+
+	switch t := t.(type) {
+	case json.Delim:
+		switch t {
+		case '{':
+			o := &object{values: map[string]any{}}
+			for dec.More() {
+				kt, err := dec.Token()
+				if err != nil {
+					return nil, lazyerrors.Error(err)
+				}
+
+				k := kt.(string)
+				if _, ok := o.values[k]; ok {
+					return nil, lazyerrors.Errorf("extjson.readValue: duplicate key %q", k)
+				}
+
+				v, err := readValue(dec)
+				if err != nil {
+					return nil, lazyerrors.Error(err)
+				}
+
+				o.keys = append(o.keys, k)
+				o.values[k] = v
+			}
+
+			if _, err = dec.Token(); err != nil {
+				return nil, lazyerrors.Error(err)
+			}
+
+			return o, nil
+
+		case '[':
+			a := []any{}
+			for dec.More() {
+				v, err := readValue(dec)
+				if err != nil {
+					return nil, lazyerrors.Error(err)
+				}
+
+				a = append(a, v)
+			}
+
+			if _, err = dec.Token(); err != nil {
+				return nil, lazyerrors.Error(err)
+			}
+
+			return a, nil
+
+		default:
+			return nil, lazyerrors.Errorf("extjson.readValue: unexpected delimiter %s", t)
+		}
+
+	default:
+		return t, nil
+	}
+}
+
+// convert converts value returned by readValue to built-in or types' package value.
+func convert(v any) (any, error) {
+	switch v := v.(type) {
+	case *object:
+		return convertObject(v)
+
+	case []any:
+		arr := types.MakeArray(len(v))
+		for _, e := range v {
+			e, err := convert(e)
+			if err != nil {
+				return nil, lazyerrors.Error(err)
+			}
+
+			if err = arr.Append(e); err != nil {
+				return nil, lazyerrors.Error(err)
+			}
+		}
+
+		return arr, nil
+
+	case string:
+		return v, nil
+
+	case json.Number:
+		return convertNumber(v)
+
+	case bool:
+		return v, nil
+
+	case nil:
+		return types.Null, nil
+
+	default:
+		return nil, lazyerrors.Errorf("extjson.convert: unexpected type %T", v)
+	}
+}
+
+// convertNumber converts JSON number to int32 or int64 if it is an integer that fits, or to float64.
+func convertNumber(n json.Number) (any, error) {
+	s := string(n)
+
+	if !strings.ContainsAny(s, ".eE") {
+		if i, err := strconv.ParseInt(s, 10, 32); err == nil {
+			return int32(i), nil
+		}
+
+		if i, err := strconv.ParseInt(s, 10, 64); err == nil {
+			return i, nil
+		}
+	}
+
+	f, err := strconv.ParseFloat(s, 64)
+	if err != nil {
+		return nil, lazyerrors.Error(err)
+	}
+
+	return f, nil
+}
+
+// convertObject converts JSON object to a document or to a scalar value for type wrappers like {"$oid": "..."}.
+//
+// Objects with unknown keys starting with $ (like query operators) are converted to documents.
+func convertObject(o *object) (any, error) {
+	if len(o.keys) == 0 {
+		return types.NewDocument()
+	}
+
+	switch k := o.keys[0]; k {
+	case "$numberDouble":
+		if !o.has(k) {
+			return nil, errMalformed(k)
+		}
+
+		s, ok := o.values[k].(string)
+		if !ok {
+			return nil, errMalformed(k)
+		}
+
+		f, err := strconv.ParseFloat(s, 64)
+		if err != nil {
+			return nil, lazyerrors.Error(err)
+		}
+
+		return f, nil
+
+	case "$binary":
+		if !o.has(k) {
+			return nil, errMalformed(k)
+		}
+
+		b, ok := o.values[k].(*object)
+		if !ok || !b.has("base64", "subType") {
+			return nil, errMalformed(k)
+		}
+
+		data, ok := b.values["base64"].(string)
+		if !ok {
+			return nil, errMalformed(k)
+		}
+
+		subtype, ok := b.values["subType"].(string)
+		if !ok || len(subtype) == 0 || len(subtype) > 2 {
+			return nil, errMalformed(k)
+		}
+
+		st, err := strconv.ParseUint(subtype, 16, 8)
+		if err != nil {
+			return nil, lazyerrors.Error(err)
+		}
+
+		res, err := base64.StdEncoding.DecodeString(data)
+		if err != nil {
+			return nil, lazyerrors.Error(err)
+		}
+
+		return types.Binary{Subtype: types.BinarySubtype(st), B: res}, nil
+
+	case "$uuid":
+		if !o.has(k) {
+			return nil, errMalformed(k)
+		}
+
+		s, ok := o.values[k].(string)
+		if !ok {
+			return nil, errMalformed(k)
+		}
+
+		return types.ParseUUID(s)
+
+	case "$undefined":
+		if !o.has(k) || o.values[k] != true {
+			return nil, errMalformed(k)
+		}
+
+		return types.Undefined, nil
+
+	case "$oid":
+		if !o.has(k) {
+			return nil, errMalformed(k)
+		}
+
+		return parseObjectID(o.values[k])
+
+	case "$date":
+		if !o.has(k) {
+			return nil, errMalformed(k)
+		}
+
+		return parseDate(o.values[k])
+
+	case "$regularExpression":
+		if !o.has(k) {
+			return nil, errMalformed(k)
+		}
+
+		re, ok := o.values[k].(*object)
+		if !ok || !re.has("pattern", "options") {
+			return nil, errMalformed(k)
+		}
+
+		pattern, ok := re.values["pattern"].(string)
+		if !ok {
+			return nil, errMalformed(k)
+		}
+
+		options, ok := re.values["options"].(string)
+		if !ok {
+			return nil, errMalformed(k)
+		}
+
+		return types.Regex{Pattern: pattern, Options: options}, nil
+
+	case "$dbPointer":
+		if !o.has(k) {
+			return nil, errMalformed(k)
+		}
+
+		p, ok := o.values[k].(*object)
+		if !ok || !p.has("$ref", "$id") {
+			return nil, errMalformed(k)
+		}
+
+		ns, ok := p.values["$ref"].(string)
+		if !ok {
+			return nil, errMalformed(k)
+		}
+
+		id, ok := p.values["$id"].(*object)
+		if !ok || !id.has("$oid") {
+			return nil, errMalformed(k)
+		}
+
+		oid, err := parseObjectID(id.values["$oid"])
+		if err != nil {
+			return nil, lazyerrors.Error(err)
+		}
+
+		return types.DBPointer{Namespace: ns, ID: oid}, nil
+
+	case "$code", "$scope":
+		code, ok := o.values["$code"].(string)
+		if !ok {
+			return nil, errMalformed("$code")
+		}
+
+		if o.has("$code") {
+			return types.JavaScript{Code: code}, nil
+		}
+
+		if !o.has("$code", "$scope") {
+			return nil, errMalformed("$code")
+		}
+
+		scope, ok := o.values["$scope"].(*object)
+		if !ok {
+			return nil, errMalformed("$scope")
+		}
+
+		doc, err := convertDocument(scope)
+		if err != nil {
+			return nil, lazyerrors.Error(err)
+		}
+
+		return types.JavaScriptWithScope{Code: code, Scope: doc}, nil
+
+	case "$symbol":
+		if !o.has(k) {
+			return nil, errMalformed(k)
+		}
+
+		s, ok := o.values[k].(string)
+		if !ok {
+			return nil, errMalformed(k)
+		}
+
+		return types.Symbol(s), nil
+
+	case "$numberInt":
+		if !o.has(k) {
+			return nil, errMalformed(k)
+		}
+
+		s, ok := o.values[k].(string)
+		if !ok {
+			return nil, errMalformed(k)
+		}
+
+		i, err := strconv.ParseInt(s, 10, 32)
+		if err != nil {
+			return nil, lazyerrors.Error(err)
+		}
+
+		return int32(i), nil
+
+	case "$timestamp":
+		if !o.has(k) {
+			return nil, errMalformed(k)
+		}
+
+		ts, ok := o.values[k].(*object)
+		if !ok || !ts.has("t", "i") {
+			return nil, errMalformed(k)
+		}
+
+		var parts [2]uint64
+		for i, f := range []string{"t", "i"} {
+			n, ok := ts.values[f].(json.Number)
+			if !ok {
+				return nil, errMalformed(k)
+			}
+
+			var err error
+			if parts[i], err = strconv.ParseUint(string(n), 10, 32); err != nil {
+				return nil, lazyerrors.Error(err)
+			}
+		}
+
+		return types.Timestamp(parts[0]<<32 | parts[1]), nil
+
+	case "$numberLong":
+		if !o.has(k) {
+			return nil, errMalformed(k)
+		}
+
+		return parseLong(o.values[k])
+
+	case "$numberDecimal":
+		if !o.has(k) {
+			return nil, errMalformed(k)
+		}
+
+		s, ok := o.values[k].(string)
+		if !ok {
+			return nil, errMalformed(k)
+		}
+
+		return types.ParseDecimal128(s)
+
+	case "$minKey", "$maxKey":
+		return nil, lazyerrors.Errorf("extjson.convertObject: %s is not supported", k)
+	}
+
+	return convertDocument(o)
+}
+
+// convertDocument converts JSON object to a document.
+func convertDocument(o *object) (*types.Document, error) {
+	pairs := make([]any, 0, len(o.keys)*2)
+	for _, k := range o.keys {
+		v, err := convert(o.values[k])
+		if err != nil {
+			return nil, lazyerrors.Error(err)
+		}
+
+		pairs = append(pairs, k, v)
+	}
+
+	doc, err := types.NewDocument(pairs...)
+	if err != nil {
+		return nil, lazyerrors.Error(err)
+	}
+
+	return doc, nil
+}
+
+// parseObjectID parses ObjectID from the hex string.
+func parseObjectID(v any) (types.ObjectID, error) {
+	var res types.ObjectID
+
+	s, ok := v.(string)
+	if !ok {
+		return res, errMalformed("$oid")
+	}
+
+	b, err := hex.DecodeString(s)
+	if err != nil {
+		return res, lazyerrors.Error(err)
+	}
+
+	if len(b) != types.ObjectIDLen {
+		return res, errMalformed("$oid")
+	}
+
+	copy(res[:], b)
+
+	return res, nil
+}
+
+// parseLong parses int64 from the string.
+func parseLong(v any) (int64, error) {
+	s, ok := v.(string)
+	if !ok {
+		return 0, errMalformed("$numberLong")
+	}
+
+	i, err := strconv.ParseInt(s, 10, 64)
+	if err != nil {
+		return 0, lazyerrors.Error(err)
+	}
+
+	return i, nil
+}
+
+// parseDate parses $date value in canonical, relaxed, or legacy (milliseconds as JSON number) forms.
+func parseDate(v any) (time.Time, error) {
+	var ms int64
+
+	switch v := v.(type) {
+	case *object:
+		if !v.has("$numberLong") {
+			return time.Time{}, errMalformed("$date")
+		}
+
+		var err error
+		if ms, err = parseLong(v.values["$numberLong"]); err != nil {
+			return time.Time{}, lazyerrors.Error(err)
+		}
+
+	case string:
+		t, err := time.Parse(time.RFC3339Nano, v)
+		if err != nil {
+			return time.Time{}, lazyerrors.Error(err)
+		}
+
+		ms = t.UnixMilli()
+
+	case json.Number:
+		f, err := strconv.ParseFloat(string(v), 64)
+		if err != nil || f != math.Trunc(f) {
+			return time.Time{}, errMalformed("$date")
+		}
+
+		ms = int64(f)
+
+	default:
+		return time.Time{}, errMalformed("$date")
+	}
+
+	// TODO Use .UTC(): https://github.com/FerretDB/FerretDB/issues/43
+	return time.UnixMilli(ms), nil
+}
+
+// errMalformed returns an error for the malformed type wrapper with the given key.
+func errMalformed(key string) error {
+	return lazyerrors.Errorf("extjson: malformed %s value", key)
+}
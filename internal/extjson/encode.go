@@ -0,0 +1,204 @@
+// Copyright 2021 FerretDB Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package extjson
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/FerretDB/FerretDB/internal/types"
+	"github.com/FerretDB/FerretDB/internal/util/lazyerrors"
+	"github.com/FerretDB/FerretDB/internal/util/must"
+)
+
+// encoder writes Extended JSON into the buffer.
+type encoder struct {
+	buf  bytes.Buffer
+	mode Mode
+	uuid bool // use {"$uuid": "..."} form for UUIDs
+}
+
+// encode writes the given value.
+func (e *encoder) encode(v any) error {
+	switch v := v.(type) {
+	case *types.Document:
+		e.buf.WriteByte('{')
+		m := v.Map()
+		for i, k := range v.Keys() {
+			if i > 0 {
+				e.buf.WriteByte(',')
+			}
+			e.string(k)
+			e.buf.WriteByte(':')
+			if err := e.encode(m[k]); err != nil {
+				return lazyerrors.Error(err)
+			}
+		}
+		e.buf.WriteByte('}')
+
+	case *types.Array:
+		e.buf.WriteByte('[')
+		for i := 0; i < v.Len(); i++ {
+			if i > 0 {
+				e.buf.WriteByte(',')
+			}
+			if err := e.encode(must.NotFail(v.Get(i))); err != nil {
+				return lazyerrors.Error(err)
+			}
+		}
+		e.buf.WriteByte(']')
+
+	case float64:
+		if e.mode == Relaxed && !math.IsNaN(v) && !math.IsInf(v, 0) {
+			e.buf.WriteString(formatDouble(v))
+			break
+		}
+		e.buf.WriteString(`{"$numberDouble":`)
+		e.string(formatDouble(v))
+		e.buf.WriteByte('}')
+
+	case string:
+		e.string(v)
+
+	case types.Binary:
+		if e.uuid && v.IsUUID() {
+			e.buf.WriteString(`{"$uuid":"` + v.UUIDString() + `"}`)
+			break
+		}
+		e.buf.WriteString(`{"$binary":{"base64":"` + base64.StdEncoding.EncodeToString(v.B) + `",`)
+		e.buf.WriteString(fmt.Sprintf(`"subType":"%02x"}}`, byte(v.Subtype)))
+
+	case types.UndefinedType:
+		e.buf.WriteString(`{"$undefined":true}`)
+
+	case types.ObjectID:
+		e.buf.WriteString(`{"$oid":"` + hex.EncodeToString(v[:]) + `"}`)
+
+	case bool:
+		e.buf.WriteString(strconv.FormatBool(v))
+
+	case time.Time:
+		if y := v.UTC().Year(); e.mode == Relaxed && y >= 1970 && y <= 9999 {
+			e.buf.WriteString(`{"$date":"` + v.UTC().Format(dateFormat) + `"}`)
+			break
+		}
+		e.buf.WriteString(`{"$date":{"$numberLong":"` + strconv.FormatInt(v.UnixMilli(), 10) + `"}}`)
+
+	case types.NullType:
+		e.buf.WriteString("null")
+
+	case types.Regex:
+		e.buf.WriteString(`{"$regularExpression":{"pattern":`)
+		e.string(v.Pattern)
+		e.buf.WriteString(`,"options":`)
+		e.string(v.Options)
+		e.buf.WriteString(`}}`)
+
+	case types.DBPointer:
+		e.buf.WriteString(`{"$dbPointer":{"$ref":`)
+		e.string(v.Namespace)
+		e.buf.WriteString(`,"$id":{"$oid":"` + hex.EncodeToString(v.ID[:]) + `"}}}`)
+
+	case types.JavaScript:
+		e.buf.WriteString(`{"$code":`)
+		e.string(v.Code)
+		e.buf.WriteByte('}')
+
+	case types.Symbol:
+		e.buf.WriteString(`{"$symbol":`)
+		e.string(string(v))
+		e.buf.WriteByte('}')
+
+	case types.JavaScriptWithScope:
+		e.buf.WriteString(`{"$code":`)
+		e.string(v.Code)
+		e.buf.WriteString(`,"$scope":`)
+		if err := e.encode(v.Scope); err != nil {
+			return lazyerrors.Error(err)
+		}
+		e.buf.WriteByte('}')
+
+	case int32:
+		if e.mode == Relaxed {
+			e.buf.WriteString(strconv.FormatInt(int64(v), 10))
+			break
+		}
+		e.buf.WriteString(`{"$numberInt":"` + strconv.FormatInt(int64(v), 10) + `"}`)
+
+	case types.Timestamp:
+		e.buf.WriteString(fmt.Sprintf(`{"$timestamp":{"t":%d,"i":%d}}`, uint32(v>>32), uint32(v)))
+
+	case int64:
+		if e.mode == Relaxed {
+			e.buf.WriteString(strconv.FormatInt(v, 10))
+			break
+		}
+		e.buf.WriteString(`{"$numberLong":"` + strconv.FormatInt(v, 10) + `"}`)
+
+	case types.Decimal128:
+		e.buf.WriteString(`{"$numberDecimal":"` + v.String() + `"}`)
+
+	default:
+		return lazyerrors.Errorf("extjson.encode: unexpected type %T", v)
+	}
+
+	return nil
+}
+
+// string writes JSON string without HTML escaping.
+func (e *encoder) string(s string) {
+	enc := json.NewEncoder(&e.buf)
+	enc.SetEscapeHTML(false)
+	must.NoError(enc.Encode(s))
+
+	// remove newline added by Encode
+	e.buf.Truncate(e.buf.Len() - 1)
+}
+
+// dateFormat is the ISO-8601 format with millisecond precision used by relaxed dates.
+const dateFormat = "2006-01-02T15:04:05.000Z07:00"
+
+// formatDouble returns the shortest string representation of the given double
+// that is parsed back as a double, not as an integer.
+func formatDouble(f float64) string {
+	switch {
+	case math.IsNaN(f):
+		return "NaN"
+	case math.IsInf(f, 1):
+		return "Infinity"
+	case math.IsInf(f, -1):
+		return "-Infinity"
+	}
+
+	s := strconv.FormatFloat(f, 'G', -1, 64)
+
+	mantissa, exp, hasExp := strings.Cut(s, "E")
+	if !strings.Contains(mantissa, ".") {
+		mantissa += ".0"
+	}
+
+	if hasExp {
+		return mantissa + "E" + exp
+	}
+
+	return mantissa
+}
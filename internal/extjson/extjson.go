@@ -0,0 +1,107 @@
+// Copyright 2021 FerretDB Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package extjson provides converters from/to MongoDB Extended JSON v2 for built-in and `types` types.
+//
+// Both Canonical and Relaxed modes are supported for encoding;
+// decoding accepts both modes (and a few legacy forms like {"$uuid": "..."}) at the same time.
+//
+// See https://github.com/mongodb/specifications/blob/master/source/extended-json.rst.
+//
+// Unlike fjson, this format is not used for storage; it is intended for import/export and for humans.
+//
+// Mapping
+//
+// Composite types
+//  *types.Document           JSON object
+//  *types.Array              JSON array
+//
+// Scalar types                canonical / relaxed
+//  float64                   {"$numberDouble": "<string>"} / JSON number for finite values
+//  string                    JSON string
+//  types.Binary              {"$binary": {"base64": "<string>", "subType": "<hex>"}}
+//  types.UndefinedType       {"$undefined": true}
+//  types.ObjectID            {"$oid": "<ObjectID as 24 character hex string>"}
+//  bool                      JSON true / false values
+//  time.Time                 {"$date": {"$numberLong": "<milliseconds>"}} / {"$date": "<ISO-8601>"} for years 1970-9999
+//  types.NullType            JSON null
+//  types.Regex               {"$regularExpression": {"pattern": "<string>", "options": "<string>"}}
+//  types.DBPointer           {"$dbPointer": {"$ref": "<namespace>", "$id": {"$oid": "<hex>"}}}
+//  types.JavaScript          {"$code": "<string>"}
+//  types.Symbol              {"$symbol": "<string>"}
+//  types.JavaScriptWithScope {"$code": "<string>", "$scope": <document>}
+//  int32                     {"$numberInt": "<string>"} / JSON number
+//  types.Timestamp           {"$timestamp": {"t": <seconds>, "i": <increment>}}
+//  int64                     {"$numberLong": "<string>"} / JSON number
+//  types.Decimal128          {"$numberDecimal": "<string>"}
+package extjson
+
+import (
+	"bytes"
+
+	"github.com/FerretDB/FerretDB/internal/util/lazyerrors"
+)
+
+// Mode represents Extended JSON v2 output mode.
+type Mode int
+
+const (
+	// Canonical mode preserves type information of all values at the expense of readability.
+	Canonical Mode = iota
+
+	// Relaxed mode uses native JSON numbers and ISO-8601 dates where possible.
+	// Some type information (like int32 vs int64) is lost.
+	Relaxed
+)
+
+// Marshal encodes given built-in or types' package value into Extended JSON using the given mode.
+func Marshal(v any, mode Mode) ([]byte, error) {
+	if v == nil {
+		panic("v is nil")
+	}
+
+	e := encoder{mode: mode}
+	if err := e.encode(v); err != nil {
+		return nil, lazyerrors.Error(err)
+	}
+
+	return e.buf.Bytes(), nil
+}
+
+// MarshalLog encodes given built-in or types' package value like Marshal in Relaxed mode,
+// but UUID binary values use legacy {"$uuid": "<canonical UUID>"} form that is easier to read.
+//
+// The result is intended for logging; it still can be decoded by Unmarshal.
+func MarshalLog(v any) ([]byte, error) {
+	if v == nil {
+		panic("v is nil")
+	}
+
+	e := encoder{mode: Relaxed, uuid: true}
+	if err := e.encode(v); err != nil {
+		return nil, lazyerrors.Error(err)
+	}
+
+	return e.buf.Bytes(), nil
+}
+
+// Unmarshal decodes the given Extended JSON data in any mode.
+func Unmarshal(data []byte) (any, error) {
+	v, err := decode(bytes.NewReader(data))
+	if err != nil {
+		return nil, lazyerrors.Error(err)
+	}
+
+	return v, nil
+}
@@ -0,0 +1,318 @@
+// Copyright 2021 FerretDB Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package extjson
+
+import (
+	"math"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/FerretDB/FerretDB/internal/types"
+	"github.com/FerretDB/FerretDB/internal/util/must"
+)
+
+var testCases = []struct {
+	name      string
+	v         any
+	canonical string
+	relaxed   string
+}{{
+	name: "document",
+	v: must.NotFail(types.NewDocument(
+		"foo", "bar",
+		"arr", must.NotFail(types.NewArray(int32(1), "<&>")),
+		"empty", must.NotFail(types.NewDocument()),
+	)),
+	canonical: `{"foo":"bar","arr":[{"$numberInt":"1"},"<&>"],"empty":{}}`,
+	relaxed:   `{"foo":"bar","arr":[1,"<&>"],"empty":{}}`,
+}, {
+	name:      "double",
+	v:         42.0,
+	canonical: `{"$numberDouble":"42.0"}`,
+	relaxed:   `42.0`,
+}, {
+	name:      "doubleExp",
+	v:         1e300,
+	canonical: `{"$numberDouble":"1.0E+300"}`,
+	relaxed:   `1.0E+300`,
+}, {
+	name:      "doubleNegZero",
+	v:         math.Copysign(0, -1),
+	canonical: `{"$numberDouble":"-0.0"}`,
+	relaxed:   `-0.0`,
+}, {
+	name:      "doubleInf",
+	v:         math.Inf(-1),
+	canonical: `{"$numberDouble":"-Infinity"}`,
+	relaxed:   `{"$numberDouble":"-Infinity"}`,
+}, {
+	name:      "binary",
+	v:         types.Binary{Subtype: types.BinaryUser, B: []byte{0x42}},
+	canonical: `{"$binary":{"base64":"Qg==","subType":"80"}}`,
+	relaxed:   `{"$binary":{"base64":"Qg==","subType":"80"}}`,
+}, {
+	name:      "undefined",
+	v:         types.Undefined,
+	canonical: `{"$undefined":true}`,
+	relaxed:   `{"$undefined":true}`,
+}, {
+	name:      "objectID",
+	v:         types.ObjectID{0x62, 0x56, 0xc5, 0xba, 0x18, 0x2d, 0x4f, 0x28, 0x31, 0x7d, 0x71, 0x0b},
+	canonical: `{"$oid":"6256c5ba182d4f28317d710b"}`,
+	relaxed:   `{"$oid":"6256c5ba182d4f28317d710b"}`,
+}, {
+	name:      "bool",
+	v:         true,
+	canonical: `true`,
+	relaxed:   `true`,
+}, {
+	name:      "date",
+	v:         time.Date(2021, 7, 27, 9, 35, 42, 123000000, time.UTC).Local(),
+	canonical: `{"$date":{"$numberLong":"1627378542123"}}`,
+	relaxed:   `{"$date":"2021-07-27T09:35:42.123Z"}`,
+}, {
+	name:      "dateBeforeEpoch",
+	v:         time.Date(1969, 7, 20, 20, 17, 40, 0, time.UTC).Local(),
+	canonical: `{"$date":{"$numberLong":"-14182940000"}}`,
+	relaxed:   `{"$date":{"$numberLong":"-14182940000"}}`,
+}, {
+	name:      "null",
+	v:         types.Null,
+	canonical: `null`,
+	relaxed:   `null`,
+}, {
+	name:      "regex",
+	v:         types.Regex{Pattern: "^a", Options: "i"},
+	canonical: `{"$regularExpression":{"pattern":"^a","options":"i"}}`,
+	relaxed:   `{"$regularExpression":{"pattern":"^a","options":"i"}}`,
+}, {
+	name:      "dbPointer",
+	v:         types.DBPointer{Namespace: "db.c", ID: types.ObjectID{0x42}},
+	canonical: `{"$dbPointer":{"$ref":"db.c","$id":{"$oid":"420000000000000000000000"}}}`,
+	relaxed:   `{"$dbPointer":{"$ref":"db.c","$id":{"$oid":"420000000000000000000000"}}}`,
+}, {
+	name:      "javaScript",
+	v:         types.JavaScript{Code: "function() {}"},
+	canonical: `{"$code":"function() {}"}`,
+	relaxed:   `{"$code":"function() {}"}`,
+}, {
+	name:      "symbol",
+	v:         types.Symbol("foo"),
+	canonical: `{"$symbol":"foo"}`,
+	relaxed:   `{"$symbol":"foo"}`,
+}, {
+	name:      "javaScriptWithScope",
+	v:         types.JavaScriptWithScope{Code: "x", Scope: must.NotFail(types.NewDocument("x", int32(1)))},
+	canonical: `{"$code":"x","$scope":{"x":{"$numberInt":"1"}}}`,
+	relaxed:   `{"$code":"x","$scope":{"x":1}}`,
+}, {
+	name:      "int32",
+	v:         int32(-42),
+	canonical: `{"$numberInt":"-42"}`,
+	relaxed:   `-42`,
+}, {
+	name:      "timestamp",
+	v:         types.Timestamp(42<<32 | 13),
+	canonical: `{"$timestamp":{"t":42,"i":13}}`,
+	relaxed:   `{"$timestamp":{"t":42,"i":13}}`,
+}, {
+	name:      "int64",
+	v:         int64(math.MaxInt64),
+	canonical: `{"$numberLong":"9223372036854775807"}`,
+	relaxed:   `9223372036854775807`,
+}, {
+	name:      "int64Small",
+	v:         int64(42),
+	canonical: `{"$numberLong":"42"}`,
+	relaxed:   `42`,
+}, {
+	name:      "decimal128",
+	v:         must.NotFail(types.ParseDecimal128("1.5")),
+	canonical: `{"$numberDecimal":"1.5"}`,
+	relaxed:   `{"$numberDecimal":"1.5"}`,
+}}
+
+func TestExtJSON(t *testing.T) {
+	t.Parallel()
+
+	for _, tc := range testCases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			b, err := Marshal(tc.v, Canonical)
+			require.NoError(t, err)
+			assert.Equal(t, tc.canonical, string(b))
+
+			b, err = Marshal(tc.v, Relaxed)
+			require.NoError(t, err)
+			assert.Equal(t, tc.relaxed, string(b))
+
+			v, err := Unmarshal([]byte(tc.canonical))
+			require.NoError(t, err)
+			assertEqual(t, tc.v, v)
+
+			v, err = Unmarshal([]byte(tc.relaxed))
+			require.NoError(t, err)
+			if tc.name == "int64Small" {
+				// type information is lost in relaxed mode
+				assert.Equal(t, int32(42), v)
+				return
+			}
+			assertEqual(t, tc.v, v)
+		})
+	}
+}
+
+func TestUnmarshal(t *testing.T) {
+	t.Parallel()
+
+	for name, tc := range map[string]struct {
+		j   string
+		v   any
+		err string
+	}{
+		"DateLegacy": {
+			j: `{"$date":1627378542123}`,
+			v: time.Date(2021, 7, 27, 9, 35, 42, 123000000, time.UTC).Local(),
+		},
+		"DateOffset": {
+			j: `{"$date":"2021-07-27T11:35:42.123+02:00"}`,
+			v: time.Date(2021, 7, 27, 9, 35, 42, 123000000, time.UTC).Local(),
+		},
+		"UUID": {
+			j: `{"$uuid":"00112233-4455-6677-8899-aabbccddeeff"}`,
+			v: must.NotFail(types.ParseUUID("00112233445566778899aabbccddeeff")),
+		},
+		"ScopeFirst": {
+			j: `{"$scope":{},"$code":"x"}`,
+			v: types.JavaScriptWithScope{Code: "x", Scope: must.NotFail(types.NewDocument())},
+		},
+		"Operator": {
+			j: `{"$gt":{"$numberLong":"1"}}`,
+			v: must.NotFail(types.NewDocument("$gt", int64(1))),
+		},
+		"BigNumber": {
+			j: `18446744073709551616`,
+			v: float64(18446744073709551616),
+		},
+		"MalformedOID": {
+			j:   `{"$oid":"42"}`,
+			err: `extjson: malformed $oid value`,
+		},
+		"ExtraKey": {
+			j:   `{"$numberInt":"1","foo":"bar"}`,
+			err: `extjson: malformed $numberInt value`,
+		},
+		"DuplicateKey": {
+			j:   `{"a":1,"a":2}`,
+			err: `extjson.readValue: duplicate key "a"`,
+		},
+		"MinKey": {
+			j:   `{"$minKey":1}`,
+			err: `extjson.convertObject: $minKey is not supported`,
+		},
+		"Trailing": {
+			j:   `1 2`,
+			err: `extjson.decode: unexpected data after the value`,
+		},
+	} {
+		name, tc := name, tc
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			v, err := Unmarshal([]byte(tc.j))
+			if tc.err != "" {
+				require.Error(t, err)
+				assert.Contains(t, err.Error(), tc.err)
+				return
+			}
+
+			require.NoError(t, err)
+			assertEqual(t, tc.v, v)
+		})
+	}
+}
+
+func TestMarshalLog(t *testing.T) {
+	t.Parallel()
+
+	u := must.NotFail(types.ParseUUID("00112233-4455-6677-8899-aabbccddeeff"))
+	doc := must.NotFail(types.NewDocument(
+		"uuid", u,
+		"old", types.Binary{Subtype: types.BinaryUUIDOld, B: u.B},
+		"n", int32(1),
+	))
+
+	b, err := MarshalLog(doc)
+	require.NoError(t, err)
+
+	expected := `{"uuid":{"$uuid":"00112233-4455-6677-8899-aabbccddeeff"},` +
+		`"old":{"$binary":{"base64":"ABEiM0RVZneImaq7zN3u/w==","subType":"03"}},` +
+		`"n":1}`
+	assert.Equal(t, expected, string(b))
+
+	v, err := Unmarshal(b)
+	require.NoError(t, err)
+	assertEqual(t, doc, v)
+}
+
+func FuzzUnmarshal(f *testing.F) {
+	for _, tc := range testCases {
+		f.Add(tc.canonical)
+		f.Add(tc.relaxed)
+	}
+
+	f.Fuzz(func(t *testing.T, j string) {
+		t.Parallel()
+
+		v, err := Unmarshal([]byte(j))
+		if err != nil {
+			t.Skip()
+		}
+
+		// canonical form should round-trip exactly
+		b, err := Marshal(v, Canonical)
+		require.NoError(t, err)
+
+		v2, err := Unmarshal(b)
+		require.NoError(t, err)
+
+		b2, err := Marshal(v2, Canonical)
+		require.NoError(t, err)
+		assert.Equal(t, string(b), string(b2))
+	})
+}
+
+// assertEqual is assert.Equal that also can compare NaNs and ±0.
+func assertEqual(tb testing.TB, expected, actual any) bool {
+	tb.Helper()
+
+	if e, ok := expected.(float64); ok {
+		require.IsType(tb, expected, actual)
+		a := actual.(float64)
+		if math.IsNaN(e) || math.IsNaN(a) {
+			return assert.Equal(tb, math.IsNaN(e), math.IsNaN(a))
+		}
+		if e == 0 && a == 0 {
+			return assert.Equal(tb, math.Signbit(e), math.Signbit(a))
+		}
+	}
+
+	return assert.Equal(tb, expected, actual)
+}
@@ -0,0 +1,60 @@
+// Copyright 2021 FerretDB Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package fjson
+
+import (
+	"testing"
+
+	"github.com/AlekSi/pointer"
+)
+
+var decimal128TestCases = []testCase{{
+	name: "1",
+	v:    pointer.To(decimal128Type{H: 0x3040000000000000, L: 1}),
+	j:    `{"$n":"1"}`,
+}, {
+	name: "-0.1",
+	v:    pointer.To(decimal128Type{H: 0xb03e000000000000, L: 1}),
+	j:    `{"$n":"-0.1"}`,
+}, {
+	name: "1E+3",
+	v:    pointer.To(decimal128Type{H: 0x3046000000000000, L: 1}),
+	j:    `{"$n":"1E+3"}`,
+}, {
+	name: "Infinity",
+	v:    pointer.To(decimal128Type{H: 0x7800000000000000, L: 0}),
+	j:    `{"$n":"Infinity"}`,
+}, {
+	name: "NaN",
+	v:    pointer.To(decimal128Type{H: 0x7c00000000000000, L: 0}),
+	j:    `{"$n":"NaN"}`,
+}, {
+	name: "EOF",
+	j:    `{`,
+	jErr: `unexpected EOF`,
+}}
+
+func TestDecimal128(t *testing.T) {
+	t.Parallel()
+	testJSON(t, decimal128TestCases, func() fjsontype { return new(decimal128Type) })
+}
+
+func FuzzDecimal128(f *testing.F) {
+	fuzzJSON(f, decimal128TestCases, func() fjsontype { return new(decimal128Type) })
+}
+
+func BenchmarkDecimal128(b *testing.B) {
+	benchmark(b, decimal128TestCases, func() fjsontype { return new(decimal128Type) })
+}
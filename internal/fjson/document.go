@@ -20,7 +20,6 @@ import (
 
 	"github.com/FerretDB/FerretDB/internal/types"
 	"github.com/FerretDB/FerretDB/internal/util/lazyerrors"
-	"github.com/FerretDB/FerretDB/internal/util/must"
 )
 
 // documentType represents BSON Document type.
@@ -59,7 +58,7 @@ func (doc *documentType) UnmarshalJSON(data []byte) error {
 		return lazyerrors.Errorf("fjson.documentType.UnmarshalJSON: %d elements in $k, %d in total", len(keys), len(rawMessages))
 	}
 
-	td := must.NotFail(types.NewDocument())
+	td := types.MakeDocument(len(keys))
 	for _, key := range keys {
 		b, ok = rawMessages[key]
 		if !ok {
@@ -16,6 +16,8 @@
 //
 // See contributing guidelines and documentation for package `types` for details.
 //
+// The mapping below is Version1; see Version for other format versions.
+//
 // Mapping
 //
 // Composite types
@@ -26,15 +28,20 @@
 //  float64          {"$f": JSON number} or {"$f": "Infinity|-Infinity|NaN"}
 //  string           JSON string
 //  types.Binary     {"$b": "<base 64 string>", "s": <subtype number>}
+//  types.UndefinedType {"$u": true}
 //  types.ObjectID   {"$o": "<ObjectID as 24 character hex string"}
 //  bool             JSON true / false values
 //  time.Time        {"$d": milliseconds since epoch as JSON number}
 //  types.NullType   JSON null
 //  types.Regex      {"$r": "<string without terminating 0x0>", "o": "<string without terminating 0x0>"}
+//  types.DBPointer  {"$p": "<namespace>", "o": "<ObjectID as 24 character hex string>"}
+//  types.JavaScript {"$j": "<code>"}
+//  types.Symbol     {"$y": "<string>"}
+//  types.JavaScriptWithScope {"$js": "<code>", "s": <scope document>}
 //  int32            JSON number
 //  types.Timestamp  {"$t": "<number as string>"}
 //  int64            {"$l": "<number as string>"}
-//  TODO Decimal128  {"$n": "<number as string>"}
+//  types.Decimal128 {"$n": "<number as string>"}
 package fjson
 
 import (
@@ -88,6 +95,8 @@ func fromFJSON(v fjsontype) any {
 		return string(*v)
 	case *binaryType:
 		return types.Binary(*v)
+	case *undefinedType:
+		return types.Undefined
 	case *objectIDType:
 		return types.ObjectID(*v)
 	case *boolType:
@@ -98,12 +107,22 @@ func fromFJSON(v fjsontype) any {
 		return types.Null
 	case *regexType:
 		return types.Regex(*v)
+	case *dbPointerType:
+		return types.DBPointer(*v)
+	case *javaScriptType:
+		return types.JavaScript(*v)
+	case *symbolType:
+		return types.Symbol(*v)
+	case *javaScriptWithScopeType:
+		return types.JavaScriptWithScope(*v)
 	case *int32Type:
 		return int32(*v)
 	case *timestampType:
 		return types.Timestamp(*v)
 	case *int64Type:
 		return int64(*v)
+	case *decimal128Type:
+		return types.Decimal128(*v)
 	}
 
 	panic(fmt.Sprintf("not reached: %T", v)) // for go-sumtype to work
@@ -122,6 +141,8 @@ func toFJSON(v any) fjsontype {
 		return pointer.To(stringType(v))
 	case types.Binary:
 		return pointer.To(binaryType(v))
+	case types.UndefinedType:
+		return pointer.To(undefinedType(v))
 	case types.ObjectID:
 		return pointer.To(objectIDType(v))
 	case bool:
@@ -132,12 +153,22 @@ func toFJSON(v any) fjsontype {
 		return pointer.To(nullType(v))
 	case types.Regex:
 		return pointer.To(regexType(v))
+	case types.DBPointer:
+		return pointer.To(dbPointerType(v))
+	case types.JavaScript:
+		return pointer.To(javaScriptType(v))
+	case types.Symbol:
+		return pointer.To(symbolType(v))
+	case types.JavaScriptWithScope:
+		return pointer.To(javaScriptWithScopeType(v))
 	case int32:
 		return pointer.To(int32Type(v))
 	case types.Timestamp:
 		return pointer.To(timestampType(v))
 	case int64:
 		return pointer.To(int64Type(v))
+	case types.Decimal128:
+		return pointer.To(decimal128Type(v))
 	}
 
 	panic(fmt.Sprintf("not reached: %T", v)) // for go-sumtype to work
@@ -172,6 +203,10 @@ func Unmarshal(data []byte) (any, error) {
 			var o binaryType
 			err = o.UnmarshalJSON(data)
 			res = &o
+		case v["$u"] != nil:
+			var o undefinedType
+			err = o.UnmarshalJSON(data)
+			res = &o
 		case v["$o"] != nil:
 			var o objectIDType
 			err = o.UnmarshalJSON(data)
@@ -184,14 +219,36 @@ func Unmarshal(data []byte) (any, error) {
 			var o regexType
 			err = o.UnmarshalJSON(data)
 			res = &o
+		case v["$p"] != nil:
+			var o dbPointerType
+			err = o.UnmarshalJSON(data)
+			res = &o
+		case v["$j"] != nil:
+			var o javaScriptType
+			err = o.UnmarshalJSON(data)
+			res = &o
+		case v["$y"] != nil:
+			var o symbolType
+			err = o.UnmarshalJSON(data)
+			res = &o
+		case v["$js"] != nil:
+			var o javaScriptWithScopeType
+			err = o.UnmarshalJSON(data)
+			res = &o
 		case v["$t"] != nil:
 			var o timestampType
 			err = o.UnmarshalJSON(data)
 			res = &o
+		case v["$i"] != nil:
+			res, err = unmarshalInt32Tagged(data)
 		case v["$l"] != nil:
 			var o int64Type
 			err = o.UnmarshalJSON(data)
 			res = &o
+		case v["$n"] != nil:
+			var o decimal128Type
+			err = o.UnmarshalJSON(data)
+			res = &o
 		default:
 			err = lazyerrors.Errorf("fjson.Unmarshal: unhandled map %v", v)
 		}
@@ -206,7 +263,9 @@ func Unmarshal(data []byte) (any, error) {
 	case nil:
 		res = new(nullType)
 	case float64:
-		res = pointer.To(int32Type(v))
+		var o int32Type
+		err = o.UnmarshalJSON(data)
+		res = &o
 	default:
 		err = lazyerrors.Errorf("fjson.Unmarshal: unhandled element %[1]T (%[1]v)", v)
 	}
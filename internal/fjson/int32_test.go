@@ -37,6 +37,10 @@ var int32TestCases = []testCase{{
 	name: "min int32",
 	v:    pointer.To(int32Type(math.MinInt32)),
 	j:    `-2147483648`,
+}, {
+	name: "fraction",
+	j:    `42.5`,
+	jErr: `json: cannot unmarshal number 42.5 into Go value of type int32`,
 }}
 
 func TestInt32(t *testing.T) {
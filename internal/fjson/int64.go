@@ -32,6 +32,12 @@ type int64JSON struct {
 	L int64 `json:"$l,string"`
 }
 
+// int64AnyJSON is a JSON object representation of the int64Type with a number as a string (Version1)
+// or as a number (Version2).
+type int64AnyJSON struct {
+	L json.Number `json:"$l"`
+}
+
 // UnmarshalJSON implements fjsontype interface.
 func (i *int64Type) UnmarshalJSON(data []byte) error {
 	if bytes.Equal(data, []byte("null")) {
@@ -42,7 +48,7 @@ func (i *int64Type) UnmarshalJSON(data []byte) error {
 	dec := json.NewDecoder(r)
 	dec.DisallowUnknownFields()
 
-	var o int64JSON
+	var o int64AnyJSON
 	if err := dec.Decode(&o); err != nil {
 		return lazyerrors.Error(err)
 	}
@@ -50,7 +56,12 @@ func (i *int64Type) UnmarshalJSON(data []byte) error {
 		return lazyerrors.Error(err)
 	}
 
-	*i = int64Type(o.L)
+	l, err := o.L.Int64()
+	if err != nil {
+		return lazyerrors.Error(err)
+	}
+
+	*i = int64Type(l)
 	return nil
 }
 
@@ -37,6 +37,11 @@ var int64TestCases = []testCase{{
 	name: "min int64",
 	v:    pointer.To(int64Type(math.MinInt64)),
 	j:    `{"$l":"-9223372036854775808"}`,
+}, {
+	name:   "number",
+	v:      pointer.To(int64Type(math.MaxInt64)),
+	j:      `{"$l":9223372036854775807}`,
+	canonJ: `{"$l":"9223372036854775807"}`,
 }, {
 	name: "EOF",
 	j:    `{`,
@@ -0,0 +1,88 @@
+// Copyright 2021 FerretDB Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package fjson
+
+import (
+	"testing"
+
+	"github.com/AlekSi/pointer"
+
+	"github.com/FerretDB/FerretDB/internal/types"
+	"github.com/FerretDB/FerretDB/internal/util/must"
+)
+
+var javaScriptTestCases = []testCase{{
+	name: "normal",
+	v:    pointer.To(javaScriptType{Code: "function() { return 42; }"}),
+	j:    `{"$j":"function() { return 42; }"}`,
+}, {
+	name: "empty",
+	v:    pointer.To(javaScriptType{Code: ""}),
+	j:    `{"$j":""}`,
+}, {
+	name: "EOF",
+	j:    `{`,
+	jErr: `unexpected EOF`,
+}}
+
+func TestJavaScript(t *testing.T) {
+	t.Parallel()
+	testJSON(t, javaScriptTestCases, func() fjsontype { return new(javaScriptType) })
+}
+
+func FuzzJavaScript(f *testing.F) {
+	fuzzJSON(f, javaScriptTestCases, func() fjsontype { return new(javaScriptType) })
+}
+
+func BenchmarkJavaScript(b *testing.B) {
+	benchmark(b, javaScriptTestCases, func() fjsontype { return new(javaScriptType) })
+}
+
+var javaScriptWithScopeTestCases = []testCase{{
+	name: "normal",
+	v: pointer.To(javaScriptWithScopeType{
+		Code:  "function() { return x; }",
+		Scope: must.NotFail(types.NewDocument("x", int32(42))),
+	}),
+	j: `{"$js":"function() { return x; }","s":{"$k":["x"],"x":42}}`,
+}, {
+	name: "empty",
+	v: pointer.To(javaScriptWithScopeType{
+		Code:  "",
+		Scope: must.NotFail(types.NewDocument()),
+	}),
+	j: `{"$js":"","s":{"$k":[]}}`,
+}, {
+	name: "missing scope",
+	j:    `{"$js":""}`,
+	jErr: `missing scope`,
+}, {
+	name: "EOF",
+	j:    `{`,
+	jErr: `unexpected EOF`,
+}}
+
+func TestJavaScriptWithScope(t *testing.T) {
+	t.Parallel()
+	testJSON(t, javaScriptWithScopeTestCases, func() fjsontype { return new(javaScriptWithScopeType) })
+}
+
+func FuzzJavaScriptWithScope(f *testing.F) {
+	fuzzJSON(f, javaScriptWithScopeTestCases, func() fjsontype { return new(javaScriptWithScopeType) })
+}
+
+func BenchmarkJavaScriptWithScope(b *testing.B) {
+	benchmark(b, javaScriptWithScopeTestCases, func() fjsontype { return new(javaScriptWithScopeType) })
+}
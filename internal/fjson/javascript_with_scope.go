@@ -0,0 +1,93 @@
+// Copyright 2021 FerretDB Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package fjson
+
+import (
+	"bytes"
+	"encoding/json"
+
+	"github.com/AlekSi/pointer"
+
+	"github.com/FerretDB/FerretDB/internal/types"
+	"github.com/FerretDB/FerretDB/internal/util/lazyerrors"
+)
+
+// javaScriptWithScopeType represents deprecated BSON JavaScript code with scope type.
+type javaScriptWithScopeType types.JavaScriptWithScope
+
+// fjsontype implements fjsontype interface.
+func (js *javaScriptWithScopeType) fjsontype() {}
+
+// javaScriptWithScopeJSON is a JSON object representation of the javaScriptWithScopeType.
+type javaScriptWithScopeJSON struct {
+	JS string          `json:"$js"`
+	S  json.RawMessage `json:"s"`
+}
+
+// UnmarshalJSON implements fjsontype interface.
+func (js *javaScriptWithScopeType) UnmarshalJSON(data []byte) error {
+	if bytes.Equal(data, []byte("null")) {
+		panic("null data")
+	}
+
+	r := bytes.NewReader(data)
+	dec := json.NewDecoder(r)
+	dec.DisallowUnknownFields()
+
+	var o javaScriptWithScopeJSON
+	if err := dec.Decode(&o); err != nil {
+		return lazyerrors.Error(err)
+	}
+	if err := checkConsumed(dec, r); err != nil {
+		return lazyerrors.Error(err)
+	}
+
+	if len(o.S) == 0 || bytes.Equal(o.S, []byte("null")) {
+		return lazyerrors.New("missing scope")
+	}
+
+	var scope documentType
+	if err := scope.UnmarshalJSON(o.S); err != nil {
+		return lazyerrors.Error(err)
+	}
+
+	*js = javaScriptWithScopeType{
+		Code:  o.JS,
+		Scope: pointer.To(types.Document(scope)),
+	}
+	return nil
+}
+
+// MarshalJSON implements fjsontype interface.
+func (js *javaScriptWithScopeType) MarshalJSON() ([]byte, error) {
+	scope, err := toFJSON(js.Scope).MarshalJSON()
+	if err != nil {
+		return nil, lazyerrors.Error(err)
+	}
+
+	res, err := json.Marshal(javaScriptWithScopeJSON{
+		JS: js.Code,
+		S:  scope,
+	})
+	if err != nil {
+		return nil, lazyerrors.Error(err)
+	}
+	return res, nil
+}
+
+// check interfaces
+var (
+	_ fjsontype = (*javaScriptWithScopeType)(nil)
+)
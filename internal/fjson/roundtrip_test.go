@@ -0,0 +1,135 @@
+// Copyright 2021 FerretDB Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Use _test package to avoid import cycle with testutil.
+package fjson_test
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"testing"
+	"unicode/utf8"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/FerretDB/FerretDB/internal/bson"
+	"github.com/FerretDB/FerretDB/internal/fjson"
+	"github.com/FerretDB/FerretDB/internal/types"
+	"github.com/FerretDB/FerretDB/internal/util/must"
+	"github.com/FerretDB/FerretDB/internal/util/testutil"
+)
+
+// jsonb returns the given JSON normalized like PostgreSQL does for jsonb values:
+// insignificant whitespace is removed, objects' keys are reordered,
+// and only the last value of duplicate keys is kept. Numbers are kept as is.
+func jsonb(tb testing.TB, b []byte) []byte {
+	tb.Helper()
+
+	dec := json.NewDecoder(bytes.NewReader(b))
+	dec.UseNumber()
+
+	var v any
+	require.NoError(tb, dec.Decode(&v))
+
+	return must.NotFail(json.Marshal(v))
+}
+
+// validUTF8 returns true if all strings in the given value, including documents' keys, are valid UTF-8.
+func validUTF8(v any) bool {
+	switch v := v.(type) {
+	case *types.Document:
+		for _, k := range v.Keys() {
+			if !utf8.ValidString(k) || !validUTF8(must.NotFail(v.Get(k))) {
+				return false
+			}
+		}
+	case *types.Array:
+		for i := 0; i < v.Len(); i++ {
+			if !validUTF8(must.NotFail(v.Get(i))) {
+				return false
+			}
+		}
+	case string:
+		return utf8.ValidString(v)
+	case types.Regex:
+		return utf8.ValidString(v.Pattern) && utf8.ValidString(v.Options)
+	case types.DBPointer:
+		return utf8.ValidString(v.Namespace)
+	case types.JavaScript:
+		return utf8.ValidString(v.Code)
+	case types.Symbol:
+		return utf8.ValidString(string(v))
+	case types.JavaScriptWithScope:
+		return utf8.ValidString(v.Code) && validUTF8(v.Scope)
+	}
+
+	return true
+}
+
+// FuzzDocumentRoundTrip checks that documents survive the path they take in the PostgreSQL handler:
+// BSON -> types -> fjson -> jsonb -> fjson -> types -> BSON.
+func FuzzDocumentRoundTrip(f *testing.F) {
+	for _, b := range testutil.DocumentsSeedCorpus(f) {
+		f.Add(b)
+	}
+
+	// the seed is fixed to keep the corpus stable
+	g := testutil.NewGenerator(f, 1, &testutil.GeneratorOpts{MaxDepth: 3})
+	for _, doc := range g.Documents(20) {
+		f.Add(must.NotFail(bson.MustConvertDocument(doc).MarshalBinary()))
+	}
+
+	f.Fuzz(func(t *testing.T, b []byte) {
+		t.Parallel()
+
+		var raw bson.Document
+		br := bytes.NewReader(b)
+		bufr := bufio.NewReader(br)
+		if err := raw.ReadFrom(bufr); err != nil {
+			t.Skip()
+		}
+
+		// remove random tail
+		expectedB := b[:len(b)-bufr.Buffered()-br.Len()]
+
+		// documents that FerretDB rejects (invalid keys, etc.) never reach storage
+		expected, err := types.ConvertDocument(&raw)
+		if err != nil {
+			t.Skip()
+		}
+
+		// encoding/json replaces invalid UTF-8 with U+FFFD, and PostgreSQL rejects it anyway
+		if !validUTF8(expected) {
+			t.Skip()
+		}
+
+		for _, version := range []fjson.Version{fjson.Version1, fjson.Version2} {
+			j, err := fjson.MarshalVersion(expected, version)
+			require.NoError(t, err)
+
+			v, err := fjson.Unmarshal(jsonb(t, j))
+			require.NoError(t, err, "version %d: %s", version, j)
+
+			actual, ok := v.(*types.Document)
+			require.True(t, ok, "version %d: %T", version, v)
+			testutil.AssertEqual(t, expected, actual)
+
+			actualB, err := bson.MustConvertDocument(actual).MarshalBinary()
+			require.NoError(t, err)
+			assert.Equal(t, expectedB, actualB, "version %d", version)
+		}
+	})
+}
@@ -0,0 +1,75 @@
+// Copyright 2021 FerretDB Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package fjson
+
+import (
+	"bytes"
+	"encoding/json"
+
+	"github.com/FerretDB/FerretDB/internal/types"
+	"github.com/FerretDB/FerretDB/internal/util/lazyerrors"
+)
+
+// undefinedType represents deprecated BSON Undefined type.
+type undefinedType types.UndefinedType
+
+// fjsontype implements fjsontype interface.
+func (*undefinedType) fjsontype() {}
+
+// undefinedJSON is a JSON object representation of the undefinedType.
+type undefinedJSON struct {
+	U bool `json:"$u"`
+}
+
+// UnmarshalJSON implements fjsontype interface.
+func (*undefinedType) UnmarshalJSON(data []byte) error {
+	if bytes.Equal(data, []byte("null")) {
+		panic("null data")
+	}
+
+	r := bytes.NewReader(data)
+	dec := json.NewDecoder(r)
+	dec.DisallowUnknownFields()
+
+	var o undefinedJSON
+	if err := dec.Decode(&o); err != nil {
+		return lazyerrors.Error(err)
+	}
+	if err := checkConsumed(dec, r); err != nil {
+		return lazyerrors.Error(err)
+	}
+
+	if !o.U {
+		return lazyerrors.Errorf("fjson.undefinedType.UnmarshalJSON: unexpected value %v", o.U)
+	}
+
+	return nil
+}
+
+// MarshalJSON implements fjsontype interface.
+func (*undefinedType) MarshalJSON() ([]byte, error) {
+	res, err := json.Marshal(undefinedJSON{
+		U: true,
+	})
+	if err != nil {
+		return nil, lazyerrors.Error(err)
+	}
+	return res, nil
+}
+
+// check interfaces
+var (
+	_ fjsontype = (*undefinedType)(nil)
+)
@@ -0,0 +1,164 @@
+// Copyright 2021 FerretDB Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package fjson
+
+import (
+	"bytes"
+	"encoding/json"
+	"strconv"
+
+	"github.com/FerretDB/FerretDB/internal/types"
+	"github.com/FerretDB/FerretDB/internal/util/lazyerrors"
+	"github.com/FerretDB/FerretDB/internal/util/must"
+)
+
+// Version represents FJSON format version.
+//
+// Unmarshal accepts all versions; Marshal produces Version1, and MarshalVersion produces the given version.
+type Version int32
+
+const (
+	// Version1 is the format described in the package documentation.
+	//
+	// int32 values are stored as bare JSON numbers, and int64 values are stored as JSON strings,
+	// so they can't be compared as numbers by PostgreSQL.
+	Version1 Version = 1
+
+	// Version2 is the format with explicit type tags for all numbers:
+	//
+	//  int32            {"$i": JSON number}
+	//  int64            {"$l": JSON number}
+	//
+	// PostgreSQL jsonb numbers have arbitrary precision, so int64 values are stored exactly,
+	// and bare JSON numbers are never used. Documents still store keys order in "$k",
+	// because jsonb does not preserve objects' keys order.
+	// Values inside types.JavaScriptWithScope scope are encoded as Version1.
+	// All other types are encoded as in Version1.
+	Version2 Version = 2
+)
+
+// LatestVersion is the version used for new collections.
+const LatestVersion = Version2
+
+// int32JSON is a JSON object representation of the int32Type in Version2.
+type int32JSON struct {
+	I int32 `json:"$i"`
+}
+
+// unmarshalInt32Tagged decodes Version2 representation of the int32Type.
+func unmarshalInt32Tagged(data []byte) (*int32Type, error) {
+	r := bytes.NewReader(data)
+	dec := json.NewDecoder(r)
+	dec.DisallowUnknownFields()
+
+	var o int32JSON
+	if err := dec.Decode(&o); err != nil {
+		return nil, lazyerrors.Error(err)
+	}
+	if err := checkConsumed(dec, r); err != nil {
+		return nil, lazyerrors.Error(err)
+	}
+
+	res := int32Type(o.I)
+	return &res, nil
+}
+
+// MarshalVersion encodes given built-in or types' package value into fjson of the given version.
+func MarshalVersion(v any, version Version) ([]byte, error) {
+	if v == nil {
+		panic("v is nil")
+	}
+
+	switch version {
+	case Version1:
+		return Marshal(v)
+	case Version2:
+		var buf bytes.Buffer
+		if err := marshalV2(&buf, v); err != nil {
+			return nil, lazyerrors.Error(err)
+		}
+
+		return buf.Bytes(), nil
+	default:
+		return nil, lazyerrors.Errorf("fjson.MarshalVersion: unknown version %d", version)
+	}
+}
+
+// marshalV2 writes Version2 representation of the given value to buf.
+func marshalV2(buf *bytes.Buffer, v any) error {
+	switch v := v.(type) {
+	case *types.Document:
+		buf.WriteString(`{"$k":`)
+		keys := v.Keys()
+		if keys == nil {
+			keys = []string{}
+		}
+		b, err := json.Marshal(keys)
+		if err != nil {
+			return lazyerrors.Error(err)
+		}
+		buf.Write(b)
+
+		for _, key := range keys {
+			buf.WriteByte(',')
+
+			if b, err = json.Marshal(key); err != nil {
+				return lazyerrors.Error(err)
+			}
+			buf.Write(b)
+			buf.WriteByte(':')
+
+			if err = marshalV2(buf, must.NotFail(v.Get(key))); err != nil {
+				return lazyerrors.Error(err)
+			}
+		}
+
+		buf.WriteByte('}')
+
+	case *types.Array:
+		buf.WriteByte('[')
+
+		for i := 0; i < v.Len(); i++ {
+			if i != 0 {
+				buf.WriteByte(',')
+			}
+
+			if err := marshalV2(buf, must.NotFail(v.Get(i))); err != nil {
+				return lazyerrors.Error(err)
+			}
+		}
+
+		buf.WriteByte(']')
+
+	case int32:
+		buf.WriteString(`{"$i":`)
+		buf.WriteString(strconv.FormatInt(int64(v), 10))
+		buf.WriteByte('}')
+
+	case int64:
+		buf.WriteString(`{"$l":`)
+		buf.WriteString(strconv.FormatInt(v, 10))
+		buf.WriteByte('}')
+
+	default:
+		b, err := Marshal(v)
+		if err != nil {
+			return lazyerrors.Error(err)
+		}
+		buf.Write(b)
+	}
+
+	return nil
+}
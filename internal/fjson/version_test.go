@@ -0,0 +1,84 @@
+// Copyright 2021 FerretDB Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package fjson
+
+import (
+	"math"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/FerretDB/FerretDB/internal/types"
+	"github.com/FerretDB/FerretDB/internal/util/must"
+)
+
+func TestMarshalVersion(t *testing.T) {
+	t.Parallel()
+
+	doc := must.NotFail(types.NewDocument(
+		"int32", int32(42),
+		"int64", int64(math.MaxInt64),
+		"double", 42.0,
+		"array", must.NotFail(types.NewArray(int32(1), int64(2), "3")),
+		"document", must.NotFail(types.NewDocument("b", int32(1), "a", int64(2))),
+	))
+
+	for name, tc := range map[string]struct {
+		version Version
+		j       string
+	}{
+		"Version1": {
+			version: Version1,
+			j: `{"$k":["int32","int64","double","array","document"],"int32":42,"int64":{"$l":"9223372036854775807"},` +
+				`"double":{"$f":42},"array":[1,{"$l":"2"},"3"],"document":{"$k":["b","a"],"b":1,"a":{"$l":"2"}}}`,
+		},
+		"Version2": {
+			version: Version2,
+			j: `{"$k":["int32","int64","double","array","document"],"int32":{"$i":42},"int64":{"$l":9223372036854775807},` +
+				`"double":{"$f":42},"array":[{"$i":1},{"$l":2},"3"],"document":{"$k":["b","a"],"b":{"$i":1},"a":{"$l":2}}}`,
+		},
+	} {
+		name, tc := name, tc
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			actual, err := MarshalVersion(doc, tc.version)
+			require.NoError(t, err)
+			assert.Equal(t, tc.j, string(actual))
+
+			v, err := Unmarshal(actual)
+			require.NoError(t, err)
+			assert.Equal(t, doc, v)
+		})
+	}
+
+	_, err := MarshalVersion(doc, Version(0))
+	assert.Error(t, err)
+}
+
+func TestUnmarshalInt32Tagged(t *testing.T) {
+	t.Parallel()
+
+	v, err := Unmarshal([]byte(`{"$i":42}`))
+	require.NoError(t, err)
+	assert.Equal(t, int32(42), v)
+
+	_, err = Unmarshal([]byte(`{"$i":42.5}`))
+	assert.Error(t, err)
+
+	_, err = Unmarshal([]byte(`{"$i":42,"foo":1}`))
+	assert.Error(t, err)
+}
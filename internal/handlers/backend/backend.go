@@ -0,0 +1,250 @@
+// Copyright 2021 FerretDB Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package backend defines the interface between handlers and storage backends.
+//
+// Handlers implement the wire protocol commands on top of Backend: they parse commands, apply filters,
+// updates, sorting, and projections that backends could not handle, and build replies.
+// Backends store documents and could handle some parts of queries themselves.
+// That way, a new storage engine could be added without re-implementing the whole command surface.
+package backend
+
+import (
+	"context"
+	"fmt"
+	"io"
+
+	"github.com/FerretDB/FerretDB/internal/types"
+	"github.com/FerretDB/FerretDB/internal/util/lazyerrors"
+)
+
+var (
+	// ErrAlreadyExist indicates that a database, collection, or index already exists.
+	ErrAlreadyExist = fmt.Errorf("schema or table already exist")
+
+	// ErrDatabaseNotExist indicates that there is no such database.
+	ErrDatabaseNotExist = fmt.Errorf("schema does not exist")
+
+	// ErrCollectionNotExist indicates that there is no such collection (or database).
+	ErrCollectionNotExist = fmt.Errorf("table does not exist")
+
+	// ErrDuplicateID indicates that a document with the same _id already exists.
+	ErrDuplicateID = fmt.Errorf("document with the same _id already exists")
+
+	// ErrQueryCanceled indicates that the query was canceled by a timeout or context.
+	ErrQueryCanceled = fmt.Errorf("query canceled")
+
+	// ErrIndexNotSupported indicates that index with such key can't be created.
+	ErrIndexNotSupported = fmt.Errorf("index is not supported")
+
+	// ErrIndexKeyConflict indicates that index with the same name but different key already exists.
+	ErrIndexKeyConflict = fmt.Errorf("index with the same name but different key already exists")
+
+	// ErrIndexNameConflict indicates that index with the same key but different name already exists.
+	ErrIndexNameConflict = fmt.Errorf("index with the same key but different name already exists")
+)
+
+// Backend stores FerretDB databases and collections.
+//
+// Every method is atomic on its own: writes are either fully applied or not applied at all,
+// and reads see a consistent snapshot. Transactions spanning multiple calls are run with InTransaction;
+// simple cases (like upserts) could rely on methods with explicit semantics such as InsertDocumentIfNotExists instead.
+//
+// Unless noted otherwise, methods that write documents create the database and collection if needed.
+// Implementations must be safe for concurrent use.
+type Backend interface {
+	// Metadata.
+
+	// Collections returns a sorted list of collection names of the given database.
+	Collections(ctx context.Context, db string) ([]string, error)
+
+	// CollectionExists returns true if the given collection exists.
+	CollectionExists(ctx context.Context, db, collection string) (bool, error)
+
+	// Indexes returns indexes of the given collection, the default _id index first.
+	// It returns ErrCollectionNotExist if collection does not exist.
+	Indexes(ctx context.Context, db, collection string) ([]Index, error)
+
+	// DDL.
+
+	// CreateDatabase creates a new database; it returns ErrAlreadyExist if it already exists.
+	CreateDatabase(ctx context.Context, db string) error
+
+	// DropDatabase drops the database; it returns ErrDatabaseNotExist if it does not exist.
+	DropDatabase(ctx context.Context, db string) error
+
+	// CreateCollection creates a new collection in the existing database.
+	// It returns ErrAlreadyExist if collection already exists, ErrDatabaseNotExist if database does not exist.
+	CreateCollection(ctx context.Context, db, collection string) error
+
+	// CreateCollectionIfNotExist creates database and collection if they don't exist.
+	// It returns true if collection was created.
+	CreateCollectionIfNotExist(ctx context.Context, db, collection string) (bool, error)
+
+	// DropCollection drops the collection.
+	// It returns ErrDatabaseNotExist or ErrCollectionNotExist if database or collection does not exist.
+	DropCollection(ctx context.Context, db, collection string) error
+
+	// CreateIndex creates an index with the given name and key.
+	// It returns ErrAlreadyExist if the same index already exists, ErrIndexNotSupported if key is not supported,
+	// ErrIndexKeyConflict or ErrIndexNameConflict if a different index with the same name or key exists,
+	// and ErrCollectionNotExist if collection does not exist.
+	CreateIndex(ctx context.Context, db, collection, name string, key *types.Document) error
+
+	// Cursors.
+
+	// QueryIterator returns an iterator over documents of the given collection.
+	// Backends could handle parts of the filter and the sort; see Iterator.
+	// The collection should exist.
+	QueryIterator(ctx context.Context, qp *QueryParams) (Iterator, error)
+
+	// CRUD.
+
+	// InsertDocument inserts a document; it returns ErrDuplicateID if a document with the same _id exists.
+	InsertDocument(ctx context.Context, db, collection string, doc *types.Document) error
+
+	// InsertDocuments inserts all documents, or none of them.
+	InsertDocuments(ctx context.Context, db, collection string, docs []*types.Document) error
+
+	// InsertDocumentIfNotExists atomically inserts a document unless a document with the same _id exists.
+	// It returns true if the document was inserted.
+	InsertDocumentIfNotExists(ctx context.Context, db, collection string, doc *types.Document) (bool, error)
+
+	// SetDocumentByID replaces the document with the given _id; it returns the number of replaced documents.
+	SetDocumentByID(ctx context.Context, db, collection string, id any, doc *types.Document) (int64, error)
+
+	// SetDocumentsByID replaces documents with the same _ids as the given ones, all or none of them,
+	// with as few round trips as possible; it returns the number of replaced documents.
+	SetDocumentsByID(ctx context.Context, db, collection string, docs []*types.Document) (int64, error)
+
+	// DeleteDocumentsByID deletes documents with the given _ids; it returns the number of deleted documents.
+	DeleteDocumentsByID(ctx context.Context, db, collection string, ids []any) (int64, error)
+
+	// Transactions.
+
+	// InTransaction calls f with a Backend which methods run in a single serializable transaction:
+	// they see a consistent snapshot, and their writes are applied if f returns nil and rolled back otherwise.
+	// The error returned by f is returned as is.
+	//
+	// f could be called again if the transaction conflicts with concurrent ones,
+	// so it should not have side effects other than calls of the passed Backend.
+	// That Backend should not be used concurrently or after f returns; iterators should be closed before that.
+	// Calling InTransaction on it runs f in a nested transaction that could be rolled back separately.
+	//
+	// Some backends could not run DDL methods in a transaction; see their documentation.
+	InTransaction(ctx context.Context, f func(Backend) error) error
+}
+
+// TimeFieldError indicates that a document written to a time series collection
+// does not have a date in the time field.
+type TimeFieldError struct {
+	TimeField string
+}
+
+// Error implements error interface.
+func (e *TimeFieldError) Error() string {
+	return fmt.Sprintf("%q must be present and contain a valid BSON UTC datetime value", e.TimeField)
+}
+
+// BulkInserter is an optional interface implemented by backends that could insert documents of bulk imports
+// (like ones made by mongorestore) faster than with Backend.InsertDocuments.
+type BulkInserter interface {
+	// BulkInsertDocuments inserts all documents, or none of them, like Backend.InsertDocuments.
+	BulkInsertDocuments(ctx context.Context, db, collection string, docs []*types.Document) error
+}
+
+// Index describes a collection index.
+type Index struct {
+	Name string
+	Key  *types.Document
+
+	// If set, the index is created by the backend itself, not by the user.
+	Internal bool
+}
+
+// QueryParams represents parameters of Backend.QueryIterator and QueryDocuments.
+type QueryParams struct {
+	DB         string
+	Collection string
+	Comment    string
+	Filter     *types.Document // nil matches all documents
+	Sort       *types.Document // nil means natural order
+	Projection *types.Document // nil means all fields
+}
+
+// Iterator iterates over documents returned by Backend.QueryIterator.
+//
+// Iterator must be closed after use; it is not safe for concurrent use.
+type Iterator interface {
+	// Next returns the next document, or io.EOF if there are no more documents.
+	// Errors are sticky: once Next returned an error, it returns the same error.
+	Next() (*types.Document, error)
+
+	// Residual returns the part of the filter that was not handled by the backend;
+	// it should be applied to returned documents.
+	Residual() *types.Document
+
+	// Sorted returns true if documents returned so far are sorted by QueryParams.Sort.
+	// It is meaningful only after Next returned io.EOF.
+	Sorted() bool
+
+	// Close releases resources used by the iterator. It is safe to call it multiple times.
+	Close() error
+}
+
+// QueryResult represents the result of QueryDocuments.
+type QueryResult struct {
+	Docs []*types.Document
+
+	// Residual filter that should be applied to Docs.
+	Residual *types.Document
+
+	// True if Docs are already sorted by QueryParams.Sort.
+	Sorted bool
+}
+
+// QueryDocuments returns all documents for the given query parameters.
+//
+// It loads all documents into memory; see Backend.QueryIterator for processing them one by one.
+func QueryDocuments(ctx context.Context, b Backend, qp *QueryParams) (*QueryResult, error) {
+	iter, err := b.QueryIterator(ctx, qp)
+	if err != nil {
+		return nil, err
+	}
+
+	var res []*types.Document
+	for {
+		var doc *types.Document
+		if doc, err = iter.Next(); err != nil {
+			break
+		}
+
+		res = append(res, doc)
+	}
+
+	if err != io.EOF {
+		iter.Close()
+		return nil, lazyerrors.Error(err)
+	}
+
+	if err = iter.Close(); err != nil {
+		return nil, lazyerrors.Error(err)
+	}
+
+	return &QueryResult{
+		Docs:     res,
+		Residual: iter.Residual(),
+		Sorted:   iter.Sorted(),
+	}, nil
+}
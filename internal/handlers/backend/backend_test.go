@@ -0,0 +1,104 @@
+// Copyright 2021 FerretDB Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package backend
+
+import (
+	"context"
+	"errors"
+	"io"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/FerretDB/FerretDB/internal/types"
+	"github.com/FerretDB/FerretDB/internal/util/must"
+)
+
+// sliceIterator is an Iterator over a slice of documents for tests.
+type sliceIterator struct {
+	docs   []*types.Document
+	err    error // returned after all documents instead of io.EOF, if set
+	closed bool
+}
+
+// Next implements Iterator.
+func (iter *sliceIterator) Next() (*types.Document, error) {
+	if len(iter.docs) == 0 {
+		if iter.err != nil {
+			return nil, iter.err
+		}
+		return nil, io.EOF
+	}
+
+	doc := iter.docs[0]
+	iter.docs = iter.docs[1:]
+
+	return doc, nil
+}
+
+// Residual implements Iterator.
+func (iter *sliceIterator) Residual() *types.Document { return nil }
+
+// Sorted implements Iterator.
+func (iter *sliceIterator) Sorted() bool { return true }
+
+// Close implements Iterator.
+func (iter *sliceIterator) Close() error {
+	iter.closed = true
+	return nil
+}
+
+// iteratorBackend is a Backend that returns the given iterator for all queries.
+// Other methods are not implemented and panic.
+type iteratorBackend struct {
+	Backend
+	iter *sliceIterator
+}
+
+// QueryIterator implements Backend.
+func (b *iteratorBackend) QueryIterator(ctx context.Context, qp *QueryParams) (Iterator, error) {
+	return b.iter, nil
+}
+
+func TestQueryDocuments(t *testing.T) {
+	t.Parallel()
+
+	docs := []*types.Document{
+		must.NotFail(types.NewDocument("_id", int32(1))),
+		must.NotFail(types.NewDocument("_id", int32(2))),
+	}
+
+	t.Run("Normal", func(t *testing.T) {
+		t.Parallel()
+
+		iter := &sliceIterator{docs: docs}
+		res, err := QueryDocuments(context.Background(), &iteratorBackend{iter: iter}, new(QueryParams))
+		require.NoError(t, err)
+		assert.Equal(t, docs, res.Docs)
+		assert.True(t, res.Sorted)
+		assert.True(t, iter.closed)
+	})
+
+	t.Run("Error", func(t *testing.T) {
+		t.Parallel()
+
+		expected := errors.New("test error")
+		iter := &sliceIterator{docs: docs, err: expected}
+		_, err := QueryDocuments(context.Background(), &iteratorBackend{iter: iter}, new(QueryParams))
+		require.ErrorIs(t, err, expected)
+		assert.True(t, iter.closed)
+	})
+}
@@ -0,0 +1,146 @@
+// Copyright 2021 FerretDB Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package backend
+
+import (
+	"errors"
+	"sync"
+)
+
+// ErrorCode represents the kind of Error.
+//
+// Handlers map them to wire protocol error codes.
+type ErrorCode int
+
+const (
+	// ErrorCodeInternal indicates an unknown storage error; it is not safe to retry.
+	ErrorCodeInternal ErrorCode = iota
+
+	// ErrorCodeUnavailable indicates that the storage is unreachable, shutting down, or overloaded.
+	ErrorCodeUnavailable
+
+	// ErrorCodeOutOfDiskSpace indicates that the storage is out of disk space.
+	ErrorCodeOutOfDiskSpace
+
+	// ErrorCodeWriteConflict indicates that the write conflicted with another concurrent operation.
+	ErrorCodeWriteConflict
+
+	// ErrorCodeDuplicateKey indicates the unique constraint violation.
+	ErrorCodeDuplicateKey
+
+	// ErrorCodeTimeout indicates that the query was canceled by the storage-side timeout.
+	ErrorCodeTimeout
+
+	// ErrorCodeUnauthorized indicates that the storage user does not have enough privileges.
+	ErrorCodeUnauthorized
+
+	// ErrorCodeAuthenticationFailed indicates that the storage rejected the credentials.
+	ErrorCodeAuthenticationFailed
+
+	// ErrorCodeReadOnly indicates that the storage does not accept writes.
+	ErrorCodeReadOnly
+
+	// ErrorCodeDatabaseNotExist indicates that the storage database does not exist.
+	ErrorCodeDatabaseNotExist
+
+	// ErrorCodeCollectionNotExist indicates that the storage table does not exist;
+	// for example, it was dropped concurrently.
+	ErrorCodeCollectionNotExist
+)
+
+// Error represents an error caused by the storage or its driver.
+//
+// Texts of storage errors are never returned to clients, because they may contain internal details
+// like table names, connection parameters, or data. Instead, Error provides a code and a safe message.
+type Error struct {
+	code ErrorCode
+	msg  string
+	err  error
+}
+
+// NewError creates a new Error with the given code and safe message for the original error.
+func NewError(code ErrorCode, msg string, err error) *Error {
+	return &Error{
+		code: code,
+		msg:  msg,
+		err:  err,
+	}
+}
+
+// Code returns the error code.
+func (e *Error) Code() ErrorCode {
+	return e.code
+}
+
+// Message returns the message that is safe to return to clients.
+func (e *Error) Message() string {
+	return e.msg
+}
+
+// Error implements error interface; it includes the original error's text for logging.
+func (e *Error) Error() string {
+	return e.msg + ": " + e.err.Error()
+}
+
+// Unwrap returns the original error.
+func (e *Error) Unwrap() error {
+	return e.err
+}
+
+// ErrorTranslator returns Error for the error caused by the backend's storage or driver,
+// or nil if the error is not caused by them.
+type ErrorTranslator func(err error) *Error
+
+// errorTranslators contains translators registered by backends.
+var errorTranslators struct {
+	rw sync.RWMutex
+	s  []ErrorTranslator
+}
+
+// RegisterErrorTranslator registers the backend's translator used by TranslateError.
+//
+// Backends should call it from their package's init function.
+func RegisterErrorTranslator(t ErrorTranslator) {
+	errorTranslators.rw.Lock()
+	defer errorTranslators.rw.Unlock()
+
+	errorTranslators.s = append(errorTranslators.s, t)
+}
+
+// TranslateError returns Error for the error caused by any backend's storage or driver,
+// or nil if the error is not caused by them.
+//
+// The error could be wrapped; Error already present in the chain is returned as is.
+func TranslateError(err error) *Error {
+	if err == nil {
+		return nil
+	}
+
+	var e *Error
+	if errors.As(err, &e) {
+		return e
+	}
+
+	errorTranslators.rw.RLock()
+	defer errorTranslators.rw.RUnlock()
+
+	for _, t := range errorTranslators.s {
+		if e = t(err); e != nil {
+			return e
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,82 @@
+// Copyright 2021 FerretDB Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package handlers
+
+// Feature is an optional feature that is not supported by all handlers.
+//
+// Commands and command fields that require features are listed in the handlers/common package;
+// requests that use features unsupported by the handler are rejected there
+// before the handler is called, so all handlers return the same error for them.
+type Feature string
+
+// Optional features.
+const (
+	// FeatureAuthentication is authentication with authenticate, saslStart, and saslContinue commands.
+	FeatureAuthentication = Feature("authentication")
+
+	// FeatureUserManagement is management of users with createUser, dropUser, and usersInfo commands.
+	FeatureUserManagement = Feature("userManagement")
+
+	// FeatureCount is the count command.
+	FeatureCount = Feature("count")
+
+	// FeatureFindAndModify is the findAndModify command.
+	FeatureFindAndModify = Feature("findAndModify")
+
+	// FeatureIndexes is creation and listing of indexes with createIndexes and listIndexes commands.
+	FeatureIndexes = Feature("indexes")
+
+	// FeatureCollStats is the collStats command.
+	FeatureCollStats = Feature("collStats")
+
+	// FeatureDataSize is the dataSize command.
+	FeatureDataSize = Feature("dataSize")
+
+	// FeatureDBStats is the dbStats command.
+	FeatureDBStats = Feature("dbStats")
+
+	// FeatureMigrateCollection is the migrateCollection command.
+	FeatureMigrateCollection = Feature("migrateCollection")
+
+	// FeatureDumpArchive is the dumpArchive command.
+	FeatureDumpArchive = Feature("dumpArchive")
+
+	// FeatureFCV is persisted feature compatibility version with setFeatureCompatibilityVersion command.
+	FeatureFCV = Feature("featureCompatibilityVersion")
+
+	// FeaturePartitioning is partitioning of collections with create command's partition field.
+	FeaturePartitioning = Feature("partitioning")
+)
+
+// Capabilities describes which optional features a handler supports.
+type Capabilities struct {
+	// Handler name, like `pg`.
+	Handler string
+
+	// Unsupported features, with URLs of issues that track their support;
+	// URL is empty if there is no such issue.
+	// All other features are supported.
+	Unsupported map[Feature]string
+
+	// If true, clients have to authenticate before running commands,
+	// and commands are allowed only by roles granted to authenticated users (see common.CheckRoles).
+	Authorization bool
+}
+
+// Supports returns true if the handler supports the given feature.
+func (c *Capabilities) Supports(feature Feature) bool {
+	_, ok := c.Unsupported[feature]
+	return !ok
+}
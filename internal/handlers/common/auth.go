@@ -0,0 +1,199 @@
+// Copyright 2021 FerretDB Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package common
+
+import (
+	"crypto/md5"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+
+	"github.com/xdg-go/scram"
+	"golang.org/x/exp/slices"
+
+	"github.com/FerretDB/FerretDB/internal/types"
+	"github.com/FerretDB/FerretDB/internal/util/lazyerrors"
+	"github.com/FerretDB/FerretDB/internal/util/must"
+)
+
+// SCRAM authentication mechanisms.
+const (
+	// MechanismSCRAMSHA1 is used by older drivers and tools.
+	MechanismSCRAMSHA1 = "SCRAM-SHA-1"
+
+	// MechanismSCRAMSHA256 is the default mechanism for modern drivers.
+	MechanismSCRAMSHA256 = "SCRAM-SHA-256"
+)
+
+// MechanismPLAIN sends username and password in clear text.
+// It is used only for passing credentials to the backend.
+const MechanismPLAIN = "PLAIN"
+
+// MechanismX509 authenticates clients by verified TLS client certificates.
+const MechanismX509 = "MONGODB-X509"
+
+// ExternalDB is a database for users authenticated by external means, like X.509 certificates.
+const ExternalDB = "$external"
+
+// SCRAMMechanisms includes all supported SCRAM mechanisms.
+var SCRAMMechanisms = []string{MechanismSCRAMSHA1, MechanismSCRAMSHA256}
+
+// scramParams contains hash function and key derivation parameters for a single mechanism.
+// They match MongoDB defaults.
+type scramParams struct {
+	hash     scram.HashGeneratorFcn
+	iters    int
+	saltSize int
+}
+
+// scramMechanisms maps SCRAM mechanisms to their parameters.
+var scramMechanisms = map[string]scramParams{
+	MechanismSCRAMSHA1:   {hash: scram.SHA1, iters: 10000, saltSize: 16},
+	MechanismSCRAMSHA256: {hash: scram.SHA256, iters: 15000, saltSize: 28},
+}
+
+// scramClient returns a SCRAM client used to derive credentials for the given mechanism.
+//
+// SCRAM-SHA-1 uses MongoDB-specific password digest without SASLprep;
+// SCRAM-SHA-256 uses SASLprep'ed password as is.
+func scramClient(mechanism, username, password string) (*scram.Client, error) {
+	params, ok := scramMechanisms[mechanism]
+	if !ok {
+		return nil, lazyerrors.Errorf("unexpected mechanism %q", mechanism)
+	}
+
+	if mechanism == MechanismSCRAMSHA1 {
+		h := md5.New()
+		h.Write([]byte(username + ":mongo:" + password))
+		return params.hash.NewClientUnprepped(username, hex.EncodeToString(h.Sum(nil)), "")
+	}
+
+	return params.hash.NewClient(username, password, "")
+}
+
+// MakeCredentials returns a credentials document for the given user, password, and mechanisms.
+// If mechanisms list is empty, all supported mechanisms are used.
+//
+// The document has the same format as the `credentials` field of MongoDB's system.users documents.
+func MakeCredentials(username, password string, mechanisms []string) (*types.Document, error) {
+	if len(mechanisms) == 0 {
+		mechanisms = SCRAMMechanisms
+	}
+
+	credentials := must.NotFail(types.NewDocument())
+
+	for _, mechanism := range mechanisms {
+		if !slices.Contains(SCRAMMechanisms, mechanism) {
+			return nil, NewErrorMsg(ErrBadValue, fmt.Sprintf("Unknown auth mechanism '%s'", mechanism))
+		}
+
+		client, err := scramClient(mechanism, username, password)
+		if err != nil {
+			return nil, NewErrorMsg(ErrBadValue, fmt.Sprintf("Error preparing password: %s", err))
+		}
+
+		params := scramMechanisms[mechanism]
+
+		salt := make([]byte, params.saltSize)
+		if _, err = rand.Read(salt); err != nil {
+			return nil, lazyerrors.Error(err)
+		}
+
+		kf := scram.KeyFactors{Salt: string(salt), Iters: params.iters}
+		stored := client.GetStoredCredentials(kf)
+
+		must.NoError(credentials.Set(mechanism, must.NotFail(types.NewDocument(
+			"iterationCount", int32(params.iters),
+			"salt", base64.StdEncoding.EncodeToString(salt),
+			"storedKey", base64.StdEncoding.EncodeToString(stored.StoredKey),
+			"serverKey", base64.StdEncoding.EncodeToString(stored.ServerKey),
+		))))
+	}
+
+	return credentials, nil
+}
+
+// UserMechanisms returns authentication mechanisms available for the given user document.
+func UserMechanisms(user *types.Document) *types.Array {
+	res := must.NotFail(types.NewArray())
+
+	v, err := user.Get("credentials")
+	if err != nil {
+		return res
+	}
+
+	credentials, ok := v.(*types.Document)
+	if !ok {
+		return res
+	}
+
+	for _, mechanism := range credentials.Keys() {
+		must.NoError(res.Append(mechanism))
+	}
+
+	return res
+}
+
+// storedCredentials returns SCRAM credentials for the given mechanism from the user document.
+func storedCredentials(user *types.Document, mechanism string) (scram.StoredCredentials, error) {
+	var res scram.StoredCredentials
+
+	credentials, err := GetRequiredParam[*types.Document](user, "credentials")
+	if err != nil {
+		return res, lazyerrors.Error(err)
+	}
+
+	creds, err := GetRequiredParam[*types.Document](credentials, mechanism)
+	if err != nil {
+		return res, lazyerrors.Errorf("mechanism %s is not available for user", mechanism)
+	}
+
+	iters, err := GetRequiredParam[int32](creds, "iterationCount")
+	if err != nil {
+		return res, lazyerrors.Error(err)
+	}
+	res.Iters = int(iters)
+
+	for _, f := range []struct {
+		key string
+		dst *[]byte
+	}{
+		{"storedKey", &res.StoredKey},
+		{"serverKey", &res.ServerKey},
+	} {
+		var s string
+		if s, err = GetRequiredParam[string](creds, f.key); err != nil {
+			return res, lazyerrors.Error(err)
+		}
+
+		if *f.dst, err = base64.StdEncoding.DecodeString(s); err != nil {
+			return res, lazyerrors.Error(err)
+		}
+	}
+
+	s, err := GetRequiredParam[string](creds, "salt")
+	if err != nil {
+		return res, lazyerrors.Error(err)
+	}
+
+	salt, err := base64.StdEncoding.DecodeString(s)
+	if err != nil {
+		return res, lazyerrors.Error(err)
+	}
+	res.Salt = string(salt)
+
+	return res, nil
+}
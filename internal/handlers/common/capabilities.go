@@ -0,0 +1,64 @@
+// Copyright 2021 FerretDB Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package common
+
+import (
+	"fmt"
+
+	"github.com/FerretDB/FerretDB/internal/handlers"
+	"github.com/FerretDB/FerretDB/internal/types"
+)
+
+// CheckCapabilities returns ErrNotImplemented error if the given command document
+// requires a feature that is not supported by the handler with given capabilities (see Commands).
+//
+// Error message contains the URL of the issue that tracks the support of that feature, if any.
+func CheckCapabilities(caps *handlers.Capabilities, document *types.Document) error {
+	command := document.Command()
+
+	cmd, ok := Commands[command]
+	if !ok {
+		return nil
+	}
+
+	if f := cmd.Feature; f != "" && !caps.Supports(f) {
+		msg := fmt.Sprintf("%s: feature %q is not implemented by `%s` handler yet", command, f, caps.Handler)
+		return NewErrorMsg(ErrNotImplemented, withIssue(msg, caps.Unsupported[f]))
+	}
+
+	for _, field := range document.Keys() {
+		f := cmd.FieldFeatures[field]
+		if f == "" || caps.Supports(f) {
+			continue
+		}
+
+		msg := fmt.Sprintf(
+			"%s: support for field %q (feature %q) is not implemented by `%s` handler yet",
+			command, field, f, caps.Handler,
+		)
+		return NewErrorMsg(ErrNotImplemented, withIssue(msg, caps.Unsupported[f]))
+	}
+
+	return nil
+}
+
+// withIssue appends the issue URL to the error message, if it is not empty.
+func withIssue(msg, issue string) string {
+	if issue == "" {
+		return msg
+	}
+
+	return msg + "; see " + issue
+}
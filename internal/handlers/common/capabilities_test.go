@@ -0,0 +1,79 @@
+// Copyright 2021 FerretDB Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package common
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/FerretDB/FerretDB/internal/handlers"
+	"github.com/FerretDB/FerretDB/internal/types"
+	"github.com/FerretDB/FerretDB/internal/util/must"
+)
+
+func TestCheckCapabilities(t *testing.T) {
+	t.Parallel()
+
+	caps := &handlers.Capabilities{
+		Handler: "test",
+		Unsupported: map[handlers.Feature]string{
+			handlers.FeatureCount:        "https://github.com/FerretDB/FerretDB/issues/771",
+			handlers.FeaturePartitioning: "",
+		},
+	}
+
+	for name, tc := range map[string]struct {
+		document *types.Document
+		err      string // empty if no error is expected
+	}{
+		"Supported": {
+			document: must.NotFail(types.NewDocument("createIndexes", "test")),
+		},
+		"UnknownCommand": {
+			document: must.NotFail(types.NewDocument("noSuchCommand", "test")),
+		},
+		"Unsupported": {
+			document: must.NotFail(types.NewDocument("count", "test")),
+			err: `count: feature "count" is not implemented by ` + "`test`" + ` handler yet; ` +
+				`see https://github.com/FerretDB/FerretDB/issues/771`,
+		},
+		"SupportedField": {
+			document: must.NotFail(types.NewDocument("create", "test", "capped", false)),
+		},
+		"UnsupportedField": {
+			document: must.NotFail(types.NewDocument("create", "test", "partition", must.NotFail(types.NewDocument()))),
+			err: `create: support for field "partition" (feature "partitioning") ` +
+				"is not implemented by `test` handler yet",
+		},
+	} {
+		name, tc := name, tc
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			err := CheckCapabilities(caps, tc.document)
+			if tc.err == "" {
+				require.NoError(t, err)
+				return
+			}
+
+			expected := NewErrorMsg(ErrNotImplemented, tc.err)
+			assert.Equal(t, expected, err)
+		})
+	}
+
+	assert.True(t, (&handlers.Capabilities{Handler: "pg"}).Supports(handlers.FeatureCount))
+}
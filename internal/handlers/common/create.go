@@ -0,0 +1,200 @@
+// Copyright 2021 FerretDB Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package common
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/FerretDB/FerretDB/internal/types"
+	"github.com/FerretDB/FerretDB/internal/util/must"
+)
+
+// defaultClusteredIndexName is the name of the clustered index used if it is not set, like in MongoDB.
+const defaultClusteredIndexName = "_id_"
+
+// CreateParams represents options of the create command that are handled the same way by all handlers.
+type CreateParams struct {
+	// Name of the clustered index; empty if the collection is not clustered.
+	ClusteredIndex string
+
+	// Time series options; nil if the collection is not a time series collection.
+	Timeseries *TimeseriesParams
+}
+
+// TimeseriesParams represents timeseries option of the create command.
+type TimeseriesParams struct {
+	TimeField   string
+	MetaField   string // empty if not set
+	Granularity string // "seconds", "minutes", or "hours"
+}
+
+// GetCreateParams returns clusteredIndex, expireAfterSeconds, and timeseries options of the create command,
+// or protocol error for invalid or not implemented options.
+//
+// Clustered collections store documents in _id order, so the only valid clustered index key is {_id: 1}.
+// Expiration of documents is not implemented yet,
+// but options are validated first, so clients get the same errors as from MongoDB for invalid ones.
+func GetCreateParams(document *types.Document) (*CreateParams, error) {
+	var res CreateParams
+
+	var clusteredIndex *types.Document
+	var err error
+	if clusteredIndex, err = GetOptionalParam(document, "clusteredIndex", clusteredIndex); err != nil {
+		return nil, err
+	}
+
+	if clusteredIndex != nil {
+		if res.ClusteredIndex, err = getClusteredIndexName(clusteredIndex); err != nil {
+			return nil, err
+		}
+	}
+
+	var timeseries *types.Document
+	if timeseries, err = GetOptionalParam(document, "timeseries", timeseries); err != nil {
+		return nil, err
+	}
+
+	if timeseries != nil {
+		if clusteredIndex != nil {
+			return nil, NewErrorMsg(ErrInvalidOptions, "Time-series collections cannot be created with a clustered index")
+		}
+
+		if res.Timeseries, err = getTimeseriesParams(timeseries); err != nil {
+			return nil, err
+		}
+	}
+
+	if v, err := document.Get("expireAfterSeconds"); err == nil {
+		expireAfterSeconds, err := GetWholeNumberParam(v)
+		if err != nil || expireAfterSeconds < 0 {
+			msg := fmt.Sprintf("Invalid value for 'expireAfterSeconds': %v", v)
+			return nil, NewErrorMsg(ErrInvalidOptions, msg)
+		}
+
+		if clusteredIndex == nil && timeseries == nil {
+			return nil, NewErrorMsg(
+				ErrInvalidOptions,
+				"'expireAfterSeconds' option is only supported on clustered collections or timeseries collections",
+			)
+		}
+	}
+
+	if err = Unimplemented(document, "expireAfterSeconds"); err != nil {
+		return nil, err
+	}
+
+	return &res, nil
+}
+
+// getClusteredIndexName validates clusteredIndex option like {key: {_id: 1}, unique: true, name: "..."}
+// and returns the name of the clustered index.
+func getClusteredIndexName(clusteredIndex *types.Document) (string, error) {
+	key, err := GetRequiredParam[*types.Document](clusteredIndex, "key")
+	if err != nil {
+		return "", err
+	}
+
+	var order int64
+	if key.Len() == 1 && key.Has("_id") {
+		order, _ = GetWholeNumberParam(must.NotFail(key.Get("_id")))
+	}
+
+	if order != 1 {
+		return "", NewErrorMsg(
+			ErrInvalidIndexSpecificationOption,
+			"The clusteredIndex option is only supported for key: {_id: 1}",
+		)
+	}
+
+	unique, err := GetRequiredParam[bool](clusteredIndex, "unique")
+	if err != nil {
+		return "", err
+	}
+
+	if !unique {
+		return "", NewErrorMsg(ErrInvalidIndexSpecificationOption, "The clusteredIndex option requires unique: true")
+	}
+
+	name, err := GetOptionalParam(clusteredIndex, "name", defaultClusteredIndexName)
+	if err != nil {
+		return "", err
+	}
+
+	if name == "" {
+		return "", NewErrorMsg(ErrInvalidIndexSpecificationOption, "The clusteredIndex name must not be empty")
+	}
+
+	return name, nil
+}
+
+// getTimeseriesParams validates timeseries option like {timeField: "t", metaField: "m", granularity: "minutes"}.
+func getTimeseriesParams(timeseries *types.Document) (*TimeseriesParams, error) {
+	var res TimeseriesParams
+	var err error
+
+	if res.TimeField, err = GetRequiredParam[string](timeseries, "timeField"); err != nil {
+		return nil, err
+	}
+
+	if res.MetaField, err = GetOptionalParam(timeseries, "metaField", res.MetaField); err != nil {
+		return nil, err
+	}
+
+	if res.Granularity, err = GetOptionalParam(timeseries, "granularity", "seconds"); err != nil {
+		return nil, err
+	}
+
+	for _, f := range []struct {
+		option string
+		field  string
+	}{
+		{"timeField", res.TimeField},
+		{"metaField", res.MetaField},
+	} {
+		if strings.Contains(f.field, ".") || strings.HasPrefix(f.field, "$") {
+			msg := fmt.Sprintf("The '%s' option must be a top-level field name without a leading '$'", f.option)
+			return nil, NewErrorMsg(ErrInvalidOptions, msg)
+		}
+	}
+
+	if res.TimeField == "" {
+		return nil, NewErrorMsg(ErrInvalidOptions, "The 'timeField' option must not be empty")
+	}
+
+	if res.MetaField == "_id" {
+		return nil, NewErrorMsg(ErrInvalidOptions, "The 'metaField' option cannot be '_id'")
+	}
+
+	if res.MetaField == res.TimeField {
+		return nil, NewErrorMsg(ErrInvalidOptions, "The 'metaField' option cannot be the same as 'timeField'")
+	}
+
+	switch res.Granularity {
+	case "seconds", "minutes", "hours":
+	default:
+		msg := fmt.Sprintf(
+			"Enumeration value '%s' for field 'timeseries.granularity' is not a valid value.",
+			res.Granularity,
+		)
+		return nil, NewErrorMsg(ErrBadValue, msg)
+	}
+
+	if err = Unimplemented(timeseries, "bucketMaxSpanSeconds", "bucketRoundingSeconds"); err != nil {
+		return nil, err
+	}
+
+	return &res, nil
+}
@@ -0,0 +1,158 @@
+// Copyright 2021 FerretDB Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package common
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/FerretDB/FerretDB/internal/types"
+	"github.com/FerretDB/FerretDB/internal/util/must"
+)
+
+func TestGetCreateParams(t *testing.T) {
+	t.Parallel()
+
+	clusteredIndex := func(pairs ...any) *types.Document {
+		return must.NotFail(types.NewDocument(pairs...))
+	}
+	idKey := must.NotFail(types.NewDocument("_id", int32(1)))
+
+	for name, tc := range map[string]struct {
+		options        []any
+		clusteredIndex string
+		timeseries     *TimeseriesParams
+		err            ErrorCode
+	}{
+		"NoOptions": {},
+		"Clustered": {
+			options:        []any{"clusteredIndex", clusteredIndex("key", idKey, "unique", true)},
+			clusteredIndex: "_id_",
+		},
+		"ClusteredName": {
+			options: []any{
+				"clusteredIndex", clusteredIndex("key", must.NotFail(types.NewDocument("_id", 1.0)), "unique", true, "name", "c"),
+			},
+			clusteredIndex: "c",
+		},
+		"ClusteredNotDocument": {
+			options: []any{"clusteredIndex", true},
+			err:     ErrTypeMismatch,
+		},
+		"ClusteredNoKey": {
+			options: []any{"clusteredIndex", clusteredIndex("unique", true)},
+			err:     ErrBadValue,
+		},
+		"ClusteredKeyDescending": {
+			options: []any{"clusteredIndex", clusteredIndex(
+				"key", must.NotFail(types.NewDocument("_id", int32(-1))),
+				"unique", true,
+			)},
+			err: ErrInvalidIndexSpecificationOption,
+		},
+		"ClusteredKeyCompound": {
+			options: []any{"clusteredIndex", clusteredIndex(
+				"key", must.NotFail(types.NewDocument("_id", int32(1), "v", int32(1))),
+				"unique", true,
+			)},
+			err: ErrInvalidIndexSpecificationOption,
+		},
+		"ClusteredNotUnique": {
+			options: []any{"clusteredIndex", clusteredIndex("key", idKey, "unique", false)},
+			err:     ErrInvalidIndexSpecificationOption,
+		},
+		"ClusteredEmptyName": {
+			options: []any{"clusteredIndex", clusteredIndex("key", idKey, "unique", true, "name", "")},
+			err:     ErrInvalidIndexSpecificationOption,
+		},
+		"ExpireNotClustered": {
+			options: []any{"expireAfterSeconds", int32(10)},
+			err:     ErrInvalidOptions,
+		},
+		"ExpireNegative": {
+			options: []any{"clusteredIndex", clusteredIndex("key", idKey, "unique", true), "expireAfterSeconds", int64(-1)},
+			err:     ErrInvalidOptions,
+		},
+		"ExpireClustered": {
+			options: []any{"clusteredIndex", clusteredIndex("key", idKey, "unique", true), "expireAfterSeconds", int32(10)},
+			err:     ErrNotImplemented,
+		},
+		"Timeseries": {
+			options:    []any{"timeseries", must.NotFail(types.NewDocument("timeField", "t"))},
+			timeseries: &TimeseriesParams{TimeField: "t", Granularity: "seconds"},
+		},
+		"TimeseriesMeta": {
+			options: []any{"timeseries", must.NotFail(types.NewDocument(
+				"timeField", "t", "metaField", "m", "granularity", "hours",
+			))},
+			timeseries: &TimeseriesParams{TimeField: "t", MetaField: "m", Granularity: "hours"},
+		},
+		"TimeseriesNoTimeField": {
+			options: []any{"timeseries", must.NotFail(types.NewDocument("metaField", "m"))},
+			err:     ErrBadValue,
+		},
+		"TimeseriesEmbeddedTimeField": {
+			options: []any{"timeseries", must.NotFail(types.NewDocument("timeField", "a.t"))},
+			err:     ErrInvalidOptions,
+		},
+		"TimeseriesSameFields": {
+			options: []any{"timeseries", must.NotFail(types.NewDocument("timeField", "t", "metaField", "t"))},
+			err:     ErrInvalidOptions,
+		},
+		"TimeseriesMetaID": {
+			options: []any{"timeseries", must.NotFail(types.NewDocument("timeField", "t", "metaField", "_id"))},
+			err:     ErrInvalidOptions,
+		},
+		"TimeseriesGranularity": {
+			options: []any{"timeseries", must.NotFail(types.NewDocument("timeField", "t", "granularity", "days"))},
+			err:     ErrBadValue,
+		},
+		"TimeseriesExpire": {
+			options: []any{
+				"timeseries", must.NotFail(types.NewDocument("timeField", "t")),
+				"expireAfterSeconds", int32(10),
+			},
+			err: ErrNotImplemented,
+		},
+		"TimeseriesClustered": {
+			options: []any{
+				"timeseries", must.NotFail(types.NewDocument("timeField", "t")),
+				"clusteredIndex", clusteredIndex("key", idKey, "unique", true),
+			},
+			err: ErrInvalidOptions,
+		},
+	} {
+		name, tc := name, tc
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			document := must.NotFail(types.NewDocument(append([]any{"create", "test"}, tc.options...)...))
+
+			params, err := GetCreateParams(document)
+			if tc.err != 0 {
+				var e *Error
+				require.ErrorAs(t, err, &e)
+				assert.Equal(t, tc.err, e.Code())
+				return
+			}
+
+			require.NoError(t, err)
+			assert.Equal(t, tc.clusteredIndex, params.ClusteredIndex)
+			assert.Equal(t, tc.timeseries, params.Timeseries)
+		})
+	}
+}
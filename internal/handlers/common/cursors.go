@@ -0,0 +1,451 @@
+// Copyright 2021 FerretDB Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package common
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"sync"
+	"time"
+
+	"github.com/FerretDB/FerretDB/internal/clientconn/conninfo"
+	"github.com/FerretDB/FerretDB/internal/types"
+	"github.com/FerretDB/FerretDB/internal/util/lazyerrors"
+	"github.com/FerretDB/FerretDB/internal/util/must"
+	"github.com/FerretDB/FerretDB/internal/wire"
+)
+
+// cursorTimeout is the time after which idle cursors are closed, like MongoDB's default cursorTimeoutMillis.
+const cursorTimeout = 10 * time.Minute
+
+// maxBatchSize returns the maximum total size of documents in a single cursor batch.
+// It leaves some room for other reply document fields, so the reply fits into the maximum message.
+func maxBatchSize() int {
+	return int(wire.GetLimits().MaxMessageSizeBytes) - 16*1024
+}
+
+// sweepInterval is the interval of closing idle cursors in the background (see cursorRegistry.runSweeper),
+// as cursor iterators could hold backend resources like connections.
+const sweepInterval = time.Minute
+
+// CursorIterator iterates over documents of the cursor; see MakeIteratorCursorReply.
+//
+// It is not safe for concurrent use.
+type CursorIterator interface {
+	// Next returns the next document, or io.EOF if there are no more documents.
+	// Passed context is the context of the request (find, getMore, etc.) that needs the document.
+	Next(ctx context.Context) (*types.Document, error)
+
+	// Close releases resources used by the iterator. It is safe to call it multiple times.
+	Close()
+}
+
+// sliceIterator is a CursorIterator over documents already loaded into memory.
+type sliceIterator struct {
+	docs []*types.Document
+}
+
+// Next implements CursorIterator interface.
+func (iter *sliceIterator) Next(context.Context) (*types.Document, error) {
+	if len(iter.docs) == 0 {
+		return nil, io.EOF
+	}
+
+	doc := iter.docs[0]
+	iter.docs = iter.docs[1:]
+
+	return doc, nil
+}
+
+// Close implements CursorIterator interface.
+func (iter *sliceIterator) Close() {
+	iter.docs = nil
+}
+
+// cursor stores the iterator over documents that were not returned in the previous batches.
+type cursor struct {
+	ns       string
+	username string // user who created the cursor
+	iter     CursorIterator
+	next     *types.Document // fetched from iter, but not returned yet
+	lastUsed time.Time
+
+	// if true, the cursor is not closed when idle (see cursorTimeout),
+	// but it is closed with the connection that created it (see KillConnCursors)
+	noTimeout bool
+	connInfo  *conninfo.ConnInfo
+}
+
+// cursorRegistry stores cursors of all connections,
+// as drivers may send getMore on a different connection.
+type cursorRegistry struct {
+	rw sync.Mutex
+	m  map[int64]*cursor
+
+	// totals for serverStatus.metrics.cursor; see CursorMetrics
+	opened   int64
+	timedOut int64
+	killed   int64
+
+	sweeper sync.Once
+}
+
+// cursors is a global cursor registry.
+var cursors = &cursorRegistry{
+	m: map[int64]*cursor{},
+}
+
+// close closes the cursor's iterator.
+func (c *cursor) close() {
+	if c.iter != nil {
+		c.iter.Close()
+	}
+}
+
+// closeCursors closes iterators of the given cursors.
+//
+// It should be called without the registry lock held, as closing could take time.
+func closeCursors(cs []*cursor) {
+	for _, c := range cs {
+		c.close()
+	}
+}
+
+// store adds a new cursor and returns its ID.
+func (r *cursorRegistry) store(c *cursor) int64 {
+	r.rw.Lock()
+
+	expired := r.sweep(c.lastUsed)
+
+	var id int64
+	for {
+		var b [8]byte
+		must.NotFail(rand.Read(b[:]))
+
+		// positive non-zero IDs; zero means no cursor
+		id = int64(binary.LittleEndian.Uint64(b[:]) >> 1)
+		if _, ok := r.m[id]; id == 0 || ok {
+			continue
+		}
+
+		r.m[id] = c
+		r.opened++
+
+		break
+	}
+
+	r.rw.Unlock()
+
+	closeCursors(expired)
+
+	return id
+}
+
+// take removes the cursor with the given ID created by the given user from the registry and returns it.
+// Nil is returned if there is no such cursor.
+//
+// The caller should either close the cursor or return it to the registry with put.
+func (r *cursorRegistry) take(id int64, username string, now time.Time) *cursor {
+	r.rw.Lock()
+
+	expired := r.sweep(now)
+
+	c := r.m[id]
+	if c != nil && c.username == username {
+		delete(r.m, id)
+	} else {
+		c = nil
+	}
+
+	r.rw.Unlock()
+
+	closeCursors(expired)
+
+	return c
+}
+
+// put returns the cursor taken by take back to the registry.
+func (r *cursorRegistry) put(id int64, c *cursor) {
+	r.rw.Lock()
+	defer r.rw.Unlock()
+
+	r.m[id] = c
+}
+
+// kill removes the cursor like take, closes it, and counts it as killed.
+// It returns false if there is no such cursor.
+func (r *cursorRegistry) kill(id int64, username string, now time.Time) bool {
+	c := r.take(id, username, now)
+	if c == nil {
+		return false
+	}
+
+	c.close()
+
+	r.rw.Lock()
+	defer r.rw.Unlock()
+
+	r.killed++
+
+	return true
+}
+
+// killConn removes and closes cursors without timeout created by the given connection, and counts them as killed.
+// It returns the number of removed cursors.
+func (r *cursorRegistry) killConn(connInfo *conninfo.ConnInfo) int {
+	r.rw.Lock()
+
+	var killed []*cursor
+	for id, c := range r.m {
+		if c.noTimeout && c.connInfo == connInfo {
+			delete(r.m, id)
+			killed = append(killed, c)
+		}
+	}
+
+	r.killed += int64(len(killed))
+
+	r.rw.Unlock()
+
+	closeCursors(killed)
+
+	return len(killed)
+}
+
+// sweep removes idle cursors, except ones with noTimeout, and returns them.
+// The caller should close returned cursors after releasing the lock (see closeCursors).
+//
+// It should be called with the lock held.
+func (r *cursorRegistry) sweep(now time.Time) []*cursor {
+	var expired []*cursor
+	for id, c := range r.m {
+		if !c.noTimeout && now.Sub(c.lastUsed) > cursorTimeout {
+			delete(r.m, id)
+			expired = append(expired, c)
+			r.timedOut++
+		}
+	}
+
+	return expired
+}
+
+// runSweeper starts a goroutine that closes idle cursors periodically,
+// so their iterators are closed even if no other cursor operations are performed.
+//
+// It is safe to call it multiple times; only one goroutine is started.
+func (r *cursorRegistry) runSweeper() {
+	r.sweeper.Do(func() {
+		go func() {
+			ticker := time.NewTicker(sweepInterval)
+			defer ticker.Stop()
+
+			for now := range ticker.C {
+				r.rw.Lock()
+				expired := r.sweep(now)
+				r.rw.Unlock()
+
+				closeCursors(expired)
+			}
+		}()
+	})
+}
+
+// metrics returns serverStatus.metrics.cursor document.
+func (r *cursorRegistry) metrics(now time.Time) *types.Document {
+	r.rw.Lock()
+
+	expired := r.sweep(now)
+
+	var noTimeout int64
+	for _, c := range r.m {
+		if c.noTimeout {
+			noTimeout++
+		}
+	}
+
+	res := must.NotFail(types.NewDocument(
+		"timedOut", r.timedOut,
+		"totalOpened", r.opened,
+		"killed", r.killed,
+		"open", must.NotFail(types.NewDocument(
+			"noTimeout", noTimeout,
+			"pinned", int64(0),
+			"total", int64(len(r.m)),
+		)),
+	))
+
+	r.rw.Unlock()
+
+	closeCursors(expired)
+
+	return res
+}
+
+// CursorMetrics returns serverStatus.metrics.cursor document with counters of cursors of all connections.
+//
+// In addition to MongoDB's fields, it contains the total number of cursors closed by killCursors.
+func CursorMetrics() *types.Document {
+	return cursors.metrics(time.Now())
+}
+
+// nextBatch returns the next batch of cursor's documents and true if there are more documents.
+//
+// The batch contains at most batchSize documents (if batchSize is positive)
+// with total size of at most maxBatchSize, but at least one document (if there are any).
+// Documents are fetched from the cursor's iterator on demand;
+// one more document is fetched to check if there are more of them.
+func (c *cursor) nextBatch(ctx context.Context, batchSize int64) (*types.Array, bool, error) {
+	maxSize := maxBatchSize()
+
+	batch := types.MakeArray(0)
+	var size int
+
+	for {
+		if c.next == nil {
+			doc, err := c.iter.Next(ctx)
+			if err == io.EOF {
+				return batch, false, nil
+			}
+
+			if err != nil {
+				return nil, false, err
+			}
+
+			c.next = doc
+		}
+
+		if batchSize > 0 && int64(batch.Len()) >= batchSize {
+			return batch, true, nil
+		}
+
+		s, err := wire.DocumentSize(c.next)
+		if err != nil {
+			return nil, false, lazyerrors.Error(err)
+		}
+
+		if batch.Len() > 0 && size+s > maxSize {
+			return batch, true, nil
+		}
+
+		if err = batch.Append(c.next); err != nil {
+			return nil, false, lazyerrors.Error(err)
+		}
+
+		size += s
+		c.next = nil
+	}
+}
+
+// cursorUsername returns the name of the user authenticated on the connection.
+func cursorUsername(ctx context.Context) string {
+	username, db := conninfo.GetConnInfo(ctx).Auth()
+	return db + "." + username
+}
+
+// MakeCursorReply returns a reply for find-like commands with the first batch of documents.
+//
+// It is MakeIteratorCursorReply for documents already loaded into memory.
+func MakeCursorReply(
+	ctx context.Context, ns string, docs []*types.Document, batchSize int64, singleBatch, noTimeout bool,
+) (*wire.OpMsg, error) {
+	return MakeIteratorCursorReply(ctx, ns, &sliceIterator{docs: docs}, batchSize, singleBatch, noTimeout)
+}
+
+// MakeIteratorCursorReply returns a reply for find-like commands with the first batch of documents
+// fetched from the given iterator.
+//
+// If not all documents fit into the first batch (see cursor.nextBatch), the iterator is stored in a new cursor
+// which ID is returned in the reply; the rest of documents are fetched by getMore command on demand.
+// Otherwise, or if singleBatch is true, or if an error is returned, the iterator is closed.
+// If noTimeout is true, the cursor is not closed when idle; it should be exhausted or killed by the client,
+// otherwise it is closed together with the connection (see KillConnCursors).
+//
+// The iterator is used after the request is handled, so it should not depend on the request's context.
+func MakeIteratorCursorReply(
+	ctx context.Context, ns string, iter CursorIterator, batchSize int64, singleBatch, noTimeout bool,
+) (*wire.OpMsg, error) {
+	c := &cursor{
+		ns:       ns,
+		username: cursorUsername(ctx),
+		iter:     iter,
+		lastUsed: time.Now(),
+
+		noTimeout: noTimeout,
+		connInfo:  conninfo.GetConnInfo(ctx),
+	}
+
+	firstBatch, more, err := c.nextBatch(ctx, batchSize)
+	if err != nil {
+		c.close()
+		return nil, err
+	}
+
+	var id int64
+	if more && !singleBatch {
+		id = cursors.store(c)
+		cursors.runSweeper()
+	} else {
+		c.close()
+	}
+
+	var reply wire.OpMsg
+	err = reply.SetSections(wire.OpMsgSection{
+		Documents: []*types.Document{must.NotFail(types.NewDocument(
+			"cursor", must.NotFail(types.NewDocument(
+				"firstBatch", firstBatch,
+				"id", id,
+				"ns", ns,
+			)),
+			"ok", float64(1),
+		))},
+	})
+	if err != nil {
+		return nil, lazyerrors.Error(err)
+	}
+
+	return &reply, nil
+}
+
+// cursorNotFound returns CursorNotFound error for the given cursor ID.
+func cursorNotFound(id int64) error {
+	return NewErrorMsg(ErrCursorNotFound, fmt.Sprintf("cursor id %d not found", id))
+}
+
+// KillCursors closes cursors with given IDs created by the current user.
+// It returns IDs of closed cursors and IDs of cursors that were not found.
+func KillCursors(ctx context.Context, ids []int64) (killed, notFound []int64) {
+	username := cursorUsername(ctx)
+	now := time.Now()
+
+	for _, id := range ids {
+		if cursors.kill(id, username, now) {
+			killed = append(killed, id)
+		} else {
+			notFound = append(notFound, id)
+		}
+	}
+
+	return
+}
+
+// KillConnCursors closes cursors without timeout created by the current connection.
+// It should be called when the connection is closed, so they are not kept forever.
+// It returns the number of closed cursors.
+func KillConnCursors(ctx context.Context) int {
+	return cursors.killConn(conninfo.GetConnInfo(ctx))
+}
@@ -0,0 +1,356 @@
+// Copyright 2021 FerretDB Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package common
+
+import (
+	"context"
+	"io"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+
+	"github.com/FerretDB/FerretDB/internal/clientconn/conninfo"
+	"github.com/FerretDB/FerretDB/internal/types"
+	"github.com/FerretDB/FerretDB/internal/util/must"
+	"github.com/FerretDB/FerretDB/internal/wire"
+)
+
+func TestNextBatch(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+
+	small := make([]*types.Document, 10)
+	for i := range small {
+		small[i] = must.NotFail(types.NewDocument("_id", int32(i)))
+	}
+
+	c := &cursor{iter: &sliceIterator{docs: small}}
+	batch, more, err := c.nextBatch(ctx, 0)
+	require.NoError(t, err)
+	assert.Equal(t, 10, batch.Len())
+	assert.False(t, more)
+
+	c = &cursor{iter: &sliceIterator{docs: small}}
+	batch, more, err = c.nextBatch(ctx, 3)
+	require.NoError(t, err)
+	assert.Equal(t, 3, batch.Len())
+	assert.True(t, more)
+
+	batch, more, err = c.nextBatch(ctx, 7)
+	require.NoError(t, err)
+	assert.Equal(t, 7, batch.Len())
+	assert.Equal(t, int32(3), must.NotFail(must.NotFail(batch.Get(0)).(*types.Document).Get("_id")))
+	assert.False(t, more)
+
+	// each document takes a bit more than a quarter of the batch
+	s := strings.Repeat("x", maxBatchSize()/4)
+	large := make([]*types.Document, 10)
+	for i := range large {
+		large[i] = must.NotFail(types.NewDocument("_id", int32(i), "s", s))
+	}
+
+	c = &cursor{iter: &sliceIterator{docs: large}}
+	batch, more, err = c.nextBatch(ctx, 0)
+	require.NoError(t, err)
+	assert.Equal(t, 3, batch.Len())
+	assert.True(t, more)
+}
+
+// testIterator is a CursorIterator that records whether it was closed.
+type testIterator struct {
+	sliceIterator
+	closed bool
+	err    error // returned instead of io.EOF
+}
+
+// Next implements CursorIterator interface.
+func (iter *testIterator) Next(ctx context.Context) (*types.Document, error) {
+	doc, err := iter.sliceIterator.Next(ctx)
+	if err == io.EOF && iter.err != nil {
+		err = iter.err
+	}
+
+	return doc, err
+}
+
+// Close implements CursorIterator interface.
+func (iter *testIterator) Close() {
+	iter.closed = true
+}
+
+func TestCursorIteratorClose(t *testing.T) {
+	t.Parallel()
+
+	connInfo := new(conninfo.ConnInfo)
+	connInfo.SetAuth("user", "admin")
+	ctx := conninfo.WithConnInfo(context.Background(), connInfo)
+
+	newIter := func() *testIterator {
+		docs := make([]*types.Document, 3)
+		for i := range docs {
+			docs[i] = must.NotFail(types.NewDocument("_id", int32(i)))
+		}
+
+		return &testIterator{sliceIterator: sliceIterator{docs: docs}}
+	}
+
+	getMore := func(id int64) (*wire.OpMsg, error) {
+		var msg wire.OpMsg
+		must.NoError(msg.SetSections(wire.OpMsgSection{Documents: []*types.Document{must.NotFail(types.NewDocument(
+			"getMore", id, "collection", "values", "batchSize", int32(1), "$db", "test",
+		))}}))
+
+		return MsgGetMore(ctx, &msg, zap.NewNop())
+	}
+
+	cursorID := func(reply *wire.OpMsg) int64 {
+		cursor := must.NotFail(must.NotFail(reply.Document()).Get("cursor")).(*types.Document)
+		return must.NotFail(cursor.Get("id")).(int64)
+	}
+
+	t.Run("FirstBatch", func(t *testing.T) {
+		t.Parallel()
+
+		iter := newIter()
+		reply, err := MakeIteratorCursorReply(ctx, "test.values", iter, 0, false, false)
+		require.NoError(t, err)
+		assert.Zero(t, cursorID(reply))
+		assert.True(t, iter.closed)
+	})
+
+	t.Run("SingleBatch", func(t *testing.T) {
+		t.Parallel()
+
+		iter := newIter()
+		reply, err := MakeIteratorCursorReply(ctx, "test.values", iter, 1, true, false)
+		require.NoError(t, err)
+		assert.Zero(t, cursorID(reply))
+		assert.True(t, iter.closed)
+	})
+
+	t.Run("Exhausted", func(t *testing.T) {
+		t.Parallel()
+
+		iter := newIter()
+		reply, err := MakeIteratorCursorReply(ctx, "test.values", iter, 1, false, false)
+		require.NoError(t, err)
+		id := cursorID(reply)
+		require.NotZero(t, id)
+		assert.False(t, iter.closed)
+
+		reply, err = getMore(id)
+		require.NoError(t, err)
+		assert.Equal(t, id, cursorID(reply))
+		assert.False(t, iter.closed)
+
+		reply, err = getMore(id)
+		require.NoError(t, err)
+		assert.Zero(t, cursorID(reply))
+		assert.True(t, iter.closed)
+	})
+
+	t.Run("Error", func(t *testing.T) {
+		t.Parallel()
+
+		iter := newIter()
+		iter.err = NewErrorMsg(ErrMaxTimeMSExpired, "operation exceeded time limit")
+		reply, err := MakeIteratorCursorReply(ctx, "test.values", iter, 1, false, false)
+		require.NoError(t, err)
+		id := cursorID(reply)
+		require.NotZero(t, id)
+
+		_, err = getMore(id)
+		require.NoError(t, err)
+
+		_, err = getMore(id)
+		assert.Equal(t, iter.err, err)
+		assert.True(t, iter.closed)
+
+		_, err = getMore(id)
+		var e *Error
+		require.ErrorAs(t, err, &e)
+		assert.Equal(t, ErrCursorNotFound, e.Code())
+	})
+
+	t.Run("Killed", func(t *testing.T) {
+		t.Parallel()
+
+		iter := newIter()
+		reply, err := MakeIteratorCursorReply(ctx, "test.values", iter, 1, false, false)
+		require.NoError(t, err)
+		id := cursorID(reply)
+		require.NotZero(t, id)
+
+		killed, _ := KillCursors(ctx, []int64{id})
+		assert.Equal(t, []int64{id}, killed)
+		assert.True(t, iter.closed)
+	})
+
+	t.Run("Expired", func(t *testing.T) {
+		t.Parallel()
+
+		r := &cursorRegistry{m: map[int64]*cursor{}}
+		now := time.Now()
+
+		iter := newIter()
+		r.store(&cursor{iter: iter, lastUsed: now})
+		r.metrics(now)
+		assert.False(t, iter.closed)
+
+		r.metrics(now.Add(cursorTimeout + time.Second))
+		assert.True(t, iter.closed)
+	})
+}
+
+func TestCursors(t *testing.T) {
+	t.Parallel()
+
+	connInfo := new(conninfo.ConnInfo)
+	connInfo.SetAuth("user", "admin")
+	ctx := conninfo.WithConnInfo(context.Background(), connInfo)
+
+	docs := make([]*types.Document, 5)
+	for i := range docs {
+		docs[i] = must.NotFail(types.NewDocument("_id", int32(i)))
+	}
+
+	msg := func(doc *types.Document) *wire.OpMsg {
+		var res wire.OpMsg
+		must.NoError(res.SetSections(wire.OpMsgSection{Documents: []*types.Document{doc}}))
+		return &res
+	}
+
+	reply, err := MakeCursorReply(ctx, "test.values", docs, 2, false, false)
+	require.NoError(t, err)
+	cursor := must.NotFail(must.NotFail(reply.Document()).Get("cursor")).(*types.Document)
+	assert.Equal(t, 2, must.NotFail(cursor.Get("firstBatch")).(*types.Array).Len())
+	id := must.NotFail(cursor.Get("id")).(int64)
+	require.NotZero(t, id)
+
+	t.Run("OtherUser", func(t *testing.T) {
+		otherCtx := conninfo.WithConnInfo(context.Background(), new(conninfo.ConnInfo))
+		_, err := MsgGetMore(otherCtx, msg(must.NotFail(types.NewDocument(
+			"getMore", id, "collection", "values", "$db", "test",
+		))), zap.NewNop())
+		var e *Error
+		require.ErrorAs(t, err, &e)
+		assert.Equal(t, ErrCursorNotFound, e.Code())
+	})
+
+	reply, err = MsgGetMore(ctx, msg(must.NotFail(types.NewDocument(
+		"getMore", id, "collection", "values", "batchSize", int32(2), "$db", "test",
+	))), zap.NewNop())
+	require.NoError(t, err)
+	cursor = must.NotFail(must.NotFail(reply.Document()).Get("cursor")).(*types.Document)
+	assert.Equal(t, 2, must.NotFail(cursor.Get("nextBatch")).(*types.Array).Len())
+	assert.Equal(t, id, must.NotFail(cursor.Get("id")))
+
+	killed, notFound := KillCursors(ctx, []int64{id, 42})
+	assert.Equal(t, []int64{id}, killed)
+	assert.Equal(t, []int64{42}, notFound)
+
+	_, err = MsgGetMore(ctx, msg(must.NotFail(types.NewDocument(
+		"getMore", id, "collection", "values", "$db", "test",
+	))), zap.NewNop())
+	var e *Error
+	require.ErrorAs(t, err, &e)
+	assert.Equal(t, ErrCursorNotFound, e.Code())
+
+	reply, err = MakeCursorReply(ctx, "test.values", docs, 2, true, false)
+	require.NoError(t, err)
+	cursor = must.NotFail(must.NotFail(reply.Document()).Get("cursor")).(*types.Document)
+	assert.Equal(t, int64(0), must.NotFail(cursor.Get("id")))
+}
+
+func TestCursorMetrics(t *testing.T) {
+	t.Parallel()
+
+	r := &cursorRegistry{m: map[int64]*cursor{}}
+	now := time.Now()
+
+	idle := r.store(&cursor{lastUsed: now})
+	r.store(&cursor{lastUsed: now, noTimeout: true})
+	killed := r.store(&cursor{lastUsed: now})
+
+	assert.True(t, r.kill(killed, "", now))
+	assert.False(t, r.kill(killed, "", now))
+
+	expected := must.NotFail(types.NewDocument(
+		"timedOut", int64(0),
+		"totalOpened", int64(3),
+		"killed", int64(1),
+		"open", must.NotFail(types.NewDocument(
+			"noTimeout", int64(1),
+			"pinned", int64(0),
+			"total", int64(2),
+		)),
+	))
+	assert.Equal(t, expected, r.metrics(now))
+
+	// only the cursor without noTimeout is closed
+	expected = must.NotFail(types.NewDocument(
+		"timedOut", int64(1),
+		"totalOpened", int64(3),
+		"killed", int64(1),
+		"open", must.NotFail(types.NewDocument(
+			"noTimeout", int64(1),
+			"pinned", int64(0),
+			"total", int64(1),
+		)),
+	))
+	assert.Equal(t, expected, r.metrics(now.Add(cursorTimeout+time.Second)))
+	assert.Nil(t, r.take(idle, "", now))
+}
+
+func TestKillConnCursors(t *testing.T) {
+	t.Parallel()
+
+	ctx := conninfo.WithConnInfo(context.Background(), new(conninfo.ConnInfo))
+	otherCtx := conninfo.WithConnInfo(context.Background(), new(conninfo.ConnInfo))
+
+	docs := make([]*types.Document, 2)
+	for i := range docs {
+		docs[i] = must.NotFail(types.NewDocument("_id", int32(i)))
+	}
+
+	cursorID := func(ctx context.Context, noTimeout bool) int64 {
+		t.Helper()
+
+		reply, err := MakeCursorReply(ctx, "test.values", docs, 1, false, noTimeout)
+		require.NoError(t, err)
+		cursor := must.NotFail(must.NotFail(reply.Document()).Get("cursor")).(*types.Document)
+		id := must.NotFail(cursor.Get("id")).(int64)
+		require.NotZero(t, id)
+
+		return id
+	}
+
+	noTimeout := cursorID(ctx, true)
+	timeout := cursorID(ctx, false)
+	other := cursorID(otherCtx, true)
+
+	// cursors of other connections and cursors with timeout are not closed
+	assert.Equal(t, 1, KillConnCursors(ctx))
+	assert.Equal(t, 0, KillConnCursors(ctx))
+
+	killed, notFound := KillCursors(ctx, []int64{noTimeout, timeout, other})
+	assert.Equal(t, []int64{timeout, other}, killed)
+	assert.Equal(t, []int64{noTimeout}, notFound)
+}
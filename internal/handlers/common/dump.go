@@ -0,0 +1,158 @@
+// Copyright 2021 FerretDB Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package common
+
+import (
+	"context"
+	"errors"
+	"io"
+	"strings"
+	"sync/atomic"
+
+	"github.com/FerretDB/FerretDB/internal/handlers/backend"
+	"github.com/FerretDB/FerretDB/internal/types"
+	"github.com/FerretDB/FerretDB/internal/util/archive"
+	"github.com/FerretDB/FerretDB/internal/util/lazyerrors"
+	"github.com/FerretDB/FerretDB/internal/util/must"
+	"github.com/FerretDB/FerretDB/internal/util/version"
+)
+
+// DumpStats represents the result of DumpArchive.
+type DumpStats struct {
+	Collections int
+	Documents   int64
+}
+
+// DumpArchive writes the given collections of the database to w in mongodump archive format,
+// so they could be restored by mongorestore --archive.
+//
+// If collections are not given, all database collections except system ones are written.
+// Documents are streamed from the backend one by one.
+// It returns backend.ErrCollectionNotExist if one of the given collections does not exist.
+func DumpArchive(ctx context.Context, b backend.Backend, w io.Writer, db string, collections []string) (*DumpStats, error) {
+	if len(collections) == 0 {
+		all, err := b.Collections(ctx, db)
+		if err != nil {
+			return nil, lazyerrors.Error(err)
+		}
+
+		for _, c := range all {
+			if !strings.HasPrefix(c, "system.") {
+				collections = append(collections, c)
+			}
+		}
+	}
+
+	prelude := make([]archive.Collection, len(collections))
+
+	for i, c := range collections {
+		indexes, err := b.Indexes(ctx, db, c)
+		if err != nil {
+			return nil, err
+		}
+
+		prelude[i] = archive.Collection{
+			DB:   db,
+			Name: c,
+		}
+
+		for _, index := range indexes {
+			if index.Internal {
+				continue
+			}
+
+			prelude[i].Indexes = append(prelude[i].Indexes, must.NotFail(types.NewDocument(
+				"v", int32(2),
+				"key", index.Key,
+				"name", index.Name,
+			)))
+		}
+	}
+
+	aw, err := archive.NewWriter(w, version.MongoDBVersion, "FerretDB "+version.Get().Version, prelude)
+	if err != nil {
+		return nil, lazyerrors.Error(err)
+	}
+
+	var stats DumpStats
+
+	for i := range prelude {
+		n, err := dumpCollection(ctx, b, aw, &prelude[i])
+		if err != nil {
+			return nil, err
+		}
+
+		stats.Collections++
+		stats.Documents += n
+	}
+
+	return &stats, nil
+}
+
+// dumpCollection writes all documents of the given collection to the archive.
+// It returns the number of written documents.
+func dumpCollection(ctx context.Context, b backend.Backend, aw *archive.Writer, c *archive.Collection) (int64, error) {
+	iter, err := b.QueryIterator(ctx, &backend.QueryParams{DB: c.DB, Collection: c.Name})
+	if err != nil {
+		return 0, err
+	}
+
+	defer iter.Close()
+
+	if err = aw.StartCollection(c); err != nil {
+		return 0, lazyerrors.Error(err)
+	}
+
+	var n int64
+
+	for {
+		var doc *types.Document
+		if doc, err = iter.Next(); err != nil {
+			if errors.Is(err, io.EOF) {
+				break
+			}
+
+			return 0, lazyerrors.Error(err)
+		}
+
+		if err = aw.WriteDocument(doc); err != nil {
+			return 0, lazyerrors.Error(err)
+		}
+
+		n++
+	}
+
+	if err = aw.FinishCollection(); err != nil {
+		return 0, lazyerrors.Error(err)
+	}
+
+	return n, nil
+}
+
+// archiveDir stores the directory for archives written by dumpArchive command.
+var archiveDir atomic.Value
+
+// GetArchiveDir returns the directory for archives written by dumpArchive command;
+// empty if the command is disabled.
+func GetArchiveDir() string {
+	dir, _ := archiveDir.Load().(string)
+	return dir
+}
+
+// SetArchiveDir changes the directory for archives written by dumpArchive command;
+// empty value disables the command.
+func SetArchiveDir(dir string) {
+	archiveDir.Store(dir)
+}
@@ -0,0 +1,142 @@
+// Copyright 2021 FerretDB Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package common
+
+import (
+	"bytes"
+	"encoding/binary"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap/zaptest"
+
+	"github.com/FerretDB/FerretDB/internal/clientconn/conninfo"
+	"github.com/FerretDB/FerretDB/internal/handlers/backend"
+	"github.com/FerretDB/FerretDB/internal/handlers/sqlite/sqlitedb"
+	"github.com/FerretDB/FerretDB/internal/types"
+	"github.com/FerretDB/FerretDB/internal/util/must"
+	"github.com/FerretDB/FerretDB/internal/util/testutil"
+	"github.com/FerretDB/FerretDB/internal/wire"
+)
+
+func TestDumpArchive(t *testing.T) {
+	t.Parallel()
+
+	ctx := testutil.Ctx(t)
+	l := zaptest.NewLogger(t)
+
+	db, err := sqlitedb.Open(ctx, filepath.Join(t.TempDir(), "test.sqlite"), l)
+	require.NoError(t, err)
+	t.Cleanup(db.Close)
+
+	docs := []*types.Document{
+		must.NotFail(types.NewDocument("_id", int32(1), "v", "foo")),
+		must.NotFail(types.NewDocument("_id", int32(2), "v", int64(42))),
+	}
+	require.NoError(t, db.InsertDocuments(ctx, "test", "values", docs))
+	require.NoError(t, db.CreateCollection(ctx, "test", "empty"))
+	require.NoError(t, db.CreateCollection(ctx, "test", "system.js"))
+
+	t.Run("All", func(t *testing.T) {
+		t.Parallel()
+
+		var buf bytes.Buffer
+		stats, err := DumpArchive(ctx, db, &buf, "test", nil)
+		require.NoError(t, err)
+		assert.Equal(t, &DumpStats{Collections: 2, Documents: 2}, stats)
+
+		assert.Equal(t, uint32(0x8199e26d), binary.LittleEndian.Uint32(buf.Bytes()))
+		assert.True(t, bytes.Contains(buf.Bytes(), []byte(`"collectionName":"empty"`)))
+		assert.False(t, bytes.Contains(buf.Bytes(), []byte("system.js")))
+	})
+
+	t.Run("Collection", func(t *testing.T) {
+		t.Parallel()
+
+		stats, err := DumpArchive(ctx, db, new(bytes.Buffer), "test", []string{"values"})
+		require.NoError(t, err)
+		assert.Equal(t, &DumpStats{Collections: 1, Documents: 2}, stats)
+	})
+
+	t.Run("NotExist", func(t *testing.T) {
+		t.Parallel()
+
+		_, err := DumpArchive(ctx, db, new(bytes.Buffer), "test", []string{"none"})
+		assert.ErrorIs(t, err, backend.ErrCollectionNotExist)
+	})
+}
+
+func TestMsgDumpArchive(t *testing.T) {
+	// not parallel because of the global archive directory
+
+	ctx := conninfo.WithConnInfo(testutil.Ctx(t), new(conninfo.ConnInfo))
+	l := zaptest.NewLogger(t)
+
+	db, err := sqlitedb.Open(ctx, filepath.Join(t.TempDir(), "test.sqlite"), l)
+	require.NoError(t, err)
+	t.Cleanup(db.Close)
+
+	doc := must.NotFail(types.NewDocument("_id", int32(1), "v", "foo"))
+	require.NoError(t, db.InsertDocument(ctx, "test", "values", doc))
+
+	msg := func(pairs ...any) *wire.OpMsg {
+		var res wire.OpMsg
+		must.NoError(res.SetSections(wire.OpMsgSection{
+			Documents: []*types.Document{must.NotFail(types.NewDocument(pairs...))},
+		}))
+		return &res
+	}
+
+	_, err = MsgDumpArchive(ctx, msg("dumpArchive", int32(1), "archive", "test.archive", "$db", "test"), db, l)
+	assert.Equal(t, NewErrorMsg(ErrIllegalOperation, "dumpArchive is disabled; archive directory is not set"), err)
+
+	dir := t.TempDir()
+	SetArchiveDir(dir)
+	t.Cleanup(func() { SetArchiveDir("") })
+
+	res, err := MsgDumpArchive(ctx, msg("dumpArchive", "values", "archive", "test.archive", "$db", "test"), db, l)
+	require.NoError(t, err)
+
+	actual := must.NotFail(res.Document())
+	assert.Equal(t, int32(1), must.NotFail(actual.Get("collections")))
+	assert.Equal(t, int64(1), must.NotFail(actual.Get("documents")))
+
+	b, err := os.ReadFile(filepath.Join(dir, "test.archive"))
+	require.NoError(t, err)
+	assert.Equal(t, uint32(0x8199e26d), binary.LittleEndian.Uint32(b))
+
+	_, err = MsgDumpArchive(ctx, msg("dumpArchive", int32(1), "archive", "test.archive", "$db", "test"), db, l)
+	assert.Equal(t, NewErrorMsg(ErrBadValue, "Archive 'test.archive' already exists"), err)
+
+	_, err = MsgDumpArchive(ctx, msg("dumpArchive", int32(1), "archive", "../test.archive", "$db", "test"), db, l)
+	assert.Equal(t, NewErrorMsg(
+		ErrBadValue, "Invalid archive name '../test.archive'; it should be a file name without directories",
+	), err)
+
+	_, err = MsgDumpArchive(ctx, msg("dumpArchive", "system.users", "archive", "users.archive", "$db", "admin"), db, l)
+	assert.Equal(t, NewErrorMsg(ErrUnauthorized, "dumpArchive of system collection admin.system.users is not allowed"), err)
+
+	_, err = os.Stat(filepath.Join(dir, "users.archive"))
+	assert.ErrorIs(t, err, os.ErrNotExist)
+
+	_, err = MsgDumpArchive(ctx, msg("dumpArchive", "none", "archive", "none.archive", "$db", "test"), db, l)
+	assert.Equal(t, NewErrorMsg(ErrNamespaceNotFound, "ns not found"), err)
+
+	_, err = os.Stat(filepath.Join(dir, "none.archive"))
+	assert.ErrorIs(t, err, os.ErrNotExist)
+}
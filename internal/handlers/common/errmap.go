@@ -0,0 +1,101 @@
+// Copyright 2021 FerretDB Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package common
+
+import (
+	"errors"
+
+	"github.com/FerretDB/FerretDB/internal/handlers/backend"
+)
+
+// Error sources returned by ErrorSource.
+const (
+	// ErrorSourceHandler is a protocol error returned by the handler.
+	ErrorSourceHandler = "handler"
+
+	// ErrorSourceBackend is an error caused by the storage or its driver; see backend.TranslateError.
+	ErrorSourceBackend = "backend"
+
+	// ErrorSourceInternal is any other error; it is returned to the client as InternalError.
+	ErrorSourceInternal = "internal"
+)
+
+// writeConflictMsg is the message of WriteConflict error; it matches MongoDB.
+const writeConflictMsg = "WriteConflict error: this operation conflicted with another operation. Please retry your operation."
+
+// backendErrorMapping represents MongoDB error code and message for backend error code.
+type backendErrorMapping struct {
+	code ErrorCode
+	msg  string // if empty, backend's safe message is used
+}
+
+// backendErrors maps backend error codes to MongoDB errors.
+var backendErrors = map[backend.ErrorCode]backendErrorMapping{
+	backend.ErrorCodeInternal:             {errInternalError, ""},
+	backend.ErrorCodeUnavailable:          {ErrHostUnreachable, ""},
+	backend.ErrorCodeOutOfDiskSpace:       {ErrOutOfDiskSpace, ""},
+	backend.ErrorCodeWriteConflict:        {ErrWriteConflict, writeConflictMsg},
+	backend.ErrorCodeDuplicateKey:         {ErrDuplicateKey, "E11000 duplicate key error"},
+	backend.ErrorCodeTimeout:              {ErrMaxTimeMSExpired, "operation exceeded time limit"},
+	backend.ErrorCodeUnauthorized:         {ErrUnauthorized, "not authorized to execute command"},
+	backend.ErrorCodeAuthenticationFailed: {ErrAuthenticationFailed, "Authentication failed."},
+	backend.ErrorCodeReadOnly:             {ErrNotWritablePrimary, "not primary"},
+	backend.ErrorCodeDatabaseNotExist:     {ErrNamespaceNotFound, "database does not exist"},
+	backend.ErrorCodeCollectionNotExist:   {ErrNamespaceNotFound, "ns not found"},
+}
+
+// ErrorSource returns the source of the error returned by the handler, one of ErrorSourceXXX constants.
+func ErrorSource(err error) string {
+	if backend.TranslateError(err) != nil {
+		return ErrorSourceBackend
+	}
+
+	if _, ok := ProtocolError(err); ok {
+		return ErrorSourceHandler
+	}
+
+	return ErrorSourceInternal
+}
+
+// mapBackendError converts errors caused by the storage or its driver to protocol errors, see ProtocolError.
+//
+// Each backend translates errors of its storage to backend.Error (see backend.TranslateError)
+// with a safe message that is used instead of the storage error text; the text is logged by the caller.
+// Protocol errors returned by handlers that wrap storage errors are mapped by the storage error's code.
+// Unknown storage errors are mapped to InternalError.
+//
+// It returns false as the last value if the error is not caused by the storage.
+func mapBackendError(err error) (protoErr *Error, recoverable, ok bool) {
+	be := backend.TranslateError(err)
+	if be == nil {
+		return nil, false, false
+	}
+
+	m, known := backendErrors[be.Code()]
+	if !known {
+		m = backendErrors[backend.ErrorCodeInternal]
+	}
+
+	msg := m.msg
+	if msg == "" {
+		msg = be.Message()
+	}
+
+	if m.code == errInternalError {
+		return &Error{code: errInternalError, err: errors.New(msg)}, false, true
+	}
+
+	return &Error{code: m.code, err: errors.New(msg)}, true, true
+}
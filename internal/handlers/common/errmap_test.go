@@ -0,0 +1,129 @@
+// Copyright 2021 FerretDB Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package common
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/FerretDB/FerretDB/internal/handlers/backend"
+	"github.com/FerretDB/FerretDB/internal/util/lazyerrors"
+)
+
+func TestMapBackendError(t *testing.T) {
+	t.Parallel()
+
+	backendErr := func(code backend.ErrorCode, msg string) error {
+		return backend.NewError(code, msg, errors.New(`relation "secret_table" does not exist`))
+	}
+
+	for name, tc := range map[string]struct {
+		err         error
+		code        ErrorCode
+		msg         string
+		recoverable bool
+		source      string
+	}{
+		"Known": {
+			err:         lazyerrors.Error(backendErr(backend.ErrorCodeDuplicateKey, "unique violation")),
+			code:        ErrDuplicateKey,
+			msg:         "E11000 duplicate key error",
+			recoverable: true,
+			source:      ErrorSourceBackend,
+		},
+		"BackendMessage": {
+			err:         lazyerrors.Error(backendErr(backend.ErrorCodeUnavailable, "connection failed")),
+			code:        ErrHostUnreachable,
+			msg:         "connection failed",
+			recoverable: true,
+			source:      ErrorSourceBackend,
+		},
+		"Unknown": {
+			err:         lazyerrors.Error(backendErr(backend.ErrorCodeInternal, "error 42")),
+			code:        errInternalError,
+			msg:         "error 42",
+			recoverable: false,
+			source:      ErrorSourceBackend,
+		},
+		"Wrapped": {
+			err: NewError(
+				ErrBadValue, fmt.Errorf("regex: %w", backendErr(backend.ErrorCodeDuplicateKey, "unique violation")),
+			),
+			code:        ErrDuplicateKey,
+			msg:         "E11000 duplicate key error",
+			recoverable: true,
+			source:      ErrorSourceBackend,
+		},
+		"WrappedUnknown": {
+			err:         NewError(ErrBadValue, fmt.Errorf("regex: %w", backendErr(backend.ErrorCodeInternal, "error 42"))),
+			code:        errInternalError,
+			msg:         "error 42",
+			recoverable: false,
+			source:      ErrorSourceBackend,
+		},
+		"CollectionNotExist": {
+			err:         lazyerrors.Error(backendErr(backend.ErrorCodeCollectionNotExist, "table does not exist")),
+			code:        ErrNamespaceNotFound,
+			msg:         "ns not found",
+			recoverable: true,
+			source:      ErrorSourceBackend,
+		},
+		"Handler": {
+			err:         NewErrorMsg(ErrBadValue, "bad value"),
+			code:        ErrBadValue,
+			msg:         "bad value",
+			recoverable: true,
+			source:      ErrorSourceHandler,
+		},
+		"Internal": {
+			err:         errors.New("oops"),
+			code:        errInternalError,
+			msg:         "oops",
+			recoverable: false,
+			source:      ErrorSourceInternal,
+		},
+	} {
+		name, tc := name, tc
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			protoErr, recoverable := ProtocolError(tc.err)
+			assert.Equal(t, tc.code, protoErr.Code())
+			assert.Equal(t, tc.recoverable, recoverable)
+			assert.Equal(t, tc.source, ErrorSource(tc.err))
+
+			errmsg, err := protoErr.Document().Get("errmsg")
+			require.NoError(t, err)
+			assert.NotContains(t, errmsg, "secret_table")
+
+			var e *Error
+			require.True(t, errors.As(protoErr, &e))
+			assert.Equal(t, tc.msg, e.Unwrap().Error())
+		})
+	}
+
+	t.Run("WriteErrors", func(t *testing.T) {
+		t.Parallel()
+
+		var we WriteErrors
+		we.Append(lazyerrors.Error(backendErr(backend.ErrorCodeDuplicateKey, "unique violation")), 1)
+		assert.Equal(t, ErrDuplicateKey, we.Code())
+		assert.Equal(t, "E11000 duplicate key error,", we.Error())
+	})
+}
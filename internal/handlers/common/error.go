@@ -15,9 +15,12 @@
 package common
 
 import (
+	"context"
 	"errors"
 	"fmt"
 
+	"golang.org/x/exp/slices"
+
 	"github.com/FerretDB/FerretDB/internal/types"
 	"github.com/FerretDB/FerretDB/internal/util/must"
 )
@@ -36,36 +39,112 @@ const (
 	// ErrBadValue indicates wrong input.
 	ErrBadValue = ErrorCode(2) // BadValue
 
+	// ErrHostUnreachable indicates that the storage backend is unreachable.
+	ErrHostUnreachable = ErrorCode(6) // HostUnreachable
+
 	// ErrFailedToParse indicates user input parsing failure.
 	ErrFailedToParse = ErrorCode(9) // FailedToParse
 
+	// ErrUserNotFound indicates that a user is not found.
+	ErrUserNotFound = ErrorCode(11) // UserNotFound
+
+	// ErrUnauthorized indicates that the command requires authentication.
+	ErrUnauthorized = ErrorCode(13) // Unauthorized
+
 	// ErrTypeMismatch for $sort indicates that the expression in the $sort is not an object.
 	ErrTypeMismatch = ErrorCode(14) // TypeMismatch
 
+	// ErrInvalidLength indicates that the write batch is too large.
+	ErrInvalidLength = ErrorCode(16) // InvalidLength
+
+	// ErrAuthenticationFailed indicates failed authentication.
+	ErrAuthenticationFailed = ErrorCode(18) // AuthenticationFailed
+
+	// ErrIllegalOperation indicates that the operation is not allowed in the current state.
+	ErrIllegalOperation = ErrorCode(20) // IllegalOperation
+
 	// ErrNamespaceNotFound indicates that a collection is not found.
 	ErrNamespaceNotFound = ErrorCode(26) // NamespaceNotFound
 
+	// ErrPathNotViable indicates that the update path can't be created.
+	ErrPathNotViable = ErrorCode(28) // PathNotViable
+
 	// ErrConflictingUpdateOperators indicates that $set, $inc or $setOnInsert were used together.
 	ErrConflictingUpdateOperators = ErrorCode(40) // ConflictingUpdateOperators
 
+	// ErrCursorNotFound indicates that a cursor is not found.
+	ErrCursorNotFound = ErrorCode(43) // CursorNotFound
+
 	// ErrNamespaceExists indicates that the collection already exists.
 	ErrNamespaceExists = ErrorCode(48) // NamespaceExists
 
+	// ErrMaxTimeMSExpired indicates that the command exceeded the time limit set by maxTimeMS.
+	ErrMaxTimeMSExpired = ErrorCode(50) // MaxTimeMSExpired
+
+	// ErrDollarPrefixedFieldName indicates that the stored document has a field name with a leading dollar.
+	ErrDollarPrefixedFieldName = ErrorCode(52) // DollarPrefixedFieldName
+
+	// ErrEmptyFieldName indicates that the update path contains an empty field name.
+	ErrEmptyFieldName = ErrorCode(56) // EmptyFieldName
+
+	// ErrDottedFieldName indicates that the stored document has a field name with a dot.
+	ErrDottedFieldName = ErrorCode(57) // DottedFieldName
+
 	// ErrCommandNotFound indicates unknown command input.
 	ErrCommandNotFound = ErrorCode(59) // CommandNotFound
 
+	// ErrInvalidOptions indicates that the given option is not supported.
+	ErrInvalidOptions = ErrorCode(72) // InvalidOptions
+
 	// ErrInvalidNamespace indicates that the collection name is empty.
 	ErrInvalidNamespace = ErrorCode(73) // InvalidNamespace
 
+	// ErrIndexOptionsConflict indicates that index with the same key but different name already exists.
+	ErrIndexOptionsConflict = ErrorCode(85) // IndexOptionsConflict
+
+	// ErrIndexKeySpecsConflict indicates that index with the same name but different key already exists.
+	ErrIndexKeySpecsConflict = ErrorCode(86) // IndexKeySpecsConflict
+
+	// ErrShutdownInProgress indicates that the server is shutting down.
+	ErrShutdownInProgress = ErrorCode(91) // ShutdownInProgress
+
+	// ErrWriteConflict indicates that the write conflicted with another concurrent operation and could be retried.
+	ErrWriteConflict = ErrorCode(112) // WriteConflict
+
+	// ErrInvalidIndexSpecificationOption indicates that the index or clustered index specification is invalid.
+	ErrInvalidIndexSpecificationOption = ErrorCode(197) // InvalidIndexSpecificationOption
+
 	// ErrNotImplemented indicates that a flag or command is not implemented.
 	ErrNotImplemented = ErrorCode(238) // NotImplemented
 
+	// ErrMechanismUnavailable indicates that the requested authentication mechanism is not supported.
+	ErrMechanismUnavailable = ErrorCode(334) // MechanismUnavailable
+
+	// ErrRateLimitExceeded indicates that the client exceeded configured operation limits.
+	// The operation can be retried later.
+	ErrRateLimitExceeded = ErrorCode(462) // IngressRequestRateLimitExceeded
+
+	// ErrNotWritablePrimary indicates that the write can't be performed because the backend is read-only.
+	ErrNotWritablePrimary = ErrorCode(10107) // NotWritablePrimary
+
+	// ErrBSONObjectTooLarge indicates that the document exceeds the maximum BSON object size.
+	ErrBSONObjectTooLarge = ErrorCode(10334) // BSONObjectTooLarge
+
+	// ErrDuplicateKey indicates that a document with the same _id already exists.
+	ErrDuplicateKey = ErrorCode(11000) // DuplicateKey
+
+	// ErrOutOfDiskSpace indicates that the storage backend is out of disk space.
+	ErrOutOfDiskSpace = ErrorCode(14031) // OutOfDiskSpace
+
 	// ErrSortBadValue indicates bad value in sort input.
 	ErrSortBadValue = ErrorCode(15974) // Location15974
 
 	// ErrSortBadOrder indicates bad sort order input.
 	ErrSortBadOrder = ErrorCode(15975) // Location15975
 
+	// ErrEmptyFieldPath indicates that the field path contains an empty field name.
+	ErrEmptyFieldPath = ErrorCode(15998) // Location15998
+
 	// ErrInvalidArg indicates invalid argument in projection document.
 	ErrInvalidArg = ErrorCode(28667) // Location28667
 
@@ -84,6 +163,9 @@ const (
 	// by command-line or config file.
 	ErrFreeMonitoringDisabled = ErrorCode(50840) // Location50840
 
+	// ErrUserAlreadyExists indicates that a user with the given name already exists.
+	ErrUserAlreadyExists = ErrorCode(51003) // Location51003
+
 	// ErrRegexOptions indicates regex options error.
 	ErrRegexOptions = ErrorCode(51075) // Location51075
 
@@ -91,6 +173,12 @@ const (
 	ErrRegexMissingParen = ErrorCode(51091) // Location51091
 )
 
+// errorLabels contains error labels for error codes that have them.
+// Drivers use labels to decide whether the operation can be retried.
+var errorLabels = map[ErrorCode][]any{
+	ErrRateLimitExceeded: {"RetryableWriteError", "SystemOverloadedError"},
+}
+
 // ProtoErr represents protocol error type.
 type ProtoErr interface {
 	error
@@ -102,13 +190,18 @@ type ProtoErr interface {
 
 // ProtocolError converts any error to wire protocol error.
 //
-// Nil panics, *Error or *WriteErrors (possibly wrapped) is returned unwrapped with true,
+// Nil panics, errors caused by the storage are mapped to *Error without storage error text (see mapBackendError),
+// *Error or *WriteErrors (possibly wrapped) is returned unwrapped with true,
 // any other value is wrapped with InternalError and returned with false.
 func ProtocolError(err error) (ProtoErr, bool) {
 	if err == nil {
 		panic("err is nil")
 	}
 
+	if e, recoverable, ok := mapBackendError(err); ok {
+		return e, recoverable
+	}
+
 	var e *Error
 	if errors.As(err, &e) {
 		return e, true
@@ -122,6 +215,18 @@ func ProtocolError(err error) (ProtoErr, bool) {
 	return NewError(errInternalError, err).(*Error), false
 }
 
+// IsWriteError returns true if the error of a single statement of insert, update, or delete command
+// should be reported as a write error for that statement (see WriteErrors.Append),
+// and false if the whole command should fail with it: for unexpected errors and when ctx is done.
+func IsWriteError(ctx context.Context, err error) bool {
+	if ctx.Err() != nil {
+		return false
+	}
+
+	_, ok := ProtocolError(err)
+	return ok
+}
+
 // CommandError represents wire protocol command error.
 type CommandError = Error
 
@@ -179,6 +284,9 @@ func (e *Error) Document() *types.Document {
 		must.NoError(d.Set("code", int32(e.code)))
 		must.NoError(d.Set("codeName", e.code.String()))
 	}
+	if labels := errorLabels[e.code]; labels != nil {
+		must.NoError(d.Set("errorLabels", must.NotFail(types.NewArray(labels...))))
+	}
 	return d
 }
 
@@ -194,6 +302,45 @@ func NewWriteErrorMsg(code ErrorCode, msg string) error {
 	}}
 }
 
+// Append converts the given error to a protocol write error for the document with the given index
+// in the write batch, and appends it keeping write errors sorted by index.
+//
+// Errors that are not protocol errors are appended as internal errors.
+func (we *WriteErrors) Append(err error, index int32) {
+	protoErr, _ := ProtocolError(err)
+
+	switch protoErr := protoErr.(type) {
+	case *Error:
+		we.insert(writeError{
+			index: index,
+			code:  protoErr.code,
+			err:   protoErr.err.Error(),
+		})
+	case *WriteErrors:
+		for _, e := range *protoErr {
+			e.index = index
+			we.insert(e)
+		}
+	}
+}
+
+// Merge appends all write errors from other, keeping write errors sorted by index.
+func (we *WriteErrors) Merge(other WriteErrors) {
+	for _, e := range other {
+		we.insert(e)
+	}
+}
+
+// insert inserts write error after all errors with the same or smaller index.
+func (we *WriteErrors) insert(e writeError) {
+	i := len(*we)
+	for i > 0 && (*we)[i-1].index > e.index {
+		i--
+	}
+
+	*we = slices.Insert(*we, i, e)
+}
+
 // Error implements error interface.
 func (we *WriteErrors) Error() string {
 	var err string
@@ -227,6 +374,7 @@ func (we *WriteErrors) Document() *types.Document {
 		// Fields "code" and "errmsg" must always be filled in so that clients can parse the error message.
 		// Otherwise, the mongo client would parse it as a CommandError.
 		must.NoError(errs.Append(must.NotFail(types.NewDocument(
+			"index", e.index,
 			"code", int32(e.code),
 			"errmsg", e.err,
 		))))
@@ -243,8 +391,9 @@ func (we *WriteErrors) Document() *types.Document {
 // writeError represents protocol write error.
 // It required to build the correct write error result.
 type writeError struct {
-	code ErrorCode
-	err  string
+	index int32 // index of the document in the write batch
+	code  ErrorCode
+	err   string
 }
 
 // formatBitwiseOperatorErr formats protocol error for given internal error and bitwise operator.
@@ -0,0 +1,43 @@
+// Copyright 2021 FerretDB Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package common
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWriteErrorsAppend(t *testing.T) {
+	t.Parallel()
+
+	var we WriteErrors
+	we.Append(NewErrorMsg(ErrBadValue, "bad value"), 3)
+	we.Append(NewWriteErrorMsg(ErrDottedFieldName, "dotted"), 1)
+	we.Append(errors.New("internal"), 3)
+
+	var other WriteErrors
+	other.Append(NewErrorMsg(ErrTypeMismatch, "type mismatch"), 2)
+	we.Merge(other)
+
+	expected := WriteErrors{
+		{index: 1, code: ErrDottedFieldName, err: "dotted"},
+		{index: 2, code: ErrTypeMismatch, err: "type mismatch"},
+		{index: 3, code: ErrBadValue, err: "bad value"},
+		{index: 3, code: errInternalError, err: "internal"},
+	}
+	assert.Equal(t, expected, we)
+}
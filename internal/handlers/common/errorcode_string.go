@@ -11,48 +11,100 @@ func _() {
 	_ = x[errUnset-0]
 	_ = x[errInternalError-1]
 	_ = x[ErrBadValue-2]
+	_ = x[ErrHostUnreachable-6]
 	_ = x[ErrFailedToParse-9]
+	_ = x[ErrUserNotFound-11]
+	_ = x[ErrUnauthorized-13]
 	_ = x[ErrTypeMismatch-14]
+	_ = x[ErrInvalidLength-16]
+	_ = x[ErrAuthenticationFailed-18]
+	_ = x[ErrIllegalOperation-20]
 	_ = x[ErrNamespaceNotFound-26]
+	_ = x[ErrPathNotViable-28]
 	_ = x[ErrConflictingUpdateOperators-40]
+	_ = x[ErrCursorNotFound-43]
 	_ = x[ErrNamespaceExists-48]
+	_ = x[ErrMaxTimeMSExpired-50]
+	_ = x[ErrDollarPrefixedFieldName-52]
+	_ = x[ErrEmptyFieldName-56]
+	_ = x[ErrDottedFieldName-57]
 	_ = x[ErrCommandNotFound-59]
+	_ = x[ErrInvalidOptions-72]
 	_ = x[ErrInvalidNamespace-73]
+	_ = x[ErrIndexOptionsConflict-85]
+	_ = x[ErrIndexKeySpecsConflict-86]
+	_ = x[ErrShutdownInProgress-91]
+	_ = x[ErrWriteConflict-112]
+	_ = x[ErrInvalidIndexSpecificationOption-197]
 	_ = x[ErrNotImplemented-238]
+	_ = x[ErrMechanismUnavailable-334]
+	_ = x[ErrRateLimitExceeded-462]
+	_ = x[ErrNotWritablePrimary-10107]
+	_ = x[ErrBSONObjectTooLarge-10334]
+	_ = x[ErrDuplicateKey-11000]
+	_ = x[ErrOutOfDiskSpace-14031]
 	_ = x[ErrSortBadValue-15974]
 	_ = x[ErrSortBadOrder-15975]
+	_ = x[ErrEmptyFieldPath-15998]
 	_ = x[ErrInvalidArg-28667]
 	_ = x[ErrSliceFirstArg-28724]
 	_ = x[ErrProjectionInEx-31253]
 	_ = x[ErrProjectionExIn-31254]
 	_ = x[ErrFreeMonitoringDisabled-50840]
+	_ = x[ErrUserAlreadyExists-51003]
 	_ = x[ErrRegexOptions-51075]
 	_ = x[ErrRegexMissingParen-51091]
 }
 
-const _ErrorCode_name = "UnsetInternalErrorBadValueFailedToParseTypeMismatchNamespaceNotFoundConflictingUpdateOperatorsNamespaceExistsCommandNotFoundInvalidNamespaceNotImplementedLocation15974Location15975Location28667Location28724Location31253Location31254Location50840Location51075Location51091"
+const _ErrorCode_name = "UnsetInternalErrorBadValueHostUnreachableFailedToParseUserNotFoundUnauthorizedTypeMismatchInvalidLengthAuthenticationFailedIllegalOperationNamespaceNotFoundPathNotViableConflictingUpdateOperatorsCursorNotFoundNamespaceExistsMaxTimeMSExpiredDollarPrefixedFieldNameEmptyFieldNameDottedFieldNameCommandNotFoundInvalidOptionsInvalidNamespaceIndexOptionsConflictIndexKeySpecsConflictShutdownInProgressWriteConflictInvalidIndexSpecificationOptionNotImplementedMechanismUnavailableIngressRequestRateLimitExceededNotWritablePrimaryBSONObjectTooLargeDuplicateKeyOutOfDiskSpaceLocation15974Location15975Location15998Location28667Location28724Location31253Location31254Location50840Location51003Location51075Location51091"
 
 var _ErrorCode_map = map[ErrorCode]string{
 	0:     _ErrorCode_name[0:5],
 	1:     _ErrorCode_name[5:18],
 	2:     _ErrorCode_name[18:26],
-	9:     _ErrorCode_name[26:39],
-	14:    _ErrorCode_name[39:51],
-	26:    _ErrorCode_name[51:68],
-	40:    _ErrorCode_name[68:94],
-	48:    _ErrorCode_name[94:109],
-	59:    _ErrorCode_name[109:124],
-	73:    _ErrorCode_name[124:140],
-	238:   _ErrorCode_name[140:154],
-	15974: _ErrorCode_name[154:167],
-	15975: _ErrorCode_name[167:180],
-	28667: _ErrorCode_name[180:193],
-	28724: _ErrorCode_name[193:206],
-	31253: _ErrorCode_name[206:219],
-	31254: _ErrorCode_name[219:232],
-	50840: _ErrorCode_name[232:245],
-	51075: _ErrorCode_name[245:258],
-	51091: _ErrorCode_name[258:271],
+	6:     _ErrorCode_name[26:41],
+	9:     _ErrorCode_name[41:54],
+	11:    _ErrorCode_name[54:66],
+	13:    _ErrorCode_name[66:78],
+	14:    _ErrorCode_name[78:90],
+	16:    _ErrorCode_name[90:103],
+	18:    _ErrorCode_name[103:123],
+	20:    _ErrorCode_name[123:139],
+	26:    _ErrorCode_name[139:156],
+	28:    _ErrorCode_name[156:169],
+	40:    _ErrorCode_name[169:195],
+	43:    _ErrorCode_name[195:209],
+	48:    _ErrorCode_name[209:224],
+	50:    _ErrorCode_name[224:240],
+	52:    _ErrorCode_name[240:263],
+	56:    _ErrorCode_name[263:277],
+	57:    _ErrorCode_name[277:292],
+	59:    _ErrorCode_name[292:307],
+	72:    _ErrorCode_name[307:321],
+	73:    _ErrorCode_name[321:337],
+	85:    _ErrorCode_name[337:357],
+	86:    _ErrorCode_name[357:378],
+	91:    _ErrorCode_name[378:396],
+	112:   _ErrorCode_name[396:409],
+	197:   _ErrorCode_name[409:440],
+	238:   _ErrorCode_name[440:454],
+	334:   _ErrorCode_name[454:474],
+	462:   _ErrorCode_name[474:505],
+	10107: _ErrorCode_name[505:523],
+	10334: _ErrorCode_name[523:541],
+	11000: _ErrorCode_name[541:553],
+	14031: _ErrorCode_name[553:567],
+	15974: _ErrorCode_name[567:580],
+	15975: _ErrorCode_name[580:593],
+	15998: _ErrorCode_name[593:606],
+	28667: _ErrorCode_name[606:619],
+	28724: _ErrorCode_name[619:632],
+	31253: _ErrorCode_name[632:645],
+	31254: _ErrorCode_name[645:658],
+	50840: _ErrorCode_name[658:671],
+	51003: _ErrorCode_name[671:684],
+	51075: _ErrorCode_name[684:697],
+	51091: _ErrorCode_name[697:710],
 }
 
 func (i ErrorCode) String() string {
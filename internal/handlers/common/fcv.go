@@ -0,0 +1,205 @@
+// Copyright 2021 FerretDB Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package common
+
+import (
+	"context"
+	"fmt"
+	"sync/atomic"
+
+	"go.uber.org/zap"
+
+	"github.com/FerretDB/FerretDB/internal/fjson"
+	"github.com/FerretDB/FerretDB/internal/handlers/backend"
+	"github.com/FerretDB/FerretDB/internal/types"
+	"github.com/FerretDB/FerretDB/internal/util/lazyerrors"
+	"github.com/FerretDB/FerretDB/internal/util/must"
+)
+
+// FCV represents the feature compatibility version.
+//
+// Behaviors that older FerretDB versions do not understand (see FCVFeature) are enabled
+// only when the FCV is high enough, so a fleet of FerretDB instances sharing the same backend
+// could be upgraded one by one, and only then switched to the new behaviors with
+// setFeatureCompatibilityVersion command.
+type FCV int32
+
+const (
+	// FCV44 disables all gated behaviors, like MongoDB 4.4.
+	FCV44 FCV = iota + 1
+
+	// FCV50 enables all gated behaviors, like MongoDB 5.0.
+	FCV50
+)
+
+// AllFCVs includes all feature compatibility versions, with the first one being the default (and the latest).
+var AllFCVs = []FCV{FCV50, FCV44}
+
+// String implements fmt.Stringer interface.
+func (v FCV) String() string {
+	switch v {
+	case FCV44:
+		return "4.4"
+	case FCV50:
+		return "5.0"
+	default:
+		return fmt.Sprintf("FCV(%d)", int32(v))
+	}
+}
+
+// ParseFCV returns FCV for the given string representation.
+func ParseFCV(s string) (FCV, error) {
+	for _, v := range AllFCVs {
+		if v.String() == s {
+			return v, nil
+		}
+	}
+
+	return AllFCVs[0], fmt.Errorf("unknown feature compatibility version %q", s)
+}
+
+// fcv stores the current FCV.
+var fcv = int32(AllFCVs[0])
+
+// GetFCV returns the current feature compatibility version.
+func GetFCV() FCV {
+	return FCV(atomic.LoadInt32(&fcv))
+}
+
+// SetFCV changes the current feature compatibility version.
+// It is safe to call it concurrently with requests handling.
+func SetFCV(v FCV) {
+	atomic.StoreInt32(&fcv, int32(v))
+}
+
+// FCVFeature represents a behavior gated by the feature compatibility version.
+type FCVFeature string
+
+const (
+	// FCVFeatureRelaxedFieldNames allows FieldNamesRelaxed mode;
+	// FieldNamesStrict mode is used for lower FCVs.
+	FCVFeatureRelaxedFieldNames = FCVFeature("relaxedFieldNames")
+
+	// FCVFeatureStorageFormatV2 stores new and migrated collections in fjson.Version2 format;
+	// fjson.Version1 is used for lower FCVs.
+	FCVFeatureStorageFormatV2 = FCVFeature("storageFormatV2")
+)
+
+// fcvFeatures maps gated behaviors to the lowest FCV that enables them.
+var fcvFeatures = map[FCVFeature]FCV{
+	FCVFeatureRelaxedFieldNames: FCV50,
+	FCVFeatureStorageFormatV2:   FCV50,
+}
+
+// Enables returns true if the given behavior is enabled by that FCV.
+func (v FCV) Enables(f FCVFeature) bool {
+	lowest, ok := fcvFeatures[f]
+	if !ok {
+		panic(fmt.Sprintf("unknown FCV feature %q", f))
+	}
+
+	return v >= lowest
+}
+
+// FCVFeatureEnabled returns true if the given behavior is enabled by the current FCV.
+func FCVFeatureEnabled(f FCVFeature) bool {
+	return GetFCV().Enables(f)
+}
+
+// StorageFormatVersion returns FJSON format version for new and migrated collections
+// permitted by the current FCV.
+func StorageFormatVersion() fjson.Version {
+	if FCVFeatureEnabled(FCVFeatureStorageFormatV2) {
+		return fjson.LatestVersion
+	}
+
+	return fjson.Version1
+}
+
+// The FCV is persisted in the same place as MongoDB does it.
+const (
+	fcvDB         = "admin"
+	fcvCollection = "system.version"
+	fcvID         = "featureCompatibilityVersion"
+)
+
+// LoadFCV sets the current FCV to the value persisted in the backend by setFeatureCompatibilityVersion command.
+//
+// If there is no such value, the current FCV (set by the caller on startup) is kept.
+func LoadFCV(ctx context.Context, b backend.Backend, l *zap.Logger) error {
+	exists, err := b.CollectionExists(ctx, fcvDB, fcvCollection)
+	if err != nil {
+		return lazyerrors.Error(err)
+	}
+
+	if !exists {
+		l.Info("Feature compatibility version is not persisted, using default.", zap.Stringer("version", GetFCV()))
+		return nil
+	}
+
+	res, err := backend.QueryDocuments(ctx, b, &backend.QueryParams{DB: fcvDB, Collection: fcvCollection})
+	if err != nil {
+		return lazyerrors.Error(err)
+	}
+
+	for _, doc := range res.Docs {
+		if id, _ := doc.Get("_id"); id != fcvID {
+			continue
+		}
+
+		s, err := GetRequiredParam[string](doc, "version")
+		if err != nil {
+			return lazyerrors.Error(err)
+		}
+
+		v, err := ParseFCV(s)
+		if err != nil {
+			return lazyerrors.Error(err)
+		}
+
+		SetFCV(v)
+		l.Info("Feature compatibility version loaded.", zap.Stringer("version", v))
+
+		return nil
+	}
+
+	l.Info("Feature compatibility version is not persisted, using default.", zap.Stringer("version", GetFCV()))
+
+	return nil
+}
+
+// storeFCV persists the given FCV in the backend.
+func storeFCV(ctx context.Context, b backend.Backend, v FCV) error {
+	if _, err := b.CreateCollectionIfNotExist(ctx, fcvDB, fcvCollection); err != nil {
+		return lazyerrors.Error(err)
+	}
+
+	doc := must.NotFail(types.NewDocument("_id", fcvID, "version", v.String()))
+
+	inserted, err := b.InsertDocumentIfNotExists(ctx, fcvDB, fcvCollection, doc)
+	if err != nil {
+		return lazyerrors.Error(err)
+	}
+
+	if inserted {
+		return nil
+	}
+
+	if _, err = b.SetDocumentByID(ctx, fcvDB, fcvCollection, fcvID, doc); err != nil {
+		return lazyerrors.Error(err)
+	}
+
+	return nil
+}
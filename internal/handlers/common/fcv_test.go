@@ -0,0 +1,85 @@
+// Copyright 2021 FerretDB Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package common
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap/zaptest"
+
+	"github.com/FerretDB/FerretDB/internal/fjson"
+	"github.com/FerretDB/FerretDB/internal/handlers/backend"
+	"github.com/FerretDB/FerretDB/internal/handlers/sqlite/sqlitedb"
+	"github.com/FerretDB/FerretDB/internal/util/testutil"
+)
+
+func TestParseFCV(t *testing.T) {
+	t.Parallel()
+
+	for _, v := range AllFCVs {
+		actual, err := ParseFCV(v.String())
+		require.NoError(t, err)
+		assert.Equal(t, v, actual)
+	}
+
+	_, err := ParseFCV("4.2")
+	assert.Error(t, err)
+}
+
+func TestFCVEnables(t *testing.T) {
+	t.Parallel()
+
+	for f := range fcvFeatures {
+		assert.True(t, AllFCVs[0].Enables(f), "%s", f)
+		assert.False(t, FCV44.Enables(f), "%s", f)
+	}
+
+	assert.Panics(t, func() { FCV50.Enables(FCVFeature("unknown")) })
+
+	// the default FCV must not change the storage format
+	assert.Equal(t, fjson.LatestVersion, StorageFormatVersion())
+}
+
+func TestFCVPersistence(t *testing.T) {
+	t.Parallel()
+
+	ctx := testutil.Ctx(t)
+	l := zaptest.NewLogger(t)
+
+	db, err := sqlitedb.Open(ctx, filepath.Join(t.TempDir(), "test.sqlite"), l)
+	require.NoError(t, err)
+	t.Cleanup(db.Close)
+
+	// lower versions are not stored, as they change behavior for concurrent tests
+	require.NoError(t, LoadFCV(ctx, db, l))
+	assert.Equal(t, AllFCVs[0], GetFCV())
+
+	require.NoError(t, storeFCV(ctx, db, AllFCVs[0]))
+	require.NoError(t, storeFCV(ctx, db, AllFCVs[0]))
+
+	res, err := backend.QueryDocuments(ctx, db, &backend.QueryParams{DB: fcvDB, Collection: fcvCollection})
+	require.NoError(t, err)
+	require.Len(t, res.Docs, 1)
+
+	v, err := GetRequiredParam[string](res.Docs[0], "version")
+	require.NoError(t, err)
+	assert.Equal(t, AllFCVs[0].String(), v)
+
+	require.NoError(t, LoadFCV(ctx, db, l))
+	assert.Equal(t, AllFCVs[0], GetFCV())
+}
@@ -0,0 +1,163 @@
+// Copyright 2021 FerretDB Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package common
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"sync/atomic"
+
+	"github.com/FerretDB/FerretDB/internal/types"
+	"github.com/FerretDB/FerretDB/internal/util/must"
+)
+
+// FieldNamesMode represents the policy for field names with dots and leading dollars in stored documents.
+//
+// In all modes, top-level field names can't start with a dollar.
+type FieldNamesMode int32
+
+const (
+	// FieldNamesRelaxed allows dots and leading dollars in embedded field names, like MongoDB 5.0 and later.
+	FieldNamesRelaxed FieldNamesMode = iota
+
+	// FieldNamesStrict rejects dots and leading dollars in all field names, like MongoDB before 5.0.
+	FieldNamesStrict
+)
+
+// AllFieldNamesModes includes all field names modes, with the first one being the default.
+var AllFieldNamesModes = []FieldNamesMode{FieldNamesRelaxed, FieldNamesStrict}
+
+// String implements fmt.Stringer interface.
+func (mode FieldNamesMode) String() string {
+	switch mode {
+	case FieldNamesRelaxed:
+		return "relaxed"
+	case FieldNamesStrict:
+		return "strict"
+	default:
+		return fmt.Sprintf("FieldNamesMode(%d)", int32(mode))
+	}
+}
+
+// ParseFieldNamesMode returns FieldNamesMode for the given string representation.
+func ParseFieldNamesMode(s string) (FieldNamesMode, error) {
+	for _, mode := range AllFieldNamesModes {
+		if mode.String() == s {
+			return mode, nil
+		}
+	}
+
+	return FieldNamesRelaxed, fmt.Errorf("unknown field names mode %q", s)
+}
+
+// fieldNamesMode stores the current FieldNamesMode.
+var fieldNamesMode int32
+
+// GetFieldNamesMode returns the current field names mode.
+func GetFieldNamesMode() FieldNamesMode {
+	return FieldNamesMode(atomic.LoadInt32(&fieldNamesMode))
+}
+
+// SetFieldNamesMode changes the current field names mode.
+// It is safe to call it concurrently with requests handling.
+func SetFieldNamesMode(mode FieldNamesMode) {
+	atomic.StoreInt32(&fieldNamesMode, int32(mode))
+}
+
+// CheckInsertFieldNames returns an error if the document to insert has field names
+// not allowed by the current field names mode.
+func CheckInsertFieldNames(doc *types.Document) error {
+	for _, key := range doc.Keys() {
+		if strings.HasPrefix(key, "$") {
+			msg := fmt.Sprintf("Document can't have $ prefixed field names: %s", key)
+			return NewErrorMsg(ErrBadValue, msg)
+		}
+	}
+
+	return checkFieldNames(doc, effectiveFieldNamesMode())
+}
+
+// CheckUpdateFieldNames returns an error if the document after update has field names
+// not allowed by the current field names mode.
+func CheckUpdateFieldNames(doc *types.Document) error {
+	return checkFieldNames(doc, effectiveFieldNamesMode())
+}
+
+// effectiveFieldNamesMode returns the current field names mode,
+// or FieldNamesStrict if relaxed field names are not enabled by the current FCV.
+func effectiveFieldNamesMode() FieldNamesMode {
+	if !FCVFeatureEnabled(FCVFeatureRelaxedFieldNames) {
+		return FieldNamesStrict
+	}
+
+	return GetFieldNamesMode()
+}
+
+// checkFieldNames checks field names of the document to store according to the given mode.
+func checkFieldNames(doc *types.Document, mode FieldNamesMode) error {
+	for _, key := range doc.Keys() {
+		if strings.HasPrefix(key, "$") {
+			return fieldNameError(key, key)
+		}
+	}
+
+	if mode == FieldNamesRelaxed {
+		return nil
+	}
+
+	return checkStrictFieldNames(doc, nil)
+}
+
+// checkStrictFieldNames recursively checks that there are no field names with dots or leading dollars
+// in the given document or array, which is located by the given path.
+func checkStrictFieldNames(v any, path []string) error {
+	switch v := v.(type) {
+	case *types.Document:
+		for _, key := range v.Keys() {
+			fieldPath := append(path[:len(path):len(path)], key)
+
+			if strings.HasPrefix(key, "$") || strings.Contains(key, ".") {
+				return fieldNameError(key, strings.Join(fieldPath, "."))
+			}
+
+			if err := checkStrictFieldNames(must.NotFail(v.Get(key)), fieldPath); err != nil {
+				return err
+			}
+		}
+
+	case *types.Array:
+		for i := 0; i < v.Len(); i++ {
+			elemPath := append(path[:len(path):len(path)], strconv.Itoa(i))
+
+			if err := checkStrictFieldNames(must.NotFail(v.Get(i)), elemPath); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+// fieldNameError returns a write error for the field name with a leading dollar or a dot.
+func fieldNameError(key, path string) error {
+	if strings.HasPrefix(key, "$") {
+		msg := fmt.Sprintf("The dollar ($) prefixed field '%s' in '%s' is not valid for storage.", key, path)
+		return NewWriteErrorMsg(ErrDollarPrefixedFieldName, msg)
+	}
+
+	msg := fmt.Sprintf("The dotted field '%s' in '%s' is not valid for storage.", key, path)
+	return NewWriteErrorMsg(ErrDottedFieldName, msg)
+}
@@ -0,0 +1,116 @@
+// Copyright 2021 FerretDB Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package common
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/FerretDB/FerretDB/internal/types"
+	"github.com/FerretDB/FerretDB/internal/util/must"
+)
+
+func TestParseFieldNamesMode(t *testing.T) {
+	t.Parallel()
+
+	for _, mode := range AllFieldNamesModes {
+		actual, err := ParseFieldNamesMode(mode.String())
+		require.NoError(t, err)
+		assert.Equal(t, mode, actual)
+	}
+
+	_, err := ParseFieldNamesMode("unknown")
+	assert.Error(t, err)
+}
+
+func TestCheckFieldNames(t *testing.T) {
+	t.Parallel()
+
+	for name, tc := range map[string]struct {
+		doc     *types.Document
+		relaxed *WriteErrors
+		strict  *WriteErrors
+	}{
+		"Valid": {
+			doc: must.NotFail(types.NewDocument("_id", int32(1), "v", must.NotFail(types.NewDocument("foo", "bar")))),
+		},
+		"TopLevelDollar": {
+			doc: must.NotFail(types.NewDocument("_id", int32(1), "$foo", "bar")),
+			relaxed: &WriteErrors{{
+				code: ErrDollarPrefixedFieldName,
+				err:  "The dollar ($) prefixed field '$foo' in '$foo' is not valid for storage.",
+			}},
+			strict: &WriteErrors{{
+				code: ErrDollarPrefixedFieldName,
+				err:  "The dollar ($) prefixed field '$foo' in '$foo' is not valid for storage.",
+			}},
+		},
+		"EmbeddedDollar": {
+			doc: must.NotFail(types.NewDocument("_id", int32(1), "v", must.NotFail(types.NewDocument("$foo", "bar")))),
+			strict: &WriteErrors{{
+				code: ErrDollarPrefixedFieldName,
+				err:  "The dollar ($) prefixed field '$foo' in 'v.$foo' is not valid for storage.",
+			}},
+		},
+		"Dotted": {
+			doc: must.NotFail(types.NewDocument("_id", int32(1), "foo.bar", "baz")),
+			strict: &WriteErrors{{
+				code: ErrDottedFieldName,
+				err:  "The dotted field 'foo.bar' in 'foo.bar' is not valid for storage.",
+			}},
+		},
+		"DottedInArray": {
+			doc: must.NotFail(types.NewDocument("_id", int32(1), "v", must.NotFail(types.NewArray(
+				int32(42), must.NotFail(types.NewDocument("foo.bar", "baz")),
+			)))),
+			strict: &WriteErrors{{
+				code: ErrDottedFieldName,
+				err:  "The dotted field 'foo.bar' in 'v.1.foo.bar' is not valid for storage.",
+			}},
+		},
+	} {
+		name, tc := name, tc
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			err := checkFieldNames(tc.doc, FieldNamesRelaxed)
+			if tc.relaxed == nil {
+				assert.NoError(t, err)
+			} else {
+				assert.Equal(t, tc.relaxed, err)
+			}
+
+			err = checkFieldNames(tc.doc, FieldNamesStrict)
+			if tc.strict == nil {
+				assert.NoError(t, err)
+			} else {
+				assert.Equal(t, tc.strict, err)
+			}
+		})
+	}
+}
+
+func TestCheckInsertFieldNames(t *testing.T) {
+	t.Parallel()
+
+	err := CheckInsertFieldNames(must.NotFail(types.NewDocument("_id", int32(1), "$foo", "bar")))
+
+	var e *Error
+	require.ErrorAs(t, err, &e)
+	assert.Equal(t, ErrBadValue, e.Code())
+	assert.Equal(t, "Document can't have $ prefixed field names: $foo", e.Unwrap().Error())
+}
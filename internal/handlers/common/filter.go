@@ -17,7 +17,6 @@ package common
 import (
 	"fmt"
 	"math"
-	"strconv"
 	"strings"
 	"time"
 
@@ -67,18 +66,9 @@ func filterDocumentPair(doc *types.Document, filterKey string, filterValue any)
 			doc = docValue
 			filterKey = path.Suffix()
 		case *types.Array:
-			index, err := strconv.Atoi(path.Suffix())
+			value, err := docValue.GetByPath(types.NewPath([]string{path.Suffix()}))
 			if err != nil {
-				return false, nil
-			}
-
-			if docValue.Len() == 0 || docValue.Len() <= index {
-				return false, nil
-			}
-
-			value, err := docValue.Get(index)
-			if err != nil {
-				return false, err
+				return false, nil // no error - the element is just not present
 			}
 
 			if _, ok := value.(*types.Array); ok {
@@ -1045,6 +1035,10 @@ func filterFieldValueByTypeCode(fieldValue any, code typeCode) (bool, error) {
 		if _, ok := fieldValue.(types.Binary); !ok {
 			return false, nil
 		}
+	case typeCodeUndefined:
+		if _, ok := fieldValue.(types.UndefinedType); !ok {
+			return false, nil
+		}
 	case typeCodeObjectID:
 		if _, ok := fieldValue.(types.ObjectID); !ok {
 			return false, nil
@@ -1065,6 +1059,10 @@ func filterFieldValueByTypeCode(fieldValue any, code typeCode) (bool, error) {
 		if _, ok := fieldValue.(types.Regex); !ok {
 			return false, nil
 		}
+	case typeCodeDBPointer:
+		if _, ok := fieldValue.(types.DBPointer); !ok {
+			return false, nil
+		}
 	case typeCodeInt:
 		if _, ok := fieldValue.(int32); !ok {
 			return false, nil
@@ -1077,15 +1075,31 @@ func filterFieldValueByTypeCode(fieldValue any, code typeCode) (bool, error) {
 		if _, ok := fieldValue.(int64); !ok {
 			return false, nil
 		}
+	case typeCodeJS:
+		if _, ok := fieldValue.(types.JavaScript); !ok {
+			return false, nil
+		}
+	case typeCodeSymbol:
+		if _, ok := fieldValue.(types.Symbol); !ok {
+			return false, nil
+		}
+	case typeCodeJSScope:
+		if _, ok := fieldValue.(types.JavaScriptWithScope); !ok {
+			return false, nil
+		}
+	case typeCodeDecimal:
+		if _, ok := fieldValue.(types.Decimal128); !ok {
+			return false, nil
+		}
 	case typeCodeNumber:
-		// typeCodeNumber should match int32, int64 and float64 types
+		// typeCodeNumber should match int32, int64, float64 and Decimal128 types
 		switch fieldValue.(type) {
-		case int32, int64, float64:
+		case int32, int64, float64, types.Decimal128:
 			return true, nil
 		default:
 			return false, nil
 		}
-	case typeCodeDecimal, typeCodeMinKey, typeCodeMaxKey:
+	case typeCodeMinKey, typeCodeMaxKey:
 		return false, NewErrorMsg(ErrNotImplemented, fmt.Sprintf(`Type code %v not implemented`, code))
 	default:
 		return false, NewErrorMsg(ErrBadValue, fmt.Sprintf(`Unknown type name alias: %s`, code.String()))
@@ -0,0 +1,138 @@
+// Copyright 2021 FerretDB Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package common
+
+import (
+	"context"
+	"sync"
+)
+
+// writesLock blocks write commands while the fsync command holds the lock.
+//
+// The lock is local to this FerretDB instance; other instances using the same backend are not affected.
+type writesLock struct {
+	m        sync.Mutex
+	count    int64         // fsync lock count; writes are blocked if positive
+	unlocked chan struct{} // closed when count drops to zero
+	inFlight int           // started and not finished writes
+	drained  chan struct{} // if not nil, closed when inFlight drops to zero
+}
+
+// writes is a global writes lock.
+var writes = new(writesLock)
+
+// StartWrite waits until writes are not locked by fsync command (or ctx is canceled),
+// and registers a new write, so fsync lock waits for it.
+//
+// Returned function should be called when the write is done.
+func StartWrite(ctx context.Context) (func(), error) {
+	return writes.start(ctx)
+}
+
+// start implements StartWrite.
+func (wl *writesLock) start(ctx context.Context) (func(), error) {
+	for {
+		wl.m.Lock()
+
+		if wl.count == 0 {
+			wl.inFlight++
+			wl.m.Unlock()
+
+			return wl.finish, nil
+		}
+
+		unlocked := wl.unlocked
+		wl.m.Unlock()
+
+		select {
+		case <-unlocked:
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+	}
+}
+
+// finish marks a write registered by start as done.
+func (wl *writesLock) finish() {
+	wl.m.Lock()
+	defer wl.m.Unlock()
+
+	wl.inFlight--
+
+	if wl.inFlight == 0 && wl.drained != nil {
+		close(wl.drained)
+		wl.drained = nil
+	}
+}
+
+// lock increments the lock count and waits for in-flight writes to finish (or ctx to be canceled).
+// It returns the new lock count.
+func (wl *writesLock) lock(ctx context.Context) (int64, error) {
+	wl.m.Lock()
+
+	wl.count++
+	if wl.count == 1 {
+		wl.unlocked = make(chan struct{})
+	}
+
+	count := wl.count
+
+	if wl.inFlight == 0 {
+		wl.m.Unlock()
+		return count, nil
+	}
+
+	if wl.drained == nil {
+		wl.drained = make(chan struct{})
+	}
+
+	drained := wl.drained
+	wl.m.Unlock()
+
+	select {
+	case <-drained:
+		return count, nil
+	case <-ctx.Done():
+		wl.unlock()
+		return 0, ctx.Err()
+	}
+}
+
+// unlock decrements the lock count and returns the new one.
+// It returns false if writes were not locked.
+func (wl *writesLock) unlock() (int64, bool) {
+	wl.m.Lock()
+	defer wl.m.Unlock()
+
+	if wl.count == 0 {
+		return 0, false
+	}
+
+	wl.count--
+	if wl.count == 0 {
+		close(wl.unlocked)
+		wl.unlocked = nil
+	}
+
+	return wl.count, true
+}
+
+// locked returns true if writes are locked.
+func (wl *writesLock) locked() bool {
+	wl.m.Lock()
+	defer wl.m.Unlock()
+
+	return wl.count > 0
+}
@@ -0,0 +1,117 @@
+// Copyright 2021 FerretDB Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package common
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/FerretDB/FerretDB/internal/util/testutil"
+)
+
+func TestWritesLock(t *testing.T) {
+	t.Parallel()
+
+	ctx := testutil.Ctx(t)
+	wl := new(writesLock)
+
+	_, ok := wl.unlock()
+	assert.False(t, ok)
+
+	done, err := wl.start(ctx)
+	require.NoError(t, err)
+
+	// lock waits for the in-flight write
+	locked := make(chan int64)
+	go func() {
+		count, err := wl.lock(ctx)
+		assert.NoError(t, err)
+		locked <- count
+	}()
+
+	select {
+	case <-locked:
+		t.Fatal("lock did not wait for in-flight write")
+	case <-time.After(50 * time.Millisecond):
+	}
+
+	done()
+	assert.Equal(t, int64(1), <-locked)
+	assert.True(t, wl.locked())
+
+	count, err := wl.lock(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, int64(2), count)
+
+	// new writes wait for the last unlock
+	started := make(chan struct{})
+	go func() {
+		done, err := wl.start(ctx)
+		assert.NoError(t, err)
+		done()
+		close(started)
+	}()
+
+	count, ok = wl.unlock()
+	assert.True(t, ok)
+	assert.Equal(t, int64(1), count)
+
+	select {
+	case <-started:
+		t.Fatal("write did not wait for unlock")
+	case <-time.After(50 * time.Millisecond):
+	}
+
+	count, ok = wl.unlock()
+	assert.True(t, ok)
+	assert.Equal(t, int64(0), count)
+	<-started
+	assert.False(t, wl.locked())
+}
+
+func TestWritesLockCanceled(t *testing.T) {
+	t.Parallel()
+
+	wl := new(writesLock)
+
+	_, err := wl.lock(testutil.Ctx(t))
+	require.NoError(t, err)
+
+	ctx, cancel := context.WithTimeout(testutil.Ctx(t), 50*time.Millisecond)
+	defer cancel()
+
+	_, err = wl.start(ctx)
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+
+	_, ok := wl.unlock()
+	assert.True(t, ok)
+
+	// canceled lock is released
+	done, err := wl.start(testutil.Ctx(t))
+	require.NoError(t, err)
+
+	ctx, cancel = context.WithTimeout(testutil.Ctx(t), 50*time.Millisecond)
+	defer cancel()
+
+	_, err = wl.lock(ctx)
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+	assert.False(t, wl.locked())
+
+	done()
+}
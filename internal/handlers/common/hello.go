@@ -0,0 +1,109 @@
+// Copyright 2021 FerretDB Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package common
+
+import (
+	"context"
+	"time"
+
+	"github.com/FerretDB/FerretDB/internal/types"
+	"github.com/FerretDB/FerretDB/internal/util/must"
+)
+
+// processID identifies this process in topologyVersion of hello responses.
+// FerretDB is always a standalone server, so the topology never changes within a process,
+// and topologyVersion counter is always zero.
+var processID = types.NewObjectID()
+
+// TopologyVersion returns the topologyVersion document for hello and isMaster responses.
+func TopologyVersion() *types.Document {
+	return must.NotFail(types.NewDocument(
+		"processId", processID,
+		"counter", int64(0),
+	))
+}
+
+// AwaitHello implements awaitable hello and isMaster commands used by drivers' streaming monitoring protocol.
+//
+// If the request contains the topologyVersion of the current process and maxAwaitTimeMS,
+// AwaitHello waits for that time (as the topology never changes) or until ctx is canceled,
+// so monitoring connections long-poll instead of re-polling on every heartbeat.
+// Otherwise, it returns immediately.
+func AwaitHello(ctx context.Context, document *types.Document) error {
+	d, err := helloAwaitTime(document)
+	if err != nil || d == 0 {
+		return err
+	}
+
+	t := time.NewTimer(d)
+	defer t.Stop()
+
+	select {
+	case <-t.C:
+	case <-ctx.Done():
+	}
+
+	return nil
+}
+
+// HelloAwaits returns true if AwaitHello waits before responding to the given valid request.
+func HelloAwaits(document *types.Document) bool {
+	d, err := helloAwaitTime(document)
+	return err == nil && d > 0
+}
+
+// helloAwaitTime returns the time AwaitHello should wait before responding to the given request,
+// or zero if it should respond immediately.
+func helloAwaitTime(document *types.Document) (time.Duration, error) {
+	v, err := document.Get("maxAwaitTimeMS")
+	if err != nil {
+		return 0, nil
+	}
+
+	maxAwaitTimeMS, err := GetWholeNumberParam(v)
+	if err != nil || maxAwaitTimeMS < 0 {
+		return 0, NewErrorMsg(ErrBadValue, "maxAwaitTimeMS must be a non-negative integer")
+	}
+
+	var topologyVersion *types.Document
+	if topologyVersion, err = GetOptionalParam(document, "topologyVersion", topologyVersion); err != nil {
+		return 0, err
+	}
+
+	if topologyVersion == nil {
+		return 0, NewErrorMsg(ErrBadValue, "A request with a 'maxAwaitTimeMS' must also include a 'topologyVersion'")
+	}
+
+	id, err := GetRequiredParam[types.ObjectID](topologyVersion, "processId")
+	if err != nil {
+		return 0, err
+	}
+
+	counter, err := GetRequiredParam[int64](topologyVersion, "counter")
+	if err != nil {
+		return 0, err
+	}
+
+	// topology has changed since the client's last response, so respond immediately
+	if id != processID {
+		return 0, nil
+	}
+
+	if counter > 0 {
+		return 0, NewErrorMsg(ErrBadValue, "Received a topology version with the same process ID and a higher counter")
+	}
+
+	return time.Duration(maxAwaitTimeMS) * time.Millisecond, nil
+}
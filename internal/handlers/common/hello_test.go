@@ -0,0 +1,106 @@
+// Copyright 2021 FerretDB Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package common
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/FerretDB/FerretDB/internal/types"
+	"github.com/FerretDB/FerretDB/internal/util/must"
+)
+
+func TestAwaitHello(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+
+	t.Run("NotAwaitable", func(t *testing.T) {
+		t.Parallel()
+
+		doc := must.NotFail(types.NewDocument("hello", int32(1), "topologyVersion", TopologyVersion()))
+		assert.NoError(t, AwaitHello(ctx, doc))
+	})
+
+	t.Run("Await", func(t *testing.T) {
+		t.Parallel()
+
+		doc := must.NotFail(types.NewDocument(
+			"hello", int32(1),
+			"topologyVersion", TopologyVersion(),
+			"maxAwaitTimeMS", int64(100),
+		))
+
+		start := time.Now()
+		require.NoError(t, AwaitHello(ctx, doc))
+		assert.GreaterOrEqual(t, time.Since(start), 100*time.Millisecond)
+	})
+
+	t.Run("Canceled", func(t *testing.T) {
+		t.Parallel()
+
+		doc := must.NotFail(types.NewDocument(
+			"hello", int32(1),
+			"topologyVersion", TopologyVersion(),
+			"maxAwaitTimeMS", int32(10000),
+		))
+
+		ctx, cancel := context.WithTimeout(ctx, 10*time.Millisecond)
+		defer cancel()
+
+		start := time.Now()
+		require.NoError(t, AwaitHello(ctx, doc))
+		assert.Less(t, time.Since(start), 5*time.Second)
+	})
+
+	t.Run("OtherProcess", func(t *testing.T) {
+		t.Parallel()
+
+		doc := must.NotFail(types.NewDocument(
+			"hello", int32(1),
+			"topologyVersion", must.NotFail(types.NewDocument("processId", types.NewObjectID(), "counter", int64(0))),
+			"maxAwaitTimeMS", int64(10000),
+		))
+
+		start := time.Now()
+		require.NoError(t, AwaitHello(ctx, doc))
+		assert.Less(t, time.Since(start), 5*time.Second)
+	})
+
+	t.Run("Invalid", func(t *testing.T) {
+		t.Parallel()
+
+		for name, doc := range map[string]*types.Document{
+			"NoTopologyVersion": must.NotFail(types.NewDocument("hello", int32(1), "maxAwaitTimeMS", int64(1))),
+			"Negative": must.NotFail(types.NewDocument(
+				"hello", int32(1), "topologyVersion", TopologyVersion(), "maxAwaitTimeMS", int64(-1),
+			)),
+			"Counter": must.NotFail(types.NewDocument(
+				"hello", int32(1),
+				"topologyVersion", must.NotFail(types.NewDocument("processId", processID, "counter", int64(1))),
+				"maxAwaitTimeMS", int64(1),
+			)),
+		} {
+			var e *Error
+			err := AwaitHello(ctx, doc)
+			require.ErrorAs(t, err, &e, name)
+			assert.Equal(t, ErrBadValue, e.Code(), name)
+		}
+	})
+}
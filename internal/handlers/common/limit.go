@@ -31,3 +31,18 @@ func LimitDocuments(docs []*types.Document, limit int64) ([]*types.Document, err
 		return nil, NewErrorMsg(ErrNotImplemented, "LimitDocuments: negative limit values are not supported")
 	}
 }
+
+// SkipDocuments returns a subslice of given documents according to the given skip value.
+func SkipDocuments(docs []*types.Document, skip int64) ([]*types.Document, error) {
+	switch {
+	case skip == 0:
+		return docs, nil
+	case skip > 0:
+		if int64(len(docs)) <= skip {
+			return []*types.Document{}, nil
+		}
+		return docs[skip:], nil
+	default:
+		return nil, NewErrorMsg(ErrBadValue, "SkipValue must be non-negative")
+	}
+}
@@ -0,0 +1,56 @@
+// Copyright 2021 FerretDB Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package common
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"time"
+
+	"github.com/FerretDB/FerretDB/internal/types"
+)
+
+// GetMaxTimeMSParam returns the value of the maxTimeMS command parameter.
+//
+// It returns 0 (no time limit) if the parameter is not set.
+func GetMaxTimeMSParam(document *types.Document) (int64, error) {
+	v, err := document.Get("maxTimeMS")
+	if err != nil {
+		return 0, nil
+	}
+
+	maxTimeMS, err := GetWholeNumberParam(v)
+	if err != nil {
+		return 0, NewErrorMsg(ErrBadValue, "maxTimeMS must be a number")
+	}
+
+	if maxTimeMS < 0 || maxTimeMS > math.MaxInt32 {
+		return 0, NewErrorMsg(ErrBadValue, fmt.Sprintf("%d value for maxTimeMS is out of range", maxTimeMS))
+	}
+
+	return maxTimeMS, nil
+}
+
+// WithMaxTimeMS returns a copy of the context with the deadline set according to maxTimeMS.
+//
+// Zero maxTimeMS means no time limit; in that case, only cancellation is added.
+func WithMaxTimeMS(ctx context.Context, maxTimeMS int64) (context.Context, context.CancelFunc) {
+	if maxTimeMS == 0 {
+		return context.WithCancel(ctx)
+	}
+
+	return context.WithTimeout(ctx, time.Duration(maxTimeMS)*time.Millisecond)
+}
@@ -0,0 +1,382 @@
+// Copyright 2021 FerretDB Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package common
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"go.uber.org/zap"
+
+	"github.com/FerretDB/FerretDB/internal/handlers"
+	"github.com/FerretDB/FerretDB/internal/types"
+	"github.com/FerretDB/FerretDB/internal/util/lazyerrors"
+	"github.com/FerretDB/FerretDB/internal/util/must"
+	"github.com/FerretDB/FerretDB/internal/wire"
+)
+
+// MsgApplyOps is a common implementation of the applyOps command.
+//
+// Only a subset used by replication-based migration tools is supported:
+// insert ("i"), update ("u"), delete ("d"), and no-op ("n") oplog entries.
+// The target collection is taken from the ns field; collection UUIDs (ui field) are accepted but not used,
+// as FerretDB does not have them.
+// Operations are applied one by one with handler's insert, update, and delete commands, so they are not atomic:
+// operations before the failing one stay applied.
+//
+// Updates could be replacements, update operators, or $v: 2 diffs without array changes.
+// Inserts and replacements are applied by deleting the existing document (if any) and inserting a new one,
+// so replaying the same change batch is idempotent.
+func MsgApplyOps(ctx context.Context, h handlers.Interface, msg *wire.OpMsg, l *zap.Logger) (*wire.OpMsg, error) {
+	document, err := msg.Document()
+	if err != nil {
+		return nil, lazyerrors.Error(err)
+	}
+
+	if err = Unimplemented(document, "preCondition"); err != nil {
+		return nil, err
+	}
+
+	Ignored(document, l, "allowAtomic", "bypassDocumentValidation", "writeConcern", "comment")
+
+	ops, err := GetRequiredParam[*types.Array](document, document.Command())
+	if err != nil {
+		return nil, err
+	}
+
+	alwaysUpsert := true
+	if alwaysUpsert, err = GetOptionalParam(document, "alwaysUpsert", alwaysUpsert); err != nil {
+		return nil, err
+	}
+
+	results := must.NotFail(types.NewArray())
+
+	for i := 0; i < ops.Len(); i++ {
+		op, ok := must.NotFail(ops.Get(i)).(*types.Document)
+		if !ok {
+			return nil, NewErrorMsg(ErrFailedToParse, fmt.Sprintf("applyOps: operation %d is not an object", i))
+		}
+
+		if err = applyOp(ctx, h, op, alwaysUpsert); err != nil {
+			return nil, err
+		}
+
+		must.NoError(results.Append(true))
+	}
+
+	var reply wire.OpMsg
+	err = reply.SetSections(wire.OpMsgSection{
+		Documents: []*types.Document{must.NotFail(types.NewDocument(
+			"applied", int32(results.Len()),
+			"results", results,
+			"ok", float64(1),
+		))},
+	})
+	if err != nil {
+		return nil, lazyerrors.Error(err)
+	}
+
+	return &reply, nil
+}
+
+// applyOp applies a single oplog entry with the handler.
+func applyOp(ctx context.Context, h handlers.Interface, op *types.Document, alwaysUpsert bool) error {
+	opType, err := GetRequiredParam[string](op, "op")
+	if err != nil {
+		return err
+	}
+
+	if opType == "n" {
+		return nil
+	}
+
+	if v, _ := op.Get("ui"); v != nil {
+		if b, ok := v.(types.Binary); !ok || !b.IsUUID() {
+			return NewErrorMsg(ErrTypeMismatch, "applyOps: 'ui' must be a UUID")
+		}
+	}
+
+	ns, err := GetRequiredParam[string](op, "ns")
+	if err != nil {
+		return err
+	}
+
+	db, collection, ok := strings.Cut(ns, ".")
+	if !ok || db == "" || collection == "" {
+		return NewErrorMsg(ErrInvalidNamespace, fmt.Sprintf("applyOps: invalid namespace '%s'", ns))
+	}
+
+	if err = checkSystemCollection("applyOps", db, collection); err != nil {
+		return err
+	}
+
+	o, err := GetRequiredParam[*types.Document](op, "o")
+	if err != nil {
+		return err
+	}
+
+	switch opType {
+	case "i":
+		id, err := o.Get("_id")
+		if err != nil {
+			return NewErrorMsg(ErrBadValue, "applyOps: insert operation is missing _id")
+		}
+
+		return applyOpReplace(ctx, h, db, collection, must.NotFail(types.NewDocument("_id", id)), o, true)
+
+	case "u":
+		filter, err := GetRequiredParam[*types.Document](op, "o2")
+		if err != nil {
+			return err
+		}
+
+		update, err := oplogUpdate(o)
+		if err != nil {
+			return err
+		}
+
+		// diffs without changes are no-ops
+		if update == nil {
+			return nil
+		}
+
+		if !isUpdateOperators(update) {
+			return applyOpReplace(ctx, h, db, collection, filter, update, alwaysUpsert)
+		}
+
+		_, err = runWriteCommand(ctx, h.MsgUpdate, must.NotFail(types.NewDocument(
+			"update", collection,
+			"updates", must.NotFail(types.NewArray(must.NotFail(types.NewDocument(
+				"q", filter,
+				"u", update,
+				"upsert", alwaysUpsert,
+			)))),
+			"$db", db,
+		)))
+
+		return err
+
+	case "d":
+		_, err = applyOpDelete(ctx, h, db, collection, o)
+		return err
+
+	default:
+		return NewErrorMsg(
+			ErrNotImplemented,
+			fmt.Sprintf("applyOps: operation type '%s' is not supported; only 'i', 'u', 'd', and 'n' are", opType),
+		)
+	}
+}
+
+// applyOpDelete deletes a single document matching the filter with the handler's delete command.
+// It returns the number of deleted documents.
+func applyOpDelete(ctx context.Context, h handlers.Interface, db, collection string, filter *types.Document) (int32, error) {
+	res, err := runWriteCommand(ctx, h.MsgDelete, must.NotFail(types.NewDocument(
+		"delete", collection,
+		"deletes", must.NotFail(types.NewArray(must.NotFail(types.NewDocument("q", filter, "limit", int32(1))))),
+		"$db", db,
+	)))
+	if err != nil {
+		return 0, err
+	}
+
+	n, _ := res.Get("n")
+	deleted, _ := n.(int32)
+
+	return deleted, nil
+}
+
+// applyOpReplace replaces a single document matching the filter with the given document
+// by deleting it and inserting a new one with the same _id.
+// If there is no such document, a new one is inserted only if upsert is true.
+func applyOpReplace(
+	ctx context.Context, h handlers.Interface, db, collection string, filter, doc *types.Document, upsert bool,
+) error {
+	deleted, err := applyOpDelete(ctx, h, db, collection, filter)
+	if err != nil {
+		return err
+	}
+
+	if deleted == 0 && !upsert {
+		return nil
+	}
+
+	if !doc.Has("_id") {
+		id, err := filter.Get("_id")
+		if err != nil {
+			return NewErrorMsg(ErrBadValue, "applyOps: replacement document is missing _id")
+		}
+
+		doc = doc.DeepCopy()
+		must.NoError(doc.Set("_id", id))
+	}
+
+	_, err = runWriteCommand(ctx, h.MsgInsert, must.NotFail(types.NewDocument(
+		"insert", collection,
+		"documents", must.NotFail(types.NewArray(doc)),
+		"$db", db,
+	)))
+
+	return err
+}
+
+// isUpdateOperators returns true if the update document contains update operators instead of fields.
+func isUpdateOperators(update *types.Document) bool {
+	for _, k := range update.Keys() {
+		if strings.HasPrefix(k, "$") {
+			return true
+		}
+	}
+
+	return false
+}
+
+// oplogUpdate converts the o field of the update oplog entry to the replacement document
+// or update operators document of the update command.
+//
+// It returns nil if the $v: 2 diff does not change anything.
+func oplogUpdate(o *types.Document) (*types.Document, error) {
+	version, _ := o.Get("$v")
+
+	switch version {
+	case nil:
+		return o, nil
+
+	case int32(1), int64(1), float64(1):
+		res := o.DeepCopy()
+		res.Remove("$v")
+
+		return res, nil
+
+	case int32(2), int64(2), float64(2):
+		diff, err := GetRequiredParam[*types.Document](o, "diff")
+		if err != nil {
+			return nil, err
+		}
+
+		set := must.NotFail(types.NewDocument())
+		unset := must.NotFail(types.NewDocument())
+
+		if err = collectDiff(diff, "", set, unset); err != nil {
+			return nil, err
+		}
+
+		res := must.NotFail(types.NewDocument())
+
+		if set.Len() > 0 {
+			must.NoError(res.Set("$set", set))
+		}
+
+		if unset.Len() > 0 {
+			must.NoError(res.Set("$unset", unset))
+		}
+
+		if res.Len() == 0 {
+			return nil, nil
+		}
+
+		return res, nil
+
+	default:
+		return nil, NewErrorMsg(ErrBadValue, fmt.Sprintf("applyOps: unsupported update version %v", version))
+	}
+}
+
+// collectDiff collects fields set and removed by the $v: 2 diff into $set and $unset operators' documents.
+//
+// Nested documents' diffs ("s" prefixed fields) are collected with dot notation paths.
+func collectDiff(diff *types.Document, prefix string, set, unset *types.Document) error {
+	for _, k := range diff.Keys() {
+		v := must.NotFail(diff.Get(k))
+
+		switch {
+		case k == "i" || k == "u":
+			fields, ok := v.(*types.Document)
+			if !ok {
+				return NewErrorMsg(ErrFailedToParse, fmt.Sprintf("applyOps: diff field '%s' must be an object", k))
+			}
+
+			for _, f := range fields.Keys() {
+				must.NoError(set.Set(prefix+f, must.NotFail(fields.Get(f))))
+			}
+
+		case k == "d":
+			fields, ok := v.(*types.Document)
+			if !ok {
+				return NewErrorMsg(ErrFailedToParse, "applyOps: diff field 'd' must be an object")
+			}
+
+			for _, f := range fields.Keys() {
+				must.NoError(unset.Set(prefix+f, ""))
+			}
+
+		case k == "a":
+			return NewErrorMsg(ErrNotImplemented, "applyOps: array diffs are not supported")
+
+		case strings.HasPrefix(k, "s") && len(k) > 1:
+			sub, ok := v.(*types.Document)
+			if !ok {
+				return NewErrorMsg(ErrFailedToParse, fmt.Sprintf("applyOps: diff field '%s' must be an object", k))
+			}
+
+			if err := collectDiff(sub, prefix+k[1:]+".", set, unset); err != nil {
+				return err
+			}
+
+		default:
+			return NewErrorMsg(ErrBadValue, fmt.Sprintf("applyOps: unsupported diff field '%s'", k))
+		}
+	}
+
+	return nil
+}
+
+// runWriteCommand runs the given write command with the handler function and returns the reply document.
+// The first write error is returned as a command error.
+func runWriteCommand(
+	ctx context.Context, f func(context.Context, *wire.OpMsg) (*wire.OpMsg, error), cmd *types.Document,
+) (*types.Document, error) {
+	var msg wire.OpMsg
+	must.NoError(msg.SetSections(wire.OpMsgSection{Documents: []*types.Document{cmd}}))
+
+	res, err := f(ctx, &msg)
+	if err != nil {
+		return nil, err
+	}
+
+	doc, err := res.Document()
+	if err != nil {
+		return nil, lazyerrors.Error(err)
+	}
+
+	writeErrors, _ := doc.Get("writeErrors")
+	arr, _ := writeErrors.(*types.Array)
+	if arr == nil || arr.Len() == 0 {
+		return doc, nil
+	}
+
+	we, ok := must.NotFail(arr.Get(0)).(*types.Document)
+	if !ok {
+		return nil, lazyerrors.Errorf("invalid write error: %v", must.NotFail(arr.Get(0)))
+	}
+
+	code, _ := we.Get("code")
+	errmsg, _ := we.Get("errmsg")
+	c, _ := code.(int32)
+	m, _ := errmsg.(string)
+
+	return nil, NewErrorMsg(ErrorCode(c), m)
+}
@@ -0,0 +1,94 @@
+// Copyright 2021 FerretDB Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package common
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/FerretDB/FerretDB/internal/types"
+	"github.com/FerretDB/FerretDB/internal/util/must"
+)
+
+func TestOplogUpdate(t *testing.T) {
+	t.Parallel()
+
+	for name, tc := range map[string]struct {
+		o        *types.Document
+		expected *types.Document
+		err      bool
+	}{
+		"Replacement": {
+			o:        must.NotFail(types.NewDocument("_id", int32(1), "v", "foo")),
+			expected: must.NotFail(types.NewDocument("_id", int32(1), "v", "foo")),
+		},
+		"V1": {
+			o: must.NotFail(types.NewDocument(
+				"$v", int32(1),
+				"$set", must.NotFail(types.NewDocument("v", "foo")),
+			)),
+			expected: must.NotFail(types.NewDocument("$set", must.NotFail(types.NewDocument("v", "foo")))),
+		},
+		"V2": {
+			o: must.NotFail(types.NewDocument(
+				"$v", int32(2),
+				"diff", must.NotFail(types.NewDocument(
+					"u", must.NotFail(types.NewDocument("v", "foo")),
+					"i", must.NotFail(types.NewDocument("w", int32(42))),
+					"d", must.NotFail(types.NewDocument("x", false)),
+					"sy", must.NotFail(types.NewDocument(
+						"u", must.NotFail(types.NewDocument("z", "bar")),
+					)),
+				)),
+			)),
+			expected: must.NotFail(types.NewDocument(
+				"$set", must.NotFail(types.NewDocument("v", "foo", "w", int32(42), "y.z", "bar")),
+				"$unset", must.NotFail(types.NewDocument("x", "")),
+			)),
+		},
+		"V2Empty": {
+			o: must.NotFail(types.NewDocument("$v", int32(2), "diff", must.NotFail(types.NewDocument()))),
+		},
+		"V2Array": {
+			o: must.NotFail(types.NewDocument(
+				"$v", int32(2),
+				"diff", must.NotFail(types.NewDocument(
+					"sarr", must.NotFail(types.NewDocument("a", true, "u0", int32(1))),
+				)),
+			)),
+			err: true,
+		},
+		"UnknownVersion": {
+			o:   must.NotFail(types.NewDocument("$v", int32(3))),
+			err: true,
+		},
+	} {
+		name, tc := name, tc
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			actual, err := oplogUpdate(tc.o)
+			if tc.err {
+				require.Error(t, err)
+				return
+			}
+
+			require.NoError(t, err)
+			assert.Equal(t, tc.expected, actual)
+		})
+	}
+}
@@ -0,0 +1,105 @@
+// Copyright 2021 FerretDB Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package common
+
+import (
+	"context"
+	"fmt"
+
+	"go.uber.org/zap"
+
+	"github.com/FerretDB/FerretDB/internal/clientconn/conninfo"
+	"github.com/FerretDB/FerretDB/internal/types"
+	"github.com/FerretDB/FerretDB/internal/util/lazyerrors"
+	"github.com/FerretDB/FerretDB/internal/util/must"
+	"github.com/FerretDB/FerretDB/internal/wire"
+)
+
+// MsgAuthenticate is a common implementation of the authenticate command.
+//
+// Only MONGODB-X509 mechanism is supported. The subject of the verified client certificate
+// in RFC 2253 form is used as a username of the user in the $external database, which is found by lookup.
+func MsgAuthenticate(ctx context.Context, msg *wire.OpMsg, l *zap.Logger, lookup UserLookup) (*wire.OpMsg, error) {
+	document, err := msg.Document()
+	if err != nil {
+		return nil, lazyerrors.Error(err)
+	}
+
+	mechanism, err := GetRequiredParam[string](document, "mechanism")
+	if err != nil {
+		return nil, err
+	}
+
+	if mechanism != MechanismX509 {
+		msg := fmt.Sprintf("Received authentication for mechanism %s which is unknown or not enabled", mechanism)
+		return nil, NewErrorMsg(ErrMechanismUnavailable, msg)
+	}
+
+	db, err := GetRequiredParam[string](document, "$db")
+	if err != nil {
+		return nil, err
+	}
+
+	if db != ExternalDB {
+		msg := fmt.Sprintf("%s authentication must be run against the %s database", MechanismX509, ExternalDB)
+		return nil, NewErrorMsg(ErrBadValue, msg)
+	}
+
+	connInfo := conninfo.GetConnInfo(ctx)
+
+	cert := connInfo.PeerCertificate
+	if cert == nil {
+		return nil, errAuthenticationFailed(l, lazyerrors.New("no verified client certificate"))
+	}
+
+	subject := cert.Subject.String()
+
+	var username string
+	if username, err = GetOptionalParam(document, "user", subject); err != nil {
+		return nil, err
+	}
+
+	if username != subject {
+		msg := fmt.Sprintf(
+			"There is no x.509 client certificate matching the user %q (certificate subject is %q)", username, subject,
+		)
+		return nil, NewErrorMsg(ErrAuthenticationFailed, msg)
+	}
+
+	user, err := lookup(ctx, db, username)
+	if err != nil {
+		return nil, lazyerrors.Error(err)
+	}
+
+	if user == nil {
+		return nil, errAuthenticationFailed(l, lazyerrors.Errorf("user %s@%s not found", username, db))
+	}
+
+	connInfo.SetAuth(username, db)
+
+	var reply wire.OpMsg
+	err = reply.SetSections(wire.OpMsgSection{
+		Documents: []*types.Document{must.NotFail(types.NewDocument(
+			"dbname", db,
+			"user", username,
+			"ok", float64(1),
+		))},
+	})
+	if err != nil {
+		return nil, lazyerrors.Error(err)
+	}
+
+	return &reply, nil
+}
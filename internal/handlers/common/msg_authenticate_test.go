@@ -0,0 +1,113 @@
+// Copyright 2021 FerretDB Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package common
+
+import (
+	"context"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+
+	"github.com/FerretDB/FerretDB/internal/clientconn/conninfo"
+	"github.com/FerretDB/FerretDB/internal/types"
+	"github.com/FerretDB/FerretDB/internal/util/must"
+)
+
+func TestMsgAuthenticate(t *testing.T) {
+	t.Parallel()
+
+	cert := &x509.Certificate{
+		Subject: pkix.Name{
+			CommonName:         "client",
+			OrganizationalUnit: []string{"Clients"},
+			Organization:       []string{"FerretDB"},
+		},
+	}
+	subject := "CN=client,OU=Clients,O=FerretDB"
+
+	lookup := func(ctx context.Context, db, username string) (*types.Document, error) {
+		if db == ExternalDB && username == subject {
+			return must.NotFail(types.NewDocument("user", username, "db", db)), nil
+		}
+		return nil, nil
+	}
+
+	for name, tc := range map[string]struct {
+		cert  *x509.Certificate
+		pairs []any
+		err   ErrorCode
+	}{
+		"Valid": {
+			cert:  cert,
+			pairs: []any{"authenticate", int32(1), "mechanism", MechanismX509, "$db", ExternalDB},
+		},
+		"ValidWithUser": {
+			cert:  cert,
+			pairs: []any{"authenticate", int32(1), "mechanism", MechanismX509, "user", subject, "$db", ExternalDB},
+		},
+		"WrongUser": {
+			cert:  cert,
+			pairs: []any{"authenticate", int32(1), "mechanism", MechanismX509, "user", "CN=other", "$db", ExternalDB},
+			err:   ErrAuthenticationFailed,
+		},
+		"NoCertificate": {
+			pairs: []any{"authenticate", int32(1), "mechanism", MechanismX509, "$db", ExternalDB},
+			err:   ErrAuthenticationFailed,
+		},
+		"UnknownUser": {
+			cert:  &x509.Certificate{Subject: pkix.Name{CommonName: "unknown"}},
+			pairs: []any{"authenticate", int32(1), "mechanism", MechanismX509, "$db", ExternalDB},
+			err:   ErrAuthenticationFailed,
+		},
+		"WrongDatabase": {
+			cert:  cert,
+			pairs: []any{"authenticate", int32(1), "mechanism", MechanismX509, "$db", "admin"},
+			err:   ErrBadValue,
+		},
+		"WrongMechanism": {
+			cert:  cert,
+			pairs: []any{"authenticate", int32(1), "mechanism", "MONGODB-CR", "$db", ExternalDB},
+			err:   ErrMechanismUnavailable,
+		},
+	} {
+		name, tc := name, tc
+
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			connInfo := &conninfo.ConnInfo{PeerCertificate: tc.cert}
+			ctx := conninfo.WithConnInfo(context.Background(), connInfo)
+
+			_, err := MsgAuthenticate(ctx, saslMsg(t, tc.pairs...), zap.NewNop(), lookup)
+			username, db := connInfo.Auth()
+
+			if tc.err != 0 {
+				var protoErr *Error
+				require.ErrorAs(t, err, &protoErr)
+				assert.Equal(t, tc.err, protoErr.Code())
+				assert.Empty(t, username)
+				return
+			}
+
+			require.NoError(t, err)
+			assert.Equal(t, subject, username)
+			assert.Equal(t, ExternalDB, db)
+		})
+	}
+}
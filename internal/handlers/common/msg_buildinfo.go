@@ -37,7 +37,7 @@ func MsgBuildInfo(ctx context.Context, msg *wire.OpMsg) (*wire.OpMsg, error) {
 			"versionArray", version.MongoDBVersionArray,
 			"bits", int32(strconv.IntSize),
 			"debug", version.Get().Debug,
-			"maxBsonObjectSize", int32(types.MaxDocumentLen),
+			"maxBsonObjectSize", wire.GetLimits().MaxBSONObjectSize,
 			"buildEnvironment", version.Get().BuildEnvironment,
 
 			// our extensions
@@ -17,6 +17,7 @@ package common
 import (
 	"context"
 
+	"github.com/FerretDB/FerretDB/internal/clientconn/conninfo"
 	"github.com/FerretDB/FerretDB/internal/types"
 	"github.com/FerretDB/FerretDB/internal/util/lazyerrors"
 	"github.com/FerretDB/FerretDB/internal/util/must"
@@ -25,12 +26,31 @@ import (
 
 // MsgConnectionStatus is a common implementation of the connectionStatus command.
 func MsgConnectionStatus(ctx context.Context, msg *wire.OpMsg) (*wire.OpMsg, error) {
+	connInfo := conninfo.GetConnInfo(ctx)
+
+	users := must.NotFail(types.NewArray())
+	if username, db := connInfo.Auth(); username != "" {
+		must.NoError(users.Append(must.NotFail(types.NewDocument(
+			"user", username,
+			"db", db,
+		))))
+	}
+
+	// roles granted by external authentication sources are always defined in the admin database
+	roles := must.NotFail(types.NewArray())
+	for _, role := range connInfo.Roles() {
+		must.NoError(roles.Append(must.NotFail(types.NewDocument(
+			"role", role,
+			"db", "admin",
+		))))
+	}
+
 	var reply wire.OpMsg
 	err := reply.SetSections(wire.OpMsgSection{
 		Documents: []*types.Document{must.NotFail(types.NewDocument(
 			"authInfo", must.NotFail(types.NewDocument(
-				"authenticatedUsers", must.NotFail(types.NewArray()),
-				"authenticatedUserRoles", must.NotFail(types.NewArray()),
+				"authenticatedUsers", users,
+				"authenticatedUserRoles", roles,
 				"authenticatedUserPrivileges", must.NotFail(types.NewArray()),
 			)),
 			"ok", float64(1),
@@ -0,0 +1,75 @@
+// Copyright 2021 FerretDB Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package common
+
+import (
+	"context"
+	"time"
+
+	"go.uber.org/zap"
+
+	"github.com/FerretDB/FerretDB/internal/types"
+	"github.com/FerretDB/FerretDB/internal/util/lazyerrors"
+	"github.com/FerretDB/FerretDB/internal/util/must"
+	"github.com/FerretDB/FerretDB/internal/wire"
+)
+
+// MsgCurrentOp is a common implementation of the currentOp command.
+//
+// Only long-running operations registered by StartOperation are reported,
+// and fsyncLock field is set if writes are locked by the fsync command.
+// Filters are not supported.
+func MsgCurrentOp(ctx context.Context, msg *wire.OpMsg, l *zap.Logger) (*wire.OpMsg, error) {
+	document, err := msg.Document()
+	if err != nil {
+		return nil, lazyerrors.Error(err)
+	}
+
+	Ignored(document, l, "$all", "$ownOps", "comment")
+
+	db, err := GetRequiredParam[string](document, "$db")
+	if err != nil {
+		return nil, err
+	}
+
+	if db != "admin" {
+		return nil, NewErrorMsg(ErrUnauthorized, "currentOp may only be run against the admin database.")
+	}
+
+	inprog := types.MakeArray(0)
+	for _, op := range operations.inProgress(time.Now()) {
+		if err = inprog.Append(op); err != nil {
+			return nil, lazyerrors.Error(err)
+		}
+	}
+
+	res := must.NotFail(types.NewDocument("inprog", inprog))
+
+	if writes.locked() {
+		must.NoError(res.Set("fsyncLock", true))
+	}
+
+	must.NoError(res.Set("ok", float64(1)))
+
+	var reply wire.OpMsg
+	err = reply.SetSections(wire.OpMsgSection{
+		Documents: []*types.Document{res},
+	})
+	if err != nil {
+		return nil, lazyerrors.Error(err)
+	}
+
+	return &reply, nil
+}
@@ -0,0 +1,156 @@
+// Copyright 2021 FerretDB Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package common
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"go.uber.org/zap"
+
+	"github.com/FerretDB/FerretDB/internal/handlers/backend"
+	"github.com/FerretDB/FerretDB/internal/types"
+	"github.com/FerretDB/FerretDB/internal/util/lazyerrors"
+	"github.com/FerretDB/FerretDB/internal/util/must"
+	"github.com/FerretDB/FerretDB/internal/wire"
+)
+
+// MsgDumpArchive is a common implementation of the dumpArchive command.
+//
+// It writes the collection given as the command value, or all database collections if the value is not a string,
+// except system collections, to the new file in mongodump archive format (see DumpArchive).
+// The archive field is the file name in the directory set by SetArchiveDir;
+// the command is disabled if it is not set, so clients can't write arbitrary files.
+func MsgDumpArchive(ctx context.Context, msg *wire.OpMsg, b backend.Backend, l *zap.Logger) (*wire.OpMsg, error) {
+	document, err := msg.Document()
+	if err != nil {
+		return nil, lazyerrors.Error(err)
+	}
+
+	Ignored(document, l, "comment")
+
+	command := document.Command()
+
+	dir := GetArchiveDir()
+	if dir == "" {
+		msg := fmt.Sprintf("%s is disabled; archive directory is not set", command)
+		return nil, NewErrorMsg(ErrIllegalOperation, msg)
+	}
+
+	db, err := GetRequiredParam[string](document, "$db")
+	if err != nil {
+		return nil, err
+	}
+
+	var collections []string
+	if collection, ok := must.NotFail(document.Get(command)).(string); ok {
+		if collection == "" {
+			return nil, NewErrorMsg(ErrInvalidNamespace, "Invalid namespace specified '"+db+".'")
+		}
+
+		// system collections like users collection could contain credentials
+		if strings.HasPrefix(collection, "system.") {
+			msg := fmt.Sprintf("%s of system collection %s.%s is not allowed", command, db, collection)
+			return nil, NewErrorMsg(ErrUnauthorized, msg)
+		}
+
+		collections = []string{collection}
+	}
+
+	name, err := GetRequiredParam[string](document, "archive")
+	if err != nil {
+		return nil, err
+	}
+
+	if name == "" || name == "." || name == ".." || filepath.Base(name) != name {
+		msg := fmt.Sprintf("Invalid archive name '%s'; it should be a file name without directories", name)
+		return nil, NewErrorMsg(ErrBadValue, msg)
+	}
+
+	path := filepath.Join(dir, name)
+
+	f, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0o666)
+	if err != nil {
+		if errors.Is(err, os.ErrExist) {
+			return nil, NewErrorMsg(ErrBadValue, fmt.Sprintf("Archive '%s' already exists", name))
+		}
+
+		return nil, lazyerrors.Error(err)
+	}
+
+	op := StartOperation(ctx, db, document, command)
+	defer op.Finish()
+
+	stats, err := dumpArchiveFile(ctx, b, f, db, collections)
+	if err != nil {
+		if rmErr := os.Remove(path); rmErr != nil {
+			l.Warn("Failed to remove incomplete archive", zap.String("path", path), zap.Error(rmErr))
+		}
+
+		if errors.Is(err, backend.ErrCollectionNotExist) {
+			return nil, NewErrorMsg(ErrNamespaceNotFound, "ns not found")
+		}
+
+		return nil, lazyerrors.Error(err)
+	}
+
+	l.Info(
+		"Archive written",
+		zap.String("path", path), zap.String("db", db),
+		zap.Int("collections", stats.Collections), zap.Int64("documents", stats.Documents),
+	)
+
+	var reply wire.OpMsg
+	err = reply.SetSections(wire.OpMsgSection{
+		Documents: []*types.Document{must.NotFail(types.NewDocument(
+			"archive", name,
+			"collections", int32(stats.Collections),
+			"documents", stats.Documents,
+			"ok", float64(1),
+		))},
+	})
+	if err != nil {
+		return nil, lazyerrors.Error(err)
+	}
+
+	return &reply, nil
+}
+
+// dumpArchiveFile writes the archive to the given file and closes it.
+func dumpArchiveFile(ctx context.Context, b backend.Backend, f *os.File, db string, collections []string) (*DumpStats, error) {
+	defer f.Close()
+
+	w := bufio.NewWriter(f)
+
+	stats, err := DumpArchive(ctx, b, w, db, collections)
+	if err != nil {
+		return nil, err
+	}
+
+	if err = w.Flush(); err != nil {
+		return nil, lazyerrors.Error(err)
+	}
+
+	if err = f.Close(); err != nil {
+		return nil, lazyerrors.Error(err)
+	}
+
+	return stats, nil
+}
@@ -0,0 +1,89 @@
+// Copyright 2021 FerretDB Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package common
+
+import (
+	"context"
+
+	"go.uber.org/zap"
+
+	"github.com/FerretDB/FerretDB/internal/types"
+	"github.com/FerretDB/FerretDB/internal/util/lazyerrors"
+	"github.com/FerretDB/FerretDB/internal/util/must"
+	"github.com/FerretDB/FerretDB/internal/wire"
+)
+
+// MsgFsync is a common implementation of the fsync command.
+//
+// Backends persist writes before acknowledging them, so without lock field it does nothing.
+// With lock field, it blocks new write commands (waiting for in-flight ones) until the matching fsyncUnlock,
+// so backup scripts written for MongoDB could take a consistent snapshot of the backend.
+// Backend-specific backup modes (like PostgreSQL's pg_backup_start) are not used,
+// because they require a single session held for the whole backup.
+func MsgFsync(ctx context.Context, msg *wire.OpMsg, l *zap.Logger) (*wire.OpMsg, error) {
+	document, err := msg.Document()
+	if err != nil {
+		return nil, lazyerrors.Error(err)
+	}
+
+	Ignored(document, l, "comment")
+
+	db, err := GetRequiredParam[string](document, "$db")
+	if err != nil {
+		return nil, err
+	}
+
+	if db != "admin" {
+		return nil, NewErrorMsg(ErrUnauthorized, "fsync may only be run against the admin database.")
+	}
+
+	lock, err := GetBoolOptionalParam(document, "lock")
+	if err != nil {
+		return nil, err
+	}
+
+	var res *types.Document
+
+	if lock {
+		var count int64
+		if count, err = writes.lock(ctx); err != nil {
+			return nil, lazyerrors.Error(err)
+		}
+
+		l.Info("Writes locked by fsync", zap.Int64("lockCount", count))
+
+		res = must.NotFail(types.NewDocument(
+			"info", "now locked against writes, use db.fsyncUnlock() to unlock",
+			"lockCount", count,
+			"seeAlso", "http://dochub.mongodb.org/core/fsynccommand",
+			"ok", float64(1),
+		))
+	} else {
+		res = must.NotFail(types.NewDocument(
+			"numFiles", int32(1),
+			"ok", float64(1),
+		))
+	}
+
+	var reply wire.OpMsg
+	err = reply.SetSections(wire.OpMsgSection{
+		Documents: []*types.Document{res},
+	})
+	if err != nil {
+		return nil, lazyerrors.Error(err)
+	}
+
+	return &reply, nil
+}
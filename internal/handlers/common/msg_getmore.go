@@ -0,0 +1,114 @@
+// Copyright 2021 FerretDB Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package common
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"go.uber.org/zap"
+
+	"github.com/FerretDB/FerretDB/internal/types"
+	"github.com/FerretDB/FerretDB/internal/util/lazyerrors"
+	"github.com/FerretDB/FerretDB/internal/util/must"
+	"github.com/FerretDB/FerretDB/internal/wire"
+)
+
+// MsgGetMore is a common implementation of the getMore command.
+//
+// It returns the next batch of documents from the cursor created by MakeCursorReply or MakeIteratorCursorReply.
+// The cursor is closed if it is exhausted or if fetching documents fails.
+func MsgGetMore(ctx context.Context, msg *wire.OpMsg, l *zap.Logger) (*wire.OpMsg, error) {
+	document, err := msg.Document()
+	if err != nil {
+		return nil, lazyerrors.Error(err)
+	}
+
+	Ignored(document, l, "maxTimeMS", "comment", "term", "lastKnownCommittedOpTime")
+
+	v := must.NotFail(document.Get(document.Command()))
+	id, ok := v.(int64)
+	if !ok {
+		msg := fmt.Sprintf("Field 'getMore' must be of type long in: %s", AliasFromType(v))
+		return nil, NewErrorMsg(ErrTypeMismatch, msg)
+	}
+
+	db, err := GetRequiredParam[string](document, "$db")
+	if err != nil {
+		return nil, err
+	}
+
+	collection, err := GetRequiredParam[string](document, "collection")
+	if err != nil {
+		return nil, err
+	}
+
+	var batchSize int64
+	if v, _ := document.Get("batchSize"); v != nil {
+		if batchSize, err = GetWholeNumberParam(v); err != nil || batchSize < 0 {
+			return nil, NewErrorMsg(ErrBadValue, "BatchSize value must be non-negative")
+		}
+	}
+
+	ns := db + "." + collection
+	now := time.Now()
+
+	c := cursors.take(id, cursorUsername(ctx), now)
+	if c == nil {
+		return nil, cursorNotFound(id)
+	}
+
+	if c.ns != ns {
+		cursors.put(id, c)
+
+		msg := fmt.Sprintf(
+			"Requested getMore on namespace '%s', but cursor belongs to a different namespace %s", ns, c.ns,
+		)
+		return nil, NewErrorMsg(ErrUnauthorized, msg)
+	}
+
+	batch, more, err := c.nextBatch(ctx, batchSize)
+	if err != nil {
+		c.close()
+		return nil, err
+	}
+
+	var resID int64
+	if more {
+		c.lastUsed = now
+		cursors.put(id, c)
+		resID = id
+	} else {
+		c.close()
+	}
+
+	var reply wire.OpMsg
+	err = reply.SetSections(wire.OpMsgSection{
+		Documents: []*types.Document{must.NotFail(types.NewDocument(
+			"cursor", must.NotFail(types.NewDocument(
+				"nextBatch", batch,
+				"id", resID,
+				"ns", ns,
+			)),
+			"ok", float64(1),
+		))},
+	})
+	if err != nil {
+		return nil, lazyerrors.Error(err)
+	}
+
+	return &reply, nil
+}
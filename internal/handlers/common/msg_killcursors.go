@@ -0,0 +1,87 @@
+// Copyright 2021 FerretDB Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package common
+
+import (
+	"context"
+	"fmt"
+
+	"go.uber.org/zap"
+
+	"github.com/FerretDB/FerretDB/internal/types"
+	"github.com/FerretDB/FerretDB/internal/util/lazyerrors"
+	"github.com/FerretDB/FerretDB/internal/util/must"
+	"github.com/FerretDB/FerretDB/internal/wire"
+)
+
+// MsgKillCursors is a common implementation of the killCursors command.
+func MsgKillCursors(ctx context.Context, msg *wire.OpMsg, l *zap.Logger) (*wire.OpMsg, error) {
+	document, err := msg.Document()
+	if err != nil {
+		return nil, lazyerrors.Error(err)
+	}
+
+	Ignored(document, l, "comment")
+
+	if _, err = GetRequiredParam[string](document, document.Command()); err != nil {
+		return nil, err
+	}
+
+	ids, err := GetRequiredParam[*types.Array](document, "cursors")
+	if err != nil {
+		return nil, err
+	}
+
+	cursorIDs := make([]int64, ids.Len())
+	for i := 0; i < ids.Len(); i++ {
+		v := must.NotFail(ids.Get(i))
+
+		id, ok := v.(int64)
+		if !ok {
+			msg := fmt.Sprintf("Field 'cursors' must contain only longs, got %s", AliasFromType(v))
+			return nil, NewErrorMsg(ErrTypeMismatch, msg)
+		}
+
+		cursorIDs[i] = id
+	}
+
+	killed, notFound := KillCursors(ctx, cursorIDs)
+
+	var reply wire.OpMsg
+	err = reply.SetSections(wire.OpMsgSection{
+		Documents: []*types.Document{must.NotFail(types.NewDocument(
+			"cursorsKilled", int64Array(killed),
+			"cursorsNotFound", int64Array(notFound),
+			"cursorsAlive", must.NotFail(types.NewArray()),
+			"cursorsUnknown", must.NotFail(types.NewArray()),
+			"ok", float64(1),
+		))},
+	})
+	if err != nil {
+		return nil, lazyerrors.Error(err)
+	}
+
+	return &reply, nil
+}
+
+// int64Array returns an array of given values.
+func int64Array(values []int64) *types.Array {
+	res := types.MakeArray(len(values))
+	for _, v := range values {
+		must.NoError(res.Append(v))
+	}
+
+	return res
+}
@@ -16,8 +16,11 @@ package common
 
 import (
 	"context"
+	"errors"
+	"fmt"
 	"sort"
 
+	"go.uber.org/zap"
 	"golang.org/x/exp/maps"
 
 	"github.com/FerretDB/FerretDB/internal/handlers"
@@ -34,6 +37,15 @@ type command struct {
 
 	// Handler processes command
 	Handler func(handlers.Interface, context.Context, *wire.OpMsg) (*wire.OpMsg, error)
+
+	// Feature is an optional feature required by the command, if any (see CheckCapabilities)
+	Feature handlers.Feature
+
+	// FieldFeatures are optional features required by command fields, if any (see CheckCapabilities)
+	FieldFeatures map[string]handlers.Feature
+
+	// Write is true for commands that modify data; they wait while writes are locked by fsync (see StartWrite)
+	Write bool
 }
 
 // Commands is a map of Commands that Handler interface can support.
@@ -42,6 +54,16 @@ type command struct {
 // Please keep help text in sync with handlers.Interface methods documentation.
 var Commands = map[string]command{
 	// sorted alphabetically
+	"applyOps": {
+		Help:    "Applies insert, update, and delete oplog entries.",
+		Handler: (handlers.Interface).MsgApplyOps,
+		Write:   true,
+	},
+	"authenticate": {
+		Help:    "Authenticates the client using X.509 certificate.",
+		Handler: (handlers.Interface).MsgAuthenticate,
+		Feature: handlers.FeatureAuthentication,
+	},
 	"buildinfo": {
 		Help:    "Returns a summary of the build information.",
 		Handler: (handlers.Interface).MsgBuildInfo,
@@ -53,6 +75,7 @@ var Commands = map[string]command{
 	"collStats": {
 		Help:    "Returns storage data for a collection.",
 		Handler: (handlers.Interface).MsgCollStats,
+		Feature: handlers.FeatureCollStats,
 	},
 	"connectionStatus": {
 		Help: "Returns information about the current connection, " +
@@ -62,22 +85,41 @@ var Commands = map[string]command{
 	"count": {
 		Help:    "Returns the count of documents that's matched by the query.",
 		Handler: (handlers.Interface).MsgCount,
+		Feature: handlers.FeatureCount,
 	},
 	"create": {
 		Help:    "Creates the collection.",
 		Handler: (handlers.Interface).MsgCreate,
+		FieldFeatures: map[string]handlers.Feature{
+			"partition": handlers.FeaturePartitioning,
+		},
+		Write: true,
 	},
 	"createIndexes": {
 		Help:    "Creates indexes on a collection.",
 		Handler: (handlers.Interface).MsgCreateIndexes,
+		Feature: handlers.FeatureIndexes,
+		Write:   true,
+	},
+	"createUser": {
+		Help:    "Creates a new user.",
+		Handler: (handlers.Interface).MsgCreateUser,
+		Feature: handlers.FeatureUserManagement,
+		Write:   true,
+	},
+	"currentOp": {
+		Help:    "Returns information about operations currently in progress.",
+		Handler: (handlers.Interface).MsgCurrentOp,
 	},
 	"dataSize": {
 		Help:    "Returns the size of the collection in bytes.",
 		Handler: (handlers.Interface).MsgDataSize,
+		Feature: handlers.FeatureDataSize,
 	},
 	"dbStats": {
 		Help:    "Returns the statistics of the database.",
 		Handler: (handlers.Interface).MsgDBStats,
+		Feature: handlers.FeatureDBStats,
 	},
 	"debugError": {
 		Help:    "Returns error for debugging.",
@@ -86,14 +128,28 @@ var Commands = map[string]command{
 	"delete": {
 		Help:    "Deletes documents matched by the query.",
 		Handler: (handlers.Interface).MsgDelete,
+		Write:   true,
 	},
 	"drop": {
 		Help:    "Drops the collection.",
 		Handler: (handlers.Interface).MsgDrop,
+		Write:   true,
 	},
 	"dropDatabase": {
 		Help:    "Drops production database.",
 		Handler: (handlers.Interface).MsgDropDatabase,
+		Write:   true,
+	},
+	"dropUser": {
+		Help:    "Removes the user.",
+		Handler: (handlers.Interface).MsgDropUser,
+		Feature: handlers.FeatureUserManagement,
+		Write:   true,
+	},
+	"dumpArchive": {
+		Help:    "Writes collections to the archive in mongodump format.",
+		Handler: (handlers.Interface).MsgDumpArchive,
+		Feature: handlers.FeatureDumpArchive,
 	},
 	"find": {
 		Help:    "Returns documents matched by the query.",
@@ -102,6 +158,20 @@ var Commands = map[string]command{
 	"findAndModify": {
 		Help:    "Inserts, updates, or deletes, and returns a document matched by the query.",
 		Handler: (handlers.Interface).MsgFindAndModify,
+		Feature: handlers.FeatureFindAndModify,
+		Write:   true,
+	},
+	"fsync": {
+		Help: "Locks writes with lock field for backups; see fsyncUnlock.",
+		Handler: func(h handlers.Interface, ctx context.Context, msg *wire.OpMsg) (*wire.OpMsg, error) {
+			return MsgFsync(ctx, msg, zap.L())
+		},
+	},
+	"fsyncUnlock": {
+		Help: "Unlocks writes locked by fsync.",
+		Handler: func(h handlers.Interface, ctx context.Context, msg *wire.OpMsg) (*wire.OpMsg, error) {
+			return MsgFsyncUnlock(ctx, msg, zap.L())
+		},
 	},
 	"getCmdLineOpts": {
 		Help:    "Returns a summary of all runtime and configuration options.",
@@ -115,6 +185,10 @@ var Commands = map[string]command{
 		Help:    "Returns the most recent logged events from memory.",
 		Handler: (handlers.Interface).MsgGetLog,
 	},
+	"getMore": {
+		Help:    "Returns the next batch of documents from a cursor.",
+		Handler: (handlers.Interface).MsgGetMore,
+	},
 	"getParameter": {
 		Help:    "Returns the value of the parameter.",
 		Handler: (handlers.Interface).MsgGetParameter,
@@ -130,6 +204,7 @@ var Commands = map[string]command{
 	"insert": {
 		Help:    "Inserts documents into the database.",
 		Handler: (handlers.Interface).MsgInsert,
+		Write:   true,
 	},
 	"ismaster": {
 		Help:    "Returns the role of the FerretDB instance.",
@@ -139,6 +214,10 @@ var Commands = map[string]command{
 		Help:    "Returns the role of the FerretDB instance.",
 		Handler: (handlers.Interface).MsgIsMaster,
 	},
+	"killCursors": {
+		Help:    "Closes cursors.",
+		Handler: (handlers.Interface).MsgKillCursors,
+	},
 	"listCollections": {
 		Help:    "Returns the information of the collections and views in the database.",
 		Handler: (handlers.Interface).MsgListCollections,
@@ -151,21 +230,65 @@ var Commands = map[string]command{
 		Help:    "Returns a summary of all the databases.",
 		Handler: (handlers.Interface).MsgListDatabases,
 	},
+	"listIndexes": {
+		Help:    "Returns a summary of indexes of the specified collection.",
+		Handler: (handlers.Interface).MsgListIndexes,
+		Feature: handlers.FeatureIndexes,
+	},
+	"mapReduce": {
+		Help: "Runs a map-reduce aggregation; only common patterns that sum values are supported.",
+		Handler: func(h handlers.Interface, ctx context.Context, msg *wire.OpMsg) (*wire.OpMsg, error) {
+			return MsgMapReduce(ctx, h, msg)
+		},
+		Write: true,
+	},
+	"migrateCollection": {
+		Help:    "Converts the collection to the latest storage format.",
+		Handler: (handlers.Interface).MsgMigrateCollection,
+		Feature: handlers.FeatureMigrateCollection,
+		Write:   true,
+	},
 	"ping": {
 		Help:    "Returns a pong response.",
 		Handler: (handlers.Interface).MsgPing,
 	},
+	"saslContinue": {
+		Help:    "Continues the SASL authentication conversation.",
+		Handler: (handlers.Interface).MsgSASLContinue,
+		Feature: handlers.FeatureAuthentication,
+	},
+	"saslStart": {
+		Help:    "Starts the SASL authentication conversation.",
+		Handler: (handlers.Interface).MsgSASLStart,
+		Feature: handlers.FeatureAuthentication,
+	},
 	"serverStatus": {
 		Help:    "Returns an overview of the databases state.",
 		Handler: (handlers.Interface).MsgServerStatus,
 	},
+	"setFeatureCompatibilityVersion": {
+		Help:    "Sets the feature compatibility version that gates new behaviors.",
+		Handler: (handlers.Interface).MsgSetFeatureCompatibilityVersion,
+		Feature: handlers.FeatureFCV,
+		Write:   true,
+	},
 	"setFreeMonitoring": {
 		Help:    "Toggles free monitoring.",
 		Handler: (handlers.Interface).MsgSetFreeMonitoring,
 	},
+	"setParameter": {
+		Help:    "Sets the value of the parameter.",
+		Handler: (handlers.Interface).MsgSetParameter,
+	},
 	"update": {
 		Help:    "Updates documents that are matched by the query.",
 		Handler: (handlers.Interface).MsgUpdate,
+		Write:   true,
+	},
+	"usersInfo": {
+		Help:    "Returns information about users.",
+		Handler: (handlers.Interface).MsgUsersInfo,
+		Feature: handlers.FeatureUserManagement,
 	},
 	"whatsmyuri": {
 		Help:    "Returns peer information.",
@@ -173,6 +296,38 @@ var Commands = map[string]command{
 	},
 }
 
+// RegisterCommand adds a custom command, like a proprietary one, to Commands.
+// Custom commands do not use the handler.
+//
+// If write is true, the command is treated as modifying data (see command's Write field).
+//
+// It returns an error if the command with the same name already exists.
+// It is not safe for concurrent use and should be called before any commands are handled,
+// for example, from init functions.
+func RegisterCommand(name, help string, write bool, handler func(context.Context, *wire.OpMsg) (*wire.OpMsg, error)) error {
+	if name == "" {
+		return errors.New("command name is empty")
+	}
+
+	if handler == nil {
+		return fmt.Errorf("command %q has no handler", name)
+	}
+
+	if _, ok := Commands[name]; ok {
+		return fmt.Errorf("command %q already exists", name)
+	}
+
+	Commands[name] = command{
+		Help: help,
+		Handler: func(_ handlers.Interface, ctx context.Context, msg *wire.OpMsg) (*wire.OpMsg, error) {
+			return handler(ctx, msg)
+		},
+		Write: write,
+	}
+
+	return nil
+}
+
 // MsgListCommands is a common implementation of the listCommands command.
 func MsgListCommands(ctx context.Context, msg *wire.OpMsg) (*wire.OpMsg, error) {
 	var reply wire.OpMsg
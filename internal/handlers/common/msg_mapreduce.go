@@ -0,0 +1,477 @@
+// Copyright 2021 FerretDB Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package common
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/FerretDB/FerretDB/internal/handlers"
+	"github.com/FerretDB/FerretDB/internal/types"
+	"github.com/FerretDB/FerretDB/internal/util/lazyerrors"
+	"github.com/FerretDB/FerretDB/internal/util/must"
+	"github.com/FerretDB/FerretDB/internal/wire"
+)
+
+// jsPath matches a JavaScript property path like `a.b`.
+const jsPath = `[A-Za-z_$][\w$]*(?:\.[A-Za-z_$][\w$]*)*`
+
+// mapFunctionRE matches supported map functions: `function() { emit(this.<key>, <number or this.<value>>); }`.
+var mapFunctionRE = regexp.MustCompile(
+	`^function\s*(?:[\w$]+\s*)?\(\s*\)\s*\{\s*emit\s*\(\s*this\.(` + jsPath + `)\s*,\s*` +
+		`(?:this\.(` + jsPath + `)|([-+]?\d+(?:\.\d+)?))\s*\)\s*;?\s*\}\s*;?$`,
+)
+
+// jsCommentRE matches JavaScript comments.
+var jsCommentRE = regexp.MustCompile(`(?s)//[^\n]*|/\*.*?\*/`)
+
+// jsTokenRE matches JavaScript tokens that are used by reduce functions.
+var jsTokenRE = regexp.MustCompile(`[A-Za-z_$][\w$]*|\d+(?:\.\d+)?|=>|\+\+|\+=|===|==|<=|\S`)
+
+// jsKeywords contains identifiers that are not renamed by canonicalReduce.
+// Property names like `length` are not renamed either.
+var jsKeywords = map[string]struct{}{
+	"Array": {}, "for": {}, "function": {}, "in": {}, "of": {}, "return": {}, "var": {},
+}
+
+// sumReduceFunctions contains supported reduce functions that sum values.
+//
+// Functions are compared after canonicalization (see canonicalReduce),
+// so names, formatting, optional semicolons and braces, and var/let/const do not matter.
+var sumReduceFunctions = map[string]struct{}{}
+
+func init() {
+	for _, f := range []string{
+		`function(k, v) { return Array.sum(v); }`,
+		`function(k, v) { return v.reduce(function(a, b) { return a + b; }, 0); }`,
+		`function(k, v) { return v.reduce(function(a, b) { return a + b; }); }`,
+		`function(k, v) { return v.reduce((a, b) => a + b, 0); }`,
+		`function(k, v) { return v.reduce((a, b) => a + b); }`,
+		`function(k, v) { var s = 0; for (var i = 0; i < v.length; i++) { s += v[i]; } return s; }`,
+		`function(k, v) { var s = 0; for (var i = 0; i < v.length; ++i) { s += v[i]; } return s; }`,
+		`function(k, v) { var s = 0; for (var i = 0; i < v.length; i += 1) { s += v[i]; } return s; }`,
+		`function(k, v) { var s = 0; for (var i in v) { s += v[i]; } return s; }`,
+		`function(k, v) { var s = 0; for (var x of v) { s += x; } return s; }`,
+		`function(k, v) { var s = 0; v.forEach(function(x) { s += x; }); return s; }`,
+		`function(k, v) { var s = 0; v.forEach((x) => { s += x; }); return s; }`,
+		`function(k, v) { var s = 0; v.forEach(x => { s += x; }); return s; }`,
+	} {
+		sumReduceFunctions[must.NotFail(canonicalReduce(f))] = struct{}{}
+	}
+}
+
+// mapFunction represents a supported map function that emits a key and a value.
+type mapFunction struct {
+	key      types.Path // emitted key
+	value    types.Path // emitted value; empty if constant is emitted instead
+	constant float64    // emitted constant value
+}
+
+// emit returns the key and the value emitted for the given document.
+//
+// Missing fields are emitted as null.
+func (m *mapFunction) emit(doc *types.Document) (any, any) {
+	key, err := doc.GetByPath(m.key)
+	if err != nil {
+		key = types.Null
+	}
+
+	if m.value.Len() == 0 {
+		return key, m.constant
+	}
+
+	value, err := doc.GetByPath(m.value)
+	if err != nil {
+		value = types.Null
+	}
+
+	return key, value
+}
+
+// parseMap parses the map function's code.
+func parseMap(code string) (*mapFunction, error) {
+	code = strings.TrimSpace(jsCommentRE.ReplaceAllString(code, ""))
+
+	match := mapFunctionRE.FindStringSubmatch(code)
+	if match == nil {
+		msg := "mapReduce: map function is not supported; " +
+			"only `function() { emit(this.<field>, <number or this.<field>>); }` is"
+		return nil, NewErrorMsg(ErrNotImplemented, msg)
+	}
+
+	res := &mapFunction{
+		key: types.NewPathFromString(match[1]),
+	}
+
+	if match[2] != "" {
+		res.value = types.NewPathFromString(match[2])
+		return res, nil
+	}
+
+	v, err := strconv.ParseFloat(match[3], 64)
+	if err != nil {
+		return nil, lazyerrors.Error(err)
+	}
+
+	res.constant = v
+
+	return res, nil
+}
+
+// parseReduce checks that the reduce function's code sums values.
+func parseReduce(code string) error {
+	canonical, err := canonicalReduce(code)
+	if err == nil {
+		if _, ok := sumReduceFunctions[canonical]; ok {
+			return nil
+		}
+	}
+
+	msg := "mapReduce: reduce function is not supported; only functions that sum values, " +
+		"like `function(key, values) { return Array.sum(values); }`, are"
+	return NewErrorMsg(ErrNotImplemented, msg)
+}
+
+// canonicalReduce returns the canonical form of the reduce function's code:
+// tokens separated by spaces without braces and semicolons,
+// with parameters renamed to K and V, and other identifiers renamed to x0, x1, etc.
+func canonicalReduce(code string) (string, error) {
+	tokens := jsTokenRE.FindAllString(jsCommentRE.ReplaceAllString(code, ""), -1)
+
+	// function [name](key, values) { ... } or (key, values) => ...
+	var arrow bool
+	switch {
+	case len(tokens) > 0 && tokens[0] == "function":
+		tokens = tokens[1:]
+		if len(tokens) > 0 && tokens[0] != "(" {
+			tokens = tokens[1:]
+		}
+	default:
+		arrow = true
+	}
+
+	if len(tokens) < 5 || tokens[0] != "(" || tokens[2] != "," || tokens[4] != ")" {
+		return "", lazyerrors.New("invalid reduce function parameters")
+	}
+
+	if tokens[1] == tokens[3] {
+		return "", lazyerrors.New("duplicate reduce function parameters")
+	}
+
+	names := map[string]string{
+		tokens[1]: "K",
+		tokens[3]: "V",
+	}
+	body := tokens[5:]
+
+	if arrow {
+		if len(body) == 0 || body[0] != "=>" {
+			return "", lazyerrors.New("invalid reduce function")
+		}
+
+		body = body[1:]
+		if len(body) > 0 && body[0] != "{" {
+			body = append([]string{"return"}, body...)
+		}
+	}
+
+	res := make([]string, 0, len(body))
+	for i, t := range body {
+		switch t {
+		case "{", "}", ";":
+			continue
+		case "let", "const":
+			t = "var"
+		}
+
+		c := t[0]
+		isIdent := c == '_' || c == '$' || (c >= 'A' && c <= 'Z') || (c >= 'a' && c <= 'z')
+		_, isKeyword := jsKeywords[t]
+
+		// property names after dot are kept
+		if isIdent && !isKeyword && (i == 0 || body[i-1] != ".") {
+			name, ok := names[t]
+			if !ok {
+				name = "x" + strconv.Itoa(len(names)-2)
+				names[t] = name
+			}
+
+			t = name
+		}
+
+		res = append(res, t)
+	}
+
+	return strings.Join(res, " "), nil
+}
+
+// javaScriptParam returns the JavaScript code of the given field that could be string or JavaScript value.
+func javaScriptParam(doc *types.Document, field string) (string, error) {
+	v, err := doc.Get(field)
+	if err != nil {
+		return "", NewErrorMsg(ErrFailedToParse, fmt.Sprintf("'%s' argument must be specified", field))
+	}
+
+	switch v := v.(type) {
+	case string:
+		return v, nil
+	case types.JavaScript:
+		return v.Code, nil
+	default:
+		msg := fmt.Sprintf("'%s' argument must be a string or JavaScript code, not %s", field, AliasFromType(v))
+		return "", NewErrorMsg(ErrTypeMismatch, msg)
+	}
+}
+
+// isInlineOutput returns true if the out argument is {inline: 1}.
+func isInlineOutput(out any) bool {
+	doc, ok := out.(*types.Document)
+	if !ok || doc.Len() != 1 {
+		return false
+	}
+
+	v, err := doc.Get("inline")
+	if err != nil {
+		return false
+	}
+
+	n, err := GetWholeNumberParam(v)
+	return err == nil && n == 1
+}
+
+// mapReduceGroup groups emitted values by keys like $group stage and sums them like the reduce function.
+//
+// Keys are compared by values, so 1 and 1.0 are the same key. The result is sorted by keys.
+// The reduce function is not called for keys with a single value, so it is returned as is.
+// Otherwise, values are summed as JavaScript numbers; nulls are treated as zeros.
+func mapReduceGroup(keys, values []any) (*types.Array, error) {
+	indexes := make([]int, len(keys))
+	for i := range indexes {
+		indexes[i] = i
+	}
+
+	sort.SliceStable(indexes, func(i, j int) bool {
+		return types.CompareOrderByValue(keys[indexes[i]], keys[indexes[j]]) == types.Less
+	})
+
+	res := types.MakeArray(0)
+
+	for start := 0; start < len(indexes); {
+		end := start + 1
+		for end < len(indexes) && types.CompareOrderByValue(keys[indexes[start]], keys[indexes[end]]) == types.Equal {
+			end++
+		}
+
+		value := values[indexes[start]]
+
+		if end-start > 1 {
+			var sum float64
+			for _, i := range indexes[start:end] {
+				switch v := values[i].(type) {
+				case float64:
+					sum += v
+				case int32:
+					sum += float64(v)
+				case int64:
+					sum += float64(v)
+				case types.NullType:
+					// the same as 0
+				default:
+					msg := fmt.Sprintf("mapReduce: emitted value of type %s can't be summed", AliasFromType(v))
+					return nil, NewErrorMsg(ErrNotImplemented, msg)
+				}
+			}
+
+			value = sum
+		}
+
+		must.NoError(res.Append(must.NotFail(types.NewDocument("_id", keys[indexes[start]], "value", value))))
+
+		start = end
+	}
+
+	return res, nil
+}
+
+// MsgMapReduce is a common implementation of the mapReduce command.
+//
+// FerretDB does not execute JavaScript, so only common patterns that could be expressed as $group stage
+// with $sum are supported: map functions that emit a field value as the key and a number or another field value
+// as the value (see parseMap), and reduce functions that sum values (see parseReduce).
+// Other functions are rejected with a clear error instead of being executed.
+// Input documents are fetched with handler's find and getMore commands; results are returned inline.
+func MsgMapReduce(ctx context.Context, h handlers.Interface, msg *wire.OpMsg) (*wire.OpMsg, error) {
+	document, err := msg.Document()
+	if err != nil {
+		return nil, lazyerrors.Error(err)
+	}
+
+	if err = Unimplemented(document, "finalize", "scope", "collation"); err != nil {
+		return nil, err
+	}
+
+	collection, err := GetRequiredParam[string](document, document.Command())
+	if err != nil {
+		return nil, err
+	}
+
+	db, err := GetRequiredParam[string](document, "$db")
+	if err != nil {
+		return nil, err
+	}
+
+	out, err := document.Get("out")
+	if err != nil {
+		return nil, NewErrorMsg(ErrFailedToParse, "'out' argument must be specified")
+	}
+
+	if !isInlineOutput(out) {
+		return nil, NewErrorMsg(ErrNotImplemented, "mapReduce: only inline output ({out: {inline: 1}}) is supported")
+	}
+
+	code, err := javaScriptParam(document, "map")
+	if err != nil {
+		return nil, err
+	}
+
+	m, err := parseMap(code)
+	if err != nil {
+		return nil, err
+	}
+
+	if code, err = javaScriptParam(document, "reduce"); err != nil {
+		return nil, err
+	}
+
+	if err = parseReduce(code); err != nil {
+		return nil, err
+	}
+
+	maxTimeMS, err := GetMaxTimeMSParam(document)
+	if err != nil {
+		return nil, err
+	}
+
+	ctx, cancel := WithMaxTimeMS(ctx, maxTimeMS)
+	defer cancel()
+
+	find := must.NotFail(types.NewDocument("find", collection))
+	for _, field := range []string{"query", "sort", "limit"} {
+		if v, _ := document.Get(field); v != nil {
+			if field == "query" {
+				field = "filter"
+			}
+
+			must.NoError(find.Set(field, v))
+		}
+	}
+	must.NoError(find.Set("$db", db))
+
+	var keys, values []any
+	err = findAll(ctx, h, find, func(doc *types.Document) {
+		key, value := m.emit(doc)
+		keys = append(keys, key)
+		values = append(values, value)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	results, err := mapReduceGroup(keys, values)
+	if err != nil {
+		return nil, err
+	}
+
+	var reply wire.OpMsg
+	err = reply.SetSections(wire.OpMsgSection{
+		Documents: []*types.Document{must.NotFail(types.NewDocument(
+			"results", results,
+			"ok", float64(1),
+		))},
+	})
+	if err != nil {
+		return nil, lazyerrors.Error(err)
+	}
+
+	return &reply, nil
+}
+
+// findAll runs the given find command with the handler and calls fn for all found documents,
+// fetching the rest of them with getMore commands.
+func findAll(ctx context.Context, h handlers.Interface, find *types.Document, fn func(*types.Document)) error {
+	var msg wire.OpMsg
+	must.NoError(msg.SetSections(wire.OpMsgSection{Documents: []*types.Document{find}}))
+
+	res, err := h.MsgFind(ctx, &msg)
+	if err != nil {
+		return err
+	}
+
+	batchField := "firstBatch"
+
+	for {
+		doc, err := res.Document()
+		if err != nil {
+			return lazyerrors.Error(err)
+		}
+
+		cursor, err := GetRequiredParam[*types.Document](doc, "cursor")
+		if err != nil {
+			return lazyerrors.Error(err)
+		}
+
+		batch, err := GetRequiredParam[*types.Array](cursor, batchField)
+		if err != nil {
+			return lazyerrors.Error(err)
+		}
+
+		for i := 0; i < batch.Len(); i++ {
+			d, ok := must.NotFail(batch.Get(i)).(*types.Document)
+			if !ok {
+				return lazyerrors.Errorf("expected document in %s", batchField)
+			}
+
+			fn(d)
+		}
+
+		id, err := GetRequiredParam[int64](cursor, "id")
+		if err != nil {
+			return lazyerrors.Error(err)
+		}
+
+		if id == 0 {
+			return nil
+		}
+
+		getMore := must.NotFail(types.NewDocument(
+			"getMore", id,
+			"collection", must.NotFail(find.Get("find")),
+			"$db", must.NotFail(find.Get("$db")),
+		))
+
+		msg = wire.OpMsg{}
+		must.NoError(msg.SetSections(wire.OpMsgSection{Documents: []*types.Document{getMore}}))
+
+		if res, err = h.MsgGetMore(ctx, &msg); err != nil {
+			return err
+		}
+
+		batchField = "nextBatch"
+	}
+}
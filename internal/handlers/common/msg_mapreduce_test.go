@@ -0,0 +1,156 @@
+// Copyright 2021 FerretDB Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package common
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/FerretDB/FerretDB/internal/types"
+	"github.com/FerretDB/FerretDB/internal/util/must"
+)
+
+func TestParseMap(t *testing.T) {
+	t.Parallel()
+
+	for name, tc := range map[string]struct {
+		code     string
+		expected *mapFunction // nil if not supported
+	}{
+		"Count": {
+			code:     "function() { emit(this.status, 1); }",
+			expected: &mapFunction{key: types.NewPathFromString("status"), constant: 1},
+		},
+		"Sum": {
+			code: "function map() {\n  // sum amounts\n  emit(this.customer.id, this.amount)\n}",
+			expected: &mapFunction{
+				key:   types.NewPathFromString("customer.id"),
+				value: types.NewPathFromString("amount"),
+			},
+		},
+		"Object": {
+			code: "function() { emit(this.status, {count: 1}); }",
+		},
+		"Loop": {
+			code: "function() { this.tags.forEach(function(t) { emit(t, 1); }); }",
+		},
+		"Arrow": {
+			code: "() => emit(this.status, 1)",
+		},
+	} {
+		name, tc := name, tc
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			actual, err := parseMap(tc.code)
+			if tc.expected == nil {
+				var ce *CommandError
+				require.ErrorAs(t, err, &ce)
+				assert.Equal(t, ErrNotImplemented, ce.Code())
+				return
+			}
+
+			require.NoError(t, err)
+			assert.Equal(t, tc.expected, actual)
+		})
+	}
+}
+
+func TestParseReduce(t *testing.T) {
+	t.Parallel()
+
+	for name, tc := range map[string]struct {
+		code      string
+		supported bool
+	}{
+		"ArraySum": {
+			code:      "function(key, values) { return Array.sum(values); }",
+			supported: true,
+		},
+		"ArraySumArrow": {
+			code:      "(k, vals) => Array.sum(vals)",
+			supported: true,
+		},
+		"Loop": {
+			code: `function reduce(key, counts) {
+				let total = 0;
+				for (let j = 0; j < counts.length; j++)
+					total += counts[j];
+				return total;
+			}`,
+			supported: true,
+		},
+		"ForEach": {
+			code:      "function(key, values) { var sum = 0; values.forEach(v => { sum += v }); return sum }",
+			supported: true,
+		},
+		"Reduce": {
+			code:      "function(_, values) { /* sum */ return values.reduce((x, y) => x + y, 0); }",
+			supported: true,
+		},
+		"Length": {
+			code: "function(key, values) { return values.length; }",
+		},
+		"SumKey": {
+			code: "function(key, values) { return Array.sum(key); }",
+		},
+		"Max": {
+			code: "function(key, values) { return Math.max.apply(null, values); }",
+		},
+		"Invalid": {
+			code: "return 1",
+		},
+	} {
+		name, tc := name, tc
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			err := parseReduce(tc.code)
+			if tc.supported {
+				assert.NoError(t, err)
+				return
+			}
+
+			var ce *CommandError
+			require.ErrorAs(t, err, &ce)
+			assert.Equal(t, ErrNotImplemented, ce.Code())
+		})
+	}
+}
+
+func TestMapReduceGroup(t *testing.T) {
+	t.Parallel()
+
+	keys := []any{"b", "a", int32(1), "b", float64(1), types.Null, "b"}
+	values := []any{float64(1), int32(2), int64(3), int32(4), float64(0.5), "x", types.Null}
+
+	actual, err := mapReduceGroup(keys, values)
+	require.NoError(t, err)
+
+	expected := must.NotFail(types.NewArray(
+		must.NotFail(types.NewDocument("_id", types.Null, "value", "x")),
+		must.NotFail(types.NewDocument("_id", int32(1), "value", float64(3.5))),
+		must.NotFail(types.NewDocument("_id", "a", "value", int32(2))),
+		must.NotFail(types.NewDocument("_id", "b", "value", float64(5))),
+	))
+	assert.Equal(t, expected, actual)
+
+	_, err = mapReduceGroup([]any{"a", "a"}, []any{"x", float64(1)})
+	var ce *CommandError
+	require.ErrorAs(t, err, &ce)
+	assert.Equal(t, ErrNotImplemented, ce.Code())
+}
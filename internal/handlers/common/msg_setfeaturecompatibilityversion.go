@@ -0,0 +1,96 @@
+// Copyright 2021 FerretDB Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package common
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"go.uber.org/zap"
+
+	"github.com/FerretDB/FerretDB/internal/handlers/backend"
+	"github.com/FerretDB/FerretDB/internal/types"
+	"github.com/FerretDB/FerretDB/internal/util/lazyerrors"
+	"github.com/FerretDB/FerretDB/internal/util/must"
+	"github.com/FerretDB/FerretDB/internal/wire"
+)
+
+// MsgSetFeatureCompatibilityVersion is a common implementation of the setFeatureCompatibilityVersion command.
+//
+// The new FCV is persisted in the given backend (see LoadFCV) before it is applied.
+// Other FerretDB instances using the same backend pick it up on restart.
+func MsgSetFeatureCompatibilityVersion(
+	ctx context.Context, msg *wire.OpMsg, b backend.Backend, l *zap.Logger,
+) (*wire.OpMsg, error) {
+	document, err := msg.Document()
+	if err != nil {
+		return nil, lazyerrors.Error(err)
+	}
+
+	Ignored(document, l, "comment", "confirm", "writeConcern")
+
+	db, err := GetRequiredParam[string](document, "$db")
+	if err != nil {
+		return nil, err
+	}
+
+	command := document.Command()
+
+	if db != fcvDB {
+		msg := fmt.Sprintf("%s may only be run against the admin database.", command)
+		return nil, NewErrorMsg(ErrUnauthorized, msg)
+	}
+
+	s, err := GetRequiredParam[string](document, command)
+	if err != nil {
+		return nil, err
+	}
+
+	v, err := ParseFCV(s)
+	if err != nil {
+		expected := make([]string, len(AllFCVs))
+		for i, v := range AllFCVs {
+			expected[i] = "'" + v.String() + "'"
+		}
+
+		msg := fmt.Sprintf(
+			"Invalid feature compatibility version value '%s'. Expected one of the following versions: %s",
+			s, strings.Join(expected, ", "),
+		)
+		return nil, NewErrorMsg(ErrBadValue, msg)
+	}
+
+	if err = storeFCV(ctx, b, v); err != nil {
+		return nil, lazyerrors.Error(err)
+	}
+
+	was := GetFCV()
+	SetFCV(v)
+
+	l.Info("Feature compatibility version changed", zap.Stringer("from", was), zap.Stringer("to", v))
+
+	var reply wire.OpMsg
+	err = reply.SetSections(wire.OpMsgSection{
+		Documents: []*types.Document{must.NotFail(types.NewDocument(
+			"ok", float64(1),
+		))},
+	})
+	if err != nil {
+		return nil, lazyerrors.Error(err)
+	}
+
+	return &reply, nil
+}
@@ -0,0 +1,197 @@
+// Copyright 2021 FerretDB Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package common
+
+import (
+	"context"
+	"fmt"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+
+	"github.com/FerretDB/FerretDB/internal/types"
+	"github.com/FerretDB/FerretDB/internal/util/lazyerrors"
+	"github.com/FerretDB/FerretDB/internal/util/logging"
+	"github.com/FerretDB/FerretDB/internal/util/must"
+	"github.com/FerretDB/FerretDB/internal/wire"
+)
+
+// MsgSetParameter is a common implementation of the setParameter command.
+//
+// Only redactClientLogData and logComponentVerbosity parameters are supported.
+// The first one accepts either a boolean (like MongoDB) or one of wire.RedactMode string representations.
+// The second one changes the logging level; see setLogComponentVerbosity.
+func MsgSetParameter(ctx context.Context, msg *wire.OpMsg, l *zap.Logger) (*wire.OpMsg, error) {
+	document, err := msg.Document()
+	if err != nil {
+		return nil, lazyerrors.Error(err)
+	}
+
+	Ignored(document, l, "comment")
+
+	db, err := GetRequiredParam[string](document, "$db")
+	if err != nil {
+		return nil, err
+	}
+
+	if db != "admin" {
+		return nil, NewErrorMsg(ErrUnauthorized, "setParameter may only be run against the admin database.")
+	}
+
+	var set bool
+	var was any
+
+	for _, k := range document.Keys() {
+		switch k {
+		case document.Command(), "comment", "$db":
+			continue
+
+		case "redactClientLogData":
+			if set {
+				return nil, NewErrorMsg(ErrInvalidOptions, "setParameter can only set one parameter at a time")
+			}
+
+			was = wire.GetRedactMode() != wire.RedactNone
+
+			if err = setRedactClientLogData(must.NotFail(document.Get(k))); err != nil {
+				return nil, err
+			}
+
+			l.Info("Log redaction mode changed", zap.Stringer("mode", wire.GetRedactMode()))
+
+			set = true
+
+		case "logComponentVerbosity":
+			if set {
+				return nil, NewErrorMsg(ErrInvalidOptions, "setParameter can only set one parameter at a time")
+			}
+
+			was = LogComponentVerbosity()
+
+			if err = setLogComponentVerbosity(must.NotFail(document.Get(k)), l); err != nil {
+				return nil, err
+			}
+
+			l.Info("Log level changed", zap.Stringer("level", logging.GetLevel()))
+
+			set = true
+
+		default:
+			msg := fmt.Sprintf("Attempted to set unknown parameter '%s' via setParameter command", k)
+			return nil, NewErrorMsg(ErrInvalidOptions, msg)
+		}
+	}
+
+	if !set {
+		return nil, NewErrorMsg(ErrInvalidOptions, "no option found to set, use help:true to see options ")
+	}
+
+	var reply wire.OpMsg
+	err = reply.SetSections(wire.OpMsgSection{
+		Documents: []*types.Document{must.NotFail(types.NewDocument(
+			"was", was,
+			"ok", float64(1),
+		))},
+	})
+	if err != nil {
+		return nil, lazyerrors.Error(err)
+	}
+
+	return &reply, nil
+}
+
+// setRedactClientLogData changes log redaction mode.
+//
+// true keeps the current redaction mode if it is already enabled, or enables wire.RedactElide mode.
+func setRedactClientLogData(value any) error {
+	switch value := value.(type) {
+	case bool:
+		switch {
+		case !value:
+			wire.SetRedactMode(wire.RedactNone)
+		case wire.GetRedactMode() == wire.RedactNone:
+			wire.SetRedactMode(wire.RedactElide)
+		}
+
+	case string:
+		mode, err := wire.ParseRedactMode(value)
+		if err != nil {
+			return NewErrorMsg(ErrBadValue, fmt.Sprintf("Invalid value for redactClientLogData: %s", err))
+		}
+
+		wire.SetRedactMode(mode)
+
+	default:
+		msg := fmt.Sprintf(
+			"BSON field 'redactClientLogData' is the wrong type '%s', expected types '[bool, string]'",
+			AliasFromType(value),
+		)
+		return NewErrorMsg(ErrTypeMismatch, msg)
+	}
+
+	return nil
+}
+
+// LogComponentVerbosity returns the value of logComponentVerbosity parameter for the current logging level.
+//
+// Verbosity 0 is info level (and higher), 1 and higher are debug level, like in MongoDB.
+func LogComponentVerbosity() *types.Document {
+	var verbosity int32
+	if logging.GetLevel() <= zapcore.DebugLevel {
+		verbosity = 1
+	}
+
+	return must.NotFail(types.NewDocument("verbosity", verbosity))
+}
+
+// setLogComponentVerbosity changes the logging level.
+//
+// Only the default verbosity is supported; per-component verbosities are ignored
+// because FerretDB does not have separate loggers for MongoDB's components.
+func setLogComponentVerbosity(value any, l *zap.Logger) error {
+	doc, ok := value.(*types.Document)
+	if !ok {
+		msg := fmt.Sprintf(
+			"BSON field 'logComponentVerbosity' is the wrong type '%s', expected type 'object'",
+			AliasFromType(value),
+		)
+		return NewErrorMsg(ErrTypeMismatch, msg)
+	}
+
+	for _, k := range doc.Keys() {
+		if k != "verbosity" {
+			l.Warn("Ignoring log component verbosity", zap.String("component", k))
+		}
+	}
+
+	v, err := doc.Get("verbosity")
+	if err != nil {
+		return nil
+	}
+
+	verbosity, err := GetWholeNumberParam(v)
+	if err != nil || verbosity < 0 || verbosity > 5 {
+		return NewErrorMsg(ErrBadValue, fmt.Sprintf("Invalid verbosity value: %v", v))
+	}
+
+	level := zapcore.InfoLevel
+	if verbosity > 0 {
+		level = zapcore.DebugLevel
+	}
+
+	logging.SetLevel(level)
+
+	return nil
+}
@@ -0,0 +1,145 @@
+// Copyright 2021 FerretDB Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package common
+
+import (
+	"context"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/FerretDB/FerretDB/internal/clientconn/conninfo"
+	"github.com/FerretDB/FerretDB/internal/types"
+	"github.com/FerretDB/FerretDB/internal/util/must"
+)
+
+// operation represents a long-running operation reported by the currentOp command.
+type operation struct {
+	client  string // empty if unknown
+	ns      string
+	command *types.Document
+	desc    string
+	start   time.Time
+	done    int64
+	total   int64
+}
+
+// operationRegistry stores long-running operations of all connections.
+type operationRegistry struct {
+	rw     sync.RWMutex
+	m      map[int32]*operation
+	lastID int32
+}
+
+// operations is a global operation registry.
+var operations = &operationRegistry{
+	m: map[int32]*operation{},
+}
+
+// Operation is a handle of the long-running operation registered by StartOperation.
+type Operation struct {
+	id int32
+}
+
+// StartOperation registers a new long-running operation of the client connection (taken from ctx)
+// with the given namespace, command document and description, so it is reported by the currentOp command.
+//
+// Operation.Finish should be called when the operation is done.
+func StartOperation(ctx context.Context, ns string, command *types.Document, desc string) *Operation {
+	var client string
+	if addr := conninfo.GetConnInfo(ctx).PeerAddr; addr != nil {
+		client = addr.String()
+	}
+
+	operations.rw.Lock()
+	defer operations.rw.Unlock()
+
+	operations.lastID++
+	id := operations.lastID
+
+	operations.m[id] = &operation{
+		client:  client,
+		ns:      ns,
+		command: command,
+		desc:    desc,
+		start:   time.Now(),
+	}
+
+	return &Operation{id: id}
+}
+
+// SetProgress updates the numbers of processed and total items of the operation.
+func (op *Operation) SetProgress(done, total int64) {
+	operations.rw.Lock()
+	defer operations.rw.Unlock()
+
+	if o := operations.m[op.id]; o != nil {
+		o.done = done
+		o.total = total
+	}
+}
+
+// Finish removes the operation from the registry.
+func (op *Operation) Finish() {
+	operations.rw.Lock()
+	defer operations.rw.Unlock()
+
+	delete(operations.m, op.id)
+}
+
+// inProgress returns documents describing all registered operations, ordered by their IDs.
+func (r *operationRegistry) inProgress(now time.Time) []*types.Document {
+	r.rw.RLock()
+	defer r.rw.RUnlock()
+
+	ids := make([]int32, 0, len(r.m))
+	for id := range r.m {
+		ids = append(ids, id)
+	}
+	sort.Slice(ids, func(i, j int) bool { return ids[i] < ids[j] })
+
+	res := make([]*types.Document, 0, len(ids))
+	for _, id := range ids {
+		o := r.m[id]
+		running := now.Sub(o.start)
+
+		doc := must.NotFail(types.NewDocument(
+			"type", "op",
+			"opid", id,
+			"active", true,
+			"secs_running", int64(running/time.Second),
+			"microsecs_running", running.Microseconds(),
+			"op", "command",
+			"ns", o.ns,
+			"command", o.command,
+			"desc", o.desc,
+		))
+
+		if o.client != "" {
+			must.NoError(doc.Set("client", o.client))
+		}
+
+		if o.total > 0 {
+			must.NoError(doc.Set("progress", must.NotFail(types.NewDocument(
+				"done", o.done,
+				"total", o.total,
+			))))
+		}
+
+		res = append(res, doc)
+	}
+
+	return res
+}
@@ -0,0 +1,73 @@
+// Copyright 2021 FerretDB Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package common
+
+import (
+	"context"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/FerretDB/FerretDB/internal/clientconn/conninfo"
+	"github.com/FerretDB/FerretDB/internal/types"
+	"github.com/FerretDB/FerretDB/internal/util/must"
+)
+
+func TestOperations(t *testing.T) {
+	t.Parallel()
+
+	command := must.NotFail(types.NewDocument("migrateCollection", "test", "$db", "db"))
+
+	connInfo := &conninfo.ConnInfo{
+		PeerAddr: &net.TCPAddr{IP: net.IPv4(192, 0, 2, 1), Port: 12345},
+	}
+	ctx := conninfo.WithConnInfo(context.Background(), connInfo)
+
+	op := StartOperation(ctx, "db.test", command, "migrateCollection")
+
+	find := func() *types.Document {
+		for _, doc := range operations.inProgress(time.Now()) {
+			if must.NotFail(doc.Get("opid")).(int32) == op.id {
+				return doc
+			}
+		}
+		return nil
+	}
+
+	doc := find()
+	require.NotNil(t, doc)
+	assert.Equal(t, "192.0.2.1:12345", must.NotFail(doc.Get("client")))
+	assert.Equal(t, "db.test", must.NotFail(doc.Get("ns")))
+	assert.Equal(t, command, must.NotFail(doc.Get("command")))
+	assert.Equal(t, "migrateCollection", must.NotFail(doc.Get("desc")))
+	assert.False(t, doc.Has("progress"))
+
+	op.SetProgress(10, 100)
+
+	doc = find()
+	require.NotNil(t, doc)
+	expected := must.NotFail(types.NewDocument("done", int64(10), "total", int64(100)))
+	assert.Equal(t, expected, must.NotFail(doc.Get("progress")))
+
+	op.Finish()
+	assert.Nil(t, find())
+
+	// no-op for finished operations
+	op.SetProgress(20, 100)
+	assert.Nil(t, find())
+}
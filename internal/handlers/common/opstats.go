@@ -0,0 +1,128 @@
+// Copyright 2021 FerretDB Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package common
+
+import (
+	"context"
+	"sync/atomic"
+
+	"github.com/FerretDB/FerretDB/internal/types"
+)
+
+// OpStats collects statistics of a single operation for the slow operations log.
+//
+// Handlers get it from the context with GetOpStats.
+// All methods are safe for concurrent use and could be called on nil receiver.
+type OpStats struct {
+	queries      int64 // accessed atomically
+	pushdowns    int64 // accessed atomically
+	docsExamined int64 // accessed atomically
+}
+
+// opStatsKey is a context key for OpStats.
+type opStatsKey struct{}
+
+// WithOpStats returns a new context with new OpStats.
+func WithOpStats(ctx context.Context) (context.Context, *OpStats) {
+	stats := new(OpStats)
+	return context.WithValue(ctx, opStatsKey{}, stats), stats
+}
+
+// GetOpStats returns OpStats from the context, or nil if there are none.
+func GetOpStats(ctx context.Context) *OpStats {
+	stats, _ := ctx.Value(opStatsKey{}).(*OpStats)
+	return stats
+}
+
+// AddQuery records a backend query with the given filter and its residual part
+// that was not handled by the backend.
+func (s *OpStats) AddQuery(filter, residual *types.Document) {
+	if s == nil {
+		return
+	}
+
+	atomic.AddInt64(&s.queries, 1)
+
+	if filterPushedDown(filter, residual) {
+		atomic.AddInt64(&s.pushdowns, 1)
+	}
+}
+
+// AddDocsExamined records the number of documents returned by the backend.
+func (s *OpStats) AddDocsExamined(n int64) {
+	if s == nil {
+		return
+	}
+
+	atomic.AddInt64(&s.docsExamined, n)
+}
+
+// DocsExamined returns the total number of documents returned by the backend.
+func (s *OpStats) DocsExamined() int64 {
+	if s == nil {
+		return 0
+	}
+
+	return atomic.LoadInt64(&s.docsExamined)
+}
+
+// PlanSummary returns a short description of how backend queries were executed, like in MongoDB:
+// "PUSHDOWN" if a part of the filter was handled by the backend, "COLLSCAN" if all documents were scanned,
+// or empty string if there were no queries.
+func (s *OpStats) PlanSummary() string {
+	if s == nil {
+		return ""
+	}
+
+	switch {
+	case atomic.LoadInt64(&s.queries) == 0:
+		return ""
+	case atomic.LoadInt64(&s.pushdowns) > 0:
+		return "PUSHDOWN"
+	default:
+		return "COLLSCAN"
+	}
+}
+
+// filterPushedDown returns true if some part of the filter is not present in the residual filter.
+func filterPushedDown(filter, residual *types.Document) bool {
+	if filter == nil || filter.Len() == 0 {
+		return false
+	}
+
+	if residual == nil || residual.Len() < filter.Len() {
+		return true
+	}
+
+	// some operators of the field could be pushed down
+	for _, k := range filter.Keys() {
+		f, _ := filter.Get(k)
+		r, err := residual.Get(k)
+		if err != nil {
+			return true
+		}
+
+		fd, ok := f.(*types.Document)
+		if !ok {
+			continue
+		}
+
+		if rd, ok := r.(*types.Document); ok && rd.Len() < fd.Len() {
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,104 @@
+// Copyright 2021 FerretDB Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package common
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/FerretDB/FerretDB/internal/types"
+	"github.com/FerretDB/FerretDB/internal/util/must"
+)
+
+func TestOpStats(t *testing.T) {
+	t.Parallel()
+
+	t.Run("Nil", func(t *testing.T) {
+		t.Parallel()
+
+		stats := GetOpStats(context.Background())
+		assert.Nil(t, stats)
+
+		stats.AddQuery(nil, nil)
+		stats.AddDocsExamined(1)
+		assert.Equal(t, "", stats.PlanSummary())
+		assert.Equal(t, int64(0), stats.DocsExamined())
+	})
+
+	t.Run("PlanSummary", func(t *testing.T) {
+		t.Parallel()
+
+		ctx, stats := WithOpStats(context.Background())
+		assert.Same(t, stats, GetOpStats(ctx))
+		assert.Equal(t, "", stats.PlanSummary())
+
+		filter := must.NotFail(types.NewDocument("a", "x", "b", int32(1)))
+		stats.AddQuery(filter, filter)
+		stats.AddDocsExamined(3)
+		assert.Equal(t, "COLLSCAN", stats.PlanSummary())
+
+		stats.AddQuery(filter, must.NotFail(types.NewDocument("b", int32(1))))
+		stats.AddDocsExamined(2)
+		assert.Equal(t, "PUSHDOWN", stats.PlanSummary())
+		assert.Equal(t, int64(5), stats.DocsExamined())
+	})
+}
+
+func TestFilterPushedDown(t *testing.T) {
+	t.Parallel()
+
+	for name, tc := range map[string]struct {
+		filter   *types.Document
+		residual *types.Document
+		expected bool
+	}{
+		"Nil": {
+			expected: false,
+		},
+		"Empty": {
+			filter:   must.NotFail(types.NewDocument()),
+			residual: must.NotFail(types.NewDocument()),
+			expected: false,
+		},
+		"Same": {
+			filter:   must.NotFail(types.NewDocument("a", int32(1))),
+			residual: must.NotFail(types.NewDocument("a", int32(1))),
+			expected: false,
+		},
+		"Field": {
+			filter:   must.NotFail(types.NewDocument("a", "x", "b", int32(1))),
+			residual: must.NotFail(types.NewDocument("b", int32(1))),
+			expected: true,
+		},
+		"Operator": {
+			filter: must.NotFail(types.NewDocument(
+				"a", must.NotFail(types.NewDocument("$eq", "x", "$gt", "a")),
+			)),
+			residual: must.NotFail(types.NewDocument(
+				"a", must.NotFail(types.NewDocument("$gt", "a")),
+			)),
+			expected: true,
+		},
+	} {
+		name, tc := name, tc
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			assert.Equal(t, tc.expected, filterPushedDown(tc.filter, tc.residual))
+		})
+	}
+}
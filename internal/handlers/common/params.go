@@ -115,6 +115,7 @@ var (
 	errNotBinaryMask         = fmt.Errorf("not a binary mask")
 	errUnexpectedLeftOpType  = fmt.Errorf("unexpected left operand type")
 	errUnexpectedRightOpType = fmt.Errorf("unexpected right operand type")
+	errLongExceeded          = fmt.Errorf("long exceeded")
 )
 
 // GetWholeNumberParam checks if the given value is int32, int64, or float64 containing a whole number,
@@ -221,7 +222,7 @@ func parseTypeCode(alias string) (typeCode, error) {
 }
 
 // addNumbers returns the result of v1 and v2 addition and error if addition failed.
-// The v1 and v2 parameters could be float64, int32, int64.
+// The v1 and v2 parameters could be float64, int32, int64, types.Decimal128.
 // The result would be the broader type possible, i.e. int32 + int64 produces int64.
 func addNumbers(v1, v2 any) (any, error) {
 	switch v1 := v1.(type) {
@@ -233,6 +234,8 @@ func addNumbers(v1, v2 any) (any, error) {
 			return v1 + float64(v2), nil
 		case int64:
 			return v1 + float64(v2), nil
+		case types.Decimal128:
+			return types.NewDecimal128FromFloat64(v1).Add(v2), nil
 		default:
 			return nil, errUnexpectedRightOpType
 		}
@@ -244,6 +247,8 @@ func addNumbers(v1, v2 any) (any, error) {
 			return v1 + v2, nil
 		case int64:
 			return v2 + int64(v1), nil
+		case types.Decimal128:
+			return types.NewDecimal128FromInt64(int64(v1)).Add(v2), nil
 		default:
 			return nil, errUnexpectedRightOpType
 		}
@@ -255,10 +260,108 @@ func addNumbers(v1, v2 any) (any, error) {
 			return v1 + int64(v2), nil
 		case int64:
 			return v1 + v2, nil
+		case types.Decimal128:
+			return types.NewDecimal128FromInt64(v1).Add(v2), nil
 		default:
 			return nil, errUnexpectedRightOpType
 		}
+	case types.Decimal128:
+		d2, err := decimal128FromNumber(v2)
+		if err != nil {
+			return nil, errUnexpectedRightOpType
+		}
+		return v1.Add(d2), nil
 	default:
 		return nil, errUnexpectedLeftOpType
 	}
 }
+
+// multiplyNumbers returns the result of v1 and v2 multiplication and error if multiplication failed.
+// The v1 and v2 parameters could be float64, int32, int64, types.Decimal128.
+// The result would be the broader type possible, i.e. int32 * int64 produces int64.
+// The int32 result is widened to int64 on overflow; errLongExceeded is returned on int64 overflow.
+func multiplyNumbers(v1, v2 any) (any, error) {
+	switch v1 := v1.(type) {
+	case float64:
+		switch v2 := v2.(type) {
+		case float64:
+			return v1 * v2, nil
+		case int32:
+			return v1 * float64(v2), nil
+		case int64:
+			return v1 * float64(v2), nil
+		case types.Decimal128:
+			return types.NewDecimal128FromFloat64(v1).Mul(v2), nil
+		default:
+			return nil, errUnexpectedRightOpType
+		}
+	case int32:
+		switch v2 := v2.(type) {
+		case float64:
+			return float64(v1) * v2, nil
+		case int32:
+			res := int64(v1) * int64(v2)
+			if res < math.MinInt32 || res > math.MaxInt32 {
+				return res, nil
+			}
+			return int32(res), nil
+		case int64:
+			return multiplyLongs(int64(v1), v2)
+		case types.Decimal128:
+			return types.NewDecimal128FromInt64(int64(v1)).Mul(v2), nil
+		default:
+			return nil, errUnexpectedRightOpType
+		}
+	case int64:
+		switch v2 := v2.(type) {
+		case float64:
+			return float64(v1) * v2, nil
+		case int32:
+			return multiplyLongs(v1, int64(v2))
+		case int64:
+			return multiplyLongs(v1, v2)
+		case types.Decimal128:
+			return types.NewDecimal128FromInt64(v1).Mul(v2), nil
+		default:
+			return nil, errUnexpectedRightOpType
+		}
+	case types.Decimal128:
+		d2, err := decimal128FromNumber(v2)
+		if err != nil {
+			return nil, errUnexpectedRightOpType
+		}
+		return v1.Mul(d2), nil
+	default:
+		return nil, errUnexpectedLeftOpType
+	}
+}
+
+// multiplyLongs returns the result of v1 and v2 multiplication or errLongExceeded on overflow.
+func multiplyLongs(v1, v2 int64) (any, error) {
+	if v1 == 0 || v2 == 0 {
+		return int64(0), nil
+	}
+
+	res := v1 * v2
+	if res/v2 != v1 || (v1 == -1 && v2 == math.MinInt64) || (v2 == -1 && v1 == math.MinInt64) {
+		return nil, errLongExceeded
+	}
+
+	return res, nil
+}
+
+// decimal128FromNumber converts float64, int32, int64, or types.Decimal128 value to types.Decimal128.
+func decimal128FromNumber(v any) (types.Decimal128, error) {
+	switch v := v.(type) {
+	case float64:
+		return types.NewDecimal128FromFloat64(v), nil
+	case int32:
+		return types.NewDecimal128FromInt64(int64(v)), nil
+	case int64:
+		return types.NewDecimal128FromInt64(v), nil
+	case types.Decimal128:
+		return v, nil
+	default:
+		return types.Decimal128{}, errUnexpectedType
+	}
+}
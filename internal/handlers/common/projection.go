@@ -18,6 +18,7 @@ import (
 	"fmt"
 	"math"
 	"strconv"
+	"strings"
 
 	"golang.org/x/exp/slices"
 
@@ -31,6 +32,11 @@ import (
 func isProjectionInclusion(projection *types.Document) (inclusion bool, err error) {
 	var exclusion bool
 	for _, k := range projection.Keys() {
+		if k == "" || slices.Contains(strings.Split(k, "."), "") {
+			err = NewErrorMsg(ErrEmptyFieldPath, "FieldPath field names may not be empty strings.")
+			return
+		}
+
 		if k == "_id" { // _id is a special case and can be both
 			continue
 		}
@@ -112,7 +118,7 @@ func ProjectDocuments(docs []*types.Document, projection *types.Document) error
 	}
 
 	for i := 0; i < len(docs); i++ {
-		err = projectDocument(inclusion, docs[i], projection)
+		err = projectDocument(inclusion, docs[i], projection, true)
 		if err != nil {
 			return err
 		}
@@ -121,13 +127,22 @@ func ProjectDocuments(docs []*types.Document, projection *types.Document) error
 	return nil
 }
 
-func projectDocument(inclusion bool, doc *types.Document, projection *types.Document) error {
+// projectDocument applies projection to the document in place.
+// The _id field is handled specially only for the top-level document.
+func projectDocument(inclusion bool, doc *types.Document, projection *types.Document, topLevel bool) error {
 	projectionMap := projection.Map()
+	nested := nestedProjections(projection)
 
 	for k1 := range doc.Map() {
 		projectionVal, ok := projectionMap[k1]
 		if !ok {
-			if k1 == "_id" { // if _id is not in projection map, do not do anything with it
+			if nestedProjection, ok := nested[k1]; ok {
+				if err := projectNestedValue(inclusion, doc, k1, nestedProjection); err != nil {
+					return err
+				}
+				continue
+			}
+			if topLevel && k1 == "_id" { // if _id is not in projection map, do not do anything with it
 				continue
 			}
 			if inclusion { // k1 from doc is absent in projection, remove from doc only if projection type inclusion
@@ -159,6 +174,68 @@ func projectDocument(inclusion bool, doc *types.Document, projection *types.Docu
 	return nil
 }
 
+// nestedProjections returns projections of dot notation paths grouped by the first path element.
+// For example, {"v.foo": 1, "v.bar.baz": 1} is returned as {"v": {"foo": 1, "bar.baz": 1}}.
+func nestedProjections(projection *types.Document) map[string]*types.Document {
+	var res map[string]*types.Document
+
+	for _, k := range projection.Keys() {
+		if !strings.ContainsRune(k, '.') {
+			continue
+		}
+
+		path := types.NewPathFromString(k)
+		prefix := path.Prefix()
+
+		if res == nil {
+			res = make(map[string]*types.Document)
+		}
+		if res[prefix] == nil {
+			res[prefix] = new(types.Document)
+		}
+
+		must.NoError(res[prefix].Set(path.TrimPrefix().String(), must.NotFail(projection.Get(k))))
+	}
+
+	return res
+}
+
+// projectNestedValue applies projection of dot notation paths to the given document field.
+//
+// The projection is applied to embedded documents, including documents in arrays.
+// For inclusion projections, other values can't contain included fields, so they are removed.
+func projectNestedValue(inclusion bool, doc *types.Document, k1 string, projection *types.Document) error {
+	switch v := must.NotFail(doc.Get(k1)).(type) {
+	case *types.Document:
+		return projectDocument(inclusion, v, projection, false)
+
+	case *types.Array:
+		res := types.MakeArray(v.Len())
+		for i := 0; i < v.Len(); i++ {
+			switch elem := must.NotFail(v.Get(i)).(type) {
+			case *types.Document:
+				if err := projectDocument(inclusion, elem, projection, false); err != nil {
+					return err
+				}
+				must.NoError(res.Append(elem))
+
+			default:
+				if !inclusion {
+					must.NoError(res.Append(elem))
+				}
+			}
+		}
+		must.NoError(doc.Set(k1, res))
+
+	default:
+		if inclusion {
+			doc.Remove(k1)
+		}
+	}
+
+	return nil
+}
+
 func applyComplexProjection(k1 string, doc, projectionVal *types.Document) (err error) {
 	for _, projectionType := range projectionVal.Keys() {
 		switch projectionType {
@@ -0,0 +1,82 @@
+// Copyright 2021 FerretDB Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package common
+
+import (
+	"fmt"
+
+	"golang.org/x/exp/slices"
+
+	"github.com/FerretDB/FerretDB/internal/types"
+)
+
+// ReadPreferenceMode represents read preference mode.
+//
+// See https://www.mongodb.com/docs/manual/core/read-preference/#read-preference-modes.
+type ReadPreferenceMode string
+
+// Read preference modes.
+const (
+	ReadPreferencePrimary            ReadPreferenceMode = "primary"
+	ReadPreferencePrimaryPreferred   ReadPreferenceMode = "primaryPreferred"
+	ReadPreferenceSecondary          ReadPreferenceMode = "secondary"
+	ReadPreferenceSecondaryPreferred ReadPreferenceMode = "secondaryPreferred"
+	ReadPreferenceNearest            ReadPreferenceMode = "nearest"
+)
+
+// allReadPreferenceModes includes all read preference modes.
+var allReadPreferenceModes = []ReadPreferenceMode{
+	ReadPreferencePrimary,
+	ReadPreferencePrimaryPreferred,
+	ReadPreferenceSecondary,
+	ReadPreferenceSecondaryPreferred,
+	ReadPreferenceNearest,
+}
+
+// SecondaryOK returns true if reads with that mode should be routed to secondaries (replicas) if there are any.
+func (mode ReadPreferenceMode) SecondaryOK() bool {
+	return mode == ReadPreferenceSecondary || mode == ReadPreferenceSecondaryPreferred
+}
+
+// GetReadPreferenceMode returns read preference mode from the $readPreference field of the command document.
+//
+// It returns ReadPreferencePrimary if the field is not set.
+func GetReadPreferenceMode(document *types.Document) (ReadPreferenceMode, error) {
+	v, err := document.Get("$readPreference")
+	if err != nil {
+		return ReadPreferencePrimary, nil
+	}
+
+	readPreference, ok := v.(*types.Document)
+	if !ok {
+		return "", NewErrorMsg(ErrFailedToParse, "$readPreference must be an object")
+	}
+
+	mode, err := GetRequiredParam[string](readPreference, "mode")
+	if err != nil {
+		return "", NewErrorMsg(ErrFailedToParse, "$readPreference mode must be a string")
+	}
+
+	if !slices.Contains(allReadPreferenceModes, ReadPreferenceMode(mode)) {
+		msg := fmt.Sprintf(
+			"Could not parse $readPreference mode '%s'. Only the modes 'primary', 'primaryPreferred', "+
+				"'secondary', 'secondaryPreferred', and 'nearest' are supported.",
+			mode,
+		)
+		return "", NewErrorMsg(ErrFailedToParse, msg)
+	}
+
+	return ReadPreferenceMode(mode), nil
+}
@@ -0,0 +1,102 @@
+// Copyright 2021 FerretDB Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package common
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/FerretDB/FerretDB/internal/types"
+	"github.com/FerretDB/FerretDB/internal/util/must"
+)
+
+func TestGetReadPreferenceMode(t *testing.T) {
+	t.Parallel()
+
+	for name, tc := range map[string]struct {
+		document    *types.Document
+		mode        ReadPreferenceMode
+		secondaryOK bool
+		err         bool
+	}{
+		"NotSet": {
+			document: must.NotFail(types.NewDocument("find", "test")),
+			mode:     ReadPreferencePrimary,
+		},
+		"Secondary": {
+			document: must.NotFail(types.NewDocument(
+				"find", "test",
+				"$readPreference", must.NotFail(types.NewDocument("mode", "secondary")),
+			)),
+			mode:        ReadPreferenceSecondary,
+			secondaryOK: true,
+		},
+		"SecondaryPreferred": {
+			document: must.NotFail(types.NewDocument(
+				"find", "test",
+				"$readPreference", must.NotFail(types.NewDocument(
+					"mode", "secondaryPreferred",
+					"maxStalenessSeconds", int32(90),
+				)),
+			)),
+			mode:        ReadPreferenceSecondaryPreferred,
+			secondaryOK: true,
+		},
+		"PrimaryPreferred": {
+			document: must.NotFail(types.NewDocument(
+				"find", "test",
+				"$readPreference", must.NotFail(types.NewDocument("mode", "primaryPreferred")),
+			)),
+			mode: ReadPreferencePrimaryPreferred,
+		},
+		"UnknownMode": {
+			document: must.NotFail(types.NewDocument(
+				"find", "test",
+				"$readPreference", must.NotFail(types.NewDocument("mode", "fastest")),
+			)),
+			err: true,
+		},
+		"NoMode": {
+			document: must.NotFail(types.NewDocument(
+				"find", "test",
+				"$readPreference", must.NotFail(types.NewDocument()),
+			)),
+			err: true,
+		},
+		"NotDocument": {
+			document: must.NotFail(types.NewDocument("find", "test", "$readPreference", "secondary")),
+			err:      true,
+		},
+	} {
+		name, tc := name, tc
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			mode, err := GetReadPreferenceMode(tc.document)
+			if tc.err {
+				var e *Error
+				require.ErrorAs(t, err, &e)
+				assert.Equal(t, ErrFailedToParse, e.Code())
+				return
+			}
+
+			require.NoError(t, err)
+			assert.Equal(t, tc.mode, mode)
+			assert.Equal(t, tc.secondaryOK, mode.SecondaryOK())
+		})
+	}
+}
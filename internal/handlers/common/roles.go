@@ -0,0 +1,136 @@
+// Copyright 2021 FerretDB Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package common
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/FerretDB/FerretDB/internal/clientconn/conninfo"
+	"github.com/FerretDB/FerretDB/internal/types"
+)
+
+// Roles that could be granted to users authenticated by external sources like LDAP
+// when the handler enforces authorization (see handlers.Capabilities.Authorization).
+//
+// Roles are granted on all databases.
+const (
+	// RoleRead allows commands that read data.
+	RoleRead = "read"
+
+	// RoleReadWrite allows commands that read and modify data.
+	RoleReadWrite = "readWrite"
+
+	// RoleRoot allows all commands, including administrative ones.
+	RoleRoot = "root"
+)
+
+// AllRoles includes all roles that could be granted.
+var AllRoles = []string{RoleRead, RoleReadWrite, RoleRoot}
+
+// publicCommands are commands that could be run without authentication;
+// drivers use them for the connection handshake and authentication.
+var publicCommands = map[string]struct{}{
+	"authenticate":     {},
+	"buildinfo":        {},
+	"buildInfo":        {},
+	"connectionStatus": {},
+	"hello":            {},
+	"ismaster":         {},
+	"isMaster":         {},
+	"ping":             {},
+	"saslContinue":     {},
+	"saslStart":        {},
+	"whatsmyuri":       {},
+}
+
+// adminCommands are commands that require RoleRoot.
+var adminCommands = map[string]struct{}{
+	"applyOps":                       {},
+	"createUser":                     {},
+	"currentOp":                      {},
+	"debugError":                     {},
+	"dropDatabase":                   {},
+	"dropUser":                       {},
+	"dumpArchive":                    {},
+	"fsync":                          {},
+	"fsyncUnlock":                    {},
+	"getCmdLineOpts":                 {},
+	"getFreeMonitoringStatus":        {},
+	"getLog":                         {},
+	"hostInfo":                       {},
+	"listDatabases":                  {},
+	"migrateCollection":              {},
+	"serverStatus":                   {},
+	"setFeatureCompatibilityVersion": {},
+	"setFreeMonitoring":              {},
+	"setParameter":                   {},
+	"usersInfo":                      {},
+}
+
+// CheckRoles returns Unauthorized error if the given command document can't be run by the client:
+// either the client did not authenticate yet, or the authenticated user was not granted the required role
+// (see conninfo.ConnInfo.Roles).
+//
+// Commands used for the handshake and authentication are always allowed.
+// Administrative commands require RoleRoot, commands that modify data (see Commands) require RoleReadWrite,
+// and all other commands require RoleRead.
+// Roles that imply the required one are accepted: RoleRoot implies RoleReadWrite that implies RoleRead.
+func CheckRoles(ctx context.Context, document *types.Document) error {
+	command := document.Command()
+	if _, ok := publicCommands[command]; ok {
+		return nil
+	}
+
+	connInfo := conninfo.GetConnInfo(ctx)
+
+	if username, _ := connInfo.Auth(); username == "" {
+		msg := fmt.Sprintf("command %s requires authentication", command)
+		return NewErrorMsg(ErrUnauthorized, msg)
+	}
+
+	required := RoleRead
+	if _, ok := adminCommands[command]; ok {
+		required = RoleRoot
+	} else if Commands[command].Write {
+		required = RoleReadWrite
+	}
+
+	for _, role := range connInfo.Roles() {
+		if roleImplies(role, required) {
+			return nil
+		}
+	}
+
+	db, _ := GetOptionalParam(document, "$db", "")
+	msg := fmt.Sprintf("not authorized on %s to execute command %s", db, command)
+
+	return NewErrorMsg(ErrUnauthorized, msg)
+}
+
+// roleImplies returns true if the granted role allows everything that the required role allows.
+// Unknown roles do not allow anything.
+func roleImplies(granted, required string) bool {
+	switch granted {
+	case RoleRoot:
+		return true
+	case RoleReadWrite:
+		return required == RoleReadWrite || required == RoleRead
+	case RoleRead:
+		return required == RoleRead
+	default:
+		return false
+	}
+}
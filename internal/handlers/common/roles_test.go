@@ -0,0 +1,101 @@
+// Copyright 2021 FerretDB Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package common
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/FerretDB/FerretDB/internal/clientconn/conninfo"
+	"github.com/FerretDB/FerretDB/internal/types"
+	"github.com/FerretDB/FerretDB/internal/util/must"
+)
+
+func TestCheckRoles(t *testing.T) {
+	t.Parallel()
+
+	hello := must.NotFail(types.NewDocument("hello", int32(1), "$db", "admin"))
+	find := must.NotFail(types.NewDocument("find", "values", "$db", "test"))
+	insert := must.NotFail(types.NewDocument("insert", "values", "documents", types.MakeArray(0), "$db", "test"))
+	dropDatabase := must.NotFail(types.NewDocument("dropDatabase", int32(1), "$db", "test"))
+
+	for name, tc := range map[string]struct {
+		authenticated bool
+		roles         []string
+		allowed       []*types.Document
+		unauthorized  []*types.Document
+	}{
+		"NotAuthenticated": {
+			allowed:      []*types.Document{hello},
+			unauthorized: []*types.Document{find, insert, dropDatabase},
+		},
+		"NoRoles": {
+			authenticated: true,
+			allowed:       []*types.Document{hello},
+			unauthorized:  []*types.Document{find, insert, dropDatabase},
+		},
+		"UnknownRole": {
+			authenticated: true,
+			roles:         []string{"dbOwner"},
+			allowed:       []*types.Document{hello},
+			unauthorized:  []*types.Document{find, insert, dropDatabase},
+		},
+		"Read": {
+			authenticated: true,
+			roles:         []string{RoleRead},
+			allowed:       []*types.Document{hello, find},
+			unauthorized:  []*types.Document{insert, dropDatabase},
+		},
+		"ReadWrite": {
+			authenticated: true,
+			roles:         []string{RoleRead, RoleReadWrite},
+			allowed:       []*types.Document{hello, find, insert},
+			unauthorized:  []*types.Document{dropDatabase},
+		},
+		"Root": {
+			authenticated: true,
+			roles:         []string{RoleRoot},
+			allowed:       []*types.Document{hello, find, insert, dropDatabase},
+		},
+	} {
+		name, tc := name, tc
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			connInfo := new(conninfo.ConnInfo)
+			if tc.authenticated {
+				connInfo.SetAuth("user", "$external")
+				connInfo.SetRoles(tc.roles)
+			}
+
+			ctx := conninfo.WithConnInfo(context.Background(), connInfo)
+
+			for _, doc := range tc.allowed {
+				assert.NoError(t, CheckRoles(ctx, doc), doc.Command())
+			}
+
+			for _, doc := range tc.unauthorized {
+				err := CheckRoles(ctx, doc)
+
+				var e *Error
+				require.ErrorAs(t, err, &e, doc.Command())
+				assert.Equal(t, ErrUnauthorized, e.Code(), doc.Command())
+			}
+		})
+	}
+}
@@ -0,0 +1,311 @@
+// Copyright 2021 FerretDB Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package common
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/xdg-go/scram"
+	"go.uber.org/zap"
+	"golang.org/x/exp/slices"
+
+	"github.com/FerretDB/FerretDB/internal/clientconn/conninfo"
+	"github.com/FerretDB/FerretDB/internal/types"
+	"github.com/FerretDB/FerretDB/internal/util/lazyerrors"
+	"github.com/FerretDB/FerretDB/internal/util/must"
+	"github.com/FerretDB/FerretDB/internal/wire"
+)
+
+// UserLookup returns a user document for the given authentication database and username,
+// or nil if there is no such user.
+type UserLookup func(ctx context.Context, db, username string) (*types.Document, error)
+
+// PLAINAuthenticator checks username and password received via SASL PLAIN mechanism.
+// It returns roles granted to the user by the authentication source (if any),
+// or non-nil error if credentials are not valid.
+type PLAINAuthenticator func(ctx context.Context, username, password string) ([]string, error)
+
+// saslConversation represents SCRAM conversation state stored in the connection info.
+type saslConversation struct {
+	*scram.ServerConversation
+	db                string
+	skipEmptyExchange bool
+}
+
+// check interfaces
+var (
+	_ conninfo.SASLConversation = (*saslConversation)(nil)
+)
+
+// errAuthenticationFailed returns a generic authentication error.
+// Details are logged, but not returned to the client.
+func errAuthenticationFailed(l *zap.Logger, err error) error {
+	l.Debug("Authentication failed", zap.Error(err))
+	return NewErrorMsg(ErrAuthenticationFailed, "Authentication failed.")
+}
+
+// SASLStart is a common implementation of the saslStart command.
+//
+// It starts a new SCRAM conversation for the current connection using lookup to find the user.
+func SASLStart(ctx context.Context, msg *wire.OpMsg, l *zap.Logger, lookup UserLookup) (*wire.OpMsg, error) {
+	document, err := msg.Document()
+	if err != nil {
+		return nil, lazyerrors.Error(err)
+	}
+
+	Ignored(document, l, "autoAuthorize")
+
+	db, err := GetRequiredParam[string](document, "$db")
+	if err != nil {
+		return nil, err
+	}
+
+	mechanism, err := GetRequiredParam[string](document, "mechanism")
+	if err != nil {
+		return nil, err
+	}
+
+	if !slices.Contains(SCRAMMechanisms, mechanism) {
+		msg := fmt.Sprintf("Received authentication for mechanism %s which is unknown or not enabled", mechanism)
+		return nil, NewErrorMsg(ErrMechanismUnavailable, msg)
+	}
+
+	payload, err := saslPayload(document)
+	if err != nil {
+		return nil, err
+	}
+
+	var skipEmptyExchange bool
+	if options, _ := GetOptionalParam(document, "options", (*types.Document)(nil)); options != nil {
+		if skipEmptyExchange, err = GetBoolOptionalParam(options, "skipEmptyExchange"); err != nil {
+			return nil, err
+		}
+	}
+
+	server, err := scramMechanisms[mechanism].hash.NewServer(func(username string) (scram.StoredCredentials, error) {
+		user, err := lookup(ctx, db, username)
+		if err != nil {
+			return scram.StoredCredentials{}, lazyerrors.Error(err)
+		}
+
+		if user == nil {
+			return scram.StoredCredentials{}, lazyerrors.Errorf("user %s@%s not found", username, db)
+		}
+
+		return storedCredentials(user, mechanism)
+	})
+	if err != nil {
+		return nil, lazyerrors.Error(err)
+	}
+
+	connInfo := conninfo.GetConnInfo(ctx)
+
+	conv := &saslConversation{
+		ServerConversation: server.NewConversation(),
+		db:                 db,
+		skipEmptyExchange:  skipEmptyExchange,
+	}
+
+	res, err := conv.Step(string(payload))
+	if err != nil {
+		connInfo.SetSASLConversation(nil)
+		return nil, errAuthenticationFailed(l, err)
+	}
+
+	connInfo.SetSASLConversation(conv)
+
+	return saslReply(false, res)
+}
+
+// SASLContinue is a common implementation of the saslContinue command.
+//
+// It continues the SCRAM conversation started by SASLStart and marks connection as authenticated on success.
+func SASLContinue(ctx context.Context, msg *wire.OpMsg, l *zap.Logger) (*wire.OpMsg, error) {
+	document, err := msg.Document()
+	if err != nil {
+		return nil, lazyerrors.Error(err)
+	}
+
+	Ignored(document, l, "conversationId")
+
+	payload, err := saslPayload(document)
+	if err != nil {
+		return nil, err
+	}
+
+	connInfo := conninfo.GetConnInfo(ctx)
+
+	conv, ok := connInfo.SASLConversation().(*saslConversation)
+	if !ok {
+		return nil, errAuthenticationFailed(l, lazyerrors.New("no SASL conversation"))
+	}
+
+	// the final empty exchange of the conversation without skipEmptyExchange option
+	if conv.Done() {
+		connInfo.SetSASLConversation(nil)
+
+		if !conv.Valid() {
+			return nil, errAuthenticationFailed(l, lazyerrors.New("invalid SASL conversation"))
+		}
+
+		return saslReply(true, "")
+	}
+
+	res, err := conv.Step(string(payload))
+	if err != nil {
+		connInfo.SetSASLConversation(nil)
+		return nil, errAuthenticationFailed(l, err)
+	}
+
+	if !conv.Done() {
+		return saslReply(false, res)
+	}
+
+	if !conv.Valid() {
+		connInfo.SetSASLConversation(nil)
+		return nil, errAuthenticationFailed(l, lazyerrors.New("invalid SASL conversation"))
+	}
+
+	connInfo.SetAuth(conv.Username(), conv.db)
+
+	if conv.skipEmptyExchange {
+		connInfo.SetSASLConversation(nil)
+	}
+
+	return saslReply(conv.skipEmptyExchange, res)
+}
+
+// SASLStartPLAIN is a common implementation of the saslStart command for the PLAIN mechanism.
+//
+// PLAIN is a single-step mechanism: credentials are checked by authenticate,
+// and the connection is marked as authenticated on success.
+// Other mechanisms are rejected.
+func SASLStartPLAIN(ctx context.Context, msg *wire.OpMsg, l *zap.Logger, authenticate PLAINAuthenticator) (*wire.OpMsg, error) {
+	document, err := msg.Document()
+	if err != nil {
+		return nil, lazyerrors.Error(err)
+	}
+
+	Ignored(document, l, "autoAuthorize", "options")
+
+	db, err := GetRequiredParam[string](document, "$db")
+	if err != nil {
+		return nil, err
+	}
+
+	mechanism, err := GetRequiredParam[string](document, "mechanism")
+	if err != nil {
+		return nil, err
+	}
+
+	if mechanism != MechanismPLAIN {
+		msg := fmt.Sprintf("Received authentication for mechanism %s which is unknown or not enabled", mechanism)
+		return nil, NewErrorMsg(ErrMechanismUnavailable, msg)
+	}
+
+	payload, err := saslPayload(document)
+	if err != nil {
+		return nil, err
+	}
+
+	// authzid NUL authcid NUL passwd, see RFC 4616
+	parts := bytes.Split(payload, []byte{0})
+	if len(parts) != 3 {
+		return nil, NewErrorMsg(ErrBadValue, "Incorrectly formatted PLAIN client message")
+	}
+
+	username, password := string(parts[1]), string(parts[2])
+
+	connInfo := conninfo.GetConnInfo(ctx)
+	connInfo.SetSASLConversation(nil)
+
+	roles, err := authenticate(ctx, username, password)
+	if err != nil {
+		return nil, errAuthenticationFailed(l, err)
+	}
+
+	connInfo.SetAuth(username, db)
+	connInfo.SetRoles(roles)
+
+	return saslReply(true, "")
+}
+
+// SASLStartUsername returns username from the saslStart command's payload for audit purposes.
+// Empty string is returned if username can't be determined.
+func SASLStartUsername(document *types.Document) string {
+	mechanism, _ := GetOptionalParam(document, "mechanism", "")
+
+	payload, err := saslPayload(document)
+	if err != nil {
+		return ""
+	}
+
+	switch {
+	case mechanism == MechanismPLAIN:
+		// authzid NUL authcid NUL passwd; password is never returned
+		if parts := bytes.Split(payload, []byte{0}); len(parts) == 3 {
+			return string(parts[1])
+		}
+
+	case slices.Contains(SCRAMMechanisms, mechanism):
+		// client-first-message: gs2-header "n=" saslname "," "r=" nonce, see RFC 5802
+		for _, attr := range strings.Split(string(payload), ",") {
+			if strings.HasPrefix(attr, "n=") {
+				return strings.NewReplacer("=2C", ",", "=3D", "=").Replace(strings.TrimPrefix(attr, "n="))
+			}
+		}
+	}
+
+	return ""
+}
+
+// saslPayload returns SASL payload from the saslStart or saslContinue command document.
+func saslPayload(document *types.Document) ([]byte, error) {
+	v, err := document.Get("payload")
+	if err != nil {
+		return nil, NewErrorMsg(ErrBadValue, `required parameter "payload" is missing`)
+	}
+
+	switch v := v.(type) {
+	case types.Binary:
+		return v.B, nil
+	case string:
+		return []byte(v), nil
+	default:
+		msg := fmt.Sprintf(`BSON field 'payload' is the wrong type '%s', expected type 'binData'`, AliasFromType(v))
+		return nil, NewErrorMsg(ErrTypeMismatch, msg)
+	}
+}
+
+// saslReply returns saslStart or saslContinue command reply.
+func saslReply(done bool, payload string) (*wire.OpMsg, error) {
+	var reply wire.OpMsg
+	err := reply.SetSections(wire.OpMsgSection{
+		Documents: []*types.Document{must.NotFail(types.NewDocument(
+			"conversationId", int32(1),
+			"done", done,
+			"payload", types.Binary{Subtype: types.BinaryGeneric, B: []byte(payload)},
+			"ok", float64(1),
+		))},
+	})
+	if err != nil {
+		return nil, lazyerrors.Error(err)
+	}
+
+	return &reply, nil
+}
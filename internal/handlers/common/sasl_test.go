@@ -0,0 +1,248 @@
+// Copyright 2021 FerretDB Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package common
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+
+	"github.com/FerretDB/FerretDB/internal/clientconn/conninfo"
+	"github.com/FerretDB/FerretDB/internal/types"
+	"github.com/FerretDB/FerretDB/internal/util/must"
+	"github.com/FerretDB/FerretDB/internal/wire"
+)
+
+// saslMsg returns OP_MSG with a given command document.
+func saslMsg(t *testing.T, pairs ...any) *wire.OpMsg {
+	t.Helper()
+
+	var msg wire.OpMsg
+	err := msg.SetSections(wire.OpMsgSection{
+		Documents: []*types.Document{must.NotFail(types.NewDocument(pairs...))},
+	})
+	require.NoError(t, err)
+
+	return &msg
+}
+
+// saslResponse returns done flag and payload of saslStart or saslContinue reply.
+func saslResponse(t *testing.T, reply *wire.OpMsg) (bool, string) {
+	t.Helper()
+
+	doc, err := reply.Document()
+	require.NoError(t, err)
+
+	return must.NotFail(doc.Get("done")).(bool), string(must.NotFail(doc.Get("payload")).(types.Binary).B)
+}
+
+func TestSASL(t *testing.T) {
+	t.Parallel()
+
+	for _, mechanism := range SCRAMMechanisms {
+		mechanism := mechanism
+
+		t.Run(mechanism, func(t *testing.T) {
+			t.Parallel()
+
+			credentials, err := MakeCredentials("user", "pencil", []string{mechanism})
+			require.NoError(t, err)
+			user := must.NotFail(types.NewDocument("user", "user", "db", "admin", "credentials", credentials))
+			assert.Equal(t, must.NotFail(types.NewArray(mechanism)), UserMechanisms(user))
+
+			lookup := func(ctx context.Context, db, username string) (*types.Document, error) {
+				if db == "admin" && username == "user" {
+					return user, nil
+				}
+				return nil, nil
+			}
+
+			for name, tc := range map[string]struct {
+				username string
+				password string
+				valid    bool
+			}{
+				"Valid":         {username: "user", password: "pencil", valid: true},
+				"WrongPassword": {username: "user", password: "pen"},
+				"WrongUser":     {username: "other", password: "pencil"},
+			} {
+				name, tc := name, tc
+
+				t.Run(name, func(t *testing.T) {
+					t.Parallel()
+
+					l := zap.NewNop()
+					connInfo := new(conninfo.ConnInfo)
+					ctx := conninfo.WithConnInfo(context.Background(), connInfo)
+
+					client, err := scramClient(mechanism, tc.username, tc.password)
+					require.NoError(t, err)
+					conv := client.NewConversation()
+
+					payload, err := conv.Step("")
+					require.NoError(t, err)
+
+					reply, err := SASLStart(ctx, saslMsg(t,
+						"saslStart", int32(1),
+						"mechanism", mechanism,
+						"payload", types.Binary{B: []byte(payload)},
+						"$db", "admin",
+					), l, lookup)
+					if tc.username != "user" {
+						require.Error(t, err)
+						return
+					}
+					require.NoError(t, err)
+
+					done, res := saslResponse(t, reply)
+					require.False(t, done)
+
+					payload, err = conv.Step(res)
+					require.NoError(t, err)
+
+					reply, err = SASLContinue(ctx, saslMsg(t,
+						"saslContinue", int32(1),
+						"conversationId", int32(1),
+						"payload", types.Binary{B: []byte(payload)},
+						"$db", "admin",
+					), l)
+					if !tc.valid {
+						var protoErr *Error
+						require.ErrorAs(t, err, &protoErr)
+						assert.Equal(t, ErrAuthenticationFailed, protoErr.Code())
+
+						username, _ := connInfo.Auth()
+						assert.Empty(t, username)
+						return
+					}
+					require.NoError(t, err)
+
+					done, res = saslResponse(t, reply)
+					assert.False(t, done)
+
+					_, err = conv.Step(res)
+					require.NoError(t, err)
+					assert.True(t, conv.Valid())
+
+					username, db := connInfo.Auth()
+					assert.Equal(t, "user", username)
+					assert.Equal(t, "admin", db)
+
+					// final empty exchange
+					reply, err = SASLContinue(ctx, saslMsg(t,
+						"saslContinue", int32(1),
+						"conversationId", int32(1),
+						"payload", types.Binary{},
+						"$db", "admin",
+					), l)
+					require.NoError(t, err)
+
+					done, _ = saslResponse(t, reply)
+					assert.True(t, done)
+					assert.Nil(t, connInfo.SASLConversation())
+				})
+			}
+		})
+	}
+}
+
+func TestSASLStartPLAIN(t *testing.T) {
+	t.Parallel()
+
+	authenticate := func(ctx context.Context, username, password string) ([]string, error) {
+		if username == "user" && password == "pencil" {
+			return []string{"readAnyDatabase"}, nil
+		}
+		return nil, errors.New("invalid credentials")
+	}
+
+	for name, tc := range map[string]struct {
+		mechanism string
+		payload   string
+		err       ErrorCode
+	}{
+		"Valid":         {mechanism: MechanismPLAIN, payload: "\x00user\x00pencil"},
+		"WrongPassword": {mechanism: MechanismPLAIN, payload: "\x00user\x00pen", err: ErrAuthenticationFailed},
+		"BadPayload":    {mechanism: MechanismPLAIN, payload: "user:pencil", err: ErrBadValue},
+		"SCRAM":         {mechanism: MechanismSCRAMSHA256, payload: "n,,n=user,r=nonce", err: ErrMechanismUnavailable},
+	} {
+		name, tc := name, tc
+
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			connInfo := new(conninfo.ConnInfo)
+			ctx := conninfo.WithConnInfo(context.Background(), connInfo)
+
+			reply, err := SASLStartPLAIN(ctx, saslMsg(t,
+				"saslStart", int32(1),
+				"mechanism", tc.mechanism,
+				"payload", types.Binary{B: []byte(tc.payload)},
+				"$db", "$external",
+			), zap.NewNop(), authenticate)
+
+			username, db := connInfo.Auth()
+
+			if tc.err != 0 {
+				var protoErr *Error
+				require.ErrorAs(t, err, &protoErr)
+				assert.Equal(t, tc.err, protoErr.Code())
+				assert.Empty(t, username)
+				return
+			}
+
+			require.NoError(t, err)
+			done, _ := saslResponse(t, reply)
+			assert.True(t, done)
+			assert.Equal(t, "user", username)
+			assert.Equal(t, "$external", db)
+			assert.Equal(t, []string{"readAnyDatabase"}, connInfo.Roles())
+		})
+	}
+}
+
+func TestSASLStartUsername(t *testing.T) {
+	t.Parallel()
+
+	for name, tc := range map[string]struct {
+		mechanism string
+		payload   any
+		expected  string
+	}{
+		"PLAIN":       {mechanism: MechanismPLAIN, payload: types.Binary{B: []byte("\x00user\x00pencil")}, expected: "user"},
+		"PLAINBad":    {mechanism: MechanismPLAIN, payload: types.Binary{B: []byte("user:pencil")}},
+		"SCRAM":       {mechanism: MechanismSCRAMSHA256, payload: "n,,n=us=2Cer=3D,r=nonce", expected: "us,er="},
+		"SCRAMAuthz":  {mechanism: MechanismSCRAMSHA1, payload: "n,a=other,n=user,r=nonce", expected: "user"},
+		"NoPayload":   {mechanism: MechanismSCRAMSHA1},
+		"UnknownMech": {mechanism: "GSSAPI", payload: "n,,n=user,r=nonce"},
+	} {
+		name, tc := name, tc
+
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			document := must.NotFail(types.NewDocument("saslStart", int32(1), "mechanism", tc.mechanism))
+			if tc.payload != nil {
+				must.NoError(document.Set("payload", tc.payload))
+			}
+
+			assert.Equal(t, tc.expected, SASLStartUsername(document))
+		})
+	}
+}
@@ -54,17 +54,11 @@ func SortDocuments(docs []*types.Document, sort *types.Document) error {
 // compares selected key of 2 documents.
 func lessFunc(sortKey string, sortType types.SortType) func(a, b *types.Document) bool {
 	return func(a, b *types.Document) bool {
-		aField, err := a.Get(sortKey)
-		if err != nil {
-			return false
-		}
-
-		bField, err := b.Get(sortKey)
-		if err != nil {
-			return false
-		}
+		// missing fields are nil, they are handled by CompareOrderForSort
+		aField, _ := a.Get(sortKey)
+		bField, _ := b.Get(sortKey)
 
-		result := types.CompareOrder(aField, bField, sortType)
+		result := types.CompareOrderForSort(aField, bField, sortType)
 
 		switch result {
 		case types.Less:
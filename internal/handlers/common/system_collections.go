@@ -0,0 +1,88 @@
+// Copyright 2021 FerretDB Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package common
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/FerretDB/FerretDB/internal/types"
+)
+
+// Users are stored in the same format as MongoDB's system.users collection,
+// with "<db>.<username>" _id values.
+//
+// That collection contains credentials, so clients can't access it directly (see CheckSystemCollections);
+// users are managed by createUser, dropUser, and usersInfo commands.
+const (
+	UsersDB         = "admin"
+	UsersCollection = "system.users"
+)
+
+// collectionCommands are commands which value is the name of the collection in the $db database.
+var collectionCommands = map[string]struct{}{
+	"collStats":         {},
+	"count":             {},
+	"create":            {},
+	"createIndexes":     {},
+	"delete":            {},
+	"drop":              {},
+	"dumpArchive":       {},
+	"find":              {},
+	"findAndModify":     {},
+	"insert":            {},
+	"listIndexes":       {},
+	"mapReduce":         {},
+	"migrateCollection": {},
+	"update":            {},
+}
+
+// CheckSystemCollections returns Unauthorized error if the given command document accesses
+// a collection that can't be accessed by clients directly, like users collection.
+//
+// It does not check collections accessed by operations of applyOps command; they are checked by the command itself.
+func CheckSystemCollections(document *types.Document) error {
+	command := document.Command()
+	value, _ := document.Get(command)
+
+	name, ok := value.(string)
+	if !ok {
+		return nil
+	}
+
+	db, _ := GetOptionalParam(document, "$db", "")
+	collection := name
+
+	if command == "dataSize" {
+		// value is the full namespace
+		if db, collection, ok = strings.Cut(name, "."); !ok {
+			return nil
+		}
+	} else if _, ok = collectionCommands[command]; !ok {
+		return nil
+	}
+
+	return checkSystemCollection(command, db, collection)
+}
+
+// checkSystemCollection returns Unauthorized error if the given collection can't be accessed by clients directly.
+func checkSystemCollection(command, db, collection string) error {
+	if db != UsersDB || collection != UsersCollection {
+		return nil
+	}
+
+	msg := fmt.Sprintf("not authorized on %s to execute command %s on collection %s", db, command, collection)
+	return NewErrorMsg(ErrUnauthorized, msg)
+}
@@ -0,0 +1,79 @@
+// Copyright 2021 FerretDB Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package common
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/FerretDB/FerretDB/internal/types"
+	"github.com/FerretDB/FerretDB/internal/util/must"
+)
+
+func TestCheckSystemCollections(t *testing.T) {
+	t.Parallel()
+
+	for name, tc := range map[string]struct {
+		document     *types.Document
+		unauthorized bool
+	}{
+		"Find": {
+			document:     must.NotFail(types.NewDocument("find", "system.users", "$db", "admin")),
+			unauthorized: true,
+		},
+		"Insert": {
+			document: must.NotFail(types.NewDocument(
+				"insert", "system.users", "documents", types.MakeArray(0), "$db", "admin",
+			)),
+			unauthorized: true,
+		},
+		"DataSize": {
+			document:     must.NotFail(types.NewDocument("dataSize", "admin.system.users", "$db", "test")),
+			unauthorized: true,
+		},
+		"DumpArchive": {
+			document: must.NotFail(types.NewDocument(
+				"dumpArchive", "system.users", "archive", "users.archive", "$db", "admin",
+			)),
+			unauthorized: true,
+		},
+		"OtherDatabase": {
+			document: must.NotFail(types.NewDocument("find", "system.users", "$db", "test")),
+		},
+		"OtherCollection": {
+			document: must.NotFail(types.NewDocument("find", "users", "$db", "admin")),
+		},
+		"NotCollection": {
+			document: must.NotFail(types.NewDocument("usersInfo", "system.users", "$db", "admin")),
+		},
+	} {
+		name, tc := name, tc
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			err := CheckSystemCollections(tc.document)
+			if !tc.unauthorized {
+				assert.NoError(t, err)
+				return
+			}
+
+			var e *Error
+			require.ErrorAs(t, err, &e)
+			assert.Equal(t, ErrUnauthorized, e.Code())
+		})
+	}
+}
@@ -28,7 +28,8 @@ import (
 // typeCode represents BSON type codes.
 // BSON type codes represent corresponding codes in BSON specification.
 // They could be used to query fields with particular type values using $type operator.
-// Type code `number` is added to support MongoDB surrogate alias `number` which matches double, int and long type values.
+// Type code `number` is added to support MongoDB surrogate alias `number`
+// which matches double, int, long and decimal type values.
 type typeCode int32
 
 const (
@@ -37,19 +38,24 @@ const (
 	typeCodeObject    = typeCode(3)  // object
 	typeCodeArray     = typeCode(4)  // array
 	typeCodeBinData   = typeCode(5)  // binData
+	typeCodeUndefined = typeCode(6)  // undefined
 	typeCodeObjectID  = typeCode(7)  // objectId
 	typeCodeBool      = typeCode(8)  // bool
 	typeCodeDate      = typeCode(9)  // date
 	typeCodeNull      = typeCode(10) // null
 	typeCodeRegex     = typeCode(11) // regex
+	typeCodeDBPointer = typeCode(12) // dbPointer
+	typeCodeJS        = typeCode(13) // javascript
+	typeCodeSymbol    = typeCode(14) // symbol
+	typeCodeJSScope   = typeCode(15) // javascriptWithScope
 	typeCodeInt       = typeCode(16) // int
 	typeCodeTimestamp = typeCode(17) // timestamp
 	typeCodeLong      = typeCode(18) // long
+	typeCodeDecimal   = typeCode(19) // decimal
 	// Not implemented.
-	typeCodeDecimal = typeCode(19)  // decimal
-	typeCodeMinKey  = typeCode(-1)  // minKey
-	typeCodeMaxKey  = typeCode(127) // maxKey
-	// Not actual type code. `number` matches double, int and long.
+	typeCodeMinKey = typeCode(-1)  // minKey
+	typeCodeMaxKey = typeCode(127) // maxKey
+	// Not actual type code. `number` matches double, int, long and decimal.
 	typeCodeNumber = typeCode(-128) // number
 )
 
@@ -58,10 +64,11 @@ func newTypeCode(code int32) (typeCode, error) {
 	c := typeCode(code)
 	switch c {
 	case typeCodeDouble, typeCodeString, typeCodeObject, typeCodeArray,
-		typeCodeBinData, typeCodeObjectID, typeCodeBool, typeCodeDate,
-		typeCodeNull, typeCodeRegex, typeCodeInt, typeCodeTimestamp, typeCodeLong, typeCodeNumber:
+		typeCodeBinData, typeCodeUndefined, typeCodeObjectID, typeCodeBool, typeCodeDate,
+		typeCodeNull, typeCodeRegex, typeCodeDBPointer, typeCodeJS, typeCodeSymbol, typeCodeJSScope,
+		typeCodeInt, typeCodeTimestamp, typeCodeLong, typeCodeDecimal, typeCodeNumber:
 		return c, nil
-	case typeCodeDecimal, typeCodeMinKey, typeCodeMaxKey:
+	case typeCodeMinKey, typeCodeMaxKey:
 		return 0, NewErrorMsg(ErrNotImplemented, fmt.Sprintf(`Type code %v not implemented`, code))
 	default:
 		return 0, NewErrorMsg(ErrBadValue, fmt.Sprintf(`Invalid numerical type code: %d`, code))
@@ -102,8 +109,9 @@ var aliasToTypeCode = map[string]typeCode{}
 func init() {
 	for _, i := range []typeCode{
 		typeCodeDouble, typeCodeString, typeCodeObject, typeCodeArray,
-		typeCodeBinData, typeCodeObjectID, typeCodeBool, typeCodeDate, typeCodeNull,
-		typeCodeRegex, typeCodeInt, typeCodeTimestamp, typeCodeLong, typeCodeNumber,
+		typeCodeBinData, typeCodeUndefined, typeCodeObjectID, typeCodeBool, typeCodeDate, typeCodeNull,
+		typeCodeRegex, typeCodeDBPointer, typeCodeJS, typeCodeSymbol, typeCodeJSScope,
+		typeCodeInt, typeCodeTimestamp, typeCodeLong, typeCodeDecimal, typeCodeNumber,
 	} {
 		aliasToTypeCode[i.String()] = i
 	}
@@ -122,6 +130,8 @@ func AliasFromType(v any) string {
 		return typeCodeString.String()
 	case types.Binary:
 		return typeCodeBinData.String()
+	case types.UndefinedType:
+		return typeCodeUndefined.String()
 	case types.ObjectID:
 		return typeCodeObjectID.String()
 	case bool:
@@ -132,12 +142,22 @@ func AliasFromType(v any) string {
 		return typeCodeNull.String()
 	case types.Regex:
 		return typeCodeRegex.String()
+	case types.DBPointer:
+		return typeCodeDBPointer.String()
+	case types.JavaScript:
+		return typeCodeJS.String()
+	case types.Symbol:
+		return typeCodeSymbol.String()
+	case types.JavaScriptWithScope:
+		return typeCodeJSScope.String()
 	case int32:
 		return typeCodeInt.String()
 	case types.Timestamp:
 		return typeCodeTimestamp.String()
 	case int64:
 		return typeCodeLong.String()
+	case types.Decimal128:
+		return typeCodeDecimal.String()
 	default:
 		panic(fmt.Sprintf("not supported type %T", v))
 	}
@@ -13,11 +13,16 @@ func _() {
 	_ = x[typeCodeObject-3]
 	_ = x[typeCodeArray-4]
 	_ = x[typeCodeBinData-5]
+	_ = x[typeCodeUndefined-6]
 	_ = x[typeCodeObjectID-7]
 	_ = x[typeCodeBool-8]
 	_ = x[typeCodeDate-9]
 	_ = x[typeCodeNull-10]
 	_ = x[typeCodeRegex-11]
+	_ = x[typeCodeDBPointer-12]
+	_ = x[typeCodeJS-13]
+	_ = x[typeCodeSymbol-14]
+	_ = x[typeCodeJSScope-15]
 	_ = x[typeCodeInt-16]
 	_ = x[typeCodeTimestamp-17]
 	_ = x[typeCodeLong-18]
@@ -30,16 +35,12 @@ func _() {
 const (
 	_typeCode_name_0 = "number"
 	_typeCode_name_1 = "minKey"
-	_typeCode_name_2 = "doublestringobjectarraybinData"
-	_typeCode_name_3 = "objectIdbooldatenullregex"
-	_typeCode_name_4 = "inttimestamplongdecimal"
-	_typeCode_name_5 = "maxKey"
+	_typeCode_name_2 = "doublestringobjectarraybinDataundefinedobjectIdbooldatenullregexdbPointerjavascriptsymboljavascriptWithScopeinttimestamplongdecimal"
+	_typeCode_name_3 = "maxKey"
 )
 
 var (
-	_typeCode_index_2 = [...]uint8{0, 6, 12, 18, 23, 30}
-	_typeCode_index_3 = [...]uint8{0, 8, 12, 16, 20, 25}
-	_typeCode_index_4 = [...]uint8{0, 3, 12, 16, 23}
+	_typeCode_index_2 = [...]uint8{0, 6, 12, 18, 23, 30, 39, 47, 51, 55, 59, 64, 73, 83, 89, 108, 111, 120, 124, 131}
 )
 
 func (i typeCode) String() string {
@@ -48,17 +49,11 @@ func (i typeCode) String() string {
 		return _typeCode_name_0
 	case i == -1:
 		return _typeCode_name_1
-	case 1 <= i && i <= 5:
+	case 1 <= i && i <= 19:
 		i -= 1
 		return _typeCode_name_2[_typeCode_index_2[i]:_typeCode_index_2[i+1]]
-	case 7 <= i && i <= 11:
-		i -= 7
-		return _typeCode_name_3[_typeCode_index_3[i]:_typeCode_index_3[i+1]]
-	case 16 <= i && i <= 19:
-		i -= 16
-		return _typeCode_name_4[_typeCode_index_4[i]:_typeCode_index_4[i+1]]
 	case i == 127:
-		return _typeCode_name_5
+		return _typeCode_name_3
 	default:
 		return "typeCode(" + strconv.FormatInt(int64(i), 10) + ")"
 	}
@@ -15,6 +15,7 @@
 package common
 
 import (
+	"errors"
 	"fmt"
 	"sort"
 	"strings"
@@ -54,7 +55,7 @@ func UpdateDocument(doc, update *types.Document) (bool, error) {
 			sort.Strings(setDoc.Keys())
 			for _, setKey := range setDoc.Keys() {
 				setValue := must.NotFail(setDoc.Get(setKey))
-				if err := doc.Set(setKey, setValue); err != nil {
+				if err := setByPath(doc, setKey, setValue); err != nil {
 					return false, err
 				}
 			}
@@ -66,7 +67,7 @@ func UpdateDocument(doc, update *types.Document) (bool, error) {
 				continue
 			}
 			for _, key := range unsetDoc.Keys() {
-				doc.Remove(key)
+				doc.RemoveByPath(types.NewPathFromString(key))
 			}
 			changed = true
 
@@ -76,18 +77,21 @@ func UpdateDocument(doc, update *types.Document) (bool, error) {
 
 			for _, incKey := range incDoc.Keys() {
 				incValue := must.NotFail(incDoc.Get(incKey))
+				incPath := types.NewPathFromString(incKey)
 
-				if !doc.Has(incKey) {
-					must.NoError(doc.Set(incKey, incValue))
+				if !doc.HasByPath(incPath) {
+					if err := setByPath(doc, incKey, incValue); err != nil {
+						return false, err
+					}
 					changed = true
 					continue
 				}
 
-				docValue := must.NotFail(doc.Get(incKey))
+				docValue := must.NotFail(doc.GetByPath(incPath))
 
 				incremented, err := addNumbers(incValue, docValue)
 				if err == nil {
-					must.NoError(doc.Set(incKey, incremented))
+					must.NoError(doc.SetByPath(incPath, incremented))
 					changed = true
 					continue
 				}
@@ -118,6 +122,78 @@ func UpdateDocument(doc, update *types.Document) (bool, error) {
 				}
 			}
 
+		case "$min":
+			var minChanged bool
+			if minChanged, err = processMinMaxFieldExpression(doc, updateV, types.Less); err != nil {
+				return false, err
+			}
+			changed = changed || minChanged
+
+		case "$max":
+			var maxChanged bool
+			if maxChanged, err = processMinMaxFieldExpression(doc, updateV, types.Greater); err != nil {
+				return false, err
+			}
+			changed = changed || maxChanged
+
+		case "$mul":
+			// expecting here a document since all checks were made in ValidateUpdateOperators func
+			mulDoc := updateV.(*types.Document)
+
+			for _, mulKey := range mulDoc.Keys() {
+				mulValue := must.NotFail(mulDoc.Get(mulKey))
+				mulPath := types.NewPathFromString(mulKey)
+
+				// missing field is set to zero of the multiplier's type
+				var docValue any = int32(0)
+				if doc.HasByPath(mulPath) {
+					docValue = must.NotFail(doc.GetByPath(mulPath))
+				}
+
+				multiplied, err := multiplyNumbers(mulValue, docValue)
+				if err == nil {
+					if err = setByPath(doc, mulKey, multiplied); err != nil {
+						return false, err
+					}
+					changed = true
+					continue
+				}
+
+				switch err {
+				case errUnexpectedLeftOpType:
+					return false, NewWriteErrorMsg(
+						ErrTypeMismatch,
+						fmt.Sprintf(
+							`Cannot multiply with non-numeric argument: {%s: %#v}`,
+							mulKey,
+							mulValue,
+						),
+					)
+				case errUnexpectedRightOpType:
+					return false, NewWriteErrorMsg(
+						ErrTypeMismatch,
+						fmt.Sprintf(
+							`Cannot apply $mul to a value of non-numeric type. `+
+								`{_id: "%s"} has the field '%s' of non-numeric type %s`,
+							must.NotFail(doc.Get("_id")),
+							mulKey,
+							AliasFromType(docValue),
+						),
+					)
+				case errLongExceeded:
+					return false, NewWriteErrorMsg(
+						ErrBadValue,
+						fmt.Sprintf(
+							`Failed to apply $mul operations to current value (%v) for document {_id: "%s"}`,
+							docValue,
+							must.NotFail(doc.Get("_id")),
+						),
+					)
+				default:
+					return false, err
+				}
+			}
+
 		default:
 			return false, NewError(ErrNotImplemented, fmt.Errorf("UpdateDocument: unhandled operation %q", updateOp))
 		}
@@ -141,7 +217,7 @@ func processCurrentDateFieldExpression(doc *types.Document, currentDateVal any)
 
 		switch currentDateField := currentDateField.(type) {
 		case bool:
-			if err = doc.Set(field, now); err != nil {
+			if err = setByPath(doc, field, now); err != nil {
 				return false, err
 			}
 			changed = true
@@ -149,7 +225,7 @@ func processCurrentDateFieldExpression(doc *types.Document, currentDateVal any)
 		case *types.Document:
 			currentDateType, err := currentDateField.Get("$type")
 			if err != nil { // default is date
-				if err := doc.Set(field, now); err != nil {
+				if err := setByPath(doc, field, now); err != nil {
 					return false, err
 				}
 				changed = true
@@ -159,13 +235,13 @@ func processCurrentDateFieldExpression(doc *types.Document, currentDateVal any)
 			currentDateType = currentDateType.(string)
 			switch currentDateType {
 			case "timestamp":
-				if err := doc.Set(field, types.NextTimestamp(now)); err != nil {
+				if err := setByPath(doc, field, types.NextTimestamp(now)); err != nil {
 					return false, err
 				}
 				changed = true
 
 			case "date":
-				if err := doc.Set(field, now); err != nil {
+				if err := setByPath(doc, field, now); err != nil {
 					return false, err
 				}
 				changed = true
@@ -175,6 +251,45 @@ func processCurrentDateFieldExpression(doc *types.Document, currentDateVal any)
 	return changed, nil
 }
 
+// processMinMaxFieldExpression changes document according to $min or $max operator.
+// The field is updated if it is missing, or if the new value compares to the current one as expected.
+// If the document was changed it returns true.
+func processMinMaxFieldExpression(doc *types.Document, updateV any, expected types.CompareResult) (bool, error) {
+	// expecting here a document since all checks were made in ValidateUpdateOperators func
+	minMaxDoc := updateV.(*types.Document)
+
+	var changed bool
+	for _, key := range minMaxDoc.Keys() {
+		value := must.NotFail(minMaxDoc.Get(key))
+		path := types.NewPathFromString(key)
+
+		if docValue, err := doc.GetByPath(path); err == nil {
+			if types.CompareOrderByValue(value, docValue) != expected {
+				continue
+			}
+		}
+
+		if err := setByPath(doc, key, value); err != nil {
+			return false, err
+		}
+		changed = true
+	}
+
+	return changed, nil
+}
+
+// setByPath sets the value by the dot notation path, creating missing intermediate documents.
+// It returns WriteError if the path can't be created.
+func setByPath(doc *types.Document, key string, value any) error {
+	err := doc.SetByPath(types.NewPathFromString(key), value)
+	if errors.Is(err, types.ErrPathNotViable) {
+		// TODO include the element that prevents the path creation, as MongoDB does
+		return NewWriteErrorMsg(ErrPathNotViable, fmt.Sprintf("Cannot create field in path '%s'", key))
+	}
+
+	return err
+}
+
 // ValidateUpdateOperators validates update statement.
 func ValidateUpdateOperators(update *types.Document) error {
 	var err error
@@ -185,6 +300,18 @@ func ValidateUpdateOperators(update *types.Document) error {
 	if err != nil {
 		return err
 	}
+	mul, err := extractValueFromUpdateOperator("$mul", update)
+	if err != nil {
+		return err
+	}
+	minDoc, err := extractValueFromUpdateOperator("$min", update)
+	if err != nil {
+		return err
+	}
+	maxDoc, err := extractValueFromUpdateOperator("$max", update)
+	if err != nil {
+		return err
+	}
 	set, err := extractValueFromUpdateOperator("$set", update)
 	if err != nil {
 		return err
@@ -200,6 +327,23 @@ func ValidateUpdateOperators(update *types.Document) error {
 	if err = checkConflictingChanges(set, inc); err != nil {
 		return err
 	}
+	if err = checkConflictingChanges(set, mul); err != nil {
+		return err
+	}
+	if err = checkConflictingChanges(inc, mul); err != nil {
+		return err
+	}
+	for _, doc := range []*types.Document{set, inc, mul} {
+		if err = checkConflictingChanges(doc, minDoc); err != nil {
+			return err
+		}
+		if err = checkConflictingChanges(doc, maxDoc); err != nil {
+			return err
+		}
+	}
+	if err = checkConflictingChanges(minDoc, maxDoc); err != nil {
+		return err
+	}
 	if err = validateCurrentDateExpression(update); err != nil {
 		return err
 	}
@@ -214,6 +358,12 @@ func checkAllModifiersSupported(update *types.Document) error {
 			fallthrough
 		case "$inc":
 			fallthrough
+		case "$max":
+			fallthrough
+		case "$min":
+			fallthrough
+		case "$mul":
+			fallthrough
 		case "$set":
 			fallthrough
 		case "$setOnInsert":
@@ -233,7 +383,8 @@ func checkAllModifiersSupported(update *types.Document) error {
 	return nil
 }
 
-// checkConflictingChanges checks if there are the same keys in these documents and returns an error, if any.
+// checkConflictingChanges checks if there are the same or overlapping paths in these documents
+// and returns an error, if any.
 func checkConflictingChanges(a, b *types.Document) error {
 	if a == nil {
 		return nil
@@ -242,12 +393,22 @@ func checkConflictingChanges(a, b *types.Document) error {
 		return nil
 	}
 
-	for _, key := range a.Keys() {
-		if b.Has(key) {
+	for _, keyA := range a.Keys() {
+		for _, keyB := range b.Keys() {
+			path, conflict := keyA, keyB
+			if len(path) < len(conflict) {
+				path, conflict = conflict, path
+			}
+
+			// "v.foo" conflicts with "v", but not with "v.fo" or "v.foo2"
+			if path != conflict && !strings.HasPrefix(path, conflict+".") {
+				continue
+			}
+
 			return NewWriteErrorMsg(
 				ErrConflictingUpdateOperators,
 				fmt.Sprintf(
-					"Updating the path '%[1]s' would create a conflict at '%[1]s'", key,
+					"Updating the path '%s' would create a conflict at '%s'", path, conflict,
 				),
 			)
 		}
@@ -255,6 +416,24 @@ func checkConflictingChanges(a, b *types.Document) error {
 	return nil
 }
 
+// validateUpdatePath checks that the dot notation path is not empty and does not contain empty field names.
+func validateUpdatePath(key string) error {
+	if key == "" {
+		return NewWriteErrorMsg(ErrEmptyFieldName, "An empty update path is not valid.")
+	}
+
+	for _, e := range strings.Split(key, ".") {
+		if e == "" {
+			return NewWriteErrorMsg(
+				ErrEmptyFieldName,
+				fmt.Sprintf("The update path '%s' contains an empty field name, which is not allowed.", key),
+			)
+		}
+	}
+
+	return nil
+}
+
 // extractValueFromUpdateOperator gets operator "op" value and returns WriteError error if it is not a document.
 // For example, for update document
 //
@@ -274,9 +453,8 @@ func extractValueFromUpdateOperator(op string, update *types.Document) (*types.D
 	switch doc := updateExpression.(type) {
 	case *types.Document:
 		for _, v := range doc.Keys() {
-			if strings.Contains(v, ".") {
-				// TODO https://github.com/FerretDB/FerretDB/issues/803
-				return nil, NewError(ErrNotImplemented, fmt.Errorf("dot notation for operator %s is not supported yet", op))
+			if err := validateUpdatePath(v); err != nil {
+				return nil, err
 			}
 		}
 
@@ -302,6 +480,10 @@ func validateCurrentDateExpression(update *types.Document) error {
 	}
 
 	for _, field := range currentDateExpression.Keys() {
+		if err := validateUpdatePath(field); err != nil {
+			return err
+		}
+
 		setValue := must.NotFail(currentDateExpression.Get(field))
 
 		switch setValue := setValue.(type) {
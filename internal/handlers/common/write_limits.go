@@ -0,0 +1,64 @@
+// Copyright 2021 FerretDB Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package common
+
+import (
+	"fmt"
+
+	"github.com/FerretDB/FerretDB/internal/types"
+	"github.com/FerretDB/FerretDB/internal/util/lazyerrors"
+	"github.com/FerretDB/FerretDB/internal/wire"
+)
+
+// CheckWriteBatchSize returns an error if the number of write operations
+// in a single insert, update, or delete command exceeds maxWriteBatchSize.
+func CheckWriteBatchSize(n int) error {
+	if max := wire.GetLimits().MaxWriteBatchSize; n > int(max) {
+		msg := fmt.Sprintf("Write batch sizes must be between 1 and %d. Got %d operations.", max, n)
+		return NewErrorMsg(ErrInvalidLength, msg)
+	}
+
+	return nil
+}
+
+// CheckInsertSize returns an error if the document to insert exceeds maxBsonObjectSize.
+func CheckInsertSize(doc *types.Document) error {
+	size, err := wire.DocumentSize(doc)
+	if err != nil {
+		return lazyerrors.Error(err)
+	}
+
+	if max := wire.GetLimits().MaxBSONObjectSize; size > int(max) {
+		msg := fmt.Sprintf("object to insert too large. size in bytes: %d, max size: %d", size, max)
+		return NewErrorMsg(ErrBadValue, msg)
+	}
+
+	return nil
+}
+
+// CheckUpdateSize returns an error if the document after update exceeds maxBsonObjectSize.
+func CheckUpdateSize(doc *types.Document) error {
+	size, err := wire.DocumentSize(doc)
+	if err != nil {
+		return lazyerrors.Error(err)
+	}
+
+	if max := wire.GetLimits().MaxBSONObjectSize; size > int(max) {
+		msg := fmt.Sprintf("Resulting document after update is larger than %d", max)
+		return NewErrorMsg(ErrBSONObjectTooLarge, msg)
+	}
+
+	return nil
+}
@@ -0,0 +1,56 @@
+// Copyright 2021 FerretDB Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package common
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/FerretDB/FerretDB/internal/types"
+	"github.com/FerretDB/FerretDB/internal/util/must"
+	"github.com/FerretDB/FerretDB/internal/wire"
+)
+
+func TestWriteLimits(t *testing.T) {
+	t.Parallel()
+
+	limits := wire.GetLimits()
+
+	assert.NoError(t, CheckWriteBatchSize(int(limits.MaxWriteBatchSize)))
+
+	var e *Error
+	require.ErrorAs(t, CheckWriteBatchSize(int(limits.MaxWriteBatchSize)+1), &e)
+	assert.Equal(t, ErrInvalidLength, e.Code())
+
+	small := must.NotFail(types.NewDocument("_id", int32(1)))
+	assert.NoError(t, CheckInsertSize(small))
+	assert.NoError(t, CheckUpdateSize(small))
+
+	// 13 bytes are taken by the document length, field type and name, string length, and terminating zeroes,
+	// so that document is one byte larger than the limit
+	large := must.NotFail(types.NewDocument("s", strings.Repeat("x", int(limits.MaxBSONObjectSize)-12)))
+	size, err := wire.DocumentSize(large)
+	require.NoError(t, err)
+	require.Equal(t, int(limits.MaxBSONObjectSize)+1, size)
+
+	require.ErrorAs(t, CheckInsertSize(large), &e)
+	assert.Equal(t, ErrBadValue, e.Code())
+
+	require.ErrorAs(t, CheckUpdateSize(large), &e)
+	assert.Equal(t, ErrBSONObjectTooLarge, e.Code())
+}
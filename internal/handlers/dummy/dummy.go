@@ -18,6 +18,8 @@
 package dummy
 
 import (
+	"context"
+
 	"github.com/FerretDB/FerretDB/internal/handlers"
 	"github.com/FerretDB/FerretDB/internal/handlers/common"
 )
@@ -45,6 +47,34 @@ func New() (handlers.Interface, error) {
 // Close implements handlers.Interface.
 func (h *Handler) Close() {}
 
+// Capabilities implements handlers.Interface.
+//
+// No optional features are supported.
+func (h *Handler) Capabilities() *handlers.Capabilities {
+	return &handlers.Capabilities{
+		Handler: "dummy",
+		Unsupported: map[handlers.Feature]string{
+			handlers.FeatureAuthentication:    "",
+			handlers.FeatureUserManagement:    "",
+			handlers.FeatureCount:             "",
+			handlers.FeatureFindAndModify:     "",
+			handlers.FeatureIndexes:           "",
+			handlers.FeatureCollStats:         "",
+			handlers.FeatureDataSize:          "",
+			handlers.FeatureDBStats:           "",
+			handlers.FeatureMigrateCollection: "",
+			handlers.FeatureDumpArchive:       "",
+			handlers.FeatureFCV:               "",
+			handlers.FeaturePartitioning:      "",
+		},
+	}
+}
+
+// Check implements handlers.Interface.
+func (h *Handler) Check(ctx context.Context) error {
+	return nil
+}
+
 // check interfaces
 var (
 	_ handlers.Interface = (*Handler)(nil)
@@ -0,0 +1,234 @@
+// Copyright 2021 FerretDB Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package generic
+
+import (
+	"context"
+	"errors"
+	"io"
+
+	"go.uber.org/zap"
+
+	"github.com/FerretDB/FerretDB/internal/handlers/backend"
+	"github.com/FerretDB/FerretDB/internal/handlers/common"
+	"github.com/FerretDB/FerretDB/internal/types"
+	"github.com/FerretDB/FerretDB/internal/util/lazyerrors"
+)
+
+// sqlParam represents options/parameters used for sql query.
+type sqlParam struct {
+	db         string
+	collection string
+	comment    string
+	filter     *types.Document // nil matches all documents
+	sort       *types.Document // nil means natural order
+	projection *types.Document // nil means all fields
+
+	// read preference mode of read-only queries; used only by queryIterator
+	readPreference common.ReadPreferenceMode
+}
+
+// fetch fetches documents from the given database and collection.
+// If collection doesn't exist it returns an empty result and no error.
+//
+// Parts of the filter, sort, and projection are handled by the backend (see backend.QueryDocuments).
+// Residual filter should be applied to fetched documents with common.FilterDocument,
+// they should be sorted with common.SortDocuments if they are not sorted yet,
+// and projected with common.ProjectDocuments.
+//
+// TODO https://github.com/FerretDB/FerretDB/issues/372
+func (h *Handler) fetch(ctx context.Context, param sqlParam) (*backend.QueryResult, error) {
+	b, err := h.dbBackend(ctx, param.db)
+	if err != nil {
+		return nil, err
+	}
+
+	// Special case: check if collection exists at all
+	collectionExists, err := b.CollectionExists(ctx, param.db, param.collection)
+	if err != nil {
+		return nil, queryError(ctx, err)
+	}
+	if !collectionExists {
+		h.l.Info(
+			"Collection doesn't exist, handling a case to deal with a non-existing collection.",
+			zap.String("db", param.db), zap.String("collection", param.collection),
+		)
+		return &backend.QueryResult{
+			Docs:     []*types.Document{},
+			Residual: param.filter,
+		}, nil
+	}
+
+	res, err := backend.QueryDocuments(ctx, b, &backend.QueryParams{
+		DB:         param.db,
+		Collection: param.collection,
+		Comment:    param.comment,
+		Filter:     param.filter,
+		Sort:       param.sort,
+		Projection: param.projection,
+	})
+	if err != nil {
+		return nil, queryError(ctx, err)
+	}
+
+	stats := common.GetOpStats(ctx)
+	stats.AddQuery(param.filter, res.Residual)
+	stats.AddDocsExamined(int64(len(res.Docs)))
+
+	return res, nil
+}
+
+// fetchMatching fetches documents from the given database and collection
+// and returns those that match the whole filter, including its residual part.
+// Returned documents are not sorted.
+func (h *Handler) fetchMatching(ctx context.Context, param sqlParam) ([]*types.Document, error) {
+	fetched, err := h.fetch(ctx, param)
+	if err != nil {
+		return nil, err
+	}
+
+	res := make([]*types.Document, 0, len(fetched.Docs))
+	for _, doc := range fetched.Docs {
+		matches, err := common.FilterDocument(doc, fetched.Residual)
+		if err != nil {
+			return nil, err
+		}
+
+		if matches {
+			res = append(res, doc)
+		}
+	}
+
+	return res, nil
+}
+
+// iterate calls f for each document from the given database and collection
+// that matches the filter, until f returns false or an error.
+// If collection doesn't exist, f is not called and no error is returned.
+//
+// Unlike fetch, documents are streamed from the backend (see queryIterator)
+// and the residual filter is applied by iterate itself.
+// It returns true if documents passed to f were sorted by the backend;
+// that value is meaningful only if f never returned false.
+// Documents should be projected with common.ProjectDocuments by the caller.
+func (h *Handler) iterate(ctx context.Context, param sqlParam, f func(doc *types.Document) (bool, error)) (bool, error) {
+	iter, err := h.queryIterator(ctx, ctx, param)
+	if err != nil {
+		return false, err
+	}
+
+	if iter == nil {
+		return true, nil
+	}
+
+	defer h.closeIterator(iter)
+
+	stats := common.GetOpStats(ctx)
+
+	for {
+		doc, err := iter.Next()
+		if err == io.EOF {
+			return iter.Sorted(), nil
+		}
+		if err != nil {
+			return false, queryError(ctx, err)
+		}
+
+		stats.AddDocsExamined(1)
+
+		matches, err := common.FilterDocument(doc, iter.Residual())
+		if err != nil {
+			return false, err
+		}
+
+		if !matches {
+			continue
+		}
+
+		next, err := f(doc)
+		if err != nil {
+			return false, err
+		}
+
+		if !next {
+			return iter.Sorted(), nil
+		}
+	}
+}
+
+// queryIterator returns the backend iterator over documents of the given database and collection
+// (see backend.Backend.QueryIterator), or nil if collection doesn't exist.
+//
+// The backend is selected and the collection is checked with the request's context ctx,
+// while the iterator uses iterCtx that could outlive the request (see newFindIterator).
+// Queries could be routed to a different backend depending on the read preference (see readBackend).
+// The caller should apply the residual filter to returned documents and close the iterator with closeIterator.
+func (h *Handler) queryIterator(ctx, iterCtx context.Context, param sqlParam) (backend.Iterator, error) {
+	b, err := h.readBackend(ctx, param.db, param.readPreference)
+	if err != nil {
+		return nil, err
+	}
+
+	// Special case: check if collection exists at all
+	collectionExists, err := b.CollectionExists(ctx, param.db, param.collection)
+	if err != nil {
+		return nil, queryError(ctx, err)
+	}
+	if !collectionExists {
+		h.l.Info(
+			"Collection doesn't exist, handling a case to deal with a non-existing collection.",
+			zap.String("db", param.db), zap.String("collection", param.collection),
+		)
+		return nil, nil
+	}
+
+	iter, err := b.QueryIterator(iterCtx, &backend.QueryParams{
+		DB:         param.db,
+		Collection: param.collection,
+		Comment:    param.comment,
+		Filter:     param.filter,
+		Sort:       param.sort,
+		Projection: param.projection,
+	})
+	if err != nil {
+		return nil, queryError(iterCtx, err)
+	}
+
+	common.GetOpStats(ctx).AddQuery(param.filter, iter.Residual())
+
+	return iter, nil
+}
+
+// closeIterator closes the backend iterator, logging the error if any.
+func (h *Handler) closeIterator(iter backend.Iterator) {
+	if err := iter.Close(); err != nil {
+		h.l.Error("Failed to close iterator.", zap.Error(err))
+	}
+}
+
+// queryError converts errors caused by exceeded maxTimeMS (see common.WithMaxTimeMS)
+// to MaxTimeMSExpired protocol errors, and wraps other errors with lazyerrors.
+func queryError(ctx context.Context, err error) error {
+	if ctx.Err() == context.DeadlineExceeded {
+		return common.NewErrorMsg(common.ErrMaxTimeMSExpired, "operation exceeded time limit")
+	}
+
+	// statement_timeout could be exceeded slightly before the context deadline
+	if _, ok := ctx.Deadline(); ok && errors.Is(err, backend.ErrQueryCanceled) {
+		return common.NewErrorMsg(common.ErrMaxTimeMSExpired, "operation exceeded time limit")
+	}
+
+	return lazyerrors.Error(err)
+}
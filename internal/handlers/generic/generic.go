@@ -0,0 +1,229 @@
+// Copyright 2021 FerretDB Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package generic provides a handler that works with any storage implementing backend.Backend.
+//
+// It is used as is by `sqlite` and `mysql` handlers (see packages sqlitedb and mysqldb for their storage formats),
+// and embedded by `pg` handler that overrides commands using PostgreSQL-specific features.
+package generic
+
+import (
+	"context"
+	"strings"
+	"time"
+
+	"go.uber.org/zap"
+
+	"github.com/FerretDB/FerretDB/internal/handlers"
+	"github.com/FerretDB/FerretDB/internal/handlers/backend"
+	"github.com/FerretDB/FerretDB/internal/handlers/common"
+	"github.com/FerretDB/FerretDB/internal/util/lazyerrors"
+)
+
+// notImplemented returns error for stub command handlers.
+func notImplemented(command string) error {
+	return common.NewErrorMsg(common.ErrNotImplemented, "I'm a stub, not a real handler for "+command)
+}
+
+// Storage is backend.Backend that also provides information about the storage as a whole.
+//
+// All FerretDB databases are stored in a single Storage.
+type Storage interface {
+	backend.Backend
+
+	// Name returns the human-readable name of the storage, like "SQLite".
+	Name() string
+
+	// Version returns the version of the storage.
+	Version(ctx context.Context) (string, error)
+
+	// Ping checks that the storage is accessible.
+	Ping(ctx context.Context) error
+
+	// Databases returns a sorted list of database names.
+	Databases(ctx context.Context) ([]string, error)
+
+	// Size returns the size of all databases in bytes.
+	Size(ctx context.Context) (int64, error)
+
+	// Close closes the storage.
+	Close()
+}
+
+// Handler implements handlers.Interface on top of Storage.
+//
+// Handlers with additional features, like `pg`, embed it and override commands they implement differently.
+type Handler struct {
+	storage   Storage
+	l         *zap.Logger
+	startTime time.Time
+
+	dbBackendFunc   func(ctx context.Context, db string) (backend.Backend, error)
+	readBackendFunc func(ctx context.Context, db string, mode common.ReadPreferenceMode) (backend.Backend, error)
+
+	// buffered channel used as a semaphore for backend iterators of find cursors; nil if there is no limit
+	iterSlots chan struct{}
+}
+
+// NewOpts represents handler configuration.
+type NewOpts struct {
+	Storage Storage
+	L       *zap.Logger
+
+	// If set, it returns the backend for queries to the given database instead of Storage.
+	// It could also return protocol errors, for example, if the client did not authenticate yet.
+	DBBackend func(ctx context.Context, db string) (backend.Backend, error)
+
+	// If set, it returns the backend for read-only queries to the given database
+	// with the given read preference mode instead of DBBackend, for example, a read replica.
+	ReadBackend func(ctx context.Context, db string, mode common.ReadPreferenceMode) (backend.Backend, error)
+
+	// If positive, at most that many backend iterators are kept open between find and getMore commands.
+	// They hold backend resources like PostgreSQL connections, so that should be less than the connection pool size;
+	// find commands beyond that limit fetch all documents at once (see MsgFind).
+	// See also MaxOpenIterators function.
+	MaxOpenIterators int
+}
+
+// MaxOpenIterators returns the limit of open backend iterators (see NewOpts.MaxOpenIterators)
+// for the backend with the given maximum number of connections.
+//
+// Each open iterator holds a connection with an open transaction;
+// at least half of them are kept for other queries.
+// If maxConns is not positive (connections are not limited), iterators are not limited either.
+func MaxOpenIterators(maxConns int) int {
+	if maxConns <= 0 {
+		return 0
+	}
+
+	if res := maxConns / 2; res > 0 {
+		return res
+	}
+
+	return 1
+}
+
+// New returns a new handler.
+func New(opts *NewOpts) (*Handler, error) {
+	h := &Handler{
+		storage:   opts.Storage,
+		l:         opts.L,
+		startTime: time.Now(),
+
+		dbBackendFunc:   opts.DBBackend,
+		readBackendFunc: opts.ReadBackend,
+	}
+
+	if opts.MaxOpenIterators > 0 {
+		h.iterSlots = make(chan struct{}, opts.MaxOpenIterators)
+	}
+
+	return h, nil
+}
+
+// Close implements HandlerInterface.
+func (h *Handler) Close() {
+	h.storage.Close()
+}
+
+// Check implements handlers.Interface.
+func (h *Handler) Check(ctx context.Context) error {
+	if err := h.storage.Ping(ctx); err != nil {
+		return lazyerrors.Error(err)
+	}
+
+	return nil
+}
+
+// Capabilities implements handlers.Interface.
+//
+// Handler names are lowercase storage names, like `sqlite`.
+func (h *Handler) Capabilities() *handlers.Capabilities {
+	return &handlers.Capabilities{
+		Handler: strings.ToLower(h.storage.Name()),
+		Unsupported: map[handlers.Feature]string{
+			handlers.FeatureAuthentication:    "",
+			handlers.FeatureUserManagement:    "",
+			handlers.FeatureCollStats:         "https://github.com/FerretDB/FerretDB/issues/770",
+			handlers.FeatureDataSize:          "",
+			handlers.FeatureDBStats:           "https://github.com/FerretDB/FerretDB/issues/774",
+			handlers.FeatureMigrateCollection: "",
+			handlers.FeaturePartitioning:      "",
+		},
+	}
+}
+
+// dbBackend returns the storage backend for queries to the given database; see NewOpts.DBBackend.
+//
+// By default, all databases are stored in the same Storage.
+//
+// Inside inTransaction's function, it returns the backend of that transaction for its database.
+func (h *Handler) dbBackend(ctx context.Context, db string) (backend.Backend, error) {
+	if tx, ok := ctx.Value(txKey{}).(*txValue); ok && tx.db == db {
+		return tx.b, nil
+	}
+
+	if h.dbBackendFunc != nil {
+		return h.dbBackendFunc(ctx, db)
+	}
+
+	return h.storage, nil
+}
+
+// readBackend returns the storage backend for read-only queries to the given database
+// with the given read preference mode; see NewOpts.ReadBackend.
+//
+// By default, it is the same as dbBackend. Inside inTransaction's function, read preference is ignored
+// and the backend of that transaction is returned, so reads see the transaction's writes.
+func (h *Handler) readBackend(ctx context.Context, db string, mode common.ReadPreferenceMode) (backend.Backend, error) {
+	if tx, ok := ctx.Value(txKey{}).(*txValue); ok && tx.db == db {
+		return tx.b, nil
+	}
+
+	if h.readBackendFunc != nil {
+		return h.readBackendFunc(ctx, db, mode)
+	}
+
+	return h.dbBackend(ctx, db)
+}
+
+// txKey is the context key for txValue.
+type txKey struct{}
+
+// txValue represents the backend transaction of the given database stored in the context by inTransaction.
+type txValue struct {
+	db string
+	b  backend.Backend
+}
+
+// inTransaction calls f in a transaction of the given database's backend (see backend.Backend.InTransaction).
+//
+// The context passed to f makes dbBackend and readBackend return the backend of that transaction,
+// so all queries of f to that database are made in it.
+// f could be called more than once if the transaction conflicts with concurrent ones.
+func (h *Handler) inTransaction(ctx context.Context, db string, f func(ctx context.Context) error) error {
+	b, err := h.dbBackend(ctx, db)
+	if err != nil {
+		return err
+	}
+
+	return b.InTransaction(ctx, func(tx backend.Backend) error {
+		return f(context.WithValue(ctx, txKey{}, &txValue{db: db, b: tx}))
+	})
+}
+
+// check interfaces
+var (
+	_ handlers.Interface = (*Handler)(nil)
+)
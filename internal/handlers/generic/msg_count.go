@@ -12,7 +12,7 @@
 // See the License for the specific language governing permissions and
 // limitations under the License.
 
-package pg
+package generic
 
 import (
 	"context"
@@ -46,6 +46,14 @@ func (h *Handler) MsgCount(ctx context.Context, msg *wire.OpMsg) (*wire.OpMsg, e
 	}
 	common.Ignored(document, h.l, ignoredFields...)
 
+	maxTimeMS, err := common.GetMaxTimeMSParam(document)
+	if err != nil {
+		return nil, err
+	}
+
+	ctx, cancel := common.WithMaxTimeMS(ctx, maxTimeMS)
+	defer cancel()
+
 	var filter *types.Document
 	if filter, err = common.GetOptionalParam(document, "query", filter); err != nil {
 		return nil, err
@@ -74,33 +82,30 @@ func (h *Handler) MsgCount(ctx context.Context, msg *wire.OpMsg) (*wire.OpMsg, e
 		)
 	}
 
-	fetchedDocs, err := h.fetch(ctx, sp)
-	if err != nil {
+	// check limit before iterating
+	if _, err = common.LimitDocuments(nil, limit); err != nil {
 		return nil, err
 	}
 
-	resDocs := make([]*types.Document, 0, 16)
-	for _, doc := range fetchedDocs {
-		matches, err := common.FilterDocument(doc, filter)
-		if err != nil {
-			return nil, err
-		}
-
-		if !matches {
-			continue
-		}
-
-		resDocs = append(resDocs, doc)
+	if sp.readPreference, err = common.GetReadPreferenceMode(document); err != nil {
+		return nil, err
 	}
 
-	if resDocs, err = common.LimitDocuments(resDocs, limit); err != nil {
+	sp.filter = filter
+
+	var n int32
+	_, err = h.iterate(ctx, sp, func(*types.Document) (bool, error) {
+		n++
+		return limit == 0 || int64(n) < limit, nil
+	})
+	if err != nil {
 		return nil, err
 	}
 
 	var reply wire.OpMsg
 	err = reply.SetSections(wire.OpMsgSection{
 		Documents: []*types.Document{must.NotFail(types.NewDocument(
-			"n", int32(len(resDocs)),
+			"n", n,
 			"ok", float64(1),
 		))},
 	})
@@ -0,0 +1,155 @@
+// Copyright 2021 FerretDB Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package generic
+
+import (
+	"context"
+	"fmt"
+
+	"go.uber.org/zap"
+
+	"github.com/FerretDB/FerretDB/internal/handlers/backend"
+	"github.com/FerretDB/FerretDB/internal/handlers/common"
+	"github.com/FerretDB/FerretDB/internal/types"
+	"github.com/FerretDB/FerretDB/internal/util/lazyerrors"
+	"github.com/FerretDB/FerretDB/internal/util/must"
+	"github.com/FerretDB/FerretDB/internal/wire"
+)
+
+// MsgCreateIndexes implements HandlerInterface.
+func (h *Handler) MsgCreateIndexes(ctx context.Context, msg *wire.OpMsg) (*wire.OpMsg, error) {
+	document, err := msg.Document()
+	if err != nil {
+		return nil, lazyerrors.Error(err)
+	}
+
+	common.Ignored(document, h.l, "writeConcern", "commitQuorum", "comment")
+
+	command := document.Command()
+
+	var db, collection string
+	if db, err = common.GetRequiredParam[string](document, "$db"); err != nil {
+		return nil, err
+	}
+	if collection, err = common.GetRequiredParam[string](document, command); err != nil {
+		return nil, err
+	}
+
+	b, err := h.dbBackend(ctx, db)
+	if err != nil {
+		return nil, err
+	}
+
+	var indexes *types.Array
+	if indexes, err = common.GetRequiredParam[*types.Array](document, "indexes"); err != nil {
+		return nil, err
+	}
+
+	created, err := b.CreateCollectionIfNotExist(ctx, db, collection)
+	if err != nil {
+		return nil, lazyerrors.Error(err)
+	}
+
+	before, err := h.countIndexes(ctx, b, db, collection)
+	if err != nil {
+		return nil, err
+	}
+
+	allExist := true
+	for i := 0; i < indexes.Len(); i++ {
+		index, ok := must.NotFail(indexes.Get(i)).(*types.Document)
+		if !ok {
+			return nil, common.NewErrorMsg(common.ErrTypeMismatch, "each index specification must be an object")
+		}
+
+		var key *types.Document
+		if key, err = common.GetRequiredParam[*types.Document](index, "key"); err != nil {
+			return nil, err
+		}
+
+		var name string
+		if name, err = common.GetRequiredParam[string](index, "name"); err != nil {
+			return nil, err
+		}
+
+		if name == "" {
+			return nil, common.NewErrorMsg(common.ErrBadValue, "index name cannot be empty")
+		}
+
+		err = b.CreateIndex(ctx, db, collection, name, key)
+		switch err {
+		case nil:
+			allExist = false
+		case backend.ErrAlreadyExist:
+			// nothing
+		case backend.ErrIndexNotSupported:
+			// TODO https://github.com/FerretDB/FerretDB/issues/78
+			allExist = false
+			h.l.Debug("Index is not supported, ignoring.", zap.String("name", name), zap.Strings("key", key.Keys()))
+		case backend.ErrIndexKeyConflict:
+			msg := fmt.Sprintf("An existing index has the same name as the requested index. Requested index name: %s", name)
+			return nil, common.NewErrorMsg(common.ErrIndexKeySpecsConflict, msg)
+		case backend.ErrIndexNameConflict:
+			msg := fmt.Sprintf("Index already exists with a different name. Requested index name: %s", name)
+			return nil, common.NewErrorMsg(common.ErrIndexOptionsConflict, msg)
+		default:
+			return nil, lazyerrors.Error(err)
+		}
+	}
+
+	after, err := h.countIndexes(ctx, b, db, collection)
+	if err != nil {
+		return nil, err
+	}
+
+	res := must.NotFail(types.NewDocument(
+		"numIndexesBefore", before,
+		"numIndexesAfter", after,
+		"createdCollectionAutomatically", created,
+	))
+
+	if allExist {
+		must.NoError(res.Set("note", "all indexes already exist"))
+	}
+
+	must.NoError(res.Set("ok", float64(1)))
+
+	var reply wire.OpMsg
+	err = reply.SetSections(wire.OpMsgSection{
+		Documents: []*types.Document{res},
+	})
+	if err != nil {
+		return nil, lazyerrors.Error(err)
+	}
+
+	return &reply, nil
+}
+
+// countIndexes returns the number of indexes of the given collection, excluding internal ones.
+func (h *Handler) countIndexes(ctx context.Context, b backend.Backend, db, collection string) (int32, error) {
+	indexes, err := b.Indexes(ctx, db, collection)
+	if err != nil {
+		return 0, lazyerrors.Error(err)
+	}
+
+	var res int32
+	for _, index := range indexes {
+		if !index.Internal {
+			res++
+		}
+	}
+
+	return res, nil
+}
@@ -0,0 +1,220 @@
+// Copyright 2021 FerretDB Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package generic
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/FerretDB/FerretDB/internal/handlers/backend"
+	"github.com/FerretDB/FerretDB/internal/handlers/common"
+	"github.com/FerretDB/FerretDB/internal/types"
+	"github.com/FerretDB/FerretDB/internal/util/lazyerrors"
+	"github.com/FerretDB/FerretDB/internal/util/must"
+	"github.com/FerretDB/FerretDB/internal/wire"
+)
+
+// deleteStatement represents a single statement of the delete command.
+type deleteStatement struct {
+	filter *types.Document
+	limit  int64
+}
+
+// MsgDelete implements HandlerInterface.
+//
+// All statements are parsed before any of them is executed.
+// Errors of individual statements are returned as write errors with statement indexes;
+// ordered deletes stop on the first such error, unordered deletes continue with the next statement.
+func (h *Handler) MsgDelete(ctx context.Context, msg *wire.OpMsg) (*wire.OpMsg, error) {
+	document, err := msg.Document()
+	if err != nil {
+		return nil, lazyerrors.Error(err)
+	}
+
+	if err := common.Unimplemented(document, "let"); err != nil {
+		return nil, err
+	}
+	common.Ignored(document, h.l, "writeConcern")
+
+	var deletes *types.Array
+	if deletes, err = common.GetOptionalParam(document, "deletes", deletes); err != nil {
+		return nil, err
+	}
+
+	ordered := true
+	if ordered, err = common.GetOptionalParam(document, "ordered", ordered); err != nil {
+		return nil, err
+	}
+
+	if err = common.CheckWriteBatchSize(deletes.Len()); err != nil {
+		return nil, err
+	}
+
+	var sp sqlParam
+	if sp.db, err = common.GetRequiredParam[string](document, "$db"); err != nil {
+		return nil, err
+	}
+	collectionParam, err := document.Get(document.Command())
+	if err != nil {
+		return nil, err
+	}
+	var ok bool
+	if sp.collection, ok = collectionParam.(string); !ok {
+		return nil, common.NewErrorMsg(
+			common.ErrBadValue,
+			fmt.Sprintf("collection name has invalid type %s", common.AliasFromType(collectionParam)),
+		)
+	}
+
+	statements := make([]*deleteStatement, deletes.Len())
+	for i := 0; i < deletes.Len(); i++ {
+		d, err := common.AssertType[*types.Document](must.NotFail(deletes.Get(i)))
+		if err != nil {
+			return nil, err
+		}
+
+		if statements[i], err = parseDeleteStatement(d); err != nil {
+			return nil, err
+		}
+	}
+
+	var deleted int32
+	var writeErrors common.WriteErrors
+	for i, s := range statements {
+		n, err := h.execDelete(ctx, sp, s)
+		deleted += n
+
+		if err == nil {
+			continue
+		}
+
+		if !common.IsWriteError(ctx, err) {
+			return nil, err
+		}
+
+		writeErrors.Append(err, int32(i))
+
+		if ordered {
+			break
+		}
+	}
+
+	res := must.NotFail(types.NewDocument(
+		"n", deleted,
+	))
+
+	if len(writeErrors) > 0 {
+		must.NoError(res.Set("writeErrors", must.NotFail(writeErrors.Document().Get("writeErrors"))))
+	}
+
+	must.NoError(res.Set("ok", float64(1)))
+
+	var reply wire.OpMsg
+	err = reply.SetSections(wire.OpMsgSection{
+		Documents: []*types.Document{res},
+	})
+	if err != nil {
+		return nil, lazyerrors.Error(err)
+	}
+
+	return &reply, nil
+}
+
+// parseDeleteStatement parses a single statement of the delete command.
+func parseDeleteStatement(d *types.Document) (*deleteStatement, error) {
+	if err := common.Unimplemented(d, "collation", "hint", "comment"); err != nil {
+		return nil, err
+	}
+
+	var s deleteStatement
+	var err error
+	if s.filter, err = common.GetOptionalParam(d, "q", s.filter); err != nil {
+		return nil, err
+	}
+
+	if l, _ := d.Get("limit"); l != nil {
+		if s.limit, err = common.GetWholeNumberParam(l); err != nil {
+			return nil, err
+		}
+	}
+
+	return &s, nil
+}
+
+// execDelete executes a single delete statement and returns the number of deleted documents.
+//
+// All matching documents are deleted with a single backend call.
+func (h *Handler) execDelete(ctx context.Context, sp sqlParam, s *deleteStatement) (int32, error) {
+	sp.filter = s.filter
+	fetched, err := h.fetch(ctx, sp)
+	if err != nil {
+		return 0, err
+	}
+
+	resDocs := make([]*types.Document, 0, 16)
+	for _, doc := range fetched.Docs {
+		matches, err := common.FilterDocument(doc, fetched.Residual)
+		if err != nil {
+			return 0, err
+		}
+
+		if !matches {
+			continue
+		}
+
+		resDocs = append(resDocs, doc)
+	}
+
+	if resDocs, err = common.LimitDocuments(resDocs, s.limit); err != nil {
+		return 0, err
+	}
+
+	if len(resDocs) == 0 {
+		return 0, nil
+	}
+
+	rowsDeleted, err := h.delete(ctx, sp, resDocs)
+	if err != nil {
+		return 0, err
+	}
+
+	return int32(rowsDeleted), nil
+}
+
+// delete deletes documents by _id.
+func (h *Handler) delete(ctx context.Context, sp sqlParam, docs []*types.Document) (int64, error) {
+	b, err := h.dbBackend(ctx, sp.db)
+	if err != nil {
+		return 0, err
+	}
+
+	ids := make([]any, len(docs))
+	for i, doc := range docs {
+		id := must.NotFail(doc.Get("_id"))
+		ids[i] = id
+	}
+
+	rowsDeleted, err := b.DeleteDocumentsByID(ctx, sp.db, sp.collection, ids)
+	switch {
+	case err == nil:
+		return rowsDeleted, nil
+	case errors.Is(err, backend.ErrCollectionNotExist):
+		// collection was dropped concurrently; nothing was deleted, as with other backends
+		return 0, nil
+	default:
+		return 0, lazyerrors.Error(err)
+	}
+}
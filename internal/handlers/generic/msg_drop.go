@@ -12,13 +12,13 @@
 // See the License for the specific language governing permissions and
 // limitations under the License.
 
-package pg
+package generic
 
 import (
 	"context"
 
+	"github.com/FerretDB/FerretDB/internal/handlers/backend"
 	"github.com/FerretDB/FerretDB/internal/handlers/common"
-	"github.com/FerretDB/FerretDB/internal/handlers/pg/pgdb"
 	"github.com/FerretDB/FerretDB/internal/types"
 	"github.com/FerretDB/FerretDB/internal/util/lazyerrors"
 	"github.com/FerretDB/FerretDB/internal/util/must"
@@ -44,9 +44,14 @@ func (h *Handler) MsgDrop(ctx context.Context, msg *wire.OpMsg) (*wire.OpMsg, er
 		return nil, err
 	}
 
-	err = h.pgPool.DropCollection(ctx, db, collection)
-	if err != nil && err != pgdb.ErrSchemaNotExist {
-		if err == pgdb.ErrTableNotExist {
+	b, err := h.dbBackend(ctx, db)
+	if err != nil {
+		return nil, err
+	}
+
+	err = b.DropCollection(ctx, db, collection)
+	if err != nil && err != backend.ErrDatabaseNotExist {
+		if err == backend.ErrCollectionNotExist {
 			return nil, common.NewErrorMsg(common.ErrNamespaceNotFound, "ns not found")
 		}
 		return nil, lazyerrors.Error(err)
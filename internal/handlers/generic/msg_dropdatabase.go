@@ -12,13 +12,13 @@
 // See the License for the specific language governing permissions and
 // limitations under the License.
 
-package pg
+package generic
 
 import (
 	"context"
 
+	"github.com/FerretDB/FerretDB/internal/handlers/backend"
 	"github.com/FerretDB/FerretDB/internal/handlers/common"
-	"github.com/FerretDB/FerretDB/internal/handlers/pg/pgdb"
 	"github.com/FerretDB/FerretDB/internal/types"
 	"github.com/FerretDB/FerretDB/internal/util/lazyerrors"
 	"github.com/FerretDB/FerretDB/internal/util/must"
@@ -39,12 +39,17 @@ func (h *Handler) MsgDropDatabase(ctx context.Context, msg *wire.OpMsg) (*wire.O
 		return nil, err
 	}
 
+	b, err := h.dbBackend(ctx, db)
+	if err != nil {
+		return nil, err
+	}
+
 	res := must.NotFail(types.NewDocument())
-	err = h.pgPool.DropDatabase(ctx, db)
+	err = b.DropDatabase(ctx, db)
 	switch err {
 	case nil:
 		res.Set("dropped", db)
-	case pgdb.ErrSchemaNotExist:
+	case backend.ErrDatabaseNotExist:
 		// nothing
 	default:
 		return nil, lazyerrors.Error(err)
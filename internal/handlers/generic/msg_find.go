@@ -0,0 +1,336 @@
+// Copyright 2021 FerretDB Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package generic
+
+import (
+	"context"
+	"fmt"
+	"io"
+
+	"go.uber.org/zap"
+
+	"github.com/FerretDB/FerretDB/internal/handlers/backend"
+	"github.com/FerretDB/FerretDB/internal/handlers/common"
+	"github.com/FerretDB/FerretDB/internal/types"
+	"github.com/FerretDB/FerretDB/internal/util/lazyerrors"
+	"github.com/FerretDB/FerretDB/internal/wire"
+)
+
+// MsgFind implements HandlerInterface.
+func (h *Handler) MsgFind(ctx context.Context, msg *wire.OpMsg) (*wire.OpMsg, error) {
+	document, err := msg.Document()
+	if err != nil {
+		return nil, lazyerrors.Error(err)
+	}
+
+	unimplementedFields := []string{
+		"returnKey",
+		"showRecordId",
+		"tailable",
+		"oplogReplay",
+		"awaitData",
+		"allowPartialResults",
+		"collation",
+		"allowDiskUse",
+		"let",
+	}
+	if err := common.Unimplemented(document, unimplementedFields...); err != nil {
+		return nil, err
+	}
+	ignoredFields := []string{
+		"hint",
+		"readConcern",
+		"max",
+		"min",
+	}
+	common.Ignored(document, h.l, ignoredFields...)
+
+	maxTimeMS, err := common.GetMaxTimeMSParam(document)
+	if err != nil {
+		return nil, err
+	}
+
+	ctx, cancel := common.WithMaxTimeMS(ctx, maxTimeMS)
+	defer cancel()
+
+	var filter, sort, projection *types.Document
+	if filter, err = common.GetOptionalParam(document, "filter", filter); err != nil {
+		return nil, err
+	}
+	if sort, err = common.GetOptionalParam(document, "sort", sort); err != nil {
+		return nil, common.NewErrorMsg(common.ErrTypeMismatch, "Expected field sort to be of type object")
+	}
+	if projection, err = common.GetOptionalParam(document, "projection", projection); err != nil {
+		return nil, err
+	}
+
+	var limit int64
+	if l, _ := document.Get("limit"); l != nil {
+		if limit, err = common.GetWholeNumberParam(l); err != nil {
+			return nil, err
+		}
+	}
+
+	var skip int64
+	if s, _ := document.Get("skip"); s != nil {
+		if skip, err = common.GetWholeNumberParam(s); err != nil {
+			return nil, err
+		}
+		if skip < 0 {
+			return nil, common.NewErrorMsg(common.ErrBadValue, "SkipValue must be non-negative")
+		}
+	}
+
+	var batchSize int64
+	if b, _ := document.Get("batchSize"); b != nil {
+		if batchSize, err = common.GetWholeNumberParam(b); err != nil {
+			return nil, err
+		}
+		if batchSize < 0 {
+			return nil, common.NewErrorMsg(common.ErrBadValue, "BatchSize value must be non-negative")
+		}
+	}
+
+	singleBatch, err := common.GetBoolOptionalParam(document, "singleBatch")
+	if err != nil {
+		return nil, err
+	}
+
+	noCursorTimeout, err := common.GetBoolOptionalParam(document, "noCursorTimeout")
+	if err != nil {
+		return nil, err
+	}
+
+	var sp sqlParam
+	if sp.db, err = common.GetRequiredParam[string](document, "$db"); err != nil {
+		return nil, err
+	}
+	collectionParam, err := document.Get(document.Command())
+	if err != nil {
+		return nil, err
+	}
+	var ok bool
+	if sp.collection, ok = collectionParam.(string); !ok {
+		return nil, common.NewErrorMsg(
+			common.ErrBadValue,
+			fmt.Sprintf("collection name has invalid type %s", common.AliasFromType(collectionParam)),
+		)
+	}
+
+	// get comment from options.FindOne().SetComment() method
+	if sp.comment, err = common.GetOptionalParam(document, "comment", sp.comment); err != nil {
+		return nil, err
+	}
+	// get comment from query, e.g. db.collection.find({$comment: "test"})
+	if filter != nil {
+		if sp.comment, err = common.GetOptionalParam(filter, "$comment", sp.comment); err != nil {
+			return nil, err
+		}
+	}
+
+	if sp.readPreference, err = common.GetReadPreferenceMode(document); err != nil {
+		return nil, err
+	}
+
+	sp.filter = filter
+	sp.sort = sort
+	sp.projection = projection
+
+	ns := sp.db + "." + sp.collection
+
+	// without sort, documents are fetched from the backend batch by batch as the client requests them,
+	// unless too many backend iterators are already open
+	if sort.Len() == 0 {
+		iter, err := h.newFindIterator(ctx, sp, skip, limit)
+		if err != nil {
+			return nil, err
+		}
+
+		if iter != nil {
+			return common.MakeIteratorCursorReply(ctx, ns, iter, batchSize, singleBatch, noCursorTimeout)
+		}
+	}
+
+	resDocs := make([]*types.Document, 0, 16)
+	sorted, err := h.iterate(ctx, sp, func(doc *types.Document) (bool, error) {
+		resDocs = append(resDocs, doc)
+
+		// without sort, there is no need to fetch documents past skip and limit
+		return sort.Len() > 0 || limit <= 0 || int64(len(resDocs)) < skip+limit, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if !sorted {
+		if err = common.SortDocuments(resDocs, sort); err != nil {
+			return nil, err
+		}
+	}
+	if resDocs, err = common.SkipDocuments(resDocs, skip); err != nil {
+		return nil, err
+	}
+	if resDocs, err = common.LimitDocuments(resDocs, limit); err != nil {
+		return nil, err
+	}
+	if err = common.ProjectDocuments(resDocs, projection); err != nil {
+		return nil, err
+	}
+
+	return common.MakeCursorReply(ctx, ns, resDocs, batchSize, singleBatch, noCursorTimeout)
+}
+
+// findIterator is a common.CursorIterator over documents of find command without sort.
+//
+// It applies the residual filter, skip, limit, and projection to documents of the backend iterator.
+// The backend iterator is not bound to the find request, so it can be used by subsequent getMore commands;
+// it holds backend resources (like PostgreSQL connection) until the cursor is exhausted, killed, or closed when idle.
+// The number of such iterators is limited; see NewOpts.MaxOpenIterators.
+type findIterator struct {
+	h          *Handler
+	iter       backend.Iterator // nil if collection doesn't exist or iterator is closed
+	ctx        context.Context  // iterator's context
+	cancel     context.CancelFunc
+	projection *types.Document
+	skip       int64 // number of matching documents to skip
+	limit      int64 // 0 means no limit
+	n          int64 // number of returned documents
+	slot       bool  // true if iterator slot is not released yet
+}
+
+// newFindIterator returns a new findIterator for the given parameters,
+// or nil if too many backend iterators are already open (see NewOpts.MaxOpenIterators).
+//
+// Iterator's context is not canceled when the find request is finished, but it keeps the request's deadline,
+// so maxTimeMS limits the whole lifetime of the cursor, including getMore commands.
+func (h *Handler) newFindIterator(ctx context.Context, sp sqlParam, skip, limit int64) (*findIterator, error) {
+	if !h.acquireIterator() {
+		h.l.Debug(
+			"Too many open iterators, fetching all documents.",
+			zap.String("db", sp.db), zap.String("collection", sp.collection),
+		)
+		return nil, nil
+	}
+
+	iterCtx, cancel := context.WithCancel(context.Background())
+	if deadline, ok := ctx.Deadline(); ok {
+		cancel()
+		iterCtx, cancel = context.WithDeadline(context.Background(), deadline)
+	}
+
+	iter, err := h.queryIterator(ctx, iterCtx, sp)
+	if err != nil {
+		cancel()
+		h.releaseIterator()
+		return nil, err
+	}
+
+	return &findIterator{
+		h:          h,
+		iter:       iter,
+		ctx:        iterCtx,
+		cancel:     cancel,
+		projection: sp.projection,
+		skip:       skip,
+		limit:      limit,
+		slot:       true,
+	}, nil
+}
+
+// Next implements common.CursorIterator interface.
+func (fi *findIterator) Next(ctx context.Context) (*types.Document, error) {
+	if fi.iter == nil || (fi.limit > 0 && fi.n >= fi.limit) {
+		return nil, io.EOF
+	}
+
+	stats := common.GetOpStats(ctx)
+
+	for {
+		doc, err := fi.iter.Next()
+		if err == io.EOF {
+			return nil, io.EOF
+		}
+		if err != nil {
+			return nil, queryError(fi.ctx, err)
+		}
+
+		stats.AddDocsExamined(1)
+
+		matches, err := common.FilterDocument(doc, fi.iter.Residual())
+		if err != nil {
+			return nil, err
+		}
+
+		if !matches {
+			continue
+		}
+
+		if fi.skip > 0 {
+			fi.skip--
+			continue
+		}
+
+		if err = common.ProjectDocuments([]*types.Document{doc}, fi.projection); err != nil {
+			return nil, err
+		}
+
+		fi.n++
+
+		return doc, nil
+	}
+}
+
+// Close implements common.CursorIterator interface.
+func (fi *findIterator) Close() {
+	if fi.iter != nil {
+		fi.h.closeIterator(fi.iter)
+		fi.iter = nil
+	}
+
+	if fi.slot {
+		fi.h.releaseIterator()
+		fi.slot = false
+	}
+
+	fi.cancel()
+}
+
+// acquireIterator returns true if one more backend iterator could be kept open between requests
+// (see NewOpts.MaxOpenIterators); releaseIterator should be called after it is closed.
+// It does not block.
+func (h *Handler) acquireIterator() bool {
+	if h.iterSlots == nil {
+		return true
+	}
+
+	select {
+	case h.iterSlots <- struct{}{}:
+		return true
+	default:
+		return false
+	}
+}
+
+// releaseIterator releases the slot acquired by acquireIterator.
+func (h *Handler) releaseIterator() {
+	if h.iterSlots != nil {
+		<-h.iterSlots
+	}
+}
+
+// check interfaces
+var (
+	_ common.CursorIterator = (*findIterator)(nil)
+)
@@ -12,10 +12,11 @@
 // See the License for the specific language governing permissions and
 // limitations under the License.
 
-package pg
+package generic
 
 import (
 	"context"
+	"errors"
 	"fmt"
 
 	"github.com/FerretDB/FerretDB/internal/handlers/common"
@@ -44,7 +45,6 @@ func (h *Handler) MsgFindAndModify(ctx context.Context, msg *wire.OpMsg) (*wire.
 	ignoredFields := []string{
 		"bypassDocumentValidation",
 		"writeConcern",
-		"maxTimeMS",
 		"collation",
 		"hint",
 		"comment",
@@ -56,19 +56,44 @@ func (h *Handler) MsgFindAndModify(ctx context.Context, msg *wire.OpMsg) (*wire.
 		return nil, err
 	}
 
-	fetchedDocs, err := h.fetch(ctx, params.sqlParam)
+	maxTimeMS, err := common.GetMaxTimeMSParam(document)
 	if err != nil {
 		return nil, err
 	}
 
-	err = common.SortDocuments(fetchedDocs, params.sort)
+	ctx, cancel := common.WithMaxTimeMS(ctx, maxTimeMS)
+	defer cancel()
+
+	// the document is queried and modified in a single transaction,
+	// so concurrent commands can't modify or remove it in between
+	var reply *wire.OpMsg
+	err = h.inTransaction(ctx, params.sqlParam.db, func(ctx context.Context) error {
+		reply, err = h.findAndModify(ctx, params)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return reply, nil
+}
+
+// findAndModify finds and modifies or removes a single document; see MsgFindAndModify.
+func (h *Handler) findAndModify(ctx context.Context, params *findAndModifyParams) (*wire.OpMsg, error) {
+	fetched, err := h.fetch(ctx, params.sqlParam)
 	if err != nil {
 		return nil, err
 	}
 
+	if !fetched.Sorted {
+		if err = common.SortDocuments(fetched.Docs, params.sort); err != nil {
+			return nil, err
+		}
+	}
+
 	resDocs := make([]*types.Document, 0, 16)
-	for _, doc := range fetchedDocs {
-		matches, err := common.FilterDocument(doc, params.query)
+	for _, doc := range fetched.Docs {
+		matches, err := common.FilterDocument(doc, fetched.Residual)
 		if err != nil {
 			return nil, err
 		}
@@ -97,6 +122,19 @@ func (h *Handler) MsgFindAndModify(ctx context.Context, msg *wire.OpMsg) (*wire.
 				sqlParam:           params.sqlParam,
 			}
 			upsert, upserted, err = h.upsert(ctx, resDocs, p)
+			if err == errUpsertConflict {
+				// a document with the same _id was inserted concurrently; update it if it matches the query
+				if resDocs, err = h.fetchMatching(ctx, params.sqlParam); err != nil {
+					return nil, err
+				}
+
+				if len(resDocs) == 0 {
+					return nil, duplicateKeyError(params.sqlParam)
+				}
+
+				resDocs = resDocs[:1]
+				upsert, upserted, err = h.upsert(ctx, resDocs, p)
+			}
 			if err != nil {
 				return nil, err
 			}
@@ -198,6 +236,10 @@ func (h *Handler) MsgFindAndModify(ctx context.Context, msg *wire.OpMsg) (*wire.
 	return nil, lazyerrors.New("bad flags combination")
 }
 
+// errUpsertConflict is returned by Handler.upsert when a document with the same _id
+// was inserted concurrently between the query and the insert.
+var errUpsertConflict = errors.New("upsert conflict")
+
 // upsertParams represent parameters for Handler.upsert method.
 type upsertParams struct {
 	hasUpdateOperators bool
@@ -207,6 +249,7 @@ type upsertParams struct {
 
 // upsert inserts new document if no documents in query result or updates given document.
 // When inserting new document we must check that `_id` is present, so we must extract `_id` from query or generate a new one.
+// The insert is atomic; if a document with the same _id already exists, errUpsertConflict is returned.
 func (h *Handler) upsert(ctx context.Context, docs []*types.Document, params *upsertParams) (*types.Document, bool, error) {
 	if len(docs) == 0 {
 		upsert := must.NotFail(types.NewDocument())
@@ -228,11 +271,15 @@ func (h *Handler) upsert(ctx context.Context, docs []*types.Document, params *up
 			}
 		}
 
-		err := h.insert(ctx, params.sqlParam, upsert)
+		inserted, err := h.insertIfNotExists(ctx, params.sqlParam, upsert)
 		if err != nil {
 			return nil, false, err
 		}
 
+		if !inserted {
+			return nil, false, errUpsertConflict
+		}
+
 		return upsert, true, nil
 	}
 
@@ -346,10 +393,18 @@ func prepareFindAndModifyParams(document *types.Document) (*findAndModifyParams,
 		}
 	}
 
+	if hasUpdateOperators {
+		if err = common.ValidateUpdateOperators(update); err != nil {
+			return nil, err
+		}
+	}
+
 	return &findAndModifyParams{
 		sqlParam: sqlParam{
 			db:         db,
 			collection: collection,
+			filter:     query,
+			sort:       sort,
 		},
 		query:              query,
 		update:             update,
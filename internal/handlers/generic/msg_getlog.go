@@ -12,13 +12,12 @@
 // See the License for the specific language governing permissions and
 // limitations under the License.
 
-package pg
+package generic
 
 import (
 	"context"
 	"encoding/json"
 	"fmt"
-	"strings"
 	"time"
 
 	"github.com/FerretDB/FerretDB/internal/handlers/common"
@@ -41,18 +40,16 @@ func (h *Handler) MsgGetLog(ctx context.Context, msg *wire.OpMsg) (*wire.OpMsg,
 		return nil, common.NewErrorMsg(common.ErrNotImplemented, errMsg)
 	}
 
-	var pv string
-	err = h.pgPool.QueryRow(ctx, "SHOW server_version").Scan(&pv)
+	sv, err := h.storage.Version(ctx)
 	if err != nil {
 		return nil, lazyerrors.Error(err)
 	}
 
-	pv, _, _ = strings.Cut(pv, " ")
 	mv := version.Get()
 
 	var log types.Array
 	for _, line := range []string{
-		"Powered by 🥭 FerretDB " + mv.Version + " and PostgreSQL " + pv + ".",
+		"Powered by 🥭 FerretDB " + mv.Version + " and " + h.storage.Name() + " " + sv + ".",
 		"Please star us on GitHub: https://github.com/FerretDB/FerretDB",
 	} {
 		b, err := json.Marshal(map[string]any{
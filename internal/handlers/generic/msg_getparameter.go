@@ -12,7 +12,7 @@
 // See the License for the specific language governing permissions and
 // limitations under the License.
 
-package pg
+package generic
 
 import (
 	"context"
@@ -47,6 +47,11 @@ func (h *Handler) MsgGetParameter(ctx context.Context, msg *wire.OpMsg) (*wire.O
 			"settableAtRuntime", true,
 			"settableAtStartup", true,
 		)),
+		"featureCompatibilityVersion", must.NotFail(types.NewDocument(
+			"value", must.NotFail(types.NewDocument("version", common.GetFCV().String())),
+			"settableAtRuntime", false,
+			"settableAtStartup", false,
+		)),
 		"tlsMode", must.NotFail(types.NewDocument(
 			"value", "disabled",
 			"settableAtRuntime", true,
@@ -62,6 +67,16 @@ func (h *Handler) MsgGetParameter(ctx context.Context, msg *wire.OpMsg) (*wire.O
 			"settableAtRuntime", true,
 			"settableAtStartup", true,
 		)),
+		"redactClientLogData", must.NotFail(types.NewDocument(
+			"value", wire.GetRedactMode() != wire.RedactNone,
+			"settableAtRuntime", true,
+			"settableAtStartup", true,
+		)),
+		"logComponentVerbosity", must.NotFail(types.NewDocument(
+			"value", common.LogComponentVerbosity(),
+			"settableAtRuntime", true,
+			"settableAtStartup", true,
+		)),
 		"ok", float64(1),
 	))
 
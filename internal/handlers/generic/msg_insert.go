@@ -0,0 +1,292 @@
+// Copyright 2021 FerretDB Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package generic
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"go.uber.org/zap"
+
+	"github.com/FerretDB/FerretDB/internal/handlers/backend"
+	"github.com/FerretDB/FerretDB/internal/handlers/common"
+	"github.com/FerretDB/FerretDB/internal/types"
+	"github.com/FerretDB/FerretDB/internal/util/lazyerrors"
+	"github.com/FerretDB/FerretDB/internal/util/must"
+	"github.com/FerretDB/FerretDB/internal/wire"
+)
+
+// batchMinDocuments is the minimal number of documents in the insert batch
+// for which a single backend call is used first instead of a call per document.
+const batchMinDocuments = 10
+
+// bulkImportMinDocuments is the minimal number of documents in the unordered insert batch
+// with bypassDocumentValidation set that is treated as a part of a bulk import.
+//
+// mongorestore sends batches of up to 1000 documents like that; see backend.BulkInserter.
+const bulkImportMinDocuments = 100
+
+// MsgInsert implements HandlerInterface.
+func (h *Handler) MsgInsert(ctx context.Context, msg *wire.OpMsg) (*wire.OpMsg, error) {
+	document, err := msg.Document()
+	if err != nil {
+		return nil, lazyerrors.Error(err)
+	}
+
+	common.Ignored(document, h.l, "writeConcern", "comment")
+
+	var sp sqlParam
+	if sp.db, err = common.GetRequiredParam[string](document, "$db"); err != nil {
+		return nil, err
+	}
+	collectionParam, err := document.Get(document.Command())
+	if err != nil {
+		return nil, err
+	}
+	var ok bool
+	if sp.collection, ok = collectionParam.(string); !ok {
+		return nil, common.NewErrorMsg(
+			common.ErrBadValue,
+			fmt.Sprintf("collection name has invalid type %s", common.AliasFromType(collectionParam)),
+		)
+	}
+
+	var docs *types.Array
+	if docs, err = common.GetOptionalParam(document, "documents", docs); err != nil {
+		return nil, err
+	}
+
+	ordered := true
+	if ordered, err = common.GetOptionalParam(document, "ordered", ordered); err != nil {
+		return nil, err
+	}
+
+	// there are no validators to bypass, but that flag identifies bulk imports
+	var bypass bool
+	if bypass, err = common.GetOptionalParam(document, "bypassDocumentValidation", bypass); err != nil {
+		return nil, err
+	}
+
+	if err = common.CheckWriteBatchSize(docs.Len()); err != nil {
+		return nil, err
+	}
+
+	insertDocs := make([]*types.Document, 0, docs.Len())
+	indexes := make([]int32, 0, docs.Len())
+
+	var invalid common.WriteErrors
+	for i := 0; i < docs.Len(); i++ {
+		d, err := prepareInsert(must.NotFail(docs.Get(i)))
+		if err == nil {
+			err = common.CheckInsertFieldNames(d)
+		}
+
+		if err != nil {
+			invalid.Append(err, int32(i))
+
+			if ordered {
+				break
+			}
+
+			continue
+		}
+
+		insertDocs = append(insertDocs, d)
+		indexes = append(indexes, int32(i))
+	}
+
+	bulk := !ordered && bypass && len(insertDocs) >= bulkImportMinDocuments
+
+	inserted, writeErrors, err := h.insertMany(ctx, sp, insertDocs, indexes, ordered, bulk)
+	if err != nil {
+		return nil, err
+	}
+
+	// for ordered inserts, only the first error is reported
+	if !ordered || len(writeErrors) == 0 {
+		writeErrors.Merge(invalid)
+	}
+
+	res := must.NotFail(types.NewDocument(
+		"n", inserted,
+	))
+
+	if len(writeErrors) > 0 {
+		must.NoError(res.Set("writeErrors", must.NotFail(writeErrors.Document().Get("writeErrors"))))
+	}
+
+	must.NoError(res.Set("ok", float64(1)))
+
+	var reply wire.OpMsg
+	err = reply.SetSections(wire.OpMsgSection{
+		Documents: []*types.Document{res},
+	})
+	if err != nil {
+		return nil, lazyerrors.Error(err)
+	}
+
+	return &reply, nil
+}
+
+// insertResult accumulates results of insertBatch.
+type insertResult struct {
+	inserted    int32
+	writeErrors common.WriteErrors
+}
+
+// insertMany inserts the given documents that have the given indexes in the write batch.
+//
+// Large batches are inserted in a single transaction (see insertBatch),
+// small batches are inserted one by one; errors are mapped to write errors for individual documents.
+// Batches of bulk imports are inserted with backend.BulkInserter, if the backend implements it.
+// For ordered inserts, the first error stops the insertion.
+//
+// It returns the number of inserted documents and write errors.
+// Only context errors are returned as errors.
+func (h *Handler) insertMany(
+	ctx context.Context, sp sqlParam, docs []*types.Document, indexes []int32, ordered, bulk bool,
+) (int32, common.WriteErrors, error) {
+	b, err := h.dbBackend(ctx, sp.db)
+	if err != nil {
+		return 0, nil, err
+	}
+
+	var res insertResult
+	if _, err = h.insertBatch(ctx, b, sp, docs, indexes, ordered, bulk, &res); err != nil {
+		return res.inserted, nil, err
+	}
+
+	return res.inserted, res.writeErrors, nil
+}
+
+// insertBatch inserts documents for insertMany and adds results to res.
+//
+// If a batch fails, it is split in halves that are inserted the same way,
+// so failing documents are found with a few statements instead of a statement per document.
+// It returns true if the ordered insertion should stop.
+func (h *Handler) insertBatch(
+	ctx context.Context, b backend.Backend, sp sqlParam, docs []*types.Document, indexes []int32, ordered, bulk bool,
+	res *insertResult,
+) (bool, error) {
+	if len(docs) >= batchMinDocuments {
+		insertDocuments := b.InsertDocuments
+		if bi, ok := b.(backend.BulkInserter); ok && bulk {
+			insertDocuments = bi.BulkInsertDocuments
+		}
+
+		err := insertDocuments(ctx, sp.db, sp.collection, docs)
+		if err == nil {
+			res.inserted += int32(len(docs))
+			return false, nil
+		}
+
+		if ctx.Err() != nil {
+			return true, lazyerrors.Error(err)
+		}
+
+		h.l.Debug("Failed to insert documents in a batch, splitting it.", zap.Int("documents", len(docs)), zap.Error(err))
+
+		mid := len(docs) / 2
+
+		stop, err := h.insertBatch(ctx, b, sp, docs[:mid], indexes[:mid], ordered, bulk, res)
+		if stop || err != nil {
+			return stop, err
+		}
+
+		return h.insertBatch(ctx, b, sp, docs[mid:], indexes[mid:], ordered, bulk, res)
+	}
+
+	for i, doc := range docs {
+		if err := b.InsertDocument(ctx, sp.db, sp.collection, doc); err != nil {
+			if ctx.Err() != nil {
+				return true, lazyerrors.Error(err)
+			}
+
+			res.writeErrors.Append(lazyerrors.Error(writeError(sp, err)), indexes[i])
+
+			if ordered {
+				return true, nil
+			}
+
+			continue
+		}
+
+		res.inserted++
+	}
+
+	return false, nil
+}
+
+// insertIfNotExists inserts a document unless a document with the same _id already exists
+// (see backend.Backend.InsertDocumentIfNotExists). It returns true if the document was inserted.
+func (h *Handler) insertIfNotExists(ctx context.Context, sp sqlParam, doc *types.Document) (bool, error) {
+	b, err := h.dbBackend(ctx, sp.db)
+	if err != nil {
+		return false, err
+	}
+
+	if _, err = prepareInsert(doc); err != nil {
+		return false, err
+	}
+
+	inserted, err := b.InsertDocumentIfNotExists(ctx, sp.db, sp.collection, doc)
+	if err != nil {
+		return false, lazyerrors.Error(writeError(sp, err))
+	}
+
+	return inserted, nil
+}
+
+// writeError returns protocol error for backend errors caused by the written document,
+// or the given error as is.
+func writeError(sp sqlParam, err error) error {
+	if errors.Is(err, backend.ErrDuplicateID) {
+		return duplicateKeyError(sp)
+	}
+
+	var tfe *backend.TimeFieldError
+	if errors.As(err, &tfe) {
+		msg := fmt.Sprintf("'%s' must be present and contain a valid BSON UTC datetime value", tfe.TimeField)
+		return common.NewErrorMsg(common.ErrBadValue, msg)
+	}
+
+	return err
+}
+
+// duplicateKeyError returns DuplicateKey protocol error for a document with already existing _id.
+func duplicateKeyError(sp sqlParam) error {
+	return common.NewErrorMsg(
+		common.ErrDuplicateKey,
+		fmt.Sprintf("E11000 duplicate key error collection: %s.%s index: _id_", sp.db, sp.collection),
+	)
+}
+
+// prepareInsert checks that the given value could be inserted as a document.
+func prepareInsert(doc any) (*types.Document, error) {
+	d, ok := doc.(*types.Document)
+	if !ok {
+		return nil, common.NewErrorMsg(
+			common.ErrBadValue,
+			fmt.Sprintf("document has invalid type %s", common.AliasFromType(doc)),
+		)
+	}
+
+	if err := common.CheckInsertSize(d); err != nil {
+		return nil, err
+	}
+
+	return d, nil
+}
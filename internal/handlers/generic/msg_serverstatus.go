@@ -12,7 +12,7 @@
 // See the License for the specific language governing permissions and
 // limitations under the License.
 
-package pg
+package generic
 
 import (
 	"context"
@@ -51,7 +51,12 @@ func (h *Handler) MsgServerStatus(ctx context.Context, msg *wire.OpMsg) (*wire.O
 
 	uptime := time.Since(h.startTime)
 
-	stats, err := h.pgPool.SchemaStats(ctx, db, "")
+	b, err := h.dbBackend(ctx, db)
+	if err != nil {
+		return nil, err
+	}
+
+	collections, err := b.Collections(ctx, db)
 	if err != nil {
 		return nil, lazyerrors.Error(err)
 	}
@@ -68,7 +73,7 @@ func (h *Handler) MsgServerStatus(ctx context.Context, msg *wire.OpMsg) (*wire.O
 			"uptimeEstimate", int64(uptime.Seconds()),
 			"localTime", time.Now(),
 			"catalogStats", must.NotFail(types.NewDocument(
-				"collections", stats.CountTables,
+				"collections", int32(len(collections)),
 				"capped", int32(0),
 				"timeseries", int32(0),
 				"views", int32(0),
@@ -78,6 +83,9 @@ func (h *Handler) MsgServerStatus(ctx context.Context, msg *wire.OpMsg) (*wire.O
 			"freeMonitoring", must.NotFail(types.NewDocument(
 				"state", "disabled",
 			)),
+			"metrics", must.NotFail(types.NewDocument(
+				"cursor", common.CursorMetrics(),
+			)),
 			"ok", float64(1),
 		))},
 	})
@@ -0,0 +1,307 @@
+// Copyright 2021 FerretDB Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package generic
+
+import (
+	"context"
+	"fmt"
+
+	"go.uber.org/zap"
+
+	"github.com/FerretDB/FerretDB/internal/handlers/common"
+	"github.com/FerretDB/FerretDB/internal/types"
+	"github.com/FerretDB/FerretDB/internal/util/lazyerrors"
+	"github.com/FerretDB/FerretDB/internal/util/must"
+	"github.com/FerretDB/FerretDB/internal/wire"
+)
+
+// updateStatement represents a single statement of the update command.
+type updateStatement struct {
+	q      *types.Document
+	u      *types.Document
+	upsert bool
+}
+
+// updateResult accumulates results of update statements.
+type updateResult struct {
+	matched  int32
+	modified int32
+	upserted types.Array
+}
+
+// MsgUpdate implements HandlerInterface.
+//
+// All statements are parsed before any of them is executed.
+// Errors of individual statements are returned as write errors with statement indexes;
+// ordered updates stop on the first such error, unordered updates continue with the next statement.
+func (h *Handler) MsgUpdate(ctx context.Context, msg *wire.OpMsg) (*wire.OpMsg, error) {
+	document, err := msg.Document()
+	if err != nil {
+		return nil, lazyerrors.Error(err)
+	}
+
+	if err := common.Unimplemented(document, "let"); err != nil {
+		return nil, err
+	}
+	common.Ignored(document, h.l, "writeConcern", "bypassDocumentValidation", "comment")
+
+	var sp sqlParam
+	if sp.db, err = common.GetRequiredParam[string](document, "$db"); err != nil {
+		return nil, err
+	}
+	collectionParam, err := document.Get(document.Command())
+	if err != nil {
+		return nil, err
+	}
+	var ok bool
+	if sp.collection, ok = collectionParam.(string); !ok {
+		return nil, common.NewErrorMsg(
+			common.ErrBadValue,
+			fmt.Sprintf("collection name has invalid type %s", common.AliasFromType(collectionParam)),
+		)
+	}
+
+	var updates *types.Array
+	if updates, err = common.GetOptionalParam(document, "updates", updates); err != nil {
+		return nil, err
+	}
+
+	ordered := true
+	if ordered, err = common.GetOptionalParam(document, "ordered", ordered); err != nil {
+		return nil, err
+	}
+
+	if err = common.CheckWriteBatchSize(updates.Len()); err != nil {
+		return nil, err
+	}
+
+	statements := make([]*updateStatement, updates.Len())
+	for i := 0; i < updates.Len(); i++ {
+		update, err := common.AssertType[*types.Document](must.NotFail(updates.Get(i)))
+		if err != nil {
+			return nil, err
+		}
+
+		if statements[i], err = parseUpdateStatement(update); err != nil {
+			return nil, err
+		}
+	}
+
+	b, err := h.dbBackend(ctx, sp.db)
+	if err != nil {
+		return nil, err
+	}
+
+	created, err := b.CreateCollectionIfNotExist(ctx, sp.db, sp.collection)
+	if err != nil {
+		return nil, err
+	}
+	if created {
+		h.l.Info("Created collection.", zap.String("db", sp.db), zap.String("collection", sp.collection))
+	}
+
+	var res updateResult
+	var writeErrors common.WriteErrors
+	for i, s := range statements {
+		if err = h.execUpdate(ctx, sp, s, int32(i), &res); err == nil {
+			continue
+		}
+
+		if !common.IsWriteError(ctx, err) {
+			return nil, err
+		}
+
+		writeErrors.Append(err, int32(i))
+
+		if ordered {
+			break
+		}
+	}
+
+	reply := must.NotFail(types.NewDocument(
+		"n", res.matched,
+	))
+	if res.upserted.Len() != 0 {
+		must.NoError(reply.Set("upserted", &res.upserted))
+	}
+	must.NoError(reply.Set("nModified", res.modified))
+
+	if len(writeErrors) > 0 {
+		must.NoError(reply.Set("writeErrors", must.NotFail(writeErrors.Document().Get("writeErrors"))))
+	}
+
+	must.NoError(reply.Set("ok", float64(1)))
+
+	var resMsg wire.OpMsg
+	err = resMsg.SetSections(wire.OpMsgSection{
+		Documents: []*types.Document{reply},
+	})
+	if err != nil {
+		return nil, lazyerrors.Error(err)
+	}
+
+	return &resMsg, nil
+}
+
+// parseUpdateStatement parses a single statement of the update command.
+func parseUpdateStatement(update *types.Document) (*updateStatement, error) {
+	unimplementedFields := []string{
+		"c",
+		"multi",
+		"collation",
+		"arrayFilters",
+		"hint",
+	}
+	if err := common.Unimplemented(update, unimplementedFields...); err != nil {
+		return nil, err
+	}
+
+	var s updateStatement
+	var err error
+	if s.q, err = common.GetOptionalParam(update, "q", s.q); err != nil {
+		return nil, err
+	}
+	if s.u, err = common.GetOptionalParam(update, "u", s.u); err != nil {
+		return nil, err
+	}
+	if s.upsert, err = common.GetOptionalParam(update, "upsert", s.upsert); err != nil {
+		return nil, err
+	}
+
+	return &s, nil
+}
+
+// execUpdate executes a single update statement with the given index and adds its results to res.
+//
+// All documents changed by the statement are replaced with a single backend call,
+// so they are either all changed or none of them.
+func (h *Handler) execUpdate(ctx context.Context, sp sqlParam, s *updateStatement, index int32, res *updateResult) error {
+	if s.u != nil {
+		if err := common.ValidateUpdateOperators(s.u); err != nil {
+			return err
+		}
+	}
+
+	sp.filter = s.q
+	resDocs, err := h.fetchMatching(ctx, sp)
+	if err != nil {
+		return err
+	}
+
+	if len(resDocs) == 0 {
+		if !s.upsert {
+			// nothing to do
+			return nil
+		}
+
+		doc := s.q.DeepCopy()
+		if _, err = common.UpdateDocument(doc, s.u); err != nil {
+			return err
+		}
+		if !doc.Has("_id") {
+			must.NoError(doc.Set("_id", types.NewObjectID()))
+		}
+
+		inserted, err := h.insertIfNotExists(ctx, sp, doc)
+		if err != nil {
+			return err
+		}
+
+		if inserted {
+			must.NoError(res.upserted.Append(must.NotFail(types.NewDocument(
+				"index", index,
+				"_id", must.NotFail(doc.Get("_id")),
+			))))
+
+			res.matched++
+			return nil
+		}
+
+		// a document with the same _id was inserted concurrently; update it if it matches the query
+		if resDocs, err = h.fetchMatching(ctx, sp); err != nil {
+			return err
+		}
+
+		if len(resDocs) == 0 {
+			return duplicateKeyError(sp)
+		}
+	}
+
+	changedDocs := make([]*types.Document, 0, len(resDocs))
+	for _, doc := range resDocs {
+		changed, err := common.UpdateDocument(doc, s.u)
+		if err != nil {
+			return err
+		}
+
+		if !changed {
+			continue
+		}
+
+		if err = common.CheckUpdateSize(doc); err != nil {
+			return err
+		}
+
+		if err = common.CheckUpdateFieldNames(doc); err != nil {
+			return err
+		}
+
+		changedDocs = append(changedDocs, doc)
+	}
+
+	modified, err := h.updateMany(ctx, sp, changedDocs)
+	if err != nil {
+		return err
+	}
+
+	res.matched += int32(len(resDocs))
+	res.modified += int32(modified)
+
+	return nil
+}
+
+// update updates documents by _id.
+func (h *Handler) update(ctx context.Context, sp sqlParam, doc *types.Document) (int64, error) {
+	b, err := h.dbBackend(ctx, sp.db)
+	if err != nil {
+		return 0, err
+	}
+
+	id := must.NotFail(doc.Get("_id"))
+
+	rowsUpdated, err := b.SetDocumentByID(ctx, sp.db, sp.collection, id, doc)
+	if err != nil {
+		return 0, writeError(sp, err)
+	}
+	return rowsUpdated, nil
+}
+
+// updateMany replaces the given documents by their _id values with a single backend call.
+func (h *Handler) updateMany(ctx context.Context, sp sqlParam, docs []*types.Document) (int64, error) {
+	if len(docs) == 0 {
+		return 0, nil
+	}
+
+	b, err := h.dbBackend(ctx, sp.db)
+	if err != nil {
+		return 0, err
+	}
+
+	rowsUpdated, err := b.SetDocumentsByID(ctx, sp.db, sp.collection, docs)
+	if err != nil {
+		return 0, writeError(sp, err)
+	}
+	return rowsUpdated, nil
+}
@@ -25,7 +25,7 @@ import (
 //
 // Those methods are called to handle clients' requests sent over wire protocol.
 // MsgXXX methods handle OP_MSG commands.
-// CmdQuery handles a limited subset of OP_QUERY messages.
+// Legacy OP_QUERY messages are translated to OP_MSG commands by the caller.
 //
 // Handlers are shared between all connections! Be careful when you need connection-specific information.
 // Currently, we pass connection information through context, see `ConnectionInfo` and its usage.
@@ -35,12 +35,21 @@ type Interface interface {
 	// Close gracefully shutdowns handler.
 	Close()
 
-	// CmdQuery queries collections for documents.
-	// Used by deprecated OP_QUERY message during connection handshake with an old client.
-	CmdQuery(ctx context.Context, query *wire.OpQuery) (*wire.OpReply, error)
+	// Capabilities returns optional features supported by the handler.
+	Capabilities() *Capabilities
+
+	// Check returns an error if the backend can't handle requests, for example, if it is not reachable.
+	// It is used by readiness probes.
+	Check(ctx context.Context) error
 
 	// OP_MSG commands, sorted alphabetically
 
+	// MsgApplyOps applies insert, update, and delete oplog entries.
+	MsgApplyOps(ctx context.Context, msg *wire.OpMsg) (*wire.OpMsg, error)
+
+	// MsgAuthenticate authenticates the client using X.509 certificate.
+	MsgAuthenticate(ctx context.Context, msg *wire.OpMsg) (*wire.OpMsg, error)
+
 	// MsgBuildInfo returns a summary of the build information.
 	MsgBuildInfo(ctx context.Context, msg *wire.OpMsg) (*wire.OpMsg, error)
 
@@ -60,6 +69,12 @@ type Interface interface {
 	// MsgCreateIndexes creates indexes on a collection.
 	MsgCreateIndexes(ctx context.Context, msg *wire.OpMsg) (*wire.OpMsg, error)
 
+	// MsgCreateUser creates a new user.
+	MsgCreateUser(ctx context.Context, msg *wire.OpMsg) (*wire.OpMsg, error)
+
+	// MsgCurrentOp returns information about operations currently in progress.
+	MsgCurrentOp(ctx context.Context, msg *wire.OpMsg) (*wire.OpMsg, error)
+
 	// MsgDataSize returns the size of the collection in bytes.
 	MsgDataSize(ctx context.Context, msg *wire.OpMsg) (*wire.OpMsg, error)
 
@@ -78,6 +93,12 @@ type Interface interface {
 	// MsgDropDatabase drops production database.
 	MsgDropDatabase(ctx context.Context, msg *wire.OpMsg) (*wire.OpMsg, error)
 
+	// MsgDropUser removes the user.
+	MsgDropUser(ctx context.Context, msg *wire.OpMsg) (*wire.OpMsg, error)
+
+	// MsgDumpArchive writes collections to the archive in mongodump format.
+	MsgDumpArchive(ctx context.Context, msg *wire.OpMsg) (*wire.OpMsg, error)
+
 	// MsgFind returns documents matched by the query.
 	MsgFind(ctx context.Context, msg *wire.OpMsg) (*wire.OpMsg, error)
 
@@ -93,6 +114,9 @@ type Interface interface {
 	// MsgGetLog returns the most recent logged events from memory.
 	MsgGetLog(ctx context.Context, msg *wire.OpMsg) (*wire.OpMsg, error)
 
+	// MsgGetMore returns the next batch of documents from a cursor.
+	MsgGetMore(ctx context.Context, msg *wire.OpMsg) (*wire.OpMsg, error)
+
 	// MsgGetParameter returns the value of the parameter.
 	MsgGetParameter(ctx context.Context, msg *wire.OpMsg) (*wire.OpMsg, error)
 
@@ -108,6 +132,9 @@ type Interface interface {
 	// MsgIsMaster returns the role of the FerretDB instance.
 	MsgIsMaster(ctx context.Context, msg *wire.OpMsg) (*wire.OpMsg, error)
 
+	// MsgKillCursors closes cursors.
+	MsgKillCursors(ctx context.Context, msg *wire.OpMsg) (*wire.OpMsg, error)
+
 	// MsgListCollections returns the information of the collections and views in the database.
 	MsgListCollections(ctx context.Context, msg *wire.OpMsg) (*wire.OpMsg, error)
 
@@ -117,18 +144,39 @@ type Interface interface {
 	// MsgListDatabases returns a summary of all the databases.
 	MsgListDatabases(ctx context.Context, msg *wire.OpMsg) (*wire.OpMsg, error)
 
+	// MsgListIndexes returns a summary of indexes of the specified collection.
+	MsgListIndexes(ctx context.Context, msg *wire.OpMsg) (*wire.OpMsg, error)
+
+	// MsgMigrateCollection converts the collection to the latest storage format.
+	MsgMigrateCollection(ctx context.Context, msg *wire.OpMsg) (*wire.OpMsg, error)
+
 	// MsgPing returns a pong response.
 	MsgPing(ctx context.Context, msg *wire.OpMsg) (*wire.OpMsg, error)
 
+	// MsgSASLContinue continues the SASL authentication conversation.
+	MsgSASLContinue(ctx context.Context, msg *wire.OpMsg) (*wire.OpMsg, error)
+
+	// MsgSASLStart starts the SASL authentication conversation.
+	MsgSASLStart(ctx context.Context, msg *wire.OpMsg) (*wire.OpMsg, error)
+
 	// MsgServerStatus returns an overview of the databases state.
 	MsgServerStatus(ctx context.Context, msg *wire.OpMsg) (*wire.OpMsg, error)
 
+	// MsgSetFeatureCompatibilityVersion sets the feature compatibility version.
+	MsgSetFeatureCompatibilityVersion(ctx context.Context, msg *wire.OpMsg) (*wire.OpMsg, error)
+
 	// MsgSetFreeMonitoring toggles free monitoring.
 	MsgSetFreeMonitoring(ctx context.Context, msg *wire.OpMsg) (*wire.OpMsg, error)
 
+	// MsgSetParameter sets the value of the parameter.
+	MsgSetParameter(ctx context.Context, msg *wire.OpMsg) (*wire.OpMsg, error)
+
 	// MsgUpdate updates documents that are matched by the query.
 	MsgUpdate(ctx context.Context, msg *wire.OpMsg) (*wire.OpMsg, error)
 
+	// MsgUsersInfo returns information about users.
+	MsgUsersInfo(ctx context.Context, msg *wire.OpMsg) (*wire.OpMsg, error)
+
 	// MsgWhatsMyURI returns peer information.
 	MsgWhatsMyURI(ctx context.Context, msg *wire.OpMsg) (*wire.OpMsg, error)
 }
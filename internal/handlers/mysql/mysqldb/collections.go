@@ -0,0 +1,266 @@
+// Copyright 2021 FerretDB Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package mysqldb
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+
+	"github.com/FerretDB/FerretDB/internal/util/lazyerrors"
+)
+
+// Databases returns a sorted list of FerretDB database names.
+func (mysqlDB *DB) Databases(ctx context.Context) ([]string, error) {
+	return queryStrings(ctx, mysqlDB.q(), `SELECT name FROM `+quoteIdentifier(databasesTable)+` ORDER BY name`)
+}
+
+// Collections returns a sorted list of FerretDB collection names of the given database.
+//
+// It returns an empty list if database does not exist.
+func (mysqlDB *DB) Collections(ctx context.Context, db string) ([]string, error) {
+	query := `SELECT name FROM ` + quoteIdentifier(collectionsTable) + ` WHERE db = ? ORDER BY name`
+	return queryStrings(ctx, mysqlDB.q(), query, db)
+}
+
+// CollectionExists returns true if the given FerretDB collection exists.
+func (mysqlDB *DB) CollectionExists(ctx context.Context, db, collection string) (bool, error) {
+	return collectionExists(ctx, mysqlDB.q(), db, collection)
+}
+
+// CreateDatabase creates a new FerretDB database.
+//
+// It returns ErrAlreadyExist if database already exists.
+func (mysqlDB *DB) CreateDatabase(ctx context.Context, db string) error {
+	created, err := createDatabaseIfNotExist(ctx, mysqlDB.q(), db)
+	if err != nil {
+		return err
+	}
+
+	if !created {
+		return ErrAlreadyExist
+	}
+
+	return nil
+}
+
+// DropDatabase drops FerretDB database with all its collections.
+//
+// It returns ErrDatabaseNotExist if database does not exist.
+func (mysqlDB *DB) DropDatabase(ctx context.Context, db string) error {
+	var collections []string
+	err := mysqlDB.inTransaction(ctx, func(tx *sql.Tx) error {
+		res, err := tx.ExecContext(ctx, `DELETE FROM `+quoteIdentifier(databasesTable)+` WHERE name = ?`, db)
+		if err != nil {
+			return lazyerrors.Error(err)
+		}
+
+		if n, _ := res.RowsAffected(); n == 0 {
+			return ErrDatabaseNotExist
+		}
+
+		query := `SELECT name FROM ` + quoteIdentifier(collectionsTable) + ` WHERE db = ? FOR UPDATE`
+		if collections, err = queryStrings(ctx, tx, query, db); err != nil {
+			return err
+		}
+
+		query = `DELETE FROM ` + quoteIdentifier(collectionsTable) + ` WHERE db = ?`
+		if _, err = tx.ExecContext(ctx, query, db); err != nil {
+			return lazyerrors.Error(err)
+		}
+
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	for _, collection := range collections {
+		if err = dropTable(ctx, mysqlDB.q(), db, collection); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// CreateCollection creates a new FerretDB collection in the existing database.
+//
+// It returns ErrAlreadyExist if collection already exists, ErrDatabaseNotExist if database does not exist.
+func (mysqlDB *DB) CreateCollection(ctx context.Context, db, collection string) error {
+	var exists bool
+	query := `SELECT EXISTS(SELECT 1 FROM ` + quoteIdentifier(databasesTable) + ` WHERE name = ?)`
+	if err := mysqlDB.q().QueryRowContext(ctx, query, db).Scan(&exists); err != nil {
+		return queryError(err)
+	}
+
+	if !exists {
+		return ErrDatabaseNotExist
+	}
+
+	created, err := mysqlDB.CreateCollectionIfNotExist(ctx, db, collection)
+	if err != nil {
+		return err
+	}
+
+	if !created {
+		return ErrAlreadyExist
+	}
+
+	return nil
+}
+
+// CreateCollectionIfNotExist creates FerretDB database and collection if they don't exist.
+//
+// MySQL can't roll back DDL statements, so the table is created first,
+// and then it is registered in metadata tables in a transaction.
+// The table has a unique index on _id values.
+// It returns true if collection was created.
+func (mysqlDB *DB) CreateCollectionIfNotExist(ctx context.Context, db, collection string) (bool, error) {
+	// _ferretdb_rowid keeps documents in the insertion order
+	query := `CREATE TABLE IF NOT EXISTS ` + quoteIdentifier(formatTableName(db, collection)) + ` (` +
+		`_ferretdb_rowid BIGINT NOT NULL AUTO_INCREMENT PRIMARY KEY, ` +
+		`_ferretdb_id VARBINARY(` + fmt.Sprint(maxIDLength) + `) NOT NULL, ` +
+		`_jsonb JSON NOT NULL, ` +
+		`UNIQUE KEY _ferretdb_id (_ferretdb_id))` + tableOptions
+	if _, err := mysqlDB.q().ExecContext(ctx, query); err != nil {
+		return false, queryError(err)
+	}
+
+	var created bool
+	err := mysqlDB.inTransaction(ctx, func(tx *sql.Tx) error {
+		if _, err := createDatabaseIfNotExist(ctx, tx, db); err != nil {
+			return err
+		}
+
+		query := `INSERT INTO ` + quoteIdentifier(collectionsTable) + ` (db, name, indexes) VALUES (?, ?, '{"$k":[]}')` +
+			` ON DUPLICATE KEY UPDATE name = name`
+		res, err := tx.ExecContext(ctx, query, db, collection)
+		if err != nil {
+			return lazyerrors.Error(err)
+		}
+
+		n, err := res.RowsAffected()
+		if err != nil {
+			return lazyerrors.Error(err)
+		}
+
+		created = n == 1
+		return nil
+	})
+	if err != nil {
+		return false, err
+	}
+
+	return created, nil
+}
+
+// DropCollection drops FerretDB collection.
+//
+// The collection is removed from metadata tables first, so it is not visible while the table is dropped.
+// It returns ErrDatabaseNotExist or ErrTableNotExist if database or collection does not exist.
+func (mysqlDB *DB) DropCollection(ctx context.Context, db, collection string) error {
+	err := mysqlDB.inTransaction(ctx, func(tx *sql.Tx) error {
+		var exists bool
+		query := `SELECT EXISTS(SELECT 1 FROM ` + quoteIdentifier(databasesTable) + ` WHERE name = ?)`
+		if err := tx.QueryRowContext(ctx, query, db).Scan(&exists); err != nil {
+			return lazyerrors.Error(err)
+		}
+
+		if !exists {
+			return ErrDatabaseNotExist
+		}
+
+		query = `DELETE FROM ` + quoteIdentifier(collectionsTable) + ` WHERE db = ? AND name = ?`
+		res, err := tx.ExecContext(ctx, query, db, collection)
+		if err != nil {
+			return lazyerrors.Error(err)
+		}
+
+		if n, _ := res.RowsAffected(); n == 0 {
+			return ErrTableNotExist
+		}
+
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	return dropTable(ctx, mysqlDB.q(), db, collection)
+}
+
+// createDatabaseIfNotExist creates FerretDB database if it does not exist.
+//
+// It returns true if database was created.
+func createDatabaseIfNotExist(ctx context.Context, q querier, db string) (bool, error) {
+	query := `INSERT INTO ` + quoteIdentifier(databasesTable) + ` (name) VALUES (?) ON DUPLICATE KEY UPDATE name = name`
+	res, err := q.ExecContext(ctx, query, db)
+	if err != nil {
+		return false, queryError(err)
+	}
+
+	n, err := res.RowsAffected()
+	if err != nil {
+		return false, lazyerrors.Error(err)
+	}
+
+	return n == 1, nil
+}
+
+// dropTable drops the table of FerretDB collection with its indexes if it exists.
+func dropTable(ctx context.Context, q querier, db, collection string) error {
+	if _, err := q.ExecContext(ctx, `DROP TABLE IF EXISTS `+quoteIdentifier(formatTableName(db, collection))); err != nil {
+		return queryError(err)
+	}
+
+	return nil
+}
+
+// collectionExists returns true if the given FerretDB collection exists.
+func collectionExists(ctx context.Context, q querier, db, collection string) (bool, error) {
+	var exists bool
+	query := `SELECT EXISTS(SELECT 1 FROM ` + quoteIdentifier(collectionsTable) + ` WHERE db = ? AND name = ?)`
+	if err := q.QueryRowContext(ctx, query, db, collection).Scan(&exists); err != nil {
+		return false, queryError(err)
+	}
+
+	return exists, nil
+}
+
+// queryStrings runs the given query that returns a single text column and returns its values.
+func queryStrings(ctx context.Context, q querier, query string, args ...any) ([]string, error) {
+	rows, err := q.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, queryError(err)
+	}
+	defer rows.Close()
+
+	res := []string{}
+	for rows.Next() {
+		var s string
+		if err = rows.Scan(&s); err != nil {
+			return nil, lazyerrors.Error(err)
+		}
+
+		res = append(res, s)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, queryError(err)
+	}
+
+	return res, nil
+}
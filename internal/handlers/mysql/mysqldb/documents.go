@@ -0,0 +1,267 @@
+// Copyright 2021 FerretDB Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package mysqldb
+
+import (
+	"context"
+	"database/sql"
+
+	"github.com/FerretDB/FerretDB/internal/fjson"
+	"github.com/FerretDB/FerretDB/internal/types"
+	"github.com/FerretDB/FerretDB/internal/util/lazyerrors"
+)
+
+// InsertDocument inserts a document into FerretDB database and collection.
+// If database or collection does not exist, it will be created.
+//
+// It returns ErrDuplicateID if a document with the same _id already exists.
+func (mysqlDB *DB) InsertDocument(ctx context.Context, db, collection string, doc *types.Document) error {
+	return mysqlDB.InsertDocuments(ctx, db, collection, []*types.Document{doc})
+}
+
+// InsertDocuments inserts all documents into FerretDB database and collection in a single transaction.
+// If database or collection does not exist, it will be created.
+//
+// It returns ErrDuplicateID if a document with the same _id already exists; no documents are inserted in that case.
+func (mysqlDB *DB) InsertDocuments(ctx context.Context, db, collection string, docs []*types.Document) error {
+	if err := mysqlDB.createCollectionForWrite(ctx, db, collection); err != nil {
+		return err
+	}
+
+	return mysqlDB.inTransaction(ctx, func(tx *sql.Tx) error {
+		query := `INSERT INTO ` + quoteIdentifier(formatTableName(db, collection)) + ` (_ferretdb_id, _jsonb) VALUES (?, ?)`
+		stmt, err := tx.PrepareContext(ctx, query)
+		if err != nil {
+			return queryError(err)
+		}
+		defer stmt.Close()
+
+		for _, doc := range docs {
+			idArg, b, err := marshalDocument(doc)
+			if err != nil {
+				return err
+			}
+
+			if _, err = stmt.ExecContext(ctx, idArg, b); err != nil {
+				if isUniqueViolation(err) {
+					return ErrDuplicateID
+				}
+
+				return queryError(err)
+			}
+		}
+
+		return nil
+	})
+}
+
+// InsertDocumentIfNotExists atomically inserts a document unless a document with the same _id already exists.
+// If database or collection does not exist, it will be created.
+//
+// It returns true if the document was inserted.
+func (mysqlDB *DB) InsertDocumentIfNotExists(ctx context.Context, db, collection string, doc *types.Document) (bool, error) {
+	idArg, b, err := marshalDocument(doc)
+	if err != nil {
+		return false, err
+	}
+
+	if err = mysqlDB.createCollectionForWrite(ctx, db, collection); err != nil {
+		return false, err
+	}
+
+	// unlike INSERT IGNORE, other errors are not ignored
+	query := `INSERT INTO ` + quoteIdentifier(formatTableName(db, collection)) + ` (_ferretdb_id, _jsonb) VALUES (?, ?)` +
+		` ON DUPLICATE KEY UPDATE _ferretdb_rowid = _ferretdb_rowid`
+	res, err := mysqlDB.q().ExecContext(ctx, query, idArg, b)
+	if err != nil {
+		return false, queryError(err)
+	}
+
+	n, err := res.RowsAffected()
+	if err != nil {
+		return false, lazyerrors.Error(err)
+	}
+
+	return n == 1, nil
+}
+
+// SetDocumentByID replaces the document with the given _id.
+//
+// It returns the number of changed documents: 0 if there is no such document (or collection)
+// or if the document is the same.
+func (mysqlDB *DB) SetDocumentByID(ctx context.Context, db, collection string, id any, doc *types.Document) (int64, error) {
+	newIDArg, b, err := marshalDocument(doc)
+	if err != nil {
+		return 0, err
+	}
+
+	idArg, err := fjson.Marshal(id)
+	if err != nil {
+		return 0, lazyerrors.Error(err)
+	}
+
+	exists, err := collectionExists(ctx, mysqlDB.q(), db, collection)
+	if err != nil || !exists {
+		return 0, err
+	}
+
+	query := `UPDATE ` + quoteIdentifier(formatTableName(db, collection)) + ` SET _ferretdb_id = ?, _jsonb = ?` +
+		` WHERE _ferretdb_id = ?`
+	res, err := mysqlDB.q().ExecContext(ctx, query, newIDArg, b, idArg)
+	if err != nil {
+		if isUniqueViolation(err) {
+			return 0, ErrDuplicateID
+		}
+
+		return 0, queryError(err)
+	}
+
+	updated, err := res.RowsAffected()
+	if err != nil {
+		return 0, lazyerrors.Error(err)
+	}
+
+	return updated, nil
+}
+
+// SetDocumentsByID replaces documents with the same _ids as the given ones in a single transaction.
+//
+// It returns the number of changed documents; documents that are the same are not counted.
+func (mysqlDB *DB) SetDocumentsByID(ctx context.Context, db, collection string, docs []*types.Document) (int64, error) {
+	exists, err := collectionExists(ctx, mysqlDB.q(), db, collection)
+	if err != nil || !exists {
+		return 0, err
+	}
+
+	var updated int64
+	err = mysqlDB.inTransaction(ctx, func(tx *sql.Tx) error {
+		// _id is not changed, so _ferretdb_id is not updated
+		query := `UPDATE ` + quoteIdentifier(formatTableName(db, collection)) + ` SET _jsonb = ? WHERE _ferretdb_id = ?`
+		stmt, err := tx.PrepareContext(ctx, query)
+		if err != nil {
+			return queryError(err)
+		}
+		defer stmt.Close()
+
+		for _, doc := range docs {
+			idArg, b, err := marshalDocument(doc)
+			if err != nil {
+				return err
+			}
+
+			res, err := stmt.ExecContext(ctx, b, idArg)
+			if err != nil {
+				return queryError(err)
+			}
+
+			n, err := res.RowsAffected()
+			if err != nil {
+				return lazyerrors.Error(err)
+			}
+
+			updated += n
+		}
+
+		return nil
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	return updated, nil
+}
+
+// DeleteDocumentsByID deletes documents with the given _ids from FerretDB database and collection.
+//
+// It returns the number of deleted documents; 0 if collection does not exist.
+func (mysqlDB *DB) DeleteDocumentsByID(ctx context.Context, db, collection string, ids []any) (int64, error) {
+	exists, err := collectionExists(ctx, mysqlDB.q(), db, collection)
+	if err != nil || !exists {
+		return 0, err
+	}
+
+	var deleted int64
+	err = mysqlDB.inTransaction(ctx, func(tx *sql.Tx) error {
+		// a statement per _id avoids the limit on the number of placeholders
+		query := `DELETE FROM ` + quoteIdentifier(formatTableName(db, collection)) + ` WHERE _ferretdb_id = ?`
+		stmt, err := tx.PrepareContext(ctx, query)
+		if err != nil {
+			return queryError(err)
+		}
+		defer stmt.Close()
+
+		for _, id := range ids {
+			idArg, err := fjson.Marshal(id)
+			if err != nil {
+				return lazyerrors.Error(err)
+			}
+
+			res, err := stmt.ExecContext(ctx, idArg)
+			if err != nil {
+				return queryError(err)
+			}
+
+			n, err := res.RowsAffected()
+			if err != nil {
+				return lazyerrors.Error(err)
+			}
+
+			deleted += n
+		}
+
+		return nil
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	return deleted, nil
+}
+
+// createCollectionForWrite creates FerretDB database and collection for writing documents if they don't exist.
+func (mysqlDB *DB) createCollectionForWrite(ctx context.Context, db, collection string) error {
+	exists, err := collectionExists(ctx, mysqlDB.q(), db, collection)
+	if err != nil || exists {
+		return err
+	}
+
+	if _, err = mysqlDB.CreateCollectionIfNotExist(ctx, db, collection); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// marshalDocument returns FJSON-encoded _id value of the given document and the document itself.
+//
+// The document is returned as a string because MySQL does not accept binary strings for JSON columns.
+func marshalDocument(doc *types.Document) ([]byte, string, error) {
+	id, err := doc.Get("_id")
+	if err != nil {
+		return nil, "", lazyerrors.Error(err)
+	}
+
+	idArg, err := fjson.Marshal(id)
+	if err != nil {
+		return nil, "", lazyerrors.Error(err)
+	}
+
+	b, err := fjson.Marshal(doc)
+	if err != nil {
+		return nil, "", lazyerrors.Error(err)
+	}
+
+	return idArg, string(b), nil
+}
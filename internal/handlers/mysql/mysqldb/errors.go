@@ -0,0 +1,82 @@
+// Copyright 2021 FerretDB Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package mysqldb
+
+import (
+	"database/sql/driver"
+	"errors"
+	"fmt"
+
+	"github.com/go-sql-driver/mysql"
+
+	"github.com/FerretDB/FerretDB/internal/handlers/backend"
+)
+
+func init() {
+	backend.RegisterErrorTranslator(translateError)
+}
+
+// errorMapping represents backend error code and safe message for MySQL error.
+type errorMapping struct {
+	code backend.ErrorCode
+	msg  string
+}
+
+// mysqlErrors maps MySQL and MariaDB error numbers to backend errors.
+var mysqlErrors = map[uint16]errorMapping{
+	errConCount:       {backend.ErrorCodeUnavailable, "MySQL has too many connections"},
+	errServerShutdown: {backend.ErrorCodeUnavailable, "MySQL is shutting down"},
+	errDiskFull:       {backend.ErrorCodeOutOfDiskSpace, "MySQL is out of disk space"},
+	errRecordFileFull: {backend.ErrorCodeOutOfDiskSpace, "MySQL table is full"},
+
+	errLockWaitTimeout: {backend.ErrorCodeWriteConflict, "MySQL lock wait timeout exceeded"},
+	errLockDeadlock:    {backend.ErrorCodeWriteConflict, "MySQL deadlock found"},
+
+	errDupEntry:         {backend.ErrorCodeDuplicateKey, "MySQL duplicate entry"},
+	errQueryInterrupted: {backend.ErrorCodeTimeout, "MySQL query interrupted"},
+	errQueryTimeout:     {backend.ErrorCodeTimeout, "MySQL query timed out"},
+	errStatementTimeout: {backend.ErrorCodeTimeout, "MySQL query timed out"},
+
+	errDBAccessDenied:      {backend.ErrorCodeUnauthorized, "MySQL access denied"},
+	errTableAccessDenied:   {backend.ErrorCodeUnauthorized, "MySQL access denied"},
+	errAccessDenied:        {backend.ErrorCodeAuthenticationFailed, "MySQL access denied"},
+	errOptionPreventsStmt:  {backend.ErrorCodeReadOnly, "MySQL is read-only"},
+	errReadOnlyTransaction: {backend.ErrorCodeReadOnly, "MySQL is read-only"},
+	errReadOnlyMode:        {backend.ErrorCodeReadOnly, "MySQL is read-only"},
+	errBadDB:               {backend.ErrorCodeDatabaseNotExist, "MySQL database does not exist"},
+	errNoSuchTable:         {backend.ErrorCodeCollectionNotExist, "MySQL table does not exist"},
+}
+
+// translateError returns backend error for the error caused by MySQL or its driver, or nil.
+//
+// It implements backend.ErrorTranslator.
+func translateError(err error) *backend.Error {
+	if errors.Is(err, mysql.ErrInvalidConn) || errors.Is(err, driver.ErrBadConn) {
+		return backend.NewError(backend.ErrorCodeUnavailable, "MySQL connection failed", err)
+	}
+
+	var e *mysql.MySQLError
+	if !errors.As(err, &e) {
+		return nil
+	}
+
+	if m, ok := mysqlErrors[e.Number]; ok {
+		return backend.NewError(m.code, m.msg, err)
+	}
+
+	msg := fmt.Sprintf("MySQL error %d", e.Number)
+
+	return backend.NewError(backend.ErrorCodeInternal, msg, err)
+}
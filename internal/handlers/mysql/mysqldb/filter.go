@@ -0,0 +1,115 @@
+// Copyright 2021 FerretDB Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package mysqldb
+
+import (
+	"strings"
+
+	"github.com/FerretDB/FerretDB/internal/fjson"
+	"github.com/FerretDB/FerretDB/internal/types"
+	"github.com/FerretDB/FerretDB/internal/util/must"
+)
+
+// jsonPath returns MySQL JSON path for the given dot notation path as SQL string literal.
+//
+// The path should be checked with validPath first.
+func jsonPath(path string) string {
+	res := "$"
+	for _, e := range strings.Split(path, ".") {
+		res += `."` + e + `"`
+	}
+
+	return `'` + res + `'`
+}
+
+// pathSQL returns SQL expression that extracts the value of the given dot notation path from the document.
+//
+// The path should be checked with validPath first.
+func pathSQL(path string) string {
+	return `JSON_EXTRACT(_jsonb, ` + jsonPath(path) + `)`
+}
+
+// validPath returns true if the given dot notation path could be used with jsonPath and pathSQL.
+//
+// JSON paths in MySQL can't contain double quotes in keys;
+// quotes and backslashes are also rejected to avoid escaping them in SQL string literals.
+func validPath(path string) bool {
+	if strings.HasPrefix(path, "$") || strings.ContainsAny(path, `"'\`) {
+		return false
+	}
+
+	for _, e := range strings.Split(path, ".") {
+		if e == "" {
+			return false
+		}
+	}
+
+	return true
+}
+
+// buildFilter returns SQL condition for WHERE clause and its arguments for the given filter.
+//
+// Only equality of top-level fields to strings and ObjectIDs is handled.
+// The condition matches a superset of documents that match the filter
+// (for example, documents with arrays or symbols in those fields), so the whole filter
+// should still be applied to fetched documents.
+// Empty condition is returned if nothing could be handled.
+func buildFilter(filter *types.Document) (string, []any) {
+	if filter == nil {
+		return "", nil
+	}
+
+	var conditions []string
+	var args []any
+
+	for _, field := range filter.Keys() {
+		if strings.Contains(field, ".") || !validPath(field) {
+			continue
+		}
+
+		value := must.NotFail(filter.Get(field))
+		if ops, ok := value.(*types.Document); ok {
+			if ops.Len() != 1 || ops.Keys()[0] != "$eq" {
+				continue
+			}
+
+			value = must.NotFail(ops.Get("$eq"))
+		}
+
+		switch value.(type) {
+		case string, types.ObjectID:
+		default:
+			continue
+		}
+
+		b := must.NotFail(fjson.Marshal(value))
+
+		// _id values can't be arrays, and they are stored separately with a unique index
+		if field == "_id" {
+			conditions = append(conditions, `_ferretdb_id = ?`)
+			args = append(args, b)
+
+			continue
+		}
+
+		// JSON_CONTAINS matches both equal values and arrays with equal elements, like MongoDB;
+		// objects could be symbols equal to strings
+		cond := `(JSON_CONTAINS(_jsonb, ?, ` + jsonPath(field) + `) OR JSON_TYPE(` + pathSQL(field) + `) = 'OBJECT')`
+		conditions = append(conditions, cond)
+		args = append(args, string(b))
+	}
+
+	return strings.Join(conditions, " AND "), args
+}
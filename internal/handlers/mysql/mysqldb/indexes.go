@@ -0,0 +1,277 @@
+// Copyright 2021 FerretDB Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package mysqldb
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"hash/fnv"
+	"strings"
+
+	"github.com/go-sql-driver/mysql"
+
+	"github.com/FerretDB/FerretDB/internal/fjson"
+	"github.com/FerretDB/FerretDB/internal/handlers/backend"
+	"github.com/FerretDB/FerretDB/internal/types"
+	"github.com/FerretDB/FerretDB/internal/util/lazyerrors"
+	"github.com/FerretDB/FerretDB/internal/util/must"
+)
+
+const (
+	// maxIndexValueLength is the number of characters of indexed values stored in the index.
+	maxIndexValueLength = 128
+
+	// maxIndexKeys is the maximal number of fields in the index key
+	// that fits InnoDB limit of 3072 bytes per index (4 bytes per utf8mb4 character).
+	maxIndexKeys = 3072 / (maxIndexValueLength * 4)
+)
+
+// MySQL and MariaDB error codes for conflicting DDL statements.
+const (
+	errDupFieldName = 1060
+	errDupKeyName   = 1061
+)
+
+// Indexes returns indexes of the given FerretDB collection, the default _id index first.
+//
+// It returns ErrTableNotExist if collection does not exist.
+func (mysqlDB *DB) Indexes(ctx context.Context, db, collection string) ([]backend.Index, error) {
+	indexes, err := getIndexes(ctx, mysqlDB.q(), db, collection, false)
+	if err != nil {
+		return nil, err
+	}
+
+	res := []backend.Index{{
+		Name: "_id_",
+		Key:  must.NotFail(types.NewDocument("_id", int32(1))),
+	}}
+
+	for _, name := range indexes.Keys() {
+		index := must.NotFail(indexes.Get(name)).(*types.Document)
+		res = append(res, backend.Index{
+			Name: name,
+			Key:  must.NotFail(index.Get("key")).(*types.Document),
+		})
+	}
+
+	return res, nil
+}
+
+// CreateIndex creates an index with the given name and key for FerretDB collection.
+//
+// MySQL can't index JSON values directly, so a virtual generated column is added for each field of the key,
+// and the index is created on those columns. Only first maxIndexValueLength characters of values are indexed.
+// Compound keys with up to maxIndexKeys fields with ascending and descending orders are supported;
+// ErrIndexNotSupported is returned for other keys.
+//
+// It returns ErrAlreadyExist if the same index already exists,
+// ErrIndexKeyConflict or ErrIndexNameConflict if a different index with the same name or key exists,
+// and ErrTableNotExist if collection does not exist.
+func (mysqlDB *DB) CreateIndex(ctx context.Context, db, collection, name string, key *types.Document) error {
+	if key.Len() > maxIndexKeys {
+		return backend.ErrIndexNotSupported
+	}
+
+	orders, ok := indexOrders(key)
+	if !ok {
+		return backend.ErrIndexNotSupported
+	}
+
+	if name == "_id_" {
+		return backend.ErrIndexKeyConflict
+	}
+
+	indexes, err := getIndexes(ctx, mysqlDB.q(), db, collection, false)
+	if err != nil {
+		return err
+	}
+
+	if err = checkIndexConflicts(indexes, name, key); err != nil {
+		return err
+	}
+
+	idx := indexName(name)
+
+	clauses := make([]string, 0, key.Len()+1)
+	columns := make([]string, 0, key.Len())
+	for i, path := range key.Keys() {
+		column := quoteIdentifier(fmt.Sprintf("%s_%d", idx, i))
+		expr := fmt.Sprintf(`LEFT(JSON_UNQUOTE(%s), %d)`, pathSQL(path), maxIndexValueLength)
+		clauses = append(clauses, fmt.Sprintf(`ADD COLUMN %s VARCHAR(%d) AS (%s) VIRTUAL`, column, maxIndexValueLength, expr))
+		columns = append(columns, column+orders[i])
+	}
+
+	clauses = append(clauses, `ADD INDEX `+quoteIdentifier(idx)+` (`+strings.Join(columns, ", ")+`)`)
+
+	// DDL statement commits the transaction implicitly, so metadata is updated separately
+	query := `ALTER TABLE ` + quoteIdentifier(formatTableName(db, collection)) + ` ` + strings.Join(clauses, ", ")
+	if _, err = mysqlDB.q().ExecContext(ctx, query); err != nil {
+		var e *mysql.MySQLError
+		if errors.As(err, &e) && (e.Number == errDupFieldName || e.Number == errDupKeyName) {
+			// the same index was created concurrently
+			return ErrAlreadyExist
+		}
+
+		return queryError(err)
+	}
+
+	return mysqlDB.inTransaction(ctx, func(tx *sql.Tx) error {
+		indexes, err := getIndexes(ctx, tx, db, collection, true)
+		if err != nil {
+			return err
+		}
+
+		must.NoError(indexes.Set(name, must.NotFail(types.NewDocument("key", key))))
+
+		b, err := fjson.Marshal(indexes)
+		if err != nil {
+			return lazyerrors.Error(err)
+		}
+
+		query := `UPDATE ` + quoteIdentifier(collectionsTable) + ` SET indexes = ? WHERE db = ? AND name = ?`
+		if _, err = tx.ExecContext(ctx, query, string(b), db, collection); err != nil {
+			return lazyerrors.Error(err)
+		}
+
+		return nil
+	})
+}
+
+// getIndexes returns FJSON document with indexes of the given FerretDB collection from the metadata table.
+//
+// If forUpdate is true, the metadata row is locked until the end of the transaction.
+// It returns ErrTableNotExist if collection does not exist.
+func getIndexes(ctx context.Context, q querier, db, collection string, forUpdate bool) (*types.Document, error) {
+	query := `SELECT indexes FROM ` + quoteIdentifier(collectionsTable) + ` WHERE db = ? AND name = ?`
+	if forUpdate {
+		query += ` FOR UPDATE`
+	}
+
+	var s string
+	if err := q.QueryRowContext(ctx, query, db, collection).Scan(&s); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, ErrTableNotExist
+		}
+
+		return nil, queryError(err)
+	}
+
+	indexes, err := fjson.Unmarshal([]byte(s))
+	if err != nil {
+		return nil, lazyerrors.Error(err)
+	}
+
+	return indexes.(*types.Document), nil
+}
+
+// checkIndexConflicts returns an error if the given index conflicts with existing ones.
+func checkIndexConflicts(indexes *types.Document, name string, key *types.Document) error {
+	for _, existingName := range indexes.Keys() {
+		existing := must.NotFail(indexes.Get(existingName)).(*types.Document)
+		sameKey := sameIndexKey(must.NotFail(existing.Get("key")).(*types.Document), key)
+
+		switch {
+		case existingName == name && sameKey:
+			return ErrAlreadyExist
+		case existingName == name:
+			return backend.ErrIndexKeyConflict
+		case sameKey:
+			return backend.ErrIndexNameConflict
+		}
+	}
+
+	return nil
+}
+
+// indexOrders returns SQL sort orders for fields of the given index key,
+// or false if the index key is not supported.
+//
+// The key should contain at least one field, and all fields should be valid paths other than _id.
+func indexOrders(key *types.Document) ([]string, bool) {
+	if key.Len() == 0 {
+		return nil, false
+	}
+
+	res := make([]string, 0, key.Len())
+
+	for _, path := range key.Keys() {
+		if path == "_id" || !validPath(path) {
+			return nil, false
+		}
+
+		switch sortOrder(must.NotFail(key.Get(path))) {
+		case 1:
+			res = append(res, " ASC")
+		case -1:
+			res = append(res, " DESC")
+		default:
+			return nil, false
+		}
+	}
+
+	return res, true
+}
+
+// sameIndexKey returns true if both index keys have the same paths and directions in the same order.
+func sameIndexKey(a, b *types.Document) bool {
+	aKeys, bKeys := a.Keys(), b.Keys()
+	if len(aKeys) != len(bKeys) {
+		return false
+	}
+
+	for i, path := range aKeys {
+		if path != bKeys[i] {
+			return false
+		}
+
+		if sortOrder(must.NotFail(a.Get(path))) != sortOrder(must.NotFail(b.Get(path))) {
+			return false
+		}
+	}
+
+	return true
+}
+
+// sortOrder returns 1 for ascending and -1 for descending index key values, and 0 for other values.
+func sortOrder(v any) int {
+	switch v := v.(type) {
+	case int32:
+		if v == 1 || v == -1 {
+			return int(v)
+		}
+	case int64:
+		if v == 1 || v == -1 {
+			return int(v)
+		}
+	case float64:
+		if v == 1 || v == -1 {
+			return int(v)
+		}
+	}
+
+	return 0
+}
+
+// indexName returns the name of the MySQL index for the given FerretDB index name.
+//
+// Index names are scoped by tables in MySQL, and generated columns use it as a prefix.
+func indexName(name string) string {
+	hash32 := fnv.New32a()
+	_ = must.NotFail(hash32.Write([]byte(name)))
+
+	return fmt.Sprintf("_ferretdb_idx_%08x", hash32.Sum32())
+}
@@ -0,0 +1,141 @@
+// Copyright 2021 FerretDB Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package mysqldb
+
+import (
+	"context"
+	"database/sql"
+	"io"
+	"strings"
+
+	"github.com/FerretDB/FerretDB/internal/fjson"
+	"github.com/FerretDB/FerretDB/internal/handlers/backend"
+	"github.com/FerretDB/FerretDB/internal/types"
+	"github.com/FerretDB/FerretDB/internal/util/lazyerrors"
+)
+
+// Iterator iterates over documents of FerretDB collection returned by QueryIterator.
+//
+// Iterator must be closed after use; it is not safe for concurrent use.
+type Iterator struct {
+	rows     *sql.Rows
+	residual *types.Document
+	err      error
+}
+
+// QueryIterator returns an iterator over documents for given FerretDB database and collection.
+//
+// Parts of the filter could be handled by MySQL (see buildFilter), but the whole filter
+// is returned as the residual one. Sort is never handled.
+//
+// Passed context is used for all iterator operations;
+// ErrQueryCanceled is returned if it is canceled or its deadline is exceeded.
+// It returns ErrTableNotExist if collection does not exist.
+func (mysqlDB *DB) QueryIterator(ctx context.Context, qp *backend.QueryParams) (backend.Iterator, error) {
+	exists, err := collectionExists(ctx, mysqlDB.q(), qp.DB, qp.Collection)
+	if err != nil {
+		return nil, err
+	}
+
+	if !exists {
+		return nil, ErrTableNotExist
+	}
+
+	query := `SELECT _jsonb `
+	if comment := qp.Comment; comment != "" {
+		comment = strings.ReplaceAll(comment, "/*", "/ *")
+		comment = strings.ReplaceAll(comment, "*/", "* /")
+
+		query += `/* ` + comment + ` */ `
+	}
+
+	query += `FROM ` + quoteIdentifier(formatTableName(qp.DB, qp.Collection))
+
+	where, args := buildFilter(qp.Filter)
+	if where != "" {
+		query += ` WHERE ` + where
+	}
+
+	// InnoDB uses a consistent snapshot for a single statement without a transaction
+	rows, err := mysqlDB.q().QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, queryError(err)
+	}
+
+	iter := &Iterator{
+		rows:     rows,
+		residual: qp.Filter,
+	}
+
+	return iter, nil
+}
+
+// Next returns the next document.
+//
+// It returns io.EOF after the last document.
+// Once an error is returned, all subsequent calls return the same error.
+func (iter *Iterator) Next() (*types.Document, error) {
+	if iter.err != nil {
+		return nil, iter.err
+	}
+
+	if !iter.rows.Next() {
+		if iter.err = iter.rows.Err(); iter.err != nil {
+			iter.err = queryError(iter.err)
+		} else {
+			iter.err = io.EOF
+		}
+
+		return nil, iter.err
+	}
+
+	var s string
+	if err := iter.rows.Scan(&s); err != nil {
+		iter.err = lazyerrors.Error(err)
+		return nil, iter.err
+	}
+
+	doc, err := fjson.Unmarshal([]byte(s))
+	if err != nil {
+		iter.err = lazyerrors.Error(err)
+		return nil, iter.err
+	}
+
+	return doc.(*types.Document), nil
+}
+
+// Residual returns the filter that should be applied to returned documents.
+func (iter *Iterator) Residual() *types.Document {
+	return iter.residual
+}
+
+// Sorted always returns false; documents should be sorted by the caller.
+func (iter *Iterator) Sorted() bool {
+	return false
+}
+
+// Close closes the iterator.
+func (iter *Iterator) Close() error {
+	if err := iter.rows.Close(); err != nil {
+		return lazyerrors.Error(err)
+	}
+
+	return nil
+}
+
+// check interfaces
+var (
+	_ backend.Iterator = (*Iterator)(nil)
+)
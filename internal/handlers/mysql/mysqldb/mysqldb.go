@@ -0,0 +1,410 @@
+// Copyright 2021 FerretDB Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package mysqldb provides MySQL storage for the `mysql` handler (see package generic).
+//
+// All FerretDB databases are stored in a single MySQL database (schema) set in the data source name.
+// Each FerretDB collection is stored in a separate table with a _jsonb column of JSON type
+// that contains documents encoded as FJSON, and a _ferretdb_id column with the encoded _id value
+// that has a unique index. FerretDB databases, collections, and their indexes are tracked in metadata tables.
+//
+// Both MySQL 8 and MariaDB 10.5+ are supported; only SQL and JSON functions available in both are used.
+package mysqldb
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"hash/fnv"
+	"strings"
+
+	"github.com/go-sql-driver/mysql"
+	"go.uber.org/zap"
+
+	"github.com/FerretDB/FerretDB/internal/handlers/backend"
+	"github.com/FerretDB/FerretDB/internal/util/lazyerrors"
+	"github.com/FerretDB/FerretDB/internal/util/must"
+)
+
+const (
+	// databasesTable is the name of the metadata table with FerretDB databases.
+	databasesTable = "_ferretdb_databases"
+
+	// collectionsTable is the name of the metadata table with FerretDB collections and their indexes.
+	collectionsTable = "_ferretdb_collections"
+
+	// tableOptions are options of all created tables.
+	//
+	// Binary collation makes comparisons of names and values case- and accent-sensitive, like in MongoDB.
+	tableOptions = ` ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_bin`
+
+	// maxIDLength is the maximal length in bytes of FJSON-encoded _id value.
+	maxIDLength = 1024
+
+	// maxTransactionAttempts is the maximum number of attempts to run a transaction
+	// that conflicts with concurrent ones.
+	maxTransactionAttempts = 3
+)
+
+// MySQL and MariaDB error codes.
+const (
+	errDupEntry         = 1062
+	errQueryInterrupted = 1317
+	errQueryTimeout     = 3024 // MySQL's max_execution_time
+	errStatementTimeout = 1969 // MariaDB's max_statement_time
+
+	errDiskFull            = 1021
+	errRecordFileFull      = 1114
+	errConCount            = 1040
+	errServerShutdown      = 1053
+	errDBAccessDenied      = 1044
+	errAccessDenied        = 1045
+	errTableAccessDenied   = 1142
+	errBadDB               = 1049
+	errNoSuchTable         = 1146
+	errLockWaitTimeout     = 1205
+	errLockDeadlock        = 1213
+	errOptionPreventsStmt  = 1290 // for example, --read-only
+	errReadOnlyTransaction = 1792
+	errReadOnlyMode        = 1836
+)
+
+// Errors are the same as backend's, so callers could use either.
+var (
+	// ErrTableNotExist indicates that there is no such table (FerretDB collection).
+	ErrTableNotExist = backend.ErrCollectionNotExist
+
+	// ErrDatabaseNotExist indicates that there is no such FerretDB database.
+	ErrDatabaseNotExist = backend.ErrDatabaseNotExist
+
+	// ErrAlreadyExist indicates that a database, collection, or index already exists.
+	ErrAlreadyExist = backend.ErrAlreadyExist
+
+	// ErrQueryCanceled indicates that the query was interrupted by the context cancelation or deadline.
+	ErrQueryCanceled = backend.ErrQueryCanceled
+
+	// ErrDuplicateID indicates that a document with the same _id already exists.
+	ErrDuplicateID = backend.ErrDuplicateID
+)
+
+// DB represents a MySQL database that contains FerretDB databases.
+//
+// It is safe for concurrent use, except for DB passed to InTransaction's function.
+type DB struct {
+	db *sql.DB
+	tx *sql.Tx // set for DB passed to InTransaction's function
+	l  *zap.Logger
+}
+
+// Open connects to MySQL with the given data source name (like `user:password@tcp(host:3306)/ferretdb`)
+// and creates metadata tables if needed.
+//
+// The database (schema) in the data source name should already exist.
+func Open(ctx context.Context, dsn string, l *zap.Logger) (*DB, error) {
+	cfg, err := mysql.ParseDSN(dsn)
+	if err != nil {
+		return nil, fmt.Errorf("mysqldb.Open: %w", err)
+	}
+
+	if cfg.DBName == "" {
+		return nil, fmt.Errorf("mysqldb.Open: database name is not set")
+	}
+
+	cfg.Collation = "utf8mb4_bin"
+
+	connector, err := mysql.NewConnector(cfg)
+	if err != nil {
+		return nil, fmt.Errorf("mysqldb.Open: %w", err)
+	}
+
+	mysqlDB := &DB{
+		db: sql.OpenDB(connector),
+		l:  l,
+	}
+
+	if err = mysqlDB.init(ctx); err != nil {
+		mysqlDB.db.Close()
+		return nil, fmt.Errorf("mysqldb.Open: %w", err)
+	}
+
+	return mysqlDB, nil
+}
+
+// init limits the number of open connections and creates metadata tables if they don't exist.
+//
+// Connections are limited by the server's max_connections value,
+// so FerretDB does not open more connections than the server allows.
+func (mysqlDB *DB) init(ctx context.Context) error {
+	var maxConnections int
+	if err := mysqlDB.db.QueryRowContext(ctx, `SELECT @@max_connections`).Scan(&maxConnections); err != nil {
+		return lazyerrors.Error(err)
+	}
+
+	mysqlDB.db.SetMaxOpenConns(maxConnections)
+
+	query := `CREATE TABLE IF NOT EXISTS ` + quoteIdentifier(databasesTable) + ` (` +
+		`name VARCHAR(255) NOT NULL PRIMARY KEY)` + tableOptions
+	if _, err := mysqlDB.db.ExecContext(ctx, query); err != nil {
+		return lazyerrors.Error(err)
+	}
+
+	// indexes contains FJSON document with index names as keys and {key: <index key>} documents as values
+	query = `CREATE TABLE IF NOT EXISTS ` + quoteIdentifier(collectionsTable) + ` (` +
+		`db VARCHAR(255) NOT NULL, ` +
+		`name VARCHAR(255) NOT NULL, ` +
+		`indexes LONGTEXT NOT NULL, ` +
+		`PRIMARY KEY (db, name))` + tableOptions
+	if _, err := mysqlDB.db.ExecContext(ctx, query); err != nil {
+		return lazyerrors.Error(err)
+	}
+
+	return nil
+}
+
+// Name returns "MySQL".
+func (mysqlDB *DB) Name() string {
+	return "MySQL"
+}
+
+// MaxOpenConns returns the maximum number of open connections to the server.
+func (mysqlDB *DB) MaxOpenConns() int {
+	return mysqlDB.db.Stats().MaxOpenConnections
+}
+
+// Close closes the connection pool.
+func (mysqlDB *DB) Close() {
+	if err := mysqlDB.db.Close(); err != nil {
+		mysqlDB.l.Error("Failed to close MySQL connection pool.", zap.Error(err))
+	}
+}
+
+// Ping checks that the database is accessible.
+func (mysqlDB *DB) Ping(ctx context.Context) error {
+	if err := mysqlDB.db.PingContext(ctx); err != nil {
+		return lazyerrors.Error(err)
+	}
+
+	return nil
+}
+
+// Version returns MySQL server version, like `8.0.29` or `10.8.3-MariaDB`.
+func (mysqlDB *DB) Version(ctx context.Context) (string, error) {
+	var res string
+	if err := mysqlDB.db.QueryRowContext(ctx, `SELECT VERSION()`).Scan(&res); err != nil {
+		return "", lazyerrors.Error(err)
+	}
+
+	return res, nil
+}
+
+// Size returns the size of all tables in the MySQL database in bytes, including indexes.
+//
+// The value is estimated by the storage engine and could be outdated.
+func (mysqlDB *DB) Size(ctx context.Context) (int64, error) {
+	var res int64
+	query := `SELECT CAST(COALESCE(SUM(data_length + index_length), 0) AS SIGNED)` +
+		` FROM information_schema.tables WHERE table_schema = DATABASE()`
+	if err := mysqlDB.db.QueryRowContext(ctx, query).Scan(&res); err != nil {
+		return 0, lazyerrors.Error(err)
+	}
+
+	return res, nil
+}
+
+// InTransaction calls f with DB which methods run in a single serializable transaction.
+//
+// The transaction is retried with f called again if it fails due to a deadlock or a lock wait timeout,
+// up to maxTransactionAttempts attempts in total.
+// Nested transactions use savepoints; they are retried only together with the outermost one.
+//
+// MySQL commits the transaction implicitly before and after DDL statements,
+// so changes made by f before creating or dropping collections and indexes can't be rolled back.
+//
+// It implements backend.Backend.
+func (mysqlDB *DB) InTransaction(ctx context.Context, f func(backend.Backend) error) error {
+	if mysqlDB.tx != nil {
+		return mysqlDB.inSavepoint(ctx, func(tx *sql.Tx) error {
+			return f(mysqlDB)
+		})
+	}
+
+	opts := &sql.TxOptions{Isolation: sql.LevelSerializable}
+
+	var err error
+	for attempt := 1; attempt <= maxTransactionAttempts; attempt++ {
+		err = mysqlDB.beginTx(ctx, opts, func(tx *sql.Tx) error {
+			return f(&DB{db: mysqlDB.db, tx: tx, l: mysqlDB.l})
+		})
+		if !isTransactionConflict(err) {
+			return err
+		}
+
+		mysqlDB.l.Debug("Transaction conflict.", zap.Int("attempt", attempt), zap.Error(err))
+	}
+
+	return err
+}
+
+// q returns the current transaction, if any, or the database.
+func (mysqlDB *DB) q() querier {
+	if mysqlDB.tx != nil {
+		return mysqlDB.tx
+	}
+
+	return mysqlDB.db
+}
+
+// inTransaction uses a transaction to run f.
+//
+// If f returns an error or context is canceled, the transaction is rolled back.
+// Errors are returned as is, so f could return ones defined in this package.
+// DDL statements commit the transaction implicitly, so f should not use them.
+//
+// In DB passed to InTransaction's function, f runs in a savepoint of that transaction.
+func (mysqlDB *DB) inTransaction(ctx context.Context, f func(*sql.Tx) error) error {
+	if mysqlDB.tx != nil {
+		return mysqlDB.inSavepoint(ctx, f)
+	}
+
+	return mysqlDB.beginTx(ctx, nil, f)
+}
+
+// beginTx runs f in a new transaction with the given options; see inTransaction.
+func (mysqlDB *DB) beginTx(ctx context.Context, opts *sql.TxOptions, f func(*sql.Tx) error) (err error) {
+	tx, err := mysqlDB.db.BeginTx(ctx, opts)
+	if err != nil {
+		return queryError(err)
+	}
+
+	defer func() {
+		if err == nil {
+			return
+		}
+
+		if rerr := tx.Rollback(); rerr != nil && !errors.Is(rerr, sql.ErrTxDone) {
+			mysqlDB.l.Error("Failed to roll back transaction.", zap.Error(rerr))
+		}
+	}()
+
+	if err = f(tx); err != nil {
+		return err
+	}
+
+	if err = tx.Commit(); err != nil {
+		return queryError(err)
+	}
+
+	return nil
+}
+
+// inSavepoint runs f in a savepoint of the current transaction, so its changes could be rolled back separately.
+//
+// Savepoints with the same name are nested; the innermost one is released or rolled back.
+func (mysqlDB *DB) inSavepoint(ctx context.Context, f func(*sql.Tx) error) (err error) {
+	tx := mysqlDB.tx
+
+	if _, err = tx.ExecContext(ctx, `SAVEPOINT ferretdb`); err != nil {
+		return queryError(err)
+	}
+
+	if err = f(tx); err != nil {
+		if _, rerr := tx.ExecContext(ctx, `ROLLBACK TO SAVEPOINT ferretdb`); rerr != nil {
+			mysqlDB.l.Error("Failed to roll back to savepoint.", zap.Error(rerr))
+			return err
+		}
+	}
+
+	if _, rerr := tx.ExecContext(ctx, `RELEASE SAVEPOINT ferretdb`); rerr != nil && err == nil {
+		err = queryError(rerr)
+	}
+
+	return err
+}
+
+// isTransactionConflict returns true if the transaction failed due to concurrent ones and could be retried.
+func isTransactionConflict(err error) bool {
+	var e *mysql.MySQLError
+	return errors.As(err, &e) && (e.Number == errLockDeadlock || e.Number == errLockWaitTimeout)
+}
+
+// queryError converts errors caused by the context cancelation, deadline, or server-side timeouts
+// to ErrQueryCanceled, and wraps other errors with lazyerrors.
+func queryError(err error) error {
+	if errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) {
+		return ErrQueryCanceled
+	}
+
+	var e *mysql.MySQLError
+	if errors.As(err, &e) {
+		switch e.Number {
+		case errQueryInterrupted, errQueryTimeout, errStatementTimeout:
+			return ErrQueryCanceled
+		}
+	}
+
+	return lazyerrors.Error(err)
+}
+
+// isUniqueViolation returns true if err is caused by the unique constraint violation.
+func isUniqueViolation(err error) bool {
+	var e *mysql.MySQLError
+	return errors.As(err, &e) && e.Number == errDupEntry
+}
+
+// quoteIdentifier quotes the given MySQL identifier.
+func quoteIdentifier(s string) string {
+	return "`" + strings.ReplaceAll(s, "`", "``") + "`"
+}
+
+// formatTableName returns the name of the table for the given FerretDB database and collection.
+//
+// MySQL table names could be case-insensitive depending on the platform and lower_case_table_names,
+// are limited to 64 characters, and collection names could contain any characters,
+// so the name consists of the sanitized database and collection names and a hash of the original ones.
+func formatTableName(db, collection string) string {
+	hash32 := fnv.New32a()
+	_ = must.NotFail(hash32.Write([]byte(db + "." + collection)))
+
+	name := strings.Map(func(r rune) rune {
+		switch {
+		case r >= 'a' && r <= 'z', r >= '0' && r <= '9':
+			return r
+		case r >= 'A' && r <= 'Z':
+			return r - 'A' + 'a'
+		default:
+			return '_'
+		}
+	}, db+"_"+collection)
+
+	if len(name) > 50 {
+		name = name[:50]
+	}
+
+	return fmt.Sprintf("%s_%08x", name, hash32.Sum32())
+}
+
+// querier is implemented by *sql.DB and *sql.Tx.
+type querier interface {
+	ExecContext(ctx context.Context, query string, args ...any) (sql.Result, error)
+	QueryContext(ctx context.Context, query string, args ...any) (*sql.Rows, error)
+	QueryRowContext(ctx context.Context, query string, args ...any) *sql.Row
+}
+
+// check interfaces
+var (
+	_ querier         = (*sql.DB)(nil)
+	_ querier         = (*sql.Tx)(nil)
+	_ backend.Backend = (*DB)(nil)
+)
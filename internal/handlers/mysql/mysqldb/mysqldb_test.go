@@ -0,0 +1,278 @@
+// Copyright 2021 FerretDB Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Use _test package to avoid import cycle with testutil.
+package mysqldb_test
+
+import (
+	"io"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap/zaptest"
+
+	"github.com/FerretDB/FerretDB/internal/handlers/backend"
+	"github.com/FerretDB/FerretDB/internal/handlers/mysql/mysqldb"
+	"github.com/FerretDB/FerretDB/internal/types"
+	"github.com/FerretDB/FerretDB/internal/util/must"
+	"github.com/FerretDB/FerretDB/internal/util/testutil"
+)
+
+// testDSN is the data source name of MySQL database for testing; see docker-compose.yml.
+const testDSN = "root@tcp(127.0.0.1:3306)/ferretdb"
+
+// setup connects to MySQL and returns FerretDB database name for that test.
+//
+// The database is dropped before and after the test.
+func setup(t *testing.T) (*mysqldb.DB, string) {
+	t.Helper()
+
+	if testing.Short() {
+		t.Skip("skipping in -short mode")
+	}
+
+	ctx := testutil.Ctx(t)
+
+	db, err := mysqldb.Open(ctx, testDSN, zaptest.NewLogger(t))
+	require.NoError(t, err)
+	t.Cleanup(db.Close)
+
+	dbName := testutil.SchemaName(t)
+
+	dropDatabase := func() {
+		err := db.DropDatabase(ctx, dbName)
+		if err != mysqldb.ErrDatabaseNotExist {
+			require.NoError(t, err)
+		}
+	}
+
+	dropDatabase()
+	t.Cleanup(dropDatabase)
+
+	return db, dbName
+}
+
+// queryAll returns all documents matching the given filter.
+func queryAll(t *testing.T, db *mysqldb.DB, dbName, collection string, filter *types.Document) []*types.Document {
+	t.Helper()
+
+	iter, err := db.QueryIterator(testutil.Ctx(t), &backend.QueryParams{
+		DB:         dbName,
+		Collection: collection,
+		Filter:     filter,
+	})
+	require.NoError(t, err)
+	defer iter.Close()
+
+	var res []*types.Document
+	for {
+		doc, err := iter.Next()
+		if err == io.EOF {
+			return res
+		}
+		require.NoError(t, err)
+
+		res = append(res, doc)
+	}
+}
+
+func TestOpenWithoutDatabase(t *testing.T) {
+	t.Parallel()
+
+	_, err := mysqldb.Open(testutil.Ctx(t), "root@tcp(127.0.0.1:3306)/", zaptest.NewLogger(t))
+	require.Error(t, err)
+}
+
+func TestCollections(t *testing.T) {
+	t.Parallel()
+
+	ctx := testutil.Ctx(t)
+	db, dbName := setup(t)
+
+	err := db.CreateCollection(ctx, dbName, "test")
+	require.Equal(t, mysqldb.ErrDatabaseNotExist, err)
+
+	require.NoError(t, db.CreateDatabase(ctx, dbName))
+	require.Equal(t, mysqldb.ErrAlreadyExist, db.CreateDatabase(ctx, dbName))
+
+	// collection names are case-sensitive
+	require.NoError(t, db.CreateCollection(ctx, dbName, "test"))
+	require.NoError(t, db.CreateCollection(ctx, dbName, "Test"))
+	require.Equal(t, mysqldb.ErrAlreadyExist, db.CreateCollection(ctx, dbName, "test"))
+
+	created, err := db.CreateCollectionIfNotExist(ctx, dbName, "test")
+	require.NoError(t, err)
+	assert.False(t, created)
+
+	created, err = db.CreateCollectionIfNotExist(ctx, dbName, "test.other")
+	require.NoError(t, err)
+	assert.True(t, created)
+
+	collections, err := db.Collections(ctx, dbName)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"Test", "test", "test.other"}, collections)
+
+	databases, err := db.Databases(ctx)
+	require.NoError(t, err)
+	assert.Contains(t, databases, dbName)
+
+	require.NoError(t, db.DropCollection(ctx, dbName, "Test"))
+	require.Equal(t, mysqldb.ErrTableNotExist, db.DropCollection(ctx, dbName, "Test"))
+	require.Equal(t, mysqldb.ErrDatabaseNotExist, db.DropCollection(ctx, "nodb", "test"))
+
+	exists, err := db.CollectionExists(ctx, dbName, "Test")
+	require.NoError(t, err)
+	assert.False(t, exists)
+
+	require.NoError(t, db.DropDatabase(ctx, dbName))
+	require.Equal(t, mysqldb.ErrDatabaseNotExist, db.DropDatabase(ctx, dbName))
+
+	collections, err = db.Collections(ctx, dbName)
+	require.NoError(t, err)
+	assert.Empty(t, collections)
+}
+
+func TestDocuments(t *testing.T) {
+	t.Parallel()
+
+	ctx := testutil.Ctx(t)
+	db, dbName := setup(t)
+
+	id := types.NewObjectID()
+	doc1 := must.NotFail(types.NewDocument("_id", id, "v", "foo"))
+	doc2 := must.NotFail(types.NewDocument("_id", "2", "v", must.NotFail(types.NewArray("foo", "bar"))))
+	doc3 := must.NotFail(types.NewDocument("_id", int32(3), "v", "bar"))
+
+	require.NoError(t, db.InsertDocuments(ctx, dbName, "test", []*types.Document{doc1, doc2}))
+
+	err := db.InsertDocuments(ctx, dbName, "test", []*types.Document{doc3, doc1})
+	require.Equal(t, mysqldb.ErrDuplicateID, err)
+	assert.Len(t, queryAll(t, db, dbName, "test", nil), 2, "documents should be inserted all or none")
+
+	inserted, err := db.InsertDocumentIfNotExists(ctx, dbName, "test", doc1)
+	require.NoError(t, err)
+	assert.False(t, inserted)
+
+	inserted, err = db.InsertDocumentIfNotExists(ctx, dbName, "test", doc3)
+	require.NoError(t, err)
+	assert.True(t, inserted)
+
+	t.Run("Filter", func(t *testing.T) {
+		res := queryAll(t, db, dbName, "test", must.NotFail(types.NewDocument("_id", id)))
+		require.Len(t, res, 1)
+		testutil.AssertEqual(t, doc1, res[0])
+
+		// superset of matching documents is returned for arrays
+		res = queryAll(t, db, dbName, "test", must.NotFail(types.NewDocument("v", "foo")))
+		require.Len(t, res, 2)
+
+		res = queryAll(t, db, dbName, "test", must.NotFail(types.NewDocument("v", must.NotFail(types.NewDocument("$eq", "bar")))))
+		require.Len(t, res, 2)
+
+		// not handled
+		res = queryAll(t, db, dbName, "test", must.NotFail(types.NewDocument("_id", int32(3))))
+		require.Len(t, res, 3)
+	})
+
+	replacement := must.NotFail(types.NewDocument("_id", id, "v", "baz"))
+	updated, err := db.SetDocumentByID(ctx, dbName, "test", id, replacement)
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), updated)
+
+	res := queryAll(t, db, dbName, "test", must.NotFail(types.NewDocument("_id", id)))
+	require.Len(t, res, 1)
+	testutil.AssertEqual(t, replacement, res[0])
+
+	deleted, err := db.DeleteDocumentsByID(ctx, dbName, "test", []any{id, int32(3), "none"})
+	require.NoError(t, err)
+	assert.Equal(t, int64(2), deleted)
+
+	deleted, err = db.DeleteDocumentsByID(ctx, dbName, "nocollection", []any{id})
+	require.NoError(t, err)
+	assert.Equal(t, int64(0), deleted)
+
+	res = queryAll(t, db, dbName, "test", nil)
+	require.Len(t, res, 1)
+	testutil.AssertEqual(t, doc2, res[0])
+
+	_, err = db.QueryIterator(ctx, &backend.QueryParams{DB: dbName, Collection: "nocollection"})
+	require.Equal(t, mysqldb.ErrTableNotExist, err)
+}
+
+func TestIndexes(t *testing.T) {
+	t.Parallel()
+
+	ctx := testutil.Ctx(t)
+	db, dbName := setup(t)
+
+	err := db.CreateIndex(ctx, dbName, "test", "v_1", must.NotFail(types.NewDocument("v", int32(1))))
+	require.Equal(t, mysqldb.ErrTableNotExist, err)
+
+	_, err = db.CreateCollectionIfNotExist(ctx, dbName, "test")
+	require.NoError(t, err)
+
+	key := must.NotFail(types.NewDocument("v", int32(1), "w.x", float64(-1)))
+	require.NoError(t, db.CreateIndex(ctx, dbName, "test", "v_1_w.x_-1", key))
+
+	for name, tc := range map[string]struct {
+		name     string
+		key      *types.Document
+		expected error
+	}{
+		"Same": {
+			name:     "v_1_w.x_-1",
+			key:      must.NotFail(types.NewDocument("v", int64(1), "w.x", int32(-1))),
+			expected: mysqldb.ErrAlreadyExist,
+		},
+		"SameName": {
+			name:     "v_1_w.x_-1",
+			key:      must.NotFail(types.NewDocument("v", int32(1))),
+			expected: backend.ErrIndexKeyConflict,
+		},
+		"SameKey": {
+			name:     "other",
+			key:      key,
+			expected: backend.ErrIndexNameConflict,
+		},
+		"ID": {
+			name:     "_id_",
+			key:      must.NotFail(types.NewDocument("v", int32(-1))),
+			expected: backend.ErrIndexKeyConflict,
+		},
+		"Text": {
+			name:     "v_text",
+			key:      must.NotFail(types.NewDocument("v", "text")),
+			expected: backend.ErrIndexNotSupported,
+		},
+	} {
+		name, tc := name, tc
+		t.Run(name, func(t *testing.T) {
+			err := db.CreateIndex(ctx, dbName, "test", tc.name, tc.key)
+			require.Equal(t, tc.expected, err)
+		})
+	}
+
+	indexes, err := db.Indexes(ctx, dbName, "test")
+	require.NoError(t, err)
+	require.Len(t, indexes, 2)
+	assert.Equal(t, "_id_", indexes[0].Name)
+	assert.Equal(t, "v_1_w.x_-1", indexes[1].Name)
+	testutil.AssertEqual(t, key, indexes[1].Key)
+
+	require.NoError(t, db.DropCollection(ctx, dbName, "test"))
+
+	_, err = db.Indexes(ctx, dbName, "test")
+	require.Equal(t, mysqldb.ErrTableNotExist, err)
+}
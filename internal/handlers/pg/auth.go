@@ -0,0 +1,183 @@
+// Copyright 2021 FerretDB Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package pg
+
+import (
+	"context"
+	"sync"
+
+	"go.uber.org/zap"
+
+	"github.com/FerretDB/FerretDB/internal/clientconn/conninfo"
+	"github.com/FerretDB/FerretDB/internal/handlers/common"
+	"github.com/FerretDB/FerretDB/internal/handlers/pg/pgdb"
+	"github.com/FerretDB/FerretDB/internal/util/lazyerrors"
+)
+
+// AuthMode represents a way clients are authenticated.
+type AuthMode string
+
+const (
+	// AuthModeFerretDB uses users created by createUser command and SCRAM mechanisms.
+	// All clients share a single PostgreSQL connection pool.
+	AuthModeFerretDB AuthMode = "ferretdb"
+
+	// AuthModePassthrough uses username and password received via SASL PLAIN mechanism
+	// to open a separate PostgreSQL connection pool for each user,
+	// so PostgreSQL roles and grants control access.
+	// Clients have to authenticate before running any commands that access data.
+	AuthModePassthrough AuthMode = "passthrough"
+
+	// AuthModeLDAP checks username and password received via SASL PLAIN mechanism against LDAP server.
+	// Roles are granted by LDAP group membership; clients have to authenticate before running any commands
+	// other than handshake ones, and commands are allowed only by granted roles (see common.CheckRoles).
+	// All clients share a single PostgreSQL connection pool.
+	AuthModeLDAP AuthMode = "ldap"
+)
+
+// AllAuthModes includes all authentication modes, with the first one being the default.
+var AllAuthModes = []AuthMode{AuthModeFerretDB, AuthModePassthrough, AuthModeLDAP}
+
+// userPools stores per-user PostgreSQL connection pools opened with users' credentials for AuthModePassthrough.
+//
+// Pools are never replaced or closed before the handler is closed,
+// as requests of authenticated clients could use them at any time.
+type userPools struct {
+	rw    sync.RWMutex
+	pools map[string]*pgdb.Pool
+}
+
+// pool returns PostgreSQL connection pool for the current client connection.
+//
+// In AuthModePassthrough, it returns a pool of the authenticated user, or Unauthorized error
+// if client did not authenticate yet. In tenant roles mode, it returns tenantPool.
+//
+// If PostgreSQL is considered unreachable, it returns HostUnreachable error.
+func (h *Handler) pool(ctx context.Context) (*pgdb.Pool, error) {
+	if err := h.checkHealth(); err != nil {
+		return nil, err
+	}
+
+	if h.tenantRoles {
+		return h.tenantPool(ctx)
+	}
+
+	if h.authMode != AuthModePassthrough {
+		return h.pgPool, nil
+	}
+
+	username, _ := conninfo.GetConnInfo(ctx).Auth()
+	if username == "" {
+		return nil, common.NewErrorMsg(common.ErrUnauthorized, "Command requires authentication")
+	}
+
+	h.userPools.rw.RLock()
+	defer h.userPools.rw.RUnlock()
+
+	pool := h.userPools.pools[username]
+	if pool == nil {
+		// that should not be possible as pools are never removed
+		return nil, lazyerrors.Errorf("no connection pool for user %q", username)
+	}
+
+	return pool, nil
+}
+
+// authenticatePassthrough checks username and password by establishing a new PostgreSQL connection with them.
+//
+// PostgreSQL is asked on every call, so changed or revoked passwords are not accepted.
+// The first successful call for the user opens its connection pool;
+// later calls change the password of new connections of that pool (see pgdb.Pool.Authenticate).
+//
+// It implements common.PLAINAuthenticator.
+func (h *Handler) authenticatePassthrough(ctx context.Context, username, password string) ([]string, error) {
+	h.userPools.rw.RLock()
+	pool := h.userPools.pools[username]
+	h.userPools.rw.RUnlock()
+
+	if pool != nil {
+		if err := pool.Authenticate(ctx, password); err != nil {
+			return nil, lazyerrors.Error(err)
+		}
+
+		return nil, nil
+	}
+
+	opts := *h.poolOpts
+	opts.Username = username
+	opts.Password = password
+
+	pool, err := pgdb.NewPool(ctx, h.connString, h.l, &opts)
+	if err != nil {
+		if pool != nil {
+			pool.Close()
+		}
+		return nil, lazyerrors.Error(err)
+	}
+
+	h.userPools.rw.Lock()
+	defer h.userPools.rw.Unlock()
+
+	// another request could open it while we were waiting for the lock;
+	// our pool was not used yet, so it could be closed right away
+	if h.userPools.pools[username] != nil {
+		pool.Close()
+		return nil, nil
+	}
+
+	h.userPools.pools[username] = pool
+
+	h.l.Info("Opened connection pool for user", zap.String("username", username))
+
+	return nil, nil
+}
+
+// authenticateLDAP checks username and password against LDAP server.
+//
+// It implements common.PLAINAuthenticator.
+func (h *Handler) authenticateLDAP(ctx context.Context, username, password string) ([]string, error) {
+	roles, err := h.ldap.Authenticate(ctx, username, password)
+	if err != nil {
+		return nil, lazyerrors.Error(err)
+	}
+
+	h.l.Debug("Authenticated LDAP user", zap.String("username", username), zap.Strings("roles", roles))
+
+	return roles, nil
+}
+
+// all returns all opened per-user connection pools.
+func (up *userPools) all() []*pgdb.Pool {
+	up.rw.RLock()
+	defer up.rw.RUnlock()
+
+	res := make([]*pgdb.Pool, 0, len(up.pools))
+	for _, p := range up.pools {
+		res = append(res, p)
+	}
+
+	return res
+}
+
+// closeUserPools closes all per-user connection pools.
+func (h *Handler) closeUserPools() {
+	h.userPools.rw.Lock()
+	defer h.userPools.rw.Unlock()
+
+	for username, pool := range h.userPools.pools {
+		pool.Close()
+		delete(h.userPools.pools, username)
+	}
+}
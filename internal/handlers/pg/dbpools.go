@@ -0,0 +1,195 @@
+// Copyright 2021 FerretDB Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package pg
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"go.uber.org/zap"
+
+	"github.com/FerretDB/FerretDB/internal/handlers/backend"
+	"github.com/FerretDB/FerretDB/internal/handlers/common"
+	"github.com/FerretDB/FerretDB/internal/handlers/pg/pgdb"
+	"github.com/FerretDB/FerretDB/internal/util/lazyerrors"
+)
+
+// dbPoolMinIdleTime is the minimal time since the last use of a per-database pool
+// after which it could be closed to open a pool for another database.
+const dbPoolMinIdleTime = time.Minute
+
+// dbPools stores per-database PostgreSQL connection pools.
+type dbPools struct {
+	rw    sync.RWMutex
+	pools map[string]*pgdb.Pool
+
+	// last use time of pools in Unix nanoseconds, accessed atomically;
+	// tracked only for per-database pools (see dbPool)
+	lastUsed map[string]*int64
+}
+
+// dbPool returns PostgreSQL connection pool for queries to the given database (PostgreSQL schema).
+//
+// If per-database pools are enabled, a separate pool is opened for each database on first use,
+// so queries to one database can't take all connections from queries to others.
+// Otherwise, it returns the same pool as pool.
+//
+// At most NewOpts.MaxDatabasePools per-database pools are open at the same time.
+// When that limit is reached, the least recently used idle pool is closed (see closeIdleDBPool);
+// if there are no such pools, ErrRateLimitExceeded protocol error is returned.
+func (h *Handler) dbPool(ctx context.Context, db string) (*pgdb.Pool, error) {
+	if !h.perDatabasePools {
+		return h.pool(ctx)
+	}
+
+	if err := h.checkHealth(); err != nil {
+		return nil, err
+	}
+
+	h.dbPools.rw.RLock()
+	pool := h.dbPools.pools[db]
+	if pool != nil {
+		atomic.StoreInt64(h.dbPools.lastUsed[db], time.Now().UnixNano())
+	}
+	h.dbPools.rw.RUnlock()
+
+	if pool != nil {
+		return pool, nil
+	}
+
+	h.dbPools.rw.Lock()
+	defer h.dbPools.rw.Unlock()
+
+	// another request could open it while we were waiting for the lock
+	if pool = h.dbPools.pools[db]; pool != nil {
+		atomic.StoreInt64(h.dbPools.lastUsed[db], time.Now().UnixNano())
+		return pool, nil
+	}
+
+	if h.maxDatabasePools > 0 && len(h.dbPools.pools) >= h.maxDatabasePools && !h.closeIdleDBPool() {
+		msg := fmt.Sprintf("Too many databases in use: all %d connection pools are busy", h.maxDatabasePools)
+		return nil, common.NewErrorMsg(common.ErrRateLimitExceeded, msg)
+	}
+
+	// PostgreSQL settings were checked by the shared pool
+	opts := *h.poolOpts
+	opts.Lazy = true
+
+	pool, err := pgdb.NewPool(ctx, h.connString, h.l, &opts)
+	if err != nil {
+		return nil, lazyerrors.Error(err)
+	}
+
+	lastUsed := time.Now().UnixNano()
+	h.dbPools.pools[db] = pool
+	h.dbPools.lastUsed[db] = &lastUsed
+
+	h.l.Info("Opened connection pool for database", zap.String("db", db))
+
+	return pool, nil
+}
+
+// closeIdleDBPool closes the least recently used per-database pool that is idle, if any.
+// It returns true if the pool was closed.
+//
+// The pool is idle if it was not returned by dbPool for dbPoolMinIdleTime
+// and none of its connections are in use, for example, by cursors.
+//
+// h.dbPools.rw should be locked for writing.
+func (h *Handler) closeIdleDBPool() bool {
+	idleSince := time.Now().Add(-dbPoolMinIdleTime).UnixNano()
+
+	var lruDB string
+	var lruUsed int64
+
+	for db, pool := range h.dbPools.pools {
+		used := atomic.LoadInt64(h.dbPools.lastUsed[db])
+		if used > idleSince || pool.Stat().AcquiredConns() > 0 {
+			continue
+		}
+
+		if lruDB == "" || used < lruUsed {
+			lruDB, lruUsed = db, used
+		}
+	}
+
+	if lruDB == "" {
+		return false
+	}
+
+	h.dbPools.pools[lruDB].Close()
+	delete(h.dbPools.pools, lruDB)
+	delete(h.dbPools.lastUsed, lruDB)
+
+	h.l.Info("Closed idle connection pool for database", zap.String("db", lruDB))
+
+	return true
+}
+
+// dbBackend returns the storage backend for queries to the given database; see dbPool.
+//
+// It is used by the generic handler; see generic.NewOpts.DBBackend.
+func (h *Handler) dbBackend(ctx context.Context, db string) (backend.Backend, error) {
+	pool, err := h.dbPool(ctx, db)
+	if err != nil {
+		return nil, err
+	}
+
+	return pool, nil
+}
+
+// readBackend returns the storage backend for read-only queries to the given database
+// with the given read preference mode.
+//
+// If read preference allows reading from secondaries and there are read replicas,
+// one of them is returned (in round-robin order). Otherwise, it returns the same backend as dbBackend.
+//
+// It is used by the generic handler; see generic.NewOpts.ReadBackend.
+func (h *Handler) readBackend(ctx context.Context, db string, mode common.ReadPreferenceMode) (backend.Backend, error) {
+	if len(h.replicas) == 0 || !mode.SecondaryOK() {
+		return h.dbBackend(ctx, db)
+	}
+
+	n := atomic.AddUint32(&h.replicaNext, 1)
+	return h.replicas[n%uint32(len(h.replicas))], nil
+}
+
+// all returns all opened per-database connection pools.
+func (dp *dbPools) all() []*pgdb.Pool {
+	dp.rw.RLock()
+	defer dp.rw.RUnlock()
+
+	res := make([]*pgdb.Pool, 0, len(dp.pools))
+	for _, pool := range dp.pools {
+		res = append(res, pool)
+	}
+
+	return res
+}
+
+// closeDBPools closes all per-database connection pools.
+func (h *Handler) closeDBPools() {
+	h.dbPools.rw.Lock()
+	defer h.dbPools.rw.Unlock()
+
+	for db, pool := range h.dbPools.pools {
+		pool.Close()
+		delete(h.dbPools.pools, db)
+		delete(h.dbPools.lastUsed, db)
+	}
+}
@@ -0,0 +1,89 @@
+// Copyright 2021 FerretDB Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package pg
+
+import (
+	"context"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap/zaptest"
+
+	"github.com/FerretDB/FerretDB/internal/handlers/common"
+	"github.com/FerretDB/FerretDB/internal/handlers/pg/pgdb"
+)
+
+func TestDBPoolsLimit(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+
+	// pools are lazy, so PostgreSQL is not accessed
+	h := &Handler{
+		l:                zaptest.NewLogger(t),
+		connString:       "postgres://127.0.0.1:1/ferretdb",
+		poolOpts:         new(pgdb.NewPoolOpts),
+		perDatabasePools: true,
+		maxDatabasePools: 2,
+		dbPools: dbPools{
+			pools:    map[string]*pgdb.Pool{},
+			lastUsed: map[string]*int64{},
+		},
+	}
+	t.Cleanup(h.closeDBPools)
+
+	// makeIdle marks the pool of the given database as unused for a while.
+	makeIdle := func(db string, ago time.Duration) {
+		atomic.StoreInt64(h.dbPools.lastUsed[db], time.Now().Add(-ago).UnixNano())
+	}
+
+	poolA, err := h.dbPool(ctx, "a")
+	require.NoError(t, err)
+	_, err = h.dbPool(ctx, "b")
+	require.NoError(t, err)
+
+	pool, err := h.dbPool(ctx, "a")
+	require.NoError(t, err)
+	assert.Same(t, poolA, pool)
+
+	t.Run("Busy", func(t *testing.T) {
+		_, err := h.dbPool(ctx, "c")
+		var e *common.Error
+		require.ErrorAs(t, err, &e)
+		assert.Equal(t, common.ErrRateLimitExceeded, e.Code())
+		assert.Len(t, h.dbPools.all(), 2)
+	})
+
+	t.Run("LeastRecentlyUsed", func(t *testing.T) {
+		makeIdle("a", 2*dbPoolMinIdleTime)
+		makeIdle("b", 3*dbPoolMinIdleTime)
+
+		poolC, err := h.dbPool(ctx, "c")
+		require.NoError(t, err)
+		assert.ElementsMatch(t, []*pgdb.Pool{poolA, poolC}, h.dbPools.all())
+		assert.NotContains(t, h.dbPools.lastUsed, "b")
+
+		// using the pool again prevents closing it
+		makeIdle("a", 2*dbPoolMinIdleTime)
+		_, err = h.dbPool(ctx, "a")
+		require.NoError(t, err)
+
+		_, err = h.dbPool(ctx, "b")
+		require.Error(t, err)
+	})
+}
@@ -0,0 +1,70 @@
+// Copyright 2021 FerretDB Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package pg
+
+import (
+	"context"
+	"time"
+
+	"go.uber.org/zap"
+
+	"github.com/FerretDB/FerretDB/internal/handlers/common"
+)
+
+// resetPoolsTimeout is the maximum duration of closing idle connections of all pools after PostgreSQL recovery.
+const resetPoolsTimeout = 5 * time.Second
+
+// checkHealth returns HostUnreachable error if PostgreSQL is considered unreachable by the health checker,
+// so requests fail fast instead of waiting for connection or query timeouts.
+func (h *Handler) checkHealth() error {
+	if err := h.health.Err(); err != nil {
+		return common.NewErrorMsg(common.ErrHostUnreachable, err.Error())
+	}
+
+	return nil
+}
+
+// resetPools closes idle connections of per-user, per-database, and per-tenant pools after PostgreSQL recovery;
+// the shared pool is reset by the health checker itself.
+//
+// Read replicas are not probed, so their pools are not reset.
+func (h *Handler) resetPools() {
+	ctx, cancel := context.WithTimeout(context.Background(), resetPoolsTimeout)
+	defer cancel()
+
+	var closed int
+
+	h.userPools.rw.RLock()
+	for _, pool := range h.userPools.pools {
+		closed += pool.CloseIdleConns(ctx)
+	}
+	h.userPools.rw.RUnlock()
+
+	h.dbPools.rw.RLock()
+	for _, pool := range h.dbPools.pools {
+		closed += pool.CloseIdleConns(ctx)
+	}
+	h.dbPools.rw.RUnlock()
+
+	h.tenantPools.rw.RLock()
+	for _, pool := range h.tenantPools.pools {
+		closed += pool.CloseIdleConns(ctx)
+	}
+	h.tenantPools.rw.RUnlock()
+
+	if closed > 0 {
+		h.l.Info("Closed idle connections of other pools.", zap.Int("closed_conns", closed))
+	}
+}
@@ -26,6 +26,11 @@ import (
 
 // MsgCollStats implements HandlerInterface.
 func (h *Handler) MsgCollStats(ctx context.Context, msg *wire.OpMsg) (*wire.OpMsg, error) {
+	pgPool, err := h.pool(ctx)
+	if err != nil {
+		return nil, err
+	}
+
 	document, err := msg.Document()
 	if err != nil {
 		return nil, lazyerrors.Error(err)
@@ -41,7 +46,7 @@ func (h *Handler) MsgCollStats(ctx context.Context, msg *wire.OpMsg) (*wire.OpMs
 		return nil, err
 	}
 
-	stats, err := h.pgPool.SchemaStats(ctx, db, collection)
+	stats, err := pgPool.SchemaStats(ctx, db, collection)
 	if err != nil {
 		return nil, lazyerrors.Error(err)
 	}
@@ -17,7 +17,9 @@ package pg
 import (
 	"context"
 	"fmt"
+	"math"
 
+	"github.com/FerretDB/FerretDB/internal/handlers/backend"
 	"github.com/FerretDB/FerretDB/internal/handlers/common"
 	"github.com/FerretDB/FerretDB/internal/handlers/pg/pgdb"
 	"github.com/FerretDB/FerretDB/internal/types"
@@ -35,8 +37,6 @@ func (h *Handler) MsgCreate(ctx context.Context, msg *wire.OpMsg) (*wire.OpMsg,
 
 	unimplementedFields := []string{
 		"capped",
-		"timeseries",
-		"expireAfterSeconds",
 		"size",
 		"max",
 		"validator",
@@ -68,12 +68,49 @@ func (h *Handler) MsgCreate(ctx context.Context, msg *wire.OpMsg) (*wire.OpMsg,
 		return nil, err
 	}
 
-	if err := h.pgPool.CreateDatabase(ctx, db); err != nil && err != pgdb.ErrAlreadyExist {
+	pgPool, err := h.dbPool(ctx, db)
+	if err != nil {
+		return nil, err
+	}
+
+	params, err := common.GetCreateParams(document)
+	if err != nil {
+		return nil, err
+	}
+
+	partitioning, err := getPartitioning(document)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := pgPool.CreateDatabase(ctx, db); err != nil && err != backend.ErrAlreadyExist {
+		if err == pgdb.ErrInvalidDatabaseName {
+			msg := fmt.Sprintf("Invalid namespace specified '%s.%s': database name is too long", db, collection)
+			return nil, common.NewErrorMsg(common.ErrInvalidNamespace, msg)
+		}
 		return nil, lazyerrors.Error(err)
 	}
 
-	if err = h.pgPool.CreateCollection(ctx, db, collection); err != nil {
-		if err == pgdb.ErrAlreadyExist {
+	createParams := &pgdb.CreateCollectionParams{
+		Partitioning:   partitioning,
+		ClusteredIndex: params.ClusteredIndex,
+	}
+
+	if ts := params.Timeseries; ts != nil {
+		if partitioning != nil {
+			return nil, common.NewErrorMsg(common.ErrInvalidOptions, "Time-series collections cannot be partitioned")
+		}
+
+		createParams.Timeseries = &pgdb.Timeseries{
+			TimeField:   ts.TimeField,
+			MetaField:   ts.MetaField,
+			Granularity: pgdb.TimeseriesGranularity(ts.Granularity),
+		}
+	}
+
+	err = pgPool.CreateCollectionWithParams(ctx, db, collection, createParams)
+	if err != nil {
+		if err == backend.ErrAlreadyExist {
 			msg := fmt.Sprintf("Collection already exists. NS: %s.%s", db, collection)
 			return nil, common.NewErrorMsg(common.ErrNamespaceExists, msg)
 		}
@@ -92,3 +129,57 @@ func (h *Handler) MsgCreate(ctx context.Context, msg *wire.OpMsg) (*wire.OpMsg,
 
 	return &reply, nil
 }
+
+// getPartitioning returns collection table partitioning from the FerretDB-specific "partition" field
+// of the create command, or nil if it is not set.
+//
+// The field is a document like {kind: "hash", partitions: 16} or {kind: "date", path: "created", interval: "month"}.
+// Partitioning can be set only when the collection is created; there is no collMod command,
+// and partitioning an existing collection would require rewriting its whole table anyway.
+func getPartitioning(document *types.Document) (*pgdb.Partitioning, error) {
+	var partition *types.Document
+	var err error
+	if partition, err = common.GetOptionalParam(document, "partition", partition); err != nil || partition == nil {
+		return nil, err
+	}
+
+	kind, err := common.GetRequiredParam[string](partition, "kind")
+	if err != nil {
+		return nil, err
+	}
+
+	res := &pgdb.Partitioning{Kind: pgdb.PartitionKind(kind)}
+
+	switch res.Kind {
+	case pgdb.PartitionByHash:
+		v, err := partition.Get("partitions")
+		if err != nil {
+			return nil, common.NewErrorMsg(common.ErrBadValue, `required parameter "partitions" is missing`)
+		}
+
+		n, err := common.GetWholeNumberParam(v)
+		if err != nil || n < 0 || n > math.MaxInt32 {
+			return nil, common.NewErrorMsg(common.ErrBadValue, fmt.Sprintf("invalid number of partitions %v", v))
+		}
+
+		res.Partitions = int32(n)
+
+	case pgdb.PartitionByDate:
+		if res.Path, err = common.GetRequiredParam[string](partition, "path"); err != nil {
+			return nil, err
+		}
+
+		interval, err := common.GetOptionalParam(partition, "interval", string(pgdb.PartitionMonth))
+		if err != nil {
+			return nil, err
+		}
+
+		res.Interval = pgdb.PartitionInterval(interval)
+	}
+
+	if err = res.Validate(); err != nil {
+		return nil, common.NewErrorMsg(common.ErrBadValue, err.Error())
+	}
+
+	return res, nil
+}
@@ -0,0 +1,125 @@
+// Copyright 2021 FerretDB Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package pg
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/FerretDB/FerretDB/internal/handlers/common"
+	"github.com/FerretDB/FerretDB/internal/types"
+	"github.com/FerretDB/FerretDB/internal/util/lazyerrors"
+	"github.com/FerretDB/FerretDB/internal/util/must"
+	"github.com/FerretDB/FerretDB/internal/wire"
+)
+
+// MsgCreateUser implements HandlerInterface.
+func (h *Handler) MsgCreateUser(ctx context.Context, msg *wire.OpMsg) (*wire.OpMsg, error) {
+	pgPool, err := h.createUserPool(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	document, err := msg.Document()
+	if err != nil {
+		return nil, lazyerrors.Error(err)
+	}
+
+	if err = common.Unimplemented(document, "authenticationRestrictions"); err != nil {
+		return nil, err
+	}
+
+	common.Ignored(document, h.l, "writeConcern", "digestPassword", "comment")
+
+	db, err := common.GetRequiredParam[string](document, "$db")
+	if err != nil {
+		return nil, err
+	}
+
+	username, err := common.GetRequiredParam[string](document, document.Command())
+	if err != nil {
+		return nil, err
+	}
+
+	if username == "" {
+		return nil, common.NewErrorMsg(common.ErrBadValue, "User document needs 'user' field to be non-empty")
+	}
+
+	rolesParam, err := common.GetRequiredParam[*types.Array](document, "roles")
+	if err != nil {
+		return nil, err
+	}
+
+	roles, err := userRoles(rolesParam, db)
+	if err != nil {
+		return nil, err
+	}
+
+	credentials, err := userCredentials(document, db, username)
+	if err != nil {
+		return nil, err
+	}
+
+	existing, err := h.findUser(ctx, db, username)
+	if err != nil {
+		return nil, lazyerrors.Error(err)
+	}
+
+	if existing != nil {
+		msg := fmt.Sprintf("User \"%s@%s\" already exists", username, db)
+		return nil, common.NewErrorMsg(common.ErrUserAlreadyExists, msg)
+	}
+
+	user := must.NotFail(types.NewDocument(
+		"_id", userID(db, username),
+		"user", username,
+		"db", db,
+		"credentials", credentials,
+		"roles", roles,
+	))
+
+	var customData *types.Document
+	if customData, err = common.GetOptionalParam(document, "customData", customData); err != nil {
+		return nil, err
+	}
+
+	if customData != nil {
+		must.NoError(user.Set("customData", customData))
+	}
+
+	if err = pgPool.InsertDocument(ctx, common.UsersDB, common.UsersCollection, user); err != nil {
+		return nil, lazyerrors.Error(err)
+	}
+
+	// the user was inserted with the pool of the current user, so PostgreSQL checked that it is allowed
+	if h.tenantRoles && db != common.UsersDB && db != common.ExternalDB {
+		if err = h.pgPool.CreateTenant(ctx, db); err != nil {
+			_, _ = h.pgPool.DeleteDocumentsByID(ctx, common.UsersDB, common.UsersCollection, []any{userID(db, username)})
+			return nil, lazyerrors.Error(err)
+		}
+	}
+
+	var reply wire.OpMsg
+	err = reply.SetSections(wire.OpMsgSection{
+		Documents: []*types.Document{must.NotFail(types.NewDocument(
+			"ok", float64(1),
+		))},
+	})
+	if err != nil {
+		return nil, lazyerrors.Error(err)
+	}
+
+	return &reply, nil
+}
@@ -32,6 +32,11 @@ import (
 
 // MsgDataSize implements HandlerInterface.
 func (h *Handler) MsgDataSize(ctx context.Context, msg *wire.OpMsg) (*wire.OpMsg, error) {
+	pgPool, err := h.pool(ctx)
+	if err != nil {
+		return nil, err
+	}
+
 	document, err := msg.Document()
 	if err != nil {
 		return nil, lazyerrors.Error(err)
@@ -54,7 +59,7 @@ func (h *Handler) MsgDataSize(ctx context.Context, msg *wire.OpMsg) (*wire.OpMsg
 	db, collection := targets[0], targets[1]
 
 	started := time.Now()
-	stats, err := h.pgPool.SchemaStats(ctx, db, collection)
+	stats, err := pgPool.SchemaStats(ctx, db, collection)
 	elapses := time.Since(started)
 
 	addEstimate := true
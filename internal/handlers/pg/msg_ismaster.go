@@ -18,6 +18,7 @@ import (
 	"context"
 	"time"
 
+	"github.com/FerretDB/FerretDB/internal/handlers/common"
 	"github.com/FerretDB/FerretDB/internal/types"
 	"github.com/FerretDB/FerretDB/internal/util/lazyerrors"
 	"github.com/FerretDB/FerretDB/internal/util/must"
@@ -26,26 +27,53 @@ import (
 
 // MsgIsMaster implements HandlerInterface.
 func (h *Handler) MsgIsMaster(ctx context.Context, msg *wire.OpMsg) (*wire.OpMsg, error) {
-	if err := h.pgPool.Ping(ctx); err != nil {
+	document, err := msg.Document()
+	if err != nil {
+		return nil, lazyerrors.Error(err)
+	}
+
+	if err = common.AwaitHello(ctx, document); err != nil {
+		return nil, err
+	}
+
+	if err = h.checkHealth(); err != nil {
+		return nil, err
+	}
+
+	if err = h.pgPool.Ping(ctx); err != nil {
 		return nil, err
 	}
 
+	mechanisms, err := h.saslSupportedMechs(ctx, document)
+	if err != nil {
+		return nil, err
+	}
+
+	limits := wire.GetLimits()
+
+	res := must.NotFail(types.NewDocument(
+		"ismaster", true, // only lowercase
+		"topologyVersion", common.TopologyVersion(),
+		"maxBsonObjectSize", limits.MaxBSONObjectSize,
+		"maxMessageSizeBytes", limits.MaxMessageSizeBytes,
+		"maxWriteBatchSize", limits.MaxWriteBatchSize,
+		"localTime", time.Now(),
+		// logicalSessionTimeoutMinutes
+		// connectionId
+		"minWireVersion", int32(13),
+		"maxWireVersion", int32(13),
+		"readOnly", false,
+	))
+
+	if mechanisms != nil {
+		must.NoError(res.Set("saslSupportedMechs", mechanisms))
+	}
+
+	must.NoError(res.Set("ok", float64(1)))
+
 	var reply wire.OpMsg
-	err := reply.SetSections(wire.OpMsgSection{
-		Documents: []*types.Document{must.NotFail(types.NewDocument(
-			"ismaster", true, // only lowercase
-			// topologyVersion
-			"maxBsonObjectSize", int32(types.MaxDocumentLen),
-			"maxMessageSizeBytes", int32(wire.MaxMsgLen),
-			"maxWriteBatchSize", int32(100000),
-			"localTime", time.Now(),
-			// logicalSessionTimeoutMinutes
-			// connectionId
-			"minWireVersion", int32(13),
-			"maxWireVersion", int32(13),
-			"readOnly", false,
-			"ok", float64(1),
-		))},
+	err = reply.SetSections(wire.OpMsgSection{
+		Documents: []*types.Document{res},
 	})
 	if err != nil {
 		return nil, lazyerrors.Error(err)
@@ -18,6 +18,7 @@ import (
 	"context"
 
 	"github.com/FerretDB/FerretDB/internal/handlers/common"
+	"github.com/FerretDB/FerretDB/internal/handlers/pg/pgdb"
 	"github.com/FerretDB/FerretDB/internal/types"
 	"github.com/FerretDB/FerretDB/internal/util/lazyerrors"
 	"github.com/FerretDB/FerretDB/internal/util/must"
@@ -53,16 +54,32 @@ func (h *Handler) MsgListCollections(ctx context.Context, msg *wire.OpMsg) (*wir
 		return nil, err
 	}
 
-	names, err := h.pgPool.Collections(ctx, db)
+	pgPool, err := h.dbPool(ctx, db)
+	if err != nil {
+		return nil, err
+	}
+
+	names, err := pgPool.Collections(ctx, db)
 	if err != nil {
 		return nil, lazyerrors.Error(err)
 	}
 
 	collections := types.MakeArray(len(names))
 	for _, n := range names {
+		params, err := pgPool.CollectionParams(ctx, db, n)
+		if err != nil {
+			return nil, lazyerrors.Error(err)
+		}
+
+		typ := "collection"
+		if params.Timeseries != nil {
+			typ = "timeseries"
+		}
+
 		d := must.NotFail(types.NewDocument(
 			"name", n,
-			"type", "collection",
+			"type", typ,
+			"options", collectionOptions(params),
 		))
 		if err = collections.Append(d); err != nil {
 			return nil, lazyerrors.Error(err)
@@ -86,3 +103,29 @@ func (h *Handler) MsgListCollections(ctx context.Context, msg *wire.OpMsg) (*wir
 
 	return &reply, nil
 }
+
+// collectionOptions returns options of the collection as reported by listCollections.
+func collectionOptions(params *pgdb.CreateCollectionParams) *types.Document {
+	options := must.NotFail(types.NewDocument())
+
+	if name := params.ClusteredIndex; name != "" {
+		must.NoError(options.Set("clusteredIndex", must.NotFail(types.NewDocument(
+			"v", int32(2),
+			"key", must.NotFail(types.NewDocument("_id", int32(1))),
+			"name", name,
+			"unique", true,
+		))))
+	}
+
+	if ts := params.Timeseries; ts != nil {
+		timeseries := must.NotFail(types.NewDocument("timeField", ts.TimeField))
+		if ts.MetaField != "" {
+			must.NoError(timeseries.Set("metaField", ts.MetaField))
+		}
+		must.NoError(timeseries.Set("granularity", string(ts.Granularity)))
+
+		must.NoError(options.Set("timeseries", timeseries))
+	}
+
+	return options
+}
@@ -26,6 +26,11 @@ import (
 
 // MsgListDatabases implements HandlerInterface.
 func (h *Handler) MsgListDatabases(ctx context.Context, msg *wire.OpMsg) (*wire.OpMsg, error) {
+	pgPool, err := h.pool(ctx)
+	if err != nil {
+		return nil, err
+	}
+
 	document, err := msg.Document()
 	if err != nil {
 		return nil, lazyerrors.Error(err)
@@ -38,7 +43,7 @@ func (h *Handler) MsgListDatabases(ctx context.Context, msg *wire.OpMsg) (*wire.
 
 	common.Ignored(document, h.l, "comment", "authorizedDatabases")
 
-	databaseNames, err := h.pgPool.Schemas(ctx)
+	databaseNames, err := pgPool.Schemas(ctx)
 	if err != nil {
 		return nil, err
 	}
@@ -50,7 +55,7 @@ func (h *Handler) MsgListDatabases(ctx context.Context, msg *wire.OpMsg) (*wire.
 
 	databases := types.MakeArray(len(databaseNames))
 	for _, databaseName := range databaseNames {
-		tables, err := h.pgPool.Tables(ctx, databaseName)
+		tables, err := pgPool.Tables(ctx, databaseName)
 		if err != nil {
 			return nil, lazyerrors.Error(err)
 		}
@@ -60,7 +65,7 @@ func (h *Handler) MsgListDatabases(ctx context.Context, msg *wire.OpMsg) (*wire.
 		for _, name := range tables {
 			var tableSize int64
 			fullName := databaseName + "." + name
-			err = h.pgPool.QueryRow(ctx, "SELECT pg_total_relation_size($1)", fullName).Scan(&tableSize)
+			err = pgPool.QueryRow(ctx, "SELECT pg_total_relation_size($1)", fullName).Scan(&tableSize)
 			if err != nil {
 				return nil, lazyerrors.Error(err)
 			}
@@ -106,7 +111,7 @@ func (h *Handler) MsgListDatabases(ctx context.Context, msg *wire.OpMsg) (*wire.
 	}
 
 	var totalSize int64
-	err = h.pgPool.QueryRow(ctx, "SELECT pg_database_size(current_database())").Scan(&totalSize)
+	err = pgPool.QueryRow(ctx, "SELECT pg_database_size(current_database())").Scan(&totalSize)
 	if err != nil {
 		return nil, err
 	}
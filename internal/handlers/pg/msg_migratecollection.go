@@ -0,0 +1,90 @@
+// Copyright 2021 FerretDB Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package pg
+
+import (
+	"context"
+
+	"github.com/FerretDB/FerretDB/internal/handlers/common"
+	"github.com/FerretDB/FerretDB/internal/handlers/pg/pgdb"
+	"github.com/FerretDB/FerretDB/internal/types"
+	"github.com/FerretDB/FerretDB/internal/util/lazyerrors"
+	"github.com/FerretDB/FerretDB/internal/util/must"
+	"github.com/FerretDB/FerretDB/internal/wire"
+)
+
+// MsgMigrateCollection implements HandlerInterface.
+//
+// The collection is migrated online; progress is reported by the currentOp command.
+func (h *Handler) MsgMigrateCollection(ctx context.Context, msg *wire.OpMsg) (*wire.OpMsg, error) {
+	pgPool, err := h.pool(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	document, err := msg.Document()
+	if err != nil {
+		return nil, lazyerrors.Error(err)
+	}
+
+	common.Ignored(document, h.l, "comment")
+
+	command := document.Command()
+
+	var db, collection string
+	if db, err = common.GetRequiredParam[string](document, "$db"); err != nil {
+		return nil, err
+	}
+	if collection, err = common.GetRequiredParam[string](document, command); err != nil {
+		return nil, err
+	}
+
+	var batchSize int64
+	if b, _ := document.Get("batchSize"); b != nil {
+		if batchSize, err = common.GetWholeNumberParam(b); err != nil {
+			return nil, err
+		}
+		if batchSize < 0 {
+			return nil, common.NewErrorMsg(common.ErrBadValue, "BatchSize value must be non-negative")
+		}
+	}
+
+	ns := db + "." + collection
+
+	op := common.StartOperation(ctx, ns, document, "migrateCollection")
+	defer op.Finish()
+
+	n, err := pgPool.MigrateCollection(ctx, db, collection, int(batchSize), op.SetProgress)
+	if err != nil {
+		if err == pgdb.ErrTableNotExist {
+			return nil, common.NewErrorMsg(common.ErrNamespaceNotFound, "ns not found")
+		}
+		return nil, lazyerrors.Error(err)
+	}
+
+	var reply wire.OpMsg
+	err = reply.SetSections(wire.OpMsgSection{
+		Documents: []*types.Document{must.NotFail(types.NewDocument(
+			"n", n,
+			"ns", ns,
+			"ok", float64(1),
+		))},
+	})
+	if err != nil {
+		return nil, lazyerrors.Error(err)
+	}
+
+	return &reply, nil
+}
@@ -25,6 +25,10 @@ import (
 
 // MsgPing implements HandlerInterface.
 func (h *Handler) MsgPing(ctx context.Context, msg *wire.OpMsg) (*wire.OpMsg, error) {
+	if err := h.checkHealth(); err != nil {
+		return nil, err
+	}
+
 	if err := h.pgPool.Ping(ctx); err != nil {
 		return nil, err
 	}
@@ -0,0 +1,191 @@
+// Copyright 2021 FerretDB Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package pg
+
+import (
+	"context"
+	"fmt"
+	"sort"
+
+	"golang.org/x/exp/maps"
+
+	"github.com/FerretDB/FerretDB/internal/handlers/common"
+	"github.com/FerretDB/FerretDB/internal/types"
+	"github.com/FerretDB/FerretDB/internal/util/lazyerrors"
+	"github.com/FerretDB/FerretDB/internal/util/must"
+	"github.com/FerretDB/FerretDB/internal/wire"
+)
+
+// MsgUsersInfo implements HandlerInterface.
+func (h *Handler) MsgUsersInfo(ctx context.Context, msg *wire.OpMsg) (*wire.OpMsg, error) {
+	document, err := msg.Document()
+	if err != nil {
+		return nil, lazyerrors.Error(err)
+	}
+
+	if err = common.Unimplemented(document, "filter"); err != nil {
+		return nil, err
+	}
+
+	common.Ignored(document, h.l, "showPrivileges", "showAuthenticationRestrictions", "comment")
+
+	db, err := common.GetRequiredParam[string](document, "$db")
+	if err != nil {
+		return nil, err
+	}
+
+	showCredentials, err := common.GetBoolOptionalParam(document, "showCredentials")
+	if err != nil {
+		return nil, err
+	}
+
+	match, err := usersInfoMatcher(must.NotFail(document.Get(document.Command())), db)
+	if err != nil {
+		return nil, err
+	}
+
+	users, err := h.fetchUsers(ctx, nil)
+	if err != nil {
+		return nil, lazyerrors.Error(err)
+	}
+
+	infos := make(map[string]*types.Document, len(users))
+	for _, user := range users {
+		userDB, err := common.GetRequiredParam[string](user, "db")
+		if err != nil {
+			return nil, lazyerrors.Error(err)
+		}
+
+		username, err := common.GetRequiredParam[string](user, "user")
+		if err != nil {
+			return nil, lazyerrors.Error(err)
+		}
+
+		if !match(userDB, username) {
+			continue
+		}
+
+		info := must.NotFail(types.NewDocument(
+			"_id", userID(userDB, username),
+			"user", username,
+			"db", userDB,
+			"roles", must.NotFail(user.Get("roles")),
+			"mechanisms", common.UserMechanisms(user),
+		))
+
+		if v, err := user.Get("customData"); err == nil {
+			must.NoError(info.Set("customData", v))
+		}
+
+		if showCredentials {
+			must.NoError(info.Set("credentials", must.NotFail(user.Get("credentials"))))
+		}
+
+		infos[userID(userDB, username)] = info
+	}
+
+	ids := maps.Keys(infos)
+	sort.Strings(ids)
+
+	res := types.MakeArray(len(ids))
+	for _, id := range ids {
+		must.NoError(res.Append(infos[id]))
+	}
+
+	var reply wire.OpMsg
+	err = reply.SetSections(wire.OpMsgSection{
+		Documents: []*types.Document{must.NotFail(types.NewDocument(
+			"users", res,
+			"ok", float64(1),
+		))},
+	})
+	if err != nil {
+		return nil, lazyerrors.Error(err)
+	}
+
+	return &reply, nil
+}
+
+// usersInfoMatcher returns a function that checks if the user matches usersInfo command value.
+//
+// The value could be:
+//   - 1 for all users in the current database;
+//   - username string for the user in the current database;
+//   - document with user and db fields;
+//   - document {forAllDBs: true} for all users in all databases;
+//   - array of strings and documents.
+func usersInfoMatcher(value any, db string) (func(db, username string) bool, error) {
+	switch value := value.(type) {
+	case int32, int64, float64:
+		return func(userDB, _ string) bool { return userDB == db }, nil
+
+	case string, *types.Array:
+		ids, err := usersInfoIDs(value, db)
+		if err != nil {
+			return nil, err
+		}
+
+		return func(userDB, username string) bool {
+			_, ok := ids[userID(userDB, username)]
+			return ok
+		}, nil
+
+	case *types.Document:
+		if forAllDBs, _ := common.GetBoolOptionalParam(value, "forAllDBs"); forAllDBs {
+			return func(string, string) bool { return true }, nil
+		}
+
+		return usersInfoMatcher(must.NotFail(types.NewArray(value)), db)
+
+	default:
+		msg := fmt.Sprintf("User and role names must be either strings or objects, not %s", common.AliasFromType(value))
+		return nil, common.NewErrorMsg(common.ErrBadValue, msg)
+	}
+}
+
+// usersInfoIDs returns a set of user _id values for usersInfo command value.
+func usersInfoIDs(value any, db string) (map[string]struct{}, error) {
+	values, ok := value.(*types.Array)
+	if !ok {
+		values = must.NotFail(types.NewArray(value))
+	}
+
+	res := make(map[string]struct{}, values.Len())
+	for i := 0; i < values.Len(); i++ {
+		switch v := must.NotFail(values.Get(i)).(type) {
+		case string:
+			res[userID(db, v)] = struct{}{}
+
+		case *types.Document:
+			username, err := common.GetRequiredParam[string](v, "user")
+			if err != nil {
+				return nil, err
+			}
+
+			userDB, err := common.GetRequiredParam[string](v, "db")
+			if err != nil {
+				return nil, err
+			}
+
+			res[userID(userDB, username)] = struct{}{}
+
+		default:
+			msg := fmt.Sprintf("User and role names must be either strings or objects, not %s", common.AliasFromType(v))
+			return nil, common.NewErrorMsg(common.ErrBadValue, msg)
+		}
+	}
+
+	return res, nil
+}
@@ -13,48 +13,341 @@
 // limitations under the License.
 
 // Package pg provides PostgreSQL handler.
+//
+// Commands are implemented by the generic handler on top of PostgreSQL backends (see pgdb.Pool);
+// this package adds authentication, connection pool routing, and commands that use PostgreSQL-specific features.
 package pg
 
 import (
+	"context"
+	"fmt"
 	"time"
 
+	"github.com/prometheus/client_golang/prometheus"
 	"go.uber.org/zap"
+	"golang.org/x/exp/slices"
 
 	"github.com/FerretDB/FerretDB/internal/handlers"
+	"github.com/FerretDB/FerretDB/internal/handlers/common"
+	"github.com/FerretDB/FerretDB/internal/handlers/generic"
 	"github.com/FerretDB/FerretDB/internal/handlers/pg/pgdb"
+	"github.com/FerretDB/FerretDB/internal/util/lazyerrors"
+	"github.com/FerretDB/FerretDB/internal/util/ldapauth"
 )
 
 // Handler implements handlers.Interface on top of PostgreSQL.
 type Handler struct {
+	*generic.Handler
+
 	// TODO replace those fields with
 	// opts *NewOpts
-	pgPool    *pgdb.Pool
-	l         *zap.Logger
-	startTime time.Time
+	pgPool     *pgdb.Pool
+	l          *zap.Logger
+	authMode   AuthMode
+	connString string
+	poolOpts   *pgdb.NewPoolOpts
+	userPools  userPools
+	ldap       *ldapauth.Authenticator
+
+	perDatabasePools bool
+	maxDatabasePools int
+	dbPools          dbPools
+
+	replicas    []*pgdb.Pool
+	replicaNext uint32 // accessed atomically
+
+	health *pgdb.HealthChecker
+
+	tenantRoles bool
+	tenantPools dbPools // keyed by tenant database name
 }
 
 // NewOpts represents handler configuration.
 type NewOpts struct {
 	PgPool *pgdb.Pool
 	L      *zap.Logger
+
+	// Authentication mode; AuthModeFerretDB if empty.
+	AuthMode AuthMode
+
+	// Connection string and options used to open per-user pools in AuthModePassthrough.
+	PostgreSQLURL string
+	PoolOpts      *pgdb.NewPoolOpts
+
+	// LDAP server configuration for AuthModeLDAP.
+	LDAP *ldapauth.Config
+
+	// If set, queries to each database use a separate connection pool opened with PostgreSQLURL and PoolOpts.
+	// Not supported in AuthModePassthrough.
+	PerDatabasePools bool
+
+	// If positive, at most that many per-database pools are open at the same time;
+	// least recently used idle pools are closed to open new ones.
+	MaxDatabasePools int
+
+	// Pools of PostgreSQL read replicas; reads with secondary and secondaryPreferred read preference
+	// are routed to them. Not supported in AuthModePassthrough.
+	Replicas []*pgdb.Pool
+
+	// If positive, PostgreSQL is probed with that interval via PgPool.
+	// After HealthCheckFailures consecutive failed probes, commands fail fast with HostUnreachable error
+	// until the next successful probe; see pgdb.HealthChecker.
+	HealthCheckInterval time.Duration
+	HealthCheckFailures int
+
+	// If set, each database, except admin, is owned by a dedicated PostgreSQL role that is created
+	// together with the first user of that database (see pgdb.CreateTenant).
+	// Queries of clients authenticated as users of that database run as that role,
+	// so they can't access other databases even if FerretDB authorization has a bug.
+	// Clients have to authenticate before running any commands that access data.
+	// PostgreSQL user should have CREATEROLE privilege.
+	// Only AuthModeFerretDB is supported; per-database pools and read replicas are not supported.
+	TenantRoles bool
 }
 
 // New returns a new handler.
 func New(opts *NewOpts) (handlers.Interface, error) {
+	authMode := opts.AuthMode
+	if authMode == "" {
+		authMode = AuthModeFerretDB
+	}
+	if !slices.Contains(AllAuthModes, authMode) {
+		return nil, fmt.Errorf("pg.New: unknown authentication mode %q", authMode)
+	}
+
+	if authMode == AuthModePassthrough && opts.PostgreSQLURL == "" {
+		return nil, fmt.Errorf("pg.New: PostgreSQL URL is required for %q authentication mode", authMode)
+	}
+
+	if opts.PerDatabasePools {
+		if authMode == AuthModePassthrough {
+			return nil, fmt.Errorf("pg.New: per-database pools are not supported in %q authentication mode", authMode)
+		}
+
+		if opts.PostgreSQLURL == "" {
+			return nil, fmt.Errorf("pg.New: PostgreSQL URL is required for per-database pools")
+		}
+	}
+
+	if len(opts.Replicas) > 0 && authMode == AuthModePassthrough {
+		return nil, fmt.Errorf("pg.New: read replicas are not supported in %q authentication mode", authMode)
+	}
+
+	if opts.TenantRoles {
+		if authMode != AuthModeFerretDB {
+			return nil, fmt.Errorf("pg.New: tenant roles are not supported in %q authentication mode", authMode)
+		}
+
+		if opts.PerDatabasePools || len(opts.Replicas) > 0 {
+			return nil, fmt.Errorf("pg.New: per-database pools and read replicas are not supported with tenant roles")
+		}
+
+		if opts.PostgreSQLURL == "" {
+			return nil, fmt.Errorf("pg.New: PostgreSQL URL is required for tenant roles")
+		}
+	}
+
+	var ldap *ldapauth.Authenticator
+	if authMode == AuthModeLDAP {
+		if opts.LDAP == nil {
+			return nil, fmt.Errorf("pg.New: LDAP configuration is required for %q authentication mode", authMode)
+		}
+
+		for group, roles := range opts.LDAP.GroupRoles {
+			for _, role := range roles {
+				if !slices.Contains(common.AllRoles, role) {
+					return nil, fmt.Errorf("pg.New: unexpected role %q for LDAP group %q", role, group)
+				}
+			}
+		}
+
+		var err error
+		if ldap, err = ldapauth.New(opts.LDAP); err != nil {
+			return nil, fmt.Errorf("pg.New: %w", err)
+		}
+	}
+
+	poolOpts := opts.PoolOpts
+	if poolOpts == nil {
+		poolOpts = new(pgdb.NewPoolOpts)
+	}
+
 	h := &Handler{
-		pgPool:    opts.PgPool,
-		l:         opts.L,
-		startTime: time.Now(),
+		pgPool:     opts.PgPool,
+		l:          opts.L,
+		authMode:   authMode,
+		connString: opts.PostgreSQLURL,
+		poolOpts:   poolOpts,
+		userPools: userPools{
+			pools: map[string]*pgdb.Pool{},
+		},
+		ldap: ldap,
+
+		perDatabasePools: opts.PerDatabasePools,
+		maxDatabasePools: opts.MaxDatabasePools,
+		dbPools: dbPools{
+			pools:    map[string]*pgdb.Pool{},
+			lastUsed: map[string]*int64{},
+		},
+		replicas: opts.Replicas,
+
+		tenantRoles: opts.TenantRoles,
+		tenantPools: dbPools{
+			pools: map[string]*pgdb.Pool{},
+		},
+	}
+
+	maxOpenIterators, err := h.maxOpenIterators()
+	if err != nil {
+		return nil, fmt.Errorf("pg.New: %w", err)
 	}
+
+	h.Handler, err = generic.New(&generic.NewOpts{
+		Storage:          h.pgPool,
+		L:                h.l,
+		DBBackend:        h.dbBackend,
+		ReadBackend:      h.readBackend,
+		MaxOpenIterators: maxOpenIterators,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("pg.New: %w", err)
+	}
+
+	if opts.HealthCheckInterval > 0 {
+		h.health = pgdb.NewHealthChecker(h.pgPool, h.l, &pgdb.HealthCheckerOpts{
+			Interval:         opts.HealthCheckInterval,
+			FailureThreshold: opts.HealthCheckFailures,
+			OnRecover:        h.resetPools,
+		})
+	}
+
 	return h, nil
 }
 
+// maxOpenIterators returns the limit of open iterators for the generic handler (see generic.MaxOpenIterators).
+//
+// Iterators hold connections of pools they were created by: the shared pool,
+// per-database, tenant, and user pools (created on demand with the same options), or read replicas.
+// All iterators could be created by the same pool, so the limit is based on the smallest one.
+func (h *Handler) maxOpenIterators() (int, error) {
+	maxConns := h.pgPool.Config().MaxConns
+
+	if h.connString != "" {
+		n, err := pgdb.PoolMaxConns(h.connString, h.poolOpts)
+		if err != nil {
+			return 0, err
+		}
+
+		if n < maxConns {
+			maxConns = n
+		}
+	}
+
+	for _, replica := range h.replicas {
+		if n := replica.Config().MaxConns; n < maxConns {
+			maxConns = n
+		}
+	}
+
+	return generic.MaxOpenIterators(int(maxConns)), nil
+}
+
 // Close implements HandlerInterface.
 func (h *Handler) Close() {
+	h.health.Close()
+
+	h.closeUserPools()
+	h.closeDBPools()
+	h.closeTenantPools()
+
+	for _, replica := range h.replicas {
+		replica.Close()
+	}
+
 	h.pgPool.Close()
 }
 
+// Capabilities implements HandlerInterface.
+//
+// All optional features are supported.
+// In AuthModeLDAP, commands are authorized by roles granted by LDAP group membership.
+func (h *Handler) Capabilities() *handlers.Capabilities {
+	return &handlers.Capabilities{
+		Handler:       "pg",
+		Authorization: h.authMode == AuthModeLDAP,
+	}
+}
+
+// Check implements HandlerInterface.
+//
+// It fails fast if PostgreSQL is considered unreachable by the health checker,
+// and then executes a trivial query using the shared pool.
+func (h *Handler) Check(ctx context.Context) error {
+	if err := h.checkHealth(); err != nil {
+		return err
+	}
+
+	var one int
+	if err := h.pgPool.QueryRow(ctx, "SELECT 1").Scan(&one); err != nil {
+		return lazyerrors.Error(err)
+	}
+
+	return nil
+}
+
+// Describe implements prometheus.Collector.
+func (h *Handler) Describe(ch chan<- *prometheus.Desc) {
+	if m := h.poolOpts.StatementCacheMetrics; m != nil {
+		m.Describe(ch)
+	}
+
+	if a := h.poolOpts.Analyzer; a != nil {
+		a.Describe(ch)
+	}
+
+	if h.health != nil {
+		h.health.Describe(ch)
+	}
+
+	pgdb.DescribePoolStats(ch)
+}
+
+// Collect implements prometheus.Collector.
+func (h *Handler) Collect(ch chan<- prometheus.Metric) {
+	if m := h.poolOpts.StatementCacheMetrics; m != nil {
+		m.Collect(ch)
+	}
+
+	if a := h.poolOpts.Analyzer; a != nil {
+		a.Collect(ch)
+	}
+
+	if h.health != nil {
+		h.health.Collect(ch)
+	}
+
+	pgdb.CollectPoolStats(ch, "default", []*pgdb.Pool{h.pgPool})
+
+	if len(h.replicas) > 0 {
+		pgdb.CollectPoolStats(ch, "replica", h.replicas)
+	}
+
+	if h.perDatabasePools {
+		pgdb.CollectPoolStats(ch, "database", h.dbPools.all())
+	}
+
+	if h.authMode == AuthModePassthrough {
+		pgdb.CollectPoolStats(ch, "user", h.userPools.all())
+	}
+
+	if h.tenantRoles {
+		pgdb.CollectPoolStats(ch, "tenant", h.tenantPools.all())
+	}
+}
+
 // check interfaces
 var (
-	_ handlers.Interface = (*Handler)(nil)
+	_ handlers.Interface   = (*Handler)(nil)
+	_ prometheus.Collector = (*Handler)(nil)
+	_ generic.Storage      = (*pgdb.Pool)(nil)
 )
@@ -0,0 +1,107 @@
+// Copyright 2021 FerretDB Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package pg
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap/zaptest"
+
+	"github.com/FerretDB/FerretDB/internal/clientconn/conninfo"
+	"github.com/FerretDB/FerretDB/internal/handlers/pg/pgdb"
+	"github.com/FerretDB/FerretDB/internal/types"
+	"github.com/FerretDB/FerretDB/internal/util/must"
+	"github.com/FerretDB/FerretDB/internal/util/testutil"
+	"github.com/FerretDB/FerretDB/internal/wire"
+)
+
+func TestFindOpenIterators(t *testing.T) {
+	t.Parallel()
+
+	ctx := conninfo.WithConnInfo(testutil.Ctx(t), new(conninfo.ConnInfo))
+
+	pool, err := pgdb.NewPool(ctx, testutil.PoolConnString(t, nil), zaptest.NewLogger(t), &pgdb.NewPoolOpts{
+		MaxConns: 2,
+	})
+	require.NoError(t, err)
+	t.Cleanup(pool.Close)
+
+	h, err := New(&NewOpts{
+		PgPool: pool,
+		L:      zaptest.NewLogger(t),
+	})
+	require.NoError(t, err)
+
+	db := testutil.SchemaName(t)
+	collection := testutil.TableName(t)
+
+	t.Cleanup(func() {
+		require.NoError(t, pool.DropDatabase(ctx, db))
+	})
+
+	docs := make([]*types.Document, 10)
+	for i := range docs {
+		docs[i] = must.NotFail(types.NewDocument("_id", int32(i)))
+	}
+
+	require.NoError(t, pool.InsertDocuments(ctx, db, collection, docs))
+
+	msg := func(pairs ...any) *wire.OpMsg {
+		var res wire.OpMsg
+		must.NoError(res.SetSections(wire.OpMsgSection{
+			Documents: []*types.Document{must.NotFail(types.NewDocument(pairs...))},
+		}))
+		return &res
+	}
+
+	cursor := func(reply *wire.OpMsg) *types.Document {
+		return must.NotFail(must.NotFail(reply.Document()).Get("cursor")).(*types.Document)
+	}
+
+	// more cursors than pool connections
+	ids := make([]int64, 5)
+	for i := range ids {
+		reply, err := h.MsgFind(ctx, msg("find", collection, "batchSize", int32(1), "$db", db))
+		require.NoError(t, err)
+
+		c := cursor(reply)
+		assert.Equal(t, 1, must.NotFail(c.Get("firstBatch")).(*types.Array).Len())
+
+		ids[i] = must.NotFail(c.Get("id")).(int64)
+		require.NotZero(t, ids[i])
+	}
+
+	// other queries still get connections
+	countCtx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	reply, err := h.MsgCount(countCtx, msg("count", collection, "$db", db))
+	require.NoError(t, err)
+	assert.Equal(t, int32(len(docs)), must.NotFail(must.NotFail(reply.Document()).Get("n")))
+
+	// all cursors return the rest of documents
+	for _, id := range ids {
+		reply, err := h.MsgGetMore(ctx, msg("getMore", id, "collection", collection, "$db", db))
+		require.NoError(t, err)
+
+		c := cursor(reply)
+		assert.Equal(t, len(docs)-1, must.NotFail(c.Get("nextBatch")).(*types.Array).Len())
+		assert.Equal(t, int64(0), must.NotFail(c.Get("id")))
+	}
+}
@@ -0,0 +1,185 @@
+// Copyright 2021 FerretDB Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package pgdb
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/jackc/pgx/v4"
+	"github.com/prometheus/client_golang/prometheus"
+	"go.uber.org/zap"
+)
+
+// analyzeTimeout is the maximum duration of a single ANALYZE statement run by Analyzer.
+const analyzeTimeout = 5 * time.Minute
+
+// Analyzer runs ANALYZE on tables after bulk writes, so planner statistics used for pushed down queries
+// don't become stale until autovacuum gets to them.
+//
+// A table is analyzed in the background once the number of rows inserted or deleted since the last run
+// reaches the threshold, but not more often than once per interval.
+// It could be shared by multiple pools.
+type Analyzer struct {
+	threshold int64
+	interval  time.Duration
+
+	m      sync.Mutex
+	tables map[string]*analyzeState // keyed by schema and table names
+
+	runs        *prometheus.CounterVec
+	rateLimited prometheus.Counter
+}
+
+// analyzeState represents Analyzer's state of a single table.
+type analyzeState struct {
+	written     int64     // rows inserted or deleted since the last run
+	last        time.Time // the end of the last run
+	running     bool
+	rateLimited bool // set when the threshold is reached within the interval
+}
+
+// NewAnalyzer creates a new Analyzer with the given threshold of written rows and minimal interval between runs.
+func NewAnalyzer(threshold int64, interval time.Duration) *Analyzer {
+	return &Analyzer{
+		threshold: threshold,
+		interval:  interval,
+		tables:    map[string]*analyzeState{},
+		runs: prometheus.NewCounterVec(
+			prometheus.CounterOpts{
+				Namespace: "ferretdb",
+				Subsystem: "postgresql",
+				Name:      "analyze_total",
+				Help:      "Total number of ANALYZE statements run after bulk writes.",
+			},
+			[]string{"result"},
+		),
+		rateLimited: prometheus.NewCounter(
+			prometheus.CounterOpts{
+				Namespace: "ferretdb",
+				Subsystem: "postgresql",
+				Name:      "analyze_rate_limited_total",
+				Help:      "Total number of ANALYZE statements delayed by the minimal interval between them.",
+			},
+		),
+	}
+}
+
+// Describe implements prometheus.Collector.
+func (a *Analyzer) Describe(ch chan<- *prometheus.Desc) {
+	a.runs.Describe(ch)
+	a.rateLimited.Describe(ch)
+}
+
+// Collect implements prometheus.Collector.
+func (a *Analyzer) Collect(ch chan<- prometheus.Metric) {
+	a.runs.Collect(ch)
+	a.rateLimited.Collect(ch)
+}
+
+// written records n rows inserted into or deleted from the given table.
+// It returns true if the table should be analyzed now; the caller then should call done.
+//
+// It is safe to call on nil Analyzer.
+func (a *Analyzer) written(db, table string, n int64) bool {
+	if a == nil || n <= 0 {
+		return false
+	}
+
+	a.m.Lock()
+	defer a.m.Unlock()
+
+	key := pgx.Identifier{db, table}.Sanitize()
+	s := a.tables[key]
+	if s == nil {
+		s = new(analyzeState)
+		a.tables[key] = s
+	}
+
+	s.written += n
+	if s.written < a.threshold || s.running {
+		return false
+	}
+
+	if time.Since(s.last) < a.interval {
+		if !s.rateLimited {
+			s.rateLimited = true
+			a.rateLimited.Inc()
+		}
+
+		return false
+	}
+
+	s.written = 0
+	s.running = true
+	s.rateLimited = false
+
+	return true
+}
+
+// done records the end of ANALYZE run of the given table.
+func (a *Analyzer) done(db, table string, err error) {
+	a.m.Lock()
+	defer a.m.Unlock()
+
+	if s := a.tables[pgx.Identifier{db, table}.Sanitize()]; s != nil {
+		s.running = false
+		s.last = time.Now()
+	}
+
+	result := "ok"
+	if err != nil {
+		result = "error"
+	}
+
+	a.runs.WithLabelValues(result).Inc()
+}
+
+// written records n rows inserted into or deleted from the given table after the transaction is committed,
+// and runs ANALYZE on it in the background if needed (see Analyzer).
+func (pgPool *Pool) written(db, table string, n int64) {
+	if !pgPool.analyzer.written(db, table, n) {
+		return
+	}
+
+	go func() {
+		ctx, cancel := context.WithTimeout(context.Background(), analyzeTimeout)
+		defer cancel()
+
+		start := time.Now()
+		// not in the transaction, if any (see InTransaction)
+		_, err := pgPool.Pool.Exec(ctx, `ANALYZE `+pgx.Identifier{db, table}.Sanitize())
+		pgPool.analyzer.done(db, table, err)
+
+		if err != nil {
+			pgPool.logger.Warn(
+				"Failed to analyze table.",
+				zap.String("schema", db), zap.String("table", table), zap.Error(err),
+			)
+			return
+		}
+
+		pgPool.logger.Debug(
+			"Analyzed table.",
+			zap.String("schema", db), zap.String("table", table), zap.Duration("duration", time.Since(start)),
+		)
+	}()
+}
+
+// check interfaces
+var (
+	_ prometheus.Collector = (*Analyzer)(nil)
+)
@@ -0,0 +1,77 @@
+// Copyright 2021 FerretDB Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package pgdb
+
+import (
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAnalyzer(t *testing.T) {
+	t.Parallel()
+
+	t.Run("Nil", func(t *testing.T) {
+		t.Parallel()
+
+		var a *Analyzer
+		assert.False(t, a.written("db", "table", 1000))
+	})
+
+	t.Run("Threshold", func(t *testing.T) {
+		t.Parallel()
+
+		a := NewAnalyzer(100, 0)
+
+		assert.False(t, a.written("db", "table", 0))
+		assert.False(t, a.written("db", "table", 60))
+		assert.False(t, a.written("db", "other", 60))
+		assert.True(t, a.written("db", "table", 40))
+
+		// already running
+		assert.False(t, a.written("db", "table", 1000))
+
+		a.done("db", "table", nil)
+		assert.Equal(t, float64(1), testutil.ToFloat64(a.runs.WithLabelValues("ok")))
+
+		// rows written during the run are counted
+		assert.True(t, a.written("db", "table", 1))
+
+		a.done("db", "table", assert.AnError)
+		assert.Equal(t, float64(1), testutil.ToFloat64(a.runs.WithLabelValues("error")))
+
+		assert.False(t, a.written("db", "table", 99))
+	})
+
+	t.Run("Interval", func(t *testing.T) {
+		t.Parallel()
+
+		a := NewAnalyzer(10, time.Hour)
+
+		// the first run is not delayed
+		assert.True(t, a.written("db", "table", 10))
+		a.done("db", "table", nil)
+
+		assert.False(t, a.written("db", "table", 10))
+		assert.False(t, a.written("db", "table", 10))
+		assert.Equal(t, float64(1), testutil.ToFloat64(a.rateLimited))
+
+		a.tables[`"db"."table"`].last = time.Now().Add(-time.Hour)
+		assert.True(t, a.written("db", "table", 1))
+		assert.Equal(t, float64(1), testutil.ToFloat64(a.rateLimited))
+	})
+}
@@ -0,0 +1,157 @@
+// Copyright 2021 FerretDB Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Use _test package to avoid import cycle with testutil.
+package pgdb_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+
+	"github.com/FerretDB/FerretDB/internal/handlers/pg/pgdb"
+	"github.com/FerretDB/FerretDB/internal/types"
+	"github.com/FerretDB/FerretDB/internal/util/must"
+	"github.com/FerretDB/FerretDB/internal/util/testutil"
+)
+
+// benchmarkDocuments is the number of distinct generated documents used by benchmarks.
+const benchmarkDocuments = 100
+
+// setupBenchmark returns pool, database and collection names, and generated documents of the given size.
+// If insert is true, documents are inserted into the collection.
+func setupBenchmark(b *testing.B, opts *testutil.GeneratorOpts, insert bool) (*pgdb.Pool, string, string, []*types.Document) {
+	b.Helper()
+
+	ctx := testutil.Ctx(b)
+
+	// do not log queries, that significantly affects results
+	pool := testutil.Pool(ctx, b, nil, zap.NewNop())
+	schemaName := testutil.Schema(ctx, b, pool)
+	tableName := testutil.Table(ctx, b, pool, schemaName)
+
+	docs := testutil.NewGenerator(b, 1, opts).Documents(benchmarkDocuments)
+
+	if insert {
+		require.NoError(b, pool.InsertDocuments(ctx, schemaName, tableName, docs))
+	}
+
+	return pool, schemaName, tableName, docs
+}
+
+func BenchmarkInsertDocuments(b *testing.B) {
+	for _, size := range testutil.GeneratorSizes {
+		size := size
+
+		b.Run(size.Name, func(b *testing.B) {
+			pool, schemaName, tableName, docs := setupBenchmark(b, size.Opts, false)
+			ctx := testutil.Ctx(b)
+
+			var err error
+
+			b.ReportAllocs()
+			b.ResetTimer()
+
+			for i := 0; i < b.N && err == nil; i++ {
+				// generated documents are reused with unique _id
+				doc := docs[i%len(docs)].DeepCopy()
+				must.NoError(doc.Set("_id", int32(i)))
+
+				err = pool.InsertDocuments(ctx, schemaName, tableName, []*types.Document{doc})
+			}
+
+			b.StopTimer()
+			require.NoError(b, err)
+		})
+	}
+}
+
+func BenchmarkQueryDocuments(b *testing.B) {
+	for _, size := range testutil.GeneratorSizes {
+		size := size
+
+		b.Run(size.Name, func(b *testing.B) {
+			b.Run("ByID", func(b *testing.B) {
+				pool, schemaName, tableName, docs := setupBenchmark(b, size.Opts, true)
+				ctx := testutil.Ctx(b)
+
+				filters := make([]*types.Document, len(docs))
+				for i, doc := range docs {
+					filters[i] = must.NotFail(types.NewDocument("_id", must.NotFail(doc.Get("_id"))))
+				}
+
+				var res *pgdb.QueryResult
+				var err error
+
+				b.ReportAllocs()
+				b.ResetTimer()
+
+				for i := 0; i < b.N && err == nil; i++ {
+					qp := &pgdb.QueryParams{DB: schemaName, Collection: tableName, Filter: filters[i%len(filters)]}
+					res, err = pool.QueryDocuments(ctx, qp)
+				}
+
+				b.StopTimer()
+				require.NoError(b, err)
+				require.Len(b, res.Docs, 1)
+			})
+
+			b.Run("All", func(b *testing.B) {
+				pool, schemaName, tableName, docs := setupBenchmark(b, size.Opts, true)
+				ctx := testutil.Ctx(b)
+
+				var res *pgdb.QueryResult
+				var err error
+
+				b.ReportAllocs()
+				b.ResetTimer()
+
+				for i := 0; i < b.N && err == nil; i++ {
+					res, err = pool.QueryDocuments(ctx, &pgdb.QueryParams{DB: schemaName, Collection: tableName})
+				}
+
+				b.StopTimer()
+				require.NoError(b, err)
+				require.Len(b, res.Docs, len(docs))
+			})
+		})
+	}
+}
+
+func BenchmarkSetDocumentByID(b *testing.B) {
+	for _, size := range testutil.GeneratorSizes {
+		size := size
+
+		b.Run(size.Name, func(b *testing.B) {
+			pool, schemaName, tableName, docs := setupBenchmark(b, size.Opts, true)
+			ctx := testutil.Ctx(b)
+
+			var err error
+
+			b.ReportAllocs()
+			b.ResetTimer()
+
+			for i := 0; i < b.N && err == nil; i++ {
+				doc := docs[i%len(docs)]
+				must.NoError(doc.Set("v", int32(i)))
+
+				_, err = pool.SetDocumentByID(ctx, schemaName, tableName, must.NotFail(doc.Get("_id")), doc)
+			}
+
+			b.StopTimer()
+			require.NoError(b, err)
+		})
+	}
+}
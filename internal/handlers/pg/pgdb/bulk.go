@@ -0,0 +1,382 @@
+// Copyright 2021 FerretDB Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package pgdb
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/jackc/pgx/v4"
+	"go.uber.org/zap"
+
+	"github.com/FerretDB/FerretDB/internal/types"
+	"github.com/FerretDB/FerretDB/internal/util/lazyerrors"
+	"github.com/FerretDB/FerretDB/internal/util/must"
+)
+
+// deferredIndexesTimeout is the maximum duration of building deferred indexes of a single collection
+// in the background.
+const deferredIndexesTimeout = time.Hour
+
+// bulkImports tracks collections with bulk imports in progress; see BulkInsertDocuments.
+type bulkImports struct {
+	m      sync.Mutex
+	timers map[string]*time.Timer // keyed by schema and collection names
+}
+
+// BulkInsertDocuments inserts documents like InsertDocuments,
+// but defers maintenance of secondary indexes of the collection until the bulk import ends.
+//
+// Before the first batch, the GIN index and indexes created by CreateIndex are dropped,
+// and that is recorded in the settings table.
+// They are built again once no batches were inserted into the collection for NewPoolOpts.BulkImportIndexDelay,
+// before CreateIndex creates another index, or by BuildDeferredIndexes on the next start.
+// Building an index once is much faster than updating it for every copied row.
+// The unique _id index is never deferred, so duplicates are still detected.
+//
+// If that delay is not set, or in transactions (see InTransaction), it is the same as InsertDocuments.
+//
+// It implements backend.BulkInserter.
+func (pgPool *Pool) BulkInsertDocuments(ctx context.Context, db, collection string, docs []*types.Document) error {
+	// indexes are built after the delay, when the transaction is over
+	if pgPool.bulkImportIndexDelay <= 0 || pgPool.tx != nil {
+		return pgPool.InsertDocuments(ctx, db, collection, docs)
+	}
+
+	if _, err := pgPool.CreateCollectionIfNotExist(ctx, db, collection); err != nil {
+		return lazyerrors.Error(err)
+	}
+
+	if err := pgPool.startBulkImport(ctx, db, collection); err != nil {
+		return lazyerrors.Error(err)
+	}
+
+	// inserting a large batch could take longer than the delay, so the timer is restarted
+	defer pgPool.resetBulkImport(db, collection)
+
+	return pgPool.InsertDocuments(ctx, db, collection, docs)
+}
+
+// startBulkImport defers indexes of the given collection if that was not done yet,
+// and (re)starts the timer that builds them after the delay.
+func (pgPool *Pool) startBulkImport(ctx context.Context, db, collection string) error {
+	bi := pgPool.bulkImports
+
+	bi.m.Lock()
+	defer bi.m.Unlock()
+
+	key := pgx.Identifier{db, collection}.Sanitize()
+	if t := bi.timers[key]; t != nil {
+		t.Reset(pgPool.bulkImportIndexDelay)
+		return nil
+	}
+
+	if err := pgPool.deferIndexes(ctx, db, collection); err != nil {
+		return err
+	}
+
+	if bi.timers == nil {
+		bi.timers = map[string]*time.Timer{}
+	}
+
+	var t *time.Timer
+	t = time.AfterFunc(pgPool.bulkImportIndexDelay, func() {
+		bi.m.Lock()
+		current := bi.timers[key] == t
+		if current {
+			delete(bi.timers, key)
+		}
+		bi.m.Unlock()
+
+		if !current {
+			return
+		}
+
+		ctx, cancel := context.WithTimeout(context.Background(), deferredIndexesTimeout)
+		defer cancel()
+
+		start := time.Now()
+		if err := pgPool.buildDeferredIndexes(ctx, db, collection); err != nil {
+			pgPool.logger.Warn(
+				"Failed to build deferred indexes.",
+				zap.String("db", db), zap.String("collection", collection), zap.Error(err),
+			)
+			return
+		}
+
+		pgPool.logger.Debug(
+			"Built deferred indexes.",
+			zap.String("db", db), zap.String("collection", collection), zap.Duration("duration", time.Since(start)),
+		)
+	})
+	bi.timers[key] = t
+
+	return nil
+}
+
+// resetBulkImport restarts the timer of the bulk import into the given collection, if any.
+func (pgPool *Pool) resetBulkImport(db, collection string) {
+	bi := pgPool.bulkImports
+
+	bi.m.Lock()
+	defer bi.m.Unlock()
+
+	if t := bi.timers[pgx.Identifier{db, collection}.Sanitize()]; t != nil {
+		t.Reset(pgPool.bulkImportIndexDelay)
+	}
+}
+
+// finishBulkImport stops the bulk import into the given collection (if any) and builds deferred indexes now.
+func (pgPool *Pool) finishBulkImport(ctx context.Context, db, collection string) error {
+	bi := pgPool.bulkImports
+
+	bi.m.Lock()
+	key := pgx.Identifier{db, collection}.Sanitize()
+	if t := bi.timers[key]; t != nil {
+		t.Stop()
+		delete(bi.timers, key)
+	}
+	bi.m.Unlock()
+
+	return pgPool.buildDeferredIndexes(ctx, db, collection)
+}
+
+// deferIndexes drops secondary indexes of the given collection and records that in the settings table.
+//
+// It does nothing if indexes are already deferred.
+func (pgPool *Pool) deferIndexes(ctx context.Context, db, collection string) error {
+	return pgPool.beginDDL(ctx, db, func(tx pgx.Tx) error {
+		settings, err := pgPool.getSettingsTable(ctx, tx, db)
+		if err != nil {
+			return err
+		}
+
+		if deferred, _ := getDeferredIndexes(settings, collection); deferred {
+			return nil
+		}
+
+		table, err := pgPool.getTableName(ctx, tx, db, collection)
+		if err != nil {
+			return err
+		}
+
+		indexes, err := getIndexes(settings, collection)
+		if err != nil {
+			return err
+		}
+
+		var names []string
+		for _, name := range indexes.Keys() {
+			for _, column := range indexColumns(name) {
+				names = append(names, formatCollectionName(table+"_"+column))
+			}
+		}
+
+		gin, err := ginIndexNames(ctx, tx, db, table)
+		if err != nil {
+			return err
+		}
+
+		names = append(names, gin...)
+
+		if len(names) == 0 {
+			return nil
+		}
+
+		for _, name := range names {
+			sql := `DROP INDEX IF EXISTS ` + pgx.Identifier{db, name}.Sanitize()
+			if _, err = tx.Exec(ctx, sql); err != nil {
+				return lazyerrors.Error(err)
+			}
+		}
+
+		setDeferredIndexes(settings, collection, true, len(gin) > 0)
+
+		return pgPool.updateSettingsTable(ctx, tx, db, settings)
+	})
+}
+
+// buildDeferredIndexes builds indexes of the given collection dropped by deferIndexes.
+//
+// It does nothing if indexes are not deferred.
+func (pgPool *Pool) buildDeferredIndexes(ctx context.Context, db, collection string) error {
+	return pgPool.beginDDL(ctx, db, func(tx pgx.Tx) error {
+		settings, err := pgPool.getSettingsTable(ctx, tx, db)
+		if err != nil {
+			return err
+		}
+
+		deferred, gin := getDeferredIndexes(settings, collection)
+		if !deferred {
+			return nil
+		}
+
+		table, err := pgPool.getTableName(ctx, tx, db, collection)
+		if err != nil {
+			return err
+		}
+
+		indexes, err := getIndexes(settings, collection)
+		if err != nil {
+			return err
+		}
+
+		ident := pgx.Identifier{db, table}.Sanitize()
+		for _, name := range indexes.Keys() {
+			for _, column := range indexColumns(name) {
+				indexName := formatCollectionName(table + "_" + column)
+				sql := `CREATE INDEX IF NOT EXISTS ` + pgx.Identifier{indexName}.Sanitize() + ` ON ` + ident +
+					` (` + pgx.Identifier{column}.Sanitize() + `)`
+				if _, err = tx.Exec(ctx, sql); err != nil {
+					return lazyerrors.Error(err)
+				}
+			}
+		}
+
+		if gin {
+			exists, err := hasGINIndex(ctx, tx, db, table)
+			if err != nil {
+				return err
+			}
+
+			if !exists {
+				if err = createGINIndex(ctx, tx, db, table); err != nil {
+					return err
+				}
+			}
+		}
+
+		setDeferredIndexes(settings, collection, false, false)
+
+		return pgPool.updateSettingsTable(ctx, tx, db, settings)
+	})
+}
+
+// BuildDeferredIndexes builds indexes of all collections that were deferred by bulk imports
+// that didn't finish, for example, because FerretDB was stopped.
+func (pgPool *Pool) BuildDeferredIndexes(ctx context.Context) error {
+	var dbs []string
+	sql := `SELECT table_schema FROM information_schema.tables WHERE table_name = $1 ORDER BY table_schema`
+	rows, err := pgPool.Query(ctx, sql, settingsTableName)
+	if err != nil {
+		return lazyerrors.Error(err)
+	}
+
+	for rows.Next() {
+		var db string
+		if err = rows.Scan(&db); err != nil {
+			rows.Close()
+			return lazyerrors.Error(err)
+		}
+
+		dbs = append(dbs, db)
+	}
+	rows.Close()
+
+	if err = rows.Err(); err != nil {
+		return lazyerrors.Error(err)
+	}
+
+	for _, db := range dbs {
+		var collections []string
+		err = pgPool.BeginFunc(ctx, func(tx pgx.Tx) error {
+			settings, err := pgPool.getSettingsTable(ctx, tx, db)
+			if err != nil {
+				return err
+			}
+
+			if all, ok := getSettingsDocument(settings, "deferredIndexes"); ok {
+				collections = all.Keys()
+			}
+
+			return nil
+		})
+		if err != nil {
+			return lazyerrors.Error(err)
+		}
+
+		for _, collection := range collections {
+			pgPool.logger.Info("Building deferred indexes.", zap.String("db", db), zap.String("collection", collection))
+
+			if err = pgPool.finishBulkImport(ctx, db, collection); err != nil {
+				return lazyerrors.Error(err)
+			}
+		}
+	}
+
+	return nil
+}
+
+// ginIndexNames returns names of GIN indexes on the _jsonb column of the given table.
+func ginIndexNames(ctx context.Context, tx pgx.Tx, db, table string) ([]string, error) {
+	sql := `SELECT indexname FROM pg_indexes WHERE schemaname = $1 AND tablename = $2 AND indexdef LIKE $3`
+	rows, err := tx.Query(ctx, sql, db, table, `% `+ginIndexDef)
+	if err != nil {
+		return nil, lazyerrors.Error(err)
+	}
+	defer rows.Close()
+
+	var res []string
+	for rows.Next() {
+		var name string
+		if err = rows.Scan(&name); err != nil {
+			return nil, lazyerrors.Error(err)
+		}
+
+		res = append(res, name)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, lazyerrors.Error(err)
+	}
+
+	return res, nil
+}
+
+// getDeferredIndexes returns true if indexes of the given collection are deferred by a bulk import,
+// and true if the GIN index is among them.
+func getDeferredIndexes(settings *types.Document, collection string) (bool, bool) {
+	all, ok := getSettingsDocument(settings, "deferredIndexes")
+	if !ok {
+		return false, false
+	}
+
+	v, err := all.Get(collection)
+	if err != nil {
+		return false, false
+	}
+
+	gin, _ := v.(bool)
+
+	return true, gin
+}
+
+// setDeferredIndexes records whether indexes of the given collection are deferred by a bulk import
+// in the "deferredIndexes" field of the settings document.
+func setDeferredIndexes(settings *types.Document, collection string, deferred, gin bool) {
+	all, ok := getSettingsDocument(settings, "deferredIndexes")
+	if !ok {
+		all = must.NotFail(types.NewDocument())
+	}
+
+	if deferred {
+		must.NoError(all.Set(collection, gin))
+	} else {
+		all.Remove(collection)
+	}
+
+	must.NoError(settings.Set("deferredIndexes", all))
+}
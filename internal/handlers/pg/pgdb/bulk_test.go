@@ -0,0 +1,50 @@
+// Copyright 2021 FerretDB Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package pgdb
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/FerretDB/FerretDB/internal/types"
+	"github.com/FerretDB/FerretDB/internal/util/must"
+)
+
+func TestDeferredIndexesSettings(t *testing.T) {
+	t.Parallel()
+
+	settings := must.NotFail(types.NewDocument())
+
+	deferred, gin := getDeferredIndexes(settings, "test")
+	assert.False(t, deferred)
+	assert.False(t, gin)
+
+	setDeferredIndexes(settings, "test", true, true)
+	setDeferredIndexes(settings, "other", true, false)
+
+	deferred, gin = getDeferredIndexes(settings, "test")
+	assert.True(t, deferred)
+	assert.True(t, gin)
+
+	deferred, gin = getDeferredIndexes(settings, "other")
+	assert.True(t, deferred)
+	assert.False(t, gin)
+
+	setDeferredIndexes(settings, "test", false, false)
+
+	deferred, _ = getDeferredIndexes(settings, "test")
+	assert.False(t, deferred)
+}
@@ -0,0 +1,59 @@
+// Copyright 2021 FerretDB Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package pgdb
+
+import (
+	"github.com/FerretDB/FerretDB/internal/types"
+	"github.com/FerretDB/FerretDB/internal/util/must"
+)
+
+// Clustered collections return documents in _id order when no sort is given,
+// as MongoDB clustered collections store them ordered by the clustered index key.
+// Tables of such collections are regular tables with the same unique _id index;
+// scans without sort are ordered by it. Clustered index names are recorded in the settings table.
+
+// clusteredSort is the sort document used for scans of clustered collections without sort.
+var clusteredSort = must.NotFail(types.NewDocument("_id", int32(1)))
+
+// getClusteredIndex returns the name of the clustered index of the given collection from the settings document,
+// or empty string if the collection is not clustered.
+func getClusteredIndex(settings *types.Document, collection string) string {
+	all, ok := getSettingsDocument(settings, "clustered")
+	if !ok {
+		return ""
+	}
+
+	v, _ := all.Get(collection)
+	res, _ := v.(string)
+
+	return res
+}
+
+// setClusteredIndex records the name of the clustered index of the given collection in the settings document.
+// Empty name removes the collection from the record.
+func setClusteredIndex(settings *types.Document, collection, name string) {
+	all, ok := getSettingsDocument(settings, "clustered")
+	if !ok {
+		all = must.NotFail(types.NewDocument())
+	}
+
+	if name != "" {
+		must.NoError(all.Set(collection, name))
+	} else {
+		all.Remove(collection)
+	}
+
+	must.NoError(settings.Set("clustered", all))
+}
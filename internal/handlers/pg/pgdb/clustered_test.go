@@ -0,0 +1,45 @@
+// Copyright 2021 FerretDB Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package pgdb
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/FerretDB/FerretDB/internal/types"
+	"github.com/FerretDB/FerretDB/internal/util/must"
+)
+
+func TestClusteredIndexSettings(t *testing.T) {
+	t.Parallel()
+
+	settings := must.NotFail(types.NewDocument(
+		"collections", must.NotFail(types.NewDocument("test", "test_1", "other", "other_2")),
+	))
+	assert.Empty(t, getClusteredIndex(settings, "test"))
+
+	setClusteredIndex(settings, "test", "_id_")
+	assert.Equal(t, "_id_", getClusteredIndex(settings, "test"))
+	assert.Empty(t, getClusteredIndex(settings, "other"))
+
+	ti, err := tableInfoFromSettings(settings, "test", "test_1")
+	require.NoError(t, err)
+	assert.Equal(t, "_id_", ti.clusteredIndex)
+
+	setClusteredIndex(settings, "test", "")
+	assert.Empty(t, getClusteredIndex(settings, "test"))
+}
@@ -0,0 +1,121 @@
+// Copyright 2021 FerretDB Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package pgdb
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+
+	"github.com/jackc/pgconn"
+	"github.com/jackc/pgerrcode"
+
+	"github.com/FerretDB/FerretDB/internal/handlers/backend"
+)
+
+func init() {
+	backend.RegisterErrorTranslator(translateError)
+}
+
+// errorMapping represents backend error code and safe message for PostgreSQL error.
+type errorMapping struct {
+	code backend.ErrorCode
+	msg  string
+}
+
+// pgErrors maps PostgreSQL error codes (SQLSTATE) to backend errors.
+// Errors from the same class as the code with "000" suffix are mapped to the same error,
+// unless there is a more specific entry.
+var pgErrors = map[string]errorMapping{
+	pgerrcode.ConnectionException: {backend.ErrorCodeUnavailable, "PostgreSQL connection failed"},
+	pgerrcode.AdminShutdown:       {backend.ErrorCodeUnavailable, "PostgreSQL is shutting down"},
+	pgerrcode.CrashShutdown:       {backend.ErrorCodeUnavailable, "PostgreSQL is shutting down"},
+	pgerrcode.CannotConnectNow:    {backend.ErrorCodeUnavailable, "PostgreSQL is not accepting connections"},
+	pgerrcode.TooManyConnections:  {backend.ErrorCodeUnavailable, "PostgreSQL has too many connections"},
+	pgerrcode.DiskFull:            {backend.ErrorCodeOutOfDiskSpace, "PostgreSQL is out of disk space"},
+
+	pgerrcode.SerializationFailure: {backend.ErrorCodeWriteConflict, "PostgreSQL serialization failure"},
+	pgerrcode.DeadlockDetected:     {backend.ErrorCodeWriteConflict, "PostgreSQL deadlock detected"},
+
+	pgerrcode.UniqueViolation: {backend.ErrorCodeDuplicateKey, "PostgreSQL unique violation"},
+	pgerrcode.QueryCanceled:   {backend.ErrorCodeTimeout, "PostgreSQL query canceled"},
+
+	pgerrcode.InsufficientPrivilege:  {backend.ErrorCodeUnauthorized, "PostgreSQL insufficient privilege"},
+	pgerrcode.InvalidPassword:        {backend.ErrorCodeAuthenticationFailed, "PostgreSQL authentication failed"},
+	pgerrcode.ReadOnlySQLTransaction: {backend.ErrorCodeReadOnly, "PostgreSQL is read-only"},
+	pgerrcode.InvalidCatalogName:     {backend.ErrorCodeDatabaseNotExist, "PostgreSQL database does not exist"},
+	pgerrcode.UndefinedTable:         {backend.ErrorCodeCollectionNotExist, "PostgreSQL table does not exist"},
+	pgerrcode.InsufficientResources:  {backend.ErrorCodeUnavailable, "PostgreSQL has insufficient resources"},
+	pgerrcode.OperatorIntervention:   {backend.ErrorCodeUnavailable, "PostgreSQL is not available"},
+
+	pgerrcode.InvalidAuthorizationSpecification: {
+		backend.ErrorCodeAuthenticationFailed, "PostgreSQL authentication failed",
+	},
+	pgerrcode.SQLClientUnableToEstablishSQLConnection: {
+		backend.ErrorCodeUnavailable, "PostgreSQL connection failed",
+	},
+}
+
+// pgconnPkgPath is the package path of PostgreSQL driver's low-level errors.
+var pgconnPkgPath = reflect.TypeOf(pgconn.PgError{}).PkgPath()
+
+// translateError returns backend error for the error caused by PostgreSQL or its driver, or nil.
+//
+// It implements backend.ErrorTranslator.
+func translateError(err error) *backend.Error {
+	if !fromPostgreSQL(err) {
+		return nil
+	}
+
+	var pgErr *pgconn.PgError
+	if !errors.As(err, &pgErr) {
+		// connection failures, I/O errors, and timeouts
+		if pgconn.Timeout(err) {
+			return backend.NewError(backend.ErrorCodeTimeout, "PostgreSQL query timed out", err)
+		}
+
+		return backend.NewError(backend.ErrorCodeUnavailable, "PostgreSQL connection failed", err)
+	}
+
+	if m, ok := pgErrors[pgErr.Code]; ok {
+		return backend.NewError(m.code, m.msg, err)
+	}
+
+	if len(pgErr.Code) == 5 {
+		if m, ok := pgErrors[pgErr.Code[:2]+"000"]; ok {
+			return backend.NewError(m.code, m.msg, err)
+		}
+	}
+
+	msg := fmt.Sprintf("PostgreSQL error (SQLSTATE %s)", pgErr.Code)
+
+	return backend.NewError(backend.ErrorCodeInternal, msg, err)
+}
+
+// fromPostgreSQL returns true if the error is caused by PostgreSQL or its driver.
+func fromPostgreSQL(err error) bool {
+	for e := err; e != nil; e = errors.Unwrap(e) {
+		t := reflect.TypeOf(e)
+		if t.Kind() == reflect.Pointer {
+			t = t.Elem()
+		}
+
+		if t.PkgPath() == pgconnPkgPath {
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,85 @@
+// Copyright 2021 FerretDB Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package pgdb
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/jackc/pgconn"
+	"github.com/jackc/pgerrcode"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/FerretDB/FerretDB/internal/handlers/backend"
+	"github.com/FerretDB/FerretDB/internal/util/lazyerrors"
+)
+
+func TestTranslateError(t *testing.T) {
+	t.Parallel()
+
+	pgErr := func(code string) error {
+		return &pgconn.PgError{
+			Severity: "ERROR",
+			Code:     code,
+			Message:  `relation "secret_table" does not exist`,
+		}
+	}
+
+	for name, tc := range map[string]struct {
+		err  error
+		code backend.ErrorCode
+		msg  string
+	}{
+		"Known": {
+			err:  lazyerrors.Error(pgErr(pgerrcode.UniqueViolation)),
+			code: backend.ErrorCodeDuplicateKey,
+			msg:  "PostgreSQL unique violation",
+		},
+		"Class": {
+			err:  lazyerrors.Error(pgErr(pgerrcode.ProtocolViolation)),
+			code: backend.ErrorCodeUnavailable,
+			msg:  "PostgreSQL connection failed",
+		},
+		"TableNotExist": {
+			err:  lazyerrors.Error(pgErr(pgerrcode.UndefinedTable)),
+			code: backend.ErrorCodeCollectionNotExist,
+			msg:  "PostgreSQL table does not exist",
+		},
+		"Unknown": {
+			err:  lazyerrors.Error(pgErr(pgerrcode.UndefinedColumn)),
+			code: backend.ErrorCodeInternal,
+			msg:  "PostgreSQL error (SQLSTATE 42703)",
+		},
+	} {
+		name, tc := name, tc
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			e := backend.TranslateError(tc.err)
+			require.NotNil(t, e)
+			assert.Equal(t, tc.code, e.Code())
+			assert.Equal(t, tc.msg, e.Message())
+			assert.NotContains(t, e.Message(), "secret_table")
+			assert.True(t, errors.Is(e, tc.err))
+		})
+	}
+
+	t.Run("Other", func(t *testing.T) {
+		t.Parallel()
+
+		assert.Nil(t, translateError(errors.New("oops")))
+	})
+}
@@ -0,0 +1,189 @@
+// Copyright 2021 FerretDB Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package pgdb
+
+import (
+	"encoding/json"
+	"strings"
+	"time"
+
+	"github.com/jackc/pgx/v4"
+
+	"github.com/FerretDB/FerretDB/internal/fjson"
+	"github.com/FerretDB/FerretDB/internal/types"
+	"github.com/FerretDB/FerretDB/internal/util/must"
+)
+
+// pushdownFunc returns SQL condition for the given top-level field and operator value.
+//
+// Empty condition is returned if the operator can't be pushed down for that value.
+// If the condition is exact (matches the same documents as the operator), true is returned,
+// and the operator is removed from the residual filter. Otherwise, the condition should match
+// a superset of matching documents, and the operator is kept in the residual filter.
+type pushdownFunc func(b *filterBuilder, field string, value any) (string, bool)
+
+// pushdownOperators is a registry of filter operators that could be pushed down to PostgreSQL.
+var pushdownOperators = map[string]pushdownFunc{
+	"$eq":  pushdownEq,
+	"$gt":  pushdownTimeField(">"),
+	"$gte": pushdownTimeField(">="),
+	"$lt":  pushdownTimeField("<"),
+	"$lte": pushdownTimeField("<="),
+}
+
+// filterBuilder builds SQL WHERE conditions for filters.
+type filterBuilder struct {
+	table *tableInfo
+	p     *Placeholder
+	args  []any
+}
+
+// arg adds a query argument and returns its placeholder.
+func (b *filterBuilder) arg(v any) string {
+	b.args = append(b.args, v)
+	return b.p.Next()
+}
+
+// buildFilter converts the given filter to SQL condition for WHERE clause and its arguments
+// using placeholders starting after p.
+//
+// It also returns the residual filter with parts of the given filter that were not pushed down;
+// it should be applied to fetched documents.
+// Empty condition is returned if nothing was pushed down.
+func buildFilter(table *tableInfo, p *Placeholder, filter *types.Document) (string, []any, *types.Document) {
+	b := &filterBuilder{
+		table: table,
+		p:     p,
+	}
+
+	residual := must.NotFail(types.NewDocument())
+	if filter == nil {
+		return "", nil, residual
+	}
+
+	// stubs of large documents always have _id, but other fields could be missing
+	var conditions, stubConditions []string
+
+	for _, field := range filter.Keys() {
+		value := must.NotFail(filter.Get(field))
+
+		// top-level operators like $and, and dot notation are not supported yet
+		if strings.HasPrefix(field, "$") || strings.Contains(field, ".") {
+			must.NoError(residual.Set(field, value))
+			continue
+		}
+
+		ops, isOps := operatorsDocument(value)
+		if !isOps {
+			ops = must.NotFail(types.NewDocument("$eq", value))
+		}
+
+		rest := must.NotFail(types.NewDocument())
+		for _, op := range ops.Keys() {
+			opValue := must.NotFail(ops.Get(op))
+
+			var cond string
+			var exact bool
+			if f := pushdownOperators[op]; f != nil {
+				cond, exact = f(b, field, opValue)
+			}
+
+			if cond != "" {
+				if b.table.large && field != "_id" {
+					stubConditions = append(stubConditions, cond)
+				} else {
+					conditions = append(conditions, cond)
+				}
+			}
+
+			if !exact {
+				must.NoError(rest.Set(op, opValue))
+			}
+		}
+
+		if rest.Len() == 0 {
+			continue
+		}
+
+		if !isOps {
+			must.NoError(residual.Set(field, value))
+			continue
+		}
+
+		must.NoError(residual.Set(field, rest))
+	}
+
+	if len(stubConditions) > 0 {
+		cond := `(` + strings.Join(stubConditions, " AND ") + `) OR ` + pgx.Identifier{largeColumn}.Sanitize() + ` IS NOT NULL`
+		conditions = append(conditions, `(`+cond+`)`)
+
+		// large documents match that condition, so the whole filter should be applied to them
+		residual = filter
+	}
+
+	return strings.Join(conditions, " AND "), b.args, residual
+}
+
+// operatorsDocument returns the value as a document if it is a non-empty document of operators.
+func operatorsDocument(value any) (*types.Document, bool) {
+	doc, ok := value.(*types.Document)
+	if !ok || doc.Len() == 0 {
+		return nil, false
+	}
+
+	for _, k := range doc.Keys() {
+		if !strings.HasPrefix(k, "$") {
+			return nil, false
+		}
+	}
+
+	return doc, true
+}
+
+// pushdownEq handles $eq operator and implicit equality.
+func pushdownEq(b *filterBuilder, field string, value any) (string, bool) {
+	switch value := value.(type) {
+	case string, bool:
+		// Strings and booleans have the same representation in all formats.
+		// jsonb containment matches a scalar in an array only at the top level of the right operand,
+		// so equal scalar values and arrays with equal elements are matched by separate conditions, like MongoDB.
+		// Nested arrays and documents are not matched.
+		// The whole document is checked, so the GIN index could be used (see createGINIndex).
+		v := must.NotFail(fjson.MarshalVersion(value, b.table.format))
+		scalar := must.NotFail(json.Marshal(map[string]json.RawMessage{field: v}))
+		array := must.NotFail(json.Marshal(map[string][]json.RawMessage{field: {v}}))
+		return "(_jsonb @> " + b.arg(scalar) + "::jsonb OR _jsonb @> " + b.arg(array) + "::jsonb)", true
+
+	case types.ObjectID:
+		// _id can't be an array, so simple equality is enough;
+		// for other fields, arrays of documents with extra fields would be matched by containment.
+		if field != "_id" {
+			return "", false
+		}
+
+		placeholders := make([]string, 0, 2)
+		for _, arg := range b.table.idArgs(value) {
+			placeholders = append(placeholders, b.arg(arg))
+		}
+
+		return "_jsonb->'_id' IN (" + strings.Join(placeholders, ", ") + ")", true
+
+	case time.Time:
+		return pushdownTimeField("=")(b, field, value)
+
+	default:
+		return "", false
+	}
+}
@@ -0,0 +1,125 @@
+// Copyright 2021 FerretDB Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package pgdb
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/FerretDB/FerretDB/internal/fjson"
+	"github.com/FerretDB/FerretDB/internal/types"
+	"github.com/FerretDB/FerretDB/internal/util/must"
+)
+
+func TestBuildFilter(t *testing.T) {
+	t.Parallel()
+
+	objectID := types.ObjectID{0x62, 0x56, 0xc5, 0xba, 0x0d, 0xad, 0xc0, 0xde, 0xfe, 0xed, 0xbe, 0xef}
+	objectIDArg := must.NotFail(fjson.Marshal(objectID))
+
+	for name, tc := range map[string]struct {
+		filter   *types.Document
+		legacy   fjson.Version
+		large    bool
+		where    string
+		args     []any
+		residual *types.Document
+	}{
+		"Nil": {
+			residual: must.NotFail(types.NewDocument()),
+		},
+		"String": {
+			filter:   must.NotFail(types.NewDocument("v", "foo")),
+			where:    `(_jsonb @> $2::jsonb OR _jsonb @> $3::jsonb)`,
+			args:     []any{[]byte(`{"v":"foo"}`), []byte(`{"v":["foo"]}`)},
+			residual: must.NotFail(types.NewDocument()),
+		},
+		"EqBool": {
+			filter:   must.NotFail(types.NewDocument("v", must.NotFail(types.NewDocument("$eq", true)))),
+			where:    `(_jsonb @> $2::jsonb OR _jsonb @> $3::jsonb)`,
+			args:     []any{[]byte(`{"v":true}`), []byte(`{"v":[true]}`)},
+			residual: must.NotFail(types.NewDocument()),
+		},
+		"ObjectID": {
+			filter:   must.NotFail(types.NewDocument("_id", objectID)),
+			where:    `_jsonb->'_id' IN ($2)`,
+			args:     []any{objectIDArg},
+			residual: must.NotFail(types.NewDocument()),
+		},
+		"ObjectIDMigration": {
+			filter:   must.NotFail(types.NewDocument("_id", objectID)),
+			legacy:   fjson.Version1,
+			where:    `_jsonb->'_id' IN ($2, $3)`,
+			args:     []any{objectIDArg, objectIDArg},
+			residual: must.NotFail(types.NewDocument()),
+		},
+		"ObjectIDNotID": {
+			filter:   must.NotFail(types.NewDocument("v", objectID)),
+			residual: must.NotFail(types.NewDocument("v", objectID)),
+		},
+		"Number": {
+			filter:   must.NotFail(types.NewDocument("v", int32(42))),
+			residual: must.NotFail(types.NewDocument("v", int32(42))),
+		},
+		"Mixed": {
+			filter: must.NotFail(types.NewDocument(
+				"$comment", "test",
+				"a", "foo",
+				"b.c", "bar",
+				"d", must.NotFail(types.NewDocument("$eq", "baz", "$ne", "qux")),
+				"e", must.NotFail(types.NewDocument("$gt", int32(1))),
+			)),
+			where: `(_jsonb @> $2::jsonb OR _jsonb @> $3::jsonb) AND (_jsonb @> $4::jsonb OR _jsonb @> $5::jsonb)`,
+			args: []any{
+				[]byte(`{"a":"foo"}`), []byte(`{"a":["foo"]}`),
+				[]byte(`{"d":"baz"}`), []byte(`{"d":["baz"]}`),
+			},
+			residual: must.NotFail(types.NewDocument(
+				"$comment", "test",
+				"b.c", "bar",
+				"d", must.NotFail(types.NewDocument("$ne", "qux")),
+				"e", must.NotFail(types.NewDocument("$gt", int32(1))),
+			)),
+		},
+		"LargeID": {
+			filter:   must.NotFail(types.NewDocument("_id", objectID)),
+			large:    true,
+			where:    `_jsonb->'_id' IN ($2)`,
+			args:     []any{objectIDArg},
+			residual: must.NotFail(types.NewDocument()),
+		},
+		"LargeMixed": {
+			filter:   must.NotFail(types.NewDocument("_id", objectID, "v", "foo", "w", int32(42))),
+			large:    true,
+			where:    `_jsonb->'_id' IN ($2) AND (((_jsonb @> $3::jsonb OR _jsonb @> $4::jsonb)) OR "_large" IS NOT NULL)`,
+			args:     []any{objectIDArg, []byte(`{"v":"foo"}`), []byte(`{"v":["foo"]}`)},
+			residual: must.NotFail(types.NewDocument("_id", objectID, "v", "foo", "w", int32(42))),
+		},
+	} {
+		name, tc := name, tc
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			table := &tableInfo{name: "test", format: fjson.Version2, legacy: tc.legacy, large: tc.large}
+			p := Placeholder(1)
+
+			where, args, residual := buildFilter(table, &p, tc.filter)
+			assert.Equal(t, tc.where, where)
+			assert.Equal(t, tc.args, args)
+			assert.Equal(t, tc.residual, residual)
+		})
+	}
+}
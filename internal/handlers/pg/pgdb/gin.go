@@ -0,0 +1,60 @@
+// Copyright 2021 FerretDB Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package pgdb
+
+import (
+	"context"
+
+	"github.com/jackc/pgx/v4"
+
+	"github.com/FerretDB/FerretDB/internal/util/lazyerrors"
+)
+
+// ginIndexName is a name of the internal GIN index returned by Indexes.
+//
+// PostgreSQL index itself has a generated name.
+const ginIndexName = "_ferretdb_jsonb_gin"
+
+// ginIndexDef is a part of PostgreSQL index definition that identifies the GIN index on the _jsonb column.
+//
+// jsonb_path_ops operator class supports only containment operator @>,
+// but indexes are smaller and faster than default ones.
+const ginIndexDef = `USING gin (_jsonb jsonb_path_ops)`
+
+// createGINIndex creates a GIN index on the _jsonb column of the given table.
+//
+// It is created only for new collections when NewPoolOpts.GINIndex is set.
+func createGINIndex(ctx context.Context, tx pgx.Tx, db, table string) error {
+	sql := `CREATE INDEX ON ` + pgx.Identifier{db, table}.Sanitize() + ` ` + ginIndexDef
+	if _, err := tx.Exec(ctx, sql); err != nil {
+		return lazyerrors.Error(err)
+	}
+
+	return nil
+}
+
+// hasGINIndex returns true if the given table has a GIN index on the _jsonb column.
+//
+// Tables created before GINIndex option was enabled don't have it.
+func hasGINIndex(ctx context.Context, tx pgx.Tx, db, table string) (bool, error) {
+	sql := `SELECT EXISTS(SELECT 1 FROM pg_indexes WHERE schemaname = $1 AND tablename = $2 AND indexdef LIKE $3)`
+
+	var res bool
+	if err := tx.QueryRow(ctx, sql, db, table, `% `+ginIndexDef).Scan(&res); err != nil {
+		return false, lazyerrors.Error(err)
+	}
+
+	return res, nil
+}
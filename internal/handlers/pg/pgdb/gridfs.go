@@ -0,0 +1,131 @@
+// Copyright 2021 FerretDB Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package pgdb
+
+import (
+	"context"
+	"strings"
+
+	"github.com/jackc/pgx/v4"
+
+	"github.com/FerretDB/FerretDB/internal/types"
+	"github.com/FerretDB/FerretDB/internal/util/lazyerrors"
+	"github.com/FerretDB/FerretDB/internal/util/must"
+)
+
+// GridFS stores files in two collections of a bucket: `<bucket>.files` with metadata,
+// and `<bucket>.chunks` with documents like {_id, files_id, n, data} where data is a generic binary value
+// up to 255 KiB by default. Storing those values as base64 strings in jsonb bloats tables and TOAST,
+// and spends CPU on encoding and compression of data that is rarely compressible.
+//
+// Tables of chunks collections created by this version have an additional bytea column
+// that stores chunk data as is; the rest of the document stays in the _jsonb column.
+// Tables created by previous versions don't have it; that's recorded in the settings table.
+
+// gridFSDataColumn is a name of the column that stores GridFS chunk data.
+const gridFSDataColumn = "_data"
+
+// gridFSDataField is a name of the chunk document field stored in gridFSDataColumn.
+const gridFSDataField = "data"
+
+// isGridFSChunks returns true if the given collection looks like GridFS bucket chunks collection.
+func isGridFSChunks(collection string) bool {
+	bucket := strings.TrimSuffix(collection, ".chunks")
+	return bucket != "" && bucket != collection
+}
+
+// gridFSColumnDefinition returns column definition for CREATE TABLE statement.
+func gridFSColumnDefinition() string {
+	return pgx.Identifier{gridFSDataColumn}.Sanitize() + ` bytea`
+}
+
+// setGridFSStorage stores chunk data of the given table out of line and uncompressed.
+func setGridFSStorage(ctx context.Context, tx pgx.Tx, db, table string) error {
+	sql := `ALTER TABLE ` + pgx.Identifier{db, table}.Sanitize() +
+		` ALTER COLUMN ` + pgx.Identifier{gridFSDataColumn}.Sanitize() + ` SET STORAGE EXTERNAL`
+	if _, err := tx.Exec(ctx, sql); err != nil {
+		return lazyerrors.Error(err)
+	}
+
+	return nil
+}
+
+// splitChunk returns the document without chunk data and that data.
+//
+// Data is split only if it is the last field with generic binary value, as written by drivers,
+// so appending it back in joinChunk restores the same document.
+// Otherwise, the document is returned as is with nil data.
+func splitChunk(doc *types.Document) (*types.Document, []byte) {
+	keys := doc.Keys()
+	if len(keys) == 0 || keys[len(keys)-1] != gridFSDataField {
+		return doc, nil
+	}
+
+	data, ok := must.NotFail(doc.Get(gridFSDataField)).(types.Binary)
+	if !ok || data.Subtype != types.BinaryGeneric {
+		return doc, nil
+	}
+
+	res := must.NotFail(types.NewDocument())
+	for _, k := range keys[:len(keys)-1] {
+		must.NoError(res.Set(k, must.NotFail(doc.Get(k))))
+	}
+
+	// distinguish empty data from no data
+	if data.B == nil {
+		data.B = []byte{}
+	}
+
+	return res, data.B
+}
+
+// joinChunk appends chunk data split by splitChunk back to the document.
+// Nil data means that the document was not split.
+func joinChunk(doc *types.Document, data []byte) {
+	if data == nil {
+		return
+	}
+
+	must.NoError(doc.Set(gridFSDataField, types.Binary{Subtype: types.BinaryGeneric, B: data}))
+}
+
+// getGridFS returns true if chunk data of the given collection is stored in gridFSDataColumn.
+func getGridFS(settings *types.Document, collection string) bool {
+	all, ok := getSettingsDocument(settings, "gridfs")
+	if !ok {
+		return false
+	}
+
+	v, _ := all.Get(collection)
+	res, _ := v.(bool)
+
+	return res
+}
+
+// setGridFS records whether chunk data of the given collection is stored in gridFSDataColumn.
+func setGridFS(settings *types.Document, collection string, gridFS bool) {
+	all, ok := getSettingsDocument(settings, "gridfs")
+	if !ok {
+		all = must.NotFail(types.NewDocument())
+	}
+
+	if gridFS {
+		must.NoError(all.Set(collection, true))
+	} else {
+		all.Remove(collection)
+	}
+
+	must.NoError(settings.Set("gridfs", all))
+}
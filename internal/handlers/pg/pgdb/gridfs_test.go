@@ -0,0 +1,98 @@
+// Copyright 2021 FerretDB Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package pgdb
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/FerretDB/FerretDB/internal/types"
+	"github.com/FerretDB/FerretDB/internal/util/must"
+)
+
+func TestIsGridFSChunks(t *testing.T) {
+	t.Parallel()
+
+	assert.True(t, isGridFSChunks("fs.chunks"))
+	assert.True(t, isGridFSChunks("photos.chunks"))
+	assert.False(t, isGridFSChunks("fs.files"))
+	assert.False(t, isGridFSChunks(".chunks"))
+	assert.False(t, isGridFSChunks("chunks"))
+}
+
+func TestSplitChunk(t *testing.T) {
+	t.Parallel()
+
+	for name, tc := range map[string]struct {
+		doc  *types.Document
+		rest *types.Document // nil if the document is not split
+		data []byte
+	}{
+		"Chunk": {
+			doc: must.NotFail(types.NewDocument(
+				"_id", int32(1), "n", int32(0), "data", types.Binary{B: []byte{1, 2}},
+			)),
+			rest: must.NotFail(types.NewDocument("_id", int32(1), "n", int32(0))),
+			data: []byte{1, 2},
+		},
+		"Empty": {
+			doc:  must.NotFail(types.NewDocument("_id", int32(1), "data", types.Binary{B: []byte{}})),
+			rest: must.NotFail(types.NewDocument("_id", int32(1))),
+			data: []byte{},
+		},
+		"NotLast": {
+			doc: must.NotFail(types.NewDocument("_id", int32(1), "data", types.Binary{B: []byte{1}}, "n", int32(0))),
+		},
+		"Subtype": {
+			doc: must.NotFail(types.NewDocument("_id", int32(1), "data", types.Binary{Subtype: types.BinaryUser})),
+		},
+		"String": {
+			doc: must.NotFail(types.NewDocument("_id", int32(1), "data", "foo")),
+		},
+	} {
+		name, tc := name, tc
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			rest, data := splitChunk(tc.doc)
+			if tc.rest == nil {
+				assert.Same(t, tc.doc, rest)
+				assert.Nil(t, data)
+				return
+			}
+
+			assert.Equal(t, tc.rest, rest)
+			assert.Equal(t, tc.data, data)
+
+			joinChunk(rest, data)
+			assert.Equal(t, tc.doc, rest)
+		})
+	}
+}
+
+func TestGridFSSettings(t *testing.T) {
+	t.Parallel()
+
+	settings := must.NotFail(types.NewDocument())
+	assert.False(t, getGridFS(settings, "fs.chunks"))
+
+	setGridFS(settings, "fs.chunks", true)
+	assert.True(t, getGridFS(settings, "fs.chunks"))
+	assert.False(t, getGridFS(settings, "fs.files"))
+
+	setGridFS(settings, "fs.chunks", false)
+	assert.False(t, getGridFS(settings, "fs.chunks"))
+}
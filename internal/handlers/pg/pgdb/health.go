@@ -0,0 +1,257 @@
+// Copyright 2021 FerretDB Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package pgdb
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"go.uber.org/zap"
+)
+
+// ErrUnreachable indicates that PostgreSQL is considered unreachable by HealthChecker.
+var ErrUnreachable = errors.New("PostgreSQL is unreachable")
+
+// HealthCheckerOpts represents HealthChecker configuration.
+type HealthCheckerOpts struct {
+	// Interval between probes; it is also the probe timeout. Must be positive.
+	Interval time.Duration
+
+	// Number of consecutive failed probes after which PostgreSQL is considered unreachable; 1 if not positive.
+	FailureThreshold int
+
+	// If set, it is called after PostgreSQL becomes reachable again,
+	// so connections of other pools could be re-established too.
+	OnRecover func()
+}
+
+// HealthChecker periodically probes PostgreSQL and acts as a circuit breaker.
+//
+// After the given number of consecutive failed probes, PostgreSQL is considered unreachable,
+// and Err returns an error immediately instead of letting requests wait for connection or query timeouts.
+// The next successful probe closes the circuit again.
+//
+// Idle connections of the probed pool are broken after PostgreSQL restart,
+// so they are closed after any failed probes followed by a successful one;
+// pgx then establishes new connections on demand.
+type HealthChecker struct {
+	l         *zap.Logger
+	interval  time.Duration
+	threshold int
+	ping      func(context.Context) error
+	reset     func(context.Context) int
+	onRecover func()
+
+	m        sync.Mutex
+	failures int   // consecutive failed probes
+	err      error // the last probe error if unreachable, nil otherwise
+
+	stop chan struct{}
+	done chan struct{}
+
+	up       prometheus.Gauge
+	probes   *prometheus.CounterVec
+	rejected prometheus.Counter
+}
+
+// NewHealthChecker creates a new HealthChecker for the given pool and starts probing it in the background.
+//
+// Close should be called when it is no longer needed.
+func NewHealthChecker(pool *Pool, l *zap.Logger, opts *HealthCheckerOpts) *HealthChecker {
+	hc := newHealthChecker(pool.Ping, pool.CloseIdleConns, l, opts)
+
+	go hc.run()
+
+	return hc
+}
+
+// newHealthChecker creates a new HealthChecker with the given probe and reset functions without starting it.
+func newHealthChecker(
+	ping func(context.Context) error, reset func(context.Context) int, l *zap.Logger, opts *HealthCheckerOpts,
+) *HealthChecker {
+	threshold := opts.FailureThreshold
+	if threshold <= 0 {
+		threshold = 1
+	}
+
+	hc := &HealthChecker{
+		l:         l.Named("pg.HealthChecker"),
+		interval:  opts.Interval,
+		threshold: threshold,
+		ping:      ping,
+		reset:     reset,
+		onRecover: opts.OnRecover,
+		stop:      make(chan struct{}),
+		done:      make(chan struct{}),
+		up: prometheus.NewGauge(
+			prometheus.GaugeOpts{
+				Namespace: "ferretdb",
+				Subsystem: "postgresql",
+				Name:      "up",
+				Help:      "Whether PostgreSQL is considered reachable by health checks.",
+			},
+		),
+		probes: prometheus.NewCounterVec(
+			prometheus.CounterOpts{
+				Namespace: "ferretdb",
+				Subsystem: "postgresql",
+				Name:      "health_checks_total",
+				Help:      "Total number of PostgreSQL health checks.",
+			},
+			[]string{"result"},
+		),
+		rejected: prometheus.NewCounter(
+			prometheus.CounterOpts{
+				Namespace: "ferretdb",
+				Subsystem: "postgresql",
+				Name:      "unreachable_rejected_total",
+				Help:      "Total number of requests rejected while PostgreSQL was considered unreachable.",
+			},
+		),
+	}
+
+	hc.up.Set(1)
+
+	return hc
+}
+
+// run probes PostgreSQL until Close is called.
+func (hc *HealthChecker) run() {
+	defer close(hc.done)
+
+	ticker := time.NewTicker(hc.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-hc.stop:
+			return
+		case <-ticker.C:
+		}
+
+		ctx, cancel := context.WithTimeout(context.Background(), hc.interval)
+		hc.probe(ctx)
+		cancel()
+	}
+}
+
+// probe pings PostgreSQL once and updates the circuit state.
+func (hc *HealthChecker) probe(ctx context.Context) {
+	err := hc.ping(ctx)
+
+	hc.m.Lock()
+
+	if err != nil {
+		hc.probes.WithLabelValues("error").Inc()
+
+		hc.failures++
+		if hc.failures >= hc.threshold {
+			if hc.err == nil {
+				hc.l.Error("PostgreSQL is unreachable, rejecting requests.", zap.Error(err))
+				hc.up.Set(0)
+			}
+
+			hc.err = err
+		} else {
+			hc.l.Warn("PostgreSQL health check failed.", zap.Int("failures", hc.failures), zap.Error(err))
+		}
+
+		hc.m.Unlock()
+
+		return
+	}
+
+	hc.probes.WithLabelValues("ok").Inc()
+
+	failures, unreachable := hc.failures, hc.err != nil
+	hc.failures = 0
+	hc.err = nil
+	hc.up.Set(1)
+
+	hc.m.Unlock()
+
+	if failures == 0 {
+		return
+	}
+
+	closed := hc.reset(ctx)
+
+	if unreachable {
+		hc.l.Info("PostgreSQL is reachable again.", zap.Int("closed_conns", closed))
+	} else {
+		hc.l.Info("PostgreSQL health check succeeded after failures.", zap.Int("closed_conns", closed))
+	}
+
+	if hc.onRecover != nil {
+		hc.onRecover()
+	}
+}
+
+// Err returns an error wrapping ErrUnreachable with the last probe error message if PostgreSQL is considered unreachable,
+// nil otherwise.
+//
+// It is safe to call on nil HealthChecker.
+func (hc *HealthChecker) Err() error {
+	if hc == nil {
+		return nil
+	}
+
+	hc.m.Lock()
+	err := hc.err
+	hc.m.Unlock()
+
+	if err == nil {
+		return nil
+	}
+
+	hc.rejected.Inc()
+
+	return fmt.Errorf("%w: %s", ErrUnreachable, err)
+}
+
+// Close stops probing and waits for the current probe to finish.
+//
+// It is safe to call on nil HealthChecker.
+func (hc *HealthChecker) Close() {
+	if hc == nil {
+		return
+	}
+
+	close(hc.stop)
+	<-hc.done
+}
+
+// Describe implements prometheus.Collector.
+func (hc *HealthChecker) Describe(ch chan<- *prometheus.Desc) {
+	hc.up.Describe(ch)
+	hc.probes.Describe(ch)
+	hc.rejected.Describe(ch)
+}
+
+// Collect implements prometheus.Collector.
+func (hc *HealthChecker) Collect(ch chan<- prometheus.Metric) {
+	hc.up.Collect(ch)
+	hc.probes.Collect(ch)
+	hc.rejected.Collect(ch)
+}
+
+// check interfaces
+var (
+	_ prometheus.Collector = (*HealthChecker)(nil)
+)
@@ -0,0 +1,122 @@
+// Copyright 2021 FerretDB Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package pgdb
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap/zaptest"
+)
+
+func TestHealthChecker(t *testing.T) {
+	t.Parallel()
+
+	t.Run("Nil", func(t *testing.T) {
+		t.Parallel()
+
+		var hc *HealthChecker
+		assert.NoError(t, hc.Err())
+		hc.Close()
+	})
+
+	t.Run("Circuit", func(t *testing.T) {
+		t.Parallel()
+
+		var pingErr error
+		var resets, recovers int
+		hc := newHealthChecker(
+			func(context.Context) error { return pingErr },
+			func(context.Context) int { resets++; return 2 },
+			zaptest.NewLogger(t),
+			&HealthCheckerOpts{
+				Interval:         time.Second,
+				FailureThreshold: 2,
+				OnRecover:        func() { recovers++ },
+			},
+		)
+
+		ctx := context.Background()
+
+		hc.probe(ctx)
+		require.NoError(t, hc.Err())
+		assert.Equal(t, float64(1), testutil.ToFloat64(hc.up))
+
+		// a single failure is below the threshold
+		pingErr = errors.New("connection refused")
+		hc.probe(ctx)
+		require.NoError(t, hc.Err())
+
+		// the circuit opens
+		hc.probe(ctx)
+		err := hc.Err()
+		require.ErrorIs(t, err, ErrUnreachable)
+		assert.Equal(t, "PostgreSQL is unreachable: connection refused", err.Error())
+		assert.Equal(t, float64(0), testutil.ToFloat64(hc.up))
+		assert.Equal(t, float64(1), testutil.ToFloat64(hc.rejected))
+		assert.Equal(t, 0, resets)
+
+		// the circuit closes, and idle connections are reset
+		pingErr = nil
+		hc.probe(ctx)
+		require.NoError(t, hc.Err())
+		assert.Equal(t, float64(1), testutil.ToFloat64(hc.up))
+		assert.Equal(t, 1, resets)
+		assert.Equal(t, 1, recovers)
+
+		// no reset without failures
+		hc.probe(ctx)
+		assert.Equal(t, 1, resets)
+
+		// a single failure below the threshold still resets idle connections after it
+		pingErr = errors.New("connection reset")
+		hc.probe(ctx)
+		pingErr = nil
+		hc.probe(ctx)
+		assert.Equal(t, 2, resets)
+		assert.Equal(t, 2, recovers)
+
+		assert.Equal(t, float64(3), testutil.ToFloat64(hc.probes.WithLabelValues("error")))
+		assert.Equal(t, float64(4), testutil.ToFloat64(hc.probes.WithLabelValues("ok")))
+	})
+
+	t.Run("Run", func(t *testing.T) {
+		t.Parallel()
+
+		probed := make(chan struct{}, 1)
+		hc := newHealthChecker(
+			func(context.Context) error {
+				select {
+				case probed <- struct{}{}:
+				default:
+				}
+				return nil
+			},
+			func(context.Context) int { return 0 },
+			zaptest.NewLogger(t),
+			&HealthCheckerOpts{Interval: 10 * time.Millisecond},
+		)
+
+		go hc.run()
+
+		<-probed
+		hc.Close()
+	})
+}
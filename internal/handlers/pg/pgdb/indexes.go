@@ -0,0 +1,320 @@
+// Copyright 2021 FerretDB Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package pgdb
+
+import (
+	"context"
+	"fmt"
+	"hash/fnv"
+	"strings"
+
+	"github.com/jackc/pgx/v4"
+
+	"github.com/FerretDB/FerretDB/internal/handlers/backend"
+	"github.com/FerretDB/FerretDB/internal/types"
+	"github.com/FerretDB/FerretDB/internal/util/lazyerrors"
+	"github.com/FerretDB/FerretDB/internal/util/must"
+)
+
+var (
+	// ErrIndexNotSupported indicates that index with such key can't be created.
+	ErrIndexNotSupported = backend.ErrIndexNotSupported
+
+	// ErrIndexKeyConflict indicates that index with the same name but different key already exists.
+	ErrIndexKeyConflict = backend.ErrIndexKeyConflict
+
+	// ErrIndexNameConflict indicates that index with the same key but different name already exists.
+	ErrIndexNameConflict = backend.ErrIndexNameConflict
+)
+
+// Index describes a collection index.
+type Index = backend.Index
+
+// Indexes returns a list of indexes of the given FerretDB collection.
+//
+// The default _id index is always returned first, like in MongoDB.
+// It returns ErrTableNotExist if schema or table does not exist.
+func (pgPool *Pool) Indexes(ctx context.Context, db, collection string) ([]Index, error) {
+	exists, err := pgPool.CollectionExists(ctx, db, collection)
+	if err != nil {
+		return nil, lazyerrors.Error(err)
+	}
+
+	if !exists {
+		return nil, ErrTableNotExist
+	}
+
+	res := []Index{{
+		Name: "_id_",
+		Key:  must.NotFail(types.NewDocument("_id", int32(1))),
+	}}
+
+	err = pgPool.BeginFunc(ctx, func(tx pgx.Tx) error {
+		table, err := pgPool.getTableName(ctx, tx, db, collection)
+		if err != nil {
+			return err
+		}
+
+		gin, err := hasGINIndex(ctx, tx, db, table)
+		if err != nil {
+			return err
+		}
+
+		if gin {
+			res = append(res, Index{
+				Name:     ginIndexName,
+				Key:      must.NotFail(types.NewDocument("$**", int32(1))),
+				Internal: true,
+			})
+		}
+
+		settings, err := pgPool.getSettingsTable(ctx, tx, db)
+		if err != nil {
+			return err
+		}
+
+		// the GIN index is still reported while it is deferred by a bulk import
+		if _, deferredGIN := getDeferredIndexes(settings, collection); deferredGIN && !gin {
+			res = append(res, Index{
+				Name:     ginIndexName,
+				Key:      must.NotFail(types.NewDocument("$**", int32(1))),
+				Internal: true,
+			})
+		}
+
+		indexes, err := getIndexes(settings, collection)
+		if err != nil {
+			return err
+		}
+
+		for _, name := range indexes.Keys() {
+			index := must.NotFail(indexes.Get(name)).(*types.Document)
+			res = append(res, Index{
+				Name: name,
+				Key:  must.NotFail(index.Get("key")).(*types.Document),
+			})
+		}
+
+		return nil
+	})
+	if err != nil {
+		return nil, lazyerrors.Error(err)
+	}
+
+	return res, nil
+}
+
+// CreateIndex creates an index with the given name and key for the given FerretDB collection.
+//
+// Only ascending or descending indexes on a single scalar path are supported.
+// For them, generated columns with number and string values of that path are added to the table,
+// and btree indexes are created on them. Unlike expression indexes on jsonb,
+// typed columns provide better selectivity estimates and range scans.
+// Generated columns are tracked in the settings table.
+//
+// It returns ErrAlreadyExist if the same index already exists, ErrIndexNotSupported if key is not supported,
+// ErrIndexKeyConflict or ErrIndexNameConflict if a different index with the same name or key exists,
+// and ErrTableNotExist if schema or table does not exist.
+func (pgPool *Pool) CreateIndex(ctx context.Context, db, collection, name string, key *types.Document) error {
+	path, ok := indexPath(key)
+	if !ok {
+		return ErrIndexNotSupported
+	}
+
+	if name == "_id_" || name == ginIndexName {
+		return ErrIndexKeyConflict
+	}
+
+	exists, err := pgPool.CollectionExists(ctx, db, collection)
+	if err != nil {
+		return lazyerrors.Error(err)
+	}
+
+	if !exists {
+		return ErrTableNotExist
+	}
+
+	// mongorestore creates indexes after restoring documents, so that is a good time to build deferred ones
+	if err = pgPool.finishBulkImport(ctx, db, collection); err != nil {
+		return lazyerrors.Error(err)
+	}
+
+	return pgPool.BeginFunc(ctx, func(tx pgx.Tx) error {
+		table, err := pgPool.getTableName(ctx, tx, db, collection)
+		if err != nil {
+			return err
+		}
+
+		settings, err := pgPool.getSettingsTable(ctx, tx, db)
+		if err != nil {
+			return err
+		}
+
+		indexes, err := getIndexes(settings, collection)
+		if err != nil {
+			return err
+		}
+
+		for _, existingName := range indexes.Keys() {
+			existing := must.NotFail(indexes.Get(existingName)).(*types.Document)
+			sameKey := sameIndexKey(must.NotFail(existing.Get("key")).(*types.Document), key)
+
+			switch {
+			case existingName == name && sameKey:
+				return ErrAlreadyExist
+			case existingName == name:
+				return ErrIndexKeyConflict
+			case sameKey:
+				return ErrIndexNameConflict
+			}
+		}
+
+		columns := indexColumns(name)
+		v := indexPathSQL(path)
+		exprs := sortValuesSQL(v)
+
+		ident := pgx.Identifier{db, table}.Sanitize()
+		sql := `ALTER TABLE ` + ident +
+			` ADD COLUMN ` + pgx.Identifier{columns[0]}.Sanitize() +
+			` float8 GENERATED ALWAYS AS (` + exprs[0] + `) STORED,` +
+			` ADD COLUMN ` + pgx.Identifier{columns[1]}.Sanitize() +
+			` text COLLATE "C" GENERATED ALWAYS AS (` + exprs[2] + `) STORED`
+		if _, err = tx.Exec(ctx, sql); err != nil {
+			return lazyerrors.Error(err)
+		}
+
+		for _, column := range columns {
+			indexName := formatCollectionName(table + "_" + column)
+			sql = `CREATE INDEX ` + pgx.Identifier{indexName}.Sanitize() + ` ON ` + ident +
+				` (` + pgx.Identifier{column}.Sanitize() + `)`
+			if _, err = tx.Exec(ctx, sql); err != nil {
+				return lazyerrors.Error(err)
+			}
+		}
+
+		columnsArray := must.NotFail(types.NewArray())
+		for _, column := range columns {
+			must.NoError(columnsArray.Append(column))
+		}
+
+		must.NoError(indexes.Set(name, must.NotFail(types.NewDocument(
+			"key", key,
+			"columns", columnsArray,
+		))))
+		setIndexes(settings, collection, indexes)
+
+		return pgPool.updateSettingsTable(ctx, tx, db, settings)
+	})
+}
+
+// indexPath returns the path of the ascending or descending index on a single field.
+func indexPath(key *types.Document) (string, bool) {
+	if key.Len() != 1 {
+		return "", false
+	}
+
+	path := key.Keys()[0]
+	if path == "_id" || strings.HasPrefix(path, "$") {
+		return "", false
+	}
+
+	for _, e := range strings.Split(path, ".") {
+		if e == "" {
+			return "", false
+		}
+	}
+
+	if sortOrder(must.NotFail(key.Get(path))) == 0 {
+		return "", false
+	}
+
+	return path, true
+}
+
+// sameIndexKey returns true if both index keys have the same paths and directions.
+func sameIndexKey(a, b *types.Document) bool {
+	if a.Len() != b.Len() {
+		return false
+	}
+
+	for _, path := range a.Keys() {
+		bv, err := b.Get(path)
+		if err != nil || sortOrder(must.NotFail(a.Get(path))) != sortOrder(bv) {
+			return false
+		}
+	}
+
+	return true
+}
+
+// indexPathSQL returns SQL expression that extracts jsonb value of the given dot notation path.
+//
+// It does not use placeholders because it is used in generated columns definitions.
+func indexPathSQL(path string) string {
+	res := `_jsonb`
+	for _, e := range strings.Split(path, ".") {
+		res += `->'` + strings.ReplaceAll(e, `'`, `''`) + `'`
+	}
+
+	return `(` + res + `)`
+}
+
+// indexColumns returns names of generated columns with number and string values for the given index name.
+func indexColumns(name string) []string {
+	hash32 := fnv.New32a()
+	_ = must.NotFail(hash32.Write([]byte(name)))
+	prefix := fmt.Sprintf("_idx_%x", hash32.Sum(nil))
+
+	return []string{prefix + "_num", prefix + "_str"}
+}
+
+// getIndexes returns indexes of the given collection from the "indexes" field of the settings document.
+//
+// Keys of the returned document are index names, values are documents with "key" and "columns" fields.
+func getIndexes(settings *types.Document, collection string) (*types.Document, error) {
+	all, ok := getSettingsDocument(settings, "indexes")
+	if !ok {
+		return must.NotFail(types.NewDocument()), nil
+	}
+
+	v, err := all.Get(collection)
+	if err != nil {
+		return must.NotFail(types.NewDocument()), nil
+	}
+
+	indexes, ok := v.(*types.Document)
+	if !ok {
+		return nil, lazyerrors.Errorf("expected document but got %[1]T: %[1]v", v)
+	}
+
+	return indexes, nil
+}
+
+// setIndexes sets indexes of the given collection in the "indexes" field of the settings document.
+// Nil or empty indexes remove the collection from that field.
+func setIndexes(settings *types.Document, collection string, indexes *types.Document) {
+	all, ok := getSettingsDocument(settings, "indexes")
+	if !ok {
+		all = must.NotFail(types.NewDocument())
+	}
+
+	if indexes == nil || indexes.Len() == 0 {
+		all.Remove(collection)
+	} else {
+		must.NoError(all.Set(collection, indexes))
+	}
+
+	must.NoError(settings.Set("indexes", all))
+}
@@ -0,0 +1,298 @@
+// Copyright 2021 FerretDB Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package pgdb
+
+import (
+	"context"
+	"io"
+	"strconv"
+	"strings"
+
+	"github.com/jackc/pgx/v4"
+
+	"github.com/FerretDB/FerretDB/internal/fjson"
+	"github.com/FerretDB/FerretDB/internal/handlers/backend"
+	"github.com/FerretDB/FerretDB/internal/types"
+	"github.com/FerretDB/FerretDB/internal/util/lazyerrors"
+)
+
+// iteratorBatchSize is the number of documents fetched from the server-side cursor at once.
+const iteratorBatchSize = 100
+
+// iteratorCursor is the name of the server-side cursor.
+//
+// Cursors are scoped by transactions, and each iterator uses its own transaction.
+const iteratorCursor = "ferretdb_iterator"
+
+// QueryParams represents parameters of QueryIterator and QueryDocuments.
+type QueryParams = backend.QueryParams
+
+// QueryResult represents the result of QueryDocuments.
+type QueryResult = backend.QueryResult
+
+// Iterator iterates over documents of FerretDB collection returned by QueryIterator.
+//
+// Documents are fetched in batches from a server-side cursor,
+// so they are never loaded into memory all at once.
+// Iterator must be closed after use; it is not safe for concurrent use.
+type Iterator struct {
+	ctx      context.Context
+	tx       pgx.Tx
+	residual *types.Document
+	sortKeys []string // nil if sort is not pushed down
+	gridFS   bool     // true if chunk data is fetched from a separate column
+	large    bool     // true if large documents are fetched from a separate column
+
+	batch  []*types.Document
+	done   bool // true if the cursor is exhausted
+	sorted bool
+	err    error
+}
+
+// QueryIterator returns an iterator over documents for given FerretDB database and collection.
+//
+// Parts of the filter that could be handled by PostgreSQL are pushed down (see buildFilter);
+// the residual filter (see Iterator.Residual) should be applied to returned documents.
+// Sort is pushed down if possible (see buildSort); if documents could not be sorted by PostgreSQL
+// (for example, because values of sort fields have mixed or unsupported types), they should be sorted by the caller
+// (see Iterator.Sorted). Documents of clustered collections are returned in _id order if sort is not given.
+// Inclusion projection is pushed down if possible (see buildProjection) to fetch only needed fields;
+// it still should be applied to returned documents.
+//
+// Passed context is used for all iterator operations.
+// If it has a deadline, statement_timeout is set accordingly;
+// ErrQueryCanceled is returned if the deadline is exceeded.
+func (pgPool *Pool) QueryIterator(ctx context.Context, qp *QueryParams) (backend.Iterator, error) {
+	iter, err := pgPool.queryIterator(ctx, qp)
+	if err != nil {
+		return nil, err
+	}
+
+	return iter, nil
+}
+
+// queryIterator is QueryIterator that returns the concrete type.
+func (pgPool *Pool) queryIterator(ctx context.Context, qp *QueryParams) (iter *Iterator, err error) {
+	tx, err := pgPool.Begin(ctx)
+	if err != nil {
+		return nil, lazyerrors.Error(err)
+	}
+	defer func() {
+		if err != nil {
+			_ = tx.Rollback(ctx)
+		}
+	}()
+
+	if err = setStatementTimeout(ctx, tx); err != nil {
+		return nil, err
+	}
+
+	table, err := pgPool.getTableInfo(ctx, tx, qp.DB, qp.Collection)
+	if err != nil {
+		return nil, err
+	}
+
+	var p Placeholder
+	where, args, residual := buildFilter(table, &p, qp.Filter)
+
+	// stubs of large documents could miss sort fields
+	var orderBy string
+	var sortArgs []any
+	if !table.large {
+		orderBy, sortArgs = buildSort(&p, qp.Sort)
+	}
+	sorted := orderBy != ""
+
+	// clustered collections are scanned in _id order unless other sort is given
+	if !sorted && table.clusteredIndex != "" {
+		orderBy, sortArgs = buildSort(&p, clusteredSort)
+	}
+
+	args = append(args, sortArgs...)
+
+	selectExpr, projectionArgs := buildProjection(&p, qp.Projection, residual, qp.Sort)
+	if selectExpr == "" {
+		selectExpr = `_jsonb`
+	}
+	if table.gridFS {
+		selectExpr += `, ` + pgx.Identifier{gridFSDataColumn}.Sanitize()
+	}
+	if table.large {
+		selectExpr += `, ` + pgx.Identifier{largeColumn}.Sanitize()
+	}
+	args = append(args, projectionArgs...)
+
+	sql := `DECLARE ` + iteratorCursor + ` NO SCROLL CURSOR FOR SELECT ` + selectExpr + ` `
+	if comment := qp.Comment; comment != "" {
+		comment = strings.ReplaceAll(comment, "/*", "/ *")
+		comment = strings.ReplaceAll(comment, "*/", "* /")
+
+		sql += `/* ` + comment + ` */ `
+	}
+
+	sql += `FROM ` + pgx.Identifier{qp.DB, table.name}.Sanitize()
+
+	if where != "" {
+		sql += ` WHERE ` + where
+	}
+
+	if orderBy != "" {
+		sql += ` ORDER BY ` + orderBy
+	}
+
+	if _, err = tx.Exec(ctx, sql, args...); err != nil {
+		return nil, queryError(err)
+	}
+
+	iter = &Iterator{
+		ctx:      ctx,
+		tx:       tx,
+		residual: residual,
+		gridFS:   table.gridFS,
+		large:    table.large,
+		sorted:   sorted,
+	}
+
+	if sorted {
+		iter.sortKeys = qp.Sort.Keys()
+	}
+
+	return iter, nil
+}
+
+// Next returns the next document.
+//
+// It returns io.EOF after the last document.
+// Once an error is returned, all subsequent calls return the same error.
+func (iter *Iterator) Next() (*types.Document, error) {
+	if iter.err != nil {
+		return nil, iter.err
+	}
+
+	if len(iter.batch) == 0 && !iter.done {
+		if iter.err = iter.fetch(); iter.err != nil {
+			return nil, iter.err
+		}
+	}
+
+	if len(iter.batch) == 0 {
+		iter.err = io.EOF
+		return nil, iter.err
+	}
+
+	doc := iter.batch[0]
+	iter.batch[0] = nil
+	iter.batch = iter.batch[1:]
+
+	if iter.sorted && !sortSupported(doc, iter.sortKeys) {
+		iter.sorted = false
+	}
+
+	return doc, nil
+}
+
+// fetch fetches the next batch of documents from the cursor.
+func (iter *Iterator) fetch() error {
+	sql := `FETCH FORWARD ` + strconv.Itoa(iteratorBatchSize) + ` FROM ` + iteratorCursor
+
+	rows, err := iter.tx.Query(iter.ctx, sql)
+	if err != nil {
+		return queryError(err)
+	}
+	defer rows.Close()
+
+	batch := make([]*types.Document, 0, iteratorBatchSize)
+	for rows.Next() {
+		var b, data, large []byte
+		dest := []any{&b}
+		if iter.gridFS {
+			dest = append(dest, &data)
+		}
+		if iter.large {
+			dest = append(dest, &large)
+		}
+
+		if err = rows.Scan(dest...); err != nil {
+			return lazyerrors.Error(err)
+		}
+
+		v, err := fjson.Unmarshal(b)
+		if err != nil {
+			return lazyerrors.Error(err)
+		}
+
+		doc := v.(*types.Document)
+		if iter.gridFS {
+			joinChunk(doc, data)
+		}
+		if iter.large {
+			if doc, err = joinLarge(doc, large); err != nil {
+				return err
+			}
+		}
+
+		batch = append(batch, doc)
+	}
+
+	if err = rows.Err(); err != nil {
+		return queryError(err)
+	}
+
+	iter.batch = batch
+	iter.done = len(batch) < iteratorBatchSize
+
+	return nil
+}
+
+// Residual returns the residual filter that should be applied to returned documents.
+func (iter *Iterator) Residual() *types.Document {
+	return iter.residual
+}
+
+// Sorted returns true if all returned documents are sorted by QueryParams.Sort.
+//
+// The result is final only after Next returned io.EOF.
+func (iter *Iterator) Sorted() bool {
+	return iter.sorted
+}
+
+// Close closes the cursor and commits the transaction
+// (that could create settings for the collection, see getTableInfo).
+//
+// The transaction is rolled back instead if Next returned an error other than io.EOF.
+func (iter *Iterator) Close() error {
+	if iter.tx == nil {
+		return nil
+	}
+
+	tx := iter.tx
+	iter.tx = nil
+
+	if iter.err != nil && iter.err != io.EOF {
+		_ = tx.Rollback(iter.ctx)
+		return nil
+	}
+
+	if err := tx.Commit(iter.ctx); err != nil {
+		return lazyerrors.Error(err)
+	}
+
+	return nil
+}
+
+// check interfaces
+var (
+	_ backend.Iterator = (*Iterator)(nil)
+)
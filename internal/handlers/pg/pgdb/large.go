@@ -0,0 +1,217 @@
+// Copyright 2021 FerretDB Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package pgdb
+
+import (
+	"context"
+	"errors"
+
+	"github.com/jackc/pgx/v4"
+
+	"github.com/FerretDB/FerretDB/internal/fjson"
+	"github.com/FerretDB/FerretDB/internal/types"
+	"github.com/FerretDB/FerretDB/internal/util/lazyerrors"
+	"github.com/FerretDB/FerretDB/internal/util/must"
+)
+
+// Documents close to the 16 MiB limit are slow to store and query as jsonb:
+// PostgreSQL parses and converts the whole value on every write,
+// and filters on any field detoast and decompress all of it for every row.
+// Documents with FJSON representation larger than NewPoolOpts.LargeDocumentThreshold
+// are stored as is in an additional bytea column instead.
+//
+// The _jsonb column of such rows stores a small stub: the same document without top-level fields
+// with large values. Stubs always have _id, so _id lookups and the unique _id index work as before;
+// other small fields are kept for indexes and partitioning, but they are not reliable for filtering:
+// for tables with that column, only _id conditions are pushed down for all rows,
+// other conditions are checked again after fetching (see buildFilter), and sorting is not pushed down.
+//
+// The column is added to the table when the first large document is written; that's recorded in the settings table.
+// Tables of GridFS chunks collections are never changed.
+
+// largeColumn is a name of the column that stores large documents.
+const largeColumn = "_large"
+
+// largeFieldSize is the maximum size of FJSON representation of top-level field values kept in stubs.
+//
+// It is well below the maximum size of B-tree index entries.
+const largeFieldSize = 1024
+
+// DefaultLargeDocumentThreshold is the default value of NewPoolOpts.LargeDocumentThreshold.
+const DefaultLargeDocumentThreshold = 1024 * 1024
+
+// errLargeColumnNeeded is returned by Pool.rows if the column for large documents should be added first;
+// see beginWrite.
+var errLargeColumnNeeded = errors.New("column for large documents is needed")
+
+// beginWrite is BeginFunc for transactions that write documents of the given collection.
+//
+// If f returns errLargeColumnNeeded, the column for large documents is added in a separate DDL transaction
+// (so cached metadata is not stale), and f is called again in a new transaction.
+func (pgPool *Pool) beginWrite(ctx context.Context, db, collection string, f func(pgx.Tx) error) error {
+	err := pgPool.BeginFunc(ctx, f)
+	if err != errLargeColumnNeeded {
+		return err
+	}
+
+	err = pgPool.beginDDL(ctx, db, func(tx pgx.Tx) error {
+		return pgPool.addLargeColumn(ctx, tx, db, collection)
+	})
+	if err != nil {
+		return lazyerrors.Error(err)
+	}
+
+	return pgPool.BeginFunc(ctx, f)
+}
+
+// addLargeColumn adds the column for large documents to the table of the given collection
+// and records that in the settings table.
+func (pgPool *Pool) addLargeColumn(ctx context.Context, tx pgx.Tx, db, collection string) error {
+	table, err := pgPool.getTableInfo(ctx, tx, db, collection)
+	if err != nil {
+		return lazyerrors.Error(err)
+	}
+
+	// the column could be added concurrently
+	if table.large {
+		return nil
+	}
+
+	sql := `ALTER TABLE ` + pgx.Identifier{db, table.name}.Sanitize() +
+		` ADD COLUMN IF NOT EXISTS ` + pgx.Identifier{largeColumn}.Sanitize() + ` bytea`
+	if _, err = tx.Exec(ctx, sql); err != nil {
+		return lazyerrors.Error(err)
+	}
+
+	settings, err := pgPool.getSettingsTable(ctx, tx, db)
+	if err != nil {
+		return lazyerrors.Error(err)
+	}
+
+	setLarge(settings, collection, true)
+
+	if err = pgPool.updateSettingsTable(ctx, tx, db, settings); err != nil {
+		return lazyerrors.Error(err)
+	}
+
+	return nil
+}
+
+// rows returns names of the table's columns that store the given documents and their values (see tableInfo.row),
+// with documents larger than the threshold split by splitLarge.
+//
+// It returns errLargeColumnNeeded if the table doesn't have the column for large documents yet, and it is needed.
+func (pgPool *Pool) rows(table *tableInfo, docs []*types.Document) ([]string, [][]any, error) {
+	var columns []string
+	res := make([][]any, len(docs))
+	for i, doc := range docs {
+		columns, res[i] = table.row(doc)
+	}
+
+	if table.gridFS {
+		return columns, res, nil
+	}
+
+	threshold := pgPool.largeDocumentThreshold
+
+	isLarge := func(row []any) bool {
+		return threshold > 0 && len(row[0].([]byte)) > threshold
+	}
+
+	if !table.large {
+		for _, row := range res {
+			if isLarge(row) {
+				return nil, nil, errLargeColumnNeeded
+			}
+		}
+
+		return columns, res, nil
+	}
+
+	// the column is always set, so updated documents that are no longer large don't keep previous values
+	columns = append(columns, largeColumn)
+
+	for i, row := range res {
+		if !isLarge(row) {
+			res[i] = append(row, nil)
+			continue
+		}
+
+		stub, b := splitLarge(docs[i], table.format)
+		res[i] = []any{must.NotFail(fjson.MarshalVersion(stub, table.format)), b}
+	}
+
+	return columns, res, nil
+}
+
+// splitLarge returns the stub of the given large document and its FJSON representation.
+func splitLarge(doc *types.Document, format fjson.Version) (*types.Document, []byte) {
+	stub := must.NotFail(types.NewDocument())
+	for _, k := range doc.Keys() {
+		v := must.NotFail(doc.Get(k))
+
+		if k != "_id" && len(must.NotFail(fjson.MarshalVersion(v, format))) > largeFieldSize {
+			continue
+		}
+
+		must.NoError(stub.Set(k, v))
+	}
+
+	return stub, must.NotFail(fjson.MarshalVersion(doc, format))
+}
+
+// joinLarge returns the document stored by splitLarge.
+// Nil data means that the document was not split, and the stub is the whole document.
+func joinLarge(stub *types.Document, data []byte) (*types.Document, error) {
+	if data == nil {
+		return stub, nil
+	}
+
+	v, err := fjson.Unmarshal(data)
+	if err != nil {
+		return nil, lazyerrors.Error(err)
+	}
+
+	return v.(*types.Document), nil
+}
+
+// getLarge returns true if the table of the given collection has the column for large documents.
+func getLarge(settings *types.Document, collection string) bool {
+	all, ok := getSettingsDocument(settings, "large")
+	if !ok {
+		return false
+	}
+
+	v, _ := all.Get(collection)
+	res, _ := v.(bool)
+
+	return res
+}
+
+// setLarge records whether the table of the given collection has the column for large documents.
+func setLarge(settings *types.Document, collection string, large bool) {
+	all, ok := getSettingsDocument(settings, "large")
+	if !ok {
+		all = must.NotFail(types.NewDocument())
+	}
+
+	if large {
+		must.NoError(all.Set(collection, true))
+	} else {
+		all.Remove(collection)
+	}
+
+	must.NoError(settings.Set("large", all))
+}
@@ -0,0 +1,120 @@
+// Copyright 2021 FerretDB Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package pgdb
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/FerretDB/FerretDB/internal/fjson"
+	"github.com/FerretDB/FerretDB/internal/types"
+	"github.com/FerretDB/FerretDB/internal/util/must"
+)
+
+func TestSplitLarge(t *testing.T) {
+	t.Parallel()
+
+	large := strings.Repeat("x", largeFieldSize)
+	doc := must.NotFail(types.NewDocument(
+		"_id", large,
+		"a", large,
+		"b", int32(42),
+		"c", must.NotFail(types.NewDocument("d", large)),
+		"e", "foo",
+	))
+
+	stub, data := splitLarge(doc, fjson.Version2)
+	expected := must.NotFail(types.NewDocument("_id", large, "b", int32(42), "e", "foo"))
+	assert.Equal(t, expected, stub)
+	require.NotNil(t, data)
+
+	actual, err := joinLarge(stub, data)
+	require.NoError(t, err)
+	assert.Equal(t, doc, actual)
+
+	actual, err = joinLarge(stub, nil)
+	require.NoError(t, err)
+	assert.Same(t, stub, actual)
+}
+
+func TestRows(t *testing.T) {
+	t.Parallel()
+
+	pgPool := &Pool{largeDocumentThreshold: 100}
+	small := must.NotFail(types.NewDocument("_id", int32(1)))
+	large := must.NotFail(types.NewDocument("_id", int32(2), "v", strings.Repeat("x", largeFieldSize)))
+	smallJSON := must.NotFail(fjson.MarshalVersion(small, fjson.Version2))
+	stubJSON := must.NotFail(fjson.MarshalVersion(must.NotFail(types.NewDocument("_id", int32(2))), fjson.Version2))
+
+	t.Run("NoColumn", func(t *testing.T) {
+		t.Parallel()
+
+		table := &tableInfo{name: "test", format: fjson.Version2}
+
+		columns, rows, err := pgPool.rows(table, []*types.Document{small})
+		require.NoError(t, err)
+		assert.Equal(t, []string{"_jsonb"}, columns)
+		assert.Equal(t, [][]any{{smallJSON}}, rows)
+
+		_, _, err = pgPool.rows(table, []*types.Document{small, large})
+		assert.Equal(t, errLargeColumnNeeded, err)
+	})
+
+	t.Run("Column", func(t *testing.T) {
+		t.Parallel()
+
+		table := &tableInfo{name: "test", format: fjson.Version2, large: true}
+
+		columns, rows, err := pgPool.rows(table, []*types.Document{small, large})
+		require.NoError(t, err)
+		assert.Equal(t, []string{"_jsonb", largeColumn}, columns)
+		require.Len(t, rows, 2)
+
+		assert.Equal(t, []any{smallJSON, nil}, rows[0])
+
+		assert.Equal(t, stubJSON, rows[1][0])
+		actual, err := joinLarge(nil, rows[1][1].([]byte))
+		require.NoError(t, err)
+		assert.Equal(t, large, actual)
+	})
+
+	t.Run("Disabled", func(t *testing.T) {
+		t.Parallel()
+
+		table := &tableInfo{name: "test", format: fjson.Version2, large: true}
+
+		columns, rows, err := new(Pool).rows(table, []*types.Document{large})
+		require.NoError(t, err)
+		assert.Equal(t, []string{"_jsonb", largeColumn}, columns)
+		assert.Nil(t, rows[0][1])
+	})
+}
+
+func TestLargeSettings(t *testing.T) {
+	t.Parallel()
+
+	settings := must.NotFail(types.NewDocument())
+	assert.False(t, getLarge(settings, "test"))
+
+	setLarge(settings, "test", true)
+	assert.True(t, getLarge(settings, "test"))
+	assert.False(t, getLarge(settings, "other"))
+
+	setLarge(settings, "test", false)
+	assert.False(t, getLarge(settings, "test"))
+}
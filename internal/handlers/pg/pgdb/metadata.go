@@ -0,0 +1,262 @@
+// Copyright 2021 FerretDB Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package pgdb
+
+import (
+	"context"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/jackc/pgx/v4"
+
+	"github.com/FerretDB/FerretDB/internal/fjson"
+	"github.com/FerretDB/FerretDB/internal/types"
+	"github.com/FerretDB/FerretDB/internal/util/lazyerrors"
+)
+
+// MetadataCache caches FerretDB collections metadata stored in settings tables
+// (collection to table mapping and FJSON format versions),
+// so operations do not query settings tables every time.
+//
+// It should be shared by all pools connected to the same PostgreSQL primary server;
+// entries are invalidated by DDL operations of those pools.
+// Entries also expire after TTL, so changes made by other FerretDB instances are eventually noticed.
+type MetadataCache struct {
+	ttl time.Duration
+
+	rw       sync.RWMutex
+	dbs      map[string]*dbMetadata
+	gen      uint64 // incremented on each DDL operation start and end
+	inflight int    // number of running DDL operations
+}
+
+// dbMetadata represents cached metadata of a single FerretDB database.
+type dbMetadata struct {
+	tables  map[string]tableInfo // by collection name
+	expires time.Time
+}
+
+// NewMetadataCache creates a new metadata cache with the given entries TTL.
+func NewMetadataCache(ttl time.Duration) *MetadataCache {
+	return &MetadataCache{
+		ttl: ttl,
+		dbs: map[string]*dbMetadata{},
+	}
+}
+
+// generation returns the current generation of the cache that should be passed to put.
+//
+// It should be called before reading the settings table.
+func (c *MetadataCache) generation() uint64 {
+	if c == nil {
+		return 0
+	}
+
+	c.rw.RLock()
+	defer c.rw.RUnlock()
+
+	return c.gen
+}
+
+// get returns cached metadata of the given database.
+//
+// Returned map should not be modified.
+func (c *MetadataCache) get(db string) (map[string]tableInfo, bool) {
+	if c == nil {
+		return nil, false
+	}
+
+	c.rw.RLock()
+	defer c.rw.RUnlock()
+
+	m := c.dbs[db]
+	if m == nil || time.Now().After(m.expires) {
+		return nil, false
+	}
+
+	return m.tables, true
+}
+
+// put caches metadata of the given database from the settings document
+// read at the given generation (see generation) by the transaction that did not modify it.
+//
+// It does nothing if DDL operations were started since then or are still running,
+// as the document could be outdated.
+func (c *MetadataCache) put(db string, settings *types.Document, gen uint64) {
+	if c == nil {
+		return
+	}
+
+	tables, err := metadataFromSettings(settings)
+	if err != nil {
+		return
+	}
+
+	c.rw.Lock()
+	defer c.rw.Unlock()
+
+	if c.inflight > 0 || c.gen != gen {
+		return
+	}
+
+	c.dbs[db] = &dbMetadata{
+		tables:  tables,
+		expires: time.Now().Add(c.ttl),
+	}
+}
+
+// invalidate removes cached metadata of the given database.
+func (c *MetadataCache) invalidate(db string) {
+	if c == nil {
+		return
+	}
+
+	c.rw.Lock()
+	defer c.rw.Unlock()
+
+	c.gen++
+	delete(c.dbs, db)
+}
+
+// ddl marks the start of DDL operation on the given database.
+//
+// Returned function marks its end; it should be called after the transaction is committed or rolled back.
+func (c *MetadataCache) ddl(db string) func() {
+	if c == nil {
+		return func() {}
+	}
+
+	c.rw.Lock()
+	defer c.rw.Unlock()
+
+	c.gen++
+	c.inflight++
+	delete(c.dbs, db)
+
+	return func() {
+		c.rw.Lock()
+		defer c.rw.Unlock()
+
+		c.gen++
+		c.inflight--
+		delete(c.dbs, db)
+	}
+}
+
+// metadataFromSettings returns table information of all collections from the settings document.
+func metadataFromSettings(settings *types.Document) (map[string]tableInfo, error) {
+	collections, ok := getSettingsDocument(settings, "collections")
+	if !ok {
+		return nil, lazyerrors.Errorf("invalid settings document")
+	}
+
+	res := make(map[string]tableInfo, collections.Len())
+	for _, collection := range collections.Keys() {
+		v, _ := collections.Get(collection)
+		name, ok := v.(string)
+		if !ok {
+			return nil, lazyerrors.Errorf("expected string but got %[1]T: %[1]v", v)
+		}
+
+		ti, err := tableInfoFromSettings(settings, collection, name)
+		if err != nil {
+			return nil, lazyerrors.Error(err)
+		}
+
+		res[collection] = *ti
+	}
+
+	return res, nil
+}
+
+// WarmUpMetadata loads metadata of all FerretDB databases into the cache with a single query.
+//
+// It does nothing if the cache is not used.
+func (pgPool *Pool) WarmUpMetadata(ctx context.Context) error {
+	if pgPool.metadata == nil {
+		return nil
+	}
+
+	gen := pgPool.metadata.generation()
+
+	return pgPool.BeginFunc(ctx, func(tx pgx.Tx) error {
+		sql := `SELECT table_schema FROM information_schema.tables WHERE table_name = $1`
+		rows, err := tx.Query(ctx, sql, settingsTableName)
+		if err != nil {
+			return lazyerrors.Error(err)
+		}
+
+		var dbs []string
+		for rows.Next() {
+			var db string
+			if err = rows.Scan(&db); err != nil {
+				rows.Close()
+				return lazyerrors.Error(err)
+			}
+
+			dbs = append(dbs, db)
+		}
+		rows.Close()
+
+		if err = rows.Err(); err != nil {
+			return lazyerrors.Error(err)
+		}
+
+		if len(dbs) == 0 {
+			return nil
+		}
+
+		selects := make([]string, len(dbs))
+		args := make([]any, len(dbs))
+		var p Placeholder
+		for i, db := range dbs {
+			selects[i] = `SELECT ` + p.Next() + `::text, settings FROM ` + pgx.Identifier{db, settingsTableName}.Sanitize()
+			args[i] = db
+		}
+
+		rows, err = tx.Query(ctx, strings.Join(selects, ` UNION ALL `), args...)
+		if err != nil {
+			return lazyerrors.Error(err)
+		}
+		defer rows.Close()
+
+		for rows.Next() {
+			var db string
+			var b []byte
+			if err = rows.Scan(&db, &b); err != nil {
+				return lazyerrors.Error(err)
+			}
+
+			doc, err := fjson.Unmarshal(b)
+			if err != nil {
+				return lazyerrors.Error(err)
+			}
+
+			settings, ok := doc.(*types.Document)
+			if !ok {
+				return lazyerrors.Errorf("invalid settings document: %v", doc)
+			}
+
+			pgPool.metadata.put(db, settings, gen)
+		}
+
+		if err = rows.Err(); err != nil {
+			return lazyerrors.Error(err)
+		}
+
+		return nil
+	})
+}
@@ -0,0 +1,109 @@
+// Copyright 2021 FerretDB Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package pgdb
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/FerretDB/FerretDB/internal/fjson"
+	"github.com/FerretDB/FerretDB/internal/types"
+	"github.com/FerretDB/FerretDB/internal/util/must"
+)
+
+func TestMetadataCache(t *testing.T) {
+	t.Parallel()
+
+	settings := must.NotFail(types.NewDocument(
+		"collections", must.NotFail(types.NewDocument("foo", "foo_1234", "bar", "bar_5678")),
+		"formats", must.NotFail(types.NewDocument("foo", int32(fjson.Version2))),
+		"migrations", must.NotFail(types.NewDocument("foo", int32(fjson.Version1))),
+	))
+	expected := map[string]tableInfo{
+		"foo": {name: "foo_1234", format: fjson.Version2, legacy: fjson.Version1},
+		"bar": {name: "bar_5678", format: fjson.Version1},
+	}
+
+	t.Run("Nil", func(t *testing.T) {
+		t.Parallel()
+
+		var c *MetadataCache
+		c.put("db", settings, c.generation())
+		c.invalidate("db")
+		c.ddl("db")()
+
+		_, ok := c.get("db")
+		assert.False(t, ok)
+	})
+
+	t.Run("PutGet", func(t *testing.T) {
+		t.Parallel()
+
+		c := NewMetadataCache(time.Hour)
+		c.put("db", settings, c.generation())
+
+		actual, ok := c.get("db")
+		assert.True(t, ok)
+		assert.Equal(t, expected, actual)
+
+		_, ok = c.get("other")
+		assert.False(t, ok)
+
+		c.invalidate("db")
+		_, ok = c.get("db")
+		assert.False(t, ok)
+	})
+
+	t.Run("Expired", func(t *testing.T) {
+		t.Parallel()
+
+		c := NewMetadataCache(-time.Second)
+		c.put("db", settings, c.generation())
+
+		_, ok := c.get("db")
+		assert.False(t, ok)
+	})
+
+	t.Run("DDL", func(t *testing.T) {
+		t.Parallel()
+
+		c := NewMetadataCache(time.Hour)
+		gen := c.generation()
+
+		end := c.ddl("db")
+
+		// settings read during DDL could be outdated
+		c.put("db", settings, c.generation())
+		_, ok := c.get("db")
+		assert.False(t, ok)
+
+		end()
+
+		// settings read before DDL end could be outdated
+		c.put("db", settings, gen)
+		_, ok = c.get("db")
+		assert.False(t, ok)
+
+		c.put("db", settings, c.generation())
+		_, ok = c.get("db")
+		assert.True(t, ok)
+
+		c.ddl("db")()
+		_, ok = c.get("db")
+		assert.False(t, ok)
+	})
+}
@@ -0,0 +1,247 @@
+// Copyright 2021 FerretDB Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package pgdb
+
+import (
+	"context"
+
+	"github.com/jackc/pgx/v4"
+	"go.uber.org/zap"
+
+	"github.com/FerretDB/FerretDB/internal/fjson"
+	"github.com/FerretDB/FerretDB/internal/util/lazyerrors"
+)
+
+// DefaultMigrateBatchSize is the default number of documents converted in a single transaction
+// by MigrateCollection.
+const DefaultMigrateBatchSize = 1000
+
+// MigrateProgressFunc is called by MigrateCollection after each batch
+// with numbers of processed documents and total documents in the collection.
+// The total is estimated at the start of the migration, so done could exceed it.
+type MigrateProgressFunc func(done, total int64)
+
+// MigrateCollection converts all documents of the given collection to the latest permitted FJSON format version
+// (see fjson.LatestVersion and NewPoolOpts.FormatVersion) without blocking concurrent reads and writes.
+//
+// First, the collection is switched to the latest version for new documents, and the previous version
+// is recorded in the settings table as the version of not yet migrated documents,
+// so lookups by _id match both representations.
+// Then existing documents are converted in batches of batchSize documents (DefaultMigrateBatchSize if not positive)
+// by _id ranges, each batch in a separate transaction.
+// Finally, the previous version record is removed.
+//
+// Collections created before the unique _id index was introduced also get that index
+// after documents are converted (see createUniqueIDIndex).
+//
+// Interrupted migration could be continued by calling MigrateCollection again.
+// It does not convert documents if the collection already uses that or newer version.
+// It returns the number of processed documents.
+// It returns ErrTableNotExist if schema or table does not exist.
+func (pgPool *Pool) MigrateCollection(
+	ctx context.Context, db, collection string, batchSize int, progress MigrateProgressFunc,
+) (int64, error) {
+	exists, err := pgPool.CollectionExists(ctx, db, collection)
+	if err != nil {
+		return 0, lazyerrors.Error(err)
+	}
+
+	if !exists {
+		return 0, ErrTableNotExist
+	}
+
+	if batchSize <= 0 {
+		batchSize = DefaultMigrateBatchSize
+	}
+
+	var table *tableInfo
+	err = pgPool.beginDDL(ctx, db, func(tx pgx.Tx) error {
+		var err error
+		if table, err = pgPool.getTableInfo(ctx, tx, db, collection); err != nil {
+			return err
+		}
+
+		if table.legacy != 0 {
+			// continue interrupted migration
+			return nil
+		}
+
+		target := pgPool.newFormatVersion()
+		if table.format >= target {
+			return nil
+		}
+
+		table.legacy = table.format
+		table.format = target
+
+		return pgPool.setTableFormats(ctx, tx, db, collection, table.format, table.legacy)
+	})
+	if err != nil {
+		return 0, lazyerrors.Error(err)
+	}
+
+	if table.legacy == 0 {
+		if err = pgPool.createUniqueIDIndex(ctx, db, collection); err != nil {
+			return 0, lazyerrors.Error(err)
+		}
+
+		return 0, nil
+	}
+
+	pgPool.logger.Info(
+		"Collection migration started",
+		zap.String("db", db), zap.String("collection", collection),
+		zap.Int32("from", int32(table.legacy)), zap.Int32("to", int32(table.format)),
+	)
+
+	ident := pgx.Identifier{db, table.name}.Sanitize()
+
+	var total int64
+	if err = pgPool.QueryRow(ctx, `SELECT count(*) FROM `+ident).Scan(&total); err != nil {
+		return 0, lazyerrors.Error(err)
+	}
+
+	var done int64
+	var lastID []byte
+	for {
+		var n int
+		err = pgPool.BeginFunc(ctx, func(tx pgx.Tx) error {
+			var err error
+			n, lastID, err = migrateBatch(ctx, tx, ident, lastID, batchSize, table.format)
+			return err
+		})
+		if err != nil {
+			return done, lazyerrors.Error(err)
+		}
+
+		if n == 0 {
+			break
+		}
+
+		done += int64(n)
+
+		if progress != nil {
+			progress(done, total)
+		}
+	}
+
+	err = pgPool.beginDDL(ctx, db, func(tx pgx.Tx) error {
+		return pgPool.setTableFormats(ctx, tx, db, collection, table.format, 0)
+	})
+	if err != nil {
+		return done, lazyerrors.Error(err)
+	}
+
+	if err = pgPool.createUniqueIDIndex(ctx, db, collection); err != nil {
+		return done, lazyerrors.Error(err)
+	}
+
+	pgPool.logger.Info(
+		"Collection migration finished",
+		zap.String("db", db), zap.String("collection", collection), zap.Int64("documents", done),
+	)
+
+	return done, nil
+}
+
+// setTableFormats sets FJSON format versions of new and not yet migrated documents of the given collection.
+func (pgPool *Pool) setTableFormats(ctx context.Context, tx pgx.Tx, db, collection string, format, legacy fjson.Version) error {
+	settings, err := pgPool.getSettingsTable(ctx, tx, db)
+	if err != nil {
+		return lazyerrors.Error(err)
+	}
+
+	setFormat(settings, "formats", collection, format)
+	setFormat(settings, "migrations", collection, legacy)
+
+	if err = pgPool.updateSettingsTable(ctx, tx, db, settings); err != nil {
+		return lazyerrors.Error(err)
+	}
+
+	return nil
+}
+
+// migrateBatch converts up to batchSize documents of the given table with _id values greater than lastID
+// (or all documents if lastID is nil) to the given format.
+//
+// It returns the number of processed documents and the last processed _id value.
+//
+// Documents are ordered by PostgreSQL jsonb ordering of _id values, not by BSON ordering;
+// the only requirement is that it is a total order.
+// Converted _id values may be greater than lastID, so some documents could be processed twice;
+// it is fine because conversion is idempotent.
+// Documents concurrently changed by other transactions are not overwritten;
+// they are already stored in the new format.
+func migrateBatch(
+	ctx context.Context, tx pgx.Tx, ident string, lastID []byte, batchSize int, format fjson.Version,
+) (int, []byte, error) {
+	sql := `SELECT _jsonb, _jsonb->'_id' FROM ` + ident
+	args := []any{batchSize}
+	if lastID != nil {
+		sql += ` WHERE _jsonb->'_id' > $2`
+		args = append(args, lastID)
+	}
+	sql += ` ORDER BY _jsonb->'_id' LIMIT $1`
+
+	rows, err := tx.Query(ctx, sql, args...)
+	if err != nil {
+		return 0, nil, lazyerrors.Error(err)
+	}
+
+	// all rows should be read before executing other queries in the same transaction
+	var docs [][]byte
+	for rows.Next() {
+		var b []byte
+		if err = rows.Scan(&b, &lastID); err != nil {
+			rows.Close()
+			return 0, nil, lazyerrors.Error(err)
+		}
+
+		docs = append(docs, b)
+	}
+	rows.Close()
+
+	if err = rows.Err(); err != nil {
+		return 0, nil, lazyerrors.Error(err)
+	}
+
+	sql = `UPDATE ` + ident + ` SET _jsonb = $1 WHERE _jsonb = $2 AND _jsonb <> $1`
+	for _, b := range docs {
+		doc, err := fjson.Unmarshal(b)
+		if err != nil {
+			return 0, nil, lazyerrors.Error(err)
+		}
+
+		nb, err := fjson.MarshalVersion(doc, format)
+		if err != nil {
+			return 0, nil, lazyerrors.Error(err)
+		}
+
+		if _, err = tx.Exec(ctx, sql, nb, b); err != nil {
+			return 0, nil, lazyerrors.Error(err)
+		}
+	}
+
+	return len(docs), lastID, nil
+}
+
+// newFormatVersion returns FJSON format version for new and migrated collections.
+func (pgPool *Pool) newFormatVersion() fjson.Version {
+	if pgPool.formatVersion == nil {
+		return fjson.LatestVersion
+	}
+
+	return pgPool.formatVersion()
+}
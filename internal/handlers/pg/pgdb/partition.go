@@ -0,0 +1,411 @@
+// Copyright 2021 FerretDB Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package pgdb
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"hash/fnv"
+	"strings"
+	"time"
+
+	"github.com/jackc/pgconn"
+	"github.com/jackc/pgerrcode"
+	"github.com/jackc/pgx/v4"
+	"golang.org/x/exp/maps"
+	"golang.org/x/exp/slices"
+
+	"github.com/FerretDB/FerretDB/internal/types"
+	"github.com/FerretDB/FerretDB/internal/util/lazyerrors"
+	"github.com/FerretDB/FerretDB/internal/util/must"
+)
+
+// PartitionKind represents a kind of collection table partitioning.
+type PartitionKind string
+
+const (
+	// PartitionByHash partitions documents by hash of _id values.
+	PartitionByHash = PartitionKind("hash")
+
+	// PartitionByDate partitions documents by date values of the given path.
+	PartitionByDate = PartitionKind("date")
+)
+
+// PartitionInterval represents a range of dates stored in a single partition.
+type PartitionInterval string
+
+const (
+	// PartitionDay stores dates of a single day in a partition.
+	PartitionDay = PartitionInterval("day")
+
+	// PartitionMonth stores dates of a single month in a partition.
+	PartitionMonth = PartitionInterval("month")
+
+	// PartitionYear stores dates of a single year in a partition.
+	PartitionYear = PartitionInterval("year")
+)
+
+// maxHashPartitions is the maximal number of partitions for PartitionByHash.
+const maxHashPartitions = 1024
+
+// idLockBuckets is the number of advisory locks per table used by checkDateIDs.
+//
+// _id values are hashed into buckets, so a transaction holds at most that many locks
+// regardless of the number of inserted documents (PostgreSQL's shared lock table is small).
+const idLockBuckets = 32
+
+// Partitioning describes partitioning of a collection table.
+//
+// Partitions are regular PostgreSQL tables attached to the collection table;
+// reads and writes of the collection table are routed to them by PostgreSQL itself.
+type Partitioning struct {
+	Kind PartitionKind
+
+	// Number of partitions for PartitionByHash; they are created with the collection.
+	Partitions int32
+
+	// Dotted path of date values and dates range of a single partition for PartitionByDate.
+	// Partitions are created on demand when documents are written;
+	// documents without date value at that path are stored in the default partition.
+	// PostgreSQL can't enforce the uniqueness of _id values for such collections, so pgdb does that itself;
+	// see checkDateIDs.
+	Path     string
+	Interval PartitionInterval
+}
+
+// Validate returns an error if partitioning is invalid.
+func (p *Partitioning) Validate() error {
+	switch p.Kind {
+	case PartitionByHash:
+		if p.Partitions < 1 || p.Partitions > maxHashPartitions {
+			return fmt.Errorf("number of partitions should be between 1 and %d, got %d", maxHashPartitions, p.Partitions)
+		}
+
+	case PartitionByDate:
+		if p.Path == "" {
+			return fmt.Errorf("date partitioning path is empty")
+		}
+
+		for _, e := range strings.Split(p.Path, ".") {
+			if e == "" || strings.HasPrefix(e, "$") {
+				return fmt.Errorf("invalid date partitioning path %q", p.Path)
+			}
+		}
+
+		switch p.Interval {
+		case PartitionDay, PartitionMonth, PartitionYear:
+		default:
+			return fmt.Errorf("unknown partition interval %q", p.Interval)
+		}
+
+	default:
+		return fmt.Errorf("unknown partitioning kind %q", p.Kind)
+	}
+
+	return nil
+}
+
+// partitionBy returns PARTITION BY clause of CREATE TABLE statement.
+func (p *Partitioning) partitionBy() string {
+	if p.Kind == PartitionByHash {
+		return `PARTITION BY HASH ((_jsonb->'_id'))`
+	}
+
+	return `PARTITION BY RANGE (` + datePathSQL(p.Path) + `)`
+}
+
+// datePathSQL returns SQL expression for milliseconds since epoch of date values at the given dotted path.
+//
+// Dates are stored as milliseconds since epoch (see fjson).
+// The same expression is used as the partition key, so PostgreSQL could prune partitions
+// for conditions that use it.
+func datePathSQL(path string) string {
+	return `((` + indexPathSQL(path) + `->>'$d')::bigint)`
+}
+
+// dateRange returns the range of dates [from, to) of the partition for the given date.
+func (p *Partitioning) dateRange(t time.Time) (time.Time, time.Time) {
+	t = t.UTC()
+
+	switch p.Interval {
+	case PartitionDay:
+		from := time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC)
+		return from, from.AddDate(0, 0, 1)
+	case PartitionMonth:
+		from := time.Date(t.Year(), t.Month(), 1, 0, 0, 0, 0, time.UTC)
+		return from, from.AddDate(0, 1, 0)
+	case PartitionYear:
+		from := time.Date(t.Year(), time.January, 1, 0, 0, 0, 0, time.UTC)
+		return from, from.AddDate(1, 0, 0)
+	default:
+		panic(fmt.Sprintf("unknown partition interval %q", p.Interval))
+	}
+}
+
+// createPartitions creates partitions of the new collection table.
+//
+// For PartitionByHash, all partitions are created with unique _id indexes;
+// since documents with the same _id value are stored in the same partition, that is enough to enforce uniqueness.
+// For PartitionByDate, only the default partition is created, and a non-unique _id index is created
+// on the collection table; PostgreSQL creates it on all partitions, including future ones.
+func createPartitions(ctx context.Context, tx pgx.Tx, db, table string, p *Partitioning) error {
+	parent := pgx.Identifier{db, table}.Sanitize()
+
+	if p.Kind == PartitionByDate {
+		name := formatCollectionName(table + "_default")
+		sql := `CREATE TABLE ` + pgx.Identifier{db, name}.Sanitize() + ` PARTITION OF ` + parent + ` DEFAULT`
+		if _, err := tx.Exec(ctx, sql); err != nil {
+			return lazyerrors.Error(err)
+		}
+
+		sql = `CREATE INDEX ON ` + parent + ` ((_jsonb->'_id'))`
+		if _, err := tx.Exec(ctx, sql); err != nil {
+			return lazyerrors.Error(err)
+		}
+
+		return nil
+	}
+
+	for i := int32(0); i < p.Partitions; i++ {
+		name := formatCollectionName(fmt.Sprintf("%s_p%d", table, i))
+		sql := `CREATE TABLE ` + pgx.Identifier{db, name}.Sanitize() + ` PARTITION OF ` + parent +
+			fmt.Sprintf(` FOR VALUES WITH (MODULUS %d, REMAINDER %d)`, p.Partitions, i)
+		if _, err := tx.Exec(ctx, sql); err != nil {
+			return lazyerrors.Error(err)
+		}
+
+		if err := createIDIndex(ctx, tx, db, name); err != nil {
+			return lazyerrors.Error(err)
+		}
+	}
+
+	return nil
+}
+
+// createDatePartitions creates missing partitions for date values of the given documents
+// if the table is partitioned by date.
+//
+// Partitions are created in savepoints, so partitions created by concurrent transactions are not an error.
+func createDatePartitions(ctx context.Context, tx pgx.Tx, db string, table *tableInfo, docs []*types.Document) error {
+	p := table.partitioning
+	if p == nil || p.Kind != PartitionByDate {
+		return nil
+	}
+
+	path := types.NewPathFromString(p.Path)
+
+	// partition name -> start of the dates range
+	ranges := make(map[string]time.Time)
+	for _, doc := range docs {
+		v, err := doc.GetByPath(path)
+		if err != nil {
+			continue
+		}
+
+		t, ok := v.(time.Time)
+		if !ok {
+			continue
+		}
+
+		from, _ := p.dateRange(t)
+		ranges[formatCollectionName(table.name+"_"+from.Format("20060102"))] = from
+	}
+
+	if len(ranges) == 0 {
+		return nil
+	}
+
+	sql := `SELECT relname FROM pg_catalog.pg_class AS c ` +
+		`JOIN pg_catalog.pg_namespace AS n ON n.oid = c.relnamespace ` +
+		`WHERE n.nspname = $1 AND c.relname = ANY($2)`
+	rows, err := tx.Query(ctx, sql, db, maps.Keys(ranges))
+	if err != nil {
+		return lazyerrors.Error(err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var name string
+		if err = rows.Scan(&name); err != nil {
+			return lazyerrors.Error(err)
+		}
+
+		delete(ranges, name)
+	}
+	if err = rows.Err(); err != nil {
+		return lazyerrors.Error(err)
+	}
+
+	rows.Close()
+
+	parent := pgx.Identifier{db, table.name}.Sanitize()
+	for name, from := range ranges {
+		_, to := p.dateRange(from)
+		sql := `CREATE TABLE IF NOT EXISTS ` + pgx.Identifier{db, name}.Sanitize() + ` PARTITION OF ` + parent +
+			fmt.Sprintf(` FOR VALUES FROM (%d) TO (%d)`, from.UnixMilli(), to.UnixMilli())
+
+		err := tx.BeginFunc(ctx, func(tx pgx.Tx) error {
+			_, err := tx.Exec(ctx, sql)
+			return err
+		})
+
+		var pgErr *pgconn.PgError
+		if errors.As(err, &pgErr) && (pgErr.Code == pgerrcode.DuplicateTable || pgErr.Code == pgerrcode.UniqueViolation) {
+			continue
+		}
+
+		if err != nil {
+			return lazyerrors.Error(err)
+		}
+	}
+
+	return nil
+}
+
+// checkDateIDs returns ErrDuplicateID if any of the given documents inserted into the table partitioned by date
+// has the same _id value as an existing document or another given document.
+// It does nothing for other tables, including time series collections that do not require unique _id values.
+//
+// Unique indexes on partitioned tables must include the partition key, so PostgreSQL can't enforce
+// the uniqueness of _id values for such tables. Instead, transaction-level advisory locks are taken
+// for buckets of inserted _id values, so concurrent inserts of the same _id value are serialized,
+// and then existing documents are checked; in READ COMMITTED transactions, that check sees documents
+// inserted by transactions that held the same locks.
+func checkDateIDs(ctx context.Context, tx pgx.Tx, db string, table *tableInfo, docs []*types.Document) error {
+	p := table.partitioning
+	if p == nil || p.Kind != PartitionByDate || table.timeseries != nil {
+		return nil
+	}
+
+	seen := make(map[string]struct{}, len(docs))
+	var ids []string
+	buckets := make(map[int32]struct{})
+
+	for _, doc := range docs {
+		id := must.NotFail(doc.Get("_id"))
+
+		args := table.idArgs(id)
+		key := string(args[0].([]byte))
+
+		if _, ok := seen[key]; ok {
+			return ErrDuplicateID
+		}
+		seen[key] = struct{}{}
+
+		for _, arg := range args {
+			ids = append(ids, string(arg.([]byte)))
+		}
+
+		h := fnv.New32a()
+		must.NotFail(h.Write(args[0].([]byte)))
+		buckets[int32(h.Sum32()%idLockBuckets)] = struct{}{}
+	}
+
+	// lock buckets in the same order to avoid deadlocks
+	lock := maps.Keys(buckets)
+	slices.Sort(lock)
+
+	h := fnv.New32a()
+	must.NotFail(h.Write([]byte(db + "." + table.name)))
+
+	sql := `SELECT pg_advisory_xact_lock($1, b) FROM unnest($2::integer[]) AS b`
+	if _, err := tx.Exec(ctx, sql, int32(h.Sum32()), lock); err != nil {
+		return lazyerrors.Error(err)
+	}
+
+	var exists bool
+	sql = `SELECT EXISTS (SELECT 1 FROM ` + pgx.Identifier{db, table.name}.Sanitize() +
+		` WHERE _jsonb->'_id' = ANY($1::jsonb[]))`
+	if err := tx.QueryRow(ctx, sql, ids).Scan(&exists); err != nil {
+		return lazyerrors.Error(err)
+	}
+
+	if exists {
+		return ErrDuplicateID
+	}
+
+	return nil
+}
+
+// getPartitioning returns partitioning of the given collection from the "partitions" field of the settings document,
+// or nil if collection is not partitioned.
+func getPartitioning(settings *types.Document, collection string) (*Partitioning, error) {
+	all, ok := getSettingsDocument(settings, "partitions")
+	if !ok {
+		return nil, nil
+	}
+
+	v, err := all.Get(collection)
+	if err != nil {
+		return nil, nil
+	}
+
+	doc, ok := v.(*types.Document)
+	if !ok {
+		return nil, lazyerrors.Errorf("expected document but got %[1]T: %[1]v", v)
+	}
+
+	var p Partitioning
+	for _, k := range doc.Keys() {
+		v := must.NotFail(doc.Get(k))
+
+		switch k {
+		case "kind":
+			s, _ := v.(string)
+			p.Kind = PartitionKind(s)
+		case "partitions":
+			p.Partitions, _ = v.(int32)
+		case "path":
+			p.Path, _ = v.(string)
+		case "interval":
+			s, _ := v.(string)
+			p.Interval = PartitionInterval(s)
+		}
+	}
+
+	if err = p.Validate(); err != nil {
+		return nil, lazyerrors.Error(err)
+	}
+
+	return &p, nil
+}
+
+// setPartitioning sets partitioning of the given collection in the "partitions" field of the settings document.
+// Nil partitioning removes the collection from that field.
+func setPartitioning(settings *types.Document, collection string, p *Partitioning) {
+	all, ok := getSettingsDocument(settings, "partitions")
+	if !ok {
+		all = must.NotFail(types.NewDocument())
+	}
+
+	switch {
+	case p == nil:
+		all.Remove(collection)
+	case p.Kind == PartitionByHash:
+		must.NoError(all.Set(collection, must.NotFail(types.NewDocument(
+			"kind", string(p.Kind),
+			"partitions", p.Partitions,
+		))))
+	default:
+		must.NoError(all.Set(collection, must.NotFail(types.NewDocument(
+			"kind", string(p.Kind),
+			"path", p.Path,
+			"interval", string(p.Interval),
+		))))
+	}
+
+	must.NoError(settings.Set("partitions", all))
+}
@@ -0,0 +1,139 @@
+// Copyright 2021 FerretDB Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package pgdb
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/FerretDB/FerretDB/internal/types"
+	"github.com/FerretDB/FerretDB/internal/util/must"
+)
+
+func TestPartitioningValidate(t *testing.T) {
+	t.Parallel()
+
+	for name, tc := range map[string]struct {
+		p   Partitioning
+		err string
+	}{
+		"Hash": {
+			p: Partitioning{Kind: PartitionByHash, Partitions: 16},
+		},
+		"HashZero": {
+			p:   Partitioning{Kind: PartitionByHash},
+			err: "number of partitions should be between 1 and 1024, got 0",
+		},
+		"HashTooMany": {
+			p:   Partitioning{Kind: PartitionByHash, Partitions: 1025},
+			err: "number of partitions should be between 1 and 1024, got 1025",
+		},
+		"Date": {
+			p: Partitioning{Kind: PartitionByDate, Path: "a.b", Interval: PartitionDay},
+		},
+		"DateEmptyPath": {
+			p:   Partitioning{Kind: PartitionByDate, Interval: PartitionDay},
+			err: "date partitioning path is empty",
+		},
+		"DateInvalidPath": {
+			p:   Partitioning{Kind: PartitionByDate, Path: "a..b", Interval: PartitionDay},
+			err: `invalid date partitioning path "a..b"`,
+		},
+		"DateOperatorPath": {
+			p:   Partitioning{Kind: PartitionByDate, Path: "$a", Interval: PartitionDay},
+			err: `invalid date partitioning path "$a"`,
+		},
+		"DateInterval": {
+			p:   Partitioning{Kind: PartitionByDate, Path: "a", Interval: "week"},
+			err: `unknown partition interval "week"`,
+		},
+		"Kind": {
+			p:   Partitioning{Kind: "list"},
+			err: `unknown partitioning kind "list"`,
+		},
+	} {
+		name, tc := name, tc
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			err := tc.p.Validate()
+			if tc.err == "" {
+				assert.NoError(t, err)
+				return
+			}
+
+			assert.EqualError(t, err, tc.err)
+		})
+	}
+}
+
+func TestPartitioningDateRange(t *testing.T) {
+	t.Parallel()
+
+	// not UTC
+	date := time.Date(2022, time.December, 31, 23, 30, 0, 0, time.FixedZone("", -3600))
+
+	for interval, expected := range map[PartitionInterval][2]time.Time{
+		PartitionDay: {
+			time.Date(2023, time.January, 1, 0, 0, 0, 0, time.UTC),
+			time.Date(2023, time.January, 2, 0, 0, 0, 0, time.UTC),
+		},
+		PartitionMonth: {
+			time.Date(2023, time.January, 1, 0, 0, 0, 0, time.UTC),
+			time.Date(2023, time.February, 1, 0, 0, 0, 0, time.UTC),
+		},
+		PartitionYear: {
+			time.Date(2023, time.January, 1, 0, 0, 0, 0, time.UTC),
+			time.Date(2024, time.January, 1, 0, 0, 0, 0, time.UTC),
+		},
+	} {
+		p := Partitioning{Kind: PartitionByDate, Path: "v", Interval: interval}
+		from, to := p.dateRange(date)
+		assert.Equal(t, expected[0], from, interval)
+		assert.Equal(t, expected[1], to, interval)
+	}
+}
+
+func TestPartitioningSettings(t *testing.T) {
+	t.Parallel()
+
+	settings := must.NotFail(types.NewDocument("collections", must.NotFail(types.NewDocument())))
+
+	p, err := getPartitioning(settings, "foo")
+	require.NoError(t, err)
+	assert.Nil(t, p)
+
+	hash := &Partitioning{Kind: PartitionByHash, Partitions: 8}
+	date := &Partitioning{Kind: PartitionByDate, Path: "a.b", Interval: PartitionMonth}
+	setPartitioning(settings, "foo", hash)
+	setPartitioning(settings, "bar", date)
+
+	p, err = getPartitioning(settings, "foo")
+	require.NoError(t, err)
+	assert.Equal(t, hash, p)
+
+	p, err = getPartitioning(settings, "bar")
+	require.NoError(t, err)
+	assert.Equal(t, date, p)
+
+	setPartitioning(settings, "foo", nil)
+
+	p, err = getPartitioning(settings, "foo")
+	require.NoError(t, err)
+	assert.Nil(t, p)
+}
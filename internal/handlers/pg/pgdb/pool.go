@@ -17,19 +17,28 @@ package pgdb
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"net"
 	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
 
 	"github.com/jackc/pgconn"
+	"github.com/jackc/pgconn/stmtcache"
 	"github.com/jackc/pgerrcode"
 	"github.com/jackc/pgx/v4"
 	"github.com/jackc/pgx/v4/log/zapadapter"
 	"github.com/jackc/pgx/v4/pgxpool"
 	"go.uber.org/zap"
+	"golang.org/x/exp/maps"
 	"golang.org/x/exp/slices"
 
 	"github.com/FerretDB/FerretDB/internal/fjson"
+	"github.com/FerretDB/FerretDB/internal/handlers/backend"
 	"github.com/FerretDB/FerretDB/internal/types"
+	"github.com/FerretDB/FerretDB/internal/util/fault"
 	"github.com/FerretDB/FerretDB/internal/util/lazyerrors"
 	"github.com/FerretDB/FerretDB/internal/util/must"
 )
@@ -41,23 +50,140 @@ const (
 	// Supported locales: (For more info see: https://www.gnu.org/software/libc/manual/html_node/Standard-Locales.html)
 	localeC     = "C"
 	localePOSIX = "POSIX"
+
+	// Statement cache capacity in PgBouncer mode; the same as pgx's default.
+	pgBouncerStatementCacheCapacity = 512
 )
 
+// Errors are the same as backend's, so callers could use either.
 var (
 	// ErrTableNotExist indicates that there is no such table.
-	ErrTableNotExist = fmt.Errorf("table does not exist")
+	ErrTableNotExist = backend.ErrCollectionNotExist
 
 	// ErrSchemaNotExist indicates that there is no such schema.
-	ErrSchemaNotExist = fmt.Errorf("schema does not exist")
+	ErrSchemaNotExist = backend.ErrDatabaseNotExist
 
 	// ErrAlreadyExist indicates that a schema or table already exists.
-	ErrAlreadyExist = fmt.Errorf("schema or table already exist")
+	ErrAlreadyExist = backend.ErrAlreadyExist
+
+	// ErrQueryCanceled indicates that the query was canceled by statement timeout or context.
+	ErrQueryCanceled = backend.ErrQueryCanceled
+
+	// ErrDuplicateID indicates that a document with the same _id already exists.
+	ErrDuplicateID = backend.ErrDuplicateID
 )
 
+// ErrInvalidDatabaseName indicates that the database name can't be used as PostgreSQL schema name
+// because it is longer than PostgreSQL identifiers could be.
+var ErrInvalidDatabaseName = errors.New("database name is too long")
+
 // Pool represents PostgreSQL concurrency-safe connection pool.
 type Pool struct {
 	*pgxpool.Pool
-	logger *zap.Logger
+	logger     *zap.Logger
+	watchMode  WatchMode
+	uuidColumn bool
+	ginIndex   bool
+
+	pgBouncerMode bool
+	metadata      *MetadataCache
+	analyzer      *Analyzer
+	formatVersion func() fjson.Version
+
+	bulkImportIndexDelay time.Duration
+	bulkImports          *bulkImports
+
+	// set for Pool passed to InTransaction's function
+	tx      pgx.Tx
+	txState *txState
+
+	largeDocumentThreshold int
+
+	// collections that were already reported by checkUniqueIDs
+	nonUniqueIDs *sync.Map
+
+	// password of new connections (string); see Authenticate
+	password *atomic.Value
+}
+
+// NewPoolOpts represents connection pool configuration.
+type NewPoolOpts struct {
+	// If set, connections are established only when needed.
+	Lazy bool
+
+	// Change notification transport; WatchModeNone if empty.
+	WatchMode WatchMode
+
+	// If set, new collections get a generated indexed column with native uuid values
+	// of UUID (binary subtype 4) _id values; lookups by such _id values use it.
+	// It requires PostgreSQL 12 or later.
+	UUIDColumn bool
+
+	// If set, new collections get a GIN index on documents
+	// that is used by filters pushed down as jsonb containment.
+	GINIndex bool
+
+	// If set, prepared statements cache requests are recorded there.
+	// Cache capacity and mode could be set with statement_cache_capacity and statement_cache_mode
+	// connection string parameters, see https://pkg.go.dev/github.com/jackc/pgx/v4#ParseConfig.
+	StatementCacheMetrics *StatementCacheMetrics
+
+	// If positive, they override pool_max_conns, pool_max_conn_lifetime, pool_max_conn_idle_time,
+	// and pool_health_check_period connection string parameters,
+	// see https://pkg.go.dev/github.com/jackc/pgx/v4/pgxpool#ParseConfig.
+	MaxConns          int32
+	MaxConnLifetime   time.Duration
+	MaxConnIdleTime   time.Duration
+	HealthCheckPeriod time.Duration
+
+	// If set, session-level state (named prepared statements, session parameters, LISTEN) is not used,
+	// so PostgreSQL could be accessed via PgBouncer or other pooler in transaction pooling mode.
+	// WatchModeNotify is not supported in that mode.
+	PgBouncerMode bool
+
+	// If set, collections metadata is cached there; see MetadataCache.
+	MetadataCache *MetadataCache
+
+	// If set, tables are analyzed after bulk writes; see Analyzer.
+	Analyzer *Analyzer
+
+	// If positive, BulkInsertDocuments defers secondary indexes of collections
+	// until no batches were inserted into them for that duration.
+	BulkImportIndexDelay time.Duration
+
+	// If positive, documents with larger FJSON representation (in bytes) are stored
+	// in a separate bytea column; see splitLarge.
+	LargeDocumentThreshold int
+
+	// If set, it returns FJSON format version for new and migrated collections;
+	// otherwise, fjson.LatestVersion is used.
+	// It allows the handler to keep an older format until all FerretDB instances are upgraded.
+	FormatVersion func() fjson.Version
+
+	// If set, SQL statements are recorded as spans of traced client requests; see tracing.Record.
+	Tracing bool
+
+	// If set, they override user and password from the connection string.
+	Username string
+	Password string
+
+	// If set, they override TLS settings from the connection string.
+	// See https://www.postgresql.org/docs/current/libpq-connect.html#LIBPQ-CONNECT-SSLMODE.
+	SSLMode     string
+	SSLRootCert string
+	SSLCert     string
+	SSLKey      string
+
+	// If set and sslmode is not set at all, server certificate is verified for non-local hosts.
+	// It should be set for release builds.
+	SecureSSLDefault bool
+
+	// If set, latency and errors are injected into writes to PostgreSQL connections; only for tests.
+	Faults *fault.Injector
+
+	// If set, all queries run as that PostgreSQL role (see TenantRole); the user should be its member.
+	// It is not supported in PgBouncer mode.
+	Role string
 }
 
 // DBStats describes statistics for a database.
@@ -71,17 +197,88 @@ type DBStats struct {
 	CountIndexes int32
 }
 
+// PoolMaxConns returns the maximum size of the connection pool that NewPool would create with the given arguments.
+func PoolMaxConns(connString string, opts *NewPoolOpts) (int32, error) {
+	if opts != nil && opts.MaxConns > 0 {
+		return opts.MaxConns, nil
+	}
+
+	config, err := pgxpool.ParseConfig(connString)
+	if err != nil {
+		return 0, fmt.Errorf("pg.PoolMaxConns: %w", err)
+	}
+
+	return config.MaxConns, nil
+}
+
 // NewPool returns a new concurrency-safe connection pool.
 //
 // Passed context is used only by the first checking connection.
 // Canceling it after that function returns does nothing.
-func NewPool(ctx context.Context, connString string, logger *zap.Logger, lazy bool) (*Pool, error) {
+//
+// If opts is nil, default configuration is used.
+func NewPool(ctx context.Context, connString string, logger *zap.Logger, opts *NewPoolOpts) (*Pool, error) {
+	if opts == nil {
+		opts = new(NewPoolOpts)
+	}
+
+	watchMode := opts.WatchMode
+	if watchMode == "" {
+		watchMode = WatchModeNone
+	}
+	if !slices.Contains(AllWatchModes, watchMode) {
+		return nil, fmt.Errorf("pg.NewPool: unknown watch mode %q", watchMode)
+	}
+
+	if opts.PgBouncerMode && watchMode != WatchModeNone {
+		return nil, fmt.Errorf("pg.NewPool: watch mode %q is not supported in PgBouncer mode", watchMode)
+	}
+
+	if opts.PgBouncerMode && opts.Role != "" {
+		return nil, fmt.Errorf("pg.NewPool: role is not supported in PgBouncer mode")
+	}
+
+	connString, err := connStringWithTLS(connString, opts)
+	if err != nil {
+		return nil, fmt.Errorf("pg.NewPool: %w", err)
+	}
+
 	config, err := pgxpool.ParseConfig(connString)
 	if err != nil {
 		return nil, fmt.Errorf("pg.NewPool: %w", err)
 	}
 
-	config.LazyConnect = lazy
+	config.LazyConnect = opts.Lazy
+
+	if opts.MaxConns > 0 {
+		config.MaxConns = opts.MaxConns
+	}
+	if opts.MaxConnLifetime > 0 {
+		config.MaxConnLifetime = opts.MaxConnLifetime
+	}
+	if opts.MaxConnIdleTime > 0 {
+		config.MaxConnIdleTime = opts.MaxConnIdleTime
+	}
+	if opts.HealthCheckPeriod > 0 {
+		config.HealthCheckPeriod = opts.HealthCheckPeriod
+	}
+
+	// MinConns from the connection string should not exceed overridden MaxConns
+	if config.MinConns > config.MaxConns {
+		config.MinConns = config.MaxConns
+	}
+
+	if opts.Username != "" {
+		config.ConnConfig.User = opts.Username
+		config.ConnConfig.Password = opts.Password
+	}
+
+	password := new(atomic.Value)
+	password.Store(config.ConnConfig.Password)
+	config.BeforeConnect = func(_ context.Context, cc *pgx.ConnConfig) error {
+		cc.Password = password.Load().(string)
+		return nil
+	}
 
 	// That only affects text protocol; pgx mostly uses a binary one.
 	// See:
@@ -91,25 +288,93 @@ func NewPool(ctx context.Context, connString string, logger *zap.Logger, lazy bo
 	// * https://github.com/FerretDB/FerretDB/issues/43
 	config.ConnConfig.RuntimeParams["timezone"] = "UTC"
 
+	if opts.PgBouncerMode {
+		// Statements are described with unnamed prepared statements and executed in the same round-trip.
+		// Using no cache at all is not an option, as pgx prepares and executes statements in different round-trips then.
+		config.ConnConfig.BuildStatementCache = func(conn *pgconn.PgConn) stmtcache.Cache {
+			return stmtcache.New(conn, stmtcache.ModeDescribe, pgBouncerStatementCacheCapacity)
+		}
+	}
+
+	// Prepared statements are cached per connection by pgx;
+	// the cache is nil if disabled by connection string parameters.
+	if build := config.ConnConfig.BuildStatementCache; build != nil && opts.StatementCacheMetrics != nil {
+		metrics := opts.StatementCacheMetrics
+		config.ConnConfig.BuildStatementCache = func(conn *pgconn.PgConn) stmtcache.Cache {
+			return newStatementCache(build(conn), metrics)
+		}
+	}
+
+	if faults := opts.Faults; faults != nil {
+		dial := config.ConnConfig.DialFunc
+		config.ConnConfig.DialFunc = func(ctx context.Context, network, addr string) (net.Conn, error) {
+			c, err := dial(ctx, network, addr)
+			if err != nil {
+				return nil, err
+			}
+
+			return faults.Conn(c), nil
+		}
+	}
+
+	if opts.Role != "" {
+		sql := `SET ROLE ` + pgx.Identifier{opts.Role}.Sanitize()
+		config.AfterConnect = func(ctx context.Context, conn *pgx.Conn) error {
+			_, err := conn.Exec(ctx, sql)
+			return err
+		}
+	}
+
 	config.ConnConfig.RuntimeParams["application_name"] = "FerretDB"
-	config.ConnConfig.RuntimeParams["search_path"] = ""
+
+	// PgBouncer rejects unknown startup parameters by default
+	if !opts.PgBouncerMode {
+		config.ConnConfig.RuntimeParams["search_path"] = ""
+	}
 
 	if logger.Core().Enabled(zap.DebugLevel) {
 		config.ConnConfig.LogLevel = pgx.LogLevelTrace
 		config.ConnConfig.Logger = zapadapter.NewLogger(logger.Named("pg.Pool"))
 	}
 
+	if opts.Tracing {
+		tl := &traceLogger{next: config.ConnConfig.Logger, lvl: config.ConnConfig.LogLevel}
+
+		// statements are logged at info level
+		if config.ConnConfig.LogLevel < pgx.LogLevelInfo {
+			config.ConnConfig.LogLevel = pgx.LogLevelInfo
+		}
+		config.ConnConfig.Logger = tl
+	}
+
 	p, err := pgxpool.ConnectConfig(ctx, config)
 	if err != nil {
 		return nil, fmt.Errorf("pg.NewPool: %w", err)
 	}
 
 	res := &Pool{
-		Pool:   p,
-		logger: logger.Named("pg.Pool"),
+		Pool:       p,
+		logger:     logger.Named("pg.Pool"),
+		watchMode:  watchMode,
+		uuidColumn: opts.UUIDColumn,
+		ginIndex:   opts.GINIndex,
+
+		pgBouncerMode: opts.PgBouncerMode,
+		metadata:      opts.MetadataCache,
+		analyzer:      opts.Analyzer,
+		formatVersion: opts.FormatVersion,
+
+		bulkImportIndexDelay: opts.BulkImportIndexDelay,
+		bulkImports:          new(bulkImports),
+
+		largeDocumentThreshold: opts.LargeDocumentThreshold,
+
+		nonUniqueIDs: new(sync.Map),
+
+		password: password,
 	}
 
-	if !lazy {
+	if !opts.Lazy {
 		err = res.checkConnection(ctx)
 	}
 
@@ -132,6 +397,11 @@ func IsValidUTF8Locale(setting string) bool {
 
 // checkConnection checks PostgreSQL settings.
 func (pgPool *Pool) checkConnection(ctx context.Context) error {
+	// that should be checked first, as other checks use prepared statements
+	if err := pgPool.checkPooler(ctx); err != nil {
+		return err
+	}
+
 	logger := pgPool.Config().ConnConfig.Logger
 
 	rows, err := pgPool.Query(ctx, "SHOW ALL")
@@ -182,6 +452,75 @@ func (pgPool *Pool) checkConnection(ctx context.Context) error {
 	return nil
 }
 
+// checkPooler detects connection poolers such as PgBouncer.
+//
+// Poolers report fake backend process IDs to clients, so the real one differs.
+// If it changes between queries on the same client connection, the pooler uses transaction (or statement) pooling,
+// and an error is returned if PgBouncer mode is not enabled.
+func (pgPool *Pool) checkPooler(ctx context.Context) error {
+	conn, err := pgPool.Acquire(ctx)
+	if err != nil {
+		return fmt.Errorf("pg.Pool.checkPooler: %w", err)
+	}
+	defer conn.Release()
+
+	// simple protocol does not use prepared statements that could be not available with transaction pooling
+	var pids [2]int32
+	for i := range pids {
+		if err = conn.QueryRow(ctx, `SELECT pg_backend_pid()`, pgx.QuerySimpleProtocol(true)).Scan(&pids[i]); err != nil {
+			return fmt.Errorf("pg.Pool.checkPooler: %w", err)
+		}
+	}
+
+	if pgPool.pgBouncerMode || uint32(pids[0]) == conn.Conn().PgConn().PID() {
+		return nil
+	}
+
+	if pids[0] != pids[1] {
+		return fmt.Errorf(
+			"pg.Pool.checkPooler: connection pooler with transaction pooling detected; PgBouncer mode should be enabled",
+		)
+	}
+
+	pgPool.logger.Warn(
+		"Connection pooler detected. If it uses transaction pooling, PgBouncer mode should be enabled.",
+	)
+
+	return nil
+}
+
+// Name returns "PostgreSQL".
+func (pgPool *Pool) Name() string {
+	return "PostgreSQL"
+}
+
+// Version returns PostgreSQL server version without build details.
+func (pgPool *Pool) Version(ctx context.Context) (string, error) {
+	var res string
+	if err := pgPool.QueryRow(ctx, "SHOW server_version").Scan(&res); err != nil {
+		return "", lazyerrors.Error(err)
+	}
+
+	res, _, _ = strings.Cut(res, " ")
+
+	return res, nil
+}
+
+// Size returns the size of the current PostgreSQL database in bytes.
+func (pgPool *Pool) Size(ctx context.Context) (int64, error) {
+	var res int64
+	if err := pgPool.QueryRow(ctx, "SELECT pg_database_size(current_database())").Scan(&res); err != nil {
+		return 0, lazyerrors.Error(err)
+	}
+
+	return res, nil
+}
+
+// Databases returns a sorted list of FerretDB database names; see Schemas.
+func (pgPool *Pool) Databases(ctx context.Context) ([]string, error) {
+	return pgPool.Schemas(ctx)
+}
+
 // Schemas returns a sorted list of FerretDB database / PostgreSQL schema names.
 func (pgPool *Pool) Schemas(ctx context.Context) ([]string, error) {
 	sql := "SELECT schema_name FROM information_schema.schemata ORDER BY schema_name"
@@ -222,6 +561,14 @@ func (pgPool *Pool) Collections(ctx context.Context, db string) ([]string, error
 		return nil, ErrSchemaNotExist
 	}
 
+	if tables, ok := pgPool.metadata.get(db); ok {
+		res := maps.Keys(tables)
+		slices.Sort(res)
+		return res, nil
+	}
+
+	gen := pgPool.metadata.generation()
+
 	// Create transaction to pass it to `getSettingsTable` and Rollback in the end.
 	tx, err := pgPool.Begin(ctx)
 	if err != nil {
@@ -244,6 +591,8 @@ func (pgPool *Pool) Collections(ctx context.Context, db string) ([]string, error
 		return nil, lazyerrors.Errorf("invalid settings document: %v", collectionsDoc)
 	}
 
+	pgPool.metadata.put(db, settings, gen)
+
 	return collections.Keys(), nil
 }
 
@@ -283,8 +632,13 @@ func (pgPool *Pool) Tables(ctx context.Context, schema string) ([]string, error)
 
 // CreateDatabase creates a new FerretDB database.
 //
-// It returns ErrAlreadyExist if schema already exist.
+// It returns ErrAlreadyExist if schema already exist,
+// ErrInvalidDatabaseName if the name would be truncated by PostgreSQL.
 func (pgPool *Pool) CreateDatabase(ctx context.Context, db string) error {
+	if len(db) > maxTableNameLength {
+		return ErrInvalidDatabaseName
+	}
+
 	tx, err := pgPool.Begin(ctx)
 	if err != nil {
 		return lazyerrors.Error(err)
@@ -324,6 +678,8 @@ func (pgPool *Pool) CreateDatabase(ctx context.Context, db string) error {
 //
 // It returns ErrTableNotExist if schema does not exist.
 func (pgPool *Pool) DropDatabase(ctx context.Context, db string) error {
+	defer pgPool.ddl(db)()
+
 	sql := `DROP SCHEMA ` + pgx.Identifier{db}.Sanitize() + ` CASCADE`
 	_, err := pgPool.Exec(ctx, sql)
 	if err == nil {
@@ -343,10 +699,91 @@ func (pgPool *Pool) DropDatabase(ctx context.Context, db string) error {
 	}
 }
 
+// CreateCollectionParams represents options of a new FerretDB collection.
+type CreateCollectionParams struct {
+	// Table partitioning; nil if the table is not partitioned.
+	Partitioning *Partitioning
+
+	// Name of the clustered index; empty if the collection is not clustered.
+	ClusteredIndex string
+
+	// Time series options; nil if the collection is not a time series collection.
+	// Time series collection tables are partitioned by date (see Timeseries),
+	// so Partitioning should not be set.
+	Timeseries *Timeseries
+}
+
 // CreateCollection creates a new FerretDB collection in existing schema.
 //
 // It returns ErrAlreadyExist if table already exist, ErrTableNotExist is schema does not exist.
 func (pgPool *Pool) CreateCollection(ctx context.Context, db, collection string) error {
+	return pgPool.createCollection(ctx, db, collection, new(CreateCollectionParams))
+}
+
+// CreatePartitionedCollection creates a new FerretDB collection with partitioned table in existing schema.
+//
+// It returns the same errors as CreateCollection.
+func (pgPool *Pool) CreatePartitionedCollection(ctx context.Context, db, collection string, p *Partitioning) error {
+	return pgPool.CreateCollectionWithParams(ctx, db, collection, &CreateCollectionParams{Partitioning: p})
+}
+
+// CreateCollectionWithParams creates a new FerretDB collection with the given options in existing schema.
+//
+// It returns the same errors as CreateCollection.
+func (pgPool *Pool) CreateCollectionWithParams(ctx context.Context, db, collection string, params *CreateCollectionParams) error {
+	if params.Partitioning != nil {
+		if err := params.Partitioning.Validate(); err != nil {
+			return lazyerrors.Error(err)
+		}
+	}
+
+	if params.Timeseries != nil {
+		if params.Partitioning != nil {
+			return lazyerrors.Errorf("time series collection %q can't have custom partitioning", collection)
+		}
+
+		if err := params.Timeseries.Validate(); err != nil {
+			return lazyerrors.Error(err)
+		}
+	}
+
+	return pgPool.createCollection(ctx, db, collection, params)
+}
+
+// CollectionParams returns options of the given existing collection.
+func (pgPool *Pool) CollectionParams(ctx context.Context, db, collection string) (*CreateCollectionParams, error) {
+	var res CreateCollectionParams
+	err := pgPool.BeginFunc(ctx, func(tx pgx.Tx) error {
+		table, err := pgPool.getTableInfo(ctx, tx, db, collection)
+		if err != nil {
+			return err
+		}
+
+		res.ClusteredIndex = table.clusteredIndex
+		res.Timeseries = table.timeseries
+		if res.Timeseries == nil {
+			res.Partitioning = table.partitioning
+		}
+
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &res, nil
+}
+
+// createCollection creates a new FerretDB collection with the given options.
+func (pgPool *Pool) createCollection(ctx context.Context, db, collection string, params *CreateCollectionParams) error {
+	p := params.Partitioning
+	if params.Timeseries != nil {
+		p = params.Timeseries.partitioning()
+	}
+
+	// that should be called after the transaction is committed or rolled back
+	defer pgPool.ddl(db)()
+
 	schemaExists, err := pgPool.schemaExists(ctx, db)
 	if err != nil {
 		return lazyerrors.Error(err)
@@ -368,16 +805,6 @@ func (pgPool *Pool) CreateCollection(ctx context.Context, db, collection string)
 		pgPool.logger.Error("failed to perform commit", zap.Error(tx.Commit(ctx)))
 	}()
 
-	table := formatCollectionName(collection)
-
-	tables, err := pgPool.tables(ctx, tx, db)
-	if err != nil {
-		return err
-	}
-	if slices.Contains(tables, table) {
-		return ErrAlreadyExist
-	}
-
 	settings, err := pgPool.getSettingsTable(ctx, tx, db)
 	if err != nil {
 		return lazyerrors.Error(err)
@@ -389,24 +816,93 @@ func (pgPool *Pool) CreateCollection(ctx context.Context, db, collection string)
 		return lazyerrors.Errorf("expected document but got %[1]T: %[1]v", collectionsDoc)
 	}
 
+	used, err := pgPool.relationNames(ctx, tx, db)
+	if err != nil {
+		return lazyerrors.Error(err)
+	}
+
+	var table string
 	if collections.Has(collection) {
-		return nil
+		// the mapping could be created by getTableName without the table; reuse it in that case
+		table = must.NotFail(collections.Get(collection)).(string)
+		if slices.Contains(used, table) {
+			return ErrAlreadyExist
+		}
+	} else {
+		table = newTableName(collection, append(used, mappedTables(collections)...))
 	}
 
 	must.NoError(collections.Set(collection, table))
 	must.NoError(settings.Set("collections", collections))
+	setFormat(settings, "formats", collection, pgPool.newFormatVersion())
+	setPartitioning(settings, collection, p)
+	setClusteredIndex(settings, collection, params.ClusteredIndex)
+	setTimeseries(settings, collection, params.Timeseries)
+
+	gridFS := isGridFSChunks(collection)
+	setGridFS(settings, collection, gridFS)
+
+	// partitioned tables don't have the unique _id index; see createPartitions
+	setUniqueIDs(settings, collection, p == nil)
 
 	err = pgPool.updateSettingsTable(ctx, tx, db, settings)
 	if err != nil {
 		return lazyerrors.Error(err)
 	}
 
-	sql := `CREATE TABLE IF NOT EXISTS ` + pgx.Identifier{db, table}.Sanitize() + ` (_jsonb jsonb)`
+	columns := `_jsonb jsonb`
+	if pgPool.uuidColumn {
+		columns += `, ` + uuidColumnDefinition()
+	}
+	if gridFS {
+		columns += `, ` + gridFSColumnDefinition()
+	}
+
+	sql := `CREATE TABLE IF NOT EXISTS ` + pgx.Identifier{db, table}.Sanitize() + ` (` + columns + `)`
+	if p != nil {
+		sql += ` ` + p.partitionBy()
+	}
+
 	_, err = tx.Exec(ctx, sql)
 	if err != nil {
 		return lazyerrors.Errorf("pg.CreateCollection: %w", err)
 	}
 
+	if gridFS {
+		if err = setGridFSStorage(ctx, tx, db, table); err != nil {
+			return lazyerrors.Error(err)
+		}
+	}
+
+	if p == nil {
+		err = createIDIndex(ctx, tx, db, table)
+	} else {
+		// unique indexes on partitioned tables can't use expressions, so they are created on partitions if possible
+		err = createPartitions(ctx, tx, db, table, p)
+	}
+
+	if err != nil {
+		return lazyerrors.Error(err)
+	}
+
+	if pgPool.uuidColumn {
+		if err = createUUIDIndex(ctx, tx, db, table); err != nil {
+			return lazyerrors.Error(err)
+		}
+	}
+
+	if pgPool.ginIndex {
+		if err = createGINIndex(ctx, tx, db, table); err != nil {
+			return lazyerrors.Error(err)
+		}
+	}
+
+	if pgPool.watchMode == WatchModeNotify {
+		if err = createWatchTrigger(ctx, tx, db, table, collection); err != nil {
+			return lazyerrors.Error(err)
+		}
+	}
+
 	return nil
 }
 
@@ -414,6 +910,9 @@ func (pgPool *Pool) CreateCollection(ctx context.Context, db, collection string)
 //
 // It returns ErrTableNotExist if schema or table does not exist.
 func (pgPool *Pool) DropCollection(ctx context.Context, schema, collection string) error {
+	// that should be called after the transaction is committed or rolled back
+	defer pgPool.ddl(schema)()
+
 	schemaExists, err := pgPool.schemaExists(ctx, schema)
 	if err != nil {
 		return lazyerrors.Error(err)
@@ -435,16 +934,22 @@ func (pgPool *Pool) DropCollection(ctx context.Context, schema, collection strin
 		pgPool.logger.Error("failed to perform commit", zap.Error(tx.Commit(ctx)))
 	}()
 
-	table := formatCollectionName(collection)
-
-	tables, err := pgPool.tables(ctx, tx, schema)
+	settings, err := pgPool.getSettingsTable(ctx, tx, schema)
 	if err != nil {
 		return lazyerrors.Error(err)
 	}
-	if !slices.Contains(tables, table) {
+
+	collections, ok := must.NotFail(settings.Get("collections")).(*types.Document)
+	if !ok {
+		return lazyerrors.Errorf("invalid settings document")
+	}
+
+	if !collections.Has(collection) {
 		return ErrTableNotExist
 	}
 
+	table := must.NotFail(collections.Get(collection)).(string)
+
 	err = pgPool.removeTableFromSettings(ctx, tx, schema, collection)
 	if err != nil && err != ErrTableNotExist {
 		return lazyerrors.Error(err)
@@ -463,12 +968,12 @@ func (pgPool *Pool) DropCollection(ctx context.Context, schema, collection strin
 	return nil
 }
 
-// CreateTableIfNotExist ensures that given FerretDB database / PostgreSQL schema
+// CreateCollectionIfNotExist ensures that given FerretDB database / PostgreSQL schema
 // and FerretDB collection / PostgreSQL table exist.
 // If needed, it creates both schema and table.
 //
 // True is returned if table was created.
-func (pgPool *Pool) CreateTableIfNotExist(ctx context.Context, db, collection string) (bool, error) {
+func (pgPool *Pool) CreateCollectionIfNotExist(ctx context.Context, db, collection string) (bool, error) {
 	exists, err := pgPool.CollectionExists(ctx, db, collection)
 	if err != nil {
 		return false, lazyerrors.Error(err)
@@ -543,182 +1048,386 @@ func (pgPool *Pool) SchemaStats(ctx context.Context, schema, collection string)
 }
 
 // QueryDocuments returns a list of documents for given FerretDB database and collection.
-func (pgPool *Pool) QueryDocuments(ctx context.Context, db, collection, comment string) ([]*types.Document, error) {
-	tx, err := pgPool.Begin(ctx)
-	if err != nil {
-		return nil, lazyerrors.Error(err)
-	}
-	defer func() {
+//
+// It loads all documents into memory; see QueryIterator for details and for processing them one by one.
+func (pgPool *Pool) QueryDocuments(ctx context.Context, qp *QueryParams) (*QueryResult, error) {
+	return backend.QueryDocuments(ctx, pgPool, qp)
+}
+
+// SetDocumentByID sets a document by its ID.
+func (pgPool *Pool) SetDocumentByID(ctx context.Context, db, collection string, id any, doc *types.Document) (int64, error) {
+	var updated int64
+	err := pgPool.beginWrite(ctx, db, collection, func(tx pgx.Tx) error {
+		table, err := pgPool.getTableInfo(ctx, tx, db, collection)
 		if err != nil {
-			pgPool.logger.Error("failed to perform rollback", zap.Error(tx.Rollback(ctx)))
-			return
+			return err
 		}
-		pgPool.logger.Error("failed to perform commit", zap.Error(tx.Commit(ctx)))
-	}()
 
-	table, err := pgPool.getTableName(ctx, tx, db, collection)
-	if err != nil {
-		return nil, err
-	}
+		if err = checkTimeField(table, []*types.Document{doc}); err != nil {
+			return err
+		}
 
-	sql := `SELECT _jsonb `
-	if comment != "" {
-		comment = strings.ReplaceAll(comment, "/*", "/ *")
-		comment = strings.ReplaceAll(comment, "*/", "* /")
+		// the updated document could be moved to another partition
+		if err = createDatePartitions(ctx, tx, db, table, []*types.Document{doc}); err != nil {
+			return lazyerrors.Error(err)
+		}
 
-		sql += `/* ` + comment + ` */ `
-	}
+		useUUID, err := pgPool.useUUIDColumn(ctx, tx, db, table.name, []any{id})
+		if err != nil {
+			return lazyerrors.Error(err)
+		}
+
+		columns, rows, err := pgPool.rows(table, []*types.Document{doc})
+		if err != nil {
+			return err
+		}
+
+		sql, args := setDocumentSQL(db, table, id, columns, rows[0], useUUID)
 
-	sql += `FROM ` + pgx.Identifier{db, table}.Sanitize()
+		tag, err := tx.Exec(ctx, sql, args...)
+		if err != nil {
+			return err
+		}
 
-	rows, err := tx.Query(ctx, sql)
+		updated = tag.RowsAffected()
+		return nil
+	})
 	if err != nil {
-		return nil, lazyerrors.Error(err)
+		return 0, err
 	}
-	defer rows.Close()
 
-	var res []*types.Document
-	for rows.Next() {
-		var b []byte
-		if err := rows.Scan(&b); err != nil {
-			return nil, lazyerrors.Error(err)
+	return updated, nil
+}
+
+// SetDocumentsByID replaces documents with the same _id values as the given documents in a single transaction.
+// All UPDATE statements are sent to PostgreSQL in a single batch.
+//
+// It returns the number of replaced documents.
+func (pgPool *Pool) SetDocumentsByID(ctx context.Context, db, collection string, docs []*types.Document) (int64, error) {
+	if len(docs) == 0 {
+		return 0, nil
+	}
+
+	var table *tableInfo
+	var updated int64
+	err := pgPool.beginWrite(ctx, db, collection, func(tx pgx.Tx) error {
+		var err error
+		if table, err = pgPool.getTableInfo(ctx, tx, db, collection); err != nil {
+			return err
 		}
 
-		doc, err := fjson.Unmarshal(b)
-		if err != nil {
-			return nil, lazyerrors.Error(err)
+		if err = checkTimeField(table, docs); err != nil {
+			return err
 		}
 
-		res = append(res, doc.(*types.Document))
-	}
+		// updated documents could be moved to other partitions
+		if err = createDatePartitions(ctx, tx, db, table, docs); err != nil {
+			return lazyerrors.Error(err)
+		}
 
-	return res, nil
-}
+		ids := make([]any, len(docs))
+		for i, doc := range docs {
+			ids[i] = must.NotFail(doc.Get("_id"))
+		}
 
-// SetDocumentByID sets a document by its ID.
-func (pgPool *Pool) SetDocumentByID(ctx context.Context, db, collection string, id any, doc *types.Document) (int64, error) {
-	tx, err := pgPool.Begin(ctx)
-	if err != nil {
-		return 0, lazyerrors.Error(err)
-	}
-	defer func() {
+		useUUID, err := pgPool.useUUIDColumn(ctx, tx, db, table.name, ids)
 		if err != nil {
-			pgPool.logger.Error("failed to perform rollback", zap.Error(tx.Rollback(ctx)))
-			return
+			return lazyerrors.Error(err)
+		}
+
+		columns, rows, err := pgPool.rows(table, docs)
+		if err != nil {
+			return err
+		}
+
+		var batch pgx.Batch
+		for i, row := range rows {
+			sql, args := setDocumentSQL(db, table, ids[i], columns, row, useUUID)
+			batch.Queue(sql, args...)
+		}
+
+		br := tx.SendBatch(ctx, &batch)
+		for range docs {
+			tag, err := br.Exec()
+			if err != nil {
+				_ = br.Close()
+				return err
+			}
+
+			updated += tag.RowsAffected()
 		}
-		pgPool.logger.Error("failed to perform commit", zap.Error(tx.Commit(ctx)))
-	}()
 
-	table, err := pgPool.getTableName(ctx, tx, db, collection)
+		return br.Close()
+	})
 	if err != nil {
 		return 0, err
 	}
 
-	sql := "UPDATE " + pgx.Identifier{db, table}.Sanitize() +
-		" SET _jsonb = $1 WHERE _jsonb->'_id' = $2"
+	pgPool.written(db, table.name, updated)
 
-	tag, err := tx.Exec(ctx, sql, must.NotFail(fjson.Marshal(doc)), must.NotFail(fjson.Marshal(id)))
-	if err != nil {
-		return 0, err
+	return updated, nil
+}
+
+// setDocumentSQL returns UPDATE statement that replaces the document with the given _id, and its arguments.
+// Columns and row are returned by Pool.rows for the new document.
+func setDocumentSQL(db string, table *tableInfo, id any, columns []string, row []any, useUUID bool) (string, []any) {
+	var p Placeholder
+	args := append([]any{}, row...)
+
+	set := make([]string, len(columns))
+	for i, column := range columns {
+		set[i] = column + " = " + p.Next()
+	}
+
+	var where string
+	if u := uuidID(id); useUUID && u != "" {
+		where = pgx.Identifier{uuidColumn}.Sanitize() + " = " + p.Next()
+		args = append(args, u)
+	} else {
+		var placeholders []string
+		for _, arg := range table.idArgs(id) {
+			placeholders = append(placeholders, p.Next())
+			args = append(args, arg)
+		}
+		where = "_jsonb->'_id' IN (" + strings.Join(placeholders, ", ") + ")"
 	}
 
-	return tag.RowsAffected(), nil
+	sql := "UPDATE " + pgx.Identifier{db, table.name}.Sanitize() +
+		" SET " + strings.Join(set, ", ") + " WHERE " + where
+
+	return sql, args
 }
 
 // DeleteDocumentsByID deletes documents by given IDs.
 func (pgPool *Pool) DeleteDocumentsByID(ctx context.Context, db, collection string, ids []any) (int64, error) {
-	tx, err := pgPool.Begin(ctx)
-	if err != nil {
-		return 0, lazyerrors.Error(err)
-	}
-	defer func() {
+	var table *tableInfo
+	var deleted int64
+	err := pgPool.BeginFunc(ctx, func(tx pgx.Tx) error {
+		var err error
+		if table, err = pgPool.getTableInfo(ctx, tx, db, collection); err != nil {
+			return err
+		}
+
+		useUUID, err := pgPool.useUUIDColumn(ctx, tx, db, table.name, ids)
 		if err != nil {
-			pgPool.logger.Error("failed to perform rollback", zap.Error(tx.Rollback(ctx)))
-			return
+			return lazyerrors.Error(err)
 		}
-		pgPool.logger.Error("failed to perform commit", zap.Error(tx.Commit(ctx)))
-	}()
 
-	table, err := pgPool.getTableName(ctx, tx, db, collection)
-	if err != nil {
-		return 0, err
-	}
+		var p Placeholder
+		args := make([]any, 0, len(ids))
+		var placeholders, uuidPlaceholders []string
+		for _, id := range ids {
+			if u := uuidID(id); useUUID && u != "" {
+				uuidPlaceholders = append(uuidPlaceholders, p.Next())
+				args = append(args, u)
+				continue
+			}
 
-	var p Placeholder
-	idsMarshalled := make([]any, len(ids))
-	placeholders := make([]string, len(ids))
-	for i, id := range ids {
-		placeholders[i] = p.Next()
-		idsMarshalled[i] = must.NotFail(fjson.Marshal(id))
-	}
+			for _, arg := range table.idArgs(id) {
+				placeholders = append(placeholders, p.Next())
+				args = append(args, arg)
+			}
+		}
 
-	sql := `DELETE FROM ` + pgx.Identifier{db, table}.Sanitize() +
-		` WHERE _jsonb->'_id' IN (` +
-		strings.Join(placeholders, ", ") +
-		`)`
+		var conditions []string
+		if len(placeholders) > 0 {
+			conditions = append(conditions, `_jsonb->'_id' IN (`+strings.Join(placeholders, ", ")+`)`)
+		}
+		if len(uuidPlaceholders) > 0 {
+			column := pgx.Identifier{uuidColumn}.Sanitize()
+			conditions = append(conditions, column+` IN (`+strings.Join(uuidPlaceholders, ", ")+`)`)
+		}
+
+		sql := `DELETE FROM ` + pgx.Identifier{db, table.name}.Sanitize() +
+			` WHERE ` + strings.Join(conditions, " OR ")
 
-	tag, err := tx.Exec(ctx, sql, idsMarshalled...)
+		tag, err := tx.Exec(ctx, sql, args...)
+		if err != nil {
+			return err
+		}
+
+		deleted = tag.RowsAffected()
+		return nil
+	})
 	if err != nil {
 		return 0, err
 	}
 
-	return tag.RowsAffected(), nil
+	pgPool.written(db, table.name, deleted)
+
+	return deleted, nil
 }
 
 // InsertDocument inserts a document into FerretDB database and collection.
 // If database or collection does not exist, it will be created.
+//
+// It returns ErrDuplicateID if a document with the same _id already exists.
 func (pgPool *Pool) InsertDocument(ctx context.Context, db, collection string, doc *types.Document) error {
+	_, err := pgPool.insertDocument(ctx, db, collection, doc, "")
+	return err
+}
+
+// insertDocument inserts a document into FerretDB database and collection,
+// creating them if needed. The given ON CONFLICT clause is appended to the INSERT statement.
+//
+// It returns true if the document was inserted.
+func (pgPool *Pool) insertDocument(
+	ctx context.Context, db, collection string, doc *types.Document, onConflict string,
+) (bool, error) {
 	exists, err := pgPool.CollectionExists(ctx, db, collection)
 	if err != nil {
-		return err
+		return false, err
 	}
 
 	if !exists {
 		if err := pgPool.CreateDatabase(ctx, db); err != nil && err != ErrAlreadyExist {
+			return false, lazyerrors.Error(err)
+		}
+
+		// the collection could be created concurrently; insert into it anyway
+		if err := pgPool.CreateCollection(ctx, db, collection); err != nil && err != ErrAlreadyExist {
+			return false, lazyerrors.Error(err)
+		}
+	}
+
+	var table *tableInfo
+	var inserted bool
+	err = pgPool.beginWrite(ctx, db, collection, func(tx pgx.Tx) error {
+		var err error
+		if table, err = pgPool.getTableInfo(ctx, tx, db, collection); err != nil {
+			return err
+		}
+
+		if err = checkTimeField(table, []*types.Document{doc}); err != nil {
+			return err
+		}
+
+		if onConflict != "" {
+			pgPool.checkUniqueIDs(db, collection, table)
+		}
+
+		if err = createDatePartitions(ctx, tx, db, table, []*types.Document{doc}); err != nil {
 			return lazyerrors.Error(err)
 		}
 
-		if err := pgPool.CreateCollection(ctx, db, collection); err != nil {
-			if err == ErrAlreadyExist {
+		if err = checkDateIDs(ctx, tx, db, table, []*types.Document{doc}); err != nil {
+			// the same as ON CONFLICT for tables with the unique _id index
+			if err == ErrDuplicateID && onConflict != "" {
 				return nil
 			}
-			return lazyerrors.Error(err)
+
+			return err
 		}
-	}
 
-	tx, err := pgPool.Begin(ctx)
+		columns, rows, err := pgPool.rows(table, []*types.Document{doc})
+		if err != nil {
+			return err
+		}
+
+		row := rows[0]
+
+		var p Placeholder
+		placeholders := make([]string, len(row))
+		for i := range row {
+			placeholders[i] = p.Next()
+		}
+
+		sql := `INSERT INTO ` + pgx.Identifier{db, table.name}.Sanitize() +
+			` (` + strings.Join(columns, ", ") + `) VALUES (` + strings.Join(placeholders, ", ") + `)`
+		if onConflict != "" {
+			sql += ` ` + onConflict
+		}
+
+		tag, err := tx.Exec(ctx, sql, row...)
+		if err != nil {
+			var pgErr *pgconn.PgError
+			if errors.As(err, &pgErr) && pgErr.Code == pgerrcode.UniqueViolation {
+				return ErrDuplicateID
+			}
+
+			return err
+		}
+
+		inserted = tag.RowsAffected() == 1
+		return nil
+	})
 	if err != nil {
+		return false, err
+	}
+
+	if inserted {
+		pgPool.written(db, table.name, 1)
+	}
+
+	return inserted, nil
+}
+
+// InsertDocuments inserts documents into FerretDB database and collection
+// with a single COPY statement in a single transaction.
+// If database or collection does not exist, it will be created.
+//
+// Either all documents are inserted, or none of them; in the latter case,
+// callers could fall back to InsertDocument to find out which document can't be inserted.
+func (pgPool *Pool) InsertDocuments(ctx context.Context, db, collection string, docs []*types.Document) error {
+	if _, err := pgPool.CreateCollectionIfNotExist(ctx, db, collection); err != nil {
 		return lazyerrors.Error(err)
 	}
-	defer func() {
+
+	var table *tableInfo
+	err := pgPool.beginWrite(ctx, db, collection, func(tx pgx.Tx) error {
+		var err error
+		if table, err = pgPool.getTableInfo(ctx, tx, db, collection); err != nil {
+			return err
+		}
+
+		if err = checkTimeField(table, docs); err != nil {
+			return err
+		}
+
+		if err = createDatePartitions(ctx, tx, db, table, docs); err != nil {
+			return lazyerrors.Error(err)
+		}
+
+		if err = checkDateIDs(ctx, tx, db, table, docs); err != nil {
+			return err
+		}
+
+		columns, rows, err := pgPool.rows(table, docs)
 		if err != nil {
-			pgPool.logger.Error("failed to perform rollback", zap.Error(tx.Rollback(ctx)))
-			return
+			return err
 		}
-		pgPool.logger.Error("failed to perform commit", zap.Error(tx.Commit(ctx)))
-	}()
 
-	table, err := pgPool.getTableName(ctx, tx, db, collection)
-	if err != nil {
-		return err
-	}
+		n, err := tx.CopyFrom(ctx, pgx.Identifier{db, table.name}, columns, pgx.CopyFromRows(rows))
+		if err != nil {
+			return lazyerrors.Error(err)
+		}
 
-	sql := `INSERT INTO ` + pgx.Identifier{db, table}.Sanitize() +
-		` (_jsonb) VALUES ($1)`
+		if n != int64(len(docs)) {
+			return lazyerrors.Errorf("expected %d documents to be copied, got %d", len(docs), n)
+		}
 
-	_, err = tx.Exec(ctx, sql, must.NotFail(fjson.Marshal(doc)))
+		return nil
+	})
 	if err != nil {
 		return err
 	}
 
+	pgPool.written(db, table.name, int64(len(docs)))
+
 	return nil
 }
 
 // tables returns a list of PostgreSQL table names.
 func (pgPool *Pool) tables(ctx context.Context, tx pgx.Tx, schema string) ([]string, error) {
+	// partitions of collection tables are not included
 	sql := `SELECT table_name ` +
 		`FROM information_schema.columns ` +
 		`WHERE table_schema = $1 ` +
+		`AND NOT EXISTS (` +
+		`SELECT 1 FROM pg_catalog.pg_class AS c ` +
+		`JOIN pg_catalog.pg_namespace AS n ON n.oid = c.relnamespace ` +
+		`WHERE n.nspname = table_schema AND c.relname = table_name AND c.relispartition` +
+		`) ` +
 		`GROUP BY table_name ` +
 		`ORDER BY table_name`
 	rows, err := tx.Query(ctx, sql, schema)
@@ -762,3 +1471,45 @@ func (pgPool *Pool) schemaExists(ctx context.Context, db string) (bool, error) {
 
 	return false, nil
 }
+
+// check interfaces
+var (
+	_ backend.Backend      = (*Pool)(nil)
+	_ backend.BulkInserter = (*Pool)(nil)
+)
+
+// Authenticate checks that the pool's user could log in to PostgreSQL with the given password
+// by establishing a new connection, and then uses that password for new connections of the pool.
+//
+// Existing connections are not affected. They were authenticated when they were established,
+// so acquiring one of them does not check that the password is still valid.
+func (pgPool *Pool) Authenticate(ctx context.Context, password string) error {
+	config := pgPool.Config().ConnConfig
+	config.Password = password
+
+	conn, err := pgx.ConnectConfig(ctx, config)
+	if err != nil {
+		return fmt.Errorf("pg.Pool.Authenticate: %w", err)
+	}
+
+	_ = conn.Close(ctx)
+
+	pgPool.password.Store(password)
+
+	return nil
+}
+
+// CloseIdleConns closes all idle connections of the pool and returns their number.
+//
+// It is used after PostgreSQL restart, when idle connections are broken;
+// new connections are established on demand.
+func (pgPool *Pool) CloseIdleConns(ctx context.Context) int {
+	conns := pgPool.AcquireAllIdle(ctx)
+	for _, c := range conns {
+		// closed connections are destroyed on release
+		_ = c.Conn().Close(ctx)
+		c.Release()
+	}
+
+	return len(conns)
+}
@@ -0,0 +1,88 @@
+// Copyright 2021 FerretDB Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package pgdb
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// Descriptors of connection pool statistics metrics; see CollectPoolStats.
+var (
+	poolConnsDesc = prometheus.NewDesc(
+		prometheus.BuildFQName("ferretdb", "postgresql", "pool_connections"),
+		"The current number of connections in PostgreSQL connection pools.",
+		[]string{"pool", "state"}, nil,
+	)
+	poolMaxConnsDesc = prometheus.NewDesc(
+		prometheus.BuildFQName("ferretdb", "postgresql", "pool_max_connections"),
+		"The maximum number of connections in PostgreSQL connection pools.",
+		[]string{"pool"}, nil,
+	)
+	poolAcquiresDesc = prometheus.NewDesc(
+		prometheus.BuildFQName("ferretdb", "postgresql", "pool_acquires_total"),
+		"Total number of connections acquired from PostgreSQL connection pools.",
+		[]string{"pool", "result"}, nil,
+	)
+	poolEmptyAcquiresDesc = prometheus.NewDesc(
+		prometheus.BuildFQName("ferretdb", "postgresql", "pool_empty_acquires_total"),
+		"Total number of acquires that waited for a connection because PostgreSQL connection pools were empty.",
+		[]string{"pool"}, nil,
+	)
+	poolAcquireSecondsDesc = prometheus.NewDesc(
+		prometheus.BuildFQName("ferretdb", "postgresql", "pool_acquire_seconds_total"),
+		"Total time spent acquiring connections from PostgreSQL connection pools.",
+		[]string{"pool"}, nil,
+	)
+)
+
+// DescribePoolStats sends descriptors of connection pool statistics metrics to ch.
+func DescribePoolStats(ch chan<- *prometheus.Desc) {
+	ch <- poolConnsDesc
+	ch <- poolMaxConnsDesc
+	ch <- poolAcquiresDesc
+	ch <- poolEmptyAcquiresDesc
+	ch <- poolAcquireSecondsDesc
+}
+
+// CollectPoolStats sends statistics of the given connection pools to ch.
+//
+// Statistics are summed up and labeled with the given pool kind (like "default" or "replica").
+// Pools that are closed are not counted anymore, so counters could decrease.
+func CollectPoolStats(ch chan<- prometheus.Metric, kind string, pools []*Pool) {
+	var acquired, idle, constructing, maxConns float64
+	var acquires, canceled, empty, seconds float64
+
+	for _, p := range pools {
+		s := p.Stat()
+
+		acquired += float64(s.AcquiredConns())
+		idle += float64(s.IdleConns())
+		constructing += float64(s.ConstructingConns())
+		maxConns += float64(s.MaxConns())
+		acquires += float64(s.AcquireCount())
+		canceled += float64(s.CanceledAcquireCount())
+		empty += float64(s.EmptyAcquireCount())
+		seconds += s.AcquireDuration().Seconds()
+	}
+
+	ch <- prometheus.MustNewConstMetric(poolConnsDesc, prometheus.GaugeValue, acquired, kind, "acquired")
+	ch <- prometheus.MustNewConstMetric(poolConnsDesc, prometheus.GaugeValue, idle, kind, "idle")
+	ch <- prometheus.MustNewConstMetric(poolConnsDesc, prometheus.GaugeValue, constructing, kind, "constructing")
+	ch <- prometheus.MustNewConstMetric(poolMaxConnsDesc, prometheus.GaugeValue, maxConns, kind)
+	ch <- prometheus.MustNewConstMetric(poolAcquiresDesc, prometheus.CounterValue, acquires, kind, "ok")
+	ch <- prometheus.MustNewConstMetric(poolAcquiresDesc, prometheus.CounterValue, canceled, kind, "canceled")
+	ch <- prometheus.MustNewConstMetric(poolEmptyAcquiresDesc, prometheus.CounterValue, empty, kind)
+	ch <- prometheus.MustNewConstMetric(poolAcquireSecondsDesc, prometheus.CounterValue, seconds, kind)
+}
@@ -17,13 +17,23 @@ package pgdb_test
 
 import (
 	"fmt"
+	"io"
+	"strings"
 	"testing"
+	"time"
 
+	"github.com/jackc/pgconn"
+	"github.com/jackc/pgerrcode"
+	"github.com/jackc/pgx/v4"
+	"github.com/prometheus/client_golang/prometheus"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 	"go.uber.org/zap/zaptest"
 
+	"github.com/FerretDB/FerretDB/internal/fjson"
 	"github.com/FerretDB/FerretDB/internal/handlers/pg/pgdb"
+	"github.com/FerretDB/FerretDB/internal/types"
+	"github.com/FerretDB/FerretDB/internal/util/must"
 	"github.com/FerretDB/FerretDB/internal/util/testutil"
 )
 
@@ -55,6 +65,96 @@ func TestValidUTF8Locale(t *testing.T) {
 	}
 }
 
+func TestCollectPoolStats(t *testing.T) {
+	t.Parallel()
+
+	ctx := testutil.Ctx(t)
+	pool := testutil.Pool(ctx, t, nil, zaptest.NewLogger(t))
+	require.NoError(t, pool.Ping(ctx))
+
+	descs := make(chan *prometheus.Desc, 10)
+	pgdb.DescribePoolStats(descs)
+	close(descs)
+	assert.Len(t, descs, 5)
+
+	metrics := make(chan prometheus.Metric, 10)
+	pgdb.CollectPoolStats(metrics, "default", []*pgdb.Pool{pool})
+	close(metrics)
+	assert.Len(t, metrics, 8)
+}
+
+func TestNewPoolSettings(t *testing.T) {
+	t.Parallel()
+
+	ctx := testutil.Ctx(t)
+	connString := testutil.PoolConnString(t, nil) + "&pool_max_conns=10&pool_max_conn_lifetime=1h"
+
+	pool, err := pgdb.NewPool(ctx, connString, zaptest.NewLogger(t), &pgdb.NewPoolOpts{
+		MaxConns:          3,
+		MaxConnIdleTime:   time.Minute,
+		HealthCheckPeriod: 10 * time.Second,
+	})
+	require.NoError(t, err)
+	t.Cleanup(pool.Close)
+
+	config := pool.Config()
+	assert.Equal(t, int32(3), config.MaxConns)
+	assert.Equal(t, time.Hour, config.MaxConnLifetime)
+	assert.Equal(t, time.Minute, config.MaxConnIdleTime)
+	assert.Equal(t, 10*time.Second, config.HealthCheckPeriod)
+
+	maxConns, err := pgdb.PoolMaxConns(connString, &pgdb.NewPoolOpts{MaxConns: 3})
+	require.NoError(t, err)
+	assert.Equal(t, int32(3), maxConns)
+
+	maxConns, err = pgdb.PoolMaxConns(connString, nil)
+	require.NoError(t, err)
+	assert.Equal(t, int32(10), maxConns)
+}
+
+func TestPgBouncerMode(t *testing.T) {
+	t.Parallel()
+
+	ctx := testutil.Ctx(t)
+	connString := testutil.PoolConnString(t, nil)
+
+	_, err := pgdb.NewPool(ctx, connString, zaptest.NewLogger(t), &pgdb.NewPoolOpts{
+		PgBouncerMode: true,
+		Role:          "test",
+	})
+	require.Error(t, err)
+
+	_, err = pgdb.NewPool(ctx, connString, zaptest.NewLogger(t), &pgdb.NewPoolOpts{
+		PgBouncerMode: true,
+		WatchMode:     pgdb.WatchModeNotify,
+	})
+	require.Error(t, err)
+
+	pool, err := pgdb.NewPool(ctx, connString, zaptest.NewLogger(t), &pgdb.NewPoolOpts{
+		PgBouncerMode: true,
+	})
+	require.NoError(t, err)
+	t.Cleanup(pool.Close)
+
+	schemaName := testutil.SchemaName(t)
+	tableName := testutil.TableName(t)
+
+	t.Cleanup(func() {
+		require.NoError(t, pool.DropDatabase(ctx, schemaName))
+	})
+
+	doc := must.NotFail(types.NewDocument("_id", int32(1), "v", "foo"))
+	require.NoError(t, pool.InsertDocuments(ctx, schemaName, tableName, []*types.Document{doc}))
+
+	res, err := pool.QueryDocuments(ctx, &pgdb.QueryParams{
+		DB:         schemaName,
+		Collection: tableName,
+		Filter:     must.NotFail(types.NewDocument("v", "foo")),
+	})
+	require.NoError(t, err)
+	assert.Equal(t, []*types.Document{doc}, res.Docs)
+}
+
 func TestCreateDrop(t *testing.T) {
 	t.Parallel()
 
@@ -191,7 +291,7 @@ func TestConcurrentCreate(t *testing.T) {
 
 	n := 10
 	dsn := fmt.Sprintf("postgres://postgres@127.0.0.1:5432/%[1]s?pool_min_conns=%[2]d&pool_max_conns=%[2]d", dbName, n)
-	pool, err := pgdb.NewPool(ctx, dsn, zaptest.NewLogger(t), false)
+	pool, err := pgdb.NewPool(ctx, dsn, zaptest.NewLogger(t), nil)
 	require.NoError(t, err)
 	t.Cleanup(pool.Close)
 
@@ -309,7 +409,7 @@ func TestTableExists(t *testing.T) {
 	})
 }
 
-func TestCreateTableIfNotExist(t *testing.T) {
+func TestCreateCollectionIfNotExist(t *testing.T) {
 	t.Parallel()
 
 	ctx := testutil.Ctx(t)
@@ -325,7 +425,7 @@ func TestCreateTableIfNotExist(t *testing.T) {
 			pool.DropDatabase(ctx, schemaName)
 		})
 
-		ok, err := pool.CreateTableIfNotExist(ctx, schemaName, tableName)
+		ok, err := pool.CreateCollectionIfNotExist(ctx, schemaName, tableName)
 		require.NoError(t, err)
 		assert.True(t, ok)
 	})
@@ -342,7 +442,7 @@ func TestCreateTableIfNotExist(t *testing.T) {
 			pool.DropDatabase(ctx, schemaName)
 		})
 
-		created, err := pool.CreateTableIfNotExist(ctx, schemaName, tableName)
+		created, err := pool.CreateCollectionIfNotExist(ctx, schemaName, tableName)
 		require.NoError(t, err)
 		assert.True(t, created)
 	})
@@ -360,8 +460,1086 @@ func TestCreateTableIfNotExist(t *testing.T) {
 			pool.DropDatabase(ctx, schemaName)
 		})
 
-		created, err := pool.CreateTableIfNotExist(ctx, schemaName, tableName)
+		created, err := pool.CreateCollectionIfNotExist(ctx, schemaName, tableName)
 		require.NoError(t, err)
 		assert.False(t, created)
 	})
 }
+
+func TestMigrateCollection(t *testing.T) {
+	t.Parallel()
+
+	ctx := testutil.Ctx(t)
+	pool := testutil.Pool(ctx, t, nil, zaptest.NewLogger(t))
+	schemaName := testutil.Schema(ctx, t, pool)
+	tableName := testutil.Table(ctx, t, pool, schemaName)
+
+	settings := pgx.Identifier{schemaName, "_ferretdb_settings"}.Sanitize()
+	formatSQL := `SELECT (settings->'formats'->>$1)::int FROM ` + settings
+
+	var format int32
+	require.NoError(t, pool.QueryRow(ctx, formatSQL, tableName).Scan(&format))
+	assert.Equal(t, int32(fjson.LatestVersion), format)
+
+	// make the collection look like one created before format versions were introduced
+	_, err := pool.Exec(ctx, `UPDATE `+settings+` SET settings = settings #- ARRAY['formats', $1]`, tableName)
+	require.NoError(t, err)
+
+	docs := []*types.Document{
+		must.NotFail(types.NewDocument("_id", int32(1), "v", int64(42))),
+		must.NotFail(types.NewDocument("_id", int64(2), "v", must.NotFail(types.NewDocument("b", int32(1), "a", 42.0)))),
+	}
+	for _, doc := range docs {
+		require.NoError(t, pool.InsertDocuments(ctx, schemaName, tableName, []*types.Document{doc}))
+	}
+
+	var progress [][2]int64
+	n, err := pool.MigrateCollection(ctx, schemaName, tableName, 1, func(done, total int64) {
+		progress = append(progress, [2]int64{done, total})
+	})
+	require.NoError(t, err)
+	assert.GreaterOrEqual(t, n, int64(len(docs)))
+	require.NotEmpty(t, progress)
+	assert.Equal(t, [2]int64{1, 2}, progress[0])
+
+	var migrating bool
+	migratingSQL := `SELECT settings->'migrations' ? $1 FROM ` + settings
+	require.NoError(t, pool.QueryRow(ctx, migratingSQL, tableName).Scan(&migrating))
+	assert.False(t, migrating)
+
+	require.NoError(t, pool.QueryRow(ctx, formatSQL, tableName).Scan(&format))
+	assert.Equal(t, int32(fjson.LatestVersion), format)
+
+	res, err := pool.QueryDocuments(ctx, &pgdb.QueryParams{DB: schemaName, Collection: tableName})
+	require.NoError(t, err)
+	assert.ElementsMatch(t, docs, res.Docs)
+
+	// second migration does nothing
+	n, err = pool.MigrateCollection(ctx, schemaName, tableName, 0, nil)
+	require.NoError(t, err)
+	assert.Zero(t, n)
+
+	// _id lookups use the new format
+	deleted, err := pool.DeleteDocumentsByID(ctx, schemaName, tableName, []any{int32(1), int64(2)})
+	require.NoError(t, err)
+	assert.Equal(t, int64(2), deleted)
+
+	_, err = pool.MigrateCollection(ctx, schemaName, "no-such-collection", 0, nil)
+	assert.Equal(t, pgdb.ErrTableNotExist, err)
+}
+
+func TestMigrateCollectionUniqueIDs(t *testing.T) {
+	t.Parallel()
+
+	ctx := testutil.Ctx(t)
+	pool := testutil.Pool(ctx, t, nil, zaptest.NewLogger(t))
+	schemaName := testutil.Schema(ctx, t, pool)
+	tableName := testutil.Table(ctx, t, pool, schemaName)
+
+	settings := pgx.Identifier{schemaName, "_ferretdb_settings"}.Sanitize()
+
+	var table string
+	tableSQL := `SELECT settings->'collections'->>$1 FROM ` + settings
+	require.NoError(t, pool.QueryRow(ctx, tableSQL, tableName).Scan(&table))
+
+	// make the collection look like one created before the unique _id index was introduced
+	var index string
+	indexSQL := `SELECT indexname FROM pg_indexes WHERE schemaname = $1 AND tablename = $2 AND indexdef LIKE 'CREATE UNIQUE%'`
+	require.NoError(t, pool.QueryRow(ctx, indexSQL, schemaName, table).Scan(&index))
+
+	_, err := pool.Exec(ctx, `DROP INDEX `+pgx.Identifier{schemaName, index}.Sanitize())
+	require.NoError(t, err)
+
+	_, err = pool.Exec(ctx, `UPDATE `+settings+` SET settings = settings #- ARRAY['unique_ids', $1]`, tableName)
+	require.NoError(t, err)
+
+	doc := must.NotFail(types.NewDocument("_id", int32(1)))
+	require.NoError(t, pool.InsertDocument(ctx, schemaName, tableName, doc))
+
+	// upserts are not atomic, and duplicates are not rejected
+	inserted, err := pool.InsertDocumentIfNotExists(ctx, schemaName, tableName, doc)
+	require.NoError(t, err)
+	assert.True(t, inserted)
+
+	// the index can't be created
+	_, err = pool.MigrateCollection(ctx, schemaName, tableName, 0, nil)
+	var pgErr *pgconn.PgError
+	require.ErrorAs(t, err, &pgErr)
+	assert.Equal(t, pgerrcode.UniqueViolation, pgErr.Code)
+
+	var indexes int
+	countSQL := `SELECT count(*) FROM pg_indexes WHERE schemaname = $1 AND tablename = $2 AND indexdef LIKE 'CREATE UNIQUE%'`
+	require.NoError(t, pool.QueryRow(ctx, countSQL, schemaName, table).Scan(&indexes))
+	assert.Zero(t, indexes, "invalid index should be dropped")
+
+	_, err = pool.DeleteDocumentsByID(ctx, schemaName, tableName, []any{int32(1)})
+	require.NoError(t, err)
+	require.NoError(t, pool.InsertDocument(ctx, schemaName, tableName, doc))
+
+	_, err = pool.MigrateCollection(ctx, schemaName, tableName, 0, nil)
+	require.NoError(t, err)
+
+	require.NoError(t, pool.QueryRow(ctx, countSQL, schemaName, table).Scan(&indexes))
+	assert.Equal(t, 1, indexes)
+
+	var uniqueIDs bool
+	uniqueIDsSQL := `SELECT (settings->'unique_ids'->>$1)::bool FROM ` + settings
+	require.NoError(t, pool.QueryRow(ctx, uniqueIDsSQL, tableName).Scan(&uniqueIDs))
+	assert.True(t, uniqueIDs)
+
+	inserted, err = pool.InsertDocumentIfNotExists(ctx, schemaName, tableName, doc)
+	require.NoError(t, err)
+	assert.False(t, inserted)
+
+	err = pool.InsertDocument(ctx, schemaName, tableName, doc)
+	assert.Equal(t, pgdb.ErrDuplicateID, err)
+
+	// second migration does nothing
+	_, err = pool.MigrateCollection(ctx, schemaName, tableName, 0, nil)
+	require.NoError(t, err)
+}
+
+func TestLongNames(t *testing.T) {
+	t.Parallel()
+
+	ctx := testutil.Ctx(t)
+	pool := testutil.Pool(ctx, t, nil, zaptest.NewLogger(t))
+	schemaName := testutil.Schema(ctx, t, pool)
+
+	err := pool.CreateDatabase(ctx, strings.Repeat("d", 64))
+	assert.Equal(t, pgdb.ErrInvalidDatabaseName, err)
+
+	// both names are longer than PostgreSQL identifiers and differ only after the truncated part
+	prefix := strings.Repeat("я", 40)
+	collections := []string{prefix + "_1", prefix + "_2"}
+
+	for i, collection := range collections {
+		require.NoError(t, pool.CreateCollection(ctx, schemaName, collection))
+
+		doc := must.NotFail(types.NewDocument("_id", int32(i)))
+		require.NoError(t, pool.InsertDocuments(ctx, schemaName, collection, []*types.Document{doc}))
+	}
+
+	actual, err := pool.Collections(ctx, schemaName)
+	require.NoError(t, err)
+	assert.Equal(t, collections, actual)
+
+	tables, err := pool.Tables(ctx, schemaName)
+	require.NoError(t, err)
+	require.Len(t, tables, 2)
+
+	for _, table := range tables {
+		assert.LessOrEqual(t, len(table), 63)
+	}
+
+	require.NoError(t, pool.DropCollection(ctx, schemaName, collections[0]))
+
+	res, err := pool.QueryDocuments(ctx, &pgdb.QueryParams{DB: schemaName, Collection: collections[1]})
+	require.NoError(t, err)
+	assert.Equal(t, []*types.Document{must.NotFail(types.NewDocument("_id", int32(1)))}, res.Docs)
+
+	err = pool.DropCollection(ctx, schemaName, collections[0])
+	assert.Equal(t, pgdb.ErrTableNotExist, err)
+}
+
+func TestMigrateTableNames(t *testing.T) {
+	t.Parallel()
+
+	ctx := testutil.Ctx(t)
+	pool := testutil.Pool(ctx, t, nil, zaptest.NewLogger(t))
+	schemaName := testutil.Schema(ctx, t, pool)
+	tableName := testutil.Table(ctx, t, pool, schemaName)
+
+	// make the table look like one created before the collection names mapping was introduced
+	legacy := "legacy"
+	_, err := pool.Exec(ctx, `CREATE TABLE `+pgx.Identifier{schemaName, legacy}.Sanitize()+` (_jsonb jsonb)`)
+	require.NoError(t, err)
+
+	// tables without documents are not collections
+	_, err = pool.Exec(ctx, `CREATE TABLE `+pgx.Identifier{schemaName, "other"}.Sanitize()+` (v int)`)
+	require.NoError(t, err)
+
+	collections, err := pool.Collections(ctx, schemaName)
+	require.NoError(t, err)
+	assert.Equal(t, []string{tableName}, collections)
+
+	n, err := pool.MigrateTableNames(ctx)
+	require.NoError(t, err)
+	assert.GreaterOrEqual(t, n, 1)
+
+	collections, err = pool.Collections(ctx, schemaName)
+	require.NoError(t, err)
+	assert.ElementsMatch(t, []string{legacy, tableName}, collections)
+
+	tables, err := pool.Tables(ctx, schemaName)
+	require.NoError(t, err)
+	assert.NotContains(t, tables, legacy)
+	assert.Contains(t, tables, "other")
+
+	doc := must.NotFail(types.NewDocument("_id", int32(1)))
+	require.NoError(t, pool.InsertDocuments(ctx, schemaName, legacy, []*types.Document{doc}))
+
+	res, err := pool.QueryDocuments(ctx, &pgdb.QueryParams{DB: schemaName, Collection: legacy})
+	require.NoError(t, err)
+	assert.Equal(t, []*types.Document{doc}, res.Docs)
+
+	// second migration does nothing for that schema
+	_, err = pool.MigrateTableNames(ctx)
+	require.NoError(t, err)
+
+	collections, err = pool.Collections(ctx, schemaName)
+	require.NoError(t, err)
+	assert.ElementsMatch(t, []string{legacy, tableName}, collections)
+}
+
+func TestMigrateCollectionFormatVersion(t *testing.T) {
+	t.Parallel()
+
+	ctx := testutil.Ctx(t)
+	pool := testutil.Pool(ctx, t, nil, zaptest.NewLogger(t))
+	schemaName := testutil.Schema(ctx, t, pool)
+
+	formatVersion := func() fjson.Version { return fjson.Version1 }
+	v1Pool, err := pgdb.NewPool(ctx, testutil.PoolConnString(t, nil), zaptest.NewLogger(t), &pgdb.NewPoolOpts{
+		FormatVersion: formatVersion,
+	})
+	require.NoError(t, err)
+	t.Cleanup(v1Pool.Close)
+
+	tableName := testutil.TableName(t)
+	require.NoError(t, v1Pool.CreateCollection(ctx, schemaName, tableName))
+
+	settings := pgx.Identifier{schemaName, "_ferretdb_settings"}.Sanitize()
+	formatSQL := `SELECT (settings->'formats'->>$1)::int FROM ` + settings
+
+	var format int32
+	require.NoError(t, pool.QueryRow(ctx, formatSQL, tableName).Scan(&format))
+	assert.Equal(t, int32(fjson.Version1), format)
+
+	doc := must.NotFail(types.NewDocument("_id", int32(1), "v", int64(42)))
+	require.NoError(t, v1Pool.InsertDocuments(ctx, schemaName, tableName, []*types.Document{doc}))
+
+	// the permitted version is already used
+	n, err := v1Pool.MigrateCollection(ctx, schemaName, tableName, 0, nil)
+	require.NoError(t, err)
+	assert.Equal(t, int64(0), n)
+
+	require.NoError(t, pool.QueryRow(ctx, formatSQL, tableName).Scan(&format))
+	assert.Equal(t, int32(fjson.Version1), format)
+
+	// the latest version is permitted by the default pool
+	_, err = pool.MigrateCollection(ctx, schemaName, tableName, 0, nil)
+	require.NoError(t, err)
+
+	require.NoError(t, pool.QueryRow(ctx, formatSQL, tableName).Scan(&format))
+	assert.Equal(t, int32(fjson.LatestVersion), format)
+
+	res, err := pool.QueryDocuments(ctx, &pgdb.QueryParams{DB: schemaName, Collection: tableName})
+	require.NoError(t, err)
+	assert.Equal(t, []*types.Document{doc}, res.Docs)
+}
+
+func TestQueryDocumentsFilter(t *testing.T) {
+	t.Parallel()
+
+	ctx := testutil.Ctx(t)
+	pool := testutil.Pool(ctx, t, nil, zaptest.NewLogger(t))
+	schemaName := testutil.Schema(ctx, t, pool)
+	tableName := testutil.Table(ctx, t, pool, schemaName)
+
+	docs := []*types.Document{
+		must.NotFail(types.NewDocument("_id", "string", "v", "foo")),
+		must.NotFail(types.NewDocument("_id", "array", "v", must.NotFail(types.NewArray("bar", "foo")))),
+		must.NotFail(types.NewDocument("_id", "nested", "v", must.NotFail(types.NewArray(must.NotFail(types.NewArray("foo")))))),
+		must.NotFail(types.NewDocument("_id", "other", "v", "bar")),
+	}
+	for _, doc := range docs {
+		require.NoError(t, pool.InsertDocuments(ctx, schemaName, tableName, []*types.Document{doc}))
+	}
+
+	filter := must.NotFail(types.NewDocument("v", "foo", "w", must.NotFail(types.NewDocument("$exists", false))))
+	res, err := pool.QueryDocuments(ctx, &pgdb.QueryParams{DB: schemaName, Collection: tableName, Filter: filter})
+	require.NoError(t, err)
+
+	// arrays containing the value are matched; nested arrays are not
+	assert.ElementsMatch(t, docs[:2], res.Docs)
+	assert.False(t, res.Sorted)
+
+	expected := must.NotFail(types.NewDocument("w", must.NotFail(types.NewDocument("$exists", false))))
+	assert.Equal(t, expected, res.Residual)
+}
+
+func TestQueryDocumentsSort(t *testing.T) {
+	t.Parallel()
+
+	ctx := testutil.Ctx(t)
+	pool := testutil.Pool(ctx, t, nil, zaptest.NewLogger(t))
+	schemaName := testutil.Schema(ctx, t, pool)
+	tableName := testutil.Table(ctx, t, pool, schemaName)
+
+	docs := []*types.Document{
+		must.NotFail(types.NewDocument("_id", int32(1), "v", "foo", "w", int32(1))),
+		must.NotFail(types.NewDocument("_id", int32(2), "v", int64(42), "w", int32(1))),
+		must.NotFail(types.NewDocument("_id", int32(3), "v", 3.5, "w", int32(2))),
+		must.NotFail(types.NewDocument("_id", int32(4), "w", int32(2))),
+		must.NotFail(types.NewDocument("_id", int32(5), "v", true, "w", int32(1))),
+		must.NotFail(types.NewDocument("_id", int32(6), "v", types.Null, "w", int32(2))),
+	}
+	for _, doc := range docs {
+		require.NoError(t, pool.InsertDocuments(ctx, schemaName, tableName, []*types.Document{doc}))
+	}
+
+	t.Run("SingleKey", func(t *testing.T) {
+		t.Parallel()
+
+		sort := must.NotFail(types.NewDocument("v", int32(1)))
+		res, err := pool.QueryDocuments(ctx, &pgdb.QueryParams{DB: schemaName, Collection: tableName, Sort: sort})
+		require.NoError(t, err)
+		require.True(t, res.Sorted)
+
+		// null and missing values are equal, so their relative order is not defined
+		assert.ElementsMatch(t, []*types.Document{docs[3], docs[5]}, res.Docs[:2])
+		assert.Equal(t, []*types.Document{docs[2], docs[1], docs[0], docs[4]}, res.Docs[2:])
+	})
+
+	t.Run("MultiKey", func(t *testing.T) {
+		t.Parallel()
+
+		sort := must.NotFail(types.NewDocument("w", int32(-1), "_id", int32(1)))
+		res, err := pool.QueryDocuments(ctx, &pgdb.QueryParams{DB: schemaName, Collection: tableName, Sort: sort})
+		require.NoError(t, err)
+		require.True(t, res.Sorted)
+
+		expected := []*types.Document{docs[2], docs[3], docs[5], docs[0], docs[1], docs[4]}
+		assert.Equal(t, expected, res.Docs)
+	})
+
+	t.Run("Unsupported", func(t *testing.T) {
+		t.Parallel()
+
+		sort := must.NotFail(types.NewDocument("v.foo", int32(1)))
+		res, err := pool.QueryDocuments(ctx, &pgdb.QueryParams{DB: schemaName, Collection: tableName, Sort: sort})
+		require.NoError(t, err)
+		assert.False(t, res.Sorted)
+		assert.ElementsMatch(t, docs, res.Docs)
+	})
+}
+
+func TestQueryDocumentsProjection(t *testing.T) {
+	t.Parallel()
+
+	ctx := testutil.Ctx(t)
+	pool := testutil.Pool(ctx, t, nil, zaptest.NewLogger(t))
+	schemaName := testutil.Schema(ctx, t, pool)
+	tableName := testutil.Table(ctx, t, pool, schemaName)
+
+	docs := []*types.Document{
+		must.NotFail(types.NewDocument(
+			"_id", int32(1), "a", "foo", "b", int32(1), "c", must.NotFail(types.NewDocument("d", true)),
+		)),
+		must.NotFail(types.NewDocument("_id", int32(2), "c", int64(42), "b", int32(2))),
+	}
+	for _, doc := range docs {
+		require.NoError(t, pool.InsertDocuments(ctx, schemaName, tableName, []*types.Document{doc}))
+	}
+
+	res, err := pool.QueryDocuments(ctx, &pgdb.QueryParams{
+		DB:         schemaName,
+		Collection: tableName,
+		Filter:     must.NotFail(types.NewDocument("b", must.NotFail(types.NewDocument("$gt", int32(0))))),
+		Sort:       must.NotFail(types.NewDocument("_id", int32(1))),
+		Projection: must.NotFail(types.NewDocument("c.d", int32(1), "a", true)),
+	})
+	require.NoError(t, err)
+	require.True(t, res.Sorted)
+
+	expected := []*types.Document{
+		must.NotFail(types.NewDocument(
+			"_id", int32(1), "a", "foo", "b", int32(1), "c", must.NotFail(types.NewDocument("d", true)),
+		)),
+		must.NotFail(types.NewDocument("_id", int32(2), "c", int64(42), "b", int32(2))),
+	}
+	assert.Equal(t, expected, res.Docs)
+
+	res, err = pool.QueryDocuments(ctx, &pgdb.QueryParams{
+		DB:         schemaName,
+		Collection: tableName,
+		Sort:       must.NotFail(types.NewDocument("_id", int32(-1))),
+		Projection: must.NotFail(types.NewDocument("_id", int32(0), "a", int32(1))),
+	})
+	require.NoError(t, err)
+
+	expected = []*types.Document{
+		must.NotFail(types.NewDocument("_id", int32(2))),
+		must.NotFail(types.NewDocument("_id", int32(1), "a", "foo")),
+	}
+	assert.Equal(t, expected, res.Docs)
+}
+
+func TestIndexes(t *testing.T) {
+	t.Parallel()
+
+	ctx := testutil.Ctx(t)
+	pool := testutil.Pool(ctx, t, nil, zaptest.NewLogger(t))
+	schemaName := testutil.Schema(ctx, t, pool)
+
+	ginPool, err := pgdb.NewPool(ctx, testutil.PoolConnString(t, nil), zaptest.NewLogger(t), &pgdb.NewPoolOpts{GINIndex: true})
+	require.NoError(t, err)
+	t.Cleanup(ginPool.Close)
+
+	idIndex := pgdb.Index{Name: "_id_", Key: must.NotFail(types.NewDocument("_id", int32(1)))}
+
+	t.Run("Default", func(t *testing.T) {
+		t.Parallel()
+
+		tableName := testutil.TableName(t)
+		require.NoError(t, pool.CreateCollection(ctx, schemaName, tableName))
+
+		indexes, err := pool.Indexes(ctx, schemaName, tableName)
+		require.NoError(t, err)
+		assert.Equal(t, []pgdb.Index{idIndex}, indexes)
+	})
+
+	t.Run("GIN", func(t *testing.T) {
+		t.Parallel()
+
+		tableName := testutil.TableName(t)
+		require.NoError(t, ginPool.CreateCollection(ctx, schemaName, tableName))
+
+		indexes, err := ginPool.Indexes(ctx, schemaName, tableName)
+		require.NoError(t, err)
+
+		expected := []pgdb.Index{idIndex, {
+			Name:     "_ferretdb_jsonb_gin",
+			Key:      must.NotFail(types.NewDocument("$**", int32(1))),
+			Internal: true,
+		}}
+		assert.Equal(t, expected, indexes)
+	})
+
+	t.Run("NotExist", func(t *testing.T) {
+		t.Parallel()
+
+		_, err := pool.Indexes(ctx, schemaName, "no-such-table")
+		require.Equal(t, pgdb.ErrTableNotExist, err)
+	})
+}
+
+func TestCreateIndex(t *testing.T) {
+	t.Parallel()
+
+	ctx := testutil.Ctx(t)
+	pool := testutil.Pool(ctx, t, nil, zaptest.NewLogger(t))
+	schemaName := testutil.Schema(ctx, t, pool)
+	tableName := testutil.Table(ctx, t, pool, schemaName)
+
+	docs := []*types.Document{
+		must.NotFail(types.NewDocument("_id", int32(1), "v", must.NotFail(types.NewDocument("n", int32(42))))),
+		must.NotFail(types.NewDocument("_id", int32(2), "v", must.NotFail(types.NewDocument("n", "foo")))),
+		must.NotFail(types.NewDocument("_id", int32(3), "v", "bar")),
+	}
+	for _, doc := range docs {
+		require.NoError(t, pool.InsertDocuments(ctx, schemaName, tableName, []*types.Document{doc}))
+	}
+
+	key := must.NotFail(types.NewDocument("v.n", int32(1)))
+	require.NoError(t, pool.CreateIndex(ctx, schemaName, tableName, "v.n_1", key))
+
+	err := pool.CreateIndex(ctx, schemaName, tableName, "v.n_1", key)
+	assert.Equal(t, pgdb.ErrAlreadyExist, err)
+
+	err = pool.CreateIndex(ctx, schemaName, tableName, "v.n_1", must.NotFail(types.NewDocument("v.n", int32(-1))))
+	assert.Equal(t, pgdb.ErrIndexKeyConflict, err)
+
+	err = pool.CreateIndex(ctx, schemaName, tableName, "other", must.NotFail(types.NewDocument("v.n", 1.0)))
+	assert.Equal(t, pgdb.ErrIndexNameConflict, err)
+
+	err = pool.CreateIndex(ctx, schemaName, tableName, "compound", must.NotFail(types.NewDocument("a", int32(1), "b", int32(1))))
+	assert.Equal(t, pgdb.ErrIndexNotSupported, err)
+
+	err = pool.CreateIndex(ctx, schemaName, "no-such-table", "v_1", must.NotFail(types.NewDocument("v", int32(1))))
+	assert.Equal(t, pgdb.ErrTableNotExist, err)
+
+	indexes, err := pool.Indexes(ctx, schemaName, tableName)
+	require.NoError(t, err)
+	require.Len(t, indexes, 2)
+	assert.Equal(t, pgdb.Index{Name: "v.n_1", Key: key}, indexes[1])
+
+	// generated columns do not break inserts and queries
+	require.NoError(t, pool.InsertDocument(ctx, schemaName, tableName, must.NotFail(types.NewDocument(
+		"_id", int32(4), "v", must.NotFail(types.NewDocument("n", int64(13))),
+	))))
+
+	res, err := pool.QueryDocuments(ctx, &pgdb.QueryParams{DB: schemaName, Collection: tableName})
+	require.NoError(t, err)
+	assert.Len(t, res.Docs, 4)
+
+	require.NoError(t, pool.DropCollection(ctx, schemaName, tableName))
+	require.NoError(t, pool.CreateCollection(ctx, schemaName, tableName))
+
+	indexes, err = pool.Indexes(ctx, schemaName, tableName)
+	require.NoError(t, err)
+	assert.Len(t, indexes, 1)
+}
+
+func TestPartitionedCollection(t *testing.T) {
+	t.Parallel()
+
+	ctx := testutil.Ctx(t)
+	pool := testutil.Pool(ctx, t, nil, zaptest.NewLogger(t))
+
+	for name, p := range map[string]*pgdb.Partitioning{
+		"Hash": {Kind: pgdb.PartitionByHash, Partitions: 4},
+		"Date": {Kind: pgdb.PartitionByDate, Path: "v.date", Interval: pgdb.PartitionMonth},
+	} {
+		name, p := name, p
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			schemaName := testutil.SchemaName(t)
+			tableName := testutil.TableName(t)
+
+			t.Cleanup(func() {
+				require.NoError(t, pool.DropDatabase(ctx, schemaName))
+			})
+
+			require.NoError(t, pool.CreateDatabase(ctx, schemaName))
+			require.NoError(t, pool.CreatePartitionedCollection(ctx, schemaName, tableName, p))
+
+			date := time.Date(2022, time.September, 1, 0, 0, 0, 0, time.UTC)
+			docs := make([]*types.Document, 30)
+			for i := range docs {
+				v := must.NotFail(types.NewDocument("date", date.AddDate(0, i%3, 0)))
+				docs[i] = must.NotFail(types.NewDocument("_id", int32(i), "v", v))
+			}
+
+			// documents without date values are stored in the default partition
+			docs[0] = must.NotFail(types.NewDocument("_id", int32(0), "v", "foo"))
+
+			require.NoError(t, pool.InsertDocuments(ctx, schemaName, tableName, docs[:20]))
+			for _, doc := range docs[20:] {
+				require.NoError(t, pool.InsertDocument(ctx, schemaName, tableName, doc))
+			}
+
+			// _id values are unique across partitions
+			dup := must.NotFail(types.NewDocument("_id", int32(2), "v", "bar"))
+			assert.Equal(t, pgdb.ErrDuplicateID, pool.InsertDocument(ctx, schemaName, tableName, dup))
+			assert.Error(t, pool.InsertDocuments(ctx, schemaName, tableName, []*types.Document{dup}))
+
+			inserted, err := pool.InsertDocumentIfNotExists(ctx, schemaName, tableName, dup)
+			require.NoError(t, err)
+			assert.False(t, inserted)
+
+			// only one of concurrent inserts of the same _id value into different partitions succeeds
+			errs := make(chan error, 10)
+			for i := 0; i < cap(errs); i++ {
+				v := must.NotFail(types.NewDocument("date", date.AddDate(0, 0, i)))
+				doc := must.NotFail(types.NewDocument("_id", int32(100), "v", v))
+				go func() {
+					errs <- pool.InsertDocument(ctx, schemaName, tableName, doc)
+				}()
+			}
+
+			var succeeded int
+			for i := 0; i < cap(errs); i++ {
+				if err := <-errs; err == nil {
+					succeeded++
+				} else {
+					assert.Equal(t, pgdb.ErrDuplicateID, err)
+				}
+			}
+			assert.Equal(t, 1, succeeded)
+
+			_, err = pool.DeleteDocumentsByID(ctx, schemaName, tableName, []any{int32(100)})
+			require.NoError(t, err)
+
+			// with date partitioning, updated document is moved to a new partition
+			v := must.NotFail(types.NewDocument("date", date.AddDate(1, 0, 0)))
+			updated := must.NotFail(types.NewDocument("_id", int32(1), "v", v))
+			n, err := pool.SetDocumentByID(ctx, schemaName, tableName, int32(1), updated)
+			require.NoError(t, err)
+			assert.Equal(t, int64(1), n)
+			docs[1] = updated
+
+			res, err := pool.QueryDocuments(ctx, &pgdb.QueryParams{
+				DB:         schemaName,
+				Collection: tableName,
+				Sort:       must.NotFail(types.NewDocument("_id", int32(1))),
+			})
+			require.NoError(t, err)
+			assert.Equal(t, docs, res.Docs)
+
+			// partitions are not listed
+			tables, err := pool.Tables(ctx, schemaName)
+			require.NoError(t, err)
+			assert.Len(t, tables, 1)
+
+			collections, err := pool.Collections(ctx, schemaName)
+			require.NoError(t, err)
+			assert.Equal(t, []string{tableName}, collections)
+		})
+	}
+}
+
+func TestTimeseriesCollection(t *testing.T) {
+	t.Parallel()
+
+	ctx := testutil.Ctx(t)
+	pool := testutil.Pool(ctx, t, nil, zaptest.NewLogger(t))
+	schemaName := testutil.SchemaName(t)
+	tableName := testutil.TableName(t)
+
+	t.Cleanup(func() {
+		require.NoError(t, pool.DropDatabase(ctx, schemaName))
+	})
+
+	ts := &pgdb.Timeseries{TimeField: "t", MetaField: "m", Granularity: pgdb.TimeseriesSeconds}
+
+	require.NoError(t, pool.CreateDatabase(ctx, schemaName))
+	require.NoError(t, pool.CreateCollectionWithParams(ctx, schemaName, tableName, &pgdb.CreateCollectionParams{
+		Timeseries: ts,
+	}))
+
+	params, err := pool.CollectionParams(ctx, schemaName, tableName)
+	require.NoError(t, err)
+	assert.Equal(t, ts, params.Timeseries)
+	assert.Nil(t, params.Partitioning)
+
+	date := time.Date(2022, time.September, 1, 0, 0, 0, 0, time.UTC)
+	docs := make([]*types.Document, 30)
+	for i := range docs {
+		docs[i] = must.NotFail(types.NewDocument("_id", int32(i), "t", date.Add(time.Duration(i)*time.Hour), "m", "foo"))
+	}
+
+	require.NoError(t, pool.InsertDocuments(ctx, schemaName, tableName, docs))
+
+	var tfe *pgdb.TimeFieldError
+	err = pool.InsertDocument(ctx, schemaName, tableName, must.NotFail(types.NewDocument("_id", int32(30), "m", "foo")))
+	require.ErrorAs(t, err, &tfe)
+	assert.Equal(t, "t", tfe.TimeField)
+
+	// the range query is pushed down, so only partitions of matching days are scanned
+	res, err := pool.QueryDocuments(ctx, &pgdb.QueryParams{
+		DB:         schemaName,
+		Collection: tableName,
+		Filter: must.NotFail(types.NewDocument("t", must.NotFail(types.NewDocument(
+			"$gte", date.AddDate(0, 0, 1),
+		)))),
+		Sort: must.NotFail(types.NewDocument("_id", int32(1))),
+	})
+	require.NoError(t, err)
+	assert.Equal(t, docs[24:], res.Docs)
+}
+
+func TestInsertDocuments(t *testing.T) {
+	t.Parallel()
+
+	ctx := testutil.Ctx(t)
+	pool := testutil.Pool(ctx, t, nil, zaptest.NewLogger(t))
+	schemaName := testutil.SchemaName(t)
+	tableName := testutil.TableName(t)
+
+	t.Cleanup(func() {
+		require.NoError(t, pool.DropDatabase(ctx, schemaName))
+	})
+
+	docs := make([]*types.Document, 100)
+	for i := range docs {
+		docs[i] = must.NotFail(types.NewDocument("_id", int32(i), "v", int64(i)))
+	}
+
+	// database and collection are created
+	require.NoError(t, pool.InsertDocuments(ctx, schemaName, tableName, docs))
+
+	res, err := pool.QueryDocuments(ctx, &pgdb.QueryParams{
+		DB:         schemaName,
+		Collection: tableName,
+		Sort:       must.NotFail(types.NewDocument("_id", int32(1))),
+	})
+	require.NoError(t, err)
+	require.True(t, res.Sorted)
+	assert.Equal(t, docs, res.Docs)
+}
+
+func TestBulkInsertDocuments(t *testing.T) {
+	t.Parallel()
+
+	ctx := testutil.Ctx(t)
+	pool := testutil.Pool(ctx, t, nil, zaptest.NewLogger(t))
+	schemaName := testutil.Schema(ctx, t, pool)
+
+	countIndexes := func(t *testing.T) int {
+		t.Helper()
+
+		var res int
+		sql := `SELECT count(*) FROM pg_indexes WHERE schemaname = $1`
+		require.NoError(t, pool.QueryRow(ctx, sql, schemaName).Scan(&res))
+
+		return res
+	}
+
+	docs := make([]*types.Document, 100)
+	for i := range docs {
+		docs[i] = must.NotFail(types.NewDocument("_id", int32(i), "v", int64(i)))
+	}
+
+	t.Run("CreateIndex", func(t *testing.T) {
+		bulkPool, err := pgdb.NewPool(ctx, testutil.PoolConnString(t, nil), zaptest.NewLogger(t), &pgdb.NewPoolOpts{
+			GINIndex:             true,
+			BulkImportIndexDelay: time.Hour,
+		})
+		require.NoError(t, err)
+		t.Cleanup(bulkPool.Close)
+
+		tableName := testutil.TableName(t)
+		require.NoError(t, bulkPool.CreateCollection(ctx, schemaName, tableName))
+		key := must.NotFail(types.NewDocument("v", int32(1)))
+		require.NoError(t, bulkPool.CreateIndex(ctx, schemaName, tableName, "v_1", key))
+
+		before := countIndexes(t)
+
+		require.NoError(t, bulkPool.BulkInsertDocuments(ctx, schemaName, tableName, docs))
+
+		// GIN index and two btree indexes on generated columns are deferred
+		assert.Equal(t, before-3, countIndexes(t))
+
+		// but they are still reported
+		indexes, err := bulkPool.Indexes(ctx, schemaName, tableName)
+		require.NoError(t, err)
+		assert.Len(t, indexes, 3)
+
+		// creating another index builds deferred ones
+		key = must.NotFail(types.NewDocument("w", int32(1)))
+		require.NoError(t, bulkPool.CreateIndex(ctx, schemaName, tableName, "w_1", key))
+		assert.Equal(t, before+2, countIndexes(t))
+
+		res, err := bulkPool.QueryDocuments(ctx, &pgdb.QueryParams{DB: schemaName, Collection: tableName})
+		require.NoError(t, err)
+		assert.Len(t, res.Docs, len(docs))
+	})
+
+	t.Run("Delay", func(t *testing.T) {
+		bulkPool, err := pgdb.NewPool(ctx, testutil.PoolConnString(t, nil), zaptest.NewLogger(t), &pgdb.NewPoolOpts{
+			GINIndex:             true,
+			BulkImportIndexDelay: 100 * time.Millisecond,
+		})
+		require.NoError(t, err)
+		t.Cleanup(bulkPool.Close)
+
+		tableName := testutil.TableName(t)
+		require.NoError(t, bulkPool.CreateCollection(ctx, schemaName, tableName))
+
+		before := countIndexes(t)
+
+		require.NoError(t, bulkPool.BulkInsertDocuments(ctx, schemaName, tableName, docs))
+
+		assert.Eventually(t, func() bool {
+			return countIndexes(t) == before
+		}, 10*time.Second, 50*time.Millisecond)
+	})
+}
+
+func TestInsertDocumentIfNotExists(t *testing.T) {
+	t.Parallel()
+
+	ctx := testutil.Ctx(t)
+	pool := testutil.Pool(ctx, t, nil, zaptest.NewLogger(t))
+	schemaName := testutil.SchemaName(t)
+	tableName := testutil.TableName(t)
+
+	t.Cleanup(func() {
+		require.NoError(t, pool.DropDatabase(ctx, schemaName))
+	})
+
+	require.NoError(t, pool.InsertDocument(ctx, schemaName, tableName, must.NotFail(types.NewDocument("_id", int32(1)))))
+
+	err := pool.InsertDocument(ctx, schemaName, tableName, must.NotFail(types.NewDocument("_id", int32(1))))
+	assert.Equal(t, pgdb.ErrDuplicateID, err)
+
+	n := 10
+	start := make(chan struct{})
+	res := make(chan bool, n)
+	for i := 0; i < n; i++ {
+		go func(i int) {
+			<-start
+			inserted, err := pool.InsertDocumentIfNotExists(
+				ctx, schemaName, tableName, must.NotFail(types.NewDocument("_id", int32(2), "v", int32(i))),
+			)
+			assert.NoError(t, err)
+			res <- inserted
+		}(i)
+	}
+
+	close(start)
+
+	var inserted int
+	for i := 0; i < n; i++ {
+		if <-res {
+			inserted++
+		}
+	}
+	assert.Equal(t, 1, inserted)
+
+	docs, err := pool.QueryDocuments(ctx, &pgdb.QueryParams{
+		DB:         schemaName,
+		Collection: tableName,
+	})
+	require.NoError(t, err)
+	assert.Len(t, docs.Docs, 2)
+}
+
+func TestQueryIterator(t *testing.T) {
+	t.Parallel()
+
+	ctx := testutil.Ctx(t)
+	pool := testutil.Pool(ctx, t, nil, zaptest.NewLogger(t))
+	schemaName := testutil.SchemaName(t)
+	tableName := testutil.TableName(t)
+
+	t.Cleanup(func() {
+		require.NoError(t, pool.DropDatabase(ctx, schemaName))
+	})
+
+	// more than one batch
+	docs := make([]*types.Document, 250)
+	for i := range docs {
+		docs[i] = must.NotFail(types.NewDocument("_id", int32(i), "v", int64(i)))
+	}
+
+	require.NoError(t, pool.InsertDocuments(ctx, schemaName, tableName, docs))
+
+	qp := &pgdb.QueryParams{
+		DB:         schemaName,
+		Collection: tableName,
+		Sort:       must.NotFail(types.NewDocument("_id", int32(1))),
+	}
+
+	t.Run("All", func(t *testing.T) {
+		t.Parallel()
+
+		iter, err := pool.QueryIterator(ctx, qp)
+		require.NoError(t, err)
+
+		var actual []*types.Document
+		for {
+			doc, err := iter.Next()
+			if err == io.EOF {
+				break
+			}
+			require.NoError(t, err)
+			actual = append(actual, doc)
+		}
+
+		// errors are sticky
+		_, err = iter.Next()
+		require.Equal(t, io.EOF, err)
+
+		require.NoError(t, iter.Close())
+		assert.True(t, iter.Sorted())
+		assert.Equal(t, docs, actual)
+	})
+
+	t.Run("Close", func(t *testing.T) {
+		t.Parallel()
+
+		iter, err := pool.QueryIterator(ctx, qp)
+		require.NoError(t, err)
+
+		doc, err := iter.Next()
+		require.NoError(t, err)
+		assert.Equal(t, docs[0], doc)
+
+		require.NoError(t, iter.Close())
+		require.NoError(t, iter.Close())
+	})
+}
+
+func TestMetadataCache(t *testing.T) {
+	t.Parallel()
+
+	ctx := testutil.Ctx(t)
+	pool, err := pgdb.NewPool(ctx, testutil.PoolConnString(t, nil), zaptest.NewLogger(t), &pgdb.NewPoolOpts{
+		MetadataCache: pgdb.NewMetadataCache(time.Hour),
+	})
+	require.NoError(t, err)
+	t.Cleanup(pool.Close)
+
+	schemaName := testutil.SchemaName(t)
+	tableName := testutil.TableName(t)
+
+	t.Cleanup(func() {
+		require.NoError(t, pool.DropDatabase(ctx, schemaName))
+	})
+
+	doc := must.NotFail(types.NewDocument("_id", int32(1)))
+	require.NoError(t, pool.InsertDocuments(ctx, schemaName, tableName, []*types.Document{doc}))
+
+	require.NoError(t, pool.WarmUpMetadata(ctx))
+
+	collections, err := pool.Collections(ctx, schemaName)
+	require.NoError(t, err)
+	assert.Equal(t, []string{tableName}, collections)
+
+	res, err := pool.QueryDocuments(ctx, &pgdb.QueryParams{DB: schemaName, Collection: tableName})
+	require.NoError(t, err)
+	assert.Equal(t, []*types.Document{doc}, res.Docs)
+
+	// DDL operations invalidate cached metadata
+	require.NoError(t, pool.DropCollection(ctx, schemaName, tableName))
+
+	exists, err := pool.CollectionExists(ctx, schemaName, tableName)
+	require.NoError(t, err)
+	assert.False(t, exists)
+
+	require.NoError(t, pool.CreateCollection(ctx, schemaName, tableName))
+
+	exists, err = pool.CollectionExists(ctx, schemaName, tableName)
+	require.NoError(t, err)
+	assert.True(t, exists)
+
+	res, err = pool.QueryDocuments(ctx, &pgdb.QueryParams{DB: schemaName, Collection: tableName})
+	require.NoError(t, err)
+	assert.Empty(t, res.Docs)
+}
+
+func TestAuthenticate(t *testing.T) {
+	t.Parallel()
+
+	ctx := testutil.Ctx(t)
+	pool := testutil.Pool(ctx, t, nil, zaptest.NewLogger(t))
+
+	role := testutil.SchemaName(t)
+	_, err := pool.Exec(ctx, `CREATE ROLE `+pgx.Identifier{role}.Sanitize()+` LOGIN PASSWORD 'secret'`)
+	require.NoError(t, err)
+
+	rolePool, err := pgdb.NewPool(ctx, testutil.PoolConnString(t, nil), zaptest.NewLogger(t), &pgdb.NewPoolOpts{
+		Username: role,
+		Password: "secret",
+	})
+	require.NoError(t, err)
+	t.Cleanup(rolePool.Close)
+
+	require.NoError(t, rolePool.Authenticate(ctx, "secret"))
+
+	_, err = pool.Exec(ctx, `DROP ROLE `+pgx.Identifier{role}.Sanitize())
+	require.NoError(t, err)
+
+	// idle connections of the pool still work, but a new connection is checked
+	require.NoError(t, rolePool.Ping(ctx))
+	assert.Error(t, rolePool.Authenticate(ctx, "secret"))
+}
+
+func TestWatch(t *testing.T) {
+	t.Parallel()
+
+	ctx := testutil.Ctx(t)
+	pool, err := pgdb.NewPool(ctx, testutil.PoolConnString(t, nil), zaptest.NewLogger(t), &pgdb.NewPoolOpts{
+		WatchMode: pgdb.WatchModeNotify,
+	})
+	require.NoError(t, err)
+	t.Cleanup(pool.Close)
+
+	schemaName := testutil.SchemaName(t)
+	tableName := testutil.TableName(t)
+
+	t.Cleanup(func() {
+		pool.DropDatabase(ctx, schemaName)
+	})
+
+	require.NoError(t, pool.CreateCollection(ctx, schemaName, tableName))
+
+	events, err := pool.Watch(ctx)
+	require.NoError(t, err)
+
+	doc := must.NotFail(types.NewDocument("_id", int32(1), "v", "foo"))
+	require.NoError(t, pool.InsertDocuments(ctx, schemaName, tableName, []*types.Document{doc}))
+
+	require.NoError(t, doc.Set("v", "bar"))
+	_, err = pool.SetDocumentByID(ctx, schemaName, tableName, int32(1), doc)
+	require.NoError(t, err)
+
+	// too large for notification payload
+	largeID := strings.Repeat("x", 8000)
+	largeDoc := must.NotFail(types.NewDocument("_id", largeID))
+	require.NoError(t, pool.InsertDocuments(ctx, schemaName, tableName, []*types.Document{largeDoc}))
+
+	_, err = pool.DeleteDocumentsByID(ctx, schemaName, tableName, []any{int32(1), largeID})
+	require.NoError(t, err)
+
+	var actual []pgdb.WatchEvent
+	for len(actual) < 5 {
+		select {
+		case event, ok := <-events:
+			require.True(t, ok)
+			if event.DB == schemaName {
+				actual = append(actual, event)
+			}
+
+		case <-time.After(10 * time.Second):
+			t.Fatalf("got only %d events: %+v", len(actual), actual)
+		}
+	}
+
+	expected := []pgdb.WatchEvent{
+		{Op: "insert", DB: schemaName, Collection: tableName, ID: int32(1)},
+		{Op: "update", DB: schemaName, Collection: tableName, ID: int32(1)},
+		{Op: "insert", DB: schemaName, Collection: tableName, ID: types.Null},
+		{Op: "delete", DB: schemaName, Collection: tableName, ID: int32(1)},
+		{Op: "delete", DB: schemaName, Collection: tableName, ID: types.Null},
+	}
+
+	// deletes of the same statement could be in any order
+	assert.Equal(t, expected[:3], actual[:3])
+	assert.ElementsMatch(t, expected[3:], actual[3:])
+
+	// watch mode is not enabled
+	other := testutil.Pool(ctx, t, nil, zaptest.NewLogger(t))
+	_, err = other.Watch(ctx)
+	require.Error(t, err)
+}
+
+func TestCreateWatchTriggers(t *testing.T) {
+	t.Parallel()
+
+	ctx := testutil.Ctx(t)
+
+	// collection created without watch mode
+	other := testutil.Pool(ctx, t, nil, zaptest.NewLogger(t))
+	schemaName := testutil.Schema(ctx, t, other)
+	tableName := testutil.Table(ctx, t, other, schemaName)
+
+	pool, err := pgdb.NewPool(ctx, testutil.PoolConnString(t, nil), zaptest.NewLogger(t), &pgdb.NewPoolOpts{
+		WatchMode: pgdb.WatchModeNotify,
+	})
+	require.NoError(t, err)
+	t.Cleanup(pool.Close)
+
+	require.NoError(t, pool.CreateWatchTriggers(ctx))
+
+	// triggers are not created twice
+	require.NoError(t, pool.CreateWatchTriggers(ctx))
+
+	events, err := pool.Watch(ctx)
+	require.NoError(t, err)
+
+	doc := must.NotFail(types.NewDocument("_id", int32(1)))
+	require.NoError(t, other.InsertDocuments(ctx, schemaName, tableName, []*types.Document{doc}))
+
+	for {
+		select {
+		case event, ok := <-events:
+			require.True(t, ok)
+			if event.DB != schemaName {
+				continue
+			}
+
+			expected := pgdb.WatchEvent{Op: "insert", DB: schemaName, Collection: tableName, ID: int32(1)}
+			assert.Equal(t, expected, event)
+
+			return
+
+		case <-time.After(10 * time.Second):
+			t.Fatal("no event")
+		}
+	}
+}
@@ -0,0 +1,128 @@
+// Copyright 2021 FerretDB Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package pgdb
+
+import (
+	"strings"
+
+	"golang.org/x/exp/slices"
+
+	"github.com/FerretDB/FerretDB/internal/types"
+	"github.com/FerretDB/FerretDB/internal/util/must"
+)
+
+// buildProjection returns SQL expression that selects only top-level fields needed
+// for the given inclusion projection, residual filter, and sort, and its arguments
+// using placeholders starting after p.
+//
+// Selected documents contain all fields included by projection, so the projection
+// should still be applied to them by the caller.
+// Empty string is returned if projection can't be pushed down: it is empty, not a pure inclusion,
+// or residual filter or sort contains operators.
+func buildProjection(p *Placeholder, projection, residual, sort *types.Document) (string, []any) {
+	keys := projectionKeys(projection)
+	if keys == nil {
+		return "", nil
+	}
+
+	for _, doc := range []*types.Document{residual, sort} {
+		if doc == nil {
+			continue
+		}
+
+		for _, k := range doc.Keys() {
+			if k == "$comment" {
+				continue
+			}
+
+			if k == "" || strings.HasPrefix(k, "$") {
+				return "", nil
+			}
+
+			if prefix := topLevelField(k); !slices.Contains(keys, prefix) {
+				keys = append(keys, prefix)
+			}
+		}
+	}
+
+	keysArg := p.Next() + `::text[]`
+
+	sql := `COALESCE((SELECT jsonb_object_agg(key, value) FROM jsonb_each(_jsonb) WHERE key = ANY(` + keysArg + `)), '{}')` +
+		` || jsonb_build_object('$k', (SELECT COALESCE(jsonb_agg(k ORDER BY o), '[]')` +
+		` FROM jsonb_array_elements_text(_jsonb->'$k') WITH ORDINALITY AS t(k, o) WHERE k = ANY(` + keysArg + `)))`
+
+	return sql, []any{keys}
+}
+
+// projectionKeys returns top-level fields included by the given projection,
+// or nil if it is not a pure inclusion projection.
+//
+// The _id field is included unless it is explicitly excluded.
+func projectionKeys(projection *types.Document) []string {
+	if projection == nil {
+		return nil
+	}
+
+	keys := []string{"_id"}
+	var inclusion bool
+
+	for _, k := range projection.Keys() {
+		if k == "" || strings.HasPrefix(k, "$") || slices.Contains(strings.Split(k, "."), "") {
+			return nil
+		}
+
+		included := projectionIncluded(must.NotFail(projection.Get(k)))
+
+		if k == "_id" {
+			if !included {
+				keys = slices.Delete(keys, 0, 1)
+			}
+			continue
+		}
+
+		if !included {
+			return nil
+		}
+		inclusion = true
+
+		if prefix := topLevelField(k); !slices.Contains(keys, prefix) {
+			keys = append(keys, prefix)
+		}
+	}
+
+	if !inclusion {
+		return nil
+	}
+
+	return keys
+}
+
+// projectionIncluded returns true if the given projection value includes the field.
+// Projection operators are not supported.
+func projectionIncluded(v any) bool {
+	switch v := v.(type) {
+	case float64, int32, int64:
+		return types.Compare(v, int32(0)) != types.Equal
+	case bool:
+		return v
+	default:
+		return false
+	}
+}
+
+// topLevelField returns the first element of the given dot notation path.
+func topLevelField(path string) string {
+	return strings.SplitN(path, ".", 2)[0]
+}
@@ -0,0 +1,86 @@
+// Copyright 2021 FerretDB Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package pgdb
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/FerretDB/FerretDB/internal/types"
+	"github.com/FerretDB/FerretDB/internal/util/must"
+)
+
+func TestBuildProjection(t *testing.T) {
+	t.Parallel()
+
+	for name, tc := range map[string]struct {
+		projection *types.Document
+		residual   *types.Document
+		sort       *types.Document
+		keys       []string // nil if projection can't be pushed down
+	}{
+		"Nil":   {},
+		"Empty": {projection: must.NotFail(types.NewDocument())},
+		"Inclusion": {
+			projection: must.NotFail(types.NewDocument("a", int32(1), "b", true)),
+			keys:       []string{"_id", "a", "b"},
+		},
+		"DotNotation": {
+			projection: must.NotFail(types.NewDocument("a.b", int32(1), "a.c", 1.0)),
+			keys:       []string{"_id", "a"},
+		},
+		"ExcludeID": {
+			projection: must.NotFail(types.NewDocument("_id", false, "a", int64(1))),
+			keys:       []string{"a"},
+		},
+		"OnlyID": {
+			projection: must.NotFail(types.NewDocument("_id", int32(1))),
+		},
+		"Exclusion": {
+			projection: must.NotFail(types.NewDocument("a", int32(0))),
+		},
+		"Operator": {
+			projection: must.NotFail(types.NewDocument("a", must.NotFail(types.NewDocument("$slice", int32(1))))),
+		},
+		"ResidualAndSort": {
+			projection: must.NotFail(types.NewDocument("a", int32(1))),
+			residual:   must.NotFail(types.NewDocument("$comment", "test", "b.c", int32(1), "a", int32(2))),
+			sort:       must.NotFail(types.NewDocument("d", int32(-1))),
+			keys:       []string{"_id", "a", "b", "d"},
+		},
+		"ResidualOperator": {
+			projection: must.NotFail(types.NewDocument("a", int32(1))),
+			residual:   must.NotFail(types.NewDocument("$or", must.NotFail(types.NewArray()))),
+		},
+	} {
+		name, tc := name, tc
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			p := Placeholder(1)
+			sql, args := buildProjection(&p, tc.projection, tc.residual, tc.sort)
+
+			if tc.keys == nil {
+				assert.Empty(t, sql)
+				assert.Nil(t, args)
+				return
+			}
+
+			assert.Contains(t, sql, "ANY($2::text[])")
+			assert.Equal(t, []any{tc.keys}, args)
+		})
+	}
+}
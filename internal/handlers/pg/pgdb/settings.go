@@ -18,6 +18,7 @@ import (
 	"context"
 	"fmt"
 	"hash/fnv"
+	"unicode/utf8"
 
 	"github.com/jackc/pgconn"
 	"github.com/jackc/pgerrcode"
@@ -42,7 +43,8 @@ const (
 )
 
 // createSettingsTable creates FerretDB settings table if it doesn't exist.
-// Settings table is used to store FerretDB settings like collections names mapping.
+// Settings table is used to store FerretDB settings like collections names mapping
+// and FJSON format versions of collections documents.
 // That table consists of a single document with settings.
 func (pgPool *Pool) createSettingsTable(ctx context.Context, tx pgx.Tx, db string) error {
 	tables, err := pgPool.tables(ctx, tx, db)
@@ -90,52 +92,252 @@ func (pgPool *Pool) createSettingsTable(ctx context.Context, tx pgx.Tx, db strin
 // If the settings table doesn't exist, it will be created.
 // If the record for collection doesn't exist, it will be created.
 func (pgPool *Pool) getTableName(ctx context.Context, tx pgx.Tx, db, collection string) (string, error) {
+	table, _, err := pgPool.tableName(ctx, tx, db, collection)
+	return table, err
+}
+
+// tableName is getTableName that also returns the settings document
+// if it exists and was not modified by that call.
+func (pgPool *Pool) tableName(ctx context.Context, tx pgx.Tx, db, collection string) (string, *types.Document, error) {
 	schemaExists, err := pgPool.schemaExists(ctx, db)
 	if err != nil {
-		return "", lazyerrors.Error(err)
+		return "", nil, lazyerrors.Error(err)
 	}
 
 	if !schemaExists {
-		return formatCollectionName(collection), nil
+		return formatCollectionName(collection), nil, nil
 	}
 
 	tables, err := pgPool.tables(ctx, tx, db)
 	if err != nil {
-		return "", lazyerrors.Error(err)
+		return "", nil, lazyerrors.Error(err)
 	}
 
 	if !slices.Contains(tables, settingsTableName) {
 		err = pgPool.createSettingsTable(ctx, tx, db)
 		if err != nil {
-			return "", err
+			return "", nil, err
 		}
 	}
 
 	settings, err := pgPool.getSettingsTable(ctx, tx, db)
 	if err != nil {
-		return "", lazyerrors.Error(err)
+		return "", nil, lazyerrors.Error(err)
 	}
 
 	collectionsDoc := must.NotFail(settings.Get("collections"))
 	collections, ok := collectionsDoc.(*types.Document)
 	if !ok {
-		return "", lazyerrors.Errorf("expected document but got %[1]T: %[1]v", collectionsDoc)
+		return "", nil, lazyerrors.Errorf("expected document but got %[1]T: %[1]v", collectionsDoc)
 	}
 
 	if collections.Has(collection) {
-		return must.NotFail(collections.Get(collection)).(string), nil
+		return must.NotFail(collections.Get(collection)).(string), settings, nil
 	}
 
-	tableName := formatCollectionName(collection)
+	used, err := pgPool.relationNames(ctx, tx, db)
+	if err != nil {
+		return "", nil, lazyerrors.Error(err)
+	}
+
+	tableName := newTableName(collection, append(used, mappedTables(collections)...))
 	must.NoError(collections.Set(collection, tableName))
 	must.NoError(settings.Set("collections", collections))
 
 	err = pgPool.updateSettingsTable(ctx, tx, db, settings)
 	if err != nil {
-		return "", lazyerrors.Error(err)
+		return "", nil, lazyerrors.Error(err)
+	}
+
+	return tableName, nil, nil
+}
+
+// tableInfo represents PostgreSQL table of FerretDB collection.
+type tableInfo struct {
+	name string
+
+	// FJSON format version of new documents.
+	format fjson.Version
+
+	// FJSON format version of documents not yet migrated to format; zero if there is no migration in progress.
+	legacy fjson.Version
+
+	// Table partitioning; nil if the table is not partitioned.
+	partitioning *Partitioning
+
+	// True if GridFS chunk data is stored in a separate column; see splitChunk.
+	gridFS bool
+
+	// True if the table has a column for large documents; see splitLarge.
+	large bool
+
+	// True if the table has the unique _id index; see createIDIndex.
+	uniqueIDs bool
+
+	// Name of the clustered index; empty if the collection is not clustered.
+	clusteredIndex string
+
+	// Time series options; nil if the collection is not a time series collection.
+	timeseries *Timeseries
+}
+
+// idArgs returns FJSON-encoded representations of the given _id value
+// in all formats that could be used by documents in the table.
+func (ti *tableInfo) idArgs(id any) []any {
+	res := []any{must.NotFail(fjson.MarshalVersion(id, ti.format))}
+	if ti.legacy != 0 {
+		res = append(res, must.NotFail(fjson.MarshalVersion(id, ti.legacy)))
+	}
+
+	return res
+}
+
+// row returns names of the table's columns that store the given document and their values.
+func (ti *tableInfo) row(doc *types.Document) ([]string, []any) {
+	if !ti.gridFS {
+		return []string{"_jsonb"}, []any{must.NotFail(fjson.MarshalVersion(doc, ti.format))}
+	}
+
+	rest, data := splitChunk(doc)
+	columns := []string{"_jsonb", gridFSDataColumn}
+	return columns, []any{must.NotFail(fjson.MarshalVersion(rest, ti.format)), data}
+}
+
+// getTableInfo returns the table information for given collection.
+// If the settings table doesn't exist, it will be created.
+// If the record for collection doesn't exist, it will be created.
+//
+// Cached metadata is used if available; see MetadataCache.
+func (pgPool *Pool) getTableInfo(ctx context.Context, tx pgx.Tx, db, collection string) (*tableInfo, error) {
+	if tables, ok := pgPool.metadata.get(db); ok {
+		if ti, ok := tables[collection]; ok {
+			// return a copy that could be modified by the caller
+			return &ti, nil
+		}
+	}
+
+	gen := pgPool.metadata.generation()
+
+	table, settings, err := pgPool.tableName(ctx, tx, db, collection)
+	if err != nil {
+		return nil, err
 	}
 
-	return tableName, nil
+	if settings != nil {
+		// settings were not modified, so they could be cached
+		pgPool.metadata.put(db, settings, gen)
+	} else {
+		schemaExists, err := pgPool.schemaExists(ctx, db)
+		if err != nil {
+			return nil, lazyerrors.Error(err)
+		}
+
+		if !schemaExists {
+			return &tableInfo{name: table, format: pgPool.newFormatVersion()}, nil
+		}
+
+		if settings, err = pgPool.getSettingsTable(ctx, tx, db); err != nil {
+			return nil, lazyerrors.Error(err)
+		}
+	}
+
+	return tableInfoFromSettings(settings, collection, table)
+}
+
+// tableInfoFromSettings returns the information about the given table of the given collection
+// from the settings document.
+func tableInfoFromSettings(settings *types.Document, collection, table string) (*tableInfo, error) {
+	format, err := getFormat(settings, "formats", collection, fjson.Version1)
+	if err != nil {
+		return nil, lazyerrors.Error(err)
+	}
+
+	legacy, err := getFormat(settings, "migrations", collection, 0)
+	if err != nil {
+		return nil, lazyerrors.Error(err)
+	}
+
+	partitioning, err := getPartitioning(settings, collection)
+	if err != nil {
+		return nil, lazyerrors.Error(err)
+	}
+
+	timeseries, err := getTimeseries(settings, collection)
+	if err != nil {
+		return nil, lazyerrors.Error(err)
+	}
+
+	return &tableInfo{
+		name:           table,
+		format:         format,
+		legacy:         legacy,
+		partitioning:   partitioning,
+		gridFS:         getGridFS(settings, collection),
+		large:          getLarge(settings, collection),
+		uniqueIDs:      getUniqueIDs(settings, collection),
+		clusteredIndex: getClusteredIndex(settings, collection),
+		timeseries:     timeseries,
+	}, nil
+}
+
+// getFormat returns FJSON format version of the given collection
+// from the given field of the settings document, or def if there is none.
+//
+// The "formats" field contains format versions of new documents;
+// collections created before format versions were introduced use fjson.Version1.
+// The "migrations" field contains format versions of not yet migrated documents.
+func getFormat(settings *types.Document, field, collection string, def fjson.Version) (fjson.Version, error) {
+	formats, ok := getSettingsDocument(settings, field)
+	if !ok {
+		return def, nil
+	}
+
+	v, err := formats.Get(collection)
+	if err != nil {
+		return def, nil
+	}
+
+	version, ok := v.(int32)
+	if !ok {
+		return 0, lazyerrors.Errorf("expected int32 but got %[1]T: %[1]v", v)
+	}
+
+	switch format := fjson.Version(version); format {
+	case fjson.Version1, fjson.Version2:
+		return format, nil
+	default:
+		return 0, lazyerrors.Errorf("unknown format version %d for collection %q", version, collection)
+	}
+}
+
+// setFormat sets FJSON format version of the given collection in the given field of the settings document.
+// Zero version removes the collection from that field.
+func setFormat(settings *types.Document, field, collection string, format fjson.Version) {
+	formats, ok := getSettingsDocument(settings, field)
+	if !ok {
+		formats = must.NotFail(types.NewDocument())
+	}
+
+	if format == 0 {
+		formats.Remove(collection)
+	} else {
+		must.NoError(formats.Set(collection, int32(format)))
+	}
+
+	must.NoError(settings.Set(field, formats))
+}
+
+// getSettingsDocument returns the document stored in the given field of the settings document.
+//
+// Settings created before that field was introduced don't have it.
+func getSettingsDocument(settings *types.Document, field string) (*types.Document, bool) {
+	v, err := settings.Get(field)
+	if err != nil {
+		return nil, false
+	}
+
+	doc, ok := v.(*types.Document)
+	return doc, ok
 }
 
 // getSettingsTable returns FerretDB settings table.
@@ -170,7 +372,12 @@ func (pgPool *Pool) getSettingsTable(ctx context.Context, tx pgx.Tx, db string)
 }
 
 // updateSettingsTable updates FerretDB settings table.
+//
+// Cached metadata of the database is invalidated; callers that change collections metadata
+// should also mark the whole transaction as DDL operation (see MetadataCache.ddl).
 func (pgPool *Pool) updateSettingsTable(ctx context.Context, tx pgx.Tx, db string, settings *types.Document) error {
+	pgPool.metadata.invalidate(db)
+
 	sql := `UPDATE ` + pgx.Identifier{db, settingsTableName}.Sanitize() + `SET settings = $1`
 	_, err := tx.Exec(ctx, sql, must.NotFail(fjson.Marshal(settings)))
 	return err
@@ -196,6 +403,17 @@ func (pgPool *Pool) removeTableFromSettings(ctx context.Context, tx pgx.Tx, db,
 
 	must.NoError(settings.Set("collections", collections))
 
+	setFormat(settings, "formats", collection, 0)
+	setFormat(settings, "migrations", collection, 0)
+	setIndexes(settings, collection, nil)
+	setPartitioning(settings, collection, nil)
+	setGridFS(settings, collection, false)
+	setLarge(settings, collection, false)
+	setUniqueIDs(settings, collection, false)
+	setClusteredIndex(settings, collection, "")
+	setTimeseries(settings, collection, nil)
+	setDeferredIndexes(settings, collection, false, false)
+
 	if err := pgPool.updateSettingsTable(ctx, tx, db, settings); err != nil {
 		return lazyerrors.Error(err)
 	}
@@ -205,14 +423,33 @@ func (pgPool *Pool) removeTableFromSettings(ctx context.Context, tx pgx.Tx, db,
 
 // formatCollectionName returns collection name in form <shortened_name>_<name_hash>.
 func formatCollectionName(name string) string {
+	return formatTableName(name, name)
+}
+
+// formatTableName returns table name in form <shortened_prefix>_<key_hash>.
+//
+// The prefix is shortened on UTF-8 character boundary,
+// so the result is a valid PostgreSQL identifier of at most maxTableNameLength bytes.
+func formatTableName(prefix, key string) string {
 	hash32 := fnv.New32a()
-	_ = must.NotFail(hash32.Write([]byte(name)))
+	_ = must.NotFail(hash32.Write([]byte(key)))
 
-	nameSymbolsLeft := maxTableNameLength - hash32.Size()*2 - 1
-	truncateTo := len(name)
-	if truncateTo > nameSymbolsLeft {
-		truncateTo = nameSymbolsLeft
+	suffix := "_" + fmt.Sprintf("%x", hash32.Sum([]byte{}))
+
+	if truncateTo := maxTableNameLength - len(suffix); len(prefix) > truncateTo {
+		for truncateTo > 0 && !utf8.RuneStart(prefix[truncateTo]) {
+			truncateTo--
+		}
+
+		prefix = prefix[:truncateTo]
 	}
 
-	return name[:truncateTo] + "_" + fmt.Sprintf("%x", hash32.Sum([]byte{}))
+	return prefix + suffix
+}
+
+// beginDDL is BeginFunc for transactions that modify collections metadata; see MetadataCache.ddl.
+func (pgPool *Pool) beginDDL(ctx context.Context, db string, f func(pgx.Tx) error) error {
+	defer pgPool.ddl(db)()
+
+	return pgPool.BeginFunc(ctx, f)
 }
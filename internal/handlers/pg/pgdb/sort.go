@@ -0,0 +1,161 @@
+// Copyright 2021 FerretDB Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package pgdb
+
+import (
+	"math"
+	"strings"
+	"time"
+
+	"github.com/FerretDB/FerretDB/internal/types"
+	"github.com/FerretDB/FerretDB/internal/util/must"
+)
+
+// maxSortKeys is the maximum number of sort keys, like in MongoDB.
+const maxSortKeys = 32
+
+// sortBracketSQL returns SQL expression for BSON type order bracket of the given jsonb value v
+// (see types.CompareOrder) for types supported by sortValuesSQL.
+// Missing values and nulls are in the same bracket, like in MongoDB.
+// All unsupported types are in the last bracket.
+func sortBracketSQL(v string) string {
+	return `CASE jsonb_typeof(` + v + `)` +
+		` WHEN 'null' THEN 1 WHEN 'number' THEN 2 WHEN 'string' THEN 3 WHEN 'boolean' THEN 8` +
+		` WHEN 'object' THEN CASE WHEN ` + v + ` ? '$k' THEN 100 WHEN ` + v + ` ?| array['$f', '$i', '$l'] THEN 2` +
+		` WHEN ` + v + ` ? '$o' THEN 7 WHEN ` + v + ` ? '$d' THEN 9 ELSE 100 END` +
+		` ELSE CASE WHEN ` + v + ` IS NULL THEN 1 ELSE 100 END END`
+}
+
+// sortValuesSQL returns SQL expressions for ordering values of the given jsonb value v
+// inside a single type order bracket. For each row, at most one expression of each bracket is not NULL.
+//
+// Numbers of all types and formats are ordered first by double precision value,
+// then by exact numeric value (for large integers that are not representable as doubles).
+// Strings and ObjectIDs (hex strings) are ordered by bytes, like in MongoDB.
+func sortValuesSQL(v string) []string {
+	// documents are excluded, so their fields are never casted
+	obj := `jsonb_typeof(` + v + `) = 'object' AND NOT ` + v + ` ? '$k'`
+
+	return []string{
+		// numbers
+		`CASE WHEN jsonb_typeof(` + v + `) = 'number' THEN (` + v + ` #>> '{}')::float8` +
+			` WHEN ` + obj + ` THEN COALESCE(` + v + `->>'$f', ` + v + `->>'$i', ` + v + `->>'$l')::float8 END`,
+		`CASE WHEN jsonb_typeof(` + v + `) = 'number' THEN (` + v + ` #>> '{}')::numeric` +
+			` WHEN ` + obj + ` AND jsonb_typeof(` + v + `->'$f') = 'number' THEN (` + v + `->>'$f')::numeric` +
+			` WHEN ` + obj + ` THEN COALESCE(` + v + `->>'$i', ` + v + `->>'$l')::numeric END`,
+
+		// strings
+		`(CASE WHEN jsonb_typeof(` + v + `) = 'string' THEN ` + v + ` #>> '{}' END) COLLATE "C"`,
+
+		// ObjectIDs
+		`(CASE WHEN ` + obj + ` THEN ` + v + `->>'$o' END) COLLATE "C"`,
+
+		// booleans
+		`CASE WHEN jsonb_typeof(` + v + `) = 'boolean' THEN (` + v + ` #>> '{}')::boolean END`,
+
+		// dates
+		`CASE WHEN ` + obj + ` THEN (` + v + `->>'$d')::numeric END`,
+	}
+}
+
+// buildSort returns SQL expressions for ORDER BY clause for the given sort document,
+// and its arguments using placeholders starting after p.
+//
+// Empty string is returned if sort can't be pushed down: it is empty, invalid,
+// or contains dot notation or operators.
+// Even if it is pushed down, each returned document should be checked with sortSupported.
+func buildSort(p *Placeholder, sort *types.Document) (string, []any) {
+	if sort == nil || sort.Len() == 0 || sort.Len() > maxSortKeys {
+		return "", nil
+	}
+
+	var args []any
+	var exprs []string
+
+	for _, key := range sort.Keys() {
+		if key == "" || strings.HasPrefix(key, "$") || strings.Contains(key, ".") {
+			return "", nil
+		}
+
+		var dir string
+		switch sortOrder(must.NotFail(sort.Get(key))) {
+		case 1:
+			dir = " ASC"
+		case -1:
+			dir = " DESC"
+		default:
+			return "", nil
+		}
+
+		args = append(args, key)
+		v := `(_jsonb->` + p.Next() + `::text)`
+
+		exprs = append(exprs, sortBracketSQL(v)+dir)
+		for _, e := range sortValuesSQL(v) {
+			exprs = append(exprs, e+dir)
+		}
+	}
+
+	return strings.Join(exprs, ", "), args
+}
+
+// sortOrder returns 1 or -1 for valid sort order values, and 0 for all other values.
+func sortOrder(v any) int {
+	switch v := v.(type) {
+	case int32:
+		if v == 1 || v == -1 {
+			return int(v)
+		}
+	case int64:
+		if v == 1 || v == -1 {
+			return int(v)
+		}
+	case float64:
+		if v == 1 || v == -1 {
+			return int(v)
+		}
+	}
+
+	return 0
+}
+
+// sortSupported returns true if values of the given sort keys in the document
+// have types supported by sortValuesSQL.
+// If that's the case for all documents, documents ordered by SQL expressions returned by buildSort
+// are ordered like MongoDB does, so they should not be sorted again.
+//
+// In particular, arrays (that are ordered by their minimal or maximal elements)
+// and NaNs (that are less than all other numbers in MongoDB, but not in PostgreSQL) are not supported.
+func sortSupported(doc *types.Document, keys []string) bool {
+	for _, key := range keys {
+		v, err := doc.Get(key)
+		if err != nil {
+			continue // missing field
+		}
+
+		switch v := v.(type) {
+		case types.NullType, int32, int64, string, bool, types.ObjectID, time.Time:
+			// supported
+		case float64:
+			if math.IsNaN(v) {
+				return false
+			}
+		default:
+			return false
+		}
+	}
+
+	return true
+}
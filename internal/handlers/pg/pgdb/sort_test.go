@@ -0,0 +1,118 @@
+// Copyright 2021 FerretDB Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package pgdb
+
+import (
+	"math"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/FerretDB/FerretDB/internal/types"
+	"github.com/FerretDB/FerretDB/internal/util/must"
+)
+
+func TestBuildSort(t *testing.T) {
+	t.Parallel()
+
+	for name, tc := range map[string]struct {
+		sort      *types.Document
+		args      []any // nil if sort can't be pushed down
+		asc, desc int   // number of sort keys in each direction
+	}{
+		"Nil":   {},
+		"Empty": {sort: must.NotFail(types.NewDocument())},
+		"Asc": {
+			sort: must.NotFail(types.NewDocument("a", int32(1))),
+			args: []any{"a"},
+			asc:  1,
+		},
+		"DescInt64": {
+			sort: must.NotFail(types.NewDocument("a", int64(-1))),
+			args: []any{"a"},
+			desc: 1,
+		},
+		"MultiKey": {
+			sort: must.NotFail(types.NewDocument("a", 1.0, "b", int32(-1), "c", int32(1))),
+			args: []any{"a", "b", "c"},
+			asc:  2,
+			desc: 1,
+		},
+		"DotNotation":  {sort: must.NotFail(types.NewDocument("a.b", int32(1)))},
+		"Operator":     {sort: must.NotFail(types.NewDocument("$natural", int32(1)))},
+		"InvalidOrder": {sort: must.NotFail(types.NewDocument("a", int32(2)))},
+		"InvalidType":  {sort: must.NotFail(types.NewDocument("a", "asc"))},
+	} {
+		name, tc := name, tc
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			p := Placeholder(1)
+			orderBy, args := buildSort(&p, tc.sort)
+			assert.Equal(t, tc.args, args)
+
+			if tc.args == nil {
+				assert.Empty(t, orderBy)
+				return
+			}
+
+			// bracket expression and value expressions for each key
+			exprs := 1 + len(sortValuesSQL("v"))
+			assert.Equal(t, tc.asc*exprs, strings.Count(orderBy, " ASC"))
+			assert.Equal(t, tc.desc*exprs, strings.Count(orderBy, " DESC"))
+
+			assert.True(t, strings.HasPrefix(orderBy, "CASE jsonb_typeof((_jsonb->$2::text))"), orderBy)
+			assert.NotContains(t, orderBy, "$1::")
+			assert.Contains(t, orderBy, "(_jsonb->$"+string(rune('1'+len(args)))+"::text)")
+		})
+	}
+}
+
+func TestSortSupported(t *testing.T) {
+	t.Parallel()
+
+	for name, tc := range map[string]struct {
+		v        any // nil for missing field
+		expected bool
+	}{
+		"Missing":  {expected: true},
+		"Null":     {v: types.Null, expected: true},
+		"Int32":    {v: int32(42), expected: true},
+		"Int64":    {v: int64(42), expected: true},
+		"Double":   {v: 42.13, expected: true},
+		"NaN":      {v: math.NaN(), expected: false},
+		"String":   {v: "foo", expected: true},
+		"Bool":     {v: true, expected: true},
+		"ObjectID": {v: types.ObjectID{0x01}, expected: true},
+		"DateTime": {v: time.Date(2022, 1, 1, 0, 0, 0, 0, time.UTC), expected: true},
+		"Array":    {v: must.NotFail(types.NewArray(int32(1))), expected: false},
+		"Document": {v: must.NotFail(types.NewDocument("foo", int32(1))), expected: false},
+		"Binary":   {v: types.Binary{B: []byte{0x01}}, expected: false},
+	} {
+		name, tc := name, tc
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			doc := must.NotFail(types.NewDocument("_id", int32(1)))
+			if tc.v != nil {
+				must.NoError(doc.Set("v", tc.v))
+			}
+
+			assert.Equal(t, tc.expected, sortSupported(doc, []string{"v"}))
+		})
+	}
+}
@@ -0,0 +1,136 @@
+// Copyright 2021 FerretDB Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package pgdb
+
+import (
+	"context"
+	"strings"
+
+	"github.com/jackc/pgconn"
+	"github.com/jackc/pgconn/stmtcache"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// StatementCacheMetrics represents prepared statements cache metrics.
+//
+// They could be shared by multiple pools.
+type StatementCacheMetrics struct {
+	requests *prometheus.CounterVec
+}
+
+// NewStatementCacheMetrics creates new prepared statements cache metrics.
+func NewStatementCacheMetrics() *StatementCacheMetrics {
+	return &StatementCacheMetrics{
+		requests: prometheus.NewCounterVec(
+			prometheus.CounterOpts{
+				Namespace: "ferretdb",
+				Subsystem: "postgresql",
+				Name:      "statement_cache_requests_total",
+				Help:      "Total number of prepared statements cache requests.",
+			},
+			[]string{"statement", "result"},
+		),
+	}
+}
+
+// Describe implements prometheus.Collector.
+func (m *StatementCacheMetrics) Describe(ch chan<- *prometheus.Desc) {
+	m.requests.Describe(ch)
+}
+
+// Collect implements prometheus.Collector.
+func (m *StatementCacheMetrics) Collect(ch chan<- prometheus.Metric) {
+	m.requests.Collect(ch)
+}
+
+// observe records the cache request for the given SQL query.
+func (m *StatementCacheMetrics) observe(sql string, hit bool) {
+	result := "miss"
+	if hit {
+		result = "hit"
+	}
+
+	m.requests.WithLabelValues(statementKind(sql), result).Inc()
+}
+
+// statementKind returns the kind of the given SQL query (the first keyword) for metrics labels.
+func statementKind(sql string) string {
+	kind := strings.ToLower(strings.SplitN(strings.TrimSpace(sql), " ", 2)[0])
+
+	switch kind {
+	case "select", "insert", "update", "delete", "with":
+		return kind
+	default:
+		return "other"
+	}
+}
+
+// statementCache wraps stmtcache.Cache of a single connection to record metrics.
+//
+// Prepared statements are cached by SQL text; since schema and table names are a part of it,
+// the cache is effectively keyed by the query shape, schema, and table.
+//
+// Like the connection itself, it is not safe for concurrent use.
+type statementCache struct {
+	stmtcache.Cache
+	metrics *StatementCacheMetrics
+
+	// last descriptions returned by the wrapped cache;
+	// the same description is returned again only if it was not evicted
+	descs map[string]*pgconn.StatementDescription
+}
+
+// newStatementCache returns a new statementCache wrapping the given cache.
+func newStatementCache(cache stmtcache.Cache, metrics *StatementCacheMetrics) *statementCache {
+	return &statementCache{
+		Cache:   cache,
+		metrics: metrics,
+		descs:   make(map[string]*pgconn.StatementDescription),
+	}
+}
+
+// Get implements stmtcache.Cache.
+func (c *statementCache) Get(ctx context.Context, sql string) (*pgconn.StatementDescription, error) {
+	sd, err := c.Cache.Get(ctx, sql)
+	if err != nil {
+		return nil, err
+	}
+
+	hit := sd != nil && c.descs[sql] == sd
+	if !hit {
+		// forget evicted statements from time to time
+		if len(c.descs) >= 2*c.Cap() {
+			c.descs = make(map[string]*pgconn.StatementDescription, c.Cap())
+		}
+
+		c.descs[sql] = sd
+	}
+
+	c.metrics.observe(sql, hit)
+
+	return sd, nil
+}
+
+// Clear implements stmtcache.Cache.
+func (c *statementCache) Clear(ctx context.Context) error {
+	c.descs = make(map[string]*pgconn.StatementDescription)
+	return c.Cache.Clear(ctx)
+}
+
+// check interfaces
+var (
+	_ stmtcache.Cache      = (*statementCache)(nil)
+	_ prometheus.Collector = (*StatementCacheMetrics)(nil)
+)
@@ -0,0 +1,90 @@
+// Copyright 2021 FerretDB Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package pgdb
+
+import (
+	"context"
+	"testing"
+
+	"github.com/jackc/pgconn"
+	"github.com/jackc/pgconn/stmtcache"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeCache is a stmtcache.Cache that keeps at most one statement.
+type fakeCache struct {
+	sql  string
+	desc *pgconn.StatementDescription
+}
+
+func (c *fakeCache) Get(ctx context.Context, sql string) (*pgconn.StatementDescription, error) {
+	if c.sql != sql {
+		c.sql = sql
+		c.desc = &pgconn.StatementDescription{SQL: sql}
+	}
+
+	return c.desc, nil
+}
+
+func (c *fakeCache) Clear(ctx context.Context) error {
+	c.sql, c.desc = "", nil
+	return nil
+}
+
+func (c *fakeCache) StatementErrored(sql string, err error) {}
+func (c *fakeCache) Len() int                               { return 1 }
+func (c *fakeCache) Cap() int                               { return 1 }
+func (c *fakeCache) Mode() int                              { return stmtcache.ModePrepare }
+
+func TestStatementCache(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	metrics := NewStatementCacheMetrics()
+	cache := newStatementCache(new(fakeCache), metrics)
+
+	insert := `INSERT INTO "db"."table" (_jsonb) VALUES ($1)`
+	selectAll := `SELECT _jsonb FROM "db"."table"`
+
+	for _, sql := range []string{insert, insert, insert, selectAll, insert} {
+		_, err := cache.Get(ctx, sql)
+		require.NoError(t, err)
+	}
+
+	require.NoError(t, cache.Clear(ctx))
+	_, err := cache.Get(ctx, insert)
+	require.NoError(t, err)
+
+	assert.Equal(t, float64(2), testutil.ToFloat64(metrics.requests.WithLabelValues("insert", "hit")))
+	assert.Equal(t, float64(3), testutil.ToFloat64(metrics.requests.WithLabelValues("insert", "miss")))
+	assert.Equal(t, float64(0), testutil.ToFloat64(metrics.requests.WithLabelValues("select", "hit")))
+	assert.Equal(t, float64(1), testutil.ToFloat64(metrics.requests.WithLabelValues("select", "miss")))
+}
+
+func TestStatementKind(t *testing.T) {
+	t.Parallel()
+
+	for sql, expected := range map[string]string{
+		`SELECT _jsonb FROM "db"."table"`:                "select",
+		` insert INTO "db"."table" (_jsonb) VALUES ($1)`: "insert",
+		`DELETE FROM "db"."table"`:                       "delete",
+		`CREATE TABLE "db"."table" (_jsonb jsonb)`:       "other",
+		``: "other",
+	} {
+		assert.Equal(t, expected, statementKind(sql), "%q", sql)
+	}
+}
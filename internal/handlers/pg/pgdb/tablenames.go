@@ -0,0 +1,245 @@
+// Copyright 2021 FerretDB Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package pgdb
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/jackc/pgx/v4"
+	"go.uber.org/zap"
+	"golang.org/x/exp/slices"
+
+	"github.com/FerretDB/FerretDB/internal/types"
+	"github.com/FerretDB/FerretDB/internal/util/lazyerrors"
+	"github.com/FerretDB/FerretDB/internal/util/must"
+)
+
+// newTableName returns a name for the new table of the given collection that is not in the used list.
+//
+// It is formatCollectionName(collection) unless that name is already used (by a table of another collection
+// with the same shortened name and hash, by an index, by a partition, etc.);
+// in that case, the collection name with a counter is hashed instead.
+// Table names are recorded in the settings table, so they stay stable even if the used list changes later.
+func newTableName(collection string, used []string) string {
+	table := formatCollectionName(collection)
+
+	for i := 1; slices.Contains(used, table); i++ {
+		table = formatTableName(collection, fmt.Sprintf("%s\x00%d", collection, i))
+	}
+
+	return table
+}
+
+// mappedTables returns table names of all collections in the given "collections" settings document.
+func mappedTables(collections *types.Document) []string {
+	res := make([]string, 0, collections.Len())
+	for _, v := range collections.Map() {
+		if table, ok := v.(string); ok {
+			res = append(res, table)
+		}
+	}
+
+	return res
+}
+
+// relationNames returns names of all relations (tables, partitions, indexes, sequences, etc.) in the given schema.
+// They share a single namespace, so the name of a new table should not match any of them.
+func (pgPool *Pool) relationNames(ctx context.Context, tx pgx.Tx, schema string) ([]string, error) {
+	sql := `SELECT c.relname ` +
+		`FROM pg_catalog.pg_class AS c ` +
+		`JOIN pg_catalog.pg_namespace AS n ON n.oid = c.relnamespace ` +
+		`WHERE n.nspname = $1`
+	rows, err := tx.Query(ctx, sql, schema)
+	if err != nil {
+		return nil, lazyerrors.Error(err)
+	}
+	defer rows.Close()
+
+	var res []string
+	for rows.Next() {
+		var name string
+		if err = rows.Scan(&name); err != nil {
+			return nil, lazyerrors.Error(err)
+		}
+
+		res = append(res, name)
+	}
+	if err = rows.Err(); err != nil {
+		return nil, lazyerrors.Error(err)
+	}
+
+	return res, nil
+}
+
+// legacyTables returns names of tables in the given schema that store documents
+// but are not mapped to collections in the settings table.
+//
+// Such tables were created by old FerretDB versions that used collection names as table names.
+func (pgPool *Pool) legacyTables(ctx context.Context, tx pgx.Tx, schema string, collections *types.Document) ([]string, error) {
+	sql := `SELECT c.relname ` +
+		`FROM pg_catalog.pg_class AS c ` +
+		`JOIN pg_catalog.pg_namespace AS n ON n.oid = c.relnamespace ` +
+		`JOIN pg_catalog.pg_attribute AS a ON a.attrelid = c.oid ` +
+		`WHERE n.nspname = $1 AND c.relkind IN ('r', 'p') AND NOT c.relispartition ` +
+		`AND a.attname = '_jsonb' AND NOT a.attisdropped ` +
+		`ORDER BY c.relname`
+	rows, err := tx.Query(ctx, sql, schema)
+	if err != nil {
+		return nil, lazyerrors.Error(err)
+	}
+	defer rows.Close()
+
+	mapped := mappedTables(collections)
+
+	var res []string
+	for rows.Next() {
+		var name string
+		if err = rows.Scan(&name); err != nil {
+			return nil, lazyerrors.Error(err)
+		}
+
+		if strings.HasPrefix(name, collectionPrefix) || slices.Contains(mapped, name) {
+			continue
+		}
+
+		res = append(res, name)
+	}
+	if err = rows.Err(); err != nil {
+		return nil, lazyerrors.Error(err)
+	}
+
+	return res, nil
+}
+
+// MigrateTableNames adds tables of old FerretDB versions to the collection names mapping
+// of the settings table in all FerretDB databases.
+//
+// Those versions used collection names as table names, so long names were silently truncated by PostgreSQL.
+// Such tables (with _jsonb column, but not mapped to any collection) are renamed to names returned by newTableName,
+// and their names are recorded as collection names.
+// Tables with names of already mapped collections are left as is, and a warning is logged.
+//
+// It returns the number of migrated tables.
+func (pgPool *Pool) MigrateTableNames(ctx context.Context) (int, error) {
+	var dbs []string
+	err := pgPool.BeginFunc(ctx, func(tx pgx.Tx) error {
+		sql := `SELECT table_schema FROM information_schema.tables WHERE table_name = $1 ORDER BY table_schema`
+		rows, err := tx.Query(ctx, sql, settingsTableName)
+		if err != nil {
+			return lazyerrors.Error(err)
+		}
+		defer rows.Close()
+
+		for rows.Next() {
+			var db string
+			if err = rows.Scan(&db); err != nil {
+				return lazyerrors.Error(err)
+			}
+
+			dbs = append(dbs, db)
+		}
+
+		return rows.Err()
+	})
+	if err != nil {
+		return 0, lazyerrors.Error(err)
+	}
+
+	var res int
+	for _, db := range dbs {
+		var n int
+		err = pgPool.beginDDL(ctx, db, func(tx pgx.Tx) error {
+			var err error
+			n, err = pgPool.migrateTableNames(ctx, tx, db)
+			return err
+		})
+		if err != nil {
+			return res, lazyerrors.Error(err)
+		}
+
+		res += n
+	}
+
+	return res, nil
+}
+
+// migrateTableNames implements MigrateTableNames for a single database.
+func (pgPool *Pool) migrateTableNames(ctx context.Context, tx pgx.Tx, db string) (int, error) {
+	settings, err := pgPool.getSettingsTable(ctx, tx, db)
+	if err != nil {
+		return 0, lazyerrors.Error(err)
+	}
+
+	collections, ok := must.NotFail(settings.Get("collections")).(*types.Document)
+	if !ok {
+		return 0, lazyerrors.Errorf("invalid settings document")
+	}
+
+	legacy, err := pgPool.legacyTables(ctx, tx, db, collections)
+	if err != nil {
+		return 0, lazyerrors.Error(err)
+	}
+
+	if len(legacy) == 0 {
+		return 0, nil
+	}
+
+	used, err := pgPool.relationNames(ctx, tx, db)
+	if err != nil {
+		return 0, lazyerrors.Error(err)
+	}
+
+	var res int
+	for _, collection := range legacy {
+		if collections.Has(collection) {
+			pgPool.logger.Warn(
+				"Table is not migrated: collection with the same name already exists.",
+				zap.String("schema", db), zap.String("table", collection),
+			)
+
+			continue
+		}
+
+		table := newTableName(collection, append(used, mappedTables(collections)...))
+
+		sql := `ALTER TABLE ` + pgx.Identifier{db, collection}.Sanitize() + ` RENAME TO ` + pgx.Identifier{table}.Sanitize()
+		if _, err = tx.Exec(ctx, sql); err != nil {
+			return 0, lazyerrors.Error(err)
+		}
+
+		must.NoError(collections.Set(collection, table))
+		used = append(used, table)
+		res++
+
+		pgPool.logger.Info(
+			"Table migrated.",
+			zap.String("schema", db), zap.String("collection", collection), zap.String("table", table),
+		)
+	}
+
+	if res == 0 {
+		return 0, nil
+	}
+
+	must.NoError(settings.Set("collections", collections))
+
+	if err = pgPool.updateSettingsTable(ctx, tx, db, settings); err != nil {
+		return 0, lazyerrors.Error(err)
+	}
+
+	return res, nil
+}
@@ -0,0 +1,83 @@
+// Copyright 2021 FerretDB Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package pgdb
+
+import (
+	"strings"
+	"testing"
+	"unicode/utf8"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFormatCollectionName(t *testing.T) {
+	t.Parallel()
+
+	for name, tc := range map[string]struct {
+		collection string
+		expected   string
+	}{
+		"Short": {
+			collection: "values",
+			expected:   "values_34474c3b",
+		},
+		"Long": {
+			collection: strings.Repeat("a", 100),
+			expected:   strings.Repeat("a", 54) + "_0a0bb1d9",
+		},
+		"Unicode": {
+			// 2-byte characters: 27 of them fit into 54 bytes
+			collection: strings.Repeat("я", 40),
+			expected:   strings.Repeat("я", 27) + "_aee212c5",
+		},
+		"UnicodeBoundary": {
+			// one-byte prefix shifts 2-byte characters, so only 26 of them fit
+			collection: "a" + strings.Repeat("я", 40),
+			expected:   "a" + strings.Repeat("я", 26) + "_461c292c",
+		},
+	} {
+		name, tc := name, tc
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			actual := formatCollectionName(tc.collection)
+			assert.LessOrEqual(t, len(actual), maxTableNameLength)
+			assert.True(t, utf8.ValidString(actual))
+			assert.Equal(t, tc.expected, actual)
+		})
+	}
+}
+
+func TestNewTableName(t *testing.T) {
+	t.Parallel()
+
+	collection := strings.Repeat("a", 100)
+	table := formatCollectionName(collection)
+
+	assert.Equal(t, table, newTableName(collection, nil))
+	assert.Equal(t, table, newTableName(collection, []string{"other"}))
+
+	second := newTableName(collection, []string{table})
+	assert.NotEqual(t, table, second)
+	assert.Equal(t, table[:len(table)-8], second[:len(second)-8])
+	assert.LessOrEqual(t, len(second), maxTableNameLength)
+
+	third := newTableName(collection, []string{table, second})
+	assert.NotEqual(t, table, third)
+	assert.NotEqual(t, second, third)
+
+	// names are stable
+	assert.Equal(t, second, newTableName(collection, []string{table}))
+}
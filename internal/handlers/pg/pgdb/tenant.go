@@ -0,0 +1,90 @@
+// Copyright 2021 FerretDB Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package pgdb
+
+import (
+	"context"
+
+	"github.com/jackc/pgx/v4"
+	"go.uber.org/zap"
+
+	"github.com/FerretDB/FerretDB/internal/util/lazyerrors"
+)
+
+// tenantRolePrefix is the prefix of PostgreSQL roles that own FerretDB databases in tenant roles mode.
+const tenantRolePrefix = "ferretdb_tenant_"
+
+// TenantRole returns the name of PostgreSQL role that owns the given FerretDB database (PostgreSQL schema);
+// see CreateTenant.
+func TenantRole(db string) string {
+	name := tenantRolePrefix + db
+	if len(name) <= maxTableNameLength {
+		return name
+	}
+
+	return formatCollectionName(name)
+}
+
+// CreateTenant creates the given FerretDB database if it does not exist, and its PostgreSQL role (see TenantRole)
+// if it does not exist. The role becomes the owner of the schema and gets all privileges on its tables,
+// and it is granted to the current user, so pools with that role (see NewPoolOpts.Role) could be opened.
+//
+// The role can't log in and has no privileges on other schemas,
+// so queries that run as that role can't access other databases.
+// The current user should have CREATEROLE privilege.
+func (pgPool *Pool) CreateTenant(ctx context.Context, db string) error {
+	if err := pgPool.CreateDatabase(ctx, db); err != nil && err != ErrAlreadyExist {
+		return lazyerrors.Error(err)
+	}
+
+	role := TenantRole(db)
+	roleSQL := pgx.Identifier{role}.Sanitize()
+	schemaSQL := pgx.Identifier{db}.Sanitize()
+
+	err := pgPool.beginDDL(ctx, db, func(tx pgx.Tx) error {
+		var exists bool
+		sql := `SELECT EXISTS(SELECT 1 FROM pg_roles WHERE rolname = $1)`
+		if err := tx.QueryRow(ctx, sql, role).Scan(&exists); err != nil {
+			return lazyerrors.Error(err)
+		}
+
+		if !exists {
+			if _, err := tx.Exec(ctx, `CREATE ROLE `+roleSQL+` NOLOGIN`); err != nil {
+				return lazyerrors.Error(err)
+			}
+		}
+
+		for _, sql := range []string{
+			// the current user should be a member to change the owner and to set the role
+			`GRANT ` + roleSQL + ` TO CURRENT_USER`,
+			`ALTER SCHEMA ` + schemaSQL + ` OWNER TO ` + roleSQL,
+			`GRANT ALL ON ALL TABLES IN SCHEMA ` + schemaSQL + ` TO ` + roleSQL,
+			`ALTER DEFAULT PRIVILEGES IN SCHEMA ` + schemaSQL + ` GRANT ALL ON TABLES TO ` + roleSQL,
+		} {
+			if _, err := tx.Exec(ctx, sql); err != nil {
+				return lazyerrors.Error(err)
+			}
+		}
+
+		return nil
+	})
+	if err != nil {
+		return lazyerrors.Error(err)
+	}
+
+	pgPool.logger.Info("Tenant created.", zap.String("db", db), zap.String("role", role))
+
+	return nil
+}
@@ -0,0 +1,103 @@
+// Copyright 2021 FerretDB Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package pgdb_test
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/jackc/pgconn"
+	"github.com/jackc/pgerrcode"
+	"github.com/jackc/pgx/v4"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap/zaptest"
+
+	"github.com/FerretDB/FerretDB/internal/handlers/pg/pgdb"
+	"github.com/FerretDB/FerretDB/internal/types"
+	"github.com/FerretDB/FerretDB/internal/util/must"
+	"github.com/FerretDB/FerretDB/internal/util/testutil"
+)
+
+func TestTenantRole(t *testing.T) {
+	t.Parallel()
+
+	assert.Equal(t, "ferretdb_tenant_test", pgdb.TenantRole("test"))
+
+	long := strings.Repeat("a", 63)
+	role := pgdb.TenantRole(long)
+	assert.Len(t, role, 63)
+	assert.True(t, strings.HasPrefix(role, "ferretdb_tenant_aaa"))
+	assert.NotEqual(t, role, pgdb.TenantRole(long[:62]))
+}
+
+func TestCreateTenant(t *testing.T) {
+	t.Parallel()
+
+	ctx := testutil.Ctx(t)
+	connString := testutil.PoolConnString(t, nil)
+	pool := testutil.Pool(ctx, t, nil, zaptest.NewLogger(t))
+
+	tenantDB := testutil.SchemaName(t) + "_t"
+	otherDB := testutil.SchemaName(t) + "_o"
+	tableName := testutil.TableName(t)
+
+	t.Cleanup(func() {
+		for _, db := range []string{tenantDB, otherDB} {
+			_ = pool.DropDatabase(ctx, db)
+
+			role := pgx.Identifier{pgdb.TenantRole(db)}.Sanitize()
+			_, _ = pool.Exec(ctx, `DROP OWNED BY `+role)
+			_, _ = pool.Exec(ctx, `DROP ROLE IF EXISTS `+role)
+		}
+	})
+
+	doc := must.NotFail(types.NewDocument("_id", int32(1), "v", "foo"))
+	require.NoError(t, pool.InsertDocuments(ctx, otherDB, tableName, []*types.Document{doc}))
+	require.NoError(t, pool.CreateTenant(ctx, otherDB))
+
+	require.NoError(t, pool.CreateTenant(ctx, tenantDB))
+	require.NoError(t, pool.CreateTenant(ctx, tenantDB), "should be idempotent")
+
+	tenantPool, err := pgdb.NewPool(ctx, connString, zaptest.NewLogger(t), &pgdb.NewPoolOpts{
+		Role: pgdb.TenantRole(tenantDB),
+	})
+	require.NoError(t, err)
+	t.Cleanup(tenantPool.Close)
+
+	// tables in own schema could be created and used
+	require.NoError(t, tenantPool.InsertDocuments(ctx, tenantDB, tableName, []*types.Document{doc}))
+
+	res, err := tenantPool.QueryDocuments(ctx, &pgdb.QueryParams{DB: tenantDB, Collection: tableName})
+	require.NoError(t, err)
+	assert.Equal(t, []*types.Document{doc}, res.Docs)
+
+	// other schemas could not be accessed
+	_, err = tenantPool.Exec(ctx, `SELECT * FROM `+pgx.Identifier{otherDB, tableName}.Sanitize())
+
+	var pgErr *pgconn.PgError
+	require.True(t, errors.As(err, &pgErr), "%v", err)
+	assert.Equal(t, pgerrcode.InsufficientPrivilege, pgErr.Code)
+
+	err = tenantPool.CreateDatabase(ctx, testutil.SchemaName(t)+"_n")
+	require.True(t, errors.As(err, &pgErr), "%v", err)
+	assert.Equal(t, pgerrcode.InsufficientPrivilege, pgErr.Code)
+
+	// the owner of the other schema still has access
+	res, err = pool.QueryDocuments(ctx, &pgdb.QueryParams{DB: otherDB, Collection: tableName})
+	require.NoError(t, err)
+	assert.Equal(t, []*types.Document{doc}, res.Docs)
+}
@@ -0,0 +1,77 @@
+// Copyright 2021 FerretDB Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package pgdb
+
+import (
+	"context"
+	"errors"
+	"strconv"
+	"time"
+
+	"github.com/jackc/pgconn"
+	"github.com/jackc/pgerrcode"
+	"github.com/jackc/pgx/v4"
+
+	"github.com/FerretDB/FerretDB/internal/util/lazyerrors"
+)
+
+// statementTimeoutSQL returns SQL statement that sets statement_timeout for the current transaction
+// to the time left until the context deadline, or empty string if context has no deadline.
+//
+// Unlike context cancellation that closes the connection, statement timeout is handled by PostgreSQL
+// and keeps the connection usable.
+func statementTimeoutSQL(ctx context.Context) string {
+	deadline, ok := ctx.Deadline()
+	if !ok {
+		return ""
+	}
+
+	// zero disables timeout, so use the smallest positive value for expired deadlines
+	ms := time.Until(deadline).Milliseconds()
+	if ms < 1 {
+		ms = 1
+	}
+
+	return `SET LOCAL statement_timeout = ` + strconv.FormatInt(ms, 10)
+}
+
+// setStatementTimeout sets statement_timeout for the given transaction from the context deadline, if any.
+func setStatementTimeout(ctx context.Context, tx pgx.Tx) error {
+	sql := statementTimeoutSQL(ctx)
+	if sql == "" {
+		return nil
+	}
+
+	if _, err := tx.Exec(ctx, sql); err != nil {
+		return queryError(err)
+	}
+
+	return nil
+}
+
+// queryError returns ErrQueryCanceled if the query was canceled by statement timeout or context,
+// and the given error wrapped with lazyerrors otherwise.
+func queryError(err error) error {
+	if errors.Is(err, context.DeadlineExceeded) || errors.Is(err, context.Canceled) {
+		return ErrQueryCanceled
+	}
+
+	var pgErr *pgconn.PgError
+	if errors.As(err, &pgErr) && pgErr.Code == pgerrcode.QueryCanceled {
+		return ErrQueryCanceled
+	}
+
+	return lazyerrors.Error(err)
+}
@@ -0,0 +1,52 @@
+// Copyright 2021 FerretDB Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package pgdb
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/jackc/pgconn"
+	"github.com/jackc/pgerrcode"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/FerretDB/FerretDB/internal/util/lazyerrors"
+)
+
+func TestStatementTimeoutSQL(t *testing.T) {
+	t.Parallel()
+
+	assert.Equal(t, "", statementTimeoutSQL(context.Background()))
+
+	ctx, cancel := context.WithDeadline(context.Background(), time.Now().Add(-time.Second))
+	defer cancel()
+	assert.Equal(t, "SET LOCAL statement_timeout = 1", statementTimeoutSQL(ctx))
+
+	ctx, cancel = context.WithTimeout(context.Background(), time.Hour)
+	defer cancel()
+	assert.Regexp(t, `^SET LOCAL statement_timeout = 3[56]\d{5}$`, statementTimeoutSQL(ctx))
+}
+
+func TestQueryError(t *testing.T) {
+	t.Parallel()
+
+	assert.Equal(t, ErrQueryCanceled, queryError(lazyerrors.Error(context.DeadlineExceeded)))
+	assert.Equal(t, ErrQueryCanceled, queryError(&pgconn.PgError{Code: pgerrcode.QueryCanceled}))
+
+	err := &pgconn.PgError{Code: pgerrcode.UniqueViolation}
+	assert.True(t, errors.Is(queryError(err), err))
+}
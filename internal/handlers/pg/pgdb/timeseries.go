@@ -0,0 +1,211 @@
+// Copyright 2021 FerretDB Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package pgdb
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/FerretDB/FerretDB/internal/handlers/backend"
+	"github.com/FerretDB/FerretDB/internal/types"
+	"github.com/FerretDB/FerretDB/internal/util/lazyerrors"
+	"github.com/FerretDB/FerretDB/internal/util/must"
+)
+
+// Time series collections store measurements with a date in the time field.
+// Their tables are partitioned by that date (see PartitionByDate), so partitions are created on demand
+// when measurements are written, and range queries on the time field are pushed down
+// with the same expression as the partition key, so PostgreSQL scans only matching partitions.
+// Time series options are recorded in the settings table.
+
+// TimeseriesGranularity represents expected interval between measurements of a time series collection.
+type TimeseriesGranularity string
+
+const (
+	// TimeseriesSeconds is used for measurements arriving every few seconds;
+	// a partition stores measurements of a single day.
+	TimeseriesSeconds = TimeseriesGranularity("seconds")
+
+	// TimeseriesMinutes is used for measurements arriving every few minutes;
+	// a partition stores measurements of a single month.
+	TimeseriesMinutes = TimeseriesGranularity("minutes")
+
+	// TimeseriesHours is used for measurements arriving every few hours;
+	// a partition stores measurements of a single year.
+	TimeseriesHours = TimeseriesGranularity("hours")
+)
+
+// Timeseries describes options of a time series collection.
+type Timeseries struct {
+	// Top-level field with dates of measurements; all documents should have it.
+	TimeField string
+
+	// Top-level field with metadata of measurements; empty if not set.
+	MetaField string
+
+	Granularity TimeseriesGranularity
+}
+
+// Validate returns an error if time series options are invalid.
+func (ts *Timeseries) Validate() error {
+	if ts.TimeField == "" {
+		return fmt.Errorf("time field is empty")
+	}
+
+	if ts.MetaField == ts.TimeField {
+		return fmt.Errorf("meta field is the same as time field %q", ts.TimeField)
+	}
+
+	for _, f := range []string{ts.TimeField, ts.MetaField} {
+		if f == "_id" || strings.Contains(f, ".") || strings.HasPrefix(f, "$") {
+			return fmt.Errorf("invalid time series field %q", f)
+		}
+	}
+
+	if _, err := ts.interval(); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// interval returns the interval of time series collection table partitions.
+func (ts *Timeseries) interval() (PartitionInterval, error) {
+	switch ts.Granularity {
+	case TimeseriesSeconds:
+		return PartitionDay, nil
+	case TimeseriesMinutes:
+		return PartitionMonth, nil
+	case TimeseriesHours:
+		return PartitionYear, nil
+	default:
+		return "", fmt.Errorf("unknown time series granularity %q", ts.Granularity)
+	}
+}
+
+// partitioning returns partitioning of time series collection table.
+func (ts *Timeseries) partitioning() *Partitioning {
+	return &Partitioning{
+		Kind:     PartitionByDate,
+		Path:     ts.TimeField,
+		Interval: must.NotFail(ts.interval()),
+	}
+}
+
+// TimeFieldError is the same as backend's, so callers could use either.
+type TimeFieldError = backend.TimeFieldError
+
+// checkTimeField returns TimeFieldError if any of the given documents written to the time series collection
+// does not have a date in the time field. It does nothing for other collections.
+func checkTimeField(table *tableInfo, docs []*types.Document) error {
+	ts := table.timeseries
+	if ts == nil {
+		return nil
+	}
+
+	for _, doc := range docs {
+		v, _ := doc.Get(ts.TimeField)
+		if _, ok := v.(time.Time); !ok {
+			return &TimeFieldError{TimeField: ts.TimeField}
+		}
+	}
+
+	return nil
+}
+
+// pushdownTimeField returns pushdownFunc that handles the given comparison operator
+// with date value for the time field of time series collections.
+//
+// Documents of such collections always have dates in the time field (see checkTimeField),
+// so the condition is exact.
+func pushdownTimeField(op string) pushdownFunc {
+	return func(b *filterBuilder, field string, value any) (string, bool) {
+		ts := b.table.timeseries
+		if ts == nil || field != ts.TimeField {
+			return "", false
+		}
+
+		t, ok := value.(time.Time)
+		if !ok {
+			return "", false
+		}
+
+		return datePathSQL(ts.TimeField) + " " + op + " " + b.arg(t.UnixMilli()), true
+	}
+}
+
+// getTimeseries returns time series options of the given collection from the "timeseries" field
+// of the settings document, or nil if collection is not a time series collection.
+func getTimeseries(settings *types.Document, collection string) (*Timeseries, error) {
+	all, ok := getSettingsDocument(settings, "timeseries")
+	if !ok {
+		return nil, nil
+	}
+
+	v, err := all.Get(collection)
+	if err != nil {
+		return nil, nil
+	}
+
+	doc, ok := v.(*types.Document)
+	if !ok {
+		return nil, lazyerrors.Errorf("expected document but got %[1]T: %[1]v", v)
+	}
+
+	var ts Timeseries
+	for _, k := range doc.Keys() {
+		v := must.NotFail(doc.Get(k))
+
+		switch k {
+		case "timeField":
+			ts.TimeField, _ = v.(string)
+		case "metaField":
+			ts.MetaField, _ = v.(string)
+		case "granularity":
+			s, _ := v.(string)
+			ts.Granularity = TimeseriesGranularity(s)
+		}
+	}
+
+	if err = ts.Validate(); err != nil {
+		return nil, lazyerrors.Error(err)
+	}
+
+	return &ts, nil
+}
+
+// setTimeseries sets time series options of the given collection in the "timeseries" field of the settings document.
+// Nil options remove the collection from that field.
+func setTimeseries(settings *types.Document, collection string, ts *Timeseries) {
+	all, ok := getSettingsDocument(settings, "timeseries")
+	if !ok {
+		all = must.NotFail(types.NewDocument())
+	}
+
+	if ts == nil {
+		all.Remove(collection)
+	} else {
+		doc := must.NotFail(types.NewDocument("timeField", ts.TimeField))
+		if ts.MetaField != "" {
+			must.NoError(doc.Set("metaField", ts.MetaField))
+		}
+		must.NoError(doc.Set("granularity", string(ts.Granularity)))
+
+		must.NoError(all.Set(collection, doc))
+	}
+
+	must.NoError(settings.Set("timeseries", all))
+}
@@ -0,0 +1,177 @@
+// Copyright 2021 FerretDB Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package pgdb
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/FerretDB/FerretDB/internal/fjson"
+	"github.com/FerretDB/FerretDB/internal/types"
+	"github.com/FerretDB/FerretDB/internal/util/must"
+)
+
+func TestTimeseriesValidate(t *testing.T) {
+	t.Parallel()
+
+	for name, tc := range map[string]struct {
+		ts       Timeseries
+		interval PartitionInterval
+	}{
+		"Seconds": {
+			ts:       Timeseries{TimeField: "t", Granularity: TimeseriesSeconds},
+			interval: PartitionDay,
+		},
+		"Minutes": {
+			ts:       Timeseries{TimeField: "t", MetaField: "m", Granularity: TimeseriesMinutes},
+			interval: PartitionMonth,
+		},
+		"Hours": {
+			ts:       Timeseries{TimeField: "t", Granularity: TimeseriesHours},
+			interval: PartitionYear,
+		},
+		"NoTimeField": {
+			ts: Timeseries{Granularity: TimeseriesSeconds},
+		},
+		"SameFields": {
+			ts: Timeseries{TimeField: "t", MetaField: "t", Granularity: TimeseriesSeconds},
+		},
+		"EmbeddedTimeField": {
+			ts: Timeseries{TimeField: "a.t", Granularity: TimeseriesSeconds},
+		},
+		"MetaID": {
+			ts: Timeseries{TimeField: "t", MetaField: "_id", Granularity: TimeseriesSeconds},
+		},
+		"Granularity": {
+			ts: Timeseries{TimeField: "t", Granularity: "days"},
+		},
+	} {
+		name, tc := name, tc
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			err := tc.ts.Validate()
+			if tc.interval == "" {
+				assert.Error(t, err)
+				return
+			}
+
+			require.NoError(t, err)
+
+			expected := &Partitioning{Kind: PartitionByDate, Path: tc.ts.TimeField, Interval: tc.interval}
+			assert.Equal(t, expected, tc.ts.partitioning())
+			assert.NoError(t, tc.ts.partitioning().Validate())
+		})
+	}
+}
+
+func TestTimeseriesSettings(t *testing.T) {
+	t.Parallel()
+
+	settings := must.NotFail(types.NewDocument())
+
+	ts, err := getTimeseries(settings, "test")
+	require.NoError(t, err)
+	assert.Nil(t, ts)
+
+	expected := &Timeseries{TimeField: "t", MetaField: "m", Granularity: TimeseriesMinutes}
+	setTimeseries(settings, "test", expected)
+
+	ts, err = getTimeseries(settings, "test")
+	require.NoError(t, err)
+	assert.Equal(t, expected, ts)
+
+	setTimeseries(settings, "test", nil)
+
+	ts, err = getTimeseries(settings, "test")
+	require.NoError(t, err)
+	assert.Nil(t, ts)
+}
+
+func TestCheckTimeField(t *testing.T) {
+	t.Parallel()
+
+	now := time.Now()
+	valid := must.NotFail(types.NewDocument("_id", int32(1), "t", now))
+	missing := must.NotFail(types.NewDocument("_id", int32(2)))
+	notDate := must.NotFail(types.NewDocument("_id", int32(3), "t", "now"))
+
+	assert.NoError(t, checkTimeField(&tableInfo{}, []*types.Document{missing}))
+
+	table := &tableInfo{timeseries: &Timeseries{TimeField: "t", Granularity: TimeseriesSeconds}}
+	assert.NoError(t, checkTimeField(table, []*types.Document{valid}))
+
+	for _, doc := range []*types.Document{missing, notDate} {
+		var tfe *TimeFieldError
+		require.ErrorAs(t, checkTimeField(table, []*types.Document{valid, doc}), &tfe)
+		assert.Equal(t, "t", tfe.TimeField)
+	}
+}
+
+func TestBuildFilterTimeseries(t *testing.T) {
+	t.Parallel()
+
+	from := time.Date(2022, time.July, 1, 0, 0, 0, 0, time.UTC)
+	to := from.AddDate(0, 0, 1)
+
+	table := &tableInfo{
+		name:       "test",
+		format:     fjson.Version2,
+		timeseries: &Timeseries{TimeField: "t", Granularity: TimeseriesSeconds},
+	}
+
+	for name, tc := range map[string]struct {
+		filter   *types.Document
+		where    string
+		args     []any
+		residual *types.Document
+	}{
+		"Range": {
+			filter:   must.NotFail(types.NewDocument("t", must.NotFail(types.NewDocument("$gte", from, "$lt", to)))),
+			where:    `(((_jsonb->'t')->>'$d')::bigint) >= $2 AND (((_jsonb->'t')->>'$d')::bigint) < $3`,
+			args:     []any{from.UnixMilli(), to.UnixMilli()},
+			residual: must.NotFail(types.NewDocument()),
+		},
+		"Eq": {
+			filter:   must.NotFail(types.NewDocument("t", from)),
+			where:    `(((_jsonb->'t')->>'$d')::bigint) = $2`,
+			args:     []any{from.UnixMilli()},
+			residual: must.NotFail(types.NewDocument()),
+		},
+		"NotDate": {
+			filter:   must.NotFail(types.NewDocument("t", must.NotFail(types.NewDocument("$gt", int32(1))))),
+			residual: must.NotFail(types.NewDocument("t", must.NotFail(types.NewDocument("$gt", int32(1))))),
+		},
+		"OtherField": {
+			filter:   must.NotFail(types.NewDocument("v", must.NotFail(types.NewDocument("$lte", from)))),
+			residual: must.NotFail(types.NewDocument("v", must.NotFail(types.NewDocument("$lte", from)))),
+		},
+	} {
+		name, tc := name, tc
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			p := Placeholder(1)
+
+			where, args, residual := buildFilter(table, &p, tc.filter)
+			assert.Equal(t, tc.where, where)
+			assert.Equal(t, tc.args, args)
+			assert.Equal(t, tc.residual, residual)
+		})
+	}
+}
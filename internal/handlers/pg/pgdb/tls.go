@@ -0,0 +1,117 @@
+// Copyright 2021 FerretDB Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package pgdb
+
+import (
+	"fmt"
+	"net"
+	"net/url"
+	"regexp"
+	"strings"
+
+	"github.com/jackc/pgx/v4/pgxpool"
+)
+
+// sslModeRe matches sslmode setting in keyword/value connection strings.
+var sslModeRe = regexp.MustCompile(`(^|\s)sslmode\s*=`)
+
+// tlsParams returns TLS-related connection parameters from opts.
+func tlsParams(opts *NewPoolOpts) map[string]string {
+	res := make(map[string]string, 4)
+
+	for k, v := range map[string]string{
+		"sslmode":     opts.SSLMode,
+		"sslrootcert": opts.SSLRootCert,
+		"sslcert":     opts.SSLCert,
+		"sslkey":      opts.SSLKey,
+	} {
+		if v != "" {
+			res[k] = v
+		}
+	}
+
+	return res
+}
+
+// isLocalHost returns true if host is a Unix socket directory or a loopback address.
+func isLocalHost(host string) bool {
+	if strings.HasPrefix(host, "/") || host == "localhost" {
+		return true
+	}
+
+	ip := net.ParseIP(host)
+	return ip != nil && ip.IsLoopback()
+}
+
+// connStringWithTLS returns connection string with TLS settings from opts applied.
+//
+// If sslmode is not set in the connection string or in opts, and opts.SecureSSLDefault is true,
+// server certificate is verified for non-local hosts (sslmode=verify-full);
+// otherwise, the driver's default (sslmode=prefer) is used.
+func connStringWithTLS(connString string, opts *NewPoolOpts) (string, error) {
+	params := tlsParams(opts)
+
+	isURL := strings.HasPrefix(connString, "postgres://") || strings.HasPrefix(connString, "postgresql://")
+
+	var u *url.URL
+	var hasSSLMode bool
+	if isURL {
+		var err error
+		if u, err = url.Parse(connString); err != nil {
+			return "", err
+		}
+
+		hasSSLMode = u.Query().Has("sslmode")
+	} else {
+		hasSSLMode = sslModeRe.MatchString(connString)
+	}
+
+	if _, ok := params["sslmode"]; !ok && !hasSSLMode && opts.SecureSSLDefault {
+		config, err := pgxpool.ParseConfig(connString)
+		if err != nil {
+			return "", err
+		}
+
+		if !isLocalHost(config.ConnConfig.Host) {
+			params["sslmode"] = "verify-full"
+		}
+	}
+
+	if len(params) == 0 {
+		return connString, nil
+	}
+
+	if isURL {
+		q := u.Query()
+		for k, v := range params {
+			q.Set(k, v)
+		}
+		u.RawQuery = q.Encode()
+
+		return u.String(), nil
+	}
+
+	// later keywords override earlier ones
+	var sb strings.Builder
+	sb.WriteString(connString)
+	for _, k := range []string{"sslmode", "sslrootcert", "sslcert", "sslkey"} {
+		if v, ok := params[k]; ok {
+			v = strings.ReplaceAll(strings.ReplaceAll(v, `\`, `\\`), `'`, `\'`)
+			fmt.Fprintf(&sb, " %s='%s'", k, v)
+		}
+	}
+
+	return sb.String(), nil
+}
@@ -0,0 +1,86 @@
+// Copyright 2021 FerretDB Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package pgdb
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestConnStringWithTLS(t *testing.T) {
+	t.Parallel()
+
+	for name, tc := range map[string]struct {
+		connString string
+		opts       *NewPoolOpts
+		expected   string
+	}{
+		"LocalDefault": {
+			connString: "postgres://postgres@127.0.0.1:5432/ferretdb",
+			opts:       &NewPoolOpts{SecureSSLDefault: true},
+			expected:   "postgres://postgres@127.0.0.1:5432/ferretdb",
+		},
+		"RemoteDefault": {
+			connString: "postgres://postgres@db.example.com:5432/ferretdb",
+			opts:       &NewPoolOpts{SecureSSLDefault: true},
+			expected:   "postgres://postgres@db.example.com:5432/ferretdb?sslmode=verify-full",
+		},
+		"RemoteDebug": {
+			connString: "postgres://postgres@db.example.com:5432/ferretdb",
+			opts:       &NewPoolOpts{},
+			expected:   "postgres://postgres@db.example.com:5432/ferretdb",
+		},
+		"RemoteExplicit": {
+			connString: "postgres://postgres@db.example.com:5432/ferretdb?sslmode=disable",
+			opts:       &NewPoolOpts{SecureSSLDefault: true},
+			expected:   "postgres://postgres@db.example.com:5432/ferretdb?sslmode=disable",
+		},
+		"URLOverride": {
+			connString: "postgres://postgres@db.example.com:5432/ferretdb?sslmode=disable",
+			opts: &NewPoolOpts{
+				SSLMode:     "verify-ca",
+				SSLRootCert: "/etc/ferretdb/ca.pem",
+			},
+			expected: "postgres://postgres@db.example.com:5432/ferretdb?sslmode=verify-ca&sslrootcert=%2Fetc%2Fferretdb%2Fca.pem",
+		},
+		"KeywordValue": {
+			connString: "host=db.example.com user=postgres",
+			opts: &NewPoolOpts{
+				SSLCert: "/etc/ferretdb/client's.pem",
+				SSLKey:  "/etc/ferretdb/client.key",
+
+				SecureSSLDefault: true,
+			},
+			expected: "host=db.example.com user=postgres sslmode='verify-full' " +
+				`sslcert='/etc/ferretdb/client\'s.pem' sslkey='/etc/ferretdb/client.key'`,
+		},
+		"KeywordValueExplicit": {
+			connString: "host=db.example.com sslmode=require",
+			opts:       &NewPoolOpts{SecureSSLDefault: true},
+			expected:   "host=db.example.com sslmode=require",
+		},
+	} {
+		name, tc := name, tc
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			actual, err := connStringWithTLS(tc.connString, tc.opts)
+			require.NoError(t, err)
+			assert.Equal(t, tc.expected, actual)
+		})
+	}
+}
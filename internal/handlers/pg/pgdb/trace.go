@@ -0,0 +1,77 @@
+// Copyright 2021 FerretDB Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package pgdb
+
+import (
+	"context"
+	"strings"
+	"time"
+
+	"github.com/jackc/pgx/v4"
+
+	"github.com/FerretDB/FerretDB/internal/util/tracing"
+)
+
+// traceLogger is a pgx.Logger that records SQL statements as spans of traced client requests
+// (see tracing.Record) and passes all messages to the next logger, if any.
+//
+// pgx v4 has no tracing hooks, but it logs every statement after completion, usually with its duration.
+type traceLogger struct {
+	next pgx.Logger   // may be nil
+	lvl  pgx.LogLevel // level of messages passed to next
+}
+
+// Log implements pgx.Logger.
+func (l *traceLogger) Log(ctx context.Context, level pgx.LogLevel, msg string, data map[string]any) {
+	if l.next != nil && level <= l.lvl {
+		l.next.Log(ctx, level, msg, data)
+	}
+
+	var sql string
+	switch {
+	case msg == "Query", msg == "Exec", strings.HasPrefix(msg, "BatchResult."):
+		sql, _ = data["sql"].(string)
+	case msg == "CopyFrom":
+		table, _ := data["tableName"].(pgx.Identifier)
+		sql = "COPY " + table.Sanitize()
+	default:
+		return
+	}
+
+	d, _ := data["time"].(time.Duration)
+	err, _ := data["err"].(error)
+
+	operation := msg
+	if fields := strings.Fields(sql); len(fields) > 0 {
+		operation = strings.ToUpper(fields[0])
+	}
+
+	attrs := []tracing.Attr{
+		tracing.String("db.system", "postgresql"),
+		tracing.String("db.operation", operation),
+		tracing.String("db.statement", sql),
+	}
+
+	if rows, ok := data["rowCount"].(int); ok {
+		attrs = append(attrs, tracing.Int("db.rows", int64(rows)))
+	}
+
+	tracing.Record(ctx, operation, time.Now().Add(-d), d, err, attrs...)
+}
+
+// check interfaces
+var (
+	_ pgx.Logger = (*traceLogger)(nil)
+)
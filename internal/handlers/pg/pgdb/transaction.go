@@ -0,0 +1,177 @@
+// Copyright 2021 FerretDB Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package pgdb
+
+import (
+	"context"
+	"errors"
+
+	"github.com/jackc/pgconn"
+	"github.com/jackc/pgerrcode"
+	"github.com/jackc/pgx/v4"
+	"go.uber.org/zap"
+
+	"github.com/FerretDB/FerretDB/internal/handlers/backend"
+	"github.com/FerretDB/FerretDB/internal/util/lazyerrors"
+)
+
+// maxTransactionAttempts is the maximum number of attempts to run a transaction that conflicts with concurrent ones.
+const maxTransactionAttempts = 3
+
+// txState represents the state of the outermost transaction shared by Pools passed to InTransaction's functions.
+type txState struct {
+	ddlDone []func() // see ddl
+}
+
+// InTransaction calls f with Pool which methods run in a single serializable transaction.
+//
+// The transaction is retried with f called again if it fails due to a serialization failure or a deadlock,
+// up to maxTransactionAttempts attempts in total.
+// Nested transactions use savepoints; they are retried only together with the outermost one.
+//
+// It implements backend.Backend.
+func (pgPool *Pool) InTransaction(ctx context.Context, f func(backend.Backend) error) error {
+	if pgPool.tx != nil {
+		return pgPool.tx.BeginFunc(ctx, func(tx pgx.Tx) error {
+			nested := *pgPool
+			nested.tx = tx
+
+			return f(&nested)
+		})
+	}
+
+	var err error
+	for attempt := 1; attempt <= maxTransactionAttempts; attempt++ {
+		if err = pgPool.inTransaction(ctx, f); !isTransactionConflict(err) {
+			return err
+		}
+
+		pgPool.logger.Debug("Transaction conflict.", zap.Int("attempt", attempt), zap.Error(err))
+	}
+
+	return err
+}
+
+// inTransaction runs a single attempt of InTransaction.
+func (pgPool *Pool) inTransaction(ctx context.Context, f func(backend.Backend) error) (err error) {
+	state := new(txState)
+
+	defer func() {
+		for _, done := range state.ddlDone {
+			done()
+		}
+	}()
+
+	tx, err := pgPool.Pool.BeginTx(ctx, pgx.TxOptions{IsoLevel: pgx.Serializable})
+	if err != nil {
+		return lazyerrors.Error(err)
+	}
+
+	defer func() {
+		if err == nil {
+			return
+		}
+
+		if rerr := tx.Rollback(ctx); rerr != nil && !errors.Is(rerr, pgx.ErrTxClosed) {
+			pgPool.logger.Error("Failed to roll back transaction.", zap.Error(rerr))
+		}
+	}()
+
+	txPool := *pgPool
+	txPool.tx = tx
+	txPool.txState = state
+
+	if err = f(&txPool); err != nil {
+		return err
+	}
+
+	if err = tx.Commit(ctx); err != nil {
+		return lazyerrors.Error(err)
+	}
+
+	return nil
+}
+
+// isTransactionConflict returns true if the transaction failed due to concurrent ones and could be retried.
+func isTransactionConflict(err error) bool {
+	var pgErr *pgconn.PgError
+	if !errors.As(err, &pgErr) {
+		return false
+	}
+
+	return pgErr.Code == pgerrcode.SerializationFailure || pgErr.Code == pgerrcode.DeadlockDetected
+}
+
+// ddl marks the start of DDL operation on the given database in the metadata cache (see MetadataCache.ddl)
+// and returns the function that marks its end.
+//
+// In transactions, the end is marked when the outermost transaction ends instead,
+// so metadata read by other transactions is not cached before DDL changes are committed.
+func (pgPool *Pool) ddl(db string) func() {
+	done := pgPool.metadata.ddl(db)
+
+	if pgPool.txState == nil {
+		return done
+	}
+
+	pgPool.txState.ddlDone = append(pgPool.txState.ddlDone, done)
+
+	return func() {}
+}
+
+// Begin starts a transaction, or a savepoint in the current transaction (see InTransaction).
+func (pgPool *Pool) Begin(ctx context.Context) (pgx.Tx, error) {
+	if pgPool.tx != nil {
+		return pgPool.tx.Begin(ctx)
+	}
+
+	return pgPool.Pool.Begin(ctx)
+}
+
+// BeginFunc runs f in a transaction, or in a savepoint of the current transaction (see InTransaction).
+func (pgPool *Pool) BeginFunc(ctx context.Context, f func(pgx.Tx) error) error {
+	if pgPool.tx != nil {
+		return pgPool.tx.BeginFunc(ctx, f)
+	}
+
+	return pgPool.Pool.BeginFunc(ctx, f)
+}
+
+// Exec executes the statement, in the current transaction if any (see InTransaction).
+func (pgPool *Pool) Exec(ctx context.Context, sql string, args ...any) (pgconn.CommandTag, error) {
+	if pgPool.tx != nil {
+		return pgPool.tx.Exec(ctx, sql, args...)
+	}
+
+	return pgPool.Pool.Exec(ctx, sql, args...)
+}
+
+// Query executes the query, in the current transaction if any (see InTransaction).
+func (pgPool *Pool) Query(ctx context.Context, sql string, args ...any) (pgx.Rows, error) {
+	if pgPool.tx != nil {
+		return pgPool.tx.Query(ctx, sql, args...)
+	}
+
+	return pgPool.Pool.Query(ctx, sql, args...)
+}
+
+// QueryRow executes the query that returns at most one row, in the current transaction if any (see InTransaction).
+func (pgPool *Pool) QueryRow(ctx context.Context, sql string, args ...any) pgx.Row {
+	if pgPool.tx != nil {
+		return pgPool.tx.QueryRow(ctx, sql, args...)
+	}
+
+	return pgPool.Pool.QueryRow(ctx, sql, args...)
+}
@@ -0,0 +1,192 @@
+// Copyright 2021 FerretDB Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package pgdb
+
+import (
+	"context"
+	"errors"
+
+	"github.com/jackc/pgconn"
+	"github.com/jackc/pgerrcode"
+	"github.com/jackc/pgx/v4"
+	"go.uber.org/zap"
+
+	"github.com/FerretDB/FerretDB/internal/types"
+	"github.com/FerretDB/FerretDB/internal/util/lazyerrors"
+	"github.com/FerretDB/FerretDB/internal/util/must"
+)
+
+// createIDIndex creates a unique index on the _id field of the given table.
+//
+// It is created for new collections; existing collections get it by MigrateCollection (see createUniqueIDIndex).
+// It rejects documents with duplicate _id values and serves as an arbiter index for InsertDocumentIfNotExists.
+// PostgreSQL index itself has a generated name.
+func createIDIndex(ctx context.Context, tx pgx.Tx, db, table string) error {
+	sql := `CREATE UNIQUE INDEX ON ` + pgx.Identifier{db, table}.Sanitize() + ` ((_jsonb->'_id'))`
+	if _, err := tx.Exec(ctx, sql); err != nil {
+		return lazyerrors.Error(err)
+	}
+
+	return nil
+}
+
+// InsertDocumentIfNotExists inserts a document into FerretDB database and collection
+// unless a document with the same _id already exists.
+// If database or collection does not exist, it will be created.
+//
+// The check and the insert are done by a single INSERT ... ON CONFLICT DO NOTHING statement
+// that relies on the unique _id index, so concurrent upserts can't insert duplicate documents.
+// It returns false if the document was not inserted; the caller should then update the existing document.
+//
+// Collections partitioned by date can't have that index; for them, existing documents are checked
+// in the same transaction instead (see checkDateIDs).
+// Collections created before the unique _id index was introduced don't have it until they are migrated
+// by MigrateCollection; for them, the document is always inserted, and a warning is logged (see checkUniqueIDs).
+// Time series collections do not require unique _id values; for them, the document is always inserted too.
+func (pgPool *Pool) InsertDocumentIfNotExists(ctx context.Context, db, collection string, doc *types.Document) (bool, error) {
+	inserted, err := pgPool.insertDocument(ctx, db, collection, doc, `ON CONFLICT DO NOTHING`)
+	if err != nil {
+		return false, lazyerrors.Error(err)
+	}
+
+	return inserted, nil
+}
+
+// checkUniqueIDs logs a warning once per collection if InsertDocumentIfNotExists is not atomic
+// for the given table because it doesn't have the unique _id index.
+//
+// Partitioned tables are not reported: they either have unique _id indexes on partitions,
+// or _id values are checked by checkDateIDs, or they don't require unique _id values.
+func (pgPool *Pool) checkUniqueIDs(db, collection string, table *tableInfo) {
+	if table.uniqueIDs || table.partitioning != nil {
+		return
+	}
+
+	if _, loaded := pgPool.nonUniqueIDs.LoadOrStore(db+"."+collection, struct{}{}); loaded {
+		return
+	}
+
+	pgPool.logger.Warn(
+		"Collection does not have the unique _id index, concurrent upserts could insert duplicate documents; "+
+			"run migrateCollection to create it",
+		zap.String("db", db), zap.String("collection", collection),
+	)
+}
+
+// createUniqueIDIndex creates the unique _id index (see createIDIndex) for the given existing collection
+// if it doesn't have one, and records that in the settings table.
+//
+// The index is built concurrently, so it does not block reads and writes, but it can't be done in a transaction.
+// The index has a fixed name, so interrupted builds are detected and restarted.
+// If the collection already contains documents with duplicate _id values, the index is dropped,
+// and PostgreSQL unique violation error is returned.
+func (pgPool *Pool) createUniqueIDIndex(ctx context.Context, db, collection string) error {
+	var table *tableInfo
+	err := pgPool.BeginFunc(ctx, func(tx pgx.Tx) error {
+		var err error
+		table, err = pgPool.getTableInfo(ctx, tx, db, collection)
+		return err
+	})
+	if err != nil {
+		return lazyerrors.Error(err)
+	}
+
+	if table.uniqueIDs || table.partitioning != nil {
+		return nil
+	}
+
+	name := formatCollectionName(table.name + "_id_unique")
+	index := pgx.Identifier{db, name}.Sanitize()
+
+	var valid bool
+	err = pgPool.QueryRow(ctx, `SELECT indisvalid FROM pg_index WHERE indexrelid = to_regclass($1)`, index).Scan(&valid)
+	switch {
+	case err == pgx.ErrNoRows:
+		// no index yet
+	case err != nil:
+		return lazyerrors.Error(err)
+	case !valid:
+		// left by the interrupted build
+		if _, err = pgPool.Exec(ctx, `DROP INDEX CONCURRENTLY `+index); err != nil {
+			return lazyerrors.Error(err)
+		}
+	}
+
+	if !valid {
+		pgPool.logger.Info(
+			"Creating unique _id index",
+			zap.String("db", db), zap.String("collection", collection),
+		)
+
+		sql := `CREATE UNIQUE INDEX CONCURRENTLY ` + pgx.Identifier{name}.Sanitize() +
+			` ON ` + pgx.Identifier{db, table.name}.Sanitize() + ` ((_jsonb->'_id'))`
+		if _, err = pgPool.Exec(ctx, sql); err != nil {
+			var pgErr *pgconn.PgError
+			if errors.As(err, &pgErr) && pgErr.Code == pgerrcode.UniqueViolation {
+				// the failed build leaves an invalid index
+				if _, dropErr := pgPool.Exec(ctx, `DROP INDEX CONCURRENTLY IF EXISTS `+index); dropErr != nil {
+					pgPool.logger.Error("Failed to drop invalid unique _id index", zap.Error(dropErr))
+				}
+			}
+
+			return lazyerrors.Error(err)
+		}
+	}
+
+	err = pgPool.beginDDL(ctx, db, func(tx pgx.Tx) error {
+		settings, err := pgPool.getSettingsTable(ctx, tx, db)
+		if err != nil {
+			return lazyerrors.Error(err)
+		}
+
+		setUniqueIDs(settings, collection, true)
+
+		return pgPool.updateSettingsTable(ctx, tx, db, settings)
+	})
+	if err != nil {
+		return lazyerrors.Error(err)
+	}
+
+	return nil
+}
+
+// getUniqueIDs returns true if the table of the given collection has the unique _id index.
+func getUniqueIDs(settings *types.Document, collection string) bool {
+	all, ok := getSettingsDocument(settings, "unique_ids")
+	if !ok {
+		return false
+	}
+
+	v, _ := all.Get(collection)
+	res, _ := v.(bool)
+
+	return res
+}
+
+// setUniqueIDs records whether the table of the given collection has the unique _id index.
+func setUniqueIDs(settings *types.Document, collection string, uniqueIDs bool) {
+	all, ok := getSettingsDocument(settings, "unique_ids")
+	if !ok {
+		all = must.NotFail(types.NewDocument())
+	}
+
+	if uniqueIDs {
+		must.NoError(all.Set(collection, true))
+	} else {
+		all.Remove(collection)
+	}
+
+	must.NoError(settings.Set("unique_ids", all))
+}
@@ -0,0 +1,91 @@
+// Copyright 2021 FerretDB Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package pgdb
+
+import (
+	"context"
+
+	"github.com/jackc/pgx/v4"
+
+	"github.com/FerretDB/FerretDB/internal/types"
+	"github.com/FerretDB/FerretDB/internal/util/lazyerrors"
+)
+
+// uuidColumn is a name of the generated column that stores UUID _id values as native PostgreSQL uuid.
+//
+// It is created only for new collections when NewPoolOpts.UUIDColumn is set.
+const uuidColumn = "_uuid"
+
+// uuidColumnExpr extracts UUID from FJSON-encoded _id: binary value with subtype 4 and 16 bytes
+// (24 base64 characters). Documents with "$b" and "s" fields are excluded by the "$k" key.
+// It is NULL for all other _id values.
+const uuidColumnExpr = `CASE WHEN _jsonb->'_id'->>'s' = '4' AND NOT _jsonb->'_id' ? '$k' ` +
+	`AND length(_jsonb->'_id'->>'$b') = 24 ` +
+	`THEN encode(decode(_jsonb->'_id'->>'$b', 'base64'), 'hex')::uuid END`
+
+// uuidColumnDefinition returns column definition for CREATE TABLE statement.
+func uuidColumnDefinition() string {
+	return pgx.Identifier{uuidColumn}.Sanitize() + ` uuid GENERATED ALWAYS AS (` + uuidColumnExpr + `) STORED`
+}
+
+// createUUIDIndex creates an index on the UUID column of the given table.
+func createUUIDIndex(ctx context.Context, tx pgx.Tx, db, table string) error {
+	sql := `CREATE INDEX ON ` + pgx.Identifier{db, table}.Sanitize() + ` (` + pgx.Identifier{uuidColumn}.Sanitize() + `)`
+	if _, err := tx.Exec(ctx, sql); err != nil {
+		return lazyerrors.Error(err)
+	}
+
+	return nil
+}
+
+// uuidID returns canonical UUID string for UUID _id value, or empty string for other values.
+func uuidID(id any) string {
+	if b, ok := id.(types.Binary); ok {
+		return b.UUIDString()
+	}
+
+	return ""
+}
+
+// useUUIDColumn returns true if lookups by UUID values among given _id values can use the UUID column.
+//
+// Tables created before UUIDColumn option was enabled don't have it.
+func (pgPool *Pool) useUUIDColumn(ctx context.Context, tx pgx.Tx, db, table string, ids []any) (bool, error) {
+	if !pgPool.uuidColumn {
+		return false, nil
+	}
+
+	var found bool
+	for _, id := range ids {
+		if uuidID(id) != "" {
+			found = true
+			break
+		}
+	}
+
+	if !found {
+		return false, nil
+	}
+
+	sql := `SELECT EXISTS(SELECT 1 FROM information_schema.columns ` +
+		`WHERE table_schema = $1 AND table_name = $2 AND column_name = $3)`
+
+	var res bool
+	if err := tx.QueryRow(ctx, sql, db, table, uuidColumn).Scan(&res); err != nil {
+		return false, lazyerrors.Error(err)
+	}
+
+	return res, nil
+}
@@ -0,0 +1,315 @@
+// Copyright 2021 FerretDB Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package pgdb
+
+import (
+	"context"
+	"encoding/json"
+	"strconv"
+	"strings"
+
+	"github.com/jackc/pgx/v4"
+	"go.uber.org/zap"
+	"golang.org/x/exp/slices"
+
+	"github.com/FerretDB/FerretDB/internal/fjson"
+	"github.com/FerretDB/FerretDB/internal/util/lazyerrors"
+	"github.com/FerretDB/FerretDB/internal/util/must"
+)
+
+// WatchMode represents a change notification transport.
+type WatchMode string
+
+const (
+	// WatchModeNone disables change notifications.
+	WatchModeNone WatchMode = "none"
+
+	// WatchModeNotify uses row-level triggers and LISTEN/NOTIFY.
+	//
+	// It works without logical replication, but has weaker delivery guarantees:
+	//  * notifications are delivered at most once and only to currently listening sessions,
+	//    events that happen while the listener is disconnected are lost;
+	//  * notifications are sent on transaction commit, so the order between concurrent transactions
+	//    matches commit order, not statement order;
+	//  * payload carries only the operation type and _id (NOTIFY payload is limited to 8000 bytes),
+	//    consumers have to fetch the document themselves if needed;
+	//  * _id values that do not fit into payload are replaced by null;
+	//  * triggers of collections created before the mode was enabled are created on start (see CreateWatchTriggers),
+	//    so collections created by other FerretDB instances without that mode don't have them until the next start;
+	//  * triggers are not dropped when the mode is disabled, so notifications are still sent.
+	WatchModeNotify WatchMode = "notify"
+)
+
+// AllWatchModes includes all change notification transports, with the first one being the default.
+var AllWatchModes = []WatchMode{WatchModeNone, WatchModeNotify}
+
+const (
+	// watchChannel is a PostgreSQL notification channel used by all watch triggers.
+	watchChannel = "ferretdb_watch"
+
+	// watchFunction is a name of trigger function created in each schema.
+	watchFunction = collectionPrefix + "notify"
+
+	// maxWatchPayload is the NOTIFY payload limit.
+	maxWatchPayload = 8000
+)
+
+// WatchEvent represents a single change notification.
+type WatchEvent struct {
+	Op         string // insert, update, or delete
+	DB         string
+	Collection string
+	ID         any // types.Null if _id is too large
+}
+
+// watchPayload is a JSON representation of the notification payload sent by the trigger function.
+type watchPayload struct {
+	Op         string          `json:"op"`
+	DB         string          `json:"db"`
+	Collection string          `json:"collection"`
+	ID         json.RawMessage `json:"id"`
+}
+
+// createWatchTrigger creates trigger function in the given schema (if needed)
+// and a trigger that sends notifications for changes in the given table.
+func createWatchTrigger(ctx context.Context, tx pgx.Tx, db, table, collection string) error {
+	function := pgx.Identifier{db, watchFunction}.Sanitize()
+
+	sql := `CREATE OR REPLACE FUNCTION ` + function + `() RETURNS trigger AS $$
+DECLARE
+    doc jsonb;
+    payload text;
+BEGIN
+    IF TG_OP = 'DELETE' THEN
+        doc := OLD._jsonb;
+    ELSE
+        doc := NEW._jsonb;
+    END IF;
+    payload := json_build_object(
+        'op', lower(TG_OP), 'db', TG_TABLE_SCHEMA, 'collection', TG_ARGV[0], 'id', doc->'_id'
+    )::text;
+    -- pg_notify fails for large payloads, and that would fail the write
+    IF octet_length(payload) >= ` + strconv.Itoa(maxWatchPayload) + ` THEN
+        payload := json_build_object(
+            'op', lower(TG_OP), 'db', TG_TABLE_SCHEMA, 'collection', TG_ARGV[0], 'id', NULL
+        )::text;
+    END IF;
+    PERFORM pg_notify('` + watchChannel + `', payload);
+    RETURN NULL;
+END;
+$$ LANGUAGE plpgsql`
+	if _, err := tx.Exec(ctx, sql); err != nil {
+		return lazyerrors.Error(err)
+	}
+
+	// trigger arguments are string literals, not identifiers
+	arg := "'" + strings.ReplaceAll(collection, "'", "''") + "'"
+
+	sql = `CREATE TRIGGER ` + pgx.Identifier{watchFunction}.Sanitize() +
+		` AFTER INSERT OR UPDATE OR DELETE ON ` + pgx.Identifier{db, table}.Sanitize() +
+		` FOR EACH ROW EXECUTE FUNCTION ` + function + `(` + arg + `)`
+	if _, err := tx.Exec(ctx, sql); err != nil {
+		return lazyerrors.Error(err)
+	}
+
+	return nil
+}
+
+// CreateWatchTriggers creates triggers (see createWatchTrigger) for all existing collections that don't have them,
+// for example, because they were created before WatchModeNotify was enabled.
+//
+// It does nothing if the watch mode is not WatchModeNotify.
+func (pgPool *Pool) CreateWatchTriggers(ctx context.Context) error {
+	if pgPool.watchMode != WatchModeNotify {
+		return nil
+	}
+
+	var dbs []string
+	sql := `SELECT table_schema FROM information_schema.tables WHERE table_name = $1 ORDER BY table_schema`
+	rows, err := pgPool.Query(ctx, sql, settingsTableName)
+	if err != nil {
+		return lazyerrors.Error(err)
+	}
+
+	for rows.Next() {
+		var db string
+		if err = rows.Scan(&db); err != nil {
+			rows.Close()
+			return lazyerrors.Error(err)
+		}
+
+		dbs = append(dbs, db)
+	}
+	rows.Close()
+
+	if err = rows.Err(); err != nil {
+		return lazyerrors.Error(err)
+	}
+
+	for _, db := range dbs {
+		var created int
+		err = pgPool.BeginFunc(ctx, func(tx pgx.Tx) error {
+			settings, err := pgPool.getSettingsTable(ctx, tx, db)
+			if err != nil {
+				return err
+			}
+
+			collections, ok := getSettingsDocument(settings, "collections")
+			if !ok {
+				return nil
+			}
+
+			used, err := pgPool.relationNames(ctx, tx, db)
+			if err != nil {
+				return err
+			}
+
+			triggers, err := watchTriggerTables(ctx, tx, db)
+			if err != nil {
+				return err
+			}
+
+			for _, collection := range collections.Keys() {
+				// the mapping could be created by getTableName without the table
+				table, _ := must.NotFail(collections.Get(collection)).(string)
+				if !slices.Contains(used, table) || slices.Contains(triggers, table) {
+					continue
+				}
+
+				if err = createWatchTrigger(ctx, tx, db, table, collection); err != nil {
+					return err
+				}
+
+				created++
+			}
+
+			return nil
+		})
+		if err != nil {
+			return lazyerrors.Error(err)
+		}
+
+		if created > 0 {
+			pgPool.logger.Info("Created watch triggers.", zap.String("db", db), zap.Int("collections", created))
+		}
+	}
+
+	return nil
+}
+
+// watchTriggerTables returns names of tables in the given schema that have watch triggers.
+func watchTriggerTables(ctx context.Context, tx pgx.Tx, db string) ([]string, error) {
+	sql := `SELECT c.relname ` +
+		`FROM pg_catalog.pg_trigger AS t ` +
+		`JOIN pg_catalog.pg_class AS c ON c.oid = t.tgrelid ` +
+		`JOIN pg_catalog.pg_namespace AS n ON n.oid = c.relnamespace ` +
+		`WHERE n.nspname = $1 AND t.tgname = $2`
+	rows, err := tx.Query(ctx, sql, db, watchFunction)
+	if err != nil {
+		return nil, lazyerrors.Error(err)
+	}
+	defer rows.Close()
+
+	var res []string
+	for rows.Next() {
+		var name string
+		if err = rows.Scan(&name); err != nil {
+			return nil, lazyerrors.Error(err)
+		}
+
+		res = append(res, name)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, lazyerrors.Error(err)
+	}
+
+	return res, nil
+}
+
+// Watch listens for change notifications until ctx is done.
+//
+// It uses a dedicated connection outside of the pool.
+// The returned channel is closed when ctx is done or the connection is broken;
+// consumers should call Watch again in the latter case, keeping in mind that
+// events in between are lost (see WatchModeNotify).
+func (pgPool *Pool) Watch(ctx context.Context) (<-chan WatchEvent, error) {
+	if pgPool.watchMode != WatchModeNotify {
+		return nil, lazyerrors.Errorf("pg.Watch: watch mode is %q", pgPool.watchMode)
+	}
+
+	conn, err := pgx.ConnectConfig(ctx, pgPool.Config().ConnConfig)
+	if err != nil {
+		return nil, lazyerrors.Error(err)
+	}
+
+	if _, err = conn.Exec(ctx, `LISTEN `+pgx.Identifier{watchChannel}.Sanitize()); err != nil {
+		conn.Close(context.Background())
+		return nil, lazyerrors.Error(err)
+	}
+
+	ch := make(chan WatchEvent, 16)
+
+	go func() {
+		defer func() {
+			conn.Close(context.Background()) //nolint:contextcheck // ctx may be already canceled
+			close(ch)
+		}()
+
+		for {
+			n, err := conn.WaitForNotification(ctx)
+			if err != nil {
+				if ctx.Err() == nil {
+					pgPool.logger.Warn("Failed to wait for notification", zap.Error(err))
+				}
+				return
+			}
+
+			event, err := parseWatchPayload(n.Payload)
+			if err != nil {
+				pgPool.logger.Warn("Failed to parse notification", zap.String("payload", n.Payload), zap.Error(err))
+				continue
+			}
+
+			select {
+			case ch <- *event:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+
+	return ch, nil
+}
+
+// parseWatchPayload converts notification payload to WatchEvent.
+func parseWatchPayload(payload string) (*WatchEvent, error) {
+	var p watchPayload
+	if err := json.Unmarshal([]byte(payload), &p); err != nil {
+		return nil, lazyerrors.Error(err)
+	}
+
+	id, err := fjson.Unmarshal(p.ID)
+	if err != nil {
+		return nil, lazyerrors.Error(err)
+	}
+
+	return &WatchEvent{
+		Op:         p.Op,
+		DB:         p.DB,
+		Collection: p.Collection,
+		ID:         id,
+	}, nil
+}
@@ -0,0 +1,47 @@
+// Copyright 2021 FerretDB Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package pgdb
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/FerretDB/FerretDB/internal/types"
+)
+
+func TestParseWatchPayload(t *testing.T) {
+	t.Parallel()
+
+	payload := `{"op" : "update", "db" : "test", "collection" : "values", "id" : {"$o": "000102030405060708091011"}}`
+	event, err := parseWatchPayload(payload)
+	require.NoError(t, err)
+
+	expected := &WatchEvent{
+		Op:         "update",
+		DB:         "test",
+		Collection: "values",
+		ID:         types.ObjectID{0x00, 0x01, 0x02, 0x03, 0x04, 0x05, 0x06, 0x07, 0x08, 0x09, 0x10, 0x11},
+	}
+	assert.Equal(t, expected, event)
+
+	event, err = parseWatchPayload(`{"op" : "delete", "db" : "test", "collection" : "values", "id" : null}`)
+	require.NoError(t, err)
+	assert.Equal(t, types.Null, event.ID)
+
+	_, err = parseWatchPayload(`{"op": "insert"}`)
+	assert.Error(t, err)
+}
@@ -0,0 +1,118 @@
+// Copyright 2021 FerretDB Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package pg
+
+import (
+	"context"
+	"fmt"
+
+	"go.uber.org/zap"
+
+	"github.com/FerretDB/FerretDB/internal/clientconn/conninfo"
+	"github.com/FerretDB/FerretDB/internal/handlers/common"
+	"github.com/FerretDB/FerretDB/internal/handlers/pg/pgdb"
+	"github.com/FerretDB/FerretDB/internal/util/lazyerrors"
+)
+
+// tenantPool returns PostgreSQL connection pool for the authenticated user in tenant roles mode;
+// see NewOpts.TenantRoles.
+//
+// Users of the admin database use the shared pool.
+// Users of other databases use a separate pool for each database that runs all queries
+// as its tenant role (see pgdb.TenantRole), so PostgreSQL does not let them access other databases.
+// Unauthorized error is returned if client did not authenticate yet.
+func (h *Handler) tenantPool(ctx context.Context) (*pgdb.Pool, error) {
+	username, db := conninfo.GetConnInfo(ctx).Auth()
+	if username == "" {
+		return nil, common.NewErrorMsg(common.ErrUnauthorized, "Command requires authentication")
+	}
+
+	switch db {
+	case common.UsersDB:
+		return h.pgPool, nil
+	case common.ExternalDB:
+		msg := fmt.Sprintf("Users of %s database are not supported with tenant roles", common.ExternalDB)
+		return nil, common.NewErrorMsg(common.ErrUnauthorized, msg)
+	}
+
+	h.tenantPools.rw.RLock()
+	pool := h.tenantPools.pools[db]
+	h.tenantPools.rw.RUnlock()
+
+	if pool != nil {
+		return pool, nil
+	}
+
+	h.tenantPools.rw.Lock()
+	defer h.tenantPools.rw.Unlock()
+
+	// another request could open it while we were waiting for the lock
+	if pool = h.tenantPools.pools[db]; pool != nil {
+		return pool, nil
+	}
+
+	// PostgreSQL settings were checked by the shared pool
+	opts := *h.poolOpts
+	opts.Lazy = true
+	opts.Role = pgdb.TenantRole(db)
+
+	pool, err := pgdb.NewPool(ctx, h.connString, h.l, &opts)
+	if err != nil {
+		return nil, lazyerrors.Error(err)
+	}
+
+	h.tenantPools.pools[db] = pool
+
+	h.l.Info("Opened connection pool for tenant", zap.String("db", db), zap.String("role", opts.Role))
+
+	return pool, nil
+}
+
+// createUserPool returns PostgreSQL connection pool for createUser command; see pool.
+//
+// In tenant roles mode, an unauthenticated client could create the first user,
+// like with MongoDB's localhost exception.
+func (h *Handler) createUserPool(ctx context.Context) (*pgdb.Pool, error) {
+	pool, err := h.pool(ctx)
+	if err == nil || !h.tenantRoles {
+		return pool, err
+	}
+
+	if username, _ := conninfo.GetConnInfo(ctx).Auth(); username != "" {
+		return nil, err
+	}
+
+	users, e := h.fetchUsers(ctx, nil)
+	if e != nil {
+		return nil, lazyerrors.Error(e)
+	}
+
+	if len(users) > 0 {
+		return nil, err
+	}
+
+	return h.pgPool, nil
+}
+
+// closeTenantPools closes all per-tenant connection pools.
+func (h *Handler) closeTenantPools() {
+	h.tenantPools.rw.Lock()
+	defer h.tenantPools.rw.Unlock()
+
+	for db, pool := range h.tenantPools.pools {
+		pool.Close()
+		delete(h.tenantPools.pools, db)
+	}
+}
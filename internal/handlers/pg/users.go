@@ -0,0 +1,214 @@
+// Copyright 2021 FerretDB Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package pg
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/FerretDB/FerretDB/internal/handlers/backend"
+	"github.com/FerretDB/FerretDB/internal/handlers/common"
+	"github.com/FerretDB/FerretDB/internal/types"
+	"github.com/FerretDB/FerretDB/internal/util/lazyerrors"
+	"github.com/FerretDB/FerretDB/internal/util/must"
+)
+
+// userID returns _id of the user document.
+func userID(db, username string) string {
+	return db + "." + username
+}
+
+// fetchUsers returns user documents matching the given filter; nil filter matches all users.
+//
+// In tenant roles mode, they are fetched with the shared pool, as they are needed for authentication.
+func (h *Handler) fetchUsers(ctx context.Context, filter *types.Document) ([]*types.Document, error) {
+	var b backend.Backend = h.pgPool
+	if h.tenantRoles {
+		if err := h.checkHealth(); err != nil {
+			return nil, err
+		}
+	} else {
+		var err error
+		if b, err = h.dbBackend(ctx, common.UsersDB); err != nil {
+			return nil, err
+		}
+	}
+
+	exists, err := b.CollectionExists(ctx, common.UsersDB, common.UsersCollection)
+	if err != nil {
+		return nil, lazyerrors.Error(err)
+	}
+
+	if !exists {
+		return nil, nil
+	}
+
+	fetched, err := backend.QueryDocuments(ctx, b, &backend.QueryParams{
+		DB:         common.UsersDB,
+		Collection: common.UsersCollection,
+		Filter:     filter,
+	})
+	if err != nil {
+		return nil, lazyerrors.Error(err)
+	}
+
+	res := make([]*types.Document, 0, len(fetched.Docs))
+	for _, doc := range fetched.Docs {
+		matches, err := common.FilterDocument(doc, fetched.Residual)
+		if err != nil {
+			return nil, lazyerrors.Error(err)
+		}
+
+		if matches {
+			res = append(res, doc)
+		}
+	}
+
+	return res, nil
+}
+
+// findUser returns user document for the given authentication database and username,
+// or nil if there is no such user.
+//
+// It implements common.UserLookup.
+func (h *Handler) findUser(ctx context.Context, db, username string) (*types.Document, error) {
+	users, err := h.fetchUsers(ctx, must.NotFail(types.NewDocument("_id", userID(db, username))))
+	if err != nil {
+		return nil, lazyerrors.Error(err)
+	}
+
+	if len(users) == 0 {
+		return nil, nil
+	}
+
+	return users[0], nil
+}
+
+// userRoles converts roles parameter of createUser command to the stored form.
+//
+// Each role could be either a role name in the current database or a document with role and db fields.
+func userRoles(roles *types.Array, db string) (*types.Array, error) {
+	res := types.MakeArray(roles.Len())
+
+	for i := 0; i < roles.Len(); i++ {
+		v := must.NotFail(roles.Get(i))
+
+		switch v := v.(type) {
+		case string:
+			must.NoError(res.Append(must.NotFail(types.NewDocument("role", v, "db", db))))
+
+		case *types.Document:
+			role, err := common.GetRequiredParam[string](v, "role")
+			if err != nil {
+				return nil, err
+			}
+
+			roleDB, err := common.GetRequiredParam[string](v, "db")
+			if err != nil {
+				return nil, err
+			}
+
+			must.NoError(res.Append(must.NotFail(types.NewDocument("role", role, "db", roleDB))))
+
+		default:
+			msg := fmt.Sprintf("Role names must be either strings or objects, not %s", common.AliasFromType(v))
+			return nil, common.NewErrorMsg(common.ErrBadValue, msg)
+		}
+	}
+
+	return res, nil
+}
+
+// saslSupportedMechs returns authentication mechanisms for the user specified by
+// hello's and isMaster's saslSupportedMechs parameter in "<db>.<username>" form.
+// Nil is returned if the parameter is not set or the user does not exist.
+func (h *Handler) saslSupportedMechs(ctx context.Context, document *types.Document) (*types.Array, error) {
+	v, err := document.Get("saslSupportedMechs")
+	if err != nil {
+		return nil, nil
+	}
+
+	id, ok := v.(string)
+	if !ok {
+		msg := fmt.Sprintf(
+			"BSON field 'saslSupportedMechs' is the wrong type '%s', expected type 'string'", common.AliasFromType(v),
+		)
+		return nil, common.NewErrorMsg(common.ErrTypeMismatch, msg)
+	}
+
+	if h.authMode == AuthModePassthrough || h.authMode == AuthModeLDAP {
+		return must.NotFail(types.NewArray(common.MechanismPLAIN)), nil
+	}
+
+	db, username, ok := strings.Cut(id, ".")
+	if !ok {
+		return nil, common.NewErrorMsg(common.ErrBadValue, "UserName must contain a '.' separated database.user pair")
+	}
+
+	user, err := h.findUser(ctx, db, username)
+	if err != nil || user == nil {
+		return nil, err
+	}
+
+	return common.UserMechanisms(user), nil
+}
+
+// userCredentials returns credentials for the new user from createUser command parameters.
+//
+// Users in the $external database do not have passwords; they are authenticated by other means
+// (for example, with X.509 certificates).
+func userCredentials(document *types.Document, db, username string) (*types.Document, error) {
+	if db == common.ExternalDB {
+		if document.Has("pwd") || document.Has("mechanisms") {
+			msg := "Cannot set password or mechanisms for users in the $external database"
+			return nil, common.NewErrorMsg(common.ErrBadValue, msg)
+		}
+
+		return must.NotFail(types.NewDocument("external", true)), nil
+	}
+
+	password, err := common.GetRequiredParam[string](document, "pwd")
+	if err != nil {
+		return nil, err
+	}
+
+	if password == "" {
+		return nil, common.NewErrorMsg(common.ErrBadValue, "Password cannot be empty")
+	}
+
+	var mechanismsParam *types.Array
+	if mechanismsParam, err = common.GetOptionalParam(document, "mechanisms", mechanismsParam); err != nil {
+		return nil, err
+	}
+
+	var mechanisms []string
+	if mechanismsParam != nil {
+		if mechanismsParam.Len() == 0 {
+			return nil, common.NewErrorMsg(common.ErrBadValue, "mechanisms field must not be empty")
+		}
+
+		for i := 0; i < mechanismsParam.Len(); i++ {
+			mechanism, ok := must.NotFail(mechanismsParam.Get(i)).(string)
+			if !ok {
+				return nil, common.NewErrorMsg(common.ErrBadValue, "mechanisms field must be an array of strings")
+			}
+
+			mechanisms = append(mechanisms, mechanism)
+		}
+	}
+
+	return common.MakeCredentials(username, password, mechanisms)
+}
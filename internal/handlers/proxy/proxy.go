@@ -51,6 +51,18 @@ func (r *Router) Close() {
 
 // Route routes the message by sending it to another wire protocol compatible service.
 func (r *Router) Route(ctx context.Context, header *wire.MsgHeader, body wire.MsgBody) (*wire.MsgHeader, wire.MsgBody, bool) {
+	r.Send(ctx, header, body)
+
+	resHeader, resBody, err := wire.ReadMessage(r.bufr)
+	if err != nil {
+		panic(err)
+	}
+
+	return resHeader, resBody, false
+}
+
+// Send sends the message that has no response (like OP_KILL_CURSORS) to another wire protocol compatible service.
+func (r *Router) Send(ctx context.Context, header *wire.MsgHeader, body wire.MsgBody) {
 	deadline, _ := ctx.Deadline()
 	r.conn.SetDeadline(deadline)
 
@@ -61,11 +73,4 @@ func (r *Router) Route(ctx context.Context, header *wire.MsgHeader, body wire.Ms
 	if err := r.bufw.Flush(); err != nil {
 		panic(err)
 	}
-
-	resHeader, resBody, err := wire.ReadMessage(r.bufr)
-	if err != nil {
-		panic(err)
-	}
-
-	return resHeader, resBody, false
 }
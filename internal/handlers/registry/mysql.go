@@ -0,0 +1,45 @@
+// Copyright 2021 FerretDB Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package registry
+
+import (
+	"fmt"
+
+	"github.com/FerretDB/FerretDB/internal/handlers"
+	"github.com/FerretDB/FerretDB/internal/handlers/common"
+	"github.com/FerretDB/FerretDB/internal/handlers/generic"
+	"github.com/FerretDB/FerretDB/internal/handlers/mysql/mysqldb"
+)
+
+// init registers `mysql` handler.
+func init() {
+	registry["mysql"] = func(opts *NewHandlerOpts) (handlers.Interface, error) {
+		db, err := mysqldb.Open(opts.Ctx, opts.MySQLURL, opts.Logger)
+		if err != nil {
+			return nil, err
+		}
+
+		if err = common.LoadFCV(opts.Ctx, db, opts.Logger); err != nil {
+			return nil, fmt.Errorf("failed to load feature compatibility version: %w", err)
+		}
+
+		handlerOpts := &generic.NewOpts{
+			Storage:          db,
+			L:                opts.Logger,
+			MaxOpenIterators: generic.MaxOpenIterators(db.MaxOpenConns()),
+		}
+		return generic.New(handlerOpts)
+	}
+}
@@ -18,15 +18,20 @@ package registry
 import (
 	"context"
 	"fmt"
+	"time"
 
 	"go.uber.org/zap"
 	"golang.org/x/exp/maps"
 	"golang.org/x/exp/slices"
 
 	"github.com/FerretDB/FerretDB/internal/handlers"
+	"github.com/FerretDB/FerretDB/internal/handlers/common"
 	"github.com/FerretDB/FerretDB/internal/handlers/dummy"
 	"github.com/FerretDB/FerretDB/internal/handlers/pg"
 	"github.com/FerretDB/FerretDB/internal/handlers/pg/pgdb"
+	"github.com/FerretDB/FerretDB/internal/util/fault"
+	"github.com/FerretDB/FerretDB/internal/util/ldapauth"
+	"github.com/FerretDB/FerretDB/internal/util/version"
 )
 
 // newHandlerFunc represents a function that constructs a new handler.
@@ -44,11 +49,77 @@ type NewHandlerOpts struct {
 	Ctx    context.Context
 	Logger *zap.Logger
 
+	// If set, `pg` handler records SQL statements as spans of traced client requests
+	Tracing bool
+
 	// for `pg` handler
-	PostgreSQLURL string
+	PostgreSQLURL        string
+	PostgreSQLWatchMode  pgdb.WatchMode
+	PostgreSQLAuthMode   pg.AuthMode
+	PostgreSQLUUIDColumn bool
+	PostgreSQLGINIndex   bool
+
+	// If set, `pg` handler does not use session-level state, so it could be used with PgBouncer's transaction pooling
+	PostgreSQLPgBouncerMode bool
+
+	// Connection pool settings for `pg` handler that override ones from PostgreSQLURL if positive
+	PostgreSQLPoolMaxConns          int32
+	PostgreSQLPoolMaxConnLifetime   time.Duration
+	PostgreSQLPoolMaxConnIdleTime   time.Duration
+	PostgreSQLPoolHealthCheckPeriod time.Duration
+	PostgreSQLPoolPerDatabase       bool
+
+	// Maximal number of `pg` handler's per-database pools open at the same time; zero means no limit
+	PostgreSQLPoolPerDatabaseMax int
+
+	// TTL of `pg` handler's collections metadata cache; zero disables it
+	PostgreSQLMetadataCacheTTL time.Duration
+
+	// Number of inserted or deleted rows after which `pg` handler runs ANALYZE on a table,
+	// and the minimal interval between such runs; zero threshold disables them
+	PostgreSQLAnalyzeThreshold int64
+	PostgreSQLAnalyzeInterval  time.Duration
+
+	// Duration without bulk import batches into a collection after which `pg` handler builds
+	// its deferred secondary indexes; zero disables deferring them
+	PostgreSQLBulkImportIndexDelay time.Duration
+
+	// Size of FJSON representation of documents (in bytes) above which `pg` handler stores them
+	// in a separate bytea column; zero disables that
+	PostgreSQLLargeDocumentThreshold int
+
+	// Interval between `pg` handler's health checks of PostgreSQL and the number of consecutive failed ones
+	// after which requests fail fast; zero interval disables them
+	PostgreSQLHealthCheckInterval time.Duration
+	PostgreSQLHealthCheckFailures int
+
+	// Read replica URLs for `pg` handler; TLS and pool settings are the same as for PostgreSQLURL
+	PostgreSQLReplicaURLs []string
+
+	// TLS settings for `pg` handler that override ones from PostgreSQLURL
+	PostgreSQLSSLMode     string
+	PostgreSQLSSLRootCert string
+	PostgreSQLSSLCert     string
+	PostgreSQLSSLKey      string
+
+	// If set, `pg` handler's databases are owned by dedicated PostgreSQL roles,
+	// and queries of their users run as those roles
+	PostgreSQLTenantRoles bool
+
+	// LDAP server configuration for `pg` handler's "ldap" authentication mode
+	LDAP *ldapauth.Config
 
 	// for `tigris` handler
 	TigrisURL string
+
+	// for `sqlite` handler; a file path or `file:` URI
+	SQLiteURL string
+
+	// for `mysql` handler; a data source name like `user:password@tcp(host:3306)/database`
+	MySQLURL string
+
+	// If set, `pg` handler injects latency and errors into PostgreSQL connections; only for tests
+	Faults *fault.Injector
 }
 
 // NewHandler constructs a new handler.
@@ -82,14 +153,96 @@ func init() {
 	}
 
 	registry["pg"] = func(opts *NewHandlerOpts) (handlers.Interface, error) {
-		pgPool, err := pgdb.NewPool(opts.Ctx, opts.PostgreSQLURL, opts.Logger, false)
+		poolOpts := &pgdb.NewPoolOpts{
+			WatchMode:   opts.PostgreSQLWatchMode,
+			UUIDColumn:  opts.PostgreSQLUUIDColumn,
+			GINIndex:    opts.PostgreSQLGINIndex,
+			SSLMode:     opts.PostgreSQLSSLMode,
+			SSLRootCert: opts.PostgreSQLSSLRootCert,
+			SSLCert:     opts.PostgreSQLSSLCert,
+			SSLKey:      opts.PostgreSQLSSLKey,
+
+			MaxConns:          opts.PostgreSQLPoolMaxConns,
+			MaxConnLifetime:   opts.PostgreSQLPoolMaxConnLifetime,
+			MaxConnIdleTime:   opts.PostgreSQLPoolMaxConnIdleTime,
+			HealthCheckPeriod: opts.PostgreSQLPoolHealthCheckPeriod,
+
+			SecureSSLDefault: !version.Get().Debug,
+
+			PgBouncerMode: opts.PostgreSQLPgBouncerMode,
+
+			Tracing: opts.Tracing,
+
+			FormatVersion: common.StorageFormatVersion,
+
+			BulkImportIndexDelay: opts.PostgreSQLBulkImportIndexDelay,
+
+			LargeDocumentThreshold: opts.PostgreSQLLargeDocumentThreshold,
+
+			StatementCacheMetrics: pgdb.NewStatementCacheMetrics(),
+
+			Faults: opts.Faults,
+		}
+
+		// replicas could lag behind, so they don't use the cache that is invalidated by the primary
+		replicaPoolOpts := *poolOpts
+
+		if opts.PostgreSQLMetadataCacheTTL > 0 {
+			poolOpts.MetadataCache = pgdb.NewMetadataCache(opts.PostgreSQLMetadataCacheTTL)
+		}
+
+		if opts.PostgreSQLAnalyzeThreshold > 0 {
+			poolOpts.Analyzer = pgdb.NewAnalyzer(opts.PostgreSQLAnalyzeThreshold, opts.PostgreSQLAnalyzeInterval)
+		}
+
+		pgPool, err := pgdb.NewPool(opts.Ctx, opts.PostgreSQLURL, opts.Logger, poolOpts)
 		if err != nil {
 			return nil, err
 		}
 
+		if _, err = pgPool.MigrateTableNames(opts.Ctx); err != nil {
+			opts.Logger.Warn("Failed to migrate table names.", zap.Error(err))
+		}
+
+		if err = pgPool.WarmUpMetadata(opts.Ctx); err != nil {
+			opts.Logger.Warn("Failed to warm up metadata cache.", zap.Error(err))
+		}
+
+		if err = common.LoadFCV(opts.Ctx, pgPool, opts.Logger); err != nil {
+			return nil, fmt.Errorf("failed to load feature compatibility version: %w", err)
+		}
+
+		if err = pgPool.BuildDeferredIndexes(opts.Ctx); err != nil {
+			opts.Logger.Warn("Failed to build deferred indexes.", zap.Error(err))
+		}
+
+		if err = pgPool.CreateWatchTriggers(opts.Ctx); err != nil {
+			return nil, fmt.Errorf("failed to create watch triggers: %w", err)
+		}
+
+		replicas := make([]*pgdb.Pool, len(opts.PostgreSQLReplicaURLs))
+		for i, u := range opts.PostgreSQLReplicaURLs {
+			if replicas[i], err = pgdb.NewPool(opts.Ctx, u, opts.Logger.Named("replica"), &replicaPoolOpts); err != nil {
+				return nil, fmt.Errorf("replica %d: %w", i, err)
+			}
+		}
+
 		handlerOpts := &pg.NewOpts{
-			PgPool: pgPool,
-			L:      opts.Logger,
+			PgPool:        pgPool,
+			L:             opts.Logger,
+			AuthMode:      opts.PostgreSQLAuthMode,
+			PostgreSQLURL: opts.PostgreSQLURL,
+			PoolOpts:      poolOpts,
+			LDAP:          opts.LDAP,
+
+			PerDatabasePools: opts.PostgreSQLPoolPerDatabase,
+			MaxDatabasePools: opts.PostgreSQLPoolPerDatabaseMax,
+			Replicas:         replicas,
+
+			HealthCheckInterval: opts.PostgreSQLHealthCheckInterval,
+			HealthCheckFailures: opts.PostgreSQLHealthCheckFailures,
+
+			TenantRoles: opts.PostgreSQLTenantRoles,
 		}
 		return pg.New(handlerOpts)
 	}
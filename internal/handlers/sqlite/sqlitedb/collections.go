@@ -0,0 +1,262 @@
+// Copyright 2021 FerretDB Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package sqlitedb
+
+import (
+	"context"
+	"database/sql"
+
+	"github.com/FerretDB/FerretDB/internal/util/lazyerrors"
+)
+
+// Databases returns a sorted list of FerretDB database names.
+func (sqliteDB *DB) Databases(ctx context.Context) ([]string, error) {
+	return queryStrings(ctx, sqliteDB.q(), `SELECT name FROM `+quoteIdentifier(databasesTable)+` ORDER BY name`)
+}
+
+// Collections returns a sorted list of FerretDB collection names of the given database.
+//
+// It returns an empty list if database does not exist.
+func (sqliteDB *DB) Collections(ctx context.Context, db string) ([]string, error) {
+	query := `SELECT name FROM ` + quoteIdentifier(collectionsTable) + ` WHERE db = ? ORDER BY name`
+	return queryStrings(ctx, sqliteDB.q(), query, db)
+}
+
+// CollectionExists returns true if the given FerretDB collection exists.
+func (sqliteDB *DB) CollectionExists(ctx context.Context, db, collection string) (bool, error) {
+	return collectionExists(ctx, sqliteDB.q(), db, collection)
+}
+
+// CreateDatabase creates a new FerretDB database.
+//
+// It returns ErrAlreadyExist if database already exists.
+func (sqliteDB *DB) CreateDatabase(ctx context.Context, db string) error {
+	return sqliteDB.inTransaction(ctx, func(tx *sql.Tx) error {
+		created, err := createDatabaseIfNotExist(ctx, tx, db)
+		if err != nil {
+			return err
+		}
+
+		if !created {
+			return ErrAlreadyExist
+		}
+
+		return nil
+	})
+}
+
+// DropDatabase drops FerretDB database with all its collections.
+//
+// It returns ErrDatabaseNotExist if database does not exist.
+func (sqliteDB *DB) DropDatabase(ctx context.Context, db string) error {
+	return sqliteDB.inTransaction(ctx, func(tx *sql.Tx) error {
+		res, err := tx.ExecContext(ctx, `DELETE FROM `+quoteIdentifier(databasesTable)+` WHERE name = ?`, db)
+		if err != nil {
+			return lazyerrors.Error(err)
+		}
+
+		if n, _ := res.RowsAffected(); n == 0 {
+			return ErrDatabaseNotExist
+		}
+
+		query := `SELECT name FROM ` + quoteIdentifier(collectionsTable) + ` WHERE db = ?`
+		collections, err := queryStrings(ctx, tx, query, db)
+		if err != nil {
+			return err
+		}
+
+		for _, collection := range collections {
+			if err = dropCollection(ctx, tx, db, collection); err != nil {
+				return err
+			}
+		}
+
+		return nil
+	})
+}
+
+// CreateCollection creates a new FerretDB collection in the existing database.
+//
+// It returns ErrAlreadyExist if collection already exists, ErrDatabaseNotExist if database does not exist.
+func (sqliteDB *DB) CreateCollection(ctx context.Context, db, collection string) error {
+	return sqliteDB.inTransaction(ctx, func(tx *sql.Tx) error {
+		var exists bool
+		query := `SELECT EXISTS(SELECT 1 FROM ` + quoteIdentifier(databasesTable) + ` WHERE name = ?)`
+		if err := tx.QueryRowContext(ctx, query, db).Scan(&exists); err != nil {
+			return lazyerrors.Error(err)
+		}
+
+		if !exists {
+			return ErrDatabaseNotExist
+		}
+
+		created, err := createCollectionIfNotExist(ctx, tx, db, collection)
+		if err != nil {
+			return err
+		}
+
+		if !created {
+			return ErrAlreadyExist
+		}
+
+		return nil
+	})
+}
+
+// CreateCollectionIfNotExist creates FerretDB database and collection if they don't exist.
+//
+// It returns true if collection was created.
+func (sqliteDB *DB) CreateCollectionIfNotExist(ctx context.Context, db, collection string) (bool, error) {
+	var created bool
+	err := sqliteDB.inTransaction(ctx, func(tx *sql.Tx) error {
+		if _, err := createDatabaseIfNotExist(ctx, tx, db); err != nil {
+			return err
+		}
+
+		var err error
+		created, err = createCollectionIfNotExist(ctx, tx, db, collection)
+		return err
+	})
+	if err != nil {
+		return false, err
+	}
+
+	return created, nil
+}
+
+// DropCollection drops FerretDB collection.
+//
+// It returns ErrDatabaseNotExist or ErrTableNotExist if database or collection does not exist.
+func (sqliteDB *DB) DropCollection(ctx context.Context, db, collection string) error {
+	return sqliteDB.inTransaction(ctx, func(tx *sql.Tx) error {
+		var exists bool
+		query := `SELECT EXISTS(SELECT 1 FROM ` + quoteIdentifier(databasesTable) + ` WHERE name = ?)`
+		if err := tx.QueryRowContext(ctx, query, db).Scan(&exists); err != nil {
+			return lazyerrors.Error(err)
+		}
+
+		if !exists {
+			return ErrDatabaseNotExist
+		}
+
+		return dropCollection(ctx, tx, db, collection)
+	})
+}
+
+// createDatabaseIfNotExist creates FerretDB database if it does not exist.
+//
+// It returns true if database was created.
+func createDatabaseIfNotExist(ctx context.Context, tx *sql.Tx, db string) (bool, error) {
+	query := `INSERT INTO ` + quoteIdentifier(databasesTable) + ` (name) VALUES (?) ON CONFLICT DO NOTHING`
+	res, err := tx.ExecContext(ctx, query, db)
+	if err != nil {
+		return false, lazyerrors.Error(err)
+	}
+
+	n, err := res.RowsAffected()
+	if err != nil {
+		return false, lazyerrors.Error(err)
+	}
+
+	return n == 1, nil
+}
+
+// createCollectionIfNotExist creates FerretDB collection in the existing database if it does not exist.
+//
+// The table has a unique index on _id values.
+// It returns true if collection was created.
+func createCollectionIfNotExist(ctx context.Context, tx *sql.Tx, db, collection string) (bool, error) {
+	query := `INSERT INTO ` + quoteIdentifier(collectionsTable) + ` (db, name, indexes) VALUES (?, ?, '{"$k":[]}')` +
+		` ON CONFLICT DO NOTHING`
+	res, err := tx.ExecContext(ctx, query, db, collection)
+	if err != nil {
+		return false, lazyerrors.Error(err)
+	}
+
+	if n, _ := res.RowsAffected(); n == 0 {
+		return false, nil
+	}
+
+	table := formatTableName(db, collection)
+
+	query = `CREATE TABLE ` + quoteIdentifier(table) + ` (_jsonb TEXT NOT NULL)`
+	if _, err = tx.ExecContext(ctx, query); err != nil {
+		return false, lazyerrors.Error(err)
+	}
+
+	query = `CREATE UNIQUE INDEX ` + quoteIdentifier(table+"_id") + ` ON ` + quoteIdentifier(table) + ` (` + pathSQL("_id") + `)`
+	if _, err = tx.ExecContext(ctx, query); err != nil {
+		return false, lazyerrors.Error(err)
+	}
+
+	return true, nil
+}
+
+// dropCollection drops FerretDB collection with its indexes.
+//
+// It returns ErrTableNotExist if collection does not exist.
+func dropCollection(ctx context.Context, tx *sql.Tx, db, collection string) error {
+	query := `DELETE FROM ` + quoteIdentifier(collectionsTable) + ` WHERE db = ? AND name = ?`
+	res, err := tx.ExecContext(ctx, query, db, collection)
+	if err != nil {
+		return lazyerrors.Error(err)
+	}
+
+	if n, _ := res.RowsAffected(); n == 0 {
+		return ErrTableNotExist
+	}
+
+	if _, err = tx.ExecContext(ctx, `DROP TABLE `+quoteIdentifier(formatTableName(db, collection))); err != nil {
+		return lazyerrors.Error(err)
+	}
+
+	return nil
+}
+
+// collectionExists returns true if the given FerretDB collection exists.
+func collectionExists(ctx context.Context, q querier, db, collection string) (bool, error) {
+	var exists bool
+	query := `SELECT EXISTS(SELECT 1 FROM ` + quoteIdentifier(collectionsTable) + ` WHERE db = ? AND name = ?)`
+	if err := q.QueryRowContext(ctx, query, db, collection).Scan(&exists); err != nil {
+		return false, queryError(err)
+	}
+
+	return exists, nil
+}
+
+// queryStrings runs the given query that returns a single text column and returns its values.
+func queryStrings(ctx context.Context, q querier, query string, args ...any) ([]string, error) {
+	rows, err := q.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, queryError(err)
+	}
+	defer rows.Close()
+
+	res := []string{}
+	for rows.Next() {
+		var s string
+		if err = rows.Scan(&s); err != nil {
+			return nil, lazyerrors.Error(err)
+		}
+
+		res = append(res, s)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, queryError(err)
+	}
+
+	return res, nil
+}
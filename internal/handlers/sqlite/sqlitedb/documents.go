@@ -0,0 +1,268 @@
+// Copyright 2021 FerretDB Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package sqlitedb
+
+import (
+	"context"
+	"database/sql"
+
+	"github.com/FerretDB/FerretDB/internal/fjson"
+	"github.com/FerretDB/FerretDB/internal/types"
+	"github.com/FerretDB/FerretDB/internal/util/lazyerrors"
+	"github.com/FerretDB/FerretDB/internal/util/must"
+)
+
+// InsertDocument inserts a document into FerretDB database and collection.
+// If database or collection does not exist, it will be created.
+//
+// It returns ErrDuplicateID if a document with the same _id already exists.
+func (sqliteDB *DB) InsertDocument(ctx context.Context, db, collection string, doc *types.Document) error {
+	return sqliteDB.InsertDocuments(ctx, db, collection, []*types.Document{doc})
+}
+
+// InsertDocuments inserts documents into FerretDB database and collection in a single transaction,
+// so either all of them are inserted or none.
+// If database or collection does not exist, it will be created.
+//
+// It returns ErrDuplicateID if a document with the same _id already exists.
+func (sqliteDB *DB) InsertDocuments(ctx context.Context, db, collection string, docs []*types.Document) error {
+	return sqliteDB.inTransaction(ctx, func(tx *sql.Tx) error {
+		if err := createCollectionForWrite(ctx, tx, db, collection); err != nil {
+			return err
+		}
+
+		query := `INSERT INTO ` + quoteIdentifier(formatTableName(db, collection)) + ` (_jsonb) VALUES (?)`
+		stmt, err := tx.PrepareContext(ctx, query)
+		if err != nil {
+			return lazyerrors.Error(err)
+		}
+		defer stmt.Close()
+
+		for _, doc := range docs {
+			b, err := fjson.Marshal(doc)
+			if err != nil {
+				return lazyerrors.Error(err)
+			}
+
+			if _, err = stmt.ExecContext(ctx, string(b)); err != nil {
+				if isUniqueViolation(err) {
+					return ErrDuplicateID
+				}
+
+				return queryError(err)
+			}
+		}
+
+		return nil
+	})
+}
+
+// InsertDocumentIfNotExists inserts a document into FerretDB database and collection
+// unless a document with the same _id already exists.
+// If database or collection does not exist, it will be created.
+//
+// The check and the insert are atomic, so it could be used for upserts.
+// It returns true if the document was inserted.
+func (sqliteDB *DB) InsertDocumentIfNotExists(ctx context.Context, db, collection string, doc *types.Document) (bool, error) {
+	b, err := fjson.Marshal(doc)
+	if err != nil {
+		return false, lazyerrors.Error(err)
+	}
+
+	var inserted bool
+	err = sqliteDB.inTransaction(ctx, func(tx *sql.Tx) error {
+		if err := createCollectionForWrite(ctx, tx, db, collection); err != nil {
+			return err
+		}
+
+		query := `INSERT INTO ` + quoteIdentifier(formatTableName(db, collection)) + ` (_jsonb) VALUES (?)` +
+			` ON CONFLICT DO NOTHING`
+		res, err := tx.ExecContext(ctx, query, string(b))
+		if err != nil {
+			return queryError(err)
+		}
+
+		n, err := res.RowsAffected()
+		if err != nil {
+			return lazyerrors.Error(err)
+		}
+
+		inserted = n == 1
+		return nil
+	})
+	if err != nil {
+		return false, err
+	}
+
+	return inserted, nil
+}
+
+// SetDocumentByID replaces the document with the given _id.
+//
+// It returns the number of replaced documents; zero if collection does not exist.
+func (sqliteDB *DB) SetDocumentByID(ctx context.Context, db, collection string, id any, doc *types.Document) (int64, error) {
+	b, err := fjson.Marshal(doc)
+	if err != nil {
+		return 0, lazyerrors.Error(err)
+	}
+
+	idArg, err := fjson.Marshal(id)
+	if err != nil {
+		return 0, lazyerrors.Error(err)
+	}
+
+	var updated int64
+	err = sqliteDB.inTransaction(ctx, func(tx *sql.Tx) error {
+		exists, err := collectionExists(ctx, tx, db, collection)
+		if err != nil || !exists {
+			return err
+		}
+
+		query := `UPDATE ` + quoteIdentifier(formatTableName(db, collection)) + ` SET _jsonb = ?` +
+			` WHERE ` + pathSQL("_id") + ` = json_extract(?, '$')`
+		res, err := tx.ExecContext(ctx, query, string(b), string(idArg))
+		if err != nil {
+			if isUniqueViolation(err) {
+				return ErrDuplicateID
+			}
+
+			return queryError(err)
+		}
+
+		if updated, err = res.RowsAffected(); err != nil {
+			return lazyerrors.Error(err)
+		}
+
+		return nil
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	return updated, nil
+}
+
+// SetDocumentsByID replaces documents with the same _ids as the given ones in a single transaction.
+//
+// It returns the number of replaced documents; zero if collection does not exist.
+func (sqliteDB *DB) SetDocumentsByID(ctx context.Context, db, collection string, docs []*types.Document) (int64, error) {
+	var updated int64
+	err := sqliteDB.inTransaction(ctx, func(tx *sql.Tx) error {
+		exists, err := collectionExists(ctx, tx, db, collection)
+		if err != nil || !exists {
+			return err
+		}
+
+		query := `UPDATE ` + quoteIdentifier(formatTableName(db, collection)) + ` SET _jsonb = ?` +
+			` WHERE ` + pathSQL("_id") + ` = json_extract(?, '$')`
+		stmt, err := tx.PrepareContext(ctx, query)
+		if err != nil {
+			return lazyerrors.Error(err)
+		}
+		defer stmt.Close()
+
+		for _, doc := range docs {
+			b, err := fjson.Marshal(doc)
+			if err != nil {
+				return lazyerrors.Error(err)
+			}
+
+			idArg, err := fjson.Marshal(must.NotFail(doc.Get("_id")))
+			if err != nil {
+				return lazyerrors.Error(err)
+			}
+
+			res, err := stmt.ExecContext(ctx, string(b), string(idArg))
+			if err != nil {
+				return queryError(err)
+			}
+
+			n, err := res.RowsAffected()
+			if err != nil {
+				return lazyerrors.Error(err)
+			}
+
+			updated += n
+		}
+
+		return nil
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	return updated, nil
+}
+
+// DeleteDocumentsByID deletes documents with the given _ids.
+//
+// It returns the number of deleted documents; zero if collection does not exist.
+func (sqliteDB *DB) DeleteDocumentsByID(ctx context.Context, db, collection string, ids []any) (int64, error) {
+	var deleted int64
+	err := sqliteDB.inTransaction(ctx, func(tx *sql.Tx) error {
+		exists, err := collectionExists(ctx, tx, db, collection)
+		if err != nil || !exists {
+			return err
+		}
+
+		// a statement per _id uses the unique index and avoids the limit on the number of parameters
+		query := `DELETE FROM ` + quoteIdentifier(formatTableName(db, collection)) +
+			` WHERE ` + pathSQL("_id") + ` = json_extract(?, '$')`
+		stmt, err := tx.PrepareContext(ctx, query)
+		if err != nil {
+			return lazyerrors.Error(err)
+		}
+		defer stmt.Close()
+
+		for _, id := range ids {
+			idArg, err := fjson.Marshal(id)
+			if err != nil {
+				return lazyerrors.Error(err)
+			}
+
+			res, err := stmt.ExecContext(ctx, string(idArg))
+			if err != nil {
+				return queryError(err)
+			}
+
+			n, err := res.RowsAffected()
+			if err != nil {
+				return lazyerrors.Error(err)
+			}
+
+			deleted += n
+		}
+
+		return nil
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	return deleted, nil
+}
+
+// createCollectionForWrite creates FerretDB database and collection for writing documents if they don't exist.
+func createCollectionForWrite(ctx context.Context, tx *sql.Tx, db, collection string) error {
+	if _, err := createDatabaseIfNotExist(ctx, tx, db); err != nil {
+		return err
+	}
+
+	if _, err := createCollectionIfNotExist(ctx, tx, db, collection); err != nil {
+		return err
+	}
+
+	return nil
+}
@@ -0,0 +1,72 @@
+// Copyright 2021 FerretDB Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package sqlitedb
+
+import (
+	"errors"
+	"fmt"
+
+	"modernc.org/sqlite"
+	sqlite3 "modernc.org/sqlite/lib"
+
+	"github.com/FerretDB/FerretDB/internal/handlers/backend"
+)
+
+func init() {
+	backend.RegisterErrorTranslator(translateError)
+}
+
+// errorMapping represents backend error code and safe message for SQLite error.
+type errorMapping struct {
+	code backend.ErrorCode
+	msg  string
+}
+
+// sqliteErrors maps SQLite primary result codes to backend errors.
+var sqliteErrors = map[int]errorMapping{
+	sqlite3.SQLITE_PERM:      {backend.ErrorCodeUnauthorized, "SQLite access permission denied"},
+	sqlite3.SQLITE_AUTH:      {backend.ErrorCodeUnauthorized, "SQLite authorization denied"},
+	sqlite3.SQLITE_BUSY:      {backend.ErrorCodeWriteConflict, "SQLite database is locked"},
+	sqlite3.SQLITE_LOCKED:    {backend.ErrorCodeWriteConflict, "SQLite table is locked"},
+	sqlite3.SQLITE_NOMEM:     {backend.ErrorCodeUnavailable, "SQLite is out of memory"},
+	sqlite3.SQLITE_READONLY:  {backend.ErrorCodeReadOnly, "SQLite database is read-only"},
+	sqlite3.SQLITE_INTERRUPT: {backend.ErrorCodeTimeout, "SQLite query interrupted"},
+	sqlite3.SQLITE_IOERR:     {backend.ErrorCodeUnavailable, "SQLite disk I/O error"},
+	sqlite3.SQLITE_FULL:      {backend.ErrorCodeOutOfDiskSpace, "SQLite database or disk is full"},
+	sqlite3.SQLITE_CANTOPEN:  {backend.ErrorCodeUnavailable, "SQLite unable to open database file"},
+}
+
+// translateError returns backend error for the error caused by SQLite, or nil.
+//
+// It implements backend.ErrorTranslator.
+func translateError(err error) *backend.Error {
+	var e *sqlite.Error
+	if !errors.As(err, &e) {
+		return nil
+	}
+
+	switch e.Code() {
+	case sqlite3.SQLITE_CONSTRAINT_UNIQUE, sqlite3.SQLITE_CONSTRAINT_PRIMARYKEY:
+		return backend.NewError(backend.ErrorCodeDuplicateKey, "SQLite unique constraint failed", err)
+	}
+
+	if m, ok := sqliteErrors[e.Code()&0xff]; ok {
+		return backend.NewError(m.code, m.msg, err)
+	}
+
+	msg := fmt.Sprintf("SQLite error (code %d)", e.Code())
+
+	return backend.NewError(backend.ErrorCodeInternal, msg, err)
+}
@@ -0,0 +1,111 @@
+// Copyright 2021 FerretDB Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package sqlitedb
+
+import (
+	"strings"
+
+	"github.com/FerretDB/FerretDB/internal/fjson"
+	"github.com/FerretDB/FerretDB/internal/types"
+	"github.com/FerretDB/FerretDB/internal/util/must"
+)
+
+// pathSQL returns SQL expression that extracts the value of the given dot notation path from the document.
+//
+// It does not use placeholders because it is used in index definitions,
+// and SQLite uses expression indexes only for queries with exactly the same expressions.
+// The path should be checked with validPath first.
+func pathSQL(path string) string {
+	jsonPath := "$"
+	for _, e := range strings.Split(path, ".") {
+		jsonPath += `."` + e + `"`
+	}
+
+	return `json_extract(_jsonb, '` + strings.ReplaceAll(jsonPath, `'`, `''`) + `')`
+}
+
+// validPath returns true if the given dot notation path could be used with pathSQL.
+//
+// JSON paths in SQLite can't contain double quotes in keys.
+func validPath(path string) bool {
+	if strings.HasPrefix(path, "$") || strings.Contains(path, `"`) {
+		return false
+	}
+
+	for _, e := range strings.Split(path, ".") {
+		if e == "" {
+			return false
+		}
+	}
+
+	return true
+}
+
+// buildFilter returns SQL condition for WHERE clause and its arguments for the given filter.
+//
+// Only equality of top-level fields to strings and ObjectIDs is handled.
+// The condition matches a superset of documents that match the filter
+// (for example, documents with arrays or symbols in those fields), so the whole filter
+// should still be applied to fetched documents.
+// Empty condition is returned if nothing could be handled.
+func buildFilter(filter *types.Document) (string, []any) {
+	if filter == nil {
+		return "", nil
+	}
+
+	var conditions []string
+	var args []any
+
+	for _, field := range filter.Keys() {
+		if strings.Contains(field, ".") || !validPath(field) {
+			continue
+		}
+
+		value := must.NotFail(filter.Get(field))
+		if ops, ok := value.(*types.Document); ok {
+			if ops.Len() != 1 || ops.Keys()[0] != "$eq" {
+				continue
+			}
+
+			value = must.NotFail(ops.Get("$eq"))
+		}
+
+		var arg any
+		switch value := value.(type) {
+		case string:
+			arg = value
+		case types.ObjectID:
+			// json_extract returns minified JSON text for objects
+			arg = string(must.NotFail(fjson.Marshal(value)))
+		default:
+			continue
+		}
+
+		args = append(args, arg)
+
+		// _id values can't be arrays; that way, the unique index is used
+		if field == "_id" {
+			conditions = append(conditions, pathSQL(field)+` = ?`)
+			continue
+		}
+
+		// arrays could contain equal elements, and objects could be symbols equal to strings
+		typ := `json_type(_jsonb, '$."` + strings.ReplaceAll(field, `'`, `''`) + `"')`
+		cond := `(` + pathSQL(field) + ` = ? OR ` + typ + ` IN ('array', 'object'))`
+		conditions = append(conditions, cond)
+	}
+
+	return strings.Join(conditions, " AND "), args
+}
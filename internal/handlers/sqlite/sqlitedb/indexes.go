@@ -0,0 +1,223 @@
+// Copyright 2021 FerretDB Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package sqlitedb
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"hash/fnv"
+	"strings"
+
+	"github.com/FerretDB/FerretDB/internal/fjson"
+	"github.com/FerretDB/FerretDB/internal/handlers/backend"
+	"github.com/FerretDB/FerretDB/internal/types"
+	"github.com/FerretDB/FerretDB/internal/util/lazyerrors"
+	"github.com/FerretDB/FerretDB/internal/util/must"
+)
+
+// Indexes returns a list of indexes of the given FerretDB collection.
+//
+// The default _id index is always returned first, like in MongoDB.
+// It returns ErrTableNotExist if collection does not exist.
+func (sqliteDB *DB) Indexes(ctx context.Context, db, collection string) ([]backend.Index, error) {
+	indexes, err := getIndexes(ctx, sqliteDB.q(), db, collection)
+	if err != nil {
+		return nil, err
+	}
+
+	res := []backend.Index{{
+		Name: "_id_",
+		Key:  must.NotFail(types.NewDocument("_id", int32(1))),
+	}}
+
+	for _, name := range indexes.Keys() {
+		index := must.NotFail(indexes.Get(name)).(*types.Document)
+		res = append(res, backend.Index{
+			Name: name,
+			Key:  must.NotFail(index.Get("key")).(*types.Document),
+		})
+	}
+
+	return res, nil
+}
+
+// CreateIndex creates an index with the given name and key for the given FerretDB collection.
+//
+// Ascending and descending indexes on one or more paths are supported;
+// they are created as SQLite indexes on expressions that extract values of those paths.
+// Indexes are tracked in the collections metadata table.
+//
+// It returns ErrAlreadyExist if the same index already exists, backend.ErrIndexNotSupported if key is not supported,
+// backend.ErrIndexKeyConflict or backend.ErrIndexNameConflict if a different index with the same name or key exists,
+// and ErrTableNotExist if collection does not exist.
+func (sqliteDB *DB) CreateIndex(ctx context.Context, db, collection, name string, key *types.Document) error {
+	exprs, ok := indexExprs(key)
+	if !ok {
+		return backend.ErrIndexNotSupported
+	}
+
+	if name == "_id_" {
+		return backend.ErrIndexKeyConflict
+	}
+
+	return sqliteDB.inTransaction(ctx, func(tx *sql.Tx) error {
+		indexes, err := getIndexes(ctx, tx, db, collection)
+		if err != nil {
+			return err
+		}
+
+		for _, existingName := range indexes.Keys() {
+			existing := must.NotFail(indexes.Get(existingName)).(*types.Document)
+			sameKey := sameIndexKey(must.NotFail(existing.Get("key")).(*types.Document), key)
+
+			switch {
+			case existingName == name && sameKey:
+				return ErrAlreadyExist
+			case existingName == name:
+				return backend.ErrIndexKeyConflict
+			case sameKey:
+				return backend.ErrIndexNameConflict
+			}
+		}
+
+		table := formatTableName(db, collection)
+
+		query := `CREATE INDEX ` + quoteIdentifier(indexName(table, name)) +
+			` ON ` + quoteIdentifier(table) + ` (` + strings.Join(exprs, ", ") + `)`
+		if _, err = tx.ExecContext(ctx, query); err != nil {
+			return lazyerrors.Error(err)
+		}
+
+		must.NoError(indexes.Set(name, must.NotFail(types.NewDocument("key", key))))
+
+		b, err := fjson.Marshal(indexes)
+		if err != nil {
+			return lazyerrors.Error(err)
+		}
+
+		query = `UPDATE ` + quoteIdentifier(collectionsTable) + ` SET indexes = ? WHERE db = ? AND name = ?`
+		if _, err = tx.ExecContext(ctx, query, string(b), db, collection); err != nil {
+			return lazyerrors.Error(err)
+		}
+
+		return nil
+	})
+}
+
+// getIndexes returns indexes of the given collection from the collections metadata table.
+//
+// Keys of the returned document are index names, values are documents with "key" field.
+// It returns ErrTableNotExist if collection does not exist.
+func getIndexes(ctx context.Context, q querier, db, collection string) (*types.Document, error) {
+	var s string
+	query := `SELECT indexes FROM ` + quoteIdentifier(collectionsTable) + ` WHERE db = ? AND name = ?`
+	if err := q.QueryRowContext(ctx, query, db, collection).Scan(&s); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, ErrTableNotExist
+		}
+
+		return nil, queryError(err)
+	}
+
+	indexes, err := fjson.Unmarshal([]byte(s))
+	if err != nil {
+		return nil, lazyerrors.Error(err)
+	}
+
+	return indexes.(*types.Document), nil
+}
+
+// indexExprs returns SQL expressions for the given index key.
+//
+// It returns false if key is not supported.
+func indexExprs(key *types.Document) ([]string, bool) {
+	if key.Len() == 0 {
+		return nil, false
+	}
+
+	res := make([]string, 0, key.Len())
+
+	for _, path := range key.Keys() {
+		if path == "_id" || !validPath(path) {
+			return nil, false
+		}
+
+		var order string
+		switch sortOrder(must.NotFail(key.Get(path))) {
+		case 1:
+			order = " ASC"
+		case -1:
+			order = " DESC"
+		default:
+			return nil, false
+		}
+
+		res = append(res, pathSQL(path)+order)
+	}
+
+	return res, true
+}
+
+// sameIndexKey returns true if both index keys have the same paths and directions in the same order.
+func sameIndexKey(a, b *types.Document) bool {
+	aKeys, bKeys := a.Keys(), b.Keys()
+	if len(aKeys) != len(bKeys) {
+		return false
+	}
+
+	for i, path := range aKeys {
+		if path != bKeys[i] {
+			return false
+		}
+
+		if sortOrder(must.NotFail(a.Get(path))) != sortOrder(must.NotFail(b.Get(path))) {
+			return false
+		}
+	}
+
+	return true
+}
+
+// sortOrder returns 1 for ascending and -1 for descending index key values, and 0 for other values.
+func sortOrder(v any) int {
+	switch v := v.(type) {
+	case int32:
+		if v == 1 || v == -1 {
+			return int(v)
+		}
+	case int64:
+		if v == 1 || v == -1 {
+			return int(v)
+		}
+	case float64:
+		if v == 1 || v == -1 {
+			return int(v)
+		}
+	}
+
+	return 0
+}
+
+// indexName returns the name of the SQLite index for the given table and FerretDB index name.
+//
+// SQLite index names are unique for the whole database, so they are prefixed with the table name.
+func indexName(table, name string) string {
+	hash32 := fnv.New32a()
+	_ = must.NotFail(hash32.Write([]byte(name)))
+
+	return fmt.Sprintf("%s_idx_%08x", table, hash32.Sum32())
+}
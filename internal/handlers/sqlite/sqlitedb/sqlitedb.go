@@ -0,0 +1,343 @@
+// Copyright 2021 FerretDB Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package sqlitedb provides SQLite storage for the `sqlite` handler (see package generic).
+//
+// All FerretDB databases are stored in a single SQLite database file.
+// Each FerretDB collection is stored in a separate table with a single _jsonb column
+// that contains documents encoded as FJSON; SQLite's JSON1 functions are used to query them.
+// FerretDB databases, collections, and their indexes are tracked in metadata tables.
+package sqlitedb
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"hash/fnv"
+	"strings"
+
+	"go.uber.org/zap"
+	"modernc.org/sqlite"
+	sqlite3 "modernc.org/sqlite/lib"
+
+	"github.com/FerretDB/FerretDB/internal/handlers/backend"
+	"github.com/FerretDB/FerretDB/internal/util/lazyerrors"
+	"github.com/FerretDB/FerretDB/internal/util/must"
+)
+
+const (
+	// databasesTable is the name of the metadata table with FerretDB databases.
+	databasesTable = "_ferretdb_databases"
+
+	// collectionsTable is the name of the metadata table with FerretDB collections and their indexes.
+	collectionsTable = "_ferretdb_collections"
+
+	// busyTimeout is the time in milliseconds a write waits for other writes to finish.
+	busyTimeout = 5000
+
+	// maxOpenConns is the maximum number of open connections to the database file.
+	//
+	// Each open iterator holds a connection with a read transaction that prevents WAL checkpoints,
+	// so their number should be limited; see MaxOpenConns.
+	maxOpenConns = 32
+)
+
+// Errors are the same as backend's, so callers could use either.
+var (
+	// ErrTableNotExist indicates that there is no such table (FerretDB collection).
+	ErrTableNotExist = backend.ErrCollectionNotExist
+
+	// ErrDatabaseNotExist indicates that there is no such FerretDB database.
+	ErrDatabaseNotExist = backend.ErrDatabaseNotExist
+
+	// ErrAlreadyExist indicates that a database, collection, or index already exists.
+	ErrAlreadyExist = backend.ErrAlreadyExist
+
+	// ErrQueryCanceled indicates that the query was interrupted by the context cancelation or deadline.
+	ErrQueryCanceled = backend.ErrQueryCanceled
+
+	// ErrDuplicateID indicates that a document with the same _id already exists.
+	ErrDuplicateID = backend.ErrDuplicateID
+)
+
+// DB represents an SQLite database that contains FerretDB databases.
+//
+// It is safe for concurrent use, except for DB passed to InTransaction's function.
+type DB struct {
+	db *sql.DB
+	tx *sql.Tx // set for DB passed to InTransaction's function
+	l  *zap.Logger
+}
+
+// Open opens the SQLite database with the given URL (a file path or `file:` URI)
+// and creates metadata tables if needed.
+//
+// Write-ahead log is enabled, so readers don't block the writer.
+// Write transactions lock the database when they begin and wait for each other.
+// In-memory databases are not supported because each connection would see a separate database;
+// use a temporary file instead.
+func Open(ctx context.Context, u string, l *zap.Logger) (*DB, error) {
+	if u == "" || u == ":memory:" || strings.Contains(u, "mode=memory") {
+		return nil, fmt.Errorf("sqlitedb.Open: in-memory databases are not supported")
+	}
+
+	sep := "?"
+	if strings.Contains(u, "?") {
+		sep = "&"
+	}
+
+	dsn := u + sep + fmt.Sprintf("_pragma=busy_timeout(%d)&_pragma=journal_mode(wal)&_txlock=immediate", busyTimeout)
+
+	db, err := sql.Open("sqlite", dsn)
+	if err != nil {
+		return nil, fmt.Errorf("sqlitedb.Open: %w", err)
+	}
+
+	db.SetMaxOpenConns(maxOpenConns)
+
+	sqliteDB := &DB{
+		db: db,
+		l:  l,
+	}
+
+	if err = sqliteDB.init(ctx); err != nil {
+		db.Close()
+		return nil, fmt.Errorf("sqlitedb.Open: %w", err)
+	}
+
+	return sqliteDB, nil
+}
+
+// init creates metadata tables if they don't exist.
+func (sqliteDB *DB) init(ctx context.Context) error {
+	return sqliteDB.inTransaction(ctx, func(tx *sql.Tx) error {
+		query := `CREATE TABLE IF NOT EXISTS ` + quoteIdentifier(databasesTable) + ` (name TEXT PRIMARY KEY)`
+		if _, err := tx.ExecContext(ctx, query); err != nil {
+			return lazyerrors.Error(err)
+		}
+
+		// indexes contains FJSON document with index names as keys and {key: <index key>} documents as values
+		query = `CREATE TABLE IF NOT EXISTS ` + quoteIdentifier(collectionsTable) + ` (` +
+			`db TEXT NOT NULL, ` +
+			`name TEXT NOT NULL, ` +
+			`indexes TEXT NOT NULL, ` +
+			`PRIMARY KEY (db, name))`
+		if _, err := tx.ExecContext(ctx, query); err != nil {
+			return lazyerrors.Error(err)
+		}
+
+		return nil
+	})
+}
+
+// Close closes the database.
+func (sqliteDB *DB) Close() {
+	if err := sqliteDB.db.Close(); err != nil {
+		sqliteDB.l.Error("Failed to close SQLite database.", zap.Error(err))
+	}
+}
+
+// MaxOpenConns returns the maximum number of open connections to the database.
+func (sqliteDB *DB) MaxOpenConns() int {
+	return sqliteDB.db.Stats().MaxOpenConnections
+}
+
+// Name returns "SQLite".
+func (sqliteDB *DB) Name() string {
+	return "SQLite"
+}
+
+// Ping checks that the database is accessible.
+func (sqliteDB *DB) Ping(ctx context.Context) error {
+	if err := sqliteDB.db.PingContext(ctx); err != nil {
+		return lazyerrors.Error(err)
+	}
+
+	return nil
+}
+
+// Version returns SQLite library version.
+func (sqliteDB *DB) Version(ctx context.Context) (string, error) {
+	var res string
+	if err := sqliteDB.db.QueryRowContext(ctx, `SELECT sqlite_version()`).Scan(&res); err != nil {
+		return "", lazyerrors.Error(err)
+	}
+
+	return res, nil
+}
+
+// Size returns the size of the SQLite database in bytes, excluding the write-ahead log.
+func (sqliteDB *DB) Size(ctx context.Context) (int64, error) {
+	var res int64
+	query := `SELECT page_count * page_size FROM pragma_page_count(), pragma_page_size()`
+	if err := sqliteDB.db.QueryRowContext(ctx, query).Scan(&res); err != nil {
+		return 0, lazyerrors.Error(err)
+	}
+
+	return res, nil
+}
+
+// InTransaction calls f with DB which methods run in a single write transaction.
+//
+// Write transactions lock the whole database when they begin, so they are serializable and never conflict.
+// Nested transactions use savepoints.
+//
+// It implements backend.Backend.
+func (sqliteDB *DB) InTransaction(ctx context.Context, f func(backend.Backend) error) error {
+	return sqliteDB.inTransaction(ctx, func(tx *sql.Tx) error {
+		return f(&DB{db: sqliteDB.db, tx: tx, l: sqliteDB.l})
+	})
+}
+
+// q returns the current transaction, if any, or the database.
+func (sqliteDB *DB) q() querier {
+	if sqliteDB.tx != nil {
+		return sqliteDB.tx
+	}
+
+	return sqliteDB.db
+}
+
+// inTransaction uses a write transaction to run f.
+//
+// If f returns an error or context is canceled, the transaction is rolled back.
+// Errors are returned as is, so f could return ones defined in this package.
+//
+// In DB passed to InTransaction's function, f runs in a savepoint of that transaction.
+func (sqliteDB *DB) inTransaction(ctx context.Context, f func(*sql.Tx) error) (err error) {
+	if sqliteDB.tx != nil {
+		return sqliteDB.inSavepoint(ctx, f)
+	}
+
+	tx, err := sqliteDB.db.BeginTx(ctx, nil)
+	if err != nil {
+		return queryError(err)
+	}
+
+	defer func() {
+		if err == nil {
+			return
+		}
+
+		if rerr := tx.Rollback(); rerr != nil && !errors.Is(rerr, sql.ErrTxDone) {
+			sqliteDB.l.Error("Failed to roll back transaction.", zap.Error(rerr))
+		}
+	}()
+
+	if err = f(tx); err != nil {
+		return err
+	}
+
+	if err = tx.Commit(); err != nil {
+		return queryError(err)
+	}
+
+	return nil
+}
+
+// inSavepoint runs f in a savepoint of the current transaction, so its changes could be rolled back separately.
+//
+// Savepoints with the same name are nested; the innermost one is released or rolled back.
+func (sqliteDB *DB) inSavepoint(ctx context.Context, f func(*sql.Tx) error) (err error) {
+	tx := sqliteDB.tx
+
+	if _, err = tx.ExecContext(ctx, `SAVEPOINT ferretdb`); err != nil {
+		return queryError(err)
+	}
+
+	if err = f(tx); err != nil {
+		if _, rerr := tx.ExecContext(ctx, `ROLLBACK TO ferretdb`); rerr != nil {
+			sqliteDB.l.Error("Failed to roll back to savepoint.", zap.Error(rerr))
+			return err
+		}
+	}
+
+	if _, rerr := tx.ExecContext(ctx, `RELEASE ferretdb`); rerr != nil && err == nil {
+		err = queryError(rerr)
+	}
+
+	return err
+}
+
+// queryError converts SQLite errors caused by the context cancelation or deadline to ErrQueryCanceled,
+// and wraps other errors with lazyerrors.
+func queryError(err error) error {
+	if errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) {
+		return ErrQueryCanceled
+	}
+
+	var e *sqlite.Error
+	if errors.As(err, &e) && e.Code() == sqlite3.SQLITE_INTERRUPT {
+		return ErrQueryCanceled
+	}
+
+	return lazyerrors.Error(err)
+}
+
+// isUniqueViolation returns true if err is caused by the unique constraint violation.
+func isUniqueViolation(err error) bool {
+	var e *sqlite.Error
+	if !errors.As(err, &e) {
+		return false
+	}
+
+	return e.Code() == sqlite3.SQLITE_CONSTRAINT_UNIQUE || e.Code() == sqlite3.SQLITE_CONSTRAINT_PRIMARYKEY
+}
+
+// quoteIdentifier quotes the given SQLite identifier.
+func quoteIdentifier(s string) string {
+	return `"` + strings.ReplaceAll(s, `"`, `""`) + `"`
+}
+
+// formatTableName returns the name of the table for the given FerretDB database and collection.
+//
+// SQLite identifiers are case-insensitive, and collection names could contain any characters,
+// so the name consists of the sanitized database and collection names and a hash of the original ones.
+func formatTableName(db, collection string) string {
+	hash32 := fnv.New32a()
+	_ = must.NotFail(hash32.Write([]byte(db + "." + collection)))
+
+	name := strings.Map(func(r rune) rune {
+		switch {
+		case r >= 'a' && r <= 'z', r >= '0' && r <= '9':
+			return r
+		case r >= 'A' && r <= 'Z':
+			return r - 'A' + 'a'
+		default:
+			return '_'
+		}
+	}, db+"_"+collection)
+
+	// keep names readable in the SQLite shell
+	if len(name) > 50 {
+		name = name[:50]
+	}
+
+	return fmt.Sprintf("%s_%08x", name, hash32.Sum32())
+}
+
+// querier is implemented by *sql.DB and *sql.Tx.
+type querier interface {
+	ExecContext(ctx context.Context, query string, args ...any) (sql.Result, error)
+	QueryContext(ctx context.Context, query string, args ...any) (*sql.Rows, error)
+	QueryRowContext(ctx context.Context, query string, args ...any) *sql.Row
+}
+
+// check interfaces
+var (
+	_ querier         = (*sql.DB)(nil)
+	_ querier         = (*sql.Tx)(nil)
+	_ backend.Backend = (*DB)(nil)
+)
@@ -0,0 +1,327 @@
+// Copyright 2021 FerretDB Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Use _test package to avoid import cycle with testutil.
+package sqlitedb_test
+
+import (
+	"errors"
+	"io"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap/zaptest"
+
+	"github.com/FerretDB/FerretDB/internal/handlers/backend"
+	"github.com/FerretDB/FerretDB/internal/handlers/sqlite/sqlitedb"
+	"github.com/FerretDB/FerretDB/internal/types"
+	"github.com/FerretDB/FerretDB/internal/util/must"
+	"github.com/FerretDB/FerretDB/internal/util/testutil"
+)
+
+// setup opens a new database in a temporary directory.
+func setup(t *testing.T) *sqlitedb.DB {
+	t.Helper()
+
+	db, err := sqlitedb.Open(testutil.Ctx(t), filepath.Join(t.TempDir(), "test.sqlite"), zaptest.NewLogger(t))
+	require.NoError(t, err)
+	t.Cleanup(db.Close)
+
+	return db
+}
+
+// queryAll returns all documents matching the given filter.
+func queryAll(t *testing.T, db backend.Backend, collection string, filter *types.Document) []*types.Document {
+	t.Helper()
+
+	iter, err := db.QueryIterator(testutil.Ctx(t), &backend.QueryParams{
+		DB:         "testdb",
+		Collection: collection,
+		Filter:     filter,
+	})
+	require.NoError(t, err)
+	defer iter.Close()
+
+	var res []*types.Document
+	for {
+		doc, err := iter.Next()
+		if err == io.EOF {
+			return res
+		}
+		require.NoError(t, err)
+
+		res = append(res, doc)
+	}
+}
+
+func TestOpenInMemory(t *testing.T) {
+	t.Parallel()
+
+	_, err := sqlitedb.Open(testutil.Ctx(t), ":memory:", zaptest.NewLogger(t))
+	require.Error(t, err)
+}
+
+func TestMaxOpenConns(t *testing.T) {
+	t.Parallel()
+
+	db := setup(t)
+	assert.Equal(t, 32, db.MaxOpenConns())
+}
+
+func TestCollections(t *testing.T) {
+	t.Parallel()
+
+	ctx := testutil.Ctx(t)
+	db := setup(t)
+
+	err := db.CreateCollection(ctx, "testdb", "test")
+	require.Equal(t, sqlitedb.ErrDatabaseNotExist, err)
+
+	require.NoError(t, db.CreateDatabase(ctx, "testdb"))
+	require.Equal(t, sqlitedb.ErrAlreadyExist, db.CreateDatabase(ctx, "testdb"))
+
+	// collection names are case-sensitive
+	require.NoError(t, db.CreateCollection(ctx, "testdb", "test"))
+	require.NoError(t, db.CreateCollection(ctx, "testdb", "Test"))
+	require.Equal(t, sqlitedb.ErrAlreadyExist, db.CreateCollection(ctx, "testdb", "test"))
+
+	created, err := db.CreateCollectionIfNotExist(ctx, "testdb", "test")
+	require.NoError(t, err)
+	assert.False(t, created)
+
+	created, err = db.CreateCollectionIfNotExist(ctx, "testdb", "test.other")
+	require.NoError(t, err)
+	assert.True(t, created)
+
+	collections, err := db.Collections(ctx, "testdb")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"Test", "test", "test.other"}, collections)
+
+	databases, err := db.Databases(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"testdb"}, databases)
+
+	require.NoError(t, db.DropCollection(ctx, "testdb", "Test"))
+	require.Equal(t, sqlitedb.ErrTableNotExist, db.DropCollection(ctx, "testdb", "Test"))
+	require.Equal(t, sqlitedb.ErrDatabaseNotExist, db.DropCollection(ctx, "nodb", "test"))
+
+	exists, err := db.CollectionExists(ctx, "testdb", "Test")
+	require.NoError(t, err)
+	assert.False(t, exists)
+
+	require.NoError(t, db.DropDatabase(ctx, "testdb"))
+	require.Equal(t, sqlitedb.ErrDatabaseNotExist, db.DropDatabase(ctx, "testdb"))
+
+	collections, err = db.Collections(ctx, "testdb")
+	require.NoError(t, err)
+	assert.Empty(t, collections)
+}
+
+func TestDocuments(t *testing.T) {
+	t.Parallel()
+
+	ctx := testutil.Ctx(t)
+	db := setup(t)
+
+	id := types.NewObjectID()
+	doc1 := must.NotFail(types.NewDocument("_id", id, "v", "foo"))
+	doc2 := must.NotFail(types.NewDocument("_id", "2", "v", must.NotFail(types.NewArray("foo", "bar"))))
+	doc3 := must.NotFail(types.NewDocument("_id", int32(3), "v", "bar"))
+
+	require.NoError(t, db.InsertDocuments(ctx, "testdb", "test", []*types.Document{doc1, doc2}))
+
+	err := db.InsertDocuments(ctx, "testdb", "test", []*types.Document{doc3, doc1})
+	require.Equal(t, sqlitedb.ErrDuplicateID, err)
+	assert.Len(t, queryAll(t, db, "test", nil), 2, "documents should be inserted all or none")
+
+	inserted, err := db.InsertDocumentIfNotExists(ctx, "testdb", "test", doc1)
+	require.NoError(t, err)
+	assert.False(t, inserted)
+
+	inserted, err = db.InsertDocumentIfNotExists(ctx, "testdb", "test", doc3)
+	require.NoError(t, err)
+	assert.True(t, inserted)
+
+	t.Run("Filter", func(t *testing.T) {
+		res := queryAll(t, db, "test", must.NotFail(types.NewDocument("_id", id)))
+		require.Len(t, res, 1)
+		testutil.AssertEqual(t, doc1, res[0])
+
+		// superset of matching documents is returned for arrays
+		res = queryAll(t, db, "test", must.NotFail(types.NewDocument("v", "foo")))
+		require.Len(t, res, 2)
+
+		res = queryAll(t, db, "test", must.NotFail(types.NewDocument("v", must.NotFail(types.NewDocument("$eq", "bar")))))
+		require.Len(t, res, 2)
+
+		// not handled
+		res = queryAll(t, db, "test", must.NotFail(types.NewDocument("_id", int32(3))))
+		require.Len(t, res, 3)
+	})
+
+	replacement := must.NotFail(types.NewDocument("_id", id, "v", "baz"))
+	updated, err := db.SetDocumentByID(ctx, "testdb", "test", id, replacement)
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), updated)
+
+	res := queryAll(t, db, "test", must.NotFail(types.NewDocument("_id", id)))
+	require.Len(t, res, 1)
+	testutil.AssertEqual(t, replacement, res[0])
+
+	replacements := []*types.Document{
+		must.NotFail(types.NewDocument("_id", id, "v", "qux")),
+		must.NotFail(types.NewDocument("_id", int32(3), "v", "quux")),
+		must.NotFail(types.NewDocument("_id", "none", "v", "none")),
+	}
+	updated, err = db.SetDocumentsByID(ctx, "testdb", "test", replacements)
+	require.NoError(t, err)
+	assert.Equal(t, int64(2), updated)
+
+	res = queryAll(t, db, "test", must.NotFail(types.NewDocument("_id", id)))
+	require.Len(t, res, 1)
+	testutil.AssertEqual(t, replacements[0], res[0])
+
+	deleted, err := db.DeleteDocumentsByID(ctx, "testdb", "test", []any{id, int32(3), "none"})
+	require.NoError(t, err)
+	assert.Equal(t, int64(2), deleted)
+
+	deleted, err = db.DeleteDocumentsByID(ctx, "testdb", "nocollection", []any{id})
+	require.NoError(t, err)
+	assert.Equal(t, int64(0), deleted)
+
+	res = queryAll(t, db, "test", nil)
+	require.Len(t, res, 1)
+	testutil.AssertEqual(t, doc2, res[0])
+
+	_, err = db.QueryIterator(ctx, &backend.QueryParams{DB: "testdb", Collection: "nocollection"})
+	require.Equal(t, sqlitedb.ErrTableNotExist, err)
+}
+
+func TestIndexes(t *testing.T) {
+	t.Parallel()
+
+	ctx := testutil.Ctx(t)
+	db := setup(t)
+
+	err := db.CreateIndex(ctx, "testdb", "test", "v_1", must.NotFail(types.NewDocument("v", int32(1))))
+	require.Equal(t, sqlitedb.ErrTableNotExist, err)
+
+	_, err = db.CreateCollectionIfNotExist(ctx, "testdb", "test")
+	require.NoError(t, err)
+
+	key := must.NotFail(types.NewDocument("v", int32(1), "w.x", float64(-1)))
+	require.NoError(t, db.CreateIndex(ctx, "testdb", "test", "v_1_w.x_-1", key))
+
+	for name, tc := range map[string]struct {
+		name     string
+		key      *types.Document
+		expected error
+	}{
+		"Same": {
+			name:     "v_1_w.x_-1",
+			key:      must.NotFail(types.NewDocument("v", int64(1), "w.x", int32(-1))),
+			expected: sqlitedb.ErrAlreadyExist,
+		},
+		"SameName": {
+			name:     "v_1_w.x_-1",
+			key:      must.NotFail(types.NewDocument("v", int32(1))),
+			expected: backend.ErrIndexKeyConflict,
+		},
+		"SameKey": {
+			name:     "other",
+			key:      key,
+			expected: backend.ErrIndexNameConflict,
+		},
+		"ID": {
+			name:     "_id_",
+			key:      must.NotFail(types.NewDocument("v", int32(-1))),
+			expected: backend.ErrIndexKeyConflict,
+		},
+		"Text": {
+			name:     "v_text",
+			key:      must.NotFail(types.NewDocument("v", "text")),
+			expected: backend.ErrIndexNotSupported,
+		},
+	} {
+		name, tc := name, tc
+		t.Run(name, func(t *testing.T) {
+			err := db.CreateIndex(ctx, "testdb", "test", tc.name, tc.key)
+			require.Equal(t, tc.expected, err)
+		})
+	}
+
+	indexes, err := db.Indexes(ctx, "testdb", "test")
+	require.NoError(t, err)
+	require.Len(t, indexes, 2)
+	assert.Equal(t, "_id_", indexes[0].Name)
+	assert.Equal(t, "v_1_w.x_-1", indexes[1].Name)
+	testutil.AssertEqual(t, key, indexes[1].Key)
+
+	require.NoError(t, db.DropCollection(ctx, "testdb", "test"))
+
+	_, err = db.Indexes(ctx, "testdb", "test")
+	require.Equal(t, sqlitedb.ErrTableNotExist, err)
+}
+
+func TestInTransaction(t *testing.T) {
+	t.Parallel()
+
+	ctx := testutil.Ctx(t)
+	db := setup(t)
+
+	doc := func(id int32) *types.Document {
+		return must.NotFail(types.NewDocument("_id", id))
+	}
+
+	expected := errors.New("test error")
+
+	err := db.InTransaction(ctx, func(tx backend.Backend) error {
+		require.NoError(t, tx.InsertDocument(ctx, "testdb", "values", doc(1)))
+
+		// nested transaction is rolled back separately
+		err := tx.InTransaction(ctx, func(nested backend.Backend) error {
+			require.NoError(t, nested.InsertDocument(ctx, "testdb", "values", doc(2)))
+			assert.Len(t, queryAll(t, nested, "values", nil), 2)
+			return expected
+		})
+		require.Equal(t, expected, err)
+
+		err = tx.InTransaction(ctx, func(nested backend.Backend) error {
+			return nested.InsertDocument(ctx, "testdb", "values", doc(3))
+		})
+		require.NoError(t, err)
+
+		assert.Equal(t, []*types.Document{doc(1), doc(3)}, queryAll(t, tx, "values", nil))
+
+		return nil
+	})
+	require.NoError(t, err)
+
+	assert.Equal(t, []*types.Document{doc(1), doc(3)}, queryAll(t, db, "values", nil))
+
+	err = db.InTransaction(ctx, func(tx backend.Backend) error {
+		require.NoError(t, tx.InsertDocument(ctx, "testdb", "values", doc(4)))
+		require.NoError(t, tx.CreateCollection(ctx, "testdb", "other"))
+		return expected
+	})
+	require.Equal(t, expected, err)
+
+	assert.Equal(t, []*types.Document{doc(1), doc(3)}, queryAll(t, db, "values", nil))
+
+	exists, err := db.CollectionExists(ctx, "testdb", "other")
+	require.NoError(t, err)
+	assert.False(t, exists)
+}
@@ -0,0 +1,65 @@
+// Copyright 2021 FerretDB Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package tigris
+
+import (
+	"errors"
+	"fmt"
+
+	api "github.com/tigrisdata/tigris-client-go/api/server/v1"
+	"github.com/tigrisdata/tigris-client-go/driver"
+
+	"github.com/FerretDB/FerretDB/internal/handlers/backend"
+)
+
+func init() {
+	backend.RegisterErrorTranslator(translateError)
+}
+
+// errorMapping represents backend error code and safe message for Tigris error.
+type errorMapping struct {
+	code backend.ErrorCode
+	msg  string
+}
+
+// tigrisErrors maps Tigris error codes to backend errors.
+var tigrisErrors = map[api.Code]errorMapping{
+	api.Code_DEADLINE_EXCEEDED:  {backend.ErrorCodeTimeout, "Tigris deadline exceeded"},
+	api.Code_PERMISSION_DENIED:  {backend.ErrorCodeUnauthorized, "Tigris permission denied"},
+	api.Code_UNAUTHENTICATED:    {backend.ErrorCodeAuthenticationFailed, "Tigris authentication failed"},
+	api.Code_RESOURCE_EXHAUSTED: {backend.ErrorCodeUnavailable, "Tigris resource exhausted"},
+	api.Code_UNAVAILABLE:        {backend.ErrorCodeUnavailable, "Tigris is not available"},
+	api.Code_BAD_GATEWAY:        {backend.ErrorCodeUnavailable, "Tigris is not available"},
+	api.Code_ABORTED:            {backend.ErrorCodeWriteConflict, "Tigris transaction aborted"},
+	api.Code_CONFLICT:           {backend.ErrorCodeWriteConflict, "Tigris transaction conflict"},
+}
+
+// translateError returns backend error for the error returned by Tigris, or nil.
+//
+// It implements backend.ErrorTranslator.
+func translateError(err error) *backend.Error {
+	var e *driver.Error
+	if !errors.As(err, &e) || e.TigrisError == nil {
+		return nil
+	}
+
+	if m, ok := tigrisErrors[e.Code]; ok {
+		return backend.NewError(m.code, m.msg, err)
+	}
+
+	msg := fmt.Sprintf("Tigris error (code %s)", e.Code)
+
+	return backend.NewError(backend.ErrorCodeInternal, msg, err)
+}
@@ -19,6 +19,7 @@ import (
 
 	"github.com/tigrisdata/tigris-client-go/driver"
 
+	"github.com/FerretDB/FerretDB/internal/handlers/common"
 	"github.com/FerretDB/FerretDB/internal/tjson"
 	"github.com/FerretDB/FerretDB/internal/types"
 	"github.com/FerretDB/FerretDB/internal/util/lazyerrors"
@@ -63,5 +64,10 @@ func (h *Handler) fetch(ctx context.Context, param fetchParam) ([]*types.Documen
 		res = append(res, doc.(*types.Document))
 	}
 
+	// filters are not pushed down yet
+	stats := common.GetOpStats(ctx)
+	stats.AddQuery(nil, nil)
+	stats.AddDocsExamined(int64(len(res)))
+
 	return res, iter.Err()
 }
@@ -46,6 +46,10 @@ func (h *Handler) MsgDelete(ctx context.Context, msg *wire.OpMsg) (*wire.OpMsg,
 		return nil, err
 	}
 
+	if err = common.CheckWriteBatchSize(deletes.Len()); err != nil {
+		return nil, err
+	}
+
 	var deleted int32
 	for i := 0; i < deletes.Len(); i++ {
 		d, err := common.AssertType[*types.Document](must.NotFail(deletes.Get(i)))
@@ -21,7 +21,6 @@ import (
 	"github.com/FerretDB/FerretDB/internal/handlers/common"
 	"github.com/FerretDB/FerretDB/internal/types"
 	"github.com/FerretDB/FerretDB/internal/util/lazyerrors"
-	"github.com/FerretDB/FerretDB/internal/util/must"
 	"github.com/FerretDB/FerretDB/internal/wire"
 )
 
@@ -33,12 +32,10 @@ func (h *Handler) MsgFind(ctx context.Context, msg *wire.OpMsg) (*wire.OpMsg, er
 	}
 
 	unimplementedFields := []string{
-		"skip",
 		"returnKey",
 		"showRecordId",
 		"tailable",
 		"oplogReplay",
-		"noCursorTimeout",
 		"awaitData",
 		"allowPartialResults",
 		"collation",
@@ -50,8 +47,6 @@ func (h *Handler) MsgFind(ctx context.Context, msg *wire.OpMsg) (*wire.OpMsg, er
 	}
 	ignoredFields := []string{
 		"hint",
-		"batchSize",
-		"singleBatch",
 		"maxTimeMS",
 		"readConcern",
 		"max",
@@ -77,6 +72,36 @@ func (h *Handler) MsgFind(ctx context.Context, msg *wire.OpMsg) (*wire.OpMsg, er
 		}
 	}
 
+	var skip int64
+	if s, _ := document.Get("skip"); s != nil {
+		if skip, err = common.GetWholeNumberParam(s); err != nil {
+			return nil, err
+		}
+		if skip < 0 {
+			return nil, common.NewErrorMsg(common.ErrBadValue, "SkipValue must be non-negative")
+		}
+	}
+
+	var batchSize int64
+	if b, _ := document.Get("batchSize"); b != nil {
+		if batchSize, err = common.GetWholeNumberParam(b); err != nil {
+			return nil, err
+		}
+		if batchSize < 0 {
+			return nil, common.NewErrorMsg(common.ErrBadValue, "BatchSize value must be non-negative")
+		}
+	}
+
+	singleBatch, err := common.GetBoolOptionalParam(document, "singleBatch")
+	if err != nil {
+		return nil, err
+	}
+
+	noCursorTimeout, err := common.GetBoolOptionalParam(document, "noCursorTimeout")
+	if err != nil {
+		return nil, err
+	}
+
 	var fp fetchParam
 	if fp.db, err = common.GetRequiredParam[string](document, "$db"); err != nil {
 		return nil, err
@@ -115,6 +140,9 @@ func (h *Handler) MsgFind(ctx context.Context, msg *wire.OpMsg) (*wire.OpMsg, er
 	if err = common.SortDocuments(resDocs, sort); err != nil {
 		return nil, err
 	}
+	if resDocs, err = common.SkipDocuments(resDocs, skip); err != nil {
+		return nil, err
+	}
 	if resDocs, err = common.LimitDocuments(resDocs, limit); err != nil {
 		return nil, err
 	}
@@ -122,24 +150,5 @@ func (h *Handler) MsgFind(ctx context.Context, msg *wire.OpMsg) (*wire.OpMsg, er
 		return nil, err
 	}
 
-	firstBatch := types.MakeArray(len(resDocs))
-	for _, doc := range resDocs {
-		if err = firstBatch.Append(doc); err != nil {
-			return nil, err
-		}
-	}
-
-	var reply wire.OpMsg
-	must.NoError(reply.SetSections(wire.OpMsgSection{
-		Documents: []*types.Document{must.NotFail(types.NewDocument(
-			"cursor", must.NotFail(types.NewDocument(
-				"firstBatch", firstBatch,
-				"id", int64(0), // TODO
-				"ns", fp.db+"."+fp.collection,
-			)),
-			"ok", float64(1),
-		))},
-	}))
-
-	return &reply, nil
+	return common.MakeCursorReply(ctx, fp.db+"."+fp.collection, resDocs, batchSize, singleBatch, noCursorTimeout)
 }
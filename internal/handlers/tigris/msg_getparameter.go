@@ -42,6 +42,8 @@ func (h *Handler) MsgGetParameter(ctx context.Context, msg *wire.OpMsg) (*wire.O
 		"acceptApiVersion2", false,
 		"authSchemaVersion", int32(5),
 		"quiet", false,
+		"redactClientLogData", wire.GetRedactMode() != wire.RedactNone,
+		"logComponentVerbosity", common.LogComponentVerbosity(),
 		"ok", float64(1),
 	))
 
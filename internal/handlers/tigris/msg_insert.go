@@ -59,6 +59,10 @@ func (h *Handler) MsgInsert(ctx context.Context, msg *wire.OpMsg) (*wire.OpMsg,
 		return nil, err
 	}
 
+	if err = common.CheckWriteBatchSize(docs.Len()); err != nil {
+		return nil, err
+	}
+
 	var inserted int32
 	for i := 0; i < docs.Len(); i++ {
 		doc, err := docs.Get(i)
@@ -66,6 +70,10 @@ func (h *Handler) MsgInsert(ctx context.Context, msg *wire.OpMsg) (*wire.OpMsg,
 			return nil, lazyerrors.Error(err)
 		}
 
+		if err = common.CheckInsertFieldNames(doc.(*types.Document)); err != nil {
+			return nil, err
+		}
+
 		err = h.insert(ctx, fp, doc.(*types.Document))
 		if err != nil {
 			return nil, lazyerrors.Error(err)
@@ -86,6 +94,10 @@ func (h *Handler) MsgInsert(ctx context.Context, msg *wire.OpMsg) (*wire.OpMsg,
 }
 
 func (h *Handler) insert(ctx context.Context, fp fetchParam, doc *types.Document) error {
+	if err := common.CheckInsertSize(doc); err != nil {
+		return err
+	}
+
 	// TODO https://github.com/FerretDB/FerretDB/issues/787
 	err := h.driver.CreateDatabase(ctx, fp.db)
 	if err != nil {
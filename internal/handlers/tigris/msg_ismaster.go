@@ -18,6 +18,7 @@ import (
 	"context"
 	"time"
 
+	"github.com/FerretDB/FerretDB/internal/handlers/common"
 	"github.com/FerretDB/FerretDB/internal/types"
 	"github.com/FerretDB/FerretDB/internal/util/lazyerrors"
 	"github.com/FerretDB/FerretDB/internal/util/must"
@@ -26,18 +27,29 @@ import (
 
 // MsgIsMaster implements HandlerInterface.
 func (h *Handler) MsgIsMaster(ctx context.Context, msg *wire.OpMsg) (*wire.OpMsg, error) {
-	if _, err := h.driver.Info(ctx); err != nil {
+	document, err := msg.Document()
+	if err != nil {
 		return nil, lazyerrors.Error(err)
 	}
 
+	if err = common.AwaitHello(ctx, document); err != nil {
+		return nil, err
+	}
+
+	if _, err = h.driver.Info(ctx); err != nil {
+		return nil, lazyerrors.Error(err)
+	}
+
+	limits := wire.GetLimits()
+
 	var reply wire.OpMsg
 	must.NoError(reply.SetSections(wire.OpMsgSection{
 		Documents: []*types.Document{must.NotFail(types.NewDocument(
 			"ismaster", true, // only lowercase
-			// topologyVersion
-			"maxBsonObjectSize", int32(types.MaxDocumentLen),
-			"maxMessageSizeBytes", int32(wire.MaxMsgLen),
-			"maxWriteBatchSize", int32(100000),
+			"topologyVersion", common.TopologyVersion(),
+			"maxBsonObjectSize", limits.MaxBSONObjectSize,
+			"maxMessageSizeBytes", limits.MaxMessageSizeBytes,
+			"maxWriteBatchSize", limits.MaxWriteBatchSize,
 			"localTime", time.Now(),
 			// logicalSessionTimeoutMinutes
 			// connectionId
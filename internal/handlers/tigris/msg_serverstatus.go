@@ -20,6 +20,7 @@ import (
 	"path/filepath"
 	"time"
 
+	"github.com/FerretDB/FerretDB/internal/handlers/common"
 	"github.com/FerretDB/FerretDB/internal/types"
 	"github.com/FerretDB/FerretDB/internal/util/lazyerrors"
 	"github.com/FerretDB/FerretDB/internal/util/must"
@@ -72,6 +73,9 @@ func (h *Handler) MsgServerStatus(ctx context.Context, msg *wire.OpMsg) (*wire.O
 			"freeMonitoring", must.NotFail(types.NewDocument(
 				"state", "disabled",
 			)),
+			"metrics", must.NotFail(types.NewDocument(
+				"cursor", common.CursorMetrics(),
+			)),
 			"ok", float64(1),
 		))},
 	}))
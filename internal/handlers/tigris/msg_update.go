@@ -63,6 +63,10 @@ func (h *Handler) MsgUpdate(ctx context.Context, msg *wire.OpMsg) (*wire.OpMsg,
 		return nil, err
 	}
 
+	if err = common.CheckWriteBatchSize(updates.Len()); err != nil {
+		return nil, err
+	}
+
 	// created, err := h.pgPool.CreateTableIfNotExist(ctx, fp.db, fp.collection)
 	// if err != nil {
 	// 	return nil, err
@@ -166,6 +170,14 @@ func (h *Handler) MsgUpdate(ctx context.Context, msg *wire.OpMsg) (*wire.OpMsg,
 				continue
 			}
 
+			if err = common.CheckUpdateSize(doc); err != nil {
+				return nil, err
+			}
+
+			if err = common.CheckUpdateFieldNames(doc); err != nil {
+				return nil, err
+			}
+
 			res, err := h.update(ctx, fp, doc)
 			if err != nil {
 				return nil, err
@@ -13,6 +13,11 @@
 // limitations under the License.
 
 // Package tigris provides Tigris handler.
+//
+// Unlike `pg`, `sqlite`, and `mysql` handlers, it does not use the generic handler yet:
+// Tigris collections require a JSON schema for their documents that is inferred from inserted documents,
+// and backend.Backend has no way to pass or evolve it.
+// Moving this handler on top of backend.Backend is tracked separately.
 package tigris
 
 import (
@@ -69,6 +74,36 @@ func (h *Handler) Close() {
 	h.driver.Close()
 }
 
+// Capabilities implements handlers.Interface.
+func (h *Handler) Capabilities() *handlers.Capabilities {
+	return &handlers.Capabilities{
+		Handler: "tigris",
+		Unsupported: map[handlers.Feature]string{
+			handlers.FeatureAuthentication:    "",
+			handlers.FeatureUserManagement:    "",
+			handlers.FeatureCount:             "https://github.com/FerretDB/FerretDB/issues/771",
+			handlers.FeatureFindAndModify:     "https://github.com/FerretDB/FerretDB/issues/775",
+			handlers.FeatureIndexes:           "https://github.com/FerretDB/FerretDB/issues/78",
+			handlers.FeatureCollStats:         "https://github.com/FerretDB/FerretDB/issues/770",
+			handlers.FeatureDataSize:          "https://github.com/FerretDB/FerretDB/issues/773",
+			handlers.FeatureDBStats:           "https://github.com/FerretDB/FerretDB/issues/774",
+			handlers.FeatureMigrateCollection: "",
+			handlers.FeatureDumpArchive:       "",
+			handlers.FeatureFCV:               "",
+			handlers.FeaturePartitioning:      "",
+		},
+	}
+}
+
+// Check implements handlers.Interface.
+func (h *Handler) Check(ctx context.Context) error {
+	if _, err := h.driver.Info(ctx); err != nil {
+		return lazyerrors.Error(err)
+	}
+
+	return nil
+}
+
 // check interfaces
 var (
 	_ handlers.Interface = (*Handler)(nil)
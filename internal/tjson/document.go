@@ -21,7 +21,6 @@ import (
 
 	"github.com/FerretDB/FerretDB/internal/types"
 	"github.com/FerretDB/FerretDB/internal/util/lazyerrors"
-	"github.com/FerretDB/FerretDB/internal/util/must"
 )
 
 // documentType represents BSON Document type.
@@ -67,7 +66,7 @@ func (doc *documentType) UnmarshalJSONWithSchema(data []byte, schema *Schema) er
 		)
 	}
 
-	td := must.NotFail(types.NewDocument())
+	td := types.MakeDocument(len(keys))
 	for _, key := range keys {
 		b, ok = rawMessages[key]
 		if !ok {
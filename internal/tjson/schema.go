@@ -218,6 +218,8 @@ func valueSchema(v any) (*Schema, error) {
 		return nil, lazyerrors.Errorf("%T is not supported yet", v)
 	case int64:
 		return int64Schema, nil
+	case types.UndefinedType, types.DBPointer, types.Symbol, types.Decimal128, types.JavaScript, types.JavaScriptWithScope:
+		return nil, lazyerrors.Errorf("%T is not supported yet", v)
 	default:
 		panic(fmt.Sprintf("not reached: %T", v))
 	}
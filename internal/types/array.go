@@ -80,6 +80,20 @@ func (a *Array) GetByPath(path Path) (any, error) {
 	return getByPath(a, path)
 }
 
+// HasByPath returns true if the given path is present in the array.
+func (a *Array) HasByPath(path Path) bool {
+	_, err := getByPath(a, path)
+	return err == nil
+}
+
+// SetByPath sets the value by path - a sequence of indexes and keys.
+//
+// Missing intermediate documents are created, and the array is padded with nulls if needed.
+// If the path can't be created, the returned error wraps ErrPathNotViable.
+func (a *Array) SetByPath(path Path, value any) error {
+	return setByPath(a, path, value)
+}
+
 // Set sets the value at the given index.
 func (a *Array) Set(index int, value any) error {
 	if l := a.Len(); index < 0 || index >= l {
@@ -14,6 +14,12 @@
 
 package types
 
+import (
+	"encoding/hex"
+	"fmt"
+	"strings"
+)
+
 //go:generate ../../bin/stringer -linecomment -type BinarySubtype
 
 // BinarySubtype represents BSON Binary's subtype.
@@ -50,3 +56,32 @@ type Binary struct {
 	Subtype BinarySubtype
 	B       []byte
 }
+
+// IsUUID returns true if binary value is a UUID: it has BinaryUUID subtype and 16 bytes.
+//
+// BinaryUUIDOld values are not considered UUIDs there because their byte order depends on the driver.
+func (bin Binary) IsUUID() bool {
+	return bin.Subtype == BinaryUUID && len(bin.B) == 16
+}
+
+// UUIDString returns UUID in the canonical "xxxxxxxx-xxxx-xxxx-xxxx-xxxxxxxxxxxx" form.
+//
+// It returns an empty string if binary value is not a UUID.
+func (bin Binary) UUIDString() string {
+	if !bin.IsUUID() {
+		return ""
+	}
+
+	h := hex.EncodeToString(bin.B)
+	return h[0:8] + "-" + h[8:12] + "-" + h[12:16] + "-" + h[16:20] + "-" + h[20:32]
+}
+
+// ParseUUID parses UUID in the canonical form (with or without dashes) into a binary value with BinaryUUID subtype.
+func ParseUUID(s string) (Binary, error) {
+	b, err := hex.DecodeString(strings.ReplaceAll(s, "-", ""))
+	if err != nil || len(b) != 16 {
+		return Binary{}, fmt.Errorf("types.ParseUUID: invalid UUID %q", s)
+	}
+
+	return Binary{Subtype: BinaryUUID, B: b}, nil
+}
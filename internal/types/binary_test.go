@@ -0,0 +1,65 @@
+// Copyright 2021 FerretDB Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package types
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/FerretDB/FerretDB/internal/util/must"
+)
+
+func TestBinaryUUID(t *testing.T) {
+	t.Parallel()
+
+	u, err := ParseUUID("00112233-4455-6677-8899-aabbccddeeff")
+	require.NoError(t, err)
+	assert.Equal(t, BinaryUUID, u.Subtype)
+	assert.True(t, u.IsUUID())
+	assert.Equal(t, "00112233-4455-6677-8899-aabbccddeeff", u.UUIDString())
+
+	same, err := ParseUUID("00112233445566778899AABBCCDDEEFF")
+	require.NoError(t, err)
+	assert.Equal(t, u, same)
+
+	for _, s := range []string{"", "0011", "00112233-4455-6677-8899-aabbccddeeXX"} {
+		_, err = ParseUUID(s)
+		assert.Error(t, err, "%q", s)
+	}
+
+	old := Binary{Subtype: BinaryUUIDOld, B: u.B}
+	assert.False(t, old.IsUUID())
+	assert.Empty(t, old.UUIDString())
+	assert.False(t, Binary{Subtype: BinaryUUID, B: []byte{1}}.IsUUID())
+}
+
+func TestCompareBinaryUUID(t *testing.T) {
+	t.Parallel()
+
+	u := must.NotFail(ParseUUID("00112233-4455-6677-8899-aabbccddeeff"))
+	u2 := must.NotFail(ParseUUID("00112233-4455-6677-8899-aabbccddef00"))
+	old := Binary{Subtype: BinaryUUIDOld, B: u.B}
+
+	// the same bytes with different subtypes are not equal; subtype is compared before bytes
+	assert.Equal(t, Equal, Compare(u, must.NotFail(ParseUUID(u.UUIDString()))))
+	assert.Equal(t, Less, Compare(old, u))
+	assert.Equal(t, Greater, Compare(u, old))
+	assert.Equal(t, Less, Compare(u, u2))
+
+	// length is compared first
+	assert.Equal(t, Less, Compare(Binary{Subtype: BinaryUser, B: []byte{0xff}}, old))
+}
@@ -99,16 +99,21 @@ func compareScalars(v1, v2 any) CompareResult {
 			return compareNumbers(v1, int64(v2))
 		case int64:
 			return compareNumbers(v1, v2)
+		case Decimal128:
+			return compareInvert(compareDecimal128(v2, v1))
 		default:
 			return Incomparable
 		}
 
 	case string:
-		v2, ok := v2.(string)
-		if ok {
+		switch v2 := v2.(type) {
+		case string:
 			return compareOrdered(v1, v2)
+		case Symbol:
+			return compareOrdered(v1, string(v2))
+		default:
+			return Incomparable
 		}
-		return Incomparable
 
 	case Binary:
 		v2, ok := v2.(Binary)
@@ -124,6 +129,13 @@ func compareScalars(v1, v2 any) CompareResult {
 		}
 		return CompareResult(bytes.Compare(v1.B, v2.B))
 
+	case UndefinedType:
+		_, ok := v2.(UndefinedType)
+		if ok {
+			return Equal
+		}
+		return Incomparable
+
 	case ObjectID:
 		v2, ok := v2.(ObjectID)
 		if !ok {
@@ -167,6 +179,37 @@ func compareScalars(v1, v2 any) CompareResult {
 		}
 		return Incomparable
 
+	case DBPointer:
+		v2, ok := v2.(DBPointer)
+		if ok {
+			return compareDBPointer(v1, v2)
+		}
+		return Incomparable
+
+	case JavaScript:
+		v2, ok := v2.(JavaScript)
+		if ok {
+			return compareOrdered(v1.Code, v2.Code)
+		}
+		return Incomparable
+
+	case Symbol:
+		switch v2 := v2.(type) {
+		case string:
+			return compareOrdered(string(v1), v2)
+		case Symbol:
+			return compareOrdered(v1, v2)
+		default:
+			return Incomparable
+		}
+
+	case JavaScriptWithScope:
+		v2, ok := v2.(JavaScriptWithScope)
+		if ok {
+			return compareJavaScriptWithScope(v1, v2)
+		}
+		return Incomparable
+
 	case int32:
 		switch v2 := v2.(type) {
 		case float64:
@@ -175,6 +218,8 @@ func compareScalars(v1, v2 any) CompareResult {
 			return compareOrdered(v1, v2)
 		case int64:
 			return compareOrdered(int64(v1), v2)
+		case Decimal128:
+			return compareInvert(compareDecimal128(v2, v1))
 		default:
 			return Incomparable
 		}
@@ -194,9 +239,14 @@ func compareScalars(v1, v2 any) CompareResult {
 			return compareOrdered(v1, int64(v2))
 		case int64:
 			return compareOrdered(v1, v2)
+		case Decimal128:
+			return compareInvert(compareDecimal128(v2, v1))
 		default:
 			return Incomparable
 		}
+
+	case Decimal128:
+		return compareDecimal128(v1, v2)
 	}
 
 	panic("not reached")
@@ -209,7 +259,8 @@ func isScalar(v any) bool {
 	}
 
 	switch v.(type) {
-	case float64, string, Binary, ObjectID, bool, time.Time, NullType, Regex, int32, Timestamp, int64:
+	case float64, string, Binary, UndefinedType, ObjectID, bool, time.Time, NullType, Regex, DBPointer,
+		JavaScript, Symbol, JavaScriptWithScope, int32, Timestamp, int64, Decimal128:
 		return true
 	}
 
@@ -259,6 +310,63 @@ func compareNumbers(a float64, b int64) CompareResult {
 	return CompareResult(bigA.Cmp(bigB))
 }
 
+// compareDecimal128 compares Decimal128 value with any BSON number exactly.
+// NaN is equal to NaN and incomparable with other numbers.
+func compareDecimal128(a Decimal128, b any) CompareResult {
+	aNaN, aInf, aRat := decimal128Parts(a)
+
+	var bNaN bool
+	var bInf int
+	var bRat *big.Rat
+
+	switch b := b.(type) {
+	case float64:
+		switch {
+		case math.IsNaN(b):
+			bNaN = true
+		case math.IsInf(b, 1):
+			bInf = 1
+		case math.IsInf(b, -1):
+			bInf = -1
+		default:
+			bRat = new(big.Rat).SetFloat64(b)
+		}
+	case int32:
+		bRat = new(big.Rat).SetInt64(int64(b))
+	case int64:
+		bRat = new(big.Rat).SetInt64(b)
+	case Decimal128:
+		bNaN, bInf, bRat = decimal128Parts(b)
+	default:
+		return Incomparable
+	}
+
+	switch {
+	case aNaN && bNaN:
+		return Equal
+	case aNaN || bNaN:
+		return Incomparable
+	case aInf != 0 || bInf != 0:
+		return compareOrdered(aInf, bInf)
+	default:
+		return CompareResult(aRat.Cmp(bRat))
+	}
+}
+
+// decimal128Parts returns NaN flag, infinity sign, or the value of the finite number.
+func decimal128Parts(d Decimal128) (nan bool, inf int, r *big.Rat) {
+	switch {
+	case d.IsNaN():
+		return true, 0, nil
+	case d.IsInf(1):
+		return false, 1, nil
+	case d.IsInf(-1):
+		return false, -1, nil
+	default:
+		return false, 0, d.rat()
+	}
+}
+
 // compareArrays compares indices of a filter array according to indices of a document array;
 // returns Equal when a document array contains another array(subarray) that equals filter array.
 func compareArrays(filterArr, docArr *Array) CompareResult {
@@ -324,7 +432,7 @@ func compareArrays(filterArr, docArr *Array) CompareResult {
 				}
 				continue
 			default:
-				res := CompareOrder(arrValue, filterValue, Ascending)
+				res := CompareOrderByValue(arrValue, filterValue)
 				if entireArrayResult == Incomparable && i == 0 { // set first non-Incomparable result
 					entireArrayResult = res
 				}
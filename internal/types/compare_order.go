@@ -27,9 +27,9 @@ import (
 // compareTypeOrderResult represents the comparison order of data types.
 type compareTypeOrderResult uint8
 
-// TODO: handle sorting for documentDataType and arrayDataType; https://github.com/FerretDB/FerretDB/issues/457
 const (
 	_ compareTypeOrderResult = iota
+	undefinedDataType
 	nullDataType
 	nanDataType
 	numbersDataType
@@ -42,11 +42,18 @@ const (
 	dateDataType
 	timestampDataType
 	regexDataType
+	dbPointerDataType
+	javaScriptDataType
+	javaScriptWithScopeDataType
 )
 
 // detectDataType returns a sequence for build-in type.
 func detectDataType(value any) compareTypeOrderResult {
 	switch value := value.(type) {
+	case *Document:
+		return documentDataType
+	case *Array:
+		return arrayDataType
 	case float64:
 		if math.IsNaN(value) {
 			return nanDataType
@@ -56,6 +63,8 @@ func detectDataType(value any) compareTypeOrderResult {
 		return stringDataType
 	case Binary:
 		return binDataType
+	case UndefinedType:
+		return undefinedDataType
 	case ObjectID:
 		return objectIDDataType
 	case bool:
@@ -66,12 +75,25 @@ func detectDataType(value any) compareTypeOrderResult {
 		return nullDataType
 	case Regex:
 		return regexDataType
+	case DBPointer:
+		return dbPointerDataType
+	case JavaScript:
+		return javaScriptDataType
+	case Symbol:
+		return stringDataType
+	case JavaScriptWithScope:
+		return javaScriptWithScopeDataType
 	case int32:
 		return numbersDataType
 	case Timestamp:
 		return timestampDataType
 	case int64:
 		return numbersDataType
+	case Decimal128:
+		if value.IsNaN() {
+			return nanDataType
+		}
+		return numbersDataType
 	default:
 		panic(fmt.Sprintf("value cannot be defined, value is %[1]v, data type of value is %[1]T", value))
 	}
@@ -86,9 +108,10 @@ const (
 	doubleDT
 	int32DT
 	int64DT
+	decimal128DT
 )
 
-// detectNumberType returns a sequence for float64, int32 and int64 types.
+// detectNumberType returns a sequence for float64, int32, int64, and Decimal128 types.
 func detectNumberType(value any) numberOrderResult {
 	switch value := value.(type) {
 	case float64:
@@ -100,6 +123,8 @@ func detectNumberType(value any) numberOrderResult {
 		return int32DT
 	case int64:
 		return int64DT
+	case Decimal128:
+		return decimal128DT
 	default:
 		panic(fmt.Sprintf("detectNumberType: value cannot be defined, value is %[1]v, data type of value is %[1]T", value))
 	}
@@ -118,16 +143,37 @@ const (
 
 // CompareOrder detects the data type for two values and compares them.
 // When the types are equal, it compares their values using Compare.
+//
+// Documents are compared field by field: first by the data type of values, then by field names, then by values.
+// Arrays are compared element by element. If all fields or elements are equal, the shorter value is less.
+// Unlike Compare, it never returns Incomparable.
+//
+// Numbers with equal values are ordered by their types to make sorting stable;
+// see CompareOrderByValue for the comparison without that.
 func CompareOrder(a, b any, order SortType) CompareResult {
+	if order != Ascending && order != Descending {
+		panic(fmt.Sprintf("CompareOrder: order is %v", order))
+	}
+
+	return compareOrder(a, b, order)
+}
+
+// CompareOrderByValue compares two values in the same way as CompareOrder,
+// but numbers of different types with equal values are equal.
+// It is used by $min and $max update operators.
+func CompareOrderByValue(a, b any) CompareResult {
+	return compareOrder(a, b, 0)
+}
+
+// compareOrder implements CompareOrder and CompareOrderByValue.
+// Zero order means that numbers are compared by values only.
+func compareOrder(a, b any, order SortType) CompareResult {
 	if a == nil {
 		panic("CompareOrder: a is nil")
 	}
 	if b == nil {
 		panic("CompareOrder: b is nil")
 	}
-	if order != Ascending && order != Descending {
-		panic(fmt.Sprintf("CompareOrder: order is %v", order))
-	}
 
 	aType := detectDataType(a)
 	bType := detectDataType(b)
@@ -136,15 +182,92 @@ func CompareOrder(a, b any, order SortType) CompareResult {
 		return Less
 	case aType > bType:
 		return Greater
+	case aType == documentDataType:
+		return compareDocumentsOrder(a.(*Document), b.(*Document), order)
+	case aType == arrayDataType:
+		return compareArraysOrder(a.(*Array), b.(*Array), order)
 	default:
 		res := Compare(a, b)
-		if res == Equal && aType == numbersDataType {
+		if res == Equal && aType == numbersDataType && order != 0 {
 			return compareNumberOrder(a, b, order)
 		}
 		return res
 	}
 }
 
+// CompareOrderForSort compares values of the sort key of two documents as MongoDB does it for sorting.
+//
+// Missing value (nil) is treated as null.
+// Array is represented by its minimal element for ascending order and by its maximal element for descending order;
+// empty array is less than any other value, including null.
+func CompareOrderForSort(a, b any, order SortType) CompareResult {
+	a, aEmpty := sortValue(a, order)
+	b, bEmpty := sortValue(b, order)
+
+	switch {
+	case aEmpty && bEmpty:
+		return Equal
+	case aEmpty:
+		return Less
+	case bEmpty:
+		return Greater
+	default:
+		return CompareOrder(a, b, order)
+	}
+}
+
+// sortValue returns the value used for sorting, or true if the value is an empty array.
+func sortValue(v any, order SortType) (any, bool) {
+	switch v := v.(type) {
+	case nil:
+		return Null, false
+	case *Array:
+		if v.Len() == 0 {
+			return nil, true
+		}
+		if order == Descending {
+			return v.Max(), false
+		}
+		return v.Min(), false
+	default:
+		return v, false
+	}
+}
+
+// compareDocumentsOrder compares two documents field by field.
+func compareDocumentsOrder(a, b *Document, order SortType) CompareResult {
+	aKeys, bKeys := a.Keys(), b.Keys()
+
+	for i := 0; i < len(aKeys) && i < len(bKeys); i++ {
+		aValue, bValue := a.m[aKeys[i]], b.m[bKeys[i]]
+
+		if res := compareOrdered(detectDataType(aValue), detectDataType(bValue)); res != Equal {
+			return res
+		}
+
+		if res := compareOrdered(aKeys[i], bKeys[i]); res != Equal {
+			return res
+		}
+
+		if res := compareOrder(aValue, bValue, order); res != Equal {
+			return res
+		}
+	}
+
+	return compareOrdered(len(aKeys), len(bKeys))
+}
+
+// compareArraysOrder compares two arrays element by element.
+func compareArraysOrder(a, b *Array, order SortType) CompareResult {
+	for i := 0; i < len(a.s) && i < len(b.s); i++ {
+		if res := compareOrder(a.s[i], b.s[i], order); res != Equal {
+			return res
+		}
+	}
+
+	return compareOrdered(len(a.s), len(b.s))
+}
+
 // compareNumberOrder detects the number type for two values and compares them.
 func compareNumberOrder(a, b any, order SortType) CompareResult {
 	if a == nil {
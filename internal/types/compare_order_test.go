@@ -0,0 +1,227 @@
+// Copyright 2021 FerretDB Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package types
+
+import (
+	"math"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/FerretDB/FerretDB/internal/util/must"
+)
+
+func TestCompareOrder(t *testing.T) {
+	t.Parallel()
+
+	for name, tc := range map[string]struct {
+		a, b     any
+		expected CompareResult
+	}{
+		"NullNumber": {
+			a:        Null,
+			b:        int32(0),
+			expected: Less,
+		},
+		"NaNNumber": {
+			a:        math.NaN(),
+			b:        math.Inf(-1),
+			expected: Less,
+		},
+		"NumberString": {
+			a:        int64(math.MaxInt64),
+			b:        "",
+			expected: Less,
+		},
+		"NumbersCrossType": {
+			a:        int32(2),
+			b:        1.5,
+			expected: Greater,
+		},
+		"NumbersTypeOrder": {
+			a:        int32(1),
+			b:        1.0,
+			expected: Greater,
+		},
+		"StringDocument": {
+			a:        "foo",
+			b:        must.NotFail(NewDocument()),
+			expected: Less,
+		},
+		"DocumentArray": {
+			a:        must.NotFail(NewDocument("foo", int32(1))),
+			b:        must.NotFail(NewArray()),
+			expected: Less,
+		},
+		"ArrayBinary": {
+			a:        must.NotFail(NewArray(int32(1))),
+			b:        Binary{},
+			expected: Less,
+		},
+		"DateTimestamp": {
+			a:        time.Date(2022, 1, 1, 0, 0, 0, 0, time.UTC),
+			b:        Timestamp(0),
+			expected: Less,
+		},
+		"DocumentsEqual": {
+			a:        must.NotFail(NewDocument("foo", int32(1), "bar", "baz")),
+			b:        must.NotFail(NewDocument("foo", int32(1), "bar", "baz")),
+			expected: Equal,
+		},
+		"DocumentsEmpty": {
+			a:        must.NotFail(NewDocument()),
+			b:        must.NotFail(NewDocument("foo", Null)),
+			expected: Less,
+		},
+		"DocumentsValueType": {
+			a:        must.NotFail(NewDocument("foo", "bar")),
+			b:        must.NotFail(NewDocument("foo", int32(1))),
+			expected: Greater,
+		},
+		"DocumentsFieldName": {
+			a:        must.NotFail(NewDocument("a", int32(2))),
+			b:        must.NotFail(NewDocument("b", int32(1))),
+			expected: Less,
+		},
+		"DocumentsTypeBeforeFieldName": {
+			a:        must.NotFail(NewDocument("a", "foo")),
+			b:        must.NotFail(NewDocument("b", int32(1))),
+			expected: Greater,
+		},
+		"DocumentsValue": {
+			a:        must.NotFail(NewDocument("foo", int32(1), "bar", int32(3))),
+			b:        must.NotFail(NewDocument("foo", int32(1), "bar", 2.5)),
+			expected: Greater,
+		},
+		"DocumentsPrefix": {
+			a:        must.NotFail(NewDocument("foo", int32(1), "bar", int32(2))),
+			b:        must.NotFail(NewDocument("foo", int32(1))),
+			expected: Greater,
+		},
+		"DocumentsNested": {
+			a:        must.NotFail(NewDocument("foo", must.NotFail(NewDocument("bar", int32(1))))),
+			b:        must.NotFail(NewDocument("foo", must.NotFail(NewDocument("bar", int32(2))))),
+			expected: Less,
+		},
+		"ArraysEqual": {
+			a:        must.NotFail(NewArray(int32(1), "foo")),
+			b:        must.NotFail(NewArray(int32(1), "foo")),
+			expected: Equal,
+		},
+		"ArraysElement": {
+			a:        must.NotFail(NewArray(int32(1), "foo")),
+			b:        must.NotFail(NewArray(int32(1), Null)),
+			expected: Greater,
+		},
+		"ArraysPrefix": {
+			a:        must.NotFail(NewArray(int32(1))),
+			b:        must.NotFail(NewArray(int32(1), int32(0))),
+			expected: Less,
+		},
+		"ArraysNested": {
+			a:        must.NotFail(NewArray(must.NotFail(NewArray(int32(1))))),
+			b:        must.NotFail(NewArray(must.NotFail(NewDocument()))),
+			expected: Greater,
+		},
+	} {
+		name, tc := name, tc
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			assert.Equal(t, tc.expected, CompareOrder(tc.a, tc.b, Ascending))
+			assert.Equal(t, compareInvert(tc.expected), CompareOrder(tc.b, tc.a, Ascending))
+		})
+	}
+}
+
+func TestCompareOrderByValue(t *testing.T) {
+	t.Parallel()
+
+	assert.Equal(t, Equal, CompareOrderByValue(int32(1), 1.0))
+	assert.Equal(t, Equal, CompareOrderByValue(int64(0), math.Copysign(0, -1)))
+	assert.Equal(t, Less, CompareOrderByValue(int32(1), 1.5))
+	assert.Equal(t, Equal, CompareOrderByValue(
+		must.NotFail(NewDocument("foo", must.NotFail(NewArray(int32(1))))),
+		must.NotFail(NewDocument("foo", must.NotFail(NewArray(int64(1))))),
+	))
+	assert.Equal(t, Greater, CompareOrderByValue(must.NotFail(NewArray()), must.NotFail(NewDocument())))
+}
+
+func TestCompareOrderForSort(t *testing.T) {
+	t.Parallel()
+
+	empty := must.NotFail(NewArray())
+	arr := must.NotFail(NewArray(int32(2), "foo", Null))
+
+	for name, tc := range map[string]struct {
+		a, b       any
+		ascending  CompareResult
+		descending CompareResult
+	}{
+		"MissingNull": {
+			a:          nil,
+			b:          Null,
+			ascending:  Equal,
+			descending: Equal,
+		},
+		"MissingNumber": {
+			a:          nil,
+			b:          int32(1),
+			ascending:  Less,
+			descending: Less,
+		},
+		"EmptyArrayMissing": {
+			a:          empty,
+			b:          nil,
+			ascending:  Less,
+			descending: Less,
+		},
+		"EmptyArrays": {
+			a:          empty,
+			b:          must.NotFail(NewArray()),
+			ascending:  Equal,
+			descending: Equal,
+		},
+		"ArrayMinMax": {
+			a:          arr,
+			b:          int32(1),
+			ascending:  Less,    // null < 1
+			descending: Greater, // "foo" > 1
+		},
+		"ArrayNumber": {
+			a:          must.NotFail(NewArray(int32(3), int32(1))),
+			b:          int32(2),
+			ascending:  Less,
+			descending: Greater,
+		},
+		"Arrays": {
+			a:          arr,
+			b:          must.NotFail(NewArray(int32(5), "bar")),
+			ascending:  Less,    // null < 5
+			descending: Greater, // "foo" > "bar"
+		},
+	} {
+		name, tc := name, tc
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			assert.Equal(t, tc.ascending, CompareOrderForSort(tc.a, tc.b, Ascending))
+			assert.Equal(t, compareInvert(tc.ascending), CompareOrderForSort(tc.b, tc.a, Ascending))
+			assert.Equal(t, tc.descending, CompareOrderForSort(tc.a, tc.b, Descending))
+			assert.Equal(t, compareInvert(tc.descending), CompareOrderForSort(tc.b, tc.a, Descending))
+		})
+	}
+}
@@ -0,0 +1,34 @@
+// Copyright 2021 FerretDB Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package types
+
+// DBPointer represents deprecated BSON type DBPointer.
+type DBPointer struct {
+	Namespace string
+	ID        ObjectID
+}
+
+// compareDBPointer compares namespace lengths first, then namespaces and ObjectIDs bytes, like MongoDB does.
+func compareDBPointer(a, b DBPointer) CompareResult {
+	if res := compareOrdered(len(a.Namespace), len(b.Namespace)); res != Equal {
+		return res
+	}
+
+	if res := compareOrdered(a.Namespace, b.Namespace); res != Equal {
+		return res
+	}
+
+	return compareOrdered(string(a.ID[:]), string(b.ID[:]))
+}
@@ -0,0 +1,38 @@
+// Copyright 2021 FerretDB Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package types
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCompareDBPointer(t *testing.T) {
+	t.Parallel()
+
+	a := DBPointer{Namespace: "db.b", ID: ObjectID{0x01}}
+
+	assert.Equal(t, Equal, Compare(a, DBPointer{Namespace: "db.b", ID: ObjectID{0x01}}))
+	assert.Equal(t, Greater, Compare(a, DBPointer{Namespace: "db.a", ID: ObjectID{0x02}}))
+	assert.Equal(t, Less, Compare(a, DBPointer{Namespace: "db.b", ID: ObjectID{0x02}}))
+
+	// shorter namespace goes first regardless of its content
+	assert.Equal(t, Greater, Compare(a, DBPointer{Namespace: "z.z", ID: ObjectID{0x01}}))
+
+	assert.Equal(t, Incomparable, Compare(a, a.ID))
+	assert.Equal(t, Greater, CompareOrder(a, Regex{Pattern: "a"}, Ascending))
+	assert.Equal(t, Less, CompareOrder(a, JavaScript{Code: "a"}, Ascending))
+}
@@ -0,0 +1,408 @@
+// Copyright 2021 FerretDB Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package types
+
+import (
+	"encoding/binary"
+	"fmt"
+	"math"
+	"math/big"
+	"strconv"
+	"strings"
+
+	"github.com/FerretDB/FerretDB/internal/util/must"
+)
+
+// Decimal128 represents BSON type Decimal128 - IEEE 754-2008 128-bit decimal floating point number
+// with binary integer decimal encoding.
+//
+// H and L are the high and low 64 bits of the value.
+type Decimal128 struct {
+	H uint64
+	L uint64
+}
+
+const (
+	// decimal128MaxDigits is the maximum number of decimal digits in the coefficient.
+	decimal128MaxDigits = 34
+
+	// decimal128ExponentBias is the exponent bias.
+	decimal128ExponentBias = 6176
+
+	// decimal128MinExponent and decimal128MaxExponent are the minimum and maximum exponents.
+	decimal128MinExponent = -6176
+	decimal128MaxExponent = 6111
+
+	decimal128SignBit = uint64(1) << 63
+	decimal128InfBits = uint64(0x78) << 56
+	decimal128NaNBits = uint64(0x7c) << 56
+)
+
+var (
+	// decimal128NaN is the canonical NaN value.
+	decimal128NaN = Decimal128{H: decimal128NaNBits}
+
+	// decimal128Inf is the positive infinity value.
+	decimal128Inf = Decimal128{H: decimal128InfBits}
+
+	// big10 is used for coefficients scaling.
+	big10 = big.NewInt(10)
+
+	// decimal128MaxCoefficient is the maximum coefficient value, 10^34 - 1.
+	decimal128MaxCoefficient = new(big.Int).Sub(new(big.Int).Exp(big10, big.NewInt(decimal128MaxDigits), nil), big.NewInt(1))
+)
+
+// ParseDecimal128 parses Decimal128 value from the string representation
+// like "1.23", "-1E+10", "Infinity", or "NaN".
+//
+// Values with more than 34 significant digits are rounded half to even.
+func ParseDecimal128(s string) (Decimal128, error) {
+	orig := s
+
+	var neg bool
+	switch {
+	case strings.HasPrefix(s, "-"):
+		neg = true
+		s = s[1:]
+	case strings.HasPrefix(s, "+"):
+		s = s[1:]
+	}
+
+	switch strings.ToLower(s) {
+	case "inf", "infinity":
+		return decimal128Special(neg, decimal128Inf), nil
+	case "nan":
+		return decimal128NaN, nil
+	}
+
+	mantissa := s
+	var exp int
+	if i := strings.IndexAny(s, "eE"); i >= 0 {
+		var err error
+		if exp, err = strconv.Atoi(s[i+1:]); err != nil {
+			return Decimal128{}, fmt.Errorf("types.ParseDecimal128: invalid exponent in %q", orig)
+		}
+		mantissa = s[:i]
+	}
+
+	intPart, fracPart, _ := strings.Cut(mantissa, ".")
+	digits := intPart + fracPart
+	if digits == "" || strings.Trim(digits, "0123456789") != "" {
+		return Decimal128{}, fmt.Errorf("types.ParseDecimal128: invalid value %q", orig)
+	}
+
+	coef, _ := new(big.Int).SetString(digits, 10)
+
+	return newDecimal128(neg, coef, exp-len(fracPart)), nil
+}
+
+// NewDecimal128FromInt64 returns Decimal128 value equal to the given integer.
+func NewDecimal128FromInt64(v int64) Decimal128 {
+	coef := big.NewInt(v)
+	return newDecimal128(v < 0, coef.Abs(coef), 0)
+}
+
+// NewDecimal128FromFloat64 returns Decimal128 value for the given float64 value
+// rounded to 15 significant digits, like MongoDB does.
+func NewDecimal128FromFloat64(v float64) Decimal128 {
+	switch {
+	case math.IsNaN(v):
+		return decimal128NaN
+	case math.IsInf(v, 0):
+		return decimal128Special(v < 0, decimal128Inf)
+	}
+
+	return must.NotFail(ParseDecimal128(strconv.FormatFloat(v, 'E', 14, 64)))
+}
+
+// decimal128Special returns the given special value with the given sign.
+func decimal128Special(neg bool, d Decimal128) Decimal128 {
+	if neg {
+		d.H |= decimal128SignBit
+	}
+
+	return d
+}
+
+// newDecimal128 returns Decimal128 value for the given sign, non-negative coefficient, and exponent.
+//
+// The coefficient is rounded half to even to 34 digits, and the exponent is clamped to the valid range;
+// too large values are converted to infinity, too small - rounded to zero.
+func newDecimal128(neg bool, coef *big.Int, exp int) Decimal128 {
+	coef = new(big.Int).Set(coef)
+
+	if digits := len(coef.String()); digits > decimal128MaxDigits {
+		coef = roundHalfEven(coef, digits-decimal128MaxDigits)
+		exp += digits - decimal128MaxDigits
+
+		// rounding could add one more digit, like 9.99 -> 10.0
+		if coef.Cmp(decimal128MaxCoefficient) > 0 {
+			coef = roundHalfEven(coef, 1)
+			exp++
+		}
+	}
+
+	if exp < decimal128MinExponent {
+		shift := decimal128MinExponent - exp
+		if shift > decimal128MaxDigits+1 {
+			coef.SetInt64(0)
+		} else {
+			coef = roundHalfEven(coef, shift)
+		}
+		exp = decimal128MinExponent
+	}
+
+	for exp > decimal128MaxExponent {
+		if coef.Sign() == 0 {
+			exp = decimal128MaxExponent
+			break
+		}
+
+		next := new(big.Int).Mul(coef, big10)
+		if next.Cmp(decimal128MaxCoefficient) > 0 {
+			return decimal128Special(neg, decimal128Inf)
+		}
+
+		coef = next
+		exp--
+	}
+
+	var res Decimal128
+	if neg {
+		res.H = decimal128SignBit
+	}
+
+	b := coef.FillBytes(make([]byte, 16))
+	hi, lo := binary.BigEndian.Uint64(b[:8]), binary.BigEndian.Uint64(b[8:])
+
+	res.H |= uint64(exp+decimal128ExponentBias)<<49 | hi
+	res.L = lo
+
+	return res
+}
+
+// roundHalfEven divides v by 10^n and rounds the result half to even.
+func roundHalfEven(v *big.Int, n int) *big.Int {
+	div := new(big.Int).Exp(big10, big.NewInt(int64(n)), nil)
+	q, r := new(big.Int).QuoRem(v, div, new(big.Int))
+
+	switch r.Mul(r, big.NewInt(2)).Cmp(div) {
+	case 1:
+		q.Add(q, big.NewInt(1))
+	case 0:
+		if q.Bit(0) == 1 {
+			q.Add(q, big.NewInt(1))
+		}
+	}
+
+	return q
+}
+
+// IsNaN returns true if d is NaN.
+func (d Decimal128) IsNaN() bool {
+	return d.H&decimal128NaNBits == decimal128NaNBits
+}
+
+// IsInf returns true if d is an infinity with the given sign.
+// If sign > 0, IsInf checks for positive infinity; if sign < 0, for negative infinity;
+// if sign == 0, for either infinity.
+func (d Decimal128) IsInf(sign int) bool {
+	if d.H&decimal128NaNBits != decimal128InfBits {
+		return false
+	}
+
+	neg := d.H&decimal128SignBit != 0
+	return sign == 0 || (sign > 0 && !neg) || (sign < 0 && neg)
+}
+
+// parts returns sign, coefficient, and exponent of the finite value.
+func (d Decimal128) parts() (neg bool, coef *big.Int, exp int) {
+	neg = d.H&decimal128SignBit != 0
+
+	// coefficients with "11" combination bits are always larger than the maximum, so they are treated as zero
+	if d.H>>61&3 == 3 {
+		return neg, new(big.Int), int(d.H>>47&0x3fff) - decimal128ExponentBias
+	}
+
+	exp = int(d.H>>49&0x3fff) - decimal128ExponentBias
+
+	coef = new(big.Int).SetUint64(d.H & (1<<49 - 1))
+	coef.Lsh(coef, 64)
+	coef.Or(coef, new(big.Int).SetUint64(d.L))
+
+	if coef.Cmp(decimal128MaxCoefficient) > 0 {
+		coef.SetInt64(0)
+	}
+
+	return neg, coef, exp
+}
+
+// rat returns the value of the finite d as a rational number.
+func (d Decimal128) rat() *big.Rat {
+	neg, coef, exp := d.parts()
+
+	res := new(big.Rat).SetInt(coef)
+	scale := new(big.Rat).SetInt(new(big.Int).Exp(big10, big.NewInt(int64(abs(exp))), nil))
+	if exp >= 0 {
+		res.Mul(res, scale)
+	} else {
+		res.Quo(res, scale)
+	}
+
+	if neg {
+		res.Neg(res)
+	}
+
+	return res
+}
+
+// abs returns the absolute value of v.
+func abs(v int) int {
+	if v < 0 {
+		return -v
+	}
+
+	return v
+}
+
+// String returns the string representation of d as defined by the BSON Decimal128 specification.
+//
+// It implements fmt.Stringer interface.
+func (d Decimal128) String() string {
+	neg := d.H&decimal128SignBit != 0
+
+	var res string
+	switch {
+	case d.IsNaN():
+		return "NaN"
+
+	case d.IsInf(0):
+		res = "Infinity"
+
+	default:
+		_, coef, exp := d.parts()
+		digits := coef.String()
+		adjusted := exp + len(digits) - 1
+
+		switch {
+		case exp > 0 || adjusted < -6:
+			res = digits[:1]
+			if len(digits) > 1 {
+				res += "." + digits[1:]
+			}
+			res += fmt.Sprintf("E%+d", adjusted)
+
+		case exp == 0:
+			res = digits
+
+		default:
+			if point := len(digits) + exp; point > 0 {
+				res = digits[:point] + "." + digits[point:]
+			} else {
+				res = "0." + strings.Repeat("0", -point) + digits
+			}
+		}
+	}
+
+	if neg {
+		res = "-" + res
+	}
+
+	return res
+}
+
+// Float64 returns the nearest float64 value.
+func (d Decimal128) Float64() float64 {
+	switch {
+	case d.IsNaN():
+		return math.NaN()
+	case d.IsInf(1):
+		return math.Inf(1)
+	case d.IsInf(-1):
+		return math.Inf(-1)
+	}
+
+	f, _ := strconv.ParseFloat(d.String(), 64)
+
+	return f
+}
+
+// Add returns the sum of d and v.
+func (d Decimal128) Add(v Decimal128) Decimal128 {
+	switch {
+	case d.IsNaN() || v.IsNaN():
+		return decimal128NaN
+	case d.IsInf(0) && v.IsInf(0):
+		if d.H&decimal128SignBit != v.H&decimal128SignBit {
+			return decimal128NaN
+		}
+		return d
+	case d.IsInf(0):
+		return d
+	case v.IsInf(0):
+		return v
+	}
+
+	dNeg, dCoef, dExp := d.parts()
+	vNeg, vCoef, vExp := v.parts()
+
+	// align exponents
+	exp := dExp
+	if vExp < exp {
+		exp = vExp
+	}
+
+	dCoef.Mul(dCoef, new(big.Int).Exp(big10, big.NewInt(int64(dExp-exp)), nil))
+	vCoef.Mul(vCoef, new(big.Int).Exp(big10, big.NewInt(int64(vExp-exp)), nil))
+
+	if dNeg {
+		dCoef.Neg(dCoef)
+	}
+	if vNeg {
+		vCoef.Neg(vCoef)
+	}
+
+	sum := dCoef.Add(dCoef, vCoef)
+
+	// the sum of zeros is negative only if both are negative
+	neg := sum.Sign() < 0 || (sum.Sign() == 0 && dNeg && vNeg)
+
+	return newDecimal128(neg, sum.Abs(sum), exp)
+}
+
+// Mul returns the product of d and v.
+func (d Decimal128) Mul(v Decimal128) Decimal128 {
+	neg := d.H&decimal128SignBit != v.H&decimal128SignBit
+
+	switch {
+	case d.IsNaN() || v.IsNaN():
+		return decimal128NaN
+	case d.IsInf(0) || v.IsInf(0):
+		if (!d.IsInf(0) && d.rat().Sign() == 0) || (!v.IsInf(0) && v.rat().Sign() == 0) {
+			return decimal128NaN
+		}
+		return decimal128Special(neg, decimal128Inf)
+	}
+
+	_, dCoef, dExp := d.parts()
+	_, vCoef, vExp := v.parts()
+
+	return newDecimal128(neg, dCoef.Mul(dCoef, vCoef), dExp+vExp)
+}
+
+// check interfaces
+var (
+	_ fmt.Stringer = Decimal128{}
+)
@@ -0,0 +1,114 @@
+// Copyright 2021 FerretDB Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package types
+
+import (
+	"math"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDecimal128(t *testing.T) {
+	t.Parallel()
+
+	// test vectors from the BSON corpus and the decimal128 specification
+	for _, tc := range []struct {
+		s string
+		d Decimal128
+		c string // canonical string, equal to s if empty
+	}{
+		{s: "0", d: Decimal128{H: 0x3040000000000000, L: 0}},
+		{s: "-0", d: Decimal128{H: 0xb040000000000000, L: 0}},
+		{s: "1", d: Decimal128{H: 0x3040000000000000, L: 1}},
+		{s: "-1", d: Decimal128{H: 0xb040000000000000, L: 1}},
+		{s: "0.1", d: Decimal128{H: 0x303e000000000000, L: 1}},
+		{s: "0.001234", d: Decimal128{H: 0x3034000000000000, L: 0x4d2}},
+		{s: "123456789012", d: Decimal128{H: 0x3040000000000000, L: 0x1cbe991a14}},
+		{s: "1.000000000000000000000000000000000", d: Decimal128{H: 0x2ffe314dc6448d93, L: 0x38c15b0a00000000}},
+		{s: "1E+3", d: Decimal128{H: 0x3046000000000000, L: 1}},
+		{s: "1E-10", d: Decimal128{H: 0x302c000000000000, L: 1}},
+		{s: "1.5e3", d: Decimal128{H: 0x3044000000000000, L: 15}, c: "1.5E+3"},
+		{s: "12345689012345789012345", d: Decimal128{H: 0x304000000000029d, L: 0x42da3a76f9e0d979}},
+		{s: "NaN", d: Decimal128{H: 0x7c00000000000000, L: 0}},
+		{s: "Infinity", d: Decimal128{H: 0x7800000000000000, L: 0}},
+		{s: "-Infinity", d: Decimal128{H: 0xf800000000000000, L: 0}},
+		{s: "-inf", d: Decimal128{H: 0xf800000000000000, L: 0}, c: "-Infinity"},
+	} {
+		tc := tc
+		t.Run(tc.s, func(t *testing.T) {
+			t.Parallel()
+
+			d, err := ParseDecimal128(tc.s)
+			require.NoError(t, err)
+			assert.Equal(t, tc.d, d)
+
+			expected := tc.c
+			if expected == "" {
+				expected = tc.s
+			}
+			assert.Equal(t, expected, d.String())
+		})
+	}
+
+	for _, s := range []string{"", "-", "1e", "1.2.3", "abc", "1e+x"} {
+		_, err := ParseDecimal128(s)
+		assert.Error(t, err, "%q", s)
+	}
+}
+
+func TestDecimal128Arithmetic(t *testing.T) {
+	t.Parallel()
+
+	parse := func(s string) Decimal128 {
+		d, err := ParseDecimal128(s)
+		require.NoError(t, err)
+		return d
+	}
+
+	assert.Equal(t, "0.3", parse("0.1").Add(parse("0.2")).String())
+	assert.Equal(t, "10.00", parse("9.99").Add(parse("0.01")).String())
+	assert.Equal(t, "0.02", parse("0.1").Mul(parse("0.2")).String())
+	assert.Equal(t, "-6.0", parse("2").Mul(parse("-3.0")).String())
+	assert.True(t, parse("NaN").Add(parse("1")).IsNaN())
+	assert.True(t, parse("Infinity").Add(parse("-Infinity")).IsNaN())
+	assert.True(t, parse("Infinity").Mul(parse("0")).IsNaN())
+	assert.True(t, parse("9.999999999999999999999999999999999E+6144").Mul(parse("10")).IsInf(1))
+
+	assert.Equal(t, "42", NewDecimal128FromInt64(42).String())
+	assert.Equal(t, "0.100000000000000", NewDecimal128FromFloat64(0.1).String()) // 15 significant digits, as MongoDB does
+	assert.Equal(t, 0.1, parse("0.1").Float64())
+	assert.True(t, math.IsInf(parse("-Infinity").Float64(), -1))
+}
+
+func TestCompareDecimal128(t *testing.T) {
+	t.Parallel()
+
+	parse := func(s string) Decimal128 {
+		d, err := ParseDecimal128(s)
+		require.NoError(t, err)
+		return d
+	}
+
+	assert.Equal(t, Equal, Compare(parse("1.0"), int32(1)))
+	assert.Equal(t, Equal, Compare(int64(1), parse("1.00")))
+	assert.Equal(t, Less, Compare(parse("0.1"), 0.1)) // 0.1 as float64 is slightly greater
+	assert.Equal(t, Greater, Compare(parse("Infinity"), math.MaxFloat64))
+	assert.Equal(t, Less, Compare(math.Inf(-1), parse("-1E+6000")))
+	assert.Equal(t, Equal, Compare(parse("NaN"), math.NaN()))
+	assert.Equal(t, Incomparable, Compare(parse("NaN"), int32(0)))
+	assert.Equal(t, Equal, Compare(parse("-0"), 0.0))
+}
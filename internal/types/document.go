@@ -16,6 +16,7 @@ package types
 
 import (
 	"fmt"
+	"sync"
 	"unicode/utf8"
 
 	"golang.org/x/exp/slices"
@@ -58,6 +59,21 @@ func ConvertDocument(d document) (*Document, error) {
 	return doc, nil
 }
 
+// MakeDocument creates an empty document with set capacity.
+//
+// It should be used instead of NewDocument when the number of fields is known in advance,
+// for example, when document is built field-by-field with Set while decoding.
+func MakeDocument(capacity int) *Document {
+	if capacity == 0 {
+		return new(Document)
+	}
+
+	return &Document{
+		m:    make(map[string]any, capacity),
+		keys: make([]string, 0, capacity),
+	}
+}
+
 // NewDocument creates a document with the given key/value pairs.
 func NewDocument(pairs ...any) (*Document, error) {
 	l := len(pairs)
@@ -69,26 +85,20 @@ func NewDocument(pairs ...any) (*Document, error) {
 		return new(Document), nil
 	}
 
-	doc := &Document{
-		m:    make(map[string]any, l/2),
-		keys: make([]string, 0, l/2),
-	}
+	doc := MakeDocument(l / 2)
 	for i := 0; i < l; i += 2 {
 		key, ok := pairs[i].(string)
 		if !ok {
 			return nil, fmt.Errorf("types.NewDocument: invalid key type: %T", pairs[i])
 		}
 
+		// add performs the same checks as validate, so there is no need to call it at the end
 		value := pairs[i+1]
 		if err := doc.add(key, value); err != nil {
 			return nil, fmt.Errorf("types.NewDocument: %w", err)
 		}
 	}
 
-	if err := doc.validate(); err != nil {
-		return nil, fmt.Errorf("types.NewDocument: %w", err)
-	}
-
 	return doc, nil
 }
 
@@ -109,13 +119,29 @@ func isValidKey(key string) bool {
 	}
 
 	// forbid keys like $k (used by fjson representation), but allow $db (used by many commands)
-	if key[0] == '$' && len(key) <= 2 {
+	// and $v (used by update oplog entries, see applyOps)
+	if key[0] == '$' && len(key) <= 2 && key != "$v" {
 		return false
 	}
 
 	return utf8.ValidString(key)
 }
 
+// smallDocumentLen is the maximum number of fields in the document
+// for which duplicate keys are detected by the linear search instead of the map.
+const smallDocumentLen = 16
+
+// maxPooledKeysSetLen is the maximum number of keys in the set that could be returned to the pool.
+// Maps never shrink, so returning huge sets would keep memory allocated forever.
+const maxPooledKeysSetLen = 1024
+
+// keysSetPool contains sets used by validate for duplicate keys detection in large documents.
+var keysSetPool = sync.Pool{
+	New: func() any {
+		return make(map[string]struct{})
+	},
+}
+
 // validate checks if the document is valid.
 func (d *Document) validate() error {
 	if d == nil {
@@ -128,8 +154,21 @@ func (d *Document) validate() error {
 
 	// TODO check that _id is not regex or array
 
-	prevKeys := make(map[string]struct{}, len(d.keys))
-	for _, key := range d.keys {
+	var prevKeys map[string]struct{}
+	if len(d.keys) > smallDocumentLen {
+		prevKeys = keysSetPool.Get().(map[string]struct{})
+		defer func() {
+			if len(prevKeys) > maxPooledKeysSetLen {
+				return
+			}
+			for k := range prevKeys {
+				delete(prevKeys, k)
+			}
+			keysSetPool.Put(prevKeys)
+		}()
+	}
+
+	for i, key := range d.keys {
 		if !isValidKey(key) {
 			return fmt.Errorf("types.Document.validate: invalid key: %q", key)
 		}
@@ -139,10 +178,16 @@ func (d *Document) validate() error {
 			return fmt.Errorf("types.Document.validate: key not found: %q", key)
 		}
 
-		if _, ok := prevKeys[key]; ok {
-			return fmt.Errorf("types.Document.validate: duplicate key: %q", key)
+		if prevKeys == nil {
+			if slices.Contains(d.keys[:i], key) {
+				return fmt.Errorf("types.Document.validate: duplicate key: %q", key)
+			}
+		} else {
+			if _, ok := prevKeys[key]; ok {
+				return fmt.Errorf("types.Document.validate: duplicate key: %q", key)
+			}
+			prevKeys[key] = struct{}{}
 		}
-		prevKeys[key] = struct{}{}
 
 		if err := validateValue(value); err != nil {
 			return fmt.Errorf("types.Document.validate: %w", err)
@@ -243,6 +288,20 @@ func (d *Document) GetByPath(path Path) (any, error) {
 	return getByPath(d, path)
 }
 
+// HasByPath returns true if the given path is present in the document.
+func (d *Document) HasByPath(path Path) bool {
+	_, err := getByPath(d, path)
+	return err == nil
+}
+
+// SetByPath sets the value by path - a sequence of indexes and keys.
+//
+// Missing intermediate documents are created. If the path can't be created,
+// the returned error wraps ErrPathNotViable.
+func (d *Document) SetByPath(path Path, value any) error {
+	return setByPath(d, path, value)
+}
+
 // Set sets the value for the given key, replacing any existing value.
 //
 // As a special case, _id always becomes the first key.
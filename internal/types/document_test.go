@@ -19,6 +19,7 @@ import (
 	"testing"
 
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 
 	"github.com/FerretDB/FerretDB/internal/util/must"
 )
@@ -168,4 +169,141 @@ func TestDocument(t *testing.T) {
 			})
 		}
 	})
+
+	t.Run("ValidateLarge", func(t *testing.T) {
+		t.Parallel()
+
+		// large enough to use keys set from the pool instead of the linear search
+		n := smallDocumentLen * 2
+
+		doc := Document{
+			keys: make([]string, n),
+			m:    make(map[string]any, n),
+		}
+		for i := 0; i < n; i++ {
+			key := fmt.Sprint(i)
+			doc.keys[i] = key
+			doc.m[key] = int32(i)
+		}
+		assert.NoError(t, doc.validate())
+
+		// the same set could be reused by the next call
+		doc.keys[n-1] = "0"
+		doc.m["dup"] = doc.m[fmt.Sprint(n-1)]
+		delete(doc.m, fmt.Sprint(n-1))
+		assert.Equal(t, fmt.Errorf(`types.Document.validate: duplicate key: "0"`), doc.validate())
+	})
+
+	t.Run("MakeDocument", func(t *testing.T) {
+		t.Parallel()
+
+		doc := MakeDocument(0)
+		assert.Equal(t, new(Document), doc)
+
+		doc = MakeDocument(2)
+		assert.Equal(t, 0, doc.Len())
+		assert.Equal(t, 2, cap(doc.keys))
+
+		require.NoError(t, doc.Set("foo", "bar"))
+		require.NoError(t, doc.Set("_id", int32(42)))
+		assert.Equal(t, []string{"_id", "foo"}, doc.Keys())
+	})
+}
+
+// benchmarkPairs returns key/value pairs for a document with n fields.
+func benchmarkPairs(n int) []any {
+	pairs := make([]any, 0, n*2+2)
+	pairs = append(pairs, "_id", ObjectID{0x62, 0x56, 0xc5, 0xba, 0x0b, 0xad, 0xc0, 0xff, 0xee, 0xc0, 0xff, 0xee})
+	for i := 1; i < n; i++ {
+		pairs = append(pairs, fmt.Sprintf("field%d", i), int32(i))
+	}
+	return pairs
+}
+
+func BenchmarkNewDocument(b *testing.B) {
+	for _, n := range []int{10, 100} {
+		pairs := benchmarkPairs(n)
+
+		b.Run(fmt.Sprint(n), func(b *testing.B) {
+			b.ReportAllocs()
+
+			var doc *Document
+			var err error
+			b.ResetTimer()
+
+			for i := 0; i < b.N; i++ {
+				doc, err = NewDocument(pairs...)
+			}
+
+			b.StopTimer()
+
+			require.NoError(b, err)
+			assert.Equal(b, n, doc.Len())
+		})
+	}
+}
+
+func BenchmarkDocumentSet(b *testing.B) {
+	for _, n := range []int{10, 100} {
+		pairs := benchmarkPairs(n)
+
+		b.Run(fmt.Sprint(n), func(b *testing.B) {
+			for _, tc := range []struct {
+				name    string
+				newFunc func() *Document
+			}{{
+				name:    "New",
+				newFunc: func() *Document { return new(Document) },
+			}, {
+				name:    "Make",
+				newFunc: func() *Document { return MakeDocument(n) },
+			}} {
+				tc := tc
+				b.Run(tc.name, func(b *testing.B) {
+					b.ReportAllocs()
+
+					var doc *Document
+					var err error
+					b.ResetTimer()
+
+					for i := 0; i < b.N; i++ {
+						doc = tc.newFunc()
+						for j := 0; j < len(pairs); j += 2 {
+							if err = doc.Set(pairs[j].(string), pairs[j+1]); err != nil {
+								b.Fatal(err)
+							}
+						}
+					}
+
+					b.StopTimer()
+
+					require.NoError(b, err)
+					assert.Equal(b, n, doc.Len())
+				})
+			}
+		})
+	}
+}
+
+func BenchmarkConvertDocument(b *testing.B) {
+	for _, n := range []int{10, 100} {
+		src := must.NotFail(NewDocument(benchmarkPairs(n)...))
+
+		b.Run(fmt.Sprint(n), func(b *testing.B) {
+			b.ReportAllocs()
+
+			var doc *Document
+			var err error
+			b.ResetTimer()
+
+			for i := 0; i < b.N; i++ {
+				doc, err = ConvertDocument(src)
+			}
+
+			b.StopTimer()
+
+			require.NoError(b, err)
+			assert.Equal(b, n, doc.Len())
+		})
+	}
 }
@@ -0,0 +1,60 @@
+// Copyright 2021 FerretDB Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package types
+
+// JavaScript represents BSON type JavaScript code.
+//
+// FerretDB does not execute JavaScript; values are only stored and returned as is.
+type JavaScript struct {
+	Code string
+}
+
+// JavaScriptWithScope represents deprecated BSON type JavaScript code with scope.
+//
+// FerretDB does not execute JavaScript; values are only stored and returned as is.
+type JavaScriptWithScope struct {
+	Code  string
+	Scope *Document
+}
+
+// compareJavaScriptWithScope compares code first, then scopes field by field.
+// Scope values that are documents or arrays make the result incomparable.
+func compareJavaScriptWithScope(a, b JavaScriptWithScope) CompareResult {
+	if res := compareOrdered(a.Code, b.Code); res != Equal {
+		return res
+	}
+
+	aKeys, bKeys := a.Scope.Keys(), b.Scope.Keys()
+	if res := compareOrdered(len(aKeys), len(bKeys)); res != Equal {
+		return res
+	}
+
+	aMap, bMap := a.Scope.Map(), b.Scope.Map()
+	for i, k := range aKeys {
+		if res := compareOrdered(k, bKeys[i]); res != Equal {
+			return res
+		}
+
+		if !isScalar(aMap[k]) || !isScalar(bMap[k]) {
+			return Incomparable
+		}
+
+		if res := compareScalars(aMap[k], bMap[k]); res != Equal {
+			return res
+		}
+	}
+
+	return Equal
+}
@@ -0,0 +1,52 @@
+// Copyright 2021 FerretDB Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package types
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/FerretDB/FerretDB/internal/util/must"
+)
+
+func TestCompareJavaScript(t *testing.T) {
+	t.Parallel()
+
+	assert.Equal(t, Equal, Compare(JavaScript{Code: "a"}, JavaScript{Code: "a"}))
+	assert.Equal(t, Less, Compare(JavaScript{Code: "a"}, JavaScript{Code: "b"}))
+	assert.Equal(t, Incomparable, Compare(JavaScript{Code: "a"}, "a"))
+
+	scope := func(pairs ...any) *Document {
+		return must.NotFail(NewDocument(pairs...))
+	}
+
+	a := JavaScriptWithScope{Code: "a", Scope: scope("x", int32(1))}
+	assert.Equal(t, Equal, Compare(a, JavaScriptWithScope{Code: "a", Scope: scope("x", int64(1))}))
+	assert.Equal(t, Less, Compare(a, JavaScriptWithScope{Code: "a", Scope: scope("x", int32(2))}))
+	assert.Equal(t, Greater, Compare(a, JavaScriptWithScope{Code: "a", Scope: scope()}))
+	assert.Equal(t, Less, Compare(a, JavaScriptWithScope{Code: "b", Scope: scope()}))
+	assert.Equal(t, Incomparable, Compare(a, JavaScriptWithScope{Code: "a", Scope: scope("x", scope())}))
+	assert.Equal(t, Incomparable, Compare(a, JavaScript{Code: "a"}))
+
+	// code with scope goes after code in sort order
+	assert.Less(t, detectDataType(JavaScript{}), detectDataType(a))
+	assert.Less(t, detectDataType(Regex{}), detectDataType(JavaScript{}))
+
+	// scope is copied
+	c := deepCopy(a).(JavaScriptWithScope)
+	must.NoError(c.Scope.Set("x", int32(3)))
+	assert.Equal(t, int32(1), must.NotFail(a.Scope.Get("x")))
+}
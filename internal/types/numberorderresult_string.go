@@ -12,11 +12,12 @@ func _() {
 	_ = x[doubleDT-2]
 	_ = x[int32DT-3]
 	_ = x[int64DT-4]
+	_ = x[decimal128DT-5]
 }
 
-const _numberOrderResult_name = "doubleNegativeZerodoubleDTint32DTint64DT"
+const _numberOrderResult_name = "doubleNegativeZerodoubleDTint32DTint64DTdecimal128DT"
 
-var _numberOrderResult_index = [...]uint8{0, 18, 26, 33, 40}
+var _numberOrderResult_index = [...]uint8{0, 18, 26, 33, 40, 52}
 
 func (i numberOrderResult) String() string {
 	i -= 1
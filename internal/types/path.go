@@ -20,8 +20,17 @@ import (
 	"strings"
 )
 
+// ErrPathNotViable indicates that the path can't be created because it goes through a scalar value,
+// or uses an element that is not an index for an array.
+var ErrPathNotViable = fmt.Errorf("path is not viable")
+
 // Path represents the field path type. It should be used wherever we work with paths or dot notation.
 // Path should be stored and passed as a value. Its methods return new values, not modifying the receiver's state.
+//
+// Path elements are interpreted depending on the value they are applied to.
+// For documents, every element is a field name, including numeric ones like "0".
+// For arrays, every element should be an index - a non-negative decimal number without sign;
+// other elements are not found by Get/Has/Remove and are not viable for Set.
 type Path struct {
 	s []string
 }
@@ -102,10 +111,23 @@ func RemoveByPath[T CompositeTypeInterface](comp T, path Path) {
 	removeByPath(comp, path)
 }
 
+// parseIndex returns an array index for the given path element.
+//
+// Unlike strconv.Atoi, it does not accept signs.
+func parseIndex(s string) (int, error) {
+	for _, c := range s {
+		if c < '0' || c > '9' {
+			return 0, &strconv.NumError{Func: "Atoi", Num: s, Err: strconv.ErrSyntax}
+		}
+	}
+
+	return strconv.Atoi(s)
+}
+
 // getByPath returns a value by path - a sequence of indexes and keys.
 func getByPath[T CompositeTypeInterface](comp T, path Path) (any, error) {
 	var next any = comp
-	for _, p := range path.Slice() {
+	for _, p := range path.s {
 		switch s := next.(type) {
 		case *Document:
 			var err error
@@ -115,7 +137,7 @@ func getByPath[T CompositeTypeInterface](comp T, path Path) (any, error) {
 			}
 
 		case *Array:
-			index, err := strconv.Atoi(p)
+			index, err := parseIndex(p)
 			if err != nil {
 				return nil, fmt.Errorf("types.getByPath: %w", err)
 			}
@@ -132,6 +154,70 @@ func getByPath[T CompositeTypeInterface](comp T, path Path) (any, error) {
 	return next, nil
 }
 
+// setByPath sets the value by path - a sequence of indexes and keys,
+// creating missing intermediate documents.
+//
+// Arrays are padded with nulls if the index is out of bounds.
+func setByPath[T CompositeTypeInterface](comp T, path Path, value any) error {
+	// validate value first to avoid creating intermediate documents for invalid values
+	if err := validateValue(value); err != nil {
+		return fmt.Errorf("types.setByPath: %w", err)
+	}
+
+	var next any = comp
+	for i, p := range path.s {
+		last := i == len(path.s)-1
+
+		switch s := next.(type) {
+		case *Document:
+			if last {
+				if err := s.Set(p, value); err != nil {
+					return fmt.Errorf("types.setByPath: %w", err)
+				}
+				return nil
+			}
+
+			var ok bool
+			if next, ok = s.m[p]; ok {
+				continue
+			}
+
+			next = new(Document)
+			if err := s.Set(p, next); err != nil {
+				return fmt.Errorf("types.setByPath: %w", err)
+			}
+
+		case *Array:
+			index, err := parseIndex(p)
+			if err != nil {
+				return fmt.Errorf("types.setByPath: %w: can't create field %q in array", ErrPathNotViable, p)
+			}
+
+			padded := len(s.s) <= index
+			for len(s.s) <= index {
+				s.s = append(s.s, Null)
+			}
+
+			if last {
+				if err := s.Set(index, value); err != nil {
+					return fmt.Errorf("types.setByPath: %w", err)
+				}
+				return nil
+			}
+
+			if padded {
+				s.s[index] = new(Document)
+			}
+			next = s.s[index]
+
+		default:
+			return fmt.Errorf("types.setByPath: %w: can't create field %q in %T", ErrPathNotViable, p, next)
+		}
+	}
+
+	return nil
+}
+
 // removeByPath removes path elements for given value, which could be *Document or *Array.
 func removeByPath(v any, path Path) {
 	if path.Len() == 0 {
@@ -156,7 +242,7 @@ func removeByPath(v any, path Path) {
 		removeByPath(v.m[key], path.TrimPrefix())
 
 	case *Array:
-		i, err := strconv.Atoi(key)
+		i, err := parseIndex(key)
 		if err != nil {
 			return // no such path
 		}
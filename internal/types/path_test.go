@@ -192,6 +192,10 @@ func TestRemoveByPathArray(t *testing.T) {
 			path:     NewPath([]string{"11"}),
 			expected: src.DeepCopy(),
 		},
+		"array: negative index": {
+			path:     NewPath([]string{"-1"}),
+			expected: src.DeepCopy(),
+		},
 		"array: index is not number": {
 			path:     NewPath([]string{"abcd"}),
 			expected: src.DeepCopy(),
@@ -257,6 +261,12 @@ func TestGetByPath(t *testing.T) {
 	}, {
 		path: NewPath([]string{"compression", "invalid"}),
 		err:  `types.getByPath: strconv.Atoi: parsing "invalid": invalid syntax`,
+	}, {
+		path: NewPath([]string{"compression", "-1"}),
+		err:  `types.getByPath: strconv.Atoi: parsing "-1": invalid syntax`,
+	}, {
+		path: NewPath([]string{"compression", "+0"}),
+		err:  `types.getByPath: strconv.Atoi: parsing "+0": invalid syntax`,
 	}, {
 		path: NewPath([]string{"client", "missing"}),
 		err:  `types.getByPath: types.Document.Get: key not found: "missing"`,
@@ -282,3 +292,146 @@ func TestGetByPath(t *testing.T) {
 		})
 	}
 }
+
+func TestSetByPath(t *testing.T) {
+	t.Parallel()
+
+	newDoc := func() *Document {
+		return must.NotFail(NewDocument(
+			"_id", int32(1),
+			"foo", "bar",
+			"doc", must.NotFail(NewDocument("a", int32(1))),
+			"arr", must.NotFail(NewArray(int32(1), must.NotFail(NewDocument("b", int32(2))), Null)),
+		))
+	}
+
+	for name, tc := range map[string]struct {
+		path     Path
+		value    any
+		expected *Document
+		err      string
+	}{
+		"replace": {
+			path:  NewPathFromString("foo"),
+			value: "baz",
+			expected: must.NotFail(NewDocument(
+				"_id", int32(1),
+				"foo", "baz",
+				"doc", must.NotFail(NewDocument("a", int32(1))),
+				"arr", must.NotFail(NewArray(int32(1), must.NotFail(NewDocument("b", int32(2))), Null)),
+			)),
+		},
+		"nested": {
+			path:  NewPathFromString("doc.b"),
+			value: int32(2),
+			expected: must.NotFail(NewDocument(
+				"_id", int32(1),
+				"foo", "bar",
+				"doc", must.NotFail(NewDocument("a", int32(1), "b", int32(2))),
+				"arr", must.NotFail(NewArray(int32(1), must.NotFail(NewDocument("b", int32(2))), Null)),
+			)),
+		},
+		"CreateDocuments": {
+			path:  NewPathFromString("new.0.field"),
+			value: true,
+			expected: must.NotFail(NewDocument(
+				"_id", int32(1),
+				"foo", "bar",
+				"doc", must.NotFail(NewDocument("a", int32(1))),
+				"arr", must.NotFail(NewArray(int32(1), must.NotFail(NewDocument("b", int32(2))), Null)),
+				"new", must.NotFail(NewDocument("0", must.NotFail(NewDocument("field", true)))),
+			)),
+		},
+		"ArrayElement": {
+			path:  NewPathFromString("arr.1.b"),
+			value: int32(3),
+			expected: must.NotFail(NewDocument(
+				"_id", int32(1),
+				"foo", "bar",
+				"doc", must.NotFail(NewDocument("a", int32(1))),
+				"arr", must.NotFail(NewArray(int32(1), must.NotFail(NewDocument("b", int32(3))), Null)),
+			)),
+		},
+		"ArrayPadding": {
+			path:  NewPathFromString("arr.4.c"),
+			value: int32(4),
+			expected: must.NotFail(NewDocument(
+				"_id", int32(1),
+				"foo", "bar",
+				"doc", must.NotFail(NewDocument("a", int32(1))),
+				"arr", must.NotFail(NewArray(
+					int32(1), must.NotFail(NewDocument("b", int32(2))), Null, Null,
+					must.NotFail(NewDocument("c", int32(4))),
+				)),
+			)),
+		},
+		"ArrayAppend": {
+			path:  NewPathFromString("arr.3"),
+			value: "x",
+			expected: must.NotFail(NewDocument(
+				"_id", int32(1),
+				"foo", "bar",
+				"doc", must.NotFail(NewDocument("a", int32(1))),
+				"arr", must.NotFail(NewArray(int32(1), must.NotFail(NewDocument("b", int32(2))), Null, "x")),
+			)),
+		},
+		"Scalar": {
+			path:  NewPathFromString("foo.bar"),
+			value: int32(1),
+			err:   `types.setByPath: path is not viable: can't create field "bar" in string`,
+		},
+		"ExistingNull": {
+			path:  NewPathFromString("arr.2.c"),
+			value: int32(1),
+			err:   `types.setByPath: path is not viable: can't create field "c" in types.NullType`,
+		},
+		"ArrayField": {
+			path:  NewPathFromString("arr.field"),
+			value: int32(1),
+			err:   `types.setByPath: path is not viable: can't create field "field" in array`,
+		},
+		"InvalidValue": {
+			path:  NewPathFromString("new.a"),
+			value: 42,
+			err:   `types.setByPath: types.validateValue: unsupported type: int (42)`,
+		},
+	} {
+		name, tc := name, tc
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			doc := newDoc()
+			err := doc.SetByPath(tc.path, tc.value)
+			if tc.err != "" {
+				assert.EqualError(t, err, tc.err)
+				assert.Equal(t, newDoc(), doc)
+
+				if tc.value != 42 {
+					assert.ErrorIs(t, err, ErrPathNotViable)
+				}
+				return
+			}
+
+			require.NoError(t, err)
+			assert.Equal(t, tc.expected, doc)
+			assert.True(t, doc.HasByPath(tc.path))
+		})
+	}
+}
+
+func TestHasByPath(t *testing.T) {
+	t.Parallel()
+
+	doc := must.NotFail(NewDocument(
+		"doc", must.NotFail(NewDocument("0", int32(1))),
+		"arr", must.NotFail(NewArray(must.NotFail(NewDocument("a", int32(2))))),
+	))
+
+	assert.True(t, doc.HasByPath(NewPathFromString("doc")))
+	assert.True(t, doc.HasByPath(NewPathFromString("doc.0")))
+	assert.True(t, doc.HasByPath(NewPathFromString("arr.0.a")))
+	assert.False(t, doc.HasByPath(NewPathFromString("doc.1")))
+	assert.False(t, doc.HasByPath(NewPathFromString("arr.a")))
+	assert.False(t, doc.HasByPath(NewPathFromString("arr.-1")))
+	assert.False(t, doc.HasByPath(NewPathFromString("doc.0.a")))
+}
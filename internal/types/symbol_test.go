@@ -0,0 +1,35 @@
+// Copyright 2021 FerretDB Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package types
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCompareSymbol(t *testing.T) {
+	t.Parallel()
+
+	assert.Equal(t, Equal, Compare(Symbol("a"), Symbol("a")))
+	assert.Equal(t, Less, Compare(Symbol("a"), Symbol("b")))
+
+	// symbols are compared with strings as strings
+	assert.Equal(t, Equal, Compare(Symbol("a"), "a"))
+	assert.Equal(t, Equal, Compare("a", Symbol("a")))
+	assert.Equal(t, Greater, Compare("b", Symbol("a")))
+	assert.Equal(t, Less, CompareOrder(Symbol("a"), "b", Ascending))
+	assert.Equal(t, Incomparable, Compare(Symbol("1"), int32(1)))
+}
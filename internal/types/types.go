@@ -33,14 +33,20 @@
 //  float64          *bson.doubleType     *fjson.doubleType     64-bit binary floating point
 //  string           *bson.stringType     *fjson.stringType     UTF-8 string
 //  types.Binary     *bson.binaryType     *fjson.binaryType     Binary data
+//  types.UndefinedType *bson.undefinedType *fjson.undefinedType Undefined (deprecated)
 //  types.ObjectID   *bson.objectIDType   *fjson.objectIDType   ObjectId
 //  bool             *bson.boolType       *fjson.boolType       Boolean
 //  time.Time        *bson.dateTimeType   *fjson.dateTimeType   UTC datetime
 //  types.NullType   *bson.nullType       *fjson.nullType       Null
 //  types.Regex      *bson.regexType      *fjson.regexType      Regular expression
+//  types.DBPointer  *bson.dbPointerType  *fjson.dbPointerType  DBPointer (deprecated)
+//  types.JavaScript *bson.javaScriptType *fjson.javaScriptType JavaScript code
+//  types.Symbol     *bson.symbolType     *fjson.symbolType     Symbol (deprecated)
+//  types.JavaScriptWithScope *bson.javaScriptWithScopeType *fjson.javaScriptWithScopeType JavaScript code with scope
 //  int32            *bson.int32Type      *fjson.int32Type      32-bit integer
 //  types.Timestamp  *bson.timestampType  *fjson.timestampType  Timestamp
 //  int64            *bson.int64Type      *fjson.int64Type      64-bit integer
+//  types.Decimal128 *bson.decimal128Type *fjson.decimal128Type 128-bit decimal floating point
 package types
 
 import (
@@ -53,7 +59,8 @@ const MaxDocumentLen = 16777216
 
 // ScalarType represents scalar type.
 type ScalarType interface {
-	float64 | string | Binary | ObjectID | bool | time.Time | NullType | Regex | int32 | Timestamp | int64
+	float64 | string | Binary | UndefinedType | ObjectID | bool | time.Time | NullType | Regex | DBPointer |
+		JavaScript | Symbol | JavaScriptWithScope | int32 | Timestamp | int64 | Decimal128
 }
 
 // CompositeType represents composite type - *Document or *Array.
@@ -71,6 +78,8 @@ type Type interface {
 type CompositeTypeInterface interface {
 	CompositeType
 	GetByPath(path Path) (any, error)
+	HasByPath(path Path) bool
+	SetByPath(path Path, value any) error
 	RemoveByPath(path Path)
 
 	compositeType() // seal for go-sumtype
@@ -83,11 +92,19 @@ type (
 	//
 	// Most callers should use types.Null value instead.
 	NullType struct{}
+
+	// UndefinedType represents deprecated BSON type Undefined.
+	//
+	// Most callers should use types.Undefined value instead.
+	UndefinedType struct{}
 )
 
 // Null represents BSON value Null.
 var Null = NullType{}
 
+// Undefined represents deprecated BSON value Undefined.
+var Undefined = UndefinedType{}
+
 // validateValue validates value.
 //
 // TODO https://github.com/FerretDB/FerretDB/issues/260
@@ -105,6 +122,8 @@ func validateValue(value any) error {
 		return nil
 	case Binary:
 		return nil
+	case UndefinedType:
+		return nil
 	case ObjectID:
 		return nil
 	case bool:
@@ -115,12 +134,25 @@ func validateValue(value any) error {
 		return nil
 	case Regex:
 		return nil
+	case DBPointer:
+		return nil
+	case JavaScript:
+		return nil
+	case Symbol:
+		return nil
+	case JavaScriptWithScope:
+		if value.Scope == nil {
+			return fmt.Errorf("types.validateValue: JavaScript scope is nil")
+		}
+		return value.Scope.validate()
 	case int32:
 		return nil
 	case Timestamp:
 		return nil
 	case int64:
 		return nil
+	case Decimal128:
+		return nil
 	default:
 		return fmt.Errorf("types.validateValue: unsupported type: %[1]T (%[1]v)", value)
 	}
@@ -167,6 +199,8 @@ func deepCopy(value any) any {
 			Subtype: value.Subtype,
 			B:       b,
 		}
+	case UndefinedType:
+		return value
 	case ObjectID:
 		return value
 	case bool:
@@ -177,12 +211,25 @@ func deepCopy(value any) any {
 		return value
 	case Regex:
 		return value
+	case DBPointer:
+		return value
+	case JavaScript:
+		return value
+	case Symbol:
+		return value
+	case JavaScriptWithScope:
+		return JavaScriptWithScope{
+			Code:  value.Code,
+			Scope: deepCopy(value.Scope).(*Document),
+		}
 	case int32:
 		return value
 	case Timestamp:
 		return value
 	case int64:
 		return value
+	case Decimal128:
+		return value
 
 	default:
 		panic(fmt.Sprintf("types.deepCopy: unsupported type: %[1]T (%[1]v)", value))
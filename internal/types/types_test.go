@@ -52,3 +52,12 @@ func TestDeepCopy(t *testing.T) {
 		assert.NotEqual(t, o1, o2)
 	})
 }
+
+func TestCompareUndefined(t *testing.T) {
+	t.Parallel()
+
+	assert.Equal(t, Equal, Compare(Undefined, Undefined))
+	assert.Equal(t, Incomparable, Compare(Undefined, Null))
+	assert.Equal(t, Less, CompareOrder(Undefined, Null, Ascending))
+	assert.Equal(t, Less, CompareOrder(Undefined, int32(0), Ascending))
+}
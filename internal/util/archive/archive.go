@@ -0,0 +1,265 @@
+// Copyright 2021 FerretDB Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package archive implements a writer of mongodump archive format.
+//
+// Archives could be restored by mongorestore with --archive flag.
+// Collections are written one by one, so each collection body is a single block:
+//
+//	magic number
+//	prelude header document
+//	collection metadata documents
+//	terminator
+//	for each collection:
+//	  namespace header document (if collection has documents)
+//	  documents
+//	  terminator (if collection has documents)
+//	  namespace EOF header document with CRC of documents
+//	  terminator
+package archive
+
+import (
+	"encoding/binary"
+	"hash"
+	"hash/crc64"
+	"io"
+
+	"github.com/FerretDB/FerretDB/internal/bson"
+	"github.com/FerretDB/FerretDB/internal/extjson"
+	"github.com/FerretDB/FerretDB/internal/types"
+	"github.com/FerretDB/FerretDB/internal/util/lazyerrors"
+	"github.com/FerretDB/FerretDB/internal/util/must"
+)
+
+// magicNumber starts every archive.
+const magicNumber uint32 = 0x8199e26d
+
+// formatVersion is the supported archive format version.
+const formatVersion = "0.1"
+
+// terminator ends the prelude and blocks of collection documents.
+var terminator = []byte{0xff, 0xff, 0xff, 0xff}
+
+// crcTable is used for checksums of collection documents.
+var crcTable = crc64.MakeTable(crc64.ECMA)
+
+// Collection describes a collection in the archive prelude.
+type Collection struct {
+	DB   string
+	Name string
+
+	// Collection options of the create command; may be nil.
+	Options *types.Document
+
+	// Index specifications like ones returned by listIndexes command.
+	Indexes []*types.Document
+}
+
+// metadata returns a collection metadata document of the prelude.
+func (c *Collection) metadata() (*types.Document, error) {
+	options := c.Options
+	if options == nil {
+		options = must.NotFail(types.NewDocument())
+	}
+
+	indexes := types.MakeArray(len(c.Indexes))
+	for _, index := range c.Indexes {
+		must.NoError(indexes.Append(index))
+	}
+
+	// the same as the content of mongodump's .metadata.json files
+	md := must.NotFail(types.NewDocument(
+		"indexes", indexes,
+		"collectionName", c.Name,
+		"type", "collection",
+		"options", options,
+	))
+
+	b, err := extjson.Marshal(md, extjson.Canonical)
+	if err != nil {
+		return nil, lazyerrors.Error(err)
+	}
+
+	return types.NewDocument(
+		"db", c.DB,
+		"collection", c.Name,
+		"metadata", string(b),
+		"size", int32(0),
+		"type", "collection",
+	)
+}
+
+// Writer writes an archive.
+//
+// It is not safe for concurrent use.
+type Writer struct {
+	w       io.Writer
+	current *Collection
+	started bool // current collection's header was written
+	crc     hash.Hash64
+}
+
+// NewWriter writes the archive prelude with the given collections and returns a new Writer.
+//
+// Server version is reported by mongorestore; tool is the name and version of the archive producer.
+func NewWriter(w io.Writer, serverVersion, tool string, collections []Collection) (*Writer, error) {
+	aw := &Writer{
+		w: w,
+	}
+
+	magic := make([]byte, 4)
+	binary.LittleEndian.PutUint32(magic, magicNumber)
+
+	if _, err := w.Write(magic); err != nil {
+		return nil, lazyerrors.Error(err)
+	}
+
+	header := must.NotFail(types.NewDocument(
+		"concurrent_collections", int32(1),
+		"version", formatVersion,
+		"server_version", serverVersion,
+		"tool_version", tool,
+	))
+	if err := aw.writeDocument(header); err != nil {
+		return nil, lazyerrors.Error(err)
+	}
+
+	for _, c := range collections {
+		md, err := c.metadata()
+		if err != nil {
+			return nil, lazyerrors.Error(err)
+		}
+
+		if err = aw.writeDocument(md); err != nil {
+			return nil, lazyerrors.Error(err)
+		}
+	}
+
+	if _, err := w.Write(terminator); err != nil {
+		return nil, lazyerrors.Error(err)
+	}
+
+	return aw, nil
+}
+
+// StartCollection starts writing documents of the given collection.
+// It should be one of the collections passed to NewWriter; the previous collection should be finished.
+func (aw *Writer) StartCollection(c *Collection) error {
+	if aw.current != nil {
+		return lazyerrors.Errorf("collection %s.%s is not finished", aw.current.DB, aw.current.Name)
+	}
+
+	aw.current = c
+	aw.started = false
+	aw.crc = crc64.New(crcTable)
+
+	return nil
+}
+
+// WriteDocument writes a document of the current collection.
+func (aw *Writer) WriteDocument(doc *types.Document) error {
+	if aw.current == nil {
+		return lazyerrors.New("collection is not started")
+	}
+
+	if !aw.started {
+		if err := aw.writeDocument(aw.namespaceHeader(false)); err != nil {
+			return lazyerrors.Error(err)
+		}
+
+		aw.started = true
+	}
+
+	b, err := marshal(doc)
+	if err != nil {
+		return lazyerrors.Error(err)
+	}
+
+	if _, err = aw.w.Write(b); err != nil {
+		return lazyerrors.Error(err)
+	}
+
+	must.NotFail(aw.crc.Write(b))
+
+	return nil
+}
+
+// FinishCollection finishes writing documents of the current collection.
+func (aw *Writer) FinishCollection() error {
+	if aw.current == nil {
+		return lazyerrors.New("collection is not started")
+	}
+
+	if aw.started {
+		if _, err := aw.w.Write(terminator); err != nil {
+			return lazyerrors.Error(err)
+		}
+	}
+
+	if err := aw.writeDocument(aw.namespaceHeader(true)); err != nil {
+		return lazyerrors.Error(err)
+	}
+
+	if _, err := aw.w.Write(terminator); err != nil {
+		return lazyerrors.Error(err)
+	}
+
+	aw.current = nil
+
+	return nil
+}
+
+// namespaceHeader returns a header document of the current collection's documents block.
+func (aw *Writer) namespaceHeader(eof bool) *types.Document {
+	var crc int64
+	if eof {
+		crc = int64(aw.crc.Sum64())
+	}
+
+	return must.NotFail(types.NewDocument(
+		"db", aw.current.DB,
+		"collection", aw.current.Name,
+		"EOF", eof,
+		"CRC", crc,
+	))
+}
+
+// writeDocument writes a single document.
+func (aw *Writer) writeDocument(doc *types.Document) error {
+	b, err := marshal(doc)
+	if err != nil {
+		return lazyerrors.Error(err)
+	}
+
+	if _, err = aw.w.Write(b); err != nil {
+		return lazyerrors.Error(err)
+	}
+
+	return nil
+}
+
+// marshal encodes a document to BSON.
+func marshal(doc *types.Document) ([]byte, error) {
+	d, err := bson.ConvertDocument(doc)
+	if err != nil {
+		return nil, lazyerrors.Error(err)
+	}
+
+	b, err := d.MarshalBinary()
+	if err != nil {
+		return nil, lazyerrors.Error(err)
+	}
+
+	return b, nil
+}
@@ -0,0 +1,152 @@
+// Copyright 2021 FerretDB Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package archive
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"hash/crc64"
+	"io"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/FerretDB/FerretDB/internal/bson"
+	"github.com/FerretDB/FerretDB/internal/extjson"
+	"github.com/FerretDB/FerretDB/internal/types"
+	"github.com/FerretDB/FerretDB/internal/util/must"
+)
+
+// reader reads archives like mongorestore does.
+type reader struct {
+	t *testing.T
+	r *bufio.Reader
+}
+
+// terminator returns true and consumes the terminator if it is next.
+func (r *reader) terminator() bool {
+	b, err := r.r.Peek(4)
+	require.NoError(r.t, err)
+
+	if !bytes.Equal(b, terminator) {
+		return false
+	}
+
+	must.NotFail(r.r.Discard(4))
+
+	return true
+}
+
+// document reads the next document and returns it with its raw bytes.
+func (r *reader) document() (*types.Document, []byte) {
+	b, err := r.r.Peek(4)
+	require.NoError(r.t, err)
+
+	raw := make([]byte, binary.LittleEndian.Uint32(b))
+	_, err = io.ReadFull(r.r, raw)
+	require.NoError(r.t, err)
+
+	var doc bson.Document
+	require.NoError(r.t, doc.ReadFrom(bufio.NewReader(bytes.NewReader(raw))))
+
+	return must.NotFail(types.ConvertDocument(&doc)), raw
+}
+
+func TestWriter(t *testing.T) {
+	t.Parallel()
+
+	index := must.NotFail(types.NewDocument(
+		"v", int32(2),
+		"key", must.NotFail(types.NewDocument("_id", int32(1))),
+		"name", "_id_",
+	))
+	collections := []Collection{
+		{DB: "db", Name: "empty", Indexes: []*types.Document{index}},
+		{DB: "db", Name: "values", Indexes: []*types.Document{index}},
+	}
+	docs := []*types.Document{
+		must.NotFail(types.NewDocument("_id", int32(1), "v", "foo")),
+		must.NotFail(types.NewDocument("_id", int32(2), "v", int64(42))),
+	}
+
+	var buf bytes.Buffer
+	w, err := NewWriter(&buf, "6.0.42", "test", collections)
+	require.NoError(t, err)
+
+	assert.Error(t, w.WriteDocument(docs[0]))
+
+	require.NoError(t, w.StartCollection(&collections[0]))
+	assert.Error(t, w.StartCollection(&collections[1]))
+	require.NoError(t, w.FinishCollection())
+
+	require.NoError(t, w.StartCollection(&collections[1]))
+	for _, doc := range docs {
+		require.NoError(t, w.WriteDocument(doc))
+	}
+	require.NoError(t, w.FinishCollection())
+
+	r := &reader{t: t, r: bufio.NewReader(&buf)}
+
+	magic := make([]byte, 4)
+	_, err = io.ReadFull(r.r, magic)
+	require.NoError(t, err)
+	assert.Equal(t, magicNumber, binary.LittleEndian.Uint32(magic))
+
+	header, _ := r.document()
+	assert.Equal(t, formatVersion, must.NotFail(header.Get("version")))
+	assert.Equal(t, "6.0.42", must.NotFail(header.Get("server_version")))
+
+	var names []string
+	for !r.terminator() {
+		md, _ := r.document()
+		names = append(names, must.NotFail(md.Get("collection")).(string))
+
+		metadata := must.NotFail(extjson.Unmarshal([]byte(must.NotFail(md.Get("metadata")).(string)))).(*types.Document)
+		assert.Equal(t, must.NotFail(md.Get("collection")), must.NotFail(metadata.Get("collectionName")))
+
+		indexes := must.NotFail(metadata.Get("indexes")).(*types.Array)
+		assert.Equal(t, index, must.NotFail(indexes.Get(0)))
+	}
+	assert.Equal(t, []string{"empty", "values"}, names)
+
+	actual := map[string][]*types.Document{}
+	data := map[string][]byte{}
+
+	for {
+		if _, err = r.r.Peek(1); err == io.EOF {
+			break
+		}
+
+		h, _ := r.document()
+		name := must.NotFail(h.Get("collection")).(string)
+
+		if must.NotFail(h.Get("EOF")).(bool) {
+			crc := crc64.Checksum(data[name], crc64.MakeTable(crc64.ECMA))
+			assert.Equal(t, int64(crc), must.NotFail(h.Get("CRC")), "%s", name)
+			require.True(t, r.terminator())
+			continue
+		}
+
+		for !r.terminator() {
+			doc, raw := r.document()
+			actual[name] = append(actual[name], doc)
+			data[name] = append(data[name], raw...)
+		}
+	}
+
+	assert.Equal(t, map[string][]*types.Document{"values": docs}, actual)
+}
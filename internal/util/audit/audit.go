@@ -0,0 +1,212 @@
+// Copyright 2021 FerretDB Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package audit provides audit logging of security-relevant events.
+//
+// Events use a format similar to MongoDB's JSON audit log.
+package audit
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net"
+	"os"
+	"sync"
+	"time"
+
+	"golang.org/x/exp/slices"
+
+	"github.com/FerretDB/FerretDB/internal/util/lazyerrors"
+)
+
+// Event types, matching MongoDB's atype values.
+const (
+	TypeAuthenticate     = "authenticate"
+	TypeAuthCheck        = "authCheck"
+	TypeCreateCollection = "createCollection"
+	TypeDropCollection   = "dropCollection"
+	TypeDropDatabase     = "dropDatabase"
+	TypeCreateIndex      = "createIndex"
+	TypeCreateUser       = "createUser"
+	TypeDropUser         = "dropUser"
+)
+
+// Addr represents local or remote network address of the client connection.
+type Addr struct {
+	IP   string `json:"ip,omitempty"`
+	Port int    `json:"port,omitempty"`
+	Unix string `json:"unix,omitempty"`
+}
+
+// NewAddr returns Addr for the given net.Addr, or nil.
+func NewAddr(addr net.Addr) *Addr {
+	switch addr := addr.(type) {
+	case *net.TCPAddr:
+		return &Addr{IP: addr.IP.String(), Port: addr.Port}
+	case *net.UnixAddr:
+		return &Addr{Unix: addr.Name}
+	case nil:
+		return nil
+	default:
+		return &Addr{IP: addr.String()}
+	}
+}
+
+// User represents an authenticated user.
+type User struct {
+	User string `json:"user"`
+	DB   string `json:"db"`
+}
+
+// Role represents a role granted to the authenticated user.
+type Role struct {
+	Role string `json:"role"`
+	DB   string `json:"db"`
+}
+
+// Event represents a single audit event.
+type Event struct {
+	AType  string         `json:"atype"`
+	TS     time.Time      `json:"ts"`
+	Local  *Addr          `json:"local,omitempty"`
+	Remote *Addr          `json:"remote,omitempty"`
+	Users  []User         `json:"users"`
+	Roles  []Role         `json:"roles"`
+	Param  map[string]any `json:"param"`
+
+	// Error code, 0 for success.
+	Result int32 `json:"result"`
+}
+
+// Destination represents audit log destination.
+type Destination string
+
+const (
+	// DestinationFile writes events to a file, one JSON document per line.
+	DestinationFile Destination = "file"
+
+	// DestinationSyslog writes events to the local syslog daemon with AUTH facility.
+	DestinationSyslog Destination = "syslog"
+)
+
+// AllDestinations includes all audit log destinations.
+var AllDestinations = []Destination{DestinationFile, DestinationSyslog}
+
+// Filter selects events that are logged.
+type Filter struct {
+	// Logged event types; all if empty.
+	ATypes []string
+
+	// If set, only events with non-zero result are logged.
+	FailedOnly bool
+}
+
+// match returns true if the event should be logged.
+func (f *Filter) match(e *Event) bool {
+	if len(f.ATypes) > 0 && !slices.Contains(f.ATypes, e.AType) {
+		return false
+	}
+
+	if f.FailedOnly && e.Result == 0 {
+		return false
+	}
+
+	return true
+}
+
+// NewLoggerOpts represents audit logger configuration.
+type NewLoggerOpts struct {
+	Destination Destination
+
+	// File path for DestinationFile.
+	Path string
+
+	Filter Filter
+}
+
+// Logger writes audit events to the configured destination.
+//
+// Nil logger discards all events.
+type Logger struct {
+	filter Filter
+
+	m sync.Mutex
+	w io.WriteCloser
+}
+
+// NewLogger returns a new audit logger.
+func NewLogger(opts *NewLoggerOpts) (*Logger, error) {
+	l := &Logger{
+		filter: opts.Filter,
+	}
+
+	var err error
+
+	switch opts.Destination {
+	case DestinationFile:
+		if opts.Path == "" {
+			return nil, fmt.Errorf("audit.NewLogger: path is required for %q destination", opts.Destination)
+		}
+
+		l.w, err = os.OpenFile(opts.Path, os.O_WRONLY|os.O_CREATE|os.O_APPEND, 0o600)
+
+	case DestinationSyslog:
+		l.w, err = newSyslogWriter()
+
+	default:
+		return nil, fmt.Errorf("audit.NewLogger: unknown destination %q", opts.Destination)
+	}
+
+	if err != nil {
+		return nil, fmt.Errorf("audit.NewLogger: %w", err)
+	}
+
+	return l, nil
+}
+
+// Log writes the event if it matches the filter.
+func (l *Logger) Log(e *Event) error {
+	if l == nil || !l.filter.match(e) {
+		return nil
+	}
+
+	b, err := json.Marshal(e)
+	if err != nil {
+		return lazyerrors.Error(err)
+	}
+
+	b = append(b, '\n')
+
+	l.m.Lock()
+	defer l.m.Unlock()
+
+	if _, err = l.w.Write(b); err != nil {
+		return lazyerrors.Error(err)
+	}
+
+	return nil
+}
+
+// Close closes the audit log destination.
+func (l *Logger) Close() error {
+	if l == nil {
+		return nil
+	}
+
+	l.m.Lock()
+	defer l.m.Unlock()
+
+	return l.w.Close()
+}
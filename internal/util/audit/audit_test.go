@@ -0,0 +1,94 @@
+// Copyright 2021 FerretDB Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package audit
+
+import (
+	"bytes"
+	"encoding/json"
+	"net"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewAddr(t *testing.T) {
+	t.Parallel()
+
+	assert.Nil(t, NewAddr(nil))
+	assert.Equal(t, &Addr{IP: "127.0.0.1", Port: 27017}, NewAddr(&net.TCPAddr{IP: net.IPv4(127, 0, 0, 1), Port: 27017}))
+	assert.Equal(t, &Addr{Unix: "/tmp/mongodb.sock"}, NewAddr(&net.UnixAddr{Name: "/tmp/mongodb.sock", Net: "unix"}))
+}
+
+func TestLoggerFile(t *testing.T) {
+	t.Parallel()
+
+	_, err := NewLogger(&NewLoggerOpts{Destination: DestinationFile})
+	assert.EqualError(t, err, `audit.NewLogger: path is required for "file" destination`)
+
+	_, err = NewLogger(&NewLoggerOpts{Destination: "console"})
+	assert.EqualError(t, err, `audit.NewLogger: unknown destination "console"`)
+
+	var nilLogger *Logger
+	assert.NoError(t, nilLogger.Log(&Event{AType: TypeAuthenticate}))
+	assert.NoError(t, nilLogger.Close())
+
+	path := filepath.Join(t.TempDir(), "audit.json")
+	l, err := NewLogger(&NewLoggerOpts{
+		Destination: DestinationFile,
+		Path:        path,
+		Filter: Filter{
+			ATypes:     []string{TypeAuthenticate, TypeAuthCheck},
+			FailedOnly: true,
+		},
+	})
+	require.NoError(t, err)
+
+	ts := time.Date(2022, 7, 1, 12, 0, 0, 0, time.UTC)
+	events := []*Event{
+		{AType: TypeAuthenticate, TS: ts, Param: map[string]any{"user": "user"}, Result: 18},
+		{AType: TypeAuthenticate, TS: ts, Param: map[string]any{"user": "user"}},
+		{AType: TypeCreateCollection, TS: ts, Param: map[string]any{"ns": "test.values"}, Result: 13},
+		{AType: TypeAuthCheck, TS: ts, Users: []User{{User: "user", DB: "admin"}}, Result: 13},
+	}
+	for _, e := range events {
+		require.NoError(t, l.Log(e))
+	}
+	require.NoError(t, l.Close())
+
+	b, err := os.ReadFile(path)
+	require.NoError(t, err)
+
+	lines := bytes.Split(bytes.TrimSpace(b), []byte{'\n'})
+	require.Len(t, lines, 2)
+
+	var actual map[string]any
+	require.NoError(t, json.Unmarshal(lines[0], &actual))
+	expected := map[string]any{
+		"atype":  "authenticate",
+		"ts":     "2022-07-01T12:00:00Z",
+		"users":  nil,
+		"roles":  nil,
+		"param":  map[string]any{"user": "user"},
+		"result": float64(18),
+	}
+	assert.Equal(t, expected, actual)
+
+	require.NoError(t, json.Unmarshal(lines[1], &actual))
+	assert.Equal(t, "authCheck", actual["atype"])
+}
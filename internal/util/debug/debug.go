@@ -25,6 +25,8 @@ import (
 	"github.com/prometheus/client_golang/prometheus"
 	"github.com/prometheus/client_golang/prometheus/promhttp"
 	"go.uber.org/zap"
+
+	"github.com/FerretDB/FerretDB/internal/util/logging"
 )
 
 // RunHandler runs debug handler.
@@ -43,6 +45,11 @@ func RunHandler(ctx context.Context, addr string, l *zap.Logger) {
 		}),
 	))
 
+	http.Handle("/debug/loglevel", logging.LevelHandler())
+
+	http.Handle("/livez", probeHandler(false))
+	http.Handle("/readyz", probeHandler(true))
+
 	s := http.Server{
 		Addr:     addr,
 		ErrorLog: stdL,
@@ -0,0 +1,117 @@
+// Copyright 2021 FerretDB Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package debug
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Probe checks whether some component is healthy; it returns nil if it is.
+type Probe func(ctx context.Context) error
+
+// probeTimeout is the maximal duration of all probes of a single request.
+const probeTimeout = 5 * time.Second
+
+// probes are used by /livez and /readyz endpoints; see SetProbes.
+var probes struct {
+	rw    sync.RWMutex
+	set   bool
+	live  map[string]Probe
+	ready map[string]Probe
+}
+
+// SetProbes sets named probes for /livez and /readyz endpoints.
+//
+// /livez checks only live probes; it succeeds until SetProbes is called, so the process is not restarted during startup.
+// /readyz checks both live and ready probes; it fails until SetProbes is called.
+func SetProbes(live, ready map[string]Probe) {
+	probes.rw.Lock()
+	defer probes.rw.Unlock()
+
+	probes.set = true
+	probes.live = live
+	probes.ready = ready
+}
+
+// probeHandler returns a handler for /livez (if ready is false) or /readyz (if ready is true) endpoint.
+//
+// It responds with 200 OK if all probes succeeded, and with 503 Service Unavailable otherwise.
+// The response body contains the result of each probe, like Kubernetes components.
+func probeHandler(ready bool) http.HandlerFunc {
+	name := "livez"
+	if ready {
+		name = "readyz"
+	}
+
+	return func(rw http.ResponseWriter, req *http.Request) {
+		probes.rw.RLock()
+		set := probes.set
+		all := make(map[string]Probe, len(probes.live)+len(probes.ready))
+		for k, p := range probes.live {
+			all[k] = p
+		}
+		if ready {
+			for k, p := range probes.ready {
+				all[k] = p
+			}
+		}
+		probes.rw.RUnlock()
+
+		ctx, cancel := context.WithTimeout(req.Context(), probeTimeout)
+		defer cancel()
+
+		names := make([]string, 0, len(all))
+		for k := range all {
+			names = append(names, k)
+		}
+		sort.Strings(names)
+
+		var body strings.Builder
+		var failed bool
+
+		if ready && !set {
+			failed = true
+			body.WriteString("[-]startup failed: not finished yet\n")
+		}
+
+		for _, k := range names {
+			if err := all[k](ctx); err != nil {
+				failed = true
+				fmt.Fprintf(&body, "[-]%s failed: %s\n", k, err)
+				continue
+			}
+
+			fmt.Fprintf(&body, "[+]%s ok\n", k)
+		}
+
+		rw.Header().Set("Content-Type", "text/plain; charset=utf-8")
+		rw.Header().Set("X-Content-Type-Options", "nosniff")
+
+		if failed {
+			rw.WriteHeader(http.StatusServiceUnavailable)
+			fmt.Fprintf(&body, "%s check failed\n", name)
+		} else {
+			fmt.Fprintf(&body, "%s check passed\n", name)
+		}
+
+		_, _ = rw.Write([]byte(body.String()))
+	}
+}
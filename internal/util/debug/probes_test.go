@@ -0,0 +1,65 @@
+// Copyright 2021 FerretDB Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package debug
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestProbes(t *testing.T) {
+	// not parallel because probes are global
+
+	get := func(ready bool) (int, string) {
+		rec := httptest.NewRecorder()
+		probeHandler(ready).ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/", nil))
+		return rec.Code, rec.Body.String()
+	}
+
+	code, body := get(false)
+	assert.Equal(t, http.StatusOK, code)
+	assert.Equal(t, "livez check passed\n", body)
+
+	code, body = get(true)
+	assert.Equal(t, http.StatusServiceUnavailable, code)
+	assert.Equal(t, "[-]startup failed: not finished yet\nreadyz check failed\n", body)
+
+	var backendErr error
+
+	SetProbes(
+		map[string]Probe{"listener": func(context.Context) error { return nil }},
+		map[string]Probe{"backend": func(context.Context) error { return backendErr }},
+	)
+	t.Cleanup(func() { SetProbes(nil, nil) })
+
+	code, body = get(true)
+	assert.Equal(t, http.StatusOK, code)
+	assert.Equal(t, "[+]backend ok\n[+]listener ok\nreadyz check passed\n", body)
+
+	backendErr = errors.New("connection refused")
+
+	code, body = get(true)
+	assert.Equal(t, http.StatusServiceUnavailable, code)
+	assert.Equal(t, "[-]backend failed: connection refused\n[+]listener ok\nreadyz check failed\n", body)
+
+	code, body = get(false)
+	assert.Equal(t, http.StatusOK, code)
+	assert.Equal(t, "[+]listener ok\nlivez check passed\n", body)
+}
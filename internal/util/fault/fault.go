@@ -0,0 +1,289 @@
+// Copyright 2021 FerretDB Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package fault provides fault injection for load and chaos testing.
+//
+// It should be used only in tests; FerretDB enables it only with -test-faults flag.
+package fault
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/rand"
+	"net"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"golang.org/x/exp/slices"
+)
+
+// ErrInjected is returned (possibly wrapped) for injected faults.
+var ErrInjected = errors.New("injected fault")
+
+// Config represents fault injection configuration.
+//
+// Rates are probabilities from 0 to 1.
+type Config struct {
+	// Artificial latency added to affected client requests and backend writes.
+	Latency     time.Duration
+	LatencyRate float64
+
+	// Rate of client connections that are closed instead of handling the request.
+	// Dropped requests are not executed, so they are always safe to retry.
+	DropRate float64
+
+	// Rate of backend connections that are closed instead of writing to them.
+	// Data is not written, so backend operations fail without partial effects.
+	ErrorRate float64
+
+	// If set, client request faults are injected only for those commands.
+	// Backend faults do not depend on it.
+	Commands []string
+
+	// Seed of the pseudo-random generator; if 0, a random seed is used.
+	Seed int64
+}
+
+// ParseConfig parses configuration from comma-separated key=value pairs, like
+// "latency=10ms,latency-rate=0.1,drop-rate=0.01,error-rate=0.01,commands=insert;find,seed=42".
+func ParseConfig(s string) (*Config, error) {
+	var cfg Config
+
+	for _, pair := range strings.Split(s, ",") {
+		if pair = strings.TrimSpace(pair); pair == "" {
+			continue
+		}
+
+		k, v, ok := strings.Cut(pair, "=")
+		if !ok {
+			return nil, fmt.Errorf("fault.ParseConfig: invalid pair %q", pair)
+		}
+
+		var err error
+		switch k {
+		case "latency":
+			cfg.Latency, err = time.ParseDuration(v)
+		case "latency-rate":
+			cfg.LatencyRate, err = parseRate(v)
+		case "drop-rate":
+			cfg.DropRate, err = parseRate(v)
+		case "error-rate":
+			cfg.ErrorRate, err = parseRate(v)
+		case "commands":
+			cfg.Commands = strings.Split(v, ";")
+		case "seed":
+			cfg.Seed, err = strconv.ParseInt(v, 10, 64)
+		default:
+			err = errors.New("unknown key")
+		}
+
+		if err != nil {
+			return nil, fmt.Errorf("fault.ParseConfig: %q: %w", k, err)
+		}
+	}
+
+	return &cfg, nil
+}
+
+// parseRate parses a probability from 0 to 1.
+func parseRate(s string) (float64, error) {
+	rate, err := strconv.ParseFloat(s, 64)
+	if err != nil {
+		return 0, err
+	}
+
+	if rate < 0 || rate > 1 {
+		return 0, fmt.Errorf("rate %v is not in [0, 1]", rate)
+	}
+
+	return rate, nil
+}
+
+// Stats represents the number of injected faults.
+type Stats struct {
+	Latencies uint64
+	Drops     uint64
+	Errors    uint64
+}
+
+// Injector injects faults according to its configuration.
+//
+// It is safe for concurrent use. Nil *Injector is valid and injects nothing.
+type Injector struct {
+	rw  sync.RWMutex
+	cfg *Config // nil if disabled
+
+	randM sync.Mutex
+	rand  *rand.Rand
+
+	// accessed atomically
+	latencies uint64
+	drops     uint64
+	errors    uint64
+}
+
+// New returns a new injector with the given configuration; see SetConfig.
+func New(cfg *Config) *Injector {
+	i := new(Injector)
+	i.SetConfig(cfg)
+
+	return i
+}
+
+// SetConfig replaces injector's configuration at runtime.
+// If cfg is nil, faults are not injected.
+//
+// The pseudo-random generator is reseeded, so the sequence of faults could be reproduced.
+func (i *Injector) SetConfig(cfg *Config) {
+	seed := time.Now().UnixNano()
+	if cfg != nil && cfg.Seed != 0 {
+		seed = cfg.Seed
+	}
+
+	i.randM.Lock()
+	i.rand = rand.New(rand.NewSource(seed))
+	i.randM.Unlock()
+
+	i.rw.Lock()
+	i.cfg = cfg
+	i.rw.Unlock()
+}
+
+// Stats returns the number of faults injected so far.
+func (i *Injector) Stats() Stats {
+	if i == nil {
+		return Stats{}
+	}
+
+	return Stats{
+		Latencies: atomic.LoadUint64(&i.latencies),
+		Drops:     atomic.LoadUint64(&i.drops),
+		Errors:    atomic.LoadUint64(&i.errors),
+	}
+}
+
+// Request is called by the client connection before handling the request with the given command.
+//
+// It sleeps for injected latency (returning ctx error if ctx is done before that),
+// and returns true if the connection should be closed without handling the request.
+func (i *Injector) Request(ctx context.Context, command string) (bool, error) {
+	cfg := i.config()
+	if cfg == nil {
+		return false, nil
+	}
+
+	if len(cfg.Commands) > 0 && !slices.Contains(cfg.Commands, command) {
+		return false, nil
+	}
+
+	if err := i.delay(ctx, cfg); err != nil {
+		return false, err
+	}
+
+	if !i.hit(cfg.DropRate) {
+		return false, nil
+	}
+
+	atomic.AddUint64(&i.drops, 1)
+
+	return true, nil
+}
+
+// Conn wraps backend connection to inject latency and errors to writes.
+func (i *Injector) Conn(c net.Conn) net.Conn {
+	if i == nil {
+		return c
+	}
+
+	return &conn{Conn: c, i: i}
+}
+
+// config returns the current configuration or nil.
+func (i *Injector) config() *Config {
+	if i == nil {
+		return nil
+	}
+
+	i.rw.RLock()
+	defer i.rw.RUnlock()
+
+	return i.cfg
+}
+
+// delay sleeps for configured latency with configured rate until ctx is done.
+func (i *Injector) delay(ctx context.Context, cfg *Config) error {
+	if cfg.Latency <= 0 || !i.hit(cfg.LatencyRate) {
+		return nil
+	}
+
+	atomic.AddUint64(&i.latencies, 1)
+
+	t := time.NewTimer(cfg.Latency)
+	defer t.Stop()
+
+	select {
+	case <-t.C:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// hit returns true with the given probability.
+func (i *Injector) hit(rate float64) bool {
+	if rate <= 0 {
+		return false
+	}
+
+	i.randM.Lock()
+	defer i.randM.Unlock()
+
+	return i.rand.Float64() < rate
+}
+
+// conn is a backend connection with injected faults.
+type conn struct {
+	net.Conn
+	i *Injector
+}
+
+// Write implements net.Conn.
+//
+// It closes the connection and returns ErrInjected without writing anything with configured rate.
+func (c *conn) Write(b []byte) (int, error) {
+	cfg := c.i.config()
+	if cfg == nil {
+		return c.Conn.Write(b)
+	}
+
+	_ = c.i.delay(context.Background(), cfg)
+
+	if c.i.hit(cfg.ErrorRate) {
+		atomic.AddUint64(&c.i.errors, 1)
+		c.Conn.Close()
+
+		return 0, ErrInjected
+	}
+
+	return c.Conn.Write(b)
+}
+
+// check interfaces
+var (
+	_ net.Conn = (*conn)(nil)
+)
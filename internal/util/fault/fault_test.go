@@ -0,0 +1,184 @@
+// Copyright 2021 FerretDB Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package fault
+
+import (
+	"context"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseConfig(t *testing.T) {
+	t.Parallel()
+
+	for name, tc := range map[string]struct {
+		s        string
+		expected *Config
+		err      string
+	}{
+		"Empty": {
+			s:        "",
+			expected: new(Config),
+		},
+		"All": {
+			s: "latency=10ms, latency-rate=0.1,drop-rate=0.01,error-rate=1,commands=insert;find,seed=42",
+			expected: &Config{
+				Latency:     10 * time.Millisecond,
+				LatencyRate: 0.1,
+				DropRate:    0.01,
+				ErrorRate:   1,
+				Commands:    []string{"insert", "find"},
+				Seed:        42,
+			},
+		},
+		"NoValue": {
+			s:   "latency",
+			err: `fault.ParseConfig: invalid pair "latency"`,
+		},
+		"UnknownKey": {
+			s:   "foo=bar",
+			err: `fault.ParseConfig: "foo": unknown key`,
+		},
+		"InvalidRate": {
+			s:   "drop-rate=2",
+			err: `fault.ParseConfig: "drop-rate": rate 2 is not in [0, 1]`,
+		},
+		"InvalidLatency": {
+			s:   "latency=10",
+			err: `fault.ParseConfig: "latency": time: missing unit in duration "10"`,
+		},
+	} {
+		name, tc := name, tc
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			actual, err := ParseConfig(tc.s)
+			if tc.err != "" {
+				require.EqualError(t, err, tc.err)
+				return
+			}
+
+			require.NoError(t, err)
+			assert.Equal(t, tc.expected, actual)
+		})
+	}
+}
+
+func TestInjectorNil(t *testing.T) {
+	t.Parallel()
+
+	var i *Injector
+
+	drop, err := i.Request(context.Background(), "insert")
+	require.NoError(t, err)
+	assert.False(t, drop)
+
+	c, _ := net.Pipe()
+	defer c.Close()
+	assert.Same(t, c, i.Conn(c))
+
+	assert.Equal(t, Stats{}, i.Stats())
+}
+
+func TestInjectorRequest(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+
+	i := New(&Config{Latency: time.Millisecond, LatencyRate: 1, DropRate: 1, Commands: []string{"insert"}})
+
+	drop, err := i.Request(ctx, "find")
+	require.NoError(t, err)
+	assert.False(t, drop)
+	assert.Equal(t, Stats{}, i.Stats())
+
+	drop, err = i.Request(ctx, "insert")
+	require.NoError(t, err)
+	assert.True(t, drop)
+	assert.Equal(t, Stats{Latencies: 1, Drops: 1}, i.Stats())
+
+	i.SetConfig(&Config{Latency: time.Hour, LatencyRate: 1})
+
+	ctx, cancel := context.WithCancel(ctx)
+	cancel()
+
+	_, err = i.Request(ctx, "insert")
+	require.ErrorIs(t, err, context.Canceled)
+
+	i.SetConfig(nil)
+
+	drop, err = i.Request(context.Background(), "insert")
+	require.NoError(t, err)
+	assert.False(t, drop)
+	assert.Equal(t, Stats{Latencies: 2, Drops: 1}, i.Stats())
+}
+
+func TestInjectorSeed(t *testing.T) {
+	t.Parallel()
+
+	cfg := &Config{DropRate: 0.5, Seed: 42}
+
+	drops := func() []bool {
+		i := New(cfg)
+
+		res := make([]bool, 20)
+		for j := range res {
+			var err error
+			res[j], err = i.Request(context.Background(), "insert")
+			require.NoError(t, err)
+		}
+
+		return res
+	}
+
+	expected := drops()
+	assert.Contains(t, expected, true)
+	assert.Contains(t, expected, false)
+	assert.Equal(t, expected, drops())
+}
+
+func TestInjectorConn(t *testing.T) {
+	t.Parallel()
+
+	client, server := net.Pipe()
+	defer server.Close()
+
+	i := New(nil)
+	c := i.Conn(client)
+
+	go func() {
+		b := make([]byte, 3)
+		_, _ = server.Read(b)
+	}()
+
+	n, err := c.Write([]byte("foo"))
+	require.NoError(t, err)
+	assert.Equal(t, 3, n)
+
+	i.SetConfig(&Config{ErrorRate: 1})
+
+	n, err = c.Write([]byte("bar"))
+	require.ErrorIs(t, err, ErrInjected)
+	assert.Equal(t, 0, n)
+	assert.Equal(t, Stats{Errors: 1}, i.Stats())
+
+	// the connection is closed
+	_, err = server.Read(make([]byte, 3))
+	require.Error(t, err)
+}
@@ -0,0 +1,201 @@
+// Copyright 2021 FerretDB Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ftdc
+
+import (
+	"bytes"
+	"compress/zlib"
+	"encoding/binary"
+	"fmt"
+	"time"
+
+	"github.com/FerretDB/FerretDB/internal/bson"
+	"github.com/FerretDB/FerretDB/internal/types"
+	"github.com/FerretDB/FerretDB/internal/util/lazyerrors"
+	"github.com/FerretDB/FerretDB/internal/util/must"
+)
+
+// Document types, matching MongoDB's FTDC type field values.
+const (
+	typeMetadata    = int32(0)
+	typeMetricChunk = int32(1)
+)
+
+// chunk accumulates samples with the same structure as the reference (first) sample.
+type chunk struct {
+	start     time.Time
+	reference *types.Document
+	schema    []string  // path and type of each metric of the reference sample
+	metrics   [][]int64 // values of each metric, one per sample
+	samples   int
+}
+
+// newChunk returns a new chunk with the given reference sample.
+func newChunk(t time.Time, reference *types.Document) *chunk {
+	var schema []string
+	var values []int64
+	extractMetrics(reference, "", &schema, &values)
+
+	metrics := make([][]int64, len(values))
+	for i, v := range values {
+		metrics[i] = []int64{v}
+	}
+
+	return &chunk{
+		start:     t,
+		reference: reference,
+		schema:    schema,
+		metrics:   metrics,
+		samples:   1,
+	}
+}
+
+// add adds the sample to the chunk.
+// It returns false if the sample has a different structure than the reference one.
+func (c *chunk) add(sample *types.Document) bool {
+	schema := make([]string, 0, len(c.schema))
+	values := make([]int64, 0, len(c.schema))
+	extractMetrics(sample, "", &schema, &values)
+
+	if len(schema) != len(c.schema) {
+		return false
+	}
+
+	for i := range schema {
+		if schema[i] != c.schema[i] {
+			return false
+		}
+	}
+
+	for i, v := range values {
+		c.metrics[i] = append(c.metrics[i], v)
+	}
+
+	c.samples++
+
+	return true
+}
+
+// document returns FTDC metric chunk document with the compressed samples.
+//
+// Its data field contains the uncompressed size (uint32, little-endian) and zlib-compressed
+// reference sample (BSON), number of metrics and deltas (uint32, little-endian), and then
+// deltas between consecutive samples of each metric as unsigned varints,
+// with runs of zeros encoded as zero followed by the run length minus one.
+func (c *chunk) document() (*types.Document, error) {
+	ref, err := bson.MustConvertDocument(c.reference).MarshalBinary()
+	if err != nil {
+		return nil, lazyerrors.Error(err)
+	}
+
+	var buf bytes.Buffer
+	buf.Write(ref)
+
+	deltas := c.samples - 1
+	must.NoError(binary.Write(&buf, binary.LittleEndian, uint32(len(c.metrics))))
+	must.NoError(binary.Write(&buf, binary.LittleEndian, uint32(deltas)))
+
+	varint := make([]byte, binary.MaxVarintLen64)
+	var zeros uint64
+
+	for _, values := range c.metrics {
+		for i := 1; i < len(values); i++ {
+			delta := uint64(values[i] - values[i-1])
+			if delta == 0 {
+				zeros++
+				continue
+			}
+
+			if zeros > 0 {
+				buf.Write(varint[:binary.PutUvarint(varint, 0)])
+				buf.Write(varint[:binary.PutUvarint(varint, zeros-1)])
+				zeros = 0
+			}
+
+			buf.Write(varint[:binary.PutUvarint(varint, delta)])
+		}
+	}
+
+	if zeros > 0 {
+		buf.Write(varint[:binary.PutUvarint(varint, 0)])
+		buf.Write(varint[:binary.PutUvarint(varint, zeros-1)])
+	}
+
+	var data bytes.Buffer
+	must.NoError(binary.Write(&data, binary.LittleEndian, uint32(buf.Len())))
+
+	w := zlib.NewWriter(&data)
+	if _, err = w.Write(buf.Bytes()); err != nil {
+		return nil, lazyerrors.Error(err)
+	}
+
+	if err = w.Close(); err != nil {
+		return nil, lazyerrors.Error(err)
+	}
+
+	return types.NewDocument(
+		"_id", c.start,
+		"type", typeMetricChunk,
+		"data", types.Binary{Subtype: types.BinaryGeneric, B: data.Bytes()},
+	)
+}
+
+// extractMetrics appends paths with types and values of all metrics of the document
+// in depth-first order, like MongoDB.
+//
+// Numbers, booleans, and dates are metrics; timestamps are two metrics (seconds and increment).
+// Documents and arrays are traversed recursively; other values are ignored.
+func extractMetrics(doc *types.Document, prefix string, schema *[]string, values *[]int64) {
+	m := doc.Map()
+	for _, k := range doc.Keys() {
+		extractValue(m[k], prefix+k, schema, values)
+	}
+}
+
+// extractValue is extractMetrics for a single value.
+func extractValue(v any, path string, schema *[]string, values *[]int64) {
+	add := func(typ string, value int64) {
+		*schema = append(*schema, path+":"+typ)
+		*values = append(*values, value)
+	}
+
+	switch v := v.(type) {
+	case *types.Document:
+		extractMetrics(v, path+".", schema, values)
+
+	case *types.Array:
+		for i := 0; i < v.Len(); i++ {
+			extractValue(must.NotFail(v.Get(i)), fmt.Sprintf("%s.%d", path, i), schema, values)
+		}
+
+	case float64:
+		add("double", int64(v))
+	case int32:
+		add("int", int64(v))
+	case int64:
+		add("long", v)
+	case bool:
+		var i int64
+		if v {
+			i = 1
+		}
+		add("bool", i)
+	case time.Time:
+		add("date", v.UnixMilli())
+	case types.Timestamp:
+		add("timestamp", int64(uint64(v)>>32))
+		add("timestamp", int64(uint32(v)))
+	}
+}
@@ -0,0 +1,387 @@
+// Copyright 2021 FerretDB Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package ftdc implements full-time diagnostic data capture (FTDC) compatible with MongoDB.
+//
+// Samples of serverStatus-like documents are periodically written to files in the diagnostic data directory
+// in the same format as MongoDB's diagnostic.data, so existing tools could be used to analyze them.
+// Each file starts with a metadata document followed by metric chunk documents (see chunk.document).
+// Samples of the current chunk are also periodically written to the metrics.interim file,
+// so they are not lost if the process is killed; they are moved to the next file on start.
+package ftdc
+
+import (
+	"context"
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"go.uber.org/zap"
+
+	"github.com/FerretDB/FerretDB/internal/bson"
+	"github.com/FerretDB/FerretDB/internal/types"
+	"github.com/FerretDB/FerretDB/internal/util/lazyerrors"
+	"github.com/FerretDB/FerretDB/internal/util/must"
+)
+
+const (
+	// DefaultPeriod is the default value of NewCollectorOpts.Period; it matches MongoDB.
+	DefaultPeriod = time.Second
+
+	// defaultMaxFileSize is the default value of NewCollectorOpts.MaxFileSize; it matches MongoDB.
+	defaultMaxFileSize = 10 * 1024 * 1024
+
+	// defaultMaxDirSize is the default value of NewCollectorOpts.MaxDirSize; it matches MongoDB.
+	defaultMaxDirSize = 200 * 1024 * 1024
+
+	// maxChunkSamples is the maximal number of samples in a single chunk; it matches MongoDB.
+	maxChunkSamples = 300
+
+	// interimSamples is the number of samples after which the interim file is updated; it matches MongoDB.
+	interimSamples = 10
+
+	// filePrefix is the prefix of all metrics files names.
+	filePrefix = "metrics."
+
+	// interimFile is the name of the interim file.
+	interimFile = filePrefix + "interim"
+)
+
+// NewCollectorOpts represents collector configuration.
+type NewCollectorOpts struct {
+	// Diagnostic data directory; it is created if needed.
+	Dir string
+
+	// Sample returns the next sample, like {serverStatus: {...}}.
+	// Samples with the same structure are compressed together.
+	Sample func(ctx context.Context) (*types.Document, error)
+
+	// Metadata document written at the start of each file, like {buildInfo: {...}, hostInfo: {...}}.
+	Metadata *types.Document
+
+	Logger *zap.Logger
+
+	// Period between samples; zero means DefaultPeriod.
+	Period time.Duration
+
+	// Maximal size of a single file and of all files; zero means 10 MB and 200 MB.
+	MaxFileSize int64
+	MaxDirSize  int64
+}
+
+// Collector periodically collects samples and writes them to the diagnostic data directory.
+type Collector struct {
+	opts *NewCollectorOpts
+
+	f     *os.File // current file
+	size  int64    // size of the current file
+	chunk *chunk   // current chunk; nil if there are no samples yet
+}
+
+// NewCollector creates a new collector.
+func NewCollector(opts *NewCollectorOpts) (*Collector, error) {
+	o := *opts
+
+	if o.Period == 0 {
+		o.Period = DefaultPeriod
+	}
+
+	if o.MaxFileSize == 0 {
+		o.MaxFileSize = defaultMaxFileSize
+	}
+
+	if o.MaxDirSize == 0 {
+		o.MaxDirSize = defaultMaxDirSize
+	}
+
+	if err := os.MkdirAll(o.Dir, 0o755); err != nil {
+		return nil, lazyerrors.Error(err)
+	}
+
+	return &Collector{
+		opts: &o,
+	}, nil
+}
+
+// Run collects samples until ctx is done.
+// Then the current chunk is written, and the current file is closed.
+func (c *Collector) Run(ctx context.Context) {
+	l := c.opts.Logger
+
+	if err := c.rotate(); err != nil {
+		l.Error("Failed to open diagnostic data file", zap.Error(err))
+		return
+	}
+
+	if err := c.recoverInterim(); err != nil {
+		l.Warn("Failed to recover interim diagnostic data", zap.Error(err))
+	}
+
+	defer func() {
+		if err := c.flush(); err != nil {
+			l.Error("Failed to write diagnostic data", zap.Error(err))
+		}
+
+		if err := c.f.Close(); err != nil {
+			l.Error("Failed to close diagnostic data file", zap.Error(err))
+		}
+	}()
+
+	ticker := time.NewTicker(c.opts.Period)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		if err := c.collect(ctx); err != nil {
+			l.Warn("Failed to collect diagnostic data", zap.Error(err))
+		}
+	}
+}
+
+// collect collects a single sample and writes chunks and files as needed.
+func (c *Collector) collect(ctx context.Context) error {
+	start := time.Now()
+
+	sample, err := c.opts.Sample(ctx)
+	if err != nil {
+		return lazyerrors.Error(err)
+	}
+
+	// {start: ..., serverStatus: {...}, end: ...}, like MongoDB
+	doc := must.NotFail(types.NewDocument("start", start))
+	m := sample.Map()
+	for _, k := range sample.Keys() {
+		must.NoError(doc.Set(k, m[k]))
+	}
+	must.NoError(doc.Set("end", time.Now()))
+
+	return c.add(start, doc)
+}
+
+// add adds the sample to the current chunk and writes chunks and files as needed.
+func (c *Collector) add(t time.Time, sample *types.Document) error {
+	if c.chunk != nil && !c.chunk.add(sample) {
+		// structure changed
+		if err := c.flush(); err != nil {
+			return err
+		}
+	}
+
+	if c.chunk == nil {
+		c.chunk = newChunk(t, sample)
+	}
+
+	switch {
+	case c.chunk.samples >= maxChunkSamples:
+		return c.flush()
+	case c.chunk.samples%interimSamples == 0:
+		return c.writeInterim()
+	default:
+		return nil
+	}
+}
+
+// flush writes the current chunk to the current file, removes the interim file, and rotates files if needed.
+func (c *Collector) flush() error {
+	if c.chunk == nil {
+		return nil
+	}
+
+	doc, err := c.chunk.document()
+	if err != nil {
+		return err
+	}
+
+	c.chunk = nil
+
+	if err = c.write(doc); err != nil {
+		return err
+	}
+
+	if err = os.Remove(filepath.Join(c.opts.Dir, interimFile)); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return lazyerrors.Error(err)
+	}
+
+	if c.size < c.opts.MaxFileSize {
+		return nil
+	}
+
+	return c.rotate()
+}
+
+// write writes the document to the current file.
+func (c *Collector) write(doc *types.Document) error {
+	b, err := bson.MustConvertDocument(doc).MarshalBinary()
+	if err != nil {
+		return lazyerrors.Error(err)
+	}
+
+	if _, err = c.f.Write(b); err != nil {
+		return lazyerrors.Error(err)
+	}
+
+	c.size += int64(len(b))
+
+	return nil
+}
+
+// writeInterim writes the current chunk to the interim file.
+func (c *Collector) writeInterim() error {
+	doc, err := c.chunk.document()
+	if err != nil {
+		return err
+	}
+
+	b, err := bson.MustConvertDocument(doc).MarshalBinary()
+	if err != nil {
+		return lazyerrors.Error(err)
+	}
+
+	// write the whole file atomically
+	path := filepath.Join(c.opts.Dir, interimFile)
+	if err = os.WriteFile(path+".tmp", b, 0o644); err != nil {
+		return lazyerrors.Error(err)
+	}
+
+	if err = os.Rename(path+".tmp", path); err != nil {
+		return lazyerrors.Error(err)
+	}
+
+	return nil
+}
+
+// recoverInterim moves chunks from the interim file left by the previous process to the current file.
+func (c *Collector) recoverInterim() error {
+	path := filepath.Join(c.opts.Dir, interimFile)
+
+	b, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return lazyerrors.Error(err)
+	}
+
+	if len(b) > 0 {
+		if _, err = c.f.Write(b); err != nil {
+			return lazyerrors.Error(err)
+		}
+		c.size += int64(len(b))
+	}
+
+	if err = os.Remove(path); err != nil {
+		return lazyerrors.Error(err)
+	}
+
+	return nil
+}
+
+// rotate closes the current file, if any, opens a new one with the metadata document,
+// and removes the oldest files if the directory is too large.
+func (c *Collector) rotate() error {
+	if c.f != nil {
+		if err := c.f.Close(); err != nil {
+			return lazyerrors.Error(err)
+		}
+	}
+
+	now := time.Now().UTC()
+	name := filePrefix + now.Format("2006-01-02T15-04-05Z") + "-00000"
+
+	f, err := os.OpenFile(filepath.Join(c.opts.Dir, name), os.O_WRONLY|os.O_CREATE|os.O_APPEND, 0o644)
+	if err != nil {
+		return lazyerrors.Error(err)
+	}
+
+	c.f = f
+	if c.size, err = f.Seek(0, io.SeekEnd); err != nil {
+		return lazyerrors.Error(err)
+	}
+
+	metadata := must.NotFail(types.NewDocument("start", now))
+	if m := c.opts.Metadata; m != nil {
+		metadata = m.DeepCopy()
+		must.NoError(metadata.Set("start", now))
+	}
+	must.NoError(metadata.Set("end", now))
+
+	doc := must.NotFail(types.NewDocument(
+		"_id", now,
+		"type", typeMetadata,
+		"doc", metadata,
+	))
+
+	if err = c.write(doc); err != nil {
+		return err
+	}
+
+	return c.removeOld(name)
+}
+
+// removeOld removes the oldest metrics files, except the current one, while all files are larger than allowed.
+func (c *Collector) removeOld(current string) error {
+	entries, err := os.ReadDir(c.opts.Dir)
+	if err != nil {
+		return lazyerrors.Error(err)
+	}
+
+	type file struct {
+		name string
+		size int64
+	}
+
+	var files []file
+	var total int64
+
+	for _, e := range entries {
+		name := e.Name()
+		if !strings.HasPrefix(name, filePrefix) || strings.HasPrefix(name, interimFile) || e.IsDir() {
+			continue
+		}
+
+		info, err := e.Info()
+		if err != nil {
+			return lazyerrors.Error(err)
+		}
+
+		files = append(files, file{name: name, size: info.Size()})
+		total += info.Size()
+	}
+
+	// names start with timestamps
+	sort.Slice(files, func(i, j int) bool { return files[i].name < files[j].name })
+
+	for _, f := range files {
+		if total <= c.opts.MaxDirSize || f.name == current {
+			break
+		}
+
+		if err = os.Remove(filepath.Join(c.opts.Dir, f.name)); err != nil {
+			return lazyerrors.Error(err)
+		}
+
+		total -= f.size
+	}
+
+	return nil
+}
@@ -0,0 +1,305 @@
+// Copyright 2021 FerretDB Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ftdc
+
+import (
+	"bufio"
+	"bytes"
+	"compress/zlib"
+	"context"
+	"encoding/binary"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap/zaptest"
+
+	"github.com/FerretDB/FerretDB/internal/bson"
+	"github.com/FerretDB/FerretDB/internal/types"
+	"github.com/FerretDB/FerretDB/internal/util/must"
+	"github.com/FerretDB/FerretDB/internal/util/testutil"
+)
+
+// readDocuments reads all BSON documents from the file.
+func readDocuments(t *testing.T, path string) []*types.Document {
+	t.Helper()
+
+	b, err := os.ReadFile(path)
+	require.NoError(t, err)
+
+	var res []*types.Document
+
+	r := bufio.NewReader(bytes.NewReader(b))
+	for {
+		if _, err = r.Peek(1); err == io.EOF {
+			return res
+		}
+
+		var doc bson.Document
+		require.NoError(t, doc.ReadFrom(r))
+		res = append(res, must.NotFail(types.ConvertDocument(&doc)))
+	}
+}
+
+// decodeChunk decodes metric chunk document like FTDC analysis tools do.
+// It returns the reference sample and values of all metrics.
+func decodeChunk(t *testing.T, doc *types.Document) (*types.Document, [][]int64) {
+	t.Helper()
+
+	assert.Equal(t, typeMetricChunk, must.NotFail(doc.Get("type")))
+
+	data := must.NotFail(doc.Get("data")).(types.Binary).B
+	l := binary.LittleEndian.Uint32(data)
+
+	zr, err := zlib.NewReader(bytes.NewReader(data[4:]))
+	require.NoError(t, err)
+
+	b, err := io.ReadAll(zr)
+	require.NoError(t, err)
+	require.Len(t, b, int(l))
+
+	r := bufio.NewReader(bytes.NewReader(b))
+
+	var refDoc bson.Document
+	require.NoError(t, refDoc.ReadFrom(r))
+	ref := must.NotFail(types.ConvertDocument(&refDoc))
+
+	var metricsCount, deltasCount uint32
+	require.NoError(t, binary.Read(r, binary.LittleEndian, &metricsCount))
+	require.NoError(t, binary.Read(r, binary.LittleEndian, &deltasCount))
+
+	var schema []string
+	var values []int64
+	extractMetrics(ref, "", &schema, &values)
+	require.Len(t, values, int(metricsCount))
+
+	metrics := make([][]int64, metricsCount)
+	var zeros uint64
+
+	for i := range metrics {
+		metrics[i] = []int64{values[i]}
+
+		for j := uint32(0); j < deltasCount; j++ {
+			var delta uint64
+
+			if zeros > 0 {
+				zeros--
+			} else {
+				delta, err = binary.ReadUvarint(r)
+				require.NoError(t, err)
+
+				if delta == 0 {
+					zeros, err = binary.ReadUvarint(r)
+					require.NoError(t, err)
+				}
+			}
+
+			metrics[i] = append(metrics[i], metrics[i][j]+int64(delta))
+		}
+	}
+
+	_, err = r.ReadByte()
+	assert.Equal(t, io.EOF, err, "trailing data")
+
+	return ref, metrics
+}
+
+func TestChunk(t *testing.T) {
+	t.Parallel()
+
+	start := time.Date(2022, 10, 1, 12, 0, 0, 0, time.UTC)
+
+	sample := func(i int) *types.Document {
+		return must.NotFail(types.NewDocument(
+			"start", start.Add(time.Duration(i)*time.Second),
+			"serverStatus", must.NotFail(types.NewDocument(
+				"host", "test",
+				"counter", int64(i*i),
+				"constant", int32(42),
+				"decreasing", float64(-i),
+				"flag", i%2 == 0,
+				"ts", types.Timestamp(uint64(i)<<32|7),
+				"array", must.NotFail(types.NewArray(int64(1), int64(i))),
+			)),
+		))
+	}
+
+	c := newChunk(start, sample(0))
+	for i := 1; i < 20; i++ {
+		require.True(t, c.add(sample(i)))
+	}
+
+	changed := sample(20)
+	changed.Remove("start")
+	assert.False(t, c.add(changed))
+
+	doc, err := c.document()
+	require.NoError(t, err)
+	assert.Equal(t, start, must.NotFail(doc.Get("_id")))
+
+	ref, metrics := decodeChunk(t, doc)
+	testutil.AssertEqual(t, sample(0), ref)
+
+	assert.Equal(t, []string{
+		"start:date",
+		"serverStatus.counter:long",
+		"serverStatus.constant:int",
+		"serverStatus.decreasing:double",
+		"serverStatus.flag:bool",
+		"serverStatus.ts:timestamp",
+		"serverStatus.ts:timestamp",
+		"serverStatus.array.0:long",
+		"serverStatus.array.1:long",
+	}, c.schema)
+
+	require.Len(t, metrics, 9)
+
+	for i := 0; i < 20; i++ {
+		var flag int64
+		if i%2 == 0 {
+			flag = 1
+		}
+
+		expected := []int64{
+			start.Add(time.Duration(i) * time.Second).UnixMilli(),
+			int64(i * i),
+			42,
+			int64(-i),
+			flag,
+			int64(i),
+			7,
+			1,
+			int64(i),
+		}
+
+		for m := range metrics {
+			assert.Equal(t, expected[m], metrics[m][i], "sample %d, metric %s", i, c.schema[m])
+		}
+	}
+}
+
+func TestCollector(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+
+	// interim file left by the previous process
+	interim, err := newChunk(time.Date(2022, 10, 1, 12, 0, 0, 0, time.UTC), must.NotFail(types.NewDocument("previous", int64(1)))).document()
+	require.NoError(t, err)
+	b, err := bson.MustConvertDocument(interim).MarshalBinary()
+	require.NoError(t, err)
+	require.NoError(t, os.WriteFile(filepath.Join(dir, interimFile), b, 0o644))
+
+	var m sync.Mutex
+	var samples int64
+
+	c, err := NewCollector(&NewCollectorOpts{
+		Dir: dir,
+		Sample: func(context.Context) (*types.Document, error) {
+			m.Lock()
+			defer m.Unlock()
+
+			samples++
+
+			return types.NewDocument("serverStatus", must.NotFail(types.NewDocument("samples", samples)))
+		},
+		Metadata: must.NotFail(types.NewDocument("buildInfo", must.NotFail(types.NewDocument("version", "test")))),
+		Logger:   zaptest.NewLogger(t),
+		Period:   10 * time.Millisecond,
+	})
+	require.NoError(t, err)
+
+	ctx, cancel := context.WithCancel(context.Background())
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		c.Run(ctx)
+	}()
+
+	require.Eventually(t, func() bool {
+		m.Lock()
+		defer m.Unlock()
+
+		return samples >= 25
+	}, 5*time.Second, 10*time.Millisecond)
+
+	// interim file should contain pending samples
+	_, err = os.Stat(filepath.Join(dir, interimFile))
+	require.NoError(t, err)
+
+	cancel()
+	<-done
+
+	entries, err := os.ReadDir(dir)
+	require.NoError(t, err)
+	require.Len(t, entries, 1, "interim file should be removed")
+
+	name := entries[0].Name()
+	assert.True(t, strings.HasPrefix(name, filePrefix), name)
+
+	docs := readDocuments(t, filepath.Join(dir, name))
+	require.Len(t, docs, 3)
+
+	assert.Equal(t, typeMetadata, must.NotFail(docs[0].Get("type")))
+	metadata := must.NotFail(docs[0].Get("doc")).(*types.Document)
+	assert.Equal(t, "test", must.NotFail(metadata.GetByPath(types.NewPathFromString("buildInfo.version"))))
+
+	testutil.AssertEqual(t, interim, docs[1])
+
+	ref, metrics := decodeChunk(t, docs[2])
+	assert.Equal(t, int64(1), must.NotFail(ref.GetByPath(types.NewPathFromString("serverStatus.samples"))))
+
+	// start, serverStatus.samples, end
+	require.Len(t, metrics, 3)
+	for i, v := range metrics[1] {
+		assert.Equal(t, int64(i+1), v)
+	}
+}
+
+func TestRemoveOld(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+
+	for _, name := range []string{
+		"metrics.2022-10-01T00-00-00Z-00000",
+		"metrics.2022-10-02T00-00-00Z-00000",
+		"metrics.2022-10-03T00-00-00Z-00000",
+	} {
+		require.NoError(t, os.WriteFile(filepath.Join(dir, name), make([]byte, 100), 0o644))
+	}
+
+	c, err := NewCollector(&NewCollectorOpts{
+		Dir:        dir,
+		Logger:     zaptest.NewLogger(t),
+		MaxDirSize: 250,
+	})
+	require.NoError(t, err)
+
+	require.NoError(t, c.removeOld("metrics.2022-10-03T00-00-00Z-00000"))
+
+	entries, err := os.ReadDir(dir)
+	require.NoError(t, err)
+	require.Len(t, entries, 2)
+	assert.Equal(t, "metrics.2022-10-02T00-00-00Z-00000", entries[0].Name())
+	assert.Equal(t, "metrics.2022-10-03T00-00-00Z-00000", entries[1].Name())
+}
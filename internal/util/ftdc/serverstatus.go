@@ -0,0 +1,177 @@
+// Copyright 2021 FerretDB Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ftdc
+
+import (
+	"os"
+	"strings"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	dto "github.com/prometheus/client_model/go"
+
+	"github.com/FerretDB/FerretDB/internal/types"
+	"github.com/FerretDB/FerretDB/internal/util/lazyerrors"
+	"github.com/FerretDB/FerretDB/internal/util/must"
+	"github.com/FerretDB/FerretDB/internal/util/version"
+)
+
+// opcounters maps command names to serverStatus.opcounters fields; other commands are counted as "command".
+var opcounters = map[string]string{
+	"insert":  "insert",
+	"find":    "query",
+	"update":  "update",
+	"delete":  "delete",
+	"getMore": "getmore",
+}
+
+// ServerStatus returns serverStatus-like document built from metrics gathered from g.
+//
+// Fields that are used by FTDC analysis tools are filled from FerretDB's and process metrics.
+// All gathered metrics are also added to the prometheus field as-is.
+func ServerStatus(g prometheus.Gatherer, start time.Time) (*types.Document, error) {
+	mfs, err := g.Gather()
+	if err != nil {
+		return nil, lazyerrors.Error(err)
+	}
+
+	now := time.Now()
+	uptime := now.Sub(start)
+
+	var connected, accepted, bytesIn, bytesOut, requests float64
+	var resident, virtual float64
+	ops := map[string]float64{}
+
+	metrics := types.MakeDocument(len(mfs))
+
+	for _, mf := range mfs {
+		name := mf.GetName()
+
+		values := types.MakeDocument(len(mf.GetMetric()))
+		for _, m := range mf.GetMetric() {
+			v := metricValue(mf.GetType(), m)
+			labels := metricLabels(m)
+
+			switch name {
+			case "ferretdb_client_connected":
+				connected += v
+			case "ferretdb_client_accepts_total":
+				if labels == `error="0"` {
+					accepted += v
+				}
+			case "ferretdb_client_message_bytes_total":
+				switch labels {
+				case `direction="request"`:
+					bytesIn += v
+				case `direction="response"`:
+					bytesOut += v
+				}
+			case "ferretdb_client_requests_total":
+				requests += v
+
+				op, ok := opcounters[labelValue(m, "command")]
+				if !ok {
+					op = "command"
+				}
+				ops[op] += v
+			case "process_resident_memory_bytes":
+				resident = v
+			case "process_virtual_memory_bytes":
+				virtual = v
+			}
+
+			if labels == "" {
+				labels = "value"
+			}
+			must.NoError(values.Set(labels, v))
+		}
+
+		must.NoError(metrics.Set(name, values))
+	}
+
+	host, _ := os.Hostname()
+
+	return types.NewDocument(
+		"host", host,
+		"version", version.MongoDBVersion,
+		"process", "ferretdb",
+		"pid", int64(os.Getpid()),
+		"uptime", int64(uptime.Seconds()),
+		"uptimeMillis", uptime.Milliseconds(),
+		"uptimeEstimate", int64(uptime.Seconds()),
+		"localTime", now,
+		"connections", must.NotFail(types.NewDocument(
+			"current", int32(connected),
+			"totalCreated", int64(accepted),
+		)),
+		"network", must.NotFail(types.NewDocument(
+			"bytesIn", int64(bytesIn),
+			"bytesOut", int64(bytesOut),
+			"numRequests", int64(requests),
+		)),
+		"opcounters", must.NotFail(types.NewDocument(
+			"insert", int64(ops["insert"]),
+			"query", int64(ops["query"]),
+			"update", int64(ops["update"]),
+			"delete", int64(ops["delete"]),
+			"getmore", int64(ops["getmore"]),
+			"command", int64(ops["command"]),
+		)),
+		"mem", must.NotFail(types.NewDocument(
+			"bits", int32(64),
+			"resident", int32(resident/1024/1024),
+			"virtual", int32(virtual/1024/1024),
+			"supported", resident > 0,
+		)),
+		"prometheus", metrics,
+	)
+}
+
+// metricValue returns a single value of the metric; histograms and summaries are represented by their sums.
+func metricValue(t dto.MetricType, m *dto.Metric) float64 {
+	switch t {
+	case dto.MetricType_COUNTER:
+		return m.GetCounter().GetValue()
+	case dto.MetricType_GAUGE:
+		return m.GetGauge().GetValue()
+	case dto.MetricType_HISTOGRAM:
+		return m.GetHistogram().GetSampleSum()
+	case dto.MetricType_SUMMARY:
+		return m.GetSummary().GetSampleSum()
+	default:
+		return m.GetUntyped().GetValue()
+	}
+}
+
+// metricLabels returns metric labels in Prometheus text format, like `a="1",b="2"`.
+func metricLabels(m *dto.Metric) string {
+	pairs := make([]string, len(m.GetLabel()))
+	for i, l := range m.GetLabel() {
+		pairs[i] = l.GetName() + `="` + l.GetValue() + `"`
+	}
+
+	return strings.Join(pairs, ",")
+}
+
+// labelValue returns the value of the metric label with the given name, or empty string.
+func labelValue(m *dto.Metric, name string) string {
+	for _, l := range m.GetLabel() {
+		if l.GetName() == name {
+			return l.GetValue()
+		}
+	}
+
+	return ""
+}
@@ -0,0 +1,63 @@
+// Copyright 2021 FerretDB Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ftdc
+
+import (
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/FerretDB/FerretDB/internal/types"
+	"github.com/FerretDB/FerretDB/internal/util/must"
+)
+
+func TestServerStatus(t *testing.T) {
+	t.Parallel()
+
+	requests := prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "ferretdb_client_requests_total",
+	}, []string{"opcode", "command"})
+	requests.WithLabelValues("OP_MSG", "find").Add(3)
+	requests.WithLabelValues("OP_MSG", "insert").Add(2)
+	requests.WithLabelValues("OP_MSG", "ping").Add(1)
+
+	connected := prometheus.NewGauge(prometheus.GaugeOpts{
+		Name: "ferretdb_client_connected",
+	})
+	connected.Set(4)
+
+	r := prometheus.NewRegistry()
+	r.MustRegister(requests, connected)
+
+	doc, err := ServerStatus(r, time.Now().Add(-time.Minute))
+	require.NoError(t, err)
+
+	get := func(path string) any {
+		return must.NotFail(doc.GetByPath(types.NewPathFromString(path)))
+	}
+
+	assert.Equal(t, int64(60), get("uptime"))
+	assert.Equal(t, int32(4), get("connections.current"))
+	assert.Equal(t, int64(6), get("network.numRequests"))
+	assert.Equal(t, int64(3), get("opcounters.query"))
+	assert.Equal(t, int64(2), get("opcounters.insert"))
+	assert.Equal(t, int64(1), get("opcounters.command"))
+	assert.Equal(t, int64(0), get("opcounters.delete"))
+	assert.Equal(t, float64(3), get(`prometheus.ferretdb_client_requests_total.command="find",opcode="OP_MSG"`))
+	assert.Equal(t, float64(4), get("prometheus.ferretdb_client_connected.value"))
+}
@@ -0,0 +1,305 @@
+// Copyright 2021 FerretDB Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package ldapauth provides authentication of users against LDAP servers.
+package ldapauth
+
+import (
+	"context"
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"net"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/go-ldap/ldap/v3"
+
+	"github.com/FerretDB/FerretDB/internal/util/lazyerrors"
+)
+
+// Placeholders for bind DN templates and group search filter.
+const (
+	UsernamePlaceholder = "{username}"
+	DNPlaceholder       = "{dn}"
+)
+
+// DefaultGroupSearchFilter is used when Config.GroupSearchFilter is empty.
+const DefaultGroupSearchFilter = "(member=" + DNPlaceholder + ")"
+
+// DefaultTimeout is used when Config.Timeout is zero.
+const DefaultTimeout = 10 * time.Second
+
+// ErrInvalidCredentials is returned when username or password is not valid.
+var ErrInvalidCredentials = errors.New("invalid credentials")
+
+// Config represents LDAP authentication configuration.
+type Config struct {
+	// LDAP server URL with ldap:// or ldaps:// scheme.
+	URL string
+
+	// Bind DN templates with UsernamePlaceholder, like "uid={username},ou=users,dc=example,dc=com".
+	// They are tried in order until bind succeeds.
+	BindDNTemplates []string
+
+	// Base DN for searching groups of the authenticated user.
+	// Groups are not searched if empty.
+	GroupSearchBase string
+
+	// Group search filter with DNPlaceholder and/or UsernamePlaceholder; DefaultGroupSearchFilter if empty.
+	GroupSearchFilter string
+
+	// Maps group DNs to role names. Users get roles of all groups they are members of.
+	GroupRoles map[string][]string
+
+	// Use StartTLS for ldap:// URLs.
+	StartTLS bool
+
+	// TLS configuration for ldaps:// URLs and StartTLS; default if nil.
+	TLS *tls.Config
+
+	// Timeout for connecting and each request; DefaultTimeout if zero.
+	Timeout time.Duration
+}
+
+// groupRoles represents a single entry of Config.GroupRoles.
+type groupRoles struct {
+	dn    *ldap.DN
+	roles []string
+}
+
+// Authenticator checks usernames and passwords against LDAP server.
+type Authenticator struct {
+	cfg    Config
+	groups []groupRoles
+}
+
+// New validates configuration and returns a new Authenticator.
+func New(cfg *Config) (*Authenticator, error) {
+	u, err := url.Parse(cfg.URL)
+	if err != nil {
+		return nil, fmt.Errorf("ldapauth.New: invalid URL: %w", err)
+	}
+
+	switch u.Scheme {
+	case "ldap", "ldaps":
+	default:
+		return nil, fmt.Errorf("ldapauth.New: unexpected URL scheme %q", u.Scheme)
+	}
+
+	if cfg.StartTLS && u.Scheme == "ldaps" {
+		return nil, fmt.Errorf("ldapauth.New: StartTLS can't be used with ldaps:// URL")
+	}
+
+	if len(cfg.BindDNTemplates) == 0 {
+		return nil, fmt.Errorf("ldapauth.New: at least one bind DN template is required")
+	}
+
+	for _, t := range cfg.BindDNTemplates {
+		if !strings.Contains(t, UsernamePlaceholder) {
+			return nil, fmt.Errorf("ldapauth.New: bind DN template %q does not contain %s", t, UsernamePlaceholder)
+		}
+	}
+
+	a := &Authenticator{
+		cfg: *cfg,
+	}
+
+	if a.cfg.GroupSearchFilter == "" {
+		a.cfg.GroupSearchFilter = DefaultGroupSearchFilter
+	}
+
+	if a.cfg.Timeout == 0 {
+		a.cfg.Timeout = DefaultTimeout
+	}
+
+	for group, roles := range cfg.GroupRoles {
+		dn, err := ldap.ParseDN(group)
+		if err != nil {
+			return nil, fmt.Errorf("ldapauth.New: invalid group DN %q: %w", group, err)
+		}
+
+		a.groups = append(a.groups, groupRoles{dn: dn, roles: roles})
+	}
+
+	return a, nil
+}
+
+// Authenticate binds to LDAP server as the given user and returns roles granted to that user
+// by group membership.
+//
+// ErrInvalidCredentials is returned if username or password is not valid.
+func (a *Authenticator) Authenticate(ctx context.Context, username, password string) ([]string, error) {
+	// LDAP servers treat simple bind with an empty password as an unauthenticated bind that succeeds
+	if username == "" || password == "" {
+		return nil, ErrInvalidCredentials
+	}
+
+	conn, err := a.dial(ctx)
+	if err != nil {
+		return nil, lazyerrors.Error(err)
+	}
+	defer conn.Close()
+
+	var dn string
+	for _, t := range a.cfg.BindDNTemplates {
+		candidate := bindDN(t, username)
+
+		err = conn.Bind(candidate, password)
+		if err == nil {
+			dn = candidate
+			break
+		}
+
+		if !ldap.IsErrorWithCode(err, ldap.LDAPResultInvalidCredentials) {
+			return nil, lazyerrors.Error(err)
+		}
+	}
+
+	if dn == "" {
+		return nil, ErrInvalidCredentials
+	}
+
+	if a.cfg.GroupSearchBase == "" {
+		return nil, nil
+	}
+
+	req := ldap.NewSearchRequest(
+		a.cfg.GroupSearchBase, ldap.ScopeWholeSubtree, ldap.NeverDerefAliases, 0, int(a.cfg.Timeout.Seconds()), false,
+		groupFilter(a.cfg.GroupSearchFilter, dn, username), []string{"dn"}, nil,
+	)
+
+	res, err := conn.Search(req)
+	if err != nil {
+		return nil, lazyerrors.Error(err)
+	}
+
+	groups := make([]string, len(res.Entries))
+	for i, e := range res.Entries {
+		groups[i] = e.DN
+	}
+
+	return a.roles(groups), nil
+}
+
+// dial connects to LDAP server, upgrading connection with StartTLS if configured.
+func (a *Authenticator) dial(ctx context.Context) (*ldap.Conn, error) {
+	timeout := a.cfg.Timeout
+	if deadline, ok := ctx.Deadline(); ok {
+		if d := time.Until(deadline); d < timeout {
+			timeout = d
+		}
+	}
+
+	opts := []ldap.DialOpt{ldap.DialWithDialer(&net.Dialer{Timeout: timeout})}
+	if a.cfg.TLS != nil {
+		opts = append(opts, ldap.DialWithTLSConfig(a.cfg.TLS))
+	}
+
+	conn, err := ldap.DialURL(a.cfg.URL, opts...)
+	if err != nil {
+		return nil, lazyerrors.Error(err)
+	}
+
+	conn.SetTimeout(timeout)
+
+	if a.cfg.StartTLS {
+		tlsConfig := a.cfg.TLS
+		if tlsConfig == nil {
+			u, _ := url.Parse(a.cfg.URL)
+			tlsConfig = &tls.Config{
+				ServerName: u.Hostname(),
+				MinVersion: tls.VersionTLS12,
+			}
+		}
+
+		if err = conn.StartTLS(tlsConfig); err != nil {
+			conn.Close()
+			return nil, lazyerrors.Error(err)
+		}
+	}
+
+	return conn, nil
+}
+
+// roles returns roles granted by the given groups.
+// Group DNs are compared case-insensitively.
+func (a *Authenticator) roles(groups []string) []string {
+	var res []string
+	seen := map[string]struct{}{}
+
+	for _, group := range groups {
+		dn, err := ldap.ParseDN(group)
+		if err != nil {
+			continue
+		}
+
+		for _, g := range a.groups {
+			if !g.dn.EqualFold(dn) {
+				continue
+			}
+
+			for _, role := range g.roles {
+				if _, ok := seen[role]; ok {
+					continue
+				}
+
+				seen[role] = struct{}{}
+				res = append(res, role)
+			}
+		}
+	}
+
+	return res
+}
+
+// bindDN returns DN for the given template and username.
+func bindDN(template, username string) string {
+	return strings.ReplaceAll(template, UsernamePlaceholder, escapeDN(username))
+}
+
+// groupFilter returns group search filter for the given template, user DN, and username.
+func groupFilter(template, dn, username string) string {
+	r := strings.NewReplacer(
+		DNPlaceholder, ldap.EscapeFilter(dn),
+		UsernamePlaceholder, ldap.EscapeFilter(username),
+	)
+
+	return r.Replace(template)
+}
+
+// escapeDN escapes a DN attribute value as described in RFC 4514, section 2.4.
+func escapeDN(value string) string {
+	var sb strings.Builder
+
+	for i := 0; i < len(value); i++ {
+		c := value[i]
+
+		switch {
+		case c == 0:
+			sb.WriteString(`\00`)
+			continue
+
+		case strings.IndexByte(`"+,;<>\=`, c) >= 0,
+			i == 0 && (c == ' ' || c == '#'),
+			i == len(value)-1 && c == ' ':
+			sb.WriteByte('\\')
+		}
+
+		sb.WriteByte(c)
+	}
+
+	return sb.String()
+}
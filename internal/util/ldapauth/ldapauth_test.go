@@ -0,0 +1,139 @@
+// Copyright 2021 FerretDB Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ldapauth
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNew(t *testing.T) {
+	t.Parallel()
+
+	for name, tc := range map[string]struct {
+		cfg Config
+		err string
+	}{
+		"Valid": {
+			cfg: Config{URL: "ldap://127.0.0.1:389", BindDNTemplates: []string{"uid={username},dc=example"}},
+		},
+		"Scheme": {
+			cfg: Config{URL: "http://127.0.0.1", BindDNTemplates: []string{"uid={username},dc=example"}},
+			err: `ldapauth.New: unexpected URL scheme "http"`,
+		},
+		"StartTLS": {
+			cfg: Config{URL: "ldaps://127.0.0.1", BindDNTemplates: []string{"uid={username},dc=example"}, StartTLS: true},
+			err: `ldapauth.New: StartTLS can't be used with ldaps:// URL`,
+		},
+		"NoTemplates": {
+			cfg: Config{URL: "ldap://127.0.0.1"},
+			err: `ldapauth.New: at least one bind DN template is required`,
+		},
+		"NoPlaceholder": {
+			cfg: Config{URL: "ldap://127.0.0.1", BindDNTemplates: []string{"uid=admin,dc=example"}},
+			err: `ldapauth.New: bind DN template "uid=admin,dc=example" does not contain {username}`,
+		},
+		"InvalidGroup": {
+			cfg: Config{
+				URL:             "ldap://127.0.0.1",
+				BindDNTemplates: []string{"uid={username},dc=example"},
+				GroupRoles:      map[string][]string{"invalid": {"read"}},
+			},
+			err: `ldapauth.New: invalid group DN "invalid": DN ended with incomplete type, value pair`,
+		},
+	} {
+		name, tc := name, tc
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			a, err := New(&tc.cfg)
+			if tc.err != "" {
+				assert.EqualError(t, err, tc.err)
+				return
+			}
+
+			require.NoError(t, err)
+			assert.Equal(t, DefaultGroupSearchFilter, a.cfg.GroupSearchFilter)
+			assert.Equal(t, DefaultTimeout, a.cfg.Timeout)
+		})
+	}
+}
+
+func TestAuthenticateEmpty(t *testing.T) {
+	t.Parallel()
+
+	// port 1 is not expected to be open; the server should not be contacted at all
+	a, err := New(&Config{URL: "ldap://127.0.0.1:1", BindDNTemplates: []string{"uid={username},dc=example"}})
+	require.NoError(t, err)
+
+	_, err = a.Authenticate(context.Background(), "user", "")
+	assert.Equal(t, ErrInvalidCredentials, err)
+
+	_, err = a.Authenticate(context.Background(), "", "password")
+	assert.Equal(t, ErrInvalidCredentials, err)
+}
+
+func TestBindDN(t *testing.T) {
+	t.Parallel()
+
+	for username, expected := range map[string]string{
+		"john":           "uid=john,ou=users,dc=example",
+		"doe, john":      `uid=doe\, john,ou=users,dc=example`,
+		"a+b=c":          `uid=a\+b\=c,ou=users,dc=example`,
+		" #lead trail ":  `uid=\ #lead trail\ ,ou=users,dc=example`,
+		"#hash":          `uid=\#hash,ou=users,dc=example`,
+		`"q"<x>;\`:       `uid=\"q\"\<x\>\;\\,ou=users,dc=example`,
+		"nul\x00byte":    `uid=nul\00byte,ou=users,dc=example`,
+		"юникод":         "uid=юникод,ou=users,dc=example",
+		"in # middle  x": "uid=in # middle  x,ou=users,dc=example",
+	} {
+		assert.Equal(t, expected, bindDN("uid={username},ou=users,dc=example", username), "%q", username)
+	}
+}
+
+func TestGroupFilter(t *testing.T) {
+	t.Parallel()
+
+	actual := groupFilter(DefaultGroupSearchFilter, `uid=a\,b,dc=example`, "a,b")
+	assert.Equal(t, `(member=uid=a\5c,b,dc=example)`, actual)
+
+	actual = groupFilter("(&(objectClass=posixGroup)(memberUid={username}))", "uid=x,dc=example", "x*)(uid=*")
+	assert.Equal(t, `(&(objectClass=posixGroup)(memberUid=x\2a\29\28uid=\2a))`, actual)
+}
+
+func TestRoles(t *testing.T) {
+	t.Parallel()
+
+	a, err := New(&Config{
+		URL:             "ldap://127.0.0.1",
+		BindDNTemplates: []string{"uid={username},dc=example"},
+		GroupRoles: map[string][]string{
+			"cn=admins,ou=groups,dc=example":     {"root"},
+			"cn=developers,ou=groups,dc=example": {"readWriteAnyDatabase", "clusterMonitor"},
+			"cn=ops,ou=groups,dc=example":        {"clusterMonitor"},
+		},
+	})
+	require.NoError(t, err)
+
+	assert.Nil(t, a.roles(nil))
+	assert.Nil(t, a.roles([]string{"cn=unknown,ou=groups,dc=example", "invalid"}))
+	assert.Equal(t, []string{"root"}, a.roles([]string{"CN=Admins, OU=Groups, DC=Example"}))
+
+	actual := a.roles([]string{"cn=developers,ou=groups,dc=example", "cn=ops,ou=groups,dc=example"})
+	assert.Equal(t, []string{"readWriteAnyDatabase", "clusterMonitor"}, actual)
+}
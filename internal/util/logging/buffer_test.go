@@ -109,7 +109,7 @@ func TestCircularBuffer(t *testing.T) {
 		})
 	}
 
-	Setup(zap.DebugLevel)
+	Setup(zap.DebugLevel, FormatText)
 	logger := zap.L()
 	for n, tc := range []struct {
 		addMsg   string
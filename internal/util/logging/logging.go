@@ -17,17 +17,74 @@ package logging
 
 import (
 	"log"
+	"net/http"
 
 	"go.uber.org/zap"
 	"go.uber.org/zap/zapcore"
 )
 
-// Setup initializes logging with a given level.
-func Setup(level zapcore.Level) {
+// Format represents logging output format.
+type Format string
+
+const (
+	// FormatText is a human-readable format.
+	FormatText = Format("text")
+
+	// FormatJSON is a format with a single JSON object per line, see jsonEncoderConfig.
+	FormatJSON = Format("json")
+)
+
+// AllFormats includes all logging formats, with the first one being the default.
+var AllFormats = []Format{FormatText, FormatJSON}
+
+// jsonEncoderConfig is used for FormatJSON.
+// Field names should not be changed, because log indexing and alerting depend on them.
+var jsonEncoderConfig = zapcore.EncoderConfig{
+	TimeKey:        "time",
+	LevelKey:       "severity",
+	NameKey:        "component",
+	CallerKey:      "caller",
+	FunctionKey:    zapcore.OmitKey,
+	MessageKey:     "msg",
+	StacktraceKey:  "stacktrace",
+	LineEnding:     zapcore.DefaultLineEnding,
+	EncodeLevel:    zapcore.CapitalLevelEncoder,
+	EncodeTime:     zapcore.RFC3339NanoTimeEncoder,
+	EncodeDuration: zapcore.StringDurationEncoder,
+	EncodeCaller:   zapcore.ShortCallerEncoder,
+	EncodeName:     zapcore.FullNameEncoder,
+}
+
+// level is the current logging level; it could be changed at runtime.
+var level = zap.NewAtomicLevel()
+
+// GetLevel returns the current logging level.
+func GetLevel() zapcore.Level {
+	return level.Level()
+}
+
+// SetLevel changes the logging level of all loggers created by Setup.
+func SetLevel(l zapcore.Level) {
+	level.SetLevel(l)
+}
+
+// LevelHandler returns an HTTP handler that gets the current logging level with GET
+// and changes it with PUT, both using JSON like {"level":"debug"}.
+func LevelHandler() http.Handler {
+	return level
+}
+
+// Setup initializes logging with a given level and format.
+//
+// Development configuration is used if the level is debug or lower.
+// Changing the level at runtime with SetLevel does not change the configuration.
+func Setup(l zapcore.Level, format Format) {
+	level.SetLevel(l)
+
 	var config zap.Config
-	if level <= zapcore.DebugLevel {
+	if l <= zapcore.DebugLevel {
 		config = zap.Config{
-			Level:             zap.NewAtomicLevelAt(level),
+			Level:             level,
 			Development:       true,
 			DisableCaller:     false,
 			DisableStacktrace: false,
@@ -40,7 +97,7 @@ func Setup(level zapcore.Level) {
 		}
 	} else {
 		config = zap.Config{
-			Level:             zap.NewAtomicLevelAt(level),
+			Level:             level,
 			Development:       false,
 			DisableCaller:     false,
 			DisableStacktrace: false,
@@ -53,6 +110,16 @@ func Setup(level zapcore.Level) {
 		}
 	}
 
+	switch format {
+	case FormatText:
+		// keep console encoding
+	case FormatJSON:
+		config.Encoding = "json"
+		config.EncoderConfig = jsonEncoderConfig
+	default:
+		log.Fatalf("unknown log format %q", format)
+	}
+
 	logger, err := config.Build()
 	if err != nil {
 		log.Fatal(err)
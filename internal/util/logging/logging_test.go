@@ -0,0 +1,87 @@
+// Copyright 2021 FerretDB Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package logging
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+func TestLevel(t *testing.T) {
+	// not parallel because level is global
+
+	t.Cleanup(func() { SetLevel(zapcore.InfoLevel) })
+
+	SetLevel(zapcore.WarnLevel)
+	assert.Equal(t, zapcore.WarnLevel, GetLevel())
+
+	h := LevelHandler()
+
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/debug/loglevel", nil))
+	require.Equal(t, http.StatusOK, rec.Code)
+	assert.JSONEq(t, `{"level":"warn"}`, rec.Body.String())
+
+	rec = httptest.NewRecorder()
+	h.ServeHTTP(rec, httptest.NewRequest(http.MethodPut, "/debug/loglevel", strings.NewReader(`{"level":"debug"}`)))
+	require.Equal(t, http.StatusOK, rec.Code)
+	assert.Equal(t, zapcore.DebugLevel, GetLevel())
+
+	rec = httptest.NewRecorder()
+	h.ServeHTTP(rec, httptest.NewRequest(http.MethodPut, "/debug/loglevel", strings.NewReader(`{"level":"verbose"}`)))
+	assert.Equal(t, http.StatusBadRequest, rec.Code)
+	assert.Equal(t, zapcore.DebugLevel, GetLevel())
+}
+
+func TestJSONFormat(t *testing.T) {
+	t.Parallel()
+
+	entry := zapcore.Entry{
+		Level:      zapcore.WarnLevel,
+		Time:       time.Date(2022, 10, 1, 12, 0, 0, 0, time.UTC),
+		LoggerName: "listener",
+		Message:    "Command failed",
+		Caller:     zapcore.NewEntryCaller(0, "/src/clientconn/conn.go", 42, true),
+	}
+
+	buf, err := zapcore.NewJSONEncoder(jsonEncoderConfig).EncodeEntry(entry, []zapcore.Field{
+		zap.Uint64("connectionId", 7),
+		zap.String("command", "find"),
+	})
+	require.NoError(t, err)
+
+	var actual map[string]any
+	require.NoError(t, json.Unmarshal(buf.Bytes(), &actual))
+
+	expected := map[string]any{
+		"time":         "2022-10-01T12:00:00Z",
+		"severity":     "WARN",
+		"component":    "listener",
+		"caller":       "clientconn/conn.go:42",
+		"msg":          "Command failed",
+		"connectionId": float64(7),
+		"command":      "find",
+	}
+	assert.Equal(t, expected, actual)
+}
@@ -0,0 +1,148 @@
+// Copyright 2021 FerretDB Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package sdnotify implements systemd service notifications (see sd_notify(3))
+// and watchdog keep-alive pings for units with Type=notify and WatchdogSec set.
+//
+// All functions do nothing if the process was not started by systemd with notifications enabled.
+package sdnotify
+
+import (
+	"context"
+	"net"
+	"os"
+	"strconv"
+	"time"
+
+	"go.uber.org/zap"
+
+	"github.com/FerretDB/FerretDB/internal/util/lazyerrors"
+)
+
+// Service states.
+const (
+	Ready    = "READY=1"
+	Stopping = "STOPPING=1"
+	Watchdog = "WATCHDOG=1"
+)
+
+// Notify sends the given state to the service manager.
+//
+// It does nothing if NOTIFY_SOCKET environment variable is not set.
+func Notify(state string) error {
+	return notify(os.Getenv("NOTIFY_SOCKET"), state)
+}
+
+// notify sends the given state to the given socket, if it is not empty.
+func notify(socket, state string) error {
+	if socket == "" {
+		return nil
+	}
+
+	// abstract namespace socket
+	if socket[0] == '@' {
+		socket = "\x00" + socket[1:]
+	}
+
+	conn, err := net.DialUnix("unixgram", nil, &net.UnixAddr{Name: socket, Net: "unixgram"})
+	if err != nil {
+		return lazyerrors.Error(err)
+	}
+
+	defer conn.Close()
+
+	if _, err = conn.Write([]byte(state)); err != nil {
+		return lazyerrors.Error(err)
+	}
+
+	return nil
+}
+
+// WatchdogInterval returns the interval between watchdog pings expected by the service manager,
+// or zero if the watchdog is not enabled for this process.
+//
+// It is a half of the watchdog timeout, as recommended by sd_watchdog_enabled(3).
+func WatchdogInterval() time.Duration {
+	return watchdogInterval(os.Getenv("WATCHDOG_USEC"), os.Getenv("WATCHDOG_PID"), os.Getpid())
+}
+
+// watchdogInterval returns the interval between watchdog pings for the given environment variables values.
+func watchdogInterval(usec, pid string, ownPID int) time.Duration {
+	if usec == "" {
+		return 0
+	}
+
+	if pid != "" && pid != strconv.Itoa(ownPID) {
+		return 0
+	}
+
+	us, err := strconv.ParseInt(usec, 10, 64)
+	if err != nil || us <= 0 {
+		return 0
+	}
+
+	return time.Duration(us) * time.Microsecond / 2
+}
+
+// RunWatchdog sends watchdog pings every interval until ctx is done.
+//
+// Pings are not sent while check returns an error,
+// so the service manager restarts the service if the problem persists for the watchdog timeout.
+func RunWatchdog(ctx context.Context, interval time.Duration, check func(context.Context) error, l *zap.Logger) {
+	runWatchdog(ctx, interval, check, Notify, l)
+}
+
+// runWatchdog implements RunWatchdog with the given notify function.
+func runWatchdog(
+	ctx context.Context, interval time.Duration, check func(context.Context) error, notify func(string) error, l *zap.Logger,
+) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	var failing bool
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		checkCtx, cancel := context.WithTimeout(ctx, interval)
+		err := check(checkCtx)
+		cancel()
+
+		if err != nil {
+			if ctx.Err() != nil {
+				return
+			}
+
+			if !failing {
+				l.Warn("Health check failed, watchdog pings are stopped", zap.Error(err))
+				failing = true
+			}
+
+			continue
+		}
+
+		if failing {
+			l.Info("Health check passed, watchdog pings are resumed")
+			failing = false
+		}
+
+		if err = notify(Watchdog); err != nil {
+			l.Warn("Failed to send watchdog ping", zap.Error(err))
+		}
+	}
+}
@@ -0,0 +1,122 @@
+// Copyright 2021 FerretDB Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package sdnotify
+
+import (
+	"context"
+	"errors"
+	"net"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap/zaptest"
+)
+
+func TestNotify(t *testing.T) {
+	t.Parallel()
+
+	require.NoError(t, notify("", Ready))
+
+	socket := filepath.Join(t.TempDir(), "notify.sock")
+	conn, err := net.ListenUnixgram("unixgram", &net.UnixAddr{Name: socket, Net: "unixgram"})
+	require.NoError(t, err)
+	t.Cleanup(func() { conn.Close() })
+
+	require.NoError(t, notify(socket, Ready))
+
+	b := make([]byte, 64)
+	require.NoError(t, conn.SetReadDeadline(time.Now().Add(5*time.Second)))
+	n, err := conn.Read(b)
+	require.NoError(t, err)
+	assert.Equal(t, Ready, string(b[:n]))
+
+	assert.Error(t, notify(filepath.Join(t.TempDir(), "missing.sock"), Ready))
+}
+
+func TestWatchdogInterval(t *testing.T) {
+	t.Parallel()
+
+	assert.Zero(t, watchdogInterval("", "", 42))
+	assert.Zero(t, watchdogInterval("invalid", "", 42))
+	assert.Zero(t, watchdogInterval("0", "", 42))
+	assert.Zero(t, watchdogInterval("30000000", "43", 42))
+	assert.Equal(t, 15*time.Second, watchdogInterval("30000000", "", 42))
+	assert.Equal(t, 15*time.Second, watchdogInterval("30000000", "42", 42))
+}
+
+func TestRunWatchdog(t *testing.T) {
+	t.Parallel()
+
+	var m sync.Mutex
+	var checkErr error
+	var pings int
+
+	check := func(context.Context) error {
+		m.Lock()
+		defer m.Unlock()
+
+		return checkErr
+	}
+
+	notify := func(state string) error {
+		m.Lock()
+		defer m.Unlock()
+
+		assert.Equal(t, Watchdog, state)
+		pings++
+
+		return nil
+	}
+
+	getPings := func() int {
+		m.Lock()
+		defer m.Unlock()
+
+		return pings
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		runWatchdog(ctx, time.Millisecond, check, notify, zaptest.NewLogger(t))
+	}()
+
+	require.Eventually(t, func() bool { return getPings() > 0 }, 5*time.Second, time.Millisecond)
+
+	m.Lock()
+	checkErr = errors.New("wedged")
+	m.Unlock()
+
+	// wait for the ping that could be in progress
+	time.Sleep(20 * time.Millisecond)
+
+	stopped := getPings()
+	time.Sleep(20 * time.Millisecond)
+	assert.Equal(t, stopped, getPings())
+
+	m.Lock()
+	checkErr = nil
+	m.Unlock()
+
+	require.Eventually(t, func() bool { return getPings() > stopped }, 5*time.Second, time.Millisecond)
+
+	cancel()
+	<-done
+}
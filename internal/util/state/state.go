@@ -0,0 +1,216 @@
+// Copyright 2021 FerretDB Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package state provides FerretDB instance state that persists across restarts.
+package state
+
+import (
+	"crypto/rand"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"regexp"
+	"time"
+
+	"github.com/FerretDB/FerretDB/internal/util/lazyerrors"
+)
+
+// filename is the name of the state file in the state directory.
+const filename = "state.json"
+
+// keySize is the size of the cluster time signing key in bytes (HMAC-SHA1).
+const keySize = 20
+
+// uuidRe matches UUID in the canonical textual representation.
+var uuidRe = regexp.MustCompile(`^[0-9a-f]{8}-[0-9a-f]{4}-[0-9a-f]{4}-[0-9a-f]{4}-[0-9a-f]{12}$`)
+
+// State represents persistent instance state.
+type State struct {
+	// UUID identifies this FerretDB instance; it is generated on the first start.
+	//
+	// Unlike topologyVersion's processId, it does not change on restarts.
+	UUID string `json:"uuid"`
+
+	// ClusterTimeKey signs $clusterTime values; it is generated on the first start.
+	//
+	// It does not change on restarts, so clients could keep using cluster times signed before them.
+	ClusterTimeKey *Key `json:"cluster_time_key"`
+
+	// Metadata caches information about the instance, like FerretDB version and handler, from the last start;
+	// see Update.
+	Metadata map[string]string `json:"metadata,omitempty"`
+
+	dir string // state directory; empty if state is not persisted
+}
+
+// Key represents a signing key.
+type Key struct {
+	// ID is sent to clients together with signatures, like MongoDB's keyId.
+	// It is the key creation time in Unix seconds in the upper 32 bits.
+	ID int64 `json:"id"`
+
+	// Key is a secret HMAC-SHA1 key.
+	Key []byte `json:"key"`
+}
+
+// Load returns instance state from the given directory.
+// On the first start, the directory and the state file are created.
+// State files of previous versions are updated with missing values.
+//
+// If dir is empty, the state is not persisted, and a new UUID and key are generated on each call.
+func Load(dir string) (*State, error) {
+	if dir == "" {
+		return newState()
+	}
+
+	file := filepath.Join(dir, filename)
+
+	b, err := os.ReadFile(file)
+	if err == nil {
+		var s State
+		if err = json.Unmarshal(b, &s); err != nil {
+			return nil, fmt.Errorf("state.Load: failed to parse %s: %w", file, err)
+		}
+
+		// do not silently replace identifiers that might be persisted by clients
+		if !uuidRe.MatchString(s.UUID) {
+			return nil, fmt.Errorf("state.Load: invalid UUID %q in %s", s.UUID, file)
+		}
+
+		s.dir = dir
+
+		if s.ClusterTimeKey == nil {
+			if s.ClusterTimeKey, err = newKey(); err != nil {
+				return nil, err
+			}
+
+			if err = s.save(); err != nil {
+				return nil, err
+			}
+		}
+
+		if len(s.ClusterTimeKey.Key) != keySize {
+			return nil, fmt.Errorf("state.Load: invalid cluster time key %d in %s", s.ClusterTimeKey.ID, file)
+		}
+
+		return &s, nil
+	}
+
+	if !errors.Is(err, fs.ErrNotExist) {
+		return nil, lazyerrors.Error(err)
+	}
+
+	s, err := newState()
+	if err != nil {
+		return nil, err
+	}
+
+	s.dir = dir
+
+	if err = s.save(); err != nil {
+		return nil, err
+	}
+
+	return s, nil
+}
+
+// Update calls f to modify the state and persists it.
+//
+// It is not safe for concurrent use.
+func (s *State) Update(f func(s *State)) error {
+	f(s)
+
+	if s.dir == "" {
+		return nil
+	}
+
+	return s.save()
+}
+
+// newState returns a new state with a random UUID and key.
+func newState() (*State, error) {
+	var b [16]byte
+	if _, err := rand.Read(b[:]); err != nil {
+		return nil, lazyerrors.Error(err)
+	}
+
+	// version 4, variant 1 (RFC 4122)
+	b[6] = (b[6] & 0x0f) | 0x40
+	b[8] = (b[8] & 0x3f) | 0x80
+
+	key, err := newKey()
+	if err != nil {
+		return nil, err
+	}
+
+	return &State{
+		UUID:           fmt.Sprintf("%x-%x-%x-%x-%x", b[0:4], b[4:6], b[6:8], b[8:10], b[10:16]),
+		ClusterTimeKey: key,
+	}, nil
+}
+
+// newKey returns a new random key.
+func newKey() (*Key, error) {
+	key := make([]byte, keySize)
+	if _, err := rand.Read(key); err != nil {
+		return nil, lazyerrors.Error(err)
+	}
+
+	return &Key{
+		ID:  time.Now().Unix() << 32,
+		Key: key,
+	}, nil
+}
+
+// save writes state to the file in the state directory atomically, creating the directory if needed.
+func (s *State) save() error {
+	dir := s.dir
+
+	if err := os.MkdirAll(dir, 0o700); err != nil {
+		return lazyerrors.Error(err)
+	}
+
+	b, err := json.MarshalIndent(s, "", "  ")
+	if err != nil {
+		return lazyerrors.Error(err)
+	}
+
+	f, err := os.CreateTemp(dir, filename+".*.tmp")
+	if err != nil {
+		return lazyerrors.Error(err)
+	}
+
+	defer os.Remove(f.Name())
+
+	if _, err = f.Write(append(b, '\n')); err == nil {
+		err = f.Sync()
+	}
+
+	if e := f.Close(); err == nil {
+		err = e
+	}
+
+	if err != nil {
+		return lazyerrors.Error(err)
+	}
+
+	if err = os.Rename(f.Name(), filepath.Join(dir, filename)); err != nil {
+		return lazyerrors.Error(err)
+	}
+
+	return nil
+}
@@ -0,0 +1,112 @@
+// Copyright 2021 FerretDB Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package state
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLoad(t *testing.T) {
+	t.Parallel()
+
+	t.Run("NoDir", func(t *testing.T) {
+		t.Parallel()
+
+		s1, err := Load("")
+		require.NoError(t, err)
+		assert.Regexp(t, uuidRe, s1.UUID)
+		assert.Equal(t, byte('4'), s1.UUID[14])
+
+		assert.Len(t, s1.ClusterTimeKey.Key, keySize)
+
+		s2, err := Load("")
+		require.NoError(t, err)
+		assert.NotEqual(t, s1.UUID, s2.UUID)
+		assert.NotEqual(t, s1.ClusterTimeKey, s2.ClusterTimeKey)
+
+		require.NoError(t, s2.Update(func(s *State) { s.Metadata = map[string]string{"foo": "bar"} }))
+		assert.Equal(t, "bar", s2.Metadata["foo"])
+	})
+
+	t.Run("Persist", func(t *testing.T) {
+		t.Parallel()
+
+		dir := filepath.Join(t.TempDir(), "state")
+
+		s1, err := Load(dir)
+		require.NoError(t, err)
+		assert.Regexp(t, uuidRe, s1.UUID)
+
+		s2, err := Load(dir)
+		require.NoError(t, err)
+		assert.Equal(t, s1, s2)
+
+		entries, err := os.ReadDir(dir)
+		require.NoError(t, err)
+		require.Len(t, entries, 1)
+		assert.Equal(t, filename, entries[0].Name())
+
+		require.NoError(t, s2.Update(func(s *State) { s.Metadata = map[string]string{"version": "v0.9.0"} }))
+
+		s3, err := Load(dir)
+		require.NoError(t, err)
+		assert.Equal(t, s2, s3)
+		assert.Equal(t, s1.ClusterTimeKey, s3.ClusterTimeKey)
+		assert.Equal(t, "v0.9.0", s3.Metadata["version"])
+	})
+
+	t.Run("Upgrade", func(t *testing.T) {
+		t.Parallel()
+
+		dir := t.TempDir()
+
+		uuid := "9f1b5d1e-8a4c-4f0e-9a43-6d1b0c2e7f55"
+		require.NoError(t, os.WriteFile(filepath.Join(dir, filename), []byte(`{"uuid": "`+uuid+`"}`), 0o600))
+
+		s1, err := Load(dir)
+		require.NoError(t, err)
+		assert.Equal(t, uuid, s1.UUID)
+		require.NotNil(t, s1.ClusterTimeKey)
+		assert.Len(t, s1.ClusterTimeKey.Key, keySize)
+
+		s2, err := Load(dir)
+		require.NoError(t, err)
+		assert.Equal(t, s1, s2)
+	})
+
+	t.Run("Invalid", func(t *testing.T) {
+		t.Parallel()
+
+		dir := t.TempDir()
+
+		require.NoError(t, os.WriteFile(filepath.Join(dir, filename), []byte(`{"uuid": "foo"}`), 0o600))
+		_, err := Load(dir)
+		assert.ErrorContains(t, err, `invalid UUID "foo"`)
+
+		b := `{"uuid": "9f1b5d1e-8a4c-4f0e-9a43-6d1b0c2e7f55", "cluster_time_key": {"id": 1, "key": "Zm9v"}}`
+		require.NoError(t, os.WriteFile(filepath.Join(dir, filename), []byte(b), 0o600))
+		_, err = Load(dir)
+		assert.ErrorContains(t, err, "invalid cluster time key 1")
+
+		require.NoError(t, os.WriteFile(filepath.Join(dir, filename), []byte(`{`), 0o600))
+		_, err = Load(dir)
+		assert.ErrorContains(t, err, "failed to parse")
+	})
+}
@@ -21,7 +21,7 @@ import (
 
 	"github.com/stretchr/testify/require"
 
-	"github.com/FerretDB/FerretDB/internal/fjson"
+	"github.com/FerretDB/FerretDB/internal/extjson"
 	"github.com/FerretDB/FerretDB/internal/types"
 )
 
@@ -30,7 +30,7 @@ func Dump[T types.Type](tb testing.TB, o T) string {
 	tb.Helper()
 
 	// We might switch to go-spew or something else later.
-	b, err := fjson.Marshal(o)
+	b, err := extjson.Marshal(o, extjson.Canonical)
 	require.NoError(tb, err)
 
 	return string(IndentJSON(tb, b))
@@ -194,6 +194,10 @@ func equalScalars(tb testing.TB, v1, v2 any) bool {
 		}
 		return s1.Subtype == s2.Subtype && bytes.Equal(s1.B, s2.B)
 
+	case types.UndefinedType:
+		_, ok := v2.(types.UndefinedType)
+		return ok
+
 	case types.ObjectID:
 		s2, ok := v2.(types.ObjectID)
 		if !ok {
@@ -226,6 +230,34 @@ func equalScalars(tb testing.TB, v1, v2 any) bool {
 		}
 		return s1.Pattern == s2.Pattern && s1.Options == s2.Options
 
+	case types.DBPointer:
+		s2, ok := v2.(types.DBPointer)
+		if !ok {
+			return false
+		}
+		return s1 == s2
+
+	case types.JavaScript:
+		s2, ok := v2.(types.JavaScript)
+		if !ok {
+			return false
+		}
+		return s1 == s2
+
+	case types.Symbol:
+		s2, ok := v2.(types.Symbol)
+		if !ok {
+			return false
+		}
+		return s1 == s2
+
+	case types.JavaScriptWithScope:
+		s2, ok := v2.(types.JavaScriptWithScope)
+		if !ok {
+			return false
+		}
+		return s1.Code == s2.Code && equalDocuments(tb, s1.Scope, s2.Scope)
+
 	case int32:
 		s2, ok := v2.(int32)
 		if !ok {
@@ -247,6 +279,13 @@ func equalScalars(tb testing.TB, v1, v2 any) bool {
 		}
 		return s1 == s2
 
+	case types.Decimal128:
+		s2, ok := v2.(types.Decimal128)
+		if !ok {
+			return false
+		}
+		return s1 == s2
+
 	default:
 		tb.Fatalf("unhandled types %T, %T", v1, v2)
 		panic("not reached")
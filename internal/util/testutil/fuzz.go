@@ -20,6 +20,7 @@ import (
 	"fmt"
 	"os"
 	"path/filepath"
+	"runtime"
 	"testing"
 
 	"github.com/stretchr/testify/require"
@@ -44,3 +45,30 @@ func WriteSeedCorpusFile(tb testing.TB, funcName string, b []byte) {
 	err = os.WriteFile(filename, buf.Bytes(), 0o666)
 	require.NoError(tb, err)
 }
+
+// DocumentsSeedCorpus returns BSON documents from the seed corpus shared by fuzz targets
+// that accept documents or messages containing them (in bson, fjson, and wire packages).
+//
+// Documents are stored as files in testutil's testdata/documents directory;
+// interesting inputs found by any of those fuzz targets should be added there.
+func DocumentsSeedCorpus(tb testing.TB) [][]byte {
+	tb.Helper()
+
+	_, file, _, ok := runtime.Caller(0)
+	require.True(tb, ok)
+
+	dir := filepath.Join(filepath.Dir(file), "testdata", "documents")
+
+	entries, err := os.ReadDir(dir)
+	require.NoError(tb, err)
+
+	res := make([][]byte, 0, len(entries))
+	for _, e := range entries {
+		b, err := os.ReadFile(filepath.Join(dir, e.Name()))
+		require.NoError(tb, err)
+
+		res = append(res, b)
+	}
+
+	return res
+}
@@ -0,0 +1,265 @@
+// Copyright 2021 FerretDB Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package testutil
+
+import (
+	"fmt"
+	"math"
+	"math/rand"
+	"testing"
+	"time"
+
+	"github.com/FerretDB/FerretDB/internal/types"
+	"github.com/FerretDB/FerretDB/internal/util/must"
+)
+
+// GeneratorTypes contains names of types that could be generated by Generator.
+//
+// They are the same as $type aliases.
+var GeneratorTypes = []string{
+	"double", "string", "object", "array", "binData", "objectId", "bool", "date",
+	"null", "regex", "int", "timestamp", "long", "decimal",
+}
+
+// GeneratorOpts represents Generator options.
+type GeneratorOpts struct {
+	// Maximal nesting depth of objects and arrays; 0 means that only top-level fields are generated.
+	MaxDepth int
+
+	// Maximal number of fields in objects and elements in arrays (at least one field is generated).
+	MaxFields int
+
+	// Maximal length of strings, binary data, and regular expressions.
+	MaxLength int
+
+	// Names of generated types (see GeneratorTypes); repeated names make them more frequent.
+	// If empty, all types are generated with the same frequency.
+	Types []string
+}
+
+// GeneratorSizes contains options for small, medium, and large documents.
+//
+// They are used by benchmarks, so results for different handlers and changes could be compared.
+var GeneratorSizes = []struct {
+	Name string
+	Opts *GeneratorOpts
+}{{
+	Name: "Small",
+	Opts: &GeneratorOpts{MaxDepth: 0, MaxFields: 5, MaxLength: 10},
+}, {
+	Name: "Medium",
+	Opts: &GeneratorOpts{MaxDepth: 2, MaxFields: 10, MaxLength: 100},
+}, {
+	Name: "Large",
+	Opts: &GeneratorOpts{MaxDepth: 2, MaxFields: 20, MaxLength: 1000},
+}}
+
+// Generator generates pseudo-random documents for tests, benchmarks, and fuzzing seed corpora.
+//
+// The same seed and options produce the same documents, so failures could be reproduced.
+// It is not safe for concurrent use.
+type Generator struct {
+	r      *rand.Rand
+	opts   GeneratorOpts
+	nextID int32
+}
+
+// NewGenerator returns a new Generator with the given seed and options.
+//
+// If seed is 0, a random seed is used.
+// The seed is logged, so it could be passed there to reproduce the test failure.
+// If opts is nil, default options are used.
+func NewGenerator(tb testing.TB, seed int64, opts *GeneratorOpts) *Generator {
+	tb.Helper()
+
+	if seed == 0 {
+		seed = time.Now().UnixNano()
+	}
+
+	tb.Logf("Generator seed: %d", seed)
+
+	var o GeneratorOpts
+	if opts != nil {
+		o = *opts
+	}
+
+	if o.MaxFields <= 0 {
+		o.MaxFields = 10
+	}
+
+	if o.MaxLength <= 0 {
+		o.MaxLength = 20
+	}
+
+	if len(o.Types) == 0 {
+		o.Types = GeneratorTypes
+	}
+
+	for _, t := range o.Types {
+		var known bool
+		for _, gt := range GeneratorTypes {
+			if t == gt {
+				known = true
+				break
+			}
+		}
+
+		if !known {
+			tb.Fatalf("unknown generator type %q", t)
+		}
+	}
+
+	return &Generator{
+		r:      rand.New(rand.NewSource(seed)),
+		opts:   o,
+		nextID: 1,
+	}
+}
+
+// Document returns a new document with sequential int32 _id and pseudo-random fields.
+func (g *Generator) Document() *types.Document {
+	doc := must.NotFail(types.NewDocument("_id", g.nextID))
+	g.nextID++
+
+	g.fillDocument(doc, g.opts.MaxDepth)
+
+	return doc
+}
+
+// Documents returns n new documents; see Document.
+func (g *Generator) Documents(n int) []*types.Document {
+	res := make([]*types.Document, n)
+	for i := range res {
+		res[i] = g.Document()
+	}
+
+	return res
+}
+
+// fillDocument adds pseudo-random fields with values of the given maximal depth to the document.
+func (g *Generator) fillDocument(doc *types.Document, depth int) {
+	n := 1 + g.r.Intn(g.opts.MaxFields)
+	for i := 0; i < n; i++ {
+		// field names are unique and do not contain special characters
+		must.NoError(doc.Set(fmt.Sprintf("f%d", doc.Len()), g.value(depth)))
+	}
+}
+
+// value returns a pseudo-random value of the given maximal depth.
+func (g *Generator) value(depth int) any {
+	t := g.opts.Types[g.r.Intn(len(g.opts.Types))]
+
+	// replace composite types with scalars at the maximal depth
+	for depth <= 0 && (t == "object" || t == "array") {
+		if g.onlyComposites() {
+			return g.r.Int31()
+		}
+
+		t = g.opts.Types[g.r.Intn(len(g.opts.Types))]
+	}
+
+	switch t {
+	case "double":
+		return g.double()
+	case "string":
+		return g.string()
+	case "object":
+		doc := must.NotFail(types.NewDocument())
+		g.fillDocument(doc, depth-1)
+
+		return doc
+	case "array":
+		n := g.r.Intn(g.opts.MaxFields + 1)
+		arr := types.MakeArray(n)
+		for i := 0; i < n; i++ {
+			must.NoError(arr.Append(g.value(depth - 1)))
+		}
+
+		return arr
+	case "binData":
+		subtypes := []types.BinarySubtype{types.BinaryGeneric, types.BinaryUser}
+		return types.Binary{Subtype: subtypes[g.r.Intn(len(subtypes))], B: g.bytes()}
+	case "objectId":
+		var id types.ObjectID
+		_, _ = g.r.Read(id[:])
+
+		return id
+	case "bool":
+		return g.r.Intn(2) == 1
+	case "date":
+		// from 1970 to 2100 with milliseconds precision
+		return time.UnixMilli(g.r.Int63n(4102444800000)).UTC()
+	case "null":
+		return types.Null
+	case "regex":
+		options := []string{"", "i", "m", "s", "x", "ims"}
+		return types.Regex{Pattern: g.string(), Options: options[g.r.Intn(len(options))]}
+	case "int":
+		return g.r.Int31() - math.MaxInt32/2
+	case "timestamp":
+		return types.Timestamp(g.r.Uint64())
+	case "long":
+		return g.r.Int63() - math.MaxInt64/2
+	case "decimal":
+		return types.NewDecimal128FromInt64(g.r.Int63n(1<<40) - 1<<39)
+	default:
+		panic(fmt.Sprintf("not reached: %q", t))
+	}
+}
+
+// onlyComposites returns true if only composite types are generated.
+func (g *Generator) onlyComposites() bool {
+	for _, t := range g.opts.Types {
+		if t != "object" && t != "array" {
+			return false
+		}
+	}
+
+	return true
+}
+
+// double returns a pseudo-random double value; edge values are returned more often than others.
+func (g *Generator) double() float64 {
+	edge := []float64{
+		0, math.Copysign(0, -1), math.Inf(+1), math.Inf(-1), math.NaN(),
+		math.MaxFloat64, math.SmallestNonzeroFloat64,
+	}
+
+	if g.r.Intn(4) == 0 {
+		return edge[g.r.Intn(len(edge))]
+	}
+
+	return (g.r.Float64() - 0.5) * math.Pow(10, float64(g.r.Intn(20)))
+}
+
+// string returns a pseudo-random string of ASCII letters and digits.
+func (g *Generator) string() string {
+	const chars = "abcdefghijklmnopqrstuvwxyzABCDEFGHIJKLMNOPQRSTUVWXYZ0123456789"
+
+	b := make([]byte, g.r.Intn(g.opts.MaxLength+1))
+	for i := range b {
+		b[i] = chars[g.r.Intn(len(chars))]
+	}
+
+	return string(b)
+}
+
+// bytes returns pseudo-random bytes.
+func (g *Generator) bytes() []byte {
+	b := make([]byte, g.r.Intn(g.opts.MaxLength+1))
+	_, _ = g.r.Read(b)
+
+	return b
+}
@@ -0,0 +1,113 @@
+// Copyright 2021 FerretDB Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package testutil
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/FerretDB/FerretDB/internal/types"
+	"github.com/FerretDB/FerretDB/internal/util/must"
+)
+
+func TestGenerator(t *testing.T) {
+	t.Parallel()
+
+	t.Run("Reproducible", func(t *testing.T) {
+		t.Parallel()
+
+		docs1 := NewGenerator(t, 42, nil).Documents(100)
+		docs2 := NewGenerator(t, 42, nil).Documents(100)
+		docs3 := NewGenerator(t, 43, nil).Documents(100)
+
+		for i := range docs1 {
+			AssertEqual(t, docs1[i], docs2[i])
+		}
+
+		assert.False(t, equal(t, docs1[0], docs3[0]))
+	})
+
+	t.Run("Options", func(t *testing.T) {
+		t.Parallel()
+
+		opts := &GeneratorOpts{
+			MaxDepth:  2,
+			MaxFields: 3,
+			MaxLength: 5,
+			Types:     []string{"object", "array", "string", "string"},
+		}
+
+		for i, doc := range NewGenerator(t, 0, opts).Documents(100) {
+			assert.Equal(t, int32(i+1), must.NotFail(doc.Get("_id")))
+
+			// _id and at most three fields
+			assert.LessOrEqual(t, doc.Len(), 4)
+
+			checkGenerated(t, doc, 2, opts)
+		}
+	})
+
+	t.Run("UnknownType", func(t *testing.T) {
+		t.Parallel()
+
+		tb := &fatalTB{TB: t}
+		require.Panics(t, func() { NewGenerator(tb, 1, &GeneratorOpts{Types: []string{"minKey"}}) })
+		assert.True(t, tb.fatal)
+	})
+}
+
+// checkGenerated checks that the value of generated document's field has the expected type, depth, and size.
+func checkGenerated(t *testing.T, v any, depth int, opts *GeneratorOpts) {
+	t.Helper()
+
+	switch v := v.(type) {
+	case *types.Document:
+		require.GreaterOrEqual(t, depth, 0)
+
+		for _, k := range v.Keys() {
+			if k != "_id" {
+				checkGenerated(t, must.NotFail(v.Get(k)), depth-1, opts)
+			}
+		}
+
+	case *types.Array:
+		require.GreaterOrEqual(t, depth, 0)
+		assert.LessOrEqual(t, v.Len(), opts.MaxFields)
+
+		for i := 0; i < v.Len(); i++ {
+			checkGenerated(t, must.NotFail(v.Get(i)), depth-1, opts)
+		}
+
+	case string:
+		assert.LessOrEqual(t, len(v), opts.MaxLength)
+
+	default:
+		t.Fatalf("unexpected type %T", v)
+	}
+}
+
+// fatalTB is testing.TB that panics on Fatalf.
+type fatalTB struct {
+	testing.TB
+	fatal bool
+}
+
+// Fatalf implements testing.TB.
+func (tb *fatalTB) Fatalf(format string, args ...any) {
+	tb.fatal = true
+	panic("Fatalf")
+}
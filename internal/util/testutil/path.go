@@ -16,7 +16,6 @@ package testutil
 
 import (
 	"fmt"
-	"strconv"
 	"testing"
 	"time"
 
@@ -41,39 +40,8 @@ func GetByPath[T types.CompositeTypeInterface](tb testing.TB, comp T, path types
 func SetByPath[T types.CompositeTypeInterface](tb testing.TB, comp T, value any, path types.Path) {
 	tb.Helper()
 
-	l := path.Len()
-	require.NotZero(tb, l, "path is empty")
-
-	var next any = comp
-	for i, p := range path.Slice() {
-		last := i == l-1
-		switch c := next.(type) {
-		case *types.Document:
-			var err error
-			next, err = c.Get(p)
-			require.NoError(tb, err)
-
-			if last {
-				err = c.Set(p, value)
-				require.NoError(tb, err)
-			}
-
-		case *types.Array:
-			index, err := strconv.Atoi(p)
-			require.NoError(tb, err)
-
-			next, err = c.Get(index)
-			require.NoError(tb, err)
-
-			if last {
-				err = c.Set(index, value)
-				require.NoError(tb, err)
-			}
-
-		default:
-			tb.Fatalf("can't access %T by path %q", next, p)
-		}
-	}
+	require.True(tb, comp.HasByPath(path), "path %q does not exist", path)
+	require.NoError(tb, comp.SetByPath(path, value))
 }
 
 // CompareAndSetByPathNum asserts that two values with the same path in two objects (documents or arrays)
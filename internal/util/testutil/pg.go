@@ -16,7 +16,11 @@ package testutil
 
 import (
 	"context"
+	"fmt"
+	"hash/fnv"
+	"runtime"
 	"strings"
+	"sync"
 	"testing"
 
 	"github.com/stretchr/testify/require"
@@ -55,25 +59,98 @@ func PoolConnString(tb testing.TB, opts *PoolOpts) string {
 func Pool(ctx context.Context, tb testing.TB, opts *PoolOpts, l *zap.Logger) *pgdb.Pool {
 	tb.Helper()
 
-	pool, err := pgdb.NewPool(ctx, PoolConnString(tb, opts), l, false)
+	pool, err := pgdb.NewPool(ctx, PoolConnString(tb, opts), l, nil)
 	require.NoError(tb, err)
 	tb.Cleanup(pool.Close)
 
 	return pool
 }
 
+// maxSchemaNameLength is the maximal length of PostgreSQL identifiers.
+const maxSchemaNameLength = 63
+
+// schemaOwners maps names returned by SchemaName to package-qualified test names.
+var schemaOwners sync.Map
+
 // SchemaName returns a stable schema name for that test.
+//
+// The name consists of the test name (truncated if needed to fit into PostgreSQL limit)
+// and a hash of the package-qualified test name,
+// so tests with long names or with the same names in different packages
+// could be run concurrently against the same PostgreSQL instance.
+// Use SchemaOwner to get the test name back.
 func SchemaName(tb testing.TB) string {
 	tb.Helper()
 
+	owner := testPackage() + "." + tb.Name()
+
+	h := fnv.New32a()
+	_, _ = h.Write([]byte(owner))
+	suffix := fmt.Sprintf("_%08x", h.Sum32())
+
 	name := strings.ToLower(tb.Name())
 	name = strings.ReplaceAll(name, "/", "-")
 	name = strings.ReplaceAll(name, " ", "-")
 
-	require.Less(tb, len(name), 64)
+	if l := maxSchemaNameLength - len(suffix); len(name) > l {
+		name = name[:l]
+	}
+
+	name += suffix
+	schemaOwners.Store(name, owner)
+
 	return name
 }
 
+// SchemaOwner returns package-qualified name of the test that got the given name from SchemaName,
+// or empty string if the name was not returned by SchemaName in this process.
+//
+// It is useful for debugging leftover schemas and databases.
+func SchemaOwner(name string) string {
+	owner, ok := schemaOwners.Load(name)
+	if !ok {
+		return ""
+	}
+
+	return owner.(string)
+}
+
+// testPackage returns the import path of the package containing the currently running test function.
+func testPackage() string {
+	pc := make([]uintptr, 64)
+	frames := runtime.CallersFrames(pc[:runtime.Callers(2, pc)])
+
+	// the outermost frame before testing package is the test function
+	var res string
+	for {
+		frame, more := frames.Next()
+		if strings.HasPrefix(frame.Function, "testing.") {
+			break
+		}
+
+		// skip frames of fuzz functions called via reflection
+		if pkg := funcPackage(frame.Function); pkg != "reflect" && pkg != "runtime" {
+			res = pkg
+		}
+
+		if !more {
+			break
+		}
+	}
+
+	return res
+}
+
+// funcPackage returns the import path of the package from the full function name.
+func funcPackage(f string) string {
+	slash := strings.LastIndex(f, "/") + 1
+	if dot := strings.Index(f[slash:], "."); dot >= 0 {
+		return f[:slash+dot]
+	}
+
+	return f
+}
+
 // Schema creates a new FerretDB database / PostgreSQL schema for testing.
 //
 // Name is stable for that test. It is automatically dropped if test pass.
@@ -81,7 +158,7 @@ func Schema(ctx context.Context, tb testing.TB, pool *pgdb.Pool) string {
 	tb.Helper()
 
 	schema := SchemaName(tb)
-	tb.Logf("Using schema %q.", schema)
+	tb.Logf("Using schema %q for %s.", schema, SchemaOwner(schema))
 
 	err := pool.DropDatabase(ctx, schema)
 	if err == pgdb.ErrTableNotExist {
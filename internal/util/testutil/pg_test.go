@@ -0,0 +1,64 @@
+// Copyright 2021 FerretDB Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package testutil
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSchemaName(t *testing.T) {
+	t.Parallel()
+
+	name := SchemaName(t)
+	assert.True(t, strings.HasPrefix(name, "testschemaname_"), "%q", name)
+	assert.Equal(t, name, SchemaName(t), "names should be stable")
+	assert.Equal(t, "github.com/FerretDB/FerretDB/internal/util/testutil.TestSchemaName", SchemaOwner(name))
+	assert.Empty(t, SchemaOwner("unknown"))
+
+	var names []string
+	for _, sub := range []string{
+		strings.Repeat("VeryLongSubtestName", 4) + "One",
+		strings.Repeat("VeryLongSubtestName", 4) + "Two",
+	} { //nolint:paralleltest // names are collected sequentially
+		t.Run(sub, func(t *testing.T) {
+			name := SchemaName(t)
+			assert.LessOrEqual(t, len(name), maxSchemaNameLength)
+			assert.Equal(t, "github.com/FerretDB/FerretDB/internal/util/testutil.TestSchemaName/"+sub, SchemaOwner(name))
+
+			names = append(names, name)
+		})
+	}
+
+	require.Len(t, names, 2)
+	assert.NotEqual(t, names[0], names[1])
+}
+
+func TestFuncPackage(t *testing.T) {
+	t.Parallel()
+
+	for f, expected := range map[string]string{
+		"github.com/FerretDB/FerretDB/integration.TestQuery":              "github.com/FerretDB/FerretDB/integration",
+		"github.com/FerretDB/FerretDB/integration/tigris.TestQuery.func1": "github.com/FerretDB/FerretDB/integration/tigris",
+		"github.com/FerretDB/FerretDB/internal/types.(*Document).Set":     "github.com/FerretDB/FerretDB/internal/types",
+		"testing.tRunner": "testing",
+		"main.main":       "main",
+	} {
+		assert.Equal(t, expected, funcPackage(f), f)
+	}
+}
@@ -0,0 +1,317 @@
+// Copyright 2021 FerretDB Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package tracing
+
+import (
+	"bytes"
+	"context"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"sync"
+	"time"
+
+	"go.uber.org/zap"
+
+	"github.com/FerretDB/FerretDB/internal/util/lazyerrors"
+)
+
+const (
+	// defaultBatchTimeout is the default value of NewTracerOpts.BatchTimeout.
+	defaultBatchTimeout = 5 * time.Second
+
+	// maxBatchSize is the maximal number of spans exported at once.
+	maxBatchSize = 512
+
+	// maxQueueSize is the maximal number of spans waiting for export; newer spans are dropped.
+	maxQueueSize = 4 * maxBatchSize
+
+	// exportTimeout is the timeout of a single export request.
+	exportTimeout = 10 * time.Second
+)
+
+// NewTracerOpts represents tracer configuration.
+type NewTracerOpts struct {
+	// OTLP/HTTP traces endpoint, like http://127.0.0.1:4318/v1/traces.
+	URL string
+
+	// Resource attributes of all spans; service.name should be set.
+	ServiceName       string
+	ServiceVersion    string
+	ServiceInstanceID string
+
+	Logger *zap.Logger
+
+	// Maximal duration spans wait for export; zero means 5 seconds.
+	BatchTimeout time.Duration
+}
+
+// Tracer starts root spans and exports completed spans to OTLP/HTTP endpoint with JSON encoding.
+//
+// Spans are exported in batches in the background; export errors are logged, and spans are dropped.
+// All methods could be called on nil receiver; nil Tracer records nothing.
+type Tracer struct {
+	opts   *NewTracerOpts
+	client *http.Client
+
+	rw      sync.Mutex
+	queue   []*Span
+	dropped int
+
+	flush chan struct{}
+	stop  chan struct{}
+	done  chan struct{}
+}
+
+// NewTracer creates a new tracer and starts exporting spans in the background.
+//
+// Tracer.Close should be called to export remaining spans and stop the tracer.
+func NewTracer(opts *NewTracerOpts) (*Tracer, error) {
+	u, err := url.Parse(opts.URL)
+	if err != nil {
+		return nil, lazyerrors.Error(err)
+	}
+
+	if u.Scheme != "http" && u.Scheme != "https" {
+		return nil, fmt.Errorf("unsupported OTLP URL scheme %q", u.Scheme)
+	}
+
+	if opts.BatchTimeout == 0 {
+		o := *opts
+		o.BatchTimeout = defaultBatchTimeout
+		opts = &o
+	}
+
+	t := &Tracer{
+		opts:   opts,
+		client: &http.Client{Timeout: exportTimeout},
+		flush:  make(chan struct{}, 1),
+		stop:   make(chan struct{}),
+		done:   make(chan struct{}),
+	}
+
+	go t.run()
+
+	return t, nil
+}
+
+// Start starts a new root span of the server handling a client request.
+//
+// If t is nil, the context itself and nil span are returned.
+// Span.End should be called when the request is handled.
+func (t *Tracer) Start(ctx context.Context, name string, attrs ...Attr) (context.Context, *Span) {
+	if t == nil {
+		return ctx, nil
+	}
+
+	s := &Span{
+		tracer: t,
+		name:   name,
+		kind:   kindServer,
+		start:  time.Now(),
+		attrs:  attrs,
+	}
+	randomID(s.traceID[:])
+	randomID(s.spanID[:])
+
+	return context.WithValue(ctx, spanKey{}, s), s
+}
+
+// Close exports remaining spans and stops the tracer.
+func (t *Tracer) Close() {
+	if t == nil {
+		return
+	}
+
+	close(t.stop)
+	<-t.done
+}
+
+// add queues a completed span for export.
+func (t *Tracer) add(s *Span) {
+	t.rw.Lock()
+	defer t.rw.Unlock()
+
+	if len(t.queue) >= maxQueueSize {
+		t.dropped++
+		return
+	}
+
+	t.queue = append(t.queue, s)
+
+	if len(t.queue) >= maxBatchSize {
+		select {
+		case t.flush <- struct{}{}:
+		default:
+		}
+	}
+}
+
+// run exports queued spans until the tracer is closed.
+func (t *Tracer) run() {
+	defer close(t.done)
+
+	ticker := time.NewTicker(t.opts.BatchTimeout)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+		case <-t.flush:
+		case <-t.stop:
+			for t.export() {
+			}
+			return
+		}
+
+		for t.export() {
+		}
+	}
+}
+
+// export exports the next batch of queued spans.
+// It returns true if there are more spans to export.
+func (t *Tracer) export() bool {
+	t.rw.Lock()
+	batch := t.queue
+	if len(batch) > maxBatchSize {
+		batch = batch[:maxBatchSize]
+	}
+	t.queue = t.queue[len(batch):]
+	more := len(t.queue) > 0
+	dropped := t.dropped
+	t.dropped = 0
+	t.rw.Unlock()
+
+	if dropped > 0 {
+		t.opts.Logger.Warn("Tracing spans dropped: export queue is full.", zap.Int("spans", dropped))
+	}
+
+	if len(batch) == 0 {
+		return false
+	}
+
+	if err := t.send(batch); err != nil {
+		t.opts.Logger.Warn("Failed to export tracing spans.", zap.Int("spans", len(batch)), zap.Error(err))
+	}
+
+	return more
+}
+
+// send sends spans to the OTLP endpoint.
+func (t *Tracer) send(spans []*Span) error {
+	b, err := json.Marshal(t.request(spans))
+	if err != nil {
+		return lazyerrors.Error(err)
+	}
+
+	res, err := t.client.Post(t.opts.URL, "application/json", bytes.NewReader(b))
+	if err != nil {
+		return lazyerrors.Error(err)
+	}
+
+	defer res.Body.Close()
+	_, _ = io.Copy(io.Discard, res.Body)
+
+	if res.StatusCode/100 != 2 {
+		return fmt.Errorf("unexpected OTLP response status %s", res.Status)
+	}
+
+	return nil
+}
+
+// request returns OTLP ExportTraceServiceRequest for the given spans in JSON encoding.
+//
+// See https://opentelemetry.io/docs/specs/otlp/#json-protobuf-encoding.
+func (t *Tracer) request(spans []*Span) map[string]any {
+	resource := []map[string]any{attr(String("service.name", t.opts.ServiceName))}
+	if t.opts.ServiceVersion != "" {
+		resource = append(resource, attr(String("service.version", t.opts.ServiceVersion)))
+	}
+	if t.opts.ServiceInstanceID != "" {
+		resource = append(resource, attr(String("service.instance.id", t.opts.ServiceInstanceID)))
+	}
+
+	res := make([]map[string]any, len(spans))
+	for i, s := range spans {
+		res[i] = s.otlp()
+	}
+
+	return map[string]any{
+		"resourceSpans": []map[string]any{{
+			"resource": map[string]any{
+				"attributes": resource,
+			},
+			"scopeSpans": []map[string]any{{
+				"scope": map[string]any{
+					"name": "github.com/FerretDB/FerretDB",
+				},
+				"spans": res,
+			}},
+		}},
+	}
+}
+
+// otlp returns OTLP Span message in JSON encoding.
+func (s *Span) otlp() map[string]any {
+	res := map[string]any{
+		"traceId":           hex.EncodeToString(s.traceID[:]),
+		"spanId":            hex.EncodeToString(s.spanID[:]),
+		"name":              s.name,
+		"kind":              s.kind,
+		"startTimeUnixNano": strconv.FormatInt(s.start.UnixNano(), 10),
+		"endTimeUnixNano":   strconv.FormatInt(s.end.UnixNano(), 10),
+	}
+
+	if s.parentID != [8]byte{} {
+		res["parentSpanId"] = hex.EncodeToString(s.parentID[:])
+	}
+
+	if len(s.attrs) > 0 {
+		attrs := make([]map[string]any, len(s.attrs))
+		for i, a := range s.attrs {
+			attrs[i] = attr(a)
+		}
+		res["attributes"] = attrs
+	}
+
+	if s.err != "" {
+		// STATUS_CODE_ERROR
+		res["status"] = map[string]any{"code": 2, "message": s.err}
+	}
+
+	return res
+}
+
+// attr returns OTLP KeyValue message in JSON encoding.
+func attr(a Attr) map[string]any {
+	var value map[string]any
+	switch v := a.Value.(type) {
+	case string:
+		value = map[string]any{"stringValue": v}
+	case int64:
+		value = map[string]any{"intValue": strconv.FormatInt(v, 10)}
+	case bool:
+		value = map[string]any{"boolValue": v}
+	default:
+		value = map[string]any{"stringValue": fmt.Sprint(v)}
+	}
+
+	return map[string]any{"key": a.Key, "value": value}
+}
@@ -0,0 +1,157 @@
+// Copyright 2021 FerretDB Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package tracing provides distributed tracing of client requests with OpenTelemetry Protocol (OTLP) export.
+//
+// It implements only what FerretDB needs: spans with simple attributes, in-process propagation via context,
+// and batched export over OTLP/HTTP with JSON encoding (see Tracer).
+// Root spans are started by Tracer.Start; all other spans are children of the span from the context
+// and are not recorded if there is none, so code paths without a traced request pay almost nothing.
+package tracing
+
+import (
+	"context"
+	"crypto/rand"
+	"time"
+)
+
+// Span kinds, matching OTLP SpanKind values.
+const (
+	kindInternal = 1
+	kindServer   = 2
+	kindClient   = 3
+)
+
+// Attr represents a span attribute.
+// Value should be a string, int64, or bool.
+type Attr struct {
+	Key   string
+	Value any
+}
+
+// String returns a string attribute.
+func String(key, value string) Attr {
+	return Attr{Key: key, Value: value}
+}
+
+// Int returns an integer attribute.
+func Int(key string, value int64) Attr {
+	return Attr{Key: key, Value: value}
+}
+
+// Span represents a single traced operation.
+//
+// All methods could be called on nil receiver; they do nothing in that case.
+// Span is not safe for concurrent use.
+type Span struct {
+	tracer   *Tracer
+	traceID  [16]byte
+	spanID   [8]byte
+	parentID [8]byte // zero for root spans
+	name     string
+	kind     int
+	start    time.Time
+	end      time.Time
+	attrs    []Attr
+	err      string
+}
+
+// spanKey is a context key for the current Span.
+type spanKey struct{}
+
+// Start starts a new span that is a child of the span from the context.
+//
+// If there is no span in the context, the context itself and nil span are returned.
+// Span.End should be called when the operation is done.
+func Start(ctx context.Context, name string, attrs ...Attr) (context.Context, *Span) {
+	parent := fromContext(ctx)
+	if parent == nil {
+		return ctx, nil
+	}
+
+	s := parent.child(name, kindInternal, time.Now(), attrs)
+
+	return context.WithValue(ctx, spanKey{}, s), s
+}
+
+// Record records an already completed operation of a client of the other system (like a SQL statement)
+// as a child of the span from the context.
+//
+// If there is no span in the context, it does nothing.
+func Record(ctx context.Context, name string, start time.Time, d time.Duration, err error, attrs ...Attr) {
+	parent := fromContext(ctx)
+	if parent == nil {
+		return
+	}
+
+	s := parent.child(name, kindClient, start, attrs)
+	s.end = start.Add(d)
+	if err != nil {
+		s.err = err.Error()
+	}
+
+	s.tracer.add(s)
+}
+
+// fromContext returns the current span from the context, or nil.
+func fromContext(ctx context.Context) *Span {
+	s, _ := ctx.Value(spanKey{}).(*Span)
+	return s
+}
+
+// child returns a new child span.
+func (s *Span) child(name string, kind int, start time.Time, attrs []Attr) *Span {
+	c := &Span{
+		tracer:   s.tracer,
+		traceID:  s.traceID,
+		parentID: s.spanID,
+		name:     name,
+		kind:     kind,
+		start:    start,
+		attrs:    attrs,
+	}
+	randomID(c.spanID[:])
+
+	return c
+}
+
+// SetAttrs adds attributes to the span.
+func (s *Span) SetAttrs(attrs ...Attr) {
+	if s == nil {
+		return
+	}
+
+	s.attrs = append(s.attrs, attrs...)
+}
+
+// End marks the span as completed, with an error status if err is not nil, and queues it for export.
+// Subsequent calls do nothing.
+func (s *Span) End(err error) {
+	if s == nil || !s.end.IsZero() {
+		return
+	}
+
+	s.end = time.Now()
+	if err != nil {
+		s.err = err.Error()
+	}
+
+	s.tracer.add(s)
+}
+
+// randomID fills b with random bytes.
+func randomID(b []byte) {
+	// crypto/rand never fails on supported platforms
+	_, _ = rand.Read(b)
+}
@@ -0,0 +1,132 @@
+// Copyright 2021 FerretDB Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package tracing
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap/zaptest"
+)
+
+func TestTracer(t *testing.T) {
+	t.Parallel()
+
+	t.Run("Nil", func(t *testing.T) {
+		t.Parallel()
+
+		var tracer *Tracer
+		ctx, span := tracer.Start(context.Background(), "root")
+		assert.Nil(t, span)
+
+		_, child := Start(ctx, "child")
+		assert.Nil(t, child)
+
+		Record(ctx, "SELECT", time.Now(), time.Second, nil)
+		span.SetAttrs(String("k", "v"))
+		span.End(nil)
+		tracer.Close()
+	})
+
+	t.Run("Export", func(t *testing.T) {
+		t.Parallel()
+
+		var rw sync.Mutex
+		var spans []map[string]any
+		var resource []map[string]any
+
+		srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			var req struct {
+				ResourceSpans []struct {
+					Resource struct {
+						Attributes []map[string]any `json:"attributes"`
+					} `json:"resource"`
+					ScopeSpans []struct {
+						Spans []map[string]any `json:"spans"`
+					} `json:"scopeSpans"`
+				} `json:"resourceSpans"`
+			}
+			if !assert.NoError(t, json.NewDecoder(r.Body).Decode(&req)) {
+				w.WriteHeader(http.StatusBadRequest)
+				return
+			}
+
+			rw.Lock()
+			defer rw.Unlock()
+
+			for _, rs := range req.ResourceSpans {
+				resource = rs.Resource.Attributes
+				for _, ss := range rs.ScopeSpans {
+					spans = append(spans, ss.Spans...)
+				}
+			}
+		}))
+		t.Cleanup(srv.Close)
+
+		tracer, err := NewTracer(&NewTracerOpts{
+			URL:               srv.URL + "/v1/traces",
+			ServiceName:       "ferretdb",
+			ServiceInstanceID: "instance",
+			Logger:            zaptest.NewLogger(t),
+		})
+		require.NoError(t, err)
+
+		ctx, root := tracer.Start(context.Background(), "OP_MSG")
+		cmdCtx, cmd := Start(ctx, "find", String("db.name", "test"))
+		Record(cmdCtx, "SELECT", time.Now(), time.Millisecond, errors.New("query failed"), Int("db.rows", 1))
+		cmd.End(nil)
+		root.End(nil)
+		root.End(nil)
+
+		tracer.Close()
+
+		rw.Lock()
+		defer rw.Unlock()
+
+		require.Len(t, spans, 3)
+
+		expectedResource := []map[string]any{
+			{"key": "service.name", "value": map[string]any{"stringValue": "ferretdb"}},
+			{"key": "service.instance.id", "value": map[string]any{"stringValue": "instance"}},
+		}
+		assert.Equal(t, expectedResource, resource)
+
+		byName := map[string]map[string]any{}
+		for _, s := range spans {
+			byName[s["name"].(string)] = s
+		}
+
+		assert.NotContains(t, byName["OP_MSG"], "parentSpanId")
+		assert.Equal(t, byName["OP_MSG"]["spanId"], byName["find"]["parentSpanId"])
+		assert.Equal(t, byName["find"]["spanId"], byName["SELECT"]["parentSpanId"])
+		assert.Equal(t, byName["OP_MSG"]["traceId"], byName["SELECT"]["traceId"])
+		assert.Equal(t, map[string]any{"code": float64(2), "message": "query failed"}, byName["SELECT"]["status"])
+	})
+
+	t.Run("InvalidURL", func(t *testing.T) {
+		t.Parallel()
+
+		_, err := NewTracer(&NewTracerOpts{URL: "grpc://127.0.0.1:4317"})
+		assert.Error(t, err)
+	})
+}
@@ -0,0 +1,136 @@
+// Copyright 2021 FerretDB Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package wire
+
+import (
+	"bufio"
+	"encoding/binary"
+	"io"
+	"sync"
+	"time"
+
+	"github.com/FerretDB/FerretDB/internal/util/lazyerrors"
+)
+
+// Capture file consists of records, one for each client request.
+// Each record is a fixed-size prefix followed by the message (header and body) as sent by the client:
+//
+//   - int64 (little-endian) - request time as Unix time in nanoseconds;
+//   - uint64 (little-endian) - connection ID, unique for the capture file;
+//   - message header and body.
+
+// capturePrefixLen is the length of the capture record prefix.
+const capturePrefixLen = 16
+
+// CaptureRecord represents a single captured client request.
+type CaptureRecord struct {
+	Time   time.Time
+	ConnID uint64
+	Header *MsgHeader
+	Body   MsgBody
+}
+
+// CaptureWriter writes client requests to the capture file.
+//
+// It is safe for concurrent use.
+type CaptureWriter struct {
+	m sync.Mutex
+	w *bufio.Writer
+}
+
+// NewCaptureWriter returns a new capture writer.
+func NewCaptureWriter(w io.Writer) *CaptureWriter {
+	return &CaptureWriter{
+		w: bufio.NewWriter(w),
+	}
+}
+
+// Write writes a single record.
+// The record is flushed to the underlying writer, so it is not lost if the process crashes.
+func (cw *CaptureWriter) Write(record *CaptureRecord) error {
+	b, err := record.Body.MarshalBinary()
+	if err != nil {
+		return lazyerrors.Error(err)
+	}
+
+	if msg, ok := record.Body.(*OpMsg); ok && msg.FlagBits.FlagSet(OpMsgChecksumPresent) {
+		if err = setChecksum(record.Header, b); err != nil {
+			return lazyerrors.Error(err)
+		}
+	}
+
+	h, err := record.Header.MarshalBinary()
+	if err != nil {
+		return lazyerrors.Error(err)
+	}
+
+	prefix := make([]byte, capturePrefixLen)
+	binary.LittleEndian.PutUint64(prefix[0:8], uint64(record.Time.UnixNano()))
+	binary.LittleEndian.PutUint64(prefix[8:16], record.ConnID)
+
+	cw.m.Lock()
+	defer cw.m.Unlock()
+
+	for _, p := range [][]byte{prefix, h, b} {
+		if _, err = cw.w.Write(p); err != nil {
+			return lazyerrors.Error(err)
+		}
+	}
+
+	if err = cw.w.Flush(); err != nil {
+		return lazyerrors.Error(err)
+	}
+
+	return nil
+}
+
+// CaptureReader reads client requests from the capture file.
+type CaptureReader struct {
+	r *bufio.Reader
+}
+
+// NewCaptureReader returns a new capture reader.
+func NewCaptureReader(r io.Reader) *CaptureReader {
+	return &CaptureReader{
+		r: bufio.NewReader(r),
+	}
+}
+
+// Read reads the next record.
+// It returns io.EOF error if there are no more records.
+func (cr *CaptureReader) Read() (*CaptureRecord, error) {
+	prefix := make([]byte, capturePrefixLen)
+	if n, err := io.ReadFull(cr.r, prefix); err != nil {
+		if err == io.EOF {
+			return nil, err
+		}
+		return nil, lazyerrors.Errorf("expected %d, read %d: %w", len(prefix), n, err)
+	}
+
+	header, body, err := ReadMessage(cr.r)
+	if err != nil {
+		if err == io.EOF {
+			err = io.ErrUnexpectedEOF
+		}
+		return nil, lazyerrors.Error(err)
+	}
+
+	return &CaptureRecord{
+		Time:   time.Unix(0, int64(binary.LittleEndian.Uint64(prefix[0:8]))),
+		ConnID: binary.LittleEndian.Uint64(prefix[8:16]),
+		Header: header,
+		Body:   body,
+	}, nil
+}
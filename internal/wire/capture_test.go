@@ -0,0 +1,83 @@
+// Copyright 2021 FerretDB Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package wire
+
+import (
+	"bytes"
+	"io"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCapture(t *testing.T) {
+	t.Parallel()
+
+	var records []*CaptureRecord
+	for i, tc := range msgTestCases {
+		if tc.err != "" || tc.msgHeader == nil {
+			continue
+		}
+
+		records = append(records, &CaptureRecord{
+			Time:   time.Unix(1656000000, int64(i)),
+			ConnID: uint64(i%2 + 1),
+			Header: tc.msgHeader,
+			Body:   tc.msgBody,
+		})
+	}
+	require.NotEmpty(t, records)
+
+	var buf bytes.Buffer
+	w := NewCaptureWriter(&buf)
+	for _, record := range records {
+		require.NoError(t, w.Write(record))
+	}
+
+	r := NewCaptureReader(&buf)
+	for _, expected := range records {
+		actual, err := r.Read()
+		require.NoError(t, err)
+		assert.True(t, expected.Time.Equal(actual.Time))
+		assert.Equal(t, expected.ConnID, actual.ConnID)
+		assert.Equal(t, expected.Header, actual.Header)
+		assert.Equal(t, expected.Body, actual.Body)
+	}
+
+	_, err := r.Read()
+	assert.Equal(t, io.EOF, err)
+}
+
+func TestCaptureTruncated(t *testing.T) {
+	t.Parallel()
+
+	tc := msgTestCases[0]
+
+	var buf bytes.Buffer
+	require.NoError(t, NewCaptureWriter(&buf).Write(&CaptureRecord{
+		Time:   time.Now(),
+		ConnID: 1,
+		Header: tc.msgHeader,
+		Body:   tc.msgBody,
+	}))
+
+	_, err := NewCaptureReader(bytes.NewReader(buf.Bytes()[:buf.Len()-1])).Read()
+	assert.Error(t, err)
+
+	_, err = NewCaptureReader(bytes.NewReader(buf.Bytes()[:capturePrefixLen])).Read()
+	assert.ErrorIs(t, err, io.ErrUnexpectedEOF)
+}
@@ -0,0 +1,69 @@
+// Copyright 2021 FerretDB Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package wire
+
+import (
+	"encoding/binary"
+	"hash/crc32"
+
+	"github.com/FerretDB/FerretDB/internal/util/lazyerrors"
+)
+
+// crc32cTable is used for OP_MSG checksums.
+var crc32cTable = crc32.MakeTable(crc32.Castagnoli)
+
+// checksum returns CRC-32C checksum of the message with the given header and body.
+// The last four bytes of the body are reserved for the checksum itself and are not included.
+func checksum(header *MsgHeader, body []byte) (uint32, error) {
+	if len(body) < 4 {
+		return 0, lazyerrors.Errorf("wire.checksum: invalid body length %d", len(body))
+	}
+
+	h, err := header.MarshalBinary()
+	if err != nil {
+		return 0, lazyerrors.Error(err)
+	}
+
+	c := crc32.Update(0, crc32cTable, h)
+	c = crc32.Update(c, crc32cTable, body[:len(body)-4])
+
+	return c, nil
+}
+
+// verifyChecksum checks the checksum stored in the last four bytes of the message body.
+func verifyChecksum(header *MsgHeader, body []byte) error {
+	expected, err := checksum(header, body)
+	if err != nil {
+		return lazyerrors.Error(err)
+	}
+
+	if actual := binary.LittleEndian.Uint32(body[len(body)-4:]); actual != expected {
+		return lazyerrors.Errorf("wire.verifyChecksum: checksum 0x%08x, expected 0x%08x", actual, expected)
+	}
+
+	return nil
+}
+
+// setChecksum stores the checksum in the last four bytes of the message body.
+func setChecksum(header *MsgHeader, body []byte) error {
+	c, err := checksum(header, body)
+	if err != nil {
+		return lazyerrors.Error(err)
+	}
+
+	binary.LittleEndian.PutUint32(body[len(body)-4:], c)
+
+	return nil
+}
@@ -0,0 +1,176 @@
+// Copyright 2021 FerretDB Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package wire
+
+import (
+	"bytes"
+	"compress/zlib"
+	"fmt"
+	"io"
+	"sync"
+
+	"github.com/golang/snappy"
+	"github.com/klauspost/compress/zstd"
+
+	"github.com/FerretDB/FerretDB/internal/util/lazyerrors"
+	"github.com/FerretDB/FerretDB/internal/util/must"
+)
+
+// CompressorID represents wire protocol compressor identifier.
+type CompressorID uint8
+
+const (
+	// CompressorNoop does not compress messages.
+	CompressorNoop = CompressorID(0)
+
+	// CompressorSnappy uses Snappy compression.
+	CompressorSnappy = CompressorID(1)
+
+	// CompressorZlib uses zlib compression.
+	CompressorZlib = CompressorID(2)
+
+	// CompressorZstd uses Zstandard compression.
+	CompressorZstd = CompressorID(3)
+)
+
+// compressor represents a single compression algorithm.
+type compressor struct {
+	name       string
+	compress   func(b []byte) ([]byte, error)
+	decompress func(b []byte, size int) ([]byte, error)
+}
+
+// compressors contains all supported compressors.
+var compressors = map[CompressorID]compressor{
+	CompressorNoop: {
+		name: "noop",
+		compress: func(b []byte) ([]byte, error) {
+			return b, nil
+		},
+		decompress: func(b []byte, size int) ([]byte, error) {
+			return b, nil
+		},
+	},
+	CompressorSnappy: {
+		name: "snappy",
+		compress: func(b []byte) ([]byte, error) {
+			return snappy.Encode(nil, b), nil
+		},
+		decompress: func(b []byte, size int) ([]byte, error) {
+			l, err := snappy.DecodedLen(b)
+			if err != nil {
+				return nil, lazyerrors.Error(err)
+			}
+
+			// do not allocate memory for the invalid message
+			if l != size {
+				return nil, lazyerrors.Errorf("decoded length %d, expected %d", l, size)
+			}
+
+			return snappy.Decode(nil, b)
+		},
+	},
+	CompressorZlib: {
+		name: "zlib",
+		compress: func(b []byte) ([]byte, error) {
+			var buf bytes.Buffer
+			w := zlib.NewWriter(&buf)
+
+			if _, err := w.Write(b); err != nil {
+				return nil, lazyerrors.Error(err)
+			}
+
+			if err := w.Close(); err != nil {
+				return nil, lazyerrors.Error(err)
+			}
+
+			return buf.Bytes(), nil
+		},
+		decompress: func(b []byte, size int) ([]byte, error) {
+			r, err := zlib.NewReader(bytes.NewReader(b))
+			if err != nil {
+				return nil, lazyerrors.Error(err)
+			}
+			defer r.Close()
+
+			// read one more byte to detect the invalid message without reading it all
+			res, err := io.ReadAll(io.LimitReader(r, int64(size)+1))
+			if err != nil {
+				return nil, lazyerrors.Error(err)
+			}
+
+			return res, nil
+		},
+	},
+	CompressorZstd: {
+		name: "zstd",
+		compress: func(b []byte) ([]byte, error) {
+			initZstd()
+			return zstdEncoder.EncodeAll(b, nil), nil
+		},
+		decompress: func(b []byte, size int) ([]byte, error) {
+			initZstd()
+
+			res, err := zstdDecoder.DecodeAll(b, make([]byte, 0, size))
+			if err != nil {
+				return nil, lazyerrors.Error(err)
+			}
+
+			return res, nil
+		},
+	},
+}
+
+// Zstandard encoder and decoder are safe for concurrent use of EncodeAll and DecodeAll methods.
+// They are created on the first use.
+var (
+	zstdOnce    sync.Once
+	zstdEncoder *zstd.Encoder
+	zstdDecoder *zstd.Decoder
+)
+
+// initZstd creates Zstandard encoder and decoder if they were not created yet.
+func initZstd() {
+	zstdOnce.Do(func() {
+		zstdEncoder = must.NotFail(zstd.NewWriter(nil))
+		zstdDecoder = must.NotFail(zstd.NewReader(nil, zstd.WithDecoderMaxMemory(MaxMsgLen)))
+	})
+}
+
+// AllCompressors includes names of all supported compressors, with the most preferable first.
+var AllCompressors = []string{"snappy", "zstd", "zlib"}
+
+// String implements fmt.Stringer interface.
+func (id CompressorID) String() string {
+	if c, ok := compressors[id]; ok {
+		return c.name
+	}
+
+	return fmt.Sprintf("CompressorID(%d)", uint8(id))
+}
+
+// ParseCompressor returns compressor identifier for the given name.
+//
+// Only compressors listed in AllCompressors are accepted;
+// noop compressor is not a real compression algorithm and can't be enabled.
+func ParseCompressor(name string) (CompressorID, error) {
+	for id, c := range compressors {
+		if id != CompressorNoop && c.name == name {
+			return id, nil
+		}
+	}
+
+	return 0, fmt.Errorf("unknown compressor %q", name)
+}
@@ -0,0 +1,86 @@
+// Copyright 2021 FerretDB Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package wire
+
+import (
+	"fmt"
+	"sync/atomic"
+
+	"github.com/FerretDB/FerretDB/internal/types"
+)
+
+// Limits represents configurable size limits reported to clients by hello command and enforced on requests.
+type Limits struct {
+	// Maximum size of a single BSON document stored by clients.
+	MaxBSONObjectSize int32
+
+	// Maximum size of a single wire protocol message.
+	MaxMessageSizeBytes int32
+
+	// Maximum number of write operations in a single insert, update, or delete command.
+	MaxWriteBatchSize int32
+}
+
+// DefaultLimits are used if limits are not configured; they match MongoDB.
+var DefaultLimits = Limits{
+	MaxBSONObjectSize:   types.MaxDocumentLen,
+	MaxMessageSizeBytes: MaxMsgLen,
+	MaxWriteBatchSize:   100000,
+}
+
+// maxInternalOverhead is the additional space in messages for command fields besides user's documents,
+// like MongoDB's BSONObjMaxInternalSize.
+const maxInternalOverhead = 16 * 1024
+
+// limits stores current Limits.
+var limits atomic.Value
+
+func init() {
+	limits.Store(DefaultLimits)
+}
+
+// Validate checks that limits values are consistent and within hard limits.
+func (l Limits) Validate() error {
+	if l.MaxBSONObjectSize < 1 || l.MaxBSONObjectSize > types.MaxDocumentLen {
+		return fmt.Errorf("maxBsonObjectSize %d should be between 1 and %d", l.MaxBSONObjectSize, types.MaxDocumentLen)
+	}
+
+	if min := l.MaxBSONObjectSize + maxInternalOverhead; l.MaxMessageSizeBytes < min || l.MaxMessageSizeBytes > MaxMsgLen {
+		return fmt.Errorf("maxMessageSizeBytes %d should be between %d and %d", l.MaxMessageSizeBytes, min, MaxMsgLen)
+	}
+
+	if l.MaxWriteBatchSize < 1 {
+		return fmt.Errorf("maxWriteBatchSize %d should be positive", l.MaxWriteBatchSize)
+	}
+
+	return nil
+}
+
+// GetLimits returns the current limits.
+func GetLimits() Limits {
+	return limits.Load().(Limits)
+}
+
+// SetLimits validates and changes the current limits.
+// It is safe to call it concurrently with requests handling.
+func SetLimits(l Limits) error {
+	if err := l.Validate(); err != nil {
+		return err
+	}
+
+	limits.Store(l)
+
+	return nil
+}
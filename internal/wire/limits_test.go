@@ -0,0 +1,69 @@
+// Copyright 2021 FerretDB Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package wire
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLimitsValidate(t *testing.T) {
+	t.Parallel()
+
+	assert.NoError(t, DefaultLimits.Validate())
+	assert.Equal(t, DefaultLimits, GetLimits())
+
+	for name, l := range map[string]Limits{
+		"BSONObjectSizeZero": {
+			MaxBSONObjectSize:   0,
+			MaxMessageSizeBytes: MaxMsgLen,
+			MaxWriteBatchSize:   1,
+		},
+		"BSONObjectSizeTooLarge": {
+			MaxBSONObjectSize:   32 * 1024 * 1024,
+			MaxMessageSizeBytes: MaxMsgLen,
+			MaxWriteBatchSize:   1,
+		},
+		"MessageSizeTooSmall": {
+			MaxBSONObjectSize:   1024 * 1024,
+			MaxMessageSizeBytes: 1024 * 1024,
+			MaxWriteBatchSize:   1,
+		},
+		"MessageSizeTooLarge": {
+			MaxBSONObjectSize:   1024 * 1024,
+			MaxMessageSizeBytes: MaxMsgLen + 1,
+			MaxWriteBatchSize:   1,
+		},
+		"WriteBatchSizeZero": {
+			MaxBSONObjectSize:   1024 * 1024,
+			MaxMessageSizeBytes: 2 * 1024 * 1024,
+			MaxWriteBatchSize:   0,
+		},
+	} {
+		name, l := name, l
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			assert.Error(t, l.Validate())
+		})
+	}
+
+	assert.NoError(t, Limits{
+		MaxBSONObjectSize:   1024 * 1024,
+		MaxMessageSizeBytes: 2 * 1024 * 1024,
+		MaxWriteBatchSize:   1000,
+	}.Validate())
+}
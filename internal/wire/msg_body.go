@@ -35,8 +35,8 @@ type MsgBody interface {
 
 //go-sumtype:decl MsgBody
 
-// ReadMessage reads from reader and returns wire header and body.
-func ReadMessage(r *bufio.Reader) (*MsgHeader, MsgBody, error) {
+// ReadRawMessage reads from reader and returns wire header and body bytes without decoding them.
+func ReadRawMessage(r *bufio.Reader) (*MsgHeader, []byte, error) {
 	var header MsgHeader
 	if err := header.readFrom(r); err != nil {
 		if err == io.EOF {
@@ -50,6 +50,19 @@ func ReadMessage(r *bufio.Reader) (*MsgHeader, MsgBody, error) {
 		return nil, nil, lazyerrors.Errorf("expected %d, read %d: %w", len(b), n, err)
 	}
 
+	return &header, b, nil
+}
+
+// ReadMessage reads from reader and returns wire header and body.
+func ReadMessage(r *bufio.Reader) (*MsgHeader, MsgBody, error) {
+	header, b, err := ReadRawMessage(r)
+	if err != nil {
+		if err == io.EOF {
+			return nil, nil, err
+		}
+		return nil, nil, lazyerrors.Error(err)
+	}
+
 	switch header.OpCode {
 	case OpCodeReply: // not sent by clients, but we should be able to read replies from a proxy
 		var reply OpReply
@@ -57,7 +70,7 @@ func ReadMessage(r *bufio.Reader) (*MsgHeader, MsgBody, error) {
 			return nil, nil, lazyerrors.Error(err)
 		}
 
-		return &header, &reply, nil
+		return header, &reply, nil
 
 	case OpCodeMsg:
 		var msg OpMsg
@@ -65,7 +78,13 @@ func ReadMessage(r *bufio.Reader) (*MsgHeader, MsgBody, error) {
 			return nil, nil, lazyerrors.Error(err)
 		}
 
-		return &header, &msg, nil
+		if msg.FlagBits.FlagSet(OpMsgChecksumPresent) {
+			if err := verifyChecksum(header, b); err != nil {
+				return nil, nil, lazyerrors.Error(err)
+			}
+		}
+
+		return header, &msg, nil
 
 	case OpCodeQuery:
 		var query OpQuery
@@ -73,7 +92,31 @@ func ReadMessage(r *bufio.Reader) (*MsgHeader, MsgBody, error) {
 			return nil, nil, lazyerrors.Error(err)
 		}
 
-		return &header, &query, nil
+		return header, &query, nil
+
+	case OpCodeCompressed:
+		var compressed OpCompressed
+		if err := compressed.UnmarshalBinary(b); err != nil {
+			return nil, nil, lazyerrors.Error(err)
+		}
+
+		return header, &compressed, nil
+
+	case OpCodeGetMore:
+		var getMore OpGetMore
+		if err := getMore.UnmarshalBinary(b); err != nil {
+			return nil, nil, lazyerrors.Error(err)
+		}
+
+		return header, &getMore, nil
+
+	case OpCodeKillCursors:
+		var killCursors OpKillCursors
+		if err := killCursors.UnmarshalBinary(b); err != nil {
+			return nil, nil, lazyerrors.Error(err)
+		}
+
+		return header, &killCursors, nil
 
 	case OpCodeUpdate:
 		fallthrough
@@ -81,13 +124,7 @@ func ReadMessage(r *bufio.Reader) (*MsgHeader, MsgBody, error) {
 		fallthrough
 	case OpCodeGetByOID:
 		fallthrough
-	case OpCodeGetMore:
-		fallthrough
 	case OpCodeDelete:
-		fallthrough
-	case OpCodeKillCursors:
-		fallthrough
-	case OpCodeCompressed:
 		return nil, nil, lazyerrors.Errorf("unhandled opcode %s", header.OpCode)
 
 	default:
@@ -109,6 +146,12 @@ func WriteMessage(w *bufio.Writer, header *MsgHeader, msg MsgBody) error {
 		))
 	}
 
+	if msg, ok := msg.(*OpMsg); ok && msg.FlagBits.FlagSet(OpMsgChecksumPresent) {
+		if err = setChecksum(header, b); err != nil {
+			return lazyerrors.Error(err)
+		}
+	}
+
 	if err := header.writeTo(w); err != nil {
 		return lazyerrors.Error(err)
 	}
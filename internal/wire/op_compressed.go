@@ -0,0 +1,243 @@
+// Copyright 2021 FerretDB Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package wire
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"encoding/json"
+	"io"
+
+	"github.com/FerretDB/FerretDB/internal/util/lazyerrors"
+	"github.com/FerretDB/FerretDB/internal/util/must"
+)
+
+// opCompressedHeaderLen is the length of OpCompressed fields before the compressed message.
+const opCompressedHeaderLen = 9
+
+// OpCompressed is a message that wraps another compressed message.
+type OpCompressed struct {
+	OriginalOpCode OpCode
+	Compressor     CompressorID
+
+	// uncompressed message body
+	body MsgBody
+
+	// uncompressed message body bytes; set by UnmarshalBinary to verify checksum
+	uncompressed []byte
+
+	// compressed message body; cached by MarshalBinary
+	compressed []byte
+}
+
+// NewOpCompressed compresses the given message with the given compressor.
+// It returns a header and a body of OP_COMPRESSED message.
+func NewOpCompressed(header *MsgHeader, body MsgBody, compressor CompressorID) (*MsgHeader, *OpCompressed, error) {
+	if header.OpCode == OpCodeCompressed {
+		return nil, nil, lazyerrors.New("wire.NewOpCompressed: message is already compressed")
+	}
+
+	// checksum covers the uncompressed message with its header, so set it before compression
+	if m, ok := body.(*OpMsg); ok && m.FlagBits.FlagSet(OpMsgChecksumPresent) {
+		b, err := m.MarshalBinary()
+		if err != nil {
+			return nil, nil, lazyerrors.Error(err)
+		}
+
+		h := *header
+		h.MessageLength = int32(MsgHeaderLen + len(b))
+
+		withChecksum := *m
+		if withChecksum.Checksum, err = checksum(&h, b); err != nil {
+			return nil, nil, lazyerrors.Error(err)
+		}
+
+		body = &withChecksum
+	}
+
+	msg := &OpCompressed{
+		OriginalOpCode: header.OpCode,
+		Compressor:     compressor,
+		body:           body,
+	}
+
+	b, err := msg.MarshalBinary()
+	if err != nil {
+		return nil, nil, lazyerrors.Error(err)
+	}
+
+	resHeader := &MsgHeader{
+		MessageLength: int32(MsgHeaderLen + len(b)),
+		RequestID:     header.RequestID,
+		ResponseTo:    header.ResponseTo,
+		OpCode:        OpCodeCompressed,
+	}
+
+	return resHeader, msg, nil
+}
+
+// Message returns the header and the body of the original uncompressed message.
+//
+// If the original message is OP_MSG with checksum, it is verified.
+func (msg *OpCompressed) Message(header *MsgHeader) (*MsgHeader, MsgBody, error) {
+	b := msg.uncompressed
+	if b == nil {
+		var err error
+		if b, err = msg.body.MarshalBinary(); err != nil {
+			return nil, nil, lazyerrors.Error(err)
+		}
+	}
+
+	resHeader := &MsgHeader{
+		MessageLength: int32(MsgHeaderLen + len(b)),
+		RequestID:     header.RequestID,
+		ResponseTo:    header.ResponseTo,
+		OpCode:        msg.OriginalOpCode,
+	}
+
+	if m, ok := msg.body.(*OpMsg); ok && m.FlagBits.FlagSet(OpMsgChecksumPresent) {
+		if err := verifyChecksum(resHeader, b); err != nil {
+			return nil, nil, lazyerrors.Error(err)
+		}
+	}
+
+	return resHeader, msg.body, nil
+}
+
+func (msg *OpCompressed) msgbody() {}
+
+func (msg *OpCompressed) readFrom(bufr *bufio.Reader) error {
+	b, err := io.ReadAll(bufr)
+	if err != nil {
+		return lazyerrors.Error(err)
+	}
+
+	return msg.UnmarshalBinary(b)
+}
+
+// UnmarshalBinary reads an OpCompressed from a byte array and decompresses the original message.
+func (msg *OpCompressed) UnmarshalBinary(b []byte) error {
+	if len(b) < opCompressedHeaderLen {
+		return lazyerrors.Errorf("wire.OpCompressed.UnmarshalBinary: invalid length %d", len(b))
+	}
+
+	msg.OriginalOpCode = OpCode(binary.LittleEndian.Uint32(b[0:4]))
+	size := int32(binary.LittleEndian.Uint32(b[4:8]))
+	msg.Compressor = CompressorID(b[8])
+	msg.compressed = b[opCompressedHeaderLen:]
+
+	if size < 0 || size > MaxMsgLen-MsgHeaderLen {
+		return lazyerrors.Errorf("wire.OpCompressed.UnmarshalBinary: invalid uncompressed size %d", size)
+	}
+
+	c, ok := compressors[msg.Compressor]
+	if !ok {
+		return lazyerrors.Errorf("wire.OpCompressed.UnmarshalBinary: unknown compressor %s", msg.Compressor)
+	}
+
+	body, err := c.decompress(msg.compressed, int(size))
+	if err != nil {
+		return lazyerrors.Errorf("wire.OpCompressed.UnmarshalBinary: %w", err)
+	}
+
+	if len(body) != int(size) {
+		return lazyerrors.Errorf("wire.OpCompressed.UnmarshalBinary: uncompressed size %d, expected %d", len(body), size)
+	}
+
+	switch msg.OriginalOpCode {
+	case OpCodeMsg:
+		var m OpMsg
+		if err = m.UnmarshalBinary(body); err != nil {
+			return lazyerrors.Error(err)
+		}
+		msg.body = &m
+
+		if m.FlagBits.FlagSet(OpMsgChecksumPresent) {
+			msg.uncompressed = body
+		}
+
+	case OpCodeQuery:
+		var q OpQuery
+		if err = q.UnmarshalBinary(body); err != nil {
+			return lazyerrors.Error(err)
+		}
+		msg.body = &q
+
+	case OpCodeReply:
+		var r OpReply
+		if err = r.UnmarshalBinary(body); err != nil {
+			return lazyerrors.Error(err)
+		}
+		msg.body = &r
+
+	default:
+		return lazyerrors.Errorf("wire.OpCompressed.UnmarshalBinary: unexpected original opcode %s", msg.OriginalOpCode)
+	}
+
+	return nil
+}
+
+// MarshalBinary compresses the original message and writes an OpCompressed to a byte array.
+func (msg *OpCompressed) MarshalBinary() ([]byte, error) {
+	body, err := msg.body.MarshalBinary()
+	if err != nil {
+		return nil, lazyerrors.Error(err)
+	}
+
+	if msg.compressed == nil {
+		c, ok := compressors[msg.Compressor]
+		if !ok {
+			return nil, lazyerrors.Errorf("wire.OpCompressed.MarshalBinary: unknown compressor %s", msg.Compressor)
+		}
+
+		if msg.compressed, err = c.compress(body); err != nil {
+			return nil, lazyerrors.Error(err)
+		}
+	}
+
+	var buf bytes.Buffer
+	buf.Grow(opCompressedHeaderLen + len(msg.compressed))
+
+	binary.Write(&buf, binary.LittleEndian, msg.OriginalOpCode)
+	binary.Write(&buf, binary.LittleEndian, int32(len(body)))
+	buf.WriteByte(byte(msg.Compressor))
+	buf.Write(msg.compressed)
+
+	return buf.Bytes(), nil
+}
+
+// String returns a string representation for logging.
+func (msg *OpCompressed) String() string {
+	if msg == nil {
+		return "<nil>"
+	}
+
+	m := map[string]any{
+		"OriginalOpCode": msg.OriginalOpCode,
+		"Compressor":     msg.Compressor.String(),
+	}
+
+	if msg.body != nil {
+		m["Message"] = json.RawMessage(msg.body.String())
+	}
+
+	return string(must.NotFail(json.MarshalIndent(m, "", "  ")))
+}
+
+// check interfaces
+var (
+	_ MsgBody = (*OpCompressed)(nil)
+)
@@ -0,0 +1,165 @@
+// Copyright 2021 FerretDB Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package wire
+
+import (
+	"bufio"
+	"bytes"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/FerretDB/FerretDB/internal/types"
+	"github.com/FerretDB/FerretDB/internal/util/must"
+)
+
+func TestOpCompressed(t *testing.T) {
+	t.Parallel()
+
+	for _, tc := range msgTestCases {
+		tc := tc
+		if tc.msgHeader == nil {
+			continue
+		}
+
+		for _, compressor := range []CompressorID{CompressorNoop, CompressorSnappy, CompressorZlib, CompressorZstd} {
+			compressor := compressor
+			t.Run(tc.name+"/"+compressor.String(), func(t *testing.T) {
+				t.Parallel()
+
+				header, msg, err := NewOpCompressed(tc.msgHeader, tc.msgBody, compressor)
+				require.NoError(t, err)
+				assert.Equal(t, OpCodeCompressed, header.OpCode)
+				assert.Equal(t, tc.msgHeader.RequestID, header.RequestID)
+				assert.Equal(t, tc.msgHeader.ResponseTo, header.ResponseTo)
+
+				var buf bytes.Buffer
+				bufw := bufio.NewWriter(&buf)
+				require.NoError(t, WriteMessage(bufw, header, msg))
+				require.NoError(t, bufw.Flush())
+
+				actualHeader, actualBody, err := ReadMessage(bufio.NewReader(&buf))
+				require.NoError(t, err)
+				assert.Equal(t, header, actualHeader)
+
+				compressed, ok := actualBody.(*OpCompressed)
+				require.True(t, ok)
+				assert.Equal(t, compressor, compressed.Compressor)
+				assert.NotPanics(t, func() { _ = compressed.String() })
+
+				originalHeader, originalBody, err := compressed.Message(actualHeader)
+				require.NoError(t, err)
+				assert.Equal(t, tc.msgHeader, originalHeader)
+				assert.Equal(t, tc.msgBody, originalBody)
+			})
+		}
+	}
+}
+
+func TestOpCompressedInvalid(t *testing.T) {
+	t.Parallel()
+
+	for _, compressor := range []CompressorID{CompressorSnappy, CompressorZlib, CompressorZstd} {
+		header := &MsgHeader{RequestID: 1, OpCode: OpCodeMsg}
+		_, msg, err := NewOpCompressed(header, msgTestCases[0].msgBody, compressor)
+		require.NoError(t, err)
+
+		b, err := msg.MarshalBinary()
+		require.NoError(t, err)
+
+		for name, mutate := range map[string]func(b []byte){
+			"Compressor": func(b []byte) { b[8] = 42 },
+			"Size":       func(b []byte) { b[4]++ },
+			"OpCode":     func(b []byte) { b[0] = 42 },
+			"Data":       func(b []byte) { b[len(b)-1]++ },
+		} {
+			invalid := append([]byte(nil), b...)
+			mutate(invalid)
+
+			var actual OpCompressed
+			assert.Error(t, actual.UnmarshalBinary(invalid), "%s/%s", compressor, name)
+		}
+
+		var actual OpCompressed
+		assert.Error(t, actual.UnmarshalBinary(b[:5]), compressor.String())
+	}
+}
+
+func TestOpCompressedChecksum(t *testing.T) {
+	t.Parallel()
+
+	header := &MsgHeader{
+		MessageLength: 55,
+		RequestID:     1,
+		OpCode:        OpCodeMsg,
+	}
+
+	// checksum of the original message is not set yet
+	body := &OpMsg{
+		FlagBits: OpMsgFlags(OpMsgChecksumPresent),
+		sections: []OpMsgSection{{
+			Documents: []*types.Document{must.NotFail(types.NewDocument("insert", "c", "$db", "t"))},
+		}},
+	}
+
+	compressedHeader, compressed, err := NewOpCompressed(header, body, CompressorSnappy)
+	require.NoError(t, err)
+	assert.Zero(t, body.Checksum, "original message should not be modified")
+
+	b, err := compressed.MarshalBinary()
+	require.NoError(t, err)
+
+	var actual OpCompressed
+	require.NoError(t, actual.UnmarshalBinary(b))
+
+	actualHeader, actualBody, err := actual.Message(compressedHeader)
+	require.NoError(t, err)
+	assert.Equal(t, header, actualHeader)
+	assert.Equal(t, uint32(0x05d1c569), actualBody.(*OpMsg).Checksum)
+
+	// invalid checksum of the original message
+	b, err = body.MarshalBinary()
+	require.NoError(t, err)
+
+	b = append([]byte{
+		0xdd, 0x07, 0x00, 0x00, // OP_MSG
+		byte(len(b)), 0x00, 0x00, 0x00, // uncompressed size
+		byte(CompressorNoop),
+	}, b...)
+
+	actual = OpCompressed{}
+	require.NoError(t, actual.UnmarshalBinary(b))
+
+	_, _, err = actual.Message(compressedHeader)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "wire.verifyChecksum: checksum 0x00000000, expected 0x05d1c569")
+}
+
+func TestParseCompressor(t *testing.T) {
+	t.Parallel()
+
+	for _, name := range AllCompressors {
+		id, err := ParseCompressor(name)
+		require.NoError(t, err)
+		assert.Equal(t, name, id.String())
+	}
+
+	_, err := ParseCompressor("noop")
+	assert.Error(t, err)
+
+	_, err = ParseCompressor("lz4")
+	assert.Error(t, err)
+}
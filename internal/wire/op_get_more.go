@@ -0,0 +1,123 @@
+// Copyright 2021 FerretDB Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package wire
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"encoding/json"
+	"io"
+
+	"github.com/FerretDB/FerretDB/internal/bson"
+	"github.com/FerretDB/FerretDB/internal/util/lazyerrors"
+	"github.com/FerretDB/FerretDB/internal/util/must"
+)
+
+// OpGetMore is a legacy message used to get more documents from a cursor created by OpQuery.
+type OpGetMore struct {
+	FullCollectionName string
+	NumberToReturn     int32
+	CursorID           int64
+}
+
+func (getMore *OpGetMore) msgbody() {}
+
+func (getMore *OpGetMore) readFrom(bufr *bufio.Reader) error {
+	var zero int32
+	if err := binary.Read(bufr, binary.LittleEndian, &zero); err != nil {
+		return lazyerrors.Errorf("wire.OpGetMore.readFrom (binary.Read): %w", err)
+	}
+
+	var col bson.CString
+	if err := col.ReadFrom(bufr); err != nil {
+		return lazyerrors.Error(err)
+	}
+	getMore.FullCollectionName = string(col)
+
+	if err := binary.Read(bufr, binary.LittleEndian, &getMore.NumberToReturn); err != nil {
+		return lazyerrors.Errorf("wire.OpGetMore.readFrom (binary.Read): %w", err)
+	}
+
+	if err := binary.Read(bufr, binary.LittleEndian, &getMore.CursorID); err != nil {
+		return lazyerrors.Errorf("wire.OpGetMore.readFrom (binary.Read): %w", err)
+	}
+
+	return nil
+}
+
+// UnmarshalBinary reads an OpGetMore from a byte array.
+func (getMore *OpGetMore) UnmarshalBinary(b []byte) error {
+	br := bytes.NewReader(b)
+	bufr := bufio.NewReader(br)
+
+	if err := getMore.readFrom(bufr); err != nil {
+		return lazyerrors.Error(err)
+	}
+
+	if _, err := bufr.Peek(1); err != io.EOF {
+		return lazyerrors.Errorf("unexpected end of the OpGetMore: %v", err)
+	}
+
+	return nil
+}
+
+// MarshalBinary writes an OpGetMore to a byte array.
+func (getMore *OpGetMore) MarshalBinary() ([]byte, error) {
+	var buf bytes.Buffer
+	bufw := bufio.NewWriter(&buf)
+
+	if err := binary.Write(bufw, binary.LittleEndian, int32(0)); err != nil {
+		return nil, lazyerrors.Error(err)
+	}
+
+	if err := bson.CString(getMore.FullCollectionName).WriteTo(bufw); err != nil {
+		return nil, lazyerrors.Error(err)
+	}
+
+	if err := binary.Write(bufw, binary.LittleEndian, getMore.NumberToReturn); err != nil {
+		return nil, lazyerrors.Error(err)
+	}
+
+	if err := binary.Write(bufw, binary.LittleEndian, getMore.CursorID); err != nil {
+		return nil, lazyerrors.Error(err)
+	}
+
+	if err := bufw.Flush(); err != nil {
+		return nil, lazyerrors.Error(err)
+	}
+
+	return buf.Bytes(), nil
+}
+
+// String returns a string representation for logging.
+func (getMore *OpGetMore) String() string {
+	if getMore == nil {
+		return "<nil>"
+	}
+
+	m := map[string]any{
+		"FullCollectionName": getMore.FullCollectionName,
+		"NumberToReturn":     getMore.NumberToReturn,
+		"CursorID":           getMore.CursorID,
+	}
+
+	return string(must.NotFail(json.MarshalIndent(m, "", "  ")))
+}
+
+// check interfaces
+var (
+	_ MsgBody = (*OpGetMore)(nil)
+)
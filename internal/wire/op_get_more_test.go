@@ -0,0 +1,57 @@
+// Copyright 2021 FerretDB Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package wire
+
+import "testing"
+
+var getMoreTestCases = []testCase{{
+	name: "getMore",
+	expectedB: []byte{
+		0x2c, 0x00, 0x00, 0x00, 0x05, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0xd5, 0x07, 0x00, 0x00, // header
+		0x00, 0x00, 0x00, 0x00, // zero
+		0x74, 0x65, 0x73, 0x74, 0x2e, 0x76, 0x61, 0x6c, 0x75, 0x65, 0x73, 0x00, // "test.values"
+		0x64, 0x00, 0x00, 0x00, // numberToReturn
+		0x2a, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, // cursorID
+	},
+	msgHeader: &MsgHeader{
+		MessageLength: 44,
+		RequestID:     5,
+		OpCode:        OpCodeGetMore,
+	},
+	msgBody: &OpGetMore{
+		FullCollectionName: "test.values",
+		NumberToReturn:     100,
+		CursorID:           42,
+	},
+}, {
+	name: "truncated",
+	expectedB: []byte{
+		0x28, 0x00, 0x00, 0x00, 0x05, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0xd5, 0x07, 0x00, 0x00, // header
+		0x00, 0x00, 0x00, 0x00, // zero
+		0x74, 0x65, 0x73, 0x74, 0x2e, 0x76, 0x61, 0x6c, 0x75, 0x65, 0x73, 0x00, // "test.values"
+		0x64, 0x00, 0x00, 0x00, // numberToReturn
+		0x2a, 0x00, 0x00, 0x00, // truncated cursorID
+	},
+	err: "unexpected EOF",
+}}
+
+func TestGetMore(t *testing.T) {
+	t.Parallel()
+	testMessages(t, getMoreTestCases)
+}
+
+func FuzzGetMore(f *testing.F) {
+	fuzzMessages(f, getMoreTestCases)
+}
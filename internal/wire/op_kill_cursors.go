@@ -0,0 +1,111 @@
+// Copyright 2021 FerretDB Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package wire
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"encoding/json"
+	"io"
+
+	"github.com/FerretDB/FerretDB/internal/util/lazyerrors"
+	"github.com/FerretDB/FerretDB/internal/util/must"
+)
+
+// OpKillCursors is a legacy message used to close cursors created by OpQuery.
+// There is no response.
+type OpKillCursors struct {
+	CursorIDs []int64
+}
+
+func (killCursors *OpKillCursors) msgbody() {}
+
+func (killCursors *OpKillCursors) readFrom(bufr *bufio.Reader) error {
+	var zero, n int32
+	if err := binary.Read(bufr, binary.LittleEndian, &zero); err != nil {
+		return lazyerrors.Errorf("wire.OpKillCursors.readFrom (binary.Read): %w", err)
+	}
+
+	if err := binary.Read(bufr, binary.LittleEndian, &n); err != nil {
+		return lazyerrors.Errorf("wire.OpKillCursors.readFrom (binary.Read): %w", err)
+	}
+
+	if n < 0 {
+		return lazyerrors.Errorf("wire.OpKillCursors.readFrom: invalid number of cursor IDs %d", n)
+	}
+
+	// do not preallocate memory for the invalid message
+	killCursors.CursorIDs = nil
+
+	for i := int32(0); i < n; i++ {
+		var id int64
+		if err := binary.Read(bufr, binary.LittleEndian, &id); err != nil {
+			return lazyerrors.Errorf("wire.OpKillCursors.readFrom (binary.Read): %w", err)
+		}
+
+		killCursors.CursorIDs = append(killCursors.CursorIDs, id)
+	}
+
+	return nil
+}
+
+// UnmarshalBinary reads an OpKillCursors from a byte array.
+func (killCursors *OpKillCursors) UnmarshalBinary(b []byte) error {
+	br := bytes.NewReader(b)
+	bufr := bufio.NewReader(br)
+
+	if err := killCursors.readFrom(bufr); err != nil {
+		return lazyerrors.Error(err)
+	}
+
+	if _, err := bufr.Peek(1); err != io.EOF {
+		return lazyerrors.Errorf("unexpected end of the OpKillCursors: %v", err)
+	}
+
+	return nil
+}
+
+// MarshalBinary writes an OpKillCursors to a byte array.
+func (killCursors *OpKillCursors) MarshalBinary() ([]byte, error) {
+	var buf bytes.Buffer
+
+	binary.Write(&buf, binary.LittleEndian, int32(0))
+	binary.Write(&buf, binary.LittleEndian, int32(len(killCursors.CursorIDs)))
+
+	for _, id := range killCursors.CursorIDs {
+		binary.Write(&buf, binary.LittleEndian, id)
+	}
+
+	return buf.Bytes(), nil
+}
+
+// String returns a string representation for logging.
+func (killCursors *OpKillCursors) String() string {
+	if killCursors == nil {
+		return "<nil>"
+	}
+
+	m := map[string]any{
+		"CursorIDs": killCursors.CursorIDs,
+	}
+
+	return string(must.NotFail(json.MarshalIndent(m, "", "  ")))
+}
+
+// check interfaces
+var (
+	_ MsgBody = (*OpKillCursors)(nil)
+)
@@ -0,0 +1,54 @@
+// Copyright 2021 FerretDB Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package wire
+
+import "testing"
+
+var killCursorsTestCases = []testCase{{
+	name: "killCursors",
+	expectedB: []byte{
+		0x28, 0x00, 0x00, 0x00, 0x06, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0xd7, 0x07, 0x00, 0x00, // header
+		0x00, 0x00, 0x00, 0x00, // zero
+		0x02, 0x00, 0x00, 0x00, // numberOfCursorIDs
+		0x2a, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, // cursorID
+		0x2b, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, // cursorID
+	},
+	msgHeader: &MsgHeader{
+		MessageLength: 40,
+		RequestID:     6,
+		OpCode:        OpCodeKillCursors,
+	},
+	msgBody: &OpKillCursors{
+		CursorIDs: []int64{42, 43},
+	},
+}, {
+	name: "invalidNumber",
+	expectedB: []byte{
+		0x20, 0x00, 0x00, 0x00, 0x06, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0xd7, 0x07, 0x00, 0x00, // header
+		0x00, 0x00, 0x00, 0x00, // zero
+		0xff, 0xff, 0xff, 0xff, // numberOfCursorIDs
+		0x2a, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, // cursorID
+	},
+	err: "wire.OpKillCursors.readFrom: invalid number of cursor IDs -1",
+}}
+
+func TestKillCursors(t *testing.T) {
+	t.Parallel()
+	testMessages(t, killCursorsTestCases)
+}
+
+func FuzzKillCursors(f *testing.F) {
+	fuzzMessages(f, killCursorsTestCases)
+}
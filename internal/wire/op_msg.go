@@ -23,13 +23,17 @@ import (
 	"io"
 
 	"github.com/FerretDB/FerretDB/internal/bson"
-	"github.com/FerretDB/FerretDB/internal/fjson"
+	"github.com/FerretDB/FerretDB/internal/extjson"
 	"github.com/FerretDB/FerretDB/internal/types"
 	"github.com/FerretDB/FerretDB/internal/util/lazyerrors"
 	"github.com/FerretDB/FerretDB/internal/util/must"
 )
 
 // OpMsgSection is one or more sections contained in an OpMsg.
+//
+// Kind 0 section contains a single body document.
+// Kind 1 section contains a document sequence with the given identifier;
+// drivers use it to send large batches of documents (for example, for insert command).
 type OpMsgSection struct {
 	Kind       byte
 	Identifier string
@@ -55,6 +59,10 @@ func (msg *OpMsg) SetSections(sections ...OpMsgSection) error {
 }
 
 // Document returns the value of msg as a types.Document.
+//
+// Document sequences of kind 1 sections are added to the body document of kind 0 section as arrays,
+// so handlers do not have to distinguish them from inline arrays.
+// Sections may be in any order.
 func (msg *OpMsg) Document() (*types.Document, error) {
 	var doc *types.Document
 
@@ -77,33 +85,88 @@ func (msg *OpMsg) Document() (*types.Document, error) {
 			}
 
 		case 1:
-			if section.Identifier == "" {
-				return nil, lazyerrors.New("wire.OpMsg.Document: empty section identifier")
-			}
-			if doc == nil {
-				return nil, lazyerrors.New("wire.OpMsg.Document: doc is empty")
-			}
+			// handled below
 
-			m := doc.Map()
-			if _, ok := m[section.Identifier]; ok {
-				return nil, lazyerrors.Errorf("wire.OpMsg.Document: doc already has %q key", section.Identifier)
-			}
+		default:
+			return nil, lazyerrors.Errorf("wire.OpMsg.Document: unknown kind %d", section.Kind)
+		}
+	}
 
-			a := types.MakeArray(len(section.Documents)) // may be zero
-			for _, d := range section.Documents {
-				if err := a.Append(d); err != nil {
-					return nil, lazyerrors.Error(err)
-				}
+	if doc == nil {
+		return nil, lazyerrors.New("wire.OpMsg.Document: no kind 0 section")
+	}
+
+	for _, section := range msg.sections {
+		if section.Kind != 1 {
+			continue
+		}
+
+		if section.Identifier == "" {
+			return nil, lazyerrors.New("wire.OpMsg.Document: empty section identifier")
+		}
+
+		if doc.Has(section.Identifier) {
+			return nil, lazyerrors.Errorf("wire.OpMsg.Document: doc already has %q key", section.Identifier)
+		}
+
+		a := types.MakeArray(len(section.Documents)) // may be zero
+		for _, d := range section.Documents {
+			if err := a.Append(d); err != nil {
+				return nil, lazyerrors.Error(err)
 			}
+		}
+
+		doc.Set(section.Identifier, a)
+	}
 
-			doc.Set(section.Identifier, a)
+	return doc, nil
+}
 
+// RawOpMsgDocument returns the body document of kind 0 section of the encoded OP_MSG message body b
+// without decoding it; see ReadRawMessage.
+//
+// It allows accessing a few fields of large messages (like ok field of find replies)
+// without decoding the whole message. Document sequences of kind 1 sections are skipped.
+// The checksum is not verified.
+func RawOpMsgDocument(b []byte) (bson.RawDocument, error) {
+	if len(b) < 4 {
+		return nil, lazyerrors.Errorf("wire.RawOpMsgDocument: invalid length %d", len(b))
+	}
+
+	flags := OpMsgFlags(binary.LittleEndian.Uint32(b))
+	if flags.FlagSet(OpMsgChecksumPresent) {
+		if len(b) < 8 {
+			return nil, lazyerrors.Errorf("wire.RawOpMsgDocument: invalid length %d", len(b))
+		}
+
+		b = b[:len(b)-4]
+	}
+
+	for b = b[4:]; len(b) > 0; {
+		kind := b[0]
+		b = b[1:]
+
+		// both kinds of sections are prefixed by their length
+		if len(b) < 4 {
+			return nil, lazyerrors.Errorf("wire.RawOpMsgDocument: invalid kind %d section length", kind)
+		}
+
+		l := int(int32(binary.LittleEndian.Uint32(b)))
+		if l < 5 || l > len(b) {
+			return nil, lazyerrors.Errorf("wire.RawOpMsgDocument: invalid kind %d section length %d", kind, l)
+		}
+
+		switch kind {
+		case 0:
+			return bson.RawDocument(b[:l]), nil
+		case 1:
+			b = b[l:]
 		default:
-			return nil, lazyerrors.Errorf("wire.OpMsg.Document: unknown kind %d", section.Kind)
+			return nil, lazyerrors.Errorf("wire.RawOpMsgDocument: kind is %d", kind)
 		}
 	}
 
-	return doc, nil
+	return nil, lazyerrors.New("wire.RawOpMsgDocument: no kind 0 section")
 }
 
 func (msg *OpMsg) msgbody() {}
@@ -197,7 +260,7 @@ func (msg *OpMsg) readFrom(bufr *bufio.Reader) error {
 		return lazyerrors.Error(err)
 	}
 
-	// TODO validate checksum
+	// checksum covers the header too, so it is validated by ReadMessage
 
 	return nil
 }
@@ -242,7 +305,7 @@ func (msg *OpMsg) MarshalBinary() ([]byte, error) {
 			if err != nil {
 				return nil, lazyerrors.Error(err)
 			}
-			if d.WriteTo(bufw); err != nil {
+			if err = d.WriteTo(bufw); err != nil {
 				return nil, lazyerrors.Error(err)
 			}
 
@@ -259,7 +322,7 @@ func (msg *OpMsg) MarshalBinary() ([]byte, error) {
 				if err != nil {
 					return nil, lazyerrors.Error(err)
 				}
-				if d.WriteTo(secw); err != nil {
+				if err = d.WriteTo(secw); err != nil {
 					return nil, lazyerrors.Error(err)
 				}
 			}
@@ -295,7 +358,7 @@ func (msg *OpMsg) MarshalBinary() ([]byte, error) {
 
 // String returns a string representation for logging.
 //
-// Currently, it uses FJSON, but that may change in the future.
+// Currently, it uses relaxed MongoDB Extended JSON v2 with the legacy form for UUIDs, but that may change in the future.
 func (msg *OpMsg) String() string {
 	if msg == nil {
 		return "<nil>"
@@ -313,13 +376,13 @@ func (msg *OpMsg) String() string {
 		}
 		switch section.Kind {
 		case 0:
-			b := must.NotFail(fjson.Marshal(section.Documents[0]))
+			b := must.NotFail(extjson.MarshalLog(section.Documents[0]))
 			s["Document"] = json.RawMessage(b)
 		case 1:
 			s["Identifier"] = section.Identifier
 			docs := make([]json.RawMessage, len(section.Documents))
 			for j, d := range section.Documents {
-				b := must.NotFail(fjson.Marshal(d))
+				b := must.NotFail(extjson.MarshalLog(d))
 				docs[j] = json.RawMessage(b)
 			}
 			s["Documents"] = docs
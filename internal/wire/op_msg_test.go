@@ -15,8 +15,13 @@
 package wire
 
 import (
+	"encoding/binary"
+	"strconv"
 	"testing"
 
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
 	"github.com/FerretDB/FerretDB/internal/types"
 	"github.com/FerretDB/FerretDB/internal/util/must"
 	"github.com/FerretDB/FerretDB/internal/util/testutil"
@@ -143,6 +148,73 @@ var msgTestCases = []testCase{{
 			},
 		}},
 	},
+}, {
+	name: "DocumentSequenceFirst",
+	expectedB: []byte{
+		0x4e, 0x00, 0x00, 0x00, 0x01, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0xdd, 0x07, 0x00, 0x00, // header
+		0x00, 0x00, 0x00, 0x00, // flags
+		0x01, 0x1a, 0x00, 0x00, 0x00, 0x64, 0x6f, 0x63, 0x75, 0x6d, 0x65, 0x6e, 0x74, 0x73, 0x00, // kind 1 "documents"
+		0x0c, 0x00, 0x00, 0x00, 0x10, 0x61, 0x00, 0x01, 0x00, 0x00, 0x00, 0x00, // {a: 1}
+		0x00, 0x1e, 0x00, 0x00, 0x00, // kind 0
+		0x02, 0x69, 0x6e, 0x73, 0x65, 0x72, 0x74, 0x00, 0x02, 0x00, 0x00, 0x00, 0x63, 0x00, // insert: "c"
+		0x02, 0x24, 0x64, 0x62, 0x00, 0x02, 0x00, 0x00, 0x00, 0x74, 0x00, 0x00, // $db: "t"
+	},
+	msgHeader: &MsgHeader{
+		MessageLength: 78,
+		RequestID:     1,
+		OpCode:        OpCodeMsg,
+	},
+	msgBody: &OpMsg{
+		sections: []OpMsgSection{{
+			Kind:       1,
+			Identifier: "documents",
+			Documents:  []*types.Document{must.NotFail(types.NewDocument("a", int32(1)))},
+		}, {
+			Documents: []*types.Document{must.NotFail(types.NewDocument("insert", "c", "$db", "t"))},
+		}},
+	},
+}, {
+	name: "DocumentSequenceOnly",
+	expectedB: []byte{
+		0x2f, 0x00, 0x00, 0x00, 0x01, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0xdd, 0x07, 0x00, 0x00, // header
+		0x00, 0x00, 0x00, 0x00, // flags
+		0x01, 0x1a, 0x00, 0x00, 0x00, 0x64, 0x6f, 0x63, 0x75, 0x6d, 0x65, 0x6e, 0x74, 0x73, 0x00, // kind 1 "documents"
+		0x0c, 0x00, 0x00, 0x00, 0x10, 0x61, 0x00, 0x01, 0x00, 0x00, 0x00, 0x00, // {a: 1}
+	},
+	err: `wire.OpMsg.Document: no kind 0 section`,
+}, {
+	name: "Checksum",
+	expectedB: []byte{
+		0x37, 0x00, 0x00, 0x00, 0x01, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0xdd, 0x07, 0x00, 0x00, // header
+		0x01, 0x00, 0x00, 0x00, // flags
+		0x00, 0x1e, 0x00, 0x00, 0x00, // kind 0
+		0x02, 0x69, 0x6e, 0x73, 0x65, 0x72, 0x74, 0x00, 0x02, 0x00, 0x00, 0x00, 0x63, 0x00, // insert: "c"
+		0x02, 0x24, 0x64, 0x62, 0x00, 0x02, 0x00, 0x00, 0x00, 0x74, 0x00, 0x00, // $db: "t"
+		0x69, 0xc5, 0xd1, 0x05, // checksum
+	},
+	msgHeader: &MsgHeader{
+		MessageLength: 55,
+		RequestID:     1,
+		OpCode:        OpCodeMsg,
+	},
+	msgBody: &OpMsg{
+		FlagBits: OpMsgFlags(OpMsgChecksumPresent),
+		Checksum: 0x05d1c569,
+		sections: []OpMsgSection{{
+			Documents: []*types.Document{must.NotFail(types.NewDocument("insert", "c", "$db", "t"))},
+		}},
+	},
+}, {
+	name: "ChecksumInvalid",
+	expectedB: []byte{
+		0x37, 0x00, 0x00, 0x00, 0x01, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0xdd, 0x07, 0x00, 0x00, // header
+		0x01, 0x00, 0x00, 0x00, // flags
+		0x00, 0x1e, 0x00, 0x00, 0x00, // kind 0
+		0x02, 0x69, 0x6e, 0x73, 0x65, 0x72, 0x74, 0x00, 0x02, 0x00, 0x00, 0x00, 0x63, 0x00, // insert: "c"
+		0x02, 0x24, 0x64, 0x62, 0x00, 0x02, 0x00, 0x00, 0x00, 0x74, 0x00, 0x00, // $db: "t"
+		0x69, 0xc5, 0xd1, 0x06, // checksum
+	},
+	err: `wire.verifyChecksum: checksum 0x06d1c569, expected 0x05d1c569`,
 }, {
 	name:      "dollar_dot",
 	expectedB: testutil.MustParseDumpFile("testdata", "dollar_dot.hex"),
@@ -153,11 +225,173 @@ var msgTestCases = []testCase{{
 	err:       `wire.OpMsg.readFrom: invalid kind 1 section length -13619152`,
 }}
 
+func TestMsgDocument(t *testing.T) {
+	t.Parallel()
+
+	body := must.NotFail(types.NewDocument("update", "c", "$db", "t"))
+	updates := []*types.Document{
+		must.NotFail(types.NewDocument("q", must.NotFail(types.NewDocument()))),
+		must.NotFail(types.NewDocument("q", must.NotFail(types.NewDocument("a", int32(1))))),
+	}
+
+	var msg OpMsg
+	err := msg.SetSections(OpMsgSection{
+		Documents: []*types.Document{body},
+	}, OpMsgSection{
+		Kind:       1,
+		Identifier: "updates",
+		Documents:  updates,
+	}, OpMsgSection{
+		Kind:       1,
+		Identifier: "empty",
+	})
+	require.NoError(t, err)
+
+	doc, err := msg.Document()
+	require.NoError(t, err)
+
+	expected := must.NotFail(types.NewDocument(
+		"update", "c",
+		"$db", "t",
+		"updates", must.NotFail(types.NewArray(updates[0], updates[1])),
+		"empty", must.NotFail(types.NewArray()),
+	))
+	assert.Equal(t, expected, doc)
+	assert.False(t, body.Has("updates"), "body document should not be modified")
+
+	b, err := msg.MarshalBinary()
+	require.NoError(t, err)
+
+	var actual OpMsg
+	require.NoError(t, actual.UnmarshalBinary(b))
+	assert.Equal(t, msg.sections, actual.sections)
+
+	err = msg.SetSections(OpMsgSection{
+		Documents: []*types.Document{body},
+	}, OpMsgSection{
+		Kind:       1,
+		Identifier: "$db",
+	})
+	assert.Error(t, err)
+
+	err = msg.SetSections(OpMsgSection{
+		Documents: []*types.Document{body},
+	}, OpMsgSection{
+		Kind: 1,
+	})
+	assert.Error(t, err)
+}
+
+func TestRawOpMsgDocument(t *testing.T) {
+	t.Parallel()
+
+	body := must.NotFail(types.NewDocument("insert", "c", "ordered", true, "$db", "t"))
+
+	var msg OpMsg
+	err := msg.SetSections(OpMsgSection{
+		Kind:       1,
+		Identifier: "documents",
+		Documents:  []*types.Document{must.NotFail(types.NewDocument("_id", int32(1)))},
+	}, OpMsgSection{
+		Documents: []*types.Document{body},
+	})
+	require.NoError(t, err)
+
+	b, err := msg.MarshalBinary()
+	require.NoError(t, err)
+
+	raw, err := RawOpMsgDocument(b)
+	require.NoError(t, err)
+
+	v, err := raw.Get("ordered")
+	require.NoError(t, err)
+	assert.Equal(t, true, v)
+
+	doc, err := raw.Convert()
+	require.NoError(t, err)
+	assert.Equal(t, body, doc)
+
+	// checksum is not a part of the last section
+	withChecksum := append(append([]byte{}, b...), 0x42, 0x42, 0x42, 0x42)
+	binary.LittleEndian.PutUint32(withChecksum, uint32(OpMsgChecksumPresent))
+
+	raw, err = RawOpMsgDocument(withChecksum)
+	require.NoError(t, err)
+	assert.Equal(t, "t", must.NotFail(raw.Get("$db")))
+
+	for name, b := range map[string][]byte{
+		"Empty":     {},
+		"NoSection": b[:4],
+		"Truncated": b[:len(b)-1],
+		"Kind":      {0, 0, 0, 0, 2, 5, 0, 0, 0, 0},
+	} {
+		_, err = RawOpMsgDocument(b)
+		assert.Error(t, err, name)
+	}
+}
+
+// BenchmarkOpMsgOK compares getting the ok field of a large reply
+// with decoded and raw messages.
+func BenchmarkOpMsgOK(b *testing.B) {
+	batch := types.MakeArray(101)
+	for i := 0; i < 101; i++ {
+		must.NoError(batch.Append(must.NotFail(types.NewDocument(
+			"_id", int32(i),
+			"v", "value "+strconv.Itoa(i),
+			"a", must.NotFail(types.NewArray(float64(i), int64(i), "foo")),
+		))))
+	}
+
+	var msg OpMsg
+	must.NoError(msg.SetSections(OpMsgSection{
+		Documents: []*types.Document{must.NotFail(types.NewDocument(
+			"cursor", must.NotFail(types.NewDocument("firstBatch", batch, "id", int64(0), "ns", "t.c")),
+			"ok", float64(1),
+		))},
+	}))
+
+	data := must.NotFail(msg.MarshalBinary())
+
+	b.Run("Decoded", func(b *testing.B) {
+		b.ReportAllocs()
+
+		for i := 0; i < b.N; i++ {
+			var msg OpMsg
+			must.NoError(msg.UnmarshalBinary(data))
+
+			if must.NotFail(must.NotFail(msg.Document()).Get("ok")) != float64(1) {
+				b.Fatal("ok")
+			}
+		}
+	})
+
+	b.Run("Raw", func(b *testing.B) {
+		b.ReportAllocs()
+
+		for i := 0; i < b.N; i++ {
+			raw := must.NotFail(RawOpMsgDocument(data))
+
+			if must.NotFail(raw.Get("ok")) != float64(1) {
+				b.Fatal("ok")
+			}
+		}
+	})
+}
+
 func TestMsg(t *testing.T) {
 	t.Parallel()
 	testMessages(t, msgTestCases)
 }
 
 func FuzzMsg(f *testing.F) {
+	// wrap shared seed corpus documents into OP_MSG messages with a single body section
+	for _, doc := range testutil.DocumentsSeedCorpus(f) {
+		b := make([]byte, MsgHeaderLen+5, MsgHeaderLen+5+len(doc))
+		binary.LittleEndian.PutUint32(b[0:], uint32(len(b)+len(doc)))
+		binary.LittleEndian.PutUint32(b[4:], 1)
+		binary.LittleEndian.PutUint32(b[12:], uint32(OpCodeMsg))
+		f.Add(append(b, doc...))
+	}
+
 	fuzzMessages(f, msgTestCases)
 }
@@ -22,7 +22,7 @@ import (
 	"io"
 
 	"github.com/FerretDB/FerretDB/internal/bson"
-	"github.com/FerretDB/FerretDB/internal/fjson"
+	"github.com/FerretDB/FerretDB/internal/extjson"
 	"github.com/FerretDB/FerretDB/internal/types"
 	"github.com/FerretDB/FerretDB/internal/util/lazyerrors"
 	"github.com/FerretDB/FerretDB/internal/util/must"
@@ -132,7 +132,7 @@ func (query *OpQuery) MarshalBinary() ([]byte, error) {
 
 // String returns a string representation for logging.
 //
-// Currently, it uses FJSON, but that may change in the future.
+// Currently, it uses relaxed MongoDB Extended JSON v2 with the legacy form for UUIDs, but that may change in the future.
 func (query *OpQuery) String() string {
 	if query == nil {
 		return "<nil>"
@@ -143,10 +143,10 @@ func (query *OpQuery) String() string {
 		"FullCollectionName": query.FullCollectionName,
 		"NumberToSkip":       query.NumberToSkip,
 		"NumberToReturn":     query.NumberToReturn,
-		"Query":              json.RawMessage(must.NotFail(fjson.Marshal(query.Query))),
+		"Query":              json.RawMessage(must.NotFail(extjson.MarshalLog(query.Query))),
 	}
 	if query.ReturnFieldsSelector != nil {
-		m["ReturnFieldsSelector"] = json.RawMessage(must.NotFail(fjson.Marshal(query.ReturnFieldsSelector)))
+		m["ReturnFieldsSelector"] = json.RawMessage(must.NotFail(extjson.MarshalLog(query.ReturnFieldsSelector)))
 	}
 
 	return string(must.NotFail(json.MarshalIndent(m, "", "  ")))
@@ -22,7 +22,7 @@ import (
 	"io"
 
 	"github.com/FerretDB/FerretDB/internal/bson"
-	"github.com/FerretDB/FerretDB/internal/fjson"
+	"github.com/FerretDB/FerretDB/internal/extjson"
 	"github.com/FerretDB/FerretDB/internal/types"
 	"github.com/FerretDB/FerretDB/internal/util/lazyerrors"
 	"github.com/FerretDB/FerretDB/internal/util/must"
@@ -124,7 +124,7 @@ func (reply *OpReply) MarshalBinary() ([]byte, error) {
 
 // String returns a string representation for logging.
 //
-// Currently, it uses FJSON, but that may change in the future.
+// Currently, it uses relaxed MongoDB Extended JSON v2 with the legacy form for UUIDs, but that may change in the future.
 func (reply *OpReply) String() string {
 	if reply == nil {
 		return "<nil>"
@@ -139,7 +139,7 @@ func (reply *OpReply) String() string {
 
 	docs := make([]json.RawMessage, len(reply.Documents))
 	for i, d := range reply.Documents {
-		docs[i] = json.RawMessage(must.NotFail(fjson.Marshal(d)))
+		docs[i] = json.RawMessage(must.NotFail(extjson.MarshalLog(d)))
 	}
 
 	m["Documents"] = docs
@@ -0,0 +1,218 @@
+// Copyright 2021 FerretDB Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package wire
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"sync/atomic"
+
+	"github.com/FerretDB/FerretDB/internal/types"
+	"github.com/FerretDB/FerretDB/internal/util/must"
+)
+
+// RedactMode represents how document values are redacted in logged messages.
+type RedactMode int32
+
+const (
+	// RedactNone logs messages as is.
+	RedactNone RedactMode = iota
+
+	// RedactElide replaces all values with "###", keeping field names and documents and arrays structure.
+	RedactElide
+
+	// RedactHash replaces all values with their keyed hashes, keeping field names and documents and arrays structure.
+	// Equal values have equal hashes during the lifetime of the process, which helps to correlate log messages.
+	RedactHash
+)
+
+// elided replaces values in RedactElide mode; that matches MongoDB's redactClientLogData.
+const elided = "###"
+
+// AllRedactModes includes all redact modes, with the first one being the default.
+var AllRedactModes = []RedactMode{RedactNone, RedactElide, RedactHash}
+
+// String implements fmt.Stringer interface.
+func (mode RedactMode) String() string {
+	switch mode {
+	case RedactNone:
+		return "none"
+	case RedactElide:
+		return "elide"
+	case RedactHash:
+		return "hash"
+	default:
+		return fmt.Sprintf("RedactMode(%d)", int32(mode))
+	}
+}
+
+// ParseRedactMode returns RedactMode for the given string representation.
+func ParseRedactMode(s string) (RedactMode, error) {
+	for _, mode := range AllRedactModes {
+		if mode.String() == s {
+			return mode, nil
+		}
+	}
+
+	return RedactNone, fmt.Errorf("unknown redact mode %q", s)
+}
+
+// redactMode stores the current RedactMode.
+var redactMode int32
+
+// redactKey is used for values hashing; it is generated on startup and never logged.
+var redactKey = func() []byte {
+	key := make([]byte, sha256.Size)
+	must.NotFail(rand.Read(key))
+	return key
+}()
+
+// GetRedactMode returns the current redact mode.
+func GetRedactMode() RedactMode {
+	return RedactMode(atomic.LoadInt32(&redactMode))
+}
+
+// SetRedactMode changes the current redact mode.
+// It is safe to call it concurrently with logging.
+func SetRedactMode(mode RedactMode) {
+	atomic.StoreInt32(&redactMode, int32(mode))
+}
+
+// Redact returns a copy of the message body with values redacted according to the current redact mode.
+// Message body itself is returned for RedactNone mode.
+//
+// The result should be used only for logging.
+func Redact(body MsgBody) MsgBody {
+	mode := GetRedactMode()
+	if mode == RedactNone {
+		return body
+	}
+
+	switch body := body.(type) {
+	case *OpMsg:
+		if body == nil {
+			return body
+		}
+
+		res := &OpMsg{
+			FlagBits: body.FlagBits,
+			Checksum: body.Checksum,
+			sections: make([]OpMsgSection, len(body.sections)),
+		}
+
+		for i, section := range body.sections {
+			res.sections[i] = OpMsgSection{
+				Kind:       section.Kind,
+				Identifier: section.Identifier,
+				Documents:  redactDocuments(mode, section.Documents),
+			}
+		}
+
+		return res
+
+	case *OpQuery:
+		if body == nil {
+			return body
+		}
+
+		res := *body
+		res.Query = redactDocument(mode, body.Query)
+		if body.ReturnFieldsSelector != nil {
+			res.ReturnFieldsSelector = redactDocument(mode, body.ReturnFieldsSelector)
+		}
+
+		return &res
+
+	case *OpReply:
+		if body == nil {
+			return body
+		}
+
+		res := *body
+		res.Documents = redactDocuments(mode, body.Documents)
+
+		return &res
+
+	case *OpCompressed:
+		if body == nil || body.body == nil {
+			return body
+		}
+
+		res := *body
+		res.body = Redact(body.body)
+
+		return &res
+
+	default:
+		return body
+	}
+}
+
+// redactDocuments returns redacted copies of documents.
+func redactDocuments(mode RedactMode, docs []*types.Document) []*types.Document {
+	res := make([]*types.Document, len(docs))
+	for i, doc := range docs {
+		res[i] = redactDocument(mode, doc)
+	}
+
+	return res
+}
+
+// redactDocument returns a redacted copy of the document.
+func redactDocument(mode RedactMode, doc *types.Document) *types.Document {
+	if doc == nil {
+		return nil
+	}
+
+	res := must.NotFail(types.NewDocument())
+
+	m := doc.Map()
+	for _, k := range doc.Keys() {
+		must.NoError(res.Set(k, redactValue(mode, m[k])))
+	}
+
+	return res
+}
+
+// redactValue returns a redacted value.
+// Documents and arrays are redacted recursively; other values are replaced with strings.
+func redactValue(mode RedactMode, v any) any {
+	switch v := v.(type) {
+	case *types.Document:
+		return redactDocument(mode, v)
+
+	case *types.Array:
+		res := types.MakeArray(v.Len())
+		for i := 0; i < v.Len(); i++ {
+			must.NoError(res.Append(redactValue(mode, must.NotFail(v.Get(i)))))
+		}
+
+		return res
+
+	default:
+		if mode != RedactHash {
+			return elided
+		}
+
+		// include type to make values of different types with the same representation different
+		h := hmac.New(sha256.New, redactKey)
+		fmt.Fprintf(h, "%T:%#v", v, v)
+
+		return "#" + hex.EncodeToString(h.Sum(nil)[:8])
+	}
+}
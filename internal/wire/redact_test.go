@@ -0,0 +1,113 @@
+// Copyright 2021 FerretDB Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package wire
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/FerretDB/FerretDB/internal/types"
+	"github.com/FerretDB/FerretDB/internal/util/must"
+)
+
+// Tests in this file change global redact mode, so they should not be run in parallel.
+
+func TestRedact(t *testing.T) {
+	t.Cleanup(func() {
+		SetRedactMode(RedactNone)
+	})
+
+	var msg OpMsg
+	err := msg.SetSections(OpMsgSection{
+		Documents: []*types.Document{must.NotFail(types.NewDocument(
+			"insert", "values",
+			"$db", "test",
+		))},
+	}, OpMsgSection{
+		Kind:       1,
+		Identifier: "documents",
+		Documents: []*types.Document{must.NotFail(types.NewDocument(
+			"_id", types.ObjectID{1},
+			"ssn", "123-45-6789",
+			"tags", must.NotFail(types.NewArray("a", int32(42), must.NotFail(types.NewDocument("secret", true)))),
+		))},
+	})
+	require.NoError(t, err)
+
+	SetRedactMode(RedactNone)
+	assert.Same(t, &msg, Redact(&msg))
+
+	SetRedactMode(RedactElide)
+	redacted := Redact(&msg)
+	assert.NotContains(t, redacted.String(), "123-45-6789")
+	assert.NotContains(t, redacted.String(), "values")
+
+	actual, err := redacted.(*OpMsg).Document()
+	require.NoError(t, err)
+
+	expected := must.NotFail(types.NewDocument(
+		"insert", "###",
+		"$db", "###",
+		"documents", must.NotFail(types.NewArray(must.NotFail(types.NewDocument(
+			"_id", "###",
+			"ssn", "###",
+			"tags", must.NotFail(types.NewArray("###", "###", must.NotFail(types.NewDocument("secret", "###")))),
+		)))),
+	))
+	assert.Equal(t, expected, actual)
+
+	// original message is not changed
+	doc, err := msg.Document()
+	require.NoError(t, err)
+	assert.Equal(t, "values", must.NotFail(doc.Get("insert")))
+
+	SetRedactMode(RedactHash)
+	actual, err = Redact(&msg).(*OpMsg).Document()
+	require.NoError(t, err)
+
+	tags := must.NotFail(must.NotFail(must.NotFail(actual.Get("documents")).(*types.Array).Get(0)).(*types.Document).Get("tags"))
+	first := must.NotFail(tags.(*types.Array).Get(0)).(string)
+	assert.True(t, strings.HasPrefix(first, "#"), first)
+	assert.Len(t, first, 17)
+
+	// equal values have equal hashes, values of different types have different hashes
+	assert.Equal(t, redactValue(RedactHash, "42"), redactValue(RedactHash, "42"))
+	assert.NotEqual(t, redactValue(RedactHash, "42"), redactValue(RedactHash, int32(42)))
+	assert.NotEqual(t, redactValue(RedactHash, int32(42)), redactValue(RedactHash, int64(42)))
+
+	query := &OpQuery{
+		FullCollectionName: "admin.$cmd",
+		Query:              must.NotFail(types.NewDocument("isMaster", int32(1))),
+	}
+	SetRedactMode(RedactElide)
+	assert.Equal(t, must.NotFail(types.NewDocument("isMaster", "###")), Redact(query).(*OpQuery).Query)
+	assert.Equal(t, "admin.$cmd", Redact(query).(*OpQuery).FullCollectionName)
+}
+
+func TestParseRedactMode(t *testing.T) {
+	t.Parallel()
+
+	for _, mode := range AllRedactModes {
+		actual, err := ParseRedactMode(mode.String())
+		require.NoError(t, err)
+		assert.Equal(t, mode, actual)
+	}
+
+	_, err := ParseRedactMode("full")
+	assert.EqualError(t, err, `unknown redact mode "full"`)
+}
@@ -0,0 +1,36 @@
+// Copyright 2021 FerretDB Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package wire
+
+import (
+	"github.com/FerretDB/FerretDB/internal/bson"
+	"github.com/FerretDB/FerretDB/internal/types"
+	"github.com/FerretDB/FerretDB/internal/util/lazyerrors"
+)
+
+// DocumentSize returns the size of the document in BSON encoding.
+func DocumentSize(doc *types.Document) (int, error) {
+	d, err := bson.ConvertDocument(doc)
+	if err != nil {
+		return 0, lazyerrors.Error(err)
+	}
+
+	b, err := d.MarshalBinary()
+	if err != nil {
+		return 0, lazyerrors.Error(err)
+	}
+
+	return len(b), nil
+}